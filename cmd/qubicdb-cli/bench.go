@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Benchmark operation names, as reported per op.
+const (
+	benchOpWrite   = "write"
+	benchOpSearch  = "search"
+	benchOpContext = "context"
+)
+
+// benchConfig holds the flags of one benchmark run.
+type benchConfig struct {
+	Scenario    string        // write | search | mixed | context
+	Count       int           // total requests; 0 with Duration set means unbounded
+	Duration    time.Duration // wall-clock limit; 0 means run until Count
+	Concurrency int
+	Ramp        time.Duration // spread worker start-up over this long
+	Size        int           // approximate bytes per written memory
+	WriteRatio  float64       // fraction of writes in the mixed scenario
+	QueriesFile string        // one query per line; empty generates queries
+	Prepopulate int           // memories written per index before search/context runs
+	Index       string        // index ID, or prefix with several indexes
+	Indexes     int
+	Strategy    string // single | round-robin
+	Seed        int64
+}
+
+func (b *benchConfig) validate() error {
+	switch b.Scenario {
+	case "write", "search", "mixed", "context":
+	default:
+		return fmt.Errorf("unknown scenario %q (write, search, mixed, context)", b.Scenario)
+	}
+	switch b.Strategy {
+	case "single", "round-robin":
+	default:
+		return fmt.Errorf("unknown index strategy %q (single, round-robin)", b.Strategy)
+	}
+	if b.Count <= 0 && b.Duration <= 0 {
+		return fmt.Errorf("one of --count or --duration is required")
+	}
+	if b.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be >= 1")
+	}
+	if b.Indexes < 1 {
+		return fmt.Errorf("--indexes must be >= 1")
+	}
+	if b.WriteRatio < 0 || b.WriteRatio > 1 {
+		return fmt.Errorf("--write-ratio must be between 0 and 1")
+	}
+	if b.Size < 1 {
+		return fmt.Errorf("--size must be >= 1")
+	}
+	return nil
+}
+
+// indexFor returns the index targeted by the n-th request.
+func (b *benchConfig) indexFor(n uint64) string {
+	if b.Indexes == 1 {
+		return b.Index
+	}
+	return fmt.Sprintf("%s-%d", b.Index, n%uint64(b.Indexes))
+}
+
+func newBenchCmd(c *cli) *cobra.Command {
+	b := &benchConfig{}
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Run a load test against the server",
+		Long: `Run a load test and report throughput, latency percentiles and errors.
+
+Scenarios:
+  write    write --count memories of --size bytes
+  search   search with queries from --queries or generated from written content
+  mixed    writes and searches, --write-ratio of them writes
+  context  assemble LLM context for generated or supplied cues
+
+Search and context runs first write --prepopulate memories to each index.
+Server-side stats counters are captured before and after the run: pool-wide
+from /admin/stats with admin credentials, else the first index's /v1/stats.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if b.Index == "" {
+				b.Index = c.resolveIndex(cmd)
+			}
+			if b.Index == "" {
+				b.Index = "bench"
+			}
+			if !cmd.Flags().Changed("count") && b.Duration > 0 {
+				b.Count = 0
+			}
+			if b.Strategy == "single" {
+				b.Indexes = 1
+			}
+			if err := b.validate(); err != nil {
+				return err
+			}
+			report, err := c.runBench(b)
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				out, _ := json.MarshalIndent(report, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+			report.print(os.Stdout)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&b.Scenario, "scenario", "write", "Scenario: write, search, mixed or context")
+	f.IntVar(&b.Count, "count", 1000, "Total requests to send")
+	f.DurationVar(&b.Duration, "duration", 0, "Run for this long instead of --count (e.g. 30s)")
+	f.IntVarP(&b.Concurrency, "concurrency", "c", 8, "Concurrent clients")
+	f.DurationVar(&b.Ramp, "ramp", 0, "Start clients gradually over this period")
+	f.IntVar(&b.Size, "size", 256, "Approximate size of each written memory in bytes")
+	f.Float64Var(&b.WriteRatio, "write-ratio", 0.2, "Fraction of writes in the mixed scenario")
+	f.StringVar(&b.QueriesFile, "queries", "", "File with one search query per line")
+	f.IntVar(&b.Prepopulate, "prepopulate", 100, "Memories written per index before search and context runs")
+	f.StringVar(&b.Index, "index", "", "Index ID, or prefix when --indexes > 1 (default: connection index or \"bench\")")
+	f.IntVar(&b.Indexes, "indexes", 1, "Number of indexes to spread requests over")
+	f.StringVar(&b.Strategy, "strategy", "round-robin", "Index selection: single or round-robin")
+	f.Int64Var(&b.Seed, "seed", 1, "Random seed for generated content")
+	f.BoolVar(&jsonOut, "json", false, "Print the report as JSON")
+	return cmd
+}
+
+// ── Content generation ──────────────────────────────────────
+
+var (
+	benchSubjects = []string{"The platform team", "Our biggest customer", "The mobile app", "Finance", "The on-call engineer", "Marketing", "The data pipeline", "Support", "The design review", "Legal"}
+	benchVerbs    = []string{"asked about", "decided to postpone", "reported a problem with", "approved", "wants to redesign", "benchmarked", "documented", "escalated", "rolled back", "prototyped"}
+	benchObjects  = []string{"the billing migration", "search relevance", "the onboarding flow", "quarterly planning", "the Kubernetes upgrade", "password resets", "the analytics dashboard", "API rate limits", "the holiday schedule", "invoice exports"}
+	benchWhens    = []string{"this morning", "last Tuesday", "before the release", "during the retro", "after the outage", "in the weekly sync", "over lunch", "yesterday", "at the offsite", "late on Friday"}
+	benchDetails  = []string{"costs are higher than expected", "latency doubled under load", "two teams depend on it", "the deadline is next month", "nobody owns the runbook", "the tests are flaky", "users keep asking for it", "it blocks the EU launch", "the vendor contract ends soon", "a workaround exists"}
+)
+
+// contentGenerator produces varied memories and queries from templates so
+// that written content is not deduplicated by the server. Each client owns
+// one; seq is shared so every memory is distinct across clients.
+type contentGenerator struct {
+	rng  *rand.Rand
+	size int
+	seq  *atomic.Uint64
+}
+
+func newContentGenerator(seed int64, size int, seq *atomic.Uint64) *contentGenerator {
+	return &contentGenerator{rng: rand.New(rand.NewSource(seed)), size: size, seq: seq}
+}
+
+func (g *contentGenerator) pick(words []string) string {
+	return words[g.rng.Intn(len(words))]
+}
+
+func (g *contentGenerator) sentence() string {
+	return fmt.Sprintf("%s %s %s %s; %s.", g.pick(benchSubjects), g.pick(benchVerbs), g.pick(benchObjects), g.pick(benchWhens), g.pick(benchDetails))
+}
+
+// memory returns a new memory of roughly the configured size.
+func (g *contentGenerator) memory() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Note %d:", g.seq.Add(1))
+	prefix := sb.Len() // never trimmed, so memories stay unique
+	for sb.Len() < g.size || sb.Len() == prefix {
+		sb.WriteByte(' ')
+		sb.WriteString(g.sentence())
+	}
+	s := sb.String()
+	if len(s) > g.size {
+		if cut := strings.LastIndexByte(s[:g.size], ' '); cut > prefix {
+			s = s[:cut]
+		}
+	}
+	return s
+}
+
+// query returns a short search phrase drawn from the memory vocabulary.
+func (g *contentGenerator) query() string {
+	if g.rng.Intn(2) == 0 {
+		return g.pick(benchObjects)
+	}
+	return strings.ToLower(g.pick(benchSubjects)) + " " + g.pick(benchObjects)
+}
+
+func loadQueries(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var queries []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if q := strings.TrimSpace(sc.Text()); q != "" {
+			queries = append(queries, q)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s contains no queries", path)
+	}
+	return queries, nil
+}
+
+// ── Statistics ──────────────────────────────────────────────
+
+// latencySummary describes a latency distribution in milliseconds.
+type latencySummary struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// summarizeLatencies computes nearest-rank percentiles of samples.
+func summarizeLatencies(samples []time.Duration) latencySummary {
+	if len(samples) == 0 {
+		return latencySummary{}
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return ms(sorted[max(i, 0)])
+	}
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return latencySummary{
+		Min:  ms(sorted[0]),
+		Mean: ms(total) / float64(len(sorted)),
+		P50:  rank(50),
+		P95:  rank(95),
+		P99:  rank(99),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+}
+
+// benchRecorder collects per-op latencies and errors from all clients.
+type benchRecorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	lastError string
+}
+
+func newBenchRecorder() *benchRecorder {
+	return &benchRecorder{latencies: make(map[string][]time.Duration), errors: make(map[string]int)}
+}
+
+func (r *benchRecorder) record(op string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[op] = append(r.latencies[op], d)
+	if err != nil {
+		r.errors[op]++
+		r.lastError = err.Error()
+	}
+}
+
+// opReport is the result for one operation type.
+type opReport struct {
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	Latency  latencySummary `json:"latencyMs"`
+}
+
+// benchReport is the outcome of a benchmark run.
+type benchReport struct {
+	Scenario    string              `json:"scenario"`
+	Concurrency int                 `json:"concurrency"`
+	Indexes     int                 `json:"indexes"`
+	Strategy    string              `json:"strategy"`
+	Requests    int                 `json:"requests"`
+	Errors      int                 `json:"errors"`
+	LastError   string              `json:"lastError,omitempty"`
+	DurationSec float64             `json:"durationSec"`
+	Throughput  float64             `json:"throughput"` // requests per second
+	Latency     latencySummary      `json:"latencyMs"`
+	Ops         map[string]opReport `json:"ops"`
+	ServerStats map[string]float64  `json:"serverStatsDelta,omitempty"`
+}
+
+func (r *benchRecorder) report(b *benchConfig, elapsed time.Duration) *benchReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := &benchReport{
+		Scenario:    b.Scenario,
+		Concurrency: b.Concurrency,
+		Indexes:     b.Indexes,
+		Strategy:    b.Strategy,
+		LastError:   r.lastError,
+		DurationSec: elapsed.Seconds(),
+		Ops:         make(map[string]opReport),
+	}
+	var all []time.Duration
+	for op, lat := range r.latencies {
+		rep.Ops[op] = opReport{Requests: len(lat), Errors: r.errors[op], Latency: summarizeLatencies(lat)}
+		rep.Requests += len(lat)
+		rep.Errors += r.errors[op]
+		all = append(all, lat...)
+	}
+	rep.Latency = summarizeLatencies(all)
+	if elapsed > 0 {
+		rep.Throughput = float64(rep.Requests) / elapsed.Seconds()
+	}
+	return rep
+}
+
+// statsDelta flattens the numeric fields of two stats responses into
+// dotted keys and returns the non-zero differences.
+func statsDelta(before, after map[string]any) map[string]float64 {
+	b, a := map[string]float64{}, map[string]float64{}
+	flattenNumbers("", before, b)
+	flattenNumbers("", after, a)
+
+	delta := make(map[string]float64)
+	for k, v := range a {
+		if d := v - b[k]; d != 0 {
+			delta[k] = d
+		}
+	}
+	for k, v := range b {
+		if _, ok := a[k]; !ok && v != 0 {
+			delta[k] = -v
+		}
+	}
+	return delta
+}
+
+func flattenNumbers(prefix string, v any, out map[string]float64) {
+	switch v := v.(type) {
+	case float64:
+		out[prefix] = v
+	case map[string]any:
+		for k, child := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenNumbers(key, child, out)
+		}
+	}
+}
+
+func (r *benchReport) print(w io.Writer) {
+	fmt.Fprintf(w, "Scenario %s: %d clients, %d index(es) (%s)\n", r.Scenario, r.Concurrency, r.Indexes, r.Strategy)
+	fmt.Fprintf(w, "Requests %d, errors %d, %.2fs, %.1f req/s\n", r.Requests, r.Errors, r.DurationSec, r.Throughput)
+	if r.LastError != "" {
+		fmt.Fprintf(w, "Last error: %s\n", r.LastError)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %-8s %8s %7s %9s %9s %9s %9s\n", "op", "requests", "errors", "p50 ms", "p95 ms", "p99 ms", "max ms")
+	row := func(name string, requests, errors int, l latencySummary) {
+		fmt.Fprintf(w, "  %-8s %8d %7d %9.2f %9.2f %9.2f %9.2f\n", name, requests, errors, l.P50, l.P95, l.P99, l.Max)
+	}
+	ops := make([]string, 0, len(r.Ops))
+	for op := range r.Ops {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		o := r.Ops[op]
+		row(op, o.Requests, o.Errors, o.Latency)
+	}
+	if len(ops) > 1 {
+		row("total", r.Requests, r.Errors, r.Latency)
+	}
+
+	if len(r.ServerStats) > 0 {
+		keys := make([]string, 0, len(r.ServerStats))
+		for k := range r.ServerStats {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Server stats delta")
+		for _, k := range keys {
+			fmt.Fprintf(w, "  %-50s %+g\n", k, r.ServerStats[k])
+		}
+	}
+}
+
+// ── Runner ──────────────────────────────────────────────────
+
+// benchRunner sends benchmark traffic for one run.
+type benchRunner struct {
+	cli     *cli
+	cfg     *benchConfig
+	client  *http.Client
+	queries []string
+	seq     atomic.Uint64 // memories generated
+	issued  atomic.Uint64 // requests started
+}
+
+func (c *cli) runBench(b *benchConfig) (*benchReport, error) {
+	r := &benchRunner{
+		cli: c,
+		cfg: b,
+		client: &http.Client{
+			Timeout:   c.httpClient.Timeout,
+			Transport: &http.Transport{MaxIdleConnsPerHost: b.Concurrency},
+		},
+	}
+	if b.QueriesFile != "" {
+		queries, err := loadQueries(b.QueriesFile)
+		if err != nil {
+			return nil, err
+		}
+		r.queries = queries
+	}
+
+	if (b.Scenario == "search" || b.Scenario == "context") && b.Prepopulate > 0 {
+		pre := newBenchRecorder()
+		r.run(context.Background(), b.Prepopulate*b.Indexes, 0, pre, func(*contentGenerator) string { return benchOpWrite })
+		if rep := pre.report(b, 0); rep.Errors > 0 {
+			fmt.Fprintf(os.Stderr, "⚠ prepopulate: %d of %d writes failed: %s\n", rep.Errors, rep.Requests, rep.LastError)
+		}
+		r.issued.Store(0)
+	}
+
+	before, statsErr := r.serverStats()
+	if statsErr != nil {
+		fmt.Fprintf(os.Stderr, "⚠ server stats unavailable, server stats delta skipped: %v\n", statsErr)
+	}
+
+	choose := func(g *contentGenerator) string {
+		switch b.Scenario {
+		case "search":
+			return benchOpSearch
+		case "context":
+			return benchOpContext
+		case "mixed":
+			if g.rng.Float64() < b.WriteRatio {
+				return benchOpWrite
+			}
+			return benchOpSearch
+		default:
+			return benchOpWrite
+		}
+	}
+
+	ctx := context.Background()
+	if b.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.Duration)
+		defer cancel()
+	}
+	rec := newBenchRecorder()
+	start := time.Now()
+	r.run(ctx, b.Count, b.Ramp, rec, choose)
+	report := rec.report(b, time.Since(start))
+
+	if statsErr == nil {
+		if after, err := r.serverStats(); err == nil {
+			report.ServerStats = statsDelta(before, after)
+		}
+	}
+	return report, nil
+}
+
+// run drives cfg.Concurrency clients until count requests have been issued
+// (count <= 0: no limit) or ctx is done. Client i starts i/concurrency of
+// the way through ramp.
+func (r *benchRunner) run(ctx context.Context, count int, ramp time.Duration, rec *benchRecorder, choose func(*contentGenerator) string) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if ramp > 0 && i > 0 {
+				select {
+				case <-time.After(ramp * time.Duration(i) / time.Duration(r.cfg.Concurrency)):
+				case <-ctx.Done():
+					return
+				}
+			}
+			g := newContentGenerator(r.cfg.Seed+int64(i), r.cfg.Size, &r.seq)
+			for ctx.Err() == nil {
+				n := r.issued.Add(1)
+				if count > 0 && n > uint64(count) {
+					return
+				}
+				op := choose(g)
+				started := time.Now()
+				err := r.do(ctx, op, r.cfg.indexFor(n-1), g)
+				if ctx.Err() != nil && err != nil {
+					return // cut off by --duration; not a server error
+				}
+				rec.record(op, time.Since(started), err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (r *benchRunner) query(g *contentGenerator) string {
+	if len(r.queries) > 0 {
+		return r.queries[g.rng.Intn(len(r.queries))]
+	}
+	return g.query()
+}
+
+// do sends one request of the given op.
+func (r *benchRunner) do(ctx context.Context, op, indexID string, g *contentGenerator) error {
+	var path string
+	var payload map[string]any
+	switch op {
+	case benchOpWrite:
+		path, payload = "/v1/write", map[string]any{"content": g.memory()}
+	case benchOpSearch:
+		path, payload = "/v1/search", map[string]any{"query": r.query(g), "limit": 20}
+	case benchOpContext:
+		path, payload = "/v1/context", map[string]any{"cue": r.query(g), "maxTokens": 2000}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.cli.conn.BaseURL()+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Index-ID", indexID)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s: HTTP %d", path, resp.StatusCode)
+	}
+	return nil
+}
+
+// serverStats fetches pool-wide /admin/stats when the connection carries
+// admin credentials, and the first bench index's /v1/stats otherwise.
+func (r *benchRunner) serverStats() (map[string]any, error) {
+	req, err := http.NewRequest("GET", r.cli.conn.BaseURL()+"/v1/stats", nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.cli.conn.User != "" {
+		req.URL.Path = "/admin/stats"
+	} else {
+		req.Header.Set("X-Index-ID", r.cfg.indexFor(0))
+	}
+	var resp *http.Response
+	if r.cli.conn.User != "" {
+		resp, err = r.cli.doAdmin(r.client, req)
+	} else {
+		resp, err = r.client.Do(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var stats map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestSummarizeLatencies(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	got := summarizeLatencies(samples)
+	want := latencySummary{Min: 1, Mean: 50.5, P50: 50, P95: 95, P99: 99, Max: 100}
+	if got != want {
+		t.Errorf("summarizeLatencies = %+v, want %+v", got, want)
+	}
+	if got := summarizeLatencies(nil); got != (latencySummary{}) {
+		t.Errorf("empty input should give a zero summary, got %+v", got)
+	}
+	if got := summarizeLatencies([]time.Duration{3 * time.Millisecond}); got.P50 != 3 || got.P99 != 3 {
+		t.Errorf("single sample should be every percentile, got %+v", got)
+	}
+}
+
+func TestBenchRecorderReport(t *testing.T) {
+	rec := newBenchRecorder()
+	rec.record(benchOpWrite, 10*time.Millisecond, nil)
+	rec.record(benchOpWrite, 30*time.Millisecond, http.ErrHandlerTimeout)
+	rec.record(benchOpSearch, 20*time.Millisecond, nil)
+
+	rep := rec.report(&benchConfig{Scenario: "mixed"}, 2*time.Second)
+	if rep.Requests != 3 || rep.Errors != 1 || rep.Throughput != 1.5 {
+		t.Errorf("unexpected totals: %+v", rep)
+	}
+	if w := rep.Ops[benchOpWrite]; w.Requests != 2 || w.Errors != 1 || w.Latency.Max != 30 {
+		t.Errorf("unexpected write op report: %+v", w)
+	}
+	if rep.Latency.P50 != 20 || rep.LastError == "" {
+		t.Errorf("unexpected overall latency or last error: %+v", rep)
+	}
+}
+
+func TestStatsDelta(t *testing.T) {
+	before := map[string]any{
+		"pool": map[string]any{"total_created": 2.0, "max_idle_time": "30m0s",
+			"worker_details": map[string]any{"a": map[string]any{"ops_processed": 5.0}}},
+	}
+	after := map[string]any{
+		"pool": map[string]any{"total_created": 4.0, "max_idle_time": "30m0s",
+			"worker_details": map[string]any{"b": map[string]any{"ops_processed": 7.0}}},
+	}
+	got := statsDelta(before, after)
+	want := map[string]float64{
+		"pool.total_created":                  2,
+		"pool.worker_details.a.ops_processed": -5,
+		"pool.worker_details.b.ops_processed": 7,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("statsDelta = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestContentGeneratorAvoidsDuplicates(t *testing.T) {
+	var seq atomic.Uint64
+	a := newContentGenerator(1, 64, &seq)
+	b := newContentGenerator(1, 64, &seq) // same seed: only seq keeps them apart
+	seen := make(map[string]bool)
+	for i := 0; i < 500; i++ {
+		for _, g := range []*contentGenerator{a, b} {
+			m := g.memory()
+			if seen[m] {
+				t.Fatalf("duplicate memory generated: %q", m)
+			}
+			seen[m] = true
+			if len(m) > 64 {
+				t.Fatalf("memory exceeds size: %d bytes", len(m))
+			}
+		}
+	}
+	if tiny := newContentGenerator(1, 1, &seq).memory(); !strings.HasPrefix(tiny, "Note ") || len(tiny) < len("Note 1: x") {
+		t.Errorf("tiny memories should keep their unique prefix, got %q", tiny)
+	}
+}
+
+// fakeQubicDB is a minimal stand-in for the server endpoints bench uses.
+type fakeQubicDB struct {
+	mu       sync.Mutex
+	contents map[string]bool
+	indexes  map[string]int
+	dupes    int
+	searches int
+	contexts int
+}
+
+func (f *fakeQubicDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	switch r.URL.Path {
+	case "/v1/write":
+		content, _ := body["content"].(string)
+		if f.contents[content] {
+			f.dupes++
+		}
+		f.contents[content] = true
+		f.indexes[r.Header.Get("X-Index-ID")]++
+	case "/v1/search":
+		f.searches++
+	case "/v1/context":
+		f.contexts++
+	case "/v1/stats":
+		json.NewEncoder(w).Encode(map[string]any{"pool": map[string]any{"active_workers": len(f.indexes)}})
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte(`{}`))
+}
+
+func newBenchCLI(t *testing.T) (*cli, *fakeQubicDB) {
+	t.Helper()
+	fake := &fakeQubicDB{contents: make(map[string]bool), indexes: make(map[string]int)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	conn, err := core.ParseConnString("qubicdb://" + strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cli{conn: conn, httpClient: &http.Client{Timeout: 5 * time.Second}}, fake
+}
+
+func TestBenchSmoke_WriteRoundRobin(t *testing.T) {
+	c, fake := newBenchCLI(t)
+	b := &benchConfig{Scenario: "write", Count: 120, Concurrency: 4, Size: 128,
+		Index: "bench", Indexes: 3, Strategy: "round-robin", Seed: 1}
+
+	rep, err := c.runBench(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Requests != 120 || rep.Errors != 0 || rep.Ops[benchOpWrite].Requests != 120 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if fake.dupes != 0 {
+		t.Errorf("generator produced %d duplicate memories", fake.dupes)
+	}
+	for _, idx := range []string{"bench-0", "bench-1", "bench-2"} {
+		if fake.indexes[idx] != 40 {
+			t.Errorf("%s received %d writes, want 40", idx, fake.indexes[idx])
+		}
+	}
+	if rep.ServerStats["pool.active_workers"] != 3 {
+		t.Errorf("expected server stats delta for active_workers, got %v", rep.ServerStats)
+	}
+}
+
+func TestBenchSmoke_SearchPrepopulatesAndMixedRatio(t *testing.T) {
+	c, fake := newBenchCLI(t)
+	rep, err := c.runBench(&benchConfig{Scenario: "search", Count: 50, Concurrency: 2, Size: 64,
+		Prepopulate: 10, Index: "s", Indexes: 1, Strategy: "single", Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.indexes["s"] != 10 || fake.searches != 50 || rep.Requests != 50 {
+		t.Fatalf("expected 10 prepopulated writes and 50 searches, got writes=%v searches=%d report=%+v",
+			fake.indexes, fake.searches, rep)
+	}
+
+	rep, err = c.runBench(&benchConfig{Scenario: "mixed", Duration: 200 * time.Millisecond, Concurrency: 2,
+		Ramp: 50 * time.Millisecond, Size: 64, WriteRatio: 1, Index: "m", Indexes: 1, Strategy: "single", Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Requests == 0 || rep.Ops[benchOpSearch].Requests != 0 || rep.Errors != 0 {
+		t.Errorf("write-ratio 1 should only write, got %+v", rep)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// exportOptions selects the slice of an index to export.
+type exportOptions struct {
+	metadata         map[string]string
+	strict           bool
+	since            string
+	until            string
+	includeNeighbors int
+}
+
+// query encodes the options as the export endpoint's query string.
+func (o exportOptions) query() (url.Values, error) {
+	q := url.Values{}
+	keys := make([]string, 0, len(o.metadata))
+	for k := range o.metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		q.Set("metadata_"+k, o.metadata[k])
+	}
+	if o.strict {
+		q.Set("strict", "true")
+	}
+	for name, raw := range map[string]string{"since": o.since, "until": o.until} {
+		if raw == "" {
+			continue
+		}
+		t, err := parseTimeFlag(raw)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", name, err)
+		}
+		q.Set(name, t.UTC().Format(time.RFC3339))
+	}
+	if o.includeNeighbors > 0 {
+		q.Set("include_neighbors", strconv.Itoa(o.includeNeighbors))
+	}
+	return q, nil
+}
+
+// parseTimeFlag accepts an RFC 3339 time or a date such as 2024-05-01.
+func parseTimeFlag(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a YYYY-MM-DD date, got %q", raw)
+	}
+	return t, nil
+}
+
+func newExportCmd(c *cli) *cobra.Command {
+	var opts exportOptions
+	var output, format string
+	cmd := &cobra.Command{
+		Use:   "export [index-id]",
+		Short: "Export an index, or the part of it matching a filter",
+		Long: `Export an index as newline-delimited JSON.
+
+--metadata, --strict, --since and --until select the memories to export, as
+they do for search; without them the whole index is exported.
+--include-neighbors N adds the memories up to N synapses away. Synapses
+leading out of the slice are exported flagged as dangling. The output can be
+loaded into another index with "admin import".
+
+--format nrdb exports the whole index in the server's binary .nrdb format
+instead, embeddings and positions included but attachments not; importing
+it replaces the target index.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := opts.query()
+			if err != nil {
+				return err
+			}
+			if format != "" {
+				q.Set("format", format)
+			}
+			p := "/admin/indexes/" + args[0] + "/export"
+			if len(q) > 0 {
+				p += "?" + q.Encode()
+			}
+			out := io.Writer(os.Stdout)
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			return c.stream("GET", p, out)
+		},
+	}
+	cmd.Flags().StringToStringVar(&opts.metadata, "metadata", nil, "Export memories with this metadata (key=value, repeatable)")
+	cmd.Flags().BoolVar(&opts.strict, "strict", false, "Require every --metadata pair instead of any")
+	cmd.Flags().StringVar(&opts.since, "since", "", "Export memories created at or after this time (RFC 3339 or YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Export memories created before this time (RFC 3339 or YYYY-MM-DD)")
+	cmd.Flags().IntVar(&opts.includeNeighbors, "include-neighbors", 0, "Also export memories up to N synapses away")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the export to this file instead of stdout")
+	cmd.Flags().StringVar(&format, "format", "", "ndjson (default) or nrdb")
+	return cmd
+}
+
+func newAdminImportCmd(c *cli) *cobra.Command {
+	var dangling, format string
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "import [index-id] [file]",
+		Short: "Import an export into an index",
+		Long: `Import the output of "admin export" into an index, creating it if needed.
+
+Memories already in the index are left as they are. --dangling decides what
+happens to synapses whose other end is in neither the export nor the index:
+drop them, or keep them recorded on the memory (default: the server's
+import.danglingSynapses). Reads stdin when file is - or omitted.
+
+An .nrdb export (--format nrdb, or a file ending in .nrdb) replaces the
+index instead; an index holding memories is only replaced with --force.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := io.Reader(os.Stdin)
+			if len(args) == 2 && args[1] != "-" {
+				f, err := os.Open(args[1])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			if format == "" && len(args) == 2 && strings.HasSuffix(args[1], ".nrdb") {
+				format = "nrdb"
+			}
+			q := url.Values{}
+			if dangling != "" {
+				q.Set("dangling", dangling)
+			}
+			if force {
+				q.Set("force", "true")
+			}
+			contentType := "application/x-ndjson"
+			if format == "nrdb" {
+				contentType = "application/x-nrdb"
+			} else if format != "" && format != "ndjson" {
+				return fmt.Errorf("--format must be ndjson or nrdb, got %q", format)
+			}
+			p := "/admin/indexes/" + args[0] + "/import"
+			if len(q) > 0 {
+				p += "?" + q.Encode()
+			}
+			data, err := c.fetchBody("POST", p, contentType, in, "", true)
+			if err != nil {
+				return err
+			}
+			printJSON(data)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dangling, "dangling", "", "drop or keep synapses leading out of the export")
+	cmd.Flags().StringVar(&format, "format", "", "ndjson or nrdb (default: from the file name, else ndjson)")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace an index holding memories with an nrdb export")
+	return cmd
+}
+
+// stream copies the response body of an admin request to out as it
+// arrives. Exports can outlast the client's usual timeout, so none is set.
+func (c *cli) stream(method, path string, out io.Writer) error {
+	req, err := http.NewRequest(method, c.conn.BaseURL()+path, nil)
+	if err != nil {
+		return err
+	}
+	client := *c.httpClient
+	client.Timeout = 0
+	var resp *http.Response
+	if c.conn.User != "" {
+		resp, err = c.doAdmin(&client, req)
+	} else {
+		resp, err = client.Do(req)
+	}
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "Error %d: %s\n", resp.StatusCode, string(data))
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
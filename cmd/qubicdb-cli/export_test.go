@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestExportOptionsQuery(t *testing.T) {
+	q, err := exportOptions{
+		metadata:         map[string]string{"thread_id": "conv-42"},
+		strict:           true,
+		since:            "2024-05-01",
+		until:            "2024-06-01T12:00:00+02:00",
+		includeNeighbors: 1,
+	}.query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "include_neighbors=1&metadata_thread_id=conv-42&since=2024-05-01T00%3A00%3A00Z&strict=true&until=2024-06-01T10%3A00%3A00Z"
+	if got := q.Encode(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if _, err := (exportOptions{since: "last tuesday"}).query(); err == nil {
+		t.Error("expected an error for an unparseable --since")
+	}
+	if q, _ := (exportOptions{}).query(); len(q) != 0 {
+		t.Errorf("no options should export everything, got %v", q)
+	}
+}
+
+func TestRecallPath(t *testing.T) {
+	if got := recallPath(0, 0, ""); got != "/v1/recall" {
+		t.Errorf("got %s without flags", got)
+	}
+	if got, want := recallPath(200, 50, "asc"), "/v1/recall?limit=50&offset=200&order=asc"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
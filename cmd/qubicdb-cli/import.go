@@ -0,0 +1,261 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newImportCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import external notes into an index",
+	}
+
+	var dir string
+	var splitHeadings bool
+	var linkWeight float64
+	vaultCmd := &cobra.Command{
+		Use:   "vault",
+		Short: "Import a folder of Markdown notes, such as an Obsidian vault",
+		Long: `Import a folder of Markdown notes, such as an Obsidian vault.
+
+Every note becomes a memory, or every heading section with --split-headings,
+with its path and title as metadata and its frontmatter tags as tags.
+[[wikilinks]] and relative Markdown links between notes become synapses.
+Notes are recognised by path, so importing the same folder again only
+updates the notes that changed. The report lists links to missing notes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			indexID := c.resolveIndex(cmd)
+			if indexID == "" {
+				return fmt.Errorf("--index is required")
+			}
+			archive, files, err := zipMarkdownDir(dir)
+			if err != nil {
+				return err
+			}
+			if files == 0 {
+				return fmt.Errorf("no Markdown files under %s", dir)
+			}
+
+			q := url.Values{}
+			if splitHeadings {
+				q.Set("split_headings", "true")
+			}
+			if linkWeight > 0 {
+				q.Set("link_weight", strconv.FormatFloat(linkWeight, 'f', -1, 64))
+			}
+			p := "/v1/import/markdown"
+			if len(q) > 0 {
+				p += "?" + q.Encode()
+			}
+			data, err := c.fetchBody("POST", p, "application/zip", bytes.NewReader(archive), indexID, false)
+			if err != nil {
+				return err
+			}
+			printJSON(data)
+			return nil
+		},
+	}
+	vaultCmd.Flags().StringVar(&dir, "dir", ".", "Folder to import")
+	vaultCmd.Flags().String("index", "", "Index ID (overrides connection string)")
+	vaultCmd.Flags().BoolVar(&splitHeadings, "split-headings", false, "Import every heading section as its own memory")
+	vaultCmd.Flags().Float64Var(&linkWeight, "link-weight", 0, "Initial weight of synapses created from links (default: the server's import.linkWeight)")
+	cmd.AddCommand(vaultCmd)
+
+	var file string
+	var batchSize int
+	memoriesCmd := &cobra.Command{
+		Use:   "memories",
+		Short: "Write a file of memories in batches",
+		Long: `Write a file of memories through POST /v1/import, batchSize at a time.
+
+The file is a JSON array of {"content", "metadata", "parent_id"} objects or,
+with a .ndjson or .jsonl name, one such object per line; "-" reads stdin.
+Each batch must fit the server's security.maxRequestBody. Items that fail
+are listed with their position in the file; the others are still written.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			indexID := c.resolveIndex(cmd)
+			if indexID == "" {
+				return fmt.Errorf("--index is required")
+			}
+			if file == "" {
+				return fmt.Errorf("--file is required")
+			}
+			var data []byte
+			var err error
+			if file == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+			ndjson := strings.HasSuffix(file, ".ndjson") || strings.HasSuffix(file, ".jsonl")
+			batches, err := splitMemoryBatches(data, ndjson, batchSize)
+			if err != nil {
+				return err
+			}
+
+			summary := importSummary{Failures: []importFailure{}}
+			for _, batch := range batches {
+				resp, err := c.fetchBody("POST", "/v1/import", "application/x-ndjson", bytes.NewReader(batch.body), indexID, false)
+				if err != nil {
+					return fmt.Errorf("batch at item %d: %w", batch.offset, err)
+				}
+				if err := summary.add(resp, batch.offset); err != nil {
+					return err
+				}
+			}
+			out, _ := json.MarshalIndent(summary, "", "  ")
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+	memoriesCmd.Flags().StringVar(&file, "file", "", `JSON or NDJSON file of memories ("-" for stdin)`)
+	memoriesCmd.Flags().IntVar(&batchSize, "batch-size", 500, "Memories sent per request")
+	memoriesCmd.Flags().String("index", "", "Index ID (overrides connection string)")
+	cmd.AddCommand(memoriesCmd)
+	return cmd
+}
+
+// memoryBatch is one request's worth of memories as NDJSON, and the
+// position in the file of its first item.
+type memoryBatch struct {
+	body   []byte
+	offset int
+}
+
+// splitMemoryBatches splits a JSON array, or NDJSON when ndjson is set,
+// into NDJSON batches of at most size items. Blank NDJSON lines are
+// dropped; other lines are passed on as they are for the server to judge.
+func splitMemoryBatches(data []byte, ndjson bool, size int) ([]memoryBatch, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("--batch-size must be at least 1")
+	}
+	var items [][]byte
+	if ndjson {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				items = append(items, line)
+			}
+		}
+	} else {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("expected a JSON array of memories (use a .ndjson name for NDJSON): %w", err)
+		}
+		for _, item := range raw {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, item); err != nil {
+				return nil, err
+			}
+			items = append(items, compact.Bytes())
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no memories in the file")
+	}
+	var batches []memoryBatch
+	for start := 0; start < len(items); start += size {
+		end := min(start+size, len(items))
+		batches = append(batches, memoryBatch{body: bytes.Join(items[start:end], []byte("\n")), offset: start})
+	}
+	return batches, nil
+}
+
+// importSummary totals the responses of every batch of an import.
+type importSummary struct {
+	Total    int             `json:"total"`
+	Created  int             `json:"created"`
+	Failed   int             `json:"failed"`
+	Failures []importFailure `json:"failures"`
+}
+
+// importFailure is an item that was not written, by its position in the
+// file.
+type importFailure struct {
+	Index int    `json:"index"`
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// add counts a batch response whose first item is the file's item offset.
+func (s *importSummary) add(resp []byte, offset int) error {
+	var batch struct {
+		Total   int             `json:"total"`
+		Created int             `json:"created"`
+		Failed  int             `json:"failed"`
+		Results []importFailure `json:"results"`
+	}
+	if err := json.Unmarshal(resp, &batch); err != nil {
+		return fmt.Errorf("unexpected response: %w", err)
+	}
+	s.Total += batch.Total
+	s.Created += batch.Created
+	s.Failed += batch.Failed
+	for _, r := range batch.Results {
+		if r.Error != "" {
+			r.Index += offset
+			s.Failures = append(s.Failures, r)
+		}
+	}
+	return nil
+}
+
+// zipMarkdownDir zips the Markdown files under dir, leaving out hidden
+// files and folders such as .obsidian, and reports how many it zipped.
+func zipMarkdownDir(dir string) ([]byte, int, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fsys := os.DirFS(dir)
+	files := 0
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext := strings.ToLower(path.Ext(p)); ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(p)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), files, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestZipMarkdownDir(t *testing.T) {
+	archive, files, err := zipMarkdownDir("../../pkg/vault/testdata/vault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	slices.Sort(names)
+	want := []string{"Home.md", "Projects/Alpha.md", "Projects/Beta.md", "Reading List.md", "garden/Plants.md"}
+	if files != len(want) || !slices.Equal(names, want) {
+		t.Errorf("expected %v without hidden folders, got %d %v", want, files, names)
+	}
+}
+
+func TestSplitMemoryBatches(t *testing.T) {
+	batches, err := splitMemoryBatches([]byte(`[{"content": "a"}, {"content": "b"}, {"content": "c"}]`), false, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || string(batches[0].body) != "{\"content\":\"a\"}\n{\"content\":\"b\"}" || batches[1].offset != 2 {
+		t.Errorf("unexpected batches %+v", batches)
+	}
+
+	batches, err = splitMemoryBatches([]byte("{\"content\":\"a\"}\n\nnot json\n"), true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || string(batches[0].body) != "{\"content\":\"a\"}\nnot json" {
+		t.Errorf("expected blank lines dropped and bad lines passed on, got %q", batches[0].body)
+	}
+
+	if _, err := splitMemoryBatches([]byte(`{"content":"a"}`), false, 10); err == nil {
+		t.Error("expected an object that is not an array to be refused")
+	}
+
+	var s importSummary
+	s.add([]byte(`{"total":2,"created":1,"failed":1,"results":[{"index":0,"id":"x"},{"index":1,"code":"INVALID_CONTENT","error":"invalid"}]}`), 500)
+	if s.Total != 2 || s.Failed != 1 || len(s.Failures) != 1 || s.Failures[0].Index != 501 {
+		t.Errorf("expected failures reported by file position, got %+v", s)
+	}
+}
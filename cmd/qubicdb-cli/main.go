@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,10 +19,17 @@ import (
 type cli struct {
 	conn       *core.ConnInfo
 	httpClient *http.Client
+	sessionDir string // where admin login caches tokens; "" for the user config dir
+	configFile string // the profile config file; "" for the default
+
+	profileName string   // the active profile, "" without one
+	profile     *profile // nil without one
+
+	input func() (string, error) // reads a line for confirmations
 }
 
 func main() {
-	var connectStr string
+	var connectStr, profileName string
 	var interactive bool
 
 	c := &cli{
@@ -32,17 +41,22 @@ func main() {
 		Short: "QubicDB CLI — admin client for QubicDB servers",
 		Long:  "A command-line client for managing QubicDB instances, similar to redis-cli or psql.",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if connectStr == "" {
-				connectStr = os.Getenv("QUBICDB_URL")
+			cfg, err := c.loadProfiles()
+			if err != nil {
+				return err
 			}
-			if connectStr == "" {
-				connectStr = "qubicdb://localhost:6060"
+			resolved, err := resolveConnection(connectStr, profileName, cfg, os.Getenv)
+			if err != nil {
+				return err
 			}
-			info, err := core.ParseConnString(connectStr)
+			c.conn, c.profileName, c.profile = resolved.conn, resolved.profileName, resolved.profile
+			transport, err := profileTransport(c.profile)
 			if err != nil {
-				return fmt.Errorf("invalid connection string: %w", err)
+				return fmt.Errorf("profile %q: %w", c.profileName, err)
+			}
+			if transport != nil {
+				c.httpClient.Transport = transport
 			}
-			c.conn = info
 			return nil
 		},
 		// When called with no subcommand, drop into interactive shell.
@@ -52,7 +66,8 @@ func main() {
 		},
 	}
 
-	rootCmd.PersistentFlags().StringVar(&connectStr, "connect", "", "Connection string (qubicdb://[user:pass@]host[:port][/index])")
+	rootCmd.PersistentFlags().StringVar(&connectStr, "connect", "", "Connection string (qubicdb://[user:pass@]host[:port][/index]); overrides profiles")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Named server profile (default $QUBICDB_PROFILE, then the one set by profile use)")
 	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Start interactive shell (default when no subcommand given)")
 
 	// ── Health ──────────────────────────────────────────────
@@ -65,13 +80,20 @@ func main() {
 	})
 
 	// ── Stats ───────────────────────────────────────────────
-	rootCmd.AddCommand(&cobra.Command{
+	statsCmd := &cobra.Command{
 		Use:   "stats",
-		Short: "Show global server statistics",
+		Short: "Show server statistics",
+		Long: `Show server statistics. With --index this is /v1/stats for that index:
+server health plus its neuron and synapse counts, depth breakdown, energy,
+oldest and newest neuron and approximate memory footprint. Otherwise it is
+the pool-wide /admin/stats when the connection string carries admin
+credentials, and /v1/stats for the connection string's index when not.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.getJSON("/v1/stats")
+			return c.stats(c.resolveIndex(cmd), cmd.Flags().Changed("index"))
 		},
-	})
+	}
+	statsCmd.Flags().String("index", "", "Index ID; shows its stats instead of the pool-wide ones")
+	rootCmd.AddCommand(statsCmd)
 
 	// ── Config commands ─────────────────────────────────────
 	configCmd := &cobra.Command{
@@ -156,25 +178,48 @@ func main() {
 	searchCmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search memories",
-		Args:  cobra.ExactArgs(1),
+		Long:  "Search memories by query, by relatedness to an existing memory (--anchor), or both.",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			indexID := c.resolveIndex(cmd)
 			limit, _ := cmd.Flags().GetInt("limit")
 			depth, _ := cmd.Flags().GetInt("depth")
 			strict, _ := cmd.Flags().GetBool("strict")
+			mode, _ := cmd.Flags().GetString("mode")
 			metaKV, _ := cmd.Flags().GetStringToString("metadata")
+			anchor, _ := cmd.Flags().GetString("anchor")
+			includeAnchor, _ := cmd.Flags().GetBool("include-anchor")
 
+			query := ""
+			if len(args) == 1 {
+				query = args[0]
+			}
+			if query == "" && anchor == "" {
+				return fmt.Errorf("a query or --anchor is required")
+			}
 			payload := map[string]any{
-				"query": args[0],
+				"query": query,
 				"depth": depth,
 				"limit": limit,
 			}
+			if anchor != "" {
+				payload["anchor_id"] = anchor
+				if cmd.Flags().Changed("anchor-weight") {
+					payload["anchor_weight"], _ = cmd.Flags().GetFloat64("anchor-weight")
+				}
+				if includeAnchor {
+					payload["include_anchor"] = true
+				}
+			}
 			if len(metaKV) > 0 {
 				payload["metadata"] = metaKV
 			}
 			if strict {
 				payload["strict"] = true
 			}
+			if mode != "" {
+				payload["mode"] = mode
+			}
 			body, err := json.Marshal(payload)
 			if err != nil {
 				return err
@@ -187,6 +232,10 @@ func main() {
 	searchCmd.Flags().String("index", "", "Index ID")
 	searchCmd.Flags().StringToString("metadata", nil, "Metadata filter key=value pairs (e.g. --metadata thread_id=conv-1)")
 	searchCmd.Flags().Bool("strict", false, "Strict metadata filter — only return neurons matching ALL metadata keys")
+	searchCmd.Flags().String("mode", "", "Search mode: exact returns direct matches only, without spread activation")
+	searchCmd.Flags().String("anchor", "", "Neuron ID: rank memories related to it (synapses, shared metadata, embedding)")
+	searchCmd.Flags().Float64("anchor-weight", 0.5, "Share of the score from relatedness to the anchor (0-1; default search.anchorWeight)")
+	searchCmd.Flags().Bool("include-anchor", false, "Include the anchor itself in the results")
 	rootCmd.AddCommand(searchCmd)
 
 	// ── Recall ──────────────────────────────────────────────
@@ -195,10 +244,16 @@ func main() {
 		Short: "List all memories for an index",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			indexID := c.resolveIndex(cmd)
-			return c.getJSONWithIndex("/v1/recall", indexID)
+			offset, _ := cmd.Flags().GetInt("offset")
+			limit, _ := cmd.Flags().GetInt("limit")
+			order, _ := cmd.Flags().GetString("order")
+			return c.getJSONWithIndex(recallPath(offset, limit, order), indexID)
 		},
 	}
 	recallCmd.Flags().String("index", "", "Index ID")
+	recallCmd.Flags().Int("offset", 0, "Memories to skip, for paging")
+	recallCmd.Flags().Int("limit", 0, "Max memories (default 100, at most 500)")
+	recallCmd.Flags().String("order", "", "Page by creation time: asc or desc (default: by energy)")
 	rootCmd.AddCommand(recallCmd)
 
 	// ── Read ────────────────────────────────────────────────
@@ -214,12 +269,24 @@ func main() {
 	readCmd.Flags().String("index", "", "Index ID")
 	rootCmd.AddCommand(readCmd)
 
+	// ── Bench ───────────────────────────────────────────────
+	rootCmd.AddCommand(newBenchCmd(c))
+
+	// ── Import ──────────────────────────────────────────────
+	rootCmd.AddCommand(newImportCmd(c))
+
+	// ── Profiles ────────────────────────────────────────────
+	rootCmd.AddCommand(newProfileCmd(c))
+
 	// ── Admin commands ──────────────────────────────────────
 	adminCmd := &cobra.Command{
 		Use:   "admin",
 		Short: "Server administration commands",
 	}
 
+	adminCmd.AddCommand(newAdminLoginCmd(c))
+	adminCmd.AddCommand(newAdminLogoutCmd(c))
+
 	adminCmd.AddCommand(&cobra.Command{
 		Use:   "indexes",
 		Short: "List all active indexes",
@@ -230,28 +297,22 @@ func main() {
 
 	adminCmd.AddCommand(&cobra.Command{
 		Use:   "detail [index-id]",
-		Short: "Show stats and brain state for an index",
+		Short: "Show stats, graph health and brain state for an index",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.adminGet("/admin/indexes/" + args[0])
+			return c.adminDetail(args[0])
 		},
 	})
 
-	adminCmd.AddCommand(&cobra.Command{
-		Use:   "export [index-id]",
-		Short: "Export index brain data",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.adminGet("/admin/indexes/" + args[0] + "/export")
-		},
-	})
+	adminCmd.AddCommand(newExportCmd(c))
+	adminCmd.AddCommand(newAdminImportCmd(c))
 
 	adminCmd.AddCommand(&cobra.Command{
 		Use:   "reset [index-id]",
 		Short: "Reset an index brain (clears all neurons)",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.adminPost("/admin/indexes/"+args[0]+"/reset", "")
+			return c.resetIndex(args[0])
 		},
 	})
 
@@ -260,7 +321,7 @@ func main() {
 		Short: "Delete an index completely",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.adminDelete("/admin/indexes/" + args[0])
+			return c.deleteIndex(args[0])
 		},
 	})
 
@@ -290,7 +351,7 @@ func main() {
 
 	adminCmd.AddCommand(&cobra.Command{
 		Use:   "gc",
-		Short: "Force garbage collection",
+		Short: "Evict idle and dormant indexes and force garbage collection",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return c.adminPost("/admin/gc", "")
 		},
@@ -364,31 +425,47 @@ func (c *cli) resolveIndex(cmd *cobra.Command) string {
 }
 
 func (c *cli) doRequest(method, path, body, indexID string, admin bool) error {
-	url := c.conn.BaseURL() + path
+	data, err := c.fetch(method, path, body, indexID, admin)
+	if err != nil {
+		return err
+	}
+	printJSON(data)
+	return nil
+}
 
+// fetch performs a request and returns the raw response body. Error
+// responses are reported on stderr and returned as an error.
+func (c *cli) fetch(method, path, body, indexID string, admin bool) ([]byte, error) {
 	var bodyReader io.Reader
 	if body != "" {
 		bodyReader = strings.NewReader(body)
 	}
+	return c.fetchBody(method, path, "application/json", bodyReader, indexID, admin)
+}
+
+// fetchBody is fetch for a body of any content type.
+func (c *cli) fetchBody(method, path, contentType string, body io.Reader, indexID string, admin bool) ([]byte, error) {
+	url := c.conn.BaseURL() + path
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 
 	if indexID != "" {
 		req.Header.Set("X-Index-ID", indexID)
 	}
 
+	var resp *http.Response
 	if admin && c.conn.User != "" {
-		req.SetBasicAuth(c.conn.User, c.conn.Password)
+		resp, err = c.doAdmin(c.httpClient, req)
+	} else {
+		resp, err = c.httpClient.Do(req)
 	}
-
-	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("connection failed: %w", err)
+		return nil, fmt.Errorf("connection failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -396,10 +473,13 @@ func (c *cli) doRequest(method, path, body, indexID string, admin bool) error {
 
 	if resp.StatusCode >= 400 {
 		fmt.Fprintf(os.Stderr, "Error %d: %s\n", resp.StatusCode, string(data))
-		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
 	}
+	return data, nil
+}
 
-	// Pretty-print JSON
+// printJSON pretty-prints a JSON object or array, or echoes raw text.
+func printJSON(data []byte) {
 	var prettyJSON map[string]any
 	if err := json.Unmarshal(data, &prettyJSON); err == nil {
 		out, _ := json.MarshalIndent(prettyJSON, "", "  ")
@@ -414,8 +494,6 @@ func (c *cli) doRequest(method, path, body, indexID string, admin bool) error {
 			fmt.Println(string(data))
 		}
 	}
-
-	return nil
 }
 
 func (c *cli) getJSON(path string) error {
@@ -426,6 +504,25 @@ func (c *cli) getJSONWithIndex(path, indexID string) error {
 	return c.doRequest("GET", path, "", indexID, false)
 }
 
+// recallPath returns the /v1/recall path for the recall command's paging
+// flags; zero values are left to the server's defaults.
+func recallPath(offset, limit int, order string) string {
+	q := url.Values{}
+	if offset > 0 {
+		q.Set("offset", strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if order != "" {
+		q.Set("order", order)
+	}
+	if len(q) == 0 {
+		return "/v1/recall"
+	}
+	return "/v1/recall?" + q.Encode()
+}
+
 func (c *cli) postJSON(path, body, indexID string) error {
 	return c.doRequest("POST", path, body, indexID, false)
 }
@@ -434,6 +531,15 @@ func (c *cli) deleteJSON(path, indexID string) error {
 	return c.doRequest("DELETE", path, "", indexID, false)
 }
 
+// stats prints the tenant view of indexID when perIndex is set or no admin
+// credentials are present, and pool-wide stats otherwise.
+func (c *cli) stats(indexID string, perIndex bool) error {
+	if c.conn.User != "" && !perIndex {
+		return c.adminGet("/admin/stats")
+	}
+	return c.getJSONWithIndex("/v1/stats", indexID)
+}
+
 func (c *cli) adminGet(path string) error {
 	return c.doRequest("GET", path, "", "", true)
 }
@@ -446,6 +552,76 @@ func (c *cli) adminDelete(path string) error {
 	return c.doRequest("DELETE", path, "", "", true)
 }
 
+// resetIndex and deleteIndex ask for confirmation on a production profile.
+func (c *cli) resetIndex(indexID string) error {
+	if err := c.confirmDestructive("reset", indexID); err != nil {
+		return err
+	}
+	return c.adminPost("/admin/indexes/"+indexID+"/reset", "")
+}
+
+func (c *cli) deleteIndex(indexID string) error {
+	if err := c.confirmDestructive("delete", indexID); err != nil {
+		return err
+	}
+	return c.adminDelete("/admin/indexes/" + indexID)
+}
+
+// adminDetail prints index detail, rendering graph health as a readable
+// block after the stats and state JSON.
+func (c *cli) adminDetail(indexID string) error {
+	data, err := c.fetch("GET", "/admin/indexes/"+indexID, "", "", true)
+	if err != nil {
+		return err
+	}
+
+	var detail map[string]any
+	if err := json.Unmarshal(data, &detail); err != nil {
+		printJSON(data)
+		return nil
+	}
+	graph, _ := detail["graphStats"].(map[string]any)
+	delete(detail, "graphStats")
+
+	out, _ := json.MarshalIndent(detail, "", "  ")
+	fmt.Println(string(out))
+	if graph != nil {
+		printGraphStats(os.Stdout, graph)
+	}
+	return nil
+}
+
+// printGraphStats renders the graphStats object from /admin/indexes/{id}.
+func printGraphStats(w io.Writer, g map[string]any) {
+	num := func(key string) float64 {
+		v, _ := g[key].(float64)
+		return v
+	}
+	weights, _ := g["weights"].(map[string]any)
+	weight := func(key string) float64 {
+		v, _ := weights[key].(float64)
+		return v
+	}
+
+	clustering := fmt.Sprintf("%.3f", num("clusteringCoefficient"))
+	if sampled, neurons := num("clusteringSampled"), num("neurons"); sampled < neurons {
+		clustering += fmt.Sprintf(" (sampled %.0f of %.0f)", sampled, neurons)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Graph health")
+	fmt.Fprintf(w, "  neurons            %.0f\n", num("neurons"))
+	fmt.Fprintf(w, "  synapses           %.0f\n", num("synapses"))
+	fmt.Fprintf(w, "  average degree     %.2f (max %.0f)\n", num("averageDegree"), num("maxDegree"))
+	fmt.Fprintf(w, "  isolated neurons   %.0f\n", num("isolatedNeurons"))
+	fmt.Fprintf(w, "  components         %.0f (largest %.0f)\n", num("components"), num("largestComponent"))
+	fmt.Fprintf(w, "  clustering coeff   %s\n", clustering)
+	fmt.Fprintf(w, "  weight p10/p50/p90/p99  %.3f / %.3f / %.3f / %.3f\n",
+		weight("p10"), weight("p50"), weight("p90"), weight("p99"))
+	fmt.Fprintf(w, "  weight min/mean/max     %.3f / %.3f / %.3f\n",
+		weight("min"), weight("mean"), weight("max"))
+}
+
 // silentGet and silentAdminGet perform a request without printing output —
 // used for connection/auth verification in the REPL startup.
 func (c *cli) silentGet(path string) error {
@@ -466,10 +642,12 @@ func (c *cli) doSilentRequest(method, path, body, indexID string, admin bool) er
 	if indexID != "" {
 		req.Header.Set("X-Index-ID", indexID)
 	}
+	var resp *http.Response
 	if admin && c.conn.User != "" {
-		req.SetBasicAuth(c.conn.User, c.conn.Password)
+		resp, err = c.doAdmin(c.httpClient, req)
+	} else {
+		resp, err = c.httpClient.Do(req)
 	}
-	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
@@ -490,11 +668,12 @@ func (c *cli) configGetSection(section string) error {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	var resp *http.Response
 	if c.conn.User != "" {
-		req.SetBasicAuth(c.conn.User, c.conn.Password)
+		resp, err = c.doAdmin(c.httpClient, req)
+	} else {
+		resp, err = c.httpClient.Do(req)
 	}
-
-	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestStats_Routing(t *testing.T) {
+	var path, index string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, index = r.URL.Path, r.Header.Get("X-Index-ID")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	cases := []struct {
+		name, connect, index string
+		perIndex             bool
+		wantPath, wantIndex  string
+	}{
+		{"tenant", "qubicdb://" + host + "/app", "app", false, "/v1/stats", "app"},
+		{"admin", "qubicdb://admin:qubicdb@" + host + "/app", "app", false, "/admin/stats", ""},
+		{"admin with --index", "qubicdb://admin:qubicdb@" + host, "notes", true, "/v1/stats", "notes"},
+	}
+	for _, tc := range cases {
+		conn, err := core.ParseConnString(tc.connect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &cli{conn: conn, httpClient: http.DefaultClient}
+		if err := c.stats(tc.index, tc.perIndex); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if path != tc.wantPath || index != tc.wantIndex {
+			t.Errorf("%s: expected %s for %q, got %s for %q", tc.name, tc.wantPath, tc.wantIndex, path, index)
+		}
+	}
+}
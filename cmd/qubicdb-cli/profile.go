@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConnect = "qubicdb://localhost:6060"
+
+// profileConfig is the CLI config file: named connection profiles and the
+// one `profile use` made current.
+type profileConfig struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*profile `yaml:"profiles,omitempty"`
+}
+
+// profile is a named server. Passwords are never stored: PasswordEnv names
+// the environment variable holding the password of the connection's user.
+type profile struct {
+	Connect     string     `yaml:"connect"`
+	Index       string     `yaml:"index,omitempty"`
+	PasswordEnv string     `yaml:"passwordEnv,omitempty"`
+	Production  bool       `yaml:"production,omitempty"`
+	TLS         profileTLS `yaml:"tls,omitempty"`
+}
+
+// profileTLS configures HTTPS to a qubicdb+tls server.
+type profileTLS struct {
+	CAFile             string `yaml:"caFile,omitempty"`
+	ServerName         string `yaml:"serverName,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify,omitempty"`
+}
+
+// configPath is the CLI config file: QUBICDB_CLI_CONFIG, or config.yaml in
+// the user config directory (~/.config/qubicdb on Linux).
+func (c *cli) configPath() (string, error) {
+	if c.configFile != "" {
+		return c.configFile, nil
+	}
+	if path := os.Getenv("QUBICDB_CLI_CONFIG"); path != "" {
+		return path, nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "qubicdb", "config.yaml"), nil
+}
+
+// loadProfiles reads the config file; a missing file is an empty config.
+func (c *cli) loadProfiles() (*profileConfig, error) {
+	cfg := &profileConfig{Profiles: map[string]*profile{}}
+	path, err := c.configPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*profile{}
+	}
+	return cfg, nil
+}
+
+// saveProfiles writes the config file, refusing any profile whose
+// connection string carries a password.
+func (c *cli) saveProfiles(cfg *profileConfig) error {
+	for name, p := range cfg.Profiles {
+		if info, err := core.ParseConnString(p.Connect); err == nil && info.Password != "" {
+			return fmt.Errorf("profile %q: the connection string holds a password; use --password-env instead", name)
+		}
+	}
+	path, err := c.configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// resolvedConn is the connection a command runs against and the profile it
+// came from, if any.
+type resolvedConn struct {
+	conn        *core.ConnInfo
+	profileName string
+	profile     *profile
+}
+
+// resolveConnection picks the connection: an explicit --connect, then the
+// profile named by --profile, QUBICDB_PROFILE or `profile use`, then
+// QUBICDB_URL, then the local default.
+func resolveConnection(connectFlag, profileFlag string, cfg *profileConfig, getenv func(string) string) (resolvedConn, error) {
+	if connectFlag != "" {
+		info, err := core.ParseConnString(connectFlag)
+		if err != nil {
+			return resolvedConn{}, fmt.Errorf("invalid connection string: %w", err)
+		}
+		return resolvedConn{conn: info}, nil
+	}
+
+	name := profileFlag
+	if name == "" {
+		name = getenv("QUBICDB_PROFILE")
+	}
+	if name == "" {
+		name = cfg.Current
+	}
+	if name != "" {
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return resolvedConn{}, fmt.Errorf("unknown profile %q (see qubicdb-cli profile list)", name)
+		}
+		info, err := core.ParseConnString(p.Connect)
+		if err != nil {
+			return resolvedConn{}, fmt.Errorf("profile %q: invalid connection string: %w", name, err)
+		}
+		if info.IndexID == "" {
+			info.IndexID = p.Index
+		}
+		if p.PasswordEnv != "" {
+			info.Password = getenv(p.PasswordEnv)
+		}
+		return resolvedConn{conn: info, profileName: name, profile: p}, nil
+	}
+
+	connect := getenv("QUBICDB_URL")
+	if connect == "" {
+		connect = defaultConnect
+	}
+	info, err := core.ParseConnString(connect)
+	if err != nil {
+		return resolvedConn{}, fmt.Errorf("invalid connection string: %w", err)
+	}
+	return resolvedConn{conn: info}, nil
+}
+
+// profileTransport returns an HTTP transport for p's TLS options, or nil
+// when the defaults do.
+func profileTransport(p *profile) (http.RoundTripper, error) {
+	if p == nil || p.TLS == (profileTLS{}) {
+		return nil, nil
+	}
+	cfg := &tls.Config{ServerName: p.TLS.ServerName, InsecureSkipVerify: p.TLS.InsecureSkipVerify}
+	if p.TLS.CAFile != "" {
+		pem, err := os.ReadFile(p.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls.caFile: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls.caFile %s holds no PEM certificates", p.TLS.CAFile)
+		}
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = cfg
+	return t, nil
+}
+
+// confirmDestructive asks, on a production profile, for the index name
+// before action runs against it.
+func (c *cli) confirmDestructive(action, indexID string) error {
+	if c.profile == nil || !c.profile.Production {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Profile %q is production. Type the index name to %s %s: ", c.profileName, action, indexID)
+	answer, err := c.readLine()
+	if err != nil {
+		return fmt.Errorf("%s aborted: %w", action, err)
+	}
+	if strings.TrimSpace(answer) != indexID {
+		return fmt.Errorf("%s aborted: the name did not match", action)
+	}
+	return nil
+}
+
+// readLine reads a line of input: from the shell's scanner in the REPL,
+// from stdin otherwise.
+func (c *cli) readLine() (string, error) {
+	if c.input == nil {
+		scanner := bufio.NewScanner(os.Stdin)
+		c.input = func() (string, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", errors.New("no input")
+			}
+			return scanner.Text(), nil
+		}
+	}
+	return c.input()
+}
+
+func newProfileCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage named server profiles",
+		Long: `Manage named server profiles in the CLI config file (QUBICDB_CLI_CONFIG, or
+config.yaml under the user config directory, ~/.config/qubicdb on Linux).
+Select one with --profile or QUBICDB_PROFILE, or make it current with
+"profile use". An explicit --connect always wins over profiles.`,
+		// Profiles are managed without connecting, so a broken profile can
+		// still be fixed or removed.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List profiles; * marks the current one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := c.loadProfiles()
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0, len(cfg.Profiles))
+			for name := range cfg.Profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if len(names) == 0 {
+				fmt.Println("No profiles (add one with qubicdb-cli profile add)")
+			}
+			for _, name := range names {
+				p := cfg.Profiles[name]
+				marker := " "
+				if name == cfg.Current {
+					marker = "*"
+				}
+				line := fmt.Sprintf("%s %-12s %s", marker, name, p.Connect)
+				if p.Index != "" {
+					line += " index=" + p.Index
+				}
+				if p.PasswordEnv != "" {
+					line += " password=$" + p.PasswordEnv
+				}
+				if p.Production {
+					line += " [production]"
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	})
+
+	var add profile
+	addCmd := &cobra.Command{
+		Use:   "add [name]",
+		Short: "Add or replace a profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if add.Connect == "" {
+				return errors.New("--connect is required")
+			}
+			info, err := core.ParseConnString(add.Connect)
+			if err != nil {
+				return fmt.Errorf("invalid connection string: %w", err)
+			}
+			if info.Password != "" {
+				return errors.New("profiles do not store passwords: drop it from --connect and name the variable holding it with --password-env")
+			}
+			cfg, err := c.loadProfiles()
+			if err != nil {
+				return err
+			}
+			p := add
+			cfg.Profiles[args[0]] = &p
+			if err := c.saveProfiles(cfg); err != nil {
+				return err
+			}
+			fmt.Printf("Saved profile %s\n", args[0])
+			return nil
+		},
+	}
+	addCmd.Flags().StringVar(&add.Connect, "connect", "", "Connection string, without a password (qubicdb://[user@]host[:port][/index])")
+	addCmd.Flags().StringVar(&add.Index, "index", "", "Default index")
+	addCmd.Flags().StringVar(&add.PasswordEnv, "password-env", "", "Environment variable holding the user's password")
+	addCmd.Flags().BoolVar(&add.Production, "production", false, "Ask for the index name before reset and delete")
+	addCmd.Flags().StringVar(&add.TLS.CAFile, "ca-file", "", "PEM CA bundle to verify the server with")
+	addCmd.Flags().StringVar(&add.TLS.ServerName, "tls-server-name", "", "Server name to verify instead of the host")
+	addCmd.Flags().BoolVar(&add.TLS.InsecureSkipVerify, "insecure-skip-verify", false, "Do not verify the server certificate")
+	cmd.AddCommand(addCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove [name]",
+		Short: "Remove a profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := c.loadProfiles()
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Profiles[args[0]]; !ok {
+				return fmt.Errorf("unknown profile %q", args[0])
+			}
+			delete(cfg.Profiles, args[0])
+			if cfg.Current == args[0] {
+				cfg.Current = ""
+			}
+			if err := c.saveProfiles(cfg); err != nil {
+				return err
+			}
+			fmt.Printf("Removed profile %s\n", args[0])
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "use [name]",
+		Short: "Make a profile current",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := c.loadProfiles()
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Profiles[args[0]]; !ok {
+				return fmt.Errorf("unknown profile %q", args[0])
+			}
+			cfg.Current = args[0]
+			if err := c.saveProfiles(cfg); err != nil {
+				return err
+			}
+			fmt.Printf("Using profile %s\n", args[0])
+			return nil
+		},
+	})
+	return cmd
+}
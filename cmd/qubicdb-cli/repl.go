@@ -3,8 +3,10 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 )
 
@@ -13,13 +15,15 @@ QubicDB Interactive Shell — available commands:
 
   Brain ops:
     ping                              Check server health
-    stats                             Global statistics
+    stats [--index <id>]              Server statistics (pool-wide as admin unless --index)
     write <content>                   Write a memory (neuron)
       write <content> --metadata key=val,key2=val2
       write <content> --parent-id <neuron-id>
     search <query>                    Associative search
       search <query> --depth N --limit N
       search <query> --metadata key=val --strict
+      search <query> --mode exact
+      search [query] --anchor <id> [--anchor-weight W] [--include-anchor]
     recall                            List all neurons for active index
     read <neuron-id>                  Read a specific neuron
     context <cue>                     Assemble LLM context
@@ -30,9 +34,10 @@ QubicDB Interactive Shell — available commands:
 
   Admin (requires credentials in connection string):
     indexes                           List all active indexes
-    detail <index-id>                 Show index stats + brain state
+    detail <index-id>                 Show index stats, graph health + brain state
     reset <index-id>                  Wipe neurons (keep index registered)
     delete <index-id>                 Delete index completely
+                                      (production profiles ask for the index name)
     export <index-id>                 Export brain data
     wake <index-id>                   Force brain to Active state
     sleep <index-id>                  Force brain to Sleeping state
@@ -56,7 +61,7 @@ QubicDB Interactive Shell — available commands:
   Shell:
     \help                             Show this help
     \index [id]                       Show/switch active index
-    \status                           Show connection info
+    \status                           Show connection and profile info
     \quit  (or exit, quit, Ctrl-D)    Exit
 `
 
@@ -82,16 +87,25 @@ func runREPL(c *cli) {
 		}
 	}
 
+	if c.profileName != "" {
+		indexInfo += fmt.Sprintf(", profile: %s", c.profileName)
+	}
 	fmt.Printf("Connected to QubicDB at %s%s\nType \\help for commands, \\quit to exit.\n\n",
 		c.conn.BaseURL(), indexInfo)
 
 	activeIndex := c.conn.IndexID
 	scanner := bufio.NewScanner(os.Stdin)
+	c.input = func() (string, error) {
+		if !scanner.Scan() {
+			return "", errors.New("no input")
+		}
+		return scanner.Text(), nil
+	}
 
 	for {
-		prompt := "qubicdb"
+		prompt := replPrompt(c.profileName, c.profile)
 		if activeIndex != "" {
-			prompt = fmt.Sprintf("qubicdb[%s]", activeIndex)
+			prompt += fmt.Sprintf("[%s]", activeIndex)
 		}
 		fmt.Printf("%s> ", prompt)
 
@@ -111,6 +125,18 @@ func runREPL(c *cli) {
 	}
 }
 
+// replPrompt names the shell after the active profile, flagging
+// production: "qubicdb", "qubicdb(staging)", "qubicdb(prod!)".
+func replPrompt(profileName string, p *profile) string {
+	switch {
+	case profileName == "":
+		return "qubicdb"
+	case p != nil && p.Production:
+		return "qubicdb(" + profileName + "!)"
+	}
+	return "qubicdb(" + profileName + ")"
+}
+
 // dispatchREPL parses and executes one REPL line.
 // Returns true when the user wants to quit.
 func dispatchREPL(c *cli, line string, activeIndex *string) bool {
@@ -145,6 +171,9 @@ func dispatchREPL(c *cli, line string, activeIndex *string) bool {
 	// ── Status ──────────────────────────────────────────────
 	case `\status`:
 		fmt.Printf("server:  %s\n", c.conn.BaseURL())
+		if c.profileName != "" {
+			fmt.Printf("profile: %s\n", c.profileName)
+		}
 		if c.conn.User != "" {
 			fmt.Printf("user:    %s\n", c.conn.User)
 		}
@@ -162,7 +191,8 @@ func dispatchREPL(c *cli, line string, activeIndex *string) bool {
 		}
 
 	case "stats":
-		c.getJSON("/v1/stats") //nolint:errcheck
+		perIndex := slices.Contains(parts[1:], "--index") || slices.Contains(parts[1:], "-i")
+		c.stats(replResolveIndex(parts[1:], activeIndex), perIndex) //nolint:errcheck
 
 	case "write":
 		replWrite(c, parts[1:], activeIndex)
@@ -192,21 +222,21 @@ func dispatchREPL(c *cli, line string, activeIndex *string) bool {
 		if len(parts) < 2 {
 			fmt.Fprintln(os.Stderr, "usage: detail <index-id>")
 		} else {
-			c.adminGet("/admin/indexes/" + parts[1]) //nolint:errcheck
+			c.adminDetail(parts[1]) //nolint:errcheck
 		}
 
 	case "reset":
 		if len(parts) < 2 {
 			fmt.Fprintln(os.Stderr, "usage: reset <index-id>")
-		} else {
-			c.adminPost("/admin/indexes/"+parts[1]+"/reset", "") //nolint:errcheck
+		} else if err := c.resetIndex(parts[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		}
 
 	case "delete":
 		if len(parts) < 2 {
 			fmt.Fprintln(os.Stderr, "usage: delete <index-id>")
-		} else {
-			c.adminDelete("/admin/indexes/" + parts[1]) //nolint:errcheck
+		} else if err := c.deleteIndex(parts[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		}
 
 	case "export":
@@ -352,18 +382,38 @@ func replWrite(c *cli, args []string, activeIndex *string) {
 
 func replSearch(c *cli, args []string, activeIndex *string) {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "usage: search <query> [--index <id>] [--depth N] [--limit N] [--metadata key=val,...] [--strict]")
+		fmt.Fprintln(os.Stderr, "usage: search [query] [--index <id>] [--depth N] [--limit N] [--metadata key=val,...] [--strict] [--mode exact] [--anchor <id>] [--anchor-weight W] [--include-anchor]")
 		return
 	}
-	query := args[0]
+	query := ""
+	start := 0
+	if !strings.HasPrefix(args[0], "--") {
+		query, start = args[0], 1
+	}
 	idx := *activeIndex
 	depth := 2
 	limit := 20
 	strict := false
+	mode := ""
 	meta := map[string]string{}
+	anchor := ""
+	anchorWeight := -1.0
+	includeAnchor := false
 
-	for i := 1; i < len(args); i++ {
+	for i := start; i < len(args); i++ {
 		switch args[i] {
+		case "--anchor":
+			if i+1 < len(args) {
+				i++
+				anchor = args[i]
+			}
+		case "--anchor-weight":
+			if i+1 < len(args) {
+				i++
+				fmt.Sscanf(args[i], "%g", &anchorWeight)
+			}
+		case "--include-anchor":
+			includeAnchor = true
 		case "--index", "-i":
 			if i+1 < len(args) {
 				i++
@@ -381,6 +431,11 @@ func replSearch(c *cli, args []string, activeIndex *string) {
 			}
 		case "--strict":
 			strict = true
+		case "--mode":
+			if i+1 < len(args) {
+				i++
+				mode = args[i]
+			}
 		case "--metadata", "-m":
 			if i+1 < len(args) {
 				i++
@@ -404,6 +459,21 @@ func replSearch(c *cli, args []string, activeIndex *string) {
 	if strict {
 		payload["strict"] = true
 	}
+	if mode != "" {
+		payload["mode"] = mode
+	}
+	if anchor != "" {
+		payload["anchor_id"] = anchor
+		if anchorWeight >= 0 {
+			payload["anchor_weight"] = anchorWeight
+		}
+		if includeAnchor {
+			payload["include_anchor"] = true
+		}
+	} else if query == "" {
+		fmt.Fprintln(os.Stderr, "search needs a query or --anchor")
+		return
+	}
 	body, _ := json.Marshal(payload)
 	c.postJSON("/v1/search", string(body), idx) //nolint:errcheck
 }
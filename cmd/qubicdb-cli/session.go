@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cachedSession is an admin session token saved by `admin login`.
+type cachedSession struct {
+	User      string    `json:"user"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newAdminLoginCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "login",
+		Short: "Log in once and use a session token for later admin commands",
+		Long: `Log in with the user and password of the connection string and cache the
+session token the server returns in the user config directory. Later admin
+commands send the token instead of the password until it expires or
+"admin logout" is run.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.login()
+		},
+	}
+}
+
+func newAdminLogoutCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "End the cached admin session",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.logout()
+		},
+	}
+}
+
+// login exchanges the connection's credentials for a session token and
+// caches it.
+func (c *cli) login() error {
+	if c.conn.User == "" {
+		return errors.New("admin login needs user:password in the connection string")
+	}
+	body, _ := json.Marshal(map[string]string{"user": c.conn.User, "password": c.conn.Password})
+	data, err := c.fetch("POST", "/admin/login", string(body), "", false)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Role      string    `json:"role"`
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to decode login response: %w", err)
+	}
+	if resp.Token == "" {
+		fmt.Printf("Logged in as %s (%s); the server issues no session tokens, so Basic Auth stays in use\n", c.conn.User, resp.Role)
+		return nil
+	}
+	if err := c.saveSession(cachedSession{User: c.conn.User, Token: resp.Token, ExpiresAt: resp.ExpiresAt}); err != nil {
+		return err
+	}
+	fmt.Printf("Logged in as %s (%s) until %s\n", c.conn.User, resp.Role, resp.ExpiresAt.Local().Format(time.DateTime))
+	return nil
+}
+
+// logout revokes the cached session on the server and removes it.
+func (c *cli) logout() error {
+	token := c.sessionToken()
+	if token == "" {
+		fmt.Println("No cached admin session")
+		return nil
+	}
+	defer c.clearSession()
+	req, err := http.NewRequest("POST", c.conn.BaseURL()+"/admin/logout", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	fmt.Println("Logged out")
+	return nil
+}
+
+// doAdmin sends req with admin credentials: the cached session token when
+// there is one, Basic Auth otherwise. A refused token, say after a server
+// restart, is dropped and the request retried once with Basic Auth.
+func (c *cli) doAdmin(client *http.Client, req *http.Request) (*http.Response, error) {
+	token := c.sessionToken()
+	if token == "" {
+		req.SetBasicAuth(c.conn.User, c.conn.Password)
+		return client.Do(req)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	c.clearSession()
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.SetBasicAuth(c.conn.User, c.conn.Password)
+	return client.Do(retry)
+}
+
+// sessionPath is where the session of the connection's user on its host
+// is cached.
+func (c *cli) sessionPath() (string, error) {
+	dir := c.sessionDir
+	if dir == "" {
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, "qubicdb", "sessions")
+	}
+	name := strings.NewReplacer("://", "_", ":", "_", "/", "_", "\\", "_").Replace(c.conn.User + "@" + c.conn.BaseURL())
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// sessionToken returns the cached session token, or "" when there is no
+// unexpired session for the connection's user.
+func (c *cli) sessionToken() string {
+	if c.conn.User == "" {
+		return ""
+	}
+	path, err := c.sessionPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var sess cachedSession
+	if json.Unmarshal(data, &sess) != nil || sess.User != c.conn.User || !time.Now().Before(sess.ExpiresAt) {
+		os.Remove(path)
+		return ""
+	}
+	return sess.Token
+}
+
+func (c *cli) saveSession(sess cachedSession) error {
+	path, err := c.sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(sess)
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (c *cli) clearSession() {
+	if path, err := c.sessionPath(); err == nil {
+		os.Remove(path)
+	}
+}
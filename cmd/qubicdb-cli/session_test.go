@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// sessionServer is a fake admin API that issues one token per login and
+// records how each admin request authenticated.
+type sessionServer struct {
+	mu      sync.Mutex
+	valid   map[string]bool
+	schemes []string
+}
+
+func (f *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/admin/login":
+		if f.valid == nil {
+			f.valid = map[string]bool{}
+		}
+		token := "tok-" + time.Now().Format(time.RFC3339Nano)
+		f.valid[token] = true
+		json.NewEncoder(w).Encode(map[string]any{"role": "admin", "token": token, "expiresAt": time.Now().Add(time.Hour)})
+	case "/admin/logout":
+		delete(f.valid, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	default:
+		scheme, cred, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		f.schemes = append(f.schemes, scheme)
+		if scheme == "Bearer" && !f.valid[cred] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}
+}
+
+func (f *sessionServer) lastScheme() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.schemes[len(f.schemes)-1]
+}
+
+func newSessionCLI(t *testing.T, url string) *cli {
+	t.Helper()
+	conn, err := core.ParseConnString("qubicdb://ada:pw@" + strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cli{conn: conn, httpClient: http.DefaultClient, sessionDir: t.TempDir()}
+}
+
+func TestAdminSessionCache(t *testing.T) {
+	fake := &sessionServer{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	c := newSessionCLI(t, srv.URL)
+
+	if _, err := c.fetch("GET", "/admin/indexes", "", "", true); err != nil || fake.lastScheme() != "Basic" {
+		t.Fatalf("without a session Basic Auth should be sent, got %q (%v)", fake.lastScheme(), err)
+	}
+
+	if err := c.login(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.fetch("POST", "/admin/persist", "{}", "", true); err != nil || fake.lastScheme() != "Bearer" {
+		t.Fatalf("after login the token should be sent, got %q (%v)", fake.lastScheme(), err)
+	}
+
+	// A token the server no longer accepts falls back to Basic Auth and is
+	// dropped from the cache.
+	fake.mu.Lock()
+	fake.valid = nil
+	fake.mu.Unlock()
+	if _, err := c.fetch("POST", "/admin/persist", "{}", "", true); err != nil || fake.lastScheme() != "Basic" {
+		t.Fatalf("a refused token should be retried with Basic Auth, got %q (%v)", fake.lastScheme(), err)
+	}
+	if c.sessionToken() != "" {
+		t.Error("a refused token should be dropped")
+	}
+
+	if err := c.login(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.logout(); err != nil {
+		t.Fatal(err)
+	}
+	if c.sessionToken() != "" {
+		t.Error("logout should remove the cached session")
+	}
+}
+
+func TestAdminSessionCache_Expired(t *testing.T) {
+	c := newSessionCLI(t, "http://localhost:6060")
+	if err := c.saveSession(cachedSession{User: "ada", Token: "old", ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if c.sessionToken() != "" {
+		t.Error("an expired session should not be used")
+	}
+	c.saveSession(cachedSession{User: "ada", Token: "fresh", ExpiresAt: time.Now().Add(time.Minute)})
+	other := newSessionCLI(t, "http://localhost:6060")
+	other.conn.User = "bob"
+	other.sessionDir = c.sessionDir
+	if other.sessionToken() != "" {
+		t.Error("a session should only be used for the user it was issued to")
+	}
+	if c.sessionToken() != "fresh" {
+		t.Error("an unexpired session should be used")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/conformance"
+	"github.com/spf13/cobra"
+)
+
+// errFailed makes the command exit 1 once the report is written.
+var errFailed = fmt.Errorf("conformance checks failed")
+
+func main() {
+	var opts conformance.Options
+	var asJSON, list bool
+
+	rootCmd := &cobra.Command{
+		Use:   "qubicdb-conformance",
+		Short: "Run the QubicDB API conformance suite against a server",
+		Long: "Runs the documented sequence of API checks against --url and reports pass, fail or skip per check,\n" +
+			"with the exact requests and responses of failures. The suite registers, fills and deletes one index.\n" +
+			"Admin checks run only with --admin-user and --admin-password. Exits 1 when a check fails.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if list {
+				return printChecks(cmd.OutOrStdout())
+			}
+			if opts.AdminPassword == "" {
+				opts.AdminPassword = os.Getenv("QUBICDB_ADMIN_PASSWORD")
+			}
+			report, err := conformance.Run(context.Background(), opts)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printReport(cmd.OutOrStdout(), report)
+			}
+			if !report.OK() {
+				return errFailed
+			}
+			return nil
+		},
+	}
+	flags := rootCmd.Flags()
+	flags.StringVar(&opts.BaseURL, "url", "http://localhost:6060", "base URL of the server under test")
+	flags.StringVar(&opts.AdminUser, "admin-user", "", "admin user for the admin checks")
+	flags.StringVar(&opts.AdminPassword, "admin-password", "", "admin password (default $QUBICDB_ADMIN_PASSWORD)")
+	flags.StringVar(&opts.IndexID, "index", "", "index the suite writes to and deletes (default a random conformance-* index)")
+	flags.StringVar(&opts.Run, "run", "", "comma-separated check ID prefixes to run, with the checks they need")
+	flags.BoolVar(&asJSON, "json", false, "write the report as JSON")
+	flags.BoolVar(&list, "list", false, "list the checks and exit")
+
+	if err := rootCmd.Execute(); err != nil {
+		if err != errFailed {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func printChecks(w io.Writer) error {
+	for _, c := range conformance.Checks() {
+		notes := c.Codes
+		if c.Admin {
+			notes = append([]string{"admin"}, notes...)
+		}
+		suffix := ""
+		if len(notes) > 0 {
+			suffix = " [" + strings.Join(notes, ", ") + "]"
+		}
+		if _, err := fmt.Fprintf(w, "%-34s %s%s\n", c.ID, c.Title, suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printReport(w io.Writer, r *conformance.Report) {
+	fmt.Fprintf(w, "Target: %s (index %s)\n\n", r.Target, r.IndexID)
+	for _, res := range r.Results {
+		line := fmt.Sprintf("%-4s %s", strings.ToUpper(string(res.Status)), res.ID)
+		if res.Message != "" {
+			line += " — " + res.Message
+		}
+		fmt.Fprintln(w, line)
+		for _, ex := range res.Exchanges {
+			fmt.Fprintf(w, "       > %s %s\n", ex.Request.Method, ex.Request.URL)
+			if ex.Request.Body != "" {
+				fmt.Fprintf(w, "         %s\n", ex.Request.Body)
+			}
+			switch {
+			case ex.Response != nil:
+				fmt.Fprintf(w, "       < %d %s\n", ex.Response.Status, strings.TrimSpace(ex.Response.Body))
+			case ex.Error != "":
+				fmt.Fprintf(w, "       ! %s\n", ex.Error)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped in %s\n", r.Passed, r.Failed, r.Skipped, r.Duration)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// printBootstrap prints what a first run set up and which defaults are in
+// effect. The generated admin password is shown here once; afterwards it is
+// only in the data directory.
+func printBootstrap(cfg *core.Config, boot *core.BootstrapResult) {
+	rule := strings.Repeat("═", 68)
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n  FIRST RUN: no config file, no QUBICDB_* variables, empty data directory\n%s\n", rule, rule)
+	if boot.AdminPassword != "" {
+		fmt.Fprintf(&b, "  Admin user:      %s\n", cfg.Admin.User)
+		fmt.Fprintf(&b, "  Admin password:  %s\n", boot.AdminPassword)
+		fmt.Fprintf(&b, "                   (shown once; saved to %s)\n", boot.PasswordPath)
+	}
+	fmt.Fprintf(&b, "  Starter config:  %s\n", boot.ConfigPath)
+	b.WriteString("                   (loaded from the data directory on later runs)\n\n")
+	b.WriteString("  Defaults in effect:\n")
+	for _, line := range core.InsecureDefaults(cfg) {
+		fmt.Fprintf(&b, "    - %s\n", line)
+	}
+	fmt.Fprintf(&b, "\n  Run with --accept-insecure-defaults to skip this setup (CI, demos).\n%s\n\n", rule)
+	fmt.Print(b.String())
+}
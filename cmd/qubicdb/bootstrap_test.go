@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// dataPathFlags returns parsed flags that set only --data-path.
+func dataPathFlags(t *testing.T, dataPath string) (*pflag.FlagSet, *core.CLIOverrides) {
+	t.Helper()
+	var o core.CLIOverrides
+	f := pflag.NewFlagSet("qubicdb", pflag.ContinueOnError)
+	o.DataPath = f.String("data-path", "", "")
+	if err := f.Parse([]string{"--data-path", dataPath}); err != nil {
+		t.Fatal(err)
+	}
+	return f, &o
+}
+
+func TestResolveConfigFirstRun(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "data")
+	flags, o := dataPathFlags(t, dataPath)
+
+	cfg, boot, err := resolveConfig(flags, o, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if boot == nil || cfg.Admin.Password == core.DefaultAdminPassword {
+		t.Fatalf("a first run should generate the admin password, got %+v", boot)
+	}
+
+	// The next run loads the starter config and generates nothing.
+	next, boot2, err := resolveConfig(flags, o, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if boot2 != nil || next.Admin.Password != cfg.Admin.Password || next.Admin.SessionSecret != cfg.Admin.SessionSecret {
+		t.Errorf("a later run should keep the generated values, got %+v", boot2)
+	}
+}
+
+func TestResolveConfigAcceptInsecureDefaults(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "data")
+	flags, o := dataPathFlags(t, dataPath)
+
+	cfg, boot, err := resolveConfig(flags, o, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if boot != nil || cfg.Admin.Password != core.DefaultAdminPassword {
+		t.Errorf("the opt-out should keep the default password, got %+v", boot)
+	}
+	if _, err := os.Stat(dataPath); !os.IsNotExist(err) {
+		t.Error("the opt-out should not touch the data directory")
+	}
+
+	t.Setenv("QUBICDB_ENV", "production")
+	if _, _, err := resolveConfig(flags, o, false); err == nil {
+		t.Error("the default password should still fail in production")
+	}
+	if _, boot, err := resolveConfig(flags, o, true); err != nil || boot == nil {
+		t.Errorf("a production first run should pass with a generated password: %v", err)
+	}
+}
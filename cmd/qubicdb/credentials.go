@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newHashPasswordCmd prints a bcrypt hash for use as admin.users[].passwordHash.
+// The password is read from stdin unless given as an argument, so it stays
+// out of shell history by default.
+func newHashPasswordCmd() *cobra.Command {
+	var cost int
+
+	cmd := &cobra.Command{
+		Use:   "hash-password [password]",
+		Short: "Print a bcrypt hash for an admin.users passwordHash entry",
+		Long: "Hashes a password with bcrypt for the admin.users list in the config file.\n" +
+			"Without an argument the password is read from the first line of stdin.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var password string
+			if len(args) == 1 {
+				password = args[0]
+			} else {
+				if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+					fmt.Fprint(os.Stderr, "Password: ")
+				}
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("failed to read password: %w", err)
+				}
+				password = strings.TrimRight(line, "\r\n")
+			}
+			if password == "" {
+				return fmt.Errorf("password must not be empty")
+			}
+
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+			if err != nil {
+				return fmt.Errorf("failed to hash password: %w", err)
+			}
+			fmt.Println(string(hash))
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().IntVar(&cost, "cost", bcrypt.DefaultCost, "bcrypt cost factor (4-31)")
+	return cmd
+}
@@ -18,18 +18,24 @@ import (
 	"github.com/qubicDB/qubicdb/pkg/persistence"
 	"github.com/qubicDB/qubicdb/pkg/registry"
 	"github.com/qubicDB/qubicdb/pkg/sentiment"
+	"github.com/qubicDB/qubicdb/pkg/tracing"
 	"github.com/qubicDB/qubicdb/pkg/vector"
 )
 
 func main() {
 	var cliOverrides core.CLIOverrides
+	var printConfig bool
+	var acceptInsecureDefaults bool
 
 	rootCmd := &cobra.Command{
 		Use:   "qubicdb",
 		Short: "QubicDB - Brain-like Recursive Memory for LLMs",
 		Long:  "A persistent, per-user memory database that uses organic neural matrices, Hebbian learning, and sleep/consolidation cycles.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return run(cmd.Flags(), &cliOverrides)
+			if printConfig {
+				return runPrintConfig(cmd.Flags(), &cliOverrides)
+			}
+			return run(cmd.Flags(), &cliOverrides, !acceptInsecureDefaults)
 		},
 		SilenceUsage: true,
 	}
@@ -38,9 +44,12 @@ func main() {
 	f := rootCmd.Flags()
 
 	cliOverrides.ConfigPath = f.StringP("config", "f", "", "Path to YAML config file (overrides QUBICDB_CONFIG env)")
+	f.BoolVar(&printConfig, "print-config", false, "Print the effective config as YAML with secrets masked, then exit")
+	f.BoolVar(&acceptInsecureDefaults, "accept-insecure-defaults", false, "On a first run, keep the default admin password instead of generating one (CI, demos)")
 	cliOverrides.HTTPAddr = f.String("http-addr", "", "HTTP listen address")
 	cliOverrides.DataPath = f.String("data-path", "", "Data directory for .nrdb files")
 	cliOverrides.Compress = f.Bool("compress", false, "Enable msgpack compression")
+	cliOverrides.SeedForce = f.Bool("seed-force", false, "Reload storage.seed corpora into their indexes even if they already hold data")
 	cliOverrides.MaxNeurons = f.Int("max-neurons", 0, "Maximum neurons per brain")
 	cliOverrides.RegistryEnabled = f.Bool("registry", false, "Enable UUID registry")
 	cliOverrides.VectorEnabled = f.Bool("vector", false, "Enable vector embedding layer")
@@ -65,58 +74,76 @@ func main() {
 	cliOverrides.MinDimension = f.Int("min-dimension", 0, "Initial matrix dimensionality")
 	cliOverrides.MaxDimension = f.Int("max-dimension", 0, "Maximum matrix dimensionality")
 
+	rootCmd.AddCommand(newRepairContentCmd())
+	rootCmd.AddCommand(newCompactManifestsCmd())
+	rootCmd.AddCommand(newHashPasswordCmd())
+	rootCmd.AddCommand(newRestorePITRCmd())
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
 // run implements the server startup sequence after CLI flags are parsed.
-func run(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides) error {
+// With bootstrap set, a first run generates its admin password and starter
+// config; see core.Bootstrap.
+func run(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides, bootstrap bool) error {
 	core.PrintBanner()
 
-	// Resolve config path: --config flag > QUBICDB_CONFIG env var
-	configPath := ""
-	if cliOverrides.ConfigPath != nil && *cliOverrides.ConfigPath != "" {
-		configPath = *cliOverrides.ConfigPath
-	} else {
-		configPath = os.Getenv("QUBICDB_CONFIG")
-	}
-
-	// Load config through hierarchy: defaults -> YAML -> env vars
-	cfg, err := core.LoadConfig(configPath)
+	cfg, boot, err := resolveConfig(flags, cliOverrides, bootstrap)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
-
-	// Apply CLI flag overrides (only flags that were explicitly set)
-	applyExplicitFlags(flags, cfg, cliOverrides)
-
-	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid config: %w", err)
+	if boot != nil {
+		printBootstrap(cfg, boot)
 	}
 	if err := core.SetMaxNeuronContentBytes(cfg.Security.MaxNeuronContentBytes); err != nil {
 		return fmt.Errorf("invalid neuron content limit: %w", err)
 	}
+	if err := core.SetMaxContentLineLength(cfg.Write.MaxLineLength); err != nil {
+		return fmt.Errorf("invalid content line limit: %w", err)
+	}
+	core.SetContentSanitization(cfg.Write.SanitizeContent)
 
 	log.Printf("Data path: %s", cfg.Storage.DataPath)
 	log.Printf("HTTP: %s", cfg.Server.HTTPAddr)
 
+	// Initialize tracing (no-op unless telemetry.otlpEndpoint is set)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Telemetry)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	if cfg.Telemetry.OTLPEndpoint != "" {
+		log.Printf("Tracing enabled (endpoint=%s, sampleRate=%.2f, index=%s)",
+			cfg.Telemetry.OTLPEndpoint, cfg.Telemetry.SampleRate, cfg.Telemetry.IndexAttribute)
+	}
+
 	// Initialize persistence store
-	store, err := persistence.NewStoreWithDurability(
-		cfg.Storage.DataPath,
-		cfg.Storage.Compress,
-		persistence.DurabilityConfig{
-			WALEnabled:                 cfg.Storage.WALEnabled,
-			FsyncPolicy:                cfg.Storage.FsyncPolicy,
-			FsyncInterval:              cfg.Storage.FsyncInterval,
-			ChecksumValidationInterval: cfg.Storage.ChecksumValidationInterval,
-			StartupRepair:              cfg.Storage.StartupRepair,
-		},
-	)
+	store, err := openStore(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize store: %w", err)
 	}
 	log.Println("Persistence store initialized")
+	if report := store.StartupReport(); report != nil {
+		log.Printf("Startup recovery: %s", report.Summary())
+		if report.Repair != nil {
+			for _, f := range report.Repair.RemovedFiles {
+				log.Printf("⚠ Startup repair removed corrupt data file for index %s (%s): restore it from backup", f.Index, f.Error)
+			}
+			for _, id := range report.Repair.TooNewIndexes {
+				log.Printf("⚠ Data file for index %s was written by a newer server version; it was left in place and cannot be loaded", id)
+			}
+		}
+		if report.Path == "" {
+			log.Println("⚠ Startup report could not be written to the data directory")
+		}
+	}
+	if archive := cfg.Storage.WALArchive; archive.Dir != "" {
+		if err := store.StartWALArchive(walArchiveConfig(cfg)); err != nil {
+			return fmt.Errorf("failed to start wal archive: %w", err)
+		}
+		log.Printf("WAL archive enabled (dir=%s, segmentInterval=%s, maxAge=%s)", archive.Dir, archive.SegmentInterval, archive.MaxAge)
+	}
 
 	// Initialize UUID registry
 	reg, err := registry.NewStore(cfg.Storage.DataPath)
@@ -135,35 +162,77 @@ func run(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides) error {
 
 	// Initialize worker pool
 	pool := concurrency.NewWorkerPool(store, bounds)
+	pool.SetBackgroundSlice(cfg.Worker.BackgroundSlice)
+	pool.SetReadConcurrency(cfg.Worker.ReadConcurrency)
+	pool.SetLoadTimeout(cfg.Worker.LoadTimeout)
+	pool.SetChangelogSize(cfg.Sync.ChangelogSize)
 	log.Println("Worker pool initialized")
 
 	// Initialize vector layer (optional)
 	var vectorizer *vector.Vectorizer
+	var vectorHealth *vector.HealthMonitor
+	var vectorModels *vector.ModelRegistry
+	var vectorBreaker *vector.ResilientEmbedder
+	resilience := vector.ResiliencePolicy{
+		Retries:   cfg.Vector.Retries,
+		Backoff:   cfg.Vector.RetryBackoff,
+		Threshold: cfg.Vector.BreakerThreshold,
+		Cooldown:  cfg.Vector.BreakerCooldown,
+	}
 	if cfg.Vector.Enabled {
-		if cfg.Vector.ModelPath == "" {
+		if cfg.Vector.ModelPath == "" && len(cfg.Vector.Models) == 0 {
 			log.Println("⚠ Vector layer enabled but no model path configured, skipping")
 		} else if !vector.IsLibraryAvailable() {
 			log.Println("⚠ Vector layer enabled but llama.cpp library not found, skipping")
 			log.Println(vector.ResolveLibraryError(vector.ErrLibraryNotFound))
 		} else {
-			v, err := vector.NewVectorizer(cfg.Vector.ModelPath, cfg.Vector.GPULayers, cfg.Vector.EmbedContextSize)
-			if err != nil {
-				log.Printf("⚠ Vector layer failed to initialize: %v", err)
-			} else {
-				vectorizer = v
-				pool.SetVectorizerWithRepeat(vectorizer, cfg.Vector.Alpha, cfg.Vector.QueryRepeat)
-				log.Printf("Vector layer initialized (model=%s, dims=%d, gpu=%d, alpha=%.2f, query_repeat=%d)",
-					cfg.Vector.ModelPath, vectorizer.EmbedDim(), cfg.Vector.GPULayers, cfg.Vector.Alpha, cfg.Vector.QueryRepeat)
+			if cfg.Vector.ModelPath != "" {
+				v, err := vector.NewVectorizer(cfg.Vector.ModelPath, cfg.Vector.GPULayers, cfg.Vector.EmbedContextSize)
+				if err != nil {
+					log.Printf("⚠ Vector layer failed to initialize: %v", err)
+				} else {
+					vectorizer = v
+					vectorHealth = vector.NewHealthMonitor(vectorizer, cfg.Vector.ProbeTimeout)
+					vectorBreaker = vector.NewResilientEmbedder(vectorHealth.Embedder(), resilience)
+					pool.SetVectorizerWithRepeat(vectorBreaker, cfg.Vector.Alpha, cfg.Vector.QueryRepeat)
+					log.Printf("Vector layer initialized (model=%s, dims=%d, gpu=%d, alpha=%.2f, query_repeat=%d)",
+						cfg.Vector.ModelPath, vectorizer.EmbedDim(), cfg.Vector.GPULayers, cfg.Vector.Alpha, cfg.Vector.QueryRepeat)
+
+					// Pay llama.cpp context setup now rather than on the
+					// first search.
+					if probe := vectorHealth.Warmup(context.Background()); probe.OK {
+						log.Printf("Vector warm-up probe ok (%.1fms, dims=%d, fingerprint=%s)", probe.LatencyMs, probe.Dim, probe.Fingerprint)
+					} else {
+						log.Printf("⚠ Vector warm-up probe failed after %.1fms: %s; /health reports unavailable", probe.LatencyMs, probe.Error)
+					}
+					if cfg.Vector.ProbeInterval > 0 {
+						vectorHealth.Start(cfg.Vector.ProbeInterval)
+						log.Printf("Vector liveness probe every %s (timeout %s)", cfg.Vector.ProbeInterval, cfg.Vector.ProbeTimeout)
+					}
+				}
+			}
+			if len(cfg.Vector.Models) > 0 {
+				specs := make([]vector.ModelSpec, len(cfg.Vector.Models))
+				for i, m := range cfg.Vector.Models {
+					specs[i] = vector.ModelSpec{Name: m.Name, Path: m.Path, GPULayers: m.GPULayers}
+				}
+				vectorModels = vector.NewModelRegistry(specs, cfg.Vector.MaxLoadedModels, vector.VectorizerLoader(cfg.Vector.EmbedContextSize))
+				vectorModels.SetResilience(resilience)
+				pool.SetVectorModels(vectorModels)
+				log.Printf("Vector models registered (%d, loaded on demand, max %d resident)", len(specs), cfg.Vector.MaxLoadedModels)
 			}
 		}
 	} else {
 		log.Println("Vector layer disabled (enable with --vector or QUBICDB_VECTOR_ENABLED=true)")
 	}
 
-	// Initialize sentiment layer (always on — zero external dependencies)
-	sentimentAnalyzer := sentiment.New()
+	// Initialize sentiment layer (zero external dependencies)
+	sentimentAnalyzer, err := sentiment.NewAnalyzer(cfg.Sentiment.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to initialize sentiment layer: %w", err)
+	}
 	pool.SetSentimentAnalyzer(sentimentAnalyzer)
-	log.Println("Sentiment layer initialized (VADER, 6 basic emotions)")
+	log.Printf("Sentiment layer initialized (provider=%s, 6 basic emotions)", sentimentAnalyzer.Name())
 
 	// Initialize lifecycle manager
 	lm := lifecycle.NewManager()
@@ -182,9 +251,13 @@ func run(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides) error {
 			log.Printf("User %s waking up", indexID)
 		},
 	)
-	lm.StartMonitor(10 * time.Second)
 	log.Println("Lifecycle manager initialized")
 
+	// Background tasks outside the daemon manager, started and stopped
+	// together.
+	tasks := core.NewTaskRunner()
+	tasks.Add(lm.MonitorTask(10 * time.Second))
+
 	// Initialize daemon manager with config-driven intervals
 	daemons := daemon.NewDaemonManager(pool, lm, store)
 	daemons.SetIntervals(
@@ -194,16 +267,28 @@ func run(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides) error {
 		cfg.Daemons.PersistInterval,
 		cfg.Daemons.ReorgInterval,
 	)
+	daemons.SetEmbedInterval(cfg.Vector.PendingEmbedInterval)
+	daemons.SetCompactContent(cfg.Daemons.Consolidate.CompactContent)
+	daemons.SetAdaptiveSchedules(cfg.Daemons.Decay.Adaptive, cfg.Daemons.Consolidate.Adaptive, cfg.Daemons.Prune.Adaptive)
 	daemons.Start()
 	log.Println("Background daemons started")
 
-	// Start persistence flush worker
-	flushStop := store.StartFlushWorker(cfg.Daemons.PersistInterval)
-	checksumStop := store.StartChecksumValidationWorker(cfg.Storage.ChecksumValidationInterval)
+	tasks.Add(store.FlushTask(cfg.Daemons.PersistInterval))
+	tasks.Add(store.ChecksumValidationTask(cfg.Storage.ChecksumValidationInterval))
+	tasks.Start()
 
 	// Initialize HTTP server
 	httpServer := api.NewServer(cfg.Server.HTTPAddr, pool, lm, reg, cfg)
 	httpServer.SetDaemonManager(daemons)
+	httpServer.SetTaskRunner(tasks)
+	httpServer.SetVectorHealth(vectorHealth)
+	httpServer.SetVectorBreaker(vectorBreaker)
+	httpServer.SeedFromConfig()
+	httpServer.StartSubscriptions()
+	httpServer.StartRetention()
+	httpServer.StartHealth()
+	httpServer.StartCalibration()
+	httpServer.StartAttachmentSweep()
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -231,11 +316,10 @@ func run(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides) error {
 		log.Printf("HTTP shutdown error: %v", err)
 	}
 	daemons.Stop()
-	lm.Stop()
-	close(flushStop)
-	if checksumStop != nil {
-		close(checksumStop)
+	if err := tasks.Stop(shutdownCtx); err != nil {
+		log.Printf("Background task shutdown error: %v", err)
 	}
+	lm.Stop()
 
 	if err := pool.Shutdown(); err != nil {
 		log.Printf("Pool shutdown error: %v", err)
@@ -244,16 +328,122 @@ func run(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides) error {
 	if err := store.FlushAll(); err != nil {
 		log.Printf("Final flush error: %v", err)
 	}
+	store.StopWALArchive()
 
+	if vectorHealth != nil {
+		vectorHealth.Stop()
+	}
 	if vectorizer != nil {
 		vectorizer.Close()
 		log.Println("Vector layer closed")
 	}
+	if vectorModels != nil {
+		vectorModels.Close()
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Tracing shutdown error: %v", err)
+	}
 
 	log.Println("QubicDB shutdown complete")
 	return nil
 }
 
+// resolveConfig loads and validates the effective config: defaults, then
+// YAML, then env vars, then explicitly set CLI flags. Without a config file
+// the starter config in the data directory is used when there is one; with
+// bootstrap set, a first run writes it and the result is returned.
+func resolveConfig(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides, bootstrap bool) (*core.Config, *core.BootstrapResult, error) {
+	// Resolve config path: --config flag > QUBICDB_CONFIG env var
+	configPath := ""
+	if cliOverrides.ConfigPath != nil && *cliOverrides.ConfigPath != "" {
+		configPath = *cliOverrides.ConfigPath
+	} else {
+		configPath = os.Getenv("QUBICDB_CONFIG")
+	}
+
+	// Load config through hierarchy: defaults -> YAML -> env vars
+	cfg, err := core.LoadConfig(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Apply CLI flag overrides (only flags that were explicitly set)
+	applyExplicitFlags(flags, cfg, cliOverrides)
+
+	var boot *core.BootstrapResult
+	if configPath == "" {
+		starter := core.StarterConfigPath(cfg.Storage.DataPath)
+		if _, err := os.Stat(starter); err == nil {
+			if cfg, err = core.LoadConfig(starter); err != nil {
+				return nil, nil, fmt.Errorf("failed to load config: %w", err)
+			}
+			applyExplicitFlags(flags, cfg, cliOverrides)
+		} else if bootstrap && core.IsFirstRun(configPath, cfg.Storage.DataPath, os.Environ()) {
+			if boot, err = core.Bootstrap(cfg); err != nil {
+				return nil, nil, fmt.Errorf("first run setup failed: %w", err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, boot, nil
+}
+
+// runPrintConfig prints the effective config with secrets masked, for
+// sharing in support requests. Nothing is opened or started.
+func runPrintConfig(flags *pflag.FlagSet, cliOverrides *core.CLIOverrides) error {
+	cfg, _, err := resolveConfig(flags, cliOverrides, false)
+	if err != nil {
+		return err
+	}
+	out, err := cfg.MaskedYAML()
+	if err != nil {
+		return fmt.Errorf("failed to render config: %w", err)
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// openStore initializes the persistence store from the storage config section.
+func openStore(cfg *core.Config) (*persistence.Store, error) {
+	return persistence.NewStoreWithDurability(
+		cfg.Storage.DataPath,
+		cfg.Storage.Compress,
+		persistence.DurabilityConfig{
+			WALEnabled:                 cfg.Storage.WALEnabled,
+			FsyncPolicy:                cfg.Storage.FsyncPolicy,
+			FsyncInterval:              cfg.Storage.FsyncInterval,
+			ChecksumValidationInterval: cfg.Storage.ChecksumValidationInterval,
+			StartupRepair:              cfg.Storage.StartupRepair,
+			ManifestRetain:             cfg.Storage.ManifestRetain,
+			StartupReportRetain:        cfg.Storage.StartupReportRetain,
+			RetainVersions:             cfg.Storage.RetainVersions,
+			RetainVersionsMaxAge:       cfg.Storage.RetainVersionsMaxAge,
+			FlushRetryBackoff:          cfg.Storage.FlushRetryBackoff,
+			FlushRetryMaxBackoff:       cfg.Storage.FlushRetryMaxBackoff,
+			FlushStaleAfter:            cfg.Storage.FlushStaleAfter,
+			FlushStaleFailAfter:        cfg.Storage.FlushStaleFailAfter,
+		},
+	)
+}
+
+// walArchiveConfig returns the WAL archive settings of the storage config
+// section.
+func walArchiveConfig(cfg *core.Config) persistence.WALArchiveConfig {
+	a := cfg.Storage.WALArchive
+	return persistence.WALArchiveConfig{
+		Dir:             a.Dir,
+		QueueSize:       a.QueueSize,
+		SegmentBytes:    a.SegmentBytes,
+		SegmentInterval: a.SegmentInterval,
+		MaxAge:          a.MaxAge,
+		MaxBytes:        a.MaxBytes,
+	}
+}
+
 // applyExplicitFlags applies only the CLI flags that were explicitly set
 // by the user on the command line. Unset flags are ignored so they do not
 // override values resolved from YAML or environment variables.
@@ -314,6 +504,9 @@ func applyExplicitFlags(flags *pflag.FlagSet, cfg *core.Config, o *core.CLIOverr
 	if flags.Changed("tls-key") {
 		overrides.TLSKey = o.TLSKey
 	}
+	if flags.Changed("seed-force") {
+		overrides.SeedForce = o.SeedForce
+	}
 	if flags.Changed("min-dimension") {
 		overrides.MinDimension = o.MinDimension
 	}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// loadMaintenanceConfig resolves config for offline maintenance commands:
+// defaults -> YAML (--config or QUBICDB_CONFIG) -> env -> --data-path.
+func loadMaintenanceConfig(configPath, dataPath string) (*core.Config, error) {
+	if configPath == "" {
+		configPath = os.Getenv("QUBICDB_CONFIG")
+	}
+	cfg, err := core.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if dataPath != "" {
+		cfg.Storage.DataPath = dataPath
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// newRepairContentCmd scans persisted neurons for invalid UTF-8 and control
+// characters and rewrites them in place. Run it while the server is stopped.
+func newRepairContentCmd() *cobra.Command {
+	var configPath, dataPath string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "repair-content",
+		Short: "Find and fix persisted neurons with invalid UTF-8 or control characters",
+		Long: "Scans every persisted index for neuron content that the write path would reject.\n" +
+			"Invalid byte sequences are replaced with U+FFFD and control characters other than\n" +
+			"newline and tab are stripped. Stop the server before running this command.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadMaintenanceConfig(configPath, dataPath)
+			if err != nil {
+				return err
+			}
+			store, err := openStore(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to initialize store: %w", err)
+			}
+
+			if dryRun {
+				report, err := store.ValidateDataFiles(false)
+				if err != nil {
+					return fmt.Errorf("scan failed: %w", err)
+				}
+				fmt.Printf("checked %d file(s), %d corrupt, %d neuron(s) with invalid content\n",
+					report.CheckedFiles, report.CorruptFiles, report.InvalidContentNeurons)
+				for _, indexID := range report.InvalidContentIndexes {
+					fmt.Printf("  %s\n", indexID)
+				}
+				return nil
+			}
+
+			repaired, err := store.RepairContent()
+			if err != nil {
+				return fmt.Errorf("repair failed: %w", err)
+			}
+			if err := store.FlushAll(); err != nil {
+				return fmt.Errorf("flush failed: %w", err)
+			}
+
+			indexes := make([]string, 0, len(repaired))
+			total := 0
+			for indexID, n := range repaired {
+				indexes = append(indexes, string(indexID))
+				total += n
+			}
+			sort.Strings(indexes)
+			for _, indexID := range indexes {
+				fmt.Printf("  %s: %d neuron(s) repaired\n", indexID, repaired[core.IndexID(indexID)])
+			}
+			fmt.Printf("repaired %d neuron(s) across %d index(es)\n", total, len(indexes))
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&configPath, "config", "f", "", "Path to YAML config file (overrides QUBICDB_CONFIG env)")
+	f.StringVar(&dataPath, "data-path", "", "Data directory for .nrdb files")
+	f.BoolVar(&dryRun, "dry-run", false, "Report affected indexes without rewriting them")
+	return cmd
+}
+
+// newCompactManifestsCmd trims manifest and checkpoint history left behind
+// by servers that ran before storage.manifestRetain existed.
+func newCompactManifestsCmd() *cobra.Command {
+	var configPath, dataPath string
+	var retain int
+
+	cmd := &cobra.Command{
+		Use:   "compact-manifests",
+		Short: "Delete old manifest and checkpoint versions from the data directory",
+		Long: "Keeps the newest --retain manifest/checkpoint versions (including the one\n" +
+			"manifest/CURRENT points to) and deletes the rest. Versions newer than CURRENT\n" +
+			"are never touched. Stop the server before running this command.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadMaintenanceConfig(configPath, dataPath)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("retain") {
+				retain = cfg.Storage.ManifestRetain
+			}
+			if retain < 1 {
+				return fmt.Errorf("--retain must be >= 1 (storage.manifestRetain is %d)", cfg.Storage.ManifestRetain)
+			}
+
+			report, err := persistence.CompactManifests(cfg.Storage.DataPath, retain)
+			if err != nil {
+				return fmt.Errorf("compaction failed: %w", err)
+			}
+			fmt.Printf("current version %d: removed %d manifest(s) and %d checkpoint(s), kept %d version(s)\n",
+				report.CurrentVersion, report.ManifestsRemoved, report.CheckpointsRemoved, retain)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&configPath, "config", "f", "", "Path to YAML config file (overrides QUBICDB_CONFIG env)")
+	f.StringVar(&dataPath, "data-path", "", "Data directory for .nrdb files")
+	f.IntVar(&retain, "retain", 5, "Versions to keep (defaults to storage.manifestRetain)")
+	return cmd
+}
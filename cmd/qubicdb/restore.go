@@ -0,0 +1,224 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// newRestorePITRCmd restores a data directory backup, then replays the WAL
+// archive up to a point in time.
+func newRestorePITRCmd() *cobra.Command {
+	var configPath, dataPath, backupPath, archiveDir, untilFlag string
+
+	cmd := &cobra.Command{
+		Use:   "restore-pitr",
+		Short: "Restore a backup and replay the WAL archive up to a point in time",
+		Long: "Unpacks a backup of the data directory (a tar.gz of its contents, such as\n" +
+			"`tar czf backup.tgz -C <dataPath> .`) into an empty --data-path, then replays\n" +
+			"the records of the WAL archive appended up to --until. --until must not be\n" +
+			"earlier than the backup. With registry.enabled, every restored index is\n" +
+			"registered. Stop the server before running this command.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if backupPath == "" || archiveDir == "" || untilFlag == "" || dataPath == "" {
+				return errors.New("--backup, --wal-archive, --until and --data-path are required")
+			}
+			until, err := time.Parse(time.RFC3339, untilFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			cfg, err := loadMaintenanceConfig(configPath, dataPath)
+			if err != nil {
+				return err
+			}
+
+			report, registered, err := restorePITR(cfg, backupPath, archiveDir, until)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("restored %s into %s\n", backupPath, dataPath)
+			fmt.Printf("replayed %d segment(s): %d record(s) applied, %d after --until skipped\n",
+				report.Segments, report.Applied, report.Skipped)
+			if !report.Last.IsZero() {
+				fmt.Printf("last applied record: %s\n", report.Last.Format(time.RFC3339Nano))
+			}
+			indexes := make([]string, 0, len(report.Indexes))
+			for indexID := range report.Indexes {
+				indexes = append(indexes, string(indexID))
+			}
+			sort.Strings(indexes)
+			for _, indexID := range indexes {
+				fmt.Printf("  %s: %d\n", indexID, report.Indexes[core.IndexID(indexID)])
+			}
+			if len(registered) > 0 {
+				fmt.Printf("registered %d restored index(es) missing from the registry: %s\n",
+					len(registered), strings.Join(registered, ", "))
+			}
+			if report.Gaps > 0 {
+				fmt.Printf("⚠ the archive is missing %d record(s) or segment(s) before --until: writes between them may be lost\n", report.Gaps)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&configPath, "config", "", "Path to YAML config file")
+	cmd.Flags().StringVar(&dataPath, "data-path", "", "Directory to restore into; must be missing or empty")
+	cmd.Flags().StringVar(&backupPath, "backup", "", "Backup of the data directory (tar.gz)")
+	cmd.Flags().StringVar(&archiveDir, "wal-archive", "", "WAL archive directory")
+	cmd.Flags().StringVar(&untilFlag, "until", "", "Replay records appended up to this time (RFC3339)")
+	return cmd
+}
+
+// restorePITR unpacks backupPath into cfg.Storage.DataPath, which must be
+// missing or empty, and replays archiveDir up to until on top of it. The
+// backup carries the registry; with registry.enabled, indexes it does not
+// list, such as those the archive created after the backup, are
+// registered, and returned sorted.
+func restorePITR(cfg *core.Config, backupPath, archiveDir string, until time.Time) (persistence.WALArchiveReplayReport, []string, error) {
+	var report persistence.WALArchiveReplayReport
+	dataPath := cfg.Storage.DataPath
+	entries, err := os.ReadDir(dataPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return report, nil, fmt.Errorf("reading data path: %w", err)
+	}
+	if len(entries) > 0 {
+		return report, nil, fmt.Errorf("data path %s is not empty", dataPath)
+	}
+
+	taken, err := backupTime(backupPath)
+	if err != nil {
+		return report, nil, err
+	}
+	if until.Before(taken) {
+		return report, nil, fmt.Errorf("--until %s is earlier than the backup (%s)",
+			until.Format(time.RFC3339), taken.Format(time.RFC3339))
+	}
+	if err := extractBackup(backupPath, dataPath); err != nil {
+		return report, nil, err
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return report, nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+	report, err = store.ReplayWALArchive(archiveDir, until)
+	if err != nil {
+		return report, nil, fmt.Errorf("replay failed: %w", err)
+	}
+	if !cfg.Registry.Enabled {
+		return report, nil, nil
+	}
+	registered, err := registerRestored(dataPath, store.ListIndexes())
+	if err != nil {
+		return report, registered, fmt.Errorf("registering restored indexes: %w", err)
+	}
+	return report, registered, nil
+}
+
+// registerRestored adds a registry entry under dataPath for each of
+// indexes that has none, returning those it added, sorted.
+func registerRestored(dataPath string, indexes []core.IndexID) ([]string, error) {
+	reg, err := registry.NewStore(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	var registered []string
+	for _, indexID := range indexes {
+		_, created, err := reg.FindOrCreate(string(indexID), nil)
+		if err != nil {
+			return registered, err
+		}
+		if created {
+			registered = append(registered, string(indexID))
+		}
+	}
+	sort.Strings(registered)
+	return registered, nil
+}
+
+// walkBackup calls fn for each entry of a tar.gz backup.
+func walkBackup(path string, fn func(hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening backup: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("reading backup: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading backup: %w", err)
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// backupTime returns the latest modification time in a backup: the archive
+// holds every record appended after it.
+func backupTime(path string) (time.Time, error) {
+	var latest time.Time
+	err := walkBackup(path, func(hdr *tar.Header, _ io.Reader) error {
+		if hdr.Typeflag == tar.TypeReg && hdr.ModTime.After(latest) {
+			latest = hdr.ModTime
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// extractBackup unpacks the directories and regular files of a backup into
+// dir. Entries that would land outside dir are rejected.
+func extractBackup(path, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating data path: %w", err)
+	}
+	return walkBackup(path, func(hdr *tar.Header, r io.Reader) error {
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("backup entry %q is outside the data path", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			return os.MkdirAll(target, 0o755)
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, r); err != nil {
+				out.Close()
+				return err
+			}
+			return out.Close()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,61 @@
+package qubicdb_test
+
+import (
+	"errors"
+	"testing"
+
+	legacyapi "github.com/denizumutdereli/qubicdb/pkg/api"
+	legacyconcurrency "github.com/denizumutdereli/qubicdb/pkg/concurrency"
+	legacycore "github.com/denizumutdereli/qubicdb/pkg/core"
+	legacypersistence "github.com/denizumutdereli/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/api"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/lifecycle"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// TestLegacyPath builds a server from both paths at once: values made
+// through the legacy packages are the canonical types, and back.
+func TestLegacyPath(t *testing.T) {
+	dir := t.TempDir()
+	cfg := legacycore.DefaultConfig()
+	cfg.Storage.DataPath = dir
+
+	var store *persistence.Store
+	store, err := legacypersistence.NewStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pool *legacyconcurrency.WorkerPool = concurrency.NewWorkerPool(store, legacycore.MatrixBounds{
+		MinDimension: cfg.Matrix.MinDimension,
+		MaxDimension: cfg.Matrix.MaxDimension,
+		MaxNeurons:   cfg.Matrix.MaxNeurons,
+	})
+	reg, err := registry.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var server *api.Server = legacyapi.NewServer(cfg.Server.HTTPAddr, pool, lifecycle.NewManager(), reg, cfg)
+
+	worker, err := pool.GetOrCreate("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := worker.Submit(&legacyconcurrency.Operation{
+		Type:    legacyconcurrency.OpWrite,
+		Payload: concurrency.AddNeuronRequest{Content: "written through the legacy path"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n *legacycore.Neuron = result.(*core.Neuron)
+	if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpRead, Payload: legacycore.NeuronID("missing")}); !errors.Is(err, legacycore.ErrNeuronNotFound) {
+		t.Errorf("expected the legacy sentinel to match the canonical error, got %v", err)
+	}
+
+	if server == nil || n.Content != "written through the legacy path" {
+		t.Errorf("unexpected server %v or neuron %+v", server, n)
+	}
+}
@@ -0,0 +1,19 @@
+// Package qubicdb is the compatibility module for code that still imports
+// QubicDB under its old path, github.com/denizumutdereli/qubicdb. Its
+// packages forward to the canonical github.com/qubicDB/qubicdb packages:
+//
+//	github.com/denizumutdereli/qubicdb/pkg/core        -> github.com/qubicDB/qubicdb/pkg/core
+//	github.com/denizumutdereli/qubicdb/pkg/api         -> github.com/qubicDB/qubicdb/pkg/api
+//	github.com/denizumutdereli/qubicdb/pkg/concurrency -> github.com/qubicDB/qubicdb/pkg/concurrency
+//	github.com/denizumutdereli/qubicdb/pkg/persistence -> github.com/qubicDB/qubicdb/pkg/persistence
+//
+// Types are aliases, so values pass freely between code built against
+// either path. Functions are forwarded through variables of the same
+// type. Exported variables are copied when the program starts; set the
+// canonical variable to change one.
+//
+// The old path is kept for one release cycle. Switch imports to
+// github.com/qubicDB/qubicdb; nothing else changes.
+package qubicdb
+
+//go:generate go run ./internal/forwardgen
@@ -0,0 +1,137 @@
+// Command forwardgen writes forward.go for each shim package under
+// legacy/pkg: a type alias for every exported type of the canonical
+// package, and a forwarding declaration for every exported function,
+// constant and variable. Run it through go generate from the legacy
+// module root:
+//
+//	go generate ./...
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const canonicalModule = "github.com/qubicDB/qubicdb"
+
+// packages are the canonical packages the legacy path forwards.
+var packages = []string{"core", "api", "concurrency", "persistence"}
+
+// exports are the exported top-level names of a package, by kind.
+type exports struct {
+	types, funcs, consts, vars []string
+}
+
+func main() {
+	root, err := filepath.Abs("..")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range packages {
+		ex, err := collect(filepath.Join(root, "pkg", name))
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		src, err := render(name, ex)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join("pkg", name, "forward.go"), src, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// collect reads the exported top-level declarations of the package in dir.
+// Names declared once per platform are listed once.
+func collect(dir string) (exports, error) {
+	var ex exports
+	seen := make(map[string]bool)
+	add := func(list *[]string, name string) {
+		if ast.IsExported(name) && !seen[name] {
+			seen[name] = true
+			*list = append(*list, name)
+		}
+	}
+
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return ex, err
+	}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return ex, err
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv != nil || !ast.IsExported(d.Name.Name) {
+					continue
+				}
+				if d.Type.TypeParams != nil {
+					return ex, fmt.Errorf("generic function %s cannot be forwarded by a variable", d.Name.Name)
+				}
+				add(&ex.funcs, d.Name.Name)
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if s.TypeParams != nil && ast.IsExported(s.Name.Name) {
+							return ex, fmt.Errorf("generic type %s needs a parameterized alias", s.Name.Name)
+						}
+						add(&ex.types, s.Name.Name)
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							if d.Tok == token.CONST {
+								add(&ex.consts, n.Name)
+							} else {
+								add(&ex.vars, n.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, list := range [][]string{ex.types, ex.funcs, ex.consts, ex.vars} {
+		sort.Strings(list)
+	}
+	return ex, nil
+}
+
+// render formats the forward.go of the shim for package name.
+func render(name string, ex exports) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by forwardgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", name)
+	fmt.Fprintf(&b, "import %q\n", canonicalModule+"/pkg/"+name)
+	block := func(keyword string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s (\n", keyword)
+		for _, n := range names {
+			fmt.Fprintf(&b, "\t%s = %s.%s\n", n, name, n)
+		}
+		fmt.Fprintf(&b, ")\n")
+	}
+	block("type", ex.types)
+	block("const", ex.consts)
+	block("var", ex.funcs)
+	block("var", ex.vars)
+	return format.Source(b.Bytes())
+}
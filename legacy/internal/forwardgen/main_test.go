@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestForwardUpToDate fails when a canonical package gains or loses an
+// exported name that its shim does not forward yet; run go generate.
+func TestForwardUpToDate(t *testing.T) {
+	for _, name := range packages {
+		ex, err := collect(filepath.Join("..", "..", "..", "pkg", name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want, err := render(name, ex)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := os.ReadFile(filepath.Join("..", "..", "pkg", name, "forward.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("pkg/%s/forward.go is stale; run go generate in legacy/", name)
+		}
+	}
+}
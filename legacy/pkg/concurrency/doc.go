@@ -0,0 +1,6 @@
+// Package concurrency forwards to github.com/qubicDB/qubicdb/pkg/concurrency, for code
+// still importing the old module path. See forward.go for the forwarded
+// names; import the canonical package instead.
+//
+// Deprecated: use github.com/qubicDB/qubicdb/pkg/concurrency.
+package concurrency
@@ -0,0 +1,147 @@
+// Code generated by forwardgen. DO NOT EDIT.
+
+package concurrency
+
+import "github.com/qubicDB/qubicdb/pkg/concurrency"
+
+type (
+	ActivateRequest         = concurrency.ActivateRequest
+	AddNeuronRequest        = concurrency.AddNeuronRequest
+	AppendOnlyResolver      = concurrency.AppendOnlyResolver
+	BrainWorker             = concurrency.BrainWorker
+	CalibrateRequest        = concurrency.CalibrateRequest
+	CompactContentRequest   = concurrency.CompactContentRequest
+	DeadLetter              = concurrency.DeadLetter
+	DeadLetterOptions       = concurrency.DeadLetterOptions
+	DeadLetters             = concurrency.DeadLetters
+	DetectConflictsRequest  = concurrency.DetectConflictsRequest
+	Divergence              = concurrency.Divergence
+	DrainStatus             = concurrency.DrainStatus
+	EvictionReport          = concurrency.EvictionReport
+	ExpandQueryRequest      = concurrency.ExpandQueryRequest
+	ExportSliceRequest      = concurrency.ExportSliceRequest
+	Focus                   = concurrency.Focus
+	ForgetByMetadataRequest = concurrency.ForgetByMetadataRequest
+	GraphSummaryRequest     = concurrency.GraphSummaryRequest
+	HealthRequest           = concurrency.HealthRequest
+	HistoryResult           = concurrency.HistoryResult
+	ImportNotesRequest      = concurrency.ImportNotesRequest
+	ImportSliceRequest      = concurrency.ImportSliceRequest
+	IndexMemory             = concurrency.IndexMemory
+	Journal                 = concurrency.Journal
+	JournalOptions          = concurrency.JournalOptions
+	JournalRecord           = concurrency.JournalRecord
+	ListNeuronsRequest      = concurrency.ListNeuronsRequest
+	MatrixLoader            = concurrency.MatrixLoader
+	MetadataKeysResolver    = concurrency.MetadataKeysResolver
+	MigrateTurnsRequest     = concurrency.MigrateTurnsRequest
+	Mutation                = concurrency.Mutation
+	MutationKind            = concurrency.MutationKind
+	MutationObserver        = concurrency.MutationObserver
+	OpType                  = concurrency.OpType
+	Operation               = concurrency.Operation
+	PanicError              = concurrency.PanicError
+	PinRequest              = concurrency.PinRequest
+	PrefetchResult          = concurrency.PrefetchResult
+	PrefetchStatus          = concurrency.PrefetchStatus
+	Priority                = concurrency.Priority
+	PromotionsRequest       = concurrency.PromotionsRequest
+	PurgeRequest            = concurrency.PurgeRequest
+	Quarantine              = concurrency.Quarantine
+	QuarantineError         = concurrency.QuarantineError
+	QuotaResolver           = concurrency.QuotaResolver
+	QuotaSettings           = concurrency.QuotaSettings
+	RetentionRequest        = concurrency.RetentionRequest
+	SampleRequest           = concurrency.SampleRequest
+	SearchRequest           = concurrency.SearchRequest
+	SentimentResolver       = concurrency.SentimentResolver
+	SentimentSettings       = concurrency.SentimentSettings
+	SimilarityResolver      = concurrency.SimilarityResolver
+	SyncRequest             = concurrency.SyncRequest
+	UpdateNeuronRequest     = concurrency.UpdateNeuronRequest
+	VectorResolver          = concurrency.VectorResolver
+	VectorSettings          = concurrency.VectorSettings
+	VerifyDiff              = concurrency.VerifyDiff
+	VerifyReport            = concurrency.VerifyReport
+	WorkerPool              = concurrency.WorkerPool
+	WriteBatchRequest       = concurrency.WriteBatchRequest
+	WriteBatchResult        = concurrency.WriteBatchResult
+)
+
+const (
+	DefaultBackgroundSlice = concurrency.DefaultBackgroundSlice
+	DefaultReadConcurrency = concurrency.DefaultReadConcurrency
+	KeepDisk               = concurrency.KeepDisk
+	KeepMemory             = concurrency.KeepMemory
+	MutationForget         = concurrency.MutationForget
+	MutationPurge          = concurrency.MutationPurge
+	MutationReset          = concurrency.MutationReset
+	MutationRestore        = concurrency.MutationRestore
+	MutationWrite          = concurrency.MutationWrite
+	OpActivate             = concurrency.OpActivate
+	OpCalibrate            = concurrency.OpCalibrate
+	OpChainIssues          = concurrency.OpChainIssues
+	OpCompactContent       = concurrency.OpCompactContent
+	OpConsolidate          = concurrency.OpConsolidate
+	OpDecay                = concurrency.OpDecay
+	OpDetectConflicts      = concurrency.OpDetectConflicts
+	OpEmbedPending         = concurrency.OpEmbedPending
+	OpExpandQuery          = concurrency.OpExpandQuery
+	OpExportSlice          = concurrency.OpExportSlice
+	OpFire                 = concurrency.OpFire
+	OpForget               = concurrency.OpForget
+	OpForgetByMetadata     = concurrency.OpForgetByMetadata
+	OpGetActivity          = concurrency.OpGetActivity
+	OpGetGraph             = concurrency.OpGetGraph
+	OpGetStats             = concurrency.OpGetStats
+	OpGetSynapses          = concurrency.OpGetSynapses
+	OpGraphStats           = concurrency.OpGraphStats
+	OpGraphSummary         = concurrency.OpGraphSummary
+	OpHealth               = concurrency.OpHealth
+	OpHistory              = concurrency.OpHistory
+	OpImportNotes          = concurrency.OpImportNotes
+	OpImportSlice          = concurrency.OpImportSlice
+	OpListConflicts        = concurrency.OpListConflicts
+	OpListPins             = concurrency.OpListPins
+	OpListPromotions       = concurrency.OpListPromotions
+	OpListRecycled         = concurrency.OpListRecycled
+	OpMigrateTurns         = concurrency.OpMigrateTurns
+	OpPin                  = concurrency.OpPin
+	OpPrune                = concurrency.OpPrune
+	OpPrunePlan            = concurrency.OpPrunePlan
+	OpPurge                = concurrency.OpPurge
+	OpRead                 = concurrency.OpRead
+	OpRecall               = concurrency.OpRecall
+	OpReorg                = concurrency.OpReorg
+	OpRestore              = concurrency.OpRestore
+	OpRetention            = concurrency.OpRetention
+	OpSample               = concurrency.OpSample
+	OpSearch               = concurrency.OpSearch
+	OpShutdown             = concurrency.OpShutdown
+	OpSnapshot             = concurrency.OpSnapshot
+	OpSync                 = concurrency.OpSync
+	OpTouch                = concurrency.OpTouch
+	OpWrite                = concurrency.OpWrite
+	OpWriteBatch           = concurrency.OpWriteBatch
+	PrefetchAlreadyLoaded  = concurrency.PrefetchAlreadyLoaded
+	PrefetchLoading        = concurrency.PrefetchLoading
+	PrefetchQueued         = concurrency.PrefetchQueued
+	PrefetchReasonBudget   = concurrency.PrefetchReasonBudget
+	PrefetchReasonDraining = concurrency.PrefetchReasonDraining
+	PrefetchReasonNotFound = concurrency.PrefetchReasonNotFound
+	PrefetchRejected       = concurrency.PrefetchRejected
+	PriorityBackground     = concurrency.PriorityBackground
+	PriorityInteractive    = concurrency.PriorityInteractive
+)
+
+var (
+	NewBrainWorker = concurrency.NewBrainWorker
+	NewDeadLetters = concurrency.NewDeadLetters
+	NewJournal     = concurrency.NewJournal
+	NewWorkerPool  = concurrency.NewWorkerPool
+)
+
+var (
+	ErrNotDiverged    = concurrency.ErrNotDiverged
+	ErrOperationPanic = concurrency.ErrOperationPanic
+)
@@ -0,0 +1,6 @@
+// Package core forwards to github.com/qubicDB/qubicdb/pkg/core, for code
+// still importing the old module path. See forward.go for the forwarded
+// names; import the canonical package instead.
+//
+// Deprecated: use github.com/qubicDB/qubicdb/pkg/core.
+package core
@@ -0,0 +1,237 @@
+// Code generated by forwardgen. DO NOT EDIT.
+
+package core
+
+import "github.com/qubicDB/qubicdb/pkg/core"
+
+type (
+	ActivityState          = core.ActivityState
+	AdaptiveScheduleConfig = core.AdaptiveScheduleConfig
+	AdminConfig            = core.AdminConfig
+	AdminUser              = core.AdminUser
+	Attachment             = core.Attachment
+	AuthThrottleConfig     = core.AuthThrottleConfig
+	BM25Config             = core.BM25Config
+	BlockTemplate          = core.BlockTemplate
+	BootstrapResult        = core.BootstrapResult
+	BrainState             = core.BrainState
+	CLIOverrides           = core.CLIOverrides
+	CalibrationConfig      = core.CalibrationConfig
+	CloneConfig            = core.CloneConfig
+	CompactContentConfig   = core.CompactContentConfig
+	ConcurrencyConfig      = core.ConcurrencyConfig
+	Config                 = core.Config
+	ConflictConfig         = core.ConflictConfig
+	ConnInfo               = core.ConnInfo
+	ConsolidateConfig      = core.ConsolidateConfig
+	ContextConfig          = core.ContextConfig
+	DaemonConfig           = core.DaemonConfig
+	DeadLetterConfig       = core.DeadLetterConfig
+	DecayConfig            = core.DecayConfig
+	ExpansionConfig        = core.ExpansionConfig
+	HealthConfig           = core.HealthConfig
+	HealthWeights          = core.HealthWeights
+	ImportConfig           = core.ImportConfig
+	IndexID                = core.IndexID
+	IndexQuota             = core.IndexQuota
+	JournalConfig          = core.JournalConfig
+	LexicalConfig          = core.LexicalConfig
+	LifecycleConfig        = core.LifecycleConfig
+	MCPConfig              = core.MCPConfig
+	Matrix                 = core.Matrix
+	MatrixBounds           = core.MatrixBounds
+	MatrixConfig           = core.MatrixConfig
+	MetadataIndex          = core.MetadataIndex
+	MetadataIndexStats     = core.MetadataIndexStats
+	MetadataKeyStats       = core.MetadataKeyStats
+	MigrationConfig        = core.MigrationConfig
+	Neuron                 = core.Neuron
+	NeuronID               = core.NeuronID
+	PinsConfig             = core.PinsConfig
+	PrefetchConfig         = core.PrefetchConfig
+	Promotion              = core.Promotion
+	PruneConfig            = core.PruneConfig
+	QuotaError             = core.QuotaError
+	RecycledNeuron         = core.RecycledNeuron
+	RegistryConfig         = core.RegistryConfig
+	ReinforceConfig        = core.ReinforceConfig
+	ReplicationConfig      = core.ReplicationConfig
+	RetentionConfig        = core.RetentionConfig
+	RetentionRule          = core.RetentionRule
+	SearchConfig           = core.SearchConfig
+	SearchTelemetryConfig  = core.SearchTelemetryConfig
+	SecurityConfig         = core.SecurityConfig
+	SeedConfig             = core.SeedConfig
+	SentimentConfig        = core.SentimentConfig
+	ServerConfig           = core.ServerConfig
+	SessionsConfig         = core.SessionsConfig
+	ShadowConfig           = core.ShadowConfig
+	SharesConfig           = core.SharesConfig
+	StandbyConfig          = core.StandbyConfig
+	StatsConfig            = core.StatsConfig
+	StorageConfig          = core.StorageConfig
+	SubscriptionsConfig    = core.SubscriptionsConfig
+	Synapse                = core.Synapse
+	SynapseID              = core.SynapseID
+	SynapseScoreConfig     = core.SynapseScoreConfig
+	SyncConfig             = core.SyncConfig
+	Task                   = core.Task
+	TaskError              = core.TaskError
+	TaskRunner             = core.TaskRunner
+	TaskStatus             = core.TaskStatus
+	TelemetryConfig        = core.TelemetryConfig
+	TermStats              = core.TermStats
+	Tombstone              = core.Tombstone
+	TurnTemplate           = core.TurnTemplate
+	VectorConfig           = core.VectorConfig
+	VectorModelConfig      = core.VectorModelConfig
+	WALArchiveConfig       = core.WALArchiveConfig
+	WorkerConfig           = core.WorkerConfig
+	WriteConfig            = core.WriteConfig
+)
+
+const (
+	AdminPasswordFile            = core.AdminPasswordFile
+	BlockAttrsPlaceholder        = core.BlockAttrsPlaceholder
+	BlockTextPlaceholder         = core.BlockTextPlaceholder
+	CloneContentHash             = core.CloneContentHash
+	CloneContentRedact           = core.CloneContentRedact
+	CompactedKey                 = core.CompactedKey
+	CompactedOrigLenKey          = core.CompactedOrigLenKey
+	ContextFormatBlocks          = core.ContextFormatBlocks
+	ContextFormatMessages        = core.ContextFormatMessages
+	ContextFormatText            = core.ContextFormatText
+	DanglingDrop                 = core.DanglingDrop
+	DanglingKeep                 = core.DanglingKeep
+	DefaultAdminPassword         = core.DefaultAdminPassword
+	DefaultBlockTemplate         = core.DefaultBlockTemplate
+	DefaultEmbeddingModel        = core.DefaultEmbeddingModel
+	DefaultMaxContentLineLength  = core.DefaultMaxContentLineLength
+	DefaultMaxNeuronContentBytes = core.DefaultMaxNeuronContentBytes
+	DefaultTurnPattern           = core.DefaultTurnPattern
+	DefaultTurnTemplate          = core.DefaultTurnTemplate
+	DefaultVectorEnabled         = core.DefaultVectorEnabled
+	DefaultVectorModelPath       = core.DefaultVectorModelPath
+	MaxExpansionSeeds            = core.MaxExpansionSeeds
+	MaxNeuronContentBytes        = core.MaxNeuronContentBytes
+	MaxSynapseTypeLength         = core.MaxSynapseTypeLength
+	PromotionMature              = core.PromotionMature
+	PromotionPinned              = core.PromotionPinned
+	QuotaEvictLowestEnergy       = core.QuotaEvictLowestEnergy
+	QuotaReject                  = core.QuotaReject
+	RedactedContent              = core.RedactedContent
+	RetentionAnonymize           = core.RetentionAnonymize
+	RetentionDelete              = core.RetentionDelete
+	RetentionDrain               = core.RetentionDrain
+	RoleAdmin                    = core.RoleAdmin
+	RoleOperator                 = core.RoleOperator
+	RoleViewer                   = core.RoleViewer
+	SecretMask                   = core.SecretMask
+	StarterConfigFile            = core.StarterConfigFile
+	StateActive                  = core.StateActive
+	StateDormant                 = core.StateDormant
+	StateIdle                    = core.StateIdle
+	StateSleeping                = core.StateSleeping
+	SynapseAssociative           = core.SynapseAssociative
+	SynapseCoRetrieved           = core.SynapseCoRetrieved
+	SynapseFollows               = core.SynapseFollows
+	SynapseSimilar               = core.SynapseSimilar
+	TaskRestarting               = core.TaskRestarting
+	TaskRunning                  = core.TaskRunning
+	TaskStopped                  = core.TaskStopped
+	TraceIndexHash               = core.TraceIndexHash
+	TraceIndexOmit               = core.TraceIndexOmit
+	TraceIndexRaw                = core.TraceIndexRaw
+	TurnLangPlaceholder          = core.TurnLangPlaceholder
+	TurnRolePlaceholder          = core.TurnRolePlaceholder
+	TurnTextPlaceholder          = core.TurnTextPlaceholder
+)
+
+var (
+	AnonymizeNeuron             = core.AnonymizeNeuron
+	Bootstrap                   = core.Bootstrap
+	CheckContentEncoding        = core.CheckContentEncoding
+	CompileTurnPattern          = core.CompileTurnPattern
+	ConfigFromEnv               = core.ConfigFromEnv
+	ConfigFromFile              = core.ConfigFromFile
+	ContentSanitizationEnabled  = core.ContentSanitizationEnabled
+	DefaultAdaptiveSchedule     = core.DefaultAdaptiveSchedule
+	DefaultBounds               = core.DefaultBounds
+	DefaultConfig               = core.DefaultConfig
+	GetMaxContentLineLength     = core.GetMaxContentLineLength
+	GetMaxNeuronContentBytes    = core.GetMaxNeuronContentBytes
+	HashContent                 = core.HashContent
+	InsecureDefaults            = core.InsecureDefaults
+	IsCompacted                 = core.IsCompacted
+	IsContextFormat             = core.IsContextFormat
+	IsFirstRun                  = core.IsFirstRun
+	IsLanguageTag               = core.IsLanguageTag
+	IsQuotaPolicy               = core.IsQuotaPolicy
+	LoadConfig                  = core.LoadConfig
+	MaskSecret                  = core.MaskSecret
+	MaskURL                     = core.MaskURL
+	NeuronFootprint             = core.NeuronFootprint
+	NewBrainState               = core.NewBrainState
+	NewMatrix                   = core.NewMatrix
+	NewMetadataIndex            = core.NewMetadataIndex
+	NewNeuron                   = core.NewNeuron
+	NewNeuronID                 = core.NewNeuronID
+	NewSynapse                  = core.NewSynapse
+	NewSynapseID                = core.NewSynapseID
+	NewTaskRunner               = core.NewTaskRunner
+	NewTermStats                = core.NewTermStats
+	NewTypedSynapse             = core.NewTypedSynapse
+	NormalizeMetadataKeys       = core.NormalizeMetadataKeys
+	NormalizeNeuronContent      = core.NormalizeNeuronContent
+	ParseBlockTemplate          = core.ParseBlockTemplate
+	ParseConnString             = core.ParseConnString
+	ParseRetentionAge           = core.ParseRetentionAge
+	ParseTrustedProxy           = core.ParseTrustedProxy
+	ParseTurnTemplate           = core.ParseTurnTemplate
+	PrintBanner                 = core.PrintBanner
+	SanitizeContent             = core.SanitizeContent
+	SetContentSanitization      = core.SetContentSanitization
+	SetMaxContentLineLength     = core.SetMaxContentLineLength
+	SetMaxNeuronContentBytes    = core.SetMaxNeuronContentBytes
+	StarterConfigPath           = core.StarterConfigPath
+	SynapseFootprint            = core.SynapseFootprint
+	SynapseTypeIn               = core.SynapseTypeIn
+	TimeSince                   = core.TimeSince
+	TruncateContent             = core.TruncateContent
+	ValidSynapseType            = core.ValidSynapseType
+	ValidateIndexedMetadataKeys = core.ValidateIndexedMetadataKeys
+	ValidateNeuronContent       = core.ValidateNeuronContent
+	ValidateRetentionRules      = core.ValidateRetentionRules
+	WaitForShutdown             = core.WaitForShutdown
+)
+
+var (
+	ErrAppendOnly        = core.ErrAppendOnly
+	ErrBrainNotActive    = core.ErrBrainNotActive
+	ErrBrainSleeping     = core.ErrBrainSleeping
+	ErrContentTooLarge   = core.ErrContentTooLarge
+	ErrDimensionLimit    = core.ErrDimensionLimit
+	ErrDraining          = core.ErrDraining
+	ErrDuplicateNeuron   = core.ErrDuplicateNeuron
+	ErrIndexDiverged     = core.ErrIndexDiverged
+	ErrIndexLoading      = core.ErrIndexLoading
+	ErrIndexNotEmpty     = core.ErrIndexNotEmpty
+	ErrIndexQuarantined  = core.ErrIndexQuarantined
+	ErrInvalidContent    = core.ErrInvalidContent
+	ErrInvalidEncoding   = core.ErrInvalidEncoding
+	ErrInvalidQuery      = core.ErrInvalidQuery
+	ErrInvalidQuota      = core.ErrInvalidQuota
+	ErrInvalidRetention  = core.ErrInvalidRetention
+	ErrLoadFailed        = core.ErrLoadFailed
+	ErrMatrixFull        = core.ErrMatrixFull
+	ErrMatrixNotFound    = core.ErrMatrixNotFound
+	ErrNeuronNotFound    = core.ErrNeuronNotFound
+	ErrPersistenceFailed = core.ErrPersistenceFailed
+	ErrPinLimit          = core.ErrPinLimit
+	ErrQuotaExceeded     = core.ErrQuotaExceeded
+	ErrSelfLink          = core.ErrSelfLink
+	ErrSupersedeConflict = core.ErrSupersedeConflict
+	ErrSupersedeCycle    = core.ErrSupersedeCycle
+	ErrSynapseNotFound   = core.ErrSynapseNotFound
+	ErrUserNotFound      = core.ErrUserNotFound
+)
@@ -0,0 +1,6 @@
+// Package persistence forwards to github.com/qubicDB/qubicdb/pkg/persistence, for code
+// still importing the old module path. See forward.go for the forwarded
+// names; import the canonical package instead.
+//
+// Deprecated: use github.com/qubicDB/qubicdb/pkg/persistence.
+package persistence
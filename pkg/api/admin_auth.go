@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/auth"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// adminRole is an admin privilege level; higher values include lower ones.
+type adminRole int
+
+const (
+	roleNone adminRole = iota
+	roleViewer
+	roleOperator
+	roleAdmin
+)
+
+// parseAdminRole maps a configured role name to its privilege level.
+func parseAdminRole(name string) adminRole {
+	switch name {
+	case core.RoleViewer:
+		return roleViewer
+	case core.RoleOperator:
+		return roleOperator
+	case core.RoleAdmin:
+		return roleAdmin
+	}
+	return roleNone
+}
+
+func (r adminRole) String() string {
+	switch r {
+	case roleViewer:
+		return core.RoleViewer
+	case roleOperator:
+		return core.RoleOperator
+	case roleAdmin:
+		return core.RoleAdmin
+	}
+	return "none"
+}
+
+// rolePolicy returns the minimum role needed to serve a request.
+type rolePolicy func(r *http.Request) adminRole
+
+// readOr lets viewers GET (and HEAD) a route and requires min for
+// everything else.
+func readOr(min adminRole) rolePolicy {
+	return func(r *http.Request) adminRole {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return roleViewer
+		}
+		return min
+	}
+}
+
+// indexOpsPolicy grades /admin/indexes/{id}/... actions: reads are open to
+// viewers, wake/sleep and verify to operators, and anything that writes or
+// removes data (reset, seed, clone, DELETE) needs admin.
+func indexOpsPolicy(r *http.Request) adminRole {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return roleViewer
+	}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/wake"), strings.HasSuffix(r.URL.Path, "/sleep"), strings.HasSuffix(r.URL.Path, "/verify"):
+		return roleOperator
+	}
+	return roleAdmin
+}
+
+// requireRole wraps a handler with admin verification and rejects
+// authenticated users whose role is below what policy demands. The client
+// must send an Authorization header: Basic base64(user:password), or
+// Bearer with a session token from /admin/login.
+func (s *Server) requireRole(policy rolePolicy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := auth.BearerToken(r); err == nil {
+			sess, ok := s.adminSession(w, r)
+			if !ok {
+				return
+			}
+			role := parseAdminRole(sess.Role)
+			if need := policy(r); role < need {
+				apierr.Forbidden(w, "role "+role.String()+" cannot perform this operation; requires "+need.String())
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		cred, err := s.adminVerifier().Basic(r)
+		if errors.Is(err, auth.ErrMissing) || errors.Is(err, auth.ErrMalformed) {
+			auth.Challenge(w, "qubicdb admin")
+			apierr.Unauthorized(w, "admin authentication required")
+			return
+		}
+		if !s.writeLocked(w, err) {
+			return
+		}
+		role := parseAdminRole(cred.Role)
+		if err != nil || role == roleNone {
+			apierr.Unauthorized(w, "invalid admin credentials")
+			return
+		}
+		if need := policy(r); role < need {
+			apierr.Forbidden(w, "role "+role.String()+" cannot perform this operation; requires "+need.String())
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// adminSession verifies the Bearer session token of r, writing a 401 and
+// returning false when it is missing, forged, expired or logged out.
+func (s *Server) adminSession(w http.ResponseWriter, r *http.Request) (auth.Session, bool) {
+	token, err := auth.BearerToken(r)
+	if err != nil || s.adminSessions == nil {
+		auth.BearerChallenge(w, "qubicdb admin")
+		apierr.Unauthorized(w, "admin session token required")
+		return auth.Session{}, false
+	}
+	now := time.Now()
+	sess, err := s.adminSessions.Verify(token, now)
+	if err == nil && s.adminDenylist.Revoked(sess, now) {
+		err = auth.ErrExpired
+	}
+	switch {
+	case errors.Is(err, auth.ErrExpired):
+		auth.BearerChallenge(w, "qubicdb admin")
+		apierr.Unauthorized(w, "admin session expired")
+		return auth.Session{}, false
+	case err != nil:
+		auth.BearerChallenge(w, "qubicdb admin")
+		apierr.Unauthorized(w, "invalid admin session token")
+		return auth.Session{}, false
+	}
+	return sess, true
+}
+
+// adminCredential resolves a user name to its admin credential. Users in
+// admin.users are checked against their bcrypt hash; any other name is
+// checked against the legacy admin.user/admin.password pair, which maps to
+// the admin role.
+func (s *Server) adminCredential(user string) (auth.Credential, bool) {
+	for _, u := range s.config.Admin.Users {
+		if u.User == user {
+			c := auth.Hashed(u.User, u.PasswordHash)
+			c.Role = u.Role
+			return c, true
+		}
+	}
+	if s.config.Admin.User == "" || s.config.Admin.Password == "" {
+		return auth.Credential{}, false
+	}
+	c := auth.Plain(s.config.Admin.User, s.config.Admin.Password)
+	c.Role = core.RoleAdmin
+	return c, true
+}
+
+// adminVerifier checks admin credentials behind the auth throttle, when
+// one is configured. Once admin.users is set every attempt costs a bcrypt
+// comparison, so timing does not reveal which user names exist.
+func (s *Server) adminVerifier() auth.Verifier {
+	v := auth.Verifier{
+		Store:        auth.StoreFunc(s.adminCredential),
+		AlwaysBcrypt: len(s.config.Admin.Users) > 0,
+	}
+	if s.authThrottle != nil {
+		v.Throttle = throttleHook{s}
+	}
+	return v
+}
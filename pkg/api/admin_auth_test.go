@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func newRBACTestServer(t *testing.T) *Server {
+	t.Helper()
+	hash := func(pass string) string {
+		h, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("bcrypt: %v", err)
+		}
+		return string(h)
+	}
+	return newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "root"
+		cfg.Admin.Password = "legacy-secret"
+		cfg.Admin.Users = []core.AdminUser{
+			{User: "vera", PasswordHash: hash("viewer-pass"), Role: core.RoleViewer},
+			{User: "otto", PasswordHash: hash("operator-pass"), Role: core.RoleOperator},
+			{User: "ada", PasswordHash: hash("admin-pass"), Role: core.RoleAdmin},
+		}
+	})
+}
+
+func TestAdminRBAC_RoleEndpointMatrix(t *testing.T) {
+	s := newRBACTestServer(t)
+
+	users := []struct {
+		user, pass string
+		role       adminRole
+	}{
+		{"vera", "viewer-pass", roleViewer},
+		{"otto", "operator-pass", roleOperator},
+		{"ada", "admin-pass", roleAdmin},
+		{"root", "legacy-secret", roleAdmin},
+	}
+	endpoints := []struct {
+		method, path string
+		min          adminRole
+	}{
+		{"GET", "/admin/indexes", roleViewer},
+		{"GET", "/admin/indexes/rbac-idx/export", roleViewer},
+		{"GET", "/admin/indexes/rbac-idx/calibration", roleViewer},
+		{"POST", "/admin/indexes/rbac-idx/calibrate", roleAdmin},
+		{"POST", "/admin/indexes/rbac-idx/import", roleAdmin},
+		{"GET", "/v1/config", roleViewer},
+		{"GET", "/admin/config", roleViewer},
+		{"GET", "/admin/daemons", roleViewer},
+		{"GET", "/admin/memory", roleViewer},
+		{"GET", "/admin/info", roleViewer},
+		{"GET", "/admin/drain", roleViewer},
+		{"GET", "/admin/migrate-storage", roleViewer},
+		{"GET", "/admin/retention/history", roleViewer},
+		{"GET", "/admin/consistency", roleViewer},
+		{"POST", "/admin/persist", roleOperator},
+		{"POST", "/admin/gc", roleOperator},
+		{"POST", "/admin/daemons/pause", roleOperator},
+		{"POST", "/admin/indexes/rbac-idx/wake", roleOperator},
+		{"POST", "/admin/indexes/rbac-idx/sleep", roleOperator},
+		{"POST", "/admin/indexes/rbac-idx/reset", roleAdmin},
+		{"POST", "/admin/indexes/rbac-idx/seed", roleAdmin},
+		{"POST", "/admin/indexes/rbac-idx/clone", roleAdmin},
+		{"DELETE", "/admin/indexes/rbac-idx", roleAdmin},
+		{"POST", "/v1/config", roleAdmin},
+		{"POST", "/admin/consistency/repair", roleAdmin},
+		{"DELETE", "/admin/retention/policies/rbac-idx", roleAdmin},
+	}
+
+	for _, u := range users {
+		for _, ep := range endpoints {
+			rr := doRequest(t, s, ep.method, ep.path, "{}", map[string]string{
+				"Authorization": adminAuthHeader(u.user, u.pass),
+				"Content-Type":  "application/json",
+			})
+			if u.role >= ep.min {
+				if rr.Code == http.StatusUnauthorized || rr.Code == http.StatusForbidden {
+					t.Errorf("%s: %s %s should be allowed, got %d: %s", u.user, ep.method, ep.path, rr.Code, rr.Body.String())
+				}
+				continue
+			}
+			if rr.Code != http.StatusForbidden {
+				t.Errorf("%s: %s %s should be forbidden, got %d", u.user, ep.method, ep.path, rr.Code)
+				continue
+			}
+			if m := decodeJSON(t, rr); m["code"] != "FORBIDDEN" {
+				t.Errorf("%s: %s %s: expected FORBIDDEN code, got %v", u.user, ep.method, ep.path, m["code"])
+			}
+		}
+	}
+}
+
+func TestAdminRBAC_WrongPasswordForHashedUser(t *testing.T) {
+	s := newRBACTestServer(t)
+
+	rr := doRequest(t, s, "GET", "/admin/indexes", "", map[string]string{
+		"Authorization": adminAuthHeader("ada", "viewer-pass"),
+	})
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for wrong password, got %d", rr.Code)
+	}
+}
+
+func TestAdminRBAC_LoginReturnsRole(t *testing.T) {
+	s := newRBACTestServer(t)
+
+	cases := map[string]string{
+		`{"user":"vera","password":"viewer-pass"}`:   "viewer",
+		`{"user":"otto","password":"operator-pass"}`: "operator",
+		`{"user":"root","password":"legacy-secret"}`: "admin",
+	}
+	for body, want := range cases {
+		rr := doRequest(t, s, "POST", "/admin/login", body, map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("login %s: expected 200, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+		if m := decodeJSON(t, rr); m["role"] != want {
+			t.Errorf("login %s: expected role %q, got %v", body, want, m["role"])
+		}
+	}
+
+	rr := doRequest(t, s, "POST", "/admin/login", `{"user":"vera","password":"nope"}`, map[string]string{"Content-Type": "application/json"})
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for bad login, got %d", rr.Code)
+	}
+}
+
+func TestAdminRBAC_HashedUsersOnly(t *testing.T) {
+	h, _ := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = ""
+		cfg.Admin.Password = ""
+		cfg.Admin.Users = []core.AdminUser{{User: "ada", PasswordHash: string(h), Role: core.RoleAdmin}}
+	})
+
+	rr := doRequest(t, s, "GET", "/admin/indexes", "", map[string]string{
+		"Authorization": adminAuthHeader("", ""),
+	})
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("empty legacy credentials must not authenticate, got %d", rr.Code)
+	}
+	rr = doRequest(t, s, "GET", "/admin/indexes", "", map[string]string{
+		"Authorization": adminAuthHeader("ada", "pw"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 for hashed user, got %d", rr.Code)
+	}
+}
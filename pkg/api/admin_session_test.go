@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func adminLogin(t *testing.T, s *Server, user, pass string) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/admin/login", `{"user":"`+user+`","password":"`+pass+`"}`, map[string]string{"Content-Type": "application/json"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login %s: expected 200, got %d: %s", user, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func TestAdminSession_LoginTokenAuthorizes(t *testing.T) {
+	s := newRBACTestServer(t)
+
+	m := adminLogin(t, s, "otto", "operator-pass")
+	token, _ := m["token"].(string)
+	if token == "" {
+		t.Fatalf("login should return a token: %v", m)
+	}
+	expires, err := time.Parse(time.RFC3339, m["expiresAt"].(string))
+	if err != nil || expires.Sub(time.Now()) > time.Hour || expires.Before(time.Now()) {
+		t.Errorf("expected an expiry within the default hour, got %v", m["expiresAt"])
+	}
+
+	if rr := doRequest(t, s, "GET", "/admin/info", "", bearer(token)); rr.Code != http.StatusOK {
+		t.Errorf("token should authorize reads, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "POST", "/admin/persist", "", bearer(token)); rr.Code != http.StatusOK {
+		t.Errorf("an operator token should persist, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "POST", "/admin/indexes/tok-idx/reset", "", bearer(token)); rr.Code != http.StatusForbidden {
+		t.Errorf("an operator token should not reset, got %d", rr.Code)
+	}
+}
+
+func TestAdminSession_RefusesExpiredAndTamperedTokens(t *testing.T) {
+	s := newRBACTestServer(t)
+
+	expired, _, err := s.adminSessions.Issue("ada", "admin", time.Now().Add(-2*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := doRequest(t, s, "GET", "/admin/info", "", bearer(expired))
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "expired") {
+		t.Errorf("expected 401 for an expired token, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Bearer") {
+		t.Errorf("expected a Bearer challenge, got %q", got)
+	}
+
+	token := adminLogin(t, s, "vera", "viewer-pass")["token"].(string)
+	body, sig, _ := strings.Cut(token, ".")
+	for name, tampered := range map[string]string{
+		"body":      body[:len(body)-2] + "AA." + sig,
+		"signature": body + "." + strings.Repeat("A", len(sig)),
+		"garbage":   "not-a-token",
+	} {
+		if rr := doRequest(t, s, "GET", "/admin/info", "", bearer(tampered)); rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401 for a tampered token, got %d", name, rr.Code)
+		}
+	}
+}
+
+func TestAdminSession_LogoutRevokes(t *testing.T) {
+	s := newRBACTestServer(t)
+	token := adminLogin(t, s, "ada", "admin-pass")["token"].(string)
+	other := adminLogin(t, s, "ada", "admin-pass")["token"].(string)
+
+	if rr := doRequest(t, s, "POST", "/admin/logout", "", nil); rr.Code != http.StatusUnauthorized {
+		t.Errorf("logout without a token should be refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/admin/logout", "", bearer(token)); rr.Code != http.StatusOK {
+		t.Fatalf("logout: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "GET", "/admin/info", "", bearer(token)); rr.Code != http.StatusUnauthorized {
+		t.Errorf("a logged-out token should be refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/admin/logout", "", bearer(token)); rr.Code != http.StatusUnauthorized {
+		t.Errorf("logging out twice should be refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "GET", "/admin/info", "", bearer(other)); rr.Code != http.StatusOK {
+		t.Errorf("other sessions should survive a logout, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "GET", "/admin/info", "", map[string]string{"Authorization": adminAuthHeader("ada", "admin-pass")}); rr.Code != http.StatusOK {
+		t.Errorf("Basic Auth should keep working, got %d", rr.Code)
+	}
+}
+
+func TestAdminSession_KeyPerServerWithoutSecret(t *testing.T) {
+	a := newRBACTestServer(t)
+	token := adminLogin(t, a, "ada", "admin-pass")["token"].(string)
+	b := newRBACTestServer(t)
+	if rr := doRequest(t, b, "GET", "/admin/info", "", bearer(token)); rr.Code != http.StatusUnauthorized {
+		t.Errorf("without admin.sessionSecret each server should use its own key, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestSearchAnchored(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	headers := map[string]string{"X-Index-ID": "anchored"}
+	write := func(body string) string {
+		rr := doRequest(t, s, "POST", "/v1/write", body, headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+		return decodeJSON(t, rr)["id"].(string)
+	}
+	anchor := write(`{"content":"payments outage postmortem","metadata":{"incident":"INC-42"}}`)
+	related := write(`{"content":"rolled back the ledger migration","metadata":{"incident":"INC-42"}}`)
+	write(`{"content":"office wifi was flaky"}`)
+
+	doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/search?anchor_id="+anchor, "", headers))
+	results, _ := doc["results"].([]any)
+	if len(results) == 0 || results[0].(map[string]any)["id"] != related {
+		t.Fatalf("an anchor without a query should rank its related neurons first: %v", doc)
+	}
+	for _, r := range results {
+		if r.(map[string]any)["id"] == anchor {
+			t.Error("the anchor should be left out of its own results")
+		}
+	}
+	if doc["anchorId"] != anchor {
+		t.Errorf("the response should echo the anchor: %v", doc)
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/search", `{"anchor_id":"missing"}`, headers)
+	if rr.Code != http.StatusNotFound || decodeJSON(t, rr)["code"] != "NEURON_NOT_FOUND" {
+		t.Errorf("a missing anchor should be 404 NEURON_NOT_FOUND, got %d %s", rr.Code, rr.Body.String())
+	}
+	for _, path := range []string{
+		"/v1/search?q=outage&anchor_id=" + anchor + "&anchor_weight=2",
+		"/v1/search?q=outage&mode=exact&anchor_id=" + anchor,
+	} {
+		if rr := doRequest(t, s, "GET", path, "", headers); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, rr.Code)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package apierr
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
@@ -32,26 +33,72 @@ const (
 	CodeBadRequest       = "BAD_REQUEST"
 	CodeInvalidJSON      = "INVALID_JSON"
 	CodeInvalidContent   = "INVALID_CONTENT"
+	CodeInvalidEncoding  = "INVALID_CONTENT_ENCODING"
 	CodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
 	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
 	CodeNotFound         = "NOT_FOUND"
 	CodeInternalError    = "INTERNAL_ERROR"
 	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
 	CodeRateLimited      = "RATE_LIMITED"
+	CodeAuthLocked       = "AUTH_LOCKED"
 	CodeConflict         = "CONFLICT"
 	CodeMutationDisabled = "MUTATION_DISABLED"
+	CodeServerBusy       = "SERVER_BUSY"
+	CodeDraining         = "DRAINING"
+	CodeStorageMigrating = "STORAGE_MIGRATING"
+
+	CodeUnsupportedVersion = "UNSUPPORTED_API_VERSION"
+
+	CodeInsufficientStorage  = "INSUFFICIENT_STORAGE"
+	CodeStorageQuotaExceeded = "STORAGE_QUOTA_EXCEEDED"
 
 	// Brain / Neuron domain
-	CodeIndexIDRequired  = "INDEX_ID_REQUIRED"
-	CodeNeuronIDRequired = "NEURON_ID_REQUIRED"
-	CodeNeuronNotFound   = "NEURON_NOT_FOUND"
-	CodeQueryRequired    = "QUERY_REQUIRED"
-	CodeUUIDRequired     = "UUID_REQUIRED"
+	CodeIndexIDRequired   = "INDEX_ID_REQUIRED"
+	CodeIndexIDConflict   = "INDEX_ID_CONFLICT"
+	CodeNeuronIDRequired  = "NEURON_ID_REQUIRED"
+	CodeNeuronNotFound    = "NEURON_NOT_FOUND"
+	CodeQueryRequired     = "QUERY_REQUIRED"
+	CodeUUIDRequired      = "UUID_REQUIRED"
+	CodePinLimit          = "PIN_LIMIT"
+	CodeSupersedeCycle    = "SUPERSEDE_CYCLE"
+	CodeSupersedeConflict = "SUPERSEDE_CONFLICT"
+	CodeIndexLoading      = "INDEX_LOADING"
+	CodeIndexDiverged     = "INDEX_DIVERGED"
+	CodeOperationPanic    = "OPERATION_PANIC"
+	CodeIndexQuarantined  = "INDEX_QUARANTINED"
+	CodeAppendOnly        = "APPEND_ONLY"
 
 	// Registry domain
 	CodeUUIDNotRegistered = "UUID_NOT_REGISTERED"
 	CodeUUIDNotFound      = "UUID_NOT_FOUND"
 	CodeUUIDConflict      = "UUID_CONFLICT"
+	CodeInvalidFallback   = "INVALID_FALLBACK"
+	CodeRegistryLockout   = "REGISTRY_GUARD_LOCKOUT"
+
+	// Subscription domain
+	CodeSubscriptionNotFound = "SUBSCRIPTION_NOT_FOUND"
+	CodeSubscriptionLimit    = "SUBSCRIPTION_LIMIT"
+	CodeInvalidSubscription  = "INVALID_SUBSCRIPTION"
+
+	// Share domain
+	CodeShareNotFound = "SHARE_NOT_FOUND"
+	CodeShareExpired  = "SHARE_EXPIRED"
+	CodeShareLimit    = "SHARE_LIMIT"
+	CodeInvalidShare  = "INVALID_SHARE"
+
+	// Import domain
+	CodeInvalidArchive = "INVALID_ARCHIVE"
+
+	// Session domain
+	CodeSessionNotFound = "SESSION_NOT_FOUND"
+	CodeInvalidSession  = "INVALID_SESSION"
+
+	// Retention domain
+	CodeInvalidRetention = "INVALID_RETENTION"
+
+	// Attachment domain
+	CodeAttachmentNotFound = "ATTACHMENT_NOT_FOUND"
 )
 
 // ---------------------------------------------------------------------------
@@ -109,6 +156,11 @@ func Unauthorized(w http.ResponseWriter, msg string) {
 	Write(w, http.StatusUnauthorized, CodeUnauthorized, msg)
 }
 
+// Forbidden writes a 403 response.
+func Forbidden(w http.ResponseWriter, msg string) {
+	Write(w, http.StatusForbidden, CodeForbidden, msg)
+}
+
 // TooManyRequests writes a 429 response.
 func TooManyRequests(w http.ResponseWriter, msg string) {
 	if msg == "" {
@@ -117,11 +169,25 @@ func TooManyRequests(w http.ResponseWriter, msg string) {
 	Write(w, http.StatusTooManyRequests, CodeRateLimited, msg)
 }
 
+// ServiceUnavailable writes a 503 response when the server is at capacity.
+func ServiceUnavailable(w http.ResponseWriter, msg string) {
+	if msg == "" {
+		msg = "server busy"
+	}
+	Write(w, http.StatusServiceUnavailable, CodeServerBusy, msg)
+}
+
 // Conflict writes a 409 response.
 func Conflict(w http.ResponseWriter, code, msg string) {
 	Write(w, http.StatusConflict, code, msg)
 }
 
+// Gone writes a 410 response for a resource that existed but no longer
+// does.
+func Gone(w http.ResponseWriter, code, msg string) {
+	Write(w, http.StatusGone, code, msg)
+}
+
 // Internal writes a 500 response.
 func Internal(w http.ResponseWriter, msg string) {
 	Write(w, http.StatusInternalServerError, CodeInternalError, msg)
@@ -140,11 +206,64 @@ func PayloadTooLarge(w http.ResponseWriter, msg string) {
 	Write(w, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, msg)
 }
 
+// InsufficientStorage writes a 507 response refusing a mutation while the
+// data volume is below its free-space floor. The envelope also carries the
+// volume's free bytes.
+func InsufficientStorage(w http.ResponseWriter, msg string, freeBytes uint64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInsufficientStorage)
+	json.NewEncoder(w).Encode(struct {
+		Response
+		FreeBytes uint64 `json:"freeBytes"`
+	}{
+		Response:  Response{OK: false, Error: msg, Code: CodeInsufficientStorage, Status: http.StatusInsufficientStorage},
+		FreeBytes: freeBytes,
+	})
+}
+
+// StorageQuotaExceeded writes a 507 response refusing a write that would
+// take an index past its disk quota. The envelope also carries the index's
+// usage and quota in bytes.
+func StorageQuotaExceeded(w http.ResponseWriter, msg string, usageBytes, quotaBytes int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInsufficientStorage)
+	json.NewEncoder(w).Encode(struct {
+		Response
+		UsageBytes int64 `json:"usageBytes"`
+		QuotaBytes int64 `json:"quotaBytes"`
+	}{
+		Response:   Response{OK: false, Error: msg, Code: CodeStorageQuotaExceeded, Status: http.StatusInsufficientStorage},
+		UsageBytes: usageBytes,
+		QuotaBytes: quotaBytes,
+	})
+}
+
+// OperationPanic writes a 500 response for an operation that panicked. The
+// envelope also carries the reference of the dead letter recorded for it,
+// listed by GET /admin/deadletters.
+func OperationPanic(w http.ResponseWriter, msg, reference string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(struct {
+		Response
+		Reference string `json:"reference"`
+	}{
+		Response:  Response{OK: false, Error: msg, Code: CodeOperationPanic, Status: http.StatusInternalServerError},
+		Reference: reference,
+	})
+}
+
 // IndexIDRequired writes a 400 response when X-Index-ID is missing.
 func IndexIDRequired(w http.ResponseWriter) {
 	BadRequest(w, CodeIndexIDRequired, "X-Index-ID header or index_id query parameter required")
 }
 
+// IndexIDConflict writes a 400 response when the X-Index-ID header and the
+// index_id query parameter name different indexes.
+func IndexIDConflict(w http.ResponseWriter, header, query string) {
+	BadRequest(w, CodeIndexIDConflict, fmt.Sprintf("X-Index-ID header %q conflicts with index_id query parameter %q", header, query))
+}
+
 // NeuronIDRequired writes a 400 response when a neuron ID is missing.
 func NeuronIDRequired(w http.ResponseWriter) {
 	BadRequest(w, CodeNeuronIDRequired, "neuron ID required in path")
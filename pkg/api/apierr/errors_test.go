@@ -110,6 +110,19 @@ func TestMethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestForbidden(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Forbidden(rec, "insufficient role")
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+	resp := decodeResponse(t, rec)
+	if resp.Code != CodeForbidden {
+		t.Errorf("expected code %q, got %q", CodeForbidden, resp.Code)
+	}
+}
+
 func TestUnauthorized(t *testing.T) {
 	rec := httptest.NewRecorder()
 	Unauthorized(rec, "invalid credentials")
@@ -123,6 +136,22 @@ func TestUnauthorized(t *testing.T) {
 	}
 }
 
+func TestServiceUnavailable(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ServiceUnavailable(rec, "")
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	resp := decodeResponse(t, rec)
+	if resp.Code != CodeServerBusy {
+		t.Errorf("expected code %q, got %q", CodeServerBusy, resp.Code)
+	}
+	if resp.Error != "server busy" {
+		t.Errorf("expected default message 'server busy', got %q", resp.Error)
+	}
+}
+
 func TestConflict(t *testing.T) {
 	rec := httptest.NewRecorder()
 	Conflict(rec, CodeUUIDConflict, "uuid already exists")
@@ -202,6 +231,25 @@ func TestUUIDRequired(t *testing.T) {
 	}
 }
 
+func TestInsufficientStorage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	InsufficientStorage(rec, "disk full", 4096)
+
+	if rec.Code != http.StatusInsufficientStorage {
+		t.Errorf("expected 507, got %d", rec.Code)
+	}
+	var resp struct {
+		Response
+		FreeBytes uint64 `json:"freeBytes"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != CodeInsufficientStorage || resp.Status != 507 || resp.FreeBytes != 4096 {
+		t.Errorf("unexpected envelope: %+v", resp)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Verify all codes are unique
 // ---------------------------------------------------------------------------
@@ -209,10 +257,11 @@ func TestUUIDRequired(t *testing.T) {
 func TestCodesAreUnique(t *testing.T) {
 	codes := []string{
 		CodeBadRequest, CodeInvalidJSON, CodeMethodNotAllowed,
-		CodeNotFound, CodeInternalError, CodeUnauthorized, CodeConflict,
+		CodeNotFound, CodeInternalError, CodeUnauthorized, CodeForbidden, CodeConflict,
+		CodeServerBusy, CodeInvalidContent, CodeInvalidEncoding, CodeInsufficientStorage,
 		CodeIndexIDRequired, CodeNeuronIDRequired, CodeNeuronNotFound,
 		CodeQueryRequired, CodeUUIDRequired,
-		CodeUUIDNotRegistered, CodeUUIDNotFound, CodeUUIDConflict,
+		CodeUUIDNotRegistered, CodeUUIDNotFound, CodeUUIDConflict, CodeInvalidFallback,
 	}
 
 	seen := make(map[string]bool, len(codes))
@@ -0,0 +1,70 @@
+package apierr
+
+import (
+	"context"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Warning codes — stable identifiers for non-fatal conditions.
+//
+// A request that succeeds despite one of these still returns 2xx; its JSON
+// body gains a "warnings" array. Like error codes, these are part of the
+// public API contract.
+// ---------------------------------------------------------------------------
+
+const (
+	WarnSearchModeDegraded  = "SEARCH_MODE_DEGRADED"  // vector layer failed, results are lexical only
+	WarnFieldsIgnored       = "FIELDS_IGNORED"        // request fields that had no effect
+	WarnResultsTruncated    = "RESULTS_TRUNCATED"     // results cut short by a budget
+	WarnMetadataKeyReserved = "METADATA_KEY_RESERVED" // metadata keys dropped from a write
+)
+
+// Warning is one entry of a success response's "warnings" array.
+type Warning struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Warnings collects the warnings raised while handling one request. It is
+// safe for concurrent use.
+type Warnings struct {
+	mu   sync.Mutex
+	list []Warning
+}
+
+type warningsKey struct{}
+
+// WithWarnings returns a context carrying a new collector, and the
+// collector, for Warn to append to.
+func WithWarnings(ctx context.Context) (context.Context, *Warnings) {
+	ws := &Warnings{}
+	return context.WithValue(ctx, warningsKey{}, ws), ws
+}
+
+// Warn appends a warning to the collector carried by ctx. Without one, as
+// outside an HTTP request, it does nothing.
+func Warn(ctx context.Context, code, message string, details map[string]any) {
+	ws, _ := ctx.Value(warningsKey{}).(*Warnings)
+	if ws == nil {
+		return
+	}
+	ws.mu.Lock()
+	ws.list = append(ws.list, Warning{Code: code, Message: message, Details: details})
+	ws.mu.Unlock()
+}
+
+// List returns a copy of the warnings collected so far.
+func (ws *Warnings) List() []Warning {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return append([]Warning(nil), ws.list...)
+}
+
+// Len returns the number of warnings collected so far.
+func (ws *Warnings) Len() int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return len(ws.list)
+}
@@ -0,0 +1,33 @@
+package apierr
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestWarn_WithoutCollectorIsNoop(t *testing.T) {
+	Warn(context.Background(), WarnFieldsIgnored, "nobody is listening", nil)
+}
+
+func TestWarn_ConcurrentAppends(t *testing.T) {
+	ctx, ws := WithWarnings(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Warn(ctx, WarnResultsTruncated, "cut short", map[string]any{"limit": 1})
+			_ = ws.List()
+		}()
+	}
+	wg.Wait()
+	if got := len(ws.List()); got != 50 || ws.Len() != 50 {
+		t.Fatalf("expected 50 warnings, got %d", got)
+	}
+	list := ws.List()
+	list[0].Code = "CHANGED"
+	if ws.List()[0].Code != WarnResultsTruncated {
+		t.Error("List should return a copy")
+	}
+}
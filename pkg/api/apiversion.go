@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+)
+
+// apiVersionHeader selects a behavior version on a request and names the
+// version served on its response.
+const apiVersionHeader = "X-QubicDB-Version"
+
+// apiBehavior is the set of toggles a behavior version fixes. Behavior that
+// changes in a way deployed clients can notice gets a toggle here, on in a
+// new version appended to apiVersions, so clients pinned to an older
+// version keep what they were built against.
+type apiBehavior struct {
+	// IDAlias adds id, an alias of _id, to neuron documents.
+	IDAlias bool `json:"idAlias"`
+
+	// StrictSearchParams refuses a GET /v1/search whose depth, limit or
+	// boolean parameters are malformed, instead of ignoring them.
+	StrictSearchParams bool `json:"strictSearchParams"`
+}
+
+// apiToggles describes the toggles of apiBehavior by their JSON names, in
+// field order.
+var apiToggles = []struct {
+	name        string
+	description string
+	value       func(apiBehavior) bool
+}{
+	{"idAlias", "Neuron documents carry id, an alias of _id.", func(b apiBehavior) bool { return b.IDAlias }},
+	{"strictSearchParams", "GET /v1/search answers 400 to a malformed depth, limit or boolean parameter instead of ignoring it.", func(b apiBehavior) bool { return b.StrictSearchParams }},
+}
+
+// apiVersion is a named behavior version.
+type apiVersion struct {
+	Name     string
+	Behavior apiBehavior
+}
+
+// apiVersions lists the behavior versions, oldest first; the last is the
+// default. Names are YYYY-MM and sort by date.
+var apiVersions = []apiVersion{
+	{Name: "2024-06"},
+	{Name: "2025-01", Behavior: apiBehavior{IDAlias: true, StrictSearchParams: true}},
+}
+
+// latestAPIVersion is the version served when a request names none.
+func latestAPIVersion() apiVersion {
+	return apiVersions[len(apiVersions)-1]
+}
+
+// apiVersionFloor returns the index in apiVersions of the oldest version
+// served under server.minApiVersion: the first at or after it, and at most
+// the latest.
+func apiVersionFloor(minVersion string) int {
+	for i, v := range apiVersions {
+		if v.Name >= minVersion {
+			return i
+		}
+	}
+	return len(apiVersions) - 1
+}
+
+// resolveAPIVersion returns the version a request names in its
+// X-QubicDB-Version header, or the latest when it names none.
+func (s *Server) resolveAPIVersion(name string) (apiVersion, error) {
+	if name == "" {
+		return latestAPIVersion(), nil
+	}
+	for i, v := range apiVersions {
+		if v.Name != name {
+			continue
+		}
+		if i < s.apiFloor {
+			return apiVersion{}, fmt.Errorf("API version %s is no longer served; the oldest is %s", name, apiVersions[s.apiFloor].Name)
+		}
+		return v, nil
+	}
+	return apiVersion{}, fmt.Errorf("unknown API version %q; GET /v1/versions lists them", name)
+}
+
+type apiVersionKey struct{}
+
+// withAPIVersion returns ctx carrying the version a request is served with.
+func withAPIVersion(ctx context.Context, v apiVersion) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, v)
+}
+
+// apiVersionOf returns the version ctx is served with: the latest unless
+// the middleware resolved another, so MCP tools and internal calls always
+// get current behavior.
+func apiVersionOf(ctx context.Context) apiVersion {
+	if v, ok := ctx.Value(apiVersionKey{}).(apiVersion); ok {
+		return v
+	}
+	return latestAPIVersion()
+}
+
+// apiVersionDocument describes v and what changed from prev, the version
+// before it, or nil for the oldest.
+func apiVersionDocument(v apiVersion, prev *apiVersion, supported bool) map[string]any {
+	changes := []map[string]any{}
+	if prev != nil {
+		for _, t := range apiToggles {
+			if from, to := t.value(prev.Behavior), t.value(v.Behavior); from != to {
+				changes = append(changes, map[string]any{"toggle": t.name, "from": from, "to": to, "description": t.description})
+			}
+		}
+	}
+	return map[string]any{
+		"name":      v.Name,
+		"behavior":  v.Behavior,
+		"changes":   changes,
+		"supported": supported,
+	}
+}
+
+// handleAPIVersions lists the behavior versions a client may select with
+// X-QubicDB-Version (GET /v1/versions), oldest first, each with its toggles
+// and what changed from the version before it.
+func (s *Server) handleAPIVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	versions := make([]map[string]any, len(apiVersions))
+	for i, v := range apiVersions {
+		var prev *apiVersion
+		if i > 0 {
+			prev = &apiVersions[i-1]
+		}
+		versions[i] = apiVersionDocument(v, prev, i >= s.apiFloor)
+	}
+	toggles := make([]map[string]string, len(apiToggles))
+	for i, t := range apiToggles {
+		toggles[i] = map[string]string{"name": t.name, "description": t.description}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"current":  apiVersionOf(r.Context()).Name,
+		"latest":   latestAPIVersion().Name,
+		"minimum":  apiVersions[s.apiFloor].Name,
+		"toggles":  toggles,
+		"versions": versions,
+	})
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestAPIVersion_PinnedClientsKeepLegacyBehavior(t *testing.T) {
+	s := newTestServer(t, nil)
+	latest := map[string]string{"X-Index-ID": "agent"}
+	legacy := map[string]string{"X-Index-ID": "agent", apiVersionHeader: "2024-06"}
+	id := writeTo(t, s, "agent", "rocket launch notes")
+
+	rr := doRequest(t, s, "GET", "/v1/read/"+string(id), "", latest)
+	if got := rr.Header().Get(apiVersionHeader); got != "2025-01" {
+		t.Errorf("expected the latest version served by default, got %q", got)
+	}
+	if doc := decodeJSON(t, rr); doc["id"] != string(id) || doc["_id"] != string(id) {
+		t.Errorf("the latest version carries the id alias, got %v", doc)
+	}
+	rr = doRequest(t, s, "GET", "/v1/read/"+string(id), "", legacy)
+	if got := rr.Header().Get(apiVersionHeader); got != "2024-06" {
+		t.Errorf("expected the pinned version echoed, got %q", got)
+	}
+	doc := decodeJSON(t, rr)
+	if _, ok := doc["id"]; ok || doc["_id"] != string(id) {
+		t.Errorf("2024-06 documents have _id only, got %v", doc)
+	}
+	rr = doRequest(t, s, "GET", "/v1/search?q=rocket", "", legacy)
+	if hit := decodeJSON(t, rr)["results"].([]any)[0].(map[string]any); hit["id"] != nil {
+		t.Errorf("2024-06 search hits have _id only, got %v", hit)
+	}
+
+	// Malformed GET search parameters: refused now, ignored before.
+	for _, query := range []string{"&limit=ten", "&depth=-1", "&explain=yes"} {
+		if rr := doRequest(t, s, "GET", "/v1/search?q=rocket"+query, "", latest); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+		if rr := doRequest(t, s, "GET", "/v1/search?q=rocket"+query, "", legacy); rr.Code != http.StatusOK {
+			t.Errorf("%s: 2024-06 should ignore it, got %d %s", query, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := doRequest(t, s, "GET", "/v1/search?q=rocket&explain=1", "", latest); rr.Code != http.StatusOK || decodeJSON(t, rr)["results"].([]any)[0].(map[string]any)["explain"] == nil {
+		t.Error("strict parsing should take explain=1 as true")
+	}
+
+	// MCP tools always get the current document shape.
+	b := newTestMCPBackend(t)
+	mcpDoc, err := b.Write(context.Background(), "agent", "mcp note", nil)
+	if err != nil || mcpDoc["id"] == nil {
+		t.Errorf("expected MCP documents with the id alias, got %v, %v", mcpDoc, err)
+	}
+}
+
+func TestAPIVersion_UnknownAndBelowFloor(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Server.MinAPIVersion = "2025-01" })
+	headers := map[string]string{"X-Index-ID": "agent", apiVersionHeader: "2024-06"}
+	rr := doRequest(t, s, "GET", "/v1/recall", "", headers)
+	if rr.Code != http.StatusBadRequest || decodeJSON(t, rr)["code"] != apierr.CodeUnsupportedVersion {
+		t.Errorf("a version below the floor should be refused, got %d %s", rr.Code, rr.Body.String())
+	}
+	headers[apiVersionHeader] = "1999-01"
+	if rr := doRequest(t, s, "GET", "/v1/recall", "", headers); rr.Code != http.StatusBadRequest {
+		t.Errorf("an unknown version should be refused, got %d", rr.Code)
+	}
+
+	doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/versions", "", nil))
+	if doc["current"] != "2025-01" || doc["latest"] != "2025-01" || doc["minimum"] != "2025-01" {
+		t.Errorf("unexpected version summary: %v", doc)
+	}
+	versions := doc["versions"].([]any)
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %v", versions)
+	}
+	old, current := versions[0].(map[string]any), versions[1].(map[string]any)
+	if old["supported"] != false || current["supported"] != true {
+		t.Errorf("expected only 2025-01 supported, got %v", versions)
+	}
+	changes := current["changes"].([]any)
+	if len(changes) != 2 || changes[0].(map[string]any)["toggle"] != "idAlias" || changes[0].(map[string]any)["to"] != true {
+		t.Errorf("expected 2025-01 to list its two toggles, got %v", changes)
+	}
+	if current["behavior"].(map[string]any)["strictSearchParams"] != true {
+		t.Errorf("expected the behavior of 2025-01, got %v", current["behavior"])
+	}
+}
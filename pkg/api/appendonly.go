@@ -0,0 +1,58 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/auth"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// overrideAppendOnlyParam is the query flag an admin operation that
+// removes or replaces a whole index must carry to do so to an append-only
+// index.
+const overrideAppendOnlyParam = "override_append_only"
+
+// resolveAppendOnly reports whether indexID's registry entry marks it
+// append-only.
+func (s *Server) resolveAppendOnly(indexID core.IndexID) bool {
+	return s.registry != nil && s.registry.AppendOnly(string(indexID))
+}
+
+// writeAppendOnlyError writes a 403 APPEND_ONLY response.
+func writeAppendOnlyError(w http.ResponseWriter, msg string) {
+	apierr.Write(w, http.StatusForbidden, apierr.CodeAppendOnly, msg)
+}
+
+// allowDestructiveAdmin reports whether the admin operation action may
+// remove or replace the memories of indexID. An append-only index needs
+// ?override_append_only=true, and each override is written to the audit
+// log; without it a 403 APPEND_ONLY is written and false returned.
+func (s *Server) allowDestructiveAdmin(w http.ResponseWriter, r *http.Request, indexID core.IndexID, action string) bool {
+	if !s.pool.AppendOnly(indexID) {
+		return true
+	}
+	if override, _ := strconv.ParseBool(r.URL.Query().Get(overrideAppendOnlyParam)); !override {
+		writeAppendOnlyError(w, "index "+string(indexID)+" is append-only; "+action+" needs ?"+overrideAppendOnlyParam+"=true")
+		return false
+	}
+	log.Printf("AUDIT append-only override: %s of index %s by %s from %s", action, indexID, s.adminActor(r), r.RemoteAddr)
+	return true
+}
+
+// adminActor names the admin who sent r, for the audit log: the Basic
+// auth user or the session's user.
+func (s *Server) adminActor(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	if token, err := auth.BearerToken(r); err == nil && s.adminSessions != nil {
+		if sess, err := s.adminSessions.Verify(token, time.Now()); err == nil {
+			return sess.User
+		}
+	}
+	return "unknown"
+}
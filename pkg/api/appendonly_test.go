@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestAppendOnly_RefusesDestructiveOperations(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled, cfg.Admin.User, cfg.Admin.Password = true, "admin", "secret"
+	})
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"ledger","metadata":{"appendOnly":"yes"}}`, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected a non-boolean appendOnly to be refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"ledger","metadata":{"appendOnly":true}}`, nil); rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+		t.Fatalf("register: %d %s", rr.Code, rr.Body.String())
+	}
+	headers := map[string]string{"X-Index-ID": "ledger"}
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "secret")}
+
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"Invoice 42 was paid in full"}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("an append-only index must accept writes: %d %s", rr.Code, rr.Body.String())
+	}
+	id := decodeJSON(t, rr)["id"].(string)
+
+	refused := []struct{ method, path, body string }{
+		{"POST", "/v1/write", fmt.Sprintf(`{"content":"Invoice 42 was refunded","supersedes":%q}`, id)},
+		{"DELETE", "/v1/recycle/" + id, ""},
+		{"POST", "/v1/forget", `{"metadata":{"invoice":"42"}}`},
+		{"POST", "/v1/command", fmt.Sprintf(`{"type":"delete","collection":"neurons","filter":{"_id":%q}}`, id)},
+	}
+	for _, c := range refused {
+		rr := doRequest(t, s, c.method, c.path, c.body, headers)
+		if rr.Code != http.StatusForbidden || decodeJSON(t, rr)["code"] != "APPEND_ONLY" {
+			t.Errorf("%s %s: expected 403 APPEND_ONLY, got %d %s", c.method, c.path, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The flag is fixed at registration, and the entry stays.
+	if rr := doRequest(t, s, "PUT", "/v1/registry/ledger", `{"metadata":{"appendOnly":false}}`, nil); rr.Code != http.StatusConflict {
+		t.Errorf("expected changing appendOnly to conflict, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "PUT", "/v1/registry/ledger", `{"uuid":"ledger-2","metadata":{"appendOnly":true}}`, nil); rr.Code != http.StatusForbidden {
+		t.Errorf("expected renaming to be refused, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "DELETE", "/v1/registry/ledger", "", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("expected unregistering to be refused, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// The retention pass leaves it alone.
+	s.config.Retention.DefaultRules = []core.RetentionRule{{MaxAge: "1ns", Action: "delete"}}
+	if _, err := s.retentionPass(); err != nil {
+		t.Fatal(err)
+	}
+	if rr := doRequest(t, s, "GET", "/v1/read/"+id, "", headers); rr.Code != http.StatusOK {
+		t.Errorf("retention must not remove memories of an append-only index, got %d", rr.Code)
+	}
+
+	// Whole-index admin operations need the override.
+	detail := decodeJSON(t, doRequest(t, s, "GET", "/admin/indexes/ledger", "", admin))
+	if detail["appendOnly"] != true {
+		t.Errorf("expected the index detail to report appendOnly, got %v", detail["appendOnly"])
+	}
+	if rr := doRequest(t, s, "POST", "/admin/indexes/ledger/reset", "", admin); rr.Code != http.StatusForbidden {
+		t.Errorf("expected reset to need the override, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "DELETE", "/admin/indexes/ledger", "", admin); rr.Code != http.StatusForbidden {
+		t.Errorf("expected delete to need the override, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "GET", "/v1/read/"+id, "", headers); rr.Code != http.StatusOK {
+		t.Fatalf("refused admin operations must leave the index, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "DELETE", "/admin/indexes/ledger?override_append_only=true", "", admin); rr.Code != http.StatusOK {
+		t.Fatalf("delete with the override: %d %s", rr.Code, rr.Body.String())
+	}
+	if s.registry.Exists("ledger") {
+		t.Error("expected the override delete to unregister the index")
+	}
+}
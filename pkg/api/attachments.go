@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/protocol"
+)
+
+// attachmentsPath is where attachment blobs are uploaded.
+const attachmentsPath = "/v1/attachments"
+
+// handleAttachmentUpload stores the request body as an attachment blob
+// (POST /v1/attachments). ?caption= is searched with the content of every
+// neuron that references the blob. Uploading bytes that are already
+// stored returns the existing blob with 200 instead of 201.
+func (s *Server) handleAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	caption := r.URL.Query().Get("caption")
+	if err := core.CheckContentEncoding(caption); err != nil {
+		apierr.BadRequest(w, apierr.CodeInvalidEncoding, "caption: "+err.Error())
+		return
+	}
+	if int64(len(caption)) > s.config.Security.MaxNeuronContentBytes {
+		apierr.PayloadTooLarge(w, fmt.Sprintf("caption exceeds security.maxNeuronContentBytes=%d", s.config.Security.MaxNeuronContentBytes))
+		return
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	info, created, err := s.pool.Blobs().Put(r.Body, s.config.Security.MaxAttachmentBytes, contentType, caption)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.Is(err, persistence.ErrBlobTooLarge) || errors.As(err, &maxErr) {
+			apierr.PayloadTooLarge(w, fmt.Sprintf("attachment exceeds security.maxAttachmentBytes=%d", s.config.Security.MaxAttachmentBytes))
+			return
+		}
+		apierr.Internal(w, err.Error())
+		return
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(attachmentDocument(info, created))
+}
+
+// attachmentDocument renders a stored blob for the upload response.
+func attachmentDocument(info persistence.BlobInfo, created bool) map[string]any {
+	doc := map[string]any{
+		"hash":        info.Hash,
+		"size":        info.Size,
+		"contentType": info.ContentType,
+		"url":         protocol.AttachmentURL(info.Hash),
+		"created":     created,
+	}
+	if info.Caption != "" {
+		doc["caption"] = info.Caption
+	}
+	return doc
+}
+
+// handleAttachment serves an attachment blob (GET /v1/attachments/{hash}).
+// Blobs never change, so the hash is a strong ETag.
+func (s *Server) handleAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	hash := strings.TrimPrefix(r.URL.Path, attachmentsPath+"/")
+	f, info, err := s.pool.Blobs().Open(hash)
+	if errors.Is(err, persistence.ErrBlobNotFound) {
+		apierr.NotFound(w, apierr.CodeAttachmentNotFound, "attachment not found")
+		return
+	}
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	defer f.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+hash+`"`)
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	http.ServeContent(w, r, "", info.CreatedAt, f)
+}
+
+// resolveAttachments turns the hashes of a write into references, with the
+// captions given at upload. Every blob must exist; each is touched so a
+// sweep that counted references before this write keeps it.
+func (s *Server) resolveAttachments(hashes []string) ([]core.Attachment, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	blobs := s.pool.Blobs()
+	attachments := make([]core.Attachment, 0, len(hashes))
+	seen := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		info, err := blobs.Stat(hash)
+		if err == nil {
+			err = blobs.Touch(hash)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("attachment %q: %w", hash, err)
+		}
+		attachments = append(attachments, core.Attachment{Hash: hash, Caption: info.Caption})
+	}
+	return attachments, nil
+}
+
+// StartAttachmentSweep registers the attachment sweep with the daemon
+// manager when storage.attachmentSweepInterval is set.
+func (s *Server) StartAttachmentSweep() {
+	interval := s.config.Storage.AttachmentSweepInterval
+	if s.daemons == nil || interval <= 0 {
+		return
+	}
+	s.daemons.Add("attachments", func() time.Duration { return interval }, s.attachmentSweepPass)
+	log.Printf("Attachment sweep started (interval=%s, grace=%s)", interval, s.config.Storage.AttachmentGracePeriod)
+}
+
+// attachmentSweepPass removes the blobs no neuron references any more.
+// References are counted first across every index; if any index cannot be
+// read nothing is removed, since its references are unknown.
+func (s *Server) attachmentSweepPass() (int, error) {
+	refs, err := s.pool.AttachmentRefs()
+	if err != nil {
+		return 0, fmt.Errorf("counting attachment references: %w", err)
+	}
+	report, err := s.pool.Blobs().Sweep(refs, s.config.Storage.AttachmentGracePeriod, time.Now())
+	if report.Removed > 0 {
+		log.Printf("🗑 Attachment sweep removed %d unreferenced blobs (%d bytes)", report.Removed, report.BytesFreed)
+	}
+	return report.Removed, err
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// uploadAttachment uploads body and returns the response document.
+func uploadAttachment(t *testing.T, s *Server, body, caption string, wantCode int) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "POST", attachmentsPath+"?caption="+caption, body, map[string]string{"Content-Type": "text/plain"})
+	if rr.Code != wantCode {
+		t.Fatalf("upload: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func TestAttachmentUploadAndReference(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Security.MaxAttachmentBytes = 64
+	})
+	headers := map[string]string{"X-Index-ID": "att"}
+
+	doc := uploadAttachment(t, s, "quarterly figures, page one", "spreadsheet+of+zeppelin+sales", http.StatusCreated)
+	hash := doc["hash"].(string)
+	if again := uploadAttachment(t, s, "quarterly figures, page one", "", http.StatusOK); again["hash"] != hash || again["created"] != false {
+		t.Fatalf("the same bytes should deduplicate: %v", again)
+	}
+	if rr := doRequest(t, s, "POST", attachmentsPath, strings.Repeat("x", 65), nil); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("an attachment over security.maxAttachmentBytes should be refused, got %d", rr.Code)
+	}
+
+	if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"see attached","attachments":["`+strings.Repeat("0", 64)+`"]}`, headers); rr.Code != http.StatusBadRequest {
+		t.Fatalf("an unknown attachment should be refused, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"Q3 report from finance","attachments":["`+hash+`"]}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+	}
+	id := decodeJSON(t, rr)["id"].(string)
+
+	read := decodeJSON(t, doRequest(t, s, "GET", "/v1/read/"+id, "", headers))
+	attachments, _ := read["attachments"].([]any)
+	if len(attachments) != 1 {
+		t.Fatalf("the read document should list the attachment: %v", read)
+	}
+	url := attachments[0].(map[string]any)["url"].(string)
+	blob := doRequest(t, s, "GET", url, "", nil)
+	if blob.Code != http.StatusOK || blob.Body.String() != "quarterly figures, page one" || blob.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("attachment url should resolve: %d %q %s", blob.Code, blob.Body.String(), blob.Header().Get("Content-Type"))
+	}
+
+	// The caption is searchable; the blob's bytes are not.
+	results := decodeJSON(t, doRequest(t, s, "GET", "/v1/search?q=zeppelin", "", headers))["results"].([]any)
+	if len(results) != 1 || results[0].(map[string]any)["id"] != id {
+		t.Errorf("the caption should be indexed, got %v", results)
+	}
+	if results := decodeJSON(t, doRequest(t, s, "GET", "/v1/search?q=quarterly", "", headers))["results"].([]any); len(results) != 0 {
+		t.Errorf("attachment bytes should not be indexed, got %v", results)
+	}
+}
+
+func TestAttachmentSweepAfterForgetAndReset(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Storage.AttachmentGracePeriod = 0
+	})
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	shared := uploadAttachment(t, s, "shared diagram", "", http.StatusCreated)["hash"].(string)
+	only := uploadAttachment(t, s, "single diagram", "", http.StatusCreated)["hash"].(string)
+
+	write := func(index, content string, hashes ...string) string {
+		body := `{"content":"` + content + `","attachments":["` + strings.Join(hashes, `","`) + `"]}`
+		rr := doRequest(t, s, "POST", "/v1/write", body, map[string]string{"X-Index-ID": index})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+		return decodeJSON(t, rr)["id"].(string)
+	}
+	forgotten := write("a", "architecture notes", shared, only)
+	write("b", "onboarding notes", shared)
+	stored := func(hash string) bool {
+		_, err := s.pool.Blobs().Stat(hash)
+		return err == nil
+	}
+
+	if _, err := s.attachmentSweepPass(); err != nil || !stored(shared) || !stored(only) {
+		t.Fatalf("referenced blobs should survive a sweep: %v", err)
+	}
+
+	worker, _ := s.pool.Get("a")
+	if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpForget, Payload: core.NeuronID(forgotten)}); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := s.attachmentSweepPass(); err != nil || removed != 0 || !stored(only) {
+		t.Fatalf("a forgotten neuron can be restored, so its blob should survive: removed=%d err=%v", removed, err)
+	}
+	if rr := doRequest(t, s, "DELETE", "/v1/recycle/"+forgotten, "", map[string]string{"X-Index-ID": "a"}); rr.Code != http.StatusOK {
+		t.Fatalf("purge: %d %s", rr.Code, rr.Body.String())
+	}
+	if removed, err := s.attachmentSweepPass(); err != nil || removed != 1 || stored(only) || !stored(shared) {
+		t.Fatalf("purging the only reference should free the blob: removed=%d err=%v", removed, err)
+	}
+
+	if rr := doRequest(t, s, "POST", "/admin/indexes/b/reset", "", admin); rr.Code != http.StatusOK {
+		t.Fatalf("reset: %d %s", rr.Code, rr.Body.String())
+	}
+	if removed, err := s.attachmentSweepPass(); err != nil || removed != 1 || stored(shared) {
+		t.Fatalf("resetting the last index referencing a blob should free it: removed=%d err=%v", removed, err)
+	}
+}
+
+func TestAttachmentExportImportRoundTrip(t *testing.T) {
+	src := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	dst := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+
+	hash := uploadAttachment(t, src, "whiteboard photo", "whiteboard", http.StatusCreated)["hash"].(string)
+	rr := doRequest(t, src, "POST", "/v1/write", `{"content":"design review","attachments":["`+hash+`"]}`, map[string]string{"X-Index-ID": "src"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+	}
+	id := decodeJSON(t, rr)["id"].(string)
+
+	export := doRequest(t, src, "GET", "/admin/indexes/src/export", "", admin)
+	if export.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", export.Code, export.Body.String())
+	}
+	lines := exportLines(t, export.Body.String())
+	if footer := lines[len(lines)-1]; footer["blobs"] != float64(1) {
+		t.Fatalf("the export should carry the referenced blob: %v", footer)
+	}
+
+	rr = doRequest(t, dst, "POST", "/admin/indexes/dst/import", export.Body.String(), admin)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", rr.Code, rr.Body.String())
+	}
+	if blobs := decodeJSON(t, rr)["blobs"].(map[string]any); blobs["imported"] != float64(1) || blobs["missingReferences"] != float64(0) {
+		t.Errorf("unexpected blob report %v", blobs)
+	}
+	read := decodeJSON(t, doRequest(t, dst, "GET", "/v1/read/"+id, "", map[string]string{"X-Index-ID": "dst"}))
+	attachments, _ := read["attachments"].([]any)
+	if len(attachments) != 1 {
+		t.Fatalf("the imported neuron should keep its attachment: %v", read)
+	}
+	blob := doRequest(t, dst, "GET", attachments[0].(map[string]any)["url"].(string), "", nil)
+	if blob.Code != http.StatusOK || blob.Body.String() != "whiteboard photo" {
+		t.Fatalf("the imported blob should resolve: %d %q", blob.Code, blob.Body.String())
+	}
+
+	// A blob whose bytes were altered in transit is refused.
+	tampered := strings.Replace(export.Body.String(), `"data":"`, `"data":"AA`, 1)
+	if rr := doRequest(t, dst, "POST", "/admin/indexes/other/import", tampered, admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("a blob not matching its hash should be refused, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/auth"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// maxAuthBuckets bounds the failure counters kept; beyond it, counters
+// that are not locking anyone out are swept.
+const maxAuthBuckets = 10000
+
+// maxThrottledUserLen bounds the username part of a bucket key, so a
+// guessing client cannot grow memory with long usernames.
+const maxThrottledUserLen = 64
+
+// authBucket counts consecutive authentication failures of one client IP
+// or username.
+type authBucket struct {
+	failures    int
+	pending     int // attempts being verified right now
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// authThrottle locks out client IPs and usernames after
+// security.authThrottle.maxFailures consecutive failed admin
+// authentications. Each failure after that locks again for twice as long,
+// up to lockoutMax. State is in memory only.
+type authThrottle struct {
+	maxFailures int
+	base        time.Duration
+	max         time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	buckets  map[string]*authBucket
+	failed   uint64 // attempts with wrong credentials
+	rejected uint64 // attempts refused while locked out
+	lockouts uint64 // lockouts started
+}
+
+func newAuthThrottle(cfg core.AuthThrottleConfig) *authThrottle {
+	return &authThrottle{
+		maxFailures: cfg.MaxFailures,
+		base:        cfg.LockoutBase,
+		max:         cfg.LockoutMax,
+		now:         time.Now,
+		buckets:     make(map[string]*authBucket),
+	}
+}
+
+// authKeys returns the buckets an attempt from ip as user counts against.
+func authKeys(ip, user string) [2]string {
+	if len(user) > maxThrottledUserLen {
+		user = user[:maxThrottledUserLen]
+	}
+	return [2]string{"ip:" + ip, "user:" + user}
+}
+
+// begin reserves an attempt from ip as user. It returns false, and how long
+// to wait, while either is locked out. Attempts in flight count against the
+// limit, so concurrent guesses cannot verify more than maxFailures
+// passwords before the lockout starts. Every allowed attempt must be
+// settled with done.
+func (t *authThrottle) begin(ip, user string) (time.Duration, bool) {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := authKeys(ip, user)
+	var wait time.Duration
+	for _, key := range keys {
+		b := t.buckets[key]
+		if b == nil {
+			continue
+		}
+		if b.pending == 0 && !now.Before(b.lockedUntil) && now.Sub(b.lastFailure) >= t.max {
+			// Quiet for lockoutMax: start counting afresh.
+			b.failures = 0
+		}
+		if now.Before(b.lockedUntil) {
+			wait = max(wait, b.lockedUntil.Sub(now))
+		} else if b.failures+b.pending >= t.maxFailures && b.pending > 0 {
+			// The attempts in flight may start a lockout.
+			wait = max(wait, time.Second)
+		}
+	}
+	if wait > 0 {
+		t.rejected++
+		return wait, false
+	}
+
+	for _, key := range keys {
+		b := t.buckets[key]
+		if b == nil {
+			if len(t.buckets) >= maxAuthBuckets {
+				t.sweep(now)
+			}
+			b = &authBucket{}
+			t.buckets[key] = b
+		}
+		b.pending++
+	}
+	return 0, true
+}
+
+// done settles an attempt begun with begin. A success clears the
+// counters of ip and user; a failure counts against both and may lock
+// them out.
+func (t *authThrottle) done(ip, user string, ok bool) {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !ok {
+		t.failed++
+	}
+	for _, key := range authKeys(ip, user) {
+		b := t.buckets[key]
+		if b == nil {
+			// Cleared by a concurrent success.
+			continue
+		}
+		if b.pending > 0 {
+			b.pending--
+		}
+		if ok {
+			if b.pending == 0 {
+				delete(t.buckets, key)
+			} else {
+				b.failures = 0
+				b.lockedUntil = time.Time{}
+			}
+			continue
+		}
+		b.failures++
+		b.lastFailure = now
+		if b.failures >= t.maxFailures {
+			lockout := t.lockoutFor(b.failures)
+			b.lockedUntil = now.Add(lockout)
+			t.lockouts++
+			log.Printf("⚠ auth lockout: %s locked out for %s after %d consecutive failed admin logins", key, lockout, b.failures)
+		}
+	}
+}
+
+// lockoutFor returns how long the failures-th consecutive failure locks
+// out: lockoutBase at maxFailures, doubling with each one after.
+func (t *authThrottle) lockoutFor(failures int) time.Duration {
+	lockout := t.base
+	for i := t.maxFailures; i < failures && lockout < t.max; i++ {
+		lockout *= 2
+	}
+	return min(lockout, t.max)
+}
+
+// sweep drops counters that neither lock anyone out nor saw a failure for
+// lockoutMax. Callers hold the lock.
+func (t *authThrottle) sweep(now time.Time) {
+	for key, b := range t.buckets {
+		if b.pending == 0 && !now.Before(b.lockedUntil) && now.Sub(b.lastFailure) >= t.max {
+			delete(t.buckets, key)
+		}
+	}
+}
+
+// Stats returns the throttle's counters.
+func (t *authThrottle) Stats() map[string]any {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	locked := 0
+	for _, b := range t.buckets {
+		if now.Before(b.lockedUntil) {
+			locked++
+		}
+	}
+	return map[string]any{
+		"failed":      t.failed,
+		"rejected":    t.rejected,
+		"lockouts":    t.lockouts,
+		"lockedOut":   locked,
+		"tracked":     len(t.buckets),
+		"maxFailures": t.maxFailures,
+	}
+}
+
+// throttleHook puts the auth throttle in front of admin credential
+// checks, counting attempts per client IP and per username.
+type throttleHook struct{ s *Server }
+
+func (h throttleHook) Begin(r *http.Request, user string) error {
+	if wait, ok := h.s.authThrottle.begin(h.s.clientIP(r), user); !ok {
+		return &auth.LockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (h throttleHook) Done(r *http.Request, user string, ok bool) {
+	h.s.authThrottle.done(h.s.clientIP(r), user, ok)
+}
+
+// writeLocked writes a 429 with Retry-After and returns false when err is
+// a lockout, and returns true otherwise.
+func (s *Server) writeLocked(w http.ResponseWriter, err error) bool {
+	var locked *auth.LockedError
+	if !errors.As(err, &locked) {
+		return true
+	}
+	wait := locked.RetryAfter
+	w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+	apierr.Write(w, http.StatusTooManyRequests, apierr.CodeAuthLocked,
+		fmt.Sprintf("too many failed login attempts; retry in %s", wait.Round(time.Second)))
+	return false
+}
+
+// authenticate resolves login credentials to a role, or roleNone when they
+// do not match. While the client or the user is locked out it writes a
+// 429 and returns false without checking the credentials.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, user, pass string) (adminRole, bool) {
+	cred, err := s.adminVerifier().Password(r, user, pass)
+	if !s.writeLocked(w, err) {
+		return roleNone, false
+	}
+	if err != nil {
+		return roleNone, true
+	}
+	return parseAdminRole(cred.Role), true
+}
+
+// clientIP returns the address of r's client. X-Forwarded-For is only
+// believed when the peer is a trusted proxy (security.trustedProxies), and
+// then read from the right, skipping trusted proxies: entries further left
+// were written by the client and could name anyone.
+func (s *Server) clientIP(r *http.Request) string {
+	peer := remoteAddr(r)
+	if !peer.IsValid() {
+		return r.RemoteAddr
+	}
+	if !s.trustedProxy(peer) {
+		return peer.String()
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = addr.Unmap()
+		if !s.trustedProxy(addr) {
+			return addr.String()
+		}
+		peer = addr
+	}
+	return peer.String()
+}
+
+func (s *Server) trustedProxy(addr netip.Addr) bool {
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddr parses the peer address of r's connection.
+func remoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// newThrottleTestServer locks out after 3 failures for 1s, doubling up to
+// 8s, on a clock the test moves.
+func newThrottleTestServer(t *testing.T, proxies ...string) (*Server, *time.Time) {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+		cfg.Security.TrustedProxies = proxies
+		cfg.Security.AuthThrottle = core.AuthThrottleConfig{MaxFailures: 3, LockoutBase: time.Second, LockoutMax: 8 * time.Second}
+	})
+	now := time.Now()
+	s.authThrottle.now = func() time.Time { return now }
+	return s, &now
+}
+
+// adminAttempt sends GET /admin/stats with Basic Auth from remote and
+// returns the status code.
+func adminAttempt(s *Server, remote, user, pass string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/admin/stats", nil)
+	req.RemoteAddr = remote + ":40000"
+	req.Header.Set("Authorization", adminAuthHeader(user, pass))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuthThrottle_LockoutRejectsCorrectCredentialsUntilExpiry(t *testing.T) {
+	s, now := newThrottleTestServer(t)
+
+	for i := 0; i < 3; i++ {
+		if rr := adminAttempt(s, "203.0.113.5", "admin", "guess", nil); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: expected 401, got %d", i+1, rr.Code)
+		}
+	}
+	rr := adminAttempt(s, "203.0.113.5", "admin", "secret", nil)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "1" {
+		t.Fatalf("correct credentials during lockout: expected 429 with Retry-After 1, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if code := decodeJSON(t, rr)["code"]; code != "AUTH_LOCKED" {
+		t.Errorf("expected AUTH_LOCKED, got %v", code)
+	}
+
+	// Each failure after the lockout doubles it.
+	*now = now.Add(time.Second)
+	if rr := adminAttempt(s, "203.0.113.5", "admin", "guess", nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("after expiry the next attempt should be checked, got %d", rr.Code)
+	}
+	if rr := adminAttempt(s, "203.0.113.5", "admin", "secret", nil); rr.Header().Get("Retry-After") != "2" {
+		t.Errorf("the second lockout should last 2s, Retry-After %q", rr.Header().Get("Retry-After"))
+	}
+
+	*now = now.Add(2 * time.Second)
+	if rr := adminAttempt(s, "203.0.113.5", "admin", "secret", nil); rr.Code != http.StatusOK {
+		t.Fatalf("correct credentials after expiry: expected 200, got %d", rr.Code)
+	}
+	// The success cleared the counters: it takes 3 failures again.
+	for i := 0; i < 2; i++ {
+		adminAttempt(s, "203.0.113.5", "admin", "guess", nil)
+	}
+	if rr := adminAttempt(s, "203.0.113.5", "admin", "secret", nil); rr.Code != http.StatusOK {
+		t.Errorf("2 failures after a success should not lock out, got %d", rr.Code)
+	}
+
+	stats := s.authThrottle.Stats()
+	if stats["failed"] != uint64(6) || stats["lockouts"] != uint64(4) || stats["rejected"] != uint64(2) {
+		t.Errorf("unexpected throttle stats: %v", stats)
+	}
+}
+
+func TestAuthThrottle_IndependentIPsAndUsernames(t *testing.T) {
+	s, _ := newThrottleTestServer(t)
+	for i := 0; i < 3; i++ {
+		adminAttempt(s, "203.0.113.5", "alice", "guess", nil)
+	}
+
+	if rr := adminAttempt(s, "198.51.100.7", "admin", "secret", nil); rr.Code != http.StatusOK {
+		t.Errorf("another IP and user should not be affected, got %d", rr.Code)
+	}
+	if rr := adminAttempt(s, "203.0.113.5", "admin", "secret", nil); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("the guessing IP should be locked out for every user, got %d", rr.Code)
+	}
+	if rr := adminAttempt(s, "198.51.100.7", "alice", "guess", nil); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("the guessed username should be locked out from every IP, got %d", rr.Code)
+	}
+
+	// The login endpoint shares the throttle.
+	req := httptest.NewRequest("POST", "/admin/login", strings.NewReader(`{"user":"admin","password":"secret"}`))
+	req.RemoteAddr = "203.0.113.5:40000"
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("/admin/login from a locked-out IP: expected 429, got %d", rr.Code)
+	}
+}
+
+func TestAuthThrottle_ForwardedForOnlyFromTrustedProxies(t *testing.T) {
+	s, _ := newThrottleTestServer(t, "10.0.0.0/8")
+
+	// A direct client cannot move to a fresh bucket by sending the header.
+	for i := 0; i < 3; i++ {
+		adminAttempt(s, "203.0.113.5", "bob", "guess", map[string]string{"X-Forwarded-For": "192.0.2." + strconv.Itoa(i+1)})
+	}
+	if rr := adminAttempt(s, "203.0.113.5", "admin", "secret", map[string]string{"X-Forwarded-For": "192.0.2.99"}); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("a spoofed X-Forwarded-For should not escape the lockout, got %d", rr.Code)
+	}
+
+	// Behind the proxy, the address it appended identifies the client; one
+	// the client wrote itself does not.
+	for i := 0; i < 3; i++ {
+		adminAttempt(s, "10.0.0.2", "carol", "guess", map[string]string{"X-Forwarded-For": "198.51.100.7, 198.51.100.8"})
+	}
+	if rr := adminAttempt(s, "10.0.0.2", "admin", "secret", map[string]string{"X-Forwarded-For": "198.51.100.7"}); rr.Code != http.StatusOK {
+		t.Errorf("a client naming the attacker's spoofed address must not share its lockout, got %d", rr.Code)
+	}
+	if rr := adminAttempt(s, "10.0.0.2", "admin", "secret", map[string]string{"X-Forwarded-For": "192.0.2.50, 198.51.100.8"}); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("the attacker's proxied address should be locked out, got %d", rr.Code)
+	}
+	if rr := adminAttempt(s, "10.0.0.3", "admin", "secret", map[string]string{"X-Forwarded-For": "198.51.100.9"}); rr.Code != http.StatusOK {
+		t.Errorf("other proxied clients should not be affected, got %d", rr.Code)
+	}
+}
+
+func TestAuthThrottle_ConcurrentGuessesVerifyAtMostMaxFailures(t *testing.T) {
+	s, _ := newThrottleTestServer(t)
+
+	const guesses = 50
+	codes := make(chan int, guesses)
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- adminAttempt(s, "203.0.113.5", "admin", "guess", nil).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := make(map[int]int)
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusUnauthorized] > 3 || counts[http.StatusUnauthorized]+counts[http.StatusTooManyRequests] != guesses {
+		t.Errorf("at most 3 guesses should be checked and the rest locked out, got %v", counts)
+	}
+	if rr := adminAttempt(s, "203.0.113.5", "admin", "secret", nil); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected a lockout after the burst, got %d", rr.Code)
+	}
+}
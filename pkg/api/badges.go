@@ -0,0 +1,263 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/lifecycle"
+	"github.com/qubicDB/qubicdb/pkg/share"
+)
+
+// badgePrefix starts the token-authenticated badge routes.
+const badgePrefix = "/v1/badge/"
+
+// badgeLabel is the left-hand text of an SVG badge.
+const badgeLabel = "memory"
+
+// badgeStateColors colours the right-hand side of an SVG badge by state.
+var badgeStateColors = map[string]string{
+	"active":   "#4c1",
+	"idle":     "#97ca00",
+	"sleeping": "#dfb317",
+	"dormant":  "#9f9f9f",
+}
+
+// badgeColor is used when a badge does not show the state.
+const badgeColor = "#007ec6"
+
+// handleBadges creates a badge of the index named by the request (POST
+// /v1/badges). Badges are listed and revoked with the shares at
+// /v1/shares.
+func (s *Server) handleBadges(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	indexID := s.getIndexID(r)
+	if indexID == "" {
+		apierr.IndexIDRequired(w)
+		return
+	}
+
+	var spec share.BadgeSpec
+	if !s.decodeJSONRequest(w, r, &spec) {
+		return
+	}
+	if _, err := s.getWorker(indexID); err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+	sh, token, err := s.shares.CreateBadge(string(indexID), spec)
+	if err != nil {
+		writeShareError(w, err)
+		return
+	}
+	doc := shareDocument(sh)
+	doc["token"] = token
+	doc["json"] = badgePrefix + token + ".json"
+	doc["svg"] = badgePrefix + token + ".svg"
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(doc)
+}
+
+// handleBadge serves GET /v1/badge/{token}.json and .svg: the badge's
+// fields of its index, with last activity truncated to
+// shares.badgeTimeGranularity. Viewing a badge does not count as activity
+// on the index.
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	name := strings.TrimPrefix(r.URL.Path, badgePrefix)
+	token, format := name, ""
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		token, format = name[:i], name[i+1:]
+	}
+	if format != "json" && format != "svg" {
+		apierr.NotFound(w, apierr.CodeNotFound, "badges are served as .json or .svg")
+		return
+	}
+	if r.Method != "GET" && r.Method != "HEAD" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	sh, err := s.shares.Resolve(token)
+	if err == nil && !sh.IsBadge() {
+		err = share.ErrNotFound
+	}
+	if err != nil {
+		writeShareError(w, err)
+		return
+	}
+	if !s.badgeLimiter.allow(sh.ID) {
+		w.Header().Set("Retry-After", strconv.Itoa(s.badgeLimiter.retryAfterSeconds()))
+		apierr.TooManyRequests(w, "badge rate limit exceeded")
+		return
+	}
+
+	indexID := core.IndexID(sh.IndexID)
+	worker, err := s.existingWorker(indexID)
+	if err != nil {
+		// The owning index is gone; the badge is as good as revoked.
+		apierr.NotFound(w, apierr.CodeShareNotFound, share.ErrNotFound.Error())
+		return
+	}
+
+	values, err := s.badgeValues(r, indexID, worker, sh)
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	var body []byte
+	if format == "svg" {
+		body, err = renderBadgeSVG(sh, values)
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		body, err = json.Marshal(values)
+	}
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.config.Shares.BadgeMaxAge.Seconds())))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// existingWorker returns the worker of indexID, loading the index from
+// disk when it is not active. Unlike getWorker it never creates an empty
+// index, and it records no activity; the registry guard applies the same.
+func (s *Server) existingWorker(indexID core.IndexID) (*concurrency.BrainWorker, error) {
+	if s.config.Registry.Enabled && !s.registry.Exists(string(indexID)) {
+		return nil, fmt.Errorf("%s: uuid not registered: %s", apierr.CodeUUIDNotRegistered, indexID)
+	}
+	worker, err := s.pool.Get(indexID)
+	if err != nil && s.pool.Persisted(indexID) {
+		worker, err = s.pool.GetOrCreate(indexID)
+	}
+	return worker, err
+}
+
+// badgeValues returns the fields sh exposes of indexID, keyed by field
+// name. The counts get stats.noise like other tenant stats.
+func (s *Server) badgeValues(r *http.Request, indexID core.IndexID, worker *concurrency.BrainWorker, sh *share.Share) (map[string]any, error) {
+	state := lifecycle.StateName(s.lifecycle.GetState(indexID))
+	stats, err := s.tenantIndexStats(r.Context(), indexID, worker, false)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any, len(sh.Fields))
+	for _, field := range sh.Fields {
+		switch field {
+		case share.BadgeNeurons:
+			values[field] = stats["neuron_count"]
+		case share.BadgeSynapses:
+			values[field] = stats["synapse_count"]
+		case share.BadgeState:
+			values[field] = state
+		case share.BadgeLastActivity:
+			if t, ok := stats["last_activity"].(time.Time); ok && !t.IsZero() {
+				values[field] = t.UTC().Truncate(s.config.Shares.BadgeTimeGranularity)
+			} else {
+				values[field] = nil
+			}
+		case share.BadgeHealth:
+			switch h := stats["health"].(type) {
+			case *engine.Health:
+				if h != nil {
+					values[field] = h.Score
+				} else {
+					values[field] = nil
+				}
+			case map[string]any: // with stats.noise
+				values[field] = h["score"]
+			}
+		}
+	}
+	return values, nil
+}
+
+// badgeSVG is a flat, shields.io-style badge.
+var badgeSVG = template.Must(template.New("badge").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{xml .Label}}: {{xml .Message}}">` +
+		`<title>{{xml .Label}}: {{xml .Message}}</title>` +
+		`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+		`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+		`<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/>` +
+		`<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>` +
+		`<rect width="{{.Width}}" height="20" fill="url(#s)"/></g>` +
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` +
+		`<text x="{{.LabelX}}" y="14">{{xml .Label}}</text><text x="{{.MessageX}}" y="14">{{xml .Message}}</text></g></svg>`))
+
+// renderBadgeSVG renders values as an SVG badge, fields in sh's order.
+func renderBadgeSVG(sh *share.Share, values map[string]any) ([]byte, error) {
+	var parts []string
+	color := badgeColor
+	for _, field := range sh.Fields {
+		switch v := values[field]; field {
+		case share.BadgeNeurons:
+			parts = append(parts, fmt.Sprintf("%v neurons", v))
+		case share.BadgeSynapses:
+			parts = append(parts, fmt.Sprintf("%v synapses", v))
+		case share.BadgeState:
+			parts = append(parts, fmt.Sprint(v))
+			if c, ok := badgeStateColors[fmt.Sprint(v)]; ok {
+				color = c
+			}
+		case share.BadgeLastActivity:
+			if t, ok := v.(time.Time); ok {
+				parts = append(parts, "last active "+t.Format("2006-01-02 15:04"))
+			} else {
+				parts = append(parts, "never active")
+			}
+		case share.BadgeHealth:
+			if v != nil {
+				parts = append(parts, fmt.Sprintf("health %v", v))
+			}
+		}
+	}
+	message := strings.Join(parts, " | ")
+
+	labelWidth, messageWidth := badgeTextWidth(badgeLabel), badgeTextWidth(message)
+	var buf bytes.Buffer
+	err := badgeSVG.Execute(&buf, map[string]any{
+		"Label":        badgeLabel,
+		"Message":      message,
+		"Color":        color,
+		"Width":        labelWidth + messageWidth,
+		"LabelWidth":   labelWidth,
+		"MessageWidth": messageWidth,
+		"LabelX":       labelWidth / 2,
+		"MessageX":     labelWidth + messageWidth/2,
+	})
+	return buf.Bytes(), err
+}
+
+// badgeTextWidth approximates the width of s in 11px Verdana, with padding.
+func badgeTextWidth(s string) int {
+	return 7*len([]rune(s)) + 10
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
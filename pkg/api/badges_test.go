@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/share"
+)
+
+func TestBadges_FieldSetAndPrivacy(t *testing.T) {
+	s := newShareTestServer(t, nil)
+	ownerRequest(t, s, "POST", "/v1/write", `{"content":"the payroll export runs on fridays","metadata":{"team":"finance"}}`, http.StatusOK)
+
+	created := ownerRequest(t, s, "POST", "/v1/badges", `{"fields":["state","neurons"]}`, http.StatusCreated)
+	if created["kind"] != "badge" || !strings.HasPrefix(created["json"].(string), "/v1/badge/qsh_") {
+		t.Fatalf("unexpected badge: %v", created)
+	}
+	if fields := created["fields"].([]any); len(fields) != 2 || fields[0] != "neurons" || fields[1] != "state" {
+		t.Errorf("expected the fields in display order, got %v", fields)
+	}
+
+	body := sharedRequest(t, s, created["json"].(string), http.StatusOK)
+	if len(body) != 2 || body["neurons"] != float64(1) || body["state"] != "active" {
+		t.Fatalf("expected exactly the selected fields, got %v", body)
+	}
+
+	all := ownerRequest(t, s, "POST", "/v1/badges", `{}`, http.StatusCreated)
+	rr := doRequest(t, s, "GET", all["json"].(string), "", nil)
+	raw := rr.Body.String()
+	for _, leak := range []string{"payroll", "finance", "project"} {
+		if strings.Contains(raw, leak) {
+			t.Fatalf("badge leaked %q: %s", leak, raw)
+		}
+	}
+	values := decodeJSON(t, rr)
+	last, err := time.Parse(time.RFC3339, values["lastActivity"].(string))
+	if err != nil || !last.Equal(last.Truncate(time.Hour)) {
+		t.Errorf("expected last activity truncated to the hour, got %v, %v", last, err)
+	}
+	if score, ok := values["health"].(float64); !ok || score <= 0 || score > 100 {
+		t.Errorf("expected the health score, got %v", values["health"])
+	}
+
+	ownerRequest(t, s, "POST", "/v1/badges", `{"fields":["content"]}`, http.StatusBadRequest)
+
+	// A badge token grants no search.
+	token := strings.TrimSuffix(strings.TrimPrefix(created["json"].(string), badgePrefix), ".json")
+	sharedRequest(t, s, sharedPrefix+token+"/recall", http.StatusNotFound)
+
+	// Badges are listed with the shares.
+	list := ownerRequest(t, s, "GET", "/v1/shares", "", http.StatusOK)
+	if list["count"] != float64(2) {
+		t.Errorf("expected both badges in the share list, got %v", list)
+	}
+}
+
+func TestBadges_SVG(t *testing.T) {
+	s := newShareTestServer(t, nil)
+	ownerRequest(t, s, "POST", "/v1/write", `{"content":"<script>alert(1)</script> & friends"}`, http.StatusOK)
+	created := ownerRequest(t, s, "POST", "/v1/badges", `{}`, http.StatusCreated)
+
+	rr := doRequest(t, s, "GET", created["svg"].(string), "", nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("expected an SVG, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	dec := xml.NewDecoder(bytes.NewReader(rr.Body.Bytes()))
+	var root string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("badge is not well-formed XML: %v\n%s", err, rr.Body.String())
+		}
+		if start, ok := tok.(xml.StartElement); ok && root == "" {
+			root = start.Name.Local
+		}
+	}
+	if root != "svg" || !strings.Contains(rr.Body.String(), "1 neurons | 0 synapses | active | last active ") {
+		t.Errorf("unexpected badge: %s", rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "script") {
+		t.Error("badge leaked content")
+	}
+}
+
+func TestBadges_CachingAndRevocation(t *testing.T) {
+	s := newShareTestServer(t, func(cfg *core.Config) {
+		cfg.Shares.BadgeMaxAge = 2 * time.Minute
+	})
+	ownerRequest(t, s, "POST", "/v1/write", `{"content":"a neuron"}`, http.StatusOK)
+	created := ownerRequest(t, s, "POST", "/v1/badges", `{"fields":["neurons"]}`, http.StatusCreated)
+	path := created["json"].(string)
+
+	rr := doRequest(t, s, "GET", path, "", nil)
+	etag := rr.Header().Get("ETag")
+	if etag == "" || rr.Header().Get("Cache-Control") != "public, max-age=120" {
+		t.Fatalf("expected caching headers, got ETag %q, Cache-Control %q", etag, rr.Header().Get("Cache-Control"))
+	}
+	rr = doRequest(t, s, "GET", path, "", map[string]string{"If-None-Match": etag})
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected 304 for a matching ETag, got %d", rr.Code)
+	}
+
+	ownerRequest(t, s, "DELETE", "/v1/shares/"+created["id"].(string), "", http.StatusOK)
+	rr = doRequest(t, s, "GET", path, "", nil)
+	if rr.Code != http.StatusNotFound || rr.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("expected a revoked badge to be 404 and uncached, got %d %q", rr.Code, rr.Header().Get("Cache-Control"))
+	}
+}
+
+func TestBadges_RateLimit(t *testing.T) {
+	s := newShareTestServer(t, func(cfg *core.Config) {
+		cfg.Shares.BadgeRateLimitRequests = 2
+	})
+	ownerRequest(t, s, "POST", "/v1/write", `{"content":"a neuron"}`, http.StatusOK)
+	path := ownerRequest(t, s, "POST", "/v1/badges", `{}`, http.StatusCreated)["svg"].(string)
+	other := ownerRequest(t, s, "POST", "/v1/badges", `{}`, http.StatusCreated)["svg"].(string)
+
+	for i := 0; i < 2; i++ {
+		if rr := doRequest(t, s, "GET", path, "", nil); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rr.Code)
+		}
+	}
+	rr := doRequest(t, s, "GET", path, "", nil)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "GET", other, "", nil); rr.Code != http.StatusOK {
+		t.Errorf("expected another badge to have its own limit, got %d", rr.Code)
+	}
+}
+
+func TestBadges_IndexGone(t *testing.T) {
+	s := newShareTestServer(t, nil)
+
+	// A badge of an index without data is 404 and creates no index.
+	_, token, err := s.shares.CreateBadge("ghost", share.BadgeSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := doRequest(t, s, "GET", badgePrefix+token+".json", "", nil)
+	if rr.Code != http.StatusNotFound || decodeJSON(t, rr)["code"] != apierr.CodeShareNotFound {
+		t.Errorf("expected 404 for a badge of a missing index, got %d", rr.Code)
+	}
+	if _, err := s.pool.Get("ghost"); err == nil {
+		t.Error("serving a badge must not create its index")
+	}
+
+	// An evicted index is loaded from disk.
+	ownerRequest(t, s, "POST", "/v1/write", `{"content":"a neuron"}`, http.StatusOK)
+	path := ownerRequest(t, s, "POST", "/v1/badges", `{"fields":["neurons"]}`, http.StatusCreated)["json"].(string)
+	if err := s.pool.Evict("project"); err != nil {
+		t.Fatal(err)
+	}
+	if body := sharedRequest(t, s, path, http.StatusOK); body["neurons"] != float64(1) {
+		t.Errorf("expected the evicted index's count, got %v", body)
+	}
+
+	// The registry guard turns away an unregistered index.
+	s.config.Registry.Enabled = true
+	sharedRequest(t, s, path, http.StatusNotFound)
+}
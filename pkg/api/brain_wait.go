@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/lifecycle"
+)
+
+const (
+	brainStateWaitPath = "/v1/brain/state/wait"
+
+	// defaultStateWait is the timeout of a state wait that names none.
+	defaultStateWait = 30 * time.Second
+)
+
+// handleBrainStateWait long-polls until the lifecycle state of the index
+// differs from ?current (by default the state at the time of the call), and
+// answers 304 when ?timeout passes first. Waiting neither loads nor wakes
+// the brain, and holds no slot of the endpoint class limiter: waits are
+// capped per index by lifecycle.maxStateWaiters instead.
+func (s *Server) handleBrainStateWait(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	q := r.URL.Query()
+	current := s.lifecycle.GetState(indexID)
+	if raw := q.Get("current"); raw != "" {
+		state, ok := lifecycle.ParseState(raw)
+		if !ok {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "current must be one of active, idle, sleeping, dormant")
+			return
+		}
+		current = state
+	}
+
+	timeout := defaultStateWait
+	if raw := q.Get("timeout"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "timeout must be a positive duration such as 30s")
+			return
+		}
+		timeout = d
+	}
+	if max := s.config.Lifecycle.StateWaitMax; timeout > max {
+		timeout = max
+	}
+
+	// The wait may outlast server.writeTimeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	t, changed, err := s.lifecycle.WaitForChange(ctx, indexID, current, s.config.Lifecycle.MaxStateWaiters)
+	switch {
+	case errors.Is(err, lifecycle.ErrTooManyWaiters):
+		w.Header().Set("Retry-After", "1")
+		apierr.TooManyRequests(w, fmt.Sprintf("index already has %d state waiters (lifecycle.maxStateWaiters)",
+			s.config.Lifecycle.MaxStateWaiters))
+		return
+	case changed:
+		doc := map[string]any{
+			"indexId": indexID,
+			"state":   lifecycle.StateName(t.To),
+		}
+		if !t.At.IsZero() {
+			doc["previousState"] = lifecycle.StateName(t.From)
+			doc["changedAt"] = t.At
+		}
+		json.NewEncoder(w).Encode(doc)
+	case r.Context().Err() != nil:
+		// The client went away; there is no one to answer.
+	default:
+		w.WriteHeader(http.StatusNotModified)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// startStateWait serves a state wait in the background and returns its
+// recorder once it has answered.
+func startStateWait(ctx context.Context, s *Server, index, query string) chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	req := httptest.NewRequest("GET", "/v1/brain/state/wait"+query, nil).WithContext(ctx)
+	req.Header.Set("X-Index-ID", index)
+	go func() {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		done <- rr
+	}()
+	return done
+}
+
+// awaitStateWaiters waits until n state waits are open on index.
+func awaitStateWaiters(t *testing.T, s *Server, index core.IndexID, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.lifecycle.Waiters(index) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d state waiters on %s, got %d", n, index, s.lifecycle.Waiters(index))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newStateWaitServer(t *testing.T, maxWaiters int) *Server {
+	return newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Lifecycle.MaxStateWaiters = maxWaiters
+	})
+}
+
+func TestBrainStateWait_WakesOnTransition(t *testing.T) {
+	s := newStateWaitServer(t, 10)
+	s.lifecycle.ForceWake("wait")
+
+	done := startStateWait(context.Background(), s, "wait", "?current=active&timeout=5s")
+	awaitStateWaiters(t, s, "wait", 1)
+	s.lifecycle.ForceSleep("wait")
+	var rr *httptest.ResponseRecorder
+	select {
+	case rr = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the wait did not return after ForceSleep")
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rr.Code, rr.Body.String())
+	}
+	doc := decodeJSON(t, rr)
+	if doc["state"] != "sleeping" || doc["previousState"] != "active" || doc["indexId"] != "wait" || doc["changedAt"] == nil {
+		t.Errorf("unexpected transition: %v", doc)
+	}
+
+	// Without ?current the wait starts from the state at the time of the call.
+	done = startStateWait(context.Background(), s, "wait", "?timeout=5s")
+	awaitStateWaiters(t, s, "wait", 1)
+	s.lifecycle.ForceWake("wait")
+	rr = <-done
+	if doc := decodeJSON(t, rr); doc["state"] != "active" || doc["previousState"] != "sleeping" {
+		t.Errorf("unexpected wake-up: %v", doc)
+	}
+
+	// A state that already differs answers at once.
+	rr = doRequest(t, s, "GET", "/v1/brain/state/wait?current=dormant", "", map[string]string{"X-Index-ID": "wait"})
+	if doc := decodeJSON(t, rr); rr.Code != http.StatusOK || doc["state"] != "active" {
+		t.Errorf("expected the current state at once, got %d %v", rr.Code, doc)
+	}
+}
+
+func TestBrainStateWait_TimeoutAndValidation(t *testing.T) {
+	s := newStateWaitServer(t, 10)
+	headers := map[string]string{"X-Index-ID": "quiet"}
+	s.lifecycle.ForceWake("quiet")
+	invokes := s.lifecycle.GetBrainState("quiet").InvokeCount
+
+	rr := doRequest(t, s, "GET", "/v1/brain/state/wait?current=active&timeout=20ms", "", headers)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 at the timeout, got %d %s", rr.Code, rr.Body.String())
+	}
+	for _, query := range []string{"?current=awake", "?timeout=soon", "?timeout=-1s"} {
+		if rr := doRequest(t, s, "GET", "/v1/brain/state/wait"+query, "", headers); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+	if rr := doRequest(t, s, "GET", "/v1/brain/state/wait", "", nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without an index, got %d", rr.Code)
+	}
+	if s.lifecycle.GetBrainState("quiet").InvokeCount != invokes {
+		t.Error("waiting must not count as activity")
+	}
+}
+
+func TestBrainStateWait_LimitAndDisconnect(t *testing.T) {
+	s := newStateWaitServer(t, 2)
+	s.lifecycle.ForceWake("busy")
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := startStateWait(ctx, s, "busy", "?current=active&timeout=30s")
+	second := startStateWait(ctx, s, "busy", "?current=active&timeout=30s")
+	awaitStateWaiters(t, s, "busy", 2)
+
+	rr := doRequest(t, s, "GET", "/v1/brain/state/wait?current=active", "", map[string]string{"X-Index-ID": "busy"})
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 past lifecycle.maxStateWaiters, got %d", rr.Code)
+	}
+
+	cancel()
+	for _, done := range []chan *httptest.ResponseRecorder{first, second} {
+		select {
+		case rr := <-done:
+			if rr.Body.Len() != 0 {
+				t.Errorf("nothing should be written to a departed client, got %q", rr.Body.String())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("a disconnected wait did not return")
+		}
+	}
+	awaitStateWaiters(t, s, "busy", 0)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d before, %d after", before, runtime.NumGoroutine())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// Where an index's similarity threshold comes from.
+const (
+	thresholdOverride    = "override"    // registry similarity key
+	thresholdCalibration = "calibration" // registry calibration key
+	thresholdConfig      = "config"      // the server's settings
+)
+
+// resolveSimilarity returns the similarity thresholds of indexID: for each
+// measure the one its registry entry sets, else the one its last
+// calibration derived, else none, leaving the server's settings to apply.
+func (s *Server) resolveSimilarity(indexID core.IndexID) engine.SimilarityThresholds {
+	var t engine.SimilarityThresholds
+	if s.registry == nil {
+		return t
+	}
+	override, calibrated := s.registry.SimilarityThresholds(string(indexID))
+	if v, _ := pickThreshold(override.Vector, calibrated.Vector); v != nil {
+		t.Vector = *v
+	}
+	if v, _ := pickThreshold(override.Lexical, calibrated.Lexical); v != nil {
+		t.Lexical = *v
+	}
+	return t
+}
+
+// pickThreshold returns the threshold that applies of an index's override
+// and calibrated ones, and where it comes from.
+func pickThreshold(override, calibrated *float64) (*float64, string) {
+	switch {
+	case override != nil:
+		return override, thresholdOverride
+	case calibrated != nil:
+		return calibrated, thresholdCalibration
+	}
+	return nil, thresholdConfig
+}
+
+// calibrationOptions converts calibration.* into engine options.
+func (s *Server) calibrationOptions(seed int64) engine.CalibrationOptions {
+	c := s.config.Calibration
+	return engine.CalibrationOptions{
+		Pairs:           c.Pairs,
+		MinPairs:        c.MinPairs,
+		MinWeight:       c.MinWeight,
+		TargetPrecision: c.TargetPrecision,
+		Seed:            seed,
+		Now:             time.Now().UTC(),
+	}
+}
+
+// calibrateIndex calibrates the similarity thresholds of worker's index
+// and, when keep accepts the result, stores it under the registry's
+// calibration key, registering the index if needed, for conflict
+// detection and co-fires to read.
+func (s *Server) calibrateIndex(ctx context.Context, indexID core.IndexID, worker *concurrency.BrainWorker, seed int64, priority concurrency.Priority, keep func(*engine.SimilarityCalibration) bool) (*engine.SimilarityCalibration, error) {
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
+		Type:     concurrency.OpCalibrate,
+		Priority: priority,
+		Payload:  concurrency.CalibrateRequest{Options: s.calibrationOptions(seed)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := result.(*engine.SimilarityCalibration)
+	if !keep(c) {
+		return c, nil
+	}
+
+	// Stored as decoded JSON, as the registry file holds it.
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	if _, _, err := s.registry.FindOrCreate(string(indexID), nil); err != nil {
+		return nil, err
+	}
+	if _, err := s.registry.SetMetadataKey(string(indexID), registry.CalibrationKey, value); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// calibrated reports whether c derived a threshold for some measure.
+func calibrated(c *engine.SimilarityCalibration) bool {
+	return c.Lexical.Threshold != nil || (c.Vector != nil && c.Vector.Threshold != nil)
+}
+
+// handleAdminCalibrate calibrates an index's similarity thresholds
+// (POST /admin/indexes/{id}/calibrate[?seed=N]) and stores them, replacing
+// its previous calibration, even when no measure could be calibrated.
+func (s *Server) handleAdminCalibrate(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	seed := time.Now().UnixNano()
+	if raw := r.URL.Query().Get("seed"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "seed must be an integer")
+			return
+		}
+		seed = parsed
+	}
+	worker, err := s.pool.GetOrCreate(indexID)
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+	if _, err := s.calibrateIndex(r.Context(), indexID, worker, seed, concurrency.PriorityInteractive, func(*engine.SimilarityCalibration) bool { return true }); err != nil {
+		if !writeRegistryConfigError(w, err) {
+			apierr.Internal(w, err.Error())
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(s.calibrationReport(indexID))
+}
+
+// handleAdminCalibration reports an index's last calibration and the
+// similarity thresholds its consumers use
+// (GET /admin/indexes/{id}/calibration).
+func (s *Server) handleAdminCalibration(w http.ResponseWriter, indexID core.IndexID) {
+	json.NewEncoder(w).Encode(s.calibrationReport(indexID))
+}
+
+// calibrationReport describes indexID's similarity calibration: the one
+// stored, null when it has none, where each measure's threshold comes from
+// and the thresholds conflict detection and co-fires use as a result.
+func (s *Server) calibrationReport(indexID core.IndexID) map[string]any {
+	var stored any
+	var override, calibratedThresholds registry.SimilarityThresholds
+	if s.registry != nil {
+		if entry, ok := s.registry.Get(string(indexID)); ok {
+			stored = entry.Metadata[registry.CalibrationKey]
+		}
+		override, calibratedThresholds = s.registry.SimilarityThresholds(string(indexID))
+	}
+	vector, vectorSource := pickThreshold(override.Vector, calibratedThresholds.Vector)
+	lexical, lexicalSource := pickThreshold(override.Lexical, calibratedThresholds.Lexical)
+
+	t := s.pool.SimilarityThresholds(indexID)
+	conflicts := s.conflictOptions(t)
+	vectorFloor, lexicalFloor := s.config.Search.Reinforce.SimilarityFloor, s.config.Search.Reinforce.SimilarityFloor
+	if t.Vector > 0 {
+		vectorFloor = t.Vector
+	}
+	if t.Lexical > 0 {
+		lexicalFloor = t.Lexical
+	}
+	return map[string]any{
+		"indexId":     indexID,
+		"calibration": stored,
+		"thresholds": map[string]any{
+			"vector":  map[string]any{"value": vector, "source": vectorSource},
+			"lexical": map[string]any{"value": lexical, "source": lexicalSource},
+		},
+		"conflicts": map[string]any{
+			"vectorThreshold":  conflicts.VectorThreshold,
+			"lexicalThreshold": conflicts.LexicalThreshold,
+		},
+		"coFireFloor": map[string]any{
+			"vector":  vectorFloor,
+			"lexical": lexicalFloor,
+		},
+	}
+}
+
+// StartCalibration registers the daemon recalibrating the similarity
+// thresholds of every loaded index each calibration.interval. It needs the
+// daemon manager, so call it after SetDaemonManager.
+func (s *Server) StartCalibration() {
+	interval := s.config.Calibration.Interval
+	if s.daemons == nil || interval <= 0 {
+		return
+	}
+	s.daemons.Add("calibration", func() time.Duration { return interval }, s.calibrationPass)
+	log.Printf("Calibration daemon started (interval=%s, pairs=%d, targetPrecision=%g)", interval, s.config.Calibration.Pairs, s.config.Calibration.TargetPrecision)
+}
+
+// calibrationPass recalibrates every loaded index at background priority.
+// A calibration that derives no threshold is not stored, so an index keeps
+// its previous one until it has enough linked pairs again. Unloaded
+// indexes are not loaded for one.
+func (s *Server) calibrationPass() (int, error) {
+	stored := 0
+	var errs []error
+	s.pool.ForEach(func(id core.IndexID, worker *concurrency.BrainWorker) {
+		c, err := s.calibrateIndex(context.Background(), id, worker, time.Now().UnixNano(), concurrency.PriorityBackground, calibrated)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			return
+		}
+		if calibrated(c) {
+			stored++
+		}
+	})
+	return stored, errors.Join(errs...)
+}
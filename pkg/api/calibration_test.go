@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// writeCalibrationIndex writes ten topics of four memories to index, each
+// linked to the previous one of its topic, with content from profile.
+func writeCalibrationIndex(t *testing.T, s *Server, index string, profile func(rng *rand.Rand, topic, i int) string) {
+	t.Helper()
+	headers := map[string]string{"X-Index-ID": index}
+	rng := rand.New(rand.NewSource(1))
+	for topic := 0; topic < 10; topic++ {
+		prev := ""
+		for i := 0; i < 4; i++ {
+			related := ""
+			if prev != "" {
+				related = fmt.Sprintf(`,"related_ids":[%q]`, prev)
+			}
+			rr := doRequest(t, s, "POST", "/v1/write", fmt.Sprintf(`{"content":%q%s}`, profile(rng, topic, i), related), headers)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+			}
+			prev = decodeJSON(t, rr)["id"].(string)
+		}
+	}
+}
+
+func terseFact(_ *rand.Rand, topic, i int) string {
+	return fmt.Sprintf("topic%dalpha topic%dbeta fact%dx%d", topic, topic, topic, i)
+}
+
+func longDocument(rng *rand.Rand, topic, _ int) string {
+	words := make([]string, 0, 40)
+	for k := 0; k < 10; k++ {
+		words = append(words, fmt.Sprintf("topic%dword%d", topic, k))
+	}
+	for k := 0; k < 30; k++ {
+		words = append(words, fmt.Sprintf("common%d", rng.Intn(300)))
+	}
+	return strings.Join(words, " ")
+}
+
+func TestCalibration_PerIndexThresholds(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Calibration.MinPairs = 10
+	})
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	writeCalibrationIndex(t, s, "facts", terseFact)
+	writeCalibrationIndex(t, s, "documents", longDocument)
+
+	rr := doRequest(t, s, "GET", "/admin/indexes/facts/calibration", "", admin)
+	if report := decodeJSON(t, rr); rr.Code != http.StatusOK || report["calibration"] != nil {
+		t.Fatalf("expected no calibration yet, got %d %v", rr.Code, report)
+	}
+
+	thresholds := map[string]float64{}
+	for _, index := range []string{"facts", "documents"} {
+		rr := doRequest(t, s, "POST", "/admin/indexes/"+index+"/calibrate?seed=7", "", admin)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: calibrate: %d %s", index, rr.Code, rr.Body.String())
+		}
+		report := decodeJSON(t, doRequest(t, s, "GET", "/admin/indexes/"+index+"/calibration", "", admin))
+		lexical := report["calibration"].(map[string]any)["lexical"].(map[string]any)
+		if lexical["related"].(map[string]any)["pairs"].(float64) < 30 || len(lexical["random"].(map[string]any)["histogram"].([]any)) != 10 {
+			t.Fatalf("%s: expected the distributions reported, got %v", index, lexical)
+		}
+		threshold, ok := lexical["threshold"].(float64)
+		if !ok {
+			t.Fatalf("%s: expected a lexical threshold, got %v", index, lexical)
+		}
+		thresholds[index] = threshold
+
+		// Conflict detection and co-fires use the calibrated threshold.
+		chosen := report["thresholds"].(map[string]any)["lexical"].(map[string]any)
+		if chosen["value"] != threshold || chosen["source"] != "calibration" {
+			t.Errorf("%s: expected the calibrated threshold chosen, got %v", index, chosen)
+		}
+		if got := report["conflicts"].(map[string]any)["lexicalThreshold"]; got != threshold {
+			t.Errorf("%s: expected conflicts at %v, got %v", index, threshold, got)
+		}
+		if got := report["coFireFloor"].(map[string]any)["lexical"]; got != threshold {
+			t.Errorf("%s: expected the co-fire floor at %v, got %v", index, threshold, got)
+		}
+		worker, err := s.pool.GetOrCreate(core.IndexID(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := worker.SimilarityThresholds().Lexical; got != threshold {
+			t.Errorf("%s: expected the worker to read %v, got %v", index, threshold, got)
+		}
+	}
+	if thresholds["documents"] >= thresholds["facts"] {
+		t.Errorf("expected long documents calibrated below terse facts, got %v", thresholds)
+	}
+
+	// An explicit threshold still wins over the calibrated one.
+	if _, err := s.registry.SetMetadataKey("facts", registry.SimilarityKey, map[string]any{"lexicalThreshold": 0.42}); err != nil {
+		t.Fatal(err)
+	}
+	report := decodeJSON(t, doRequest(t, s, "GET", "/admin/indexes/facts/calibration", "", admin))
+	chosen := report["thresholds"].(map[string]any)["lexical"].(map[string]any)
+	if chosen["value"] != 0.42 || chosen["source"] != "override" || report["conflicts"].(map[string]any)["lexicalThreshold"] != 0.42 {
+		t.Errorf("expected the override to apply, got %v", report)
+	}
+	if _, err := s.registry.SetMetadataKey("facts", registry.SimilarityKey, map[string]any{"lexicalThreshold": 1.5}); !errors.Is(err, registry.ErrInvalidSimilarity) {
+		t.Errorf("expected ErrInvalidSimilarity, got %v", err)
+	}
+
+	if rr := doRequest(t, s, "POST", "/admin/indexes/facts/calibrate?seed=x", "", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad seed, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+type cloneRequest struct {
+	Target    string `json:"target"`
+	Anonymize bool   `json:"anonymize"`
+}
+
+// handleAdminClone copies an index into a new index ID server-side
+// (POST /admin/indexes/{src}/clone). ?force=true replaces a target that
+// already holds data; an append-only target also needs
+// ?override_append_only=true.
+func (s *Server) handleAdminClone(w http.ResponseWriter, r *http.Request, src core.IndexID) {
+	var req cloneRequest
+	if !s.decodeJSONRequest(w, r, &req) {
+		return
+	}
+	if req.Target == "" {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "target is required")
+		return
+	}
+	target := core.IndexID(req.Target)
+	if target == src {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "target must differ from the source index")
+		return
+	}
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	if force && !s.allowDestructiveAdmin(w, r, target, "clone over") {
+		return
+	}
+
+	var transform func(*core.Matrix)
+	if req.Anonymize {
+		cfg := s.config.Admin.Clone
+		transform = func(m *core.Matrix) { anonymizeMatrix(m, cfg) }
+	}
+	clone, err := s.pool.Clone(src, target, force, transform)
+	switch {
+	case errors.Is(err, core.ErrMatrixNotFound):
+		apierr.NotFound(w, apierr.CodeNotFound, "index not found")
+		return
+	case errors.Is(err, core.ErrIndexNotEmpty):
+		apierr.Conflict(w, apierr.CodeConflict, "target index already holds data; use ?force=true to replace it")
+		return
+	case err != nil:
+		apierr.Internal(w, err.Error())
+		return
+	}
+
+	registered := false
+	if s.config.Registry.Enabled {
+		if _, _, err := s.registry.FindOrCreate(req.Target, nil); err != nil {
+			apierr.Internal(w, err.Error())
+			return
+		}
+		registered = true
+	}
+	s.lifecycle.RemoveIndex(target)
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"cloned":     true,
+		"source":     src,
+		"target":     target,
+		"neurons":    len(clone.Neurons),
+		"synapses":   len(clone.Synapses),
+		"anonymized": req.Anonymize,
+		"registered": registered,
+	})
+}
+
+// anonymizeMatrix scrubs a cloned matrix in place: content is hashed or
+// redacted per cfg.ContentMode, tags are dropped and the configured
+// metadata keys removed. Embeddings, energies and synapses are kept so
+// retrieval on the clone behaves like the source.
+func anonymizeMatrix(m *core.Matrix, cfg core.CloneConfig) {
+	for _, n := range m.Neurons {
+		core.AnonymizeNeuron(n, cfg.ContentMode, cfg.StripMetadataKeys)
+	}
+	m.Terms = nil         // rebuilt from the scrubbed content on first search
+	m.MetadataIndex = nil // and from the remaining metadata
+}
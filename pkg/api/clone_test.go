@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func newCloneTestServer(t *testing.T, mutator func(*core.Config)) *Server {
+	return newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Password = "secret"
+		if mutator != nil {
+			mutator(cfg)
+		}
+	})
+}
+
+func cloneIndex(t *testing.T, s *Server, src, query, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return doRequest(t, s, "POST", "/admin/indexes/"+src+"/clone"+query, body, map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+}
+
+func indexContents(t *testing.T, s *Server, indexID string) []string {
+	t.Helper()
+	worker, err := s.pool.GetOrCreate(core.IndexID(indexID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := worker.Matrix()
+	m.RLock()
+	defer m.RUnlock()
+	var out []string
+	for _, n := range m.Neurons {
+		out = append(out, n.Content)
+	}
+	return out
+}
+
+func TestAdminClone_CopyIsIndependent(t *testing.T) {
+	s := newCloneTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	writeNeuron(t, s, "src", "likes hiking in the alps")
+	writeNeuron(t, s, "src", "allergic to peanuts")
+
+	resp := cloneIndex(t, s, "src", "", `{"target":"copy-1"}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("clone failed: %d %s", resp.Code, resp.Body.String())
+	}
+	if body := decodeJSON(t, resp); body["neurons"] != float64(2) || body["synapses"] == nil || body["target"] != "copy-1" {
+		t.Fatalf("unexpected clone response: %v", body)
+	}
+
+	// Mutating either side leaves the other untouched.
+	writeNeuron(t, s, "copy-1", "only in the copy")
+	srcWorker, _ := s.pool.GetOrCreate("src")
+	for _, n := range srcWorker.Matrix().Neurons {
+		if _, err := srcWorker.Submit(&concurrency.Operation{
+			Type:    concurrency.OpTouch,
+			Payload: concurrency.UpdateNeuronRequest{ID: n.ID, Content: "rewritten in source"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+
+	src, cp := indexContents(t, s, "src"), indexContents(t, s, "copy-1")
+	if len(src) != 2 || containsString(src, "only in the copy") {
+		t.Errorf("source changed by writes to the copy: %v", src)
+	}
+	if len(cp) != 3 || containsString(cp, "rewritten in source") {
+		t.Errorf("copy changed by updates to the source: %v", cp)
+	}
+
+	// A source that only exists on disk clones too.
+	if err := s.pool.Evict("src"); err != nil {
+		t.Fatal(err)
+	}
+	if resp := cloneIndex(t, s, "src", "", `{"target":"copy-2"}`); resp.Code != http.StatusOK {
+		t.Fatalf("clone of persisted index failed: %d %s", resp.Code, resp.Body.String())
+	}
+	if got := indexContents(t, s, "copy-2"); len(got) != 2 || !containsString(got, "rewritten in source") {
+		t.Errorf("clone of persisted index has wrong contents: %v", got)
+	}
+}
+
+func TestAdminClone_AnonymizesAndGuardsTarget(t *testing.T) {
+	s := newCloneTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = true
+		cfg.Admin.Clone.StripMetadataKeys = []string{"email"}
+	})
+	if _, _, err := s.registry.FindOrCreate("prod-user", nil); err != nil {
+		t.Fatal(err)
+	}
+	rr := doRequest(t, s, "POST", "/v1/write",
+		`{"content":"call me at 555-0100","metadata":{"email":"a@example.com","thread_id":"t1"}}`,
+		map[string]string{"X-Index-ID": "prod-user"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	resp := cloneIndex(t, s, "prod-user", "", `{"target":"qa-copy","anonymize":true}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("clone failed: %d %s", resp.Code, resp.Body.String())
+	}
+	if body := decodeJSON(t, resp); body["anonymized"] != true || body["registered"] != true {
+		t.Fatalf("unexpected clone response: %v", body)
+	}
+	if !s.registry.Exists("qa-copy") {
+		t.Error("clone target should be registered")
+	}
+
+	worker, _ := s.pool.GetOrCreate("qa-copy")
+	for _, n := range worker.Matrix().Neurons {
+		if !strings.HasPrefix(n.Content, "sha256:") || strings.Contains(n.Content, "555") {
+			t.Errorf("content not anonymized: %q", n.Content)
+		}
+		if _, ok := n.Metadata["email"]; ok {
+			t.Errorf("email metadata should be stripped: %v", n.Metadata)
+		}
+		if n.Metadata["thread_id"] != "t1" {
+			t.Errorf("unconfigured metadata should be kept: %v", n.Metadata)
+		}
+	}
+	if got := indexContents(t, s, "prod-user"); !containsString(got, "call me at 555-0100") {
+		t.Errorf("anonymizing the clone changed the source: %v", got)
+	}
+
+	if resp := cloneIndex(t, s, "prod-user", "", `{"target":"qa-copy"}`); resp.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a non-empty target, got %d %s", resp.Code, resp.Body.String())
+	}
+	if resp := cloneIndex(t, s, "prod-user", "?force=true", `{"target":"qa-copy"}`); resp.Code != http.StatusOK {
+		t.Errorf("expected forced clone to succeed, got %d %s", resp.Code, resp.Body.String())
+	}
+	if got := indexContents(t, s, "qa-copy"); !containsString(got, "call me at 555-0100") {
+		t.Errorf("forced plain clone should replace the anonymized copy, got %v", got)
+	}
+
+	if resp := cloneIndex(t, s, "missing", "", `{"target":"x"}`); resp.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing source, got %d", resp.Code)
+	}
+	if resp := cloneIndex(t, s, "prod-user", "", `{}`); resp.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a target, got %d", resp.Code)
+	}
+}
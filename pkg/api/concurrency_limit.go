@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// endpointClass groups endpoints that share a concurrency budget.
+type endpointClass string
+
+const (
+	classSearch  endpointClass = "search"
+	classContext endpointClass = "context"
+	classWrite   endpointClass = "write"
+	classAdmin   endpointClass = "admin"
+	classDefault endpointClass = "default"
+
+	// concurrencyHeaderThreshold is the utilization above which the limiter
+	// reports its state back to the client in response headers.
+	concurrencyHeaderThreshold = 0.8
+)
+
+// classifyEndpoint maps a request path to its concurrency class.
+// MCP traffic never reaches the limiter; it is exempted in withMiddleware.
+func classifyEndpoint(path string) endpointClass {
+	switch {
+	case path == "/v1/search", path == "/v1/recall", path == "/v1/sync",
+		strings.HasPrefix(path, "/v1/shared/"):
+		return classSearch
+	case path == "/v1/context":
+		return classContext
+	case path == "/v1/write", path == "/v1/touch", path == "/v1/forget",
+		strings.HasPrefix(path, "/v1/forget/"), strings.HasPrefix(path, "/v1/fire/"),
+		strings.HasPrefix(path, "/v1/pin/"), strings.HasPrefix(path, "/v1/recycle/"), path == "/v1/import/markdown", path == importBatchPath, path == attachmentsPath:
+		return classWrite
+	case strings.HasPrefix(path, "/admin/"), path == "/v1/config":
+		return classAdmin
+	default:
+		return classDefault
+	}
+}
+
+// classLimiter is a counting semaphore for one endpoint class.
+// A nil slots channel means the class is unlimited; counters are still kept.
+type classLimiter struct {
+	limit    int
+	slots    chan struct{}
+	inFlight atomic.Int64
+	queued   atomic.Int64
+	rejected atomic.Uint64
+}
+
+func newClassLimiter(limit int) *classLimiter {
+	cl := &classLimiter{limit: limit}
+	if limit > 0 {
+		cl.slots = make(chan struct{}, limit)
+	}
+	return cl
+}
+
+// acquire takes a slot, waiting up to timeout. It returns false when the
+// wait expires or ctx is cancelled first.
+func (cl *classLimiter) acquire(ctx context.Context, timeout time.Duration) bool {
+	if cl.slots == nil {
+		cl.inFlight.Add(1)
+		return true
+	}
+
+	select {
+	case cl.slots <- struct{}{}:
+		cl.inFlight.Add(1)
+		return true
+	default:
+	}
+
+	if timeout <= 0 {
+		cl.rejected.Add(1)
+		return false
+	}
+
+	cl.queued.Add(1)
+	defer cl.queued.Add(-1)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case cl.slots <- struct{}{}:
+		cl.inFlight.Add(1)
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	cl.rejected.Add(1)
+	return false
+}
+
+func (cl *classLimiter) release() {
+	cl.inFlight.Add(-1)
+	if cl.slots != nil {
+		<-cl.slots
+	}
+}
+
+// utilization returns inFlight/limit, or 0 for unlimited classes.
+func (cl *classLimiter) utilization() float64 {
+	if cl.limit <= 0 {
+		return 0
+	}
+	return float64(cl.inFlight.Load()) / float64(cl.limit)
+}
+
+// setHeaders reports limiter state on the response once a class is busy.
+func (cl *classLimiter) setHeaders(h http.Header, class endpointClass) {
+	if cl.utilization() < concurrencyHeaderThreshold {
+		return
+	}
+	h.Set("X-Concurrency-Class", string(class))
+	h.Set("X-Concurrency-Limit", strconv.Itoa(cl.limit))
+	h.Set("X-Concurrency-In-Flight", strconv.FormatInt(cl.inFlight.Load(), 10))
+	h.Set("X-Concurrency-Queued", strconv.FormatInt(cl.queued.Load(), 10))
+}
+
+// concurrencyLimiter enforces per-class caps on in-flight requests.
+// It protects the worker pool globally, independent of per-client rate limits.
+type concurrencyLimiter struct {
+	queueTimeout time.Duration
+	classes      map[endpointClass]*classLimiter
+}
+
+func newConcurrencyLimiter(cfg core.ConcurrencyConfig) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		queueTimeout: cfg.QueueTimeout,
+		classes: map[endpointClass]*classLimiter{
+			classSearch:  newClassLimiter(cfg.Search),
+			classContext: newClassLimiter(cfg.Context),
+			classWrite:   newClassLimiter(cfg.Write),
+			classAdmin:   newClassLimiter(cfg.Admin),
+			classDefault: newClassLimiter(cfg.Default),
+		},
+	}
+}
+
+// retryAfterSeconds suggests a Retry-After value for rejected requests.
+func (l *concurrencyLimiter) retryAfterSeconds() int {
+	secs := int((l.queueTimeout + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+// Stats returns the current limit, in-flight, queued and rejected counts per class.
+func (l *concurrencyLimiter) Stats() map[string]any {
+	out := make(map[string]any, len(l.classes)+1)
+	for class, cl := range l.classes {
+		out[string(class)] = map[string]any{
+			"limit":    cl.limit,
+			"inFlight": cl.inFlight.Load(),
+			"queued":   cl.queued.Load(),
+			"rejected": cl.rejected.Load(),
+		}
+	}
+	out["queueTimeout"] = l.queueTimeout.String()
+	return out
+}
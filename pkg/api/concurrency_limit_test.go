@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// slowHandler blocks every request until release is closed, signalling entry
+// on started. It stands in for a slow worker operation.
+func slowHandler(started chan<- string, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- r.URL.Path
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serveAsync(h http.Handler, method, path string) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		done <- rr
+	}()
+	return done
+}
+
+func TestClassifyEndpoint(t *testing.T) {
+	cases := map[string]endpointClass{
+		"/v1/search":          classSearch,
+		"/v1/recall":          classSearch,
+		"/v1/sync":            classSearch,
+		"/v1/context":         classContext,
+		"/v1/write":           classWrite,
+		"/v1/touch":           classWrite,
+		"/v1/forget/abc":      classWrite,
+		"/v1/forget":          classWrite,
+		"/v1/fire/abc":        classWrite,
+		"/v1/pin/abc":         classWrite,
+		"/v1/import/markdown": classWrite,
+		"/v1/import":          classWrite,
+		"/admin/indexes":      classAdmin,
+		"/v1/config":          classAdmin,
+		"/health":             classDefault,
+		"/v1/stats":           classDefault,
+		"/v1/registry/x-y-z":  classDefault,
+	}
+	for path, want := range cases {
+		if got := classifyEndpoint(path); got != want {
+			t.Errorf("classifyEndpoint(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestConcurrencyLimit_RejectsBeyondCap(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Server.Concurrency.Search = 1
+		cfg.Server.Concurrency.QueueTimeout = 50 * time.Millisecond
+	})
+
+	started := make(chan string, 4)
+	release := make(chan struct{})
+	h := s.withMiddleware(slowHandler(started, release))
+
+	first := serveAsync(h, "GET", "/v1/search")
+	<-started
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/search", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 beyond the cap, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on 503")
+	}
+	if rr.Header().Get("X-Concurrency-Class") != "search" {
+		t.Errorf("expected X-Concurrency-Class=search, got %q", rr.Header().Get("X-Concurrency-Class"))
+	}
+	if m := decodeJSON(t, rr); m["code"] != "SERVER_BUSY" {
+		t.Errorf("expected code SERVER_BUSY, got %v", m["code"])
+	}
+
+	close(release)
+	if res := <-first; res.Code != http.StatusOK {
+		t.Errorf("in-flight request should complete with 200, got %d", res.Code)
+	}
+
+	stats := s.concurrency.Stats()["search"].(map[string]any)
+	if stats["rejected"].(uint64) != 1 {
+		t.Errorf("expected 1 rejected search request, got %v", stats["rejected"])
+	}
+	if stats["inFlight"].(int64) != 0 {
+		t.Errorf("expected 0 in-flight after completion, got %v", stats["inFlight"])
+	}
+}
+
+func TestConcurrencyLimit_QueuesUntilSlotFrees(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Server.Concurrency.Context = 1
+		cfg.Server.Concurrency.QueueTimeout = 5 * time.Second
+	})
+
+	started := make(chan string, 4)
+	release := make(chan struct{})
+	h := s.withMiddleware(slowHandler(started, release))
+
+	first := serveAsync(h, "POST", "/v1/context")
+	<-started
+	second := serveAsync(h, "POST", "/v1/context")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.concurrency.classes[classContext].queued.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("second request never queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(release)
+	if res := <-first; res.Code != http.StatusOK {
+		t.Errorf("first request expected 200, got %d", res.Code)
+	}
+	<-started
+	if res := <-second; res.Code != http.StatusOK {
+		t.Errorf("queued request expected 200 once a slot freed, got %d", res.Code)
+	}
+}
+
+func TestConcurrencyLimit_OtherClassesUnaffected(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Server.Concurrency.Context = 1
+		cfg.Server.Concurrency.QueueTimeout = 10 * time.Millisecond
+	})
+
+	started := make(chan string, 8)
+	release := make(chan struct{})
+	h := s.withMiddleware(slowHandler(started, release))
+
+	blocked := serveAsync(h, "POST", "/v1/context")
+	<-started
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 3)
+	for _, path := range []string{"/v1/search", "/v1/write", "/health"} {
+		wg.Add(1)
+		done := serveAsync(h, "GET", path)
+		go func() {
+			defer wg.Done()
+			codes <- (<-done).Code
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	close(release)
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request in another class should not be limited, got %d", code)
+		}
+	}
+	if res := <-blocked; res.Code != http.StatusOK {
+		t.Errorf("blocked context request expected 200, got %d", res.Code)
+	}
+}
+
+func TestConcurrencyLimit_ZeroMeansUnlimited(t *testing.T) {
+	cl := newClassLimiter(0)
+	for i := 0; i < 100; i++ {
+		if !cl.acquire(context.Background(), 0) {
+			t.Fatalf("unlimited class rejected request %d", i)
+		}
+	}
+	if cl.inFlight.Load() != 100 {
+		t.Errorf("expected 100 in-flight, got %d", cl.inFlight.Load())
+	}
+}
+
+func TestConcurrencyLimit_StatsExposed(t *testing.T) {
+	s := newTestServer(t, nil)
+	rr := doRequest(t, s, "GET", "/admin/stats", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	m := decodeJSON(t, rr)
+	conc, ok := m["concurrency"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected concurrency section in stats, got %v", m["concurrency"])
+	}
+	for _, class := range []string{"search", "context", "write", "admin", "default"} {
+		if _, ok := conc[class]; !ok {
+			t.Errorf("missing concurrency stats for class %q", class)
+		}
+	}
+}
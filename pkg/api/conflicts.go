@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// conflictOptions converts write.conflicts into engine options, with the
+// thresholds an index's similarity settings t set in place of the server's.
+func (s *Server) conflictOptions(t engine.SimilarityThresholds) engine.ConflictOptions {
+	c := s.config.Write.Conflicts
+	opts := engine.ConflictOptions{
+		TopK:             c.TopK,
+		VectorThreshold:  c.VectorThreshold,
+		LexicalThreshold: c.LexicalThreshold,
+		MinEnergy:        c.MinEnergy,
+		Keys:             c.Keys,
+	}
+	if t.Vector > 0 {
+		opts.VectorThreshold = t.Vector
+	}
+	if t.Lexical > 0 {
+		opts.LexicalThreshold = t.Lexical
+	}
+	return opts
+}
+
+// detectConflicts flags existing neurons that id may contradict and returns
+// them for the write response. Detection is advisory: a failure is treated
+// as no conflicts so the write still succeeds.
+func (s *Server) detectConflicts(ctx context.Context, worker *concurrency.BrainWorker, id core.NeuronID) []map[string]any {
+	conflicts := []map[string]any{}
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
+		Type:    concurrency.OpDetectConflicts,
+		Payload: concurrency.DetectConflictsRequest{ID: id, Options: s.conflictOptions(worker.SimilarityThresholds())},
+	})
+	if err != nil {
+		return conflicts
+	}
+	for _, c := range result.([]engine.Conflict) {
+		conflicts = append(conflicts, map[string]any{
+			"group":      c.Group,
+			"id":         string(c.Neuron.ID),
+			"content":    c.Neuron.Content,
+			"energy":     c.Neuron.Energy,
+			"similarity": c.Similarity,
+			"reason":     c.Reason,
+		})
+	}
+	return conflicts
+}
+
+// handleConflicts lists the conflict groups flagged in an index
+// (GET /v1/conflicts). Each group lists its neurons oldest first.
+func (s *Server) handleConflicts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpListConflicts})
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+
+	groups := result.([]engine.ConflictGroup)
+	items := make([]map[string]any, len(groups))
+	for i, g := range groups {
+		neurons := make([]map[string]any, len(g.Neurons))
+		for j, n := range g.Neurons {
+			neurons[j] = s.neuronDocument(r.Context(), n)
+		}
+		items[i] = map[string]any{"group": g.ID, "neurons": neurons}
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"groups": items,
+		"count":  len(items),
+	})
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func writeWithConflicts(t *testing.T, s *Server, indexID, content string) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, map[string]string{
+		"X-Index-ID":   indexID,
+		"Content-Type": "application/json",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func TestWrite_ReportsConflicts(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Write.DetectConflicts = true
+	})
+
+	first := writeWithConflicts(t, s, "people", "Bob lives in Chicago")
+	if c, ok := first["conflicts"].([]any); !ok || len(c) != 0 {
+		t.Fatalf("expected an empty conflicts array, got %v", first["conflicts"])
+	}
+	writeWithConflicts(t, s, "people", "Bob works at Initech as an analyst")
+
+	second := writeWithConflicts(t, s, "people", "Bob lives in Seattle")
+	conflicts, _ := second["conflicts"].([]any)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected one conflict, got %v", second["conflicts"])
+	}
+	c := conflicts[0].(map[string]any)
+	if c["id"] != first["id"] || c["reason"] != "fact:location" {
+		t.Errorf("unexpected conflict: %v", c)
+	}
+	if md, _ := second["metadata"].(map[string]any); md["_conflict_group"] != c["group"] {
+		t.Errorf("new neuron should carry the conflict group, got metadata %v", second["metadata"])
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/conflicts", "", map[string]string{"X-Index-ID": "people"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("conflicts list failed: %d %s", rr.Code, rr.Body.String())
+	}
+	groups := decodeJSON(t, rr)["groups"].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("expected one group, got %v", groups)
+	}
+	g := groups[0].(map[string]any)
+	if g["group"] != c["group"] || len(g["neurons"].([]any)) != 2 {
+		t.Errorf("unexpected group: %v", g)
+	}
+}
+
+func TestWrite_ConflictDetectionDisabled(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	writeWithConflicts(t, s, "people", "Bob lives in Chicago")
+	second := writeWithConflicts(t, s, "people", "Bob lives in Seattle")
+	if _, ok := second["conflicts"]; ok {
+		t.Errorf("conflicts must be omitted when detection is off, got %v", second["conflicts"])
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/conflicts", "", map[string]string{"X-Index-ID": "people"})
+	if m := decodeJSON(t, rr); m["count"].(float64) != 0 {
+		t.Errorf("expected no groups, got %v", m)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/conformance"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// TestConformance runs the conformance suite against an in-process server
+// with every optional feature it checks enabled, so the suite and the
+// server cannot drift apart.
+func TestConformance(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+		cfg.Registry.Enabled = true
+		cfg.Sessions.Enabled = true
+		cfg.Subscriptions.Enabled = true
+		cfg.Shares.Enabled = true
+	})
+	ts := httptest.NewServer(s.httpServer.Handler)
+	defer ts.Close()
+
+	report, err := conformance.Run(context.Background(), conformance.Options{
+		BaseURL:       ts.URL,
+		AdminUser:     "admin",
+		AdminPassword: "secret",
+		Client:        ts.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range report.Results {
+		if res.Status != conformance.Pass {
+			data, _ := json.MarshalIndent(res, "", "  ")
+			t.Errorf("%s: %s", res.Status, data)
+		}
+	}
+	if n := len(conformance.Checks()); report.Passed != n {
+		t.Errorf("expected all %d checks to pass, %d did", n, report.Passed)
+	}
+}
+
+// TestConformance_CoversEveryErrorCode fails when an apierr code is added
+// without a check or a reason in conformance.UncoveredCodes.
+func TestConformance_CoversEveryErrorCode(t *testing.T) {
+	src, err := os.ReadFile("apierr/errors.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := map[string]bool{}
+	for _, c := range conformance.Checks() {
+		for _, code := range c.Codes {
+			checked[code] = true
+		}
+	}
+	codes := regexp.MustCompile(`(?m)^\s+Code\w+\s*=\s*"([A-Z_]+)"`).FindAllSubmatch(src, -1)
+	if len(codes) == 0 {
+		t.Fatal("no error codes found in apierr/errors.go")
+	}
+	for _, m := range codes {
+		code := string(m[1])
+		_, uncovered := conformance.UncoveredCodes[code]
+		switch {
+		case checked[code] && uncovered:
+			t.Errorf("%s has a check and is listed as uncovered", code)
+		case !checked[code] && !uncovered:
+			t.Errorf("%s has no conformance check; add one or list it in UncoveredCodes", code)
+		}
+	}
+}
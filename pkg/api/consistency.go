@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// Repair policies accepted by POST /admin/consistency/repair.
+const (
+	repairReportOnly      = "report_only"
+	repairRegisterOrphans = "register_orphans"
+	repairDeleteOrphans   = "delete_orphans"
+)
+
+// consistencyReport lists disagreements between the registry, persisted and
+// loaded brains, and lifecycle state. Index IDs are sorted.
+type consistencyReport struct {
+	// RegisteredWithoutData are registry entries with no data file and no
+	// loaded brain.
+	RegisteredWithoutData []string `json:"registeredWithoutData"`
+	// DataWithoutRegistry are brains on disk or in memory with no registry
+	// entry. Only checked when the registry guard is enabled, since these
+	// brains are then unreachable.
+	DataWithoutRegistry []string `json:"dataWithoutRegistry"`
+	// LifecycleOrphans are lifecycle states for indexes that are neither
+	// registered nor backed by data.
+	LifecycleOrphans []string `json:"lifecycleOrphans"`
+	// BrokenChains are the supersede links that break chain integrity,
+	// keyed by index. Only loaded brains are checked, and repair leaves
+	// them alone.
+	BrokenChains    map[string][]engine.ChainIssue `json:"brokenChains"`
+	RegistryEnabled bool                           `json:"registryEnabled"`
+	Consistent      bool                           `json:"consistent"`
+}
+
+// checkConsistency cross-references the registry, the persistence store,
+// the worker pool and the lifecycle manager.
+func (s *Server) checkConsistency() consistencyReport {
+	data := s.dataIndexes()
+
+	registered := make(map[string]bool)
+	for _, entry := range s.registry.List() {
+		registered[entry.UUID] = true
+	}
+
+	report := consistencyReport{
+		RegisteredWithoutData: []string{},
+		DataWithoutRegistry:   []string{},
+		LifecycleOrphans:      []string{},
+		BrokenChains:          make(map[string][]engine.ChainIssue),
+		RegistryEnabled:       s.config.Registry.Enabled,
+	}
+	for id := range registered {
+		if !data[id] && !s.pool.Persisted(core.IndexID(id)) {
+			report.RegisteredWithoutData = append(report.RegisteredWithoutData, id)
+		}
+	}
+	if report.RegistryEnabled {
+		for id := range data {
+			if !registered[id] {
+				report.DataWithoutRegistry = append(report.DataWithoutRegistry, id)
+			}
+		}
+	}
+	for _, id := range s.lifecycle.Indexes() {
+		if !registered[string(id)] && !data[string(id)] {
+			report.LifecycleOrphans = append(report.LifecycleOrphans, string(id))
+		}
+	}
+
+	s.pool.ForEach(func(id core.IndexID, worker *concurrency.BrainWorker) {
+		result, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpChainIssues})
+		if err != nil {
+			return
+		}
+		if issues := result.([]engine.ChainIssue); len(issues) > 0 {
+			report.BrokenChains[string(id)] = issues
+		}
+	})
+
+	sort.Strings(report.RegisteredWithoutData)
+	sort.Strings(report.DataWithoutRegistry)
+	sort.Strings(report.LifecycleOrphans)
+	report.Consistent = len(report.RegisteredWithoutData) == 0 &&
+		len(report.DataWithoutRegistry) == 0 &&
+		len(report.LifecycleOrphans) == 0 &&
+		len(report.BrokenChains) == 0
+	return report
+}
+
+// consistencyRepair records what a repair pass changed and what failed.
+type consistencyRepair struct {
+	Policy     string            `json:"policy"`
+	Registered []string          `json:"registered"`
+	Deleted    []string          `json:"deleted"`
+	Forgotten  []string          `json:"forgotten"`
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// repairConsistency fixes the problems in report according to policy:
+//
+//   - report_only changes nothing.
+//   - register_orphans registers unregistered brains.
+//   - delete_orphans deletes unregistered brains and registry entries that
+//     have no data.
+//
+// Both repairing policies drop orphaned lifecycle state.
+func (s *Server) repairConsistency(report consistencyReport, policy string) consistencyRepair {
+	res := consistencyRepair{
+		Policy:     policy,
+		Registered: []string{},
+		Deleted:    []string{},
+		Forgotten:  []string{},
+	}
+	if policy == repairReportOnly {
+		return res
+	}
+	fail := func(id string, err error) {
+		if res.Errors == nil {
+			res.Errors = make(map[string]string)
+		}
+		res.Errors[id] = err.Error()
+	}
+
+	switch policy {
+	case repairRegisterOrphans:
+		for _, id := range report.DataWithoutRegistry {
+			if _, _, err := s.registry.FindOrCreate(id, nil); err != nil {
+				fail(id, err)
+				continue
+			}
+			res.Registered = append(res.Registered, id)
+		}
+	case repairDeleteOrphans:
+		for _, id := range report.DataWithoutRegistry {
+			if err := s.pool.Truncate(core.IndexID(id)); err != nil {
+				fail(id, err)
+				continue
+			}
+			s.lifecycle.RemoveIndex(core.IndexID(id))
+			res.Deleted = append(res.Deleted, id)
+		}
+		for _, id := range report.RegisteredWithoutData {
+			if err := s.registry.Delete(id); err != nil {
+				fail(id, err)
+				continue
+			}
+			res.Deleted = append(res.Deleted, id)
+		}
+	}
+
+	for _, id := range report.LifecycleOrphans {
+		s.lifecycle.RemoveIndex(core.IndexID(id))
+		res.Forgotten = append(res.Forgotten, id)
+	}
+	return res
+}
+
+// handleAdminConsistency reports registry/data inconsistencies.
+func (s *Server) handleAdminConsistency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	json.NewEncoder(w).Encode(s.checkConsistency())
+}
+
+// handleAdminConsistencyRepair repairs inconsistencies according to the
+// policy query parameter, returning the reports taken before and after.
+func (s *Server) handleAdminConsistencyRepair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	policy := r.URL.Query().Get("policy")
+	switch policy {
+	case repairReportOnly, repairRegisterOrphans, repairDeleteOrphans:
+	default:
+		apierr.BadRequest(w, apierr.CodeBadRequest, fmt.Sprintf(
+			"policy must be one of %s, %s, %s", repairRegisterOrphans, repairDeleteOrphans, repairReportOnly))
+		return
+	}
+
+	before := s.checkConsistency()
+	repair := s.repairConsistency(before, policy)
+	json.NewEncoder(w).Encode(map[string]any{
+		"before": before,
+		"repair": repair,
+		"after":  s.checkConsistency(),
+	})
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// newInconsistentTestServer returns a guarded server with one index in each
+// inconsistency class plus one consistent index:
+//
+//	healthy     registered, with data
+//	ghost-reg   registered, no data
+//	zombie      data on disk, not registered
+//	lost        lifecycle state only
+func newInconsistentTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = true
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+
+	for _, id := range []string{"healthy", "ghost-reg"} {
+		if _, err := s.registry.Create(id, nil); err != nil {
+			t.Fatalf("registry.Create(%s): %v", id, err)
+		}
+	}
+	for _, id := range []core.IndexID{"healthy", "zombie"} {
+		if _, err := s.pool.GetOrCreate(id); err != nil {
+			t.Fatalf("GetOrCreate(%s): %v", id, err)
+		}
+		// Evict persists the brain, leaving only the data file behind.
+		if err := s.pool.Evict(id); err != nil {
+			t.Fatalf("Evict(%s): %v", id, err)
+		}
+		s.lifecycle.RecordActivity(id)
+	}
+	s.lifecycle.RecordActivity("lost")
+	return s
+}
+
+func adminRequest(t *testing.T, s *Server, method, path string) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, method, path, "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("%s %s: expected 200, got %d: %s", method, path, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func assertIDs(t *testing.T, m map[string]any, key string, want ...string) {
+	t.Helper()
+	got, _ := m[key].([]any)
+	if len(got) != len(want) {
+		t.Errorf("%s: expected %v, got %v", key, want, got)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: expected %v, got %v", key, want, got)
+			return
+		}
+	}
+}
+
+func TestConsistency_DetectsEachClass(t *testing.T) {
+	s := newInconsistentTestServer(t)
+
+	m := adminRequest(t, s, "GET", "/admin/consistency")
+	assertIDs(t, m, "registeredWithoutData", "ghost-reg")
+	assertIDs(t, m, "dataWithoutRegistry", "zombie")
+	assertIDs(t, m, "lifecycleOrphans", "lost")
+	if m["consistent"] != false {
+		t.Error("expected consistent=false")
+	}
+
+	// With the guard off unregistered data is reachable and not reported.
+	s.config.Registry.Enabled = false
+	m = adminRequest(t, s, "GET", "/admin/consistency")
+	assertIDs(t, m, "dataWithoutRegistry")
+}
+
+func TestConsistency_ReportOnlyChangesNothing(t *testing.T) {
+	s := newInconsistentTestServer(t)
+
+	m := adminRequest(t, s, "POST", "/admin/consistency/repair?policy=report_only")
+	after := m["after"].(map[string]any)
+	assertIDs(t, after, "registeredWithoutData", "ghost-reg")
+	assertIDs(t, after, "dataWithoutRegistry", "zombie")
+	assertIDs(t, after, "lifecycleOrphans", "lost")
+}
+
+func TestConsistency_RegisterOrphans(t *testing.T) {
+	s := newInconsistentTestServer(t)
+
+	m := adminRequest(t, s, "POST", "/admin/consistency/repair?policy=register_orphans")
+	assertIDs(t, m["repair"].(map[string]any), "registered", "zombie")
+	assertIDs(t, m["repair"].(map[string]any), "forgotten", "lost")
+
+	after := m["after"].(map[string]any)
+	assertIDs(t, after, "dataWithoutRegistry")
+	assertIDs(t, after, "lifecycleOrphans")
+	// Registered-but-empty indexes are valid under this policy.
+	assertIDs(t, after, "registeredWithoutData", "ghost-reg")
+
+	if !s.registry.Exists("zombie") || !s.pool.Persisted("zombie") {
+		t.Error("zombie should now be registered with its data intact")
+	}
+}
+
+func TestConsistency_DeleteOrphans(t *testing.T) {
+	s := newInconsistentTestServer(t)
+
+	m := adminRequest(t, s, "POST", "/admin/consistency/repair?policy=delete_orphans")
+	assertIDs(t, m["repair"].(map[string]any), "deleted", "zombie", "ghost-reg")
+	if after := m["after"].(map[string]any); after["consistent"] != true {
+		t.Errorf("expected consistent after delete_orphans, got %v", after)
+	}
+
+	if s.pool.Persisted("zombie") {
+		t.Error("zombie data file should be deleted")
+	}
+	if s.registry.Exists("ghost-reg") {
+		t.Error("ghost-reg registry entry should be deleted")
+	}
+	if !s.registry.Exists("healthy") || !s.pool.Persisted("healthy") {
+		t.Error("healthy index must be untouched")
+	}
+}
+
+func TestConsistency_RepairRejectsUnknownPolicy(t *testing.T) {
+	s := newInconsistentTestServer(t)
+
+	for _, path := range []string{"/admin/consistency/repair", "/admin/consistency/repair?policy=nuke"} {
+		rr := doRequest(t, s, "POST", path, "", map[string]string{
+			"Authorization": adminAuthHeader("admin", "secret"),
+		})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestAdminDelete_RemovesRegistryAndDataTogether(t *testing.T) {
+	s := newInconsistentTestServer(t)
+
+	m := adminRequest(t, s, "DELETE", "/admin/indexes/healthy")
+	if m["registryDeleted"] != true {
+		t.Errorf("expected registryDeleted=true, got %v", m["registryDeleted"])
+	}
+	if s.registry.Exists("healthy") || s.pool.Persisted("healthy") {
+		t.Error("expected both the registry entry and the data file to be gone")
+	}
+}
+
+func TestRegistryDeleteWith_RestoresEntryOnFailure(t *testing.T) {
+	s := newInconsistentTestServer(t)
+	boom := errors.New("disk full")
+
+	deleted, err := s.registry.DeleteWith("healthy", func() error { return boom })
+	if !errors.Is(err, boom) || deleted {
+		t.Fatalf("expected the removal error and deleted=false, got %v, %v", deleted, err)
+	}
+	if !s.registry.Exists("healthy") {
+		t.Fatal("registry entry should be restored after a failed removal")
+	}
+
+	reloaded, err := registry.NewStore(s.config.Storage.DataPath)
+	if err != nil {
+		t.Fatalf("registry.NewStore: %v", err)
+	}
+	if !reloaded.Exists("healthy") {
+		t.Error("restored registry entry should be persisted")
+	}
+
+	ran := false
+	deleted, err = s.registry.DeleteWith("never-registered", func() error { ran = true; return nil })
+	if err != nil || deleted || !ran {
+		t.Errorf("unregistered uuid: expected remove to run with deleted=false, got ran=%v deleted=%v err=%v", ran, deleted, err)
+	}
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// messageOverheadTokens approximates what a chat completion API charges to
+// frame one message: its role and delimiters.
+const messageOverheadTokens = 4
+
+// contextSeparator joins the memories of a text context, and the memories
+// of one grouped message.
+const contextSeparator = "\n---\n"
+
+// contextMessage is one chat message of a "messages" context.
+type contextMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// contextOptions selects how assembleContext lays out the memories that fit
+// the token budget.
+type contextOptions struct {
+	format  string // a core.ContextFormat*; empty means text
+	render  turnRenderer
+	blocks  core.BlockTemplate
+	groupBy string // metadata key grouping "messages" into one per value
+}
+
+// assembledContext is a context in one of the output formats: text and
+// blocks fill text, messages fills messages.
+type assembledContext struct {
+	text     string
+	messages []contextMessage
+	included int
+	tokens   int
+}
+
+// contextOptions returns the layout of a context request naming format,
+// which may also be a turn template, and reports whether format is known.
+func (s *Server) contextOptions(format, groupBy string) (contextOptions, bool) {
+	opts := contextOptions{format: format, blocks: core.BlockTemplate(s.config.Context.BlockTemplate), groupBy: groupBy}
+	if core.IsContextFormat(format) {
+		opts.render, _ = s.turnRenderer("")
+		return opts, true
+	}
+	opts.format = core.ContextFormatText
+	var ok bool
+	opts.render, ok = s.turnRenderer(format)
+	return opts, ok
+}
+
+// assembleContext lays out hits, best first, until maxTokens is reached.
+// Token estimates (~4 characters per token) include each format's framing:
+// separators, block markers and attributes, and per-message overhead.
+func assembleContext(hits []searchHit, maxTokens int, opts contextOptions) assembledContext {
+	switch opts.format {
+	case core.ContextFormatMessages:
+		return assembleMessages(hits, maxTokens, opts)
+	case core.ContextFormatBlocks:
+		return assembleBlocks(hits, maxTokens, opts)
+	}
+	return assembleText(hits, maxTokens, opts.render)
+}
+
+// assembleText joins hit contents, rendered by render and annotated, with
+// "---" separators.
+func assembleText(hits []searchHit, maxTokens int, render turnRenderer) assembledContext {
+	var context strings.Builder
+	var out assembledContext
+
+	for _, h := range hits {
+		// Content, with its author annotation or as a rendered turn
+		text := render.render(h.neuron)
+		neuronTokens := len(text) / 4
+		if out.tokens+neuronTokens > maxTokens {
+			break
+		}
+
+		if context.Len() > 0 {
+			context.WriteString(contextSeparator)
+		}
+		context.WriteString(text)
+		context.WriteString(hitAnnotations(h))
+
+		out.tokens += neuronTokens
+		out.included++
+	}
+	out.text = context.String()
+	return out
+}
+
+// contextResponse returns the response fields of an assembled context:
+// messages for "messages", context and its alias text otherwise.
+func contextResponse(c assembledContext, format string) map[string]any {
+	resp := map[string]any{
+		"neuronsUsed":     c.included,
+		"neuronCount":     c.included,
+		"estimatedTokens": c.tokens,
+		"tokenCount":      c.tokens,
+	}
+	if format == core.ContextFormatMessages {
+		resp["messages"] = c.messages
+	} else {
+		resp["context"] = c.text
+		resp["text"] = c.text
+	}
+	return resp
+}
+
+// hitAnnotations marks a hit's depth, and content borrowed from a fallback
+// index or working memory.
+func hitAnnotations(h searchHit) string {
+	var b strings.Builder
+	if h.neuron.Depth > 0 {
+		fmt.Fprintf(&b, " [depth:%d]", h.neuron.Depth)
+	}
+	if h.fallback {
+		fmt.Fprintf(&b, " [source:%s]", h.source)
+	}
+	if h.session != "" {
+		b.WriteString(" [scope:session]")
+	}
+	return b.String()
+}
+
+// assembleMessages makes one system message per hit or, with groupBy, per
+// value of that metadata key, in the order groups first appear.
+func assembleMessages(hits []searchHit, maxTokens int, opts contextOptions) assembledContext {
+	out := assembledContext{messages: []contextMessage{}}
+	groups := map[string]int{}
+
+	for _, h := range hits {
+		content := opts.render.render(h.neuron) + hitAnnotations(h)
+		key, grouped := "", false
+		if opts.groupBy != "" {
+			key, grouped = metadataString(h.neuron, opts.groupBy)
+		}
+		i, joins := groups[key]
+		joins = joins && grouped
+
+		cost := messageOverheadTokens + len(content)/4
+		if joins {
+			cost = len(contextSeparator+content) / 4
+		}
+		if out.tokens+cost > maxTokens {
+			break
+		}
+
+		if joins {
+			out.messages[i].Content += contextSeparator + content
+		} else {
+			if grouped {
+				groups[key] = len(out.messages)
+			}
+			out.messages = append(out.messages, contextMessage{Role: "system", Content: content})
+		}
+		out.tokens += cost
+		out.included++
+	}
+	return out
+}
+
+// assembleBlocks wraps each hit in the block template, one per line, with
+// its content and attributes escaped so they cannot close the block.
+func assembleBlocks(hits []searchHit, maxTokens int, opts contextOptions) assembledContext {
+	var context strings.Builder
+	var out assembledContext
+
+	for _, h := range hits {
+		block := opts.blocks.Render(blockAttrs(h), blockTextEscaper.Replace(h.neuron.Content))
+		if context.Len() > 0 {
+			block = "\n" + block
+		}
+		blockTokens := len(block) / 4
+		if out.tokens+blockTokens > maxTokens {
+			break
+		}
+
+		context.WriteString(block)
+		out.tokens += blockTokens
+		out.included++
+	}
+	out.text = context.String()
+	return out
+}
+
+var (
+	blockTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	blockAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+	// blockAttrNameRe matches metadata keys usable as attribute names;
+	// internal keys start with "_" and are left out.
+	blockAttrNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+)
+
+// blockAttrs formats a hit's id, creation time and metadata, then its
+// depth, source and scope, as ` name="value"` attributes. Metadata keys
+// are sorted and may not shadow the fixed attributes.
+func blockAttrs(h searchHit) string {
+	n := h.neuron
+	var b strings.Builder
+	attr := func(name, value string) {
+		fmt.Fprintf(&b, ` %s="%s"`, name, blockAttrEscaper.Replace(value))
+	}
+
+	attr("id", string(n.ID))
+	attr("ts", n.CreatedAt.UTC().Format(time.RFC3339))
+
+	fixed := map[string]bool{"id": true, "ts": true, "depth": true, "source": true, "scope": true}
+	keys := make([]string, 0, len(n.Metadata))
+	for k := range n.Metadata {
+		if !fixed[k] && blockAttrNameRe.MatchString(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v, ok := metadataString(n, k); ok {
+			attr(k, v)
+		}
+	}
+
+	if n.Depth > 0 {
+		attr("depth", fmt.Sprint(n.Depth))
+	}
+	if h.fallback {
+		attr("source", string(h.source))
+	}
+	if h.session != "" {
+		attr("scope", "session")
+	}
+	return b.String()
+}
+
+// metadataString returns a metadata value as a string: strings as they
+// are, anything else JSON-encoded.
+func metadataString(n *core.Neuron, key string) (string, bool) {
+	v, ok := n.Metadata[key]
+	if !ok || v == nil {
+		return "", false
+	}
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// contextFormatHits covers turns, threads, depth, fallback and session
+// hits, and content with marker characters in it.
+func contextFormatHits() []searchHit {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	neuron := func(id, content string, depth int, metadata map[string]any) *core.Neuron {
+		return &core.Neuron{ID: core.NeuronID(id), Content: content, Depth: depth, CreatedAt: at, Metadata: metadata}
+	}
+	return []searchHit{
+		{neuron: neuron("n1", `Deploys go out on <b>Fridays</b> & "never" on Mondays`, 0,
+			map[string]any{"thread": "conv-1", "role": "user", "_lang": "en", "bad key": "x"})},
+		{neuron: neuron("n2", "A literal </memory> in content must not close the block", 2,
+			map[string]any{"thread": "conv-2", "source": "shadowed", "priority": 3})},
+		{neuron: neuron("n3", "Follow-up in the first thread", 0,
+			map[string]any{"thread": `conv-1`}), fallback: true, source: "shared"},
+		{neuron: neuron("n4", "Scratch note from the session", 0, nil), session: "s-1"},
+	}
+}
+
+func renderAssembled(t *testing.T, c assembledContext, format string) string {
+	t.Helper()
+	body := c.text
+	if format == core.ContextFormatMessages {
+		data, err := json.MarshalIndent(c.messages, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = string(data)
+	}
+	return fmt.Sprintf("included: %d\ntokens: %d\n\n%s\n", c.included, c.tokens, body)
+}
+
+func TestContextFormats_Golden(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Context.TurnTemplates["tagged"] = "<{{role}}> {{text}}"
+	})
+
+	cases := []struct {
+		name, format, groupBy, blockTemplate string
+	}{
+		{name: "text", format: core.ContextFormatText},
+		{name: "text_turn_template", format: "tagged"},
+		{name: "messages", format: core.ContextFormatMessages},
+		{name: "messages_by_thread", format: core.ContextFormatMessages, groupBy: "thread"},
+		{name: "blocks", format: core.ContextFormatBlocks},
+		{name: "blocks_custom_template", format: core.ContextFormatBlocks, blockTemplate: "[[memo{{attrs}}]]\n{{text}}\n[[/memo]]"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, ok := s.contextOptions(tc.format, tc.groupBy)
+			if !ok {
+				t.Fatalf("format %q rejected", tc.format)
+			}
+			if tc.blockTemplate != "" {
+				opts.blocks = core.BlockTemplate(tc.blockTemplate)
+			}
+			got := assembleContext(contextFormatHits(), maxContextTokens, opts)
+			if got.included != 4 {
+				t.Errorf("expected all 4 hits, got %d", got.included)
+			}
+			assertGoldenFile(t, filepath.Join("testdata", "context_format", tc.name+".golden"), renderAssembled(t, got, opts.format))
+		})
+	}
+}
+
+func TestContextFormats_BudgetCountsFraming(t *testing.T) {
+	s := newTestServer(t, nil)
+	hits := contextFormatHits()
+
+	tokens := map[string]int{}
+	for _, format := range []string{core.ContextFormatText, core.ContextFormatMessages, core.ContextFormatBlocks} {
+		opts, _ := s.contextOptions(format, "")
+		full := assembleContext(hits, maxContextTokens, opts)
+		tokens[format] = full.tokens
+
+		// One token short of the full cost leaves the last hit out.
+		short := assembleContext(hits, full.tokens-1, opts)
+		if short.included != 3 || short.tokens > full.tokens-1 {
+			t.Errorf("%s: expected 3 hits within %d tokens, got %d using %d", format, full.tokens-1, short.included, short.tokens)
+		}
+	}
+	if tokens[core.ContextFormatMessages] <= tokens[core.ContextFormatText] || tokens[core.ContextFormatBlocks] <= tokens[core.ContextFormatText] {
+		t.Errorf("framed formats should cost more than text, got %v", tokens)
+	}
+}
+
+func TestContextFormats_Endpoint(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"Content-Type": "application/json", "X-Index-ID": "fmt-idx"}
+	writeTo(t, s, "fmt-idx", "Kubernetes clusters are upgraded every quarter")
+	writeTo(t, s, "fmt-idx", "Kubernetes upgrades need a change ticket")
+
+	rr := doRequest(t, s, "POST", "/v1/context", `{"cue":"Kubernetes upgrades","format":"messages"}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	msgs, _ := m["messages"].([]any)
+	if len(msgs) == 0 || m["context"] != nil || m["format"] != "messages" {
+		t.Fatalf("expected messages instead of context, got %v", m)
+	}
+	if first := msgs[0].(map[string]any); first["role"] != "system" || first["content"] == "" {
+		t.Errorf("unexpected message %v", first)
+	}
+
+	rr = doRequest(t, s, "POST", "/v1/context", `{"cue":"Kubernetes upgrades","format":"blocks"}`, headers)
+	if m := decodeJSON(t, rr); !strings.HasPrefix(m["context"].(string), "<memory id=") {
+		t.Errorf("expected memory blocks, got %v", m["context"])
+	}
+
+	for body, want := range map[string]string{
+		`{"cue":"Kubernetes","format":"yaml"}`:                 "unknown format",
+		`{"cue":"Kubernetes","format":"blocks","groupBy":"x"}`: "groupBy",
+		`{"cue":"Kubernetes","groupBy":"thread"}`:              "groupBy",
+	} {
+		rr := doRequest(t, s, "POST", "/v1/context", body, headers)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("%s: expected 400 mentioning %q, got %d: %s", body, want, rr.Code, rr.Body.String())
+		}
+	}
+}
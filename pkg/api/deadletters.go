@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// newDeadLetters returns the store the pool records panicking operations
+// in, written under data/deadletter/ unless worker.deadLetters.maxBytes is
+// 0.
+func newDeadLetters(cfg *core.Config) *concurrency.DeadLetters {
+	dl := cfg.Worker.DeadLetters
+	return concurrency.NewDeadLetters(concurrency.DeadLetterOptions{
+		Capacity:           dl.Capacity,
+		Dir:                filepath.Join(cfg.Storage.DataPath, "data", "deadletter"),
+		MaxBytes:           dl.MaxBytes,
+		QuarantineAfter:    dl.QuarantineAfter,
+		QuarantineWindow:   dl.QuarantineWindow,
+		QuarantineDuration: dl.QuarantineDuration,
+	})
+}
+
+// writePanicError writes the 500 of an operation that panicked, with the
+// reference of its dead letter.
+func writePanicError(w http.ResponseWriter, err error) {
+	var pe *concurrency.PanicError
+	if !errors.As(err, &pe) {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	apierr.OperationPanic(w, "operation failed unexpectedly; see GET /admin/deadletters/"+pe.Record.ID, pe.Record.ID)
+}
+
+// writeQuarantineError writes the 503 refusing an operation of an index
+// quarantined after repeated panics, retryable when the quarantine ends.
+func writeQuarantineError(w http.ResponseWriter, err error) {
+	var qe *concurrency.QuarantineError
+	if errors.As(err, &qe) {
+		secs := int(math.Ceil(time.Until(qe.Quarantine.Until).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(secs, 1)))
+	}
+	apierr.Write(w, http.StatusServiceUnavailable, apierr.CodeIndexQuarantined, err.Error())
+}
+
+// handleAdminDeadLetters lists the operations that panicked, newest first
+// (GET /admin/deadletters), with panic counts by operation type and the
+// quarantined indexes. since (RFC 3339) and index_id narrow the list.
+func (s *Server) handleAdminDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	q := r.URL.Query()
+	var since time.Time
+	if raw := q.Get("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "since must be RFC3339")
+			return
+		}
+		since = t
+	}
+
+	records := []concurrency.DeadLetter{}
+	counts := map[string]uint64{}
+	quarantined := []concurrency.Quarantine{}
+	if d := s.pool.DeadLetters(); d != nil {
+		records = d.Records(since, core.IndexID(q.Get("index_id")))
+		counts = d.Counts()
+		quarantined = d.Quarantines(time.Now())
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"deadLetters": records,
+		"count":       len(records),
+		"panics":      counts,
+		"quarantined": quarantined,
+	})
+}
+
+// handleAdminDeadLetter returns one dead letter by the reference a failed
+// request was given (GET /admin/deadletters/{id}), from disk once it has
+// left the in-memory list.
+func (s *Server) handleAdminDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/admin/deadletters/")
+	var rec concurrency.DeadLetter
+	ok := false
+	if d := s.pool.DeadLetters(); d != nil {
+		rec, ok = d.Get(id)
+	}
+	if !ok {
+		apierr.NotFound(w, apierr.CodeNotFound, "dead letter "+id+" not found")
+		return
+	}
+	json.NewEncoder(w).Encode(rec)
+}
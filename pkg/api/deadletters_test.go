@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestDeadLetters_ReferenceListingAndQuarantine(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Worker.DeadLetters.QuarantineAfter = 2
+	})
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	writeTo(t, s, "poisoned", "a memory before the crash")
+	writeTo(t, s, "healthy", "a memory elsewhere")
+
+	worker, err := s.pool.GetOrCreate("poisoned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	panicOnce := func() string {
+		t.Helper()
+		_, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpSearch, Payload: 42})
+		rr := httptest.NewRecorder()
+		s.writeOperationError(rr, err)
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500 for a panic, got %d %s", rr.Code, rr.Body.String())
+		}
+		body := decodeJSON(t, rr)
+		if body["code"] != "OPERATION_PANIC" || body["reference"] == "" {
+			t.Fatalf("expected a reference to the dead letter, got %v", body)
+		}
+		return body["reference"].(string)
+	}
+
+	ref := panicOnce()
+	rec := decodeJSON(t, doRequest(t, s, "GET", "/admin/deadletters/"+ref, "", admin))
+	if rec["indexId"] != "poisoned" || rec["op"] != "search" || rec["payload"] != "int" || !strings.Contains(rec["stack"].(string), "goroutine") {
+		t.Errorf("unexpected dead letter %v", rec)
+	}
+	if rr := doRequest(t, s, "GET", "/admin/deadletters/unknown", "", admin); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown reference, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/search", `{"query":"crash"}`, map[string]string{"X-Index-ID": "poisoned"}); rr.Code != http.StatusOK {
+		t.Errorf("one panic should not quarantine the index, got %d", rr.Code)
+	}
+
+	panicOnce()
+	rr := doRequest(t, s, "POST", "/v1/search", `{"query":"crash"}`, map[string]string{"X-Index-ID": "poisoned"})
+	if rr.Code != http.StatusServiceUnavailable || decodeJSON(t, rr)["code"] != "INDEX_QUARANTINED" || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 503 INDEX_QUARANTINED with Retry-After, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "POST", "/v1/search", `{"query":"elsewhere"}`, map[string]string{"X-Index-ID": "healthy"}); rr.Code != http.StatusOK {
+		t.Errorf("other indexes must be unaffected, got %d", rr.Code)
+	}
+
+	list := decodeJSON(t, doRequest(t, s, "GET", "/admin/deadletters?index_id=poisoned", "", admin))
+	if list["count"].(float64) != 2 || list["panics"].(map[string]any)["search"].(float64) != 2 {
+		t.Errorf("expected two search panics listed, got %v", list)
+	}
+	if q := list["quarantined"].([]any); len(q) != 1 || q[0].(map[string]any)["indexId"] != "poisoned" {
+		t.Errorf("expected poisoned quarantined, got %v", q)
+	}
+	if list := decodeJSON(t, doRequest(t, s, "GET", "/admin/deadletters?index_id=healthy", "", admin)); list["count"].(float64) != 0 {
+		t.Errorf("expected no dead letters for healthy, got %v", list)
+	}
+}
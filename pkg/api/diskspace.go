@@ -0,0 +1,109 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// isMutation reports whether r may change stored data, and so is refused
+// while the data volume is below storage.minFreeBytes or a storage
+// migration's switchover pauses writes. POST endpoints that only read,
+// runtime settings an operator may need to change during the incident and
+// moving the data directory to a larger volume are not mutations.
+func isMutation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	switch path := r.URL.Path; {
+	case path == "/v1/search", path == "/v1/context", path == "/v1/command",
+		path == "/v1/prefetch", path == "/v1/synapses/prune-plan",
+		strings.HasPrefix(path, "/v1/brain/"), strings.HasPrefix(path, sharedPrefix),
+		path == "/admin/login", path == "/admin/logout", path == "/admin/config", path == "/admin/gc", path == "/admin/persist",
+		path == migrateStoragePath:
+		return false
+	}
+	return true
+}
+
+// readOnlyError returns a persistence.ErrInsufficientStorage error while
+// the data volume is below its floor, and nil otherwise.
+func (s *Server) readOnlyError() error {
+	if s.disk == nil || !s.disk.ReadOnly() {
+		return nil
+	}
+	st := s.disk.Status()
+	return fmt.Errorf("%w: data volume has %d bytes free, below storage.minFreeBytes (%d); writes resume once space is freed",
+		persistence.ErrInsufficientStorage, st.FreeBytes, st.MinFreeBytes)
+}
+
+// refuseReadOnly answers a mutation with 507 while the data volume is below
+// its floor. It reports whether it did.
+func (s *Server) refuseReadOnly(w http.ResponseWriter, r *http.Request) bool {
+	if !isMutation(r) {
+		return false
+	}
+	err := s.readOnlyError()
+	if err == nil {
+		return false
+	}
+	apierr.InsufficientStorage(w, err.Error(), s.disk.Status().FreeBytes)
+	return true
+}
+
+// diskHealth is the disk check of /health and the status it implies: low
+// free space degrades the instance, and read-only mode makes it
+// unavailable for writes. A volume that cannot be read is reported but
+// changes nothing.
+func (s *Server) diskHealth() (map[string]any, string) {
+	st := s.disk.Status()
+	check := map[string]any{
+		"status":        string(st.State),
+		"freeBytes":     st.FreeBytes,
+		"totalBytes":    st.TotalBytes,
+		"warnFreeBytes": st.WarnFreeBytes,
+		"minFreeBytes":  st.MinFreeBytes,
+		"readOnly":      st.ReadOnly,
+		"checkedAt":     st.CheckedAt,
+	}
+	if st.Error != "" {
+		check["error"] = st.Error
+	}
+	if st.ReadOnly {
+		check["readOnlySince"] = st.ReadOnlySince
+		return check, "unavailable"
+	}
+	if st.State == persistence.DiskLow {
+		return check, "degraded"
+	}
+	return check, "healthy"
+}
+
+// flushHealth is the persistence check of /health, present while any
+// index's latest flush failed: indexes still within storage.flushStaleAfter
+// are only retrying, stale ones degrade the instance and ones past
+// storage.flushStaleFailAfter make it unavailable.
+func flushHealth(failures []persistence.FlushFailure) (map[string]any, string) {
+	status := "ok"
+	stale := []persistence.FlushFailure{}
+	for _, f := range failures {
+		switch {
+		case f.Failing:
+			status = "unavailable"
+		case f.Stale && status == "ok":
+			status = "degraded"
+		}
+		if f.Stale {
+			stale = append(stale, f)
+		}
+	}
+	return map[string]any{
+		"status":   status,
+		"retrying": len(failures),
+		"stale":    stale,
+	}, status
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/lifecycle"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// stubDisk replaces the server's disk monitor with one reading free bytes
+// from the returned counter.
+func stubDisk(t *testing.T, s *Server, warn, min uint64) *atomic.Uint64 {
+	t.Helper()
+	free := &atomic.Uint64{}
+	free.Store(1 << 30)
+	s.disk.Close()
+	s.disk = persistence.NewDiskMonitor(s.config.Storage.DataPath, warn, min, func(string) (uint64, uint64, error) {
+		return free.Load(), 1 << 31, nil
+	})
+	s.pool.SetDiskMonitor(s.disk)
+	return free
+}
+
+// healthOf returns the /health status code, status and disk check.
+func healthOf(t *testing.T, s *Server) (int, any, map[string]any) {
+	t.Helper()
+	rr := doRequest(t, s, "GET", "/health", "", nil)
+	doc := decodeJSON(t, rr)
+	disk, _ := doc["checks"].(map[string]any)["disk"].(map[string]any)
+	return rr.Code, doc["status"], disk
+}
+
+func TestDiskSpace_ReadOnlyModeAndRecovery(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	free := stubDisk(t, s, 1000, 500)
+	headers := map[string]string{"X-Index-ID": "disk"}
+	writeTo(t, s, "disk", "written before the disk filled")
+
+	free.Store(200)
+	s.disk.Check()
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"refused while full"}`, headers)
+	if rr.Code != http.StatusInsufficientStorage {
+		t.Fatalf("expected 507 below the floor, got %d %s", rr.Code, rr.Body.String())
+	}
+	if doc := decodeJSON(t, rr); doc["code"] != "INSUFFICIENT_STORAGE" || doc["freeBytes"] != float64(200) {
+		t.Errorf("unexpected error body: %v", doc)
+	}
+	for _, path := range []string{"/v1/forget/x", "/v1/pin/x", "/admin/indexes/disk/reset"} {
+		if rr := doRequest(t, s, "POST", path, "", headers); rr.Code != http.StatusInsufficientStorage {
+			t.Errorf("%s: expected 507, got %d", path, rr.Code)
+		}
+	}
+	if rr := doRequest(t, s, "GET", "/v1/search?q=written", "", headers); rr.Code != http.StatusOK {
+		t.Errorf("reads must continue in read-only mode, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/search", `{"query":"written"}`, headers); rr.Code != http.StatusOK {
+		t.Errorf("POST searches are reads, got %d", rr.Code)
+	}
+	code, status, disk := healthOf(t, s)
+	if code != http.StatusServiceUnavailable || status != "unavailable" || disk["readOnly"] != true {
+		t.Errorf("read-only mode should fail readiness, got %d %v %v", code, status, disk)
+	}
+
+	free.Store(800)
+	s.disk.Check()
+	writeTo(t, s, "disk", "accepted once space is back")
+	code, status, disk = healthOf(t, s)
+	if code != http.StatusOK || status != "degraded" || disk["status"] != "low" {
+		t.Errorf("below the warning threshold the instance is degraded, got %d %v %v", code, status, disk)
+	}
+
+	free.Store(5000)
+	s.disk.Check()
+	if _, status, disk := healthOf(t, s); status != "healthy" || disk["status"] != "ok" {
+		t.Errorf("expected healthy after recovery, got %v %v", status, disk)
+	}
+}
+
+// newFlushTestServer is newTestServer over a store that reports an index
+// stale, and failing readiness, as soon as one of its flushes fails.
+func newFlushTestServer(t *testing.T, failAfter time.Duration) *Server {
+	t.Helper()
+	cfg := core.DefaultConfig()
+	cfg.Storage.DataPath = t.TempDir()
+	cfg.Registry.Enabled = false
+	cfg.Admin.Enabled = true
+	durability := persistence.DefaultDurabilityConfig()
+	durability.FlushStaleAfter = time.Nanosecond
+	durability.FlushStaleFailAfter = failAfter
+	store, err := persistence.NewStoreWithDurability(cfg.Storage.DataPath, cfg.Storage.Compress, durability)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := concurrency.NewWorkerPool(store, core.DefaultBounds())
+	reg, err := registry.NewStore(cfg.Storage.DataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(cfg.Server.HTTPAddr, pool, lifecycle.NewManager(), reg, cfg)
+}
+
+func TestFlushFailures_HealthAndForcedPersist(t *testing.T) {
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	for _, tc := range []struct {
+		name       string
+		failAfter  time.Duration
+		code       int
+		status     string
+		checkState string
+	}{
+		{"stale", time.Hour, http.StatusOK, "degraded", "degraded"},
+		{"past the hard threshold", time.Nanosecond, http.StatusServiceUnavailable, "unavailable", "unavailable"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFlushTestServer(t, tc.failAfter)
+			writeTo(t, s, "stuck", "cannot be flushed")
+
+			// A directory where the data file's temporary copy goes makes
+			// every write of the index fail.
+			blocker := filepath.Join(s.config.Storage.DataPath, "data", "stuck.nrdb.tmp")
+			if err := os.MkdirAll(blocker, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if rr := doRequest(t, s, "POST", "/admin/persist?index=stuck", "", admin); rr.Code != http.StatusInternalServerError {
+				t.Fatalf("expected the forced flush to fail, got %d %s", rr.Code, rr.Body.String())
+			}
+
+			rr := doRequest(t, s, "GET", "/health", "", nil)
+			doc := decodeJSON(t, rr)
+			check, _ := doc["checks"].(map[string]any)["persistence"].(map[string]any)
+			if rr.Code != tc.code || doc["status"] != tc.status || check["status"] != tc.checkState {
+				t.Fatalf("expected %d %s, got %d %v with %v", tc.code, tc.status, rr.Code, doc["status"], check)
+			}
+			if stale, _ := check["stale"].([]any); len(stale) != 1 || stale[0].(map[string]any)["indexId"] != "stuck" {
+				t.Errorf("the stuck index should be listed, got %v", check["stale"])
+			}
+
+			os.Remove(blocker)
+			rr = doRequest(t, s, "POST", "/admin/persist?index=stuck", "", admin)
+			if rr.Code != http.StatusOK || decodeJSON(t, rr)["index"] != "stuck" {
+				t.Fatalf("forced flush after recovery: %d %s", rr.Code, rr.Body.String())
+			}
+			rr = doRequest(t, s, "GET", "/health", "", nil)
+			if doc := decodeJSON(t, rr); rr.Code != http.StatusOK || doc["status"] != "healthy" || doc["checks"].(map[string]any)["persistence"] != nil {
+				t.Errorf("a successful flush should clear the check, got %d %v", rr.Code, doc)
+			}
+			if rr := doRequest(t, s, "POST", "/admin/persist?index=unknown", "", admin); rr.Code != http.StatusNotFound {
+				t.Errorf("expected 404 for an index with nothing to persist, got %d", rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// handleAdminResolve ends the quarantine of an index whose loaded state
+// diverged from its data on disk (POST /admin/indexes/{id}/resolve).
+// {"keep":"memory"} saves the loaded state over the file, {"keep":"disk"}
+// discards it so the next request loads the file.
+func (s *Server) handleAdminResolve(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	var req struct {
+		Keep string `json:"keep"`
+	}
+	if !s.decodeJSONRequest(w, r, &req) {
+		return
+	}
+	if req.Keep != concurrency.KeepMemory && req.Keep != concurrency.KeepDisk {
+		apierr.BadRequest(w, apierr.CodeBadRequest, `keep must be "memory" or "disk"`)
+		return
+	}
+
+	err := s.pool.Resolve(indexID, req.Keep)
+	switch {
+	case errors.Is(err, concurrency.ErrNotDiverged):
+		apierr.Conflict(w, apierr.CodeConflict, "index "+string(indexID)+" has not diverged")
+		return
+	case err != nil:
+		s.writePersistError(w, err)
+		return
+	}
+	if req.Keep == concurrency.KeepDisk {
+		s.lifecycle.RemoveIndex(indexID)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"resolved": true, "indexId": indexID, "kept": req.Keep})
+}
+
+// Bounds of the entries listed per kind of difference by
+// POST /admin/indexes/{id}/verify.
+const (
+	defaultVerifyLimit = 100
+	maxVerifyLimit     = 10000
+)
+
+// handleAdminVerify compares an index's loaded state with its data file
+// (POST /admin/indexes/{id}/verify?limit=): neurons and synapses missing on
+// either side or differing, and whether a pending flush explains the gap.
+// An index that is not loaded only has its file checked.
+func (s *Server) handleAdminVerify(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	limit := clampPositive(parsePositiveQueryInt(r.URL.Query().Get("limit")), defaultVerifyLimit, maxVerifyLimit)
+	report, err := s.pool.Verify(indexID, limit)
+	switch {
+	case errors.Is(err, core.ErrMatrixNotFound):
+		apierr.NotFound(w, apierr.CodeNotFound, "index "+string(indexID)+" is neither loaded nor on disk")
+		return
+	case err != nil:
+		apierr.Internal(w, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// writePersistError writes the response for a failed save of an index: 409
+// when it has diverged, 507 when the volume is full, 500 otherwise.
+func (s *Server) writePersistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, core.ErrIndexDiverged):
+		apierr.Conflict(w, apierr.CodeIndexDiverged, err.Error()+"; resolve it with POST /admin/indexes/{id}/resolve")
+	case errors.Is(err, persistence.ErrInsufficientStorage) && s.disk != nil:
+		apierr.InsufficientStorage(w, err.Error(), s.disk.Status().FreeBytes)
+	default:
+		apierr.Internal(w, err.Error())
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDivergence_AdminListingAndResolve(t *testing.T) {
+	s := newTestServer(t, nil)
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	writeTo(t, s, "div", "removed by a manual cleanup")
+	if rr := doRequest(t, s, "POST", "/admin/persist?index=div", "", admin); rr.Code != http.StatusOK {
+		t.Fatalf("persist: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// The data file disappears behind the server's back.
+	if err := os.Remove(filepath.Join(s.config.Storage.DataPath, "data", "div.nrdb")); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/indexes?diverged=true", "", admin)
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"indexId":"div"`) {
+		t.Fatalf("expected div among the diverged indexes, got %d %s", rr.Code, body)
+	}
+	rr = doRequest(t, s, "GET", "/admin/indexes?sort=id", "", admin)
+	if !strings.Contains(rr.Body.String(), `"diverged":true`) {
+		t.Errorf("the listing should flag div, got %s", rr.Body.String())
+	}
+
+	rr = doRequest(t, s, "POST", "/admin/persist?index=div", "", admin)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "INDEX_DIVERGED") {
+		t.Errorf("persisting a diverged index should be refused, got %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(s.config.Storage.DataPath, "data", "div.nrdb")); !os.IsNotExist(err) {
+		t.Errorf("the removed data file must not be resurrected: %v", err)
+	}
+
+	if rr := doRequest(t, s, "POST", "/admin/indexes/div/resolve", `{"keep":"both"}`, admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown side, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/admin/indexes/div/resolve", `{"keep":"disk"}`, admin); rr.Code != http.StatusOK {
+		t.Fatalf("resolve: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "POST", "/admin/indexes/div/resolve", `{"keep":"disk"}`, admin); rr.Code != http.StatusConflict {
+		t.Errorf("resolving twice should be refused, got %d", rr.Code)
+	}
+
+	rr = doRequest(t, s, "POST", "/v1/search", `{"query":"manual cleanup"}`, map[string]string{"X-Index-ID": "div"})
+	if strings.Contains(rr.Body.String(), "removed by a manual cleanup") {
+		t.Errorf("keeping disk should drop the loaded state, got %s", rr.Body.String())
+	}
+}
+
+func TestAdminVerify(t *testing.T) {
+	s := newTestServer(t, nil)
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	writeTo(t, s, "ver", "saved before the check")
+	if rr := doRequest(t, s, "POST", "/admin/persist?index=ver", "", admin); rr.Code != http.StatusOK {
+		t.Fatalf("persist: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr := doRequest(t, s, "POST", "/admin/indexes/ver/verify", "", admin)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("verify: %d %s", rr.Code, rr.Body.String())
+	}
+	if doc := decodeJSON(t, rr); doc["match"] != true || doc["resident"] != true {
+		t.Fatalf("expected a match right after persisting, got %v", doc)
+	}
+
+	unsaved := writeTo(t, s, "ver", "written after the flush")
+	doc := decodeJSON(t, doRequest(t, s, "POST", "/admin/indexes/ver/verify?limit=5", "", admin))
+	neurons := doc["neurons"].(map[string]any)
+	if doc["match"] != false || neurons["missingOnDiskCount"].(float64) != 1 || neurons["missingOnDisk"].([]any)[0] != string(unsaved) {
+		t.Errorf("expected the unsaved neuron missing on disk, got %v", doc)
+	}
+
+	if rr := doRequest(t, s, "POST", "/admin/indexes/nothing-here/verify", "", admin); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown index, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/protocol"
+)
+
+// neuronJSON is a neuron document with protocol.DefaultDocumentFields, as
+// a struct rather than the map protocol.Document builds, plus the fields
+// search hits and session neurons add. Its fields are in the order
+// encoding/json sorts the map's keys, so both encode to the same bytes;
+// the struct costs a fraction of the map's allocations. Instances come
+// from neuronJSONPool.
+type neuronJSON struct {
+	ID          string                 `json:"_id"`
+	AccessCount uint64                 `json:"accessCount"`
+	Attachments []attachmentJSON       `json:"attachments,omitempty"`
+	Compacted   bool                   `json:"compacted,omitempty"`
+	Content     string                 `json:"content"`
+	CreatedAt   time.Time              `json:"createdAt"`
+	Depth       int                    `json:"depth"`
+	Energy      float64                `json:"energy"`
+	Explain     *engine.ScoreBreakdown `json:"explain,omitempty"`
+	Fallback    bool                   `json:"fallback,omitempty"`
+	IDAlias     string                 `json:"id,omitempty"`
+	LastFiredAt time.Time              `json:"lastFiredAt"`
+	Links       []core.NeuronID        `json:"links,omitempty"`
+	Metadata    map[string]any         `json:"metadata"`
+	Pinned      bool                   `json:"pinned"`
+	Position    []float64              `json:"position"`
+	Promotions  []core.Promotion       `json:"promotions,omitempty"`
+	Scope       string                 `json:"scope,omitempty"`
+	Sentiment   *sentimentJSON         `json:"sentiment"`
+	SessionID   string                 `json:"sessionId,omitempty"`
+	SourceIndex string                 `json:"sourceIndex,omitempty"`
+	Tags        []string               `json:"tags"`
+
+	// Scratch reused across documents.
+	sentiment   sentimentJSON
+	attachments []attachmentJSON
+}
+
+type sentimentJSON struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+type attachmentJSON struct {
+	Caption string `json:"caption,omitempty"`
+	Hash    string `json:"hash"`
+	URL     string `json:"url"`
+}
+
+var neuronJSONPool = sync.Pool{New: func() any { return new(neuronJSON) }}
+
+// typedNeuronDocument returns n as s.neuronDocument renders it, from
+// neuronJSONPool. Its fields are copied under n's read lock, as a
+// core.Neuron.Snapshot would be. Return it with releaseNeuronDocuments.
+func (s *Server) typedNeuronDocument(ctx context.Context, n *core.Neuron) *neuronJSON {
+	d := neuronJSONPool.Get().(*neuronJSON)
+	attachments := d.attachments[:0]
+	n.RLock()
+	defer n.RUnlock()
+	*d = neuronJSON{
+		ID:          string(n.ID),
+		AccessCount: n.AccessCount,
+		Compacted:   core.IsCompacted(n),
+		Content:     n.Content,
+		CreatedAt:   n.CreatedAt,
+		Depth:       n.Depth,
+		Energy:      n.Energy,
+		LastFiredAt: n.LastFiredAt,
+		Metadata:    n.Metadata,
+		Pinned:      n.Pinned,
+		Position:    n.Position,
+		Tags:        n.Tags,
+	}
+	if apiVersionOf(ctx).Behavior.IDAlias {
+		d.IDAlias = d.ID
+	}
+	if d.Metadata == nil {
+		d.Metadata = map[string]any{}
+	}
+	if d.Position == nil {
+		d.Position = []float64{}
+	}
+	if d.Tags == nil {
+		d.Tags = []string{}
+	}
+	if n.SentimentLabel != "" {
+		d.sentiment = sentimentJSON{Label: n.SentimentLabel, Score: n.SentimentScore}
+		d.Sentiment = &d.sentiment
+	}
+	for _, a := range n.Attachments {
+		attachments = append(attachments, attachmentJSON{Caption: a.Caption, Hash: a.Hash, URL: protocol.AttachmentURL(a.Hash)})
+	}
+	if len(attachments) > 0 {
+		d.Attachments = attachments
+	}
+	d.attachments = attachments
+	if len(n.Promotions) > 0 {
+		d.Promotions = n.Promotions
+	}
+	return d
+}
+
+// typedHitDocument renders a search hit, tagging fallback hits with their
+// source index and session hits with their session, plus the hit's score
+// breakdown when explain is set.
+func (s *Server) typedHitDocument(ctx context.Context, h searchHit, explain bool) *neuronJSON {
+	d := s.typedNeuronDocument(ctx, h.neuron)
+	if h.fallback {
+		d.SourceIndex = string(h.source)
+		d.Fallback = true
+	}
+	if h.session != "" {
+		d.Scope = scopeSession
+		d.SessionID = h.session
+		d.Links = h.links
+	}
+	if explain {
+		d.Explain = h.breakdown
+	}
+	return d
+}
+
+// releaseNeuronDocuments returns the typed documents among items to
+// neuronJSONPool once they have been written.
+func releaseNeuronDocuments(items []any) {
+	for _, item := range items {
+		if d, ok := item.(*neuronJSON); ok {
+			*d = neuronJSON{attachments: d.attachments[:0]}
+			neuronJSONPool.Put(d)
+		}
+	}
+}
+
+// listFlushBytes is how much encoded output a listEncoder buffers before
+// writing it out.
+const listFlushBytes = 32 << 10
+
+// listEncoder writes a JSON object holding lists of documents as it
+// encodes them, through a reused buffer and json.Encoder. The output is
+// byte for byte what json.NewEncoder(w).Encode gives the equivalent map.
+type listEncoder struct {
+	buf  bytes.Buffer
+	enc  *json.Encoder
+	keys []string
+}
+
+var listEncoderPool = sync.Pool{New: func() any {
+	e := new(listEncoder)
+	e.enc = json.NewEncoder(&e.buf)
+	return e
+}}
+
+// writeDocumentLists writes fields and lists to w as one JSON object, its
+// keys sorted, each list item encoded in turn. Output is written every
+// listFlushBytes, so an encoding error can leave a partial object behind.
+func writeDocumentLists(w io.Writer, fields map[string]any, lists map[string][]any) error {
+	e := listEncoderPool.Get().(*listEncoder)
+	defer func() {
+		e.buf.Reset()
+		e.keys = e.keys[:0]
+		listEncoderPool.Put(e)
+	}()
+
+	for k := range fields {
+		e.keys = append(e.keys, k)
+	}
+	for k := range lists {
+		e.keys = append(e.keys, k)
+	}
+	sort.Strings(e.keys)
+
+	e.buf.WriteByte('{')
+	for i, k := range e.keys {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		if err := e.encode(k); err != nil {
+			return err
+		}
+		e.buf.WriteByte(':')
+		items, isList := lists[k]
+		if !isList {
+			if err := e.encode(fields[k]); err != nil {
+				return err
+			}
+			continue
+		}
+		e.buf.WriteByte('[')
+		for j, item := range items {
+			if j > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.encode(item); err != nil {
+				return err
+			}
+			if e.buf.Len() >= listFlushBytes {
+				if _, err := e.buf.WriteTo(w); err != nil {
+					return err
+				}
+			}
+		}
+		e.buf.WriteByte(']')
+	}
+	e.buf.WriteString("}\n")
+	_, err := e.buf.WriteTo(w)
+	return err
+}
+
+// encode appends v to the buffer without the newline json.Encoder adds.
+func (e *listEncoder) encode(v any) error {
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	e.buf.Truncate(e.buf.Len() - 1)
+	return nil
+}
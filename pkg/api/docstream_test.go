@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// streamFixture returns neurons covering every optional part of a
+// document, with fixed IDs and times.
+func streamFixture() []*core.Neuron {
+	at := time.Date(2026, 3, 1, 9, 30, 0, 123456789, time.UTC)
+	plain := core.NewNeuron("plain <b>content</b> & more", 4)
+	plain.ID, plain.CreatedAt, plain.LastFiredAt = "n-plain", at, at
+	plain.Position, plain.Tags, plain.Metadata = nil, nil, nil
+
+	rich := core.NewNeuron("rich \u2028 content with \"quotes\"", 4)
+	rich.ID, rich.CreatedAt, rich.LastFiredAt = "n-rich", at, at.Add(time.Hour)
+	rich.Position = []float64{0.1, -2.5e-8, 3}
+	rich.Tags = []string{"b", "a"}
+	rich.Metadata = map[string]any{"zeta": 1.5, "alpha": "x", core.CompactedKey: "true", "nested": map[string]any{"k": []any{1, "two"}}}
+	rich.SentimentLabel, rich.SentimentScore = "happiness", 0.4404
+	rich.Pinned, rich.AccessCount, rich.Energy, rich.Depth = true, 7, 0.333333333333, 2
+	rich.Attachments = []core.Attachment{{Hash: "abc"}, {Hash: "def", Caption: "a <caption>"}}
+	rich.Promotions = []core.Promotion{{At: at, FromDepth: 1, ToDepth: 2, Reason: "activations"}}
+	return []*core.Neuron{plain, rich}
+}
+
+// mapHitDocument renders a hit through the map path.
+func mapHitDocument(s *Server, ctx context.Context, h searchHit, explain bool) map[string]any {
+	doc := s.neuronDocument(ctx, h.neuron)
+	if h.fallback {
+		doc["sourceIndex"] = string(h.source)
+		doc["fallback"] = true
+	}
+	if h.session != "" {
+		doc["scope"] = scopeSession
+		doc["sessionId"] = h.session
+		if len(h.links) > 0 {
+			doc["links"] = h.links
+		}
+	}
+	if explain && h.breakdown != nil {
+		doc["explain"] = h.breakdown
+	}
+	return doc
+}
+
+func streamHits() []searchHit {
+	neurons := streamFixture()
+	return []searchHit{
+		{neuron: neurons[0], breakdown: &engine.ScoreBreakdown{Score: 1.25, BM25: 0.5}},
+		{neuron: neurons[1], source: "other", fallback: true},
+		{neuron: neurons[1], session: "s-1", links: []core.NeuronID{"n-plain"}},
+		{neuron: neurons[0], session: "s-2"},
+	}
+}
+
+func TestTypedDocumentsMatchMaps(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, version := range []apiVersion{apiVersions[0], latestAPIVersion()} {
+		ctx := withAPIVersion(context.Background(), version)
+		for _, explain := range []bool{false, true} {
+			for i, h := range streamHits() {
+				want, _ := json.Marshal(mapHitDocument(s, ctx, h, explain))
+				d := s.typedHitDocument(ctx, h, explain)
+				got, _ := json.Marshal(d)
+				releaseNeuronDocuments([]any{d})
+				if !bytes.Equal(got, want) {
+					t.Errorf("version %s, explain %v, hit %d:\nwant %s\ngot  %s", version.Name, explain, i, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteDocumentListsGolden(t *testing.T) {
+	s := newTestServer(t, nil)
+	ctx := context.Background()
+	fields := map[string]any{"count": 4, "query": "a <query>", "depth": 2, "roles": []string{"user"}}
+
+	var maps, typed []any
+	for _, h := range streamHits() {
+		maps = append(maps, mapHitDocument(s, ctx, h, true))
+		typed = append(typed, s.typedHitDocument(ctx, h, true))
+	}
+	defer releaseNeuronDocuments(typed)
+
+	want := map[string]any{"results": maps, "memories": maps}
+	for k, v := range fields {
+		want[k] = v
+	}
+	var wantBuf, got bytes.Buffer
+	json.NewEncoder(&wantBuf).Encode(want)
+	if err := writeDocumentLists(&got, fields, map[string][]any{"results": typed, "memories": typed}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), wantBuf.Bytes()) {
+		t.Fatalf("streamed output differs from the map encoding:\nwant %s\ngot  %s", wantBuf.Bytes(), got.Bytes())
+	}
+	assertGoldenFile(t, filepath.Join("testdata", "neuron_stream", "search.golden"), got.String())
+
+	// Lists past the flush size are written in pieces and still match.
+	long := make([]any, 0, 400)
+	for i := 0; i < 400; i++ {
+		long = append(long, s.typedNeuronDocument(ctx, streamFixture()[1]))
+	}
+	defer releaseNeuronDocuments(long)
+	got.Reset()
+	wantBuf.Reset()
+	writeDocumentLists(&got, nil, map[string][]any{"results": long})
+	json.NewEncoder(&wantBuf).Encode(map[string]any{"results": long})
+	if !bytes.Equal(got.Bytes(), wantBuf.Bytes()) {
+		t.Error("a flushed list differs from the map encoding")
+	}
+}
+
+// BenchmarkSearchResponse encodes a search response of 200 results
+// through the map path and the typed, streamed one.
+func BenchmarkSearchResponse(b *testing.B) {
+	s := newTestServer(b, nil)
+	ctx := context.Background()
+	hits := make([]searchHit, 200)
+	for i := range hits {
+		n := core.NewNeuron(fmt.Sprintf("benchmark neuron %d with a sentence of content", i), 8)
+		n.Tags = []string{"bench"}
+		n.Metadata = map[string]any{"source": "bench", "n": i}
+		hits[i] = searchHit{neuron: n}
+	}
+	fields := func() map[string]any {
+		return map[string]any{"count": len(hits), "query": "benchmark", "depth": 2}
+	}
+
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			docs := make([]map[string]any, 0, len(hits))
+			for _, h := range hits {
+				docs = append(docs, mapHitDocument(s, ctx, h, false))
+			}
+			resp := fields()
+			resp["results"] = docs
+			json.NewEncoder(discard{}).Encode(resp)
+		}
+	})
+	b.Run("typed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			docs := make([]any, len(hits))
+			for j, h := range hits {
+				docs[j] = s.typedHitDocument(ctx, h, false)
+			}
+			writeDocumentLists(discard{}, fields(), map[string][]any{"results": docs})
+			releaseNeuronDocuments(docs)
+		}
+	})
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	mcpapi "github.com/qubicDB/qubicdb/pkg/mcp"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files under testdata/")
+
+// documentShape lists a document's fields with their JSON types, one per
+// line, sorted by name.
+func documentShape(t *testing.T, doc any) string {
+	t.Helper()
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatalf("document is not a JSON object: %s", raw)
+	}
+	lines := make([]string, 0, len(m))
+	for k, v := range m {
+		typ := "null"
+		switch v.(type) {
+		case string:
+			typ = "string"
+		case float64:
+			typ = "number"
+		case bool:
+			typ = "boolean"
+		case []any:
+			typ = "array"
+		case map[string]any:
+			typ = "object"
+		}
+		lines = append(lines, k+" "+typ)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	assertGoldenFile(t, filepath.Join("testdata", "document_shape", name+".golden"), got)
+}
+
+// assertGoldenFile compares got with the golden file at path, rewriting it
+// under -update.
+func assertGoldenFile(t *testing.T, path, got string) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run with -update): %v", err)
+	}
+	if string(want) != got {
+		t.Errorf("%s drifted\n--- want\n%s--- got\n%s", path, want, got)
+	}
+}
+
+// firstItem returns m[key][0], failing when the list is empty.
+func firstItem(t *testing.T, m map[string]any, key string) any {
+	t.Helper()
+	items, _ := m[key].([]any)
+	if len(items) == 0 {
+		t.Fatalf("expected at least one item under %q, got %v", key, m)
+	}
+	return items[0]
+}
+
+func TestDocumentShapeContract_HTTP(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Write.DetectConflicts = true
+	})
+	headers := map[string]string{"X-Index-ID": "shape", "Content-Type": "application/json"}
+	call := func(method, path, body string) map[string]any {
+		t.Helper()
+		rr := doRequest(t, s, method, path, body, headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s %s: %d %s", method, path, rr.Code, rr.Body.String())
+		}
+		return decodeJSON(t, rr)
+	}
+
+	call("POST", "/v1/write", `{"content":"Dana lives in Lisbon"}`)
+	written := call("POST", "/v1/write", `{"content":"Dana lives in Porto","metadata":{"source":"chat"}}`)
+	id := written["_id"].(string)
+
+	cases := map[string]any{
+		"http_write":     written,
+		"http_read":      call("GET", "/v1/read/"+id, ""),
+		"http_search":    firstItem(t, call("POST", "/v1/search", `{"query":"Dana Porto"}`), "results"),
+		"http_recall":    firstItem(t, call("GET", "/v1/recall", ""), "memories"),
+		"http_sync":      firstItem(t, call("GET", "/v1/sync", ""), "neurons"),
+		"http_conflicts": firstItem(t, firstItem(t, call("GET", "/v1/conflicts", ""), "groups").(map[string]any), "neurons"),
+		"http_command":   firstItem(t, call("POST", "/v1/command", `{"type":"find","collection":"neurons"}`), "data"),
+	}
+	for name, doc := range cases {
+		assertGolden(t, name, documentShape(t, doc))
+	}
+}
+
+func TestDocumentShapeContract_MCP(t *testing.T) {
+	b := newTestMCPBackend(t)
+	ctx := context.Background()
+
+	written, err := b.Write(ctx, "shape", "Dana works at Initech", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := b.Read(ctx, "shape", written["_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	search, err := b.Search(ctx, "shape", "Initech", 2, 5, nil, false, nil, "", mcpapi.Anchor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recall, err := b.Recall(ctx, "shape", 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]any{
+		"mcp_write":  written,
+		"mcp_read":   read,
+		"mcp_search": search["results"].([]map[string]any)[0],
+		"mcp_recall": recall["memories"].([]map[string]any)[0],
+	}
+	for name, doc := range cases {
+		assertGolden(t, name, documentShape(t, doc))
+	}
+}
+
+// TestDocumentShapeContract_CoreFields checks that every golden shape
+// carries the guaranteed core fields.
+func TestDocumentShapeContract_CoreFields(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "document_shape", "*.golden"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no golden files found: %v", err)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{"_id string", "id string", "content string", "energy number", "depth number", "createdAt string"} {
+			if !strings.Contains(string(data), field+"\n") {
+				t.Errorf("%s: missing core field %q", filepath.Base(f), field)
+			}
+		}
+	}
+}
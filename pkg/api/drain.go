@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+)
+
+// drainRetryAfterSeconds is the Retry-After sent with DRAINING: by then a
+// load balancer has usually moved the client to another instance.
+const drainRetryAfterSeconds = 5
+
+// handleAdminDrain reports drain mode (GET /admin/drain) or enters it
+// (POST). With ?max_wait= the POST waits up to that long for resident
+// indexes to be evicted, then persists every worker, so it can be the
+// last step before stopping the instance.
+func (s *Server) handleAdminDrain(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(s.pool.DrainStatus())
+		return
+	case "POST":
+	default:
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	var maxWait time.Duration
+	if raw := r.URL.Query().Get("max_wait"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "max_wait must be a non-negative duration such as 30s")
+			return
+		}
+		if limit := s.config.Security.WriteTimeout; limit > 0 && d >= limit {
+			apierr.BadRequest(w, apierr.CodeBadRequest, fmt.Sprintf("max_wait must be shorter than security.writeTimeout=%s", limit))
+			return
+		}
+		maxWait = d
+	}
+
+	since := s.pool.Drain()
+	log.Printf("⏸ Draining since %s: no new indexes are loaded (%d resident)", since.Format(time.RFC3339), s.pool.ActiveCount())
+	if maxWait == 0 {
+		json.NewEncoder(w).Encode(s.pool.DrainStatus())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), maxWait)
+	defer cancel()
+	drained := s.pool.WaitDrained(ctx)
+	doc := map[string]any{"drained": drained, "persisted": true}
+	if err := s.pool.PersistAll(); err != nil {
+		doc["persisted"] = false
+		doc["persistError"] = err.Error()
+	}
+	doc["status"] = s.pool.DrainStatus()
+	json.NewEncoder(w).Encode(doc)
+}
+
+// handleAdminUndrain leaves drain mode (POST /admin/undrain).
+func (s *Server) handleAdminUndrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	if s.pool.Undrain() {
+		log.Printf("▶ Drain ended: loading indexes again")
+	}
+	json.NewEncoder(w).Encode(s.pool.DrainStatus())
+}
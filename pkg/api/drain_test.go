@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestDrainMode(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	write := func(index string) int {
+		return doRequest(t, s, "POST", "/v1/write", `{"content":"deploy checklist"}`, map[string]string{"X-Index-ID": index}).Code
+	}
+	if code := write("resident"); code != http.StatusOK {
+		t.Fatalf("write: %d", code)
+	}
+
+	rr := doRequest(t, s, "POST", "/admin/drain", "", admin)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("drain: %d %s", rr.Code, rr.Body.String())
+	}
+	if doc := decodeJSON(t, rr); doc["draining"] != true || doc["resident"] != float64(1) {
+		t.Fatalf("unexpected drain status %v", doc)
+	}
+
+	// Loaded indexes keep serving; new ones are sent elsewhere.
+	if code := write("resident"); code != http.StatusOK {
+		t.Errorf("a resident index should still be served while draining, got %d", code)
+	}
+	rr = doRequest(t, s, "POST", "/v1/write", `{"content":"new tenant"}`, map[string]string{"X-Index-ID": "newcomer"})
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("a new index should be refused with 503 and Retry-After, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if code := decodeJSON(t, rr)["code"]; code != "DRAINING" {
+		t.Errorf("expected code DRAINING, got %v", code)
+	}
+
+	health := doRequest(t, s, "GET", "/health", "", nil)
+	if health.Code != http.StatusServiceUnavailable || decodeJSON(t, health)["status"] != "draining" {
+		t.Errorf("readiness should report draining, got %d", health.Code)
+	}
+	status := decodeJSON(t, doRequest(t, s, "GET", "/admin/drain", "", admin))
+	if status["rejected"] != float64(1) || status["since"] == nil {
+		t.Errorf("drain status should count the refused request: %v", status)
+	}
+
+	if rr := doRequest(t, s, "POST", "/admin/undrain", "", admin); rr.Code != http.StatusOK || decodeJSON(t, rr)["draining"] != false {
+		t.Fatalf("undrain: %d", rr.Code)
+	}
+	if code := write("newcomer"); code != http.StatusOK {
+		t.Errorf("new indexes should load again after undrain, got %d", code)
+	}
+	if health := doRequest(t, s, "GET", "/health", "", nil); health.Code != http.StatusOK {
+		t.Errorf("readiness should recover after undrain, got %d", health.Code)
+	}
+}
+
+func TestDrainMaxWait(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	for _, index := range []string{"a", "b"} {
+		if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"shutdown soon"}`, map[string]string{"X-Index-ID": index}); rr.Code != http.StatusOK {
+			t.Fatalf("write: %d", rr.Code)
+		}
+	}
+	if rr := doRequest(t, s, "POST", "/admin/drain?max_wait=1h", "", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("max_wait beyond the write timeout should be refused, got %d", rr.Code)
+	}
+
+	// Still resident when the wait runs out: everything is flushed anyway.
+	rr := doRequest(t, s, "POST", "/admin/drain?max_wait=100ms", "", admin)
+	doc := decodeJSON(t, rr)
+	if rr.Code != http.StatusOK || doc["drained"] != false || doc["persisted"] != true {
+		t.Fatalf("drain with max_wait: %d %v", rr.Code, doc)
+	}
+	if !s.pool.Persisted("a") || !s.pool.Persisted("b") {
+		t.Error("resident indexes should be persisted before the drain returns")
+	}
+
+	// Evictions empty the instance before the wait runs out.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.pool.Evict("a")
+		s.pool.Evict("b")
+	}()
+	doc = decodeJSON(t, doRequest(t, s, "POST", "/admin/drain?max_wait=5s", "", admin))
+	if doc["drained"] != true || doc["status"].(map[string]any)["resident"] != float64(0) {
+		t.Errorf("the drain should return once no index is resident: %v", doc)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// queryExpansion is the expansion of a search or context query: the terms
+// added and how much each counts lexically.
+type queryExpansion struct {
+	terms  []string
+	weight float64
+}
+
+// apply adds the expansion to req.
+func (x *queryExpansion) apply(req *concurrency.SearchRequest) {
+	if x != nil {
+		req.Expansion, req.ExpansionWeight = x.terms, x.weight
+	}
+}
+
+// response returns the added terms as reported in a response.
+func (x *queryExpansion) response() []string {
+	if x.terms == nil {
+		return []string{}
+	}
+	return x.terms
+}
+
+// expandQuery expands query on indexID when the request asks for it
+// (requested, from expand_query) or, when it does not say, when the
+// index's expansion settings enable it. It returns nil when no expansion
+// ran; an expansion that ran may add no terms, e.g. for a long query.
+func (s *Server) expandQuery(ctx context.Context, worker *concurrency.BrainWorker, indexID core.IndexID, query, lang string, requested *bool, strong bool) (*queryExpansion, error) {
+	cfg := s.registry.Expansion(string(indexID), s.config.Search.Expansion)
+	enabled := cfg.Enabled
+	if requested != nil {
+		enabled = *requested
+	}
+	if !enabled || query == "" {
+		return nil, nil
+	}
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
+		Type: concurrency.OpExpandQuery,
+		Payload: concurrency.ExpandQueryRequest{
+			Query:    query,
+			Language: lang,
+			Options: engine.ExpansionOptions{
+				MaxTerms:       cfg.MaxTerms,
+				MaxAddedTokens: cfg.MaxAddedTokens,
+				MaxCueTokens:   cfg.MaxCueTokens,
+				Seeds:          cfg.Seeds,
+			},
+		},
+		Strong: strong,
+	})
+	if err != nil {
+		return nil, err
+	}
+	terms, _ := result.([]string)
+	return &queryExpansion{terms: terms, weight: cfg.Weight}, nil
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// expansionTerms returns the expansion a response reports, failing when it
+// reports none.
+func expansionTerms(t *testing.T, resp map[string]any) []string {
+	t.Helper()
+	raw, ok := resp["expansion"].([]any)
+	if !ok {
+		t.Fatalf("expected an expansion in %v", resp)
+	}
+	terms := make([]string, len(raw))
+	for i, v := range raw {
+		terms[i] = v.(string)
+	}
+	return terms
+}
+
+func TestSearch_ExpandQueryRecoversRelatedMemories(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, c := range []string{
+		"weekly meeting about the apollo launch",
+		"apollo standup: launch slipped again",
+		"apollo retro: launch went well",
+		"grocery list: eggs milk bread",
+		"dentist appointment tuesday",
+		"water the garden plants",
+		"renew car insurance policy",
+		"call mom on sunday",
+	} {
+		writeTo(t, s, "work", c)
+	}
+	for _, c := range []string{
+		"budget meeting with finance",
+		"finance review: budget approved",
+		"quarterly budget forecast for finance",
+		"book flights to lisbon",
+		"fix the leaking kitchen tap",
+		"read the new novel",
+	} {
+		writeTo(t, s, "money", c)
+	}
+	headers := map[string]string{"X-Index-ID": "work"}
+
+	// Writes in quick succession are linked, so the searches spread over
+	// follows synapses only, of which there are none.
+	search := func(query string) (contents []string, resp map[string]any) {
+		rr := doRequest(t, s, "GET", "/v1/search?q="+query+"&spread_types=follows", "", headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("search: %d %s", rr.Code, rr.Body.String())
+		}
+		resp = decodeJSON(t, rr)
+		for _, r := range resp["results"].([]any) {
+			contents = append(contents, r.(map[string]any)["content"].(string))
+		}
+		return contents, resp
+	}
+	plain, resp := search("meeting")
+	if slices.Contains(plain, "apollo retro: launch went well") {
+		t.Fatalf("expected the unexpanded query to miss the retro, got %v", plain)
+	}
+	if _, ok := resp["expansion"]; ok {
+		t.Errorf("expected no expansion by default, got %v", resp["expansion"])
+	}
+	expanded, resp := search("meeting&expand_query=true")
+	for _, want := range []string{"apollo standup: launch slipped again", "apollo retro: launch went well"} {
+		if !slices.Contains(expanded, want) {
+			t.Errorf("expected %q in the expanded results %v", want, expanded)
+		}
+	}
+	work := expansionTerms(t, resp)
+	if !slices.Contains(work, "apollo") {
+		t.Errorf("expected apollo in the expansion, got %v", work)
+	}
+	if _, resp := search("meeting&expand_query=true&mode=exact"); resp["expansion"] != nil {
+		t.Error("expected an exact search not to be expanded")
+	}
+
+	ctx := decodeJSON(t, doRequest(t, s, "POST", "/v1/context", `{"cue":"meeting","expand_query":true}`, headers))
+	if terms := expansionTerms(t, ctx); !slices.Equal(terms, work) {
+		t.Errorf("expected context to expand as search does, got %v", terms)
+	}
+	ctx = decodeJSON(t, doRequest(t, s, "POST", "/v1/context", `{"cue":"meeting","expand_query":true}`, map[string]string{"X-Index-ID": "money"}))
+	money := expansionTerms(t, ctx)
+	if !slices.Contains(money, "budget") || slices.Equal(money, work) {
+		t.Errorf("expected the money index to expand with its own vocabulary, got %v (work %v)", money, work)
+	}
+}
+
+func TestContext_ExpansionRegistryOverride(t *testing.T) {
+	s := newTestServer(t, nil)
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"notes","metadata":{"expansion":{"maxTerms":0}}}`, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid expansion settings refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"notes","metadata":{"expansion":{"enabled":true,"maxTerms":1}}}`, nil); rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+		t.Fatalf("register: %d %s", rr.Code, rr.Body.String())
+	}
+	for _, c := range []string{
+		"apollo launch meeting",
+		"apollo launch review",
+		"grocery list: eggs milk bread",
+		"dentist appointment tuesday",
+		"water the garden plants",
+	} {
+		writeTo(t, s, "notes", c)
+	}
+
+	headers := map[string]string{"X-Index-ID": "notes"}
+	resp := decodeJSON(t, doRequest(t, s, "POST", "/v1/context", `{"cue":"meeting"}`, headers))
+	if terms := expansionTerms(t, resp); len(terms) != 1 {
+		t.Errorf("expected the index to expand by one term, got %v", terms)
+	}
+	resp = decodeJSON(t, doRequest(t, s, "POST", "/v1/context", `{"cue":"meeting","expand_query":false}`, headers))
+	if _, ok := resp["expansion"]; ok {
+		t.Errorf("expected expand_query false to win, got %v", resp["expansion"])
+	}
+}
@@ -0,0 +1,520 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// sliceFormat names the export envelope; sliceVersion is bumped on
+// incompatible changes.
+const (
+	sliceFormat  = "qubicdb-slice"
+	sliceVersion = 1
+)
+
+// Export formats. An ndjson export is the slice stream below; an nrdb
+// export is the index's persisted .nrdb file, with embeddings and
+// positions, and is imported by replacing the index.
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatNRDB   = "nrdb"
+
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeNRDB   = "application/x-nrdb"
+)
+
+// exportFormatOf returns the format a request selects: ?format= if given,
+// otherwise the first of the media types in header (Accept for an export,
+// Content-Type for an import) naming one; ndjson by default.
+func exportFormatOf(r *http.Request, header string) (string, error) {
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		switch raw {
+		case exportFormatNDJSON, "jsonl":
+			return exportFormatNDJSON, nil
+		case exportFormatNRDB, "msgpack":
+			return exportFormatNRDB, nil
+		}
+		return "", fmt.Errorf("format must be ndjson or nrdb, got %q", raw)
+	}
+	for _, part := range strings.Split(r.Header.Get(header), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case contentTypeNDJSON, "application/jsonl", "application/json":
+			return exportFormatNDJSON, nil
+		case contentTypeNRDB, "application/msgpack", "application/x-msgpack", "application/vnd.msgpack":
+			return exportFormatNRDB, nil
+		}
+	}
+	return exportFormatNDJSON, nil
+}
+
+// maxExportNeighborHops bounds ?include_neighbors of an export.
+const maxExportNeighborHops = 8
+
+// Line types of an export stream.
+const (
+	sliceLineHeader  = "header"
+	sliceLineNeuron  = "neuron"
+	sliceLineSynapse = "synapse"
+	sliceLineBlob    = "blob"
+	sliceLineFooter  = "footer"
+)
+
+// sliceHeader is the first line of an export: what was exported and how
+// it was selected.
+type sliceHeader struct {
+	Type             string             `json:"type"`
+	Format           string             `json:"format"`
+	Version          int                `json:"version"`
+	IndexID          string             `json:"indexId"`
+	ExportedAt       time.Time          `json:"exportedAt"`
+	Partial          bool               `json:"partial"`
+	Filter           engine.SliceFilter `json:"filter"`
+	IncludeNeighbors int                `json:"includeNeighbors"`
+}
+
+// sliceFooter is the last line of an export. An import without it was
+// cut short.
+type sliceFooter struct {
+	Type     string `json:"type"`
+	Neurons  int    `json:"neurons"`
+	Synapses int    `json:"synapses"`
+	Dangling int    `json:"dangling"`
+	Blobs    int    `json:"blobs"`
+}
+
+type sliceNeuronLine struct {
+	Type string `json:"type"`
+	engine.ExportedNeuron
+}
+
+type sliceSynapseLine struct {
+	Type string `json:"type"`
+	engine.ExportedSynapse
+}
+
+// sliceBlobLine carries an attachment blob referenced by an exported
+// neuron; Data is base64 in JSON.
+type sliceBlobLine struct {
+	Type string `json:"type"`
+	persistence.BlobInfo
+	Data []byte `json:"data"`
+}
+
+// parseSliceFilter reads an export's filter from the query: metadata_<key>
+// pairs, strict, since and until (RFC 3339) and include_neighbors.
+func parseSliceFilter(q url.Values) (engine.SliceFilter, int, error) {
+	var f engine.SliceFilter
+	for k, vs := range q {
+		if strings.HasPrefix(k, "metadata_") && len(vs) > 0 {
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			f.Metadata[strings.TrimPrefix(k, "metadata_")] = vs[0]
+		}
+	}
+	f.Strict = q.Get("strict") == "true"
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		raw := q.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return f, 0, fmt.Errorf("%s must be an RFC 3339 time, got %q", bound.name, raw)
+		}
+		*bound.dst = &t
+	}
+	if f.Since != nil && f.Until != nil && !f.Until.After(*f.Since) {
+		return f, 0, fmt.Errorf("until must be after since")
+	}
+	hops := 0
+	if raw := q.Get("include_neighbors"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxExportNeighborHops {
+			return f, 0, fmt.Errorf("include_neighbors must be an integer from 0 to %d", maxExportNeighborHops)
+		}
+		hops = n
+	}
+	return f, hops, nil
+}
+
+// handleAdminExport streams the neurons of an index matching the query's
+// filter, with their neighborhood and the synapses touching them, as
+// newline-delimited JSON: a header line, one line per neuron and synapse,
+// one per attachment blob the neurons reference, and a footer line. Without a filter the whole index is exported.
+// With format nrdb (?format= or Accept) the whole index is written as an
+// .nrdb file instead; see handleAdminExportNRDB.
+func (s *Server) handleAdminExport(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	format, err := exportFormatOf(r, "Accept")
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	filter, hops, err := parseSliceFilter(r.URL.Query())
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	if format == exportFormatNRDB && (!filter.Empty() || hops > 0) {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "an nrdb export is the whole index; filters need format=ndjson")
+		return
+	}
+	worker, err := s.pool.Get(indexID)
+	if err != nil && s.pool.Persisted(indexID) {
+		worker, err = s.pool.GetOrCreate(indexID)
+	}
+	if err != nil {
+		apierr.NotFound(w, apierr.CodeNotFound, "index not found")
+		return
+	}
+	if format == exportFormatNRDB {
+		s.handleAdminExportNRDB(w, r, worker, indexID)
+		return
+	}
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type:    concurrency.OpExportSlice,
+		Payload: concurrency.ExportSliceRequest{Filter: filter, NeighborHops: hops},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+	slice := result.(engine.Slice)
+
+	w.Header().Set("Content-Type", contentTypeNDJSON)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", string(indexID)+".ndjson"))
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.Encode(sliceHeader{
+		Type:             sliceLineHeader,
+		Format:           sliceFormat,
+		Version:          sliceVersion,
+		IndexID:          string(indexID),
+		ExportedAt:       time.Now().UTC(),
+		Partial:          !filter.Empty(),
+		Filter:           filter,
+		IncludeNeighbors: hops,
+	})
+	for _, n := range slice.Neurons {
+		if err := enc.Encode(sliceNeuronLine{Type: sliceLineNeuron, ExportedNeuron: n}); err != nil {
+			return // client gone
+		}
+	}
+	footer := sliceFooter{Type: sliceLineFooter, Neurons: len(slice.Neurons), Synapses: len(slice.Synapses)}
+	for _, syn := range slice.Synapses {
+		if syn.Dangling {
+			footer.Dangling++
+		}
+		if err := enc.Encode(sliceSynapseLine{Type: sliceLineSynapse, ExportedSynapse: syn}); err != nil {
+			return
+		}
+	}
+	// A blob swept or lost since the neuron referenced it is left out; the
+	// import drops the reference.
+	blobs := s.pool.Blobs()
+	seen := make(map[string]bool)
+	for _, n := range slice.Neurons {
+		for _, a := range n.Attachments {
+			if seen[a.Hash] {
+				continue
+			}
+			seen[a.Hash] = true
+			line, err := readBlobLine(blobs, a.Hash)
+			if err != nil {
+				continue
+			}
+			if err := enc.Encode(line); err != nil {
+				return
+			}
+			footer.Blobs++
+		}
+	}
+	enc.Encode(footer)
+	bw.Flush()
+}
+
+// handleAdminExportNRDB writes the whole index in the persisted .nrdb
+// format: neurons with their embeddings and positions, and synapses, as
+// the data directory holds them. Attachment blobs are not included. The
+// worker copies the matrix in step with its writes and the copy is
+// encoded, whole, since the file's header carries the data's length and
+// checksum.
+func (s *Server) handleAdminExportNRDB(w http.ResponseWriter, r *http.Request, worker *concurrency.BrainWorker, indexID core.IndexID) {
+	m, err := worker.Snapshot(r.Context())
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+	data, err := persistence.NewCodec(s.config.Storage.Compress).Encode(m)
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeNRDB)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", string(indexID)+".nrdb"))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// readBlobLine reads the blob stored under hash into an export line.
+func readBlobLine(blobs *persistence.BlobStore, hash string) (sliceBlobLine, error) {
+	f, info, err := blobs.Open(hash)
+	if err != nil {
+		return sliceBlobLine{}, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	return sliceBlobLine{Type: sliceLineBlob, BlobInfo: info, Data: data}, err
+}
+
+// readSlice decodes an export stream. It checks the header and that the
+// footer's counts match what was read.
+func readSlice(body io.Reader) (sliceHeader, engine.Slice, []sliceBlobLine, error) {
+	var header sliceHeader
+	var slice engine.Slice
+	var blobs []sliceBlobLine
+	dec := json.NewDecoder(body)
+	line := 0
+	footer := false
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return header, slice, blobs, fmt.Errorf("line %d: %w", line+1, err)
+		}
+		line++
+		var kind struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &kind); err != nil {
+			return header, slice, blobs, fmt.Errorf("line %d: %w", line, err)
+		}
+		if line == 1 && kind.Type != sliceLineHeader {
+			return header, slice, blobs, errors.New("line 1: expected the export header")
+		}
+		if footer {
+			return header, slice, blobs, fmt.Errorf("line %d: data after the footer", line)
+		}
+
+		var err error
+		switch kind.Type {
+		case sliceLineHeader:
+			if line != 1 {
+				return header, slice, blobs, fmt.Errorf("line %d: a second header", line)
+			}
+			if err = json.Unmarshal(raw, &header); err == nil && (header.Format != sliceFormat || header.Version != sliceVersion) {
+				err = fmt.Errorf("unsupported format %s version %d", header.Format, header.Version)
+			}
+		case sliceLineNeuron:
+			var n engine.ExportedNeuron
+			err = json.Unmarshal(raw, &n)
+			slice.Neurons = append(slice.Neurons, n)
+		case sliceLineSynapse:
+			var syn engine.ExportedSynapse
+			err = json.Unmarshal(raw, &syn)
+			slice.Synapses = append(slice.Synapses, syn)
+		case sliceLineBlob:
+			var b sliceBlobLine
+			err = json.Unmarshal(raw, &b)
+			blobs = append(blobs, b)
+		case sliceLineFooter:
+			var f sliceFooter
+			if err = json.Unmarshal(raw, &f); err == nil && (f.Neurons != len(slice.Neurons) || f.Synapses != len(slice.Synapses) || f.Blobs != len(blobs)) {
+				err = fmt.Errorf("footer counts %d neurons, %d synapses and %d blobs, read %d, %d and %d",
+					f.Neurons, f.Synapses, f.Blobs, len(slice.Neurons), len(slice.Synapses), len(blobs))
+			}
+			footer = true
+		default:
+			err = fmt.Errorf("unknown line type %q", kind.Type)
+		}
+		if err != nil {
+			return header, slice, blobs, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if line == 0 {
+		return header, slice, blobs, errors.New("empty export")
+	}
+	if !footer {
+		return header, slice, blobs, errors.New("export is truncated: no footer line")
+	}
+	return header, slice, blobs, nil
+}
+
+// handleAdminImport adds an export stream to an index. ?dangling=drop|keep
+// overrides import.danglingSynapses for synapses whose other end is in
+// neither the slice nor the index. An nrdb export (?format= or
+// Content-Type) replaces the index instead; see handleAdminImportNRDB.
+func (s *Server) handleAdminImport(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	format, err := exportFormatOf(r, "Content-Type")
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	if format == exportFormatNRDB {
+		s.handleAdminImportNRDB(w, r, indexID)
+		return
+	}
+	dangling := s.config.Import.DanglingSynapses
+	if raw := r.URL.Query().Get("dangling"); raw != "" {
+		if raw != core.DanglingDrop && raw != core.DanglingKeep {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "dangling must be drop or keep")
+			return
+		}
+		dangling = raw
+	}
+
+	header, slice, blobs, err := readSlice(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			apierr.PayloadTooLarge(w, fmt.Sprintf("%v (import.maxUploadBytes=%d)", err, s.config.Import.MaxUploadBytes))
+			return
+		}
+		apierr.BadRequest(w, apierr.CodeInvalidArchive, err.Error())
+		return
+	}
+	blobReport, err := s.importBlobs(blobs, &slice)
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeInvalidArchive, err.Error())
+		return
+	}
+
+	if s.config.Registry.Enabled {
+		if _, _, err := s.registry.FindOrCreate(string(indexID), nil); err != nil {
+			apierr.Internal(w, err.Error())
+			return
+		}
+	}
+	worker, err := s.pool.GetOrCreate(indexID)
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+	s.lifecycle.RecordActivity(indexID)
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type:    concurrency.OpImportSlice,
+		Payload: concurrency.ImportSliceRequest{Slice: slice, KeepDangling: dangling == core.DanglingKeep},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId": indexID,
+		"source": map[string]any{
+			"indexId":          header.IndexID,
+			"exportedAt":       header.ExportedAt,
+			"partial":          header.Partial,
+			"filter":           header.Filter,
+			"includeNeighbors": header.IncludeNeighbors,
+		},
+		"result": result,
+		"blobs":  blobReport,
+	})
+}
+
+// handleAdminImportNRDB replaces an index with an .nrdb export, restoring
+// it exactly as exported. A non-empty index is replaced only with
+// ?force=true, and an append-only one also with ?override_append_only=true;
+// the replaced state is retained as a version.
+func (s *Server) handleAdminImportNRDB(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	if force && !s.allowDestructiveAdmin(w, r, indexID, "nrdb import") {
+		return
+	}
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			apierr.PayloadTooLarge(w, fmt.Sprintf("%v (import.maxUploadBytes=%d)", err, s.config.Import.MaxUploadBytes))
+			return
+		}
+		apierr.BadRequest(w, apierr.CodeInvalidArchive, err.Error())
+		return
+	}
+	matrix, err := persistence.NewCodec(s.config.Storage.Compress).Decode(raw)
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeInvalidArchive, "invalid nrdb export: "+err.Error())
+		return
+	}
+	source := matrix.IndexID
+
+	if s.config.Registry.Enabled {
+		if _, _, err := s.registry.FindOrCreate(string(indexID), nil); err != nil {
+			apierr.Internal(w, err.Error())
+			return
+		}
+	}
+	switch err := s.pool.Replace(indexID, matrix, force); {
+	case errors.Is(err, core.ErrIndexNotEmpty):
+		apierr.Conflict(w, apierr.CodeConflict, "index already holds data; use ?force=true to replace it")
+		return
+	case err != nil:
+		s.writePersistError(w, err)
+		return
+	}
+	s.lifecycle.RemoveIndex(indexID)
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId":  indexID,
+		"format":   exportFormatNRDB,
+		"replaced": true,
+		"source":   map[string]any{"indexId": source},
+		"neurons":  len(matrix.Neurons),
+		"synapses": len(matrix.Synapses),
+	})
+}
+
+// importBlobs stores the blobs of an export, and drops the attachment
+// references of slice whose blob is neither in the export nor already
+// stored.
+func (s *Server) importBlobs(lines []sliceBlobLine, slice *engine.Slice) (map[string]int, error) {
+	store := s.pool.Blobs()
+	for _, line := range lines {
+		if !persistence.ValidBlobHash(line.Hash) {
+			return nil, fmt.Errorf("blob %q: invalid hash", line.Hash)
+		}
+		if int64(len(line.Data)) > s.config.Security.MaxAttachmentBytes {
+			return nil, fmt.Errorf("blob %s exceeds security.maxAttachmentBytes=%d", line.Hash, s.config.Security.MaxAttachmentBytes)
+		}
+		if err := store.PutVerified(line.BlobInfo, line.Data); err != nil {
+			return nil, err
+		}
+	}
+	missing := 0
+	for i := range slice.Neurons {
+		n := &slice.Neurons[i]
+		kept := n.Attachments[:0]
+		for _, a := range n.Attachments {
+			if err := store.Touch(a.Hash); err != nil {
+				missing++
+				continue
+			}
+			kept = append(kept, a)
+		}
+		if len(kept) == 0 {
+			kept = nil
+		}
+		n.Attachments = kept
+	}
+	return map[string]int{"imported": len(lines), "missingReferences": missing}, nil
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// exportLines splits an export stream into its decoded lines.
+func exportLines(t *testing.T, body string) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	sc := bufio.NewScanner(strings.NewReader(body))
+	sc.Buffer(make([]byte, 1<<20), 1<<20)
+	for sc.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("bad export line %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestExportImportSlice(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+
+	// A generated brain: three threads of five memories, chained within
+	// each thread, with the last memory of conv-42 linked to conv-7.
+	ids := map[string][]core.NeuronID{}
+	for _, thread := range []string{"conv-7", "conv-42", "conv-99"} {
+		for i := 0; i < 5; i++ {
+			body := `{"content":"` + thread + ` memory number ` + string(rune('a'+i)) + `","metadata":{"thread_id":"` + thread + `"}}`
+			rr := doRequest(t, s, "POST", "/v1/write", body, map[string]string{"X-Index-ID": "src"})
+			if rr.Code != http.StatusOK {
+				t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+			}
+			ids[thread] = append(ids[thread], core.NeuronID(decodeJSON(t, rr)["id"].(string)))
+		}
+	}
+	worker, _ := s.pool.Get("src")
+	m := worker.Matrix()
+	link := func(from, to core.NeuronID) {
+		syn := core.NewSynapse(from, to, 0.6)
+		m.Synapses[syn.ID] = syn
+		m.Adjacency[from] = append(m.Adjacency[from], to)
+		m.Adjacency[to] = append(m.Adjacency[to], from)
+	}
+	m.Lock()
+	// Start from a known graph rather than the synapses writes formed.
+	m.Synapses = make(map[core.SynapseID]*core.Synapse)
+	for id := range m.Adjacency {
+		m.Adjacency[id] = nil
+	}
+	for _, thread := range []string{"conv-7", "conv-42", "conv-99"} {
+		for i := 0; i+1 < 5; i++ {
+			link(ids[thread][i], ids[thread][i+1])
+		}
+	}
+	link(ids["conv-42"][4], ids["conv-7"][0])
+	// conv-42's first memory predates the month being exported.
+	m.Neurons[ids["conv-42"][0]].CreatedAt = time.Now().AddDate(0, -2, 0)
+	m.Unlock()
+
+	q := url.Values{}
+	q.Set("metadata_thread_id", "conv-42")
+	q.Set("since", time.Now().AddDate(0, -1, 0).UTC().Format(time.RFC3339))
+	rr := doRequest(t, s, "GET", "/admin/indexes/src/export?"+q.Encode(), "", admin)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("export: %d %s %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+	lines := exportLines(t, rr.Body.String())
+	header, footer := lines[0], lines[len(lines)-1]
+	if header["type"] != "header" || header["partial"] != true || header["filter"].(map[string]any)["metadata"].(map[string]any)["thread_id"] != "conv-42" {
+		t.Errorf("the header should record the filter: %v", header)
+	}
+	// conv-42 b..e, the b-c..d-e synapses, and two boundary synapses: a-b
+	// (a is too old) and e-conv-7.
+	if footer["type"] != "footer" || footer["neurons"] != float64(4) || footer["synapses"] != float64(5) || footer["dangling"] != float64(2) {
+		t.Fatalf("unexpected footer: %v", footer)
+	}
+
+	for _, mode := range []string{"drop", "keep"} {
+		dst := "dst-" + mode
+		imp := doRequest(t, s, "POST", "/admin/indexes/"+dst+"/import?dangling="+mode, rr.Body.String(), admin)
+		if imp.Code != http.StatusOK {
+			t.Fatalf("import: %d %s", imp.Code, imp.Body.String())
+		}
+		result := decodeJSON(t, imp)["result"].(map[string]any)
+		if result["created"] != float64(4) || result["synapses"] != float64(3) || result["dangling"] != float64(2) {
+			t.Errorf("%s: unexpected import result %v", mode, result)
+		}
+
+		w, _ := s.pool.Get(core.IndexID(dst))
+		got := w.Matrix()
+		got.RLock()
+		if len(got.Neurons) != 4 || len(got.Synapses) != 3 {
+			t.Errorf("%s: expected the 4-neuron conv-42 subgraph, got %d neurons and %d synapses", mode, len(got.Neurons), len(got.Synapses))
+		}
+		for _, id := range ids["conv-42"][1:] {
+			if n, ok := got.Neurons[id]; !ok || n.Metadata["thread_id"] != "conv-42" {
+				t.Errorf("%s: missing conv-42 neuron %s", mode, id)
+			}
+		}
+		for i := 1; i+1 < 5; i++ {
+			if _, ok := got.Synapses[core.NewSynapseID(ids["conv-42"][i], ids["conv-42"][i+1])]; !ok {
+				t.Errorf("%s: missing synapse %d-%d", mode, i, i+1)
+			}
+		}
+		last := got.Neurons[ids["conv-42"][4]].Metadata[engine.DanglingSynapsesKey]
+		first := got.Neurons[ids["conv-42"][1]].Metadata[engine.DanglingSynapsesKey]
+		got.RUnlock()
+		if mode == "drop" && (last != nil || first != nil) {
+			t.Errorf("dropped dangling synapses must leave no trace, got %v %v", first, last)
+		}
+		if mode == "keep" && (len(last.([]string)) != 1 || last.([]string)[0] != string(ids["conv-7"][0]) || len(first.([]string)) != 1) {
+			t.Errorf("kept dangling synapses should be recorded, got %v %v", first, last)
+		}
+	}
+
+	if rr := doRequest(t, s, "POST", "/admin/indexes/dst-cut/import", strings.SplitN(rr.Body.String(), "\n", 3)[0]+"\n", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("a truncated export should be refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "GET", "/admin/indexes/src/export?include_neighbors=99", "", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for too many hops, got %d", rr.Code)
+	}
+
+	// One hop of neighbors brings back conv-42's first memory and conv-7's.
+	rr = doRequest(t, s, "GET", "/admin/indexes/src/export?"+q.Encode()+"&include_neighbors=1", "", admin)
+	lines = exportLines(t, rr.Body.String())
+	if footer := lines[len(lines)-1]; footer["neurons"] != float64(6) || lines[0]["includeNeighbors"] != float64(1) {
+		t.Errorf("unexpected neighborhood export: %v %v", lines[0], footer)
+	}
+}
+
+func TestExportImportNRDB(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	for _, content := range []string{"the first memory", "the second memory", "the third memory"} {
+		if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, map[string]string{"X-Index-ID": "src"}); rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/indexes/src/export?format=nrdb", "", admin)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/x-nrdb" || !strings.HasPrefix(rr.Body.String(), "NRDB") {
+		t.Fatalf("export: %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	export := rr.Body.String()
+	accept := map[string]string{"Authorization": admin["Authorization"], "Accept": "application/msgpack"}
+	if rr := doRequest(t, s, "GET", "/admin/indexes/src/export", "", accept); rr.Header().Get("Content-Type") != "application/x-nrdb" {
+		t.Errorf("Accept should select nrdb, got %s", rr.Header().Get("Content-Type"))
+	}
+	if rr := doRequest(t, s, "GET", "/admin/indexes/src/export?format=nrdb&metadata_thread=a", "", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("a filtered nrdb export should be refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "GET", "/admin/indexes/src/export?format=xml", "", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("an unknown format should be refused, got %d", rr.Code)
+	}
+
+	nrdb := map[string]string{"Authorization": admin["Authorization"], "Content-Type": "application/x-nrdb"}
+	imp := doRequest(t, s, "POST", "/admin/indexes/dst/import", export, nrdb)
+	if imp.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", imp.Code, imp.Body.String())
+	}
+	if body := decodeJSON(t, imp); body["neurons"] != float64(3) || body["source"].(map[string]any)["indexId"] != "src" {
+		t.Errorf("unexpected import response %v", body)
+	}
+	src, _ := s.pool.Get("src")
+	dst, err := s.pool.GetOrCreate("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, got := src.Matrix(), dst.Matrix()
+	want.RLock()
+	got.RLock()
+	if len(got.Neurons) != len(want.Neurons) || len(got.Synapses) != len(want.Synapses) {
+		t.Errorf("expected %d neurons and %d synapses, got %d and %d", len(want.Neurons), len(want.Synapses), len(got.Neurons), len(got.Synapses))
+	}
+	for id, n := range want.Neurons {
+		m, ok := got.Neurons[id]
+		if !ok || m.Content != n.Content || m.Energy != n.Energy || len(m.Position) != len(n.Position) || len(m.Embedding) != len(n.Embedding) {
+			t.Errorf("neuron %s not restored exactly", id)
+		}
+	}
+	got.RUnlock()
+	want.RUnlock()
+
+	if rr := doRequest(t, s, "POST", "/admin/indexes/dst/import?format=nrdb", export, admin); rr.Code != http.StatusConflict {
+		t.Errorf("importing over a non-empty index needs force, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/admin/indexes/dst/import?format=nrdb&force=true", export, admin); rr.Code != http.StatusOK {
+		t.Errorf("forced import: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "POST", "/admin/indexes/bad/import", export[:len(export)/2], nrdb); rr.Code != http.StatusBadRequest {
+		t.Errorf("a truncated nrdb export should be refused, got %d", rr.Code)
+	}
+
+	// With the registry guard on, the restored index is registered.
+	s.config.Registry.Enabled = true
+	if rr := doRequest(t, s, "POST", "/admin/indexes/fresh/import", export, nrdb); rr.Code != http.StatusOK {
+		t.Fatalf("import with the guard on: %d %s", rr.Code, rr.Body.String())
+	}
+	if !s.registry.Exists("fresh") {
+		t.Error("expected the imported index to be registered")
+	}
+}
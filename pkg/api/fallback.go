@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// defaultFallbackMinResults is how many primary hits suppress fallback
+// when the request does not say otherwise.
+const defaultFallbackMinResults = 1
+
+// fallbackOptions controls when fallback indexes are consulted and how their
+// hits are combined with the primary index's hits.
+type fallbackOptions struct {
+	minResults int     // primary hits at or above minScore needed to skip fallback
+	minScore   float64 // hits scoring below this do not count toward minResults
+	merge      bool    // order all hits by score instead of primary-first
+}
+
+// fallbackBody is embedded in JSON request bodies that support fallback.
+type fallbackBody struct {
+	MinResults *int    `json:"minResults,omitempty"`
+	MinScore   float64 `json:"minScore,omitempty"`
+	Merge      bool    `json:"merge,omitempty"`
+}
+
+func (b fallbackBody) options() fallbackOptions {
+	opts := fallbackOptions{
+		minResults: defaultFallbackMinResults,
+		minScore:   b.MinScore,
+		merge:      b.Merge,
+	}
+	if b.MinResults != nil && *b.MinResults >= 0 {
+		opts.minResults = *b.MinResults
+	}
+	return opts
+}
+
+// parseFallbackQuery reads min_results, min_score and merge from GET
+// parameters. Malformed values fall back to defaults, like depth and limit.
+func parseFallbackQuery(q url.Values) fallbackOptions {
+	opts := fallbackOptions{minResults: defaultFallbackMinResults}
+	if v, err := strconv.Atoi(q.Get("min_results")); err == nil && v >= 0 {
+		opts.minResults = v
+	}
+	if v, err := strconv.ParseFloat(q.Get("min_score"), 64); err == nil {
+		opts.minScore = v
+	}
+	opts.merge = q.Get("merge") == "true"
+	return opts
+}
+
+// searchHit is a search result tagged with the index that produced it.
+type searchHit struct {
+	neuron    *core.Neuron
+	score     float64
+	breakdown *engine.ScoreBreakdown
+	source    core.IndexID
+	fallback  bool
+
+	session string          // session ID of a working-memory neuron
+	links   []core.NeuronID // persistent neurons a session neuron links to
+}
+
+func tagHits(neurons []*core.Neuron, stats engine.SearchStats, source core.IndexID, fallback bool) []searchHit {
+	hits := make([]searchHit, len(neurons))
+	for i, n := range neurons {
+		hits[i] = searchHit{neuron: n, source: source, fallback: fallback}
+		if i < len(stats.Scores) {
+			hits[i].score = stats.Scores[i]
+		}
+		if i < len(stats.Breakdowns) {
+			hits[i].breakdown = &stats.Breakdowns[i]
+		}
+	}
+	return hits
+}
+
+func countQualifying(hits []searchHit, minScore float64) int {
+	n := 0
+	for _, h := range hits {
+		if h.score >= minScore {
+			n++
+		}
+	}
+	return n
+}
+
+// searchWithFallback searches the primary index and, if it yields fewer than
+// opts.minResults qualifying hits, walks the fallback chain configured in the
+// registry breadth-first until enough hits are collected. Fallback indexes
+// are server-configured trust: the caller was authorized for the primary
+// index by getWorker, and the registry guard is not applied to fallbacks.
+// The returned slice lists every fallback index that was actually searched,
+// and mode is the searchMode* value for the searches taken together.
+func (s *Server) searchWithFallback(ctx context.Context, worker *concurrency.BrainWorker, indexID core.IndexID, kind string, req concurrency.SearchRequest, opts fallbackOptions) (hits []searchHit, consulted []string, mode string, err error) {
+	neurons, stats, err := s.runSearch(ctx, worker, indexID, kind, req)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	hits = tagHits(neurons, stats, indexID, false)
+	mode = searchModeOf(stats)
+
+	qualifying := countQualifying(hits, opts.minScore)
+	if qualifying >= opts.minResults {
+		return hits, nil, mode, nil
+	}
+
+	// A focus and reinforcement apply to the requests' own index only.
+	fallbackReq := req
+	fallbackReq.IgnoreFocus = true
+	fallbackReq.Reinforce = false
+	fallbackReq.Coalesced = nil
+	visited := map[string]bool{string(indexID): true}
+	queue := s.registry.Fallbacks(string(indexID))
+	for len(queue) > 0 && qualifying < opts.minResults {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		fallbackID := core.IndexID(id)
+		fw, err := s.pool.GetOrCreate(fallbackID)
+		if err != nil {
+			log.Printf("⚠ fallback index %s unavailable for %s: %v", id, indexID, err)
+			continue
+		}
+		s.lifecycle.RecordActivity(fallbackID)
+
+		fNeurons, fStats, err := s.runSearch(ctx, fw, fallbackID, kind, fallbackReq)
+		if err != nil {
+			log.Printf("⚠ fallback search on %s for %s failed: %v", id, indexID, err)
+			continue
+		}
+		consulted = append(consulted, id)
+		mode = combineSearchModes(mode, searchModeOf(fStats))
+
+		fHits := tagHits(fNeurons, fStats, fallbackID, true)
+		qualifying += countQualifying(fHits, opts.minScore)
+		hits = append(hits, fHits...)
+		queue = append(queue, s.registry.Fallbacks(id)...)
+	}
+
+	if opts.merge {
+		sort.SliceStable(hits, func(i, j int) bool {
+			return hits[i].score > hits[j].score
+		})
+	}
+	if req.Limit > 0 && len(hits) > req.Limit {
+		hits = hits[:req.Limit]
+	}
+	return hits, consulted, mode, nil
+}
+
+// Values of searchModeHeader.
+const (
+	searchModeHybrid          = "hybrid"           // the query embedding was scored with the lexical match
+	searchModeLexical         = "lexical"          // no vector layer for the index
+	searchModeLexicalFallback = "lexical_fallback" // the query could not be embedded
+)
+
+func searchModeOf(stats engine.SearchStats) string {
+	switch {
+	case stats.VectorFallback:
+		return searchModeLexicalFallback
+	case stats.VectorQuery:
+		return searchModeHybrid
+	default:
+		return searchModeLexical
+	}
+}
+
+// combineSearchModes reports a fallback if either search fell back, and
+// hybrid if either used vectors.
+func combineSearchModes(a, b string) string {
+	if a == searchModeLexicalFallback || b == searchModeLexicalFallback {
+		return searchModeLexicalFallback
+	}
+	if a == searchModeHybrid || b == searchModeHybrid {
+		return searchModeHybrid
+	}
+	return searchModeLexical
+}
+
+// isFallbackConfigError reports whether a registry write was rejected
+// because of its fallbackIndexes metadata.
+func isFallbackConfigError(err error) bool {
+	return errors.Is(err, registry.ErrInvalidFallback) || errors.Is(err, registry.ErrFallbackCycle)
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// newFallbackTestServer returns a server where "user-1" falls back to
+// "global-faq", and the FAQ index already holds an answer.
+func newFallbackTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+	})
+
+	if _, err := s.registry.Create("user-1", map[string]any{
+		registry.FallbackIndexesKey: []any{"global-faq"},
+	}); err != nil {
+		t.Fatalf("registry.Create: %v", err)
+	}
+	writeNeuron(t, s, "global-faq", "how to reset password from the account settings page")
+	return s
+}
+
+func writeNeuron(t *testing.T, s *Server, indexID, content string) {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, map[string]string{
+		"X-Index-ID": indexID,
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write to %s failed: %d %s", indexID, rr.Code, rr.Body.String())
+	}
+}
+
+func searchResults(t *testing.T, s *Server, indexID, query string) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "GET", "/v1/search?q="+query, "", map[string]string{
+		"X-Index-ID": indexID,
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("search failed: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func TestFallback_EmptyIndexFallsBack(t *testing.T) {
+	s := newFallbackTestServer(t)
+
+	m := searchResults(t, s, "user-1", "password")
+	results := m["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 fallback result, got %d: %v", len(results), results)
+	}
+	doc := results[0].(map[string]any)
+	if doc["sourceIndex"] != "global-faq" || doc["fallback"] != true {
+		t.Errorf("expected fallback result tagged with global-faq, got %v", doc)
+	}
+	consulted := m["fallbackIndexes"].([]any)
+	if len(consulted) != 1 || consulted[0] != "global-faq" {
+		t.Errorf("expected fallbackIndexes=[global-faq], got %v", consulted)
+	}
+}
+
+func TestFallback_PopulatedIndexDoesNotFallBack(t *testing.T) {
+	s := newFallbackTestServer(t)
+	writeNeuron(t, s, "user-1", "my password hint is the name of my cat")
+
+	m := searchResults(t, s, "user-1", "password")
+	results := m["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("expected only the primary result, got %d: %v", len(results), results)
+	}
+	if _, tagged := results[0].(map[string]any)["sourceIndex"]; tagged {
+		t.Errorf("primary result should not carry a sourceIndex tag: %v", results[0])
+	}
+	if _, ok := m["fallbackIndexes"]; ok {
+		t.Errorf("fallback should not be consulted, got %v", m["fallbackIndexes"])
+	}
+}
+
+func TestFallback_PrimaryHitsOrderedFirstUnlessMerge(t *testing.T) {
+	s := newFallbackTestServer(t)
+	// A weak primary hit: matches the query only as one word among many.
+	writeNeuron(t, s, "user-1", "notes about travel plans food music and one password")
+
+	// min_results=2 forces fallback even though the primary has a hit.
+	m := searchResults(t, s, "user-1", "password&min_results=2")
+	results := m["results"].([]any)
+	if len(results) != 2 {
+		t.Fatalf("expected primary + fallback results, got %d", len(results))
+	}
+	if _, tagged := results[0].(map[string]any)["sourceIndex"]; tagged {
+		t.Errorf("primary hit must come first without merge, got %v", results[0])
+	}
+	if results[1].(map[string]any)["sourceIndex"] != "global-faq" {
+		t.Errorf("fallback hit must come second, got %v", results[1])
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/search", `{"query":"password","minResults":2,"merge":true}`, map[string]string{
+		"X-Index-ID": "user-1",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("search failed: %d %s", rr.Code, rr.Body.String())
+	}
+	merged := decodeJSON(t, rr)["results"].([]any)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 merged results, got %d", len(merged))
+	}
+	worker, err := s.getWorker("user-1")
+	if err != nil {
+		t.Fatalf("getWorker: %v", err)
+	}
+	hits, _, _, err := s.searchWithFallback(context.Background(), worker, "user-1", searchKindSearch,
+		concurrency.SearchRequest{Query: "password", Depth: 1, Limit: 10},
+		fallbackOptions{minResults: 2, merge: true})
+	if err != nil {
+		t.Fatalf("searchWithFallback: %v", err)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i-1].score < hits[i].score {
+			t.Errorf("merged hits not ordered by score: %v then %v", hits[i-1].score, hits[i].score)
+		}
+	}
+}
+
+func TestFallback_MinScoreTriggersFallback(t *testing.T) {
+	s := newFallbackTestServer(t)
+	writeNeuron(t, s, "user-1", "password")
+
+	m := searchResults(t, s, "user-1", "password&min_score=1000")
+	if _, ok := m["fallbackIndexes"]; !ok {
+		t.Error("primary hits below min_score should not suppress fallback")
+	}
+}
+
+func TestFallback_ContextTagsSource(t *testing.T) {
+	s := newFallbackTestServer(t)
+
+	rr := doRequest(t, s, "POST", "/v1/context", `{"cue":"password"}`, map[string]string{
+		"X-Index-ID": "user-1",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("context failed: %d %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	if !strings.Contains(m["context"].(string), "[source:global-faq]") {
+		t.Errorf("expected fallback content tagged with its source, got %q", m["context"])
+	}
+}
+
+func TestFallback_RegistryGuardAppliesToPrimaryOnly(t *testing.T) {
+	s := newFallbackTestServer(t)
+	s.config.Registry.Enabled = true
+
+	m := searchResults(t, s, "user-1", "password")
+	if len(m["results"].([]any)) != 1 {
+		t.Errorf("unregistered fallback index should still be searched, got %v", m["results"])
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/search?q=password", "", map[string]string{
+		"X-Index-ID": "global-faq",
+	})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("direct access to unregistered fallback index should be rejected, got %d", rr.Code)
+	}
+}
+
+func TestFallback_CycleRejected(t *testing.T) {
+	s := newFallbackTestServer(t)
+
+	rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"global-faq","metadata":{"fallbackIndexes":["user-1"]}}`, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for fallback cycle, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if m := decodeJSON(t, rr); m["code"] != "INVALID_FALLBACK" {
+		t.Errorf("expected INVALID_FALLBACK, got %v", m["code"])
+	}
+
+	rr = doRequest(t, s, "PUT", "/v1/registry/user-1", `{"metadata":{"fallbackIndexes":["user-1"]}}`, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for self-referencing fallback, got %d", rr.Code)
+	}
+
+	if _, err := s.registry.Create("a", map[string]any{registry.FallbackIndexesKey: []string{"b"}}); err != nil {
+		t.Fatalf("create a: %v", err)
+	}
+	if _, err := s.registry.Create("b", map[string]any{registry.FallbackIndexesKey: []string{"c"}}); err != nil {
+		t.Fatalf("create b: %v", err)
+	}
+	_, err := s.registry.Create("c", map[string]any{registry.FallbackIndexesKey: []string{"a"}})
+	if !errors.Is(err, registry.ErrFallbackCycle) {
+		t.Errorf("expected ErrFallbackCycle for a→b→c→a, got %v", err)
+	}
+
+	rr = doRequest(t, s, "POST", "/v1/registry", `{"uuid":"bad","metadata":{"fallbackIndexes":"global-faq"}}`, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-list fallbackIndexes, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+)
+
+// Focus limits. The default boost matches the +30% per key of a request's
+// metadata boost.
+const (
+	defaultFocusBoost = 0.3
+	maxFocusBoost     = 5.0
+	maxFocusTTL       = 24 * time.Hour
+)
+
+// focusRequest sets the focus of an index (POST /v1/focus).
+type focusRequest struct {
+	Metadata map[string]string `json:"metadata"`
+	Boost    *float64          `json:"boost,omitempty"` // per matching key; defaultFocusBoost if omitted
+	TTL      string            `json:"ttl"`             // Go duration, at most maxFocusTTL
+}
+
+// focus validates req and returns the focus it sets at now.
+func (req focusRequest) focus(now time.Time) (concurrency.Focus, error) {
+	if len(req.Metadata) == 0 {
+		return concurrency.Focus{}, fmt.Errorf("metadata must name at least one key")
+	}
+	boost := defaultFocusBoost
+	if req.Boost != nil {
+		boost = *req.Boost
+	}
+	if boost <= 0 || boost > maxFocusBoost {
+		return concurrency.Focus{}, fmt.Errorf("boost must be above 0 and at most %g", maxFocusBoost)
+	}
+	ttl, err := time.ParseDuration(req.TTL)
+	if err != nil || ttl <= 0 {
+		return concurrency.Focus{}, fmt.Errorf("ttl must be a positive duration")
+	}
+	if ttl > maxFocusTTL {
+		return concurrency.Focus{}, fmt.Errorf("ttl must be at most %s", maxFocusTTL)
+	}
+	return concurrency.Focus{Metadata: req.Metadata, Boost: boost, SetAt: now, ExpiresAt: now.Add(ttl)}, nil
+}
+
+// focusDocument is the response describing the focus of an index: f while
+// active, null otherwise.
+func focusDocument(f concurrency.Focus, active bool) map[string]any {
+	if !active {
+		return map[string]any{"active": false, "focus": nil}
+	}
+	return map[string]any{"active": true, "focus": f}
+}
+
+// handleFocus sets (POST), shows (GET) and clears (DELETE) the focus of an
+// index: a metadata boost every search, context and recall of the index
+// applies until its TTL runs out, unless the request sets ignore_focus. A
+// new focus replaces the previous one. It is kept in memory only, so
+// evicting the index or its going dormant clears it.
+func (s *Server) handleFocus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" && r.Method != "DELETE" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		var req focusRequest
+		if !s.decodeJSONRequest(w, r, &req) {
+			return
+		}
+		f, err := req.focus(time.Now())
+		if err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+			return
+		}
+		worker.SetFocus(f)
+		json.NewEncoder(w).Encode(focusDocument(f, true))
+
+	case "GET":
+		json.NewEncoder(w).Encode(focusDocument(worker.Focus(time.Now())))
+
+	case "DELETE":
+		json.NewEncoder(w).Encode(map[string]any{"deleted": worker.ClearFocus()})
+	}
+}
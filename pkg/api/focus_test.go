@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// focusFixture writes a neuron of each project to indexID, both matching
+// "rocket launch" alike; gemini's has more energy, so it ranks first.
+func focusFixture(t *testing.T, s *Server, indexID string) (apollo, gemini core.NeuronID) {
+	t.Helper()
+	headers := map[string]string{"X-Index-ID": indexID}
+	write := func(body string) core.NeuronID {
+		t.Helper()
+		rr := doRequest(t, s, "POST", "/v1/write", body, headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+		return core.NeuronID(decodeJSON(t, rr)["id"].(string))
+	}
+	apollo = write(`{"content":"rocket launch notes","metadata":{"project":"apollo"}}`)
+	gemini = write(`{"content":"notes: rocket launch","metadata":{"project":"gemini"}}`)
+	resetFocusEnergy(t, s, indexID, apollo, gemini)
+	return apollo, gemini
+}
+
+// resetFocusEnergy restores the fixture's energies, which searches raise by
+// firing their results.
+func resetFocusEnergy(t *testing.T, s *Server, indexID string, apollo, gemini core.NeuronID) {
+	t.Helper()
+	worker, err := s.pool.GetOrCreate(core.IndexID(indexID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := worker.Matrix()
+	m.Lock()
+	m.Neurons[apollo].Energy = 0.5
+	m.Neurons[gemini].Energy = 0.6
+	m.Unlock()
+}
+
+// firstID returns the ID of the first item listed under key.
+func firstID(t *testing.T, doc map[string]any, key string) core.NeuronID {
+	t.Helper()
+	items, _ := doc[key].([]any)
+	if len(items) == 0 {
+		t.Fatalf("no %s in %v", key, doc)
+	}
+	return core.NeuronID(items[0].(map[string]any)["id"].(string))
+}
+
+func TestFocus_BoostsSearchContextAndRecall(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "agent"}
+	apollo, gemini := focusFixture(t, s, "agent")
+
+	search := func(query string) map[string]any {
+		t.Helper()
+		resetFocusEnergy(t, s, "agent", apollo, gemini)
+		rr := doRequest(t, s, "GET", "/v1/search?q=rocket+launch&explain=true"+query, "", headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("search: %d %s", rr.Code, rr.Body.String())
+		}
+		return decodeJSON(t, rr)
+	}
+	recall := func(query string) map[string]any {
+		t.Helper()
+		resetFocusEnergy(t, s, "agent", apollo, gemini)
+		rr := doRequest(t, s, "GET", "/v1/recall"+query, "", headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("recall: %d %s", rr.Code, rr.Body.String())
+		}
+		return decodeJSON(t, rr)
+	}
+
+	doc := search("")
+	if id := firstID(t, doc, "results"); id != gemini {
+		t.Fatalf("expected gemini first without a focus, got %s", id)
+	}
+	if _, ok := doc["focus"]; ok {
+		t.Error("no focus is reported without one")
+	}
+	if id := firstID(t, recall(""), "memories"); id != gemini {
+		t.Fatalf("expected the more energetic gemini first in recall, got %s", id)
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/focus", `{"metadata":{"project":"apollo"},"boost":3,"ttl":"2h"}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set focus: %d %s", rr.Code, rr.Body.String())
+	}
+	got := decodeJSON(t, doRequest(t, s, "GET", "/v1/focus", "", headers))
+	focus, _ := got["focus"].(map[string]any)
+	if got["active"] != true || focus["boost"].(float64) != 3 || focus["metadata"].(map[string]any)["project"] != "apollo" {
+		t.Fatalf("expected the focus to be shown, got %v", got)
+	}
+
+	doc = search("")
+	if id := firstID(t, doc, "results"); id != apollo {
+		t.Fatalf("expected the focus to lift apollo first, got %s", id)
+	}
+	if _, ok := doc["focus"]; !ok {
+		t.Error("explain should report the active focus")
+	}
+	explain := doc["results"].([]any)[0].(map[string]any)["explain"].(map[string]any)
+	if explain["focus"].(float64) != 4 {
+		t.Errorf("expected a focus multiplier of 4 in explain, got %v", explain["focus"])
+	}
+	if id := firstID(t, recall(""), "memories"); id != apollo {
+		t.Errorf("expected the focus to lift apollo first in recall, got %s", id)
+	}
+	rr = doRequest(t, s, "POST", "/v1/context", `{"cue":"rocket launch","depth":1,"format":"messages"}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("context: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Opting out ranks as before.
+	if id := firstID(t, search("&ignore_focus=true"), "results"); id != gemini {
+		t.Errorf("ignore_focus should rank gemini first, got %s", id)
+	}
+	resetFocusEnergy(t, s, "agent", apollo, gemini)
+	rr = doRequest(t, s, "POST", "/v1/search", `{"query":"rocket launch","ignore_focus":true}`, headers)
+	if id := firstID(t, decodeJSON(t, rr), "results"); id != gemini {
+		t.Errorf("ignore_focus in a body should rank gemini first, got %s", id)
+	}
+	if id := firstID(t, recall("?ignore_focus=true"), "memories"); id != gemini {
+		t.Errorf("ignore_focus should leave recall by energy, got %s", id)
+	}
+
+	// A new focus replaces the old one; DELETE clears it.
+	doRequest(t, s, "POST", "/v1/focus", `{"metadata":{"project":"gemini"},"ttl":"1h"}`, headers)
+	got = decodeJSON(t, doRequest(t, s, "GET", "/v1/focus", "", headers))
+	if got["focus"].(map[string]any)["metadata"].(map[string]any)["project"] != "gemini" || got["focus"].(map[string]any)["boost"].(float64) != defaultFocusBoost {
+		t.Errorf("expected the gemini focus with the default boost, got %v", got)
+	}
+	if doc := decodeJSON(t, doRequest(t, s, "DELETE", "/v1/focus", "", headers)); doc["deleted"] != true {
+		t.Errorf("expected the focus deleted, got %v", doc)
+	}
+	if got := decodeJSON(t, doRequest(t, s, "GET", "/v1/focus", "", headers)); got["active"] != false || got["focus"] != nil {
+		t.Errorf("expected no focus after DELETE, got %v", got)
+	}
+}
+
+func TestFocus_Expires(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "agent"}
+	_, gemini := focusFixture(t, s, "agent")
+
+	rr := doRequest(t, s, "POST", "/v1/focus", `{"metadata":{"project":"apollo"},"boost":3,"ttl":"50ms"}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set focus: %d %s", rr.Code, rr.Body.String())
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	if got := decodeJSON(t, doRequest(t, s, "GET", "/v1/focus", "", headers)); got["active"] != false {
+		t.Errorf("expected the focus to have expired, got %v", got)
+	}
+	doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/search?q=rocket+launch&explain=true", "", headers))
+	if id := firstID(t, doc, "results"); id != gemini {
+		t.Errorf("an expired focus must not boost, got %s first", id)
+	}
+	if _, ok := doc["focus"]; ok {
+		t.Error("an expired focus is not reported")
+	}
+}
+
+func TestFocus_StaysInItsIndex(t *testing.T) {
+	s := newTestServer(t, nil)
+	focusFixture(t, s, "a")
+	_, gemini := focusFixture(t, s, "b")
+
+	rr := doRequest(t, s, "POST", "/v1/focus", `{"metadata":{"project":"apollo"},"boost":3,"ttl":"1h"}`, map[string]string{"X-Index-ID": "a"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set focus: %d %s", rr.Code, rr.Body.String())
+	}
+
+	other := map[string]string{"X-Index-ID": "b"}
+	if got := decodeJSON(t, doRequest(t, s, "GET", "/v1/focus", "", other)); got["active"] != false {
+		t.Errorf("index b has no focus, got %v", got)
+	}
+	doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/search?q=rocket+launch&explain=true", "", other))
+	if id := firstID(t, doc, "results"); id != gemini {
+		t.Errorf("index a's focus must not rank index b, got %s first", id)
+	}
+
+	// Evicting the index drops its focus with the worker.
+	if err := s.pool.Evict("a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeJSON(t, doRequest(t, s, "GET", "/v1/focus", "", map[string]string{"X-Index-ID": "a"})); got["active"] != false {
+		t.Errorf("eviction should clear the focus, got %v", got)
+	}
+}
+
+func TestFocus_Validation(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "agent"}
+	for _, body := range []string{
+		`{"boost":0.3,"ttl":"1h"}`,
+		`{"metadata":{"project":"apollo"},"boost":0,"ttl":"1h"}`,
+		`{"metadata":{"project":"apollo"},"boost":6,"ttl":"1h"}`,
+		`{"metadata":{"project":"apollo"}}`,
+		`{"metadata":{"project":"apollo"},"ttl":"-1h"}`,
+		`{"metadata":{"project":"apollo"},"ttl":"48h"}`,
+	} {
+		if rr := doRequest(t, s, "POST", "/v1/focus", body, headers); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestMCPBackend_Focus(t *testing.T) {
+	b := newTestMCPBackend(t)
+	ctx := context.Background()
+
+	if _, err := b.SetFocus(ctx, "agent", map[string]string{"project": "apollo"}, nil, "forever"); err == nil {
+		t.Error("expected a malformed ttl to be refused")
+	}
+	result, err := b.SetFocus(ctx, "agent", map[string]string{"project": "apollo"}, nil, "2h")
+	if err != nil {
+		t.Fatalf("SetFocus: %v", err)
+	}
+	if result["active"] != true {
+		t.Errorf("expected an active focus, got %v", result)
+	}
+	result, err = b.ClearFocus(ctx, "agent")
+	if err != nil || result["deleted"] != true {
+		t.Errorf("expected the focus cleared, got %v, %v", result, err)
+	}
+}
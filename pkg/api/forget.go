@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// handleForgetByMetadata removes every memory of the index whose metadata
+// holds all the given pairs (POST /v1/forget), e.g. every turn of a
+// conversation by thread_id. The filter has the shape of a search's
+// metadata with strict set; a non-strict one is refused, since matching
+// any pair could erase far more than intended. Neurons go for good, with
+// their synapses and recycled matches, bypassing the recycle bin, and the
+// index is written to the WAL before the response so they do not come
+// back after a restart.
+func (s *Server) handleForgetByMetadata(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	indexID := s.getIndexID(r)
+	worker, err := s.getWorker(indexID)
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	var req struct {
+		Metadata map[string]string `json:"metadata"`
+		Strict   *bool             `json:"strict,omitempty"`
+	}
+	if !s.decodeJSONRequest(w, r, &req) {
+		return
+	}
+	if len(req.Metadata) == 0 {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "metadata must name at least one key to match")
+		return
+	}
+	if req.Strict != nil && !*req.Strict {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "forget filters are strict: a memory must match every metadata pair")
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type:    concurrency.OpForgetByMetadata,
+		Payload: concurrency.ForgetByMetadataRequest{Metadata: req.Metadata},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+	report := result.(engine.ForgetReport)
+	if report.Count() > 0 {
+		if err := s.pool.PersistAsync(indexID, worker); err != nil {
+			s.writePersistError(w, err)
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId":  indexID,
+		"deleted":  report.Count(),
+		"live":     len(report.Deleted),
+		"recycled": len(report.Purged),
+	})
+}
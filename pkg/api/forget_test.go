@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestForgetByMetadata(t *testing.T) {
+	dataPath := t.TempDir()
+	start := func() *Server {
+		return newTestServer(t, func(cfg *core.Config) { cfg.Storage.DataPath = dataPath })
+	}
+	s := start()
+	headers := map[string]string{"X-Index-ID": "app"}
+	for _, body := range []string{
+		`{"content":"what is my blood type?","metadata":{"thread_id":"conv-1","role":"user"}}`,
+		`{"content":"your records say O negative","metadata":{"thread_id":"conv-1","role":"assistant"}}`,
+		`{"content":"book the dentist","metadata":{"thread_id":"conv-2","role":"user"}}`,
+	} {
+		if rr := doRequest(t, s, "POST", "/v1/write", body, headers); rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+	if _, err := s.pool.PersistIndex("app"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{`{}`, `{"metadata":{}}`, `{"metadata":{"thread_id":"conv-1"},"strict":false}`} {
+		if rr := doRequest(t, s, "POST", "/v1/forget", body, headers); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+	if rr := doRequest(t, s, "DELETE", "/v1/forget/anything", "", headers); decodeJSON(t, rr)["code"] != "MUTATION_DISABLED" {
+		t.Errorf("expected forgetting one neuron to stay disabled, got %d", rr.Code)
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/forget", `{"metadata":{"thread_id":"conv-1"},"strict":true}`, headers)
+	if rr.Code != http.StatusOK || decodeJSON(t, rr)["deleted"] != float64(2) {
+		t.Fatalf("expected both turns of conv-1 forgotten, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// A restart without a flush replays the WAL: the conversation stays gone.
+	s = start()
+	worker, err := s.pool.GetOrCreate("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := neuronCount(worker); n != 1 {
+		t.Errorf("expected 1 neuron after the restart, got %d", n)
+	}
+	rr = doRequest(t, s, "POST", "/v1/forget", `{"metadata":{"thread_id":"conv-1"}}`, headers)
+	if rr.Code != http.StatusOK || decodeJSON(t, rr)["deleted"] != float64(0) {
+		t.Errorf("expected nothing left to forget, got %d %s", rr.Code, rr.Body.String())
+	}
+}
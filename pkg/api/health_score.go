@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// refreshHealth reports whether the request asks for the health score to
+// be recomputed rather than served from the cache (refresh_health=true).
+func refreshHealth(r *http.Request) bool {
+	refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh_health"))
+	return refresh
+}
+
+// indexHealth returns the health score of worker's index under
+// health.*: the cached one unless refresh is set or the formula changed.
+// It is nil for an empty index.
+func (s *Server) indexHealth(ctx context.Context, worker *concurrency.BrainWorker, refresh bool, priority concurrency.Priority) (*engine.Health, error) {
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
+		Type:     concurrency.OpHealth,
+		Priority: priority,
+		Payload:  concurrency.HealthRequest{Config: s.config.Health, Refresh: refresh},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*engine.Health), nil
+}
+
+// healthWithoutCounts returns h without the neuron count and the raw
+// signals, which would give away the exact counts stats.noise hides.
+func healthWithoutCounts(h *engine.Health) map[string]any {
+	components := make([]map[string]any, len(h.Components))
+	for i, c := range h.Components {
+		components[i] = map[string]any{"name": c.Name, "value": c.Value, "weight": c.Weight, "points": c.Points}
+	}
+	return map[string]any{
+		"score":          h.Score,
+		"formulaVersion": h.FormulaVersion,
+		"computedAt":     h.ComputedAt,
+		"components":     components,
+	}
+}
+
+// StartHealth registers the daemon recomputing the health score of every
+// loaded index each health.interval. It needs the daemon manager, so call
+// it after SetDaemonManager.
+func (s *Server) StartHealth() {
+	interval := s.config.Health.Interval
+	if s.daemons == nil || interval <= 0 {
+		return
+	}
+	s.daemons.Add("health", func() time.Duration { return interval }, s.healthPass)
+	log.Printf("Health daemon started (interval=%s, formula=%s)", interval, s.config.Health.FormulaVersion())
+}
+
+// healthPass recomputes the health score of every loaded index at
+// background priority. Unloaded indexes keep no score and are not loaded
+// for one.
+func (s *Server) healthPass() (int, error) {
+	scored := 0
+	var errs []error
+	s.pool.ForEach(func(id core.IndexID, worker *concurrency.BrainWorker) {
+		if _, err := s.indexHealth(context.Background(), worker, true, concurrency.PriorityBackground); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			return
+		}
+		scored++
+	})
+	return scored, errors.Join(errs...)
+}
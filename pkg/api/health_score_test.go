@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestHealthScore(t *testing.T) {
+	s := newTestServer(t, nil)
+	alpha := map[string]string{"X-Index-ID": "alpha"}
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	for _, content := range []string{"The release train leaves every Tuesday", "Hotfixes skip the release train"} {
+		if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, alpha); rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	health := func(path string, headers map[string]string) map[string]any {
+		t.Helper()
+		body := decodeJSON(t, doRequest(t, s, "GET", path, "", headers))
+		if index, ok := body["index"].(map[string]any); ok {
+			body = index
+		}
+		h, _ := body["health"].(map[string]any)
+		return h
+	}
+	h := health("/v1/stats", alpha)
+	if h == nil || h["formulaVersion"] != s.config.Health.FormulaVersion() || len(h["components"].([]any)) == 0 {
+		t.Fatalf("expected a health score with its formula and components, got %v", h)
+	}
+	if score := h["score"].(float64); score <= 0 || score > 100 {
+		t.Errorf("expected a score in (0, 100], got %v", score)
+	}
+
+	// The score is cached until it is refreshed.
+	doRequest(t, s, "POST", "/v1/write", `{"content":"Release notes go out on Wednesday"}`, alpha)
+	if h := health("/v1/stats", alpha); h["neurons"] != float64(2) {
+		t.Errorf("expected the cached score, got %v", h)
+	}
+	if h := health("/v1/stats?refresh_health=true", alpha); h["neurons"] != float64(3) {
+		t.Errorf("expected refresh_health to recompute, got %v", h)
+	}
+	if h := health("/admin/indexes/alpha", admin); h["neurons"] != float64(3) {
+		t.Errorf("expected the index detail to report the score, got %v", h)
+	}
+
+	// An empty index has no score and sorts last.
+	if h := health("/v1/stats", map[string]string{"X-Index-ID": "empty"}); h != nil {
+		t.Errorf("expected no score for an empty index, got %v", h)
+	}
+	rr := doRequest(t, s, "GET", "/admin/indexes?sort=health", "", admin)
+	var listing []indexListEntry
+	if err := json.Unmarshal(rr.Body.Bytes(), &listing); err != nil || len(listing) != 2 {
+		t.Fatalf("unexpected listing %s: %v", rr.Body.String(), err)
+	}
+	if listing[0].IndexID != "alpha" || listing[0].Health == nil || listing[0].HealthFormula == "" || listing[1].Health != nil {
+		t.Errorf("expected alpha first with its score, got %+v", listing)
+	}
+
+	if scored, err := s.healthPass(); err != nil || scored != 2 {
+		t.Errorf("expected the health pass to score both loaded indexes, got %d, %v", scored, err)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// handleHistory returns the supersede chain through a neuron, oldest first
+// (GET /v1/history/{id}). Each entry carries when it was superseded and
+// whether it is the current version; latest_only=true returns only the
+// current one.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/v1/history/")
+	if id == "" {
+		apierr.NeuronIDRequired(w)
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type:    concurrency.OpHistory,
+		Payload: core.NeuronID(id),
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	history := result.(concurrency.HistoryResult)
+	head := history.Chain[len(history.Chain)-1]
+	chain := history.Chain
+	if r.URL.Query().Get("latest_only") == "true" {
+		chain = chain[len(chain)-1:]
+	}
+
+	// A truncated walk may stop short of the current neuron.
+	current := ""
+	if !engine.IsSuperseded(head) {
+		current = string(head.ID)
+	}
+
+	items := make([]map[string]any, len(chain))
+	for i, n := range chain {
+		doc := s.neuronDocument(r.Context(), n)
+		doc["current"] = string(n.ID) == current
+		if at, ok := n.Metadata[engine.SupersededAtKey]; ok {
+			doc["supersededAt"] = at
+		}
+		items[i] = doc
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"chain":     items,
+		"count":     len(items),
+		"length":    len(history.Chain),
+		"current":   current,
+		"truncated": history.Truncated,
+	})
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// writeChain writes contents to the notes index, each superseding the
+// previous, and returns their IDs.
+func writeChain(t *testing.T, s *Server, contents ...string) []string {
+	t.Helper()
+	var ids []string
+	for _, content := range contents {
+		body := fmt.Sprintf(`{"content":%q}`, content)
+		if len(ids) > 0 {
+			body = fmt.Sprintf(`{"content":%q,"supersedes":%q}`, content, ids[len(ids)-1])
+		}
+		doc := pinRequest(t, s, "POST", "/v1/write", body, http.StatusOK)
+		ids = append(ids, doc["id"].(string))
+	}
+	return ids
+}
+
+func TestHistory_FiveStepChain(t *testing.T) {
+	s := newTestServer(t, nil)
+	ids := writeChain(t, s,
+		"The office is on floor 2",
+		"The office is on floor 3",
+		"The office is on floor 5",
+		"The office is on floor 7",
+		"The office is on floor 9")
+
+	history := pinRequest(t, s, "GET", "/v1/history/"+ids[2], "", http.StatusOK)
+	if history["count"] != float64(5) || history["current"] != ids[4] || history["truncated"] != false {
+		t.Fatalf("unexpected history: %v", history)
+	}
+	for i, item := range history["chain"].([]any) {
+		doc := item.(map[string]any)
+		if doc["id"] != ids[i] {
+			t.Errorf("position %d: expected %s, got %v", i, ids[i], doc["id"])
+		}
+		if current := i == 4; doc["current"] != current {
+			t.Errorf("position %d: expected current=%v, got %v", i, current, doc["current"])
+		}
+		if _, ok := doc["supersededAt"]; ok == (i == 4) {
+			t.Errorf("position %d: supersededAt should be set on every replaced version only: %v", i, doc)
+		}
+	}
+
+	latest := pinRequest(t, s, "GET", "/v1/history/"+ids[0]+"?latest_only=true", "", http.StatusOK)
+	chain := latest["chain"].([]any)
+	if len(chain) != 1 || chain[0].(map[string]any)["id"] != ids[4] || latest["length"] != float64(5) {
+		t.Errorf("latest_only should return the head of the 5-step chain, got %v", latest)
+	}
+
+	pinRequest(t, s, "GET", "/v1/history/no-such-neuron", "", http.StatusNotFound)
+}
+
+func TestSearch_ResolveSupersededReturnsHead(t *testing.T) {
+	s := newTestServer(t, nil)
+	writeChain(t, s,
+		"The standup meets at 9am in room Kepler",
+		"The standup meets at 10am in room Kepler",
+		"The standup moved to 11am")
+
+	plain := pinRequest(t, s, "POST", "/v1/search", `{"query":"standup 9am Kepler","depth":1}`, http.StatusOK)
+	if got := resultContents(plain); len(got) < 2 {
+		t.Fatalf("without resolution every version should match, got %v", got)
+	}
+
+	resolved := pinRequest(t, s, "POST", "/v1/search", `{"query":"standup 9am Kepler","depth":1,"resolve_superseded":true}`, http.StatusOK)
+	if got := resultContents(resolved); len(got) != 1 || got[0] != "The standup moved to 11am" {
+		t.Errorf("expected only the current version, got %v", got)
+	}
+	get := pinRequest(t, s, "GET", "/v1/search?q=standup+9am+Kepler&resolve_superseded=true", "", http.StatusOK)
+	if got := resultContents(get); len(got) != 1 {
+		t.Errorf("GET search should resolve too, got %v", got)
+	}
+}
+
+func TestWrite_SupersedeRejectsCyclesAndForks(t *testing.T) {
+	s := newTestServer(t, nil)
+	ids := writeChain(t, s, "Budget draft one", "Budget draft two")
+
+	// Rewriting draft one dedupes to the existing neuron, so linking it
+	// after draft two would close a loop.
+	m := pinRequest(t, s, "POST", "/v1/write", fmt.Sprintf(`{"content":"Budget draft one","supersedes":%q}`, ids[1]), http.StatusConflict)
+	if m["code"] != "SUPERSEDE_CYCLE" {
+		t.Errorf("expected SUPERSEDE_CYCLE, got %v", m)
+	}
+
+	m = pinRequest(t, s, "POST", "/v1/write", fmt.Sprintf(`{"content":"Budget draft two, alternative","supersedes":%q}`, ids[0]), http.StatusConflict)
+	if m["code"] != "SUPERSEDE_CONFLICT" {
+		t.Errorf("expected SUPERSEDE_CONFLICT, got %v", m)
+	}
+	recall := pinRequest(t, s, "GET", "/v1/recall", "", http.StatusOK)
+	if recall["count"] != float64(2) {
+		t.Errorf("a refused supersede should not leave a neuron behind, got %v", recall["count"])
+	}
+
+	pinRequest(t, s, "POST", "/v1/write", `{"content":"Budget final","supersedes":"no-such-neuron"}`, http.StatusNotFound)
+}
+
+func TestConsistency_ReportsBrokenChains(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	ids := writeChain(t, s, "Plan A", "Plan B")
+
+	if report := adminRequest(t, s, "GET", "/admin/consistency"); report["consistent"] != true {
+		t.Fatalf("a well-formed chain is consistent, got %v", report)
+	}
+
+	worker, err := s.pool.GetOrCreate("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := worker.Matrix()
+	m.Lock()
+	m.Neurons[core.NeuronID(ids[1])].Metadata[engine.SupersededByKey] = "missing"
+	m.Unlock()
+
+	report := adminRequest(t, s, "GET", "/admin/consistency")
+	broken, _ := report["brokenChains"].(map[string]any)
+	if report["consistent"] != false || len(broken["notes"].([]any)) != 1 {
+		t.Errorf("expected one broken link in notes, got %v", report)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/vault"
+)
+
+const importMarkdownPath = "/v1/import/markdown"
+
+// isImportPath reports whether path uploads data for import, and so is
+// bounded by import.maxUploadBytes rather than security.maxRequestBody.
+func isImportPath(path string) bool {
+	return path == importMarkdownPath ||
+		strings.HasPrefix(path, "/admin/indexes/") && strings.HasSuffix(path, "/import")
+}
+
+// handleImportMarkdown imports a zip of Markdown notes, such as an Obsidian
+// vault, into the index (POST /v1/import/markdown). Every file becomes a
+// neuron, or every heading section with ?split_headings=true, and links
+// between notes become synapses of ?link_weight, import.linkWeight by
+// default. Notes are recognised by path on re-import, so unchanged ones
+// are left alone and edited ones updated in place.
+func (s *Server) handleImportMarkdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	splitHeadings, _ := strconv.ParseBool(q.Get("split_headings"))
+	linkWeight := s.config.Import.LinkWeight
+	if raw := q.Get("link_weight"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 || v > 1 {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "link_weight must be a number in (0, 1]")
+			return
+		}
+		linkWeight = v
+	}
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			apierr.PayloadTooLarge(w, fmt.Sprintf("%v (import.maxUploadBytes=%d)", err, s.config.Import.MaxUploadBytes))
+			return
+		}
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeInvalidArchive, fmt.Sprintf("body is not a zip archive: %v", err))
+		return
+	}
+	v, err := vault.Read(archive, vault.Options{SplitHeadings: splitHeadings, MaxFiles: s.config.Import.MaxFiles})
+	if errors.Is(err, vault.ErrTooManyFiles) {
+		apierr.PayloadTooLarge(w, fmt.Sprintf("%v (import.maxFiles=%d)", err, s.config.Import.MaxFiles))
+		return
+	}
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeInvalidArchive, err.Error())
+		return
+	}
+
+	notes := make([]engine.ImportedNote, len(v.Notes))
+	for i, note := range v.Notes {
+		metadata := map[string]string{"source_path": note.Path, "title": note.Title}
+		if note.Section != "" {
+			metadata["section"] = note.Section
+		}
+		notes[i] = engine.ImportedNote{
+			Key:      note.Key(),
+			Hash:     note.Hash(),
+			Content:  note.Content,
+			Metadata: metadata,
+			Tags:     note.Tags,
+			Links:    note.Links,
+		}
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type:    concurrency.OpImportNotes,
+		Payload: concurrency.ImportNotesRequest{Notes: notes, LinkWeight: linkWeight},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	res := result.(engine.ImportResult)
+	unresolved := v.Unresolved
+	if unresolved == nil {
+		unresolved = []vault.UnresolvedLink{}
+	}
+	skipped := v.Skipped
+	if skipped == nil {
+		skipped = []vault.SkippedFile{}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId":    s.getIndexID(r),
+		"notes":      len(notes),
+		"created":    res.Created,
+		"updated":    res.Updated,
+		"unchanged":  res.Unchanged,
+		"linked":     res.Linked,
+		"unresolved": unresolved,
+		"skipped":    skipped,
+		"errors":     res.Errors,
+	})
+}
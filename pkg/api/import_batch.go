@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+const importBatchPath = "/v1/import"
+
+// importItem is one memory of a batch import.
+type importItem struct {
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	ParentID string            `json:"parent_id,omitempty"`
+}
+
+// importItemResult is the outcome of one item, by its position in the
+// batch: the ID of the neuron written, or the error it failed with and its
+// code as in an error response.
+type importItemResult struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// fail records err as the item's error.
+func (res *importItemResult) fail(code string, err error) {
+	res.Code, res.Error = code, err.Error()
+}
+
+// handleImportBatch writes a batch of memories in one worker operation
+// (POST /v1/import). The body is a JSON array of {content, metadata,
+// parent_id} objects or, as application/x-ndjson, one object per line; it
+// is bounded by security.maxRequestBody like any other request. Items are
+// validated and written independently: the response reports each one's
+// neuron ID or error, and a batch in which some items fail still succeeds.
+func (s *Server) handleImportBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	if limit := s.config.Security.MaxRequestBody; limit > 0 && r.ContentLength > limit {
+		apierr.PayloadTooLarge(w, fmt.Sprintf("batch of %d bytes exceeds security.maxRequestBody=%d; split it", r.ContentLength, limit))
+		return
+	}
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+	body, ok := s.readContentBody(w, r)
+	if !ok {
+		return
+	}
+	items, parseErrs, err := parseImportBatch(body, r.Header.Get("Content-Type"))
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeInvalidJSON, err.Error())
+		return
+	}
+	if len(items) == 0 {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "the batch holds no memories")
+		return
+	}
+
+	results := make([]importItemResult, len(items))
+	var batch []concurrency.AddNeuronRequest
+	var positions []int
+	for i, item := range items {
+		results[i].Index = i
+		if err, bad := parseErrs[i]; bad {
+			results[i].fail(apierr.CodeInvalidJSON, err)
+			continue
+		}
+		if err := core.ValidateNeuronContent(item.Content); err != nil {
+			results[i].fail(importErrorCode(err), err)
+			continue
+		}
+		req := concurrency.AddNeuronRequest{
+			Content:  item.Content,
+			Metadata: dropSystemMetadata(r.Context(), item.Metadata),
+		}
+		if item.ParentID != "" {
+			pid := core.NeuronID(item.ParentID)
+			req.ParentID = &pid
+		}
+		batch = append(batch, req)
+		positions = append(positions, i)
+	}
+
+	if len(batch) > 0 {
+		result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+			Type:    concurrency.OpWriteBatch,
+			Payload: concurrency.WriteBatchRequest{Items: batch},
+		})
+		if err != nil {
+			s.writeOperationError(w, err)
+			return
+		}
+		for j, res := range result.([]concurrency.WriteBatchResult) {
+			if res.Err != nil {
+				results[positions[j]].fail(importErrorCode(res.Err), res.Err)
+			} else {
+				results[positions[j]].ID = string(res.Neuron.ID)
+			}
+		}
+	}
+
+	created := 0
+	for _, res := range results {
+		if res.Error == "" {
+			created++
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId": s.getIndexID(r),
+		"total":   len(results),
+		"created": created,
+		"failed":  len(results) - created,
+		"results": results,
+	})
+}
+
+// parseImportBatch decodes a batch import body: newline-delimited JSON
+// when contentType says so, a JSON array otherwise. An NDJSON line that is
+// not an item is reported in lineErrs by its position rather than failing
+// the batch; blank lines are skipped.
+func parseImportBatch(body []byte, contentType string) (items []importItem, lineErrs map[int]error, err error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != contentTypeNDJSON && mediaType != "application/jsonl" {
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, nil, fmt.Errorf("body must be a JSON array of memories: %v", err)
+		}
+		return items, nil, nil
+	}
+	lineErrs = make(map[int]error)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var item importItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			lineErrs[len(items)] = fmt.Errorf("line %d: %v", line, err)
+		}
+		items = append(items, item)
+	}
+	return items, lineErrs, scanner.Err()
+}
+
+// importErrorCode is the code a single write failing with err would have
+// been answered with.
+func importErrorCode(err error) string {
+	switch {
+	case errors.Is(err, core.ErrInvalidContent):
+		return apierr.CodeInvalidContent
+	case errors.Is(err, core.ErrInvalidEncoding):
+		return apierr.CodeInvalidEncoding
+	case errors.Is(err, core.ErrContentTooLarge):
+		return apierr.CodePayloadTooLarge
+	case errors.Is(err, core.ErrNeuronNotFound):
+		return apierr.CodeNeuronNotFound
+	case errors.Is(err, core.ErrPinLimit):
+		return apierr.CodePinLimit
+	case errors.Is(err, core.ErrQuotaExceeded):
+		return apierr.CodeStorageQuotaExceeded
+	case errors.Is(err, core.ErrAppendOnly):
+		return apierr.CodeAppendOnly
+	}
+	return apierr.CodeInternalError
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestImportBatch(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "bulk"}
+	parent := decodeJSON(t, doRequest(t, s, "POST", "/v1/write", `{"content":"Project kickoff notes"}`, headers))["id"].(string)
+
+	body := fmt.Sprintf(`[
+		{"content":"The kickoff moved to Tuesday","parent_id":%q,"metadata":{"thread_id":"t1"}},
+		{"content":"   "},
+		{"content":"Budget approved by finance"},
+		{"content":"Design review is on Friday"}
+	]`, parent)
+	rr := doRequest(t, s, "POST", "/v1/import", body, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", rr.Code, rr.Body.String())
+	}
+	res := decodeJSON(t, rr)
+	if res["total"].(float64) != 4 || res["created"].(float64) != 3 || res["failed"].(float64) != 1 {
+		t.Fatalf("unexpected counts %v", res)
+	}
+	results := res["results"].([]any)
+	first := results[0].(map[string]any)
+	if first["id"] == nil || first["error"] != nil {
+		t.Fatalf("expected the first item written, got %v", first)
+	}
+	if c := results[1].(map[string]any)["code"]; c != "INVALID_CONTENT" {
+		t.Errorf("expected empty content refused with INVALID_CONTENT, got %v", results[1])
+	}
+	if results[2].(map[string]any)["id"] == nil {
+		t.Errorf("a failed item must not stop the next, got %v", results[2])
+	}
+
+	n := decodeJSON(t, doRequest(t, s, "GET", "/v1/read/"+first["id"].(string), "", headers))
+	if n["metadata"].(map[string]any)["thread_id"] != "t1" {
+		t.Errorf("expected the item's metadata stored, got %v", n["metadata"])
+	}
+	stats := decodeJSON(t, doRequest(t, s, "GET", "/v1/stats", "", headers))["index"].(map[string]any)
+	if stats["neuron_count"].(float64) != 4 {
+		t.Errorf("expected 4 neurons, got %v", stats["neuron_count"])
+	}
+
+	// NDJSON: a malformed line fails alone.
+	ndjson := "{\"content\":\"Retro notes from sprint 12\"}\n\nnot json\n{\"content\":\"Retro notes from sprint 13\"}\n"
+	rr = doRequest(t, s, "POST", "/v1/import", ndjson, map[string]string{"X-Index-ID": "bulk", "Content-Type": "application/x-ndjson"})
+	res = decodeJSON(t, rr)
+	if res["total"].(float64) != 3 || res["created"].(float64) != 2 {
+		t.Fatalf("unexpected NDJSON result %v", res)
+	}
+	if bad := res["results"].([]any)[1].(map[string]any); bad["code"] != "INVALID_JSON" || !strings.Contains(bad["error"].(string), "line 3") {
+		t.Errorf("expected the malformed line reported, got %v", bad)
+	}
+
+	for _, body := range []string{`{"content":"not an array"}`, `[]`} {
+		if rr := doRequest(t, s, "POST", "/v1/import", body, headers); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestImportBatch_BoundedByMaxRequestBody(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Security.MaxRequestBody = 256 })
+	items := make([]string, 20)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"content":"memory number %d of the batch"}`, i)
+	}
+	rr := doRequest(t, s, "POST", "/v1/import", "["+strings.Join(items, ",")+"]", map[string]string{"X-Index-ID": "bulk"})
+	if rr.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rr.Body.String(), "security.maxRequestBody") {
+		t.Fatalf("expected 413 naming the limit, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// zipVault zips the vault fixture, replacing the content of the files in
+// overrides.
+func zipVault(t *testing.T, overrides map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fsys := os.DirFS("../vault/testdata/vault")
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if content, ok := overrides[p]; ok {
+			data = []byte(content)
+		}
+		f, err := zw.Create(p)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func importVault(t *testing.T, s *Server, query, archive string) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/import/markdown"+query, archive, map[string]string{"X-Index-ID": "vault"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+// importedNeurons maps the source paths of the index's neurons to them.
+func importedNeurons(t *testing.T, s *Server) (map[string]*core.Neuron, *core.Matrix) {
+	t.Helper()
+	worker, err := s.pool.GetOrCreate("vault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := worker.Matrix()
+	m.RLock()
+	defer m.RUnlock()
+	out := make(map[string]*core.Neuron)
+	for _, n := range m.Neurons {
+		out[n.Metadata["source_path"].(string)] = n
+	}
+	return out, m
+}
+
+func synapseWeight(m *core.Matrix, a, b core.NeuronID) (float64, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	if syn, ok := m.Synapses[core.NewSynapseID(a, b)]; ok {
+		return syn.Weight, true
+	}
+	if syn, ok := m.Synapses[core.NewSynapseID(b, a)]; ok {
+		return syn.Weight, true
+	}
+	return 0, false
+}
+
+func counts(doc map[string]any) []float64 {
+	return []float64{doc["created"].(float64), doc["updated"].(float64), doc["unchanged"].(float64), doc["linked"].(float64)}
+}
+
+func TestImportMarkdown_CreatesLinkedNeuronsIdempotently(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+
+	doc := importVault(t, s, "?link_weight=0.7", zipVault(t, nil))
+	if got := counts(doc); !slices.Equal(got, []float64{5, 0, 0, 4}) {
+		t.Fatalf("expected 5 created and 4 linked, got %v: %v", got, doc)
+	}
+	if unresolved := doc["unresolved"].([]any); len(unresolved) != 2 {
+		t.Errorf("expected the missing note and the link outside the vault unresolved, got %v", unresolved)
+	}
+
+	neurons, m := importedNeurons(t, s)
+	home := neurons["Home.md"]
+	if home == nil || home.Metadata["title"] != "Home" || !slices.Equal(home.Tags, []string{"index", "pkm"}) {
+		t.Fatalf("unexpected Home neuron: %+v", home)
+	}
+	for _, pair := range [][2]string{{"Home.md", "Projects/Alpha.md"}, {"Home.md", "garden/Plants.md"}, {"Projects/Alpha.md", "Projects/Beta.md"}} {
+		if w, ok := synapseWeight(m, neurons[pair[0]].ID, neurons[pair[1]].ID); !ok || w != 0.7 {
+			t.Errorf("expected %s and %s linked with weight 0.7, got %v %v", pair[0], pair[1], w, ok)
+		}
+	}
+
+	doc = importVault(t, s, "", zipVault(t, nil))
+	if got := counts(doc); !slices.Equal(got, []float64{0, 0, 5, 0}) {
+		t.Errorf("re-importing an unchanged vault should change nothing, got %v", got)
+	}
+
+	doc = importVault(t, s, "", zipVault(t, map[string]string{"garden/Plants.md": "# Plants\n\nFerns need shade. Back to [[Home]].\n"}))
+	if got := counts(doc); !slices.Equal(got, []float64{0, 1, 4, 0}) {
+		t.Errorf("an edited note should be updated in place, got %v", got)
+	}
+	after, _ := importedNeurons(t, s)
+	if len(after) != 5 || after["garden/Plants.md"].ID != neurons["garden/Plants.md"].ID {
+		t.Fatalf("the edit should keep the neuron, got %d neurons", len(after))
+	}
+	if content := after["garden/Plants.md"].Content; content != "# Plants\n\nFerns need shade. Back to [[Home]]." {
+		t.Errorf("unexpected updated content %q", content)
+	}
+}
+
+func TestImportMarkdown_SplitHeadings(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+
+	doc := importVault(t, s, "?split_headings=true", zipVault(t, nil))
+	if doc["created"].(float64) <= 5 {
+		t.Fatalf("splitting headings should create a neuron per section, got %v", doc)
+	}
+	worker, _ := s.pool.GetOrCreate("vault")
+	sections := 0
+	for _, n := range worker.Matrix().Neurons {
+		if n.Metadata["source_path"] == "Projects/Alpha.md" {
+			sections++
+		}
+	}
+	if sections != 3 {
+		t.Errorf("expected a neuron for each of Alpha's 3 sections, got %d", sections)
+	}
+}
+
+func TestImportMarkdown_RejectsBadUploads(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Import.MaxFiles = 2
+		cfg.Import.MaxUploadBytes = 4096
+	})
+	headers := map[string]string{"X-Index-ID": "vault"}
+
+	rr := doRequest(t, s, "POST", "/v1/import/markdown", "not a zip", headers)
+	if rr.Code != http.StatusBadRequest || decodeJSON(t, rr)["code"] != "INVALID_ARCHIVE" {
+		t.Errorf("expected INVALID_ARCHIVE, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = doRequest(t, s, "POST", "/v1/import/markdown", zipVault(t, nil), headers)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 past import.maxFiles, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = doRequest(t, s, "POST", "/v1/import/markdown", strings.Repeat("x", 8192), headers)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 past import.maxUploadBytes, got %d", rr.Code)
+	}
+	rr = doRequest(t, s, "POST", "/v1/import/markdown?link_weight=2", zipVault(t, nil), headers)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for link_weight above 1, got %d", rr.Code)
+	}
+}
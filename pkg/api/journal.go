@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// journalRequest starts an operation journal
+// (POST /admin/indexes/{id}/operations).
+type journalRequest struct {
+	TTL            string `json:"ttl"`            // Go duration; admin.journal.defaultTTL if empty
+	File           bool   `json:"file"`           // also write data/debug/<index>.jsonl
+	IncludeContent bool   `json:"includeContent"` // record content and queries in full
+}
+
+// journalFile is where indexID's journal is written when asked to.
+func (s *Server) journalFile(indexID core.IndexID) string {
+	return filepath.Join(s.pool.DataPath(), "data", "debug", string(indexID)+".jsonl")
+}
+
+// handleAdminOperations starts (POST), reads (GET ?since=<RFC3339>) and
+// stops (DELETE) the operation journal of an index. A journal turns itself
+// off after its TTL; stopping drops its records and truncates its file.
+func (s *Server) handleAdminOperations(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	switch r.Method {
+	case "POST":
+		var req journalRequest
+		if r.ContentLength != 0 && !s.decodeJSONRequest(w, r, &req) {
+			return
+		}
+		cfg := s.config.Admin.Journal
+		ttl := cfg.DefaultTTL
+		if req.TTL != "" {
+			d, err := time.ParseDuration(req.TTL)
+			if err != nil || d <= 0 {
+				apierr.BadRequest(w, apierr.CodeBadRequest, "ttl must be a positive duration")
+				return
+			}
+			if d > cfg.MaxTTL {
+				apierr.BadRequest(w, apierr.CodeBadRequest, "ttl exceeds admin.journal.maxTTL ("+cfg.MaxTTL.String()+")")
+				return
+			}
+			ttl = d
+		}
+		if _, err := s.getWorker(indexID); err != nil {
+			s.writeWorkerError(w, err)
+			return
+		}
+		opts := concurrency.JournalOptions{
+			TTL:            ttl,
+			Capacity:       cfg.Capacity,
+			IncludeContent: req.IncludeContent,
+		}
+		if req.File {
+			opts.File = s.journalFile(indexID)
+		}
+		j, err := s.pool.StartJournal(indexID, opts)
+		if err != nil {
+			apierr.Internal(w, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(journalDocument(indexID, j))
+
+	case "GET":
+		var since time.Time
+		if raw := r.URL.Query().Get("since"); raw != "" {
+			t, err := time.Parse(time.RFC3339Nano, raw)
+			if err != nil {
+				apierr.BadRequest(w, apierr.CodeBadRequest, "since must be RFC3339")
+				return
+			}
+			since = t
+		}
+		j := s.pool.Journal(indexID)
+		resp := journalDocument(indexID, j)
+		records := []concurrency.JournalRecord{}
+		if j != nil {
+			records = j.Records(since)
+		}
+		resp["operations"] = records
+		resp["count"] = len(records)
+		json.NewEncoder(w).Encode(resp)
+
+	case "DELETE":
+		json.NewEncoder(w).Encode(map[string]any{
+			"indexId": indexID,
+			"stopped": s.pool.StopJournal(indexID),
+		})
+
+	default:
+		apierr.MethodNotAllowed(w)
+	}
+}
+
+// journalDocument describes the state of indexID's journal j, which may be
+// nil.
+func journalDocument(indexID core.IndexID, j *concurrency.Journal) map[string]any {
+	doc := map[string]any{
+		"indexId": indexID,
+		"active":  j != nil,
+	}
+	if j != nil {
+		opts := j.Options()
+		doc["startedAt"] = j.StartedAt()
+		doc["expiresAt"] = j.ExpiresAt()
+		doc["capacity"] = opts.Capacity
+		doc["includeContent"] = opts.IncludeContent
+		doc["file"] = opts.File != ""
+	}
+	return doc
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func journalAdminRequest(t *testing.T, s *Server, method, path, body string, want int) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, method, path, body, map[string]string{
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+		"Content-Type":  "application/json",
+	})
+	if rr.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d: %s", method, path, want, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+// interactiveOps lists the ops of a journal response, leaving out the
+// activations reads queue and daemon work, whose timing is not scripted.
+func interactiveOps(resp map[string]any) []map[string]any {
+	var ops []map[string]any
+	for _, o := range resp["operations"].([]any) {
+		op := o.(map[string]any)
+		if op["op"] == "activate" || op["background"] == true {
+			continue
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+func TestJournal_RecordsScriptedSequence(t *testing.T) {
+	s := newTestServer(t, nil)
+	idx := map[string]string{"X-Index-ID": "journaled", "Content-Type": "application/json"}
+	started := journalAdminRequest(t, s, "POST", "/admin/indexes/journaled/operations", `{"ttl":"10m"}`, 201)
+	if started["active"] != true || started["includeContent"] != false {
+		t.Fatalf("unexpected journal state: %v", started)
+	}
+
+	id := decodeJSON(t, doRequest(t, s, "POST", "/v1/write", `{"content":"secret launch plan","metadata":{"project":"apollo"}}`, idx))["_id"].(string)
+	doRequest(t, s, "POST", "/v1/write", `{"content":"grocery list"}`, idx)
+	doRequest(t, s, "GET", "/v1/search?q=launch&depth=2&limit=5&consistency=strong", "", idx)
+	doRequest(t, s, "GET", "/v1/read/"+id+"?consistency=strong", "", idx)
+	doRequest(t, s, "GET", "/v1/recall?consistency=strong", "", idx)
+
+	resp := journalAdminRequest(t, s, "GET", "/admin/indexes/journaled/operations", "", 200)
+	ops := interactiveOps(resp)
+	var names []string
+	for _, op := range ops {
+		names = append(names, op["op"].(string))
+	}
+	if want := []string{"write", "write", "search", "read", "recall"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("operation trail = %v, want %v", names, want)
+	}
+	if ops[0]["payload"] != "content=<18 bytes> metadata=project" || ops[0]["result"] != "neuron="+id {
+		t.Errorf("unexpected write record: %v", ops[0])
+	}
+	if ops[2]["payload"] != "query=<6 bytes> depth=2 limit=5" {
+		t.Errorf("unexpected search record: %v", ops[2])
+	}
+	for _, op := range ops {
+		if p, _ := op["payload"].(string); strings.Contains(p, "launch") || strings.Contains(p, "grocery") {
+			t.Fatalf("content recorded without includeContent: %v", op)
+		}
+	}
+
+	since := ops[3]["time"].(string)
+	later := journalAdminRequest(t, s, "GET", "/admin/indexes/journaled/operations?since="+since, "", 200)
+	if got := interactiveOps(later); len(got) != 2 || got[0]["op"] != "read" {
+		t.Errorf("since filter: got %v", got)
+	}
+
+	stopped := journalAdminRequest(t, s, "DELETE", "/admin/indexes/journaled/operations", "", 200)
+	if stopped["stopped"] != true {
+		t.Errorf("expected the journal to stop: %v", stopped)
+	}
+	if resp := journalAdminRequest(t, s, "GET", "/admin/indexes/journaled/operations", "", 200); resp["active"] != false || resp["count"] != float64(0) {
+		t.Errorf("stopped journal still serves records: %v", resp)
+	}
+}
+
+func TestJournal_ExpiryTruncatesFile(t *testing.T) {
+	s := newTestServer(t, nil)
+	idx := map[string]string{"X-Index-ID": "expiring", "Content-Type": "application/json"}
+
+	journalAdminRequest(t, s, "POST", "/admin/indexes/expiring/operations", `{"ttl":"2h"}`, 400)
+	journalAdminRequest(t, s, "POST", "/admin/indexes/expiring/operations", `{"ttl":"200ms","file":true,"includeContent":true}`, 201)
+	doRequest(t, s, "POST", "/v1/write", `{"content":"recorded in full"}`, idx)
+
+	path := filepath.Join(s.config.Storage.DataPath, "data", "debug", "expiring.jsonl")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `content=\"recorded in full\"`) {
+		t.Fatalf("journal file missing the write: %s", data)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if resp := journalAdminRequest(t, s, "GET", "/admin/indexes/expiring/operations", "", 200); resp["active"] != false {
+		t.Errorf("journal still active after its TTL: %v", resp)
+	}
+	doRequest(t, s, "POST", "/v1/write", `{"content":"after expiry"}`, idx)
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("journal file not truncated after expiry: %v %v", info, err)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// heldLoader reports every index as persisted and holds each Load until
+// release is closed.
+type heldLoader struct {
+	release chan struct{}
+}
+
+func (l heldLoader) Exists(core.IndexID) bool { return true }
+
+func (l heldLoader) Load(indexID core.IndexID) (*core.Matrix, error) {
+	<-l.release
+	m := core.NewMatrix(indexID, core.DefaultBounds())
+	n := core.NewNeuron("loaded from a slow disk", m.CurrentDim)
+	m.Neurons[n.ID] = n
+	return m, nil
+}
+
+func TestLoadTimeout_Returns503ThenServesLoadedIndex(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	loader := heldLoader{release: make(chan struct{})}
+	s.pool.SetLoader(loader)
+	s.pool.SetLoadTimeout(20 * time.Millisecond)
+
+	headers := map[string]string{"X-Index-ID": "slow", "Content-Type": "application/json"}
+	rr := doRequest(t, s, "POST", "/v1/search", `{"query":"slow disk"}`, headers)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while loading, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if code := decodeJSON(t, rr)["code"]; code != "INDEX_LOADING" {
+		t.Errorf("expected INDEX_LOADING, got %v", code)
+	}
+	if rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After: 1, got %q", rr.Header().Get("Retry-After"))
+	}
+
+	close(loader.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for s.pool.LoadStats()["in_flight"] != int64(0) {
+		if time.Now().After(deadline) {
+			t.Fatal("the load should finish after its request gave up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rr = doRequest(t, s, "POST", "/v1/search", `{"query":"slow disk"}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 once loaded, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if results, _ := decodeJSON(t, rr)["results"].([]any); len(results) != 1 {
+		t.Errorf("expected the loaded neuron, got %v", results)
+	}
+	if stats := s.pool.LoadStats(); stats["loads"] != uint64(1) || stats["timeouts"] != uint64(1) {
+		t.Errorf("unexpected load stats: %v", stats)
+	}
+}
@@ -11,7 +11,8 @@ import (
 	"github.com/qubicDB/qubicdb/pkg/api/apierr"
 	"github.com/qubicDB/qubicdb/pkg/concurrency"
 	"github.com/qubicDB/qubicdb/pkg/core"
-	"github.com/qubicDB/qubicdb/pkg/protocol"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	mcpapi "github.com/qubicDB/qubicdb/pkg/mcp"
 )
 
 type mcpBackend struct {
@@ -22,13 +23,16 @@ func newMCPBackend(s *Server) *mcpBackend {
 	return &mcpBackend{server: s}
 }
 
-func (b *mcpBackend) Write(_ context.Context, indexID, content string, metadata map[string]string) (map[string]any, error) {
+func (b *mcpBackend) Write(ctx context.Context, indexID, content string, metadata map[string]string) (map[string]any, error) {
+	if err := b.server.readOnlyError(); err != nil {
+		return nil, err
+	}
 	worker, err := b.getWorker(indexID)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := worker.Submit(&concurrency.Operation{
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
 		Type: concurrency.OpWrite,
 		Payload: concurrency.AddNeuronRequest{
 			Content:  content,
@@ -40,18 +44,16 @@ func (b *mcpBackend) Write(_ context.Context, indexID, content string, metadata
 	}
 
 	n := result.(*core.Neuron)
-	doc := protocol.NeuronToDocument(n, nil)
-	doc["id"] = doc["_id"]
-	return doc, nil
+	return b.server.neuronDocument(ctx, n), nil
 }
 
-func (b *mcpBackend) Read(_ context.Context, indexID, neuronID string) (map[string]any, error) {
+func (b *mcpBackend) Read(ctx context.Context, indexID, neuronID string) (map[string]any, error) {
 	worker, err := b.getWorker(indexID)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := worker.Submit(&concurrency.Operation{
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
 		Type:    concurrency.OpRead,
 		Payload: core.NeuronID(neuronID),
 	})
@@ -60,62 +62,88 @@ func (b *mcpBackend) Read(_ context.Context, indexID, neuronID string) (map[stri
 	}
 
 	n := result.(*core.Neuron)
-	return protocol.NeuronToDocument(n, nil), nil
+	return b.server.neuronDocument(ctx, n), nil
 }
 
-func (b *mcpBackend) Search(_ context.Context, indexID, query string, depth, limit int, metadata map[string]string, strict bool) (map[string]any, error) {
+func (b *mcpBackend) Search(ctx context.Context, indexID, query string, depth, limit int, metadata map[string]string, strict bool, roles []string, mode string, anchor mcpapi.Anchor) (map[string]any, error) {
 	worker, err := b.getWorker(indexID)
 	if err != nil {
 		return nil, err
 	}
-	if strings.TrimSpace(query) == "" {
+	if strings.TrimSpace(query) == "" && anchor.ID == "" {
 		return nil, fmt.Errorf("query is required")
 	}
+	mode, ok := parseSearchMode(mode)
+	if !ok {
+		return nil, fmt.Errorf("mode must be exact or omitted")
+	}
+	anchorWeight := b.server.config.Search.AnchorWeight
+	if anchor.Weight != nil {
+		anchorWeight = *anchor.Weight
+	}
+	if anchor.ID != "" {
+		if anchorWeight < 0 || anchorWeight > 1 {
+			return nil, fmt.Errorf("anchor_weight must be between 0 and 1")
+		}
+		if mode == engine.SearchModeExact {
+			return nil, fmt.Errorf("anchor_id cannot be combined with mode exact")
+		}
+	}
 
 	depth = clampPositive(depth, defaultSearchDepth, maxSearchDepth)
 	limit = clampPositive(limit, defaultSearchLimit, maxSearchLimit)
-
-	result, err := worker.Submit(&concurrency.Operation{
-		Type: concurrency.OpSearch,
-		Payload: concurrency.SearchRequest{
-			Query:    query,
-			Depth:    depth,
-			Limit:    limit,
-			Metadata: metadata,
-			Strict:   strict,
-		},
+	if mode == engine.SearchModeExact {
+		depth = 0
+	}
+
+	neurons, _, err := b.server.runSearch(ctx, worker, core.IndexID(indexID), searchKindSearch, concurrency.SearchRequest{
+		Query:    query,
+		Depth:    depth,
+		Limit:    limit,
+		Metadata: metadata,
+		Strict:   strict,
+		Roles:    b.server.resolveRoles(core.IndexID(indexID), roles),
+		Mode:     mode,
+
+		AnchorID:      core.NeuronID(anchor.ID),
+		AnchorWeight:  anchorWeight,
+		IncludeAnchor: anchor.Include,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	neurons := result.([]*core.Neuron)
 	docs := make([]map[string]any, 0, len(neurons))
 	for _, n := range neurons {
-		docs = append(docs, protocol.NeuronToDocument(n, nil))
+		docs = append(docs, b.server.neuronDocument(ctx, n))
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"results": docs,
 		"count":   len(docs),
 		"query":   query,
 		"depth":   depth,
-	}, nil
+	}
+	if anchor.ID != "" {
+		result["anchorId"] = anchor.ID
+	}
+	return result, nil
 }
 
-func (b *mcpBackend) Recall(_ context.Context, indexID string, limit int) (map[string]any, error) {
+func (b *mcpBackend) Recall(ctx context.Context, indexID string, limit int, roles []string) (map[string]any, error) {
 	worker, err := b.getWorker(indexID)
 	if err != nil {
 		return nil, err
 	}
 
-	limit = clampPositive(limit, 100, 500)
+	limit = clampPositive(limit, defaultRecallLimit, maxRecallLimit)
 
-	result, err := worker.Submit(&concurrency.Operation{
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
 		Type: concurrency.OpRecall,
 		Payload: concurrency.ListNeuronsRequest{
 			Offset: 0,
 			Limit:  limit,
+			Roles:  b.server.resolveRoles(core.IndexID(indexID), roles),
 		},
 	})
 	if err != nil {
@@ -125,7 +153,7 @@ func (b *mcpBackend) Recall(_ context.Context, indexID string, limit int) (map[s
 	neurons := result.([]*core.Neuron)
 	items := make([]map[string]any, len(neurons))
 	for i, n := range neurons {
-		items[i] = protocol.NeuronToDocument(n, nil)
+		items[i] = b.server.neuronDocument(ctx, n)
 	}
 
 	return map[string]any{
@@ -135,7 +163,7 @@ func (b *mcpBackend) Recall(_ context.Context, indexID string, limit int) (map[s
 	}, nil
 }
 
-func (b *mcpBackend) Context(_ context.Context, indexID, cue string, depth, maxTokens int) (map[string]any, error) {
+func (b *mcpBackend) Context(ctx context.Context, indexID, cue string, depth, maxTokens int, roles []string, format string) (map[string]any, error) {
 	worker, err := b.getWorker(indexID)
 	if err != nil {
 		return nil, err
@@ -143,55 +171,32 @@ func (b *mcpBackend) Context(_ context.Context, indexID, cue string, depth, maxT
 	if strings.TrimSpace(cue) == "" {
 		return nil, fmt.Errorf("cue is required")
 	}
+	if !core.IsContextFormat(format) {
+		return nil, fmt.Errorf("format must be one of blocks, messages or text")
+	}
+	opts, _ := b.server.contextOptions(format, "")
 
 	maxTokens = clampPositive(maxTokens, defaultContextTokens, maxContextTokens)
 	depth = clampPositive(depth, defaultContextDepth, maxContextDepth)
 
-	result, err := worker.Submit(&concurrency.Operation{
-		Type: concurrency.OpSearch,
-		Payload: concurrency.SearchRequest{
-			Query: cue,
-			Depth: depth,
-			Limit: 50,
-		},
+	neurons, _, err := b.server.runSearch(ctx, worker, core.IndexID(indexID), searchKindContext, concurrency.SearchRequest{
+		Query: cue,
+		Depth: depth,
+		Limit: 50,
+		Roles: b.server.resolveRoles(core.IndexID(indexID), roles),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	neurons := result.([]*core.Neuron)
-	var contextBuilder strings.Builder
-	tokenEstimate := 0
-	included := 0
-
-	for _, n := range neurons {
-		neuronTokens := len(n.Content) / 4
-		if tokenEstimate+neuronTokens > maxTokens {
-			break
-		}
-
-		if contextBuilder.Len() > 0 {
-			contextBuilder.WriteString("\n---\n")
-		}
-		contextBuilder.WriteString(n.Content)
-		if n.Depth > 0 {
-			contextBuilder.WriteString(fmt.Sprintf(" [depth:%d]", n.Depth))
-		}
-
-		tokenEstimate += neuronTokens
-		included++
+	hits := make([]searchHit, len(neurons))
+	for i, n := range neurons {
+		hits[i] = searchHit{neuron: n, source: core.IndexID(indexID)}
 	}
-
-	assembled := contextBuilder.String()
-	return map[string]any{
-		"context":         assembled,
-		"text":            assembled,
-		"neuronsUsed":     included,
-		"neuronCount":     included,
-		"estimatedTokens": tokenEstimate,
-		"tokenCount":      tokenEstimate,
-		"cue":             cue,
-	}, nil
+	result := contextResponse(assembleContext(hits, maxTokens, opts), format)
+	result["cue"] = cue
+	result["format"] = format
+	return result, nil
 }
 
 func (b *mcpBackend) RegistryFindOrCreate(_ context.Context, uuid string, metadata map[string]any) (map[string]any, error) {
@@ -213,6 +218,27 @@ func (b *mcpBackend) RegistryFindOrCreate(_ context.Context, uuid string, metada
 	}, nil
 }
 
+func (b *mcpBackend) SetFocus(_ context.Context, indexID string, metadata map[string]string, boost *float64, ttl string) (map[string]any, error) {
+	worker, err := b.getWorker(indexID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := focusRequest{Metadata: metadata, Boost: boost, TTL: ttl}.focus(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	worker.SetFocus(f)
+	return focusDocument(f, true), nil
+}
+
+func (b *mcpBackend) ClearFocus(_ context.Context, indexID string) (map[string]any, error) {
+	worker, err := b.getWorker(indexID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"deleted": worker.ClearFocus()}, nil
+}
+
 func (b *mcpBackend) getWorker(indexID string) (*concurrency.BrainWorker, error) {
 	worker, err := b.server.getWorker(core.IndexID(indexID))
 	if err != nil {
@@ -255,12 +281,7 @@ func (b *mcpBackend) ListIndexes(_ context.Context, activeOnly bool, limit int)
 				stats := worker.Stats()
 				entry["last_op"] = stats["last_op"]
 				entry["ops_processed"] = stats["ops_processed"]
-				// Get neuron count from matrix
-				m := worker.Matrix()
-				m.RLock()
-				entry["neuron_count"] = len(m.Neurons)
-				entry["synapse_count"] = len(m.Synapses)
-				m.RUnlock()
+				entry["neuron_count"], entry["synapse_count"] = worker.Size()
 			}
 			// Add registry metadata if available
 			if regEntry, ok := b.server.registry.Get(id); ok {
@@ -289,11 +310,7 @@ func (b *mcpBackend) ListIndexes(_ context.Context, activeOnly bool, limit int)
 				stats := worker.Stats()
 				indexEntry["last_op"] = stats["last_op"]
 				indexEntry["ops_processed"] = stats["ops_processed"]
-				m := worker.Matrix()
-				m.RLock()
-				indexEntry["neuron_count"] = len(m.Neurons)
-				indexEntry["synapse_count"] = len(m.Synapses)
-				m.RUnlock()
+				indexEntry["neuron_count"], indexEntry["synapse_count"] = worker.Size()
 			} else {
 				indexEntry["active"] = false
 			}
@@ -310,7 +327,7 @@ func (b *mcpBackend) ListIndexes(_ context.Context, activeOnly bool, limit int)
 }
 
 // GlobalSearch searches across ALL active indexes using semantic/vector similarity.
-func (b *mcpBackend) GlobalSearch(_ context.Context, query string, depth, limit int, metadata map[string]string) (map[string]any, error) {
+func (b *mcpBackend) GlobalSearch(ctx context.Context, query string, depth, limit int, metadata map[string]string) (map[string]any, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, fmt.Errorf("query is required")
 	}
@@ -349,7 +366,7 @@ func (b *mcpBackend) GlobalSearch(_ context.Context, query string, depth, limit
 				return
 			}
 
-			result, err := worker.Submit(&concurrency.Operation{
+			result, err := worker.SubmitContext(ctx, &concurrency.Operation{
 				Type: concurrency.OpSearch,
 				Payload: concurrency.SearchRequest{
 					Query:    query,
@@ -357,6 +374,9 @@ func (b *mcpBackend) GlobalSearch(_ context.Context, query string, depth, limit
 					Limit:    limit,
 					Metadata: metadata,
 					Strict:   false,
+					// Scores are compared across indexes, so no
+					// index's focus may lift its own hits.
+					IgnoreFocus: true,
 				},
 			})
 			if err != nil {
@@ -367,7 +387,7 @@ func (b *mcpBackend) GlobalSearch(_ context.Context, query string, depth, limit
 			neurons := result.([]*core.Neuron)
 			docs := make([]map[string]any, 0, len(neurons))
 			for _, n := range neurons {
-				doc := protocol.NeuronToDocument(n, nil)
+				doc := b.server.neuronDocument(ctx, n)
 				doc["_index"] = indexID
 				docs = append(docs, doc)
 			}
@@ -416,7 +436,7 @@ func (b *mcpBackend) GlobalSearch(_ context.Context, query string, depth, limit
 }
 
 // MultiSearch searches across a specific list of indexes.
-func (b *mcpBackend) MultiSearch(_ context.Context, indexIDs []string, query string, depth, limit int, metadata map[string]string) (map[string]any, error) {
+func (b *mcpBackend) MultiSearch(ctx context.Context, indexIDs []string, query string, depth, limit int, metadata map[string]string) (map[string]any, error) {
 	if len(indexIDs) == 0 {
 		return nil, fmt.Errorf("index_ids cannot be empty")
 	}
@@ -447,7 +467,7 @@ func (b *mcpBackend) MultiSearch(_ context.Context, indexIDs []string, query str
 				return
 			}
 
-			result, err := worker.Submit(&concurrency.Operation{
+			result, err := worker.SubmitContext(ctx, &concurrency.Operation{
 				Type: concurrency.OpSearch,
 				Payload: concurrency.SearchRequest{
 					Query:    query,
@@ -455,6 +475,9 @@ func (b *mcpBackend) MultiSearch(_ context.Context, indexIDs []string, query str
 					Limit:    limit,
 					Metadata: metadata,
 					Strict:   false,
+					// Scores are compared across indexes, so no
+					// index's focus may lift its own hits.
+					IgnoreFocus: true,
 				},
 			})
 			if err != nil {
@@ -465,7 +488,7 @@ func (b *mcpBackend) MultiSearch(_ context.Context, indexIDs []string, query str
 			neurons := result.([]*core.Neuron)
 			docs := make([]map[string]any, 0, len(neurons))
 			for _, n := range neurons {
-				doc := protocol.NeuronToDocument(n, nil)
+				doc := b.server.neuronDocument(ctx, n)
 				doc["_index"] = indexID
 				docs = append(docs, doc)
 			}
@@ -531,11 +554,7 @@ func (b *mcpBackend) RecentIndexes(_ context.Context, limit int, minNeurons int)
 	// Collect stats from all active workers
 	b.server.pool.ForEach(func(id core.IndexID, worker *concurrency.BrainWorker) {
 		stats := worker.Stats()
-		m := worker.Matrix()
-		m.RLock()
-		neuronCount := len(m.Neurons)
-		synapseCount := len(m.Synapses)
-		m.RUnlock()
+		neuronCount, synapseCount := worker.Size()
 
 		// Filter by minimum neurons
 		if minNeurons > 0 && neuronCount < minNeurons {
@@ -7,6 +7,7 @@ import (
 	"github.com/qubicDB/qubicdb/pkg/concurrency"
 	"github.com/qubicDB/qubicdb/pkg/core"
 	"github.com/qubicDB/qubicdb/pkg/lifecycle"
+	mcpapi "github.com/qubicDB/qubicdb/pkg/mcp"
 	"github.com/qubicDB/qubicdb/pkg/persistence"
 	"github.com/qubicDB/qubicdb/pkg/registry"
 )
@@ -158,7 +159,7 @@ func TestMCPBackend_RecentIndexes(t *testing.T) {
 	_, _ = b.Write(ctx, "old-index", "Old content", nil)
 	_, _ = b.Write(ctx, "new-index", "New content", nil)
 	// Do more operations on new-index to make it "more recent"
-	_, _ = b.Search(ctx, "new-index", "content", 2, 10, nil, false)
+	_, _ = b.Search(ctx, "new-index", "content", 2, 10, nil, false, nil, "", mcpapi.Anchor{})
 
 	result, err := b.RecentIndexes(ctx, 10, 0)
 	if err != nil {
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/lifecycle"
+)
+
+// defaultMemoryTop is how many indexes GET /admin/memory lists by default.
+const defaultMemoryTop = 10
+
+// indexListEntry is an entry of GET /admin/indexes?sort=.
+type indexListEntry struct {
+	IndexID     string `json:"indexId"`
+	MemoryBytes int64  `json:"memoryBytes"`
+	State       string `json:"state"`
+	DiskBytes   int64  `json:"diskBytes"`
+	QuotaBytes  int64  `json:"quotaBytes,omitempty"`
+
+	// Diverged marks an index quarantined because its loaded state no
+	// longer matches its data on disk.
+	Diverged bool `json:"diverged,omitempty"`
+
+	// Health is the index's cached health score and the formula version
+	// it was computed with; both are absent until one is computed.
+	Health        *float64 `json:"health,omitempty"`
+	HealthFormula string   `json:"healthFormula,omitempty"`
+}
+
+// writeIndexListing answers GET /admin/indexes?sort=memory|id|health with
+// the loaded indexes, their footprints and cached health scores, healthiest
+// first for sort=health with unscored indexes last. Without sort the
+// listing stays a plain array of IDs.
+func (s *Server) writeIndexListing(w http.ResponseWriter, by string) {
+	usage := s.pool.MemoryUsage() // largest first
+	entries := make([]indexListEntry, 0, len(usage))
+	for _, u := range usage {
+		var health *float64
+		var formula string
+		if worker, err := s.pool.Get(u.IndexID); err == nil {
+			if h := worker.CachedHealth(); h != nil {
+				health, formula = &h.Score, h.FormulaVersion
+			}
+		}
+		entries = append(entries, indexListEntry{
+			IndexID:     string(u.IndexID),
+			MemoryBytes: u.Bytes,
+			State:       lifecycle.StateName(s.lifecycle.GetState(u.IndexID)),
+			DiskBytes:   s.pool.DiskUsage(u.IndexID).Bytes(),
+			QuotaBytes:  s.pool.IndexQuota(u.IndexID).Bytes,
+			Diverged:    s.pool.Diverged(u.IndexID),
+
+			Health:        health,
+			HealthFormula: formula,
+		})
+	}
+	switch by {
+	case "id":
+		sort.Slice(entries, func(i, j int) bool { return entries[i].IndexID < entries[j].IndexID })
+	case "health":
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i].Health, entries[j].Health
+			return a != nil && (b == nil || *a > *b)
+		})
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+// handleAdminMemory reports the indexes holding the most memory, by their
+// approximate footprint, with the pending writes and Go runtime figures
+// for context.
+func (s *Server) handleAdminMemory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	top := defaultMemoryTop
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "limit must be a positive integer")
+			return
+		}
+		top = n
+	}
+
+	usage := s.pool.MemoryUsage()
+	var total int64
+	for _, u := range usage {
+		total += u.Bytes
+	}
+	if len(usage) > top {
+		usage = usage[:top]
+	}
+	indexes := make([]map[string]any, 0, len(usage))
+	for _, u := range usage {
+		entry := map[string]any{
+			"indexId":     u.IndexID,
+			"memoryBytes": u.Bytes,
+			"state":       lifecycle.StateName(s.lifecycle.GetState(u.IndexID)),
+		}
+		if worker, err := s.pool.Get(u.IndexID); err == nil {
+			st := worker.Stats()
+			entry["queueLength"] = st["queue_length"]
+			entry["opsProcessed"] = st["ops_processed"]
+		}
+		indexes = append(indexes, entry)
+	}
+
+	mem := s.pool.MemoryStats()
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexes":       indexes,
+		"loadedIndexes": mem["indexes"],
+		"totalBytes":    total,
+		"pendingWrites": map[string]any{
+			"count": mem["pending_writes"],
+			"bytes": mem["pending_writes_bytes"],
+		},
+		"runtime": map[string]any{
+			"heapAlloc": mem["runtime_heap_alloc"],
+			"heapInuse": mem["runtime_heap_inuse"],
+			"sys":       mem["runtime_sys"],
+			"numGC":     mem["runtime_num_gc"],
+		},
+	})
+}
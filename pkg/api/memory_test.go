@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestAdminMemory(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	writeTo(t, s, "small", "a short memory")
+	for i := 0; i < 5; i++ {
+		writeTo(t, s, "large", strings.Repeat("a longer memory ", 50)+string(rune('a'+i)))
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/memory?limit=1", "", admin)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rr.Code, rr.Body.String())
+	}
+	doc := decodeJSON(t, rr)
+	indexes := doc["indexes"].([]any)
+	if len(indexes) != 1 || indexes[0].(map[string]any)["indexId"] != "large" || indexes[0].(map[string]any)["state"] != "active" {
+		t.Errorf("expected the largest index only, got %v", indexes)
+	}
+	if doc["loadedIndexes"] != float64(2) || doc["totalBytes"].(float64) <= indexes[0].(map[string]any)["memoryBytes"].(float64) {
+		t.Errorf("the total should cover every loaded index: %v", doc)
+	}
+	if doc["runtime"].(map[string]any)["heapAlloc"].(float64) == 0 || doc["pendingWrites"] == nil {
+		t.Errorf("missing runtime or pending writes figures: %v", doc)
+	}
+	if rr := doRequest(t, s, "GET", "/admin/memory?limit=0", "", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for limit=0, got %d", rr.Code)
+	}
+
+	rr = doRequest(t, s, "GET", "/admin/indexes?sort=memory", "", admin)
+	var listing []indexListEntry
+	if err := json.Unmarshal(rr.Body.Bytes(), &listing); err != nil || len(listing) != 2 {
+		t.Fatalf("unexpected listing %s: %v", rr.Body.String(), err)
+	}
+	if listing[0].IndexID != "large" || listing[0].MemoryBytes <= listing[1].MemoryBytes {
+		t.Errorf("expected the listing largest first, got %+v", listing)
+	}
+	if rr := doRequest(t, s, "GET", "/admin/indexes?sort=size", "", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown sort, got %d", rr.Code)
+	}
+
+	detail := decodeJSON(t, doRequest(t, s, "GET", "/admin/indexes/large", "", admin))
+	if b, _ := detail["memoryBytes"].(float64); b <= 0 {
+		t.Errorf("index detail should carry memoryBytes: %v", detail)
+	}
+}
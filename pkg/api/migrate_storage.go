@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// migrateStoragePath moves the data directory while the server runs.
+const migrateStoragePath = "/admin/migrate-storage"
+
+// States of a storage migration job.
+const (
+	migrationRunning   = "running"
+	migrationSucceeded = "succeeded"
+	migrationFailed    = "failed"
+	migrationCancelled = "cancelled"
+)
+
+// writePause holds off mutations during a storage migration's switchover.
+// Mutations in flight when it pauses are waited for; those arriving while
+// it is paused are refused.
+type writePause struct {
+	mu       sync.Mutex
+	paused   bool
+	inflight int
+	idle     chan struct{} // closed once inflight drops to 0 while paused
+}
+
+// enter admits a mutation, or reports false while writes are paused. An
+// admitted mutation calls leave when it is done.
+func (p *writePause) enter() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		return false
+	}
+	p.inflight++
+	return true
+}
+
+func (p *writePause) leave() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inflight--
+	if p.paused && p.inflight == 0 && p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+}
+
+// pause refuses further mutations and waits for those in flight, until ctx
+// ends. It returns the function resuming writes.
+func (p *writePause) pause(ctx context.Context) (func(), error) {
+	p.mu.Lock()
+	p.paused = true
+	var idle chan struct{}
+	if p.inflight > 0 {
+		idle = make(chan struct{})
+		p.idle = idle
+	}
+	p.mu.Unlock()
+
+	resume := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.paused = false
+		p.idle = nil
+	}
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			resume()
+			return nil, ctx.Err()
+		}
+	}
+	return resume, nil
+}
+
+// migrationStatus is the state of the last storage migration
+// (GET /admin/migrate-storage).
+type migrationStatus struct {
+	State string `json:"state"`
+	persistence.MigrationProgress
+	Source       string     `json:"source"`
+	Target       string     `json:"target"`
+	Passes       int        `json:"passes"`
+	SwitchoverMs *int64     `json:"switchoverMs,omitempty"`
+	MaxPauseMs   int64      `json:"maxPauseMs"`
+	Error        string     `json:"error,omitempty"`
+	Note         string     `json:"note,omitempty"`
+	StartedAt    time.Time  `json:"startedAt"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+}
+
+// storageMigration is the server's storage migration: the last job and the
+// pause its switchover holds mutations off with.
+type storageMigration struct {
+	writes writePause
+
+	mu     sync.Mutex
+	status *migrationStatus
+	cancel context.CancelFunc
+}
+
+// snapshot returns a copy of the last job's status, or nil.
+func (m *storageMigration) snapshot() *migrationStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status == nil {
+		return nil
+	}
+	st := *m.status
+	return &st
+}
+
+func (m *storageMigration) update(fn func(st *migrationStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(m.status)
+}
+
+// storageMigrationRetryAfter is the Retry-After of mutations refused
+// during a switchover: its bound, storage.migration.maxPause.
+func (s *Server) storageMigrationRetryAfter() int {
+	return max(int(math.Ceil(s.config.Storage.Migration.MaxPause.Seconds())), 1)
+}
+
+// handleMigrateStorage moves the data directory to another path while the
+// server keeps serving (POST /admin/migrate-storage {targetPath}). The
+// copy runs in the background: GET reports its progress and DELETE cancels
+// it before the switchover, leaving the server on its directory. With
+// dryRun set, POST only estimates the copy.
+func (s *Server) handleMigrateStorage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		st := s.migration.snapshot()
+		if st == nil {
+			apierr.NotFound(w, apierr.CodeNotFound, "no storage migration has run")
+			return
+		}
+		json.NewEncoder(w).Encode(st)
+	case "POST":
+		s.startStorageMigration(w, r)
+	case "DELETE":
+		s.cancelStorageMigration(w)
+	default:
+		apierr.MethodNotAllowed(w)
+	}
+}
+
+func (s *Server) startStorageMigration(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetPath string `json:"targetPath"`
+		DryRun     bool   `json:"dryRun"`
+	}
+	if !s.decodeJSONRequest(w, r, &req) {
+		return
+	}
+	cfg := s.config.Storage.Migration
+	plan, err := s.pool.PlanStorageMigration(req.TargetPath, cfg.MaxPasses)
+	if errors.Is(err, persistence.ErrInvalidMigrationTarget) {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	} else if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	if req.DryRun {
+		json.NewEncoder(w).Encode(plan)
+		return
+	}
+	if !plan.Fits {
+		apierr.InsufficientStorage(w, fmt.Sprintf("%s has %d bytes free, %d are needed", plan.Target, plan.TargetFreeBytes, plan.Bytes), plan.TargetFreeBytes)
+		return
+	}
+
+	m := s.migration
+	m.mu.Lock()
+	if m.status != nil && m.status.State == migrationRunning {
+		m.mu.Unlock()
+		apierr.Conflict(w, apierr.CodeConflict, "a storage migration is already running; cancel it with DELETE "+migrateStoragePath)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.status = &migrationStatus{
+		State:             migrationRunning,
+		MigrationProgress: persistence.MigrationProgress{Phase: persistence.MigrationCopy},
+		Source:            plan.Source,
+		Target:            plan.Target,
+		MaxPauseMs:        cfg.MaxPause.Milliseconds(),
+		StartedAt:         time.Now().UTC(),
+	}
+	m.cancel = cancel
+	st := *m.status
+	m.mu.Unlock()
+
+	log.Printf("📦 Moving the data directory from %s to %s (%d files, %d bytes)", plan.Source, plan.Target, plan.Files, plan.Bytes)
+	go s.runStorageMigration(ctx, plan.Target)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(st)
+}
+
+func (s *Server) cancelStorageMigration(w http.ResponseWriter) {
+	m := s.migration
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status == nil || m.status.State != migrationRunning {
+		apierr.Conflict(w, apierr.CodeConflict, "no storage migration is running")
+		return
+	}
+	if m.status.Phase == persistence.MigrationSwitchover {
+		apierr.Conflict(w, apierr.CodeConflict, "the switchover has started and can no longer be cancelled")
+		return
+	}
+	m.cancel()
+	json.NewEncoder(w).Encode(m.status)
+}
+
+// runStorageMigration runs the migration to target and records how it
+// ended.
+func (s *Server) runStorageMigration(ctx context.Context, target string) {
+	cfg := s.config.Storage.Migration
+	report, err := s.pool.MigrateStorage(ctx, target, persistence.MigrationOptions{
+		MaxPasses:     cfg.MaxPasses,
+		SettleIndexes: cfg.SettleIndexes,
+		MaxPause:      cfg.MaxPause,
+		Pause:         s.migration.writes.pause,
+		Relocate:      s.relocateDataFiles,
+		Progress: func(p persistence.MigrationProgress) {
+			s.migration.update(func(st *migrationStatus) { st.MigrationProgress = p })
+		},
+	})
+
+	s.migration.update(func(st *migrationStatus) {
+		now := time.Now().UTC()
+		st.FinishedAt = &now
+		st.Passes = report.Passes
+		st.FilesCopied, st.BytesCopied = report.FilesCopied, report.BytesCopied
+		if report.Switchover > 0 {
+			ms := report.Switchover.Milliseconds()
+			st.SwitchoverMs = &ms
+		}
+		switch {
+		case errors.Is(err, context.Canceled):
+			st.State = migrationCancelled
+		case err != nil:
+			st.State = migrationFailed
+			st.Error = err.Error()
+		default:
+			st.State = migrationSucceeded
+		}
+		if report.Switched {
+			st.Note = fmt.Sprintf("the server now uses %s; set storage.dataPath to it before the next restart", target)
+		}
+	})
+	if err != nil {
+		log.Printf("⚠ storage migration to %s: %v", target, err)
+	}
+}
+
+// relocateDataFiles moves what other components keep in the data
+// directory to target, once the store has switched to it. Journals
+// already writing a file keep it.
+func (s *Server) relocateDataFiles(target string) error {
+	var errs []error
+	if s.registry != nil {
+		errs = append(errs, s.registry.Relocate(target))
+	}
+	if s.shares != nil {
+		errs = append(errs, s.shares.Relocate(target))
+	}
+	if s.subscriptions != nil {
+		errs = append(errs, s.subscriptions.Relocate(target))
+	}
+	if d := s.pool.DeadLetters(); d != nil {
+		errs = append(errs, d.Relocate(filepath.Join(target, "data", "deadletter")))
+	}
+	if s.replication != nil {
+		errs = append(errs, s.replication.spool.Relocate(filepath.Join(target, "replication")))
+	}
+	return errors.Join(errs...)
+}
+
+// refuseMigrating answers a mutation with 503 while a storage migration's
+// switchover pauses writes, reporting whether it did. An admitted mutation
+// must call the returned done.
+func (s *Server) refuseMigrating(w http.ResponseWriter, r *http.Request) (done func(), refused bool) {
+	if !isMutation(r) {
+		return func() {}, false
+	}
+	if !s.migration.writes.enter() {
+		w.Header().Set("Retry-After", strconv.Itoa(s.storageMigrationRetryAfter()))
+		apierr.Write(w, http.StatusServiceUnavailable, apierr.CodeStorageMigrating, "writes are paused while the data directory is switched; retry shortly")
+		return nil, true
+	}
+	return s.migration.writes.leave, false
+}
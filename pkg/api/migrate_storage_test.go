@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestMigrateStorage_UnderWriteLoad(t *testing.T) {
+	maxPause := 2 * time.Second
+	source := t.TempDir()
+	target := filepath.Join(t.TempDir(), "moved")
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Storage.DataPath = source
+		cfg.Registry.Enabled = false
+		cfg.Storage.Migration.MaxPause = maxPause
+		cfg.Storage.Migration.SettleIndexes = 0
+	})
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+
+	if rr := doRequest(t, s, "GET", migrateStoragePath, "", admin); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before any migration, got %d", rr.Code)
+	}
+	for _, path := range []string{source, filepath.Join(source, "data"), ""} {
+		rr := doRequest(t, s, "POST", migrateStoragePath, fmt.Sprintf(`{"targetPath":%q}`, path), admin)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", path, rr.Code)
+		}
+	}
+
+	// Writers keep writing through the migration, retrying the writes the
+	// switchover refuses, and count the ones accepted.
+	const writers = 4
+	accepted := make([]int, writers)
+	paused := 0
+	var mu sync.Mutex
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var stopOnce sync.Once
+	stopWriters := func() {
+		stopOnce.Do(func() { close(stop) })
+		wg.Wait()
+	}
+	defer stopWriters()
+	for g := 0; g < writers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			headers := map[string]string{"X-Index-ID": fmt.Sprintf("writer-%d", g)}
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				body := fmt.Sprintf(`{"content":"writer %d wrote entry number %d"}`, g, i)
+				rr := doRequest(t, s, "POST", "/v1/write", body, headers)
+				switch rr.Code {
+				case http.StatusOK:
+					// Flushes, as the flush daemon runs them, give the
+					// migration changed indexes to copy again.
+					if accepted[g]++; accepted[g]%5 == 0 {
+						if _, err := s.pool.PersistIndex(core.IndexID(headers["X-Index-ID"])); err != nil {
+							t.Errorf("persist: %v", err)
+						}
+					}
+				case http.StatusServiceUnavailable:
+					if rr.Header().Get("Retry-After") == "" || decodeJSON(t, rr)["code"] != "STORAGE_MIGRATING" {
+						t.Errorf("expected STORAGE_MIGRATING with Retry-After, got %s", rr.Body.String())
+						return
+					}
+					mu.Lock()
+					paused++
+					mu.Unlock()
+				default:
+					t.Errorf("write: %d %s", rr.Code, rr.Body.String())
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	rr := doRequest(t, s, "POST", migrateStoragePath, fmt.Sprintf(`{"targetPath":%q,"dryRun":true}`, target), admin)
+	if plan := decodeJSON(t, rr); rr.Code != http.StatusOK || plan["fits"] != true || plan["estimatedPasses"].(float64) < 2 {
+		t.Fatalf("dry run: %d %v", rr.Code, plan)
+	}
+	rr = doRequest(t, s, "POST", migrateStoragePath, fmt.Sprintf(`{"targetPath":%q}`, target), admin)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected the migration started, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	var status map[string]any
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		status = decodeJSON(t, doRequest(t, s, "GET", migrateStoragePath, "", admin))
+		if status["state"] != "running" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	stopWriters()
+
+	if status["state"] != "succeeded" {
+		t.Fatalf("expected the migration to succeed, got %v", status)
+	}
+	if ms := status["switchoverMs"].(float64); ms > float64(maxPause.Milliseconds()) {
+		t.Errorf("expected writes paused at most %s, got %vms", maxPause, ms)
+	}
+	if s.pool.DataPath() != target || status["target"] != target {
+		t.Errorf("expected the server on %s, got %s", target, s.pool.DataPath())
+	}
+	t.Logf("%d passes, switchover %vms, %d writes refused during it", int(status["passes"].(float64)), status["switchoverMs"], paused)
+
+	// Every accepted write is in the new directory after a shutdown.
+	if err := s.pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestServer(t, func(cfg *core.Config) {
+		cfg.Storage.DataPath = target
+		cfg.Registry.Enabled = false
+	})
+	for g := 0; g < writers; g++ {
+		worker, err := s.pool.GetOrCreate(core.IndexID(fmt.Sprintf("writer-%d", g)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := neuronCount(worker); n != accepted[g] {
+			t.Errorf("writer-%d: %d writes accepted, %d neurons after the restart", g, accepted[g], n)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/daemon"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// TestNeuronConcurrency_ReadsDuringMutation encodes search, read, recall
+// and graph responses while operations rewrite the same neurons' metadata,
+// tags, content and positions, as the daemons and imports do, and persists,
+// copies and exports the index meanwhile. The decay, consolidate, reorg and
+// persist daemon passes run throughout. Run it with -race: it checks the
+// copy-on-write contract on core.Neuron and the matrix locking of the
+// daemon paths.
+func TestNeuronConcurrency_ReadsDuringMutation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	s := newTestServer(t, nil)
+	const indexID = "stress"
+	idx := map[string]string{"X-Index-ID": indexID}
+	worker, err := s.pool.GetOrCreate(indexID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []core.NeuronID
+	for i := 0; i < 40; i++ {
+		result, err := worker.Submit(&concurrency.Operation{
+			Type: concurrency.OpWrite,
+			Payload: concurrency.AddNeuronRequest{
+				Content:  fmt.Sprintf("user: the deploy pipeline for service %d needs a longer timeout", i),
+				Metadata: map[string]string{"topic": "deploys", "n": fmt.Sprint(i)},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, result.(*core.Neuron).ID)
+	}
+
+	duration := 3 * time.Second
+	deadline := time.Now().Add(duration)
+	var wg sync.WaitGroup
+	var reads, mutations atomic.Int64
+	loop := func(fn func(r *rand.Rand)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(time.Now().UnixNano()))
+			for time.Now().Before(deadline) {
+				fn(r)
+			}
+		}()
+	}
+	// Every search and read queues an activation, and every mutation
+	// grows the work of the next daemon pass, so both are paced to keep
+	// the worker from falling behind the deadline.
+	read := func(path string) *httptest.ResponseRecorder {
+		rr := doRequest(t, s, "GET", path, "", idx)
+		reads.Add(1)
+		time.Sleep(2 * time.Millisecond)
+		return rr
+	}
+	submit := func(op *concurrency.Operation) {
+		worker.Submit(op)
+		mutations.Add(1)
+		time.Sleep(5 * time.Millisecond)
+	}
+	pick := func(r *rand.Rand) core.NeuronID { return ids[r.Intn(len(ids))] }
+
+	for _, path := range []string{"/v1/search?q=deploy+pipeline&limit=50", "/v1/recall?limit=50", "/v1/graph"} {
+		path := path
+		loop(func(r *rand.Rand) {
+			if rr := read(path); rr.Code != http.StatusOK {
+				t.Errorf("GET %s: %d %s", path, rr.Code, rr.Body.String())
+			}
+		})
+	}
+	loop(func(r *rand.Rand) { read("/v1/read/" + string(pick(r))) })
+
+	// Persistence, verification and exports encode the whole matrix while
+	// the daemons below rewrite its neurons.
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	loop(func(r *rand.Rand) {
+		if r.Intn(2) == 0 {
+			if err := s.pool.PersistAsync(indexID, worker); err != nil {
+				t.Errorf("persist async: %v", err)
+			}
+		} else if _, err := s.pool.PersistIndex(indexID); err != nil {
+			t.Errorf("persist: %v", err)
+		}
+		if _, err := s.pool.Verify(indexID, 5); err != nil {
+			t.Errorf("verify: %v", err)
+		}
+		if rr := doRequest(t, s, "GET", "/admin/indexes/"+indexID+"/export?format=nrdb", "", admin); rr.Code != http.StatusOK {
+			t.Errorf("nrdb export: %d %s", rr.Code, rr.Body.String())
+		}
+		time.Sleep(20 * time.Millisecond)
+	})
+
+	loop(func(r *rand.Rand) { // supersede: metadata on the old neuron
+		old := pick(r)
+		submit(&concurrency.Operation{Type: concurrency.OpWrite, Payload: concurrency.AddNeuronRequest{
+			Content:    fmt.Sprintf("the deploy pipeline timeout is now %d minutes", r.Intn(60)),
+			Supersedes: &old,
+		}})
+	})
+	loop(func(r *rand.Rand) { // conflict groups
+		submit(&concurrency.Operation{Type: concurrency.OpDetectConflicts, Payload: concurrency.DetectConflictsRequest{
+			ID:      pick(r),
+			Options: engine.ConflictOptions{TopK: 5, LexicalThreshold: 0.1, Keys: []string{"topic"}},
+		}})
+	})
+	loop(func(r *rand.Rand) { // content compaction
+		submit(&concurrency.Operation{Type: concurrency.OpCompactContent, Payload: concurrency.CompactContentRequest{
+			Config: core.CompactContentConfig{Enabled: true, MaxEnergy: 2, KeepChars: 20},
+			Now:    time.Now().Add(time.Hour),
+		}})
+	})
+	loop(func(r *rand.Rand) { // imports: metadata and tags
+		submit(&concurrency.Operation{Type: concurrency.OpImportNotes, Payload: concurrency.ImportNotesRequest{
+			Notes: []engine.ImportedNote{{
+				Key:      "note",
+				Hash:     fmt.Sprint(r.Int()),
+				Content:  "an imported deploy pipeline note",
+				Metadata: map[string]string{"rev": fmt.Sprint(r.Int())},
+				Tags:     []string{"imported", fmt.Sprint(r.Intn(3))},
+			}},
+		}})
+	})
+	loop(func(r *rand.Rand) { // turn migration
+		submit(&concurrency.Operation{Type: concurrency.OpMigrateTurns, Payload: concurrency.MigrateTurnsRequest{
+			Pattern: regexp.MustCompile(core.DefaultTurnPattern),
+		}})
+	})
+	loop(func(r *rand.Rand) { // content updates
+		submit(&concurrency.Operation{Type: concurrency.OpTouch, Payload: concurrency.UpdateNeuronRequest{
+			ID:      pick(r),
+			Content: fmt.Sprintf("user: the deploy pipeline was changed %d times", r.Intn(100)),
+		}})
+	})
+	loop(func(r *rand.Rand) { // firing, learning and positions
+		submit(&concurrency.Operation{Type: concurrency.OpActivate, Payload: concurrency.ActivateRequest{
+			IDs:    []core.NeuronID{pick(r), pick(r), pick(r)},
+			CoFire: r.Intn(2) == 0,
+		}})
+		submit(&concurrency.Operation{Type: concurrency.OpPin, Payload: concurrency.PinRequest{ID: pick(r), Pinned: r.Intn(2) == 0}})
+	})
+	loop(func(r *rand.Rand) { // daemon operations
+		for _, typ := range []concurrency.OpType{concurrency.OpDecay, concurrency.OpConsolidate, concurrency.OpReorg} {
+			submit(&concurrency.Operation{Type: typ, Priority: concurrency.PriorityBackground})
+		}
+	})
+
+	// The daemon passes themselves, as the manager schedules them, with
+	// the index alternately asleep so consolidate and reorg reach it.
+	store, err := persistence.NewStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := daemon.NewDaemonManager(s.pool, s.lifecycle, store)
+	loop(func(r *rand.Rand) {
+		if r.Intn(2) == 0 {
+			s.lifecycle.ForceSleep(indexID)
+		} else {
+			s.lifecycle.ForceWake(indexID)
+		}
+		for _, name := range []string{"decay", "consolidate", "reorg", "persist"} {
+			if err := dm.RunPass(name); err != nil {
+				t.Errorf("%s pass: %v", name, err)
+			}
+		}
+		mutations.Add(1)
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	wg.Wait()
+	if reads.Load() == 0 || mutations.Load() == 0 {
+		t.Fatalf("expected reads and mutations to interleave, got %d reads, %d mutations", reads.Load(), mutations.Load())
+	}
+	t.Logf("%d reads against %d mutations in %v", reads.Load(), mutations.Load(), duration)
+}
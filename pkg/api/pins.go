@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// handlePin pins (POST /v1/pin/{id}) or unpins (DELETE /v1/pin/{id}) a
+// neuron and returns it. Pinning past pins.maxPerIndex fails with 409
+// PIN_LIMIT.
+func (s *Server) handlePin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" && r.Method != "DELETE" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/v1/pin/")
+	if id == "" {
+		apierr.NeuronIDRequired(w)
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type:    concurrency.OpPin,
+		Payload: concurrency.PinRequest{ID: core.NeuronID(id), Pinned: r.Method == "POST"},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(s.neuronDocument(r.Context(), result.(*core.Neuron)))
+}
+
+// handlePins lists the pinned neurons of an index, oldest first
+// (GET /v1/pins).
+func (s *Server) handlePins(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpListPins})
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+
+	neurons := result.([]*core.Neuron)
+	items := make([]map[string]any, len(neurons))
+	for i, n := range neurons {
+		items[i] = s.neuronDocument(r.Context(), n)
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"pins":  items,
+		"count": len(items),
+		"limit": s.config.Pins.MaxPerIndex,
+	})
+}
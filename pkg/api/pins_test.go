@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func pinRequest(t *testing.T, s *Server, method, path, body string, want int) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, method, path, body, map[string]string{
+		"X-Index-ID":   "notes",
+		"Content-Type": "application/json",
+	})
+	if rr.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d: %s", method, path, want, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func TestPins_PinUnpinAndLimit(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Pins.MaxPerIndex = 2
+	})
+
+	first := pinRequest(t, s, "POST", "/v1/write", `{"content":"Prod database password rotates monthly","pinned":true}`, http.StatusOK)
+	if first["pinned"] != true {
+		t.Fatalf("write with pinned:true should pin, got %v", first)
+	}
+	second := pinRequest(t, s, "POST", "/v1/write", `{"content":"On-call escalates to the platform team"}`, http.StatusOK)
+	third := pinRequest(t, s, "POST", "/v1/write", `{"content":"Release freeze starts on Fridays"}`, http.StatusOK)
+	if second["pinned"] != false {
+		t.Fatalf("neurons are unpinned by default, got %v", second)
+	}
+
+	if doc := pinRequest(t, s, "POST", "/v1/pin/"+second["id"].(string), "", http.StatusOK); doc["pinned"] != true {
+		t.Errorf("pin should return the pinned neuron, got %v", doc)
+	}
+	m := pinRequest(t, s, "POST", "/v1/pin/"+third["id"].(string), "", http.StatusConflict)
+	if m["code"] != "PIN_LIMIT" {
+		t.Errorf("unexpected error: %v", m)
+	}
+	pinRequest(t, s, "POST", "/v1/write", `{"content":"A fourth memory","pinned":true}`, http.StatusConflict)
+	pinRequest(t, s, "POST", "/v1/pin/no-such-neuron", "", http.StatusNotFound)
+
+	list := pinRequest(t, s, "GET", "/v1/pins", "", http.StatusOK)
+	if list["count"] != float64(2) || list["limit"] != float64(2) {
+		t.Errorf("expected 2 of 2 pins, got %v", list)
+	}
+
+	if doc := pinRequest(t, s, "DELETE", "/v1/pin/"+first["id"].(string), "", http.StatusOK); doc["pinned"] != false {
+		t.Errorf("unpin should return the unpinned neuron, got %v", doc)
+	}
+	pinRequest(t, s, "POST", "/v1/pin/"+third["id"].(string), "", http.StatusOK)
+
+	stats := pinRequest(t, s, "GET", "/v1/brain/stats", "", http.StatusOK)
+	if stats["pinned_count"] != float64(2) {
+		t.Errorf("stats should count pinned neurons, got %v", stats["pinned_count"])
+	}
+}
+
+func TestPins_SurviveDecayAndPrune(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Pins.EnergyFloor = 0.3
+	})
+
+	pinned := pinRequest(t, s, "POST", "/v1/write", `{"content":"Customer SLA is 99.9 percent","pinned":true}`, http.StatusOK)
+	unpinned := pinRequest(t, s, "POST", "/v1/write", `{"content":"Customer SLA is 99.5 percent"}`, http.StatusOK)
+
+	worker := mustWorker(t, s, "notes")
+	for _, id := range []string{pinned["id"].(string), unpinned["id"].(string)} {
+		n := worker.Matrix().Neurons[core.NeuronID(id)]
+		n.Lock()
+		n.BaseEnergy = 0
+		n.LastDecayAt = time.Now().Add(-1000 * time.Hour)
+		n.Unlock()
+	}
+	for _, op := range []concurrency.OpType{concurrency.OpDecay, concurrency.OpPrune} {
+		if _, err := worker.Submit(&concurrency.Operation{Type: op, Priority: concurrency.PriorityBackground}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc := pinRequest(t, s, "GET", "/v1/read/"+pinned["id"].(string), "", http.StatusOK)
+	if doc["pinned"] != true || doc["energy"].(float64) < 0.3 {
+		t.Errorf("pinned neuron should survive at or above the floor, got %v", doc)
+	}
+	pinRequest(t, s, "GET", "/v1/read/"+unpinned["id"].(string), "", http.StatusNotFound)
+}
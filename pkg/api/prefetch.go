@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Reasons a prefetch is rejected before it reaches the pool.
+const (
+	prefetchReasonInvalid       = "invalid"
+	prefetchReasonNotRegistered = "not_registered"
+)
+
+// handlePrefetch starts loading the listed indexes in the background
+// (POST /v1/prefetch) and reports per index whether it was already loaded,
+// is loading, was queued or was rejected. Prefetching is a hint: it does
+// not count as activity and never evicts a loaded index.
+func (s *Server) handlePrefetch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	var req struct {
+		Indexes []string `json:"indexes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.InvalidJSON(w)
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Indexes))
+	indexes := make([]string, 0, len(req.Indexes))
+	for _, id := range req.Indexes {
+		id = strings.TrimSpace(id)
+		if !seen[id] {
+			seen[id] = true
+			indexes = append(indexes, id)
+		}
+	}
+	if len(indexes) == 0 {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "indexes must list at least one index")
+		return
+	}
+	if max := s.config.Prefetch.MaxIndexes; len(indexes) > max {
+		apierr.BadRequest(w, apierr.CodeBadRequest, fmt.Sprintf("at most %d indexes may be prefetched per request", max))
+		return
+	}
+
+	if !s.prefetchLimiter.allow(clientKey(r)) {
+		w.Header().Set("Retry-After", strconv.Itoa(s.prefetchLimiter.retryAfterSeconds()))
+		apierr.TooManyRequests(w, "prefetch rate limit exceeded")
+		return
+	}
+
+	results := make([]concurrency.PrefetchResult, len(indexes))
+	for i, id := range indexes {
+		results[i] = s.prefetch(core.IndexID(id))
+	}
+	json.NewEncoder(w).Encode(map[string]any{"results": results})
+}
+
+// prefetch applies the index guards to one hint and hands it to the pool.
+func (s *Server) prefetch(indexID core.IndexID) concurrency.PrefetchResult {
+	rejected := func(reason string) concurrency.PrefetchResult {
+		return concurrency.PrefetchResult{IndexID: indexID, Status: concurrency.PrefetchRejected, Reason: reason}
+	}
+	if indexID == "" {
+		return rejected(prefetchReasonInvalid)
+	}
+	if s.config.Registry.Enabled && !s.registry.Exists(string(indexID)) {
+		return rejected(prefetchReasonNotRegistered)
+	}
+
+	result := s.pool.Prefetch(indexID)
+	if result.Status == concurrency.PrefetchQueued {
+		s.lifecycle.Hold(indexID, s.config.Prefetch.Grace)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// coldIndex writes a memory to indexID and evicts it, leaving it on disk
+// only.
+func coldIndex(t *testing.T, s *Server, indexID string) {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"prefetch me later"}`, map[string]string{
+		"X-Index-ID":   indexID,
+		"Content-Type": "application/json",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if err := s.pool.Evict(core.IndexID(indexID)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// prefetchStatuses posts a prefetch hint and returns status and reason by
+// index.
+func prefetchStatuses(t *testing.T, s *Server, body string) map[string][2]string {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/prefetch", body, map[string]string{"Content-Type": "application/json"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("prefetch: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	out := make(map[string][2]string)
+	for _, item := range decodeJSON(t, rr)["results"].([]any) {
+		r := item.(map[string]any)
+		reason, _ := r["reason"].(string)
+		out[r["index"].(string)] = [2]string{r["status"].(string), reason}
+	}
+	return out
+}
+
+func TestPrefetch_LoadsAsynchronously(t *testing.T) {
+	s := newTestServer(t, nil)
+	coldIndex(t, s, "user-42")
+	before := s.lifecycle.GetBrainState("user-42").InvokeCount
+
+	got := prefetchStatuses(t, s, `{"indexes":["user-42","user-42"," ","user-missing"]}`)
+	if len(got) != 3 || got["user-42"][0] != "queued" || got[""] != [2]string{"rejected", "invalid"} ||
+		got["user-missing"] != [2]string{"rejected", "not_found"} {
+		t.Fatalf("unexpected statuses: %v", got)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := s.pool.Get("user-42"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the prefetched index was never loaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := prefetchStatuses(t, s, `{"indexes":["user-42"]}`); got["user-42"][0] != "already_loaded" {
+		t.Errorf("expected already_loaded, got %v", got)
+	}
+	if s.lifecycle.GetBrainState("user-42").InvokeCount != before {
+		t.Error("a prefetch should not count as lifecycle activity")
+	}
+	if _, err := s.pool.Get("user-missing"); err == nil {
+		t.Error("prefetching an unknown index should not create it")
+	}
+}
+
+func TestPrefetch_GracePreventsDormancy(t *testing.T) {
+	s := newTestServer(t, nil)
+	coldIndex(t, s, "user-42")
+	s.lifecycle.SetThresholds(time.Millisecond, time.Millisecond, time.Millisecond)
+	s.lifecycle.ForceSleep("user-42")
+	time.Sleep(5 * time.Millisecond)
+
+	prefetchStatuses(t, s, `{"indexes":["user-42"]}`)
+	if s.lifecycle.CheckAndTransition("user-42") || s.lifecycle.GetState("user-42") != core.StateSleeping {
+		t.Errorf("a prefetched index should not go dormant during its grace period, got %v", s.lifecycle.GetState("user-42"))
+	}
+}
+
+func TestPrefetch_BudgetExceededIsSkipped(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Prefetch.MaxLoadedIndexes = 1
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	coldIndex(t, s, "user-42")
+	if _, err := s.pool.GetOrCreate("hot"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := prefetchStatuses(t, s, `{"indexes":["user-42"]}`)
+	if got["user-42"] != [2]string{"rejected", "budget"} {
+		t.Fatalf("expected a budget rejection, got %v", got)
+	}
+	if _, err := s.pool.Get("hot"); err != nil {
+		t.Error("the hot index should not be evicted to make room")
+	}
+
+	prefetch := adminRequest(t, s, "GET", "/admin/stats")["prefetch"].(map[string]any)
+	if prefetch["rejected"] != float64(1) || prefetch["requested"] != float64(1) || prefetch["max_loaded"] != float64(1) {
+		t.Errorf("unexpected prefetch stats: %v", prefetch)
+	}
+}
+
+func TestPrefetch_Limits(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Prefetch.MaxIndexes = 2
+		cfg.Prefetch.RateLimitRequests = 1
+	})
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	if rr := doRequest(t, s, "POST", "/v1/prefetch", `{"indexes":["a","b","c"]}`, headers); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for too many indexes, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/prefetch", `{"indexes":["a","a","b"]}`, headers); rr.Code != http.StatusOK {
+		t.Errorf("duplicates should not count toward the limit, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr := doRequest(t, s, "POST", "/v1/prefetch", `{"indexes":["a"]}`, headers)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d", rr.Code)
+	}
+
+	s = newTestServer(t, func(cfg *core.Config) {
+		cfg.Prefetch.Enabled = false
+	})
+	if rr := doRequest(t, s, "POST", "/v1/prefetch", `{"indexes":["a"]}`, headers); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with prefetch disabled, got %d", rr.Code)
+	}
+}
+
+func TestPrefetch_RegistryGuard(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = true
+	})
+	if got := prefetchStatuses(t, s, `{"indexes":["user-42"]}`); got["user-42"] != [2]string{"rejected", "not_registered"} {
+		t.Errorf("expected a registry rejection, got %v", got)
+	}
+}
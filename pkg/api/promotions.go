@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+const defaultPromotionsLimit = 50
+
+// handlePromotions lists what consolidation promoted in an index, newest
+// first, each record with the factors it rested on and the neuron it
+// deepened (GET /v1/promotions). since (RFC 3339) keeps records made at or
+// after it; limit caps the list.
+func (s *Server) handlePromotions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	var since time.Time
+	if raw := q.Get("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "since must be RFC3339")
+			return
+		}
+		since = t
+	}
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type: concurrency.OpListPromotions,
+		Payload: concurrency.PromotionsRequest{
+			Since: since,
+			Limit: clampPositive(parsePositiveQueryInt(q.Get("limit")), defaultPromotionsLimit, maxSearchLimit),
+		},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	promoted := result.([]engine.PromotedNeuron)
+	items := make([]map[string]any, len(promoted))
+	for i, p := range promoted {
+		items[i] = map[string]any{
+			"promotion": p.Promotion,
+			"neuron":    s.neuronDocument(r.Context(), p.Neuron),
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"promotions": items,
+		"count":      len(items),
+	})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestPromotions_RecordedAndListed(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Daemons.Consolidate.PromotionHistory = 2
+	})
+	headers := map[string]string{"X-Index-ID": "tenant"}
+
+	write := func(body string) core.NeuronID {
+		t.Helper()
+		rr := doRequest(t, s, "POST", "/v1/write", body, headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+		return core.NeuronID(decodeJSON(t, rr)["id"].(string))
+	}
+	mature := write(`{"content":"the user prefers window seats on long flights"}`)
+	pinned := write(`{"content":"the user is allergic to peanuts","pinned":true}`)
+	young := write(`{"content":"the user mentioned a dentist appointment"}`)
+
+	worker, err := s.pool.GetOrCreate("tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := worker.Matrix()
+	m.Lock()
+	for _, id := range []core.NeuronID{mature, pinned} {
+		n := m.Neurons[id]
+		n.CreatedAt = time.Now().Add(-2 * time.Hour)
+		n.AccessCount = 12
+		n.Energy = 0.3
+	}
+	m.Unlock()
+
+	consolidate := func() {
+		t.Helper()
+		if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpConsolidate}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	consolidate()
+	time.Sleep(10 * time.Millisecond)
+	between := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	consolidate()
+
+	list := func(query string) []any {
+		t.Helper()
+		rr := doRequest(t, s, "GET", "/v1/promotions"+query, "", headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("promotions%s: %d %s", query, rr.Code, rr.Body.String())
+		}
+		return decodeJSON(t, rr)["promotions"].([]any)
+	}
+	all := list("")
+	if len(all) != 4 {
+		t.Fatalf("expected two promotions for each of two neurons, got %d", len(all))
+	}
+	for _, item := range all {
+		p := item.(map[string]any)["promotion"].(map[string]any)
+		neuron := item.(map[string]any)["neuron"].(map[string]any)
+		if core.NeuronID(neuron["id"].(string)) == young {
+			t.Errorf("a young neuron must not be promoted: %v", p)
+		}
+		if p["accessCount"].(float64) != 12 || p["energy"].(float64) != 0.3 || p["ageSeconds"].(float64) < 7200 {
+			t.Errorf("unexpected factors %v", p)
+		}
+		if p["toDepth"].(float64) != p["fromDepth"].(float64)+1 {
+			t.Errorf("a promotion deepens by one layer: %v", p)
+		}
+		if strength := p["synapseStrength"].(float64); strength < 0 || strength > 1 || (p["synapses"].(float64) == 0) != (strength == 0) {
+			t.Errorf("unexpected synapse factors %v", p)
+		}
+		want := core.PromotionMature
+		if core.NeuronID(neuron["id"].(string)) == pinned {
+			want = core.PromotionPinned
+		}
+		if p["reason"] != want {
+			t.Errorf("expected reason %s, got %v", want, p["reason"])
+		}
+	}
+
+	recent := list("?since=" + url.QueryEscape(between.Format(time.RFC3339Nano)))
+	if len(recent) != 2 {
+		t.Fatalf("expected the second pass only, got %d promotions", len(recent))
+	}
+	for _, item := range recent {
+		p := item.(map[string]any)["promotion"].(map[string]any)
+		at, _ := time.Parse(time.RFC3339Nano, p["at"].(string))
+		if at.Before(between) || p["toDepth"].(float64) != 2 {
+			t.Errorf("expected second-pass promotions to depth 2, got %v", p)
+		}
+	}
+	if future := list("?since=" + url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))); len(future) != 0 {
+		t.Errorf("expected nothing after the last pass, got %d", len(future))
+	}
+	if rr := doRequest(t, s, "GET", "/v1/promotions?since=last-week", "", headers); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a malformed since to be refused, got %d", rr.Code)
+	}
+
+	// A third pass drops the oldest record past promotionHistory.
+	consolidate()
+	doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/read/"+string(mature), "", headers))
+	history := doc["promotions"].([]any)
+	if len(history) != 2 || history[0].(map[string]any)["toDepth"].(float64) != 2 || history[1].(map[string]any)["toDepth"].(float64) != 3 {
+		t.Errorf("expected the last two promotions, oldest first, got %v", history)
+	}
+	if doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/read/"+string(young), "", headers)); doc["promotions"] != nil {
+		t.Errorf("an unpromoted neuron carries no promotions, got %v", doc["promotions"])
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// resolveQuota applies the registry "quota" override of indexID to the
+// server's storage.indexQuotaBytes and storage.indexQuotaPolicy. An
+// override without a policy keeps the server's.
+func (s *Server) resolveQuota(indexID core.IndexID, defaults core.IndexQuota) core.IndexQuota {
+	if s.registry == nil {
+		return defaults
+	}
+	quota, ok := s.registry.IndexQuota(string(indexID))
+	if !ok {
+		return defaults
+	}
+	if quota.Policy == "" {
+		quota.Policy = defaults.Policy
+	}
+	return quota
+}
+
+// diskReport describes indexID's disk usage, as of its last flush, and
+// its quota when it has one.
+func (s *Server) diskReport(indexID core.IndexID) map[string]any {
+	usage := s.pool.DiskUsage(indexID)
+	report := map[string]any{
+		"usageBytes":      usage.Bytes(),
+		"dataBytes":       usage.DataBytes,
+		"walBytes":        usage.WALBytes,
+		"attachmentBytes": usage.AttachmentBytes,
+	}
+	if quota := s.pool.IndexQuota(indexID); quota.Bytes > 0 {
+		report["quotaBytes"] = quota.Bytes
+		report["quotaPolicy"] = quota.Policy
+	}
+	return report
+}
+
+// writeQuotaError answers a write refused by the index's disk quota with
+// 507 STORAGE_QUOTA_EXCEEDED.
+func writeQuotaError(w http.ResponseWriter, err error) {
+	var qe *core.QuotaError
+	if !errors.As(err, &qe) {
+		apierr.StorageQuotaExceeded(w, err.Error(), 0, 0)
+		return
+	}
+	apierr.StorageQuotaExceeded(w, err.Error(), qe.Usage, qe.Quota)
+}
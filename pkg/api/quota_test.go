@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// quotaNote is a write body of a few hundred bytes, with words of its own
+// so writes are not merged.
+func quotaNote(i int, pinned bool) string {
+	words := make([]string, 24)
+	for j := range words {
+		words[j] = fmt.Sprintf("w%dx%d", i, j)
+	}
+	return fmt.Sprintf(`{"content":"note %d: %s","pinned":%v}`, i, strings.Join(words, " "), pinned)
+}
+
+func TestQuota_RejectsWritesPastQuota(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Storage.IndexQuotaBytes = 8000
+		cfg.Storage.IndexQuotaGrace = 1
+	})
+	headers := map[string]string{"X-Index-ID": "tenant"}
+
+	written := 0
+	var refused map[string]any
+	for i := 0; i < 50 && refused == nil; i++ {
+		rr := doRequest(t, s, "POST", "/v1/write", quotaNote(i, false), headers)
+		switch rr.Code {
+		case http.StatusOK:
+			written++
+		case http.StatusInsufficientStorage:
+			refused = decodeJSON(t, rr)
+		default:
+			t.Fatalf("write %d: %d %s", i, rr.Code, rr.Body.String())
+		}
+	}
+	if refused == nil || written == 0 {
+		t.Fatalf("expected some writes then a refusal, got %d writes", written)
+	}
+	if refused["code"] != "STORAGE_QUOTA_EXCEEDED" || refused["quotaBytes"].(float64) != 8000 ||
+		refused["usageBytes"].(float64) <= 0 || refused["usageBytes"].(float64) > 8000 {
+		t.Errorf("unexpected refusal %v", refused)
+	}
+
+	stats := decodeJSON(t, doRequest(t, s, "GET", "/v1/stats", "", headers))
+	disk := stats["index"].(map[string]any)["disk"].(map[string]any)
+	if disk["quotaBytes"].(float64) != 8000 || disk["quotaPolicy"] != core.QuotaReject {
+		t.Errorf("unexpected disk stats %v", disk)
+	}
+	if n := stats["index"].(map[string]any)["neuron_count"].(float64); int(n) != written {
+		t.Errorf("the refused write must not land: %v neurons, %d written", n, written)
+	}
+
+	// Flushed, the usage is the data file's.
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	if rr := doRequest(t, s, "POST", "/admin/persist", "", admin); rr.Code != http.StatusOK {
+		t.Fatalf("persist: %d %s", rr.Code, rr.Body.String())
+	}
+	detail := decodeJSON(t, doRequest(t, s, "GET", "/admin/indexes/tenant", "", admin))
+	if disk := detail["disk"].(map[string]any); disk["dataBytes"].(float64) <= 0 || disk["usageBytes"].(float64) > 8000 {
+		t.Errorf("unexpected disk usage after flush %v", disk)
+	}
+	listing := doRequest(t, s, "GET", "/admin/indexes?sort=id", "", admin)
+	if !strings.Contains(listing.Body.String(), `"quotaBytes":8000`) || !strings.Contains(listing.Body.String(), `"diskBytes"`) {
+		t.Errorf("expected the listing to carry disk usage and quota: %s", listing.Body.String())
+	}
+}
+
+func TestQuota_EvictLowestEnergy(t *testing.T) {
+	s := newTestServer(t, nil)
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"lru","metadata":{"quota":{"bytes":8000,"policy":"evict_oldest"}}}`, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected an invalid quota to be refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"lru","metadata":{"quota":{"bytes":8000,"policy":"evict_lowest_energy"}}}`, nil); rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+		t.Fatalf("register: %d %s", rr.Code, rr.Body.String())
+	}
+	headers := map[string]string{"X-Index-ID": "lru"}
+
+	keep := decodeJSON(t, doRequest(t, s, "POST", "/v1/write", quotaNote(0, true), headers))["id"].(string)
+	for i := 1; i < 40; i++ {
+		if rr := doRequest(t, s, "POST", "/v1/write", quotaNote(i, false), headers); rr.Code != http.StatusOK {
+			t.Fatalf("write %d: expected eviction to make room, got %d %s", i, rr.Code, rr.Body.String())
+		}
+	}
+
+	stats := decodeJSON(t, doRequest(t, s, "GET", "/v1/stats", "", headers))["index"].(map[string]any)
+	if n := stats["neuron_count"].(float64); n >= 40 || n < 2 {
+		t.Errorf("expected eviction to bound the index, got %v neurons", n)
+	}
+	if rr := doRequest(t, s, "GET", "/v1/read/"+keep, "", headers); rr.Code != http.StatusOK {
+		t.Errorf("the pinned neuron must survive eviction, got %d", rr.Code)
+	}
+	if disk := stats["disk"].(map[string]any); disk["quotaPolicy"] != core.QuotaEvictLowestEnergy {
+		t.Errorf("expected the registry policy, got %v", disk)
+	}
+}
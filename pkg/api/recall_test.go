@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+)
+
+func TestRecall_Pagination(t *testing.T) {
+	s := newTestServer(t, nil)
+	idx := map[string]string{"X-Index-ID": "paged"}
+	worker, err := s.pool.GetOrCreate("paged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const written = 130
+	for i := 0; i < written; i++ {
+		if _, err := worker.Submit(&concurrency.Operation{
+			Type:    concurrency.OpWrite,
+			Payload: concurrency.AddNeuronRequest{Content: fmt.Sprintf("memory number %d about the billing service", i)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[string]bool{}
+	var last string
+	for offset := 0; ; offset += 50 {
+		rr := doRequest(t, s, "GET", fmt.Sprintf("/v1/recall?order=asc&limit=50&offset=%d", offset), "", idx)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("recall failed: %d %s", rr.Code, rr.Body.String())
+		}
+		body := decodeJSON(t, rr)
+		if body["total"] != float64(written) || body["offset"] != float64(offset) {
+			t.Fatalf("unexpected page fields: total %v, offset %v", body["total"], body["offset"])
+		}
+		for _, m := range body["memories"].([]any) {
+			doc := m.(map[string]any)
+			id, created := doc["_id"].(string), doc["createdAt"].(string)
+			if seen[id] {
+				t.Fatalf("neuron %s returned on two pages", id)
+			}
+			if created < last {
+				t.Fatalf("page at offset %d is out of creation order", offset)
+			}
+			seen[id], last = true, created
+		}
+		if body["hasMore"] != (offset+50 < written) {
+			t.Fatalf("offset %d: unexpected hasMore %v", offset, body["hasMore"])
+		}
+		if body["hasMore"] == false {
+			break
+		}
+	}
+	if len(seen) != written {
+		t.Fatalf("expected to page through %d neurons, saw %d", written, len(seen))
+	}
+
+	// Limits are clamped, and the default page stays at 100.
+	if body := decodeJSON(t, doRequest(t, s, "GET", "/v1/recall", "", idx)); body["count"] != float64(defaultRecallLimit) || body["hasMore"] != true {
+		t.Errorf("expected a default page of %d, got %v (hasMore %v)", defaultRecallLimit, body["count"], body["hasMore"])
+	}
+	if body := decodeJSON(t, doRequest(t, s, "GET", "/v1/recall?limit=100000", "", idx)); body["count"] != float64(written) {
+		t.Errorf("expected an oversized limit to be clamped, got %v", body["count"])
+	}
+
+	for _, q := range []string{"order=sideways", "offset=-1", "offset=x"} {
+		if rr := doRequest(t, s, "GET", "/v1/recall?"+q, "", idx); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// handleRecycle lists the forgotten neurons of an index that can still be
+// restored, most recently forgotten first (GET /v1/recycle), with when and
+// why each was forgotten and when the prune daemon removes it for good.
+func (s *Server) handleRecycle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpListRecycled})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	window := s.config.Storage.NeuronRecycleWindow
+	recycled := result.([]core.RecycledNeuron)
+	items := make([]map[string]any, len(recycled))
+	for i, rn := range recycled {
+		doc := s.neuronDocument(r.Context(), rn.Neuron)
+		doc["deletedAt"] = rn.DeletedAt
+		doc["reason"] = rn.Reason
+		doc["purgeAt"] = rn.DeletedAt.Add(window)
+		doc["synapses"] = len(rn.Synapses)
+		items[i] = doc
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"recycled": items,
+		"count":    len(items),
+		"window":   window.String(),
+	})
+}
+
+// handleRecycled restores a forgotten neuron with the synapses whose other
+// end still exists (POST /v1/recycle/{id}/restore), or removes it for good
+// without waiting for its window to end (DELETE /v1/recycle/{id}).
+func (s *Server) handleRecycled(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/v1/recycle/")
+	id, restore := strings.CutSuffix(rest, "/restore")
+	if (restore && r.Method != "POST") || (!restore && r.Method != "DELETE") {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	if id == "" || strings.Contains(id, "/") {
+		apierr.NeuronIDRequired(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	op := &concurrency.Operation{Type: concurrency.OpPurge, Payload: concurrency.PurgeRequest{ID: core.NeuronID(id)}}
+	if restore {
+		op = &concurrency.Operation{Type: concurrency.OpRestore, Payload: core.NeuronID(id)}
+	}
+	result, err := worker.SubmitContext(r.Context(), op)
+	switch {
+	case errors.Is(err, core.ErrNeuronNotFound):
+		apierr.NotFound(w, apierr.CodeNeuronNotFound, "neuron "+id+" is not in the recycle bin")
+		return
+	case errors.Is(err, core.ErrMatrixFull), errors.Is(err, core.ErrDuplicateNeuron):
+		apierr.Conflict(w, apierr.CodeConflict, err.Error())
+		return
+	case err != nil:
+		s.writeOperationError(w, err)
+		return
+	}
+
+	if !restore {
+		json.NewEncoder(w).Encode(map[string]any{"id": id, "purged": true})
+		return
+	}
+	restored := result.(engine.RestoreResult)
+	doc := s.neuronDocument(r.Context(), restored.Neuron)
+	doc["reattachedSynapses"] = restored.Reattached
+	doc["droppedSynapses"] = restored.Dropped
+	json.NewEncoder(w).Encode(doc)
+}
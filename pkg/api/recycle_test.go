@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// linked reports whether a synapse joins a and b in m, either way.
+func linked(m *core.Matrix, a, b core.NeuronID) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ab := m.Synapses[core.NewSynapseID(a, b)]
+	_, ba := m.Synapses[core.NewSynapseID(b, a)]
+	return ab || ba
+}
+
+// searchHas reports whether a search of the index for q returns id.
+func searchHas(t *testing.T, s *Server, headers map[string]string, q string, id core.NeuronID) bool {
+	t.Helper()
+	rr := doRequest(t, s, "GET", "/v1/search?q="+q, "", headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("search: %d %s", rr.Code, rr.Body.String())
+	}
+	results, _ := decodeJSON(t, rr)["results"].([]any)
+	for _, r := range results {
+		if core.NeuronID(r.(map[string]any)["id"].(string)) == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRecycle_ForgetAndRestore(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "agent"}
+	forgotten := writeTo(t, s, "agent", "the launch moved to thursday")
+	kept := writeTo(t, s, "agent", "standup notes for the week")
+
+	worker, err := s.pool.GetOrCreate("agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Writes close together are usually linked already.
+	m := worker.Matrix()
+	if !linked(m, forgotten, kept) {
+		m.Lock()
+		syn := core.NewSynapse(forgotten, kept, 0.6)
+		m.Synapses[syn.ID] = syn
+		m.Adjacency[forgotten] = append(m.Adjacency[forgotten], kept)
+		m.Adjacency[kept] = append(m.Adjacency[kept], forgotten)
+		m.Unlock()
+	}
+
+	if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpForget, Payload: forgotten}); err != nil {
+		t.Fatal(err)
+	}
+	if searchHas(t, s, headers, "launch", forgotten) {
+		t.Error("a forgotten neuron should not be found")
+	}
+	if rr := doRequest(t, s, "GET", "/v1/read/"+string(forgotten), "", headers); rr.Code != http.StatusNotFound {
+		t.Errorf("a forgotten neuron should not be readable, got %d", rr.Code)
+	}
+
+	doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/recycle", "", headers))
+	items, _ := doc["recycled"].([]any)
+	if doc["count"] != float64(1) || doc["window"] != "72h0m0s" || len(items) != 1 {
+		t.Fatalf("expected one recycled neuron, got %v", doc)
+	}
+	item := items[0].(map[string]any)
+	if item["id"] != string(forgotten) || item["reason"] != "forget" || item["synapses"] != float64(1) || item["deletedAt"] == nil || item["purgeAt"] == nil {
+		t.Errorf("unexpected recycle entry %v", item)
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/recycle/"+string(forgotten)+"/restore", "", headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", rr.Code, rr.Body.String())
+	}
+	if doc := decodeJSON(t, rr); doc["id"] != string(forgotten) || doc["reattachedSynapses"] != float64(1) {
+		t.Errorf("expected the neuron restored with its synapse, got %v", doc)
+	}
+	if !searchHas(t, s, headers, "launch", forgotten) {
+		t.Error("a restored neuron should be found again")
+	}
+	if !linked(m, forgotten, kept) {
+		t.Error("the restored neuron should keep its association")
+	}
+	if doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/recycle", "", headers)); doc["count"] != float64(0) {
+		t.Errorf("the recycle bin should be empty after the restore, got %v", doc)
+	}
+
+	if rr := doRequest(t, s, "POST", "/v1/recycle/"+string(forgotten)+"/restore", "", headers); rr.Code != http.StatusNotFound {
+		t.Errorf("restoring a live neuron: expected 404, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "DELETE", "/v1/recycle/"+string(kept), "", headers); rr.Code != http.StatusNotFound {
+		t.Errorf("purging a live neuron: expected 404, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "GET", "/v1/recycle/"+string(forgotten)+"/restore", "", headers); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rr.Code)
+	}
+}
+
+func TestRecycle_PrunedAfterWindow(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Storage.NeuronRecycleWindow = 50 * time.Millisecond })
+	headers := map[string]string{"X-Index-ID": "agent"}
+	id := writeTo(t, s, "agent", "a note to let go of")
+
+	worker, _ := s.pool.GetOrCreate("agent")
+	if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpForget, Payload: id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpPrune}); err != nil {
+		t.Fatal(err)
+	}
+	if doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/recycle", "", headers)); doc["count"] != float64(1) {
+		t.Fatalf("a prune within the window should keep the neuron, got %v", doc)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpPrune}); err != nil {
+		t.Fatal(err)
+	}
+	if doc := decodeJSON(t, doRequest(t, s, "GET", "/v1/recycle", "", headers)); doc["count"] != float64(0) {
+		t.Errorf("a prune after the window should remove the neuron, got %v", doc)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/recycle/"+string(id)+"/restore", "", headers); rr.Code != http.StatusNotFound {
+		t.Errorf("a pruned neuron cannot be restored, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// dataIndexes returns the IDs of every index loaded in the pool or
+// persisted on disk.
+func (s *Server) dataIndexes() map[string]bool {
+	data := make(map[string]bool)
+	for _, id := range s.pool.ListIndexes() {
+		data[id] = true
+	}
+	for _, id := range s.pool.PersistedIndexes() {
+		data[string(id)] = true
+	}
+	return data
+}
+
+// unregisteredIndexes lists, sorted, the indexes with data that the
+// registry guard would lock out.
+func (s *Server) unregisteredIndexes() []string {
+	ids := []string{}
+	for id := range s.dataIndexes() {
+		if !s.registry.Exists(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// warnGuardRejection logs when the registry guard turns away an index that
+// still has data, which usually means the guard was enabled before the
+// index was registered.
+func (s *Server) warnGuardRejection(indexID core.IndexID) {
+	if _, err := s.pool.Get(indexID); err == nil || s.pool.Persisted(indexID) {
+		log.Printf("⚠ REGISTRY GUARD rejected index %s, which has existing data: register it or POST /v1/registry/import-active", indexID)
+	}
+}
+
+// writeGuardLockout refuses to enable the registry guard while indexes
+// with data are unregistered, listing them.
+func writeGuardLockout(w http.ResponseWriter, lockedOut []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(struct {
+		apierr.Response
+		LockedOut []string `json:"lockedOut"`
+	}{
+		Response: apierr.Response{
+			Error:  "enabling registry.enabled would lock out indexes that are not registered; register them (POST /v1/registry/import-active) or retry with ?force=true",
+			Code:   apierr.CodeRegistryLockout,
+			Status: http.StatusConflict,
+		},
+		LockedOut: lockedOut,
+	})
+}
+
+// handleRegistryImportActive registers every loaded or persisted index that
+// is not registered yet (POST /v1/registry/import-active), so the registry
+// guard can be enabled without locking anyone out.
+func (s *Server) handleRegistryImportActive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	imported := []string{}
+	failed := map[string]string{}
+	for _, id := range s.unregisteredIndexes() {
+		if _, _, err := s.registry.FindOrCreate(id, nil); err != nil {
+			failed[id] = err.Error()
+			continue
+		}
+		imported = append(imported, id)
+	}
+	if len(imported) > 0 {
+		log.Printf("Registry: imported %d active indexes", len(imported))
+	}
+
+	resp := map[string]any{
+		"imported": imported,
+		"count":    len(imported),
+		"total":    s.registry.Count(),
+	}
+	if len(failed) > 0 {
+		resp["errors"] = failed
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearch_ReinforceCoFiresResults(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "agent"}
+	for _, content := range []string{"rocket launch notes alpha", "rocket launch notes beta", "rocket launch notes gamma"} {
+		writeTo(t, s, "agent", content)
+	}
+	groupStats := func() (coFires, created float64) {
+		t.Helper()
+		index := decodeJSON(t, doRequest(t, s, "GET", "/v1/stats", "", headers))["index"].(map[string]any)
+		return index["group_co_fires"].(float64), index["group_co_fire_synapses"].(float64)
+	}
+
+	if coFires, created := groupStats(); coFires != 0 || created != 0 {
+		t.Errorf("expected no group co-fires yet, got %v and %v", coFires, created)
+	}
+
+	if rr := doRequest(t, s, "GET", "/v1/search?q=rocket+launch&reinforce=true", "", headers); rr.Code != http.StatusOK {
+		t.Fatalf("reinforced search: %d %s", rr.Code, rr.Body.String())
+	}
+	rr := doRequest(t, s, "POST", "/v1/context", `{"cue":"rocket launch","reinforce":true}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("reinforced context: %d %s", rr.Code, rr.Body.String())
+	}
+	// Results fire asynchronously after the response.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		coFires, _ := groupStats()
+		if coFires == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 group co-fires, got %v", coFires)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	worker, err := s.pool.GetOrCreate("agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := worker.Matrix()
+	m.RLock()
+	synapses := len(m.Synapses)
+	m.RUnlock()
+	if synapses != 3 {
+		t.Errorf("expected the 3 results fully linked, got %d synapses", synapses)
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/replication"
+)
+
+// replicator spools the committed mutations of matching indexes and ships
+// them to the standby (replication.standby).
+type replicator struct {
+	indexes []string
+	spool   *replication.Spool
+	shipper *replication.Shipper
+}
+
+// newReplicator opens the spool under dataPath/replication, recovering
+// unshipped mutations, and starts shipping.
+func newReplicator(cfg core.StandbyConfig, dataPath string) (*replicator, error) {
+	spool, err := replication.OpenSpool(filepath.Join(dataPath, "replication"), cfg.QueueSize, cfg.Overflow)
+	if err != nil {
+		return nil, err
+	}
+	return &replicator{
+		indexes: cfg.Indexes,
+		spool:   spool,
+		shipper: replication.NewShipper(spool, replication.Config{
+			URL:           cfg.URL,
+			APIKey:        cfg.APIKey,
+			FlushInterval: cfg.FlushInterval,
+			Timeout:       cfg.Timeout,
+		}),
+	}, nil
+}
+
+func (r *replicator) matches(indexID core.IndexID) bool {
+	for _, pattern := range r.indexes {
+		if ok, _ := path.Match(pattern, string(indexID)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// observe spools m. It runs on the index's worker goroutine; a spool
+// failure is logged and never fails the write it reports.
+func (r *replicator) observe(m concurrency.Mutation) {
+	if !r.matches(m.IndexID) {
+		return
+	}
+	e := replication.Entry{Index: string(m.IndexID), NeuronID: string(m.NeuronID)}
+	switch m.Kind {
+	case concurrency.MutationWrite:
+		e.Op = replication.OpWrite
+		e.Content = m.Write.Content
+		e.Metadata = m.Write.Metadata
+		e.Pinned = m.Write.Pinned
+		if m.Write.ParentID != nil {
+			e.ParentID = string(*m.Write.ParentID)
+		}
+		if m.Write.Supersedes != nil {
+			e.Supersedes = string(*m.Write.Supersedes)
+		}
+		for _, id := range m.Write.Related {
+			e.Related = append(e.Related, string(id))
+		}
+		e.RelatedType = m.Write.RelatedType
+	case concurrency.MutationForget:
+		e.Op = replication.OpForget
+	case concurrency.MutationRestore:
+		e.Op = replication.OpRestore
+	case concurrency.MutationPurge:
+		e.Op = replication.OpPurge
+	case concurrency.MutationReset:
+		e.Op = replication.OpReset
+	default:
+		return
+	}
+	if err := r.spool.Append(e); err != nil {
+		log.Printf("⚠ replication: could not spool %s on %s: %v", e.Op, m.IndexID, err)
+	}
+}
+
+// Close stops shipping and closes the spool, releasing writes blocked on
+// a full one. Unshipped mutations are shipped after the next start.
+func (r *replicator) Close() {
+	r.shipper.Close()
+	r.spool.Close()
+}
+
+// handleAdminReplication reports standby replication: lag in entries and
+// seconds, shipping counters and the last error (GET /admin/replication).
+func (s *Server) handleAdminReplication(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	if s.replication == nil {
+		json.NewEncoder(w).Encode(map[string]any{"enabled": false})
+		return
+	}
+	stats := s.replication.shipper.Stats()
+	stats["enabled"] = true
+	stats["indexes"] = s.replication.indexes
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// newStandby starts a standby server behind a real listener. While down is
+// set it answers every request with 503, like a standby being restarted.
+func newStandby(t *testing.T) (*Server, *httptest.Server, *atomic.Bool) {
+	t.Helper()
+	standby := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	down := &atomic.Bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "restarting", http.StatusServiceUnavailable)
+			return
+		}
+		standby.httpServer.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return standby, ts, down
+}
+
+// newPrimary starts a server replicating team-* indexes to standby, with
+// its data under dataPath.
+func newPrimary(t *testing.T, standby *httptest.Server, dataPath string) *Server {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Storage.DataPath = dataPath
+		cfg.Registry.Enabled = false
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+		cfg.Replication.Standby.URL = "http://admin:secret@" + standby.Listener.Addr().String()
+		cfg.Replication.Standby.Indexes = []string{"team-*"}
+		cfg.Replication.Standby.FlushInterval = 10 * time.Millisecond
+	})
+	t.Cleanup(func() { s.Stop(context.Background()) })
+	return s
+}
+
+// waitForStandby polls /admin/replication until nothing awaits shipment.
+func waitForStandby(t *testing.T, s *Server) map[string]any {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := adminRequest(t, s, "GET", "/admin/replication")
+		if stats["lag"].(map[string]any)["entries"] == float64(0) {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the standby never caught up: %v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// standbyContents returns the sorted contents of indexID's neurons.
+func standbyContents(t *testing.T, s *Server, indexID string) []string {
+	t.Helper()
+	contents := indexContents(t, s, indexID)
+	slices.Sort(contents)
+	return contents
+}
+
+func TestReplication_StandbyConvergesAcrossOutageAndRestart(t *testing.T) {
+	standby, ts, down := newStandby(t)
+	dataPath := t.TempDir()
+	primary := newPrimary(t, ts, dataPath)
+
+	first := writeTo(t, primary, "team-a", "Deploys go out on Mondays")
+	rr := doRequest(t, primary, "POST", "/v1/write",
+		fmt.Sprintf(`{"content":"Deploys go out on Tuesdays","supersedes":%q}`, first),
+		map[string]string{"X-Index-ID": "team-a"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("superseding write failed: %d %s", rr.Code, rr.Body.String())
+	}
+	writeTo(t, primary, "team-b", "The office is on floor 3")
+	writeTo(t, primary, "scratch", "Not replicated")
+
+	stats := waitForStandby(t, primary)
+	if stats["enabled"] != true || stats["shipped"] != float64(3) || stats["rejected"] != float64(0) {
+		t.Errorf("unexpected replication stats: %v", stats)
+	}
+	if got := standbyContents(t, standby, "team-a"); !slices.Equal(got, []string{"Deploys go out on Mondays", "Deploys go out on Tuesdays"}) {
+		t.Errorf("team-a did not converge: %v", got)
+	}
+	if got := standbyContents(t, standby, "team-b"); !slices.Equal(got, []string{"The office is on floor 3"}) {
+		t.Errorf("team-b did not converge: %v", got)
+	}
+	if got := standbyContents(t, standby, "scratch"); len(got) != 0 {
+		t.Errorf("indexes outside replication.standby.indexes must not be shipped: %v", got)
+	}
+	worker, _ := standby.pool.GetOrCreate("team-a")
+	for id, n := range worker.Matrix().Neurons {
+		if n.Content != "Deploys go out on Mondays" {
+			continue
+		}
+		rr := doRequest(t, standby, "GET", "/v1/history/"+string(id), "", map[string]string{"X-Index-ID": "team-a"})
+		if history := decodeJSON(t, rr); history["count"] != float64(2) {
+			t.Errorf("the supersede link should be replayed on the standby: %v", history)
+		}
+	}
+
+	// While the standby is down, lag grows with every write and with the
+	// age of the oldest unshipped one.
+	down.Store(true)
+	start := time.Now()
+	writeTo(t, primary, "team-a", "Standups start at 9:30")
+	writeTo(t, primary, "team-a", "Retros are on Fridays")
+	time.Sleep(100 * time.Millisecond)
+	stats = adminRequest(t, primary, "GET", "/admin/replication")
+	lag := stats["lag"].(map[string]any)
+	if lag["entries"] != float64(2) {
+		t.Errorf("expected 2 entries of lag, got %v", lag)
+	}
+	if seconds := lag["seconds"].(float64); seconds < 0.1 || seconds > time.Since(start).Seconds() {
+		t.Errorf("lag seconds should be the age of the oldest unshipped write, got %v", seconds)
+	}
+	if stats["lastError"] == nil {
+		t.Errorf("the failed shipment should be reported: %v", stats)
+	}
+
+	// Restart the primary mid-shipment: the spool keeps what was not shipped.
+	if err := primary.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	primary = newPrimary(t, ts, dataPath)
+	stats = adminRequest(t, primary, "GET", "/admin/replication")
+	if lag := stats["lag"].(map[string]any); lag["entries"] != float64(2) {
+		t.Fatalf("unshipped writes should survive the restart, got %v", lag)
+	}
+	down.Store(false)
+	waitForStandby(t, primary)
+	want := []string{"Deploys go out on Mondays", "Deploys go out on Tuesdays", "Retros are on Fridays", "Standups start at 9:30"}
+	if got := standbyContents(t, standby, "team-a"); !slices.Equal(got, want) {
+		t.Errorf("team-a did not converge after the restart: %v", got)
+	}
+
+	adminRequest(t, primary, "POST", "/admin/indexes/team-a/reset")
+	waitForStandby(t, primary)
+	if got := standbyContents(t, standby, "team-a"); len(got) != 0 {
+		t.Errorf("the reset should be replayed on the standby: %v", got)
+	}
+	if got := standbyContents(t, standby, "team-b"); len(got) != 1 {
+		t.Errorf("a reset must not touch other indexes: %v", got)
+	}
+
+	if stats := adminRequest(t, standby, "GET", "/admin/replication"); stats["enabled"] != false {
+		t.Errorf("the standby has no standby of its own: %v", stats)
+	}
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// Sources of an index's effective retention policy.
+const (
+	retentionSourceIndex   = "index"   // the index's registry metadata
+	retentionSourceDefault = "default" // retention.defaultRules
+)
+
+// retentionIndexRun is what one retention run did to one index.
+type retentionIndexRun struct {
+	IndexID  string                       `json:"indexId"`
+	Source   string                       `json:"source"`
+	Scanned  int                          `json:"scanned"`
+	Affected int                          `json:"affected"`
+	Rules    []engine.RetentionRuleResult `json:"rules,omitempty"`
+	Error    string                       `json:"error,omitempty"`
+}
+
+// retentionRun is an entry of GET /admin/retention/history.
+type retentionRun struct {
+	At       time.Time           `json:"at"`
+	Duration string              `json:"duration"`
+	Affected int                 `json:"affected"`
+	Indexes  []retentionIndexRun `json:"indexes"`
+}
+
+// retentionHistory keeps the most recent retention runs.
+type retentionHistory struct {
+	mu   sync.Mutex
+	size int
+	runs []retentionRun // oldest first
+}
+
+func (h *retentionHistory) add(run retentionRun) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runs = append(h.runs, run)
+	if over := len(h.runs) - h.size; over > 0 {
+		h.runs = append([]retentionRun(nil), h.runs[over:]...)
+	}
+}
+
+// list returns the recorded runs, newest first.
+func (h *retentionHistory) list() []retentionRun {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]retentionRun, len(h.runs))
+	for i, run := range h.runs {
+		out[len(out)-1-i] = run
+	}
+	return out
+}
+
+// StartRetention registers the retention daemon with the daemon manager
+// when retention.interval is set.
+func (s *Server) StartRetention() {
+	interval := s.config.Retention.Interval
+	if s.daemons == nil || interval <= 0 {
+		return
+	}
+	s.daemons.Add("retention", func() time.Duration { return interval }, s.retentionPass)
+	log.Printf("Retention daemon started (interval=%s, %d default rules)", interval, len(s.config.Retention.DefaultRules))
+}
+
+// effectiveRetention returns the policy governing indexID: its own when
+// its registry entry has one, otherwise retention.defaultRules. An index
+// may opt out of the default with an empty policy.
+func (s *Server) effectiveRetention(indexID core.IndexID) ([]core.RetentionRule, string) {
+	if s.registry != nil {
+		if rules, ok := s.registry.RetentionRules(string(indexID)); ok {
+			return rules, retentionSourceIndex
+		}
+	}
+	return s.config.Retention.DefaultRules, retentionSourceDefault
+}
+
+// applyRetention runs rules against the index of worker.
+func (s *Server) applyRetention(worker *concurrency.BrainWorker, rules []core.RetentionRule, dryRun bool, priority concurrency.Priority) (engine.RetentionReport, error) {
+	result, err := worker.Submit(&concurrency.Operation{
+		Type:     concurrency.OpRetention,
+		Priority: priority,
+		Payload: concurrency.RetentionRequest{
+			Rules: rules,
+			Options: engine.RetentionOptions{
+				Now:               time.Now(),
+				ContentMode:       s.config.Admin.Clone.ContentMode,
+				StripMetadataKeys: s.config.Admin.Clone.StripMetadataKeys,
+				DryRun:            dryRun,
+			},
+		},
+	})
+	if err != nil {
+		return engine.RetentionReport{}, err
+	}
+	return result.(engine.RetentionReport), nil
+}
+
+// retentionPass enforces the effective policy of every loaded or persisted
+// index and records the run. Indexes without rules and append-only indexes
+// are skipped; unloaded ones are loaded without counting as activity, so
+// the idle eviction puts them away again.
+func (s *Server) retentionPass() (int, error) {
+	start := time.Now()
+	seen := make(map[core.IndexID]bool)
+	for _, id := range s.pool.ListIndexes() {
+		seen[core.IndexID(id)] = true
+	}
+	for _, id := range s.pool.PersistedIndexes() {
+		seen[id] = true
+	}
+	ids := make([]core.IndexID, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	run := retentionRun{At: start, Indexes: []retentionIndexRun{}}
+	var errs []error
+	for _, id := range ids {
+		rules, source := s.effectiveRetention(id)
+		if len(rules) == 0 || s.pool.AppendOnly(id) {
+			continue
+		}
+		entry := retentionIndexRun{IndexID: string(id), Source: source}
+		worker, err := s.pool.GetOrCreate(id)
+		if errors.Is(err, core.ErrDraining) {
+			continue // enforced by whichever instance loads it next
+		}
+		if err == nil {
+			var report engine.RetentionReport
+			report, err = s.applyRetention(worker, rules, false, concurrency.PriorityBackground)
+			entry.Scanned, entry.Affected, entry.Rules = report.Scanned, report.Affected, report.Rules
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+		}
+		if entry.Affected > 0 {
+			log.Printf("🗑 Index %s: retention affected %d neurons", id, entry.Affected)
+		}
+		run.Affected += entry.Affected
+		run.Indexes = append(run.Indexes, entry)
+	}
+	run.Duration = time.Since(start).String()
+	s.retentionRuns.add(run)
+	return run.Affected, errors.Join(errs...)
+}
+
+// checkRegistryMetadata validates registry metadata beyond what the
+// registry checks itself: configured vector models and the retention rule
+// limit.
+func (s *Server) checkRegistryMetadata(metadata map[string]any) error {
+	if err := s.checkVectorModel(metadata); err != nil {
+		return err
+	}
+	rules, err := registry.Retention(metadata)
+	if err != nil {
+		return err
+	}
+	return core.ValidateRetentionRules(rules, s.config.Retention.MaxRules)
+}
+
+// handleAdminRetention routes /admin/retention/:
+//
+//	GET    /admin/retention/policies                 default and per-index policies
+//	GET    /admin/retention/policies/{index}         effective policy of an index
+//	PUT    /admin/retention/policies/{index}         set an index's policy
+//	DELETE /admin/retention/policies/{index}         fall back to the default
+//	GET    /admin/retention/policies/{index}/dry-run what the policy would do now
+//	GET    /admin/retention/history                  recent enforcement runs
+func (s *Server) handleAdminRetention(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/retention/"), "/")
+
+	switch {
+	case path == "history":
+		if r.Method != "GET" {
+			apierr.MethodNotAllowed(w)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"runs": s.retentionRuns.list()})
+	case path == "policies":
+		if r.Method != "GET" {
+			apierr.MethodNotAllowed(w)
+			return
+		}
+		s.handleRetentionPolicies(w)
+	case strings.HasPrefix(path, "policies/"):
+		rest := strings.TrimPrefix(path, "policies/")
+		if indexID, ok := strings.CutSuffix(rest, "/dry-run"); ok {
+			if r.Method != "GET" {
+				apierr.MethodNotAllowed(w)
+				return
+			}
+			s.handleRetentionDryRun(w, core.IndexID(indexID))
+			return
+		}
+		if rest == "" || strings.Contains(rest, "/") {
+			apierr.NotFound(w, apierr.CodeNotFound, "unknown retention endpoint")
+			return
+		}
+		s.handleRetentionPolicy(w, r, rest)
+	default:
+		apierr.NotFound(w, apierr.CodeNotFound, "unknown retention endpoint")
+	}
+}
+
+// handleRetentionPolicies lists the default policy and every index that
+// has its own.
+func (s *Server) handleRetentionPolicies(w http.ResponseWriter) {
+	indexes := []map[string]any{}
+	if s.registry != nil {
+		for _, entry := range s.registry.List() {
+			if rules, ok := s.registry.RetentionRules(entry.UUID); ok {
+				indexes = append(indexes, map[string]any{"indexId": entry.UUID, "rules": rules})
+			}
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i]["indexId"].(string) < indexes[j]["indexId"].(string) })
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"default":  retentionRulesOrEmpty(s.config.Retention.DefaultRules),
+		"indexes":  indexes,
+		"interval": s.config.Retention.Interval.String(),
+		"maxRules": s.config.Retention.MaxRules,
+	})
+}
+
+// handleRetentionPolicy reads, replaces or removes the policy of an index.
+// Policies live in the index's registry entry, so the index must be
+// registered to have one.
+func (s *Server) handleRetentionPolicy(w http.ResponseWriter, r *http.Request, indexID string) {
+	switch r.Method {
+	case "GET":
+		rules, source := s.effectiveRetention(core.IndexID(indexID))
+		json.NewEncoder(w).Encode(map[string]any{
+			"indexId": indexID,
+			"source":  source,
+			"rules":   retentionRulesOrEmpty(rules),
+		})
+		return
+	case "PUT", "DELETE":
+	default:
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	var value any
+	if r.Method == "PUT" {
+		var req struct {
+			Rules []core.RetentionRule `json:"rules"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierr.InvalidJSON(w)
+			return
+		}
+		if req.Rules == nil {
+			apierr.BadRequest(w, apierr.CodeInvalidRetention, "rules is required; an empty list opts the index out of the default policy")
+			return
+		}
+		if err := core.ValidateRetentionRules(req.Rules, s.config.Retention.MaxRules); err != nil {
+			apierr.BadRequest(w, apierr.CodeInvalidRetention, err.Error())
+			return
+		}
+		value = req.Rules
+	}
+	if s.registry == nil || !s.registry.Exists(indexID) {
+		apierr.NotFound(w, apierr.CodeUUIDNotFound, fmt.Sprintf("uuid not registered: %s; retention policies are kept in the registry entry", indexID))
+		return
+	}
+	if _, err := s.registry.SetMetadataKey(indexID, registry.RetentionKey, value); err != nil {
+		if !writeRegistryConfigError(w, err) {
+			apierr.Internal(w, err.Error())
+		}
+		return
+	}
+
+	rules, source := s.effectiveRetention(core.IndexID(indexID))
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId": indexID,
+		"source":  source,
+		"rules":   retentionRulesOrEmpty(rules),
+	})
+}
+
+// handleRetentionDryRun reports how many neurons each rule of the index's
+// effective policy governs and would affect right now.
+func (s *Server) handleRetentionDryRun(w http.ResponseWriter, indexID core.IndexID) {
+	if indexID == "" {
+		apierr.IndexIDRequired(w)
+		return
+	}
+	rules, source := s.effectiveRetention(indexID)
+	// An index without data governs nothing.
+	report := engine.RetentionReport{DryRun: true, Rules: make([]engine.RetentionRuleResult, len(rules))}
+	for i, rule := range rules {
+		report.Rules[i] = engine.RetentionRuleResult{Rule: i, Action: rule.Action}
+	}
+	if len(rules) > 0 {
+		worker, err := s.pool.Get(indexID)
+		if err != nil && s.pool.Persisted(indexID) {
+			worker, err = s.pool.GetOrCreate(indexID)
+		}
+		if err == nil {
+			if report, err = s.applyRetention(worker, rules, true, concurrency.PriorityInteractive); err != nil {
+				apierr.Internal(w, err.Error())
+				return
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId":  indexID,
+		"source":   source,
+		"dryRun":   true,
+		"scanned":  report.Scanned,
+		"affected": report.Affected,
+		"rules":    report.Rules,
+	})
+}
+
+func retentionRulesOrEmpty(rules []core.RetentionRule) []core.RetentionRule {
+	if rules == nil {
+		return []core.RetentionRule{}
+	}
+	return rules
+}
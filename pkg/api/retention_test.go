@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestRetentionPolicies(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Retention.MaxRules = 2
+		cfg.Retention.DefaultRules = []core.RetentionRule{{MaxAge: "365d", Action: core.RetentionDrain}}
+	})
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+	headers := map[string]string{"X-Index-ID": "ret"}
+	policy := `{"rules":[{"match":{"pii":"true"},"maxAge":"30d","action":"delete"},{"maxAge":"7d","action":"anonymize"}]}`
+
+	if rr := doRequest(t, s, "PUT", "/admin/retention/policies/ret", policy, admin); rr.Code != http.StatusNotFound {
+		t.Errorf("an unregistered index cannot hold a policy, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"ret"}`, nil); rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+		t.Fatalf("register: %d %s", rr.Code, rr.Body.String())
+	}
+	rr := doRequest(t, s, "PUT", "/admin/retention/policies/ret", `{"rules":[{"maxAge":"1d","action":"delete"},{"maxAge":"2d","action":"delete"},{"maxAge":"3d","action":"delete"}]}`, admin)
+	if rr.Code != http.StatusBadRequest || decodeJSON(t, rr)["code"] != "INVALID_RETENTION" {
+		t.Errorf("expected the rule limit to be enforced, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "PUT", "/v1/registry/ret", `{"metadata":{"retention":[{"maxAge":"soon","action":"delete"}]}}`, nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("registry writes should validate the policy too, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "PUT", "/admin/retention/policies/ret", policy, admin); rr.Code != http.StatusOK {
+		t.Fatalf("set policy: %d %s", rr.Code, rr.Body.String())
+	}
+	doc := decodeJSON(t, doRequest(t, s, "GET", "/admin/retention/policies/ret", "", admin))
+	if doc["source"] != "index" || len(doc["rules"].([]any)) != 2 {
+		t.Errorf("unexpected policy: %v", doc)
+	}
+
+	for _, body := range []string{
+		`{"content":"card ending 4242","metadata":{"pii":"true"}}`,
+		`{"content":"met the new team lead"}`,
+		`{"content":"fresh pii note","metadata":{"pii":"true"}}`,
+	} {
+		if rr := doRequest(t, s, "POST", "/v1/write", body, headers); rr.Code != http.StatusOK {
+			t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+	worker, _ := s.pool.Get("ret")
+	for _, n := range worker.Matrix().Neurons {
+		if n.Content != "fresh pii note" {
+			n.CreatedAt = time.Now().Add(-60 * 24 * time.Hour)
+		}
+	}
+
+	dry := decodeJSON(t, doRequest(t, s, "GET", "/admin/retention/policies/ret/dry-run", "", admin))
+	rules := dry["rules"].([]any)
+	if dry["affected"] != float64(2) || rules[0].(map[string]any)["matched"] != float64(2) || rules[0].(map[string]any)["affected"] != float64(1) {
+		t.Fatalf("unexpected dry run: %v", dry)
+	}
+	if len(worker.Matrix().Neurons) != 3 {
+		t.Fatal("a dry run must not change the index")
+	}
+
+	if _, err := s.retentionPass(); err != nil {
+		t.Fatal(err)
+	}
+	if len(worker.Matrix().Neurons) != 2 {
+		t.Errorf("the expired pii neuron should be deleted, %d neurons left", len(worker.Matrix().Neurons))
+	}
+	runs := decodeJSON(t, doRequest(t, s, "GET", "/admin/retention/history", "", admin))["runs"].([]any)
+	if len(runs) != 1 {
+		t.Fatalf("expected one recorded run, got %v", runs)
+	}
+	indexes := runs[0].(map[string]any)["indexes"].([]any)
+	if len(indexes) != 1 || indexes[0].(map[string]any)["affected"] != dry["affected"] {
+		t.Errorf("the run should match the dry run, got %v", indexes)
+	}
+
+	if rr := doRequest(t, s, "DELETE", "/admin/retention/policies/ret", "", admin); rr.Code != http.StatusOK {
+		t.Fatalf("delete policy: %d", rr.Code)
+	}
+	doc = decodeJSON(t, doRequest(t, s, "GET", "/admin/retention/policies", "", admin))
+	if len(doc["indexes"].([]any)) != 0 || len(doc["default"].([]any)) != 1 {
+		t.Errorf("the index should fall back to the default policy: %v", doc)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// parseRolesQuery reads the roles GET parameter, which may be repeated or
+// comma-separated: ?roles=user,system or ?roles=user&roles=system.
+func parseRolesQuery(q url.Values) []string {
+	var roles []string
+	for _, v := range q["roles"] {
+		roles = append(roles, strings.Split(v, ",")...)
+	}
+	return cleanRoles(roles)
+}
+
+// cleanRoles trims and lower-cases role names, dropping empty entries.
+func cleanRoles(roles []string) []string {
+	var out []string
+	for _, r := range roles {
+		if r = strings.ToLower(strings.TrimSpace(r)); r != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// resolveRoles returns the roles a request is restricted to: the ones it
+// named, or else the rolesFilter configured for the index in the registry.
+func (s *Server) resolveRoles(indexID core.IndexID, requested []string) []string {
+	if roles := cleanRoles(requested); len(roles) > 0 {
+		return roles
+	}
+	return cleanRoles(s.registry.DefaultRoles(string(indexID)))
+}
+
+// resolveMetadataKeys adds an index's indexedMetadataKeys registry metadata
+// to the server's.
+func (s *Server) resolveMetadataKeys(indexID core.IndexID, defaults []string) []string {
+	extra := s.registry.MetadataIndexKeys(string(indexID))
+	if len(extra) == 0 {
+		return defaults
+	}
+	return append(append([]string(nil), defaults...), extra...)
+}
+
+// roleTag annotates a context snippet with the role that authored it, so
+// prompt templates can render conversation turns. Neurons without a role
+// are left untagged.
+func roleTag(n *core.Neuron) string {
+	if role := engine.NeuronRole(n); role != "" {
+		return fmt.Sprintf(" [role:%s]", role)
+	}
+	return ""
+}
+
+// writeRegistryConfigError writes a 400 for registry writes rejected because
+// of reserved metadata keys, or the 409 or 403 of an attempt to change an
+// append-only entry, reporting whether err was one of those.
+func writeRegistryConfigError(w http.ResponseWriter, err error) bool {
+	switch {
+	case isFallbackConfigError(err):
+		apierr.BadRequest(w, apierr.CodeInvalidFallback, err.Error())
+	case errors.Is(err, registry.ErrInvalidRolesFilter), errors.Is(err, registry.ErrInvalidVectorOverride),
+		errors.Is(err, registry.ErrInvalidIndexedMetadataKeys), errors.Is(err, registry.ErrInvalidSentiment),
+		errors.Is(err, registry.ErrInvalidExpansion), errors.Is(err, registry.ErrInvalidAppendOnly),
+		errors.Is(err, registry.ErrInvalidSimilarity), errors.Is(err, registry.ErrInvalidCalibration):
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+	case errors.Is(err, registry.ErrAppendOnlyImmutable):
+		apierr.Conflict(w, apierr.CodeConflict, err.Error())
+	case errors.Is(err, core.ErrAppendOnly):
+		writeAppendOnlyError(w, err.Error())
+	case errors.Is(err, core.ErrInvalidRetention):
+		apierr.BadRequest(w, apierr.CodeInvalidRetention, err.Error())
+	case errors.Is(err, core.ErrInvalidQuota):
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	mcpapi "github.com/qubicDB/qubicdb/pkg/mcp"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// conversationTurns is a user/assistant exchange in which every assistant
+// turn echoes the user turn before it.
+var conversationTurns = []struct {
+	role    string
+	content string
+}{
+	{"user", "My name is Alex and I work at TechCorp as a senior developer"},
+	{"assistant", "Nice to meet you Alex! I'll remember you work at TechCorp as a senior developer."},
+	{"user", "I prefer using TypeScript and React for frontend development"},
+	{"assistant", "Got it! TypeScript and React are great choices for frontend development."},
+	{"user", "For the new project, I need to set up a Kubernetes cluster on AWS"},
+	{"assistant", "I can help with Kubernetes on AWS. Do you prefer EKS or self-managed?"},
+}
+
+func newConversationTestServer(t *testing.T, indexID string) *Server {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+	})
+	for _, turn := range conversationTurns {
+		body, _ := json.Marshal(map[string]any{
+			"content":  turn.content,
+			"metadata": map[string]string{"role": turn.role},
+		})
+		rr := doRequest(t, s, "POST", "/v1/write", string(body), map[string]string{"X-Index-ID": indexID})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+	return s
+}
+
+func resultRoles(t *testing.T, m map[string]any, key string) []string {
+	t.Helper()
+	var roles []string
+	for _, item := range m[key].([]any) {
+		meta, _ := item.(map[string]any)["metadata"].(map[string]any)
+		role, _ := meta["role"].(string)
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+func TestRoles_SearchSuppressesAssistantEchoes(t *testing.T) {
+	s := newConversationTestServer(t, "conv")
+
+	all := resultRoles(t, searchResults(t, s, "conv", "TechCorp+developer"), "results")
+	if !containsString(all, "assistant") {
+		t.Fatalf("expected the unfiltered search to include the assistant echo, got %v", all)
+	}
+
+	m := searchResults(t, s, "conv", "TechCorp+developer&roles=user")
+	roles := resultRoles(t, m, "results")
+	if len(roles) == 0 {
+		t.Fatal("expected user-authored results")
+	}
+	for _, role := range roles {
+		if role != "user" {
+			t.Errorf("expected only user results, got %v", roles)
+			break
+		}
+	}
+	if applied := m["roles"].([]any); len(applied) != 1 || applied[0] != "user" {
+		t.Errorf("expected roles=[user] echoed in response, got %v", m["roles"])
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/search", `{"query":"TypeScript React","roles":["Assistant"]}`, map[string]string{
+		"X-Index-ID": "conv",
+	})
+	for _, role := range resultRoles(t, decodeJSON(t, rr), "results") {
+		if role != "assistant" {
+			t.Errorf("POST roles filter should match case-insensitively and exclude %q", role)
+		}
+	}
+}
+
+func TestRoles_RecallFilters(t *testing.T) {
+	s := newConversationTestServer(t, "conv")
+
+	rr := doRequest(t, s, "GET", "/v1/recall?roles=assistant", "", map[string]string{"X-Index-ID": "conv"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("recall failed: %d %s", rr.Code, rr.Body.String())
+	}
+	roles := resultRoles(t, decodeJSON(t, rr), "memories")
+	if len(roles) != 3 {
+		t.Fatalf("expected 3 assistant memories, got %v", roles)
+	}
+	for _, role := range roles {
+		if role != "assistant" {
+			t.Errorf("expected only assistant memories, got %v", roles)
+			break
+		}
+	}
+}
+
+func TestRoles_ContextAnnotatesSnippets(t *testing.T) {
+	s := newConversationTestServer(t, "conv")
+
+	rr := doRequest(t, s, "POST", "/v1/context", `{"cue":"Kubernetes cluster on AWS"}`, map[string]string{
+		"X-Index-ID": "conv",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("context failed: %d %s", rr.Code, rr.Body.String())
+	}
+	ctx := decodeJSON(t, rr)["context"].(string)
+	for _, snippet := range strings.Split(ctx, "\n---\n") {
+		switch {
+		case strings.HasPrefix(snippet, "For the new project"):
+			if !strings.Contains(snippet, "[role:user]") {
+				t.Errorf("user snippet not annotated: %q", snippet)
+			}
+		case strings.HasPrefix(snippet, "I can help with Kubernetes"):
+			if !strings.Contains(snippet, "[role:assistant]") {
+				t.Errorf("assistant snippet not annotated: %q", snippet)
+			}
+		}
+	}
+	if !strings.Contains(ctx, "[role:user]") || !strings.Contains(ctx, "[role:assistant]") {
+		t.Fatalf("expected both roles annotated in unfiltered context, got %q", ctx)
+	}
+
+	rr = doRequest(t, s, "POST", "/v1/context", `{"cue":"Kubernetes cluster on AWS","roles":["user"]}`, map[string]string{
+		"X-Index-ID": "conv",
+	})
+	ctx = decodeJSON(t, rr)["context"].(string)
+	if strings.Contains(ctx, "[role:assistant]") || !strings.Contains(ctx, "[role:user]") {
+		t.Errorf("expected only user snippets, got %q", ctx)
+	}
+}
+
+func TestRoles_IndexDefaultFromRegistry(t *testing.T) {
+	s := newConversationTestServer(t, "conv")
+
+	rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"conv","metadata":{"rolesFilter":["user"]}}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("registry create failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	for _, role := range resultRoles(t, searchResults(t, s, "conv", "TechCorp+developer"), "results") {
+		if role != "user" {
+			t.Errorf("index rolesFilter should apply when the request names no roles, got %q", role)
+		}
+	}
+
+	// An explicit request overrides the index default.
+	roles := resultRoles(t, searchResults(t, s, "conv", "TechCorp+developer&roles=assistant"), "results")
+	if len(roles) == 0 || roles[0] != "assistant" {
+		t.Errorf("request roles should override the index default, got %v", roles)
+	}
+
+	rr = doRequest(t, s, "POST", "/v1/registry", `{"uuid":"bad","metadata":{"rolesFilter":"user"}}`, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-list rolesFilter, got %d", rr.Code)
+	}
+	if _, err := s.registry.Create("bad", map[string]any{registry.RolesFilterKey: []any{""}}); err == nil {
+		t.Error("empty role name should be rejected")
+	}
+}
+
+func TestRoles_MCPBackend(t *testing.T) {
+	s := newConversationTestServer(t, "conv")
+	b := newMCPBackend(s)
+	ctx := context.Background()
+
+	res, err := b.Context(ctx, "conv", "TypeScript React frontend", 2, 2000, []string{"assistant"}, "text")
+	if err != nil {
+		t.Fatalf("Context: %v", err)
+	}
+	text := res["context"].(string)
+	if !strings.Contains(text, "[role:assistant]") || strings.Contains(text, "[role:user]") {
+		t.Errorf("expected only assistant snippets, got %q", text)
+	}
+	res, err = b.Context(ctx, "conv", "TypeScript React frontend", 2, 2000, []string{"assistant"}, "blocks")
+	if err != nil {
+		t.Fatalf("Context: %v", err)
+	}
+	text = res["context"].(string)
+	if !strings.Contains(text, `role="assistant"`) || strings.Contains(text, `role="user"`) {
+		t.Errorf("expected only assistant blocks, got %q", text)
+	}
+
+	res, err = b.Search(ctx, "conv", "TypeScript React", 2, 10, nil, false, []string{"user"}, "", mcpapi.Anchor{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	for _, doc := range res["results"].([]map[string]any) {
+		if doc["metadata"].(map[string]any)["role"] != "user" {
+			t.Errorf("expected only user results, got %v", doc["metadata"])
+		}
+	}
+
+	res, err = b.Recall(ctx, "conv", 100, []string{"user"})
+	if err != nil {
+		t.Fatalf("Recall: %v", err)
+	}
+	if res["count"].(int) != 3 {
+		t.Errorf("expected 3 user memories, got %v", res["count"])
+	}
+}
+
+func containsString(list []string, want string) bool {
+	for _, s := range list {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+const defaultSampleSize = 10
+
+// maxSampleSeed keeps generated seeds exact in JSON clients that read
+// numbers as float64.
+const maxSampleSeed = 1 << 53
+
+// handleSample draws n neurons without replacement, weighted by energy,
+// recency, inverse recency or uniformly (GET /v1/sample). The filter is
+// that of an export: metadata_<key>, strict, since and until. Superseded
+// neurons, neurons past their retention maxAge and session neurons are
+// never drawn. The response carries the seed, so passing it back redraws
+// the same sample while the index is unchanged; rehearse=true fires the
+// drawn neurons.
+func (s *Server) handleSample(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	weightBy := q.Get("weight_by")
+	if weightBy == "" {
+		weightBy = engine.SampleWeightUniform
+	}
+	if !engine.IsSampleWeight(weightBy) {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "weight_by must be energy, recency, inverse_recency or uniform")
+		return
+	}
+	filter, _, err := parseSliceFilter(q)
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	seed := rand.Int63n(maxSampleSeed)
+	if raw := q.Get("seed"); raw != "" {
+		if seed, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "seed must be an integer")
+			return
+		}
+	}
+
+	indexID := s.getIndexID(r)
+	worker, err := s.getWorker(indexID)
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	expiry, _ := s.effectiveRetention(indexID)
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type: concurrency.OpSample,
+		Payload: concurrency.SampleRequest{
+			Options: engine.SampleOptions{
+				N:        clampPositive(parsePositiveQueryInt(q.Get("n")), defaultSampleSize, maxSearchLimit),
+				WeightBy: weightBy,
+				Filter:   filter,
+				Expiry:   expiry,
+				Now:      time.Now(),
+				Seed:     seed,
+			},
+			Rehearse: q.Get("rehearse") == "true",
+		},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+	sample := result.(engine.SampleResult)
+
+	items := make([]map[string]any, len(sample.Neurons))
+	for i, sn := range sample.Neurons {
+		doc := s.neuronDocument(r.Context(), sn.Neuron)
+		doc["weight"] = sn.Weight
+		items[i] = doc
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"neurons":     items,
+		"count":       len(items),
+		"candidates":  sample.Candidates,
+		"totalWeight": sample.TotalWeight,
+		"weightBy":    weightBy,
+		"seed":        sample.Seed,
+	})
+}
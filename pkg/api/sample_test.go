@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSample_Endpoint(t *testing.T) {
+	s := newTestServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "replay"}
+	for i, deck := range []string{"spanish", "spanish", "spanish", "french"} {
+		body := fmt.Sprintf(`{"content":"flashcard %d of the %s deck number %d","metadata":{"deck":%q}}`, i, deck, i*7, deck)
+		if rr := doRequest(t, s, "POST", "/v1/write", body, headers); rr.Code != http.StatusOK {
+			t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	sample := func(query string) map[string]any {
+		t.Helper()
+		rr := doRequest(t, s, "GET", "/v1/sample?"+query, "", headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("sample %s: %d %s", query, rr.Code, rr.Body.String())
+		}
+		return decodeJSON(t, rr)
+	}
+	ids := func(m map[string]any) []string {
+		var out []string
+		for _, n := range m["neurons"].([]any) {
+			doc := n.(map[string]any)
+			if doc["weight"] == nil {
+				t.Errorf("neuron %v has no weight", doc["id"])
+			}
+			out = append(out, doc["id"].(string))
+		}
+		return out
+	}
+
+	first := sample("n=2&weight_by=energy&seed=11")
+	if first["count"].(float64) != 2 || first["seed"].(float64) != 11 || first["candidates"].(float64) != 4 {
+		t.Fatalf("unexpected sample %v", first)
+	}
+	again := sample("n=2&weight_by=energy&seed=11")
+	if a, b := strings.Join(ids(first), ","), strings.Join(ids(again), ","); a != b {
+		t.Errorf("same seed drew %s then %s", a, b)
+	}
+	if m := sample("n=10"); m["seed"] == nil || m["weightBy"] != "uniform" || m["count"].(float64) != 4 {
+		t.Errorf("expected a uniform draw of all 4 neurons with a generated seed, got %v", m)
+	}
+
+	filtered := sample("n=10&metadata_deck=spanish&rehearse=true")
+	if filtered["count"].(float64) != 3 {
+		t.Errorf("expected the 3 spanish cards, got %v", filtered["count"])
+	}
+
+	for _, query := range []string{"weight_by=popularity", "seed=abc", "since=yesterday"} {
+		if rr := doRequest(t, s, "GET", "/v1/sample?"+query, "", headers); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/seed"
+)
+
+// seedProgressEvery is how many entries are written between progress logs.
+const seedProgressEvery = 1000
+
+// seedIndex writes entries into indexID through the regular write path, so
+// content limits and sanitization apply to each entry. An index that
+// already holds neurons is skipped unless force is set, in which case it is
+// truncated first. With the registry guard on, the index is registered.
+func (s *Server) seedIndex(indexID core.IndexID, entries []seed.Entry, force bool) (seed.Result, error) {
+	res := seed.Result{IndexID: string(indexID), Total: len(entries), Failures: []seed.Failure{}}
+
+	if s.config.Registry.Enabled {
+		if _, _, err := s.registry.FindOrCreate(string(indexID), nil); err != nil {
+			return res, err
+		}
+	}
+
+	worker, err := s.pool.GetOrCreate(indexID)
+	if err != nil {
+		return res, err
+	}
+	if neuronCount(worker) > 0 {
+		if !force {
+			res.Skipped = true
+			return res, nil
+		}
+		if err := s.pool.Truncate(indexID); err != nil {
+			return res, err
+		}
+		if worker, err = s.pool.GetOrCreate(indexID); err != nil {
+			return res, err
+		}
+	}
+	s.lifecycle.RecordActivity(indexID)
+
+	write := func(e seed.Entry, parentID string) (string, error) {
+		var parent *core.NeuronID
+		if parentID != "" {
+			pid := core.NeuronID(parentID)
+			parent = &pid
+		}
+		result, err := worker.Submit(&concurrency.Operation{
+			Type: concurrency.OpWrite,
+			Payload: concurrency.AddNeuronRequest{
+				Content:  e.Content,
+				ParentID: parent,
+				Metadata: e.Metadata,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		return string(result.(*core.Neuron).ID), nil
+	}
+	progress := func(done, total int) {
+		if done%seedProgressEvery == 0 && done < total {
+			log.Printf("Seeding %s: %d/%d entries", indexID, done, total)
+		}
+	}
+
+	applied, err := seed.Apply(entries, write, progress)
+	applied.IndexID = res.IndexID
+	return applied, err
+}
+
+func neuronCount(worker *concurrency.BrainWorker) int {
+	neurons, _ := worker.Size()
+	return neurons
+}
+
+// SeedFromConfig loads every storage.seed corpus into its index, logging a
+// summary line per index. Failures are logged and do not stop startup.
+// storage.seedForce does not reload an append-only index.
+func (s *Server) SeedFromConfig() []seed.Result {
+	var results []seed.Result
+	for _, sc := range s.config.Storage.Seed {
+		res := seed.Result{IndexID: sc.IndexID, Source: sc.File, Failures: []seed.Failure{}}
+		entries, err := seed.Load(sc.File)
+		if err == nil {
+			force := s.config.Storage.SeedForce && !s.pool.AppendOnly(core.IndexID(sc.IndexID))
+			res, err = s.seedIndex(core.IndexID(sc.IndexID), entries, force)
+			res.Source = sc.File
+		}
+
+		switch {
+		case err != nil:
+			log.Printf("⚠ Seeding %s from %s failed: %v", sc.IndexID, sc.File, err)
+		case res.Skipped:
+			log.Printf("Seed %s: index not empty, skipped (use --seed-force to reload)", sc.IndexID)
+		default:
+			log.Printf("Seed %s: wrote %d/%d entries from %s (%d failed)",
+				sc.IndexID, res.Written, res.Total, sc.File, len(res.Failures))
+			for _, f := range res.Failures {
+				log.Printf("⚠ Seed %s: entry %d: %s", sc.IndexID, f.Entry, f.Error)
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// handleAdminSeed seeds an index from a seed document in the request body.
+// ?force=true reloads an index that already holds data; an append-only
+// index also needs ?override_append_only=true.
+func (s *Server) handleAdminSeed(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	body, ok := s.readContentBody(w, r)
+	if !ok {
+		return
+	}
+	entries, err := seed.Parse(body)
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	if force && !s.allowDestructiveAdmin(w, r, indexID, "seed reload") {
+		return
+	}
+
+	res, err := s.seedIndex(indexID, entries, force)
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
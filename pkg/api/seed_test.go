@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+const seedDoc = `
+- key: kickoff
+  content: Project kickoff meeting notes
+- content: Decided to build the backend in Go
+  parent_ref: kickoff
+- content: Deployment targets Kubernetes on AWS
+  parent_ref: 1
+`
+
+func TestSeedFromConfig_IdempotentAcrossRestart(t *testing.T) {
+	dataPath := t.TempDir()
+	seedFile := filepath.Join(t.TempDir(), "demo.yaml")
+	if err := os.WriteFile(seedFile, []byte(seedDoc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := func(force bool) *Server {
+		return newTestServer(t, func(cfg *core.Config) {
+			cfg.Storage.DataPath = dataPath
+			cfg.Storage.Seed = []core.SeedConfig{{IndexID: "demo", File: seedFile}}
+			cfg.Storage.SeedForce = force
+		})
+	}
+
+	s := start(false)
+	results := s.SeedFromConfig()
+	if len(results) != 1 || results[0].Written != 3 || results[0].Skipped {
+		t.Fatalf("expected 3 entries seeded, got %+v", results)
+	}
+	if err := s.pool.Evict("demo"); err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+
+	// Restart: the index is no longer empty, so seeding is skipped.
+	s = start(false)
+	if results = s.SeedFromConfig(); !results[0].Skipped {
+		t.Fatalf("expected seeding to be skipped on restart, got %+v", results)
+	}
+	worker, _ := s.pool.GetOrCreate("demo")
+	if n := neuronCount(worker); n != 3 {
+		t.Errorf("expected 3 neurons after restart, got %d", n)
+	}
+	if err := s.pool.Evict("demo"); err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+
+	// Forced reload replaces rather than duplicates the corpus.
+	s = start(true)
+	if results = s.SeedFromConfig(); results[0].Written != 3 {
+		t.Fatalf("expected forced reload to write 3 entries, got %+v", results)
+	}
+	worker, _ = s.pool.GetOrCreate("demo")
+	if n := neuronCount(worker); n != 3 {
+		t.Errorf("expected 3 neurons after forced reload, got %d", n)
+	}
+}
+
+func TestSeedFromConfig_RegistersIndexAndReportsMissingFile(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = true
+		cfg.Storage.Seed = []core.SeedConfig{{IndexID: "demo", File: filepath.Join(t.TempDir(), "missing.yaml")}}
+	})
+	if results := s.SeedFromConfig(); results[0].Written != 0 || s.registry.Exists("demo") {
+		t.Fatalf("a missing seed file should write nothing, got %+v", results)
+	}
+
+	seedFile := filepath.Join(t.TempDir(), "demo.yaml")
+	os.WriteFile(seedFile, []byte(seedDoc), 0o644)
+	s.config.Storage.Seed[0].File = seedFile
+	s.SeedFromConfig()
+	if !s.registry.Exists("demo") {
+		t.Error("seeding should register the index when the registry guard is on")
+	}
+}
+
+func TestAdminSeed_ReportsContentLimitFailures(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+		cfg.Security.MaxNeuronContentBytes = 64
+	})
+	t.Cleanup(func() { core.SetMaxNeuronContentBytes(core.DefaultMaxNeuronContentBytes) })
+
+	doc := `[
+		{"key":"big","content":"` + strings.Repeat("x", 100) + `"},
+		{"content":"child of the oversized entry","parent_ref":"big"},
+		{"content":"a short memory"}
+	]`
+	auth := map[string]string{"Authorization": adminAuthHeader("admin", "secret")}
+	rr := doRequest(t, s, "POST", "/admin/indexes/demo/seed", doc, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	if m["written"] != float64(1) || m["total"] != float64(3) {
+		t.Errorf("expected 1 of 3 written, got %v", m)
+	}
+	failures := m["failures"].([]any)
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures, got %v", failures)
+	}
+	first := failures[0].(map[string]any)
+	if first["key"] != "big" || !strings.Contains(strings.ToLower(first["error"].(string)), "exceed") {
+		t.Errorf("expected the oversized entry to fail on the content limit, got %v", first)
+	}
+
+	// A second call leaves the now non-empty index alone.
+	rr = doRequest(t, s, "POST", "/admin/indexes/demo/seed", `[{"content":"again"}]`, auth)
+	if decodeJSON(t, rr)["skipped"] != true {
+		t.Error("expected seeding a non-empty index to be skipped without force")
+	}
+	rr = doRequest(t, s, "POST", "/admin/indexes/demo/seed?force=true", `[{"content":"again"}]`, auth)
+	if decodeJSON(t, rr)["written"] != float64(1) {
+		t.Error("expected force=true to reseed the index")
+	}
+
+	rr = doRequest(t, s, "POST", "/admin/indexes/demo/seed", `[{"content":"a","parent_ref":"nope"}]`, auth)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unresolvable parent_ref, got %d", rr.Code)
+	}
+}
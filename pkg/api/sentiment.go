@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// resolveSentiment reports whether indexID uses the sentiment layer,
+// unless its registry "sentiment" switch turns it off.
+func (s *Server) resolveSentiment(indexID core.IndexID) bool {
+	if s.registry == nil {
+		return true
+	}
+	return s.registry.SentimentEnabled(string(indexID))
+}
+
+// sentimentReport describes the sentiment layer indexID uses: whether it
+// is enabled and the analyzer labeling it, null when disabled.
+func (s *Server) sentimentReport(indexID core.IndexID) map[string]any {
+	report := map[string]any{"enabled": false, "provider": nil}
+	if a := s.pool.SentimentSettings(indexID).Analyzer; a != nil {
+		report["enabled"] = true
+		report["provider"] = a.Name()
+	}
+	return report
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/sentiment"
+)
+
+// readSentiment returns the sentiment document of neuron id in indexID.
+func readSentiment(t *testing.T, s *Server, indexID string, id core.NeuronID) any {
+	t.Helper()
+	rr := doRequest(t, s, "GET", "/v1/read/"+string(id), "", map[string]string{"X-Index-ID": indexID})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("read: %d %s", rr.Code, rr.Body.String())
+	}
+	doc := decodeJSON(t, rr)
+	if _, ok := doc["sentiment"]; !ok {
+		t.Fatalf("expected a sentiment field, got %v", doc)
+	}
+	return doc["sentiment"]
+}
+
+func TestSentiment_PerIndexSwitch(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.pool.SetSentimentAnalyzer(sentiment.New())
+	admin := map[string]string{"Authorization": adminAuthHeader("admin", "qubicdb")}
+
+	info := decodeJSON(t, doRequest(t, s, "GET", "/admin/info", "", admin))
+	if got := info["sentiment"].(map[string]any); got["provider"] != "vader" {
+		t.Errorf("expected vader reported active, got %v", got)
+	}
+
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"logs","metadata":{"sentiment":{"enabled":"no"}}}`, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected an invalid switch refused, got %d", rr.Code)
+	}
+	if rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"logs","metadata":{"sentiment":{"enabled":false}}}`, nil); rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+		t.Fatalf("register: %d %s", rr.Code, rr.Body.String())
+	}
+
+	logs := writeTo(t, s, "logs", "I love this wonderful release")
+	if got := readSentiment(t, s, "logs", logs); got != nil {
+		t.Errorf("a disabled index should record no sentiment, got %v", got)
+	}
+	notes := writeTo(t, s, "notes", "I love this wonderful release")
+	if got, _ := readSentiment(t, s, "notes", notes).(map[string]any); got["label"] != "happiness" {
+		t.Errorf("expected the enabled index labeled, got %v", got)
+	}
+
+	detail := decodeJSON(t, doRequest(t, s, "GET", "/admin/indexes/logs", "", admin))
+	if got := detail["sentiment"].(map[string]any); got["enabled"] != false || got["provider"] != nil {
+		t.Errorf("expected sentiment disabled in the detail, got %v", got)
+	}
+	detail = decodeJSON(t, doRequest(t, s, "GET", "/admin/indexes/notes", "", admin))
+	if got := detail["sentiment"].(map[string]any); got["enabled"] != true || got["provider"] != "vader" {
+		t.Errorf("expected vader in the detail, got %v", got)
+	}
+}
+
+func TestSentiment_PersistedValuesSurviveProviderChange(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.pool.SetSentimentAnalyzer(sentiment.New())
+
+	id := writeTo(t, s, "notes", "This is a terrible, awful mistake")
+	before := readSentiment(t, s, "notes", id).(map[string]any)
+	if before["label"] == "neutral" || before["score"].(float64) >= 0 {
+		t.Fatalf("expected a negative label, got %v", before)
+	}
+	if err := s.pool.Evict("notes"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.pool.SetSentimentAnalyzer(sentiment.None{})
+	after := readSentiment(t, s, "notes", id).(map[string]any)
+	if after["label"] != before["label"] || after["score"] != before["score"] {
+		t.Errorf("expected the persisted sentiment unchanged, got %v, was %v", after, before)
+	}
+	if got := readSentiment(t, s, "notes", writeTo(t, s, "notes", "Another terrible, awful mistake")); got != nil {
+		t.Errorf("none should leave new writes unlabeled, got %v", got)
+	}
+}
@@ -2,8 +2,6 @@ package api
 
 import (
 	"context"
-	"crypto/sha256"
-	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,20 +9,34 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"runtime"
+	"runtime/debug"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/auth"
 	"github.com/qubicDB/qubicdb/pkg/concurrency"
 	"github.com/qubicDB/qubicdb/pkg/core"
 	"github.com/qubicDB/qubicdb/pkg/daemon"
+	"github.com/qubicDB/qubicdb/pkg/engine"
 	"github.com/qubicDB/qubicdb/pkg/lifecycle"
 	mcpapi "github.com/qubicDB/qubicdb/pkg/mcp"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
 	"github.com/qubicDB/qubicdb/pkg/protocol"
 	"github.com/qubicDB/qubicdb/pkg/registry"
+	"github.com/qubicDB/qubicdb/pkg/session"
+	"github.com/qubicDB/qubicdb/pkg/share"
+	"github.com/qubicDB/qubicdb/pkg/subscription"
+	"github.com/qubicDB/qubicdb/pkg/synapse"
+	"github.com/qubicDB/qubicdb/pkg/telemetry"
+	"github.com/qubicDB/qubicdb/pkg/vector"
 )
 
 // Server is the HTTP/REST API server.
@@ -35,16 +47,49 @@ type Server struct {
 	registry  *registry.Store
 	config    *core.Config
 	daemons   *daemon.DaemonManager
+	tasks     *core.TaskRunner
+
+	vectorHealth  *vector.HealthMonitor     // nil unless the default model loaded
+	vectorBreaker *vector.ResilientEmbedder // nil unless the default model loaded
 
 	httpServer *http.Server
 	addr       string
 	mcpPath    string
 
+	apiFloor int // index in apiVersions of the oldest version served
+
 	rateLimitEnabled  bool
 	rateLimitRequests int
 	rateLimitWindow   time.Duration
 	rateLimitMu       sync.Mutex
 	rateLimitEntries  map[string]rateLimitEntry
+
+	authThrottle   *authThrottle  // nil when security.authThrottle.maxFailures is 0
+	trustedProxies []netip.Prefix // security.trustedProxies
+
+	adminSessions *auth.Signer  // nil unless admin.enabled
+	adminDenylist auth.Denylist // sessions ended by /admin/logout
+
+	concurrency   *concurrencyLimiter
+	searchMetrics *telemetry.SearchMetrics // nil unless search.telemetry.enabled
+	shadow        *shadowMirror            // nil unless server.shadow.url is set
+	replication   *replicator              // nil unless replication.standby.url is set
+
+	subscriptions *subscription.Store     // nil unless subscriptions.enabled
+	scheduler     *subscription.Scheduler // nil unless subscriptions.enabled
+
+	shares       *share.Store   // nil unless shares.enabled
+	shareLimiter *windowLimiter // nil unless shares.enabled, keyed by share
+	badgeLimiter *windowLimiter // nil unless shares.enabled, keyed by badge
+
+	prefetchLimiter *windowLimiter // nil unless prefetch.enabled, keyed by client
+
+	sessions *session.Store // nil unless sessions.enabled
+
+	disk *persistence.DiskMonitor // nil unless storage.diskCheckInterval > 0
+
+	retentionRuns *retentionHistory
+	migration     *storageMigration
 }
 
 const (
@@ -52,6 +97,8 @@ const (
 	defaultSearchLimit      = 20
 	maxSearchDepth          = 8
 	maxSearchLimit          = 200
+	defaultRecallLimit      = 100
+	maxRecallLimit          = 500
 	defaultContextDepth     = 2
 	defaultContextTokens    = 2000
 	maxContextDepth         = 8
@@ -60,11 +107,57 @@ const (
 	defaultRateLimitRequest = 10000
 )
 
+// warningHeader carries non-fatal notices about how a request was handled,
+// such as an index ID source that server.indexIdSource ignores.
+const warningHeader = "X-QubicDB-Warning"
+
+// searchModeHeader tells search and context callers how results were
+// scored: hybrid, lexical, or lexical_fallback when the vector layer failed.
+const searchModeHeader = "X-QubicDB-Search-Mode"
+
 type rateLimitEntry struct {
 	windowStart time.Time
 	count       int
 }
 
+// windowLimiter is a fixed-window request counter per key, for budgets
+// kept apart from the per-client limiter, such as one per share link.
+type windowLimiter struct {
+	requests int
+	window   time.Duration
+	mu       sync.Mutex
+	entries  map[string]rateLimitEntry
+}
+
+func newWindowLimiter(requests int, window time.Duration) *windowLimiter {
+	return &windowLimiter{requests: requests, window: window, entries: make(map[string]rateLimitEntry)}
+}
+
+// allow counts a request against key and reports whether it is within the
+// limit.
+func (l *windowLimiter) allow(key string) bool {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := l.entries[key]
+	if entry.windowStart.IsZero() || now.Sub(entry.windowStart) >= l.window {
+		l.entries[key] = rateLimitEntry{windowStart: now, count: 1}
+		return true
+	}
+	if entry.count >= l.requests {
+		return false
+	}
+	entry.count++
+	l.entries[key] = entry
+	return true
+}
+
+// retryAfterSeconds suggests a Retry-After value for rejected requests.
+func (l *windowLimiter) retryAfterSeconds() int {
+	return max(1, int(l.window.Seconds()))
+}
+
 // NewServer creates a new API server
 func NewServer(
 	addr string,
@@ -84,10 +177,89 @@ func NewServer(
 		rateLimitRequests: defaultRateLimitRequest,
 		rateLimitWindow:   defaultRateLimitWindow,
 		rateLimitEntries:  make(map[string]rateLimitEntry),
+		concurrency:       newConcurrencyLimiter(cfg.Server.Concurrency),
+		apiFloor:          apiVersionFloor(cfg.Server.MinAPIVersion),
+		retentionRuns:     &retentionHistory{size: cfg.Retention.HistorySize},
+		migration:         &storageMigration{},
+	}
+	for _, proxy := range cfg.Security.TrustedProxies {
+		if prefix, err := core.ParseTrustedProxy(proxy); err == nil {
+			s.trustedProxies = append(s.trustedProxies, prefix)
+		}
+	}
+	if cfg.Security.AuthThrottle.MaxFailures > 0 {
+		s.authThrottle = newAuthThrottle(cfg.Security.AuthThrottle)
+	}
+	if cfg.Admin.Enabled {
+		signer, err := auth.NewSigner(cfg.Admin.SessionSecret)
+		if err != nil {
+			log.Printf("⚠ admin session tokens disabled: %v", err)
+		} else {
+			s.adminSessions = signer
+		}
+	}
+	if cfg.Search.Telemetry.Enabled {
+		s.searchMetrics = telemetry.NewSearchMetrics(
+			cfg.Search.Telemetry.SampleZeroResults,
+			cfg.Search.Telemetry.ZeroResultSampleSize,
+		)
+	}
+	if cfg.Server.Shadow.URL != "" {
+		s.shadow = newShadowMirror(cfg.Server.Shadow)
+		log.Printf("Shadow mirroring enabled to %s (sampleRate=%.2f)", cfg.Server.Shadow.URL, cfg.Server.Shadow.SampleRate)
+	}
+	if sb := cfg.Replication.Standby; sb.URL != "" {
+		rep, err := newReplicator(sb, cfg.Storage.DataPath)
+		if err != nil {
+			log.Printf("⚠ standby replication disabled: %v", err)
+		} else {
+			s.replication = rep
+			pool.SetMutationObserver(rep.observe)
+			log.Printf("Standby replication enabled to %s (indexes=%v, overflow=%s)", rep.shipper.URL(), sb.Indexes, sb.Overflow)
+		}
 	}
 	if err := core.SetMaxNeuronContentBytes(cfg.Security.MaxNeuronContentBytes); err != nil {
 		log.Printf("⚠ invalid security.maxNeuronContentBytes=%d, using runtime default: %v", cfg.Security.MaxNeuronContentBytes, err)
 	}
+	if err := core.SetMaxContentLineLength(cfg.Write.MaxLineLength); err != nil {
+		log.Printf("⚠ invalid write.maxLineLength=%d, using runtime default: %v", cfg.Write.MaxLineLength, err)
+	}
+	core.SetContentSanitization(cfg.Write.SanitizeContent)
+	pool.SetVectorResolver(s.resolveVectorSettings)
+	pool.SetPinPolicy(cfg.Pins)
+	pool.SetPrunePolicy(cfg.Daemons.Prune)
+	pool.SetPromotionHistory(cfg.Daemons.Consolidate.PromotionHistory)
+	pool.SetReinforce(cfg.Search.Reinforce)
+	pool.SetRecycleWindow(cfg.Storage.NeuronRecycleWindow)
+	pool.SetBM25(cfg.Search.BM25)
+	pool.SetLexicalFold(cfg.Search.Lexical)
+	pool.SetDeadLetters(newDeadLetters(cfg))
+	pool.SetIndexedMetadataKeys(cfg.Matrix.IndexedMetadataKeys)
+	pool.SetMetadataKeysResolver(s.resolveMetadataKeys)
+	pool.SetQuota(core.IndexQuota{Bytes: cfg.Storage.IndexQuotaBytes, Policy: cfg.Storage.IndexQuotaPolicy}, cfg.Storage.IndexQuotaGrace)
+	pool.SetQuotaResolver(s.resolveQuota)
+	pool.SetSentimentMinASCII(cfg.Sentiment.MinASCIILetterRatio)
+	pool.SetSentimentResolver(s.resolveSentiment)
+	pool.SetAppendOnlyResolver(s.resolveAppendOnly)
+	pool.SetSimilarityResolver(s.resolveSimilarity)
+	if cfg.Subscriptions.Enabled {
+		s.newSubscriptions(cfg.Subscriptions)
+	}
+	if cfg.Shares.Enabled {
+		s.newShares(cfg.Shares)
+	}
+	if cfg.Sessions.Enabled {
+		s.sessions = session.NewStore(cfg.Sessions.TTL, cfg.Sessions.MaxNeuronsPerIndex)
+	}
+	if cfg.Storage.DiskCheckInterval > 0 {
+		s.disk = persistence.NewDiskMonitor(cfg.Storage.DataPath, uint64(cfg.Storage.WarnFreeBytes), uint64(cfg.Storage.MinFreeBytes), nil)
+		s.disk.Start(cfg.Storage.DiskCheckInterval)
+		pool.SetDiskMonitor(s.disk)
+	}
+	if cfg.Prefetch.Enabled {
+		pool.SetPrefetchPolicy(cfg.Prefetch.Grace, cfg.Prefetch.MaxLoadedIndexes)
+		s.prefetchLimiter = newWindowLimiter(cfg.Prefetch.RateLimitRequests, cfg.Prefetch.RateLimitWindow)
+	}
 
 	mux := http.NewServeMux()
 
@@ -106,6 +278,61 @@ func NewServer(
 	mux.HandleFunc("/v1/recall", s.handleRecall)  // Memory scanning
 	mux.HandleFunc("/v1/fire/", s.handleFire)     // Neural firing
 
+	// Forgetting every memory matching a metadata filter, e.g. a whole
+	// conversation
+	mux.HandleFunc("/v1/forget", s.handleForgetByMetadata)
+
+	// Attachment blobs, referenced from neurons by hash
+	mux.HandleFunc(attachmentsPath, s.handleAttachmentUpload)
+	mux.HandleFunc(attachmentsPath+"/", s.handleAttachment)
+
+	// Pinned neurons are protected from decay and pruning
+	mux.HandleFunc("/v1/pin/", s.handlePin)
+	mux.HandleFunc("/v1/pins", s.handlePins)
+
+	// Forgotten neurons stay restorable for the recycle window
+	mux.HandleFunc("/v1/recycle", s.handleRecycle)
+	mux.HandleFunc("/v1/recycle/", s.handleRecycled)
+
+	// Working memory: session-scoped neurons that are never persisted
+	if s.sessions != nil {
+		mux.HandleFunc("/v1/sessions", s.handleSessions)
+		mux.HandleFunc("/v1/sessions/", s.handleSessions)
+	}
+
+	// Batches of memories in one operation
+	mux.HandleFunc(importBatchPath, s.handleImportBatch)
+
+	// Markdown vaults become linked neurons
+	mux.HandleFunc(importMarkdownPath, s.handleImportMarkdown)
+
+	// Supersede chains: the edit history of a memory
+	mux.HandleFunc("/v1/history/", s.handleHistory)
+
+	// Change feed for client-side mirrors
+	mux.HandleFunc("/v1/sync", s.handleSync)
+	mux.HandleFunc("/v1/conflicts", s.handleConflicts)
+
+	// Scheduled queries that write their results back as memories
+	if s.subscriptions != nil {
+		mux.HandleFunc("/v1/subscriptions", s.handleSubscriptions)
+		mux.HandleFunc("/v1/subscriptions/", s.handleSubscriptions)
+	}
+
+	// Read-only links to a filtered slice of an index
+	if s.shares != nil {
+		mux.HandleFunc("/v1/shares", s.handleShares)
+		mux.HandleFunc("/v1/shares/", s.handleShares)
+		mux.HandleFunc(sharedPrefix, s.handleShared)
+		mux.HandleFunc("/v1/badges", s.handleBadges)
+		mux.HandleFunc(badgePrefix, s.handleBadge)
+	}
+
+	// Hints that load dormant indexes ahead of expected queries
+	if s.prefetchLimiter != nil {
+		mux.HandleFunc("/v1/prefetch", s.handlePrefetch)
+	}
+
 	// MongoDB-like command endpoint
 	mux.HandleFunc("/v1/command", s.handleCommand)
 
@@ -117,15 +344,31 @@ func NewServer(
 
 	// Synapses endpoint for graph visualization
 	mux.HandleFunc("/v1/synapses", s.handleSynapses)
+	mux.HandleFunc("/v1/synapses/prune-plan", s.handlePrunePlan)
 
 	// Graph data endpoint (neurons + synapses for visualization)
 	mux.HandleFunc("/v1/graph", s.handleGraph)
+	mux.HandleFunc("/v1/graph/stats", s.handleGraphStats)
+	mux.HandleFunc("/v1/graph/summary", s.handleGraphSummary)
 
 	// Activity log endpoint
 	mux.HandleFunc("/v1/activity", s.handleActivity)
 
+	// Weighted random sampling for memory replay
+	mux.HandleFunc("/v1/sample", s.handleSample)
+
+	// What consolidation promoted, and why
+	mux.HandleFunc("/v1/promotions", s.handlePromotions)
+
+	// A time-boxed metadata boost for every retrieval of an index
+	mux.HandleFunc("/v1/focus", s.handleFocus)
+
+	// API behavior versions
+	mux.HandleFunc("/v1/versions", s.handleAPIVersions)
+
 	// UUID Registry
 	mux.HandleFunc("/v1/registry/find-or-create", s.handleRegistryFindOrCreate)
+	mux.HandleFunc("/v1/registry/import-active", s.handleRegistryImportActive)
 	mux.HandleFunc("/v1/registry/", s.handleRegistry)
 	mux.HandleFunc("/v1/registry", s.handleRegistry)
 
@@ -139,12 +382,13 @@ func NewServer(
 		}
 
 		mcpHandler, err := mcpapi.NewHandler(mcpapi.Config{
-			APIKey:         cfg.MCP.APIKey,
-			Stateless:      cfg.MCP.Stateless,
-			RateLimitRPS:   cfg.MCP.RateLimitRPS,
-			RateLimitBurst: cfg.MCP.RateLimitBurst,
-			EnablePrompts:  cfg.MCP.EnablePrompts,
-			AllowedTools:   cfg.MCP.AllowedTools,
+			APIKey:          cfg.MCP.APIKey,
+			Stateless:       cfg.MCP.Stateless,
+			RateLimitRPS:    cfg.MCP.RateLimitRPS,
+			RateLimitBurst:  cfg.MCP.RateLimitBurst,
+			EnablePrompts:   cfg.MCP.EnablePrompts,
+			AllowedTools:    cfg.MCP.AllowedTools,
+			MaxToolDuration: time.Duration(cfg.MCP.MaxToolDurationMS) * time.Millisecond,
 		}, newMCPBackend(s))
 		if err != nil {
 			log.Printf("⚠ MCP endpoint disabled: %v", err)
@@ -155,22 +399,40 @@ func NewServer(
 		}
 	}
 
-	// Admin endpoints (gated by admin.enabled)
+	// Admin endpoints (gated by admin.enabled). Viewers may GET every
+	// route; writes need at least the role passed to readOr.
 	if cfg.Admin.Enabled {
 		mux.HandleFunc("/admin/login", s.handleAdminLogin)
-		mux.HandleFunc("/admin/indexes", s.requireAdmin(s.handleAdminUsers))
-		mux.HandleFunc("/admin/indexes/", s.requireAdmin(s.handleAdminIndexOps))
-		mux.HandleFunc("/v1/config", s.requireAdmin(s.handleConfig))
-		mux.HandleFunc("/admin/config", s.requireAdmin(s.handleConfig))
-		mux.HandleFunc("/admin/daemons", s.requireAdmin(s.handleAdminDaemons))
-		mux.HandleFunc("/admin/daemons/", s.requireAdmin(s.handleAdminDaemonOps))
-		mux.HandleFunc("/admin/gc", s.requireAdmin(s.handleAdminGC))
-		mux.HandleFunc("/admin/persist", s.requireAdmin(s.handleAdminPersist))
+		mux.HandleFunc("/admin/logout", s.handleAdminLogout)
+		mux.HandleFunc("/admin/indexes", s.requireRole(readOr(roleAdmin), s.handleAdminUsers))
+		mux.HandleFunc("/admin/indexes/", s.requireRole(indexOpsPolicy, s.handleAdminIndexOps))
+		mux.HandleFunc("/v1/config", s.requireRole(readOr(roleAdmin), s.handleConfig))
+		mux.HandleFunc("/admin/config", s.requireRole(readOr(roleAdmin), s.handleConfig))
+		mux.HandleFunc("/admin/daemons", s.requireRole(readOr(roleOperator), s.handleAdminDaemons))
+		mux.HandleFunc("/admin/daemons/", s.requireRole(readOr(roleOperator), s.handleAdminDaemonOps))
+		mux.HandleFunc("/admin/stats", s.requireRole(readOr(roleAdmin), s.handleAdminStats))
+		mux.HandleFunc("/admin/memory", s.requireRole(readOr(roleAdmin), s.handleAdminMemory))
+		mux.HandleFunc("/admin/retention/", s.requireRole(readOr(roleAdmin), s.handleAdminRetention))
+		mux.HandleFunc("/admin/gc", s.requireRole(readOr(roleOperator), s.handleAdminGC))
+		mux.HandleFunc("/admin/persist", s.requireRole(readOr(roleOperator), s.handleAdminPersist))
+		mux.HandleFunc("/admin/drain", s.requireRole(readOr(roleOperator), s.handleAdminDrain))
+		mux.HandleFunc("/admin/undrain", s.requireRole(readOr(roleOperator), s.handleAdminUndrain))
+		mux.HandleFunc(migrateStoragePath, s.requireRole(readOr(roleAdmin), s.handleMigrateStorage))
+		mux.HandleFunc("/admin/search-metrics", s.requireRole(readOr(roleAdmin), s.handleAdminSearchMetrics))
+		mux.HandleFunc("/admin/models", s.requireRole(readOr(roleAdmin), s.handleAdminModels))
+		mux.HandleFunc("/admin/info", s.requireRole(readOr(roleAdmin), s.handleAdminInfo))
+		mux.HandleFunc("/admin/startup-report", s.requireRole(readOr(roleAdmin), s.handleAdminStartupReport))
+		mux.HandleFunc("/admin/replication", s.requireRole(readOr(roleAdmin), s.handleAdminReplication))
+		mux.HandleFunc("/admin/shadow/mismatches", s.requireRole(readOr(roleAdmin), s.handleAdminShadowMismatches))
+		mux.HandleFunc("/admin/consistency", s.requireRole(readOr(roleAdmin), s.handleAdminConsistency))
+		mux.HandleFunc("/admin/deadletters", s.requireRole(readOr(roleAdmin), s.handleAdminDeadLetters))
+		mux.HandleFunc("/admin/deadletters/", s.requireRole(readOr(roleAdmin), s.handleAdminDeadLetter))
+		mux.HandleFunc("/admin/consistency/repair", s.requireRole(readOr(roleAdmin), s.handleAdminConsistencyRepair))
 	}
 
 	s.httpServer = &http.Server{
 		Addr:         addr,
-		Handler:      s.withMiddleware(mux),
+		Handler:      s.withTracing(s.withMiddleware(mux)),
 		ReadTimeout:  cfg.Security.ReadTimeout,
 		WriteTimeout: cfg.Security.WriteTimeout,
 	}
@@ -183,7 +445,50 @@ func (s *Server) SetDaemonManager(dm *daemon.DaemonManager) {
 	s.daemons = dm
 }
 
-// withMiddleware adds common middleware (CORS, content-type, request body limit, logging).
+// SetTaskRunner binds the runner of the background tasks outside the
+// daemon manager, reported by /admin/daemons.
+func (s *Server) SetTaskRunner(r *core.TaskRunner) {
+	s.tasks = r
+}
+
+// SetVectorHealth binds the default model's health monitor, reported by
+// /health and the startup report.
+func (s *Server) SetVectorHealth(m *vector.HealthMonitor) {
+	s.vectorHealth = m
+}
+
+// SetVectorBreaker binds the default model's circuit breaker, reported by
+// /health and the stats endpoints.
+func (s *Server) SetVectorBreaker(b *vector.ResilientEmbedder) {
+	s.vectorBreaker = b
+}
+
+// vectorStats reports the circuit breakers around the embedding models,
+// or nil without a vector layer.
+func (s *Server) vectorStats() map[string]any {
+	out := map[string]any{}
+	if s.vectorBreaker != nil {
+		out["breaker"] = s.vectorBreaker.Status()
+	}
+	if models := s.pool.VectorModels(); models != nil {
+		breakers := map[string]vector.BreakerStatus{}
+		for _, m := range models.Models() {
+			if status, ok := models.Breaker(m.Name); ok {
+				breakers[m.Name] = status
+			}
+		}
+		if len(breakers) > 0 {
+			out["models"] = breakers
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// withMiddleware adds common middleware (CORS, rate and concurrency limits,
+// content-type, request body limit, logging).
 func (s *Server) withMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if s.isMCPPath(r.URL.Path) {
@@ -192,6 +497,7 @@ func (s *Server) withMiddleware(next http.Handler) http.Handler {
 			log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
 			return
 		}
+		shared := isSharedPath(r.URL.Path)
 
 		// CORS — driven by config
 		// AllowedOrigins may be comma-separated; match against the request Origin header.
@@ -213,14 +519,15 @@ func (s *Server) withMiddleware(next http.Handler) http.Handler {
 			}
 		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Index-ID, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Index-ID, Authorization, "+apiVersionHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		if !s.allowRequestByRateLimit(r) {
+		// Shared links are limited per share by their handler.
+		if !shared && !s.allowRequestByRateLimit(r) {
 			retryAfter := int(s.rateLimitWindow.Seconds())
 			if retryAfter < 1 {
 				retryAfter = 1
@@ -230,18 +537,82 @@ func (s *Server) withMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Request body size limit
-		if s.config.Security.MaxRequestBody > 0 && r.Body != nil {
+		// Global per-endpoint-class concurrency cap. State waits are idle
+		// for most of their life and are capped per index instead.
+		if r.URL.Path != brainStateWaitPath {
+			class := classifyEndpoint(r.URL.Path)
+			limiter := s.concurrency.classes[class]
+			if !limiter.acquire(r.Context(), s.concurrency.queueTimeout) {
+				limiter.setHeaders(w.Header(), class)
+				w.Header().Set("Retry-After", strconv.Itoa(s.concurrency.retryAfterSeconds()))
+				apierr.ServiceUnavailable(w, fmt.Sprintf("too many concurrent %s requests", class))
+				return
+			}
+			defer limiter.release()
+			limiter.setHeaders(w.Header(), class)
+		}
+
+		// Below storage.minFreeBytes the server is read-only.
+		if s.refuseReadOnly(w, r) {
+			return
+		}
+
+		// A storage migration's switchover holds mutations off.
+		done, refused := s.refuseMigrating(w, r)
+		if refused {
+			return
+		}
+		defer done()
+
+		// Request body size limit. Imports upload whole vaults or index
+		// slices and attachments are blobs; both have their own.
+		if isImportPath(r.URL.Path) && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, s.config.Import.MaxUploadBytes)
+		} else if r.URL.Path == attachmentsPath && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, s.config.Security.MaxAttachmentBytes)
+		} else if s.config.Security.MaxRequestBody > 0 && r.Body != nil {
 			r.Body = http.MaxBytesReader(w, r.Body, s.config.Security.MaxRequestBody)
 		}
 
+		// Behavior version: the client's pick, or the latest.
+		version, err := s.resolveAPIVersion(r.Header.Get(apiVersionHeader))
+		if err != nil {
+			apierr.BadRequest(w, apierr.CodeUnsupportedVersion, err.Error())
+			return
+		}
+		w.Header().Set(apiVersionHeader, version.Name)
+
+		// Warnings raised from here on are added to a JSON success body.
+		ctx, warnings := apierr.WithWarnings(withAPIVersion(r.Context(), version))
+		r = r.WithContext(ctx)
+		ww := &warningWriter{ResponseWriter: w, warnings: warnings}
+		defer ww.finish()
+		w = ww
+
+		// Index ID sources: refuse to guess between a header and query
+		// parameter that disagree. Shared links take their index from the
+		// token and ignore both.
+		if !shared {
+			if _, ignored, conflict := s.resolveIndexID(r); conflict {
+				header, query := indexIDSources(r)
+				apierr.IndexIDConflict(w, header, query)
+				return
+			} else if ignored != "" {
+				message := ignored + " ignored (server.indexIdSource=" + s.config.Server.IndexIDSource + ")"
+				w.Header().Set(warningHeader, message)
+				apierr.Warn(ctx, apierr.WarnFieldsIgnored, message, map[string]any{"fields": strings.Fields(ignored)[:1]})
+			}
+		}
+
 		// Content-Type
 		w.Header().Set("Content-Type", "application/json")
 
 		// Logging
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
+		if !s.mirrorRequest(w, r, next) {
+			next.ServeHTTP(w, r)
+		}
+		log.Printf("%s %s %v", r.Method, share.Redact(r.URL.Path), time.Since(start))
 	})
 }
 
@@ -255,33 +626,13 @@ func (s *Server) isMCPPath(path string) bool {
 	return strings.HasPrefix(path, s.mcpPath+"/")
 }
 
-// requireAdmin wraps a handler with admin Basic-Auth verification.
-// The client must send an Authorization header: Basic base64(user:password).
-func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		user, pass, ok := r.BasicAuth()
-		if !ok {
-			w.Header().Set("WWW-Authenticate", `Basic realm="qubicdb admin"`)
-			apierr.Unauthorized(w, "admin authentication required")
-			return
-		}
-
-		// Constant-time comparison to prevent timing attacks.
-		userHash := sha256.Sum256([]byte(user))
-		passHash := sha256.Sum256([]byte(pass))
-		expectedUserHash := sha256.Sum256([]byte(s.config.Admin.User))
-		expectedPassHash := sha256.Sum256([]byte(s.config.Admin.Password))
-
-		userMatch := subtle.ConstantTimeCompare(userHash[:], expectedUserHash[:]) == 1
-		passMatch := subtle.ConstantTimeCompare(passHash[:], expectedPassHash[:]) == 1
-
-		if !userMatch || !passMatch {
-			apierr.Unauthorized(w, "invalid admin credentials")
-			return
-		}
-
-		next(w, r)
-	}
+// neuronDocument renders n in the canonical document shape used by every
+// HTTP and MCP endpoint, as the API version of ctx shapes it.
+func (s *Server) neuronDocument(ctx context.Context, n *core.Neuron) map[string]any {
+	return protocol.Document(n, protocol.DocumentOptions{
+		Fields:      protocol.DefaultDocumentFields,
+		OmitIDAlias: !apiVersionOf(ctx).Behavior.IDAlias,
+	})
 }
 
 // writeOperationError maps worker operation errors to HTTP API errors.
@@ -289,12 +640,28 @@ func (s *Server) writeOperationError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, core.ErrInvalidContent):
 		apierr.BadRequest(w, apierr.CodeInvalidContent, err.Error())
+	case errors.Is(err, core.ErrInvalidEncoding):
+		apierr.BadRequest(w, apierr.CodeInvalidEncoding, err.Error())
 	case errors.Is(err, core.ErrInvalidQuery):
 		apierr.BadRequest(w, apierr.CodeQueryRequired, err.Error())
 	case errors.Is(err, core.ErrContentTooLarge):
 		apierr.PayloadTooLarge(w, err.Error())
 	case errors.Is(err, core.ErrNeuronNotFound):
 		apierr.NotFound(w, apierr.CodeNeuronNotFound, err.Error())
+	case errors.Is(err, core.ErrPinLimit):
+		apierr.Conflict(w, apierr.CodePinLimit, fmt.Sprintf("%v (pins.maxPerIndex=%d)", err, s.config.Pins.MaxPerIndex))
+	case errors.Is(err, core.ErrSupersedeCycle):
+		apierr.Conflict(w, apierr.CodeSupersedeCycle, err.Error())
+	case errors.Is(err, core.ErrSupersedeConflict):
+		apierr.Conflict(w, apierr.CodeSupersedeConflict, err.Error())
+	case errors.Is(err, core.ErrQuotaExceeded):
+		writeQuotaError(w, err)
+	case errors.Is(err, concurrency.ErrOperationPanic):
+		writePanicError(w, err)
+	case errors.Is(err, core.ErrIndexQuarantined):
+		writeQuarantineError(w, err)
+	case errors.Is(err, core.ErrAppendOnly):
+		writeAppendOnlyError(w, err.Error())
 	default:
 		apierr.Internal(w, err.Error())
 	}
@@ -313,6 +680,28 @@ func (s *Server) decodeJSONRequest(w http.ResponseWriter, r *http.Request, dst a
 	return true
 }
 
+// readContentBody reads a request body that carries neuron content. JSON
+// decoding silently replaces invalid UTF-8 with U+FFFD, so the raw bytes are
+// checked first: malformed payloads are rejected unless write.sanitizeContent
+// is enabled, in which case the decoder's replacement is accepted.
+func (s *Server) readContentBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			apierr.PayloadTooLarge(w, err.Error())
+			return nil, false
+		}
+		apierr.InvalidJSON(w)
+		return nil, false
+	}
+	if !utf8.Valid(body) && !core.ContentSanitizationEnabled() {
+		apierr.BadRequest(w, apierr.CodeInvalidEncoding, core.ErrInvalidEncoding.Error())
+		return nil, false
+	}
+	return body, true
+}
+
 func clampPositive(value, fallback, maxValue int) int {
 	if value <= 0 {
 		value = fallback
@@ -334,24 +723,104 @@ func parsePositiveQueryInt(raw string) int {
 	return v
 }
 
-func (s *Server) allowRequestByRateLimit(r *http.Request) bool {
-	if !s.rateLimitEnabled || s.rateLimitRequests <= 0 || s.rateLimitWindow <= 0 {
-		return true
+// queryParser reads query parameters. A strict parser records the first
+// malformed value as err; a lenient one ignores it, as GET /v1/search did
+// before API version 2025-01.
+type queryParser struct {
+	q      url.Values
+	strict bool
+	err    error
+}
+
+// positiveInt returns the integer parameter name, or 0 when it is absent or
+// malformed.
+func (p *queryParser) positiveInt(name string) int {
+	raw := p.q.Get(name)
+	v := parsePositiveQueryInt(raw)
+	if p.strict && raw != "" && v < 1 && p.err == nil {
+		p.err = fmt.Errorf("%s must be a positive integer, got %q", name, raw)
 	}
+	return v
+}
 
-	key := r.RemoteAddr
-	if ip := strings.TrimSpace(r.Header.Get("X-Forwarded-For")); ip != "" {
-		parts := strings.Split(ip, ",")
-		key = strings.TrimSpace(parts[0])
-	} else if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
-		key = ip
-	} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil && host != "" {
-		key = host
+// flag reports whether the boolean parameter name is set. Lenient, only
+// "true" sets it; strict, it takes any strconv.ParseBool value.
+func (p *queryParser) flag(name string) bool {
+	raw := p.q.Get(name)
+	if !p.strict || raw == "" {
+		return raw == "true"
 	}
-	if key == "" {
-		key = "unknown"
+	v, err := strconv.ParseBool(raw)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("%s must be true or false, got %q", name, raw)
+	}
+	return v
+}
+
+// optionalFlag is flag for a parameter whose absence means something:
+// nil when name is not set.
+func (p *queryParser) optionalFlag(name string) *bool {
+	if !p.q.Has(name) {
+		return nil
+	}
+	v := p.flag(name)
+	return &v
+}
+
+// parseConsistency reports whether a read asks for strong consistency
+// ("strong") rather than the default ("eventual" or empty). ok is false for
+// any other value.
+func parseConsistency(raw string) (strong, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "eventual":
+		return false, true
+	case "strong":
+		return true, true
+	}
+	return false, false
+}
+
+// writeConsistencyError reports an unknown consistency value.
+func writeConsistencyError(w http.ResponseWriter) {
+	apierr.BadRequest(w, apierr.CodeBadRequest, "consistency must be eventual or strong")
+}
+
+// parseSearchMode normalizes a search mode; ok is false for anything but
+// "exact" or empty.
+func parseSearchMode(raw string) (mode string, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case engine.SearchModeDefault:
+		return engine.SearchModeDefault, true
+	case engine.SearchModeExact:
+		return engine.SearchModeExact, true
+	}
+	return "", false
+}
+
+// parseSynapseTypes splits comma-separated lists of synapse types into one
+// list. err names the first type that is not a valid synapse type.
+func parseSynapseTypes(name string, values []string) ([]string, error) {
+	var types []string
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t == "" {
+				continue
+			}
+			if !core.ValidSynapseType(t) {
+				return nil, fmt.Errorf("%s: %q is not a synapse type", name, t)
+			}
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+func (s *Server) allowRequestByRateLimit(r *http.Request) bool {
+	if !s.rateLimitEnabled || s.rateLimitRequests <= 0 || s.rateLimitWindow <= 0 {
+		return true
 	}
 
+	key := clientKey(r)
 	now := time.Now()
 	s.rateLimitMu.Lock()
 	defer s.rateLimitMu.Unlock()
@@ -369,6 +838,24 @@ func (s *Server) allowRequestByRateLimit(r *http.Request) bool {
 	return true
 }
 
+// clientKey identifies the client of r for rate limiting: the first
+// X-Forwarded-For address, then X-Real-IP, then the remote host.
+func clientKey(r *http.Request) string {
+	key := r.RemoteAddr
+	if ip := strings.TrimSpace(r.Header.Get("X-Forwarded-For")); ip != "" {
+		parts := strings.Split(ip, ",")
+		key = strings.TrimSpace(parts[0])
+	} else if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		key = ip
+	} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil && host != "" {
+		key = host
+	}
+	if key == "" {
+		key = "unknown"
+	}
+	return key
+}
+
 // Start starts the server. Uses TLS if configured.
 func (s *Server) Start() error {
 	if s.config.Security.TLSCert != "" && s.config.Security.TLSKey != "" {
@@ -381,23 +868,67 @@ func (s *Server) Start() error {
 
 // Stop gracefully stops the server
 func (s *Server) Stop(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if s.scheduler != nil {
+		s.scheduler.Stop()
+	}
+	if s.shadow != nil {
+		s.shadow.Close()
+	}
+	if s.replication != nil {
+		s.pool.SetMutationObserver(nil)
+		s.replication.Close()
+	}
+	if s.sessions != nil {
+		s.sessions.Close()
+	}
+	if s.disk != nil {
+		s.disk.Close()
+	}
+	return err
 }
 
 // getIndexID extracts index ID from request.
 func (s *Server) getIndexID(r *http.Request) core.IndexID {
-	// Header takes priority
-	if id := r.Header.Get("X-Index-ID"); id != "" {
-		return core.IndexID(id)
+	id, _, _ := s.resolveIndexID(r)
+	return id
+}
+
+// indexIDSources returns the index ID named by the X-Index-ID header and by
+// the indexId (or index_id) query parameter.
+func indexIDSources(r *http.Request) (header, query string) {
+	header = r.Header.Get("X-Index-ID")
+	query = r.URL.Query().Get("indexId")
+	if query == "" {
+		query = r.URL.Query().Get("index_id")
+	}
+	return header, query
+}
+
+// resolveIndexID picks the request's index ID from the sources allowed by
+// server.indexIdSource. ignored names a source that was set but is not
+// honored; conflict reports a header and query parameter that disagree.
+func (s *Server) resolveIndexID(r *http.Request) (id core.IndexID, ignored string, conflict bool) {
+	header, query := indexIDSources(r)
+	switch s.config.Server.IndexIDSource {
+	case "header":
+		if query != "" {
+			ignored = "index_id query parameter"
+		}
+		return core.IndexID(header), ignored, false
+	case "query":
+		if header != "" {
+			ignored = "X-Index-ID header"
+		}
+		return core.IndexID(query), ignored, false
 	}
-	// Fallback to query parameter
-	if id := r.URL.Query().Get("indexId"); id != "" {
-		return core.IndexID(id)
+	if header != "" && query != "" && header != query {
+		return "", "", true
 	}
-	if id := r.URL.Query().Get("index_id"); id != "" {
-		return core.IndexID(id)
+	if header != "" {
+		return core.IndexID(header), "", false
 	}
-	return ""
+	return core.IndexID(query), "", false
 }
 
 // getWorker gets or creates a worker for the index (requires registered UUID).
@@ -409,6 +940,7 @@ func (s *Server) getWorker(indexID core.IndexID) (*concurrency.BrainWorker, erro
 
 	// Check UUID is registered (only when registry guard is enabled)
 	if s.config.Registry.Enabled && !s.registry.Exists(string(indexID)) {
+		s.warnGuardRejection(indexID)
 		return nil, fmt.Errorf("%s: uuid not registered: %s", apierr.CodeUUIDNotRegistered, indexID)
 	}
 
@@ -422,6 +954,14 @@ func (s *Server) getWorker(indexID core.IndexID) (*concurrency.BrainWorker, erro
 func (s *Server) writeWorkerError(w http.ResponseWriter, err error) {
 	msg := err.Error()
 	switch {
+	case errors.Is(err, core.ErrIndexLoading):
+		// The load goes on; by the time the client retries it has
+		// usually finished.
+		w.Header().Set("Retry-After", strconv.Itoa(loadRetryAfterSeconds(s.pool.LoadTimeout())))
+		apierr.Write(w, http.StatusServiceUnavailable, apierr.CodeIndexLoading, msg)
+	case errors.Is(err, core.ErrDraining):
+		w.Header().Set("Retry-After", strconv.Itoa(drainRetryAfterSeconds))
+		apierr.Write(w, http.StatusServiceUnavailable, apierr.CodeDraining, msg)
 	case strings.HasPrefix(msg, apierr.CodeIndexIDRequired):
 		apierr.IndexIDRequired(w)
 	case strings.HasPrefix(msg, apierr.CodeUUIDNotRegistered):
@@ -431,14 +971,94 @@ func (s *Server) writeWorkerError(w http.ResponseWriter, err error) {
 	}
 }
 
+// loadRetryAfterSeconds suggests a Retry-After value for requests that
+// timed out waiting for an index load: one more load timeout, at least 1s.
+func loadRetryAfterSeconds(timeout time.Duration) int {
+	if secs := int((timeout + time.Second - 1) / time.Second); secs > 1 {
+		return secs
+	}
+	return 1
+}
+
 // handleHealth returns health status
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	active := s.pool.ActiveCount()
-	json.NewEncoder(w).Encode(map[string]any{
+	doc := map[string]any{
 		"status":        "healthy",
 		"timestamp":     time.Now(),
 		"activeIndexes": active,
-	})
+	}
+	if s.vectorHealth != nil {
+		// A model that loaded but failed its warm-up probe cannot serve
+		// vector search, so the instance is not ready. A failed liveness
+		// probe only degrades search to lexical matching.
+		// An open circuit breaker degrades it the same way.
+		status := s.vectorHealth.Status()
+		if s.vectorBreaker != nil {
+			breaker := s.vectorBreaker.Status()
+			status.Breaker = &breaker
+		}
+		doc["vector"] = status
+		switch {
+		case !s.vectorHealth.Ready():
+			doc["status"] = "unavailable"
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case status.State == vector.HealthFailed, status.Breaker != nil && status.Breaker.State != vector.BreakerClosed:
+			doc["status"] = "degraded"
+		}
+	}
+	checks := map[string]any{}
+	if s.daemons != nil {
+		// A daemon that stopped succeeding leaves data undecayed or
+		// unpersisted; the instance still serves, degraded.
+		check := map[string]any{"status": "ok"}
+		if degraded := s.daemons.Degraded(); len(degraded) > 0 {
+			check = map[string]any{"status": "degraded", "degraded": degraded}
+			if doc["status"] == "healthy" {
+				doc["status"] = "degraded"
+			}
+		}
+		checks["daemons"] = check
+	}
+	if s.disk != nil {
+		// Low disk degrades the instance; below the floor it only serves
+		// reads, so it is not ready.
+		check, status := s.diskHealth()
+		checks["disk"] = check
+		switch {
+		case status == "unavailable" && doc["status"] != "unavailable":
+			doc["status"] = status
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case status == "degraded" && doc["status"] == "healthy":
+			doc["status"] = status
+		}
+	}
+	if failures := s.pool.FlushFailures(); len(failures) > 0 {
+		// An index that keeps failing to persist degrades the instance once
+		// it goes stale and makes it unready past the hard threshold.
+		check, status := flushHealth(failures)
+		checks["persistence"] = check
+		switch {
+		case status == "unavailable" && doc["status"] != "unavailable":
+			doc["status"] = status
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case status == "degraded" && doc["status"] == "healthy":
+			doc["status"] = status
+		}
+	}
+	if s.pool.Draining() {
+		// Resident indexes are still served, but new traffic belongs on
+		// another instance.
+		checks["drain"] = s.pool.DrainStatus()
+		if doc["status"] != "unavailable" {
+			doc["status"] = "draining"
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+	if len(checks) > 0 {
+		doc["checks"] = checks
+	}
+	json.NewEncoder(w).Encode(doc)
 }
 
 // handleBrain handles brain-level operations
@@ -466,22 +1086,28 @@ func (s *Server) handleBrain(w http.ResponseWriter, r *http.Request) {
 		if state == nil {
 			json.NewEncoder(w).Encode(map[string]any{"state": "dormant"})
 		} else {
-			stateNames := []string{"active", "idle", "sleeping", "dormant"}
 			json.NewEncoder(w).Encode(map[string]any{
-				"state":       stateNames[state.State],
+				"state":       lifecycle.StateName(state.State),
 				"lastInvoke":  state.LastInvoke,
 				"invokeCount": state.InvokeCount,
 			})
 		}
 
+	case path == "state/wait" && r.Method == "GET":
+		s.handleBrainStateWait(w, r, indexID)
+
 	case path == "stats" && r.Method == "GET":
 		worker, err := s.getWorker(indexID)
 		if err != nil {
 			s.writeWorkerError(w, err)
 			return
 		}
-		result, _ := worker.Submit(&concurrency.Operation{Type: concurrency.OpGetStats})
-		json.NewEncoder(w).Encode(result)
+		stats, err := s.tenantIndexStats(r.Context(), indexID, worker, refreshHealth(r))
+		if err != nil {
+			s.writeOperationError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(stats)
 
 	default:
 		apierr.NotFound(w, apierr.CodeNotFound, "unknown brain action")
@@ -506,13 +1132,30 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	depth, limit := defaultSearchDepth, defaultSearchLimit
 	var metadata map[string]string
 	var strict bool
+	var roles []string
+	var fallback fallbackOptions
+	var explain bool
+	var consistency string
+	var resolveSuperseded bool
+	var mode string
+	var lang string
+	var caseSensitive bool
+	var sessionID string
+	var anchorID string
+	anchorWeight := s.config.Search.AnchorWeight
+	var includeAnchor bool
+	var ignoreFocus bool
+	var reinforce bool
+	var spreadTypes []string
+	var expand *bool
 
 	if r.Method == "GET" {
 		query = r.URL.Query().Get("q")
-		if v := parsePositiveQueryInt(r.URL.Query().Get("depth")); v > 0 {
+		params := &queryParser{q: r.URL.Query(), strict: apiVersionOf(r.Context()).Behavior.StrictSearchParams}
+		if v := params.positiveInt("depth"); v > 0 {
 			depth = v
 		}
-		if v := parsePositiveQueryInt(r.URL.Query().Get("limit")); v > 0 {
+		if v := params.positiveInt("limit"); v > 0 {
 			limit = v
 		}
 		// Metadata filter from query params: metadata_<key>=<value>
@@ -524,14 +1167,60 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 				metadata[strings.TrimPrefix(k, "metadata_")] = vs[0]
 			}
 		}
-		strict = r.URL.Query().Get("strict") == "true"
+		strict = params.flag("strict")
+		explain = params.flag("explain")
+		resolveSuperseded = params.flag("resolve_superseded")
+		mode = r.URL.Query().Get("mode")
+		lang = r.URL.Query().Get("lang")
+		caseSensitive = params.flag("case_sensitive")
+		sessionID = r.URL.Query().Get("session_id")
+		consistency = r.URL.Query().Get("consistency")
+		anchorID = r.URL.Query().Get("anchor_id")
+		if raw := r.URL.Query().Get("anchor_weight"); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				apierr.BadRequest(w, apierr.CodeBadRequest, "anchor_weight must be a number between 0 and 1")
+				return
+			}
+			anchorWeight = v
+		}
+		includeAnchor = params.flag("include_anchor")
+		ignoreFocus = params.flag("ignore_focus")
+		reinforce = params.flag("reinforce")
+		expand = params.optionalFlag("expand_query")
+		spreadTypes = r.URL.Query()["spread_types"]
+		roles = parseRolesQuery(r.URL.Query())
+		fallback = parseFallbackQuery(r.URL.Query())
+		if params.err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, params.err.Error())
+			return
+		}
 	} else {
 		var req struct {
-			Query    string            `json:"query"`
-			Depth    int               `json:"depth,omitempty"`
-			Limit    int               `json:"limit,omitempty"`
-			Metadata map[string]string `json:"metadata,omitempty"`
-			Strict   bool              `json:"strict,omitempty"`
+			Query       string            `json:"query"`
+			Depth       int               `json:"depth,omitempty"`
+			Limit       int               `json:"limit,omitempty"`
+			Metadata    map[string]string `json:"metadata,omitempty"`
+			Strict      bool              `json:"strict,omitempty"`
+			Roles       []string          `json:"roles,omitempty"`
+			Explain     bool              `json:"explain,omitempty"`
+			Consistency string            `json:"consistency,omitempty"`
+
+			ResolveSuperseded bool   `json:"resolve_superseded,omitempty"`
+			Mode              string `json:"mode,omitempty"`
+			Lang              string `json:"lang,omitempty"`
+			CaseSensitive     bool   `json:"case_sensitive,omitempty"`
+			SessionID         string `json:"session_id,omitempty"`
+
+			AnchorID      string   `json:"anchor_id,omitempty"`
+			AnchorWeight  *float64 `json:"anchor_weight,omitempty"`
+			IncludeAnchor bool     `json:"include_anchor,omitempty"`
+
+			IgnoreFocus bool     `json:"ignore_focus,omitempty"`
+			Reinforce   bool     `json:"reinforce,omitempty"`
+			SpreadTypes []string `json:"spread_types,omitempty"`
+			ExpandQuery *bool    `json:"expand_query,omitempty"`
+			fallbackBody
 		}
 		if !s.decodeJSONRequest(w, r, &req) {
 			return
@@ -545,43 +1234,172 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		}
 		metadata = req.Metadata
 		strict = req.Strict
+		roles = req.Roles
+		explain = req.Explain
+		resolveSuperseded = req.ResolveSuperseded
+		mode = req.Mode
+		lang = req.Lang
+		caseSensitive = req.CaseSensitive
+		sessionID = req.SessionID
+		consistency = req.Consistency
+		anchorID = req.AnchorID
+		if req.AnchorWeight != nil {
+			anchorWeight = *req.AnchorWeight
+		}
+		includeAnchor = req.IncludeAnchor
+		ignoreFocus = req.IgnoreFocus
+		reinforce = req.Reinforce
+		spreadTypes = req.SpreadTypes
+		expand = req.ExpandQuery
+		fallback = req.options()
+	}
+
+	strong, ok := parseConsistency(consistency)
+	if !ok {
+		writeConsistencyError(w)
+		return
+	}
+	if mode, ok = parseSearchMode(mode); !ok {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "mode must be exact or omitted")
+		return
+	}
+	if lang != "" && !core.IsLanguageTag(lang) {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "lang must be a language tag such as tr or de-AT")
+		return
+	}
+	if caseSensitive && mode != engine.SearchModeExact {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "case_sensitive requires mode exact")
+		return
+	}
+	if spreadTypes, err = parseSynapseTypes("spread_types", spreadTypes); err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
 	}
 
 	depth = clampPositive(depth, defaultSearchDepth, maxSearchDepth)
 	limit = clampPositive(limit, defaultSearchLimit, maxSearchLimit)
+	if mode == engine.SearchModeExact {
+		depth = 0
+	}
 
-	if query == "" {
+	if query == "" && anchorID == "" {
 		apierr.QueryRequired(w)
 		return
 	}
+	if anchorID != "" {
+		switch {
+		case anchorWeight < 0 || anchorWeight > 1:
+			apierr.BadRequest(w, apierr.CodeBadRequest, "anchor_weight must be between 0 and 1")
+			return
+		case mode == engine.SearchModeExact:
+			apierr.BadRequest(w, apierr.CodeBadRequest, "anchor_id cannot be combined with mode exact")
+			return
+		}
+		// The anchor belongs to this index; other indexes have nothing to
+		// relate to it.
+		var ignored []string
+		if fallback.minResults > defaultFallbackMinResults {
+			ignored = append(ignored, "min_results")
+		}
+		if fallback.merge {
+			ignored = append(ignored, "merge")
+		}
+		if len(ignored) > 0 {
+			apierr.Warn(r.Context(), apierr.WarnFieldsIgnored, "fallback indexes are not consulted by anchored searches", map[string]any{"fields": ignored})
+		}
+		fallback.minResults = 0
+	}
+	if sessionID != "" && !s.requireSessions(w, sessionID) {
+		return
+	}
 
-	result, err := worker.Submit(&concurrency.Operation{
-		Type: concurrency.OpSearch,
-		Payload: concurrency.SearchRequest{
-			Query:    query,
-			Depth:    depth,
-			Limit:    limit,
-			Metadata: metadata,
-			Strict:   strict,
-		},
-	})
+	roles = s.resolveRoles(indexID, roles)
+
+	// Exact mode matches the query as written; there is nothing to expand.
+	if mode == engine.SearchModeExact {
+		if expand != nil && *expand {
+			apierr.Warn(r.Context(), apierr.WarnFieldsIgnored, "exact searches are not expanded", map[string]any{"fields": []string{"expand_query"}})
+		}
+		expand = new(bool)
+	}
+	expansion, err := s.expandQuery(r.Context(), worker, indexID, query, lang, expand, strong)
 	if err != nil {
 		s.writeOperationError(w, err)
 		return
 	}
 
-	neurons := result.([]*core.Neuron)
-	docs := make([]map[string]any, 0, len(neurons))
-	for _, n := range neurons {
-		docs = append(docs, protocol.NeuronToDocument(n, nil))
+	searchReq := concurrency.SearchRequest{
+		Query:    query,
+		Depth:    depth,
+		Limit:    limit,
+		Metadata: metadata,
+		Strict:   strict,
+		Roles:    roles,
+		Strong:   strong,
+
+		ResolveSuperseded: resolveSuperseded,
+		Mode:              mode,
+		MinScore:          fallback.minScore,
+		Language:          lang,
+		CaseSensitive:     caseSensitive,
+
+		AnchorID:      core.NeuronID(anchorID),
+		AnchorWeight:  anchorWeight,
+		IncludeAnchor: includeAnchor,
+
+		IgnoreFocus: ignoreFocus,
+		Reinforce:   reinforce,
+		SpreadTypes: spreadTypes,
+		Explain:     explain,
+	}
+	expansion.apply(&searchReq)
+	var coalesced bool
+	searchReq.Coalesced = &coalesced
+	focus, focused := worker.Focus(time.Now())
+	hits, consulted, searchMode, err := s.searchWithFallback(r.Context(), worker, indexID, searchKindSearch, searchReq, fallback)
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+	if sessionID != "" {
+		hits = s.mergeSessionHits(r.Context(), worker, indexID, sessionID, searchReq, hits)
+	}
+	w.Header().Set(searchModeHeader, searchMode)
+	warnSearchMode(r.Context(), searchMode)
+	docs := make([]any, len(hits))
+	for i, h := range hits {
+		docs[i] = s.typedHitDocument(r.Context(), h, explain)
 	}
+	defer releaseNeuronDocuments(docs)
 
-	json.NewEncoder(w).Encode(map[string]any{
-		"results": docs,
-		"count":   len(docs),
-		"query":   query,
-		"depth":   depth,
-	})
+	resp := map[string]any{
+		"count": len(docs),
+		"query": query,
+		"depth": depth,
+	}
+	if len(roles) > 0 {
+		resp["roles"] = roles
+	}
+	if mode != engine.SearchModeDefault {
+		resp["mode"] = mode
+	}
+	if anchorID != "" {
+		resp["anchorId"] = anchorID
+		resp["anchorWeight"] = anchorWeight
+	}
+	if len(consulted) > 0 {
+		resp["fallbackIndexes"] = consulted
+	}
+	if explain && focused && !ignoreFocus && mode != engine.SearchModeExact {
+		resp["focus"] = focus
+	}
+	if coalesced {
+		resp["coalesced"] = true
+	}
+	if expansion != nil {
+		resp["expansion"] = expansion.response()
+	}
+	writeDocumentLists(w, resp, map[string][]any{"results": docs})
 }
 
 // handleCommand handles MongoDB-like commands
@@ -598,14 +1416,8 @@ func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		var maxErr *http.MaxBytesError
-		if errors.As(err, &maxErr) {
-			apierr.PayloadTooLarge(w, err.Error())
-			return
-		}
-		apierr.InvalidJSON(w)
+	body, ok := s.readContentBody(w, r)
+	if !ok {
 		return
 	}
 	cmd, err := protocol.ParseCommand(body)
@@ -616,6 +1428,10 @@ func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
 
 	result := s.executor.Execute(worker, cmd)
 	if !result.Success {
+		if strings.HasPrefix(result.Error, core.ErrAppendOnly.Error()) {
+			writeAppendOnlyError(w, result.Error)
+			return
+		}
 		if strings.Contains(result.Error, "direct neuron mutation is disabled") {
 			apierr.BadRequest(w, apierr.CodeMutationDisabled, result.Error)
 			return
@@ -641,9 +1457,18 @@ func (s *Server) handleContext(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Cue       string `json:"cue"`       // Current user message/query
-		MaxTokens int    `json:"maxTokens"` // Context window budget
-		Depth     int    `json:"depth"`     // Spread depth
+		Cue       string   `json:"cue"`        // Current user message/query
+		MaxTokens int      `json:"maxTokens"`  // Context window budget
+		Depth     int      `json:"depth"`      // Spread depth
+		Roles     []string `json:"roles"`      // Authoring roles to include
+		Format    string   `json:"format"`     // Output format, or turn template to render turns with
+		GroupBy   string   `json:"groupBy"`    // Metadata key grouping "messages"
+		SessionID string   `json:"session_id"` // Working memory to include
+
+		IgnoreFocus bool  `json:"ignore_focus"` // Leave the index's focus out
+		Reinforce   bool  `json:"reinforce"`    // Co-fire the retrieved neurons as a group
+		ExpandQuery *bool `json:"expand_query"` // Add the terms the index associates with the cue
+		fallbackBody
 	}
 	if !s.decodeJSONRequest(w, r, &req) {
 		return
@@ -652,70 +1477,100 @@ func (s *Server) handleContext(w http.ResponseWriter, r *http.Request) {
 		apierr.QueryRequired(w)
 		return
 	}
+	opts, ok := s.contextOptions(req.Format, req.GroupBy)
+	if !ok {
+		apierr.BadRequest(w, apierr.CodeBadRequest, fmt.Sprintf("unknown format %q", req.Format))
+		return
+	}
+	if req.GroupBy != "" && opts.format != core.ContextFormatMessages {
+		apierr.BadRequest(w, apierr.CodeBadRequest, `groupBy applies to format "messages" only`)
+		return
+	}
+	if req.SessionID != "" && !s.requireSessions(w, req.SessionID) {
+		return
+	}
 
 	req.MaxTokens = clampPositive(req.MaxTokens, defaultContextTokens, maxContextTokens)
 	req.Depth = clampPositive(req.Depth, defaultContextDepth, maxContextDepth)
 
 	// Search based on cue
-	result, err := worker.Submit(&concurrency.Operation{
-		Type: concurrency.OpSearch,
-		Payload: concurrency.SearchRequest{
-			Query: req.Cue,
-			Depth: req.Depth,
-			Limit: 50, // Get more, then trim by tokens
-		},
-	})
+	roles := s.resolveRoles(indexID, req.Roles)
+	expansion, err := s.expandQuery(r.Context(), worker, indexID, req.Cue, "", req.ExpandQuery, false)
 	if err != nil {
 		s.writeOperationError(w, err)
 		return
 	}
 
-	neurons := result.([]*core.Neuron)
-
-	// Assemble context string
-	var context strings.Builder
-	tokenEstimate := 0
-	included := 0
-
-	for _, n := range neurons {
-		// Approximate token count (~4 characters per token)
-		neuronTokens := len(n.Content) / 4
-		if tokenEstimate+neuronTokens > req.MaxTokens {
-			break
-		}
-
-		if context.Len() > 0 {
-			context.WriteString("\n---\n")
-		}
-
-		context.WriteString(n.Content)
+	searchReq := concurrency.SearchRequest{
+		Query: req.Cue,
+		Depth: req.Depth,
+		Limit: 50, // Get more, then trim by tokens
+		Roles: roles,
 
-		// Add depth indicator
-		if n.Depth > 0 {
-			context.WriteString(fmt.Sprintf(" [depth:%d]", n.Depth))
-		}
+		IgnoreFocus: req.IgnoreFocus,
+		Reinforce:   req.Reinforce,
+	}
+	expansion.apply(&searchReq)
+	hits, consulted, searchMode, err := s.searchWithFallback(r.Context(), worker, indexID, searchKindContext, searchReq, req.options())
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+	if req.SessionID != "" {
+		hits = s.mergeSessionHits(r.Context(), worker, indexID, req.SessionID, searchReq, hits)
+	}
+	w.Header().Set(searchModeHeader, searchMode)
+	warnSearchMode(r.Context(), searchMode)
 
-		tokenEstimate += neuronTokens
-		included++
+	assembled := assembleContext(hits, req.MaxTokens, opts)
+	if assembled.included < len(hits) {
+		apierr.Warn(r.Context(), apierr.WarnResultsTruncated, fmt.Sprintf("%d of %d matching neurons fit in maxTokens=%d", assembled.included, len(hits), req.MaxTokens),
+			map[string]any{"included": assembled.included, "matched": len(hits), "maxTokens": req.MaxTokens})
 	}
 
-	json.NewEncoder(w).Encode(map[string]any{
-		"context":         context.String(),
-		"text":            context.String(),
-		"neuronsUsed":     included,
-		"neuronCount":     included,
-		"estimatedTokens": tokenEstimate,
-		"tokenCount":      tokenEstimate,
-		"cue":             req.Cue,
-	})
+	resp := contextResponse(assembled, opts.format)
+	resp["cue"] = req.Cue
+	if len(roles) > 0 {
+		resp["roles"] = roles
+	}
+	if req.Format != "" {
+		resp["format"] = req.Format
+	}
+	if len(consulted) > 0 {
+		resp["fallbackIndexes"] = consulted
+	}
+	if expansion != nil {
+		resp["expansion"] = expansion.response()
+	}
+	json.NewEncoder(w).Encode(resp)
 }
 
-// handleStats returns global statistics
-func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
-	json.NewEncoder(w).Encode(map[string]any{
-		"pool":      s.pool.Stats(),
-		"lifecycle": s.lifecycle.Stats(),
+// Search telemetry kinds, recorded with zero-result samples.
+const (
+	searchKindSearch  = "search"
+	searchKindContext = "context"
+)
+
+// runSearch submits an OpSearch and returns the neurons with their result
+// summary. When search telemetry is enabled the summary is also recorded
+// for the index.
+func (s *Server) runSearch(ctx context.Context, worker *concurrency.BrainWorker, indexID core.IndexID, kind string, req concurrency.SearchRequest) ([]*core.Neuron, engine.SearchStats, error) {
+	var stats engine.SearchStats
+	req.Stats = &stats
+
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
+		Type:    concurrency.OpSearch,
+		Payload: req,
+		Strong:  req.Strong,
 	})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	if s.searchMetrics != nil {
+		s.searchMetrics.Record(string(indexID), kind, req.Query, stats)
+	}
+	return result.([]*core.Neuron), stats, nil
 }
 
 // ============================================================
@@ -737,23 +1592,49 @@ func (s *Server) handleAdminLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify credentials from config using constant-time comparison.
-	userHash := sha256.Sum256([]byte(req.User))
-	passHash := sha256.Sum256([]byte(req.Password))
-	expectedUserHash := sha256.Sum256([]byte(s.config.Admin.User))
-	expectedPassHash := sha256.Sum256([]byte(s.config.Admin.Password))
-
-	userOK := subtle.ConstantTimeCompare(userHash[:], expectedUserHash[:]) == 1
-	passOK := subtle.ConstantTimeCompare(passHash[:], expectedPassHash[:]) == 1
-
-	if userOK && passOK {
+	role, ok := s.authenticate(w, r, req.User, req.Password)
+	if !ok {
+		return
+	}
+	if role == roleNone {
+		apierr.Unauthorized(w, "invalid credentials")
+		return
+	}
+	if s.adminSessions == nil {
 		json.NewEncoder(w).Encode(map[string]any{
 			"success": true,
+			"role":    role.String(),
 			"message": "authenticated — use Basic Auth for subsequent admin requests",
 		})
-	} else {
-		apierr.Unauthorized(w, "invalid credentials")
+		return
+	}
+	token, sess, err := s.adminSessions.Issue(req.User, role.String(), time.Now(), s.config.Admin.SessionTTL)
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"success":   true,
+		"role":      role.String(),
+		"token":     token,
+		"expiresAt": sess.Expires,
+		"message":   "authenticated — send the token as a Bearer token for subsequent admin requests; Basic Auth also works",
+	})
+}
+
+// handleAdminLogout revokes the session token the request carries, so it
+// is refused until it would have expired anyway.
+func (s *Server) handleAdminLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.MethodNotAllowed(w)
+		return
 	}
+	sess, ok := s.adminSession(w, r)
+	if !ok {
+		return
+	}
+	s.adminDenylist.Revoke(sess)
+	json.NewEncoder(w).Encode(map[string]any{"success": true})
 }
 
 // handleAdminUsers lists all active indexes
@@ -763,8 +1644,19 @@ func (s *Server) handleAdminUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	indexes := s.pool.ListIndexes()
-	json.NewEncoder(w).Encode(indexes)
+	if diverged, _ := strconv.ParseBool(r.URL.Query().Get("diverged")); diverged {
+		json.NewEncoder(w).Encode(s.pool.CheckDivergence())
+		return
+	}
+
+	switch by := r.URL.Query().Get("sort"); by {
+	case "":
+		json.NewEncoder(w).Encode(s.pool.ListIndexes())
+	case "memory", "id", "health":
+		s.writeIndexListing(w, by)
+	default:
+		apierr.BadRequest(w, apierr.CodeBadRequest, "sort must be memory, id or health")
+	}
 }
 
 // handleAdminIndexOps handles per-index admin operations.
@@ -784,13 +1676,52 @@ func (s *Server) handleAdminIndexOps(w http.ResponseWriter, r *http.Request) {
 
 	switch {
 	case action == "reset" && r.Method == "POST":
+		if !s.allowDestructiveAdmin(w, r, indexID, "reset") {
+			return
+		}
 		if err := s.pool.Truncate(indexID); err != nil {
 			apierr.Internal(w, err.Error())
 			return
 		}
 		s.lifecycle.RemoveIndex(indexID)
+		if s.sessions != nil {
+			s.sessions.DeleteIndex(indexID)
+		}
 		json.NewEncoder(w).Encode(map[string]any{"reset": true, "truncated": true, "indexId": indexID})
 
+	case action == "seed" && r.Method == "POST":
+		s.handleAdminSeed(w, r, indexID)
+
+	case action == "clone" && r.Method == "POST":
+		s.handleAdminClone(w, r, indexID)
+
+	case action == "migrate-turns" && r.Method == "POST":
+		s.handleAdminMigrateTurns(w, r, indexID)
+
+	case action == "versions" && r.Method == "GET":
+		s.handleAdminVersions(w, indexID)
+
+	case action == "as-of" && r.Method == "GET":
+		s.handleAdminAsOf(w, r, indexID)
+
+	case action == "restore" && r.Method == "POST":
+		s.handleAdminRestore(w, r, indexID)
+
+	case action == "resolve" && r.Method == "POST":
+		s.handleAdminResolve(w, r, indexID)
+
+	case action == "verify" && r.Method == "POST":
+		s.handleAdminVerify(w, r, indexID)
+
+	case action == "operations":
+		s.handleAdminOperations(w, r, indexID)
+
+	case action == "calibrate" && r.Method == "POST":
+		s.handleAdminCalibrate(w, r, indexID)
+
+	case action == "calibration" && r.Method == "GET":
+		s.handleAdminCalibration(w, indexID)
+
 	case action == "wake" && r.Method == "POST":
 		s.lifecycle.ForceWake(indexID)
 		json.NewEncoder(w).Encode(map[string]any{"woke": true, "indexId": indexID})
@@ -800,28 +1731,38 @@ func (s *Server) handleAdminIndexOps(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(map[string]any{"slept": true, "indexId": indexID})
 
 	case action == "export" && r.Method == "GET":
-		// Export index brain
-		worker, err := s.pool.Get(indexID)
-		if err != nil {
-			apierr.NotFound(w, apierr.CodeNotFound, "index not found")
-			return
-		}
-		result, _ := worker.Submit(&concurrency.Operation{Type: concurrency.OpGetStats})
-		json.NewEncoder(w).Encode(result)
+		s.handleAdminExport(w, r, indexID)
+
+	case action == "import" && r.Method == "POST":
+		s.handleAdminImport(w, r, indexID)
 
 	case action == "" && r.Method == "DELETE":
-		if err := s.pool.Truncate(indexID); err != nil {
+		if !s.allowDestructiveAdmin(w, r, indexID, "delete") {
+			return
+		}
+		// Registry entry and data file go together: if truncation fails the
+		// registry entry is restored.
+		registryDeleted, err := s.registry.DeleteWith(string(indexID), func() error {
+			return s.pool.Truncate(indexID)
+		})
+		if err != nil {
 			apierr.Internal(w, err.Error())
 			return
 		}
 		s.lifecycle.RemoveIndex(indexID)
-		registryDeleted := false
-		if s.registry.Exists(string(indexID)) {
-			if err := s.registry.Delete(string(indexID)); err != nil {
-				apierr.Internal(w, err.Error())
-				return
+		s.pool.StopJournal(indexID)
+		if s.subscriptions != nil {
+			if _, err := s.subscriptions.DeleteIndex(string(indexID)); err != nil {
+				log.Printf("⚠ failed to delete subscriptions of %s: %v", indexID, err)
+			}
+		}
+		if s.shares != nil {
+			if _, err := s.shares.DeleteIndex(string(indexID)); err != nil {
+				log.Printf("⚠ failed to delete shares of %s: %v", indexID, err)
 			}
-			registryDeleted = true
+		}
+		if s.sessions != nil {
+			s.sessions.DeleteIndex(indexID)
 		}
 		json.NewEncoder(w).Encode(map[string]any{
 			"deleted":         true,
@@ -837,11 +1778,19 @@ func (s *Server) handleAdminIndexOps(w http.ResponseWriter, r *http.Request) {
 			apierr.NotFound(w, apierr.CodeNotFound, "index not found")
 			return
 		}
-		result, _ := worker.Submit(&concurrency.Operation{Type: concurrency.OpGetStats})
+		result, _ := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpGetStats})
+		graphStats, _ := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpGraphStats})
+		health, _ := s.indexHealth(r.Context(), worker, refreshHealth(r), concurrency.PriorityInteractive)
 		state := s.lifecycle.GetBrainState(indexID)
 		json.NewEncoder(w).Encode(map[string]any{
-			"stats": result,
-			"state": state,
+			"stats":       result,
+			"graphStats":  graphStats,
+			"state":       state,
+			"memoryBytes": worker.Footprint(),
+			"disk":        s.diskReport(indexID),
+			"sentiment":   s.sentimentReport(indexID),
+			"appendOnly":  s.pool.AppendOnly(indexID),
+			"health":      health,
 		})
 
 	default:
@@ -849,38 +1798,87 @@ func (s *Server) handleAdminIndexOps(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleAdminDaemons returns daemon status
-func (s *Server) handleAdminDaemons(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		apierr.MethodNotAllowed(w)
+// handleAdminDaemons returns daemon status
+func (s *Server) handleAdminDaemons(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	if s.daemons == nil {
+		json.NewEncoder(w).Encode(map[string]any{
+			"status":  "unavailable",
+			"daemons": map[string]any{},
+		})
+		return
+	}
+
+	status := "running"
+	daemons := make(map[string]daemon.DaemonStatus)
+	for _, st := range s.daemons.Status() {
+		daemons[st.Name] = st
+		switch {
+		case st.Degraded:
+			status = "degraded"
+		case !st.Running && status == "running":
+			status = "stopped"
+		}
+	}
+	if status == "running" && s.daemons.Paused() {
+		status = "paused"
+	}
+	resp := map[string]any{
+		"status":  status,
+		"daemons": daemons,
+	}
+	if s.tasks != nil {
+		tasks := make(map[string]core.TaskStatus)
+		for _, st := range s.tasks.Status() {
+			tasks[st.Name] = st
+		}
+		resp["tasks"] = tasks
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleAdminDaemonMetrics serves daemon status as Prometheus text-format
+// metrics (GET /admin/daemons/metrics).
+func (s *Server) handleAdminDaemonMetrics(w http.ResponseWriter) {
+	if s.daemons == nil {
+		apierr.NotFound(w, apierr.CodeNotFound, "daemon manager not available in this runtime")
 		return
 	}
-
-	json.NewEncoder(w).Encode(map[string]any{
-		"status": "running",
-		"daemons": map[string]string{
-			"decay":       "running",
-			"consolidate": "running",
-			"prune":       "running",
-			"reorg":       "running",
-		},
-	})
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.daemons.WriteMetrics(w); err != nil {
+		log.Printf("⚠ daemon metrics: %v", err)
+	}
 }
 
 // handleAdminDaemonOps handles daemon control operations
 func (s *Server) handleAdminDaemonOps(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(r.URL.Path, "/admin/daemons/")
+	if action == "metrics" && r.Method == "GET" {
+		s.handleAdminDaemonMetrics(w)
+		return
+	}
 	if r.Method != "POST" {
 		apierr.MethodNotAllowed(w)
 		return
 	}
 
-	action := strings.TrimPrefix(r.URL.Path, "/admin/daemons/")
-
 	switch action {
-	case "pause":
-		json.NewEncoder(w).Encode(map[string]any{"paused": true})
-	case "resume":
-		json.NewEncoder(w).Encode(map[string]any{"resumed": true})
+	case "pause", "resume":
+		if s.daemons == nil {
+			apierr.NotFound(w, apierr.CodeNotFound, "daemon manager not available in this runtime")
+			return
+		}
+		if action == "pause" {
+			changed := s.daemons.Pause()
+			json.NewEncoder(w).Encode(map[string]any{"paused": true, "changed": changed})
+		} else {
+			changed := s.daemons.Resume()
+			json.NewEncoder(w).Encode(map[string]any{"resumed": true, "changed": changed})
+		}
 	default:
 		apierr.NotFound(w, apierr.CodeNotFound, "unknown daemon action")
 	}
@@ -888,34 +1886,23 @@ func (s *Server) handleAdminDaemonOps(w http.ResponseWriter, r *http.Request) {
 
 // handleSynapses returns all synapses for an index
 func (s *Server) handleSynapses(w http.ResponseWriter, r *http.Request) {
+	types, err := parseSynapseTypes("types", r.URL.Query()["types"])
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
 	worker, err := s.getWorker(s.getIndexID(r))
 	if err != nil {
 		s.writeWorkerError(w, err)
 		return
 	}
 
-	matrix := worker.Matrix()
-	matrix.RLock()
-	defer matrix.RUnlock()
-
-	type SynapseInfo struct {
-		ID          string  `json:"id"`
-		FromID      string  `json:"from_id"`
-		ToID        string  `json:"to_id"`
-		Weight      float64 `json:"weight"`
-		CoFireCount uint64  `json:"co_fire_count"`
-	}
-
-	synapses := make([]SynapseInfo, 0, len(matrix.Synapses))
-	for _, syn := range matrix.Synapses {
-		synapses = append(synapses, SynapseInfo{
-			ID:          string(syn.ID),
-			FromID:      string(syn.FromID),
-			ToID:        string(syn.ToID),
-			Weight:      syn.Weight,
-			CoFireCount: syn.CoFireCount,
-		})
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpGetSynapses, Payload: types})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
 	}
+	synapses := result.([]engine.SynapseInfo)
 
 	json.NewEncoder(w).Encode(map[string]any{
 		"synapses": synapses,
@@ -923,65 +1910,70 @@ func (s *Server) handleSynapses(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleGraph returns graph data (nodes + edges) for visualization
-func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+// handlePrunePlan reports which synapses the next prune pass would remove
+// and why, without removing them (GET /v1/synapses/prune-plan).
+func (s *Server) handlePrunePlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
 	worker, err := s.getWorker(s.getIndexID(r))
 	if err != nil {
 		s.writeWorkerError(w, err)
 		return
 	}
 
-	matrix := worker.Matrix()
-	matrix.RLock()
-	defer matrix.RUnlock()
-
-	type Node struct {
-		ID          string    `json:"id"`
-		Content     string    `json:"content"`
-		Energy      float64   `json:"energy"`
-		Depth       int       `json:"depth"`
-		AccessCount int       `json:"accessCount"`
-		Position    []float64 `json:"position"`
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpPrunePlan})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
 	}
-
-	type Edge struct {
-		Source      string  `json:"source"`
-		Target      string  `json:"target"`
-		Weight      float64 `json:"weight"`
-		CoFireCount uint64  `json:"coFireCount"`
+	plan := result.(synapse.PrunePlan)
+	if plan.Evictions == nil {
+		plan.Evictions = []synapse.Eviction{}
 	}
 
-	nodes := make([]Node, 0, len(matrix.Neurons))
-	for _, n := range matrix.Neurons {
-		nodes = append(nodes, Node{
-			ID:          string(n.ID),
-			Content:     n.Content,
-			Energy:      n.Energy,
-			Depth:       n.Depth,
-			AccessCount: int(n.AccessCount),
-			Position:    n.Position,
-		})
+	json.NewEncoder(w).Encode(map[string]any{
+		"evictions": plan.Evictions,
+		"count":     len(plan.Evictions),
+		"synapses":  plan.Synapses,
+		"remaining": plan.Synapses - len(plan.Evictions),
+	})
+}
+
+// handleGraph returns graph data (nodes + edges) for visualization
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	types, err := parseSynapseTypes("types", r.URL.Query()["types"])
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
 	}
 
-	edges := make([]Edge, 0, len(matrix.Synapses))
-	for _, syn := range matrix.Synapses {
-		edges = append(edges, Edge{
-			Source:      string(syn.FromID),
-			Target:      string(syn.ToID),
-			Weight:      syn.Weight,
-			CoFireCount: syn.CoFireCount,
-		})
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpGetGraph, Payload: types})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
 	}
+	graph := result.(engine.GraphDump)
 
 	json.NewEncoder(w).Encode(map[string]any{
-		"nodes": nodes,
-		"edges": edges,
+		"nodes": graph.Nodes,
+		"edges": graph.Edges,
 	})
 }
 
-// handleActivity returns recent brain activity for an index
-func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// handleGraphStats returns synapse graph health statistics for an index
+func (s *Server) handleGraphStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
 
 	worker, err := s.getWorker(s.getIndexID(r))
 	if err != nil {
@@ -989,75 +1981,59 @@ func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	matrix := worker.Matrix()
-	matrix.RLock()
-	defer matrix.RUnlock()
-
-	// Generate activity events from recent operations
-	type Event struct {
-		Timestamp string `json:"timestamp"`
-		Type      string `json:"type"`
-		Action    string `json:"action"`
-		Details   string `json:"details"`
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpGraphStats})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
 	}
+	json.NewEncoder(w).Encode(result)
+}
 
-	events := []Event{}
-	now := time.Now()
+// handleGraphSummary returns a grid overview of an index for
+// visualizations too large to draw edge by edge: neurons bucketed into
+// cells×cells cells with bundled synapses between them.
+func (s *Server) handleGraphSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
 
-	// Add neuron activity
-	for _, n := range matrix.Neurons {
-		if now.Sub(n.LastFiredAt) < 5*time.Minute {
-			events = append(events, Event{
-				Timestamp: n.LastFiredAt.Format(time.RFC3339),
-				Type:      "neuron",
-				Action:    "FIRED",
-				Details:   truncate(n.Content, 50),
-			})
-		}
-		if now.Sub(n.CreatedAt) < 5*time.Minute {
-			events = append(events, Event{
-				Timestamp: n.CreatedAt.Format(time.RFC3339),
-				Type:      "neuron",
-				Action:    "CREATED",
-				Details:   truncate(n.Content, 50),
-			})
-		}
+	types, err := parseSynapseTypes("types", r.URL.Query()["types"])
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
+		return
+	}
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
 	}
 
-	// Add synapse activity
-	for _, syn := range matrix.Synapses {
-		if now.Sub(syn.LastCoFire) < 5*time.Minute {
-			events = append(events, Event{
-				Timestamp: syn.LastCoFire.Format(time.RFC3339),
-				Type:      "synapse",
-				Action:    fmt.Sprintf("STRENGTHENED (%.2f)", syn.Weight),
-				Details:   fmt.Sprintf("co-fired %d times", syn.CoFireCount),
-			})
-		}
-		if now.Sub(syn.CreatedAt) < 5*time.Minute {
-			events = append(events, Event{
-				Timestamp: syn.CreatedAt.Format(time.RFC3339),
-				Type:      "synapse",
-				Action:    "FORMED",
-				Details:   fmt.Sprintf("weight: %.2f", syn.Weight),
-			})
-		}
+	cells := clampPositive(parsePositiveQueryInt(r.URL.Query().Get("cells")), engine.DefaultSummaryCells, engine.MaxSummaryCells)
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpGraphSummary, Payload: concurrency.GraphSummaryRequest{Cells: cells, Types: types}})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
 	}
+	json.NewEncoder(w).Encode(result)
+}
 
-	// Sort by timestamp descending
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].Timestamp > events[j].Timestamp
-	})
+// handleActivity returns recent brain activity for an index
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Limit to 100 most recent
-	if len(events) > 100 {
-		events = events[:100]
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
 	}
 
-	// Reverse to show oldest first (terminal style)
-	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
-		events[i], events[j] = events[j], events[i]
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpGetActivity})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
 	}
+	events := result.([]engine.ActivityEvent)
 
 	json.NewEncoder(w).Encode(map[string]any{
 		"events": events,
@@ -1065,13 +2041,6 @@ func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func truncate(s string, n int) string {
-	if len(s) <= n {
-		return s
-	}
-	return s[:n] + "..."
-}
-
 // ============================================================================
 // BRAIN-LIKE API ENDPOINTS
 // ============================================================================
@@ -1096,14 +2065,71 @@ func (s *Server) handleWrite(w http.ResponseWriter, r *http.Request) {
 		ParentID string            `json:"parent_id,omitempty"`
 		Metadata map[string]string `json:"metadata,omitempty"`
 		Tags     []string          `json:"tags,omitempty"`
+		Pinned   bool              `json:"pinned,omitempty"`
+
+		Supersedes string `json:"supersedes,omitempty"`
+
+		// Attachments are hashes of blobs uploaded to /v1/attachments.
+		Attachments []string `json:"attachments,omitempty"`
+
+		// Turn is the structured alternative to Content; Format names the
+		// turn template it prefers in context.
+		Turn   *turnBody `json:"turn,omitempty"`
+		Format string    `json:"format,omitempty"`
+
+		// Scope "session" writes to the working memory of SessionID
+		// instead; Links name persistent neurons it connects to.
+		Scope     string   `json:"scope,omitempty"`
+		SessionID string   `json:"session_id,omitempty"`
+		Links     []string `json:"links,omitempty"`
+
+		// RelatedIDs name existing neurons to link the new one to, with
+		// synapses of RelatedType (associative by default).
+		RelatedIDs  []string `json:"related_ids,omitempty"`
+		RelatedType string   `json:"related_type,omitempty"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		var maxErr *http.MaxBytesError
-		if errors.As(err, &maxErr) {
-			apierr.PayloadTooLarge(w, err.Error())
+	body, ok := s.readContentBody(w, r)
+	if !ok {
+		return
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		apierr.InvalidJSON(w)
+		return
+	}
+	req.Metadata = dropSystemMetadata(r.Context(), req.Metadata)
+	switch {
+	case req.Turn != nil && req.Content != "":
+		apierr.BadRequest(w, apierr.CodeBadRequest, "content and turn are mutually exclusive")
+		return
+	case req.Turn != nil:
+		content, metadata, err := s.turnWrite(*req.Turn, req.Format, req.Metadata)
+		if err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, err.Error())
 			return
 		}
-		apierr.InvalidJSON(w)
+		req.Content, req.Metadata = content, metadata
+	case req.Format != "":
+		apierr.BadRequest(w, apierr.CodeBadRequest, "format applies to turn writes only")
+		return
+	}
+	switch {
+	case req.Scope == scopeSession && (req.Pinned || req.Supersedes != "" || len(req.Attachments) > 0):
+		apierr.BadRequest(w, apierr.CodeBadRequest, "session neurons cannot be pinned, supersede or have attachments")
+		return
+	case req.Scope == scopeSession && (len(req.RelatedIDs) > 0 || req.RelatedType != ""):
+		apierr.BadRequest(w, apierr.CodeBadRequest, "session neurons cannot have related_ids; use links")
+		return
+	case req.RelatedType != "" && !core.ValidSynapseType(req.RelatedType):
+		apierr.BadRequest(w, apierr.CodeBadRequest, fmt.Sprintf("related_type must be 1-%d lowercase letters, digits, '-' or '_', starting with a letter", core.MaxSynapseTypeLength))
+		return
+	case req.Scope == scopeSession:
+		s.writeSessionNeuron(r.Context(), w, worker, s.getIndexID(r), req.SessionID, req.Content, req.Metadata, req.ParentID, req.Links)
+		return
+	case req.Scope != "":
+		apierr.BadRequest(w, apierr.CodeBadRequest, `scope must be "session" or omitted`)
+		return
+	case req.SessionID != "" || len(req.Links) > 0:
+		apierr.BadRequest(w, apierr.CodeBadRequest, `session_id and links apply to scope "session" only`)
 		return
 	}
 
@@ -1112,13 +2138,32 @@ func (s *Server) handleWrite(w http.ResponseWriter, r *http.Request) {
 		pid := core.NeuronID(req.ParentID)
 		parentID = &pid
 	}
+	var supersedes *core.NeuronID
+	if req.Supersedes != "" {
+		sid := core.NeuronID(req.Supersedes)
+		supersedes = &sid
+	}
+	attachments, err := s.resolveAttachments(req.Attachments)
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeAttachmentNotFound, err.Error())
+		return
+	}
+	related := make([]core.NeuronID, len(req.RelatedIDs))
+	for i, id := range req.RelatedIDs {
+		related[i] = core.NeuronID(id)
+	}
 
-	result, err := worker.Submit(&concurrency.Operation{
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
 		Type: concurrency.OpWrite,
 		Payload: concurrency.AddNeuronRequest{
-			Content:  req.Content,
-			ParentID: parentID,
-			Metadata: req.Metadata,
+			Content:     req.Content,
+			ParentID:    parentID,
+			Metadata:    req.Metadata,
+			Pinned:      req.Pinned,
+			Supersedes:  supersedes,
+			Attachments: attachments,
+			Related:     related,
+			RelatedType: req.RelatedType,
 		},
 	})
 
@@ -1128,11 +2173,41 @@ func (s *Server) handleWrite(w http.ResponseWriter, r *http.Request) {
 	}
 
 	n := result.(*core.Neuron)
-	doc := protocol.NeuronToDocument(n, nil)
-	doc["id"] = doc["_id"]
+	var conflicts []map[string]any
+	if s.config.Write.DetectConflicts {
+		conflicts = s.detectConflicts(r.Context(), worker, n.ID)
+	}
+	doc := s.neuronDocument(r.Context(), n)
+	if conflicts != nil {
+		doc["conflicts"] = conflicts
+	}
 	json.NewEncoder(w).Encode(doc)
 }
 
+// dropSystemMetadata removes the metadata keys QubicDB sets itself from a
+// client write, warning about each one dropped.
+func dropSystemMetadata(ctx context.Context, metadata map[string]string) map[string]string {
+	var dropped []string
+	for k := range metadata {
+		if engine.IsSystemMetadataKey(k) {
+			dropped = append(dropped, k)
+		}
+	}
+	if len(dropped) == 0 {
+		return metadata
+	}
+	sort.Strings(dropped)
+	kept := make(map[string]string, len(metadata)-len(dropped))
+	for k, v := range metadata {
+		if !engine.IsSystemMetadataKey(k) {
+			kept[k] = v
+		}
+	}
+	apierr.Warn(ctx, apierr.WarnMetadataKeyReserved, fmt.Sprintf("reserved metadata keys dropped: %s", strings.Join(dropped, ", ")),
+		map[string]any{"keys": dropped})
+	return kept
+}
+
 // handleRead - Memory retrieval (GET /v1/read/{id})
 func (s *Server) handleRead(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -1154,10 +2229,16 @@ func (s *Server) handleRead(w http.ResponseWriter, r *http.Request) {
 		apierr.NeuronIDRequired(w)
 		return
 	}
+	strong, ok := parseConsistency(r.URL.Query().Get("consistency"))
+	if !ok {
+		writeConsistencyError(w)
+		return
+	}
 
-	result, err := worker.Submit(&concurrency.Operation{
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
 		Type:    concurrency.OpRead,
 		Payload: core.NeuronID(id),
+		Strong:  strong,
 	})
 
 	if err != nil {
@@ -1166,7 +2247,7 @@ func (s *Server) handleRead(w http.ResponseWriter, r *http.Request) {
 	}
 
 	n := result.(*core.Neuron)
-	json.NewEncoder(w).Encode(protocol.NeuronToDocument(n, nil))
+	json.NewEncoder(w).Encode(s.neuronDocument(r.Context(), n))
 }
 
 // handleTouch - Memory modification (PUT /v1/touch)
@@ -1196,18 +2277,50 @@ func (s *Server) handleRecall(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	worker, err := s.getWorker(s.getIndexID(r))
+	indexID := s.getIndexID(r)
+	worker, err := s.getWorker(indexID)
 	if err != nil {
 		s.writeWorkerError(w, err)
 		return
 	}
+	strong, ok := parseConsistency(r.URL.Query().Get("consistency"))
+	if !ok {
+		writeConsistencyError(w)
+		return
+	}
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID != "" && !s.requireSessions(w, sessionID) {
+		return
+	}
+	q := r.URL.Query()
+	offset := 0
+	if raw := q.Get("offset"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = v
+	}
+	limit := clampPositive(parsePositiveQueryInt(q.Get("limit")), defaultRecallLimit, maxRecallLimit)
+	order := q.Get("order")
+	if !engine.ValidListOrder(order) {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "order must be asc or desc")
+		return
+	}
 
-	result, err := worker.Submit(&concurrency.Operation{
+	var total int
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
 		Type: concurrency.OpRecall,
 		Payload: concurrency.ListNeuronsRequest{
-			Offset: 0,
-			Limit:  100,
+			Offset:      offset,
+			Limit:       limit,
+			Roles:       s.resolveRoles(indexID, parseRolesQuery(q)),
+			IgnoreFocus: q.Get("ignore_focus") == "true",
+			Order:       order,
+			Total:       &total,
 		},
+		Strong: strong,
 	})
 
 	if err != nil {
@@ -1215,16 +2328,29 @@ func (s *Server) handleRecall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Working memory comes first, newest first, on the first page.
+	var entries []session.Entry
+	if sessionID != "" && offset == 0 {
+		entries, _ = s.sessions.Neurons(indexID, sessionID)
+	}
 	neurons := result.([]*core.Neuron)
-	items := make([]map[string]any, len(neurons))
-	for i, n := range neurons {
-		items[i] = protocol.NeuronToDocument(n, nil)
+	items := make([]any, 0, len(entries)+len(neurons))
+	for i := len(entries) - 1; i >= 0; i-- {
+		items = append(items, s.sessionDocument(r.Context(), sessionID, entries[i]))
 	}
+	for _, n := range neurons {
+		items = append(items, s.typedNeuronDocument(r.Context(), n))
+	}
+	defer releaseNeuronDocuments(items)
 
-	json.NewEncoder(w).Encode(map[string]any{
+	writeDocumentLists(w, map[string]any{
+		"count":   len(items),
+		"total":   total,
+		"offset":  offset,
+		"hasMore": offset+len(neurons) < total,
+	}, map[string][]any{
 		"memories": items,
 		"neurons":  items,
-		"count":    len(items),
 	})
 }
 
@@ -1304,8 +2430,16 @@ func (s *Server) handleRegistryCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := s.checkRegistryMetadata(req.Metadata); err != nil {
+		writeRegistryConfigError(w, err)
+		return
+	}
+
 	entry, err := s.registry.Create(req.UUID, req.Metadata)
 	if err != nil {
+		if writeRegistryConfigError(w, err) {
+			return
+		}
 		apierr.Conflict(w, apierr.CodeUUIDConflict, err.Error())
 		return
 	}
@@ -1350,8 +2484,16 @@ func (s *Server) handleRegistryUpdate(w http.ResponseWriter, r *http.Request, ol
 		newUUID = oldUUID
 	}
 
+	if err := s.checkRegistryMetadata(req.Metadata); err != nil {
+		writeRegistryConfigError(w, err)
+		return
+	}
+
 	entry, err := s.registry.Update(oldUUID, newUUID, req.Metadata)
 	if err != nil {
+		if writeRegistryConfigError(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			apierr.NotFound(w, apierr.CodeUUIDNotFound, err.Error())
 		} else {
@@ -1366,6 +2508,9 @@ func (s *Server) handleRegistryUpdate(w http.ResponseWriter, r *http.Request, ol
 // handleRegistryDelete — DELETE /v1/registry/{uuid}
 func (s *Server) handleRegistryDelete(w http.ResponseWriter, r *http.Request, uuid string) {
 	if err := s.registry.Delete(uuid); err != nil {
+		if writeRegistryConfigError(w, err) {
+			return
+		}
 		apierr.NotFound(w, apierr.CodeUUIDNotFound, err.Error())
 		return
 	}
@@ -1394,8 +2539,16 @@ func (s *Server) handleRegistryFindOrCreate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := s.checkRegistryMetadata(req.Metadata); err != nil {
+		writeRegistryConfigError(w, err)
+		return
+	}
+
 	entry, created, err := s.registry.FindOrCreate(req.UUID, req.Metadata)
 	if err != nil {
+		if writeRegistryConfigError(w, err) {
+			return
+		}
 		apierr.Internal(w, err.Error())
 		return
 	}
@@ -1419,15 +2572,39 @@ func (s *Server) handleRegistryFindOrCreate(w http.ResponseWriter, r *http.Reque
 // ADMIN ENDPOINTS
 // ============================================================================
 
-// handleAdminGC forces garbage collection
+// handleAdminGC releases memory now rather than at the next eviction
+// tick: it evicts, persisting them, the workers idle beyond
+// worker.maxIdleTime and those of dormant brains, then runs the Go
+// garbage collector.
 func (s *Server) handleAdminGC(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		apierr.MethodNotAllowed(w)
 		return
 	}
 
-	// Placeholder — wire to runtime.GC() or pool-level cleanup as needed.
-	json.NewEncoder(w).Encode(map[string]any{"gc": "triggered"})
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	dormant := make(map[core.IndexID]bool)
+	for _, id := range s.lifecycle.GetDormantUsers() {
+		dormant[id] = true
+	}
+	report := s.pool.EvictIdle(func(indexID core.IndexID) bool { return dormant[indexID] })
+	runtime.GC()
+	debug.FreeOSMemory()
+	runtime.ReadMemStats(&after)
+
+	resp := map[string]any{
+		"gc":              "completed",
+		"workersEvicted":  report.Evicted,
+		"brainsPersisted": report.Persisted,
+		"bytesReclaimed":  max(int64(before.HeapAlloc)-int64(after.HeapAlloc), 0),
+		"heapAllocBefore": before.HeapAlloc,
+		"heapAllocAfter":  after.HeapAlloc,
+	}
+	if len(report.Failed) > 0 {
+		resp["failed"] = report.Failed
+	}
+	json.NewEncoder(w).Encode(resp)
 }
 
 // handleAdminPersist forces persistence of all brains
@@ -1437,10 +2614,109 @@ func (s *Server) handleAdminPersist(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if indexID := core.IndexID(r.URL.Query().Get("index")); indexID != "" {
+		found, err := s.pool.PersistIndex(indexID)
+		switch {
+		case !found:
+			apierr.NotFound(w, apierr.CodeNotFound, "index "+string(indexID)+" is neither loaded nor waiting to be flushed")
+		case err != nil:
+			s.writePersistError(w, err)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"persisted": true, "index": indexID})
+		}
+		return
+	}
+
 	s.pool.PersistAll()
 	json.NewEncoder(w).Encode(map[string]any{"persisted": true})
 }
 
+// handleAdminSearchMetrics returns search quality aggregates and, when
+// sampling is enabled, recent zero-result queries.
+func (s *Server) handleAdminSearchMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	if s.searchMetrics == nil {
+		json.NewEncoder(w).Encode(map[string]any{"enabled": false})
+		return
+	}
+
+	out := s.searchMetrics.Snapshot()
+	out["enabled"] = true
+	out["zeroResultSamples"] = s.searchMetrics.ZeroResultSamples()
+	json.NewEncoder(w).Encode(out)
+}
+
+// handleAdminInfo returns the server version and the data directory schema
+// it runs on, next to the schema this build writes, and the active
+// sentiment analyzer.
+func (s *Server) handleAdminInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	var provider any
+	if a := s.pool.SentimentAnalyzer(); a != nil {
+		provider = a.Name()
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"version": Version,
+		"schema": map[string]int{
+			"supported":        persistence.SchemaVersion,
+			"minReaderVersion": persistence.MinReaderVersion,
+		},
+		"dataDir": s.pool.DirVersion(),
+		"sentiment": map[string]any{
+			"provider":            provider,
+			"minAsciiLetterRatio": s.config.Sentiment.MinASCIILetterRatio,
+		},
+	})
+}
+
+// handleAdminStartupReport returns what the store did at startup: WAL
+// records replayed, corrupt files removed by checksum repair and the store
+// configuration in effect.
+func (s *Server) handleAdminStartupReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	report := s.pool.StartupReport()
+	if report == nil {
+		apierr.NotFound(w, apierr.CodeNotFound, "no startup report")
+		return
+	}
+	doc := struct {
+		*persistence.StartupReport
+		Vector *vector.ProbeResult `json:"vector,omitempty"` // the warm-up probe
+	}{StartupReport: report}
+	if s.vectorHealth != nil {
+		doc.Vector = s.vectorHealth.Status().Warmup
+	}
+	json.NewEncoder(w).Encode(doc)
+}
+
+// handleAdminShadowMismatches returns shadow mirroring counters and the
+// most recent mismatches between primary and shadow responses.
+func (s *Server) handleAdminShadowMismatches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	if s.shadow == nil {
+		json.NewEncoder(w).Encode(map[string]any{"enabled": false})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"enabled":    true,
+		"stats":      s.shadow.Stats(),
+		"mismatches": s.shadow.Mismatches(),
+	})
+}
+
 // ============================================================================
 // RUNTIME CONFIGURATION ENDPOINT
 // ============================================================================
@@ -1463,61 +2739,78 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleConfigGet returns the active configuration snapshot.
+// handleConfigGet returns the active configuration snapshot. Secrets are
+// masked by core.Config.Masked, as in `qubicdb --print-config`.
 func (s *Server) handleConfigGet(w http.ResponseWriter, _ *http.Request) {
+	cfg := s.config.Masked()
 	json.NewEncoder(w).Encode(map[string]any{
 		"server": map[string]any{
-			"httpAddr": s.config.Server.HTTPAddr,
+			"httpAddr":      cfg.Server.HTTPAddr,
+			"indexIdSource": cfg.Server.IndexIDSource,
 		},
 		"storage": map[string]any{
-			"dataPath": s.config.Storage.DataPath,
-			"compress": s.config.Storage.Compress,
+			"dataPath": cfg.Storage.DataPath,
+			"compress": cfg.Storage.Compress,
 		},
 		"matrix": map[string]any{
-			"minDimension": s.config.Matrix.MinDimension,
-			"maxDimension": s.config.Matrix.MaxDimension,
-			"maxNeurons":   s.config.Matrix.MaxNeurons,
+			"minDimension": cfg.Matrix.MinDimension,
+			"maxDimension": cfg.Matrix.MaxDimension,
+			"maxNeurons":   cfg.Matrix.MaxNeurons,
 		},
 		"lifecycle": map[string]any{
-			"idleThreshold":    s.config.Lifecycle.IdleThreshold.String(),
-			"sleepThreshold":   s.config.Lifecycle.SleepThreshold.String(),
-			"dormantThreshold": s.config.Lifecycle.DormantThreshold.String(),
+			"idleThreshold":    cfg.Lifecycle.IdleThreshold.String(),
+			"sleepThreshold":   cfg.Lifecycle.SleepThreshold.String(),
+			"dormantThreshold": cfg.Lifecycle.DormantThreshold.String(),
 		},
 		"daemons": map[string]any{
-			"decayInterval":       s.config.Daemons.DecayInterval.String(),
-			"consolidateInterval": s.config.Daemons.ConsolidateInterval.String(),
-			"pruneInterval":       s.config.Daemons.PruneInterval.String(),
-			"persistInterval":     s.config.Daemons.PersistInterval.String(),
-			"reorgInterval":       s.config.Daemons.ReorgInterval.String(),
+			"decayInterval":       cfg.Daemons.DecayInterval.String(),
+			"consolidateInterval": cfg.Daemons.ConsolidateInterval.String(),
+			"pruneInterval":       cfg.Daemons.PruneInterval.String(),
+			"persistInterval":     cfg.Daemons.PersistInterval.String(),
+			"reorgInterval":       cfg.Daemons.ReorgInterval.String(),
 		},
 		"worker": map[string]any{
-			"maxIdleTime": s.config.Worker.MaxIdleTime.String(),
+			"maxIdleTime":     cfg.Worker.MaxIdleTime.String(),
+			"backgroundSlice": cfg.Worker.BackgroundSlice.String(),
+			"readConcurrency": cfg.Worker.ReadConcurrency,
+			"loadTimeout":     cfg.Worker.LoadTimeout.String(),
 		},
 		"registry": map[string]any{
-			"enabled": s.config.Registry.Enabled,
+			"enabled": cfg.Registry.Enabled,
 		},
 		"vector": map[string]any{
-			"enabled":   s.config.Vector.Enabled,
-			"modelPath": s.config.Vector.ModelPath,
-			"gpuLayers": s.config.Vector.GPULayers,
-			"alpha":     s.config.Vector.Alpha,
+			"enabled":         cfg.Vector.Enabled,
+			"modelPath":       cfg.Vector.ModelPath,
+			"gpuLayers":       cfg.Vector.GPULayers,
+			"alpha":           cfg.Vector.Alpha,
+			"models":          len(cfg.Vector.Models),
+			"maxLoadedModels": cfg.Vector.MaxLoadedModels,
 		},
 		"admin": map[string]any{
-			"enabled": s.config.Admin.Enabled,
-			"user":    s.config.Admin.User,
+			"enabled":    cfg.Admin.Enabled,
+			"user":       cfg.Admin.User,
+			"password":   cfg.Admin.Password,
+			"sessionTTL": cfg.Admin.SessionTTL.String(),
+		},
+		"mcp": map[string]any{
+			"enabled": cfg.MCP.Enabled,
+			"path":    cfg.MCP.Path,
+			"apiKey":  cfg.MCP.APIKey,
 		},
 		"security": map[string]any{
-			"allowedOrigins": s.config.Security.AllowedOrigins,
-			"maxRequestBody": s.config.Security.MaxRequestBody,
-			"tlsEnabled":     s.config.Security.TLSCert != "",
-			"readTimeout":    s.config.Security.ReadTimeout.String(),
-			"writeTimeout":   s.config.Security.WriteTimeout.String(),
+			"allowedOrigins": cfg.Security.AllowedOrigins,
+			"maxRequestBody": cfg.Security.MaxRequestBody,
+			"tlsEnabled":     cfg.Security.TLSCert != "",
+			"readTimeout":    cfg.Security.ReadTimeout.String(),
+			"writeTimeout":   cfg.Security.WriteTimeout.String(),
 		},
 	})
 }
 
 // handleConfigSet applies a partial runtime configuration patch.
-// Only fields that are safe to change at runtime are accepted.
+// Only fields that are safe to change at runtime are accepted. Enabling the
+// registry guard is refused while indexes with data are unregistered,
+// unless ?force=true is given.
 func (s *Server) handleConfigSet(w http.ResponseWriter, r *http.Request) {
 	var patch struct {
 		Lifecycle *struct {
@@ -1533,7 +2826,9 @@ func (s *Server) handleConfigSet(w http.ResponseWriter, r *http.Request) {
 			ReorgInterval       string `json:"reorgInterval,omitempty"`
 		} `json:"daemons,omitempty"`
 		Worker *struct {
-			MaxIdleTime string `json:"maxIdleTime,omitempty"`
+			MaxIdleTime     string `json:"maxIdleTime,omitempty"`
+			BackgroundSlice string `json:"backgroundSlice,omitempty"`
+			LoadTimeout     string `json:"loadTimeout,omitempty"`
 		} `json:"worker,omitempty"`
 		Registry *struct {
 			Enabled *bool `json:"enabled,omitempty"`
@@ -1555,6 +2850,15 @@ func (s *Server) handleConfigSet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var lockedOut []string
+	if patch.Registry != nil && patch.Registry.Enabled != nil && *patch.Registry.Enabled && !s.config.Registry.Enabled {
+		lockedOut = s.unregisteredIndexes()
+		if len(lockedOut) > 0 && r.URL.Query().Get("force") != "true" {
+			writeGuardLockout(w, lockedOut)
+			return
+		}
+	}
+
 	changed := []string{}
 	rejected := []string{}
 
@@ -1624,12 +2928,23 @@ func (s *Server) handleConfigSet(w http.ResponseWriter, r *http.Request) {
 			tryDuration("worker.maxIdleTime", v, &s.config.Worker.MaxIdleTime)
 			s.pool.SetMaxIdleTime(s.config.Worker.MaxIdleTime)
 		}
+		if v := patch.Worker.BackgroundSlice; v != "" {
+			tryDuration("worker.backgroundSlice", v, &s.config.Worker.BackgroundSlice)
+			s.pool.SetBackgroundSlice(s.config.Worker.BackgroundSlice)
+		}
+		if v := patch.Worker.LoadTimeout; v != "" {
+			tryDuration("worker.loadTimeout", v, &s.config.Worker.LoadTimeout)
+			s.pool.SetLoadTimeout(s.config.Worker.LoadTimeout)
+		}
 	}
 
 	// Apply registry patches
 	if patch.Registry != nil && patch.Registry.Enabled != nil {
 		s.config.Registry.Enabled = *patch.Registry.Enabled
 		changed = append(changed, "registry.enabled")
+		if len(lockedOut) > 0 {
+			log.Printf("⚠ REGISTRY GUARD force-enabled with %d unregistered indexes now locked out: %v", len(lockedOut), lockedOut)
+		}
 	}
 
 	// Apply matrix patches
@@ -1691,5 +3006,8 @@ func (s *Server) handleConfigSet(w http.ResponseWriter, r *http.Request) {
 	if len(rejected) > 0 {
 		resp["rejected"] = rejected
 	}
+	if len(lockedOut) > 0 {
+		resp["lockedOut"] = lockedOut
+	}
 	json.NewEncoder(w).Encode(resp)
 }
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"encoding/base64"
 	"encoding/json"
 	"io"
@@ -13,6 +15,8 @@ import (
 
 	"github.com/qubicDB/qubicdb/pkg/concurrency"
 	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/daemon"
+	"github.com/qubicDB/qubicdb/pkg/engine"
 	"github.com/qubicDB/qubicdb/pkg/lifecycle"
 	"github.com/qubicDB/qubicdb/pkg/persistence"
 	"github.com/qubicDB/qubicdb/pkg/registry"
@@ -25,7 +29,7 @@ import (
 // newTestServer creates a minimal Server wired with real components for
 // integration-style HTTP handler tests. The registry store uses a temp dir
 // so tests don't pollute each other.
-func newTestServer(t *testing.T, cfgMutator func(*core.Config)) *Server {
+func newTestServer(t testing.TB, cfgMutator func(*core.Config)) *Server {
 	t.Helper()
 
 	cfg := core.DefaultConfig()
@@ -240,7 +244,7 @@ func TestBodySizeLimit_AllowsSmallBody(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// Admin auth middleware — requireAdmin()
+// Admin auth middleware — requireRole()
 // ---------------------------------------------------------------------------
 
 func TestAdminAuth_NoCredentials(t *testing.T) {
@@ -533,6 +537,112 @@ func TestRegistryGuard_MissingIndexIDAlwaysFails(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Index ID sources
+// ---------------------------------------------------------------------------
+
+// writeWithIndexSources writes to /v1/write naming the index by header
+// and/or query parameter (empty values are omitted).
+func writeWithIndexSources(t *testing.T, s *Server, header, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	path := "/v1/write"
+	if query != "" {
+		path += "?index_id=" + query
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	if header != "" {
+		headers["X-Index-ID"] = header
+	}
+	return doRequest(t, s, "POST", path, `{"content":"routing check"}`, headers)
+}
+
+func TestIndexID_HeaderAndQueryAgree(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+
+	rr := writeWithIndexSources(t, s, "tenant-a", "tenant-a")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("agreeing sources should be accepted, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get(warningHeader) != "" {
+		t.Errorf("no warning expected, got %q", rr.Header().Get(warningHeader))
+	}
+	if n := len(indexContents(t, s, "tenant-a")); n != 1 {
+		t.Errorf("expected the write in tenant-a, got %d neurons", n)
+	}
+}
+
+func TestIndexID_HeaderAndQueryConflict(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+
+	rr := writeWithIndexSources(t, s, "tenant-a", "tenant-b")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("conflicting sources should return 400, got %d", rr.Code)
+	}
+	m := decodeJSON(t, rr)
+	if m["code"] != "INDEX_ID_CONFLICT" {
+		t.Errorf("expected INDEX_ID_CONFLICT, got %v", m["code"])
+	}
+	if msg, _ := m["error"].(string); !strings.Contains(msg, "tenant-a") || !strings.Contains(msg, "tenant-b") {
+		t.Errorf("error should name both values, got %q", msg)
+	}
+	for _, id := range []string{"tenant-a", "tenant-b"} {
+		if _, err := s.pool.Get(core.IndexID(id)); err == nil {
+			t.Errorf("%s should not have been touched", id)
+		}
+	}
+}
+
+func TestIndexID_SourceLockdown(t *testing.T) {
+	cases := []struct {
+		source, header, query string
+		want                  string
+		warn                  string
+	}{
+		{source: "header", header: "tenant-a", query: "tenant-b", want: "tenant-a", warn: "index_id query parameter"},
+		{source: "header", header: "tenant-a", want: "tenant-a"},
+		{source: "query", header: "tenant-a", query: "tenant-b", want: "tenant-b", warn: "X-Index-ID header"},
+		{source: "query", query: "tenant-b", want: "tenant-b"},
+	}
+	for _, tc := range cases {
+		s := newTestServer(t, func(cfg *core.Config) {
+			cfg.Registry.Enabled = false
+			cfg.Server.IndexIDSource = tc.source
+		})
+		rr := writeWithIndexSources(t, s, tc.header, tc.query)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tc.source, rr.Code, rr.Body.String())
+		}
+		if n := len(indexContents(t, s, tc.want)); n != 1 {
+			t.Errorf("%s: expected the write in %s, got %d neurons", tc.source, tc.want, n)
+		}
+		if warn := rr.Header().Get(warningHeader); !strings.HasPrefix(warn, tc.warn) || (tc.warn == "") != (warn == "") {
+			t.Errorf("%s: unexpected warning %q", tc.source, warn)
+		}
+	}
+
+	// The disallowed source alone does not name an index.
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Server.IndexIDSource = "header"
+	})
+	if rr := writeWithIndexSources(t, s, "", "tenant-b"); rr.Code != http.StatusBadRequest {
+		t.Errorf("query-only request should be rejected in header mode, got %d", rr.Code)
+	}
+}
+
+func TestIndexID_MCPBackendUnaffected(t *testing.T) {
+	for _, source := range []string{"header", "query"} {
+		b := newTestMCPBackend(t)
+		b.server.config.Server.IndexIDSource = source
+		if _, err := b.Write(context.Background(), "tenant-a", "written over MCP", nil); err != nil {
+			t.Fatalf("%s: MCP write failed: %v", source, err)
+		}
+		if n := len(indexContents(t, b.server, "tenant-a")); n != 1 {
+			t.Errorf("%s: expected the MCP write in tenant-a, got %d neurons", source, n)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Config endpoint — full output coverage
 // ---------------------------------------------------------------------------
@@ -600,6 +710,31 @@ func TestConfigEndpoint_ContainsAllSections(t *testing.T) {
 	}
 }
 
+func TestConfigEndpoint_MasksSecrets(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Password = "config-secret"
+		cfg.MCP.APIKey = "mcp-secret"
+	})
+
+	rr := doRequest(t, s, "GET", "/v1/config", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "config-secret"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	body := rr.Body.String()
+	if strings.Contains(body, "config-secret") || strings.Contains(body, "mcp-secret") {
+		t.Fatalf("config response leaks a secret: %s", body)
+	}
+	m := decodeJSON(t, rr)
+	if got := m["admin"].(map[string]any)["password"]; got != core.MaskSecret("config-secret") {
+		t.Errorf("admin.password: got %v, want the masked fingerprint", got)
+	}
+	if got := m["mcp"].(map[string]any)["apiKey"]; got != core.MaskSecret("mcp-secret") {
+		t.Errorf("mcp.apiKey: got %v, want the masked fingerprint", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Server timeout configuration
 // ---------------------------------------------------------------------------
@@ -645,6 +780,45 @@ func TestAdminGC_RequiresAuth(t *testing.T) {
 	}
 }
 
+func TestAdminGC_EvictsDormantBrains(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	writeTo(t, s, "asleep", "a memory to keep")
+	writeTo(t, s, "awake", "a memory in use")
+
+	s.lifecycle.SetThresholds(0, 0, 0)
+	time.Sleep(time.Millisecond)
+	for range 3 {
+		s.lifecycle.CheckAndTransition("asleep")
+	}
+	if state := s.lifecycle.GetState("asleep"); state != core.StateDormant {
+		t.Fatalf("expected asleep dormant, got %v", state)
+	}
+
+	resp := adminRequest(t, s, "POST", "/admin/gc")
+	if resp["workersEvicted"] != float64(1) || resp["brainsPersisted"] != float64(1) {
+		t.Errorf("expected one dormant brain evicted and persisted, got %v", resp)
+	}
+	if _, ok := resp["bytesReclaimed"].(float64); !ok {
+		t.Errorf("expected bytesReclaimed, got %v", resp)
+	}
+	if _, err := s.pool.Get("asleep"); err == nil {
+		t.Error("the dormant brain should be dropped from memory")
+	}
+	if _, err := s.pool.Get("awake"); err != nil {
+		t.Error("an active brain should stay loaded")
+	}
+
+	// The evicted brain loads back from disk.
+	rr := doRequest(t, s, "GET", "/v1/search?q=memory", "", map[string]string{"X-Index-ID": "asleep"})
+	if got := decodeJSON(t, rr)["count"]; got != float64(1) {
+		t.Errorf("expected the persisted memory back, got %v", got)
+	}
+}
+
 func TestAdminPersist_RequiresAuth(t *testing.T) {
 	s := newTestServer(t, func(cfg *core.Config) {
 		cfg.Admin.Enabled = true
@@ -681,10 +855,549 @@ func TestAdminDaemons_RequiresAuth(t *testing.T) {
 	}
 }
 
+func TestAdminDaemons_ReportsRunStatusAndMetrics(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	store, err := persistence.NewStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := daemon.NewDaemonManager(s.pool, s.lifecycle, store)
+	// The loops never come due during the test; the passes are run
+	// explicitly, so no daemon can go degraded waiting on the scheduler.
+	dm.SetIntervals(time.Hour, time.Hour, time.Hour, time.Hour, time.Hour)
+	dm.SetEmbedInterval(time.Hour)
+	s.SetDaemonManager(dm)
+	dm.Start()
+	defer dm.Stop()
+	for _, name := range []string{"decay", "consolidate", "prune", "persist", "reorg", "embed"} {
+		if err := dm.RunPass(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := dm.RunPass("missing"); err == nil {
+		t.Error("expected an unknown daemon to be rejected")
+	}
+
+	body := adminRequest(t, s, "GET", "/admin/daemons")
+	if body["status"] != "running" || len(body["daemons"].(map[string]any)) != 6 {
+		t.Errorf("unexpected daemon status: %v", body)
+	}
+	persist := body["daemons"].(map[string]any)["persist"].(map[string]any)
+	if persist["lastSuccess"] == nil || persist["runs"] != 1.0 {
+		t.Errorf("expected one successful persist pass, got %v", persist)
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/daemons/metrics", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("metrics: got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), `qubicdb_daemon_up{daemon="persist"} 1`) {
+		t.Errorf("metrics should report the persist daemon up:\n%s", rr.Body.String())
+	}
+
+	health := decodeJSON(t, doRequest(t, s, "GET", "/health", "", nil))
+	daemons := health["checks"].(map[string]any)["daemons"].(map[string]any)
+	if health["status"] != "healthy" || daemons["status"] != "ok" {
+		t.Errorf("unexpected health: %v", health)
+	}
+}
+
+func TestAdminDaemons_ReportsTasks(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	dm := daemon.NewDaemonManager(s.pool, s.lifecycle, nil)
+	s.SetDaemonManager(dm)
+	if _, ok := adminRequest(t, s, "GET", "/admin/daemons")["tasks"]; ok {
+		t.Error("expected no tasks without a task runner")
+	}
+
+	tasks := core.NewTaskRunner()
+	tasks.Register("sweep", time.Millisecond, func(context.Context) error { return errors.New("sweep failed") })
+	s.SetTaskRunner(tasks)
+	tasks.Start()
+	defer tasks.Stop(context.Background())
+
+	var sweep map[string]any
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := adminRequest(t, s, "GET", "/admin/daemons")
+		sweep = body["tasks"].(map[string]any)["sweep"].(map[string]any)
+		if sweep["runs"].(float64) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sweep never ran: %v", body)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if sweep["state"] != core.TaskRunning || sweep["lastError"].(map[string]any)["message"] != "sweep failed" || sweep["failures"].(float64) == 0 {
+		t.Errorf("unexpected task status: %v", sweep)
+	}
+}
+
+func TestAdminDaemons_PauseResume(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	rr := doRequest(t, s, "POST", "/admin/daemons/pause", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("pausing without a daemon manager should be 404, got %d", rr.Code)
+	}
+
+	store, err := persistence.NewStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := daemon.NewDaemonManager(s.pool, s.lifecycle, store)
+	s.SetDaemonManager(dm)
+	dm.Start()
+	defer dm.Stop()
+
+	if body := adminRequest(t, s, "POST", "/admin/daemons/pause"); body["paused"] != true || body["changed"] != true {
+		t.Errorf("unexpected pause response: %v", body)
+	}
+	if !dm.Paused() {
+		t.Fatal("the pause endpoint should pause the daemon manager")
+	}
+	if body := adminRequest(t, s, "POST", "/admin/daemons/pause"); body["changed"] != false {
+		t.Errorf("pausing twice should change nothing: %v", body)
+	}
+	body := adminRequest(t, s, "GET", "/admin/daemons")
+	daemons := body["daemons"].(map[string]any)
+	decay := daemons["decay"].(map[string]any)
+	persist := daemons["persist"].(map[string]any)
+	if body["status"] != "paused" || decay["state"] != "paused" {
+		t.Errorf("expected paused daemons, got %v", body)
+	}
+	if persist["state"] != "running" {
+		t.Errorf("persist should keep running while paused, got %v", persist)
+	}
+
+	if body := adminRequest(t, s, "POST", "/admin/daemons/resume"); body["resumed"] != true || body["changed"] != true {
+		t.Errorf("unexpected resume response: %v", body)
+	}
+	body = adminRequest(t, s, "GET", "/admin/daemons")
+	persist = body["daemons"].(map[string]any)["persist"].(map[string]any)
+	if dm.Paused() || body["status"] != "running" || persist["state"] != "running" {
+		t.Errorf("expected running daemons after resume, got %v", body)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Write + Read round-trip (integration)
 // ---------------------------------------------------------------------------
 
+func TestWrite_RejectsInvalidUTF8Body(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	body := "{\"content\":\"binary \xff\xfe\x00 junk\"}"
+	rr := doRequest(t, s, "POST", "/v1/write", body, map[string]string{
+		"X-Index-ID": "encoding-test",
+	})
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid UTF-8 payload, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if m := decodeJSON(t, rr); m["code"] != "INVALID_CONTENT_ENCODING" {
+		t.Errorf("expected INVALID_CONTENT_ENCODING, got %v", m["code"])
+	}
+}
+
+func TestWrite_SanitizeContentAcceptsInvalidUTF8(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Write.SanitizeContent = true
+	})
+	t.Cleanup(func() { core.SetContentSanitization(false) })
+
+	body := "{\"content\":\"caf\xe9 au lait\"}"
+	rr := doRequest(t, s, "POST", "/v1/write", body, map[string]string{
+		"X-Index-ID": "sanitize-test",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 with sanitizeContent, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if m := decodeJSON(t, rr); m["content"] != "caf\uFFFD au lait" {
+		t.Errorf("expected U+FFFD replacement, got %q", m["content"])
+	}
+}
+
+func TestWrite_StripsControlCharacters(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	body := `{"content":"ring\u0007 the\u0000 bell\r\nnext line\tok"}`
+	rr := doRequest(t, s, "POST", "/v1/write", body, map[string]string{
+		"X-Index-ID": "control-test",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if m := decodeJSON(t, rr); m["content"] != "ring the bell\nnext line\tok" {
+		t.Errorf("expected control characters stripped, got %q", m["content"])
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Search telemetry — /admin/search-metrics
+// ---------------------------------------------------------------------------
+
+func TestAdminSearchMetrics_DisabledByDefault(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+
+	rr := doRequest(t, s, "GET", "/admin/search-metrics", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if m := decodeJSON(t, rr); m["enabled"] != false {
+		t.Errorf("expected enabled=false, got %v", m["enabled"])
+	}
+}
+
+func TestAdminSearchMetrics_RecordsSearchAndContext(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+		cfg.Search.Telemetry.Enabled = true
+		cfg.Search.Telemetry.SampleZeroResults = true
+		cfg.Search.Telemetry.ZeroResultSampleSize = 10
+	})
+
+	idx := map[string]string{"X-Index-ID": "telemetry-test"}
+	if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"golang concurrency patterns"}`, idx); rr.Code != http.StatusOK {
+		t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "GET", "/v1/search?q=golang", "", idx); rr.Code != http.StatusOK {
+		t.Fatalf("search failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "GET", "/v1/search?q=kubernetes", "", idx); rr.Code != http.StatusOK {
+		t.Fatalf("search failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(t, s, "POST", "/v1/context", `{"cue":"golang"}`, idx); rr.Code != http.StatusOK {
+		t.Fatalf("context failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/search-metrics", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	if m["enabled"] != true {
+		t.Fatalf("expected enabled=true, got %v", m["enabled"])
+	}
+
+	global := m["global"].(map[string]any)
+	if global["queries"].(float64) != 3 {
+		t.Errorf("expected 3 recorded queries, got %v", global["queries"])
+	}
+	if global["zeroResultQueries"].(float64) != 1 {
+		t.Errorf("expected 1 zero-result query, got %v", global["zeroResultQueries"])
+	}
+	if _, ok := m["indexes"].(map[string]any)["telemetry-test"]; !ok {
+		t.Errorf("expected per-index metrics for telemetry-test, got %v", m["indexes"])
+	}
+
+	samples := m["zeroResultSamples"].([]any)
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 zero-result sample, got %d", len(samples))
+	}
+	if sample := samples[0].(map[string]any); sample["query"] != "kubernetes" || sample["indexId"] != "telemetry-test" {
+		t.Errorf("unexpected zero-result sample: %v", sample)
+	}
+
+	rr = doRequest(t, s, "POST", "/admin/search-metrics", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rr.Code)
+	}
+}
+
+func TestGraphStats_EndpointAndAdminDetail(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+
+	idx := map[string]string{"X-Index-ID": "graph-stats-test"}
+	for _, content := range []string{"first memory", "second memory"} {
+		if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, idx); rr.Code != http.StatusOK {
+			t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/graph/stats", "", idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	if m["neurons"].(float64) != 2 {
+		t.Errorf("expected 2 neurons, got %v", m["neurons"])
+	}
+	for _, key := range []string{"averageDegree", "isolatedNeurons", "components", "clusteringCoefficient", "weights"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("missing %q in graph stats", key)
+		}
+	}
+
+	rr = doRequest(t, s, "GET", "/admin/indexes/graph-stats-test", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, ok := decodeJSON(t, rr)["graphStats"].(map[string]any); !ok {
+		t.Error("expected graphStats in admin index detail")
+	}
+}
+
+func TestGraphStats_MetadataIndexKeysFromRegistry(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"mi","metadata":{"indexedMetadataKeys":["project"]}}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("registry create failed: %d %s", rr.Code, rr.Body.String())
+	}
+	idx := map[string]string{"X-Index-ID": "mi"}
+	if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"roadmap review","metadata":{"project":"atlas","thread_id":"t1"}}`, idx); rr.Code != http.StatusOK {
+		t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(t, s, "GET", "/v1/graph/stats", "", idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	index, _ := decodeJSON(t, rr)["metadataIndex"].(map[string]any)
+	if index == nil {
+		t.Fatalf("expected metadataIndex in graph stats: %s", rr.Body.String())
+	}
+	var keys []string
+	for _, k := range index["keys"].([]any) {
+		keys = append(keys, k.(map[string]any)["key"].(string))
+	}
+	if strings.Join(keys, ",") != "project,role,thread_id" {
+		t.Errorf("expected the registry key next to the defaults, got %v", keys)
+	}
+
+	for _, body := range []string{
+		`{"uuid":"bad","metadata":{"indexedMetadataKeys":"project"}}`,
+		`{"uuid":"bad","metadata":{"indexedMetadataKeys":["_superseded_by"]}}`,
+	} {
+		if rr := doRequest(t, s, "POST", "/v1/registry", body, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestGraphDumpEndpoints(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	idx := map[string]string{"X-Index-ID": "graph-dump-test"}
+	for _, content := range []string{"first memory", "second memory"} {
+		if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, idx); rr.Code != http.StatusOK {
+			t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/graph", "", idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.HasPrefix(rr.Body.String(), `{"edges":[`) {
+		t.Errorf("graph keys should stay in their encoded order, got %s", rr.Body.String())
+	}
+	graph := decodeJSON(t, rr)
+	nodes := graph["nodes"].([]any)
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(nodes))
+	}
+	for _, key := range []string{"id", "content", "energy", "depth", "accessCount", "position"} {
+		if _, ok := nodes[0].(map[string]any)[key]; !ok {
+			t.Errorf("missing %q in graph node", key)
+		}
+	}
+
+	rr = doRequest(t, s, "GET", "/v1/synapses", "", idx)
+	synapses := decodeJSON(t, rr)
+	if synapses["count"] != float64(len(graph["edges"].([]any))) {
+		t.Errorf("synapses and graph edges disagree: %v vs %v", synapses["count"], len(graph["edges"].([]any)))
+	}
+
+	rr = doRequest(t, s, "GET", "/v1/activity", "", idx)
+	activity := decodeJSON(t, rr)
+	events := activity["events"].([]any)
+	if activity["count"] != float64(len(events)) || len(events) < 2 {
+		t.Fatalf("expected the recent writes as events, got %v", activity)
+	}
+	created := 0
+	for _, e := range events {
+		event := e.(map[string]any)
+		if event["type"] == "neuron" && event["action"] == "CREATED" {
+			created++
+		}
+	}
+	if created != 2 {
+		t.Errorf("expected 2 CREATED events, got %v", events)
+	}
+}
+
+func TestWriteRelatedAndTypeFilters(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	idx := map[string]string{"X-Index-ID": "synapse-types-test"}
+	cause := writeTo(t, s, "synapse-types-test", "the ledger migration failed")
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"roll back the migration","related_ids":["`+string(cause)+`"],"related_type":"resolves"}`, idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+	}
+	fix := decodeJSON(t, rr)["id"].(string)
+
+	rr = doRequest(t, s, "GET", "/v1/graph?types=resolves", "", idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	edges := decodeJSON(t, rr)["edges"].([]any)
+	if len(edges) != 1 {
+		t.Fatalf("expected the resolves edge, got %v", edges)
+	}
+	edge := edges[0].(map[string]any)
+	if edge["type"] != "resolves" || edge["source"] != fix || edge["target"] != string(cause) {
+		t.Errorf("unexpected edge %v", edge)
+	}
+
+	rr = doRequest(t, s, "GET", "/v1/synapses?types=follows,similar", "", idx)
+	if count := decodeJSON(t, rr)["count"]; count != float64(0) {
+		t.Errorf("expected no follows or similar synapses, got %v", count)
+	}
+
+	for _, path := range []string{"/v1/graph?types=Bad!", "/v1/synapses?types=ok,-no", "/v1/search?q=migration&spread_types=UPPER"} {
+		if rr := doRequest(t, s, "GET", path, "", idx); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, rr.Code)
+		}
+	}
+	rr = doRequest(t, s, "POST", "/v1/write", `{"content":"orphan","related_ids":["missing"]}`, idx)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing related neuron, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGraphSummary_Endpoint(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	idx := map[string]string{"X-Index-ID": "graph-summary-test"}
+	for _, content := range []string{"first memory", "second memory", "third memory"} {
+		if rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, idx); rr.Code != http.StatusOK {
+			t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/graph/summary?cells=4", "", idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	neurons := 0.0
+	for _, c := range m["grid"].([]any) {
+		neurons += c.(map[string]any)["neurons"].(float64)
+	}
+	if m["cells"] != float64(4) || m["neurons"] != float64(3) || neurons != 3 {
+		t.Errorf("expected 3 neurons on a 4x4 grid, got %v", m)
+	}
+
+	if m = decodeJSON(t, doRequest(t, s, "GET", "/v1/graph/summary?cells=100000", "", idx)); m["cells"] != float64(engine.MaxSummaryCells) {
+		t.Errorf("cells should be clamped to %d, got %v", engine.MaxSummaryCells, m["cells"])
+	}
+	if rr := doRequest(t, s, "POST", "/v1/graph/summary", "", idx); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rr.Code)
+	}
+}
+
+func TestSynapses_ScoreAndPrunePlan(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Daemons.Prune.MaxSynapses = 1
+	})
+
+	idx := map[string]string{"X-Index-ID": "prune-plan-test"}
+	for _, content := range []string{"alpha memory", "beta memory", "gamma memory"} {
+		writeNeuron(t, s, "prune-plan-test", content)
+	}
+	worker, err := s.pool.Get("prune-plan-test")
+	if err != nil {
+		t.Fatalf("get worker: %v", err)
+	}
+	m := worker.Matrix()
+	m.Lock()
+	ids := make([]core.NeuronID, 0, len(m.Neurons))
+	for id := range m.Neurons {
+		ids = append(ids, id)
+	}
+	for _, syn := range m.Synapses {
+		delete(m.Synapses, syn.ID)
+	}
+	for i, weight := range []float64{0.9, 0.2} {
+		syn := core.NewSynapse(ids[0], ids[i+1], weight)
+		m.Synapses[syn.ID] = syn
+	}
+	m.Unlock()
+
+	rr := doRequest(t, s, "GET", "/v1/synapses", "", idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	for _, syn := range decodeJSON(t, rr)["synapses"].([]any) {
+		if score, ok := syn.(map[string]any)["score"].(float64); !ok || score <= 0 {
+			t.Errorf("expected a positive score, got %v", syn)
+		}
+	}
+
+	rr = doRequest(t, s, "GET", "/v1/synapses/prune-plan", "", idx)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	plan := decodeJSON(t, rr)
+	if plan["count"] != float64(1) || plan["synapses"] != float64(2) || plan["remaining"] != float64(1) {
+		t.Errorf("unexpected plan: %v", plan)
+	}
+	eviction := plan["evictions"].([]any)[0].(map[string]any)
+	if eviction["reason"] != "index_budget" || eviction["weight"] != 0.2 {
+		t.Errorf("expected the weaker synapse evicted for index_budget, got %v", eviction)
+	}
+
+	m.RLock()
+	remaining := len(m.Synapses)
+	m.RUnlock()
+	if remaining != 2 {
+		t.Errorf("prune plan must not remove synapses, %d remain", remaining)
+	}
+
+	if rr := doRequest(t, s, "POST", "/v1/synapses/prune-plan", "", idx); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rr.Code)
+	}
+}
+
 func TestWriteReadRoundTrip(t *testing.T) {
 	s := newTestServer(t, func(cfg *core.Config) {
 		cfg.Registry.Enabled = false
@@ -808,6 +1521,99 @@ func TestConfigSet_RegistryEnabled(t *testing.T) {
 	}
 }
 
+func TestConfigSet_RegistryEnabledRefusesLockout(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+	})
+	for _, id := range []string{"beta", "alpha"} {
+		if _, err := s.getWorker(core.IndexID(id)); err != nil {
+			t.Fatalf("getWorker(%s): %v", id, err)
+		}
+	}
+	if _, err := s.registry.Create("beta", nil); err != nil {
+		t.Fatal(err)
+	}
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+	}
+	body := `{"registry":{"enabled":true},"matrix":{"maxNeurons":1234}}`
+
+	rr := doRequest(t, s, "POST", "/v1/config", body, headers)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	if m["code"] != "REGISTRY_GUARD_LOCKOUT" {
+		t.Errorf("unexpected code: %v", m["code"])
+	}
+	if locked := m["lockedOut"].([]any); len(locked) != 1 || locked[0] != "alpha" {
+		t.Errorf("expected alpha to be reported, got %v", m["lockedOut"])
+	}
+	if s.config.Registry.Enabled || s.config.Matrix.MaxNeurons == 1234 {
+		t.Error("a refused patch must not change anything")
+	}
+
+	rr = doRequest(t, s, "POST", "/v1/config?force=true", body, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("forced patch failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if locked := decodeJSON(t, rr)["lockedOut"].([]any); len(locked) != 1 || locked[0] != "alpha" {
+		t.Errorf("forced patch should still list locked out indexes, got %v", locked)
+	}
+	if !s.config.Registry.Enabled {
+		t.Error("registry.enabled should be true after a forced patch")
+	}
+	if _, err := s.getWorker("alpha"); err == nil {
+		t.Error("alpha should be rejected by the guard")
+	}
+}
+
+func TestRegistryImportActive(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+	})
+	for _, id := range []string{"live", "registered"} {
+		if _, err := s.getWorker(core.IndexID(id)); err != nil {
+			t.Fatalf("getWorker(%s): %v", id, err)
+		}
+	}
+	if _, err := s.getWorker("on-disk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.pool.Evict("on-disk"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.registry.Create("registered", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/registry/import-active", "", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import failed: %d %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	imported := m["imported"].([]any)
+	if len(imported) != 2 || imported[0] != "live" || imported[1] != "on-disk" {
+		t.Errorf("expected live and on-disk to be imported, got %v", imported)
+	}
+	if m["total"] != float64(3) {
+		t.Errorf("expected 3 registry entries, got %v", m["total"])
+	}
+
+	// Nothing is locked out any more, so the guard can be enabled.
+	rr = doRequest(t, s, "POST", "/v1/config", `{"registry":{"enabled":true}}`, map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enabling the guard failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := s.getWorker("on-disk"); err != nil {
+		t.Errorf("imported index should pass the guard: %v", err)
+	}
+}
+
 func TestConfigSet_MatrixMaxNeurons(t *testing.T) {
 	s := newTestServer(t, nil)
 
@@ -1039,6 +1845,76 @@ func TestSearchEndpoint_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestSearchEndpoint_Explain(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+	})
+
+	for _, content := range []string{"Go is a compiled language", "Rust is safe", "Python is dynamic"} {
+		writeNeuron(t, s, "search-explain", content)
+	}
+
+	plain := searchResults(t, s, "search-explain", "compiled")
+	if _, ok := plain["results"].([]any)[0].(map[string]any)["explain"]; ok {
+		t.Error("explain should be opt-in")
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/search?q=compiled&explain=true", "", map[string]string{
+		"X-Index-ID": "search-explain",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("search failed: %d %s", rr.Code, rr.Body.String())
+	}
+	first := decodeJSON(t, rr)["results"].([]any)[0].(map[string]any)
+	explain, ok := first["explain"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected an explain object, got %v", first)
+	}
+	terms := explain["terms"].([]any)
+	if len(terms) != 1 || terms[0].(map[string]any)["idf"].(float64) <= 0 {
+		t.Errorf("explain should carry per-term IDF, got %v", explain["terms"])
+	}
+	for _, key := range []string{"bm25", "lengthNorm", "docLength", "avgDocLength", "score"} {
+		if _, ok := explain[key]; !ok {
+			t.Errorf("explain is missing %s: %v", key, explain)
+		}
+	}
+}
+
+func TestReadEndpoints_Consistency(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+	})
+	writeNeuron(t, s, "consistency", "Strong reads wait for queued writes")
+	headers := map[string]string{"X-Index-ID": "consistency"}
+
+	for _, path := range []string{
+		"/v1/search?q=queued&consistency=strong",
+		"/v1/search?q=queued&consistency=eventual",
+		"/v1/recall?consistency=strong",
+	} {
+		rr := doRequest(t, s, "GET", path, "", headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+		if n := decodeJSON(t, rr)["count"]; n != float64(1) {
+			t.Errorf("GET %s: expected 1 result, got %v", path, n)
+		}
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/search", `{"query":"queued","consistency":"strong"}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("POST search: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	for _, path := range []string{"/v1/search?q=queued&consistency=linearizable", "/v1/recall?consistency=maybe"} {
+		rr := doRequest(t, s, "GET", path, "", headers)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected 400, got %d", path, rr.Code)
+		}
+	}
+}
+
 func TestSearchEndpoint_ClampsDepthAndLimit(t *testing.T) {
 	s := newTestServer(t, func(cfg *core.Config) {
 		cfg.Registry.Enabled = false
@@ -1073,6 +1949,55 @@ func TestSearchEndpoint_ClampsDepthAndLimit(t *testing.T) {
 	}
 }
 
+func TestSearchEndpoint_ExactMode(t *testing.T) {
+	s := newTestServer(t, nil)
+	for i := 0; i < 30; i++ {
+		pinRequest(t, s, "POST", "/v1/write", `{"content":"release note `+strconv.Itoa(i)+`"}`, http.StatusOK)
+	}
+
+	resp := pinRequest(t, s, "POST", "/v1/search", `{"query":"release note","mode":"exact","limit":5}`, http.StatusOK)
+	if resp["mode"] != "exact" || resp["depth"] != float64(0) || resp["count"] != float64(5) {
+		t.Errorf("unexpected exact search response: %v", resp)
+	}
+	get := pinRequest(t, s, "GET", "/v1/search?q=release+note&mode=EXACT&limit=3", "", http.StatusOK)
+	if get["mode"] != "exact" || get["count"] != float64(3) {
+		t.Errorf("GET search should accept mode, got %v", get)
+	}
+	if plain := pinRequest(t, s, "POST", "/v1/search", `{"query":"release note"}`, http.StatusOK); plain["mode"] != nil {
+		t.Errorf("the default mode should not be echoed, got %v", plain["mode"])
+	}
+
+	pinRequest(t, s, "POST", "/v1/search", `{"query":"release note","mode":"fuzzy"}`, http.StatusBadRequest)
+}
+
+func TestSearchEndpoint_FoldingAndCaseSensitivity(t *testing.T) {
+	s := newTestServer(t, nil)
+	pinRequest(t, s, "POST", "/v1/write", `{"content":"Kadıköy iskelesinde buluştuk"}`, http.StatusOK)
+	pinRequest(t, s, "POST", "/v1/write", `{"content":"Apple announced a phone"}`, http.StatusOK)
+
+	resp := pinRequest(t, s, "GET", "/v1/search?q=KADIKOY&explain=true", "", http.StatusOK)
+	results := resp["results"].([]any)
+	if len(results) == 0 || !strings.Contains(results[0].(map[string]any)["content"].(string), "Kadıköy") {
+		t.Fatalf("expected KADIKOY to find Kadıköy, got %v", resp)
+	}
+	terms := results[0].(map[string]any)["explain"].(map[string]any)["terms"].([]any)
+	if term := terms[0].(map[string]any); term["term"] != "kadikoy" || term["original"] != "KADIKOY" {
+		t.Errorf("expected explain to show the folded term, got %v", term)
+	}
+
+	exact := pinRequest(t, s, "POST", "/v1/search", `{"query":"apple","mode":"exact","case_sensitive":true}`, http.StatusOK)
+	if exact["count"] != float64(0) {
+		t.Errorf("a case-sensitive search for apple should not match Apple, got %v", exact["results"])
+	}
+	if exact := pinRequest(t, s, "POST", "/v1/search", `{"query":"Apple","mode":"exact","case_sensitive":true}`, http.StatusOK); exact["count"] != float64(1) {
+		t.Errorf("a case-sensitive search for Apple should match it, got %v", exact["results"])
+	}
+
+	pinRequest(t, s, "POST", "/v1/search", `{"query":"apple","case_sensitive":true}`, http.StatusBadRequest)
+	pinRequest(t, s, "GET", "/v1/search?q=apple&lang=turkish!", "", http.StatusBadRequest)
+	pinRequest(t, s, "GET", "/v1/search?q=apple&lang=tr", "", http.StatusOK)
+}
+
 func TestContextEndpoint_EmptyCueRejected(t *testing.T) {
 	s := newTestServer(t, func(cfg *core.Config) {
 		cfg.Registry.Enabled = false
@@ -1227,3 +2152,50 @@ func TestMetadataSearch_GETQueryParam(t *testing.T) {
 		t.Fatalf("GET strict: expected 1 result, got %d", len(results))
 	}
 }
+
+func TestAdminStartupReport(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+
+	m := adminRequest(t, s, "GET", "/admin/startup-report")
+	wal, _ := m["wal"].(map[string]any)
+	if wal == nil || wal["records"] != float64(0) {
+		t.Errorf("expected an empty WAL replay, got %v", m["wal"])
+	}
+	repair, _ := m["repair"].(map[string]any)
+	if repair == nil || len(repair["removedFiles"].([]any)) != 0 {
+		t.Errorf("expected no removed files, got %v", m["repair"])
+	}
+	if path, _ := m["path"].(string); path == "" {
+		t.Errorf("expected the report path, got %v", m)
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/startup-report", "", nil)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", rr.Code)
+	}
+}
+
+func TestAdminInfo(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+
+	m := adminRequest(t, s, "GET", "/admin/info")
+	if m["version"] != Version {
+		t.Errorf("expected version %q, got %v", Version, m["version"])
+	}
+	dataDir, _ := m["dataDir"].(map[string]any)
+	if dataDir == nil || dataDir["schemaVersion"] != float64(persistence.SchemaVersion) || dataDir["minReaderVersion"] != float64(persistence.MinReaderVersion) {
+		t.Errorf("expected the current schema stamp, got %v", m["dataDir"])
+	}
+	schema, _ := m["schema"].(map[string]any)
+	if schema == nil || schema["supported"] != float64(persistence.SchemaVersion) {
+		t.Errorf("expected the supported schema, got %v", m["schema"])
+	}
+}
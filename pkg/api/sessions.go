@@ -0,0 +1,279 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/session"
+)
+
+// scopeSession is the write scope of working memory.
+const scopeSession = "session"
+
+// requireSessions reports whether working memory is enabled and id is a
+// valid session ID, answering 400 otherwise.
+func (s *Server) requireSessions(w http.ResponseWriter, id string) bool {
+	if s.sessions == nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "session scope is disabled (sessions.enabled)")
+		return false
+	}
+	if err := session.ValidateID(id); err != nil {
+		apierr.BadRequest(w, apierr.CodeInvalidSession, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		apierr.NotFound(w, apierr.CodeSessionNotFound, err.Error())
+	case errors.Is(err, session.ErrInvalid):
+		apierr.BadRequest(w, apierr.CodeInvalidSession, err.Error())
+	case errors.Is(err, core.ErrInvalidContent):
+		apierr.BadRequest(w, apierr.CodeInvalidContent, err.Error())
+	case errors.Is(err, core.ErrContentTooLarge):
+		apierr.PayloadTooLarge(w, err.Error())
+	default:
+		apierr.Internal(w, err.Error())
+	}
+}
+
+// sessionDocument is the document of a session neuron: a neuron document
+// flagged with its scope and session, and the persistent neurons it links
+// to.
+func (s *Server) sessionDocument(ctx context.Context, id string, e session.Entry) map[string]any {
+	doc := s.neuronDocument(ctx, e.Neuron)
+	doc["scope"] = scopeSession
+	doc["sessionId"] = id
+	if len(e.Links) > 0 {
+		doc["links"] = e.Links
+	}
+	return doc
+}
+
+// writeSessionNeuron handles a /v1/write with scope "session". The neuron
+// goes to the session's in-memory overlay; parent_id and links name the
+// persistent neurons it connects to, which must exist.
+func (s *Server) writeSessionNeuron(ctx context.Context, w http.ResponseWriter, worker *concurrency.BrainWorker, indexID core.IndexID, id, content string, metadata map[string]string, parentID string, links []string) {
+	if !s.requireSessions(w, id) {
+		return
+	}
+	if parentID != "" {
+		links = append([]string{parentID}, links...)
+	}
+	ids := make([]core.NeuronID, 0, len(links))
+	for _, link := range links {
+		ids = append(ids, core.NeuronID(link))
+	}
+	found := worker.LookupNeurons(ids)
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			apierr.NotFound(w, apierr.CodeNeuronNotFound, fmt.Sprintf("linked neuron %s not found", id))
+			return
+		}
+	}
+
+	e, err := s.sessions.Write(indexID, id, content, metadata, ids)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(s.sessionDocument(ctx, id, e))
+}
+
+// mergeSessionHits adds the session's neurons matching req to hits. Each
+// matching session neuron passes sessions.spreadWeight of its score on to
+// the persistent neurons it links to, which join the results if they were
+// not among them and no metadata or role filter applies. The merged hits
+// are ordered by score and cut to req.Limit.
+func (s *Server) mergeSessionHits(ctx context.Context, worker *concurrency.BrainWorker, indexID core.IndexID, id string, req concurrency.SearchRequest, hits []searchHit) []searchHit {
+	sessionHits := s.sessions.Search(ctx, indexID, id, req.Query, req.Depth, req.Limit, req.Metadata, req.Strict, req.Roles)
+	if len(sessionHits) == 0 {
+		return hits
+	}
+
+	spread := make(map[core.NeuronID]float64)
+	for _, h := range sessionHits {
+		for _, link := range h.Links {
+			spread[link] += h.Score * s.config.Sessions.SpreadWeight
+		}
+	}
+	for i := range hits {
+		if boost, ok := spread[hits[i].neuron.ID]; ok && !hits[i].fallback {
+			hits[i].score += boost
+			delete(spread, hits[i].neuron.ID)
+		}
+	}
+	if len(spread) > 0 && len(req.Metadata) == 0 && len(req.Roles) == 0 {
+		ids := make([]core.NeuronID, 0, len(spread))
+		for nid := range spread {
+			ids = append(ids, nid)
+		}
+		for nid, n := range worker.LookupNeurons(ids) {
+			if boost := spread[nid]; boost > 0 {
+				hits = append(hits, searchHit{neuron: n, score: boost, source: indexID})
+			}
+		}
+	}
+	for _, h := range sessionHits {
+		hits = append(hits, searchHit{neuron: h.Neuron, score: h.Score, source: indexID, session: id, links: h.Links})
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	if len(hits) > req.Limit {
+		hits = hits[:req.Limit]
+	}
+	return hits
+}
+
+// handleSessions routes /v1/sessions, /v1/sessions/{id} and
+// /v1/sessions/{id}/commit for the index named by the request.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	indexID := s.getIndexID(r)
+	if indexID == "" {
+		apierr.IndexIDRequired(w)
+		return
+	}
+	id, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/sessions"), "/"), "/")
+
+	switch {
+	case id == "" && r.Method == "GET":
+		infos := s.sessions.List(indexID)
+		json.NewEncoder(w).Encode(map[string]any{"sessions": infos, "count": len(infos)})
+
+	case id != "" && action == "" && r.Method == "GET":
+		entries, err := s.sessions.Neurons(indexID, id)
+		if err != nil {
+			writeSessionError(w, err)
+			return
+		}
+		info, _ := s.sessions.Info(indexID, id)
+		docs := make([]map[string]any, len(entries))
+		for i, e := range entries {
+			docs[i] = s.sessionDocument(r.Context(), id, e)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"session": info, "neurons": docs, "count": len(docs)})
+
+	case id != "" && action == "" && r.Method == "DELETE":
+		n, err := s.sessions.Delete(indexID, id)
+		if err != nil {
+			writeSessionError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"deleted": true, "sessionId": id, "neurons": n})
+
+	case id != "" && action == "commit" && r.Method == "POST":
+		s.handleSessionCommit(w, r, indexID, id)
+
+	default:
+		apierr.MethodNotAllowed(w)
+	}
+}
+
+// handleSessionCommit promotes session neurons to persistent writes
+// (POST /v1/sessions/{id}/commit). The optional body selects them by ID
+// and by metadata; without one, every neuron of the session is committed.
+// Committed neurons leave the session. The first persistent neuron a
+// session neuron links to becomes the parent of its write.
+func (s *Server) handleSessionCommit(w http.ResponseWriter, r *http.Request, indexID core.IndexID, id string) {
+	var filter struct {
+		IDs      []string          `json:"ids,omitempty"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierr.InvalidJSON(w)
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &filter); err != nil {
+			apierr.InvalidJSON(w)
+			return
+		}
+	}
+
+	entries, err := s.sessions.Neurons(indexID, id)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	worker, err := s.getWorker(indexID)
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	committed := make([]map[string]any, 0, len(entries))
+	for _, e := range entries {
+		if len(filter.IDs) > 0 && !slices.Contains(filter.IDs, string(e.Neuron.ID)) {
+			continue
+		}
+		metadata := sessionMetadata(e.Neuron)
+		if !metadataMatches(metadata, filter.Metadata) {
+			continue
+		}
+		req := concurrency.AddNeuronRequest{Content: e.Neuron.Content, Metadata: metadata}
+		if len(e.Links) > 0 {
+			parent := e.Links[0]
+			req.ParentID = &parent
+		}
+		result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{Type: concurrency.OpWrite, Payload: req})
+		if err != nil {
+			s.writeOperationError(w, err)
+			return
+		}
+		s.sessions.Remove(indexID, id, e.Neuron.ID)
+		committed = append(committed, map[string]any{
+			"sessionNeuronId": e.Neuron.ID,
+			"id":              result.(*core.Neuron).ID,
+		})
+	}
+
+	remaining := 0
+	if info, err := s.sessions.Info(indexID, id); err == nil {
+		remaining = info.Neurons
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"sessionId": id,
+		"committed": committed,
+		"count":     len(committed),
+		"remaining": remaining,
+	})
+}
+
+// sessionMetadata returns a session neuron's metadata as strings.
+func sessionMetadata(n *core.Neuron) map[string]string {
+	n.RLock()
+	defer n.RUnlock()
+	if len(n.Metadata) == 0 {
+		return nil
+	}
+	metadata := make(map[string]string, len(n.Metadata))
+	for k, v := range n.Metadata {
+		metadata[k] = fmt.Sprintf("%v", v)
+	}
+	return metadata
+}
+
+func metadataMatches(metadata, filter map[string]string) bool {
+	for k, v := range filter {
+		if metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
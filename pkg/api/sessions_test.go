@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func newSessionServer(t *testing.T, mutator func(*core.Config)) *Server {
+	t.Helper()
+	return newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Storage.Compress = false
+		if mutator != nil {
+			mutator(cfg)
+		}
+	})
+}
+
+func writeSession(t *testing.T, s *Server, sessionID, body string) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/write", `{"scope":"session","session_id":"`+sessionID+`",`+body+`}`, map[string]string{"X-Index-ID": "work"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("session write: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func sessionSearch(t *testing.T, s *Server, query string) []map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "GET", "/v1/search?"+query, "", map[string]string{"X-Index-ID": "work"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("search: %d %s", rr.Code, rr.Body.String())
+	}
+	var out []map[string]any
+	for _, r := range decodeJSON(t, rr)["results"].([]any) {
+		out = append(out, r.(map[string]any))
+	}
+	return out
+}
+
+func TestSessions_SearchMergesOnlyTheRequestedSession(t *testing.T) {
+	s := newSessionServer(t, nil)
+	persistent := writeTo(t, s, "work", "the billing service owns invoices")
+	doc := writeSession(t, s, "a", `"content":"draft: invoices are late this week","parent_id":"`+string(persistent)+`"`)
+	if doc["scope"] != "session" || doc["sessionId"] != "a" {
+		t.Fatalf("the write should be flagged as working memory: %v", doc)
+	}
+	writeSession(t, s, "b", `"content":"session b thinks invoices are fine"`)
+
+	results := sessionSearch(t, s, "q=invoices&session_id=a")
+	var sawSession, sawPersistent bool
+	for _, r := range results {
+		switch {
+		case r["content"] == "session b thinks invoices are fine":
+			t.Error("another session's neuron leaked into the search")
+		case r["scope"] == "session":
+			sawSession = r["sessionId"] == "a"
+		case r["id"] == string(persistent):
+			sawPersistent = true
+		}
+	}
+	if !sawSession || !sawPersistent {
+		t.Fatalf("expected the session neuron and the persistent one, got %v", results)
+	}
+
+	for _, r := range sessionSearch(t, s, "q=invoices") {
+		if r["scope"] == "session" {
+			t.Errorf("a search without session_id must not see working memory: %v", r)
+		}
+	}
+	worker, _ := s.pool.GetOrCreate("work")
+	if n := len(worker.Matrix().Neurons); n != 1 {
+		t.Errorf("session writes must not reach the index, got %d neurons", n)
+	}
+}
+
+func TestSessions_SpreadReachesLinkedNeurons(t *testing.T) {
+	s := newSessionServer(t, nil)
+	linked := writeTo(t, s, "work", "quarterly roadmap review")
+	writeSession(t, s, "a", `"content":"remember the kestrel migration","links":["`+string(linked)+`"]`)
+
+	var found bool
+	for _, r := range sessionSearch(t, s, "q=kestrel&session_id=a") {
+		if r["id"] == string(linked) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("a persistent neuron linked from a matching session neuron should be spread to")
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/write", `{"scope":"session","session_id":"a","content":"x","links":["missing"]}`, map[string]string{"X-Index-ID": "work"})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a link to a missing neuron, got %d", rr.Code)
+	}
+}
+
+func TestSessions_DeleteAndExpire(t *testing.T) {
+	s := newSessionServer(t, func(cfg *core.Config) { cfg.Sessions.TTL = time.Minute })
+	headers := map[string]string{"X-Index-ID": "work"}
+	writeSession(t, s, "gone", `"content":"temporary scratch"`)
+	writeSession(t, s, "idle", `"content":"idle scratch"`)
+
+	rr := doRequest(t, s, "DELETE", "/v1/sessions/gone", "", headers)
+	if rr.Code != http.StatusOK || decodeJSON(t, rr)["neurons"] != float64(1) {
+		t.Fatalf("delete: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = doRequest(t, s, "GET", "/v1/sessions/gone", "", headers)
+	if rr.Code != http.StatusNotFound || decodeJSON(t, rr)["code"] != "SESSION_NOT_FOUND" {
+		t.Errorf("expected SESSION_NOT_FOUND after delete, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	s.sessions.Expire(time.Now().Add(2 * time.Minute))
+	if rr := doRequest(t, s, "GET", "/v1/sessions", "", headers); decodeJSON(t, rr)["count"] != float64(0) {
+		t.Errorf("the idle session should have expired: %s", rr.Body.String())
+	}
+	for _, r := range sessionSearch(t, s, "q=scratch&session_id=idle") {
+		if r["scope"] == "session" {
+			t.Errorf("an expired session's neurons must not be searched: %v", r)
+		}
+	}
+}
+
+func TestSessions_CommitPromotesMatchingNeurons(t *testing.T) {
+	s := newSessionServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "work"}
+	parent := writeTo(t, s, "work", "incident response handbook")
+	writeSession(t, s, "a", `"content":"page the on-call lead first","metadata":{"keep":"yes"},"parent_id":"`+string(parent)+`"`)
+	writeSession(t, s, "a", `"content":"coffee machine is broken","metadata":{"keep":"no"}`)
+
+	rr := doRequest(t, s, "POST", "/v1/sessions/a/commit", `{"metadata":{"keep":"yes"}}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("commit: %d %s", rr.Code, rr.Body.String())
+	}
+	doc := decodeJSON(t, rr)
+	if doc["count"] != float64(1) || doc["remaining"] != float64(1) {
+		t.Fatalf("expected one neuron committed and one left, got %v", doc)
+	}
+	id := core.NeuronID(doc["committed"].([]any)[0].(map[string]any)["id"].(string))
+	n := storedNeuron(t, s, "work", id)
+	if n.Content != "page the on-call lead first" || n.Metadata["keep"] != "yes" {
+		t.Errorf("unexpected committed neuron: %+v", n)
+	}
+
+	rr = doRequest(t, s, "GET", "/v1/sessions/a", "", headers)
+	if neurons := decodeJSON(t, rr)["neurons"].([]any); len(neurons) != 1 || neurons[0].(map[string]any)["content"] != "coffee machine is broken" {
+		t.Errorf("committed neurons should leave the session, got %v", neurons)
+	}
+}
+
+func TestSessions_NeverReachDisk(t *testing.T) {
+	var dataPath string
+	s := newSessionServer(t, func(cfg *core.Config) { dataPath = cfg.Storage.DataPath })
+	writeTo(t, s, "work", "durable fact about persistence")
+	const secret = "ephemeral-session-marker-7f3a"
+	writeSession(t, s, "a", `"content":"`+secret+`"`)
+
+	if err := s.pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+	var files int
+	err := filepath.WalkDir(dataPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files++
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("session content found on disk in %s", p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files == 0 {
+		t.Fatal("expected the persistent write to leave files in the data dir")
+	}
+}
+
+func TestSessions_Validation(t *testing.T) {
+	s := newSessionServer(t, nil)
+	headers := map[string]string{"X-Index-ID": "work"}
+	for _, body := range []string{
+		`{"scope":"session","content":"no session id"}`,
+		`{"scope":"session","session_id":"a b","content":"bad id"}`,
+		`{"session_id":"a","content":"no scope"}`,
+		`{"scope":"global","content":"unknown scope"}`,
+		`{"scope":"session","session_id":"a","content":"pinned","pinned":true}`,
+	} {
+		if rr := doRequest(t, s, "POST", "/v1/write", body, headers); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+
+	off := newSessionServer(t, func(cfg *core.Config) { cfg.Sessions.Enabled = false })
+	rr := doRequest(t, off, "POST", "/v1/write", `{"scope":"session","session_id":"a","content":"x"}`, headers)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 with sessions disabled, got %d", rr.Code)
+	}
+}
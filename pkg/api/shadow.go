@@ -0,0 +1,375 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+const (
+	// shadowHeader marks mirrored requests so the shadow can tell them apart
+	// and so a shadow never mirrors its own shadow traffic onward.
+	shadowHeader = "X-QubicDB-Shadow"
+
+	// shadowWorkers is the number of goroutines replaying mirrored requests.
+	shadowWorkers = 4
+)
+
+// hopHeaders are not forwarded to the shadow.
+var hopHeaders = []string{"Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade", "Te", "Trailer", "Proxy-Authorization"}
+
+// shadowRequest is one primary request queued for replay.
+type shadowRequest struct {
+	method  string
+	target  string
+	header  http.Header
+	body    []byte
+	indexID string
+
+	primaryStatus int
+	primaryCount  int
+	hasCount      bool
+}
+
+// ShadowMismatch records a shadow response that disagreed with the primary.
+type ShadowMismatch struct {
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	IndexID       string    `json:"indexId,omitempty"`
+	PrimaryStatus int       `json:"primaryStatus"`
+	ShadowStatus  int       `json:"shadowStatus"`
+	PrimaryCount  *int      `json:"primaryCount,omitempty"`
+	ShadowCount   *int      `json:"shadowCount,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// shadowMirror replays a sample of /v1 traffic against a secondary server.
+// Enqueueing never blocks: when the queue is full the request is dropped.
+type shadowMirror struct {
+	baseURL    string
+	sampleRate float64
+	endpoints  []string
+	compare    bool
+	client     *http.Client
+	queue      chan *shadowRequest
+
+	sampleFn func() float64 // returns [0,1); replaced in tests
+
+	mirrored   atomic.Uint64
+	dropped    atomic.Uint64
+	failed     atomic.Uint64
+	compared   atomic.Uint64
+	mismatched atomic.Uint64
+
+	mismatchMu   sync.Mutex
+	mismatches   []ShadowMismatch
+	mismatchNext int
+	mismatchCap  int
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+	once sync.Once
+}
+
+func newShadowMirror(cfg core.ShadowConfig) *shadowMirror {
+	m := &shadowMirror{
+		baseURL:     strings.TrimRight(cfg.URL, "/"),
+		sampleRate:  cfg.SampleRate,
+		endpoints:   cfg.Endpoints,
+		compare:     cfg.Compare,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		queue:       make(chan *shadowRequest, cfg.QueueSize),
+		sampleFn:    rand.Float64,
+		mismatchCap: cfg.MismatchSampleSize,
+		stop:        make(chan struct{}),
+	}
+	for i := 0; i < shadowWorkers; i++ {
+		m.wg.Add(1)
+		go m.run()
+	}
+	return m
+}
+
+// eligible reports whether a request may be mirrored at all.
+func (m *shadowMirror) eligible(r *http.Request) bool {
+	if r.Header.Get(shadowHeader) != "" {
+		return false
+	}
+	path := r.URL.Path
+	if !strings.HasPrefix(path, "/v1/") || isSharedPath(path) {
+		// Share tokens never leave the server.
+		return false
+	}
+	if len(m.endpoints) > 0 {
+		for _, prefix := range m.endpoints {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return !isSensitiveShadowPath(r.Method, path)
+}
+
+// isSensitiveShadowPath reports endpoints excluded from mirroring unless
+// they are listed in server.shadow.endpoints explicitly.
+func isSensitiveShadowPath(method, path string) bool {
+	if path == "/v1/config" {
+		return true
+	}
+	if strings.HasPrefix(path, "/v1/registry") && method != "GET" {
+		return true
+	}
+	if strings.HasPrefix(path, "/v1/shares") {
+		return true
+	}
+	return false
+}
+
+// sample decides whether an eligible request is mirrored.
+func (m *shadowMirror) sample() bool {
+	if m.sampleRate >= 1 {
+		return true
+	}
+	return m.sampleFn() < m.sampleRate
+}
+
+// enqueue queues req without blocking, counting it as dropped if full.
+func (m *shadowMirror) enqueue(req *shadowRequest) {
+	select {
+	case <-m.stop:
+		m.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case m.queue <- req:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+func (m *shadowMirror) run() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case req := <-m.queue:
+			m.replay(req)
+		}
+	}
+}
+
+func (m *shadowMirror) replay(req *shadowRequest) {
+	httpReq, err := http.NewRequest(req.method, m.baseURL+req.target, bytes.NewReader(req.body))
+	if err != nil {
+		m.failed.Add(1)
+		return
+	}
+	httpReq.Header = req.header
+	httpReq.Header.Set(shadowHeader, "1")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		m.failed.Add(1)
+		return
+	}
+	defer resp.Body.Close()
+	m.mirrored.Add(1)
+
+	if !m.compare {
+		io.Copy(io.Discard, resp.Body)
+		return
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	shadowCount, shadowHasCount := resultCount(body)
+	m.compared.Add(1)
+
+	mismatch := resp.StatusCode != req.primaryStatus ||
+		req.hasCount != shadowHasCount ||
+		(req.hasCount && req.primaryCount != shadowCount)
+	if !mismatch {
+		return
+	}
+	m.mismatched.Add(1)
+
+	path := req.target
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	rec := ShadowMismatch{
+		Method:        req.method,
+		Path:          path,
+		IndexID:       req.indexID,
+		PrimaryStatus: req.primaryStatus,
+		ShadowStatus:  resp.StatusCode,
+		Timestamp:     time.Now(),
+	}
+	if req.hasCount {
+		n := req.primaryCount
+		rec.PrimaryCount = &n
+	}
+	if shadowHasCount {
+		n := shadowCount
+		rec.ShadowCount = &n
+	}
+	m.recordMismatch(rec)
+}
+
+func (m *shadowMirror) recordMismatch(rec ShadowMismatch) {
+	if m.mismatchCap <= 0 {
+		return
+	}
+	m.mismatchMu.Lock()
+	defer m.mismatchMu.Unlock()
+
+	if len(m.mismatches) < m.mismatchCap {
+		m.mismatches = append(m.mismatches, rec)
+		return
+	}
+	m.mismatches[m.mismatchNext] = rec
+	m.mismatchNext = (m.mismatchNext + 1) % m.mismatchCap
+}
+
+// Mismatches returns sampled mismatches, oldest first.
+func (m *shadowMirror) Mismatches() []ShadowMismatch {
+	m.mismatchMu.Lock()
+	defer m.mismatchMu.Unlock()
+
+	out := make([]ShadowMismatch, 0, len(m.mismatches))
+	out = append(out, m.mismatches[m.mismatchNext:]...)
+	out = append(out, m.mismatches[:m.mismatchNext]...)
+	return out
+}
+
+// Stats returns mirroring counters.
+func (m *shadowMirror) Stats() map[string]any {
+	return map[string]any{
+		"url":           m.baseURL,
+		"sampleRate":    m.sampleRate,
+		"compare":       m.compare,
+		"mirrored":      m.mirrored.Load(),
+		"dropped":       m.dropped.Load(),
+		"failed":        m.failed.Load(),
+		"compared":      m.compared.Load(),
+		"mismatched":    m.mismatched.Load(),
+		"queueLength":   len(m.queue),
+		"queueCapacity": cap(m.queue),
+	}
+}
+
+// Close stops the replay workers. Queued requests are discarded.
+func (m *shadowMirror) Close() {
+	m.once.Do(func() {
+		close(m.stop)
+		m.wg.Wait()
+	})
+}
+
+// resultCount extracts the number of results from a JSON response body:
+// "count" where present, otherwise "neuronsUsed" from /v1/context.
+func resultCount(body []byte) (int, bool) {
+	var resp map[string]any
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, false
+	}
+	for _, key := range []string{"count", "neuronsUsed"} {
+		if v, ok := resp[key].(float64); ok {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// errReader returns err from every Read.
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
+
+// shadowRecorder captures the primary response status, and its body when
+// comparison is enabled, while passing everything through to the client.
+type shadowRecorder struct {
+	http.ResponseWriter
+	status  int
+	capture bool
+	body    bytes.Buffer
+}
+
+func (r *shadowRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *shadowRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if r.capture {
+		r.body.Write(p)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// mirrorRequest serves r through next and, if r is sampled for shadowing,
+// queues a replay once the primary response is complete. It reports whether
+// the request was handled; when false the caller serves r itself.
+func (s *Server) mirrorRequest(w http.ResponseWriter, r *http.Request, next http.Handler) bool {
+	m := s.shadow
+	if m == nil || !m.eligible(r) || !m.sample() {
+		return false
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			// Replay what was read followed by the error so the handler
+			// surfaces it (e.g. body too large) exactly as it would have.
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+			return false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	header := r.Header.Clone()
+	for _, h := range hopHeaders {
+		header.Del(h)
+	}
+	target := r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	rec := &shadowRecorder{ResponseWriter: w, capture: m.compare}
+	next.ServeHTTP(rec, r)
+
+	req := &shadowRequest{
+		method:        r.Method,
+		target:        target,
+		header:        header,
+		body:          body,
+		indexID:       string(s.getIndexID(r)),
+		primaryStatus: rec.status,
+	}
+	if req.primaryStatus == 0 {
+		req.primaryStatus = http.StatusOK
+	}
+	if m.compare {
+		req.primaryCount, req.hasCount = resultCount(rec.body.Bytes())
+	}
+	m.enqueue(req)
+	return true
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// shadowTarget records every request it receives and answers with respond.
+type shadowTarget struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (st *shadowTarget) count() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.requests)
+}
+
+func newShadowTarget(t *testing.T, respond http.HandlerFunc) (*shadowTarget, *httptest.Server) {
+	t.Helper()
+	st := &shadowTarget{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		st.mu.Lock()
+		st.requests = append(st.requests, r)
+		st.bodies = append(st.bodies, string(body))
+		st.mu.Unlock()
+		respond(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return st, srv
+}
+
+func newShadowTestServer(t *testing.T, shadowURL string, mutate func(*core.ShadowConfig)) *Server {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+		cfg.Server.Shadow.URL = shadowURL
+		cfg.Server.Shadow.SampleRate = 1
+		if mutate != nil {
+			mutate(&cfg.Server.Shadow)
+		}
+	})
+	t.Cleanup(s.shadow.Close)
+	return s
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShadow_MirrorsSampledRequests(t *testing.T) {
+	st, srv := newShadowTarget(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	s := newShadowTestServer(t, srv.URL, func(sc *core.ShadowConfig) {
+		sc.SampleRate = 0.5
+		sc.Compare = false
+	})
+	// Alternate above and below the sample rate: every other request is mirrored.
+	var n atomic.Int64
+	s.shadow.sampleFn = func() float64 {
+		if n.Add(1)%2 == 0 {
+			return 0.9
+		}
+		return 0.1
+	}
+
+	for i := 0; i < 10; i++ {
+		rr := doRequest(t, s, "POST", "/v1/search", `{"query":"mirror"}`, map[string]string{
+			"X-Index-ID": "shadow-idx",
+		})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("primary search failed: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	waitUntil(t, "5 mirrored requests", func() bool { return st.count() == 5 })
+	time.Sleep(20 * time.Millisecond)
+	if got := st.count(); got != 5 {
+		t.Errorf("expected 5 mirrored requests at sampleRate 0.5, got %d", got)
+	}
+
+	st.mu.Lock()
+	req, body := st.requests[0], st.bodies[0]
+	st.mu.Unlock()
+	if req.Header.Get("X-Index-ID") != "shadow-idx" {
+		t.Errorf("expected X-Index-ID forwarded, got %q", req.Header.Get("X-Index-ID"))
+	}
+	if req.Header.Get(shadowHeader) == "" {
+		t.Errorf("expected %s header on mirrored request", shadowHeader)
+	}
+	if req.Method != "POST" || req.URL.Path != "/v1/search" || body != `{"query":"mirror"}` {
+		t.Errorf("mirrored request mismatch: %s %s %q", req.Method, req.URL.Path, body)
+	}
+}
+
+func TestShadow_ExcludesSensitiveEndpoints(t *testing.T) {
+	st, srv := newShadowTarget(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	s := newShadowTestServer(t, srv.URL, nil)
+
+	doRequest(t, s, "POST", "/v1/registry", `{"uuid":"secret-idx"}`, nil)
+	doRequest(t, s, "GET", "/admin/indexes", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	doRequest(t, s, "GET", "/v1/search?q=x", "", map[string]string{shadowHeader: "1", "X-Index-ID": "a"})
+	doRequest(t, s, "GET", "/v1/search?q=x", "", map[string]string{"X-Index-ID": "a"})
+
+	waitUntil(t, "one mirrored request", func() bool { return st.count() == 1 })
+	time.Sleep(20 * time.Millisecond)
+	if got := st.count(); got != 1 {
+		t.Fatalf("expected only the plain search to be mirrored, got %d", got)
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.requests[0].URL.Path != "/v1/search" || st.requests[0].URL.RawQuery != "q=x" {
+		t.Errorf("unexpected mirrored request %s?%s", st.requests[0].URL.Path, st.requests[0].URL.RawQuery)
+	}
+}
+
+func TestShadow_StalledShadowDoesNotBlockPrimary(t *testing.T) {
+	release := make(chan struct{})
+	_, srv := newShadowTarget(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	s := newShadowTestServer(t, srv.URL, func(sc *core.ShadowConfig) {
+		sc.QueueSize = 1
+	})
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		rr := doRequest(t, s, "GET", "/v1/search?q=stall", "", map[string]string{"X-Index-ID": "stall-idx"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("primary request %d failed: %d", i, rr.Code)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("primary requests slowed by stalled shadow: %v", elapsed)
+	}
+
+	stats := s.shadow.Stats()
+	if stats["dropped"].(uint64) == 0 {
+		t.Error("expected overflow to be counted as dropped")
+	}
+}
+
+func TestShadow_MismatchAccounting(t *testing.T) {
+	_, srv := newShadowTarget(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/search":
+			w.Write([]byte(`{"results":[{},{},{}],"count":3}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{}`))
+		}
+	})
+	s := newShadowTestServer(t, srv.URL, nil)
+
+	// Primary search on an empty index returns count 0; the shadow says 3.
+	doRequest(t, s, "GET", "/v1/search?q=nothing", "", map[string]string{"X-Index-ID": "mm-idx"})
+	// /health is outside /v1 and never mirrored; /v1/stats matches (no count on either side).
+	doRequest(t, s, "GET", "/health", "", nil)
+	doRequest(t, s, "GET", "/v1/stats", "", nil)
+
+	waitUntil(t, "two comparisons", func() bool { return s.shadow.compared.Load() == 2 })
+	if got := s.shadow.mismatched.Load(); got != 1 {
+		t.Fatalf("expected 1 mismatch, got %d", got)
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/shadow/mismatches", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	m := decodeJSON(t, rr)
+	if m["enabled"] != true {
+		t.Fatalf("expected enabled=true, got %v", m["enabled"])
+	}
+	mismatches := m["mismatches"].([]any)
+	if len(mismatches) != 1 {
+		t.Fatalf("expected 1 sampled mismatch, got %d", len(mismatches))
+	}
+	mm := mismatches[0].(map[string]any)
+	if mm["path"] != "/v1/search" || mm["indexId"] != "mm-idx" || mm["primaryCount"].(float64) != 0 || mm["shadowCount"].(float64) != 3 {
+		t.Errorf("unexpected mismatch record: %v", mm)
+	}
+	if stats := m["stats"].(map[string]any); stats["mismatched"].(float64) != 1 {
+		t.Errorf("expected stats.mismatched=1, got %v", stats["mismatched"])
+	}
+}
+
+func TestShadow_MismatchRingBufferCap(t *testing.T) {
+	m := &shadowMirror{mismatchCap: 2}
+	for _, path := range []string{"/a", "/b", "/c"} {
+		m.recordMismatch(ShadowMismatch{Path: path})
+	}
+	got := m.Mismatches()
+	if len(got) != 2 || got[0].Path != "/b" || got[1].Path != "/c" {
+		t.Errorf("expected [/b /c] oldest first, got %v", got)
+	}
+}
+
+func TestShadow_DisabledByDefault(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	if s.shadow != nil {
+		t.Fatal("shadow mirror should be nil without server.shadow.url")
+	}
+	rr := doRequest(t, s, "GET", "/admin/shadow/mismatches", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "secret"),
+	})
+	if m := decodeJSON(t, rr); m["enabled"] != false {
+		t.Errorf("expected enabled=false, got %v", m["enabled"])
+	}
+}
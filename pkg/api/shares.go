@@ -0,0 +1,219 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/share"
+)
+
+// sharedPrefix starts the token-authenticated share routes.
+const sharedPrefix = "/v1/shared/"
+
+// newShares opens the share store. Shares stay disabled if the store
+// cannot be loaded.
+func (s *Server) newShares(cfg core.SharesConfig) {
+	store, err := share.NewStore(s.config.Storage.DataPath, cfg.MaxPerIndex, cfg.MaxResults, cfg.DefaultExpiry, cfg.MaxExpiry)
+	if err != nil {
+		log.Printf("⚠ Shares disabled: %v", err)
+		return
+	}
+	s.shares = store
+	s.shareLimiter = newWindowLimiter(cfg.RateLimitRequests, cfg.RateLimitWindow)
+	s.badgeLimiter = newWindowLimiter(cfg.BadgeRateLimitRequests, cfg.BadgeRateLimitWindow)
+}
+
+// isSharedPath reports whether path is served by share or badge token.
+func isSharedPath(path string) bool {
+	return strings.HasPrefix(path, sharedPrefix) || strings.HasPrefix(path, badgePrefix)
+}
+
+// handleShares routes /v1/shares and /v1/shares/{id} for the index named
+// by the request.
+func (s *Server) handleShares(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	indexID := s.getIndexID(r)
+	if indexID == "" {
+		apierr.IndexIDRequired(w)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/shares"), "/")
+
+	switch {
+	case id == "" && r.Method == "GET":
+		shares := s.shares.List(string(indexID))
+		docs := make([]map[string]any, len(shares))
+		for i, sh := range shares {
+			docs[i] = shareDocument(sh)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"shares": docs,
+			"count":  len(docs),
+		})
+
+	case id == "" && r.Method == "POST":
+		var spec share.Spec
+		if !s.decodeJSONRequest(w, r, &spec) {
+			return
+		}
+		if _, err := s.getWorker(indexID); err != nil {
+			s.writeWorkerError(w, err)
+			return
+		}
+		sh, token, err := s.shares.Create(string(indexID), spec)
+		if err != nil {
+			writeShareError(w, err)
+			return
+		}
+		doc := shareDocument(sh)
+		doc["token"] = token
+		doc["path"] = sharedPrefix + token
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(doc)
+
+	case id != "" && r.Method == "DELETE":
+		if err := s.shares.Delete(string(indexID), id); err != nil {
+			writeShareError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"deleted": true, "id": id})
+
+	default:
+		apierr.MethodNotAllowed(w)
+	}
+}
+
+// shareDocument adds whether a share has expired.
+func shareDocument(sh *share.Share) map[string]any {
+	data, _ := json.Marshal(sh)
+	var doc map[string]any
+	json.Unmarshal(data, &doc)
+	doc["expired"] = sh.Expired(time.Now())
+	return doc
+}
+
+func writeShareError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, share.ErrNotFound):
+		apierr.NotFound(w, apierr.CodeShareNotFound, err.Error())
+	case errors.Is(err, share.ErrExpired):
+		apierr.Gone(w, apierr.CodeShareExpired, err.Error())
+	case errors.Is(err, share.ErrLimitReached):
+		apierr.Conflict(w, apierr.CodeShareLimit, err.Error())
+	case errors.Is(err, share.ErrInvalid):
+		apierr.BadRequest(w, apierr.CodeInvalidShare, err.Error())
+	default:
+		apierr.Internal(w, err.Error())
+	}
+}
+
+// handleShared serves GET /v1/shared/{token}/search and /recall. The token
+// alone selects the index; only neurons matching the share's filter are
+// returned, and they are not fired.
+func (s *Server) handleShared(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	token, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, sharedPrefix), "/")
+	if action != "search" && action != "recall" {
+		apierr.NotFound(w, apierr.CodeNotFound, "shared routes are search and recall")
+		return
+	}
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	sh, err := s.shares.Resolve(token)
+	if err == nil && sh.IsBadge() {
+		err = share.ErrNotFound // a badge grants its counts only
+	}
+	if err != nil {
+		writeShareError(w, err)
+		return
+	}
+	if !s.shareLimiter.allow(sh.ID) {
+		w.Header().Set("Retry-After", strconv.Itoa(s.shareLimiter.retryAfterSeconds()))
+		apierr.TooManyRequests(w, "share rate limit exceeded")
+		return
+	}
+
+	indexID := core.IndexID(sh.IndexID)
+	worker, err := s.getWorker(indexID)
+	if err != nil {
+		// The owning index is gone; the share is as good as revoked.
+		apierr.NotFound(w, apierr.CodeShareNotFound, share.ErrNotFound.Error())
+		return
+	}
+
+	limit := sh.MaxResults
+	if v := parsePositiveQueryInt(r.URL.Query().Get("limit")); v > 0 && v < limit {
+		limit = v
+	}
+
+	var neurons []*core.Neuron
+	resp := map[string]any{}
+	if action == "search" {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			apierr.QueryRequired(w)
+			return
+		}
+		depth := defaultSearchDepth
+		if v := parsePositiveQueryInt(r.URL.Query().Get("depth")); v > 0 {
+			depth = v
+		}
+		neurons, _, err = s.runSearch(r.Context(), worker, indexID, searchKindSearch, concurrency.SearchRequest{
+			Query: query,
+			Depth: clampPositive(depth, defaultSearchDepth, maxSearchDepth),
+			// Fetch extra so filtering still fills the limit.
+			Limit:    min(limit*4, maxSearchLimit),
+			Metadata: sh.Filter.Metadata,
+			Strict:   len(sh.Filter.Metadata) > 0,
+			Roles:    s.resolveRoles(indexID, nil),
+			Passive:  true,
+			// A share shows what its filter selects, not the owner's focus.
+			IgnoreFocus: true,
+		})
+		resp["query"] = query
+	} else {
+		var result any
+		result, err = worker.SubmitContext(r.Context(), &concurrency.Operation{
+			Type: concurrency.OpRecall,
+			Payload: concurrency.ListNeuronsRequest{
+				Roles:       s.resolveRoles(indexID, nil),
+				IgnoreFocus: true,
+			},
+		})
+		if err == nil {
+			neurons = result.([]*core.Neuron)
+		}
+	}
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	docs := make([]any, 0, min(limit, len(neurons)))
+	for _, n := range neurons {
+		if len(docs) == limit {
+			break
+		}
+		if sh.Filter.Matches(n) {
+			docs = append(docs, s.typedNeuronDocument(r.Context(), n))
+		}
+	}
+	defer releaseNeuronDocuments(docs)
+	resp["count"] = len(docs)
+	resp["expiresAt"] = sh.ExpiresAt
+	writeDocumentLists(w, resp, map[string][]any{"results": docs})
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func newShareTestServer(t *testing.T, mutate func(*core.Config)) *Server {
+	t.Helper()
+	return newTestServer(t, func(cfg *core.Config) {
+		cfg.Shares.Enabled = true
+		cfg.Shares.MaxResults = 10
+		if mutate != nil {
+			mutate(cfg)
+		}
+	})
+}
+
+func ownerRequest(t *testing.T, s *Server, method, path, body string, want int) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, method, path, body, map[string]string{
+		"X-Index-ID":   "project",
+		"Content-Type": "application/json",
+	})
+	if rr.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d: %s", method, path, want, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func sharedRequest(t *testing.T, s *Server, path string, want int) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "GET", path, "", nil)
+	if rr.Code != want {
+		t.Fatalf("GET %s: expected %d, got %d: %s", path, want, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func TestShares_FilterEnforced(t *testing.T) {
+	s := newShareTestServer(t, nil)
+	for _, body := range []string{
+		`{"content":"Release notes: billing ships weekly","metadata":{"visibility":"public"}}`,
+		`{"content":"Release notes: search ships monthly","metadata":{"visibility":"public"}}`,
+		`{"content":"Release notes: billing salaries are confidential","metadata":{"visibility":"private"}}`,
+		`{"content":"Release notes: billing incident postmortem"}`,
+	} {
+		ownerRequest(t, s, "POST", "/v1/write", body, http.StatusOK)
+	}
+
+	created := ownerRequest(t, s, "POST", "/v1/shares", `{"filter":{"metadata":{"visibility":"public"}}}`, http.StatusCreated)
+	path, _ := created["path"].(string)
+	if !strings.HasPrefix(path, "/v1/shared/qsh_") || created["indexId"] != "project" || created["tokenHash"] != nil {
+		t.Fatalf("unexpected share: %v", created)
+	}
+
+	search := sharedRequest(t, s, path+"/search?q=release+notes+billing&depth=3", http.StatusOK)
+	contents := resultContents(search)
+	if len(contents) != 2 {
+		t.Fatalf("expected the 2 public neurons, got %v", contents)
+	}
+	for _, c := range append(contents, resultContents(sharedRequest(t, s, path+"/recall", http.StatusOK))...) {
+		if strings.Contains(c, "confidential") || strings.Contains(c, "postmortem") {
+			t.Fatalf("non-matching neuron leaked: %q", c)
+		}
+	}
+
+	queryShare := ownerRequest(t, s, "POST", "/v1/shares", `{"filter":{"query":"billing weekly"},"maxResults":1}`, http.StatusCreated)
+	recall := sharedRequest(t, s, queryShare["path"].(string)+"/recall", http.StatusOK)
+	if got := resultContents(recall); len(got) != 1 || got[0] != "Release notes: billing ships weekly" {
+		t.Fatalf("query filter not enforced: %v", got)
+	}
+
+	rr := doRequest(t, s, "POST", path+"/search", `{"query":"billing"}`, map[string]string{"Content-Type": "application/json"})
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("shared routes must be read-only, got %d", rr.Code)
+	}
+	rr = doRequest(t, s, "GET", path+"/graph", "", nil)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("only search and recall may be shared, got %d", rr.Code)
+	}
+
+	stats := decodeJSON(t, doRequest(t, s, "GET", "/admin/stats", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+	}))
+	if shares := stats["shares"].(map[string]any); shares["active"] != float64(2) {
+		t.Errorf("expected 2 active shares in stats, got %v", shares)
+	}
+}
+
+func TestShares_ExpiryAndRevocation(t *testing.T) {
+	s := newShareTestServer(t, nil)
+	ownerRequest(t, s, "POST", "/v1/write", `{"content":"Public roadmap","metadata":{"visibility":"public"}}`, http.StatusOK)
+
+	expiring := ownerRequest(t, s, "POST", "/v1/shares", `{"filter":{"metadata":{"visibility":"public"}},"expiry":"20ms"}`, http.StatusCreated)
+	time.Sleep(40 * time.Millisecond)
+	resp := sharedRequest(t, s, expiring["path"].(string)+"/recall", http.StatusGone)
+	if resp["code"] != "SHARE_EXPIRED" {
+		t.Errorf("expected SHARE_EXPIRED, got %v", resp)
+	}
+
+	kept := ownerRequest(t, s, "POST", "/v1/shares", `{"filter":{"tags":["roadmap"]}}`, http.StatusCreated)
+	list := ownerRequest(t, s, "GET", "/v1/shares", "", http.StatusOK)
+	if list["count"] != float64(2) {
+		t.Fatalf("expected both shares listed, got %v", list)
+	}
+	for _, sh := range list["shares"].([]any) {
+		doc := sh.(map[string]any)
+		if doc["token"] != nil || doc["path"] != nil {
+			t.Fatalf("listing must not reveal tokens: %v", doc)
+		}
+		if doc["id"] == expiring["id"] && doc["expired"] != true {
+			t.Errorf("expired share not marked: %v", doc)
+		}
+	}
+
+	ownerRequest(t, s, "DELETE", "/v1/shares/"+kept["id"].(string), "", http.StatusOK)
+	resp = sharedRequest(t, s, kept["path"].(string)+"/recall", http.StatusNotFound)
+	if resp["code"] != "SHARE_NOT_FOUND" {
+		t.Errorf("expected SHARE_NOT_FOUND, got %v", resp)
+	}
+	sharedRequest(t, s, "/v1/shared/qsh_guessed/recall", http.StatusNotFound)
+}
+
+func TestShares_RateLimitIndependent(t *testing.T) {
+	s := newShareTestServer(t, func(cfg *core.Config) {
+		cfg.Shares.RateLimitRequests = 2
+	})
+	ownerRequest(t, s, "POST", "/v1/write", `{"content":"Public roadmap","metadata":{"visibility":"public"}}`, http.StatusOK)
+	first := ownerRequest(t, s, "POST", "/v1/shares", `{"filter":{"query":"roadmap"}}`, http.StatusCreated)["path"].(string)
+	second := ownerRequest(t, s, "POST", "/v1/shares", `{"filter":{"query":"roadmap"}}`, http.StatusCreated)["path"].(string)
+
+	// Exhaust the per-client limit: shared links keep working.
+	s.rateLimitRequests = 1
+	doRequest(t, s, "GET", "/health", "", nil)
+	if rr := doRequest(t, s, "GET", "/health", "", nil); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the client limit to be exhausted, got %d", rr.Code)
+	}
+	sharedRequest(t, s, first+"/recall", http.StatusOK)
+	sharedRequest(t, s, first+"/search?q=roadmap", http.StatusOK)
+
+	// Exhaust one share: the other is unaffected.
+	rr := doRequest(t, s, "GET", first+"/recall", "", nil)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d %v", rr.Code, rr.Header())
+	}
+	sharedRequest(t, s, second+"/recall", http.StatusOK)
+}
+
+func TestShares_TokenNotLogged(t *testing.T) {
+	s := newShareTestServer(t, nil)
+	created := ownerRequest(t, s, "POST", "/v1/shares", `{"filter":{"query":"roadmap"}}`, http.StatusCreated)
+	token := created["token"].(string)
+
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+	sharedRequest(t, s, created["path"].(string)+"/recall", http.StatusOK)
+
+	if strings.Contains(buf.String(), token) || !strings.Contains(buf.String(), "/v1/shared/"+token[:8]) {
+		t.Errorf("token not redacted in log: %q", buf.String())
+	}
+	if got := spanRoute("/v1/shared/" + token + "/search"); got != "/v1/shared/{id}/search" {
+		t.Errorf("unexpected span route %q", got)
+	}
+}
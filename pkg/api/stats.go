@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// Version is the server version reported by /v1/stats. Release builds set
+// it with -ldflags "-X github.com/qubicDB/qubicdb/pkg/api.Version=v1.2.3".
+var Version = "dev"
+
+// noisedStatsKeys are the per-index counts stats.noise perturbs.
+var noisedStatsKeys = []string{"neuron_count", "pinned_count", "neurons_with_metadata", "synapse_count", "total_activations", "version"}
+
+// handleStats serves tenants: server status and version, plus the stats of
+// the caller's own index when the request names one. Pool-wide aggregates
+// would let one tenant watch another's activity, so they are only served
+// to admins at /admin/stats.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{
+		"status":  "healthy",
+		"version": Version,
+	}
+	if vector := s.vectorStats(); vector != nil {
+		resp["vector"] = vector
+	}
+
+	if indexID := s.getIndexID(r); indexID != "" {
+		worker, err := s.getWorker(indexID)
+		if err != nil {
+			s.writeWorkerError(w, err)
+			return
+		}
+		stats, err := s.tenantIndexStats(r.Context(), indexID, worker, refreshHealth(r))
+		if err != nil {
+			s.writeOperationError(w, err)
+			return
+		}
+		resp["indexId"] = indexID
+		resp["index"] = stats
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// tenantIndexStats returns the stats of worker's index as shown to
+// callers without admin credentials, with noise added when stats.noise is
+// set, the index's disk usage against its quota and its health score,
+// recomputed first when refreshHealth is set.
+func (s *Server) tenantIndexStats(ctx context.Context, indexID core.IndexID, worker *concurrency.BrainWorker, refreshHealth bool) (map[string]any, error) {
+	health, err := s.indexHealth(ctx, worker, refreshHealth, concurrency.PriorityInteractive)
+	if err != nil {
+		return nil, err
+	}
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{Type: concurrency.OpGetStats})
+	if err != nil {
+		return nil, err
+	}
+	stats := result.(map[string]any)
+	stats["health"] = health
+	if s.config.Stats.Noise {
+		addStatsNoise(stats, s.config.Stats.NoiseBound)
+	}
+	stats["disk"] = s.diskReport(indexID)
+	return stats, nil
+}
+
+// handleAdminStats returns exact pool-wide statistics.
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+	stats := map[string]any{
+		"pool":        s.pool.Stats(),
+		"lifecycle":   s.lifecycle.Stats(),
+		"concurrency": s.concurrency.Stats(),
+		"storage":     s.pool.StoreStats(),
+	}
+	if s.shares != nil {
+		stats["shares"] = s.shares.Stats()
+	}
+	if s.authThrottle != nil {
+		stats["authThrottle"] = s.authThrottle.Stats()
+	}
+	if s.replication != nil {
+		stats["replication"] = s.replication.shipper.Stats()
+	}
+	if s.prefetchLimiter != nil {
+		stats["prefetch"] = s.pool.PrefetchStats()
+	}
+	if s.sessions != nil {
+		stats["sessions"] = s.sessions.Stats()
+	}
+	if s.disk != nil {
+		stats["disk"] = s.disk.Status()
+	}
+	if vector := s.vectorStats(); vector != nil {
+		stats["vector"] = vector
+	}
+	json.NewEncoder(w).Encode(stats)
+}
+
+// addStatsNoise shifts each count in an index's stats by a uniform random
+// amount in [-bound, bound], never below zero.
+func addStatsNoise(stats map[string]any, bound int) {
+	noise := func(v int64) int64 {
+		return max(0, v+rand.Int63n(int64(2*bound+1))-int64(bound))
+	}
+	for _, key := range noisedStatsKeys {
+		switch v := stats[key].(type) {
+		case int:
+			stats[key] = int(noise(int64(v)))
+		case uint64:
+			stats[key] = uint64(noise(int64(v)))
+		}
+	}
+	if depths, ok := stats["depth_distribution"].(map[int]int); ok {
+		noisy := make(map[int]int, len(depths))
+		for depth, n := range depths {
+			noisy[depth] = int(noise(int64(n)))
+		}
+		stats["depth_distribution"] = noisy
+	}
+	// The length of the weight list is the exact synapse count.
+	delete(stats, "synapse_weights")
+	// So are the neuron count and the count signals of the health score.
+	if h, ok := stats["health"].(*engine.Health); ok && h != nil {
+		stats["health"] = healthWithoutCounts(h)
+	}
+}
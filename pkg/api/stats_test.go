@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestStats_TenantViewHasNoCrossTenantAggregates(t *testing.T) {
+	s := newTestServer(t, nil)
+	for i := 0; i < 3; i++ {
+		writeWithConflicts(t, s, "tenant-a", fmt.Sprintf("tenant a memory %d", i))
+	}
+	writeWithConflicts(t, s, "tenant-b", "tenant b memory")
+
+	for _, headers := range []map[string]string{nil, {"X-Index-ID": "tenant-b"}} {
+		rr := doRequest(t, s, "GET", "/v1/stats", "", headers)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		m := decodeJSON(t, rr)
+		for key := range m {
+			switch key {
+			case "status", "version", "indexId", "index":
+			default:
+				t.Errorf("tenant stats expose %q", key)
+			}
+		}
+		if m["status"] != "healthy" || m["version"] != Version {
+			t.Errorf("missing server health: %v", m)
+		}
+		if headers == nil {
+			if m["index"] != nil {
+				t.Errorf("no index named, expected no index stats: %v", m)
+			}
+			continue
+		}
+		index := m["index"].(map[string]any)
+		if m["indexId"] != "tenant-b" || index["neuron_count"] != float64(1) {
+			t.Errorf("expected only tenant-b's stats, got %v", m)
+		}
+		for _, key := range []string{"synapse_count", "average_energy", "depth_distribution", "neurons_with_metadata", "oldest_neuron_at", "newest_neuron_at", "memory_bytes"} {
+			if _, ok := index[key]; !ok {
+				t.Errorf("index stats missing %q", key)
+			}
+		}
+	}
+
+	if rr := doRequest(t, s, "GET", "/admin/stats", "", nil); rr.Code != http.StatusUnauthorized {
+		t.Errorf("pool-wide stats need admin credentials, got %d", rr.Code)
+	}
+	rr := doRequest(t, s, "GET", "/admin/stats", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("admin stats: %d %s", rr.Code, rr.Body.String())
+	}
+	admin := decodeJSON(t, rr)
+	for _, key := range []string{"pool", "lifecycle", "concurrency", "storage"} {
+		if _, ok := admin[key]; !ok {
+			t.Errorf("admin stats missing %q", key)
+		}
+	}
+}
+
+func TestStats_NoiseStaysWithinBound(t *testing.T) {
+	const bound = 2
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Stats.Noise = true
+		cfg.Stats.NoiseBound = bound
+	})
+	const written = 6
+	for i := 0; i < written; i++ {
+		writeWithConflicts(t, s, "tenant", fmt.Sprintf("noisy memory number %d", i))
+	}
+
+	seen := make(map[float64]bool)
+	for i := 0; i < 200; i++ {
+		path := "/v1/stats"
+		if i%2 == 1 {
+			path = "/v1/brain/stats"
+		}
+		rr := doRequest(t, s, "GET", path, "", map[string]string{"X-Index-ID": "tenant"})
+		stats := decodeJSON(t, rr)
+		if index, ok := stats["index"].(map[string]any); ok {
+			stats = index
+		}
+		n := stats["neuron_count"].(float64)
+		if n < written-bound || n > written+bound {
+			t.Fatalf("neuron_count %v outside %d±%d", n, written, bound)
+		}
+		if _, ok := stats["synapse_weights"]; ok {
+			t.Fatal("synapse_weights reveals the exact synapse count")
+		}
+		seen[n] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expected noisy counts, always saw %v", seen)
+	}
+
+	rr := doRequest(t, s, "GET", "/admin/indexes/tenant", "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+	})
+	exact := decodeJSON(t, rr)["stats"].(map[string]any)
+	if exact["neuron_count"] != float64(written) {
+		t.Errorf("admins must see exact counts, got %v", exact["neuron_count"])
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/subscription"
+)
+
+// Metadata keys set on neurons written by subscriptions.
+const (
+	subscriptionKey          = "_subscription"
+	subscriptionActionKey    = "_subscription_action"
+	subscriptionResultIDsKey = "_result_ids"
+)
+
+// defaultSnapshotLimit is how many result IDs a search_snapshot keeps when
+// the subscription does not set a limit.
+const defaultSnapshotLimit = 10
+
+// newSubscriptions opens the subscription store and builds its scheduler.
+// Subscriptions stay disabled if the store cannot be loaded.
+func (s *Server) newSubscriptions(cfg core.SubscriptionsConfig) {
+	store, err := subscription.NewStore(s.config.Storage.DataPath, cfg.MaxPerIndex, cfg.HistorySize)
+	if err != nil {
+		log.Printf("⚠ Subscriptions disabled: %v", err)
+		return
+	}
+	s.subscriptions = store
+	s.scheduler = subscription.NewScheduler(store, s.runSubscription, cfg.TickInterval)
+}
+
+// StartSubscriptions starts the subscription scheduler when subscriptions
+// are enabled. Server.Stop stops it.
+func (s *Server) StartSubscriptions() {
+	if s.scheduler == nil {
+		return
+	}
+	s.scheduler.Start()
+	log.Printf("Subscription scheduler started (%d subscriptions, tick=%s)", s.subscriptions.Count(), s.config.Subscriptions.TickInterval)
+}
+
+// runSubscription executes sub against its own index and writes the result
+// as a neuron tagged with the subscription ID. Neurons written by earlier
+// runs of the same subscription are excluded from its results.
+func (s *Server) runSubscription(ctx context.Context, sub *subscription.Subscription) (subscription.Output, error) {
+	var out subscription.Output
+
+	indexID := core.IndexID(sub.IndexID)
+	worker, err := s.getWorker(indexID)
+	if err != nil {
+		return out, err
+	}
+	s.lifecycle.RecordActivity(indexID)
+
+	text := sub.Text()
+	depth := clampPositive(sub.Depth, defaultContextDepth, maxContextDepth)
+	limit := 50 // trimmed by tokens for digests
+	if sub.Action == subscription.ActionSearchSnapshot {
+		limit = clampPositive(sub.Limit, defaultSnapshotLimit, maxSearchLimit)
+	}
+
+	kind := searchKindSearch
+	if sub.Action == subscription.ActionContextDigest {
+		kind = searchKindContext
+	}
+	neurons, stats, err := s.runSearch(ctx, worker, indexID, kind, concurrency.SearchRequest{
+		Query: text,
+		Depth: depth,
+		// Fetch extra so excluding earlier output still fills the limit.
+		Limit: limit + len(sub.History),
+		Roles: s.resolveRoles(indexID, nil),
+		// Subscriptions are standing queries; a passing focus must not
+		// change what they deliver.
+		IgnoreFocus: true,
+	})
+	if err != nil {
+		return out, err
+	}
+	var hits []searchHit
+	for _, h := range tagHits(neurons, stats, indexID, false) {
+		if owner, _ := h.neuron.Metadata[subscriptionKey].(string); owner != sub.ID {
+			hits = append(hits, h)
+		}
+	}
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	metadata := make(map[string]string, len(sub.Metadata)+3)
+	for k, v := range sub.Metadata {
+		metadata[k] = v
+	}
+	metadata[subscriptionKey] = sub.ID
+	metadata[subscriptionActionKey] = sub.Action
+
+	stamp := time.Now().UTC().Format(time.RFC3339)
+	var content string
+	switch sub.Action {
+	case subscription.ActionContextDigest:
+		renderer, _ := s.turnRenderer("")
+		digest := assembleText(hits, clampPositive(sub.MaxTokens, defaultContextTokens, maxContextTokens), renderer)
+		out.Results = digest.included
+		content = fmt.Sprintf("Digest for %q at %s:\n%s", text, stamp, digest.text)
+	default:
+		ids := make([]string, len(hits))
+		for i, h := range hits {
+			ids[i] = string(h.neuron.ID)
+		}
+		out.Results = len(ids)
+		metadata[subscriptionResultIDsKey] = strings.Join(ids, ",")
+		content = fmt.Sprintf("Top %d results for %q at %s: %s", len(ids), text, stamp, strings.Join(ids, ", "))
+	}
+	if out.Results == 0 {
+		return out, nil
+	}
+
+	result, err := worker.SubmitContext(ctx, &concurrency.Operation{
+		Type: concurrency.OpWrite,
+		Payload: concurrency.AddNeuronRequest{
+			Content:  content,
+			Metadata: metadata,
+		},
+	})
+	if err != nil {
+		return out, err
+	}
+	out.NeuronID = string(result.(*core.Neuron).ID)
+	return out, nil
+}
+
+// handleSubscriptions routes /v1/subscriptions and /v1/subscriptions/{id}
+// for the index named by the request.
+func (s *Server) handleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	indexID := s.getIndexID(r)
+	if indexID == "" {
+		apierr.IndexIDRequired(w)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/subscriptions"), "/")
+
+	switch {
+	case id == "" && r.Method == "GET":
+		subs := s.subscriptions.List(string(indexID))
+		json.NewEncoder(w).Encode(map[string]any{
+			"subscriptions": subs,
+			"count":         len(subs),
+		})
+
+	case id == "" && r.Method == "POST":
+		var spec subscription.Spec
+		if !s.decodeJSONRequest(w, r, &spec) {
+			return
+		}
+		if _, err := s.getWorker(indexID); err != nil {
+			s.writeWorkerError(w, err)
+			return
+		}
+		sub, err := s.subscriptions.Create(string(indexID), spec)
+		if err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sub)
+
+	case id != "" && r.Method == "GET":
+		sub, err := s.subscriptions.Get(string(indexID), id)
+		if err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(subscriptionDocument(sub))
+
+	case id != "" && r.Method == "PUT":
+		var spec subscription.Spec
+		if !s.decodeJSONRequest(w, r, &spec) {
+			return
+		}
+		sub, err := s.subscriptions.Update(string(indexID), id, spec)
+		if err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(sub)
+
+	case id != "" && r.Method == "DELETE":
+		if err := s.subscriptions.Delete(string(indexID), id); err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"deleted": true, "id": id})
+
+	default:
+		apierr.MethodNotAllowed(w)
+	}
+}
+
+// subscriptionDocument adds lastRun to a subscription for GET by ID.
+func subscriptionDocument(sub *subscription.Subscription) map[string]any {
+	data, _ := json.Marshal(sub)
+	var doc map[string]any
+	json.Unmarshal(data, &doc)
+	doc["lastRun"] = sub.LastRun()
+	return doc
+}
+
+func writeSubscriptionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, subscription.ErrNotFound):
+		apierr.NotFound(w, apierr.CodeSubscriptionNotFound, err.Error())
+	case errors.Is(err, subscription.ErrLimitReached):
+		apierr.Conflict(w, apierr.CodeSubscriptionLimit, err.Error())
+	case errors.Is(err, subscription.ErrInvalid):
+		apierr.BadRequest(w, apierr.CodeInvalidSubscription, err.Error())
+	default:
+		apierr.Internal(w, err.Error())
+	}
+}
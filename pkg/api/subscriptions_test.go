@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func newSubscriptionTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Subscriptions.Enabled = true
+		cfg.Subscriptions.MaxPerIndex = 2
+		cfg.Subscriptions.TickInterval = 10 * time.Millisecond
+	})
+	s.StartSubscriptions()
+	t.Cleanup(func() { s.Stop(context.Background()) })
+	return s
+}
+
+func subscriptionRequest(t *testing.T, s *Server, method, path, body string, want int) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, method, path, body, map[string]string{
+		"X-Index-ID":   "project",
+		"Content-Type": "application/json",
+	})
+	if rr.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d: %s", method, path, want, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+// waitForRuns polls a subscription until it has at least n runs.
+func waitForRuns(t *testing.T, s *Server, id string, n int) map[string]any {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sub := subscriptionRequest(t, s, "GET", "/v1/subscriptions/"+id, "", http.StatusOK)
+		if len(sub["history"].([]any)) >= n {
+			return sub
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("subscription %s did not reach %d runs: %v", id, n, sub)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscription_WritesDigestNeuron(t *testing.T) {
+	s := newSubscriptionTestServer(t)
+	writeWithConflicts(t, s, "project", "Decision: we chose Postgres for the billing service")
+	writeWithConflicts(t, s, "project", "Decision: the billing service ships weekly")
+
+	sub := subscriptionRequest(t, s, "POST", "/v1/subscriptions",
+		`{"cue":"billing decision","schedule":"50ms","action":"context_digest","metadata":{"kind":"digest"}}`, http.StatusCreated)
+	id := sub["id"].(string)
+	if sub["indexId"] != "project" || sub["nextRunAt"] == nil {
+		t.Fatalf("unexpected subscription: %v", sub)
+	}
+
+	sub = waitForRuns(t, s, id, 1)
+	last := sub["lastRun"].(map[string]any)
+	if last["status"] != "ok" || last["results"] != float64(2) {
+		t.Fatalf("unexpected run: %v", last)
+	}
+	neuronID, _ := last["neuronId"].(string)
+	if neuronID == "" {
+		t.Fatalf("run should name the digest neuron: %v", last)
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/read/"+neuronID, "", map[string]string{"X-Index-ID": "project"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("digest neuron not readable: %d %s", rr.Code, rr.Body.String())
+	}
+	digest := decodeJSON(t, rr)
+	md := digest["metadata"].(map[string]any)
+	if md["_subscription"] != id || md["_subscription_action"] != "context_digest" || md["kind"] != "digest" {
+		t.Errorf("digest neuron not linked to its subscription: %v", md)
+	}
+	content := digest["content"].(string)
+	if !strings.Contains(content, "Postgres") || !strings.Contains(content, "ships weekly") {
+		t.Errorf("digest should contain the matching memories: %q", content)
+	}
+
+	// Later runs do not digest earlier digests.
+	sub = waitForRuns(t, s, id, 2)
+	if last := sub["lastRun"].(map[string]any); last["results"] != float64(2) {
+		t.Errorf("earlier digests should be excluded, got %v", last)
+	}
+}
+
+func TestSubscription_SearchSnapshotAndDelete(t *testing.T) {
+	s := newSubscriptionTestServer(t)
+	first := writeWithConflicts(t, s, "project", "Release checklist for the mobile app")
+
+	sub := subscriptionRequest(t, s, "POST", "/v1/subscriptions",
+		`{"query":"release checklist","schedule":"@every 50ms","action":"search_snapshot","limit":3}`, http.StatusCreated)
+	id := sub["id"].(string)
+
+	last := waitForRuns(t, s, id, 1)["lastRun"].(map[string]any)
+	rr := doRequest(t, s, "GET", "/v1/read/"+last["neuronId"].(string), "", map[string]string{"X-Index-ID": "project"})
+	snapshot := decodeJSON(t, rr)
+	if md := snapshot["metadata"].(map[string]any); md["_result_ids"] != first["id"] {
+		t.Errorf("snapshot should list the matching neuron, got %v", md)
+	}
+
+	list := subscriptionRequest(t, s, "GET", "/v1/subscriptions", "", http.StatusOK)
+	if list["count"] != float64(1) {
+		t.Errorf("expected one subscription, got %v", list)
+	}
+
+	subscriptionRequest(t, s, "DELETE", "/v1/subscriptions/"+id, "", http.StatusOK)
+	time.Sleep(50 * time.Millisecond) // let a run already in flight finish
+	count := neuronCount(mustWorker(t, s, "project"))
+	time.Sleep(200 * time.Millisecond)
+	if after := neuronCount(mustWorker(t, s, "project")); after != count {
+		t.Errorf("deleted subscription kept running: %d neurons, then %d", count, after)
+	}
+	subscriptionRequest(t, s, "GET", "/v1/subscriptions/"+id, "", http.StatusNotFound)
+}
+
+func TestSubscription_Validation(t *testing.T) {
+	s := newSubscriptionTestServer(t)
+
+	subscriptionRequest(t, s, "POST", "/v1/subscriptions", `{"query":"x","schedule":"whenever","action":"search_snapshot"}`, http.StatusBadRequest)
+	subscriptionRequest(t, s, "POST", "/v1/subscriptions", `{"query":"x","schedule":"1h","action":"summarize"}`, http.StatusBadRequest)
+
+	body := `{"query":"x","schedule":"0 9 * * 1","action":"search_snapshot"}`
+	sub := subscriptionRequest(t, s, "POST", "/v1/subscriptions", body, http.StatusCreated)
+	subscriptionRequest(t, s, "POST", "/v1/subscriptions", body, http.StatusCreated)
+	m := subscriptionRequest(t, s, "POST", "/v1/subscriptions", body, http.StatusConflict)
+	if m["code"] != "SUBSCRIPTION_LIMIT" {
+		t.Errorf("unexpected error: %v", m)
+	}
+
+	updated := subscriptionRequest(t, s, "PUT", "/v1/subscriptions/"+sub["id"].(string),
+		`{"cue":"weekly plan","schedule":"@daily","action":"context_digest"}`, http.StatusOK)
+	if updated["action"] != "context_digest" || updated["schedule"] != "@daily" {
+		t.Errorf("update not applied: %v", updated)
+	}
+}
+
+func mustWorker(t *testing.T, s *Server, indexID core.IndexID) *concurrency.BrainWorker {
+	t.Helper()
+	worker, err := s.pool.Get(indexID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return worker
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// handleSync serves the index change feed (GET /v1/sync?since=<cursor>).
+// Clients start from since=0, store the returned cursor, and page while
+// hasMore is set. resync means the cursor is no longer usable and the
+// client should discard its copy and start again from since=0.
+// activity=true also reports energy/depth changes.
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	var since uint64
+	if raw := q.Get("since"); raw != "" {
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			apierr.BadRequest(w, apierr.CodeBadRequest, "since must be a non-negative integer")
+			return
+		}
+		since = v
+	}
+	activity, _ := strconv.ParseBool(q.Get("activity"))
+	pageSize := s.config.Sync.PageSize
+	limit := clampPositive(parsePositiveQueryInt(q.Get("limit")), pageSize, pageSize)
+
+	worker, err := s.getWorker(s.getIndexID(r))
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type: concurrency.OpSync,
+		Payload: concurrency.SyncRequest{
+			Since:             since,
+			Limit:             limit,
+			IncludeActivity:   activity,
+			ActivityThreshold: s.config.Sync.ActivityThreshold,
+		},
+	})
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+
+	cs := result.(engine.ChangeSet)
+	items := make([]map[string]any, len(cs.Neurons))
+	for i, n := range cs.Neurons {
+		items[i] = s.neuronDocument(r.Context(), n)
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"neurons": items,
+		"removed": cs.Removed,
+		"cursor":  cs.Cursor,
+		"hasMore": cs.HasMore,
+		"resync":  cs.Resync,
+	})
+}
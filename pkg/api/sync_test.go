@@ -0,0 +1,133 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func syncPage(t *testing.T, s *Server, indexID, query string) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, "GET", "/v1/sync?"+query, "", map[string]string{"X-Index-ID": indexID})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("sync failed: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func syncContents(page map[string]any) []string {
+	var out []string
+	for _, n := range page["neurons"].([]any) {
+		out = append(out, n.(map[string]any)["content"].(string))
+	}
+	return out
+}
+
+// forgetMatching removes every neuron whose content contains substr.
+func forgetMatching(t *testing.T, s *Server, indexID, substr string) []string {
+	t.Helper()
+	worker, err := s.pool.GetOrCreate(core.IndexID(indexID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []core.NeuronID
+	m := worker.Matrix()
+	m.RLock()
+	for id, n := range m.Neurons {
+		if strings.Contains(n.Content, substr) {
+			ids = append(ids, id)
+		}
+	}
+	m.RUnlock()
+
+	var removed []string
+	for _, id := range ids {
+		if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpForget, Payload: id}); err != nil {
+			t.Fatalf("forget %s: %v", id, err)
+		}
+		removed = append(removed, string(id))
+	}
+	return removed
+}
+
+func TestSync_IncrementalAcrossWritesForgetAndRestart(t *testing.T) {
+	dataPath := t.TempDir()
+	start := func() *Server {
+		return newTestServer(t, func(cfg *core.Config) { cfg.Storage.DataPath = dataPath })
+	}
+
+	s := start()
+	writeNeuron(t, s, "app", "draft release notes")
+	writeNeuron(t, s, "app", "draft blog post")
+	writeNeuron(t, s, "app", "meeting with design team")
+
+	page := syncPage(t, s, "app", "since=0&limit=2")
+	first := syncContents(page)
+	if len(first) != 2 || page["hasMore"] != true {
+		t.Fatalf("expected a 2-item first page with more to come, got %v", page)
+	}
+	page = syncPage(t, s, "app", fmt.Sprintf("since=%v", page["cursor"]))
+	if rest := syncContents(page); len(rest) != 1 || page["hasMore"] != false {
+		t.Fatalf("expected the last neuron on the second page, got %v", page)
+	}
+	cursor := page["cursor"]
+
+	writeNeuron(t, s, "app", "ship the release")
+	removed := forgetMatching(t, s, "app", "draft")
+	if len(removed) != 2 {
+		t.Fatalf("expected 2 draft neurons removed, got %v", removed)
+	}
+
+	page = syncPage(t, s, "app", fmt.Sprintf("since=%v", cursor))
+	if got := syncContents(page); len(got) != 1 || got[0] != "ship the release" {
+		t.Fatalf("expected only the new neuron, got %v", got)
+	}
+	if got := page["removed"].([]any); len(got) != 2 || !containsString(removed, got[0].(string)) || !containsString(removed, got[1].(string)) {
+		t.Fatalf("expected removed %v, got %v", removed, got)
+	}
+	cursor = page["cursor"]
+
+	// Restart: the sequence and tombstones come back from disk.
+	if err := s.pool.Evict("app"); err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+	s = start()
+	page = syncPage(t, s, "app", fmt.Sprintf("since=%v", cursor))
+	if len(syncContents(page)) != 0 || len(page["removed"].([]any)) != 0 || page["cursor"] != cursor {
+		t.Fatalf("expected no changes after restart, got %v", page)
+	}
+	writeNeuron(t, s, "app", "post-restart note")
+	page = syncPage(t, s, "app", fmt.Sprintf("since=%v", cursor))
+	if got := syncContents(page); len(got) != 1 || got[0] != "post-restart note" || page["cursor"].(float64) <= cursor.(float64) {
+		t.Fatalf("expected the post-restart write after the old cursor, got %v", page)
+	}
+}
+
+func TestSync_SignalsResyncWhenHistoryExpires(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.pool.SetChangelogSize(1)
+
+	writeNeuron(t, s, "app", "first note")
+	writeNeuron(t, s, "app", "second note")
+	cursor := syncPage(t, s, "app", "since=0")["cursor"]
+
+	forgetMatching(t, s, "app", "first")
+	forgetMatching(t, s, "app", "second")
+
+	page := syncPage(t, s, "app", fmt.Sprintf("since=%v", cursor))
+	if page["resync"] != true {
+		t.Fatalf("expected resync once the removal history is exceeded, got %v", page)
+	}
+	if page = syncPage(t, s, "app", "since=0"); page["resync"] != false || len(syncContents(page)) != 0 {
+		t.Fatalf("a full resync should list the surviving neurons, got %v", page)
+	}
+
+	rr := doRequest(t, s, "GET", "/v1/sync?since=abc", "", map[string]string{"X-Index-ID": "app"})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed cursor, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/qubicDB/qubicdb/pkg/tracing"
+)
+
+// idRoutes are path prefixes followed by an ID segment. The segment is
+// replaced with {id} in span names to keep their cardinality low.
+var idRoutes = []string{"/v1/read/", "/v1/forget/", "/v1/fire/", "/v1/pin/", "/v1/recycle/", "/v1/history/", "/v1/brain/", "/v1/registry/", "/v1/shared/", "/admin/indexes/"}
+
+// spanRoute returns the route template for path, e.g. /v1/read/{id}.
+func spanRoute(path string) string {
+	if path == "/v1/registry/find-or-create" {
+		return path
+	}
+	for _, prefix := range idRoutes {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			return prefix + "{id}" + rest[i:]
+		}
+		return prefix + "{id}"
+	}
+	return path
+}
+
+// withTracing wraps every request in a server span. Handlers pass the
+// request context to worker operations so their spans nest under it.
+// Responses with a 5xx status mark the span as failed.
+func (s *Server) withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tracing.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, span := tracing.StartRequest(r, spanRoute(r.URL.Path))
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		var err error
+		if rec.status >= http.StatusInternalServerError {
+			err = fmt.Errorf("%d %s", rec.status, http.StatusText(rec.status))
+		}
+		tracing.End(span, err)
+	})
+}
+
+// statusRecorder records the response status. It forwards Flush so
+// streaming MCP responses keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
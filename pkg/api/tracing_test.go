@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/tracing"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentSpan  = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testParentSpan + "-01"
+)
+
+func newTracedTestServer(t *testing.T, indexAttribute string) (*Server, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracing.SetTracerProvider(tp)
+	tracing.SetIndexAttribute(indexAttribute)
+	t.Cleanup(func() {
+		tracing.SetTracerProvider(nil)
+		tracing.SetIndexAttribute(core.TraceIndexHash)
+		tp.Shutdown(context.Background())
+	})
+
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+	})
+	return s, exporter
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	t.Fatalf("span %q not recorded; got %v", name, names)
+	return tracetest.SpanStub{}
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracing_WriteSpanHierarchy(t *testing.T) {
+	s, exporter := newTracedTestServer(t, core.TraceIndexHash)
+
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"Traced memory about Go"}`, map[string]string{
+		"X-Index-ID":  "user-1",
+		"traceparent": testTraceparent,
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	spans := exporter.GetSpans()
+	root := findSpan(t, spans, "POST /v1/write")
+	if root.SpanContext.TraceID().String() != testTraceID || root.Parent.SpanID().String() != testParentSpan {
+		t.Errorf("request span should continue the incoming trace, got trace %s parent %s",
+			root.SpanContext.TraceID(), root.Parent.SpanID())
+	}
+
+	for _, name := range []string{"worker.queue", "worker.write"} {
+		span := findSpan(t, spans, name)
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("%s should be a child of the request span", name)
+		}
+		if op, _ := spanAttr(span, tracing.AttrOperation); op.AsString() != "write" {
+			t.Errorf("%s: expected operation=write, got %q", name, op.AsString())
+		}
+		if _, ok := spanAttr(span, tracing.AttrIndexHash); !ok {
+			t.Errorf("%s: expected a hashed index attribute", name)
+		}
+		if _, ok := spanAttr(span, tracing.AttrIndexID); ok {
+			t.Errorf("%s: raw index ID must not be recorded in hash mode", name)
+		}
+	}
+
+	// Persisting the brain records WAL and flush spans.
+	exporter.Reset()
+	if err := s.pool.Evict("user-1"); err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+	spans = exporter.GetSpans()
+	findSpan(t, spans, "persistence.wal_append")
+	findSpan(t, spans, "persistence.flush")
+}
+
+func TestTracing_SearchSpansAndErrors(t *testing.T) {
+	s, exporter := newTracedTestServer(t, core.TraceIndexRaw)
+	doRequest(t, s, "POST", "/v1/write", `{"content":"Kubernetes cluster on AWS"}`, map[string]string{"X-Index-ID": "user-1"})
+	exporter.Reset()
+
+	rr := doRequest(t, s, "POST", "/v1/search", `{"query":"Kubernetes"}`, map[string]string{"X-Index-ID": "user-1"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("search failed: %d %s", rr.Code, rr.Body.String())
+	}
+	spans := exporter.GetSpans()
+	root := findSpan(t, spans, "POST /v1/search")
+	if root.Parent.IsValid() {
+		t.Error("request without traceparent should start a new trace")
+	}
+	search := findSpan(t, spans, "worker.search")
+	if search.Parent.SpanID() != root.SpanContext.SpanID() {
+		t.Error("worker.search should be a child of the request span")
+	}
+	if id, _ := spanAttr(search, tracing.AttrIndexID); id.AsString() != "user-1" {
+		t.Errorf("expected raw index attribute user-1, got %q", id.AsString())
+	}
+
+	// A failed operation sets an error status on its span.
+	exporter.Reset()
+	doRequest(t, s, "GET", "/v1/read/missing", "", map[string]string{"X-Index-ID": "user-1"})
+	spans = exporter.GetSpans()
+	if span := findSpan(t, spans, "worker.read"); span.Status.Code != codes.Error {
+		t.Errorf("expected error status on failed read, got %v", span.Status)
+	}
+	findSpan(t, spans, "GET /v1/read/{id}")
+}
+
+func TestTracing_DisabledRecordsNothing(t *testing.T) {
+	s, exporter := newTracedTestServer(t, core.TraceIndexOmit)
+	tracing.SetTracerProvider(nil)
+
+	doRequest(t, s, "POST", "/v1/write", `{"content":"untraced"}`, map[string]string{"X-Index-ID": "user-1"})
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("expected no spans with tracing disabled, got %d", len(spans))
+	}
+}
+
+func TestSpanRoute(t *testing.T) {
+	cases := map[string]string{
+		"/v1/read/abc123":             "/v1/read/{id}",
+		"/v1/search":                  "/v1/search",
+		"/v1/graph/stats":             "/v1/graph/stats",
+		"/v1/registry/find-or-create": "/v1/registry/find-or-create",
+		"/v1/registry/some-uuid":      "/v1/registry/{id}",
+		"/admin/indexes/user-1/seed":  "/admin/indexes/{id}/seed",
+		"/admin/indexes":              "/admin/indexes",
+	}
+	for path, want := range cases {
+		if got := spanRoute(path); got != want {
+			t.Errorf("spanRoute(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
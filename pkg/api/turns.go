@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// turnBody is the structured turn variant of a write: only Text becomes
+// content, Role and Lang are kept as metadata.
+type turnBody struct {
+	Role string `json:"role"`
+	Lang string `json:"lang,omitempty"`
+	Text string `json:"text"`
+}
+
+// turnWrite folds a structured turn into the content and metadata of a
+// write. format, if set, must name a configured turn template; it is kept
+// as the turn's preferred rendering.
+func (s *Server) turnWrite(turn turnBody, format string, metadata map[string]string) (string, map[string]string, error) {
+	role := strings.ToLower(strings.TrimSpace(turn.Role))
+	if role == "" {
+		return "", nil, fmt.Errorf("turn.role is required")
+	}
+	if format != "" && !s.hasTurnTemplate(format) {
+		return "", nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	out := make(map[string]string, len(metadata)+3)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	if existing, ok := out[engine.RoleMetadataKey]; ok && !strings.EqualFold(existing, role) {
+		return "", nil, fmt.Errorf("metadata role %q contradicts turn.role %q", existing, role)
+	}
+	out[engine.RoleMetadataKey] = role
+	if lang := strings.ToLower(strings.TrimSpace(turn.Lang)); lang != "" {
+		out[engine.LangMetadataKey] = lang
+	}
+	if format != "" {
+		out[engine.FormatMetadataKey] = format
+	}
+	return turn.Text, out, nil
+}
+
+func (s *Server) hasTurnTemplate(name string) bool {
+	_, ok := s.config.Context.TurnTemplates[name]
+	return ok
+}
+
+// turnRenderer renders neurons into context text. Neurons with a role are
+// conversation turns and go through a turn template: the one the request
+// named, else the one the turn was written with. Turns neither names keep
+// the role tag annotation; neurons without a role are left as they are.
+type turnRenderer struct {
+	templates map[string]string
+	format    string
+}
+
+// turnRenderer returns the renderer for a request naming format, which may
+// be empty, and whether format is a configured template.
+func (s *Server) turnRenderer(format string) (turnRenderer, bool) {
+	r := turnRenderer{templates: s.config.Context.TurnTemplates, format: format}
+	return r, format == "" || s.hasTurnTemplate(format)
+}
+
+func (r turnRenderer) render(n *core.Neuron) string {
+	role := engine.NeuronRole(n)
+	if role == "" {
+		return n.Content
+	}
+	name := r.format
+	if name == "" {
+		name = engine.NeuronFormat(n)
+	}
+	if name == "" {
+		return n.Content + roleTag(n)
+	}
+	tmpl, ok := r.templates[name]
+	if !ok {
+		// The turn's template was removed from the config since.
+		tmpl, ok = r.templates[core.DefaultTurnTemplate]
+	}
+	if !ok {
+		return n.Content + roleTag(n)
+	}
+	return core.TurnTemplate(tmpl).Render(role, engine.NeuronLang(n), n.Content)
+}
+
+// handleAdminMigrateTurns rewrites an index's role-prefixed neurons into
+// structured turns using context.turnPattern
+// (POST /admin/indexes/{id}/migrate-turns). ?dry_run=true only reports.
+func (s *Server) handleAdminMigrateTurns(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	pattern, err := core.CompileTurnPattern(s.config.Context.TurnPattern)
+	if err != nil {
+		apierr.Internal(w, fmt.Sprintf("context.turnPattern: %v", err))
+		return
+	}
+
+	worker, err := s.getWorker(indexID)
+	if err != nil {
+		s.writeWorkerError(w, err)
+		return
+	}
+	result, err := worker.SubmitContext(r.Context(), &concurrency.Operation{
+		Type:    concurrency.OpMigrateTurns,
+		Payload: concurrency.MigrateTurnsRequest{Pattern: pattern, DryRun: dryRun},
+	})
+	if err != nil {
+		s.writeOperationError(w, err)
+		return
+	}
+
+	report := result.(engine.TurnMigration)
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId":   indexID,
+		"dryRun":    report.DryRun,
+		"scanned":   report.Scanned,
+		"matched":   report.Matched,
+		"migrated":  report.Migrated,
+		"conflicts": report.Conflicts,
+		"changes":   report.Changes,
+	})
+}
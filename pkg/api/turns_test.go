@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/testutil"
+)
+
+func newTurnTestServer(t *testing.T) *Server {
+	t.Helper()
+	return newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+		cfg.Context.TurnTemplates["chat"] = "[{{lang}}] {{role}}: {{text}}"
+	})
+}
+
+func writeTurn(t *testing.T, s *Server, indexID string, turn testutil.Turn, format string) map[string]any {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{
+		"turn":   map[string]string{"role": turn.Role, "lang": turn.Lang, "text": turn.Text},
+		"format": format,
+	})
+	rr := doRequest(t, s, "POST", "/v1/write", string(body), map[string]string{"X-Index-ID": indexID})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("turn write failed: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func contextText(t *testing.T, s *Server, indexID, body string) string {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/context", body, map[string]string{"X-Index-ID": indexID})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("context failed: %d %s", rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)["context"].(string)
+}
+
+func TestWrite_TurnStoresTextAndMetadata(t *testing.T) {
+	s := newTurnTestServer(t)
+
+	doc := writeTurn(t, s, "turns", testutil.Turn{Role: "User", Lang: "TR", Text: "Kadıköy'de yaşıyorum"}, "compact")
+	if doc["content"] != "Kadıköy'de yaşıyorum" {
+		t.Errorf("content should be the turn's text only, got %q", doc["content"])
+	}
+	meta := doc["metadata"].(map[string]any)
+	if meta["role"] != "user" || meta["_lang"] != "tr" || meta["_format"] != "compact" {
+		t.Errorf("role, lang and format should be stored as metadata, got %v", meta)
+	}
+
+	for name, body := range map[string]string{
+		"content and turn":    `{"content":"hi","turn":{"role":"user","text":"hi"}}`,
+		"missing role":        `{"turn":{"text":"hi"}}`,
+		"unknown format":      `{"turn":{"role":"user","text":"hi"},"format":"fancy"}`,
+		"format without turn": `{"content":"hi","format":"compact"}`,
+		"contradicting role":  `{"turn":{"role":"user","text":"hi"},"metadata":{"role":"assistant"}}`,
+	} {
+		rr := doRequest(t, s, "POST", "/v1/write", body, map[string]string{"X-Index-ID": "turns"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", name, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestContext_RendersTurnsWithTemplates(t *testing.T) {
+	s := newTurnTestServer(t)
+	writeTurn(t, s, "turns", testutil.MultilangConversation[12], "")
+	writeTurn(t, s, "turns", testutil.MultilangConversation[13], "chat")
+
+	cue := `"cue":"Kubernetes cluster on AWS"`
+	for format, want := range map[string][]string{
+		"default": {
+			"user: For the new project, I need to set up a Kubernetes cluster on AWS",
+			"assistant: I can help with Kubernetes on AWS. Do you prefer EKS or self-managed?",
+		},
+		"compact": {
+			"For the new project, I need to set up a Kubernetes cluster on AWS",
+			"I can help with Kubernetes on AWS. Do you prefer EKS or self-managed?",
+		},
+		"chat": {
+			"[en] user: For the new project, I need to set up a Kubernetes cluster on AWS",
+			"[en] assistant: I can help with Kubernetes on AWS. Do you prefer EKS or self-managed?",
+		},
+	} {
+		snippets := strings.Split(contextText(t, s, "turns", `{`+cue+`,"format":"`+format+`"}`), "\n---\n")
+		for _, w := range want {
+			found := false
+			for _, snippet := range snippets {
+				found = found || snippet == w
+			}
+			if !found {
+				t.Errorf("format %s: expected snippet %q in %q", format, w, snippets)
+			}
+		}
+	}
+
+	// Without a requested format each turn uses the template it was written
+	// with, or keeps the role annotation.
+	ctx := contextText(t, s, "turns", `{`+cue+`}`)
+	if !strings.Contains(ctx, "Kubernetes cluster on AWS [role:user]") ||
+		!strings.Contains(ctx, "[en] assistant: I can help with Kubernetes") {
+		t.Errorf("unexpected default rendering: %q", ctx)
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/context", `{`+cue+`,"format":"fancy"}`, map[string]string{"X-Index-ID": "turns"})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: expected 400, got %d", rr.Code)
+	}
+}
+
+func TestAdminMigrateTurns_MultilangConversation(t *testing.T) {
+	s := newTurnTestServer(t)
+	ids := make(map[core.NeuronID]testutil.Turn)
+	for _, turn := range testutil.MultilangConversation {
+		ids[writeTo(t, s, "legacy", turn.Prefixed())] = turn
+	}
+	untouched := writeTo(t, s, "legacy", "Note: deploys go out on Mondays")
+
+	dry := adminRequest(t, s, "POST", "/admin/indexes/legacy/migrate-turns?dry_run=true")
+	if dry["dryRun"] != true || dry["migrated"] != float64(len(ids)) || len(dry["changes"].([]any)) != len(ids) {
+		t.Fatalf("dry run should report every turn: %v", dry)
+	}
+	for id, turn := range ids {
+		if n := storedNeuron(t, s, "legacy", id); n.Content != turn.Prefixed() {
+			t.Fatalf("a dry run must not rewrite %s: %q", id, n.Content)
+		}
+	}
+
+	report := adminRequest(t, s, "POST", "/admin/indexes/legacy/migrate-turns")
+	if report["dryRun"] != false || report["migrated"] != float64(len(ids)) || report["scanned"] != float64(len(ids)+1) {
+		t.Fatalf("unexpected migration report: %v", report)
+	}
+	for id, turn := range ids {
+		n := storedNeuron(t, s, "legacy", id)
+		if n.Content != turn.Text || n.Metadata["role"] != turn.Role || n.Metadata["_lang"] != turn.Lang {
+			t.Errorf("%s not migrated: %q %v", id, n.Content, n.Metadata)
+		}
+	}
+	if n := storedNeuron(t, s, "legacy", untouched); n.Content != "Note: deploys go out on Mondays" {
+		t.Errorf("non-turn content should be left alone, got %q", n.Content)
+	}
+
+	// Migrated turns are searchable by their text and render like new ones.
+	ctx := contextText(t, s, "legacy", `{"cue":"Schnitzel Kartoffelsalat","format":"chat"}`)
+	if !strings.Contains(ctx, "[de] user: Mein Lieblingsessen ist Schnitzel mit Kartoffelsalat") {
+		t.Errorf("migrated turn not rendered: %q", ctx)
+	}
+
+	again := adminRequest(t, s, "POST", "/admin/indexes/legacy/migrate-turns")
+	if again["matched"] != float64(0) {
+		t.Errorf("a second migration should find nothing left, got %v", again)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// resolveVectorSettings applies the "vector" registry metadata of indexID
+// on top of the server defaults. An index that names a model nobody loaded
+// gets no vectorizer rather than the default one, so its embeddings are
+// never compared with another model's.
+func (s *Server) resolveVectorSettings(indexID core.IndexID, defaults concurrency.VectorSettings) concurrency.VectorSettings {
+	override := s.registry.VectorOverrides(string(indexID))
+	if override == nil {
+		return defaults
+	}
+
+	settings := defaults
+	if override.Alpha != nil {
+		settings.Alpha = *override.Alpha
+	}
+	if override.QueryRepeat != nil {
+		settings.QueryRepeat = *override.QueryRepeat
+	}
+	if override.Model != "" && override.Model != core.DefaultEmbeddingModel {
+		settings.Embedder = nil
+		settings.Model = override.Model
+		if models := s.pool.VectorModels(); models != nil && models.Has(override.Model) {
+			settings.Embedder = models.Handle(override.Model)
+		}
+	}
+	return settings
+}
+
+// checkVectorModel rejects registry metadata selecting a model that is not
+// configured under vector.models.
+func (s *Server) checkVectorModel(metadata map[string]any) error {
+	override, err := registry.VectorConfig(metadata)
+	if err != nil || override == nil || override.Model == "" || override.Model == core.DefaultEmbeddingModel {
+		return err
+	}
+	if models := s.pool.VectorModels(); models == nil || !models.Has(override.Model) {
+		return fmt.Errorf("%w: model %q is not configured", registry.ErrInvalidVectorOverride, override.Model)
+	}
+	return nil
+}
+
+// handleAdminModels lists the embedding models: the default one and every
+// named model with whether it is loaded and its estimated memory use.
+func (s *Server) handleAdminModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		apierr.MethodNotAllowed(w)
+		return
+	}
+
+	var totalMemory int64
+	items := []map[string]any{}
+
+	defaults := s.pool.VectorDefaults()
+	def := map[string]any{
+		"name":    core.DefaultEmbeddingModel,
+		"path":    s.config.Vector.ModelPath,
+		"default": true,
+		"loaded":  defaults.Embedder != nil,
+	}
+	if defaults.Embedder != nil {
+		memory := vector.EstimateMemory(defaults.Embedder, s.config.Vector.ModelPath)
+		def["dim"] = defaults.Embedder.EmbedDim()
+		def["memoryBytes"] = memory
+		totalMemory += memory
+	}
+	if s.vectorBreaker != nil {
+		def["breaker"] = s.vectorBreaker.Status()
+	}
+	items = append(items, def)
+
+	out := map[string]any{"maxLoaded": 0, "loads": 0, "evictions": 0}
+	if models := s.pool.VectorModels(); models != nil {
+		for _, m := range models.Models() {
+			item := map[string]any{
+				"name":      m.Name,
+				"path":      m.Path,
+				"gpuLayers": m.GPULayers,
+				"default":   false,
+				"loaded":    m.Loaded,
+			}
+			if m.Loaded {
+				item["dim"] = m.Dim
+				item["memoryBytes"] = m.MemoryBytes
+				item["inUse"] = m.InUse
+				item["loadedAt"] = m.LoadedAt
+				item["lastUsedAt"] = m.LastUsedAt
+				totalMemory += m.MemoryBytes
+			}
+			if breaker, ok := models.Breaker(m.Name); ok {
+				item["breaker"] = breaker
+			}
+			items = append(items, item)
+		}
+		loads, evictions := models.Stats()
+		out["maxLoaded"] = models.MaxLoaded()
+		out["loads"] = loads
+		out["evictions"] = evictions
+	}
+
+	out["models"] = items
+	out["memoryBytes"] = totalMemory
+	json.NewEncoder(w).Encode(out)
+}
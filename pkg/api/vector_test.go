@@ -0,0 +1,404 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// tokenEmbedder is a tiny deterministic embedder hashing tokens into dim
+// buckets. It fails once closed, so use after eviction shows up as errors.
+type tokenEmbedder struct {
+	dim int
+
+	mu     sync.Mutex
+	calls  int
+	closed bool
+}
+
+func (e *tokenEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return nil, errors.New("embed on closed model")
+	}
+	e.calls++
+	out := make([]float32, e.dim)
+	for _, tok := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(tok))
+		out[h.Sum32()%uint32(e.dim)]++
+	}
+	return out, nil
+}
+
+func (e *tokenEmbedder) EmbedDim() int { return e.dim }
+
+func (e *tokenEmbedder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	return nil
+}
+
+func (e *tokenEmbedder) MemoryEstimate() int64 { return int64(e.dim) << 20 }
+
+func (e *tokenEmbedder) callCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+// modelLoads records every embedder the fake loader hands out, by model.
+type modelLoads struct {
+	mu     sync.Mutex
+	byName map[string][]*tokenEmbedder
+}
+
+func (l *modelLoads) load(spec vector.ModelSpec) (vector.Embedder, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	dim := 16
+	if spec.Name == "code" {
+		dim = 32
+	}
+	e := &tokenEmbedder{dim: dim}
+	l.byName[spec.Name] = append(l.byName[spec.Name], e)
+	return e, nil
+}
+
+func (l *modelLoads) get(name string) []*tokenEmbedder {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.byName[name]
+}
+
+// newVectorTestServer returns a server with "code" and "chat" models, at
+// most maxLoaded resident, and indexes code-idx and chat-idx using them.
+func newVectorTestServer(t *testing.T, maxLoaded int) (*Server, *modelLoads) {
+	t.Helper()
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	loads := &modelLoads{byName: make(map[string][]*tokenEmbedder)}
+	specs := []vector.ModelSpec{{Name: "code", Path: "code.gguf"}, {Name: "chat", Path: "chat.gguf"}}
+	s.pool.SetVectorModels(vector.NewModelRegistry(specs, maxLoaded, loads.load))
+
+	for id, override := range map[string]map[string]any{
+		"code-idx": {"model": "code", "alpha": 0.9},
+		"chat-idx": {"model": "chat", "queryRepeat": float64(1)},
+	} {
+		if _, err := s.registry.Create(id, map[string]any{"vector": override}); err != nil {
+			t.Fatalf("registry.Create(%s): %v", id, err)
+		}
+	}
+	return s, loads
+}
+
+func writeTo(t *testing.T, s *Server, indexID, content string) core.NeuronID {
+	t.Helper()
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"`+content+`"}`, map[string]string{"X-Index-ID": indexID})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write to %s: %d %s", indexID, rr.Code, rr.Body.String())
+	}
+	return core.NeuronID(decodeJSON(t, rr)["id"].(string))
+}
+
+func storedNeuron(t *testing.T, s *Server, indexID string, id core.NeuronID) *core.Neuron {
+	t.Helper()
+	worker, err := s.pool.GetOrCreate(core.IndexID(indexID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, ok := worker.Matrix().Neurons[id]
+	if !ok {
+		t.Fatalf("neuron %s missing from %s", id, indexID)
+	}
+	return n
+}
+
+func storedMatrixModels(t *testing.T, s *Server, indexID string) []string {
+	t.Helper()
+	worker, err := s.pool.GetOrCreate(core.IndexID(indexID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var models []string
+	for _, n := range worker.Matrix().Neurons {
+		if len(n.Embedding) == 0 {
+			t.Errorf("neuron %s has no embedding", n.ID)
+		}
+		models = append(models, n.EmbeddingModel)
+	}
+	return models
+}
+
+func TestVectorOverrides_RoutePerIndex(t *testing.T) {
+	s, loads := newVectorTestServer(t, 2)
+
+	codeID := writeTo(t, s, "code-idx", "func parseConfig reads the yaml file")
+	chatID := writeTo(t, s, "chat-idx", "the user prefers short answers")
+
+	code := storedNeuron(t, s, "code-idx", codeID)
+	if code.EmbeddingModel != "code" || len(code.Embedding) != 32 {
+		t.Errorf("code-idx neuron: model %q, %d dims", code.EmbeddingModel, len(code.Embedding))
+	}
+	chat := storedNeuron(t, s, "chat-idx", chatID)
+	if chat.EmbeddingModel != "chat" || len(chat.Embedding) != 16 {
+		t.Errorf("chat-idx neuron: model %q, %d dims", chat.EmbeddingModel, len(chat.Embedding))
+	}
+
+	if got := s.pool.VectorSettings("code-idx"); got.Alpha != 0.9 || got.Model != "code" {
+		t.Errorf("code-idx settings: %+v", got)
+	}
+	defaults := s.pool.VectorDefaults()
+	if got := s.pool.VectorSettings("chat-idx"); got.Alpha != defaults.Alpha || got.QueryRepeat != 1 {
+		t.Errorf("chat-idx settings: %+v", got)
+	}
+	if got := s.pool.VectorSettings("plain"); got.Embedder != nil || got.Model != defaults.Model {
+		t.Errorf("an index without overrides should use the defaults, got %+v", got)
+	}
+
+	codeCalls, chatCalls := loads.get("code")[0].callCount(), loads.get("chat")[0].callCount()
+	if results := searchResults(t, s, "code-idx", "parseConfig")["results"].([]any); len(results) != 1 {
+		t.Fatalf("expected the code neuron, got %v", results)
+	}
+	if loads.get("code")[0].callCount() != codeCalls+1 || loads.get("chat")[0].callCount() != chatCalls {
+		t.Error("search on code-idx should embed the query with the code model only")
+	}
+}
+
+func TestVectorOverrides_EvictedModelReloadsOnDemand(t *testing.T) {
+	s, loads := newVectorTestServer(t, 1)
+
+	codeID := writeTo(t, s, "code-idx", "func parseConfig reads the yaml file")
+	before := append([]float32(nil), storedNeuron(t, s, "code-idx", codeID).Embedding...)
+
+	writeTo(t, s, "chat-idx", "the user prefers short answers")
+	if first := loads.get("code")[0]; !first.closed {
+		t.Fatal("loading chat with maxLoadedModels=1 should unload code")
+	}
+
+	results := searchResults(t, s, "code-idx", "parseConfig")["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("expected the code neuron after reload, got %v", results)
+	}
+	if n := len(loads.get("code")); n != 2 {
+		t.Fatalf("expected code to be loaded again, got %d loads", n)
+	}
+
+	writeTo(t, s, "code-idx", "func loadModel opens the gguf file")
+	after := storedNeuron(t, s, "code-idx", codeID)
+	if after.EmbeddingModel != "code" || len(after.Embedding) != len(before) {
+		t.Fatalf("stored embedding changed: model %q, %d dims", after.EmbeddingModel, len(after.Embedding))
+	}
+	for i := range before {
+		if after.Embedding[i] != before[i] {
+			t.Fatal("stored embedding changed across eviction")
+		}
+	}
+	for _, model := range storedMatrixModels(t, s, "code-idx") {
+		if model != "code" {
+			t.Errorf("code-idx neuron embedded by %q", model)
+		}
+	}
+}
+
+func TestVectorOverrides_RegistryValidation(t *testing.T) {
+	s, _ := newVectorTestServer(t, 1)
+
+	for name, body := range map[string]string{
+		"unknown model": `{"uuid":"x","metadata":{"vector":{"model":"nope"}}}`,
+		"alpha range":   `{"uuid":"x","metadata":{"vector":{"alpha":2}}}`,
+		"repeat range":  `{"uuid":"x","metadata":{"vector":{"queryRepeat":5}}}`,
+		"unknown field": `{"uuid":"x","metadata":{"vector":{"dims":8}}}`,
+		"not an object": `{"uuid":"x","metadata":{"vector":"code"}}`,
+	} {
+		rr := doRequest(t, s, "POST", "/v1/registry", body, nil)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doRequest(t, s, "POST", "/v1/registry", `{"uuid":"x","metadata":{"vector":{"model":"chat","alpha":0.5}}}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("valid override: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doRequest(t, s, "PUT", "/v1/registry/x", `{"metadata":{"vector":{"model":"nope"}}}`, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("update to unknown model: expected 400, got %d", rr.Code)
+	}
+}
+
+func TestAdminModels(t *testing.T) {
+	s, _ := newVectorTestServer(t, 1)
+	writeTo(t, s, "code-idx", "func parseConfig reads the yaml file")
+
+	m := adminRequest(t, s, "GET", "/admin/models")
+	if m["maxLoaded"] != float64(1) || m["loads"] != float64(1) {
+		t.Errorf("unexpected registry stats: %v", m)
+	}
+
+	byName := make(map[string]map[string]any)
+	for _, item := range m["models"].([]any) {
+		model := item.(map[string]any)
+		byName[model["name"].(string)] = model
+	}
+	if def := byName[core.DefaultEmbeddingModel]; def == nil || def["default"] != true || def["loaded"] != false {
+		t.Errorf("unexpected default model entry: %v", def)
+	}
+	if code := byName["code"]; code["loaded"] != true || code["dim"] != float64(32) || code["memoryBytes"] != float64(32<<20) {
+		t.Errorf("unexpected code model entry: %v", code)
+	}
+	if chat := byName["chat"]; chat["loaded"] != false {
+		t.Errorf("chat should not be loaded: %v", chat)
+	}
+	if m["memoryBytes"] != float64(32<<20) {
+		t.Errorf("expected total memory of the loaded model, got %v", m["memoryBytes"])
+	}
+}
+
+// failingEmbedder is a tokenEmbedder that errors while fail is set.
+type failingEmbedder struct {
+	tokenEmbedder
+	fail atomic.Bool
+}
+
+func (e *failingEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	if e.fail.Load() {
+		return nil, errors.New("llama backend wedged")
+	}
+	return e.tokenEmbedder.EmbedTextContext(ctx, text)
+}
+
+func TestHealth_VectorWarmupAndDegradation(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	e := &failingEmbedder{tokenEmbedder: tokenEmbedder{dim: 16}}
+	monitor := vector.NewHealthMonitor(e, time.Second)
+	s.SetVectorHealth(monitor)
+	s.pool.SetVectorizer(monitor.Embedder(), 0.6)
+
+	if rr := doRequest(t, s, "GET", "/health", "", nil); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("before warm-up the vector layer is not ready, got %d", rr.Code)
+	}
+
+	monitor.Warmup(context.Background())
+	rr := doRequest(t, s, "GET", "/health", "", nil)
+	m := decodeJSON(t, rr)
+	if rr.Code != http.StatusOK || m["status"] != "healthy" {
+		t.Fatalf("expected a healthy instance after warm-up, got %d %v", rr.Code, m)
+	}
+	report := adminRequest(t, s, "GET", "/admin/startup-report")
+	probe, _ := report["vector"].(map[string]any)
+	if probe == nil || probe["ok"] != true || probe["dim"] != float64(16) || probe["fingerprint"] == "" {
+		t.Errorf("the startup report should carry the warm-up probe, got %v", report["vector"])
+	}
+
+	e.fail.Store(true)
+	monitor.Start(5 * time.Millisecond)
+	defer monitor.Stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rr = doRequest(t, s, "GET", "/health", "", nil)
+		if m = decodeJSON(t, rr); m["status"] == "degraded" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("a failing liveness probe should degrade the instance, got %v", m)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rr.Code != http.StatusOK {
+		t.Errorf("a degraded instance stays in rotation, got %d", rr.Code)
+	}
+	writeTo(t, s, "notes", "the search still works lexically")
+	rr = doRequest(t, s, "GET", "/v1/search?q=lexically", "", map[string]string{"X-Index-ID": "notes"})
+	if got := resultContents(decodeJSON(t, rr)); len(got) != 1 {
+		t.Errorf("search should fall back to lexical matching, got %v", got)
+	}
+
+	failed := vector.NewHealthMonitor(e, time.Second)
+	failed.Warmup(context.Background())
+	s.SetVectorHealth(failed)
+	rr = doRequest(t, s, "GET", "/health", "", nil)
+	if m = decodeJSON(t, rr); rr.Code != http.StatusServiceUnavailable || m["status"] != "unavailable" {
+		t.Errorf("a failed warm-up should fail readiness, got %d %v", rr.Code, m)
+	}
+}
+
+func TestSearch_BreakerFallsBackToLexical(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = "admin"
+		cfg.Admin.Password = "secret"
+	})
+	e := &failingEmbedder{tokenEmbedder: tokenEmbedder{dim: 16}}
+	monitor := vector.NewHealthMonitor(e, time.Second)
+	monitor.Warmup(context.Background())
+	breaker := vector.NewResilientEmbedder(monitor.Embedder(), vector.ResiliencePolicy{Retries: 1, Threshold: 2, Cooldown: time.Hour})
+	s.SetVectorHealth(monitor)
+	s.SetVectorBreaker(breaker)
+	s.pool.SetVectorizer(breaker, 0.6)
+	headers := map[string]string{"X-Index-ID": "notes"}
+
+	writeTo(t, s, "notes", "the search still works lexically")
+	rr := doRequest(t, s, "GET", "/v1/search?q=lexically", "", headers)
+	if got := rr.Header().Get(searchModeHeader); got != searchModeHybrid {
+		t.Errorf("expected a hybrid search, got %q", got)
+	}
+
+	e.fail.Store(true)
+	id := writeTo(t, s, "notes", "written while the model is down")
+	if n := storedNeuron(t, s, "notes", id); !n.EmbedPending || len(n.Embedding) != 0 {
+		t.Errorf("the write should be stored and queued for embedding, pending=%v", n.EmbedPending)
+	}
+	rr = doRequest(t, s, "GET", "/v1/search?q=lexically", "", headers)
+	doc := decodeJSON(t, rr)
+	if got := resultContents(doc); len(got) == 0 || got[0] != "the search still works lexically" || rr.Header().Get(searchModeHeader) != searchModeLexicalFallback {
+		t.Errorf("expected a lexical fallback, got %v with mode %q", got, rr.Header().Get(searchModeHeader))
+	}
+	if warnings := responseWarnings(t, doc); len(warnings) != 1 || warnings[0]["code"] != apierr.WarnSearchModeDegraded {
+		t.Errorf("expected a SEARCH_MODE_DEGRADED warning, got %v", warnings)
+	}
+	rr = doRequest(t, s, "POST", "/v1/context", `{"cue":"lexically"}`, headers)
+	if got := rr.Header().Get(searchModeHeader); got != searchModeLexicalFallback {
+		t.Errorf("context should report the fallback too, got %q", got)
+	}
+
+	m := decodeJSON(t, doRequest(t, s, "GET", "/health", "", nil))
+	status := m["vector"].(map[string]any)["breaker"].(map[string]any)
+	if m["status"] != "degraded" || status["state"] != vector.BreakerOpen {
+		t.Errorf("an open breaker should degrade the instance, got %v", m)
+	}
+
+	stats := decodeJSON(t, doRequest(t, s, "GET", "/v1/stats", "", headers))
+	status = stats["vector"].(map[string]any)["breaker"].(map[string]any)
+	if status["opens"] != float64(1) || status["rejected"] != float64(1) || status["writeFallbacks"] != float64(1) ||
+		status["searchFallbacks"] != float64(2) || status["retries"] != float64(2) {
+		t.Errorf("unexpected breaker stats: %v", status)
+	}
+	if stats["index"].(map[string]any)["pending_embeddings"] != float64(1) {
+		t.Errorf("the index stats should count the queued write, got %v", stats["index"])
+	}
+
+	models := adminRequest(t, s, "GET", "/admin/models")["models"].([]any)
+	if models[0].(map[string]any)["breaker"] == nil {
+		t.Errorf("/admin/models should report the default model's breaker, got %v", models[0])
+	}
+}
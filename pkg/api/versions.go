@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// handleAdminVersions lists the persisted states of an index, newest first
+// (GET /admin/indexes/{id}/versions).
+func (s *Server) handleAdminVersions(w http.ResponseWriter, indexID core.IndexID) {
+	versions, err := s.pool.ListVersions(indexID)
+	if err != nil {
+		apierr.Internal(w, err.Error())
+		return
+	}
+	if versions == nil {
+		versions = []persistence.Version{}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId":  indexID,
+		"versions": versions,
+		"count":    len(versions),
+		"retain":   s.config.Storage.RetainVersions,
+	})
+}
+
+// handleAdminAsOf runs a one-shot read against an index as it was persisted
+// at a past time (GET /admin/indexes/{id}/as-of?time=<RFC3339>). With q it
+// searches, otherwise it recalls. The version is loaded into a detached
+// matrix; the live index is neither read nor woken.
+func (s *Server) handleAdminAsOf(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	raw := r.URL.Query().Get("time")
+	if raw == "" {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "time is required")
+		return
+	}
+	at, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "time must be RFC3339")
+		return
+	}
+
+	matrix, version, err := s.pool.LoadVersionAt(indexID, at)
+	switch {
+	case errors.Is(err, persistence.ErrVersionNotFound):
+		apierr.NotFound(w, apierr.CodeNotFound, "no version of the index at or before that time")
+		return
+	case err != nil:
+		apierr.Internal(w, err.Error())
+		return
+	}
+
+	depth, limit := defaultSearchDepth, defaultSearchLimit
+	if v := parsePositiveQueryInt(r.URL.Query().Get("depth")); v > 0 {
+		depth = v
+	}
+	if v := parsePositiveQueryInt(r.URL.Query().Get("limit")); v > 0 {
+		limit = v
+	}
+
+	eng := engine.NewMatrixEngine(matrix)
+	bm25 := s.config.Search.BM25
+	eng.SetBM25(bm25.K1, bm25.B, bm25.MaxTerms)
+	query := r.URL.Query().Get("q")
+	var neurons []*core.Neuron
+	if query != "" {
+		settings := s.pool.VectorSettings(indexID)
+		eng.SetVectorizer(settings.Embedder, settings.Model)
+		eng.SetAlpha(settings.Alpha)
+		eng.SetQueryRepeat(settings.QueryRepeat)
+		neurons, _ = eng.SearchWithStats(query, depth, limit, nil, false, nil)
+	} else {
+		neurons = eng.ListNeurons(0, limit, nil)
+	}
+
+	items := make([]map[string]any, len(neurons))
+	for i, n := range neurons {
+		items[i] = s.neuronDocument(r.Context(), n)
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexId": indexID,
+		"asOf":    at,
+		"version": version,
+		"query":   query,
+		"results": items,
+		"count":   len(items),
+	})
+}
+
+// handleAdminRestore replaces an index with one of its retained versions
+// (POST /admin/indexes/{id}/restore?version=<id>). ?force=true replaces an
+// index that holds data, and an append-only one only with
+// ?override_append_only=true as well. With the registry guard on, the
+// restored index is registered, so it is reachable again.
+func (s *Server) handleAdminRestore(w http.ResponseWriter, r *http.Request, indexID core.IndexID) {
+	id := r.URL.Query().Get("version")
+	if id == "" {
+		apierr.BadRequest(w, apierr.CodeBadRequest, "version is required")
+		return
+	}
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	if force && !s.allowDestructiveAdmin(w, r, indexID, "restore") {
+		return
+	}
+
+	restored, err := s.pool.RestoreVersion(indexID, id, force)
+	switch {
+	case errors.Is(err, persistence.ErrVersionNotFound):
+		apierr.NotFound(w, apierr.CodeNotFound, "version not found")
+		return
+	case errors.Is(err, core.ErrIndexNotEmpty):
+		apierr.Conflict(w, apierr.CodeConflict, "index already holds data; use ?force=true to replace it")
+		return
+	case err != nil:
+		s.writePersistError(w, err)
+		return
+	}
+	s.lifecycle.RemoveIndex(indexID)
+	if s.config.Registry.Enabled {
+		if _, _, err := s.registry.FindOrCreate(string(indexID), nil); err != nil {
+			apierr.Internal(w, err.Error())
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"restored": true,
+		"indexId":  indexID,
+		"version":  id,
+		"neurons":  len(restored.Neurons),
+		"synapses": len(restored.Synapses),
+	})
+}
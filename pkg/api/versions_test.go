@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/lifecycle"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/registry"
+)
+
+// newVersionsTestServer is newTestServer with a store that retains versions.
+func newVersionsTestServer(t *testing.T, retain int) *Server {
+	t.Helper()
+
+	cfg := core.DefaultConfig()
+	cfg.Storage.DataPath = t.TempDir()
+	cfg.Storage.RetainVersions = retain
+
+	durability := persistence.DefaultDurabilityConfig()
+	durability.RetainVersions = retain
+	store, err := persistence.NewStoreWithDurability(cfg.Storage.DataPath, cfg.Storage.Compress, durability)
+	if err != nil {
+		t.Fatalf("persistence.NewStoreWithDurability: %v", err)
+	}
+	pool := concurrency.NewWorkerPool(store, core.DefaultBounds())
+	reg, err := registry.NewStore(cfg.Storage.DataPath)
+	if err != nil {
+		t.Fatalf("registry.NewStore: %v", err)
+	}
+	return NewServer(cfg.Server.HTTPAddr, pool, lifecycle.NewManager(), reg, cfg)
+}
+
+func versionsRequest(t *testing.T, s *Server, method, path string, want int) map[string]any {
+	t.Helper()
+	rr := doRequest(t, s, method, path, "", map[string]string{
+		"Authorization": adminAuthHeader("admin", "qubicdb"),
+	})
+	if rr.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d: %s", method, path, want, rr.Code, rr.Body.String())
+	}
+	return decodeJSON(t, rr)
+}
+
+func resultContents(body map[string]any) []string {
+	var out []string
+	for _, item := range body["results"].([]any) {
+		out = append(out, item.(map[string]any)["content"].(string))
+	}
+	return out
+}
+
+func TestAdminVersions_AsOfReadsPastState(t *testing.T) {
+	s := newVersionsTestServer(t, 5)
+
+	writeNeuron(t, s, "notes", "The launch is scheduled for March")
+	if err := s.pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	between := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	writeNeuron(t, s, "notes", "The launch slipped to June")
+	if err := s.pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	list := versionsRequest(t, s, "GET", "/admin/indexes/notes/versions", http.StatusOK)
+	if list["count"] != float64(2) {
+		t.Fatalf("expected the current file and one retained version, got %v", list)
+	}
+
+	at := url.QueryEscape(between.Format(time.RFC3339Nano))
+	past := versionsRequest(t, s, "GET", "/admin/indexes/notes/as-of?time="+at, http.StatusOK)
+	if got := resultContents(past); len(got) != 1 || got[0] != "The launch is scheduled for March" {
+		t.Errorf("as-of recall should return the first state, got %v", got)
+	}
+	search := versionsRequest(t, s, "GET", "/admin/indexes/notes/as-of?q=launch&time="+at, http.StatusOK)
+	if got := resultContents(search); len(got) != 1 {
+		t.Errorf("as-of search should only see the first state, got %v", got)
+	}
+	now := url.QueryEscape(time.Now().Format(time.RFC3339Nano))
+	if got := resultContents(versionsRequest(t, s, "GET", "/admin/indexes/notes/as-of?time="+now, http.StatusOK)); len(got) != 2 {
+		t.Errorf("as-of now should return the current state, got %v", got)
+	}
+
+	// The live index is untouched by as-of reads.
+	if n := len(indexContents(t, s, "notes")); n != 2 {
+		t.Errorf("live index should keep 2 neurons, got %d", n)
+	}
+
+	early := url.QueryEscape(between.Add(-time.Hour).Format(time.RFC3339))
+	versionsRequest(t, s, "GET", "/admin/indexes/notes/as-of?time="+early, http.StatusNotFound)
+	versionsRequest(t, s, "GET", "/admin/indexes/notes/as-of?time=yesterday", http.StatusBadRequest)
+	versionsRequest(t, s, "GET", "/admin/indexes/notes/as-of", http.StatusBadRequest)
+}
+
+func TestAdminVersions_Restore(t *testing.T) {
+	s := newVersionsTestServer(t, 5)
+
+	writeNeuron(t, s, "notes", "Budget approved at 10k")
+	if err := s.pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	writeNeuron(t, s, "notes", "Budget cut to 5k")
+	if err := s.pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	list := versionsRequest(t, s, "GET", "/admin/indexes/notes/versions", http.StatusOK)
+	versions := list["versions"].([]any)
+	oldest := versions[len(versions)-1].(map[string]any)["id"].(string)
+
+	versionsRequest(t, s, "POST", "/admin/indexes/notes/restore?version="+oldest, http.StatusConflict)
+	versionsRequest(t, s, "POST", "/admin/indexes/notes/restore?version=20000101T000000.000000000Z&force=true", http.StatusNotFound)
+	versionsRequest(t, s, "POST", "/admin/indexes/notes/restore", http.StatusBadRequest)
+
+	body := versionsRequest(t, s, "POST", "/admin/indexes/notes/restore?version="+oldest+"&force=true", http.StatusOK)
+	if body["neurons"] != float64(1) {
+		t.Errorf("expected the restored index to hold 1 neuron, got %v", body)
+	}
+	if got := indexContents(t, s, "notes"); len(got) != 1 || got[0] != "Budget approved at 10k" {
+		t.Errorf("live index should hold the restored state, got %v", got)
+	}
+
+	// The replaced state is kept, so a restore can be undone.
+	list = versionsRequest(t, s, "GET", "/admin/indexes/notes/versions", http.StatusOK)
+	if list["count"] != float64(3) {
+		t.Errorf("expected the replaced state to be retained, got %v", list)
+	}
+
+	stats := versionsRequest(t, s, "GET", "/admin/stats", http.StatusOK)
+	if storage := stats["storage"].(map[string]any); storage["versions"] != float64(2) {
+		t.Errorf("stats should report retained versions, got %v", storage)
+	}
+}
+
+func TestAdminVersions_RestoreRegistersIndex(t *testing.T) {
+	s := newVersionsTestServer(t, 5)
+	writeNeuron(t, s, "notes", "Budget approved at 10k")
+	if err := s.pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+	list := versionsRequest(t, s, "GET", "/admin/indexes/notes/versions", http.StatusOK)
+	version := list["versions"].([]any)[0].(map[string]any)["id"].(string)
+
+	// Written before the guard was turned on, the index has no entry.
+	s.config.Registry.Enabled = true
+	if s.registry.Exists("notes") {
+		t.Fatal("expected notes to be unregistered")
+	}
+	versionsRequest(t, s, "POST", "/admin/indexes/notes/restore?version="+version+"&force=true", http.StatusOK)
+	if !s.registry.Exists("notes") {
+		t.Error("expected the restore to register notes")
+	}
+	if rr := doRequest(t, s, "GET", "/v1/recall", "", map[string]string{"X-Index-ID": "notes"}); rr.Code != http.StatusOK {
+		t.Errorf("expected the restored index to be reachable, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
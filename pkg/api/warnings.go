@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+)
+
+// warningWriter adds the warnings collected for a request to its response.
+// A 2xx JSON object body written while warnings are pending is held back
+// and sent by finish with a "warnings" array as its last member; any other
+// response passes straight through. Warnings raised after the body has
+// started are not sent.
+type warningWriter struct {
+	http.ResponseWriter
+	warnings *apierr.Warnings
+
+	decided bool // hold has run; holding is its answer
+	holding bool
+	status  int
+	body    bytes.Buffer
+}
+
+// hold decides, once, whether a response with status is held.
+func (ww *warningWriter) hold(status int) bool {
+	if !ww.decided {
+		ww.decided = true
+		ww.status = status
+		ww.holding = status >= 200 && status < 300 && ww.warnings.Len() > 0 &&
+			strings.HasPrefix(ww.Header().Get("Content-Type"), "application/json")
+	}
+	return ww.holding
+}
+
+func (ww *warningWriter) WriteHeader(status int) {
+	if !ww.hold(status) {
+		ww.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (ww *warningWriter) Write(p []byte) (int, error) {
+	if ww.hold(http.StatusOK) {
+		return ww.body.Write(p)
+	}
+	return ww.ResponseWriter.Write(p)
+}
+
+// Flush sends a held response as it is: a streaming handler gets no
+// warnings in its body.
+func (ww *warningWriter) Flush() {
+	if ww.holding {
+		ww.release(ww.body.Bytes())
+	}
+	if f, ok := ww.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (ww *warningWriter) Unwrap() http.ResponseWriter {
+	return ww.ResponseWriter
+}
+
+// finish sends a held response with its warnings added. A body that is not
+// a JSON object is sent unchanged.
+func (ww *warningWriter) finish() {
+	if !ww.holding {
+		return
+	}
+	body := bytes.TrimSpace(ww.body.Bytes())
+	list, err := json.Marshal(ww.warnings.List())
+	if err != nil || len(body) < 2 || body[0] != '{' || body[len(body)-1] != '}' {
+		ww.release(ww.body.Bytes())
+		return
+	}
+	var out bytes.Buffer
+	out.Write(body[:len(body)-1])
+	if len(bytes.TrimSpace(body[1:len(body)-1])) > 0 {
+		out.WriteByte(',')
+	}
+	out.WriteString(`"warnings":`)
+	out.Write(list)
+	out.WriteString("}\n")
+	ww.release(out.Bytes())
+}
+
+// release writes the held status and body and stops holding.
+func (ww *warningWriter) release(body []byte) {
+	ww.holding = false
+	ww.ResponseWriter.WriteHeader(ww.status)
+	ww.ResponseWriter.Write(body)
+	ww.body.Reset()
+}
+
+// warnSearchMode warns when a search fell back to lexical scoring.
+func warnSearchMode(ctx context.Context, mode string) {
+	if mode == searchModeLexicalFallback {
+		apierr.Warn(ctx, apierr.WarnSearchModeDegraded, "the query could not be embedded; results are ranked lexically only",
+			map[string]any{"searchMode": mode})
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/api/apierr"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// responseWarnings returns the warnings array of a success response,
+// checking each entry has the documented shape.
+func responseWarnings(t *testing.T, doc map[string]any) []map[string]any {
+	t.Helper()
+	raw, ok := doc["warnings"]
+	if !ok {
+		return nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		t.Fatalf("warnings should be an array, got %T", raw)
+	}
+	out := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		w, ok := item.(map[string]any)
+		if !ok {
+			t.Fatalf("a warning should be an object, got %T", item)
+		}
+		if _, ok := w["code"].(string); !ok {
+			t.Errorf("warning without a code: %v", w)
+		}
+		if _, ok := w["message"].(string); !ok {
+			t.Errorf("warning without a message: %v", w)
+		}
+		if details, ok := w["details"]; ok {
+			if _, ok := details.(map[string]any); !ok {
+				t.Errorf("warning details should be an object: %v", w)
+			}
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+func TestWarnings_ReservedMetadataDropped(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	headers := map[string]string{"X-Index-ID": "warn"}
+
+	rr := doRequest(t, s, "POST", "/v1/write", `{"content":"quarterly plan","metadata":{"team":"ops","_supersedes":"forged","_lang":"xx"}}`, headers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("write: %d %s", rr.Code, rr.Body.String())
+	}
+	doc := decodeJSON(t, rr)
+	warnings := responseWarnings(t, doc)
+	if len(warnings) != 1 || warnings[0]["code"] != apierr.WarnMetadataKeyReserved {
+		t.Fatalf("expected one METADATA_KEY_RESERVED warning, got %v", doc["warnings"])
+	}
+	if keys := warnings[0]["details"].(map[string]any)["keys"]; fmt.Sprint(keys) != "[_lang _supersedes]" {
+		t.Errorf("unexpected dropped keys %v", keys)
+	}
+	metadata := doc["metadata"].(map[string]any)
+	if metadata["team"] != "ops" || metadata["_supersedes"] != nil || metadata["_lang"] != nil {
+		t.Errorf("reserved keys should be dropped and the rest kept: %v", metadata)
+	}
+
+	// A clean request has no warnings member at all.
+	if doc := decodeJSON(t, doRequest(t, s, "POST", "/v1/write", `{"content":"no surprises"}`, headers)); doc["warnings"] != nil {
+		t.Errorf("a request without warnings should not carry the field: %v", doc)
+	}
+}
+
+func TestWarnings_ContextTruncated(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) { cfg.Registry.Enabled = false })
+	for i := 0; i < 3; i++ {
+		writeTo(t, s, "budget", fmt.Sprintf("budget review %d %s", i, strings.Repeat("detail ", 20)))
+	}
+	rr := doRequest(t, s, "POST", "/v1/context", `{"cue":"budget review","maxTokens":50}`, map[string]string{"X-Index-ID": "budget"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("context: %d %s", rr.Code, rr.Body.String())
+	}
+	warnings := responseWarnings(t, decodeJSON(t, rr))
+	if len(warnings) != 1 || warnings[0]["code"] != apierr.WarnResultsTruncated {
+		t.Fatalf("expected one RESULTS_TRUNCATED warning, got %v", warnings)
+	}
+	details := warnings[0]["details"].(map[string]any)
+	if details["matched"] != float64(3) || details["included"] != float64(1) || details["maxTokens"] != float64(50) {
+		t.Errorf("unexpected details %v", details)
+	}
+}
+
+func TestWarnings_IgnoredIndexIDSource(t *testing.T) {
+	s := newTestServer(t, func(cfg *core.Config) {
+		cfg.Registry.Enabled = false
+		cfg.Server.IndexIDSource = "header"
+	})
+	rr := writeWithIndexSources(t, s, "tenant-a", "tenant-b")
+	warnings := responseWarnings(t, decodeJSON(t, rr))
+	if len(warnings) != 1 || warnings[0]["code"] != apierr.WarnFieldsIgnored {
+		t.Fatalf("expected one FIELDS_IGNORED warning, got %v", warnings)
+	}
+	if fields := warnings[0]["details"].(map[string]any)["fields"]; fmt.Sprint(fields) != "[index_id]" {
+		t.Errorf("unexpected ignored fields %v", fields)
+	}
+	if rr.Header().Get(warningHeader) == "" {
+		t.Error("the warning header should still be set")
+	}
+}
+
+func TestWarningWriter(t *testing.T) {
+	serve := func(h http.HandlerFunc) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		rr.Header().Set("Content-Type", "application/json")
+		ctx, warnings := apierr.WithWarnings(context.Background())
+		ww := &warningWriter{ResponseWriter: rr, warnings: warnings}
+		h(ww, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+		ww.finish()
+		return rr
+	}
+
+	// Handlers may warn from several goroutines before responding.
+	rr := serve(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				apierr.Warn(r.Context(), apierr.WarnFieldsIgnored, "ignored", nil)
+			}()
+		}
+		wg.Wait()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":"n1"}`+"\n")
+	})
+	doc := decodeJSON(t, rr)
+	if rr.Code != http.StatusCreated || doc["id"] != "n1" || len(responseWarnings(t, doc)) != 20 {
+		t.Fatalf("unexpected response %d %v", rr.Code, doc)
+	}
+
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"empty object", http.StatusOK, `{}`, `{"warnings":[{"code":"FIELDS_IGNORED","message":"ignored"}]}` + "\n"},
+		{"error response", http.StatusBadRequest, `{"ok":false}`, `{"ok":false}`},
+		{"array body", http.StatusOK, `[1,2]`, `[1,2]`},
+	}
+	for _, tc := range cases {
+		rr := serve(func(w http.ResponseWriter, r *http.Request) {
+			apierr.Warn(r.Context(), apierr.WarnFieldsIgnored, "ignored", nil)
+			w.WriteHeader(tc.status)
+			fmt.Fprint(w, tc.body)
+		})
+		if rr.Code != tc.status || rr.Body.String() != tc.want {
+			t.Errorf("%s: got %d %q", tc.name, rr.Code, rr.Body.String())
+		}
+	}
+}
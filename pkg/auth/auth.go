@@ -0,0 +1,280 @@
+// Package auth verifies the credentials clients present to QubicDB: admin
+// Basic Auth, the admin login form and API keys sent as bearer tokens.
+//
+// Every comparison is made in constant time over fixed-size digests, so
+// neither the length of a secret nor the position of its first wrong byte
+// shows in response times. Callers resolve users to credentials with a
+// Store and map the errors below to their own responses.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrMissing means the request carries no credentials of the kind
+	// asked for.
+	ErrMissing = errors.New("auth: no credentials")
+	// ErrMalformed means an Authorization header was present but could
+	// not be parsed.
+	ErrMalformed = errors.New("auth: malformed Authorization header")
+	// ErrInvalid means the credentials do not match.
+	ErrInvalid = errors.New("auth: invalid credentials")
+)
+
+// LockedError is returned when a Throttle refuses an attempt without the
+// credentials being checked.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("auth: locked out; retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Credential is what a user must present. Secret holds a plaintext secret
+// (admin.password, mcp.apiKey); SecretHash a bcrypt hash (admin.users).
+// When both are set the hash wins.
+type Credential struct {
+	User       string
+	Secret     string
+	SecretHash string
+	// Role is carried for callers that grade users; auth ignores it.
+	Role string
+}
+
+// Plain returns a credential checked against a plaintext secret.
+func Plain(user, secret string) Credential {
+	return Credential{User: user, Secret: secret}
+}
+
+// Hashed returns a credential checked against a bcrypt hash.
+func Hashed(user, hash string) Credential {
+	return Credential{User: user, SecretHash: hash}
+}
+
+// usable reports whether c can match anything: a credential without a
+// secret never does.
+func (c Credential) usable() bool {
+	return c.Secret != "" || c.SecretHash != ""
+}
+
+// Store resolves a user name to its credential. Lookup may return a
+// credential for a different user name (a single configured admin, say);
+// the name is compared in constant time with the secret.
+type Store interface {
+	Lookup(user string) (Credential, bool)
+}
+
+// StoreFunc adapts a function to Store.
+type StoreFunc func(user string) (Credential, bool)
+
+func (f StoreFunc) Lookup(user string) (Credential, bool) { return f(user) }
+
+// Static is a Store over a fixed list; the first credential with a
+// matching user wins. A list of one matches any user name, which is then
+// compared when the secret is.
+type Static []Credential
+
+func (s Static) Lookup(user string) (Credential, bool) {
+	for _, c := range s {
+		if c.User == user {
+			return c, true
+		}
+	}
+	if len(s) == 1 {
+		return s[0], true
+	}
+	return Credential{}, false
+}
+
+// compare is subtle.ConstantTimeCompare; tests replace it to observe which
+// comparisons are made.
+var compare = subtle.ConstantTimeCompare
+
+// equal compares a and b in constant time. Both are hashed first, so
+// secrets of different lengths take as long as equal ones.
+func equal(a, b string) bool {
+	ah, bh := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return compare(ah[:], bh[:]) == 1
+}
+
+// Check reports whether user and secret match c. The user name and the
+// secret are always both compared, whichever differs.
+func Check(c Credential, user, secret string) bool {
+	if !c.usable() {
+		return false
+	}
+	userOK := equal(user, c.User)
+	var secretOK bool
+	if c.SecretHash != "" {
+		secretOK = bcryptCache.verify(user, secret, c.SecretHash)
+	} else {
+		secretOK = equal(secret, c.Secret)
+	}
+	return userOK && secretOK
+}
+
+// Throttle is consulted around each verification, for brute-force
+// protection. Begin may refuse the attempt with a *LockedError; Done is
+// called after every attempt Begin allowed, with its outcome.
+type Throttle interface {
+	Begin(r *http.Request, user string) error
+	Done(r *http.Request, user string, ok bool)
+}
+
+// Verifier checks request credentials against a Store, behind an optional
+// Throttle.
+type Verifier struct {
+	Store    Store
+	Throttle Throttle
+	// AlwaysBcrypt makes every attempt cost a bcrypt comparison: users the
+	// Store does not know, and credentials with a plaintext secret, are
+	// checked against dummyHash as well. Set it when some users have
+	// bcrypt hashes, so response times do not tell which names do.
+	AlwaysBcrypt bool
+}
+
+// dummyHash is a bcrypt hash, at bcrypt.DefaultCost, of a random secret
+// that was thrown away; nothing matches it.
+const dummyHash = "$2a$10$CKUP5VW6XwbUkHrXVSjzmOAk7WaUSrF53kf2vGYPoj7BOXhhDp5Gy"
+
+// bcryptCompare is bcrypt.CompareHashAndPassword; tests replace it to
+// observe which hashes are checked.
+var bcryptCompare = bcrypt.CompareHashAndPassword
+
+// Password verifies a user name and secret the request carried in some
+// other form, such as a login body.
+func (v Verifier) Password(r *http.Request, user, secret string) (Credential, error) {
+	if v.Throttle != nil {
+		if err := v.Throttle.Begin(r, user); err != nil {
+			return Credential{}, err
+		}
+	}
+	c, found := v.Store.Lookup(user)
+	ok := found && Check(c, user, secret)
+	if !found {
+		// Spend a comparison anyway, so unknown users are not told
+		// apart from wrong secrets by timing.
+		equal(secret, user)
+	}
+	if v.AlwaysBcrypt && (!found || c.SecretHash == "") {
+		bcryptCompare([]byte(dummyHash), []byte(secret))
+	}
+	if v.Throttle != nil {
+		v.Throttle.Done(r, user, ok)
+	}
+	if !ok {
+		return Credential{}, ErrInvalid
+	}
+	return c, nil
+}
+
+// Basic verifies the request's Basic Auth credentials.
+func (v Verifier) Basic(r *http.Request) (Credential, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return Credential{}, ErrMissing
+	}
+	user, secret, ok := r.BasicAuth()
+	if !ok {
+		return Credential{}, ErrMalformed
+	}
+	return v.Password(r, user, secret)
+}
+
+// Bearer verifies the request's bearer token as the secret of the empty
+// user name.
+func (v Verifier) Bearer(r *http.Request) (Credential, error) {
+	token, err := BearerToken(r)
+	if err != nil {
+		return Credential{}, err
+	}
+	return v.Password(r, "", token)
+}
+
+// VerifyBasicAuth checks the request's Basic Auth credentials against store.
+func VerifyBasicAuth(r *http.Request, store Store) (Credential, error) {
+	return Verifier{Store: store}.Basic(r)
+}
+
+// VerifyBearer checks the request's bearer token against store.
+func VerifyBearer(r *http.Request, store Store) (Credential, error) {
+	return Verifier{Store: store}.Bearer(r)
+}
+
+// BearerToken returns the token of an "Authorization: Bearer <token>"
+// header. The scheme is case-insensitive.
+func BearerToken(r *http.Request) (string, error) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if header == "" {
+		return "", ErrMissing
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return "", ErrMalformed
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrMalformed
+	}
+	return token, nil
+}
+
+// Challenge sets the WWW-Authenticate header asking for Basic Auth in
+// realm. Call it before writing a 401.
+func Challenge(w http.ResponseWriter, realm string) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+strings.ReplaceAll(realm, `"`, `'`)+`"`)
+}
+
+// BearerChallenge sets the WWW-Authenticate header asking for a bearer
+// token in realm.
+func BearerChallenge(w http.ResponseWriter, realm string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="`+strings.ReplaceAll(realm, `"`, `'`)+`"`)
+}
+
+// maxVerifiedCredentials bounds the bcrypt result cache.
+const maxVerifiedCredentials = 256
+
+// bcryptCache remembers successful bcrypt checks so admin UIs that send
+// Basic Auth on every request don't pay the bcrypt cost each time.
+var bcryptCache credentialCache
+
+// credentialCache is keyed by a digest of user, secret and hash, so a
+// changed hash never matches a stale entry.
+type credentialCache struct {
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]struct{}
+}
+
+func (c *credentialCache) verify(user, secret, hash string) bool {
+	key := sha256.Sum256([]byte(user + "\x00" + secret + "\x00" + hash))
+
+	c.mu.Lock()
+	_, ok := c.verified[key]
+	c.mu.Unlock()
+	if ok {
+		return true
+	}
+
+	if bcryptCompare([]byte(hash), []byte(secret)) != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	if c.verified == nil || len(c.verified) >= maxVerifiedCredentials {
+		c.verified = make(map[[sha256.Size]byte]struct{})
+	}
+	c.verified[key] = struct{}{}
+	c.mu.Unlock()
+	return true
+}
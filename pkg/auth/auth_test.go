@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// countCompares replaces compare for the test, recording the length of
+// every pair compared.
+func countCompares(t *testing.T) *[][2]int {
+	t.Helper()
+	var calls [][2]int
+	compare = func(a, b []byte) int {
+		calls = append(calls, [2]int{len(a), len(b)})
+		return 0
+	}
+	t.Cleanup(func() { compare = defaultCompare })
+	return &calls
+}
+
+var defaultCompare = compare
+
+func TestCheck_AlwaysComparesEverything(t *testing.T) {
+	c := Plain("admin", "correct horse battery staple")
+	for _, tc := range []struct {
+		name, user, secret string
+	}{
+		{"first byte of the user differs", "xdmin", "correct horse battery staple"},
+		{"last byte of the user differs", "admix", "correct horse battery staple"},
+		{"first byte of the secret differs", "admin", "Correct horse battery staple"},
+		{"last byte of the secret differs", "admin", "correct horse battery staplE"},
+		{"secret is a prefix", "admin", "correct"},
+		{"everything differs", "root", "hunter2"},
+		{"empty input", "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := countCompares(t)
+			if Check(c, tc.user, tc.secret) {
+				t.Fatal("mismatched credentials must not check out")
+			}
+			// Both the user name and the secret are compared, as
+			// fixed-size digests, however early the input differs.
+			if len(*calls) != 2 {
+				t.Fatalf("expected 2 comparisons, got %d", len(*calls))
+			}
+			for _, call := range *calls {
+				if call != [2]int{32, 32} {
+					t.Errorf("expected 32-byte digests, compared %v", call)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_Credentials(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name         string
+		cred         Credential
+		user, secret string
+		want         bool
+	}{
+		{"plaintext", Plain("admin", "qubicdb"), "admin", "qubicdb", true},
+		{"plaintext wrong secret", Plain("admin", "qubicdb"), "admin", "qubicdbx", false},
+		{"plaintext wrong user", Plain("admin", "qubicdb"), "Admin", "qubicdb", false},
+		{"bcrypt", Hashed("ops", string(hash)), "ops", "s3cret", true},
+		{"bcrypt twice, from the cache", Hashed("ops", string(hash)), "ops", "s3cret", true},
+		{"bcrypt wrong secret", Hashed("ops", string(hash)), "ops", "s3cret!", false},
+		{"bcrypt wrong user", Hashed("ops", string(hash)), "dev", "s3cret", false},
+		{"hash wins over plaintext", Credential{User: "ops", Secret: "s3cret!", SecretHash: string(hash)}, "ops", "s3cret!", false},
+		{"unicode user", Plain("管理者", "pässwörd"), "管理者", "pässwörd", true},
+		{"unicode user, other normalization", Plain("zo\u00eb", "x"), "zoe\u0308", "x", false},
+		{"empty secret never matches", Plain("admin", ""), "admin", "", false},
+		{"empty credential never matches", Credential{}, "", "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Check(tc.cred, tc.user, tc.secret); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func basic(user, secret string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+secret))
+}
+
+func TestVerifyBasicAuth(t *testing.T) {
+	store := Static{Plain("admin", "qubicdb"), Plain("zoë", "pässwörd")}
+	for _, tc := range []struct {
+		name   string
+		header string
+		want   error
+	}{
+		{"valid", basic("admin", "qubicdb"), nil},
+		{"unicode user", basic("zoë", "pässwörd"), nil},
+		{"colon in the secret", basic("admin", "qubic:db"), ErrInvalid},
+		{"no header", "", ErrMissing},
+		{"scheme only", "Basic", ErrMalformed},
+		{"not base64", "Basic !!!", ErrMalformed},
+		{"no colon", "Basic " + base64.StdEncoding.EncodeToString([]byte("adminqubicdb")), ErrMalformed},
+		{"bearer instead", "Bearer qubicdb", ErrMalformed},
+		{"empty credentials", basic("", ""), ErrInvalid},
+		{"empty secret", basic("admin", ""), ErrInvalid},
+		{"unknown user", basic("root", "qubicdb"), ErrInvalid},
+		{"wrong secret", basic("admin", "QUBICDB"), ErrInvalid},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/admin/stats", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+			_, err := VerifyBasicAuth(r, store)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyBearer(t *testing.T) {
+	store := Static{Plain("", "mcp-secret")}
+	for _, tc := range []struct {
+		name   string
+		header string
+		want   error
+	}{
+		{"valid", "Bearer mcp-secret", nil},
+		{"lowercase scheme", "bearer mcp-secret", nil},
+		{"surrounding space", "  Bearer   mcp-secret  ", nil},
+		{"no header", "", ErrMissing},
+		{"scheme only", "Bearer", ErrMalformed},
+		{"scheme and space", "Bearer ", ErrMalformed},
+		{"basic instead", basic("", "mcp-secret"), ErrMalformed},
+		{"wrong token", "Bearer mcp-secreT", ErrInvalid},
+		{"token prefix", "Bearer mcp", ErrInvalid},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/mcp", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+			_, err := VerifyBearer(r, store)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+type recordingThrottle struct {
+	locked  bool
+	begun   int
+	outcome []bool
+}
+
+func (t *recordingThrottle) Begin(*http.Request, string) error {
+	t.begun++
+	if t.locked {
+		return &LockedError{RetryAfter: 30 * time.Second}
+	}
+	return nil
+}
+
+func (t *recordingThrottle) Done(_ *http.Request, _ string, ok bool) {
+	t.outcome = append(t.outcome, ok)
+}
+
+func TestVerifier_Throttle(t *testing.T) {
+	throttle := &recordingThrottle{}
+	v := Verifier{Store: Static{Plain("admin", "qubicdb")}, Throttle: throttle}
+	r := httptest.NewRequest("POST", "/admin/login", nil)
+
+	if _, err := v.Password(r, "admin", "nope"); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	if _, err := v.Password(r, "admin", "qubicdb"); err != nil {
+		t.Fatal(err)
+	}
+	if throttle.begun != 2 || len(throttle.outcome) != 2 || throttle.outcome[0] || !throttle.outcome[1] {
+		t.Errorf("the throttle should see both attempts and their outcomes: %+v", throttle)
+	}
+
+	throttle.locked = true
+	calls := countCompares(t)
+	_, err := v.Password(r, "admin", "qubicdb")
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.RetryAfter != 30*time.Second {
+		t.Fatalf("expected a LockedError, got %v", err)
+	}
+	if len(*calls) != 0 || len(throttle.outcome) != 2 {
+		t.Error("a locked out attempt must not be checked or reported as done")
+	}
+}
+
+func TestVerifier_UnknownUserStillCompares(t *testing.T) {
+	calls := countCompares(t)
+	v := Verifier{Store: Static{Plain("admin", "qubicdb"), Plain("ops", "x")}}
+	if _, err := v.Password(nil, "root", "qubicdb"); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	if len(*calls) == 0 {
+		t.Error("an unknown user should cost a comparison like a wrong secret")
+	}
+}
+
+func TestVerifier_AlwaysBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []string
+	bcryptCompare = func(h, secret []byte) error {
+		hashes = append(hashes, string(h))
+		return bcrypt.CompareHashAndPassword(h, secret)
+	}
+	t.Cleanup(func() { bcryptCompare = bcrypt.CompareHashAndPassword })
+
+	store := Static{Hashed("ada", string(hash)), Plain("ops", "x")}
+	for _, tc := range []struct {
+		user, secret string
+		always       bool
+		want         string
+	}{
+		{"root", "s3cret", true, dummyHash},
+		{"ops", "y", true, dummyHash},
+		{"ada", "wrong", true, string(hash)},
+		{"root", "s3cret", false, ""},
+	} {
+		hashes = nil
+		v := Verifier{Store: store, AlwaysBcrypt: tc.always}
+		if _, err := v.Password(nil, tc.user, tc.secret); !errors.Is(err, ErrInvalid) {
+			t.Fatalf("%s: expected ErrInvalid, got %v", tc.user, err)
+		}
+		if tc.want == "" && len(hashes) != 0 || tc.want != "" && (len(hashes) != 1 || hashes[0] != tc.want) {
+			t.Errorf("%s (always=%v): expected a comparison against %q, got %q", tc.user, tc.always, tc.want, hashes)
+		}
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	w := httptest.NewRecorder()
+	Challenge(w, `qubicdb "admin"`)
+	if got := w.Header().Get("WWW-Authenticate"); got != `Basic realm="qubicdb 'admin'"` {
+		t.Errorf("unexpected challenge %q", got)
+	}
+	BearerChallenge(w, "qubicdb mcp")
+	if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="qubicdb mcp"` {
+		t.Errorf("unexpected challenge %q", got)
+	}
+}
+
+func FuzzCheck(f *testing.F) {
+	f.Add("admin", "qubicdb", "admin", "qubicdb")
+	f.Add("admin", "qubicdb", "admin", "qubicdc")
+	f.Add("zoë", "pässwörd", "zoe", "passwort")
+	f.Add("", "k", "", "")
+	f.Fuzz(func(t *testing.T, user, secret, gotUser, gotSecret string) {
+		calls := 0
+		compare = func(a, b []byte) int {
+			calls++
+			return defaultCompare(a, b)
+		}
+		defer func() { compare = defaultCompare }()
+
+		c := Plain(user, secret)
+		want := secret != "" && user == gotUser && secret == gotSecret
+		if got := Check(c, gotUser, gotSecret); got != want {
+			t.Errorf("Check(%q, %q against %q, %q) = %v, want %v", gotUser, gotSecret, user, secret, got, want)
+		}
+		if secret != "" && calls != 2 {
+			t.Errorf("expected both comparisons, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner_IssueAndVerify(t *testing.T) {
+	s, err := NewSigner("test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	token, issued, err := s.Issue("alice", "operator", now, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := s.Verify(token, now.Add(59*time.Minute))
+	if err != nil {
+		t.Fatalf("a fresh token should verify: %v", err)
+	}
+	if sess != issued || sess.User != "alice" || sess.Role != "operator" || !sess.Expires.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected session %+v", sess)
+	}
+
+	if _, err := s.Verify(token, now.Add(time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected ErrExpired at expiry, got %v", err)
+	}
+
+	other, _ := NewSigner("another-secret")
+	if _, err := other.Verify(token, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("a token from another key should not verify, got %v", err)
+	}
+}
+
+func TestSigner_RejectsTamperedTokens(t *testing.T) {
+	s, _ := NewSigner("test-secret")
+	now := time.Now()
+	token, _, _ := s.Issue("viewer", "viewer", now, time.Hour)
+	body, sig, _ := strings.Cut(token, ".")
+
+	payload, _ := base64.RawURLEncoding.DecodeString(body)
+	elevated := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), `"role":"viewer"`, `"role":"admin"`, 1)))
+	flipped := []byte(sig)
+	flipped[0] ^= 1
+
+	for name, tampered := range map[string]string{
+		"elevated role":     elevated + "." + sig,
+		"flipped signature": body + "." + string(flipped),
+		"signature dropped": body + ".",
+	} {
+		if _, err := s.Verify(tampered, now); !errors.Is(err, ErrInvalid) && !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: expected the token to be refused, got %v", name, err)
+		}
+	}
+	for _, malformed := range []string{"", "no-dot", body + ".!!"} {
+		if _, err := s.Verify(malformed, now); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%q: expected ErrMalformed, got %v", malformed, err)
+		}
+	}
+}
+
+func TestSigner_RandomKeyWhenNoSecret(t *testing.T) {
+	a, _ := NewSigner("")
+	b, _ := NewSigner("")
+	token, _, _ := a.Issue("admin", "admin", time.Now(), time.Hour)
+	if _, err := a.Verify(token, time.Now()); err != nil {
+		t.Fatalf("the issuing signer should verify its token: %v", err)
+	}
+	if _, err := b.Verify(token, time.Now()); err == nil {
+		t.Error("signers without a secret should not share a key")
+	}
+}
+
+func TestDenylist(t *testing.T) {
+	var d Denylist
+	now := time.Now()
+	short := Session{ID: "short", Expires: now.Add(time.Minute)}
+	long := Session{ID: "long", Expires: now.Add(time.Hour)}
+	d.Revoke(short)
+	d.Revoke(long)
+
+	if !d.Revoked(short, now) || !d.Revoked(long, now) {
+		t.Fatal("revoked sessions should be denied")
+	}
+	if d.Revoked(Session{ID: "other"}, now) {
+		t.Error("other sessions should not be denied")
+	}
+	d.Revoked(long, now.Add(2*time.Minute))
+	if d.Len() != 1 {
+		t.Errorf("expired entries should be dropped, %d left", d.Len())
+	}
+}
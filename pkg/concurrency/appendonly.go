@@ -0,0 +1,82 @@
+package concurrency
+
+import (
+	"fmt"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// AppendOnlyResolver reports whether indexID is append-only, e.g. from
+// its registry entry.
+type AppendOnlyResolver func(indexID core.IndexID) bool
+
+// IsDestructive reports whether operations of type t may remove
+// memories or rewrite their content, and so are refused on an append-only
+// index. Some are destructive only with certain payloads; see
+// Operation.destructive. Pruning is not listed: on an append-only index it
+// removes dead synapses but keeps every neuron.
+func (t OpType) IsDestructive() bool {
+	switch t {
+	case OpTouch, OpForget, OpPurge, OpCompactContent, OpMigrateTurns, OpImportNotes, OpRetention, OpForgetByMetadata:
+		return true
+	}
+	return false
+}
+
+// destructive reports whether op may remove memories or rewrite their
+// content: a destructive type other than a dry run, or a write that
+// supersedes a memory, alone or in a batch.
+func (op *Operation) destructive() bool {
+	switch req := op.Payload.(type) {
+	case AddNeuronRequest:
+		return req.Supersedes != nil
+	case WriteBatchRequest:
+		for _, item := range req.Items {
+			if item.Supersedes != nil {
+				return true
+			}
+		}
+		return false
+	case MigrateTurnsRequest:
+		return !req.DryRun
+	case RetentionRequest:
+		return !req.Options.DryRun
+	}
+	return op.Type.IsDestructive()
+}
+
+// SetAppendOnlyResolver installs r to decide which indexes are
+// append-only. nil makes none of them append-only.
+func (p *WorkerPool) SetAppendOnlyResolver(r AppendOnlyResolver) {
+	p.appendOnlyMu.Lock()
+	defer p.appendOnlyMu.Unlock()
+	p.appendOnlyResolver = r
+}
+
+// AppendOnly reports whether indexID is append-only.
+func (p *WorkerPool) AppendOnly(indexID core.IndexID) bool {
+	p.appendOnlyMu.RLock()
+	resolve := p.appendOnlyResolver
+	p.appendOnlyMu.RUnlock()
+	return resolve != nil && resolve(indexID)
+}
+
+// SetAppendOnlySource sets the function the worker asks whether its index
+// is append-only. Call before the worker serves operations.
+func (w *BrainWorker) SetAppendOnlySource(fn func() bool) {
+	w.appendOnlySource = fn
+}
+
+// AppendOnly reports whether the worker's index is append-only.
+func (w *BrainWorker) AppendOnly() bool {
+	return w.appendOnlySource != nil && w.appendOnlySource()
+}
+
+// refuseDestructive returns core.ErrAppendOnly when op would remove or
+// rewrite memories of an append-only index.
+func (w *BrainWorker) refuseDestructive(op *Operation) error {
+	if !op.destructive() || !w.AppendOnly() {
+		return nil
+	}
+	return fmt.Errorf("%w: %s refused on %s", core.ErrAppendOnly, op.Type, w.indexID)
+}
@@ -0,0 +1,73 @@
+package concurrency
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// Blobs returns the attachment blob store.
+func (p *WorkerPool) Blobs() *persistence.BlobStore {
+	return p.store.Blobs()
+}
+
+// AttachmentRefs counts the neurons referencing each attachment blob, in
+// every loaded or persisted index and in the retained versions an index
+// may be restored to. Loaded indexes are read in memory; the others are
+// read from disk without being loaded into the pool.
+func (p *WorkerPool) AttachmentRefs() (map[string]int, error) {
+	refs := make(map[string]int)
+	count := func(m *core.Matrix) {
+		for _, n := range m.Neurons {
+			for _, a := range n.Attachments {
+				refs[a.Hash]++
+			}
+		}
+		// Forgotten neurons keep theirs until they can no longer be restored.
+		for _, r := range m.Recycled {
+			for _, a := range r.Neuron.Attachments {
+				refs[a.Hash]++
+			}
+		}
+	}
+
+	loaded := make(map[core.IndexID]bool)
+	p.ForEach(func(id core.IndexID, w *BrainWorker) {
+		loaded[id] = true
+		m := w.Matrix()
+		m.RLock()
+		count(m)
+		m.RUnlock()
+	})
+
+	var errs []error
+	for _, id := range p.store.ListIndexes() {
+		if !loaded[id] {
+			m, err := p.store.Load(id)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", id, err))
+				continue
+			}
+			count(m)
+		}
+		versions, err := p.store.ListVersions(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		for _, v := range versions {
+			if v.ID == persistence.CurrentVersion {
+				continue
+			}
+			m, err := p.store.LoadVersion(id, v.ID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s version %s: %w", id, v.ID, err))
+				continue
+			}
+			count(m)
+		}
+	}
+	return refs, errors.Join(errs...)
+}
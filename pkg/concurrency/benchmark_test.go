@@ -2,7 +2,9 @@ package concurrency
 
 import (
 	"fmt"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
 )
@@ -86,3 +88,64 @@ func BenchmarkBrainWorkerParallel(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkBrainWorkerSearchUnderBulkWrites measures search latency while
+// the write queue is kept deep. "strong" searches wait in the write queue,
+// as every search did before reads got their own path.
+func BenchmarkBrainWorkerSearchUnderBulkWrites(b *testing.B) {
+	for _, strong := range []bool{false, true} {
+		name := "eventual"
+		if strong {
+			name = "strong"
+		}
+		b.Run(name, func(b *testing.B) {
+			m := core.NewMatrix("bench-user", core.DefaultBounds())
+			w := NewBrainWorker("bench-user", m)
+			defer w.Stop()
+
+			for i := 0; i < 500; i++ {
+				w.Submit(&Operation{
+					Type:    OpWrite,
+					Payload: AddNeuronRequest{Content: fmt.Sprintf("Content about TypeScript programming %d", i)},
+				})
+			}
+
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if len(w.interactive) > 500 {
+						time.Sleep(time.Millisecond)
+						continue
+					}
+					w.SubmitAsync(&Operation{
+						Type:    OpWrite,
+						Payload: AddNeuronRequest{Content: fmt.Sprintf("Bulk import record %d", i)},
+					})
+				}
+			}()
+
+			latencies := make([]time.Duration, 0, b.N)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				begin := time.Now()
+				w.Submit(&Operation{
+					Type:    OpSearch,
+					Payload: SearchRequest{Query: "TypeScript", Depth: 1, Limit: 10},
+					Strong:  strong,
+				})
+				latencies = append(latencies, time.Since(begin))
+			}
+			b.StopTimer()
+
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			p95 := latencies[len(latencies)*95/100]
+			b.ReportMetric(float64(p95.Microseconds()), "p95-µs")
+		})
+	}
+}
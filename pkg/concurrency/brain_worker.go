@@ -2,14 +2,20 @@ package concurrency
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
 	"github.com/qubicDB/qubicdb/pkg/engine"
-	"github.com/qubicDB/qubicdb/pkg/sentiment"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
 	"github.com/qubicDB/qubicdb/pkg/synapse"
+	"github.com/qubicDB/qubicdb/pkg/tracing"
 	"github.com/qubicDB/qubicdb/pkg/vector"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Operation types for the worker
@@ -17,27 +23,167 @@ type OpType int
 
 const (
 	// Brain-like naming (primary)
-	OpWrite       OpType = iota // Add/create neuron (memory formation)
-	OpRead                      // Get neuron (memory retrieval)
-	OpSearch                    // Search neurons (associative recall)
-	OpTouch                     // Update neuron (memory modification)
-	OpForget                    // Delete neuron (memory erasure)
-	OpRecall                    // List neurons (memory scanning)
-	OpFire                      // Activate neuron (neural firing)
-	OpDecay                     // Energy decay (forgetting curve)
-	OpConsolidate               // Memory consolidation (depth increase)
-	OpPrune                     // Remove dead neurons (synaptic pruning)
-	OpReorg                     // Reorganize matrix (neural plasticity)
-	OpGetStats                  // Get statistics
-	OpShutdown                  // Shutdown worker
+	OpWrite            OpType = iota // Add/create neuron (memory formation)
+	OpRead                           // Get neuron (memory retrieval)
+	OpSearch                         // Search neurons (associative recall)
+	OpTouch                          // Update neuron (memory modification)
+	OpForget                         // Forget neuron into the recycle bin (memory erasure)
+	OpRecall                         // List neurons (memory scanning)
+	OpFire                           // Activate neuron (neural firing)
+	OpDecay                          // Energy decay (forgetting curve)
+	OpConsolidate                    // Memory consolidation (depth increase)
+	OpPrune                          // Remove dead neurons (synaptic pruning)
+	OpReorg                          // Reorganize matrix (neural plasticity)
+	OpGetStats                       // Get statistics
+	OpShutdown                       // Shutdown worker
+	OpGraphStats                     // Synapse graph health statistics
+	OpSync                           // Change feed page for delta sync
+	OpDetectConflicts                // Flag neurons a new neuron may contradict
+	OpListConflicts                  // List flagged conflict groups
+	OpPin                            // Pin or unpin a neuron
+	OpListPins                       // List pinned neurons
+	OpActivate                       // Fire neurons returned by a concurrent read
+	OpPrunePlan                      // Report which synapses a prune would remove
+	OpHistory                        // Walk a neuron's supersede chain
+	OpChainIssues                    // Report broken supersede chains
+	OpEmbedPending                   // Embed neurons written while the vector layer failed
+	OpGraphSummary                   // Grid overview of neurons and bundled synapses
+	OpMigrateTurns                   // Rewrite role-prefixed content into structured turns
+	OpImportNotes                    // Create or update neurons from imported notes
+	OpRetention                      // Enforce (or dry-run) a retention policy
+	OpExportSlice                    // Copy out the neurons matching a filter
+	OpImportSlice                    // Add an exported slice of neurons and synapses
+	OpCompactContent                 // Truncate the content of old, faded memories
+	OpGetGraph                       // Copy out every neuron and synapse for visualization
+	OpGetSynapses                    // Copy out every synapse with its retention score
+	OpGetActivity                    // Recent neuron and synapse events
+	OpSample                         // Weighted random draw of neurons
+	OpListPromotions                 // List recent consolidation promotions
+	OpListRecycled                   // List forgotten neurons that can be restored
+	OpRestore                        // Restore a forgotten neuron and its synapses
+	OpPurge                          // Remove a neuron for good, bypassing the recycle bin
+	OpExpandQuery                    // Find the terms the index associates with a query
+	OpWriteBatch                     // Add many neurons in one operation
+	OpHealth                         // Memory health score, cached between computations
+	OpForgetByMetadata               // Remove every neuron matching a metadata filter for good
+	OpCalibrate                      // Measure similarity distributions and derive thresholds
+	OpSnapshot                       // Copy out the whole matrix, in step with writes
 )
 
+// opNames are the span and log names of each OpType.
+var opNames = [...]string{
+	OpWrite:            "write",
+	OpRead:             "read",
+	OpSearch:           "search",
+	OpTouch:            "touch",
+	OpForget:           "forget",
+	OpRecall:           "recall",
+	OpFire:             "fire",
+	OpDecay:            "decay",
+	OpConsolidate:      "consolidate",
+	OpPrune:            "prune",
+	OpReorg:            "reorg",
+	OpGetStats:         "stats",
+	OpShutdown:         "shutdown",
+	OpGraphStats:       "graph_stats",
+	OpSync:             "sync",
+	OpDetectConflicts:  "detect_conflicts",
+	OpListConflicts:    "list_conflicts",
+	OpPin:              "pin",
+	OpListPins:         "list_pins",
+	OpActivate:         "activate",
+	OpPrunePlan:        "prune_plan",
+	OpHistory:          "history",
+	OpChainIssues:      "chain_issues",
+	OpEmbedPending:     "embed_pending",
+	OpGraphSummary:     "graph_summary",
+	OpMigrateTurns:     "migrate_turns",
+	OpImportNotes:      "import_notes",
+	OpRetention:        "retention",
+	OpExportSlice:      "export_slice",
+	OpImportSlice:      "import_slice",
+	OpCompactContent:   "compact_content",
+	OpGetGraph:         "graph",
+	OpGetSynapses:      "synapses",
+	OpGetActivity:      "activity",
+	OpSample:           "sample",
+	OpListPromotions:   "list_promotions",
+	OpListRecycled:     "list_recycled",
+	OpRestore:          "restore",
+	OpPurge:            "purge",
+	OpExpandQuery:      "expand_query",
+	OpWriteBatch:       "write_batch",
+	OpHealth:           "health",
+	OpForgetByMetadata: "forget_by_metadata",
+	OpCalibrate:        "calibrate",
+	OpSnapshot:         "snapshot",
+}
+
+// String returns the operation's short name, e.g. "search".
+func (t OpType) String() string {
+	if t >= 0 && int(t) < len(opNames) {
+		return opNames[t]
+	}
+	return fmt.Sprintf("op(%d)", int(t))
+}
+
+// Priority selects the queue an operation waits in.
+type Priority int
+
+const (
+	// PriorityInteractive is for user-facing requests (HTTP, MCP) and is the
+	// zero value, so operations are interactive unless marked otherwise.
+	PriorityInteractive Priority = iota
+	// PriorityBackground is for daemon maintenance: decay, consolidation,
+	// pruning and reorganisation.
+	PriorityBackground
+)
+
+// DefaultBackgroundSlice is how long a background operation may run before
+// it pauses to serve queued interactive operations.
+const DefaultBackgroundSlice = 10 * time.Millisecond
+
+// DefaultReadConcurrency is how many read-only operations a worker runs at
+// once.
+const DefaultReadConcurrency = 4
+
+// IsRead reports whether operations of type t leave the index unchanged
+// apart from activation, and so may run concurrently with each other.
+func (t OpType) IsRead() bool {
+	switch t {
+	case OpRead, OpSearch, OpRecall, OpGetStats, OpGraphStats, OpListConflicts, OpListPins, OpPrunePlan, OpHistory, OpChainIssues, OpGraphSummary, OpExportSlice,
+		OpGetGraph, OpGetSynapses, OpGetActivity, OpSample, OpListPromotions, OpListRecycled, OpExpandQuery, OpHealth, OpCalibrate, OpSnapshot:
+		return true
+	}
+	return false
+}
+
+// countsAsWrite reports whether operations of type t change the index on
+// a client's behalf, as counted by BrainWorker.Writes.
+func (t OpType) countsAsWrite() bool {
+	return !t.IsRead() && t != OpActivate && t != OpShutdown
+}
+
 // Operation represents a queued operation
 type Operation struct {
-	Type    OpType
-	Payload any
-	Result  chan any
-	Error   chan error
+	Type     OpType
+	Payload  any
+	Priority Priority
+	Result   chan any
+	Error    chan error
+
+	// Strong runs a read operation on the write queue, so it observes every
+	// operation submitted before it. By default reads run concurrently on
+	// the read queue and may miss writes that are still queued.
+	Strong bool
+
+	// ctx carries the trace of the request that submitted the operation;
+	// nil for untraced operations such as daemon work.
+	ctx       context.Context
+	queueSpan trace.Span
+
+	// concurrent is set while the operation runs on the read path.
+	concurrent bool
 }
 
 // BrainWorker is a dedicated goroutine per user brain
@@ -47,8 +193,89 @@ type BrainWorker struct {
 	engine  *engine.MatrixEngine
 	hebbian *synapse.HebbianEngine
 
-	// Operation queue
-	ops chan *Operation
+	// Operation queues. Interactive operations are always taken first, and
+	// long background operations yield to them every backgroundSlice.
+	interactive chan *Operation
+	background  chan *Operation
+
+	// Read path. Read operations wait in reads and run on up to
+	// readConcurrency goroutines, started with the first read. They hold
+	// rw for reading; every other operation holds it for writing, so reads
+	// run alongside each other but never alongside a mutation.
+	reads           chan *Operation
+	readConcurrency int
+	readersOnce     sync.Once
+	readsActive     atomic.Int64
+	rw              sync.RWMutex
+
+	// Background yielding state. Apart from backgroundSlice, which may be
+	// changed at runtime, only the worker goroutine touches these.
+	backgroundSlice atomic.Int64 // time.Duration
+	inBackground    bool
+	sliceStart      time.Time
+	itemHook        func() // called per background item; tests only
+
+	// vectorSource returns the index's effective vector settings; it is
+	// consulted before every write and search. nil disables the vector layer.
+	vectorSource func() VectorSettings
+
+	// metadataKeysSource returns the metadata keys the index indexes; it
+	// is consulted before every search and graph stats. nil keeps the
+	// engine's defaults.
+	metadataKeysSource func() []string
+
+	// sentimentSource returns the index's sentiment analyzer; it is
+	// consulted before every write and search. nil disables the sentiment
+	// layer.
+	sentimentSource func() SentimentSettings
+
+	// quotaSource returns the index's disk quota and usage; it is
+	// consulted before every write. nil disables the quota.
+	quotaSource func() QuotaSettings
+
+	// appendOnlySource reports whether the index is append-only; it is
+	// consulted before every destructive operation and prune. nil means
+	// it is not.
+	appendOnlySource func() bool
+
+	// similaritySource returns the index's similarity thresholds; co-fires
+	// consult it for the floor of new synapses. nil leaves them to
+	// reinforce.SimilarityFloor.
+	similaritySource func() engine.SimilarityThresholds
+
+	// Pin policy: how many neurons may be pinned and the energy decay
+	// leaves them at.
+	maxPinned int
+	pinFloor  float64
+
+	// promotionHistory is how many promotion records each neuron keeps.
+	promotionHistory int
+
+	// reinforce bounds group co-fires of reinforcing searches.
+	reinforce core.ReinforceConfig
+
+	// journal, when set, records every operation for debugging.
+	journal atomic.Pointer[Journal]
+
+	// deadLetters, when set, records operations that panic and quarantines
+	// the index when they keep doing so.
+	deadLetters atomic.Pointer[DeadLetters]
+
+	// health is the last health score computed; see CachedHealth.
+	health atomic.Pointer[engine.Health]
+
+	// focus is the index's focus, if one was set; see Focus.
+	focus atomic.Pointer[Focus]
+
+	// coalesce shares the execution of identical searches in flight.
+	coalesce coalescer
+
+	// mutations, when set, is told about committed writes and forgets.
+	mutations func(Mutation)
+
+	// generation is the store generation of the data file the matrix was
+	// loaded from; the pool persists the matrix only while it is current.
+	generation atomic.Uint64
 
 	// Lifecycle
 	ctx    context.Context
@@ -56,8 +283,10 @@ type BrainWorker struct {
 	wg     sync.WaitGroup
 
 	// Stats
+	writes       atomic.Uint64 // see Writes
 	opsProcessed uint64
 	lastOp       time.Time
+	stopped      bool // set by Stop; no readers start afterwards
 
 	mu sync.RWMutex
 }
@@ -71,11 +300,16 @@ func NewBrainWorker(indexID core.IndexID, matrix *core.Matrix) *BrainWorker {
 		matrix:  matrix,
 		engine:  engine.NewMatrixEngine(matrix),
 		hebbian: synapse.NewHebbianEngine(matrix),
-		ops:     make(chan *Operation, 1000), // Buffered for burst handling
-		ctx:     ctx,
-		cancel:  cancel,
-		lastOp:  time.Now(),
+		// Buffered for burst handling
+		interactive:     make(chan *Operation, 1000),
+		background:      make(chan *Operation, 1000),
+		reads:           make(chan *Operation, 1000),
+		readConcurrency: DefaultReadConcurrency,
+		ctx:             ctx,
+		cancel:          cancel,
+		lastOp:          time.Now(),
 	}
+	w.backgroundSlice.Store(int64(DefaultBackgroundSlice))
 
 	// Start worker goroutine
 	w.wg.Add(1)
@@ -89,62 +323,304 @@ func (w *BrainWorker) run() {
 	defer w.wg.Done()
 
 	for {
+		// Interactive operations jump ahead of queued background work.
+		select {
+		case op := <-w.interactive:
+			w.processWrite(op)
+			continue
+		default:
+		}
+
 		select {
 		case <-w.ctx.Done():
 			// Drain remaining operations
+			w.rw.Lock()
 			w.drainOps()
+			w.rw.Unlock()
 			return
 
-		case op := <-w.ops:
+		case op := <-w.interactive:
+			w.processWrite(op)
+
+		case op := <-w.background:
+			w.processWrite(op)
+		}
+	}
+}
+
+// readLoop serves the read queue. readConcurrency of them run per worker.
+func (w *BrainWorker) readLoop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			for {
+				select {
+				case op := <-w.reads:
+					w.processRead(op)
+				default:
+					return
+				}
+			}
+
+		case op := <-w.reads:
+			w.processRead(op)
+		}
+	}
+}
+
+// startReaders starts the read goroutines unless the worker is stopping.
+func (w *BrainWorker) startReaders() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped || w.ctx.Err() != nil {
+		return
+	}
+	w.wg.Add(w.readConcurrency)
+	for i := 0; i < w.readConcurrency; i++ {
+		go w.readLoop()
+	}
+}
+
+// queue returns the channel op waits in: the read queue for reads that do
+// not ask for strong consistency, otherwise the write queue of its priority.
+func (w *BrainWorker) queue(op *Operation) chan *Operation {
+	if op.Type.IsRead() && !op.Strong {
+		w.readersOnce.Do(w.startReaders)
+		return w.reads
+	}
+	if op.Priority == PriorityBackground {
+		return w.background
+	}
+	return w.interactive
+}
+
+// processWrite runs op from the write queue, excluding reads while it runs.
+func (w *BrainWorker) processWrite(op *Operation) {
+	w.rw.Lock()
+	defer w.rw.Unlock()
+	w.processOp(op)
+}
+
+// processRead runs a read from the read queue alongside other reads.
+func (w *BrainWorker) processRead(op *Operation) {
+	w.readsActive.Add(1)
+	defer w.readsActive.Add(-1)
+	w.rw.RLock()
+	defer w.rw.RUnlock()
+	op.concurrent = true
+	w.processOp(op)
+}
+
+// yieldPoint is called between items of a long operation. When running a
+// background operation whose slice is used up, it first lets waiting reads
+// run and serves every queued interactive operation, so those wait at most
+// one slice plus one item.
+func (w *BrainWorker) yieldPoint() {
+	if w.itemHook != nil {
+		w.itemHook()
+	}
+	if !w.inBackground || time.Since(w.sliceStart) < time.Duration(w.backgroundSlice.Load()) {
+		return
+	}
+
+	// Unlock admits every blocked reader before Lock can succeed again.
+	w.rw.Unlock()
+	w.rw.Lock()
+
+	w.inBackground = false
+	for drained := false; !drained; {
+		select {
+		case op := <-w.interactive:
 			w.processOp(op)
+		default:
+			drained = true
 		}
 	}
+	w.inBackground = true
+	w.sliceStart = time.Now()
+}
+
+// neuronIDs snapshots the matrix's neuron IDs so long loops can yield
+// without iterating a map that interactive operations may modify.
+func (w *BrainWorker) neuronIDs() []core.NeuronID {
+	ids := make([]core.NeuronID, 0, len(w.matrix.Neurons))
+	for id := range w.matrix.Neurons {
+		ids = append(ids, id)
+	}
+	return ids
 }
 
 // processOp handles a single operation
 func (w *BrainWorker) processOp(op *Operation) {
+	start := time.Now()
 	w.mu.Lock()
 	w.opsProcessed++
-	w.lastOp = time.Now()
+	w.lastOp = start
 	w.mu.Unlock()
 
+	if op.Priority == PriorityBackground && !op.concurrent {
+		w.inBackground = true
+		w.sliceStart = time.Now()
+		defer func() { w.inBackground = false }()
+	}
+
 	var result any
 	var err error
 
+	ctx, span := w.startOpSpan(op)
+	if span != nil {
+		if !op.concurrent {
+			w.engine.SetTraceContext(ctx)
+		}
+		defer func() {
+			if !op.concurrent {
+				w.engine.SetTraceContext(nil)
+			}
+			tracing.End(span, err)
+		}()
+	}
+
+	if op.Type == OpShutdown {
+		w.cancel()
+		return
+	}
+	result, err = w.execute(ctx, op)
+	if err == nil && op.Priority == PriorityInteractive && op.Type.countsAsWrite() {
+		w.writes.Add(1)
+	}
+
+	w.recordOp(op, start, result, err)
+	w.reportMutation(op, result, err)
+
+	// Send results
+	if op.Result != nil {
+		op.Result <- result
+	}
+	if op.Error != nil {
+		op.Error <- err
+	}
+}
+
+// execute runs op. A panic fails op with a *PanicError instead of taking
+// the server down, and is kept as a dead letter.
+func (w *BrainWorker) execute(ctx context.Context, op *Operation) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, w.panicked(op, r, debug.Stack())
+		}
+	}()
+	if err := w.refuseDestructive(op); err != nil {
+		return nil, err
+	}
+
 	switch op.Type {
 	case OpWrite: // Memory formation - create new neuron
-		req := op.Payload.(AddNeuronRequest)
-		result, err = w.engine.AddNeuron(req.Content, req.ParentID, req.Metadata)
-		if err == nil {
-			w.hebbian.OnNeuronFired(result.(*core.Neuron).ID)
+		w.applyVectorSettings()
+		w.applySentimentSettings()
+		var n *core.Neuron
+		if n, err = w.write(op.Payload.(AddNeuronRequest)); n != nil {
+			result = n
+		}
+
+	case OpWriteBatch:
+		w.applyVectorSettings()
+		w.applySentimentSettings()
+		req := op.Payload.(WriteBatchRequest)
+		results := make([]WriteBatchResult, len(req.Items))
+		for i, item := range req.Items {
+			n, err := w.write(item)
+			if err != nil {
+				n = nil
+			}
+			results[i] = WriteBatchResult{Neuron: n, Err: err}
 		}
+		result = results
 
 	case OpRead: // Memory retrieval - get specific neuron
 		id := op.Payload.(core.NeuronID)
-		result, err = w.engine.GetNeuron(id)
-		if err == nil {
-			w.hebbian.OnNeuronFired(id)
+		var n *core.Neuron
+		if n, err = w.engine.PeekNeuron(id); err == nil {
+			w.activate(op, ActivateRequest{IDs: []core.NeuronID{id}, Access: true})
+			result = n.Snapshot()
 		}
 
 	case OpSearch: // Associative recall - search by content
+		w.applyVectorSettings()
+		w.applySentimentSettings()
+		w.applyMetadataKeys()
 		req := op.Payload.(SearchRequest)
-		neurons := w.engine.Search(req.Query, req.Depth, req.Limit, req.Metadata, req.Strict)
-		for _, n := range neurons {
-			w.hebbian.OnNeuronFired(n.ID)
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if req.AnchorID != "" {
+			if _, err = w.engine.PeekNeuron(req.AnchorID); err != nil {
+				err = fmt.Errorf("anchor %s: %w", req.AnchorID, err)
+				break
+			}
 		}
-		result = neurons
+		neurons, stats := w.engine.PeekSearch(ctx, req.Query, req.Depth, req.Limit, req.Metadata, req.Strict, req.Roles, engine.SearchOptions{
+			ResolveSuperseded: req.ResolveSuperseded,
+			Mode:              req.Mode,
+			MinScore:          req.MinScore,
+			Language:          req.Language,
+			CaseSensitive:     req.CaseSensitive,
+			AnchorID:          req.AnchorID,
+			AnchorWeight:      req.AnchorWeight,
+			IncludeAnchor:     req.IncludeAnchor,
+			Focus:             w.engineFocus(req.IgnoreFocus),
+			SpreadTypes:       req.SpreadTypes,
+			Expansion:         req.Expansion,
+			ExpansionWeight:   req.ExpansionWeight,
+		})
+		if req.Stats != nil {
+			*req.Stats = stats
+		}
+		if !req.Passive {
+			ids := make([]core.NeuronID, len(neurons))
+			for i, n := range neurons {
+				ids[i] = n.ID
+			}
+			w.activate(op, ActivateRequest{IDs: ids, CoFire: req.Reinforce})
+		}
+		result = snapshots(neurons)
+
+	case OpExpandQuery:
+		req := op.Payload.(ExpandQueryRequest)
+		result = w.engine.ExpandQuery(req.Query, req.Language, req.Options)
 
 	case OpTouch: // Memory modification - update content
 		req := op.Payload.(UpdateNeuronRequest)
 		err = w.engine.UpdateNeuron(req.ID, req.Content)
 
-	case OpForget: // Memory erasure - delete neuron
+	case OpForget: // Memory erasure - recycle neuron
 		id := op.Payload.(core.NeuronID)
-		err = w.engine.DeleteNeuron(id)
+		err = w.engine.ForgetNeuron(id, engine.RecycleReasonForget)
+
+	case OpListRecycled:
+		result = w.engine.RecycledNeurons()
+
+	case OpRestore:
+		var r engine.RestoreResult
+		if r, err = w.engine.RestoreNeuron(op.Payload.(core.NeuronID)); err == nil {
+			result = r
+		}
+
+	case OpPurge:
+		req := op.Payload.(PurgeRequest)
+		err = w.engine.PurgeNeuron(req.ID, req.Live)
+
+	case OpForgetByMetadata:
+		result = w.engine.ForgetByMetadata(op.Payload.(ForgetByMetadataRequest).Metadata)
 
 	case OpRecall: // Memory scanning - list neurons
 		req := op.Payload.(ListNeuronsRequest)
-		result = w.engine.ListNeurons(req.Offset, req.Limit, req.DepthFilter)
+		neurons, total := w.engine.ListNeuronsOrdered(req.Offset, req.Limit, req.DepthFilter, req.Roles, w.engineFocus(req.IgnoreFocus), req.Order)
+		if req.Total != nil {
+			*req.Total = total
+		}
+		result = snapshots(neurons)
 
 	case OpFire:
 		id := op.Payload.(core.NeuronID)
@@ -154,12 +630,7 @@ func (w *BrainWorker) processOp(op *Operation) {
 		}
 
 	case OpDecay:
-		// Apply decay to all neurons
-		for _, n := range w.matrix.Neurons {
-			n.Decay(w.matrix.DecayRate)
-		}
-		w.hebbian.DecayAll()
-		w.hebbian.PruneDeadSynapses()
+		w.decay()
 
 	case OpConsolidate:
 		result = w.consolidate()
@@ -171,72 +642,324 @@ func (w *BrainWorker) processOp(op *Operation) {
 		w.reorg()
 
 	case OpGetStats:
-		result = w.engine.GetStats()
+		stats := w.engine.GetStats()
+		stats["group_co_fires"], stats["group_co_fire_synapses"] = w.hebbian.GroupStats()
+		stats["coalesced_searches"], stats["coalesced_executions"] = w.CoalesceStats()
+		result = stats
 
-	case OpShutdown:
-		w.cancel()
+	case OpGraphStats:
+		w.applyMetadataKeys()
+		result = w.engine.GraphStats()
+
+	case OpHealth:
+		result = w.healthScore(op.Payload.(HealthRequest))
+
+	case OpCalibrate:
+		result = w.engine.CalibrateSimilarity(op.Payload.(CalibrateRequest).Options)
+
+	case OpPrunePlan:
+		result = w.hebbian.PlanPrune()
+
+	case OpGraphSummary:
+		req := op.Payload.(GraphSummaryRequest)
+		result = w.engine.GraphSummary(req.Cells, req.Types)
+
+	case OpGetGraph:
+		types, _ := op.Payload.([]string)
+		result = w.engine.GraphDump(w.hebbian.Scores(), types)
+
+	case OpGetSynapses:
+		types, _ := op.Payload.([]string)
+		result = w.engine.Synapses(w.hebbian.Scores(), types)
+
+	case OpGetActivity:
+		result = w.engine.Activity(time.Now())
+
+	case OpSample:
+		req := op.Payload.(SampleRequest)
+		sample := w.engine.Sample(req.Options)
+		if req.Rehearse {
+			ids := make([]core.NeuronID, len(sample.Neurons))
+			for i, s := range sample.Neurons {
+				ids[i] = s.Neuron.ID
+			}
+			w.activate(op, ActivateRequest{IDs: ids})
+		}
+		for i := range sample.Neurons {
+			sample.Neurons[i].Neuron = sample.Neurons[i].Neuron.Snapshot()
+		}
+		result = sample
+
+	case OpMigrateTurns:
+		req := op.Payload.(MigrateTurnsRequest)
+		result = w.engine.MigrateTurns(req.Pattern, req.DryRun)
+
+	case OpImportNotes:
+		req := op.Payload.(ImportNotesRequest)
+		result = w.engine.ImportNotes(req.Notes, req.LinkWeight)
+
+	case OpRetention:
+		req := op.Payload.(RetentionRequest)
+		result = w.engine.ApplyRetention(req.Rules, req.Options)
+
+	case OpExportSlice:
+		req := op.Payload.(ExportSliceRequest)
+		result = w.engine.ExportSlice(req.Filter, req.NeighborHops)
+
+	case OpImportSlice:
+		req := op.Payload.(ImportSliceRequest)
+		result = w.engine.ImportSlice(req.Slice, req.KeepDangling)
+
+	case OpCompactContent:
+		req := op.Payload.(CompactContentRequest)
+		result = w.engine.CompactContent(req.Config, req.Now)
+
+	case OpSync:
+		req := op.Payload.(SyncRequest)
+		result = w.engine.Changes(req.Since, req.Limit, req.IncludeActivity, req.ActivityThreshold)
+
+	case OpDetectConflicts:
+		req := op.Payload.(DetectConflictsRequest)
+		result = w.engine.DetectConflicts(req.ID, req.Options)
+
+	case OpListConflicts:
+		result = w.engine.ConflictGroups()
+
+	case OpPin:
+		req := op.Payload.(PinRequest)
+		result, err = w.engine.SetPinned(req.ID, req.Pinned, w.maxPinned)
+
+	case OpListPins:
+		result = w.engine.PinnedNeurons()
+
+	case OpListPromotions:
+		req := op.Payload.(PromotionsRequest)
+		result = w.engine.Promotions(req.Since, req.Limit)
+
+	case OpHistory:
+		var h HistoryResult
+		if h.Chain, h.Truncated, err = w.engine.History(op.Payload.(core.NeuronID)); err == nil {
+			result = h
+		}
+
+	case OpChainIssues:
+		result = w.engine.ChainIssues()
+
+	case OpEmbedPending:
+		result, err = w.engine.EmbedPending(op.Payload.(int))
+
+	case OpActivate:
+		w.fire(op.Payload.(ActivateRequest))
+
+	case OpSnapshot:
+		result, err = persistence.CopyMatrix(w.matrix)
+
+	}
+
+	return result, err
+}
+
+// startOpSpan ends op's queue-wait span and, for traced operations, starts
+// the execution span and returns it with its context. It returns a nil span
+// when op is not traced.
+func (w *BrainWorker) startOpSpan(op *Operation) (context.Context, trace.Span) {
+	if op.queueSpan != nil {
+		op.queueSpan.End()
+	}
+	if op.ctx == nil || !tracing.Enabled() {
+		return nil, nil
+	}
+	return tracing.StartForIndex(op.ctx, "worker."+op.Type.String(), w.indexID, tracing.AttrOperation.String(op.Type.String()))
+}
+
+// snapshots copies the neurons a read returns. The worker keeps changing
+// the live neurons after the read, firing them on its write path among
+// others, so callers only ever get copies.
+func snapshots(neurons []*core.Neuron) []*core.Neuron {
+	out := make([]*core.Neuron, len(neurons))
+	for i, n := range neurons {
+		out[i] = n.Snapshot()
+	}
+	return out
+}
+
+// activate fires the neurons a read returned. Firing changes neurons that
+// concurrent reads are scoring, so a read on the read path queues it as an
+// OpActivate for the write path instead of firing in place.
+func (w *BrainWorker) activate(op *Operation, req ActivateRequest) {
+	if len(req.IDs) == 0 {
 		return
 	}
+	if !op.concurrent {
+		w.fire(req)
+		return
+	}
+	w.SubmitAsync(&Operation{Type: OpActivate, Payload: req})
+}
 
-	// Send results
-	if op.Result != nil {
-		op.Result <- result
+// fire fires every neuron in req that still exists and lets the Hebbian
+// engine learn from the co-activation: pair by pair, forming co-retrieved
+// synapses, or as one group when req.CoFire is set.
+func (w *BrainWorker) fire(req ActivateRequest) {
+	fired := make([]core.NeuronID, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		if req.Access {
+			if _, err := w.engine.GetNeuron(id); err == nil {
+				fired = append(fired, id)
+			}
+		} else if n, err := w.engine.PeekNeuron(id); err == nil {
+			n.Fire()
+			fired = append(fired, id)
+		}
 	}
-	if op.Error != nil {
-		op.Error <- err
+	if req.CoFire {
+		w.hebbian.CoFireGroup(fired, synapse.GroupCoFireOptions{
+			SimilarityFloor: w.reinforce.SimilarityFloor,
+			Floor:           w.SimilarityThresholds().Floor(w.reinforce.SimilarityFloor),
+			MaxNewSynapses:  w.reinforce.MaxNewSynapses,
+			Similarity:      engine.NeuronSimilarity(),
+		})
+		return
+	}
+	for _, id := range fired {
+		w.hebbian.OnNeuronRetrieved(id)
+	}
+}
+
+// decay applies energy decay to all neurons and synapses
+func (w *BrainWorker) decay() {
+	for _, id := range w.neuronIDs() {
+		if n, ok := w.matrix.Neurons[id]; ok {
+			if n.Pinned {
+				n.DecayAbove(w.matrix.DecayRate, w.pinFloor)
+			} else {
+				n.Decay(w.matrix.DecayRate)
+			}
+		}
+		w.yieldPoint()
 	}
+	w.hebbian.DecayAll()
+	w.yieldPoint()
+	w.hebbian.PruneSynapses()
 }
 
 // consolidate moves mature neurons to deeper layers
 func (w *BrainWorker) consolidate() int {
 	consolidated := 0
 
-	for _, n := range w.matrix.Neurons {
-		if n.ShouldConsolidate(10, 30*time.Minute) {
-			n.Depth++
-			consolidated++
+	now := time.Now()
+	for _, id := range w.neuronIDs() {
+		if n, ok := w.matrix.Neurons[id]; ok && n.ShouldConsolidate(10, 30*time.Minute) {
+			if _, err := w.engine.Promote(id, now, w.promotionHistory); err == nil {
+				consolidated++
+			}
 		}
+		w.yieldPoint()
 	}
 
 	// Self-tune Hebbian parameters
 	w.hebbian.SelfTune()
 
+	w.matrix.Lock()
 	w.matrix.LastConsolidation = time.Now()
 	w.matrix.Version++
+	w.matrix.Unlock()
 
 	return consolidated
 }
 
-// prune removes dead neurons and synapses
+// write adds the neuron req asks for. The neuron is returned even when a
+// later step, superseding or pinning, fails.
+func (w *BrainWorker) write(req AddNeuronRequest) (*core.Neuron, error) {
+	if req.Pinned && w.engine.PinnedCount() >= w.maxPinned {
+		return nil, core.ErrPinLimit
+	}
+	if req.Supersedes != nil {
+		if err := w.engine.CheckSupersede(*req.Supersedes); err != nil {
+			return nil, err
+		}
+	}
+	for _, related := range req.Related {
+		if _, err := w.engine.PeekNeuron(related); err != nil {
+			return nil, fmt.Errorf("related neuron %s: %w", related, err)
+		}
+	}
+	evicted, err := w.enforceQuota(req)
+	for _, id := range evicted {
+		if w.mutations != nil {
+			w.mutations(Mutation{IndexID: w.indexID, Kind: MutationForget, NeuronID: id})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	n, err := w.engine.AddNeuronWithAttachments(req.Content, req.ParentID, req.Metadata, req.Attachments)
+	if err != nil {
+		return nil, err
+	}
+	relatedType := req.RelatedType
+	if relatedType == "" {
+		relatedType = core.SynapseAssociative
+	}
+	// A related neuron the quota evicted meanwhile is skipped.
+	for _, related := range req.Related {
+		w.engine.Link(n.ID, related, engine.RelatedWeight, relatedType)
+	}
+	w.hebbian.OnNeuronFired(n.ID)
+	if req.Supersedes != nil {
+		if err := w.engine.Supersede(*req.Supersedes, n.ID); err != nil {
+			return n, err
+		}
+	}
+	if req.Pinned {
+		_, err = w.engine.SetPinned(n.ID, true, w.maxPinned)
+	}
+	return n, err
+}
+
+// prune removes dead neurons and synapses. Pinned neurons are kept, and
+// an append-only index keeps every neuron.
 func (w *BrainWorker) prune() int {
+	if w.AppendOnly() {
+		return w.hebbian.PruneSynapses()
+	}
 	pruned := 0
 
 	// Collect dead neurons
 	deadNeurons := make([]core.NeuronID, 0)
 	for id, n := range w.matrix.Neurons {
-		if !n.IsAlive() {
+		if !n.Pinned && !n.IsAlive() {
 			deadNeurons = append(deadNeurons, id)
 		}
 	}
 
+	w.yieldPoint()
+
 	// Delete them
 	for _, id := range deadNeurons {
 		if err := w.engine.DeleteNeuron(id); err == nil {
 			pruned++
 		}
+		w.yieldPoint()
 	}
 
 	// Also prune dead synapses
-	pruned += w.hebbian.PruneDeadSynapses()
+	pruned += w.hebbian.PruneSynapses()
+
+	// and forgotten neurons whose recycle window has ended
+	pruned += len(w.engine.PurgeExpiredRecycled(time.Now()))
 
 	return pruned
 }
 
 // reorg performs spatial reorganization of the matrix using fractal clustering.
 // Called by the reorg daemon — runs outside any matrix lock, safe to acquire locks internally.
+// Clustering runs as a single unit and does not yet yield mid-pass.
 func (w *BrainWorker) reorg() {
 	w.hebbian.UpdateFractalClusters()
+	w.matrix.Lock()
 	w.matrix.Version++
+	w.matrix.Unlock()
 }
 
 func clamp(val, min, max float64) float64 {
@@ -251,27 +974,49 @@ func clamp(val, min, max float64) float64 {
 
 // drainOps processes remaining operations before shutdown
 func (w *BrainWorker) drainOps() {
-	for {
-		select {
-		case op := <-w.ops:
-			if op.Type == OpShutdown {
-				return
+	for _, q := range []chan *Operation{w.interactive, w.background} {
+		for drained := false; !drained; {
+			select {
+			case op := <-q:
+				if op.Type == OpShutdown {
+					return
+				}
+				w.processOp(op)
+			default:
+				drained = true
 			}
-			w.processOp(op)
-		default:
-			return
 		}
 	}
 }
 
-// Submit queues an operation and waits for result
+// Submit queues an operation and waits for result. A search identical to
+// one already in flight shares its execution; see submitSearch.
 func (w *BrainWorker) Submit(op *Operation) (any, error) {
+	if err := w.quarantineError(); err != nil {
+		return nil, err
+	}
+	if req, ok := op.Payload.(SearchRequest); ok && op.Type == OpSearch && !op.Strong {
+		if key := coalesceKey(req); key != "" {
+			return w.submitSearch(key, op)
+		}
+	}
+	return w.submit(op)
+}
+
+// submit queues op and waits for its result.
+func (w *BrainWorker) submit(op *Operation) (any, error) {
 	op.Result = make(chan any, 1)
 	op.Error = make(chan error, 1)
+	if op.ctx != nil && tracing.Enabled() {
+		_, op.queueSpan = tracing.StartForIndex(op.ctx, "worker.queue", w.indexID, tracing.AttrOperation.String(op.Type.String()))
+	}
 
 	select {
-	case w.ops <- op:
+	case w.queue(op) <- op:
 	case <-w.ctx.Done():
+		if op.queueSpan != nil {
+			tracing.End(op.queueSpan, context.Canceled)
+		}
 		return nil, context.Canceled
 	}
 
@@ -284,10 +1029,17 @@ func (w *BrainWorker) Submit(op *Operation) (any, error) {
 	}
 }
 
+// SubmitContext submits op on behalf of the request whose trace is in ctx.
+// Queue wait and execution are recorded as child spans.
+func (w *BrainWorker) SubmitContext(ctx context.Context, op *Operation) (any, error) {
+	op.ctx = ctx
+	return w.Submit(op)
+}
+
 // SubmitAsync queues an operation without waiting
 func (w *BrainWorker) SubmitAsync(op *Operation) {
 	select {
-	case w.ops <- op:
+	case w.queue(op) <- op:
 	default:
 		// Queue full, drop operation (could log this)
 	}
@@ -295,27 +1047,179 @@ func (w *BrainWorker) SubmitAsync(op *Operation) {
 
 // Stop gracefully stops the worker
 func (w *BrainWorker) Stop() {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
 	w.cancel()
 	w.wg.Wait()
 }
 
-// Matrix returns the underlying matrix
+// SetBackgroundSlice sets how long a background operation may run before it
+// serves queued interactive operations. Values <= 0 are ignored.
+func (w *BrainWorker) SetBackgroundSlice(d time.Duration) {
+	if d > 0 {
+		w.backgroundSlice.Store(int64(d))
+	}
+}
+
+// SetReadConcurrency sets how many read operations the worker runs at once.
+// Values < 1 are ignored. Call before the worker serves operations.
+func (w *BrainWorker) SetReadConcurrency(n int) {
+	if n >= 1 {
+		w.readConcurrency = n
+	}
+}
+
+// Matrix returns the underlying matrix, for persistence and daemons that
+// own its locking. Request handlers read it through operations (OpGetGraph,
+// OpGetSynapses, OpGetActivity, ...) or the accessors below instead.
 func (w *BrainWorker) Matrix() *core.Matrix {
 	return w.matrix
 }
 
-// SetVectorizer attaches a vectorizer to the underlying engine for
+// Snapshot returns an independent copy of the whole matrix. It is taken by
+// an OpSnapshot on the write queue, so no write, activation or Hebbian
+// update changes the matrix while it is copied.
+func (w *BrainWorker) Snapshot(ctx context.Context) (*core.Matrix, error) {
+	result, err := w.SubmitContext(ctx, &Operation{Type: OpSnapshot, Strong: true})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*core.Matrix), nil
+}
+
+// Size returns the number of neurons and synapses in the index.
+func (w *BrainWorker) Size() (neurons, synapses int) {
+	w.matrix.RLock()
+	defer w.matrix.RUnlock()
+	return len(w.matrix.Neurons), len(w.matrix.Synapses)
+}
+
+// Footprint returns the approximate memory held by the index in bytes.
+func (w *BrainWorker) Footprint() int64 {
+	return w.matrix.Footprint()
+}
+
+// LookupNeurons returns the neurons among ids that exist, by ID, without
+// firing them.
+func (w *BrainWorker) LookupNeurons(ids []core.NeuronID) map[core.NeuronID]*core.Neuron {
+	w.matrix.RLock()
+	defer w.matrix.RUnlock()
+	found := make(map[core.NeuronID]*core.Neuron, len(ids))
+	for _, id := range ids {
+		if n, ok := w.matrix.Neurons[id]; ok {
+			found[id] = n
+		}
+	}
+	return found
+}
+
+// SetVectorSource sets the function the worker asks for its vector settings
+// before each write and search, so per-index overrides and runtime changes
+// apply without recreating the worker. Call before the worker serves
+// operations.
+func (w *BrainWorker) SetVectorSource(fn func() VectorSettings) {
+	w.vectorSource = fn
+}
+
+// applyVectorSettings hands the current vector settings to the engine for
 // auto-embedding on write and hybrid scoring on search.
-func (w *BrainWorker) SetVectorizer(v *vector.Vectorizer, alpha float64, queryRepeat int) {
-	w.engine.SetVectorizer(v)
-	w.engine.SetAlpha(alpha)
-	w.engine.SetQueryRepeat(queryRepeat)
+func (w *BrainWorker) applyVectorSettings() {
+	var settings VectorSettings
+	if w.vectorSource != nil {
+		settings = w.vectorSource()
+	}
+	w.engine.SetVectorizer(settings.Embedder, settings.Model)
+	w.engine.SetAlpha(settings.Alpha)
+	w.engine.SetQueryRepeat(settings.QueryRepeat)
 }
 
-// SetSentimentAnalyzer attaches a sentiment analyzer to the underlying engine
-// for auto-labeling on write and sentiment-aware scoring on search.
-func (w *BrainWorker) SetSentimentAnalyzer(a *sentiment.Analyzer) {
-	w.engine.SetSentimentAnalyzer(a)
+// SetMetadataKeysSource sets the function the worker asks for the metadata
+// keys to index before each search and graph stats. Call before the worker serves
+// operations.
+func (w *BrainWorker) SetMetadataKeysSource(fn func() []string) {
+	w.metadataKeysSource = fn
+}
+
+// applyMetadataKeys hands the current indexed metadata keys to the engine,
+// which rebuilds the metadata index when they changed.
+func (w *BrainWorker) applyMetadataKeys() {
+	if w.metadataKeysSource != nil {
+		w.engine.SetIndexedMetadataKeys(w.metadataKeysSource())
+	}
+}
+
+// SetChangelogSize sets how many neuron removals the matrix remembers for
+// delta sync. Call before the worker serves operations.
+func (w *BrainWorker) SetChangelogSize(n int) {
+	w.engine.SetChangelogSize(n)
+}
+
+// SetRecycleWindow sets how long forgotten neurons stay restorable. Call
+// before the worker serves operations.
+func (w *BrainWorker) SetRecycleWindow(d time.Duration) {
+	w.engine.SetRecycleWindow(d)
+}
+
+// SetPinPolicy sets how many neurons the index may pin and the energy floor
+// decay keeps them at. Call before the worker serves operations.
+func (w *BrainWorker) SetPinPolicy(maxPinned int, floor float64) {
+	w.maxPinned = maxPinned
+	w.pinFloor = floor
+}
+
+// SetPromotionHistory sets how many promotion records each neuron keeps.
+// Call before the worker serves operations.
+func (w *BrainWorker) SetPromotionHistory(n int) {
+	w.promotionHistory = n
+}
+
+// SetReinforce bounds the group co-fires of reinforcing searches. Call
+// before the worker serves operations.
+func (w *BrainWorker) SetReinforce(cfg core.ReinforceConfig) {
+	w.reinforce = cfg
+}
+
+// SetPrunePolicy sets how synapses are scored and how many survive a prune.
+// Call before the worker serves operations.
+func (w *BrainWorker) SetPrunePolicy(cfg core.PruneConfig) {
+	w.hebbian.SetPrunePolicy(cfg)
+}
+
+// SetBM25 sets the engine's lexical scoring parameters. Call before the
+// worker serves operations.
+func (w *BrainWorker) SetBM25(k1, b float64, maxTerms int) {
+	w.engine.SetBM25(k1, b, maxTerms)
+}
+
+// SetLexicalFold sets how lexical scoring folds case and diacritics. Call
+// before the worker serves operations.
+func (w *BrainWorker) SetLexicalFold(lexical core.LexicalConfig) {
+	w.engine.SetLexicalFold(engine.LexicalFold{Diacritics: lexical.FoldDiacritics, Language: lexical.Language})
+}
+
+// SetSentimentSource sets the function the worker asks for its sentiment
+// analyzer before each write and search, so a per-index switch applies
+// without recreating the worker. Call before the worker serves operations.
+func (w *BrainWorker) SetSentimentSource(fn func() SentimentSettings) {
+	w.sentimentSource = fn
+}
+
+// applySentimentSettings hands the current sentiment analyzer to the
+// engine for auto-labeling on write and sentiment-aware scoring on search.
+func (w *BrainWorker) applySentimentSettings() {
+	var settings SentimentSettings
+	if w.sentimentSource != nil {
+		settings = w.sentimentSource()
+	}
+	w.engine.SetSentimentAnalyzer(settings.Analyzer)
+	w.engine.SetSentimentMinASCII(settings.MinASCII)
+}
+
+// Writes returns how many interactive operations have changed the index
+// since the worker started. Daemons schedule their passes by its rate.
+func (w *BrainWorker) Writes() uint64 {
+	return w.writes.Load()
 }
 
 // Stats returns worker stats
@@ -324,19 +1228,62 @@ func (w *BrainWorker) Stats() map[string]any {
 	defer w.mu.RUnlock()
 
 	return map[string]any{
-		"index_id":       w.indexID,
-		"ops_processed":  w.opsProcessed,
-		"last_op":        w.lastOp,
-		"queue_length":   len(w.ops),
-		"queue_capacity": cap(w.ops),
+		"index_id":          w.indexID,
+		"ops_processed":     w.opsProcessed,
+		"writes":            w.writes.Load(),
+		"last_op":           w.lastOp,
+		"queue_length":      len(w.interactive) + len(w.background) + len(w.reads),
+		"queue_capacity":    cap(w.interactive) + cap(w.background) + cap(w.reads),
+		"queue_interactive": len(w.interactive),
+		"queue_background":  len(w.background),
+		"queue_write":       len(w.interactive) + len(w.background),
+		"queue_read":        len(w.reads),
+		"reads_active":      w.readsActive.Load(),
+		"read_concurrency":  w.readConcurrency,
 	}
 }
 
+// VectorSettings is the vector configuration an index embeds and searches
+// with.
+type VectorSettings struct {
+	Embedder    vector.Embedder // nil disables the vector layer
+	Model       string          // name stored with new embeddings
+	Alpha       float64
+	QueryRepeat int
+}
+
 // Request types
 type AddNeuronRequest struct {
 	Content  string
 	ParentID *core.NeuronID
 	Metadata map[string]string
+	Pinned   bool // pin the neuron; fails with core.ErrPinLimit at the cap
+
+	// Supersedes, when set, links the new neuron as the successor of this
+	// one; see engine.MatrixEngine.Supersede.
+	Supersedes *core.NeuronID
+
+	// Attachments are referenced from the neuron; callers check that
+	// their blobs exist.
+	Attachments []core.Attachment
+
+	// Related lists existing neurons the new one is linked to with
+	// synapses of RelatedType, associative when empty.
+	Related     []core.NeuronID
+	RelatedType string
+}
+
+// WriteBatchRequest adds Items in one operation (OpWriteBatch), each as
+// an OpWrite would. An item that fails does not stop the others.
+type WriteBatchRequest struct {
+	Items []AddNeuronRequest
+}
+
+// WriteBatchResult is the outcome of one item of a WriteBatchRequest: the
+// neuron written, or the error it failed with.
+type WriteBatchResult struct {
+	Neuron *core.Neuron
+	Err    error
 }
 
 type SearchRequest struct {
@@ -345,6 +1292,94 @@ type SearchRequest struct {
 	Limit    int
 	Metadata map[string]string
 	Strict   bool
+	Roles    []string // authoring roles to include (OR); empty means all
+	Strong   bool     // see Operation.Strong
+	Passive  bool     // do not fire the returned neurons
+
+	// ResolveSuperseded replaces superseded results with the current
+	// neuron of their supersede chain.
+	ResolveSuperseded bool
+
+	// Mode is engine.SearchModeDefault or engine.SearchModeExact; in exact
+	// mode hits scoring below MinScore are skipped.
+	Mode     string
+	MinScore float64
+
+	// Language is the query's language for lexical folding; CaseSensitive
+	// matches exact-mode terms as written. See engine.SearchOptions.
+	Language      string
+	CaseSensitive bool
+
+	// AnchorID ranks results by relatedness to that neuron as well; see
+	// engine.SearchOptions. A missing anchor fails with
+	// core.ErrNeuronNotFound.
+	AnchorID      core.NeuronID
+	AnchorWeight  float64
+	IncludeAnchor bool
+
+	// IgnoreFocus leaves the index's focus out of the ranking.
+	IgnoreFocus bool
+
+	// Reinforce co-fires the results as a group instead of pair by pair,
+	// strengthening every synapse among them; see SetReinforce.
+	Reinforce bool
+
+	// SpreadTypes restricts spread activation to synapses of those types;
+	// see engine.SearchOptions.
+	SpreadTypes []string
+
+	// Explain marks a search whose caller shows score breakdowns. It is
+	// never coalesced with another.
+	Explain bool
+
+	// Expansion are terms added to the query (from OpExpandQuery), each
+	// counting lexically ExpansionWeight times as much as a query term.
+	Expansion       []string
+	ExpansionWeight float64
+
+	// Stats, when non-nil, receives a summary of the result set.
+	Stats *engine.SearchStats
+
+	// Coalesced, when non-nil, is set to true when the search shared the
+	// execution of an identical one in flight.
+	Coalesced *bool
+}
+
+// ExpandQueryRequest asks for the terms the index associates with Query
+// (OpExpandQuery); the result is a []string. See engine.ExpandQuery.
+type ExpandQueryRequest struct {
+	Query    string
+	Language string
+	Options  engine.ExpansionOptions
+}
+
+// GraphSummaryRequest asks for a grid overview of cells × cells cells,
+// bundling synapses of Types, all without types (OpGraphSummary).
+type GraphSummaryRequest struct {
+	Cells int
+	Types []string
+}
+
+// PurgeRequest removes a forgotten neuron for good (OpPurge). Live also
+// removes the neuron when it was never forgotten: forgetting it without a
+// stay in the recycle bin.
+type PurgeRequest struct {
+	ID   core.NeuronID
+	Live bool
+}
+
+// ForgetByMetadataRequest removes for good every neuron, live or
+// recycled, whose metadata holds all of Metadata's pairs, with its synapses
+// (OpForgetByMetadata); the result is an engine.ForgetReport. An empty
+// Metadata removes nothing.
+type ForgetByMetadataRequest struct {
+	Metadata map[string]string
+}
+
+// HistoryResult is a neuron's supersede chain, oldest first.
+type HistoryResult struct {
+	Chain     []*core.Neuron
+	Truncated bool // the walk stopped at engine.MaxChainLength
 }
 
 type UpdateNeuronRequest struct {
@@ -356,4 +1391,90 @@ type ListNeuronsRequest struct {
 	Offset      int
 	Limit       int
 	DepthFilter *int
+	Roles       []string // authoring roles to include (OR); empty means all
+	IgnoreFocus bool     // rank by energy alone despite the index's focus
+	Order       string   // an engine list order; energy by default
+
+	// Total, when set, receives how many neurons pass the filters.
+	Total *int
+}
+
+type SyncRequest struct {
+	Since             uint64
+	Limit             int
+	IncludeActivity   bool    // report energy/depth changes as updates
+	ActivityThreshold float64 // minimum energy movement that counts as activity
+}
+
+// ActivateRequest lists neurons a concurrent read returned, to be fired on
+// the write path.
+type ActivateRequest struct {
+	IDs    []core.NeuronID
+	Access bool // count as a direct read in the matrix activity totals
+	CoFire bool // reinforce IDs as a group; see synapse.CoFireGroup
+}
+
+type PinRequest struct {
+	ID     core.NeuronID
+	Pinned bool
+}
+
+// MigrateTurnsRequest asks for a turn migration; see
+// engine.MatrixEngine.MigrateTurns.
+type MigrateTurnsRequest struct {
+	Pattern *regexp.Regexp
+	DryRun  bool
+}
+
+// ImportNotesRequest asks for an import; see
+// engine.MatrixEngine.ImportNotes.
+type ImportNotesRequest struct {
+	Notes      []engine.ImportedNote
+	LinkWeight float64
+}
+
+// RetentionRequest asks for a retention run; see
+// engine.MatrixEngine.ApplyRetention.
+type RetentionRequest struct {
+	Rules   []core.RetentionRule
+	Options engine.RetentionOptions
+}
+
+// CompactContentRequest asks for a content compaction run at Now; see
+// engine.MatrixEngine.CompactContent.
+type CompactContentRequest struct {
+	Config core.CompactContentConfig
+	Now    time.Time
+}
+
+// PromotionsRequest asks for the promotion records made at or after Since,
+// newest first, at most Limit of them; see engine.MatrixEngine.Promotions.
+type PromotionsRequest struct {
+	Since time.Time
+	Limit int
+}
+
+// SampleRequest asks for a weighted draw of neurons; Rehearse fires the
+// drawn ones, as a search fires its results.
+type SampleRequest struct {
+	Options  engine.SampleOptions
+	Rehearse bool
+}
+
+// ExportSliceRequest asks for the neurons Filter selects and their
+// neighborhood up to NeighborHops synapses away.
+type ExportSliceRequest struct {
+	Filter       engine.SliceFilter
+	NeighborHops int
+}
+
+// ImportSliceRequest adds an exported slice to the index.
+type ImportSliceRequest struct {
+	Slice        engine.Slice
+	KeepDangling bool
+}
+
+type DetectConflictsRequest struct {
+	ID      core.NeuronID
+	Options engine.ConflictOptions
 }
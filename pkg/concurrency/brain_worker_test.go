@@ -1,10 +1,12 @@
 package concurrency
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
 )
 
 func newTestMatrix() *core.Matrix {
@@ -250,6 +252,77 @@ func TestBrainWorkerConsolidate(t *testing.T) {
 	}
 }
 
+func TestBrainWorkerPinnedSurviveDecayAndPrune(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+	w.SetPinPolicy(1, 0.4)
+
+	add := func(content string, pinned bool) (*core.Neuron, error) {
+		result, err := w.Submit(&Operation{
+			Type:    OpWrite,
+			Payload: AddNeuronRequest{Content: content, Pinned: pinned},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.(*core.Neuron), nil
+	}
+	pinned, err := add("Deploy keys live in the vault", true)
+	if err != nil {
+		t.Fatalf("pinned write failed: %v", err)
+	}
+	unpinned, _ := add("Deploy keys live in the safe", false)
+	if _, err := add("Deploy keys live in the drawer", true); err != core.ErrPinLimit {
+		t.Errorf("expected ErrPinLimit past the cap, got %v", err)
+	}
+	if _, err := w.Submit(&Operation{Type: OpPin, Payload: PinRequest{ID: unpinned.ID, Pinned: true}}); err != core.ErrPinLimit {
+		t.Errorf("expected ErrPinLimit pinning past the cap, got %v", err)
+	}
+
+	// Age both identically so decay alone would leave them dead.
+	for _, n := range []*core.Neuron{pinned, unpinned} {
+		n.BaseEnergy = 0
+		n.LastDecayAt = time.Now().Add(-1000 * time.Hour)
+	}
+	w.Submit(&Operation{Type: OpDecay})
+	if pinned.Energy != 0.4 {
+		t.Errorf("pinned energy should stop at the floor, got %f", pinned.Energy)
+	}
+	if unpinned.Energy != 0 {
+		t.Errorf("unpinned energy should decay fully, got %f", unpinned.Energy)
+	}
+
+	pinned.Energy = 0 // even a drained pinned neuron is kept
+	result, _ := w.Submit(&Operation{Type: OpPrune})
+	if result.(int) < 1 {
+		t.Errorf("expected the unpinned neuron to be pruned, got %v", result)
+	}
+	if _, ok := m.Neurons[unpinned.ID]; ok {
+		t.Error("unpinned neuron should be pruned")
+	}
+	if _, ok := m.Neurons[pinned.ID]; !ok {
+		t.Fatal("pinned neuron must survive pruning")
+	}
+
+	result, _ = w.Submit(&Operation{Type: OpListPins})
+	if pins := result.([]*core.Neuron); len(pins) != 1 || pins[0].ID != pinned.ID {
+		t.Errorf("unexpected pins: %v", pins)
+	}
+	stats, _ := w.Submit(&Operation{Type: OpGetStats})
+	if stats.(map[string]any)["pinned_count"] != 1 {
+		t.Errorf("stats should count pinned neurons: %v", stats)
+	}
+
+	if _, err := w.Submit(&Operation{Type: OpPin, Payload: PinRequest{ID: pinned.ID}}); err != nil {
+		t.Fatalf("unpin failed: %v", err)
+	}
+	w.Submit(&Operation{Type: OpPrune})
+	if _, ok := m.Neurons[pinned.ID]; ok {
+		t.Error("unpinned neuron should be pruned once dead")
+	}
+}
+
 func TestBrainWorkerGetStats(t *testing.T) {
 	m := newTestMatrix()
 	w := NewBrainWorker("test-user", m)
@@ -284,7 +357,10 @@ func TestBrainWorkerAsync(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// Neurons may be deduplicated, but at least 1 should exist
-	if len(m.Neurons) < 1 {
+	m.RLock()
+	count := len(m.Neurons)
+	m.RUnlock()
+	if count < 1 {
 		t.Error("Async operations should add neurons")
 	}
 }
@@ -334,3 +410,85 @@ func TestBrainWorkerStop(t *testing.T) {
 		t.Error("Stop should complete within timeout")
 	}
 }
+
+func TestBrainWorkerAppendOnly(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("ledger", m)
+	defer w.Stop()
+	w.SetAppendOnlySource(func() bool { return true })
+
+	result, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "Invoice 42 was paid in full"}})
+	if err != nil {
+		t.Fatalf("an append-only index must accept new memories: %v", err)
+	}
+	n := result.(*core.Neuron)
+
+	refused := map[string]*Operation{
+		"supersede": {Type: OpWrite, Payload: AddNeuronRequest{Content: "Invoice 42 was refunded", Supersedes: &n.ID}},
+		"touch":     {Type: OpTouch, Payload: UpdateNeuronRequest{ID: n.ID, Content: "Invoice 42 was never paid"}},
+		"forget":    {Type: OpForget, Payload: n.ID},
+		"purge":     {Type: OpPurge, Payload: PurgeRequest{ID: n.ID, Live: true}},
+		"retention": {Type: OpRetention, Payload: RetentionRequest{}},
+		"migrate":   {Type: OpMigrateTurns, Payload: MigrateTurnsRequest{}},
+	}
+	for name, op := range refused {
+		if _, err := w.Submit(op); !errors.Is(err, core.ErrAppendOnly) {
+			t.Errorf("%s: expected ErrAppendOnly, got %v", name, err)
+		}
+	}
+	if _, err := w.Submit(&Operation{Type: OpMigrateTurns, Payload: MigrateTurnsRequest{DryRun: true}}); errors.Is(err, core.ErrAppendOnly) {
+		t.Error("a dry run changes nothing and should be allowed")
+	}
+
+	// Pruning keeps a dead neuron.
+	n.Energy, n.BaseEnergy = 0, 0
+	w.Submit(&Operation{Type: OpPrune})
+	if _, ok := m.Neurons[n.ID]; !ok {
+		t.Error("pruning must not remove neurons of an append-only index")
+	}
+}
+
+func TestBrainWorkerHealthCache(t *testing.T) {
+	w := NewBrainWorker("health", newTestMatrix())
+	defer w.Stop()
+	cfg := core.DefaultConfig().Health
+	health := func(cfg core.HealthConfig, refresh bool) *engine.Health {
+		t.Helper()
+		result, err := w.Submit(&Operation{Type: OpHealth, Payload: HealthRequest{Config: cfg, Refresh: refresh}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.(*engine.Health)
+	}
+
+	if h := health(cfg, false); h != nil {
+		t.Fatalf("expected no score for an empty index, got %+v", h)
+	}
+	for _, content := range []string{"The deploy key rotates monthly", "Staging runs on port 8080"} {
+		if _, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := health(cfg, false)
+	if first == nil || w.CachedHealth() != first {
+		t.Fatalf("expected the score computed and cached, got %+v", first)
+	}
+
+	// Writes leave the cached score alone until it is refreshed.
+	if _, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "Backups run nightly at 02:00"}}); err != nil {
+		t.Fatal(err)
+	}
+	if h := health(cfg, false); h != first || h.Neurons != 2 {
+		t.Errorf("expected the cached score until a refresh, got %+v", h)
+	}
+	refreshed := health(cfg, true)
+	if refreshed == first || refreshed.Neurons != 3 {
+		t.Errorf("expected a refresh to recompute, got %+v", refreshed)
+	}
+
+	// A new formula invalidates the cache.
+	cfg.Weights.Energy *= 2
+	if h := health(cfg, false); h == refreshed || h.FormulaVersion != cfg.FormulaVersion() {
+		t.Errorf("expected a formula change to recompute, got %+v", h)
+	}
+}
@@ -0,0 +1,50 @@
+package concurrency
+
+import (
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// CalibrateRequest asks for the index's similarity calibration under
+// Options (OpCalibrate); the result is an *engine.SimilarityCalibration.
+type CalibrateRequest struct {
+	Options engine.CalibrationOptions
+}
+
+// SimilarityResolver returns the similarity thresholds of indexID, e.g.
+// from its registry entry.
+type SimilarityResolver func(indexID core.IndexID) engine.SimilarityThresholds
+
+// SetSimilarityResolver installs r to resolve each index's similarity
+// thresholds. nil leaves every index on the server's settings.
+func (p *WorkerPool) SetSimilarityResolver(r SimilarityResolver) {
+	p.similarityMu.Lock()
+	defer p.similarityMu.Unlock()
+	p.similarityResolver = r
+}
+
+// SimilarityThresholds returns the similarity thresholds of indexID.
+func (p *WorkerPool) SimilarityThresholds(indexID core.IndexID) engine.SimilarityThresholds {
+	p.similarityMu.RLock()
+	resolve := p.similarityResolver
+	p.similarityMu.RUnlock()
+	if resolve == nil {
+		return engine.SimilarityThresholds{}
+	}
+	return resolve(indexID)
+}
+
+// SetSimilaritySource sets the function the worker asks for its index's
+// similarity thresholds. Call before the worker serves operations.
+func (w *BrainWorker) SetSimilaritySource(fn func() engine.SimilarityThresholds) {
+	w.similaritySource = fn
+}
+
+// SimilarityThresholds returns the similarity thresholds of the worker's
+// index.
+func (w *BrainWorker) SimilarityThresholds() engine.SimilarityThresholds {
+	if w.similaritySource == nil {
+		return engine.SimilarityThresholds{}
+	}
+	return w.similaritySource()
+}
@@ -0,0 +1,135 @@
+package concurrency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// searchFlight is a search in execution that identical searches submitted
+// meanwhile wait for instead of running their own.
+type searchFlight struct {
+	done      chan struct{}
+	followers int // guarded by coalescer.mu
+
+	// Set before done is closed.
+	result []*core.Neuron
+	stats  engine.SearchStats
+	err    error
+}
+
+// coalescer tracks a worker's searches in flight. Only searches running
+// at the same time are shared; nothing is kept once a search completes.
+type coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*searchFlight
+
+	followers  atomic.Uint64 // searches answered by another's execution
+	executions atomic.Uint64 // executions shared with at least one follower
+}
+
+// coalesceKey returns the key identical searches share, or "" when req
+// must run on its own: strongly consistent and explained searches are
+// never coalesced.
+func coalesceKey(req SearchRequest) string {
+	if req.Strong || req.Explain {
+		return ""
+	}
+	req.Stats, req.Coalesced = nil, nil
+	req.Query = strings.Join(strings.Fields(req.Query), " ")
+	req.Roles = slices.Clone(req.Roles)
+	slices.Sort(req.Roles)
+	req.SpreadTypes = slices.Clone(req.SpreadTypes)
+	slices.Sort(req.SpreadTypes)
+	// Maps are encoded with sorted keys, so equal metadata encodes alike.
+	data, err := json.Marshal(req)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// submitSearch runs a search, sharing the execution of an identical one
+// already in flight. A follower gets a copy of the leader's results and
+// statistics, or its error, and does not fire the results again. A
+// follower whose request is cancelled stops waiting; the leader runs on
+// for the others.
+func (w *BrainWorker) submitSearch(key string, op *Operation) (any, error) {
+	req := op.Payload.(SearchRequest)
+
+	c := &w.coalesce
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok {
+		f.followers++
+		c.mu.Unlock()
+		c.followers.Add(1)
+
+		var cancelled <-chan struct{}
+		if op.ctx != nil {
+			cancelled = op.ctx.Done()
+		}
+		select {
+		case <-f.done:
+		case <-cancelled:
+			return nil, op.ctx.Err()
+		case <-w.ctx.Done():
+			return nil, context.Canceled
+		}
+		if f.err != nil {
+			return nil, f.err
+		}
+		if req.Stats != nil {
+			*req.Stats = f.stats
+		}
+		if req.Coalesced != nil {
+			*req.Coalesced = true
+		}
+		return slices.Clone(f.result), nil
+	}
+	f := &searchFlight{done: make(chan struct{})}
+	if c.flights == nil {
+		c.flights = make(map[string]*searchFlight)
+	}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	// The leader's statistics are kept for its followers, and its search is
+	// not cut short should its own request be cancelled meanwhile.
+	stats := req.Stats
+	req.Stats = &f.stats
+	op.Payload = req
+	if op.ctx != nil {
+		op.ctx = context.WithoutCancel(op.ctx)
+	}
+	result, err := w.submit(op)
+	if stats != nil {
+		*stats = f.stats
+	}
+
+	c.mu.Lock()
+	delete(c.flights, key)
+	if f.followers > 0 {
+		c.executions.Add(1)
+	}
+	c.mu.Unlock()
+
+	f.result, _ = result.([]*core.Neuron)
+	f.err = err
+	close(f.done)
+	return result, err
+}
+
+// CoalesceStats returns how many searches of the index were answered by
+// an identical search in flight, and how many executions were shared.
+func (w *BrainWorker) CoalesceStats() (followers, executions uint64) {
+	return w.coalesce.followers.Load(), w.coalesce.executions.Load()
+}
@@ -0,0 +1,189 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// searchResult is the outcome of one search submitted by runSearches.
+type searchResult struct {
+	neurons   []*core.Neuron
+	stats     engine.SearchStats
+	coalesced bool
+	err       error
+}
+
+// runSearches submits the searches in reqs at once while w cannot read, and
+// lets it read again once followers of them wait on a search in flight.
+func runSearches(t *testing.T, w *BrainWorker, followers uint64, reqs ...SearchRequest) []searchResult {
+	t.Helper()
+	base, _ := w.CoalesceStats()
+	w.rw.Lock()
+	results := make([]searchResult, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req SearchRequest) {
+			defer wg.Done()
+			r := &results[i]
+			req.Stats, req.Coalesced = &r.stats, &r.coalesced
+			result, err := w.Submit(&Operation{Type: OpSearch, Payload: req})
+			r.neurons, _ = result.([]*core.Neuron)
+			r.err = err
+		}(i, req)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for got, _ := w.CoalesceStats(); got-base < followers; got, _ = w.CoalesceStats() {
+		if time.Now().After(deadline) {
+			w.rw.Unlock()
+			t.Fatalf("expected %d followers, got %d", followers, got-base)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// Searches that are not followers may still be on their way.
+	time.Sleep(20 * time.Millisecond)
+	w.rw.Unlock()
+	wg.Wait()
+	return results
+}
+
+func TestCoalesceIdenticalSearches(t *testing.T) {
+	w := NewBrainWorker("test-user", newTestMatrix())
+	defer w.Stop()
+	for _, content := range []string{"the launch moved to thursday", "launch checklist for the team"} {
+		if _, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const n = 8
+	reqs := make([]SearchRequest, n)
+	for i := range reqs {
+		reqs[i] = SearchRequest{Query: "launch", Depth: 1, Limit: 5, Passive: true}
+	}
+	// Whitespace alone does not make a search different.
+	reqs[n-1].Query = "  launch "
+	results := runSearches(t, w, n-1, reqs...)
+
+	followers, executions := w.CoalesceStats()
+	if followers != n-1 || executions != 1 {
+		t.Errorf("expected %d searches to share 1 execution, got %d and %d", n-1, followers, executions)
+	}
+	coalesced := 0
+	for _, r := range results {
+		if r.err != nil {
+			t.Fatalf("search failed: %v", r.err)
+		}
+		if len(r.neurons) != 2 || len(r.neurons) != len(results[0].neurons) || r.neurons[0].ID != results[0].neurons[0].ID {
+			t.Errorf("every search should get the same results, got %d", len(r.neurons))
+		}
+		if r.stats.Results != 2 || r.stats.TopScore != results[0].stats.TopScore {
+			t.Errorf("every search should get the same statistics, got %+v", r.stats)
+		}
+		if r.coalesced {
+			coalesced++
+		}
+	}
+	if coalesced != n-1 {
+		t.Errorf("expected %d searches marked coalesced, got %d", n-1, coalesced)
+	}
+
+	stats, _ := w.Submit(&Operation{Type: OpGetStats})
+	if s := stats.(map[string]any); s["coalesced_searches"] != uint64(n-1) || s["coalesced_executions"] != uint64(1) {
+		t.Errorf("unexpected coalescing stats %v %v", s["coalesced_searches"], s["coalesced_executions"])
+	}
+
+	// Nothing is kept once the search completes.
+	r := runSearches(t, w, 0, reqs[0])[0]
+	if r.err != nil || r.coalesced {
+		t.Errorf("a later search should run on its own: %v", r.err)
+	}
+}
+
+func TestCoalesceErrorFansOut(t *testing.T) {
+	w := NewBrainWorker("test-user", newTestMatrix())
+	defer w.Stop()
+
+	req := SearchRequest{Query: "launch", Limit: 5, AnchorID: "missing"}
+	for _, r := range runSearches(t, w, 3, req, req, req, req) {
+		if !errors.Is(r.err, core.ErrNeuronNotFound) {
+			t.Errorf("every search should fail with the missing anchor, got %v", r.err)
+		}
+	}
+}
+
+func TestCoalesceDifferentSearches(t *testing.T) {
+	w := NewBrainWorker("test-user", newTestMatrix())
+	defer w.Stop()
+	if _, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "the launch moved to thursday"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	base := SearchRequest{Query: "launch", Limit: 5, Passive: true}
+	other, limit, explain := base, base, base
+	other.Query = "thursday"
+	limit.Limit = 6
+	explain.Explain = true
+	for _, r := range runSearches(t, w, 1, base, base, other, limit, explain, explain) {
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+	}
+	if followers, executions := w.CoalesceStats(); followers != 1 || executions != 1 {
+		t.Errorf("only the identical searches should be coalesced, got %d followers in %d executions", followers, executions)
+	}
+}
+
+func TestCoalesceFollowerCancel(t *testing.T) {
+	w := NewBrainWorker("test-user", newTestMatrix())
+	defer w.Stop()
+	if _, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "the launch moved to thursday"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := SearchRequest{Query: "launch", Limit: 5, Passive: true}
+	w.rw.Lock()
+	leader := make(chan error, 1)
+	go func() {
+		_, err := w.Submit(&Operation{Type: OpSearch, Payload: req})
+		leader <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w.coalesce.mu.Lock()
+		inFlight := len(w.coalesce.flights)
+		w.coalesce.mu.Unlock()
+		if inFlight == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			w.rw.Unlock()
+			t.Fatal("the leader never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	follower := make(chan error, 1)
+	go func() {
+		_, err := w.SubmitContext(ctx, &Operation{Type: OpSearch, Payload: req})
+		follower <- err
+	}()
+	for followers, _ := w.CoalesceStats(); followers == 0; followers, _ = w.CoalesceStats() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-follower; !errors.Is(err, context.Canceled) {
+		t.Errorf("a cancelled follower should stop waiting, got %v", err)
+	}
+	w.rw.Unlock()
+	if err := <-leader; err != nil {
+		t.Errorf("the leader should complete, got %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+package concurrency
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrOperationPanic is wrapped by the error of an operation that panicked.
+var ErrOperationPanic = errors.New("operation panicked")
+
+// DeadLetterOptions configures a dead-letter store.
+type DeadLetterOptions struct {
+	// Capacity is how many records the in-memory ring keeps.
+	Capacity int
+
+	// Dir, when set, receives one JSON file per record. The oldest files
+	// are removed while the directory holds more than MaxBytes.
+	Dir      string
+	MaxBytes int64
+
+	// QuarantineAfter panics of one index within QuarantineWindow
+	// quarantine it for QuarantineDuration. 0 never quarantines.
+	QuarantineAfter    int
+	QuarantineWindow   time.Duration
+	QuarantineDuration time.Duration
+}
+
+// DeadLetter is an operation that panicked in a worker.
+type DeadLetter struct {
+	ID      string       `json:"id"`
+	Time    time.Time    `json:"time"`
+	IndexID core.IndexID `json:"indexId"`
+	Op      string       `json:"op"`
+
+	// Payload summarizes the operation's payload with content and queries
+	// reduced to their length, as in an operation journal.
+	Payload string `json:"payload,omitempty"`
+	Panic   string `json:"panic"`
+	Stack   string `json:"stack"`
+
+	// TraceID is the trace of the request that submitted the operation,
+	// when tracing is enabled.
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// PanicError is the error of an operation that panicked. It wraps
+// ErrOperationPanic; Record.ID is the reference of its dead letter.
+type PanicError struct {
+	Record DeadLetter
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %s: %s (dead letter %s)", ErrOperationPanic, e.Record.Op, e.Record.Panic, e.Record.ID)
+}
+
+func (e *PanicError) Unwrap() error { return ErrOperationPanic }
+
+// QuarantineError refuses an operation of a quarantined index. It wraps
+// core.ErrIndexQuarantined.
+type QuarantineError struct {
+	Quarantine Quarantine
+}
+
+func (e *QuarantineError) Error() string {
+	return fmt.Sprintf("%s: %s until %s", core.ErrIndexQuarantined, e.Quarantine.IndexID, e.Quarantine.Until.Format(time.RFC3339))
+}
+
+func (e *QuarantineError) Unwrap() error { return core.ErrIndexQuarantined }
+
+// Quarantine is an index whose operations are refused after repeated
+// panics.
+type Quarantine struct {
+	IndexID core.IndexID `json:"indexId"`
+	Since   time.Time    `json:"since"`
+	Until   time.Time    `json:"until"`
+	Panics  int          `json:"panics"`
+}
+
+// DeadLetters keeps the operations that panicked in any worker of a pool,
+// counts them by operation type and quarantines indexes that keep
+// panicking, so a poisoned index cannot crash-loop its worker.
+type DeadLetters struct {
+	opts DeadLetterOptions
+
+	// dirMu guards opts.Dir, which Relocate changes, against the files
+	// written and read under it.
+	dirMu sync.RWMutex
+
+	mu          sync.Mutex
+	records     []DeadLetter // ring; next is the oldest once full
+	next        int
+	counts      map[string]uint64
+	panics      map[core.IndexID][]time.Time // within the quarantine window
+	quarantined map[core.IndexID]*Quarantine
+}
+
+// NewDeadLetters returns an empty store. The directory is created with the
+// first record.
+func NewDeadLetters(opts DeadLetterOptions) *DeadLetters {
+	if opts.Capacity < 1 {
+		opts.Capacity = 1
+	}
+	return &DeadLetters{
+		opts:        opts,
+		records:     make([]DeadLetter, 0, opts.Capacity),
+		counts:      make(map[string]uint64),
+		panics:      make(map[core.IndexID][]time.Time),
+		quarantined: make(map[core.IndexID]*Quarantine),
+	}
+}
+
+// Record keeps rec, assigning its ID, and quarantines rec.IndexID when it
+// has panicked often enough. It returns the stored record.
+func (d *DeadLetters) Record(rec DeadLetter) DeadLetter {
+	rec.ID = newDeadLetterID()
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	d.mu.Lock()
+	if len(d.records) < cap(d.records) {
+		d.records = append(d.records, rec)
+	} else {
+		d.records[d.next] = rec
+		d.next = (d.next + 1) % len(d.records)
+	}
+	d.counts[rec.Op]++
+	d.notePanic(rec.IndexID, rec.Time)
+	d.mu.Unlock()
+
+	if d.opts.MaxBytes > 0 {
+		if err := d.writeFile(rec); err != nil {
+			log.Printf("⚠ dead letter %s: %v", rec.ID, err)
+		}
+	}
+	return rec
+}
+
+// notePanic counts a panic of indexID at t and starts its quarantine at
+// the threshold. d.mu must be held.
+func (d *DeadLetters) notePanic(indexID core.IndexID, t time.Time) {
+	if d.opts.QuarantineAfter <= 0 {
+		return
+	}
+	recent := d.panics[indexID][:0]
+	for _, at := range d.panics[indexID] {
+		if t.Sub(at) < d.opts.QuarantineWindow {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, t)
+	if len(recent) < d.opts.QuarantineAfter {
+		d.panics[indexID] = recent
+		return
+	}
+	delete(d.panics, indexID)
+	d.quarantined[indexID] = &Quarantine{IndexID: indexID, Since: t, Until: t.Add(d.opts.QuarantineDuration), Panics: len(recent)}
+	log.Printf("🚨 index %s quarantined until %s after %d panics within %s", indexID, t.Add(d.opts.QuarantineDuration).Format(time.RFC3339), len(recent), d.opts.QuarantineWindow)
+}
+
+// writeFile writes rec under the directory and removes the oldest records
+// while the directory is over its cap. File names sort by time.
+func (d *DeadLetters) writeFile(rec DeadLetter) error {
+	d.dirMu.RLock()
+	defer d.dirMu.RUnlock()
+	if d.opts.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(d.opts.Dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%020d-%s.json", rec.Time.UnixNano(), rec.ID)
+	if err := os.WriteFile(filepath.Join(d.opts.Dir, name), data, 0600); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(d.opts.Dir)
+	if err != nil {
+		return err
+	}
+	var total int64
+	sizes := make([]int64, len(entries))
+	for i, e := range entries {
+		if info, err := e.Info(); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	// ReadDir sorts by name, so the oldest come first. The newest record
+	// is kept even when it alone is over the cap.
+	for i := 0; total > d.opts.MaxBytes && i < len(entries)-1; i++ {
+		if err := os.Remove(filepath.Join(d.opts.Dir, entries[i].Name())); err == nil {
+			total -= sizes[i]
+		}
+	}
+	return nil
+}
+
+// Records returns the retained records at or after since, newest first,
+// limited to indexID unless it is empty.
+func (d *DeadLetters) Records(since time.Time, indexID core.IndexID) []DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]DeadLetter, 0, len(d.records))
+	for _, rec := range d.records {
+		if !rec.Time.Before(since) && (indexID == "" || rec.IndexID == indexID) {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	return out
+}
+
+// Relocate moves the records on disk to dir, as a storage migration
+// moves the data directory, and writes further records there. Without a
+// directory it does nothing.
+func (d *DeadLetters) Relocate(dir string) error {
+	d.dirMu.Lock()
+	defer d.dirMu.Unlock()
+	if d.opts.Dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(d.opts.Dir); err == nil {
+		if err := persistence.MirrorTree(d.opts.Dir, dir); err != nil {
+			return err
+		}
+	}
+	d.opts.Dir = dir
+	return nil
+}
+
+// Get returns the record with the given ID, looking on disk when it has
+// left the ring.
+func (d *DeadLetters) Get(id string) (DeadLetter, bool) {
+	d.mu.Lock()
+	for _, rec := range d.records {
+		if rec.ID == id {
+			d.mu.Unlock()
+			return rec, true
+		}
+	}
+	d.mu.Unlock()
+
+	d.dirMu.RLock()
+	defer d.dirMu.RUnlock()
+	if d.opts.Dir == "" || id == "" || strings.ContainsAny(id, `/\.*?[`) {
+		return DeadLetter{}, false
+	}
+	matches, _ := filepath.Glob(filepath.Join(d.opts.Dir, "*-"+id+".json"))
+	if len(matches) == 0 {
+		return DeadLetter{}, false
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		return DeadLetter{}, false
+	}
+	var rec DeadLetter
+	if json.Unmarshal(data, &rec) != nil {
+		return DeadLetter{}, false
+	}
+	return rec, true
+}
+
+// Counts returns how many operations of each type panicked since start.
+func (d *DeadLetters) Counts() map[string]uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make(map[string]uint64, len(d.counts))
+	for op, n := range d.counts {
+		out[op] = n
+	}
+	return out
+}
+
+// Quarantined returns indexID's quarantine if it is in force at now.
+func (d *DeadLetters) Quarantined(indexID core.IndexID, now time.Time) (Quarantine, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	q, ok := d.quarantined[indexID]
+	if !ok {
+		return Quarantine{}, false
+	}
+	if !now.Before(q.Until) {
+		delete(d.quarantined, indexID)
+		return Quarantine{}, false
+	}
+	return *q, true
+}
+
+// Quarantines returns the quarantines in force at now, by index ID.
+func (d *DeadLetters) Quarantines(now time.Time) []Quarantine {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]Quarantine, 0, len(d.quarantined))
+	for id, q := range d.quarantined {
+		if !now.Before(q.Until) {
+			delete(d.quarantined, id)
+			continue
+		}
+		out = append(out, *q)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].IndexID < out[j].IndexID })
+	return out
+}
+
+func newDeadLetterID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// panicked records op, which panicked with value, as a dead letter and
+// returns the error it fails with.
+func (w *BrainWorker) panicked(op *Operation, value any, stack []byte) error {
+	rec := DeadLetter{
+		Time:    time.Now(),
+		IndexID: w.indexID,
+		Op:      op.Type.String(),
+		Payload: summarizePayload(op.Payload, false),
+		Panic:   fmt.Sprint(value),
+		Stack:   string(stack),
+	}
+	if op.ctx != nil {
+		if sc := trace.SpanContextFromContext(op.ctx); sc.HasTraceID() {
+			rec.TraceID = sc.TraceID().String()
+		}
+	}
+	if d := w.deadLetters.Load(); d != nil {
+		rec = d.Record(rec)
+	} else {
+		rec.ID = newDeadLetterID()
+	}
+	log.Printf("🚨 index %s: %s operation panicked (dead letter %s): %s", w.indexID, rec.Op, rec.ID, rec.Panic)
+	return &PanicError{Record: rec}
+}
+
+// quarantineError returns the error operations of the worker are refused
+// with while its index is quarantined, or nil.
+func (w *BrainWorker) quarantineError() error {
+	d := w.deadLetters.Load()
+	if d == nil {
+		return nil
+	}
+	q, ok := d.Quarantined(w.indexID, time.Now())
+	if !ok {
+		return nil
+	}
+	return &QuarantineError{Quarantine: q}
+}
+
+// SetDeadLetters makes the pool's workers record panicking operations in
+// d and refuse operations of quarantined indexes. nil records nothing.
+func (p *WorkerPool) SetDeadLetters(d *DeadLetters) {
+	p.deadLetters.Store(d)
+	p.ForEach(func(_ core.IndexID, w *BrainWorker) {
+		w.deadLetters.Store(d)
+	})
+}
+
+// DeadLetters returns the pool's dead-letter store, or nil.
+func (p *WorkerPool) DeadLetters() *DeadLetters {
+	return p.deadLetters.Load()
+}
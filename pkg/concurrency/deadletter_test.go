@@ -0,0 +1,110 @@
+package concurrency
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// malformedSearch is a search whose payload is not a SearchRequest, so the
+// worker panics asserting it.
+func malformedSearch() *Operation {
+	return &Operation{Type: OpSearch, Payload: "not a search request"}
+}
+
+func TestDeadLetters_PanicRecordedAndQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDeadLetters(DeadLetterOptions{
+		Capacity:           1,
+		Dir:                dir,
+		MaxBytes:           1 << 20,
+		QuarantineAfter:    2,
+		QuarantineWindow:   time.Minute,
+		QuarantineDuration: time.Minute,
+	})
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+	pool.SetDeadLetters(d)
+
+	poisoned, err := pool.GetOrCreate("poisoned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	healthy, err := pool.GetOrCreate("healthy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = poisoned.Submit(malformedSearch())
+	var pe *PanicError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrOperationPanic) {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	first := pe.Record
+	if first.ID == "" || first.IndexID != "poisoned" || first.Op != "search" || first.Payload != "string" {
+		t.Errorf("unexpected record %+v", first)
+	}
+	if !strings.Contains(first.Panic, "interface conversion") || !strings.Contains(first.Stack, "execute") {
+		t.Errorf("expected the panic value and stack, got %q\n%s", first.Panic, first.Stack)
+	}
+
+	// The worker survives and serves the next operation.
+	if _, err := poisoned.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "still alive"}}); err != nil {
+		t.Fatalf("write after a panic: %v", err)
+	}
+
+	if _, err := poisoned.Submit(malformedSearch()); !errors.Is(err, ErrOperationPanic) {
+		t.Fatalf("expected a second panic, got %v", err)
+	}
+	_, err = poisoned.Submit(&Operation{Type: OpGetStats})
+	var qe *QuarantineError
+	if !errors.As(err, &qe) || !errors.Is(err, core.ErrIndexQuarantined) || qe.Quarantine.Panics != 2 {
+		t.Fatalf("expected the index quarantined after two panics, got %v", err)
+	}
+	if _, err := healthy.Submit(&Operation{Type: OpGetStats}); err != nil {
+		t.Errorf("other indexes must be unaffected: %v", err)
+	}
+
+	if got := d.Counts()["search"]; got != 2 {
+		t.Errorf("expected 2 search panics counted, got %d", got)
+	}
+	if panics := pool.Stats()["panics"].(map[string]uint64); panics["search"] != 2 {
+		t.Errorf("expected the pool stats to count panics, got %v", panics)
+	}
+
+	// The ring keeps one record; the first is still on disk.
+	if recs := d.Records(time.Time{}, ""); len(recs) != 1 || recs[0].ID == first.ID {
+		t.Errorf("expected only the latest record in memory, got %+v", recs)
+	}
+	got, ok := d.Get(first.ID)
+	if !ok || got.Stack != first.Stack || got.IndexID != "poisoned" {
+		t.Errorf("expected the first record from disk, got %+v %v", got, ok)
+	}
+	if _, ok := d.Get("../" + first.ID); ok {
+		t.Error("a path must not be accepted as a reference")
+	}
+}
+
+func TestDeadLetters_DirectoryCapped(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDeadLetters(DeadLetterOptions{Capacity: 10, Dir: dir, MaxBytes: 1})
+	var last DeadLetter
+	for i := 0; i < 3; i++ {
+		last = d.Record(DeadLetter{IndexID: "i", Op: "search", Panic: "boom", Stack: strings.Repeat("frame\n", 50)})
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0].Name(), last.ID) {
+		t.Errorf("expected only the newest record kept past the cap, got %v", entries)
+	}
+	if len(d.Records(time.Time{}, "")) != 3 {
+		t.Error("the in-memory ring is not affected by the directory cap")
+	}
+}
@@ -0,0 +1,131 @@
+package concurrency
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Sides a divergence can be resolved to keep.
+const (
+	KeepMemory = "memory"
+	KeepDisk   = "disk"
+)
+
+// ErrNotDiverged is returned by Resolve for an index that has not diverged.
+var ErrNotDiverged = errors.New("index has not diverged")
+
+// Divergence is a loaded index whose matrix no longer matches its data on
+// disk: the data file was deleted, reset or replaced after the worker
+// loaded it. The worker keeps serving, but it is neither persisted nor
+// evicted until Resolve decides which side to keep.
+type Divergence struct {
+	IndexID          core.IndexID `json:"indexId"`
+	DetectedAt       time.Time    `json:"detectedAt"`
+	WorkerGeneration uint64       `json:"workerGeneration"`
+	DiskGeneration   uint64       `json:"diskGeneration"`
+}
+
+// checkDivergence reports whether worker, loaded for indexID, has diverged
+// from disk, quarantining the index when it is first found to.
+func (p *WorkerPool) checkDivergence(indexID core.IndexID, worker *BrainWorker) bool {
+	p.divergedMu.Lock()
+	defer p.divergedMu.Unlock()
+	if _, ok := p.diverged[indexID]; ok {
+		return true
+	}
+	disk := p.store.Generation(indexID)
+	held := worker.generation.Load()
+	if disk == held {
+		return false
+	}
+	p.diverged[indexID] = &Divergence{IndexID: indexID, DetectedAt: time.Now(), WorkerGeneration: held, DiskGeneration: disk}
+	log.Printf("🚨 index %s diverged from its data on disk (loaded at generation %d, disk at %d): it is no longer persisted until resolved with POST /admin/indexes/%s/resolve", indexID, held, disk, indexID)
+	return true
+}
+
+// CheckDivergence checks every loaded index against its data on disk and
+// returns the diverged ones. The eviction loop runs it every minute;
+// persisting an index checks it first as well.
+func (p *WorkerPool) CheckDivergence() []Divergence {
+	p.ForEach(func(id core.IndexID, w *BrainWorker) {
+		p.checkDivergence(id, w)
+	})
+	return p.Divergences()
+}
+
+// Divergences returns the quarantined indexes, by index ID.
+func (p *WorkerPool) Divergences() []Divergence {
+	p.divergedMu.Lock()
+	out := make([]Divergence, 0, len(p.diverged))
+	for _, d := range p.diverged {
+		out = append(out, *d)
+	}
+	p.divergedMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].IndexID < out[j].IndexID })
+	return out
+}
+
+// Diverged reports whether indexID is quarantined.
+func (p *WorkerPool) Diverged(indexID core.IndexID) bool {
+	p.divergedMu.Lock()
+	defer p.divergedMu.Unlock()
+	_, ok := p.diverged[indexID]
+	return ok
+}
+
+// Resolve ends indexID's quarantine. KeepMemory saves the loaded matrix
+// over whatever is on disk; KeepDisk drops the worker unsaved, so the next
+// request loads the data file, or starts empty if there is none.
+func (p *WorkerPool) Resolve(indexID core.IndexID, keep string) error {
+	if keep != KeepMemory && keep != KeepDisk {
+		return fmt.Errorf("keep must be %q or %q", KeepMemory, KeepDisk)
+	}
+	p.divergedMu.Lock()
+	defer p.divergedMu.Unlock()
+	if _, ok := p.diverged[indexID]; !ok {
+		return ErrNotDiverged
+	}
+
+	p.mu.Lock()
+	worker, ok := p.workers[indexID]
+	if ok && keep == KeepDisk {
+		delete(p.workers, indexID)
+		p.totalEvicted++
+	}
+	p.mu.Unlock()
+
+	generation := p.store.Adopt(indexID)
+	delete(p.diverged, indexID)
+	if !ok {
+		return nil
+	}
+	if keep == KeepDisk {
+		worker.Stop()
+		log.Printf("✅ index %s: divergence resolved, loaded state discarded in favour of the data on disk", indexID)
+		return nil
+	}
+	worker.generation.Store(generation)
+	if err := p.store.Save(worker.Matrix()); err != nil {
+		return err
+	}
+	log.Printf("✅ index %s: divergence resolved, loaded state saved over the data on disk", indexID)
+	return nil
+}
+
+// takeStaleLocked removes and returns indexID's worker when it holds an
+// older generation than the store, as after a reset that raced a load, so
+// the caller can stop it unsaved. p.mu must be held.
+func (p *WorkerPool) takeStaleLocked(indexID core.IndexID) *BrainWorker {
+	worker, ok := p.workers[indexID]
+	if !ok || worker.generation.Load() == p.store.Generation(indexID) {
+		return nil
+	}
+	delete(p.workers, indexID)
+	p.totalEvicted++
+	return worker
+}
@@ -0,0 +1,238 @@
+package concurrency
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// divergedPool returns a pool whose loaded index "idx" holds "in memory"
+// while its data file was replaced by one holding "from backup".
+func divergedPool(t *testing.T) (*WorkerPool, *persistence.Store) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := persistence.NewStore(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := NewWorkerPool(store, core.DefaultBounds())
+	t.Cleanup(func() { pool.Shutdown() })
+
+	// Write the backup copy through a second store in another directory.
+	backupDir := t.TempDir()
+	backup, err := persistence.NewStore(backupDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := core.NewMatrix("idx", core.DefaultBounds())
+	n := core.NewNeuron("from backup", m.CurrentDim)
+	m.Neurons[n.ID] = n
+	if err := backup.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	worker, err := pool.GetOrCreate("idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worker.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "in memory"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(backupDir, "data", "idx.nrdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "data", "idx.nrdb")
+	if err := os.WriteFile(target+".restore", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(target+".restore", target); err != nil {
+		t.Fatal(err)
+	}
+	return pool, store
+}
+
+func contents(m *core.Matrix) []string {
+	m.RLock()
+	defer m.RUnlock()
+	var out []string
+	for _, n := range m.Neurons {
+		out = append(out, n.Content)
+	}
+	return out
+}
+
+func assertOnDisk(t *testing.T, store *persistence.Store, want string) {
+	t.Helper()
+	m, err := store.Load("idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(m); len(got) != 1 || got[0] != want {
+		t.Errorf("expected %q on disk, got %v", want, got)
+	}
+}
+
+func TestDivergence_QuarantinesAfterExternalReplacement(t *testing.T) {
+	pool, store := divergedPool(t)
+
+	if err := pool.PersistAll(); err != nil {
+		t.Fatalf("PersistAll should skip a diverged index, got %v", err)
+	}
+	assertOnDisk(t, store, "from backup")
+
+	divs := pool.Divergences()
+	if len(divs) != 1 || divs[0].IndexID != "idx" || divs[0].DiskGeneration <= divs[0].WorkerGeneration {
+		t.Fatalf("expected idx quarantined, got %+v", divs)
+	}
+	if err := pool.Evict("idx"); !errors.Is(err, core.ErrIndexDiverged) {
+		t.Errorf("a diverged index should not be evicted, got %v", err)
+	}
+	if _, err := pool.PersistIndex("idx"); !errors.Is(err, core.ErrIndexDiverged) {
+		t.Errorf("a forced persist should be refused, got %v", err)
+	}
+	w, _ := pool.Get("idx")
+	if err := pool.PersistAsync("idx", w); !errors.Is(err, core.ErrIndexDiverged) {
+		t.Errorf("an async persist should be refused, got %v", err)
+	}
+	store.FlushAll()
+	assertOnDisk(t, store, "from backup")
+	if err := pool.Resolve("other", KeepDisk); !errors.Is(err, ErrNotDiverged) {
+		t.Errorf("expected ErrNotDiverged, got %v", err)
+	}
+}
+
+func TestDivergence_ResolveKeepDisk(t *testing.T) {
+	pool, store := divergedPool(t)
+	pool.CheckDivergence()
+
+	if err := pool.Resolve("idx", KeepDisk); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Diverged("idx") {
+		t.Error("the quarantine should end")
+	}
+	w, err := pool.GetOrCreate("idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(w.Matrix()); len(got) != 1 || got[0] != "from backup" {
+		t.Errorf("the next load should read the file, got %v", got)
+	}
+	if err := pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+	assertOnDisk(t, store, "from backup")
+}
+
+func TestDivergence_ResolveKeepMemory(t *testing.T) {
+	pool, store := divergedPool(t)
+	pool.CheckDivergence()
+
+	if err := pool.Resolve("idx", KeepMemory); err != nil {
+		t.Fatal(err)
+	}
+	assertOnDisk(t, store, "in memory")
+	if pool.Diverged("idx") || len(pool.CheckDivergence()) != 0 {
+		t.Error("the quarantine should end")
+	}
+	if err := pool.PersistAll(); err != nil {
+		t.Errorf("the index should persist again, got %v", err)
+	}
+}
+
+// gatedLoader reads through the store, then holds its first load until
+// released, so a reset can run between the read and the registration.
+type gatedLoader struct {
+	*persistence.Store
+	once    sync.Once
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (l *gatedLoader) Load(indexID core.IndexID) (*core.Matrix, error) {
+	m, err := l.Store.Load(indexID)
+	l.once.Do(func() {
+		close(l.read)
+		<-l.release
+	})
+	return m, err
+}
+
+func TestDivergence_ResetRacingLoadDoesNotResurrect(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	w, _ := pool.GetOrCreate("idx")
+	w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "deleted by the reset"}})
+	if err := pool.Evict("idx"); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := &gatedLoader{Store: pool.store, read: make(chan struct{}), release: make(chan struct{})}
+	pool.SetLoader(loader)
+	loaded := make(chan *BrainWorker)
+	go func() {
+		w, _ := pool.GetOrCreate("idx")
+		loaded <- w
+	}()
+	<-loader.read
+	if err := pool.Truncate("idx"); err != nil {
+		t.Fatal(err)
+	}
+	close(loader.release)
+
+	w = <-loaded
+	if got := contents(w.Matrix()); len(got) != 0 {
+		t.Fatalf("a load racing a reset must not serve the deleted state, got %v", got)
+	}
+	if err := pool.PersistAll(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := pool.store.Load("idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(m); len(got) != 0 {
+		t.Errorf("the deleted state was resurrected on disk: %v", got)
+	}
+}
+
+func TestDivergence_TruncateDropsStaleWorker(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	w, _ := pool.GetOrCreate("idx")
+	w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "old"}})
+	pool.PersistAll()
+
+	// A worker registered under the old generation after the reset
+	// removed the resident one, as a racing load would, is dropped.
+	stale := pool.newWorker("idx", w.Matrix())
+	stale.generation.Store(pool.store.Generation("idx"))
+	if err := pool.Truncate("idx"); err != nil {
+		t.Fatal(err)
+	}
+	pool.mu.Lock()
+	pool.workers["idx"] = stale
+	dropped := pool.takeStaleLocked("idx")
+	pool.mu.Unlock()
+	if dropped != stale {
+		t.Fatal("a worker older than the reset should be dropped")
+	}
+	dropped.Stop()
+	if _, err := pool.Get("idx"); err == nil {
+		t.Error("the stale worker should no longer be resident")
+	}
+}
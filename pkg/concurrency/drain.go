@@ -0,0 +1,103 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// drainPollInterval is how often WaitDrained checks the resident count.
+const drainPollInterval = 50 * time.Millisecond
+
+// drainState records whether the pool is draining. since is zero when it
+// is not.
+type drainState struct {
+	mu       sync.RWMutex
+	since    time.Time
+	rejected atomic.Uint64 // loads refused since the drain began
+}
+
+// DrainStatus reports the pool's drain mode.
+type DrainStatus struct {
+	Draining bool       `json:"draining"`
+	Since    *time.Time `json:"since,omitempty"`
+	Resident int        `json:"resident"` // indexes still loaded
+	Rejected uint64     `json:"rejected"` // requests refused for indexes not loaded
+}
+
+// Drain stops the pool from loading indexes that are not resident: from
+// now on GetOrCreate and Prefetch refuse them with core.ErrDraining, while
+// loaded workers keep serving and loads already in flight finish. It
+// returns when the drain began; draining again keeps that time.
+func (p *WorkerPool) Drain() time.Time {
+	p.drain.mu.Lock()
+	defer p.drain.mu.Unlock()
+	if p.drain.since.IsZero() {
+		p.drain.since = time.Now().UTC()
+		p.drain.rejected.Store(0)
+	}
+	return p.drain.since
+}
+
+// Undrain ends drain mode, reporting whether the pool was draining.
+func (p *WorkerPool) Undrain() bool {
+	p.drain.mu.Lock()
+	defer p.drain.mu.Unlock()
+	was := !p.drain.since.IsZero()
+	p.drain.since = time.Time{}
+	return was
+}
+
+// Draining reports whether the pool is in drain mode.
+func (p *WorkerPool) Draining() bool {
+	p.drain.mu.RLock()
+	defer p.drain.mu.RUnlock()
+	return !p.drain.since.IsZero()
+}
+
+// DrainStatus returns the pool's drain mode and what is left resident.
+func (p *WorkerPool) DrainStatus() DrainStatus {
+	p.drain.mu.RLock()
+	since := p.drain.since
+	p.drain.mu.RUnlock()
+
+	status := DrainStatus{Resident: p.ActiveCount(), Rejected: p.drain.rejected.Load()}
+	if !since.IsZero() {
+		status.Draining = true
+		status.Since = &since
+	}
+	return status
+}
+
+// refuseDraining returns core.ErrDraining, counted, when the pool is
+// draining. Callers hold createMu and have found indexID neither loaded
+// nor loading.
+func (p *WorkerPool) refuseDraining(indexID core.IndexID) error {
+	if !p.Draining() {
+		return nil
+	}
+	p.drain.rejected.Add(1)
+	return fmt.Errorf("%w: %s is not loaded on this instance", core.ErrDraining, indexID)
+}
+
+// WaitDrained waits until no index is resident or ctx is done, and reports
+// whether none is. Workers leave through idle eviction or explicit evicts;
+// draining does not evict them.
+func (p *WorkerPool) WaitDrained(ctx context.Context) bool {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		if p.ActiveCount() == 0 {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return p.ActiveCount() == 0
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package concurrency
+
+import (
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// Focus is a time-boxed metadata boost the worker applies to every search
+// and recall of its index unless the request opts out. It lives in memory
+// only: evicting the worker or the index going dormant clears it.
+type Focus struct {
+	Metadata  map[string]string `json:"metadata"`
+	Boost     float64           `json:"boost"`
+	SetAt     time.Time         `json:"setAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// SetFocus replaces the index's focus with f.
+func (w *BrainWorker) SetFocus(f Focus) {
+	w.focus.Store(&f)
+}
+
+// Focus returns the index's focus if it is active at now, clearing it once
+// it has expired.
+func (w *BrainWorker) Focus(now time.Time) (Focus, bool) {
+	f := w.focus.Load()
+	if f == nil {
+		return Focus{}, false
+	}
+	if !now.Before(f.ExpiresAt) {
+		w.focus.CompareAndSwap(f, nil)
+		return Focus{}, false
+	}
+	return *f, true
+}
+
+// ClearFocus removes the index's focus and reports whether one was active.
+func (w *BrainWorker) ClearFocus() bool {
+	f := w.focus.Swap(nil)
+	return f != nil && time.Now().Before(f.ExpiresAt)
+}
+
+// engineFocus returns the active focus for the engine, or nil when there is
+// none or ignore is set.
+func (w *BrainWorker) engineFocus(ignore bool) *engine.Focus {
+	if ignore {
+		return nil
+	}
+	f, ok := w.Focus(time.Now())
+	if !ok {
+		return nil
+	}
+	return &engine.Focus{Metadata: f.Metadata, Boost: f.Boost}
+}
@@ -0,0 +1,37 @@
+package concurrency
+
+import (
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// HealthRequest asks for the index's health score under Config (OpHealth);
+// the result is an *engine.Health, nil for an empty index. The score last
+// computed is returned while its formula version is current, so it only
+// moves when Refresh recomputes it.
+type HealthRequest struct {
+	Config  core.HealthConfig
+	Refresh bool
+}
+
+// healthScore returns the cached health score, computing it when req asks
+// for a refresh, none is cached or the formula changed since.
+func (w *BrainWorker) healthScore(req HealthRequest) *engine.Health {
+	if h := w.health.Load(); h != nil && !req.Refresh && h.FormulaVersion == req.Config.FormulaVersion() {
+		return h
+	}
+	embeddings := false
+	if w.vectorSource != nil {
+		embeddings = w.vectorSource().Embedder != nil
+	}
+	h := w.engine.Health(req.Config, embeddings, time.Now())
+	w.health.Store(h)
+	return h
+}
+
+// CachedHealth returns the health score last computed, or nil.
+func (w *BrainWorker) CachedHealth() *engine.Health {
+	return w.health.Load()
+}
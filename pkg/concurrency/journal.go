@@ -0,0 +1,314 @@
+package concurrency
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxJournalSummary caps payload and result summaries unless content is
+// recorded.
+const maxJournalSummary = 200
+
+// JournalOptions configures an operation journal.
+type JournalOptions struct {
+	// TTL is how long the journal records before it turns itself off.
+	TTL time.Duration
+
+	// Capacity is how many records the in-memory ring keeps.
+	Capacity int
+
+	// File, when set, is appended one JSON record per line. It is
+	// truncated when the journal is turned off.
+	File string
+
+	// IncludeContent records neuron content and search queries in full.
+	// Otherwise only their length is recorded.
+	IncludeContent bool
+}
+
+// JournalRecord is one operation run by a worker.
+type JournalRecord struct {
+	Seq        uint64    `json:"seq"`
+	Time       time.Time `json:"time"`
+	Op         string    `json:"op"`
+	Background bool      `json:"background,omitempty"`
+	Payload    string    `json:"payload,omitempty"`
+	Result     string    `json:"result,omitempty"`
+	DurationMs float64   `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+
+	// TraceID is the trace of the request that submitted the operation,
+	// when tracing is enabled.
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// Journal is a time-limited debug trail of the operations one worker runs:
+// a ring of the latest records, optionally mirrored to a file.
+type Journal struct {
+	opts      JournalOptions
+	startedAt time.Time
+	expiresAt time.Time
+	closed    atomic.Bool
+
+	mu      sync.Mutex
+	records []JournalRecord // ring; next is the oldest once full
+	next    int
+	seq     uint64
+	file    *os.File
+	writer  *bufio.Writer
+	timer   *time.Timer
+}
+
+// NewJournal starts a journal that closes itself after opts.TTL. An
+// existing file at opts.File is truncated.
+func NewJournal(opts JournalOptions) (*Journal, error) {
+	if opts.TTL <= 0 {
+		return nil, fmt.Errorf("journal ttl must be positive")
+	}
+	if opts.Capacity < 1 {
+		return nil, fmt.Errorf("journal capacity must be >= 1")
+	}
+	j := &Journal{
+		opts:      opts,
+		startedAt: time.Now(),
+		records:   make([]JournalRecord, 0, opts.Capacity),
+	}
+	j.expiresAt = j.startedAt.Add(opts.TTL)
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0700); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, err
+		}
+		j.file = f
+		j.writer = bufio.NewWriter(f)
+	}
+	// Close waits for the lock, so a short TTL cannot fire before timer is set.
+	j.mu.Lock()
+	j.timer = time.AfterFunc(opts.TTL, j.Close)
+	j.mu.Unlock()
+	return j, nil
+}
+
+// Active reports whether the journal is still recording.
+func (j *Journal) Active() bool {
+	return !j.closed.Load()
+}
+
+// Options returns the options the journal was started with.
+func (j *Journal) Options() JournalOptions {
+	return j.opts
+}
+
+// StartedAt returns when the journal was started.
+func (j *Journal) StartedAt() time.Time {
+	return j.startedAt
+}
+
+// ExpiresAt returns when the journal turns itself off.
+func (j *Journal) ExpiresAt() time.Time {
+	return j.expiresAt
+}
+
+// Append records rec, assigning its sequence number.
+func (j *Journal) Append(rec JournalRecord) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed.Load() {
+		return
+	}
+	j.seq++
+	rec.Seq = j.seq
+	if len(j.records) < cap(j.records) {
+		j.records = append(j.records, rec)
+	} else {
+		j.records[j.next] = rec
+		j.next = (j.next + 1) % len(j.records)
+	}
+	if j.writer != nil {
+		if line, err := json.Marshal(rec); err == nil {
+			j.writer.Write(append(line, '\n'))
+			j.writer.Flush()
+		}
+	}
+}
+
+// Records returns the retained records at or after since, oldest first.
+// A zero since returns all of them.
+func (j *Journal) Records(since time.Time) []JournalRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]JournalRecord, 0, len(j.records))
+	for i := range j.records {
+		rec := j.records[(j.next+i)%len(j.records)]
+		if !rec.Time.Before(since) {
+			out = append(out, rec)
+		}
+	}
+	// Reads record concurrently, so Seq order may differ from ring order.
+	sort.Slice(out, func(a, b int) bool { return out[a].Seq < out[b].Seq })
+	return out
+}
+
+// Close stops recording, drops the retained records and truncates the
+// file. It is safe to call more than once.
+func (j *Journal) Close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed.Swap(true) {
+		return
+	}
+	j.timer.Stop()
+	j.records = nil
+	j.next = 0
+	if j.file != nil {
+		j.file.Truncate(0)
+		j.file.Close()
+		j.file, j.writer = nil, nil
+	}
+}
+
+// journalRecord describes op, which ran from start and produced result and
+// err.
+func (j *Journal) journalRecord(op *Operation, start time.Time, result any, err error) JournalRecord {
+	rec := JournalRecord{
+		Time:       start,
+		Op:         op.Type.String(),
+		Background: op.Priority == PriorityBackground,
+		Payload:    j.summarize(summarizePayload(op.Payload, j.opts.IncludeContent)),
+		Result:     j.summarize(summarizeResult(result)),
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if op.ctx != nil {
+		if sc := trace.SpanContextFromContext(op.ctx); sc.HasTraceID() {
+			rec.TraceID = sc.TraceID().String()
+		}
+	}
+	return rec
+}
+
+// summarize truncates s to maxJournalSummary unless content is recorded.
+func (j *Journal) summarize(s string) string {
+	if j.opts.IncludeContent || len(s) <= maxJournalSummary {
+		return s
+	}
+	return s[:maxJournalSummary] + "…"
+}
+
+// summarizePayload describes an operation payload. Content and queries are
+// reduced to their length unless includeContent is set.
+func summarizePayload(payload any, includeContent bool) string {
+	text := func(name, s string) string {
+		if includeContent {
+			return fmt.Sprintf("%s=%q", name, s)
+		}
+		return fmt.Sprintf("%s=<%d bytes>", name, len(s))
+	}
+	switch p := payload.(type) {
+	case nil:
+		return ""
+	case AddNeuronRequest:
+		parts := []string{text("content", p.Content)}
+		if p.ParentID != nil {
+			parts = append(parts, "parent="+string(*p.ParentID))
+		}
+		if len(p.Metadata) > 0 {
+			parts = append(parts, "metadata="+strings.Join(sortedKeys(p.Metadata), ","))
+		}
+		if p.Pinned {
+			parts = append(parts, "pinned")
+		}
+		if len(p.Attachments) > 0 {
+			parts = append(parts, fmt.Sprintf("attachments=%d", len(p.Attachments)))
+		}
+		return strings.Join(parts, " ")
+	case WriteBatchRequest:
+		return fmt.Sprintf("items=%d", len(p.Items))
+	case SearchRequest:
+		s := fmt.Sprintf("%s depth=%d limit=%d", text("query", p.Query), p.Depth, p.Limit)
+		if len(p.Metadata) > 0 {
+			s += " metadata=" + strings.Join(sortedKeys(p.Metadata), ",")
+		}
+		if p.Strict {
+			s += " strict"
+		}
+		if p.Passive {
+			s += " passive"
+		}
+		return s
+	case UpdateNeuronRequest:
+		return fmt.Sprintf("id=%s %s", p.ID, text("content", p.Content))
+	case core.NeuronID:
+		return "id=" + string(p)
+	case ListNeuronsRequest:
+		return fmt.Sprintf("offset=%d limit=%d", p.Offset, p.Limit)
+	case SyncRequest:
+		return fmt.Sprintf("since=%d limit=%d", p.Since, p.Limit)
+	case ActivateRequest:
+		return fmt.Sprintf("ids=%d", len(p.IDs))
+	case PinRequest:
+		return fmt.Sprintf("id=%s pinned=%t", p.ID, p.Pinned)
+	case PurgeRequest:
+		return fmt.Sprintf("id=%s live=%t", p.ID, p.Live)
+	case ForgetByMetadataRequest:
+		return "metadata=" + strings.Join(sortedKeys(p.Metadata), ",")
+	case DetectConflictsRequest:
+		return "id=" + string(p.ID)
+	}
+	return fmt.Sprintf("%T", payload)
+}
+
+// summarizeResult describes an operation result without its content.
+func summarizeResult(result any) string {
+	switch r := result.(type) {
+	case *core.Neuron:
+		if r != nil {
+			return "neuron=" + string(r.ID)
+		}
+	case []*core.Neuron:
+		return fmt.Sprintf("neurons=%d", len(r))
+	case int:
+		return fmt.Sprintf("count=%d", r)
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetJournal attaches j to the worker, or detaches the current journal
+// when j is nil.
+func (w *BrainWorker) SetJournal(j *Journal) {
+	w.journal.Store(j)
+}
+
+// recordOp appends op to the worker's journal if one is active.
+func (w *BrainWorker) recordOp(op *Operation, start time.Time, result any, err error) {
+	j := w.journal.Load()
+	if j == nil || !j.Active() {
+		return
+	}
+	j.Append(j.journalRecord(op, start, result, err))
+}
@@ -0,0 +1,39 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestJournal_RingKeepsLatest(t *testing.T) {
+	j, err := NewJournal(JournalOptions{TTL: time.Minute, Capacity: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	worker := NewBrainWorker("journal-ring", core.NewMatrix("journal-ring", core.DefaultBounds()))
+	defer worker.Stop()
+	worker.SetJournal(j)
+	for i := 0; i < 5; i++ {
+		if _, err := worker.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "memory"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := j.Records(time.Time{})
+	if len(records) != 3 || records[0].Seq != 3 || records[2].Seq != 5 {
+		t.Fatalf("expected the last 3 of 5 records, got %+v", records)
+	}
+	if records[0].Op != "write" || records[0].Payload != "content=<6 bytes>" {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+
+	j.Close()
+	worker.Submit(&Operation{Type: OpGetStats})
+	if j.Active() || len(j.Records(time.Time{})) != 0 {
+		t.Error("a closed journal must drop its records and stop recording")
+	}
+}
@@ -0,0 +1,219 @@
+package concurrency
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// MatrixLoader reads persisted matrices for the pool. *persistence.Store
+// implements it.
+type MatrixLoader interface {
+	Exists(indexID core.IndexID) bool
+	Load(indexID core.IndexID) (*core.Matrix, error)
+}
+
+// loadBuckets are the upper bounds of the load-duration histogram. The
+// last bucket is open-ended.
+var loadBuckets = [...]time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// loadBucketLabels name each histogram bucket for JSON output.
+var loadBucketLabels = [...]string{"10ms", "50ms", "100ms", "500ms", "1s", "5s", "5s+"}
+
+// indexLoad is one in-flight load of an index. Every GetOrCreate for the
+// index while it runs waits on done instead of loading again.
+type indexLoad struct {
+	done   chan struct{}
+	worker *BrainWorker
+	err    error
+}
+
+// loadCounters are the pool's cumulative load counts. Every field is
+// updated with atomic ops only.
+type loadCounters struct {
+	inFlight  atomic.Int64
+	loads     atomic.Uint64 // loads started
+	failed    atomic.Uint64
+	waits     atomic.Uint64 // requests that joined a load another one started
+	timeouts  atomic.Uint64 // requests that gave up waiting
+	durations [len(loadBucketLabels)]atomic.Uint64
+}
+
+func (c *loadCounters) observe(d time.Duration) {
+	for i, upper := range loadBuckets {
+		if d <= upper {
+			c.durations[i].Add(1)
+			return
+		}
+	}
+	c.durations[len(loadBucketLabels)-1].Add(1)
+}
+
+// SetLoader replaces where the pool reads persisted matrices from. It
+// defaults to the pool's store.
+func (p *WorkerPool) SetLoader(l MatrixLoader) {
+	p.createMu.Lock()
+	defer p.createMu.Unlock()
+	p.loader = l
+}
+
+// SetLoadTimeout bounds how long GetOrCreate waits for an index to load.
+// A request that waits longer gets core.ErrIndexLoading while the load
+// carries on in the background. 0 waits for as long as the load takes.
+func (p *WorkerPool) SetLoadTimeout(d time.Duration) {
+	p.createMu.Lock()
+	defer p.createMu.Unlock()
+	p.loadTimeout = d
+}
+
+// LoadTimeout returns the timeout set with SetLoadTimeout.
+func (p *WorkerPool) LoadTimeout() time.Duration {
+	p.createMu.Lock()
+	defer p.createMu.Unlock()
+	return p.loadTimeout
+}
+
+// getOrCreate returns the worker for indexID, loading it if needed. Only
+// one load runs per index; concurrent callers wait for it, for at most
+// timeout when timeout > 0.
+func (p *WorkerPool) getOrCreate(indexID core.IndexID, timeout time.Duration) (*BrainWorker, error) {
+	// Fast path: check if exists
+	p.mu.RLock()
+	worker, ok := p.workers[indexID]
+	p.mu.RUnlock()
+
+	if ok {
+		return worker, nil
+	}
+
+	p.createMu.Lock()
+	// Double-check after acquiring lock: a load may have just finished.
+	p.mu.RLock()
+	worker, ok = p.workers[indexID]
+	p.mu.RUnlock()
+	if ok {
+		p.createMu.Unlock()
+		return worker, nil
+	}
+
+	load, joined := p.loading[indexID]
+	if joined {
+		p.loadStats.waits.Add(1)
+	} else {
+		// A draining pool lets loads in flight finish but starts none.
+		if err := p.refuseDraining(indexID); err != nil {
+			p.createMu.Unlock()
+			return nil, err
+		}
+		load = &indexLoad{done: make(chan struct{})}
+		p.loading[indexID] = load
+		go p.load(indexID, p.loader, load)
+	}
+	p.createMu.Unlock()
+
+	if timeout <= 0 {
+		<-load.done
+		return load.worker, load.err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-load.done:
+		return load.worker, load.err
+	case <-timer.C:
+		p.loadStats.timeouts.Add(1)
+		return nil, fmt.Errorf("%w: %s still loading after %s", core.ErrIndexLoading, indexID, timeout)
+	}
+}
+
+// loadAttempts bounds how often a load is repeated because the data file
+// changed while it was read.
+const loadAttempts = 3
+
+// load reads indexID through loader, or starts an empty matrix when
+// nothing usable is persisted, and registers its worker. It finishes even
+// when every caller has stopped waiting, so the next request finds the
+// worker ready. A load that raced a reset or another change of the data
+// file is repeated, so the worker never holds a state already replaced.
+func (p *WorkerPool) load(indexID core.IndexID, loader MatrixLoader, load *indexLoad) {
+	started := time.Now()
+	p.loadStats.inFlight.Add(1)
+	p.loadStats.loads.Add(1)
+
+	for attempt := 1; ; attempt++ {
+		generation := p.store.Generation(indexID)
+		worker := p.newWorker(indexID, p.readMatrix(indexID, loader))
+		worker.generation.Store(generation)
+		if p.ctx.Err() != nil {
+			// The pool shut down while loading.
+			worker.Stop()
+			load.err = fmt.Errorf("index %s: worker pool is shut down", indexID)
+			break
+		}
+
+		p.mu.Lock()
+		if attempt < loadAttempts && p.store.Generation(indexID) != generation {
+			p.mu.Unlock()
+			worker.Stop()
+			continue
+		}
+		p.workers[indexID] = worker
+		p.totalCreated++
+		p.mu.Unlock()
+		load.worker = worker
+		break
+	}
+
+	p.createMu.Lock()
+	delete(p.loading, indexID)
+	p.createMu.Unlock()
+
+	p.loadStats.observe(time.Since(started))
+	p.loadStats.inFlight.Add(-1)
+	close(load.done)
+}
+
+// readMatrix reads indexID through loader, or starts an empty matrix when
+// nothing usable is persisted.
+func (p *WorkerPool) readMatrix(indexID core.IndexID, loader MatrixLoader) *core.Matrix {
+	if loader.Exists(indexID) {
+		loaded, err := loader.Load(indexID)
+		if err == nil {
+			return loaded
+		}
+		p.loadStats.failed.Add(1)
+	}
+
+	p.mu.RLock()
+	bounds := p.bounds
+	p.mu.RUnlock()
+	return core.NewMatrix(indexID, bounds)
+}
+
+// LoadStats returns the cumulative load counters, the loads in flight and
+// the load-duration histogram.
+func (p *WorkerPool) LoadStats() map[string]any {
+	c := &p.loadStats
+	durations := make(map[string]uint64, len(loadBucketLabels))
+	for i, label := range loadBucketLabels {
+		durations[label] = c.durations[i].Load()
+	}
+	return map[string]any{
+		"in_flight":    c.inFlight.Load(),
+		"loads":        c.loads.Load(),
+		"failed":       c.failed.Load(),
+		"waits":        c.waits.Load(),
+		"timeouts":     c.timeouts.Load(),
+		"duration":     durations,
+		"load_timeout": p.LoadTimeout().String(),
+	}
+}
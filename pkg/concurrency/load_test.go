@@ -0,0 +1,120 @@
+package concurrency
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// slowLoader wraps a loader and holds every Load until release is closed,
+// like a store on a throttled disk.
+type slowLoader struct {
+	MatrixLoader
+	release chan struct{}
+	loads   atomic.Int32
+}
+
+func (l *slowLoader) Load(indexID core.IndexID) (*core.Matrix, error) {
+	l.loads.Add(1)
+	<-l.release
+	return l.MatrixLoader.Load(indexID)
+}
+
+func TestGetOrCreate_LoadsOncePerIndex(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+	persistIndex(t, pool, "cold")
+	loader := &slowLoader{MatrixLoader: pool.store, release: make(chan struct{})}
+	pool.SetLoader(loader)
+
+	const callers = 20
+	workers := make([]*BrainWorker, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w, err := pool.GetOrCreate("cold")
+			if err != nil {
+				t.Errorf("caller %d: %v", i, err)
+			}
+			workers[i] = w
+		}(i)
+	}
+	for pool.LoadStats()["waits"].(uint64) < callers-1 {
+		time.Sleep(time.Millisecond)
+	}
+	if in := pool.LoadStats()["in_flight"]; in != int64(1) {
+		t.Errorf("expected one load in flight, got %v", in)
+	}
+	close(loader.release)
+	wg.Wait()
+
+	if n := loader.loads.Load(); n != 1 {
+		t.Errorf("expected exactly one load for %d callers, got %d", callers, n)
+	}
+	for i, w := range workers {
+		if w != workers[0] || len(w.Matrix().Neurons) != 1 {
+			t.Fatalf("caller %d got a different or empty worker", i)
+		}
+	}
+	stats := pool.Stats()
+	if stats["total_created"] != uint64(1) {
+		t.Errorf("expected one worker created, got %v", stats["total_created"])
+	}
+	loads := stats["loads"].(map[string]any)
+	observed := uint64(0)
+	for _, n := range loads["duration"].(map[string]uint64) {
+		observed += n
+	}
+	if loads["loads"] != uint64(1) || loads["in_flight"] != int64(0) || observed != 1 {
+		t.Errorf("unexpected load stats: %v", loads)
+	}
+}
+
+func TestGetOrCreate_TimeoutLeavesLoadRunning(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+	persistIndex(t, pool, "cold")
+	loader := &slowLoader{MatrixLoader: pool.store, release: make(chan struct{})}
+	pool.SetLoader(loader)
+	pool.SetLoadTimeout(20 * time.Millisecond)
+
+	if _, err := pool.GetOrCreate("cold"); !errors.Is(err, core.ErrIndexLoading) {
+		t.Fatalf("expected ErrIndexLoading, got %v", err)
+	}
+	if _, err := pool.GetOrCreate("cold"); !errors.Is(err, core.ErrIndexLoading) {
+		t.Fatalf("a second caller should wait on the same load and time out, got %v", err)
+	}
+
+	close(loader.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.LoadStats()["in_flight"] != int64(0) {
+		if time.Now().After(deadline) {
+			t.Fatal("the load should finish in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	w, err := pool.GetOrCreate("cold")
+	if err != nil || len(w.Matrix().Neurons) != 1 {
+		t.Fatalf("the next request should get the loaded worker, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("the loaded worker should be served at once, took %s", elapsed)
+	}
+	if n := loader.loads.Load(); n != 1 {
+		t.Errorf("expected exactly one load, got %d", n)
+	}
+	if stats := pool.LoadStats(); stats["timeouts"] != uint64(2) || stats["waits"] != uint64(1) {
+		t.Errorf("unexpected load stats: %v", stats)
+	}
+}
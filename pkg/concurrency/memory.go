@@ -0,0 +1,64 @@
+package concurrency
+
+import (
+	"runtime"
+	"sort"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// IndexMemory is the approximate memory held by one loaded index.
+type IndexMemory struct {
+	IndexID core.IndexID `json:"indexId"`
+	Bytes   int64        `json:"bytes"`
+}
+
+// MemoryUsage returns the footprint of every loaded index, largest first.
+// It reads the matrices' counters and takes no matrix lock.
+func (p *WorkerPool) MemoryUsage() []IndexMemory {
+	p.mu.RLock()
+	usage := make([]IndexMemory, 0, len(p.workers))
+	for id, w := range p.workers {
+		usage = append(usage, IndexMemory{IndexID: id, Bytes: w.matrix.Footprint()})
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Bytes != usage[j].Bytes {
+			return usage[i].Bytes > usage[j].Bytes
+		}
+		return usage[i].IndexID < usage[j].IndexID
+	})
+	return usage
+}
+
+// PendingWritesFootprint returns how many matrices wait to be flushed and
+// their footprint. Matrices of loaded indexes are counted in MemoryUsage
+// as well.
+func (p *WorkerPool) PendingWritesFootprint() (int, int64) {
+	return p.store.PendingFootprint()
+}
+
+// MemoryStats returns the index footprints summed, the pending writes
+// and, for context, the Go runtime's heap figures.
+func (p *WorkerPool) MemoryStats() map[string]any {
+	var total int64
+	usage := p.MemoryUsage()
+	for _, u := range usage {
+		total += u.Bytes
+	}
+	pending, pendingBytes := p.PendingWritesFootprint()
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return map[string]any{
+		"indexes_bytes":        total,
+		"indexes":              len(usage),
+		"pending_writes":       pending,
+		"pending_writes_bytes": pendingBytes,
+		"runtime_heap_alloc":   ms.HeapAlloc,
+		"runtime_heap_inuse":   ms.HeapInuse,
+		"runtime_sys":          ms.Sys,
+		"runtime_num_gc":       ms.NumGC,
+	}
+}
@@ -0,0 +1,89 @@
+package concurrency
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// withinTenPercent reports whether got is within 10% of want.
+func withinTenPercent(got, want int64) bool {
+	diff := got - want
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff*10 <= want
+}
+
+func TestMemoryFootprintTracksMutations(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	w, err := pool.GetOrCreate("mem")
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	small, _ := pool.GetOrCreate("small")
+	small.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "one small memory"}})
+
+	const writes = 60
+	ids := make([]core.NeuronID, 0, writes)
+	var contentBytes int64
+	for i := 0; i < writes; i++ {
+		content := fmt.Sprintf("memory %d %s", i, strings.Repeat("lorem ipsum ", 100))
+		contentBytes += int64(len(content))
+		req := AddNeuronRequest{Content: content, Metadata: map[string]string{"source": fmt.Sprintf("doc-%d", i)}}
+		if i > 0 {
+			req.ParentID = &ids[i-1]
+		}
+		res, err := w.Submit(&Operation{Type: OpWrite, Payload: req})
+		if err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+		ids = append(ids, res.(*core.Neuron).ID)
+	}
+	for i, id := range ids[:writes/3] {
+		if _, err := w.Submit(&Operation{Type: OpForget, Payload: id}); err != nil {
+			t.Fatalf("forget %d: %v", i, err)
+		}
+	}
+	w.Submit(&Operation{Type: OpPrune})
+
+	m := w.Matrix()
+	tracked := m.Footprint()
+	if tracked < contentBytes*2/3 {
+		t.Errorf("footprint %d should cover the remaining content (%d bytes)", tracked, contentBytes*2/3)
+	}
+	m.RLock()
+	neurons := len(m.Neurons)
+	m.RUnlock()
+	if want := m.RecomputeFootprint(); !withinTenPercent(tracked, want) {
+		t.Errorf("incremental footprint %d is not within 10%% of the recomputed %d (%d neurons)", tracked, want, neurons)
+	}
+
+	usage := pool.MemoryUsage()
+	if len(usage) != 2 || usage[0].IndexID != "mem" || usage[0].Bytes != m.Footprint() {
+		t.Errorf("expected mem first by footprint, got %+v", usage)
+	}
+
+	before := m.Footprint()
+	if err := pool.Evict("mem"); err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+	reloaded, err := pool.GetOrCreate("mem")
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if after := reloaded.Matrix().Footprint(); !withinTenPercent(after, before) {
+		t.Errorf("footprint after reload %d differs from before eviction %d", after, before)
+	}
+
+	stats := pool.Stats()["memory"].(map[string]any)
+	if stats["indexes"] != 2 || stats["indexes_bytes"].(int64) < before || stats["runtime_heap_alloc"].(uint64) == 0 {
+		t.Errorf("unexpected memory stats: %v", stats)
+	}
+}
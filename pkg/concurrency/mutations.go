@@ -0,0 +1,91 @@
+package concurrency
+
+import (
+	"sync/atomic"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// MutationKind names a committed change reported to a MutationObserver.
+type MutationKind string
+
+const (
+	MutationWrite   MutationKind = "write"  // includes writes that supersede
+	MutationForget  MutationKind = "forget" // into the recycle bin, when it has a window
+	MutationRestore MutationKind = "restore"
+	MutationPurge   MutationKind = "purge"
+	MutationReset   MutationKind = "reset" // the index was truncated
+)
+
+// Mutation is a committed change to an index.
+type Mutation struct {
+	IndexID  core.IndexID
+	Kind     MutationKind
+	NeuronID core.NeuronID     // all but reset
+	Write    *AddNeuronRequest // write only
+}
+
+// MutationObserver is told about every committed mutation. Mutations of
+// one index are reported in commit order, on the index's worker goroutine,
+// so an observer that blocks holds up the index's writes.
+type MutationObserver func(Mutation)
+
+// mutationObserver holds the pool's observer; a nil pointer means none.
+type mutationObserver = atomic.Pointer[MutationObserver]
+
+// SetMutationObserver sets the observer told about committed writes,
+// forgets, restores, purges and resets, replacing any earlier one. nil
+// removes it.
+func (p *WorkerPool) SetMutationObserver(fn MutationObserver) {
+	if fn == nil {
+		p.observer.Store(nil)
+		return
+	}
+	p.observer.Store(&fn)
+}
+
+// notifyMutation passes m to the pool's observer, if any.
+func (p *WorkerPool) notifyMutation(m Mutation) {
+	if fn := p.observer.Load(); fn != nil {
+		(*fn)(m)
+	}
+}
+
+// reportMutation tells the worker's mutation sink about op if it committed
+// a change.
+func (w *BrainWorker) reportMutation(op *Operation, result any, err error) {
+	if w.mutations == nil || err != nil {
+		return
+	}
+	switch op.Type {
+	case OpWrite:
+		req := op.Payload.(AddNeuronRequest)
+		w.mutations(Mutation{IndexID: w.indexID, Kind: MutationWrite, NeuronID: result.(*core.Neuron).ID, Write: &req})
+	case OpWriteBatch:
+		items := op.Payload.(WriteBatchRequest).Items
+		for i, r := range result.([]WriteBatchResult) {
+			if r.Err == nil {
+				w.mutations(Mutation{IndexID: w.indexID, Kind: MutationWrite, NeuronID: r.Neuron.ID, Write: &items[i]})
+			}
+		}
+	case OpForget:
+		w.mutations(Mutation{IndexID: w.indexID, Kind: MutationForget, NeuronID: op.Payload.(core.NeuronID)})
+	case OpRestore:
+		w.mutations(Mutation{IndexID: w.indexID, Kind: MutationRestore, NeuronID: op.Payload.(core.NeuronID)})
+	case OpPurge:
+		w.mutations(Mutation{IndexID: w.indexID, Kind: MutationPurge, NeuronID: op.Payload.(PurgeRequest).ID})
+	case OpRetention:
+		for _, id := range result.(engine.RetentionReport).Deleted {
+			w.mutations(Mutation{IndexID: w.indexID, Kind: MutationForget, NeuronID: id})
+		}
+	case OpForgetByMetadata:
+		report := result.(engine.ForgetReport)
+		for _, id := range report.Deleted {
+			w.mutations(Mutation{IndexID: w.indexID, Kind: MutationForget, NeuronID: id})
+		}
+		for _, id := range report.Purged {
+			w.mutations(Mutation{IndexID: w.indexID, Kind: MutationPurge, NeuronID: id})
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
 )
 
 func TestOperationTypes(t *testing.T) {
@@ -13,6 +14,7 @@ func TestOperationTypes(t *testing.T) {
 		OpWrite, OpRead, OpSearch, OpTouch,
 		OpForget, OpRecall, OpFire, OpDecay,
 		OpConsolidate, OpPrune, OpReorg, OpGetStats, OpShutdown,
+		OpGraphStats, OpSync, OpDetectConflicts, OpListConflicts,
 	}
 
 	seen := make(map[OpType]bool)
@@ -271,6 +273,33 @@ func TestBrainWorkerDeleteNeuronNotFound(t *testing.T) {
 	}
 }
 
+func TestBrainWorkerGraphStats(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+
+	for _, content := range []string{"alpha", "beta", "gamma"} {
+		if _, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: content}}); err != nil {
+			t.Fatalf("OpWrite failed: %v", err)
+		}
+	}
+
+	result, err := w.Submit(&Operation{Type: OpGraphStats})
+	if err != nil {
+		t.Fatalf("OpGraphStats failed: %v", err)
+	}
+	stats := result.(engine.GraphStats)
+	if stats.Neurons != 3 {
+		t.Errorf("Expected 3 neurons, got %d", stats.Neurons)
+	}
+	if stats.Components+stats.IsolatedNeurons == 0 {
+		t.Errorf("Expected components to be counted, got %+v", stats)
+	}
+	if stats.Version != m.Version {
+		t.Errorf("Expected stats for version %d, got %d", m.Version, stats.Version)
+	}
+}
+
 func TestBrainWorkerMatrix(t *testing.T) {
 	m := core.NewMatrix("test-user", core.DefaultBounds())
 	w := NewBrainWorker("test-user", m)
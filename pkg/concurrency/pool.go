@@ -3,10 +3,13 @@ package concurrency
 import (
 	"context"
 	"fmt"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
 	"github.com/qubicDB/qubicdb/pkg/persistence"
 	"github.com/qubicDB/qubicdb/pkg/sentiment"
 	"github.com/qubicDB/qubicdb/pkg/vector"
@@ -18,20 +21,95 @@ type WorkerPool struct {
 	store   *persistence.Store
 	bounds  core.MatrixBounds
 
-	// Vector layer (shared across all workers)
-	vectorizer        *vector.Vectorizer // nil when disabled
-	vectorAlpha       float64
-	vectorQueryRepeat int
-
-	// Sentiment layer (shared across all workers)
-	sentimentAnalyzer *sentiment.Analyzer // nil when disabled
+	// Vector layer. vectorDefaults apply to every index unless
+	// vectorResolver overrides them; vectorModels holds the named models
+	// indexes may select.
+	vectorMu       sync.RWMutex
+	vectorDefaults VectorSettings
+	vectorResolver VectorResolver
+	vectorModels   *vector.ModelRegistry
+
+	// Metadata index. metadataKeys are indexed in every index, plus what
+	// metadataKeysResolver adds for it.
+	metadataKeysMu       sync.RWMutex
+	metadataKeys         []string
+	metadataKeysResolver MetadataKeysResolver
+
+	// Disk quotas. quotaDefaults apply to every index unless
+	// quotaResolver overrides them; writes are refused past quota times
+	// quotaGrace.
+	quotaMu       sync.RWMutex
+	quotaDefaults core.IndexQuota
+	quotaGrace    float64
+	quotaResolver QuotaResolver
+
+	// Sentiment layer, shared by every index sentimentResolver does not
+	// turn it off for.
+	sentimentMu       sync.RWMutex
+	sentimentAnalyzer sentiment.Analyzer // nil when disabled
+	sentimentMinASCII float64
+	sentimentResolver SentimentResolver
+
+	// appendOnlyResolver decides which indexes are append-only.
+	appendOnlyMu       sync.RWMutex
+	appendOnlyResolver AppendOnlyResolver
+
+	// similarityResolver returns each index's similarity thresholds.
+	similarityMu       sync.RWMutex
+	similarityResolver SimilarityResolver
 
 	// Worker lifecycle
-	maxIdleTime time.Duration
+	maxIdleTime     time.Duration
+	backgroundSlice time.Duration
+	readConcurrency int
+	changelogSize   int
+	recycleWindow   time.Duration
+	pins            core.PinsConfig
+	prune           core.PruneConfig
+	promotions      int
+	reinforce       core.ReinforceConfig
+	bm25            core.BM25Config
+	lexical         core.LexicalConfig
+
+	// Operation journals by index. They outlive evicted workers and are
+	// attached again when the index is loaded.
+	journalMu sync.Mutex
+	journals  map[core.IndexID]*Journal
+
+	// deadLetters records operations that panic; see SetDeadLetters.
+	deadLetters atomic.Pointer[DeadLetters]
+
+	// observer is told about committed mutations; see SetMutationObserver.
+	observer mutationObserver
+
+	// Prefetch hints. holds maps prefetched indexes to the end of their
+	// grace period against idle eviction. prefetchMu is never taken
+	// while mu is held.
+	prefetchMu        sync.Mutex
+	prefetchGrace     time.Duration
+	prefetchMaxLoaded int
+	prefetching       map[core.IndexID]bool
+	holds             map[core.IndexID]time.Time
+	prefetchStats     prefetchCounters
 
 	// Concurrency control
 	mu       sync.RWMutex
-	createMu sync.Mutex // Prevents race during worker creation
+	createMu sync.Mutex // Guards loading, loader and loadTimeout
+
+	// Index loads. loading holds the load in flight for each index, so
+	// concurrent requests share it instead of reading the file again.
+	loader      MatrixLoader
+	loadTimeout time.Duration
+	loading     map[core.IndexID]*indexLoad
+	loadStats   loadCounters
+
+	// drain refuses loads of indexes that are not resident; see Drain.
+	drain drainState
+
+	// diverged holds the quarantined indexes; see Divergence. divergedMu
+	// is taken before mu when both are held.
+	divergedMu sync.Mutex
+	diverged   map[core.IndexID]*Divergence
 
 	// Stats
 	totalCreated uint64
@@ -46,12 +124,24 @@ func NewWorkerPool(store *persistence.Store, bounds core.MatrixBounds) *WorkerPo
 	ctx, cancel := context.WithCancel(context.Background())
 
 	p := &WorkerPool{
-		workers:     make(map[core.IndexID]*BrainWorker),
-		store:       store,
-		bounds:      bounds,
-		maxIdleTime: 30 * time.Minute,
-		ctx:         ctx,
-		cancel:      cancel,
+		workers:         make(map[core.IndexID]*BrainWorker),
+		journals:        make(map[core.IndexID]*Journal),
+		prefetching:     make(map[core.IndexID]bool),
+		holds:           make(map[core.IndexID]time.Time),
+		loading:         make(map[core.IndexID]*indexLoad),
+		diverged:        make(map[core.IndexID]*Divergence),
+		loader:          store,
+		store:           store,
+		bounds:          bounds,
+		maxIdleTime:     30 * time.Minute,
+		readConcurrency: DefaultReadConcurrency,
+		pins:            core.PinsConfig{MaxPerIndex: 100, EnergyFloor: 0.5},
+		prune:           core.DefaultConfig().Daemons.Prune,
+		bm25:            core.BM25Config{K1: 1.2, B: 0.75, MaxTerms: 100000},
+		lexical:         core.LexicalConfig{FoldDiacritics: true},
+		metadataKeys:    core.DefaultConfig().Matrix.IndexedMetadataKeys,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 
 	// Start background eviction
@@ -60,59 +150,36 @@ func NewWorkerPool(store *persistence.Store, bounds core.MatrixBounds) *WorkerPo
 	return p
 }
 
-// GetOrCreate returns existing worker or creates new one
+// GetOrCreate returns existing worker or creates new one. A dormant index
+// is loaded from the store once, however many requests ask for it; see
+// SetLoadTimeout for how long they wait.
 func (p *WorkerPool) GetOrCreate(indexID core.IndexID) (*BrainWorker, error) {
-	// Fast path: check if exists
-	p.mu.RLock()
-	worker, ok := p.workers[indexID]
-	p.mu.RUnlock()
-
-	if ok {
-		return worker, nil
-	}
-
-	// Slow path: create new worker
-	p.createMu.Lock()
-	defer p.createMu.Unlock()
-
-	// Double-check after acquiring lock
-	p.mu.RLock()
-	worker, ok = p.workers[indexID]
-	p.mu.RUnlock()
-
-	if ok {
-		return worker, nil
-	}
-
-	// Try to load from persistence
-	var matrix *core.Matrix
-	if p.store.Exists(indexID) {
-		loaded, err := p.store.Load(indexID)
-		if err == nil {
-			matrix = loaded
-		}
-	}
-
-	// Create new matrix if not loaded
-	if matrix == nil {
-		matrix = core.NewMatrix(indexID, p.bounds)
-	}
-
-	// Create worker
-	worker = NewBrainWorker(indexID, matrix)
-	if p.vectorizer != nil {
-		worker.SetVectorizer(p.vectorizer, p.vectorAlpha, p.vectorQueryRepeat)
-	}
-	if p.sentimentAnalyzer != nil {
-		worker.SetSentimentAnalyzer(p.sentimentAnalyzer)
-	}
-
-	p.mu.Lock()
-	p.workers[indexID] = worker
-	p.totalCreated++
-	p.mu.Unlock()
+	return p.getOrCreate(indexID, p.LoadTimeout())
+}
 
-	return worker, nil
+// newWorker creates the worker for matrix with the pool's settings.
+func (p *WorkerPool) newWorker(indexID core.IndexID, matrix *core.Matrix) *BrainWorker {
+	worker := NewBrainWorker(indexID, matrix)
+	worker.SetVectorSource(func() VectorSettings { return p.VectorSettings(indexID) })
+	worker.SetMetadataKeysSource(func() []string { return p.IndexedMetadataKeys(indexID) })
+	worker.SetQuotaSource(func() QuotaSettings { return p.QuotaSettings(indexID) })
+	worker.SetSentimentSource(func() SentimentSettings { return p.SentimentSettings(indexID) })
+	worker.SetAppendOnlySource(func() bool { return p.AppendOnly(indexID) })
+	worker.SetSimilaritySource(func() engine.SimilarityThresholds { return p.SimilarityThresholds(indexID) })
+	worker.SetBackgroundSlice(p.backgroundSlice)
+	worker.SetReadConcurrency(p.readConcurrency)
+	worker.SetChangelogSize(p.changelogSize)
+	worker.SetRecycleWindow(p.recycleWindow)
+	worker.SetPinPolicy(p.pins.MaxPerIndex, p.pins.EnergyFloor)
+	worker.SetPrunePolicy(p.prune)
+	worker.SetPromotionHistory(p.promotions)
+	worker.SetReinforce(p.reinforce)
+	worker.SetBM25(p.bm25.K1, p.bm25.B, p.bm25.MaxTerms)
+	worker.SetLexicalFold(p.lexical)
+	worker.SetJournal(p.Journal(indexID))
+	worker.deadLetters.Store(p.deadLetters.Load())
+	worker.mutations = p.notifyMutation
+	return worker
 }
 
 // Get returns existing worker or nil
@@ -137,26 +204,91 @@ func (p *WorkerPool) ListIndexes() []string {
 	return indexes
 }
 
-// Evict removes a worker and persists its state
+// PersistedIndexes returns the IDs of all indexes with data on disk.
+func (p *WorkerPool) PersistedIndexes() []core.IndexID {
+	return p.store.ListIndexes()
+}
+
+// Persisted reports whether indexID has data on disk.
+func (p *WorkerPool) Persisted(indexID core.IndexID) bool {
+	return p.store.Exists(indexID)
+}
+
+// StartupReport returns the recovery report of the store's startup.
+func (p *WorkerPool) StartupReport() *persistence.StartupReport {
+	return p.store.StartupReport()
+}
+
+// DirVersion returns the data directory's schema stamp.
+func (p *WorkerPool) DirVersion() persistence.DirVersion {
+	return p.store.DirVersion()
+}
+
+// SetDiskMonitor makes the store check the data volume's free space
+// before large flushes and report it in write errors.
+func (p *WorkerPool) SetDiskMonitor(m *persistence.DiskMonitor) {
+	p.store.SetDiskMonitor(m)
+}
+
+// DataPath returns the data directory the store uses, which a storage
+// migration may have moved from the configured one.
+func (p *WorkerPool) DataPath() string {
+	return p.store.BasePath()
+}
+
+// MigrateStorage moves the store's data directory to target while the
+// pool keeps serving; see persistence.Store.Migrate.
+func (p *WorkerPool) MigrateStorage(ctx context.Context, target string, opts persistence.MigrationOptions) (persistence.MigrationReport, error) {
+	return p.store.Migrate(ctx, target, opts)
+}
+
+// PlanStorageMigration estimates MigrateStorage without copying anything.
+func (p *WorkerPool) PlanStorageMigration(target string, maxPasses int) (persistence.MigrationPlan, error) {
+	return p.store.PlanMigration(target, maxPasses)
+}
+
+// Evict removes a worker and persists its state. A diverged index stays
+// loaded and core.ErrIndexDiverged is returned.
 func (p *WorkerPool) Evict(indexID core.IndexID) error {
-	p.mu.Lock()
+	_, err := p.evict(indexID)
+	return err
+}
+
+// evict is Evict, also reporting whether the worker was removed.
+func (p *WorkerPool) evict(indexID core.IndexID) (bool, error) {
+	p.mu.RLock()
 	worker, ok := p.workers[indexID]
+	p.mu.RUnlock()
 	if !ok {
+		return false, nil
+	}
+	if p.checkDivergence(indexID, worker) {
+		return false, fmt.Errorf("%w: %s", core.ErrIndexDiverged, indexID)
+	}
+
+	p.mu.Lock()
+	if p.workers[indexID] != worker {
 		p.mu.Unlock()
-		return nil
+		return false, nil
 	}
 	delete(p.workers, indexID)
 	p.totalEvicted++
 	p.mu.Unlock()
 
+	p.prefetchMu.Lock()
+	delete(p.holds, indexID)
+	p.prefetchMu.Unlock()
+
 	// Stop worker
 	worker.Stop()
 
 	// Persist matrix
-	return p.store.Save(worker.Matrix())
+	return true, p.store.Save(worker.Matrix())
 }
 
-// Truncate removes an index from memory and disk without persisting the in-memory state first.
+// Truncate removes an index from memory and disk without persisting the
+// in-memory state first. A worker that loaded the old data file while the
+// truncation ran is dropped too, and any quarantine of the index ends.
 func (p *WorkerPool) Truncate(indexID core.IndexID) error {
 	p.mu.Lock()
 	worker, ok := p.workers[indexID]
@@ -170,7 +302,171 @@ func (p *WorkerPool) Truncate(indexID core.IndexID) error {
 		worker.Stop()
 	}
 
-	return p.store.Delete(indexID)
+	if err := p.store.Delete(indexID); err != nil {
+		return err
+	}
+
+	p.divergedMu.Lock()
+	delete(p.diverged, indexID)
+	p.mu.Lock()
+	stale := p.takeStaleLocked(indexID)
+	p.mu.Unlock()
+	p.divergedMu.Unlock()
+	if stale != nil {
+		stale.Stop()
+	}
+
+	p.notifyMutation(Mutation{IndexID: indexID, Kind: MutationReset})
+	return nil
+}
+
+// Clone copies the src index, from memory or disk, into dst and persists
+// the copy. transform, if non-nil, is applied to the copy before it is
+// saved. An existing dst is replaced only when it is empty or force is set;
+// otherwise core.ErrIndexNotEmpty is returned.
+func (p *WorkerPool) Clone(src, dst core.IndexID, force bool, transform func(*core.Matrix)) (*core.Matrix, error) {
+	var clone *core.Matrix
+	if worker, err := p.Get(src); err == nil {
+		if clone, err = worker.Snapshot(context.Background()); err != nil {
+			return nil, err
+		}
+	} else if p.store.Exists(src) {
+		// A freshly loaded matrix is already independent of the source.
+		if clone, err = p.store.Load(src); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, core.ErrMatrixNotFound
+	}
+
+	if _, err := p.Get(dst); err == nil || p.store.Exists(dst) {
+		target, err := p.GetOrCreate(dst)
+		if err != nil {
+			return nil, err
+		}
+		m := target.Matrix()
+		m.RLock()
+		empty := len(m.Neurons) == 0
+		m.RUnlock()
+		if !empty && !force {
+			return nil, core.ErrIndexNotEmpty
+		}
+		if err := p.Truncate(dst); err != nil {
+			return nil, err
+		}
+	}
+
+	clone.IndexID = dst
+	clone.CreatedAt = time.Now()
+	clone.ModifiedAt = clone.CreatedAt
+	if transform != nil {
+		transform(clone)
+	}
+	if err := p.store.Save(clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// ListVersions lists the persisted states of indexID, newest first.
+func (p *WorkerPool) ListVersions(indexID core.IndexID) ([]persistence.Version, error) {
+	return p.store.ListVersions(indexID)
+}
+
+// LoadVersionAt loads the newest persisted state of indexID written at or
+// before at. The returned matrix is detached from the pool, so reads on it
+// never touch the live index.
+func (p *WorkerPool) LoadVersionAt(indexID core.IndexID, at time.Time) (*core.Matrix, persistence.Version, error) {
+	v, err := p.store.VersionAt(indexID, at)
+	if err != nil {
+		return nil, persistence.Version{}, err
+	}
+	m, err := p.store.LoadVersion(indexID, v.ID)
+	if err != nil {
+		return nil, persistence.Version{}, err
+	}
+	return m, v, nil
+}
+
+// RestoreVersion replaces indexID with one of its retained versions. A
+// non-empty index is replaced only when force is set; otherwise
+// core.ErrIndexNotEmpty is returned. The replaced state is persisted first,
+// so it is itself retained as a version.
+func (p *WorkerPool) RestoreVersion(indexID core.IndexID, id string, force bool) (*core.Matrix, error) {
+	restored, err := p.store.LoadVersion(indexID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Replace(indexID, restored, force); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// Replace makes matrix, detached from any index, the state of indexID and
+// persists it, e.g. a matrix decoded from an .nrdb export. A non-empty
+// index is replaced only when force is set; otherwise core.ErrIndexNotEmpty
+// is returned. The replaced state is persisted first, so it is retained as
+// a version.
+func (p *WorkerPool) Replace(indexID core.IndexID, matrix *core.Matrix, force bool) error {
+	var version uint64
+	if _, err := p.Get(indexID); err == nil || p.store.Exists(indexID) {
+		target, err := p.GetOrCreate(indexID)
+		if err != nil {
+			return err
+		}
+		m := target.Matrix()
+		m.RLock()
+		empty := len(m.Neurons) == 0
+		version = m.Version
+		m.RUnlock()
+		if !empty && !force {
+			return core.ErrIndexNotEmpty
+		}
+		if err := p.Evict(indexID); err != nil {
+			return err
+		}
+	}
+
+	matrix.IndexID = indexID
+	matrix.Version = version + 1
+	matrix.ModifiedAt = time.Now()
+	return p.store.Save(matrix)
+}
+
+// StoreStats returns the persistence statistics of the pool's store.
+func (p *WorkerPool) StoreStats() map[string]any {
+	return p.store.Stats()
+}
+
+// FlushFailures returns the indexes whose latest flush failed.
+func (p *WorkerPool) FlushFailures() []persistence.FlushFailure {
+	return p.store.FlushFailures()
+}
+
+// PersistIndex saves indexID now, bypassing any flush backoff: the live
+// matrix when the index is loaded, else its pending flush. It reports
+// whether there was anything to save.
+func (p *WorkerPool) PersistIndex(indexID core.IndexID) (bool, error) {
+	p.mu.RLock()
+	w, ok := p.workers[indexID]
+	p.mu.RUnlock()
+	if ok {
+		if p.checkDivergence(indexID, w) {
+			return true, fmt.Errorf("%w: %s", core.ErrIndexDiverged, indexID)
+		}
+		return true, p.store.Save(w.Matrix())
+	}
+	return p.store.FlushIndex(indexID)
+}
+
+// PersistAsync queues worker's matrix for the store's next flush, unless
+// the index has diverged, in which case core.ErrIndexDiverged is returned.
+func (p *WorkerPool) PersistAsync(indexID core.IndexID, worker *BrainWorker) error {
+	if p.checkDivergence(indexID, worker) {
+		return fmt.Errorf("%w: %s", core.ErrIndexDiverged, indexID)
+	}
+	return p.store.SaveAsync(worker.Matrix())
 }
 
 // evictionLoop periodically evicts idle workers
@@ -183,13 +479,23 @@ func (p *WorkerPool) evictionLoop() {
 		case <-p.ctx.Done():
 			return
 		case <-ticker.C:
+			p.CheckDivergence()
 			p.evictIdle()
 		}
 	}
 }
 
-// evictIdle evicts workers that have been idle too long
-func (p *WorkerPool) evictIdle() {
+// EvictionReport is the outcome of WorkerPool.EvictIdle.
+type EvictionReport struct {
+	Evicted   int               // workers stopped and dropped from memory
+	Persisted int               // evicted workers whose matrix was saved
+	Failed    map[string]string // index ID → error, for workers not evicted or not saved
+}
+
+// EvictIdle evicts, persisting them, the workers idle longer than the
+// pool's idle threshold and those dormant reports true for, sparing
+// indexes in a prefetch grace period. dormant may be nil.
+func (p *WorkerPool) EvictIdle(dormant func(core.IndexID) bool) EvictionReport {
 	now := time.Now()
 	toEvict := make([]core.IndexID, 0)
 
@@ -197,28 +503,54 @@ func (p *WorkerPool) evictIdle() {
 	for id, worker := range p.workers {
 		stats := worker.Stats()
 		lastOp := stats["last_op"].(time.Time)
-		if now.Sub(lastOp) > p.maxIdleTime {
+		if now.Sub(lastOp) > p.maxIdleTime || (dormant != nil && dormant(id)) {
 			toEvict = append(toEvict, id)
 		}
 	}
 	p.mu.RUnlock()
 
+	var report EvictionReport
 	for _, id := range toEvict {
-		p.Evict(id)
+		if p.held(id, now) {
+			continue
+		}
+		removed, err := p.evict(id)
+		if removed {
+			report.Evicted++
+			if err == nil {
+				report.Persisted++
+			}
+		}
+		if err != nil {
+			if report.Failed == nil {
+				report.Failed = make(map[string]string)
+			}
+			report.Failed[string(id)] = err.Error()
+		}
 	}
+	return report
+}
+
+// evictIdle evicts workers that have been idle too long, sparing those
+// in a prefetch grace period.
+func (p *WorkerPool) evictIdle() {
+	p.EvictIdle(nil)
 }
 
-// PersistAll persists all active workers
+// PersistAll persists all active workers, except diverged ones.
 func (p *WorkerPool) PersistAll() error {
 	p.mu.RLock()
-	workers := make([]*BrainWorker, 0, len(p.workers))
-	for _, w := range p.workers {
-		workers = append(workers, w)
+	workers := make(map[core.IndexID]*BrainWorker, len(p.workers))
+	for id, w := range p.workers {
+		workers[id] = w
 	}
 	p.mu.RUnlock()
 
 	var lastErr error
-	for _, w := range workers {
+	for id, w := range workers {
+		if p.checkDivergence(id, w) {
+			continue
+		}
 		if err := p.store.Save(w.Matrix()); err != nil {
 			lastErr = err
 		}
@@ -240,16 +572,83 @@ func (p *WorkerPool) Shutdown() error {
 	p.mu.Unlock()
 
 	var lastErr error
-	for _, w := range workers {
+	for id, w := range workers {
 		w.Stop()
+		if p.checkDivergence(id, w) {
+			log.Printf("🚨 index %s: diverged state not saved at shutdown", id)
+			continue
+		}
 		if err := p.store.Save(w.Matrix()); err != nil {
 			lastErr = err
 		}
 	}
 
+	p.journalMu.Lock()
+	for id, j := range p.journals {
+		j.Close()
+		delete(p.journals, id)
+	}
+	p.journalMu.Unlock()
+
 	return lastErr
 }
 
+// StartJournal starts recording indexID's operations, replacing any
+// journal already running for it.
+func (p *WorkerPool) StartJournal(indexID core.IndexID, opts JournalOptions) (*Journal, error) {
+	j, err := NewJournal(opts)
+	if err != nil {
+		return nil, err
+	}
+	p.journalMu.Lock()
+	if old := p.journals[indexID]; old != nil {
+		old.Close()
+	}
+	p.journals[indexID] = j
+	p.journalMu.Unlock()
+
+	p.mu.RLock()
+	if w, ok := p.workers[indexID]; ok {
+		w.SetJournal(j)
+	}
+	p.mu.RUnlock()
+	return j, nil
+}
+
+// StopJournal stops indexID's journal, dropping its records and
+// truncating its file. It reports whether one was running.
+func (p *WorkerPool) StopJournal(indexID core.IndexID) bool {
+	p.journalMu.Lock()
+	j := p.journals[indexID]
+	delete(p.journals, indexID)
+	p.journalMu.Unlock()
+
+	p.mu.RLock()
+	if w, ok := p.workers[indexID]; ok {
+		w.SetJournal(nil)
+	}
+	p.mu.RUnlock()
+
+	if j == nil || !j.Active() {
+		return false
+	}
+	j.Close()
+	return true
+}
+
+// Journal returns indexID's running journal, or nil when there is none
+// or it has expired.
+func (p *WorkerPool) Journal(indexID core.IndexID) *Journal {
+	p.journalMu.Lock()
+	defer p.journalMu.Unlock()
+	j := p.journals[indexID]
+	if j != nil && !j.Active() {
+		delete(p.journals, indexID)
+		return nil
+	}
+	return j
+}
+
 // ActiveCount returns number of active workers
 func (p *WorkerPool) ActiveCount() int {
 	p.mu.RLock()
@@ -259,52 +658,198 @@ func (p *WorkerPool) ActiveCount() int {
 
 // SetVectorizer attaches a global vectorizer to the pool.
 // All existing and future workers will use it.
-func (p *WorkerPool) SetVectorizer(v *vector.Vectorizer, alpha float64) {
+func (p *WorkerPool) SetVectorizer(v vector.Embedder, alpha float64) {
 	p.SetVectorizerWithRepeat(v, alpha, 2)
 }
 
-// SetVectorizerWithRepeat attaches a global vectorizer with explicit query repeat count.
-func (p *WorkerPool) SetVectorizerWithRepeat(v *vector.Vectorizer, alpha float64, queryRepeat int) {
+// SetVectorizerWithRepeat attaches a global vectorizer with explicit query
+// repeat count. Its embeddings are tagged core.DefaultEmbeddingModel.
+func (p *WorkerPool) SetVectorizerWithRepeat(v vector.Embedder, alpha float64, queryRepeat int) {
+	p.vectorMu.Lock()
+	defer p.vectorMu.Unlock()
+	p.vectorDefaults = VectorSettings{
+		Embedder:    v,
+		Model:       core.DefaultEmbeddingModel,
+		Alpha:       alpha,
+		QueryRepeat: queryRepeat,
+	}
+}
+
+// VectorResolver returns the vector settings of indexID given the pool
+// defaults, e.g. applying per-index overrides.
+type VectorResolver func(indexID core.IndexID, defaults VectorSettings) VectorSettings
+
+// SetVectorResolver installs r to resolve each index's vector settings
+// when it writes or searches. nil uses the defaults for every index.
+func (p *WorkerPool) SetVectorResolver(r VectorResolver) {
+	p.vectorMu.Lock()
+	defer p.vectorMu.Unlock()
+	p.vectorResolver = r
+}
+
+// SetVectorModels attaches the registry of named models indexes may select.
+func (p *WorkerPool) SetVectorModels(m *vector.ModelRegistry) {
+	p.vectorMu.Lock()
+	defer p.vectorMu.Unlock()
+	p.vectorModels = m
+}
+
+// VectorModels returns the named model registry, or nil.
+func (p *WorkerPool) VectorModels() *vector.ModelRegistry {
+	p.vectorMu.RLock()
+	defer p.vectorMu.RUnlock()
+	return p.vectorModels
+}
+
+// VectorDefaults returns the vector settings used by indexes without
+// overrides.
+func (p *WorkerPool) VectorDefaults() VectorSettings {
+	p.vectorMu.RLock()
+	defer p.vectorMu.RUnlock()
+	return p.vectorDefaults
+}
+
+// VectorSettings returns the effective vector settings of indexID.
+func (p *WorkerPool) VectorSettings(indexID core.IndexID) VectorSettings {
+	p.vectorMu.RLock()
+	defaults, resolve := p.vectorDefaults, p.vectorResolver
+	p.vectorMu.RUnlock()
+	if resolve == nil {
+		return defaults
+	}
+	return resolve(indexID, defaults)
+}
+
+// MetadataKeysResolver returns the metadata keys indexID indexes given the
+// pool defaults, e.g. adding per-index keys.
+type MetadataKeysResolver func(indexID core.IndexID, defaults []string) []string
+
+// SetIndexedMetadataKeys sets the metadata keys every index keeps an
+// inverted index of; indexes rebuild theirs on their next search.
+func (p *WorkerPool) SetIndexedMetadataKeys(keys []string) {
+	p.metadataKeysMu.Lock()
+	defer p.metadataKeysMu.Unlock()
+	p.metadataKeys = keys
+}
+
+// SetMetadataKeysResolver installs r to resolve the metadata keys each
+// index indexes when it searches. nil uses the defaults for every index.
+func (p *WorkerPool) SetMetadataKeysResolver(r MetadataKeysResolver) {
+	p.metadataKeysMu.Lock()
+	defer p.metadataKeysMu.Unlock()
+	p.metadataKeysResolver = r
+}
+
+// IndexedMetadataKeys returns the metadata keys indexID indexes.
+func (p *WorkerPool) IndexedMetadataKeys(indexID core.IndexID) []string {
+	p.metadataKeysMu.RLock()
+	defaults, resolve := p.metadataKeys, p.metadataKeysResolver
+	p.metadataKeysMu.RUnlock()
+	if resolve == nil {
+		return defaults
+	}
+	return resolve(indexID, defaults)
+}
+
+// SetVectorAlpha updates the default vector alpha; indexes pick it up on
+// their next write or search unless they override it.
+func (p *WorkerPool) SetVectorAlpha(alpha float64) {
+	p.vectorMu.Lock()
+	defer p.vectorMu.Unlock()
+	p.vectorDefaults.Alpha = alpha
+}
+
+// SetMaxIdleTime updates the idle eviction threshold at runtime.
+func (p *WorkerPool) SetMaxIdleTime(d time.Duration) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.vectorizer = v
-	p.vectorAlpha = alpha
-	p.vectorQueryRepeat = queryRepeat
-	// Update existing workers
-	for _, w := range p.workers {
-		w.SetVectorizer(v, alpha, queryRepeat)
-	}
+	p.maxIdleTime = d
 }
 
-// SetSentimentAnalyzer attaches a global sentiment analyzer to the pool.
-// All existing and future workers will use it.
-func (p *WorkerPool) SetSentimentAnalyzer(a *sentiment.Analyzer) {
+// SetBackgroundSlice sets how long background operations run before yielding
+// to interactive ones, for active and future workers.
+func (p *WorkerPool) SetBackgroundSlice(d time.Duration) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.sentimentAnalyzer = a
+	p.backgroundSlice = d
 	for _, w := range p.workers {
-		w.SetSentimentAnalyzer(a)
+		w.SetBackgroundSlice(d)
 	}
 }
 
-// SetVectorAlpha updates the vector alpha used by existing and future workers.
-func (p *WorkerPool) SetVectorAlpha(alpha float64) {
+// SetReadConcurrency sets how many read operations each worker runs at once.
+// It applies to workers created afterwards.
+func (p *WorkerPool) SetReadConcurrency(n int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.vectorAlpha = alpha
-	if p.vectorizer == nil {
-		return
-	}
-	for _, w := range p.workers {
-		w.SetVectorizer(p.vectorizer, alpha, p.vectorQueryRepeat)
-	}
+	p.readConcurrency = n
 }
 
-// SetMaxIdleTime updates the idle eviction threshold at runtime.
-func (p *WorkerPool) SetMaxIdleTime(d time.Duration) {
+// SetChangelogSize sets how many neuron removals each index remembers for
+// delta sync. It applies to workers created afterwards, so call it before
+// serving traffic.
+func (p *WorkerPool) SetChangelogSize(n int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.maxIdleTime = d
+	p.changelogSize = n
+}
+
+// SetRecycleWindow sets how long forgotten neurons stay restorable in each
+// index. It applies to workers created afterwards, so call it before
+// serving traffic.
+func (p *WorkerPool) SetRecycleWindow(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recycleWindow = d
+}
+
+// SetPinPolicy sets the per-index pin cap and pinned energy floor. It
+// applies to workers created afterwards, so call it before serving traffic.
+func (p *WorkerPool) SetPinPolicy(pins core.PinsConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pins = pins
+}
+
+// SetPrunePolicy sets how synapses are scored and how many survive a prune.
+// It applies to workers created afterwards, so call it before serving
+// traffic.
+func (p *WorkerPool) SetPrunePolicy(prune core.PruneConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prune = prune
+}
+
+// SetPromotionHistory sets how many promotion records each neuron keeps. It
+// applies to workers created afterwards, so call it before serving traffic.
+func (p *WorkerPool) SetPromotionHistory(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.promotions = n
+}
+
+// SetReinforce bounds the group co-fires of reinforcing searches. It
+// applies to workers created afterwards, so call it before serving traffic.
+func (p *WorkerPool) SetReinforce(cfg core.ReinforceConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reinforce = cfg
+}
+
+// SetLexicalFold sets how lexical scoring folds case and diacritics. It
+// applies to workers created afterwards, so call it before serving traffic.
+func (p *WorkerPool) SetLexicalFold(lexical core.LexicalConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lexical = lexical
+}
+
+// SetBM25 sets the lexical scoring parameters. It applies to workers created
+// afterwards, so call it before serving traffic.
+func (p *WorkerPool) SetBM25(bm25 core.BM25Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bm25 = bm25
 }
 
 // SetMaxNeurons updates matrix capacity bounds for active and future indexes.
@@ -327,6 +872,14 @@ func (p *WorkerPool) SetMaxNeurons(max int) {
 
 // Stats returns pool statistics
 func (p *WorkerPool) Stats() map[string]any {
+	loads := p.LoadStats()
+	memory := p.MemoryStats()
+	panics, quarantined := map[string]uint64{}, 0
+	if d := p.deadLetters.Load(); d != nil {
+		panics = d.Counts()
+		quarantined = len(d.Quarantines(time.Now()))
+	}
+
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
@@ -340,7 +893,12 @@ func (p *WorkerPool) Stats() map[string]any {
 		"total_created":  p.totalCreated,
 		"total_evicted":  p.totalEvicted,
 		"max_idle_time":  p.maxIdleTime.String(),
+		"diverged":       len(p.Divergences()),
+		"panics":         panics,
+		"quarantined":    quarantined,
 		"worker_details": workerStats,
+		"loads":          loads,
+		"memory":         memory,
 	}
 }
 
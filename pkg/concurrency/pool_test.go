@@ -105,6 +105,37 @@ func TestWorkerPoolEvictNonExistent(t *testing.T) {
 	}
 }
 
+func TestWorkerPoolEvictIdle(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	for _, id := range []core.IndexID{"busy", "dormant"} {
+		if _, err := pool.GetOrCreate(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := pool.EvictIdle(func(id core.IndexID) bool { return id == "dormant" })
+	if report.Evicted != 1 || report.Persisted != 1 || len(report.Failed) != 0 {
+		t.Errorf("expected the dormant worker evicted and persisted, got %+v", report)
+	}
+	if _, err := pool.Get("busy"); err != nil {
+		t.Error("a recently used worker should stay loaded")
+	}
+	if !pool.Persisted("dormant") {
+		t.Error("the evicted worker should be on disk")
+	}
+
+	pool.SetMaxIdleTime(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if report := pool.EvictIdle(nil); report.Evicted != 1 || report.Persisted != 1 {
+		t.Errorf("expected the idle worker evicted, got %+v", report)
+	}
+	if _, err := pool.Get("busy"); err == nil {
+		t.Error("the idle worker should be evicted")
+	}
+}
+
 func TestWorkerPoolForEach(t *testing.T) {
 	pool, tmpDir := setupTestPool(t)
 	defer os.RemoveAll(tmpDir)
@@ -263,3 +294,54 @@ func TestWorkerPoolReloadFromPersistence(t *testing.T) {
 		t.Log("Note: Persistence reload depends on store implementation")
 	}
 }
+
+func TestWorkerPoolMutationObserver(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	var mu sync.Mutex
+	var seen []Mutation
+	pool.SetMutationObserver(func(m Mutation) {
+		mu.Lock()
+		seen = append(seen, m)
+		mu.Unlock()
+	})
+
+	worker, _ := pool.GetOrCreate("user-1")
+	result, err := worker.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "Observed"}})
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	n := result.(*core.Neuron)
+	worker.Submit(&Operation{Type: OpRead, Payload: n.ID})
+	worker.Submit(&Operation{Type: OpForget, Payload: n.ID})
+	worker.Submit(&Operation{Type: OpForget, Payload: n.ID}) // already gone
+	if err := pool.Truncate("user-1"); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 3 {
+		t.Fatalf("expected write, forget and reset, got %+v", seen)
+	}
+	if seen[0].Kind != MutationWrite || seen[0].NeuronID != n.ID || seen[0].Write.Content != "Observed" {
+		t.Errorf("unexpected write mutation: %+v", seen[0])
+	}
+	if seen[1].Kind != MutationForget || seen[1].NeuronID != n.ID {
+		t.Errorf("unexpected forget mutation: %+v", seen[1])
+	}
+	if seen[2].Kind != MutationReset || seen[2].IndexID != "user-1" {
+		t.Errorf("unexpected reset mutation: %+v", seen[2])
+	}
+
+	pool.SetMutationObserver(nil)
+	mu.Unlock()
+	worker, _ = pool.GetOrCreate("user-1")
+	worker.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "Unobserved"}})
+	mu.Lock()
+	if len(seen) != 3 {
+		t.Errorf("a removed observer should not be called, got %+v", seen)
+	}
+}
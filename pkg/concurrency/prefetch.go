@@ -0,0 +1,150 @@
+package concurrency
+
+import (
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// PrefetchStatus is the outcome of a prefetch hint for one index.
+type PrefetchStatus string
+
+const (
+	// PrefetchAlreadyLoaded means the index already has a worker.
+	PrefetchAlreadyLoaded PrefetchStatus = "already_loaded"
+	// PrefetchLoading means an earlier prefetch is still loading the index.
+	PrefetchLoading PrefetchStatus = "loading"
+	// PrefetchQueued means a load was started for this hint.
+	PrefetchQueued PrefetchStatus = "queued"
+	// PrefetchRejected means the index will not be loaded; Reason says why.
+	PrefetchRejected PrefetchStatus = "rejected"
+)
+
+// Reasons a prefetch is rejected by the pool.
+const (
+	PrefetchReasonNotFound = "not_found" // nothing persisted to load
+	PrefetchReasonBudget   = "budget"    // the loaded-index budget is spent
+	PrefetchReasonDraining = "draining"  // the pool loads no new indexes
+)
+
+// PrefetchResult reports what a prefetch hint did for one index.
+type PrefetchResult struct {
+	IndexID core.IndexID   `json:"index"`
+	Status  PrefetchStatus `json:"status"`
+	Reason  string         `json:"reason,omitempty"`
+}
+
+// prefetchCounters are the pool's cumulative prefetch counts.
+type prefetchCounters struct {
+	requested     uint64
+	alreadyLoaded uint64
+	loading       uint64
+	queued        uint64
+	loaded        uint64
+	failed        uint64
+	rejected      uint64
+}
+
+// SetPrefetchPolicy sets how long a prefetched worker is protected from
+// idle eviction and how many workers may be loaded before prefetches are
+// rejected (0 for no budget).
+func (p *WorkerPool) SetPrefetchPolicy(grace time.Duration, maxLoaded int) {
+	p.prefetchMu.Lock()
+	defer p.prefetchMu.Unlock()
+	p.prefetchGrace = grace
+	p.prefetchMaxLoaded = maxLoaded
+}
+
+// Prefetch starts loading indexID in the background and returns at once.
+// It never creates an empty index and never evicts a worker to make room:
+// once the budget is spent, further hints are rejected. A loaded worker is
+// held against idle eviction for the grace period but is not otherwise
+// marked as used.
+func (p *WorkerPool) Prefetch(indexID core.IndexID) PrefetchResult {
+	result := PrefetchResult{IndexID: indexID}
+
+	p.prefetchMu.Lock()
+	defer p.prefetchMu.Unlock()
+	p.prefetchStats.requested++
+
+	p.mu.RLock()
+	_, loaded := p.workers[indexID]
+	active := len(p.workers)
+	p.mu.RUnlock()
+
+	switch {
+	case loaded:
+		p.prefetchStats.alreadyLoaded++
+		result.Status = PrefetchAlreadyLoaded
+	case p.prefetching[indexID]:
+		p.prefetchStats.loading++
+		result.Status = PrefetchLoading
+	case p.Draining():
+		p.prefetchStats.rejected++
+		result.Status, result.Reason = PrefetchRejected, PrefetchReasonDraining
+	case !p.store.Exists(indexID):
+		p.prefetchStats.rejected++
+		result.Status, result.Reason = PrefetchRejected, PrefetchReasonNotFound
+	case p.prefetchMaxLoaded > 0 && active+len(p.prefetching) >= p.prefetchMaxLoaded:
+		p.prefetchStats.rejected++
+		result.Status, result.Reason = PrefetchRejected, PrefetchReasonBudget
+	default:
+		p.prefetchStats.queued++
+		p.prefetching[indexID] = true
+		result.Status = PrefetchQueued
+		go p.prefetch(indexID)
+	}
+	return result
+}
+
+// prefetch loads indexID and holds it for the grace period. It waits out
+// slow loads rather than giving up after the load timeout.
+func (p *WorkerPool) prefetch(indexID core.IndexID) {
+	_, err := p.getOrCreate(indexID, 0)
+
+	p.prefetchMu.Lock()
+	delete(p.prefetching, indexID)
+	if err != nil {
+		p.prefetchStats.failed++
+	} else {
+		p.prefetchStats.loaded++
+		if p.prefetchGrace > 0 {
+			p.holds[indexID] = time.Now().Add(p.prefetchGrace)
+		}
+	}
+	p.prefetchMu.Unlock()
+}
+
+// held reports whether a prefetch grace period protects indexID, dropping
+// expired holds.
+func (p *WorkerPool) held(indexID core.IndexID, now time.Time) bool {
+	p.prefetchMu.Lock()
+	defer p.prefetchMu.Unlock()
+	until, ok := p.holds[indexID]
+	if ok && !now.Before(until) {
+		delete(p.holds, indexID)
+		return false
+	}
+	return ok
+}
+
+// PrefetchStats returns the cumulative prefetch counters and the number
+// of loads in flight.
+func (p *WorkerPool) PrefetchStats() map[string]any {
+	p.prefetchMu.Lock()
+	defer p.prefetchMu.Unlock()
+	c := p.prefetchStats
+	return map[string]any{
+		"requested":      c.requested,
+		"already_loaded": c.alreadyLoaded,
+		"loading":        c.loading,
+		"queued":         c.queued,
+		"loaded":         c.loaded,
+		"failed":         c.failed,
+		"rejected":       c.rejected,
+		"in_flight":      len(p.prefetching),
+		"held":           len(p.holds),
+		"grace":          p.prefetchGrace.String(),
+		"max_loaded":     p.prefetchMaxLoaded,
+	}
+}
@@ -0,0 +1,131 @@
+package concurrency
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// persistIndex writes a small index to the pool's store without loading it.
+func persistIndex(t *testing.T, pool *WorkerPool, indexID core.IndexID) {
+	t.Helper()
+	m := core.NewMatrix(indexID, core.DefaultBounds())
+	n := core.NewNeuron("prefetched memory", m.CurrentDim)
+	m.Neurons[n.ID] = n
+	m.Adjacency[n.ID] = []core.NeuronID{}
+	if err := pool.store.Save(m); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitPrefetched waits until the pool has finished n prefetch loads.
+func waitPrefetched(t *testing.T, pool *WorkerPool, n uint64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.PrefetchStats()["loaded"].(uint64) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d prefetch loads: %v", n, pool.PrefetchStats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPrefetch_LoadsInBackground(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+	persistIndex(t, pool, "cold")
+
+	if r := pool.Prefetch("cold"); r.Status != PrefetchQueued {
+		t.Fatalf("expected queued, got %+v", r)
+	}
+	waitPrefetched(t, pool, 1)
+
+	worker, err := pool.Get("cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(worker.Matrix().Neurons) != 1 {
+		t.Errorf("the persisted neuron should be loaded")
+	}
+
+	if r := pool.Prefetch("cold"); r.Status != PrefetchAlreadyLoaded {
+		t.Errorf("expected already_loaded, got %+v", r)
+	}
+	if r := pool.Prefetch("missing"); r.Status != PrefetchRejected || r.Reason != PrefetchReasonNotFound {
+		t.Errorf("expected not_found rejection, got %+v", r)
+	}
+	if _, err := pool.Get("missing"); err == nil {
+		t.Error("prefetching a missing index should not create it")
+	}
+
+	stats := pool.PrefetchStats()
+	if stats["requested"] != uint64(3) || stats["queued"] != uint64(1) || stats["already_loaded"] != uint64(1) || stats["rejected"] != uint64(1) {
+		t.Errorf("unexpected counters: %v", stats)
+	}
+}
+
+func TestPrefetch_GracePreventsIdleEviction(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+	pool.SetPrefetchPolicy(time.Hour, 0)
+	persistIndex(t, pool, "cold")
+
+	pool.Prefetch("cold")
+	waitPrefetched(t, pool, 1)
+	if _, err := pool.GetOrCreate("other"); err != nil {
+		t.Fatal(err)
+	}
+
+	pool.SetMaxIdleTime(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	pool.evictIdle()
+	if _, err := pool.Get("cold"); err != nil {
+		t.Error("a prefetched index should survive idle eviction during its grace period")
+	}
+	if _, err := pool.Get("other"); err == nil {
+		t.Error("an idle index without a hold should be evicted")
+	}
+
+	pool.prefetchMu.Lock()
+	pool.holds["cold"] = time.Now().Add(-time.Second)
+	pool.prefetchMu.Unlock()
+	pool.evictIdle()
+	if _, err := pool.Get("cold"); err == nil {
+		t.Error("the index should be evicted once its grace period ends")
+	}
+}
+
+func TestPrefetch_BudgetRejectsWithoutEvicting(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+	pool.SetPrefetchPolicy(time.Minute, 2)
+	persistIndex(t, pool, "cold")
+
+	for _, id := range []core.IndexID{"hot-1", "hot-2"} {
+		if _, err := pool.GetOrCreate(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := pool.Prefetch("cold")
+	if r.Status != PrefetchRejected || r.Reason != PrefetchReasonBudget {
+		t.Fatalf("expected a budget rejection, got %+v", r)
+	}
+	if pool.ActiveCount() != 2 {
+		t.Errorf("hot workers should stay loaded, got %v", pool.ListIndexes())
+	}
+	if _, err := pool.Get("cold"); err == nil {
+		t.Error("a rejected prefetch should not load the index")
+	}
+
+	pool.Evict("hot-2")
+	if r := pool.Prefetch("cold"); r.Status != PrefetchQueued {
+		t.Errorf("expected the prefetch to fit the budget again, got %+v", r)
+	}
+	waitPrefetched(t, pool, 1)
+}
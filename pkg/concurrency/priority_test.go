@@ -0,0 +1,106 @@
+package concurrency
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBrainWorkerInteractiveYieldsBackground(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+
+	for i := 0; i < 200; i++ {
+		w.Submit(&Operation{
+			Type:    OpWrite,
+			Payload: AddNeuronRequest{Content: fmt.Sprintf("memory number %d", i)},
+		})
+	}
+
+	// Each item of the background pass takes 2ms, so the pass takes 400ms+.
+	started := make(chan struct{})
+	var once sync.Once
+	w.itemHook = func() {
+		once.Do(func() { close(started) })
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.Submit(&Operation{Type: OpConsolidate, Priority: PriorityBackground})
+		close(done)
+	}()
+	<-started
+
+	begin := time.Now()
+	if _, err := w.Submit(&Operation{
+		Type:    OpSearch,
+		Payload: SearchRequest{Query: "memory", Depth: 1, Limit: 5},
+	}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	waited := time.Since(begin)
+
+	// Bound: one 10ms slice plus one 2ms item, with slack for slow CI.
+	if waited > 150*time.Millisecond {
+		t.Errorf("interactive search waited %v behind background work", waited)
+	}
+	select {
+	case <-done:
+		t.Error("background pass should still be running when the search returns")
+	default:
+	}
+	<-done
+}
+
+func TestBrainWorkerInteractiveJumpsBackgroundQueue(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+	w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "seed"}})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	w.itemHook = func() {
+		once.Do(func() { close(started) })
+		<-release
+	}
+
+	w.SubmitAsync(&Operation{Type: OpDecay, Priority: PriorityBackground})
+	<-started
+
+	for i := 0; i < 3; i++ {
+		w.SubmitAsync(&Operation{Type: OpDecay, Priority: PriorityBackground})
+	}
+	// Strong reads wait in the interactive queue rather than the read queue.
+	search := &Operation{
+		Type:    OpSearch,
+		Payload: SearchRequest{Query: "seed", Depth: 1, Limit: 5},
+		Strong:  true,
+		Result:  make(chan any, 1),
+		Error:   make(chan error, 1),
+	}
+	w.SubmitAsync(search)
+	w.SubmitAsync(&Operation{Type: OpGetStats, Strong: true})
+
+	stats := w.Stats()
+	if stats["queue_interactive"] != 2 || stats["queue_background"] != 3 {
+		t.Fatalf("expected 2 interactive and 3 background queued, got %v / %v",
+			stats["queue_interactive"], stats["queue_background"])
+	}
+	if stats["queue_length"] != 5 {
+		t.Errorf("expected queue_length 5, got %v", stats["queue_length"])
+	}
+
+	w.itemHook = nil
+	close(release)
+
+	select {
+	case <-search.Result:
+	case <-time.After(time.Second):
+		t.Fatal("queued interactive operation never ran")
+	}
+}
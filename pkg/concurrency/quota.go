@@ -0,0 +1,154 @@
+package concurrency
+
+import (
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// QuotaSettings is what a worker checks writes against: the index's disk
+// quota, the factor of it writes are refused at, and the index's usage as
+// the store last measured it.
+type QuotaSettings struct {
+	Quota core.IndexQuota
+	Grace float64
+	Usage persistence.DiskUsage
+}
+
+// QuotaResolver returns the disk quota of indexID given the pool default,
+// e.g. from a per-index override.
+type QuotaResolver func(indexID core.IndexID, defaults core.IndexQuota) core.IndexQuota
+
+// SetQuota sets the disk quota every index gets unless the resolver
+// overrides it, and the grace factor writes are refused at. Writes pick it
+// up immediately.
+func (p *WorkerPool) SetQuota(defaults core.IndexQuota, grace float64) {
+	p.quotaMu.Lock()
+	defer p.quotaMu.Unlock()
+	p.quotaDefaults = defaults
+	p.quotaGrace = grace
+}
+
+// SetQuotaResolver installs r to resolve each index's disk quota. nil uses
+// the defaults for every index.
+func (p *WorkerPool) SetQuotaResolver(r QuotaResolver) {
+	p.quotaMu.Lock()
+	defer p.quotaMu.Unlock()
+	p.quotaResolver = r
+}
+
+// IndexQuota returns the effective disk quota of indexID.
+func (p *WorkerPool) IndexQuota(indexID core.IndexID) core.IndexQuota {
+	p.quotaMu.RLock()
+	defaults, resolve := p.quotaDefaults, p.quotaResolver
+	p.quotaMu.RUnlock()
+	if resolve == nil {
+		return defaults
+	}
+	return resolve(indexID, defaults)
+}
+
+// DiskUsage returns the disk usage of indexID as the store last measured
+// it.
+func (p *WorkerPool) DiskUsage(indexID core.IndexID) persistence.DiskUsage {
+	if p.store == nil {
+		return persistence.DiskUsage{}
+	}
+	return p.store.Usage(indexID)
+}
+
+// QuotaSettings returns the quota, grace and usage writes to indexID are
+// checked against.
+func (p *WorkerPool) QuotaSettings(indexID core.IndexID) QuotaSettings {
+	p.quotaMu.RLock()
+	grace := p.quotaGrace
+	p.quotaMu.RUnlock()
+	return QuotaSettings{Quota: p.IndexQuota(indexID), Grace: grace, Usage: p.DiskUsage(indexID)}
+}
+
+// SetQuotaSource sets the function the worker asks for the index's quota
+// before each write. Call before the worker serves operations.
+func (w *BrainWorker) SetQuotaSource(fn func() QuotaSettings) {
+	w.quotaSource = fn
+}
+
+// enforceQuota checks that req fits the index's disk quota. The size
+// after the write is projected from the store's last measurement, scaled
+// by how the matrix footprint changed since, plus the footprint of the new
+// neuron. Past quota times grace the write is refused with a
+// *core.QuotaError, unless the policy is evict_lowest_energy and forgetting
+// the weakest unpinned neurons makes room; their IDs are returned.
+func (w *BrainWorker) enforceQuota(req AddNeuronRequest) ([]core.NeuronID, error) {
+	if w.quotaSource == nil {
+		return nil, nil
+	}
+	q := w.quotaSource()
+	if q.Quota.Bytes <= 0 {
+		return nil, nil
+	}
+	grace := q.Grace
+	if grace < 1 {
+		grace = 1
+	}
+	limit := int64(float64(q.Quota.Bytes) * grace)
+
+	incoming := incomingFootprint(req)
+	w.matrix.RLock()
+	if count := len(w.matrix.Neurons); count > 0 {
+		// The average neuron carries its share of embeddings and synapses,
+		// which the bare request does not show.
+		incoming = max(incoming, w.matrix.Footprint()/int64(count))
+	}
+	w.matrix.RUnlock()
+
+	// project estimates the bytes on disk for a matrix footprint.
+	ratio := 1.0
+	if q.Usage.Footprint > 0 {
+		ratio = float64(q.Usage.DataBytes) / float64(q.Usage.Footprint)
+	}
+	project := func(footprint int64) int64 {
+		return int64(float64(footprint)*ratio) + q.Usage.WALBytes + q.Usage.AttachmentBytes
+	}
+	current := w.matrix.Footprint()
+	projected := project(current + incoming)
+	if projected <= limit {
+		return nil, nil
+	}
+	refuse := &core.QuotaError{IndexID: w.indexID, Usage: project(current), Projected: projected, Quota: q.Quota.Bytes}
+	// An append-only index refuses the write rather than forget anything.
+	if q.Quota.Policy != core.QuotaEvictLowestEnergy || w.AppendOnly() {
+		return nil, refuse
+	}
+
+	// Plan the evictions first, so a write that cannot fit forgets nothing.
+	var evict []core.NeuronID
+	freed := int64(0)
+	for _, c := range w.engine.EvictionOrder() {
+		if project(current+incoming-freed) <= limit {
+			break
+		}
+		if req.Supersedes != nil && c.ID == *req.Supersedes {
+			continue
+		}
+		evict = append(evict, c.ID)
+		freed += c.Footprint
+	}
+	if project(current+incoming-freed) > limit {
+		return nil, refuse
+	}
+	for _, id := range evict {
+		if err := w.engine.DeleteNeuron(id); err != nil {
+			return nil, err
+		}
+	}
+	return evict, nil
+}
+
+// incomingFootprint returns the footprint of the neuron req creates,
+// before embedding.
+func incomingFootprint(req AddNeuronRequest) int64 {
+	n := &core.Neuron{Content: req.Content, Attachments: req.Attachments, Metadata: make(map[string]any, len(req.Metadata))}
+	for k, v := range req.Metadata {
+		n.Metadata[k] = v
+	}
+	return core.NeuronFootprint(n)
+}
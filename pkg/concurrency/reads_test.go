@@ -0,0 +1,231 @@
+package concurrency
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+func TestBrainWorkerReadsSkipQueuedWrites(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+
+	w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "seed memory"}})
+	for i := 0; i < 900; i++ {
+		w.SubmitAsync(&Operation{
+			Type:    OpWrite,
+			Payload: AddNeuronRequest{Content: fmt.Sprintf("bulk memory number %d about topic %d", i, i%17)},
+		})
+	}
+
+	if _, err := w.Submit(&Operation{
+		Type:    OpSearch,
+		Payload: SearchRequest{Query: "seed", Depth: 1, Limit: 5},
+	}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if queued := w.Stats()["queue_write"].(int); queued == 0 {
+		t.Error("search should return while bulk writes are still queued")
+	}
+}
+
+func TestBrainWorkerReadsReturnCopies(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+
+	result, err := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "copied memory"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := result.(*core.Neuron)
+
+	read, err := w.Submit(&Operation{Type: OpRead, Payload: live.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := w.Submit(&Operation{Type: OpSearch, Payload: SearchRequest{Query: "copied", Depth: 1, Limit: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := w.Submit(&Operation{Type: OpRecall, Payload: ListNeuronsRequest{Limit: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []*core.Neuron{read.(*core.Neuron)}
+	got = append(got, found.([]*core.Neuron)...)
+	got = append(got, listed.([]*core.Neuron)...)
+	if len(got) != 3 {
+		t.Fatalf("expected the neuron from each read, got %d", len(got))
+	}
+	for _, n := range got {
+		if n == live || n.ID != live.ID || n.Content != live.Content {
+			t.Errorf("expected a copy of %s, got %p (live %p)", live.ID, n, live)
+		}
+	}
+}
+
+func TestBrainWorkerStrongReadWaitsForWrites(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+
+	for i := 0; i < 200; i++ {
+		w.SubmitAsync(&Operation{
+			Type:    OpWrite,
+			Payload: AddNeuronRequest{Content: fmt.Sprintf("queued memory %d", i)},
+		})
+	}
+	result, err := w.Submit(&Operation{
+		Type:    OpRecall,
+		Payload: ListNeuronsRequest{Limit: 1000},
+		Strong:  true,
+	})
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	if n := len(result.([]*core.Neuron)); n != 200 {
+		t.Errorf("strong recall should see all 200 queued writes, got %d", n)
+	}
+}
+
+func TestBrainWorkerConcurrentReadActivation(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	defer w.Stop()
+
+	result, _ := w.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "activated memory"}})
+	id := result.(*core.Neuron).ID
+	before := result.(*core.Neuron).AccessCount
+
+	if _, err := w.Submit(&Operation{Type: OpRead, Payload: id}); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	// The read's activation is queued before the strong read, which fires
+	// the neuron again.
+	result, err := w.Submit(&Operation{Type: OpRead, Payload: id, Strong: true})
+	if err != nil {
+		t.Fatalf("strong Read failed: %v", err)
+	}
+	if got := result.(*core.Neuron).AccessCount - before; got != 2 {
+		t.Errorf("expected both reads to fire the neuron, got %d fires", got)
+	}
+}
+
+func TestBrainWorkerMixedWorkload(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	w.SetReadConcurrency(3)
+	defer w.Stop()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				w.Submit(&Operation{
+					Type:    OpWrite,
+					Payload: AddNeuronRequest{Content: fmt.Sprintf("writer %d memory %d", g, i)},
+				})
+			}
+			w.Submit(&Operation{Type: OpDecay, Priority: PriorityBackground})
+		}(g)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				switch i % 4 {
+				case 0:
+					w.Submit(&Operation{Type: OpSearch, Payload: SearchRequest{Query: "memory", Depth: 1, Limit: 5}})
+				case 1:
+					w.Submit(&Operation{Type: OpRecall, Payload: ListNeuronsRequest{Limit: 10}})
+				case 2:
+					w.Submit(&Operation{Type: OpGetStats})
+				default:
+					w.Submit(&Operation{Type: OpGraphStats, Strong: g%2 == 0})
+				}
+			}
+		}(g)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("mixed workload did not finish")
+	}
+
+	result, _ := w.Submit(&Operation{Type: OpRecall, Payload: ListNeuronsRequest{Limit: 1000}, Strong: true})
+	if n := len(result.([]*core.Neuron)); n != 200 {
+		t.Errorf("expected 200 neurons after the workload, got %d", n)
+	}
+	if stats := w.Stats(); stats["read_concurrency"] != 3 || stats["reads_active"] != int64(0) {
+		t.Errorf("unexpected read stats: %v", stats)
+	}
+}
+
+// TestBrainWorkerGraphDumpsDuringWrites serializes graph, synapse and
+// activity dumps while writes and daemon cycles change the matrix; run
+// with -race.
+func TestBrainWorkerGraphDumpsDuringWrites(t *testing.T) {
+	m := newTestMatrix()
+	w := NewBrainWorker("test-user", m)
+	w.SetReadConcurrency(3)
+	defer w.Stop()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 3; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 60; i++ {
+				w.Submit(&Operation{
+					Type:    OpWrite,
+					Payload: AddNeuronRequest{Content: fmt.Sprintf("writer %d memory %d about topic %d", g, i, i%5)},
+				})
+				if i%15 == 0 {
+					for _, op := range []OpType{OpDecay, OpConsolidate, OpPrune, OpReorg} {
+						w.Submit(&Operation{Type: op, Priority: PriorityBackground})
+					}
+				}
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 60; i++ {
+				op := []OpType{OpGetGraph, OpGetSynapses, OpGetActivity}[i%3]
+				result, err := w.Submit(&Operation{Type: op})
+				if err != nil {
+					t.Errorf("%s failed: %v", op, err)
+					return
+				}
+				if _, err := json.Marshal(result); err != nil {
+					t.Errorf("%s result does not serialize: %v", op, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	result, err := w.Submit(&Operation{Type: OpGetGraph, Strong: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := result.(engine.GraphDump)
+	m.RLock()
+	neurons, synapses := len(m.Neurons), len(m.Synapses)
+	m.RUnlock()
+	if len(graph.Nodes) != neurons || len(graph.Edges) != synapses {
+		t.Errorf("dump has %d nodes and %d edges, matrix %d and %d", len(graph.Nodes), len(graph.Edges), neurons, synapses)
+	}
+}
@@ -0,0 +1,62 @@
+package concurrency
+
+import (
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/sentiment"
+)
+
+// SentimentSettings is the sentiment layer an index uses: the analyzer
+// labeling its writes and queries, nil when the layer is off for it, and
+// the share of ASCII letters untagged text needs to be taken as English.
+type SentimentSettings struct {
+	Analyzer sentiment.Analyzer
+	MinASCII float64
+}
+
+// SentimentResolver reports whether indexID uses the sentiment layer,
+// e.g. from a per-index switch.
+type SentimentResolver func(indexID core.IndexID) bool
+
+// SetSentimentAnalyzer sets the analyzer every index uses unless the
+// resolver turns it off. nil disables the sentiment layer. Writes and
+// searches pick it up immediately.
+func (p *WorkerPool) SetSentimentAnalyzer(a sentiment.Analyzer) {
+	p.sentimentMu.Lock()
+	defer p.sentimentMu.Unlock()
+	p.sentimentAnalyzer = a
+}
+
+// SetSentimentMinASCII sets the share of ASCII letters text without a
+// language needs to be analyzed as English; see sentiment.GuessLanguage.
+func (p *WorkerPool) SetSentimentMinASCII(minASCII float64) {
+	p.sentimentMu.Lock()
+	defer p.sentimentMu.Unlock()
+	p.sentimentMinASCII = minASCII
+}
+
+// SetSentimentResolver installs r to decide which indexes use the
+// sentiment layer. nil enables it for every index.
+func (p *WorkerPool) SetSentimentResolver(r SentimentResolver) {
+	p.sentimentMu.Lock()
+	defer p.sentimentMu.Unlock()
+	p.sentimentResolver = r
+}
+
+// SentimentAnalyzer returns the pool's sentiment analyzer, or nil.
+func (p *WorkerPool) SentimentAnalyzer() sentiment.Analyzer {
+	p.sentimentMu.RLock()
+	defer p.sentimentMu.RUnlock()
+	return p.sentimentAnalyzer
+}
+
+// SentimentSettings returns the sentiment layer indexID uses.
+func (p *WorkerPool) SentimentSettings(indexID core.IndexID) SentimentSettings {
+	p.sentimentMu.RLock()
+	settings := SentimentSettings{Analyzer: p.sentimentAnalyzer, MinASCII: p.sentimentMinASCII}
+	resolve := p.sentimentResolver
+	p.sentimentMu.RUnlock()
+	if settings.Analyzer != nil && resolve != nil && !resolve(indexID) {
+		settings.Analyzer = nil
+	}
+	return settings
+}
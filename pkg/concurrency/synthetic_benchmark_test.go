@@ -0,0 +1,85 @@
+package concurrency_test
+
+import (
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/testutil"
+)
+
+// syntheticBrain builds the 10K-neuron fixture. Each benchmark gets its
+// own, since searches and decay change the matrix.
+func syntheticBrain() *testutil.Brain {
+	return testutil.NewSyntheticBrain(42, testutil.SyntheticOptions{Neurons: 10000, DegreeSkew: 1.5})
+}
+
+func BenchmarkSyntheticWorkerSearch10K(b *testing.B) {
+	w := syntheticBrain().NewWorker()
+	defer w.Stop()
+	op := &concurrency.Operation{
+		Type:    concurrency.OpSearch,
+		Payload: concurrency.SearchRequest{Query: "Kubernetes deployments", Depth: 2, Limit: 10},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := w.Submit(op); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSyntheticWorkerParallelSearch10K(b *testing.B) {
+	w := syntheticBrain().NewWorker()
+	defer w.Stop()
+	op := &concurrency.Operation{
+		Type:    concurrency.OpSearch,
+		Payload: concurrency.SearchRequest{Query: "learning Deutsch", Depth: 1, Limit: 10},
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := w.Submit(op); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkSyntheticWorkerDecay10K(b *testing.B) {
+	w := syntheticBrain().NewWorker()
+	defer w.Stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := w.Submit(&concurrency.Operation{Type: concurrency.OpDecay}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSyntheticPoolLoad10K(b *testing.B) {
+	store, err := persistence.NewStore(b.TempDir(), true)
+	if err != nil {
+		b.Fatal(err)
+	}
+	brain := syntheticBrain()
+	if err := brain.Save(store); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool := concurrency.NewWorkerPool(store, core.DefaultBounds())
+		if _, err := pool.GetOrCreate(brain.Matrix.IndexID); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		pool.Shutdown()
+		b.StartTimer()
+	}
+}
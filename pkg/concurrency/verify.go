@@ -0,0 +1,158 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// synapseWeightTolerance is how far a synapse weight in memory may be from
+// the one on disk and still match; weights round-trip exactly, so only
+// float noise is tolerated.
+const synapseWeightTolerance = 1e-9
+
+// VerifyDiff lists the entries, neurons or synapses, that differ between
+// memory and disk. Each list holds at most the limit Verify was given, in
+// ID order; the counts are complete.
+type VerifyDiff struct {
+	MissingOnDisk        []string `json:"missingOnDisk"`
+	MissingOnDiskCount   int      `json:"missingOnDiskCount"`
+	MissingInMemory      []string `json:"missingInMemory"`
+	MissingInMemoryCount int      `json:"missingInMemoryCount"`
+	Differing            []string `json:"differing"`
+	DifferingCount       int      `json:"differingCount"`
+}
+
+func (d *VerifyDiff) empty() bool {
+	return d.MissingOnDiskCount == 0 && d.MissingInMemoryCount == 0 && d.DifferingCount == 0
+}
+
+// VerifyReport compares the matrix of an index in memory with its data
+// file. For an index that is not loaded only the file is checked: it must
+// read, pass its checksum and decode.
+type VerifyReport struct {
+	IndexID  core.IndexID `json:"indexId"`
+	Resident bool         `json:"resident"`
+
+	// FileExists is false when the index has no data file; FileError is
+	// why the file could not be read or decoded, checksum mismatches
+	// included.
+	FileExists bool   `json:"fileExists"`
+	FileError  string `json:"fileError,omitempty"`
+
+	DiskNeurons    int `json:"diskNeurons"`
+	DiskSynapses   int `json:"diskSynapses"`
+	MemoryNeurons  int `json:"memoryNeurons,omitempty"`
+	MemorySynapses int `json:"memorySynapses,omitempty"`
+
+	// Neurons differ when their content hash does; synapses when their
+	// weight does. Only set for resident indexes.
+	Neurons  *VerifyDiff `json:"neurons,omitempty"`
+	Synapses *VerifyDiff `json:"synapses,omitempty"`
+
+	// PendingWrite is set when the store holds a state of the index for
+	// its next flush, which explains memory being ahead of disk.
+	PendingWrite bool `json:"pendingWrite"`
+
+	// Diverged is set when the index is quarantined because its data file
+	// changed behind the store's back; see Divergence.
+	Diverged bool `json:"diverged"`
+
+	// Match is set when memory and disk agree, or, for an index that is
+	// not loaded, when its file is sound.
+	Match bool `json:"match"`
+}
+
+// Verify compares indexID's matrix in memory with its data file, listing
+// at most limit entries of each kind of difference. The worker copies the
+// matrix with an OpSnapshot, in step with its writes, and the copy is
+// compared outside the worker. core.ErrMatrixNotFound is returned for an
+// index neither loaded nor on disk.
+func (p *WorkerPool) Verify(indexID core.IndexID, limit int) (VerifyReport, error) {
+	report := VerifyReport{IndexID: indexID}
+
+	var memory *core.Matrix
+	if worker, err := p.Get(indexID); err == nil {
+		if memory, err = worker.Snapshot(context.Background()); err != nil {
+			return report, err
+		}
+		report.Resident = true
+	}
+	report.PendingWrite = p.store.Pending(indexID)
+	report.Diverged = p.Diverged(indexID)
+
+	disk, err := p.store.Load(indexID)
+	switch {
+	case errors.Is(err, core.ErrMatrixNotFound):
+		if !report.Resident {
+			return report, err
+		}
+		disk = &core.Matrix{}
+	case err != nil:
+		report.FileExists = true
+		report.FileError = err.Error()
+		return report, nil
+	default:
+		report.FileExists = true
+		report.DiskNeurons = len(disk.Neurons)
+		report.DiskSynapses = len(disk.Synapses)
+	}
+
+	if !report.Resident {
+		report.Match = true
+		return report, nil
+	}
+	report.MemoryNeurons = len(memory.Neurons)
+	report.MemorySynapses = len(memory.Synapses)
+	report.Neurons = diffEntries(memory.Neurons, disk.Neurons, limit, func(a, b *core.Neuron) bool {
+		return a.ContentHash == b.ContentHash
+	})
+	report.Synapses = diffEntries(memory.Synapses, disk.Synapses, limit, func(a, b *core.Synapse) bool {
+		return math.Abs(a.Weight-b.Weight) <= synapseWeightTolerance
+	})
+	report.Match = report.FileExists && report.Neurons.empty() && report.Synapses.empty()
+	return report, nil
+}
+
+// diffEntries compares the entries of memory and disk by ID, with same
+// deciding whether two entries of an ID agree.
+func diffEntries[K ~string, V any](memory, disk map[K]V, limit int, same func(a, b V) bool) *VerifyDiff {
+	var missingOnDisk, missingInMemory, differing []string
+	for id, m := range memory {
+		d, ok := disk[id]
+		switch {
+		case !ok:
+			missingOnDisk = append(missingOnDisk, string(id))
+		case !same(m, d):
+			differing = append(differing, string(id))
+		}
+	}
+	for id := range disk {
+		if _, ok := memory[id]; !ok {
+			missingInMemory = append(missingInMemory, string(id))
+		}
+	}
+	return &VerifyDiff{
+		MissingOnDisk:        firstSorted(missingOnDisk, limit),
+		MissingOnDiskCount:   len(missingOnDisk),
+		MissingInMemory:      firstSorted(missingInMemory, limit),
+		MissingInMemoryCount: len(missingInMemory),
+		Differing:            firstSorted(differing, limit),
+		DifferingCount:       len(differing),
+	}
+}
+
+// firstSorted returns the first limit IDs in order, never nil.
+func firstSorted(ids []string, limit int) []string {
+	sort.Strings(ids)
+	if limit >= 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return ids
+}
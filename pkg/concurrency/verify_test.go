@@ -0,0 +1,154 @@
+package concurrency
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestVerify_ReportsDivergentNeurons(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	worker, err := pool.GetOrCreate("verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) core.NeuronID {
+		t.Helper()
+		result, err := worker.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: content}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.(*core.Neuron).ID
+	}
+	kept := write("the deploy runs every tuesday")
+	edited := write("the staging database lives in eu-west")
+	forgotten := write("the old vpn endpoint was retired")
+
+	m := worker.Matrix()
+	m.Lock()
+	synapse := core.NewSynapse(kept, edited, 0.5)
+	m.Synapses[synapse.ID] = synapse
+	m.Unlock()
+
+	if _, err := pool.PersistIndex("verify"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := pool.Verify("verify", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Match || !report.Resident || !report.FileExists || report.PendingWrite || report.DiskNeurons != 3 {
+		t.Fatalf("expected memory to match disk after a flush, got %+v", report)
+	}
+
+	// Mutate after the flush and skip the next one.
+	added := write("the new vpn endpoint is vpn2")
+	if _, err := worker.Submit(&Operation{Type: OpTouch, Payload: UpdateNeuronRequest{ID: edited, Content: "the staging database moved to eu-central"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worker.Submit(&Operation{Type: OpForget, Payload: forgotten}); err != nil {
+		t.Fatal(err)
+	}
+	m.Lock()
+	m.Synapses[synapse.ID].Weight += 0.1
+	m.Unlock()
+
+	report, err = pool.Verify("verify", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Match || report.PendingWrite || report.MemoryNeurons != 3 || report.DiskNeurons != 3 {
+		t.Errorf("unexpected summary %+v", report)
+	}
+	n := report.Neurons
+	if !reflect.DeepEqual(n.MissingOnDisk, []string{string(added)}) ||
+		!reflect.DeepEqual(n.MissingInMemory, []string{string(forgotten)}) ||
+		!reflect.DeepEqual(n.Differing, []string{string(edited)}) {
+		t.Errorf("expected exactly the added, forgotten and edited neurons, got %+v", n)
+	}
+	if !reflect.DeepEqual(report.Synapses.Differing, []string{string(synapse.ID)}) {
+		t.Errorf("expected the reweighted synapse to differ, got %+v", report.Synapses)
+	}
+
+	// A state queued for the next flush explains the gap.
+	if err := pool.PersistAsync("verify", worker); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ = pool.Verify("verify", 10); !report.PendingWrite || report.Match {
+		t.Errorf("expected a pending write behind the difference, got %+v", report)
+	}
+}
+
+func TestVerify_LimitsListings(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	worker, err := pool.GetOrCreate("verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"first unsaved memory", "second unsaved memory", "third unsaved memory"} {
+		if _, err := worker.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No data file yet: everything is missing on disk.
+	report, err := pool.Verify("verify", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FileExists || report.Match || report.Neurons.MissingOnDiskCount != 3 || len(report.Neurons.MissingOnDisk) != 2 {
+		t.Errorf("expected three neurons missing on disk, two listed, got %+v", report)
+	}
+}
+
+func TestVerify_NonResidentChecksFile(t *testing.T) {
+	pool, tmpDir := setupTestPool(t)
+	defer os.RemoveAll(tmpDir)
+	defer pool.Shutdown()
+
+	if _, err := pool.Verify("absent", 10); !errors.Is(err, core.ErrMatrixNotFound) {
+		t.Errorf("expected ErrMatrixNotFound for an unknown index, got %v", err)
+	}
+
+	worker, err := pool.GetOrCreate("cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worker.Submit(&Operation{Type: OpWrite, Payload: AddNeuronRequest{Content: "written before eviction"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Evict("cold"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := pool.Verify("cold", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Resident || !report.FileExists || !report.Match || report.DiskNeurons != 1 || report.Neurons != nil {
+		t.Errorf("expected a sound file for the evicted index, got %+v", report)
+	}
+
+	// Corrupt the payload so the checksum fails.
+	path := tmpDir + "/data/cold.nrdb"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if report, err = pool.Verify("cold", 10); err != nil || report.Match || report.FileError == "" {
+		t.Errorf("expected a checksum failure to be reported, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,659 @@
+package conformance
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Neurons written by write.basic, by name.
+var basicNeurons = []struct {
+	name, content, topic string
+}{
+	{"deploy", "The deploy pipeline for the payments service needs a longer timeout", "deploys"},
+	{"billing", "Billing invoices are generated on the first day of every month", "billing"},
+	{"rollback", "Rollbacks of the deploy pipeline are run by the on-call engineer", "deploys"},
+}
+
+// suite is the conformance suite in run order. IDs are stable: a check
+// that changes meaning gets a new ID and the old one is retired, never
+// reused. Error checks are named errors.<CODE>.
+var suite = []Check{
+	// Service.
+	{ID: "health.ok", Title: "GET /health answers 200 with a JSON object", run: func(s *session) error {
+		r, err := s.do("GET", "/health", nil, nil)
+		if err != nil {
+			return err
+		}
+		_, err = r.expect(http.StatusOK)
+		return err
+	}},
+	{ID: "versions.list", Title: "GET /v1/versions lists the supported API versions", run: func(s *session) error {
+		r, err := s.do("GET", "/v1/versions", nil, nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if len(list(doc, "versions")) == 0 {
+			return errors.New(`expected a non-empty "versions" list`)
+		}
+		return nil
+	}},
+	{ID: "errors.UNSUPPORTED_API_VERSION", Title: "An unknown X-QubicDB-Version is rejected", Codes: []string{"UNSUPPORTED_API_VERSION"}, run: func(s *session) error {
+		r, err := s.do("GET", "/v1/recall", nil, map[string]string{"X-Index-ID": s.index, "X-QubicDB-Version": "1999-01-01"})
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "UNSUPPORTED_API_VERSION")
+	}},
+	{ID: "errors.METHOD_NOT_ALLOWED", Title: "An unsupported method on a known route is rejected", Codes: []string{"METHOD_NOT_ALLOWED"}, run: func(s *session) error {
+		r, err := s.call("PATCH", "/v1/touch", nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED")
+	}},
+	{ID: "errors.NOT_FOUND", Title: "An unknown brain action answers 404", Codes: []string{"NOT_FOUND"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/brain/no-such-action", nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusNotFound, "NOT_FOUND")
+	}},
+
+	// Registry lifecycle.
+	{ID: "registry.create", Title: "POST /v1/registry registers the index with metadata", run: func(s *session) error {
+		r, err := s.do("POST", "/v1/registry", map[string]any{"uuid": s.index, "metadata": map[string]any{"suite": "conformance"}}, nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusCreated)
+		if err != nil {
+			return err
+		}
+		return expectField(doc, "uuid", s.index)
+	}},
+	{ID: "registry.get", Title: "GET /v1/registry/{uuid} returns the entry and its metadata", Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.do("GET", "/v1/registry/"+s.index, nil, nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if err := expectField(doc, "uuid", s.index); err != nil {
+			return err
+		}
+		return expectField(object(doc, "metadata"), "suite", "conformance")
+	}},
+	{ID: "registry.find_or_create", Title: "POST /v1/registry/find-or-create finds an existing entry", Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.do("POST", "/v1/registry/find-or-create", map[string]any{"uuid": s.index}, nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if created, _ := doc["created"].(bool); created {
+			return errors.New(`expected "created": false for a registered uuid`)
+		}
+		return nil
+	}},
+	{ID: "errors.UUID_CONFLICT", Title: "Registering a uuid twice conflicts", Codes: []string{"UUID_CONFLICT"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.do("POST", "/v1/registry", map[string]any{"uuid": s.index}, nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusConflict, "UUID_CONFLICT")
+	}},
+	{ID: "errors.UUID_NOT_FOUND", Title: "An unregistered uuid is not found", Codes: []string{"UUID_NOT_FOUND"}, run: func(s *session) error {
+		r, err := s.do("GET", "/v1/registry/missing-"+randomHex(6), nil, nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusNotFound, "UUID_NOT_FOUND")
+	}},
+	{ID: "errors.UUID_REQUIRED", Title: "Registering without a uuid is rejected", Codes: []string{"UUID_REQUIRED"}, run: func(s *session) error {
+		r, err := s.do("POST", "/v1/registry", map[string]any{"metadata": map[string]any{}}, nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "UUID_REQUIRED")
+	}},
+	{ID: "errors.INVALID_FALLBACK", Title: "An index cannot fall back to itself", Codes: []string{"INVALID_FALLBACK"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.do("PUT", "/v1/registry/"+s.index, map[string]any{"metadata": map[string]any{"fallbackIndexes": []string{s.index}}}, nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_FALLBACK")
+	}},
+	{ID: "errors.INVALID_RETENTION", Title: "A malformed retention policy is rejected", Codes: []string{"INVALID_RETENTION"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.do("PUT", "/v1/registry/"+s.index, map[string]any{"metadata": map[string]any{"retention": "forever"}}, nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_RETENTION")
+	}},
+	{ID: "errors.UUID_NOT_REGISTERED", Title: "With the registry guard on, unregistered indexes are refused", Codes: []string{"UUID_NOT_REGISTERED"}, run: func(s *session) error {
+		r, err := s.do("POST", "/v1/write", map[string]any{"content": "guarded"}, map[string]string{"X-Index-ID": "unregistered-" + randomHex(6)})
+		if err != nil {
+			return err
+		}
+		if r.status == http.StatusOK {
+			return skipf("registry guard is disabled (registry.enabled)")
+		}
+		return r.expectError(http.StatusBadRequest, "UUID_NOT_REGISTERED")
+	}},
+
+	// Index selection.
+	{ID: "errors.INDEX_ID_REQUIRED", Title: "Index operations without an index ID are rejected", Codes: []string{"INDEX_ID_REQUIRED"}, run: func(s *session) error {
+		r, err := s.do("GET", "/v1/recall", nil, nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INDEX_ID_REQUIRED")
+	}},
+	{ID: "errors.INDEX_ID_CONFLICT", Title: "A header and query index ID that disagree are rejected", Codes: []string{"INDEX_ID_CONFLICT"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/recall?index_id=other-"+randomHex(6), nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INDEX_ID_CONFLICT")
+	}},
+
+	// Writes.
+	{ID: "write.basic", Title: "POST /v1/write stores content and metadata", Needs: []string{"registry.create"}, run: func(s *session) error {
+		for _, n := range basicNeurons {
+			r, err := s.call("POST", "/v1/write", map[string]any{"content": n.content, "metadata": map[string]string{"topic": n.topic}})
+			if err != nil {
+				return err
+			}
+			doc, err := r.expect(http.StatusOK)
+			if err != nil {
+				return err
+			}
+			id, _ := doc["_id"].(string)
+			if id == "" {
+				return errors.New(`expected the written neuron's "_id"`)
+			}
+			if err := expectField(doc, "content", n.content); err != nil {
+				return err
+			}
+			if err := expectField(object(doc, "metadata"), "topic", n.topic); err != nil {
+				return err
+			}
+			s.neurons[n.name] = id
+		}
+		return nil
+	}},
+	{ID: "errors.INVALID_JSON", Title: "A malformed JSON body is rejected", Codes: []string{"INVALID_JSON"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/write", `{"content": `)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_JSON")
+	}},
+	{ID: "errors.INVALID_CONTENT", Title: "Blank content is rejected", Codes: []string{"INVALID_CONTENT"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/write", map[string]any{"content": "   "})
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_CONTENT")
+	}},
+	{ID: "errors.INVALID_CONTENT_ENCODING", Title: "Content that is not valid UTF-8 is rejected", Codes: []string{"INVALID_CONTENT_ENCODING"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/write", []byte("{\"content\":\"binary \xff\xfe junk\"}"))
+		if err != nil {
+			return err
+		}
+		if r.status == http.StatusOK {
+			return skipf("content sanitization is enabled (write.sanitizeContent)")
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_CONTENT_ENCODING")
+	}},
+	{ID: "errors.MUTATION_DISABLED", Title: "Direct neuron mutation is refused", Codes: []string{"MUTATION_DISABLED"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/touch", map[string]any{"id": "any", "content": "changed"})
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "MUTATION_DISABLED")
+	}},
+	{ID: "errors.SUPERSEDE_CONFLICT", Title: "A neuron can be superseded once", Codes: []string{"SUPERSEDE_CONFLICT"}, Needs: []string{"write.basic"}, run: func(s *session) error {
+		write := func(content string) (*response, error) {
+			return s.call("POST", "/v1/write", map[string]any{"content": content, "supersedes": s.neurons["billing"]})
+		}
+		r, err := write("Billing invoices are generated on the second day of every month")
+		if err != nil {
+			return err
+		}
+		if _, err := r.expect(http.StatusOK); err != nil {
+			return err
+		}
+		if r, err = write("Billing invoices are generated on the third day of every month"); err != nil {
+			return err
+		}
+		return r.expectError(http.StatusConflict, "SUPERSEDE_CONFLICT")
+	}},
+
+	// Reads.
+	{ID: "read.by_id", Title: "GET /v1/read/{id} returns the written neuron", Needs: []string{"write.basic"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/read/"+s.neurons["deploy"], nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if err := expectField(doc, "_id", s.neurons["deploy"]); err != nil {
+			return err
+		}
+		return expectField(doc, "content", basicNeurons[0].content)
+	}},
+	{ID: "errors.NEURON_NOT_FOUND", Title: "Reading an unknown neuron answers 404", Codes: []string{"NEURON_NOT_FOUND"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/read/missing-"+randomHex(6), nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusNotFound, "NEURON_NOT_FOUND")
+	}},
+	{ID: "errors.NEURON_ID_REQUIRED", Title: "Reading without a neuron ID is rejected", Codes: []string{"NEURON_ID_REQUIRED"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/read/", nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "NEURON_ID_REQUIRED")
+	}},
+
+	// Search.
+	{ID: "search.basic", Title: "GET /v1/search finds a neuron by its words", Needs: []string{"write.basic"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/search?q="+url.QueryEscape("deploy pipeline timeout"), nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if !containsID(list(doc, "results"), s.neurons["deploy"]) {
+			return errors.New("expected the deploy neuron among the results")
+		}
+		return nil
+	}},
+	{ID: "search.metadata_strict", Title: "Strict metadata search returns only matching neurons", Needs: []string{"write.basic"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/search", map[string]any{
+			"query":    "deploy pipeline billing invoices",
+			"metadata": map[string]string{"topic": "deploys"},
+			"strict":   true,
+		})
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		results := list(doc, "results")
+		if len(results) == 0 {
+			return errors.New("expected results tagged topic=deploys")
+		}
+		for _, res := range results {
+			hit, _ := res.(map[string]any)
+			if topic, _ := object(hit, "metadata")["topic"].(string); topic != "deploys" {
+				return fmt.Errorf("strict search returned %v with topic %q", hit["_id"], topic)
+			}
+		}
+		return nil
+	}},
+	{ID: "search.limit_clamped", Title: "An oversized search limit is clamped, not rejected", Needs: []string{"write.basic"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/search?q=deploy&limit=1000000", nil)
+		if err != nil {
+			return err
+		}
+		_, err = r.expect(http.StatusOK)
+		return err
+	}},
+	{ID: "errors.QUERY_REQUIRED", Title: "Searching without a query is rejected", Codes: []string{"QUERY_REQUIRED"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/search", nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "QUERY_REQUIRED")
+	}},
+
+	// Recall.
+	{ID: "recall.paging", Title: "GET /v1/recall pages by offset and limit", Needs: []string{"write.basic"}, run: func(s *session) error {
+		seen := map[string]bool{}
+		for offset := 0; ; offset += 2 {
+			r, err := s.call("GET", fmt.Sprintf("/v1/recall?order=asc&limit=2&offset=%d", offset), nil)
+			if err != nil {
+				return err
+			}
+			doc, err := r.expect(http.StatusOK)
+			if err != nil {
+				return err
+			}
+			neurons := list(doc, "neurons")
+			if len(neurons) > 2 {
+				return fmt.Errorf("expected at most 2 neurons a page, got %d", len(neurons))
+			}
+			for _, n := range neurons {
+				id, _ := n.(map[string]any)["_id"].(string)
+				if seen[id] {
+					return fmt.Errorf("neuron %s recalled on two pages", id)
+				}
+				seen[id] = true
+			}
+			total, _ := doc["total"].(float64)
+			hasMore, _ := doc["hasMore"].(bool)
+			if want := offset+len(neurons) < int(total); hasMore != want {
+				return fmt.Errorf("offset %d: expected hasMore %v with total %v", offset, want, total)
+			}
+			if !hasMore {
+				break
+			}
+			if offset > 1000 {
+				return errors.New("recall did not stop paging")
+			}
+		}
+		for name, id := range s.neurons {
+			if !seen[id] {
+				return fmt.Errorf("neuron %s (%s) was never recalled", name, id)
+			}
+		}
+		return nil
+	}},
+	{ID: "errors.BAD_REQUEST", Title: "An invalid recall order is rejected", Codes: []string{"BAD_REQUEST"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/recall?order=sideways", nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "BAD_REQUEST")
+	}},
+
+	// Context.
+	{ID: "context.basic", Title: "POST /v1/context assembles context for a cue", Needs: []string{"write.basic"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/context", map[string]any{"cue": "deploy pipeline timeout", "maxTokens": 500})
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if text, _ := doc["context"].(string); !strings.Contains(text, "deploy pipeline") {
+			return errors.New(`expected "context" to include the deploy neuron`)
+		}
+		return nil
+	}},
+
+	// Working memory sessions.
+	{ID: "session.write", Title: `A write with scope "session" lands in the session`, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/write", map[string]any{"content": "The user prefers short answers", "scope": "session", "session_id": "conformance"})
+		if err != nil {
+			return err
+		}
+		if r.status == http.StatusBadRequest && strings.Contains(string(r.body), "sessions.enabled") {
+			return skipf("sessions are disabled (sessions.enabled)")
+		}
+		if _, err := r.expect(http.StatusOK); err != nil {
+			return err
+		}
+		if r, err = s.call("GET", "/v1/sessions/conformance", nil); err != nil {
+			return err
+		}
+		_, err = r.expect(http.StatusOK)
+		return err
+	}},
+	{ID: "errors.INVALID_SESSION", Title: "A malformed session ID is rejected", Codes: []string{"INVALID_SESSION"}, Needs: []string{"session.write"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/write", map[string]any{"content": "x", "scope": "session", "session_id": "has space"})
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_SESSION")
+	}},
+	{ID: "errors.SESSION_NOT_FOUND", Title: "Deleting an unknown session answers 404", Codes: []string{"SESSION_NOT_FOUND"}, Needs: []string{"session.write"}, run: func(s *session) error {
+		r, err := s.call("DELETE", "/v1/sessions/missing-"+randomHex(6), nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusNotFound, "SESSION_NOT_FOUND")
+	}},
+
+	// Imports and attachments.
+	{ID: "errors.INVALID_ARCHIVE", Title: "A markdown import that is not a zip archive is rejected", Codes: []string{"INVALID_ARCHIVE"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.do("POST", "/v1/import/markdown", "not a zip archive", map[string]string{"X-Index-ID": s.index, "Content-Type": "application/zip"})
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_ARCHIVE")
+	}},
+	{ID: "errors.ATTACHMENT_NOT_FOUND", Title: "An unknown attachment answers 404", Codes: []string{"ATTACHMENT_NOT_FOUND"}, run: func(s *session) error {
+		r, err := s.call("GET", "/v1/attachments/"+strings.Repeat("0", 64), nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusNotFound, "ATTACHMENT_NOT_FOUND")
+	}},
+
+	// Subscriptions and shares.
+	{ID: "errors.INVALID_SUBSCRIPTION", Title: "A subscription with an unknown action is rejected", Codes: []string{"INVALID_SUBSCRIPTION"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/subscriptions", map[string]any{"action": "no-such-action", "query": "deploy", "schedule": "1h"})
+		if err != nil {
+			return err
+		}
+		if r.routeMissing() {
+			return skipf("subscriptions are disabled (subscriptions.enabled)")
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_SUBSCRIPTION")
+	}},
+	{ID: "errors.SUBSCRIPTION_NOT_FOUND", Title: "Deleting an unknown subscription answers 404", Codes: []string{"SUBSCRIPTION_NOT_FOUND"}, run: func(s *session) error {
+		r, err := s.call("DELETE", "/v1/subscriptions/missing-"+randomHex(6), nil)
+		if err != nil {
+			return err
+		}
+		if r.routeMissing() {
+			return skipf("subscriptions are disabled (subscriptions.enabled)")
+		}
+		return r.expectError(http.StatusNotFound, "SUBSCRIPTION_NOT_FOUND")
+	}},
+	{ID: "errors.INVALID_SHARE", Title: "A share without a filter is rejected", Codes: []string{"INVALID_SHARE"}, Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.call("POST", "/v1/shares", map[string]any{})
+		if err != nil {
+			return err
+		}
+		if r.routeMissing() {
+			return skipf("shares are disabled (shares.enabled)")
+		}
+		return r.expectError(http.StatusBadRequest, "INVALID_SHARE")
+	}},
+	{ID: "errors.SHARE_NOT_FOUND", Title: "Deleting an unknown share answers 404", Codes: []string{"SHARE_NOT_FOUND"}, run: func(s *session) error {
+		r, err := s.call("DELETE", "/v1/shares/missing-"+randomHex(6), nil)
+		if err != nil {
+			return err
+		}
+		if r.routeMissing() {
+			return skipf("shares are disabled (shares.enabled)")
+		}
+		return r.expectError(http.StatusNotFound, "SHARE_NOT_FOUND")
+	}},
+
+	// Admin auth.
+	{ID: "errors.UNAUTHORIZED", Title: "Admin endpoints refuse requests without credentials", Codes: []string{"UNAUTHORIZED"}, run: func(s *session) error {
+		r, err := s.do("GET", "/admin/info", nil, nil)
+		if err != nil {
+			return err
+		}
+		if r.routeMissing() {
+			return skipf("admin endpoints are disabled (admin.enabled)")
+		}
+		return r.expectError(http.StatusUnauthorized, "UNAUTHORIZED")
+	}},
+	{ID: "admin.login_rejected", Title: "POST /admin/login refuses a wrong password", Admin: true, Codes: []string{"UNAUTHORIZED"}, run: func(s *session) error {
+		r, err := s.do("POST", "/admin/login", map[string]string{"user": s.opts.AdminUser, "password": "wrong-" + randomHex(6)}, nil)
+		if err != nil {
+			return err
+		}
+		return r.expectError(http.StatusUnauthorized, "UNAUTHORIZED")
+	}},
+	{ID: "admin.login", Title: "POST /admin/login issues a session token", Admin: true, run: func(s *session) error {
+		r, err := s.do("POST", "/admin/login", map[string]string{"user": s.opts.AdminUser, "password": s.opts.AdminPassword}, nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if s.token, _ = doc["token"].(string); s.token == "" {
+			return errors.New(`expected a "token"`)
+		}
+		return nil
+	}},
+	{ID: "admin.bearer", Title: "The session token authenticates admin requests", Admin: true, Needs: []string{"admin.login"}, run: func(s *session) error {
+		r, err := s.do("GET", "/admin/info", nil, map[string]string{"Authorization": "Bearer " + s.token})
+		if err != nil {
+			return err
+		}
+		_, err = r.expect(http.StatusOK)
+		return err
+	}},
+	{ID: "admin.basic", Title: "Basic auth authenticates admin requests", Admin: true, run: func(s *session) error {
+		r, err := s.admin("GET", "/admin/info", nil)
+		if err != nil {
+			return err
+		}
+		_, err = r.expect(http.StatusOK)
+		return err
+	}},
+	{ID: "errors.CONFLICT", Title: "Cloning onto an index that holds data conflicts", Admin: true, Codes: []string{"CONFLICT"}, Needs: []string{"write.basic"}, run: func(s *session) error {
+		target := s.index + "-clone"
+		r, err := s.admin("POST", "/admin/indexes/"+s.index+"/clone", map[string]string{"target": target})
+		if err != nil {
+			return err
+		}
+		if _, err := r.expect(http.StatusOK); err != nil {
+			return err
+		}
+		conflict, err := s.admin("POST", "/admin/indexes/"+s.index+"/clone", map[string]string{"target": target})
+		if err != nil {
+			return err
+		}
+		if r, err = s.admin("DELETE", "/admin/indexes/"+target, nil); err != nil {
+			return err
+		}
+		if _, err := r.expect(http.StatusOK); err != nil {
+			return err
+		}
+		return conflict.expectError(http.StatusConflict, "CONFLICT")
+	}},
+	{ID: "admin.logout", Title: "POST /admin/logout revokes the session token", Admin: true, Needs: []string{"admin.login"}, run: func(s *session) error {
+		bearer := map[string]string{"Authorization": "Bearer " + s.token}
+		r, err := s.do("POST", "/admin/logout", nil, bearer)
+		if err != nil {
+			return err
+		}
+		if _, err := r.expect(http.StatusOK); err != nil {
+			return err
+		}
+		if r, err = s.do("GET", "/admin/info", nil, bearer); err != nil {
+			return err
+		}
+		return r.expectError(http.StatusUnauthorized, "UNAUTHORIZED")
+	}},
+
+	// Cleanup.
+	{ID: "registry.delete", Title: "DELETE /v1/registry/{uuid} removes the entry", Needs: []string{"registry.create"}, run: func(s *session) error {
+		r, err := s.do("DELETE", "/v1/registry/"+s.index, nil, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := r.expect(http.StatusOK); err != nil {
+			return err
+		}
+		if r, err = s.do("GET", "/v1/registry/"+s.index, nil, nil); err != nil {
+			return err
+		}
+		return r.expectError(http.StatusNotFound, "UUID_NOT_FOUND")
+	}},
+	{ID: "admin.delete_index", Title: "DELETE /admin/indexes/{id} deletes the index's data", Admin: true, Needs: []string{"write.basic"}, run: func(s *session) error {
+		r, err := s.admin("DELETE", "/admin/indexes/"+s.index, nil)
+		if err != nil {
+			return err
+		}
+		doc, err := r.expect(http.StatusOK)
+		if err != nil {
+			return err
+		}
+		if deleted, _ := doc["deleted"].(bool); !deleted {
+			return errors.New(`expected "deleted": true`)
+		}
+		return nil
+	}},
+}
+
+// UncoveredCodes are the apierr codes no check asserts, with the reason:
+// reaching them takes a fault, a limit or a configuration a black-box run
+// against a shared server cannot arrange. Every other code has a check.
+var UncoveredCodes = map[string]string{
+	"PAYLOAD_TOO_LARGE":      "depends on the target's request and content size limits",
+	"INTERNAL_ERROR":         "server fault",
+	"OPERATION_PANIC":        "server fault",
+	"INDEX_LOADING":          "needs an index caught mid-load",
+	"INDEX_DIVERGED":         "needs replica divergence",
+	"INDEX_QUARANTINED":      "needs a corrupt index on disk",
+	"INSUFFICIENT_STORAGE":   "needs a full disk",
+	"STORAGE_QUOTA_EXCEEDED": "needs a storage quota to be used up",
+	"SERVER_BUSY":            "needs the server saturated",
+	"RATE_LIMITED":           "would throttle the suite's own requests",
+	"AUTH_LOCKED":            "would lock the suite's admin user out",
+	"REGISTRY_GUARD_LOCKOUT": "would lock the suite's client out",
+	"FORBIDDEN":              "needs a non-admin account",
+	"DRAINING":               "would take the target out of service",
+	"STORAGE_MIGRATING":      "would move the target's data directory",
+	"PIN_LIMIT":              "depends on pins.maxPerIndex",
+	"SUPERSEDE_CYCLE":        "writes supersede existing neurons only, so no request forms a cycle",
+	"SUBSCRIPTION_LIMIT":     "depends on subscriptions.maxPerIndex",
+	"SHARE_LIMIT":            "depends on the share limit per index",
+	"SHARE_EXPIRED":          "needs a share to outlive its expiry",
+	"APPEND_ONLY":            "would leave an append-only index only an admin override can remove",
+}
+
+// admin sends a request with basic auth.
+func (s *session) admin(method, path string, body any) (*response, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(s.opts.AdminUser + ":" + s.opts.AdminPassword))
+	return s.do(method, path, body, map[string]string{"Authorization": "Basic " + auth})
+}
+
+func list(doc map[string]any, key string) []any {
+	items, _ := doc[key].([]any)
+	return items
+}
+
+func object(doc map[string]any, key string) map[string]any {
+	obj, _ := doc[key].(map[string]any)
+	return obj
+}
+
+func expectField(doc map[string]any, key, want string) error {
+	if got, _ := doc[key].(string); got != want {
+		return fmt.Errorf("expected %q to be %q, got %v", key, want, doc[key])
+	}
+	return nil
+}
+
+func containsID(items []any, id string) bool {
+	for _, item := range items {
+		if doc, _ := item.(map[string]any); doc["_id"] == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,408 @@
+// Package conformance is a black-box suite for the QubicDB HTTP API: a
+// documented sequence of checks, run over plain HTTP against a base URL,
+// that any server build must pass. Check IDs are stable, so clients in
+// other languages can pin the behavior they depend on to them.
+//
+// The suite runs in the repo's own tests against an in-process server
+// (pkg/api), and from cmd/qubicdb-conformance against a deployment.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options configure a run.
+type Options struct {
+	// BaseURL is the server under test, e.g. http://localhost:6060.
+	BaseURL string
+
+	// AdminUser and AdminPassword enable the admin checks, which are
+	// skipped without them.
+	AdminUser     string
+	AdminPassword string
+
+	// IndexID is the index the checks write to. It is registered, filled
+	// and deleted again; empty picks a random one.
+	IndexID string
+
+	// Run selects the checks whose ID starts with one of its
+	// comma-separated prefixes. Empty runs them all. The checks a
+	// selected check depends on run as well.
+	Run string
+
+	// Client sends the requests; nil uses a client with a 30s timeout.
+	Client *http.Client
+}
+
+// Status is a check's outcome.
+type Status string
+
+const (
+	Pass Status = "pass"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// Result is one check's outcome. A failed check carries every exchange it
+// made, the last one being the one that failed.
+type Result struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Status    Status     `json:"status"`
+	Message   string     `json:"message,omitempty"`
+	Duration  string     `json:"duration"`
+	Exchanges []Exchange `json:"exchanges,omitempty"`
+}
+
+// Exchange is a request and the response to it, as sent and received.
+type Exchange struct {
+	Request  Request   `json:"request"`
+	Response *Response `json:"response,omitempty"`
+	Error    string    `json:"error,omitempty"` // transport error, without a response
+}
+
+// Request is a captured request. Authorization headers are redacted.
+type Request struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+// Response is a captured response.
+type Response struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+// Report is a run's results, in check order.
+type Report struct {
+	Target   string    `json:"target"`
+	IndexID  string    `json:"indexId"`
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	Passed   int       `json:"passed"`
+	Failed   int       `json:"failed"`
+	Skipped  int       `json:"skipped"`
+	Results  []Result  `json:"results"`
+}
+
+// OK reports whether no check failed.
+func (r *Report) OK() bool { return r.Failed == 0 }
+
+// Check is one step of the suite. Checks run in suite order and share
+// state: a later check may use a neuron an earlier one wrote, and lists
+// those it needs in Needs.
+type Check struct {
+	ID    string
+	Title string
+
+	// Codes are the apierr codes the check asserts a response carries.
+	Codes []string
+
+	// Admin checks need admin credentials.
+	Admin bool
+
+	// Needs are the IDs of the checks this one depends on. If one of
+	// them did not pass, this check is skipped.
+	Needs []string
+
+	run func(*session) error
+}
+
+// Checks returns the suite in run order.
+func Checks() []Check {
+	return append([]Check(nil), suite...)
+}
+
+// skipError skips a check, e.g. when the target has a feature disabled.
+type skipError struct{ reason string }
+
+func (e skipError) Error() string { return e.reason }
+
+func skipf(format string, args ...any) error {
+	return skipError{fmt.Sprintf(format, args...)}
+}
+
+// Run runs the selected checks against opts.BaseURL. It fails only on a
+// malformed BaseURL; failing checks are in the report.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	base := strings.TrimRight(opts.BaseURL, "/")
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		return nil, fmt.Errorf("conformance: base URL %q must start with http:// or https://", opts.BaseURL)
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	indexID := opts.IndexID
+	if indexID == "" {
+		indexID = "conformance-" + randomHex(6)
+	}
+
+	s := &session{
+		ctx:     ctx,
+		base:    base,
+		client:  client,
+		opts:    opts,
+		index:   indexID,
+		neurons: make(map[string]string),
+	}
+	report := &Report{Target: base, IndexID: indexID, Started: time.Now().UTC()}
+	selected := selectChecks(suite, opts.Run)
+	passed := make(map[string]bool)
+	for _, c := range suite {
+		if !selected[c.ID] {
+			continue
+		}
+		res := s.runCheck(c, passed)
+		if res.Status == Pass {
+			passed[c.ID] = true
+		}
+		switch res.Status {
+		case Pass:
+			report.Passed++
+		case Fail:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+		report.Results = append(report.Results, res)
+	}
+	report.Duration = time.Since(report.Started).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// selectChecks returns the IDs of the checks run selects, with the checks
+// they need.
+func selectChecks(checks []Check, run string) map[string]bool {
+	var prefixes []string
+	for _, p := range strings.Split(run, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	byID := make(map[string]Check, len(checks))
+	for _, c := range checks {
+		byID[c.ID] = c
+	}
+	selected := make(map[string]bool)
+	var add func(id string)
+	add = func(id string) {
+		if selected[id] {
+			return
+		}
+		selected[id] = true
+		for _, need := range byID[id].Needs {
+			add(need)
+		}
+	}
+	for _, c := range checks {
+		if len(prefixes) == 0 {
+			add(c.ID)
+			continue
+		}
+		for _, p := range prefixes {
+			if strings.HasPrefix(c.ID, p) {
+				add(c.ID)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+func (s *session) runCheck(c Check, passed map[string]bool) Result {
+	res := Result{ID: c.ID, Title: c.Title}
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start).Round(time.Microsecond).String() }()
+
+	for _, need := range c.Needs {
+		if !passed[need] {
+			res.Status, res.Message = Skip, "needs "+need
+			return res
+		}
+	}
+	if c.Admin && (s.opts.AdminUser == "" || s.opts.AdminPassword == "") {
+		res.Status, res.Message = Skip, "no admin credentials"
+		return res
+	}
+
+	s.exchanges = nil
+	err := c.run(s)
+	var skip skipError
+	switch {
+	case err == nil:
+		res.Status = Pass
+	case errors.As(err, &skip):
+		res.Status, res.Message = Skip, skip.reason
+	default:
+		res.Status, res.Message = Fail, err.Error()
+		res.Exchanges = s.exchanges
+	}
+	return res
+}
+
+// session is the state a run's checks share.
+type session struct {
+	ctx    context.Context
+	base   string
+	client *http.Client
+	opts   Options
+	index  string
+
+	// neurons are the IDs of neurons written by earlier checks, by name.
+	neurons map[string]string
+	// token is the admin session token from admin.login.
+	token string
+
+	// exchanges are the current check's.
+	exchanges []Exchange
+}
+
+// response is a received response.
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// call sends a request to the run's index: body is JSON-encoded unless it
+// is a string or []byte, sent as is.
+func (s *session) call(method, path string, body any) (*response, error) {
+	return s.do(method, path, body, map[string]string{"X-Index-ID": s.index})
+}
+
+// do sends a request with exactly the given headers, plus Content-Type for
+// a body.
+func (s *session) do(method, path string, body any, header map[string]string) (*response, error) {
+	var data []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		data = []byte(b)
+	case []byte:
+		data = b
+	default:
+		var err error
+		if data, err = json.Marshal(b); err != nil {
+			return nil, err
+		}
+	}
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(s.ctx, method, s.base+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	ex := Exchange{Request: Request{
+		Method: method,
+		URL:    req.URL.String(),
+		Header: captureHeader(req.Header, true),
+		Body:   string(data),
+	}}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		ex.Error = err.Error()
+		s.exchanges = append(s.exchanges, ex)
+		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	ex.Response = &Response{Status: resp.StatusCode, Header: captureHeader(resp.Header, false), Body: string(respBody)}
+	s.exchanges = append(s.exchanges, ex)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: reading the response: %w", method, path, err)
+	}
+	return &response{status: resp.StatusCode, header: resp.Header, body: respBody}, nil
+}
+
+func captureHeader(h http.Header, redact bool) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if redact && k == "Authorization" {
+			scheme, _, _ := strings.Cut(strings.Join(v, ","), " ")
+			out[k] = scheme + " [redacted]"
+			continue
+		}
+		out[k] = strings.Join(v, ",")
+	}
+	return out
+}
+
+// object decodes a JSON object response.
+func (r *response) object() (map[string]any, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(r.body, &doc); err != nil {
+		return nil, fmt.Errorf("response is not a JSON object: %v", err)
+	}
+	return doc, nil
+}
+
+// expect checks the status and decodes the JSON object response.
+func (r *response) expect(status int) (map[string]any, error) {
+	if r.status != status {
+		return nil, fmt.Errorf("expected status %d, got %d", status, r.status)
+	}
+	return r.object()
+}
+
+// expectError checks that r is an error envelope with status and code:
+// {"ok": false, "error": "...", "code": code, "status": status}.
+func (r *response) expectError(status int, code string) error {
+	if ct := r.header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		return fmt.Errorf("expected a JSON error envelope, got Content-Type %q and status %d", ct, r.status)
+	}
+	doc, err := r.object()
+	if err != nil {
+		return err
+	}
+	if got, _ := doc["code"].(string); got != code || r.status != status {
+		return fmt.Errorf("expected %d %s, got %d %v", status, code, r.status, doc["code"])
+	}
+	if ok, isBool := doc["ok"].(bool); !isBool || ok {
+		return errors.New(`error envelope: expected "ok": false`)
+	}
+	if msg, _ := doc["error"].(string); msg == "" {
+		return errors.New(`error envelope: expected a non-empty "error" message`)
+	}
+	if got, _ := doc["status"].(float64); int(got) != status {
+		return fmt.Errorf(`error envelope: expected "status": %d, got %v`, status, doc["status"])
+	}
+	return nil
+}
+
+// routeMissing reports whether r is the plain 404 a server gives for a
+// route it does not serve, i.e. a feature that is disabled.
+func (r *response) routeMissing() bool {
+	return r.status == http.StatusNotFound && !strings.HasPrefix(r.header.Get("Content-Type"), "application/json")
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,82 @@
+package conformance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestChecks_StableIDs(t *testing.T) {
+	valid := regexp.MustCompile(`^[a-z]+\.[A-Za-z_]+$`)
+	seen := map[string]bool{}
+	for _, c := range Checks() {
+		if !valid.MatchString(c.ID) {
+			t.Errorf("check ID %q is not area.name", c.ID)
+		}
+		if seen[c.ID] {
+			t.Errorf("duplicate check ID %q", c.ID)
+		}
+		for _, need := range c.Needs {
+			if !seen[need] {
+				t.Errorf("%s needs %s, which does not run before it", c.ID, need)
+			}
+		}
+		seen[c.ID] = true
+	}
+}
+
+func TestSelectChecks_AddsNeeds(t *testing.T) {
+	selected := selectChecks(suite, "search.basic, errors.UUID_CONFLICT")
+	for _, id := range []string{"search.basic", "write.basic", "registry.create", "errors.UUID_CONFLICT"} {
+		if !selected[id] {
+			t.Errorf("expected %s selected", id)
+		}
+	}
+	if selected["read.by_id"] {
+		t.Error("read.by_id was not selected")
+	}
+}
+
+// TestRun_CapturesFailures runs the suite against a server that fails
+// every request: failures carry their exchanges, and dependent checks are
+// skipped.
+func TestRun_CapturesFailures(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"ok":false,"error":"boom","code":"INTERNAL_ERROR","status":500}`))
+	}))
+	defer ts.Close()
+
+	report, err := Run(context.Background(), Options{BaseURL: ts.URL, Run: "registry.", AdminUser: "admin", AdminPassword: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || report.Failed == 0 {
+		t.Fatalf("expected failures, got %+v", report)
+	}
+	byID := map[string]Result{}
+	for _, res := range report.Results {
+		byID[res.ID] = res
+	}
+	create := byID["registry.create"]
+	if create.Status != Fail || len(create.Exchanges) != 1 {
+		t.Fatalf("expected registry.create to fail with its exchange, got %+v", create)
+	}
+	ex := create.Exchanges[0]
+	if ex.Request.Method != "POST" || ex.Response == nil || ex.Response.Status != 500 || ex.Response.Body == "" {
+		t.Errorf("exchange not captured: %+v", ex)
+	}
+	if get := byID["registry.get"]; get.Status != Skip || get.Message != "needs registry.create" {
+		t.Errorf("expected registry.get skipped, got %+v", get)
+	}
+	if _, ran := byID["write.basic"]; ran {
+		t.Error("write.basic was not selected")
+	}
+
+	if _, err := Run(context.Background(), Options{BaseURL: "localhost:6060"}); err == nil {
+		t.Error("expected an error for a base URL without a scheme")
+	}
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Files a first run writes to the data directory.
+const (
+	// StarterConfigFile is the config written on a first run. The server
+	// loads it from the data directory when no config file is given.
+	StarterConfigFile = "qubicdb.yaml"
+
+	// AdminPasswordFile holds the admin password generated on a first run.
+	AdminPasswordFile = "admin-password.txt"
+)
+
+// StarterConfigPath returns the path of the starter config in dataPath.
+func StarterConfigPath(dataPath string) string {
+	return filepath.Join(dataPath, StarterConfigFile)
+}
+
+// IsFirstRun reports whether a server start looks like the first one: no
+// config file was given, environ holds no QUBICDB_* variables and dataPath
+// is missing or empty. QUBICDB_ENV only selects production mode and does
+// not count. An unreadable data directory is not a first run.
+func IsFirstRun(configPath, dataPath string, environ []string) bool {
+	if configPath != "" {
+		return false
+	}
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, "QUBICDB_") && !strings.HasPrefix(kv, "QUBICDB_ENV=") {
+			return false
+		}
+	}
+	entries, err := os.ReadDir(dataPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	return err == nil && len(entries) == 0
+}
+
+// BootstrapResult describes what Bootstrap generated.
+type BootstrapResult struct {
+	// AdminPassword is the generated admin password, empty when the
+	// password was already set to something other than the default.
+	AdminPassword string
+
+	// PasswordPath and ConfigPath are the files written.
+	PasswordPath string
+	ConfigPath   string
+}
+
+// starterConfig is the part of Config a first run writes down. Everything
+// else keeps its default, so later releases can still change those.
+type starterConfig struct {
+	Server struct {
+		HTTPAddr string `yaml:"httpAddr"`
+	} `yaml:"server"`
+	Storage struct {
+		DataPath string `yaml:"dataPath"`
+	} `yaml:"storage"`
+	Admin struct {
+		Enabled       bool   `yaml:"enabled"`
+		User          string `yaml:"user"`
+		Password      string `yaml:"password"`
+		SessionSecret string `yaml:"sessionSecret"`
+	} `yaml:"admin"`
+	Security struct {
+		AllowedOrigins string `yaml:"allowedOrigins"`
+	} `yaml:"security"`
+}
+
+// Bootstrap prepares a first run's data directory. It replaces the default
+// admin password with a random one, written to AdminPasswordFile, generates
+// a session secret when none is set, and writes the resulting settings to
+// StarterConfigFile with owner-only permissions, so later runs keep them.
+// cfg is updated in place. Existing files are never overwritten.
+func Bootstrap(cfg *Config) (*BootstrapResult, error) {
+	dataPath := cfg.Storage.DataPath
+	if err := os.MkdirAll(dataPath, 0o755); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+
+	result := &BootstrapResult{
+		PasswordPath: filepath.Join(dataPath, AdminPasswordFile),
+		ConfigPath:   StarterConfigPath(dataPath),
+	}
+	password := cfg.Admin.Password
+	if password == DefaultAdminPassword {
+		generated, err := randomToken(18)
+		if err != nil {
+			return nil, err
+		}
+		password = base64.RawURLEncoding.EncodeToString(generated)
+		result.AdminPassword = password
+	}
+	secret := cfg.Admin.SessionSecret
+	if secret == "" {
+		generated, err := randomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(generated)
+	}
+
+	if result.AdminPassword != "" {
+		if err := writeNewFile(result.PasswordPath, []byte(password+"\n")); err != nil {
+			return nil, err
+		}
+	} else {
+		result.PasswordPath = ""
+	}
+
+	var starter starterConfig
+	starter.Server.HTTPAddr = cfg.Server.HTTPAddr
+	starter.Storage.DataPath = dataPath
+	if abs, err := filepath.Abs(dataPath); err == nil {
+		starter.Storage.DataPath = abs
+	}
+	starter.Admin.Enabled = cfg.Admin.Enabled
+	starter.Admin.User = cfg.Admin.User
+	starter.Admin.Password = password
+	starter.Admin.SessionSecret = secret
+	starter.Security.AllowedOrigins = cfg.Security.AllowedOrigins
+	data, err := yaml.Marshal(&starter)
+	if err != nil {
+		return nil, fmt.Errorf("rendering starter config: %w", err)
+	}
+	header := "# Written by QubicDB on its first run. Edit freely; it is loaded from the\n" +
+		"# data directory whenever no config file is given.\n"
+	if err := writeNewFile(result.ConfigPath, append([]byte(header), data...)); err != nil {
+		return nil, err
+	}
+
+	cfg.Admin.Password = password
+	cfg.Admin.SessionSecret = secret
+	return result, nil
+}
+
+// InsecureDefaults lists the security-relevant settings of cfg a new user
+// should know about, one line each.
+func InsecureDefaults(cfg *Config) []string {
+	var lines []string
+	addr := cfg.Server.HTTPAddr
+	if strings.HasPrefix(addr, ":") {
+		lines = append(lines, fmt.Sprintf("HTTP listens on %s on every network interface", addr))
+	} else {
+		lines = append(lines, "HTTP listens on "+addr)
+	}
+	if cfg.Security.TLSCert == "" {
+		lines = append(lines, "TLS is off: traffic, including admin logins, is plain HTTP")
+	}
+	if cfg.Admin.Enabled {
+		line := fmt.Sprintf("admin endpoints are enabled for user %q", cfg.Admin.User)
+		if cfg.Admin.Password == DefaultAdminPassword {
+			line += fmt.Sprintf(" with the default password %q", DefaultAdminPassword)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "CORS allows "+cfg.Security.AllowedOrigins)
+	if cfg.Vector.Enabled {
+		if _, err := os.Stat(cfg.Vector.ModelPath); err != nil {
+			lines = append(lines, fmt.Sprintf("vector model %s was not found: search is lexical only", cfg.Vector.ModelPath))
+		}
+	}
+	return lines
+}
+
+// randomToken returns n random bytes.
+func randomToken(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("generating secret: %w", err)
+	}
+	return b, nil
+}
+
+// writeNewFile writes data to a new file readable by its owner only.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
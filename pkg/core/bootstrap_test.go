@@ -0,0 +1,122 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsFirstRun(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "data")
+	empty := filepath.Join(dir, "empty")
+	used := filepath.Join(dir, "used")
+	os.Mkdir(empty, 0o755)
+	os.Mkdir(used, 0o755)
+	os.WriteFile(filepath.Join(used, "agent.nrdb"), []byte("x"), 0o644)
+	environ := []string{"HOME=/root", "PATH=/usr/bin"}
+
+	cases := []struct {
+		name       string
+		configPath string
+		dataPath   string
+		environ    []string
+		want       bool
+	}{
+		{"missing data directory", "", missing, environ, true},
+		{"empty data directory", "", empty, environ, true},
+		{"production mode only", "", empty, append(environ, "QUBICDB_ENV=production"), true},
+		{"config file", "qubicdb.yaml", empty, environ, false},
+		{"QUBICDB_ variable", "", empty, append(environ, "QUBICDB_HTTP_ADDR=:7070"), false},
+		{"data directory in use", "", used, environ, false},
+	}
+	for _, c := range cases {
+		if got := IsFirstRun(c.configPath, c.dataPath, c.environ); got != c.want {
+			t.Errorf("%s: IsFirstRun = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.DataPath = filepath.Join(t.TempDir(), "data")
+
+	boot, err := Bootstrap(cfg)
+	if err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if boot.AdminPassword == "" || boot.AdminPassword == DefaultAdminPassword || cfg.Admin.Password != boot.AdminPassword {
+		t.Fatalf("expected a generated admin password, got %q (config %q)", boot.AdminPassword, cfg.Admin.Password)
+	}
+	if len(boot.AdminPassword) < 20 || cfg.Admin.SessionSecret == "" {
+		t.Errorf("expected a long password and a session secret, got %q and %q", boot.AdminPassword, cfg.Admin.SessionSecret)
+	}
+	for _, path := range []string{boot.PasswordPath, boot.ConfigPath} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s: permissions %o, want 600", path, perm)
+		}
+	}
+	if data, _ := os.ReadFile(boot.PasswordPath); strings.TrimSpace(string(data)) != boot.AdminPassword {
+		t.Errorf("the password file holds %q", data)
+	}
+
+	// The starter config gives later runs the same settings.
+	loaded, err := ConfigFromFile(StarterConfigPath(cfg.Storage.DataPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Admin.Password != cfg.Admin.Password || loaded.Admin.SessionSecret != cfg.Admin.SessionSecret || loaded.Admin.User != "admin" {
+		t.Errorf("the starter config lost the generated values: %+v", loaded.Admin)
+	}
+	if abs, _ := filepath.Abs(cfg.Storage.DataPath); loaded.Storage.DataPath != abs {
+		t.Errorf("dataPath = %q, want %q", loaded.Storage.DataPath, abs)
+	}
+	if loaded.Matrix.MaxNeurons != DefaultConfig().Matrix.MaxNeurons {
+		t.Error("settings that were not generated should keep their defaults")
+	}
+
+	// The generated password passes the production checks.
+	t.Setenv("QUBICDB_ENV", "production")
+	if err := loaded.Validate(); err != nil {
+		t.Errorf("the starter config should validate in production: %v", err)
+	}
+	if err := DefaultConfig().Validate(); err == nil {
+		t.Error("the default password should still fail in production")
+	}
+
+	// Nothing is regenerated over an existing data directory.
+	again := DefaultConfig()
+	again.Storage.DataPath = cfg.Storage.DataPath
+	if _, err := Bootstrap(again); err == nil {
+		t.Error("a second bootstrap should not overwrite the generated files")
+	}
+	if data, _ := os.ReadFile(boot.PasswordPath); strings.TrimSpace(string(data)) != boot.AdminPassword {
+		t.Error("the password file was overwritten")
+	}
+}
+
+func TestBootstrapKeepsChosenPassword(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.DataPath = t.TempDir()
+	cfg.Admin.Password = "chosen-on-the-command-line"
+
+	boot, err := Bootstrap(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if boot.AdminPassword != "" || boot.PasswordPath != "" || cfg.Admin.Password != "chosen-on-the-command-line" {
+		t.Errorf("a chosen password should be kept, got %+v", boot)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Storage.DataPath, AdminPasswordFile)); !os.IsNotExist(err) {
+		t.Error("no password file should be written for a chosen password")
+	}
+	loaded, err := ConfigFromFile(boot.ConfigPath)
+	if err != nil || loaded.Admin.Password != "chosen-on-the-command-line" {
+		t.Errorf("the starter config should keep the chosen password: %v", err)
+	}
+}
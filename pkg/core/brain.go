@@ -2,16 +2,21 @@ package core
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"log"
+	"net/netip"
+	"net/url"
 	"os"
 	"os/signal"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,6 +26,10 @@ const (
 
 	// DefaultVectorEnabled keeps semantic/hybrid search on by default for internal usage.
 	DefaultVectorEnabled = true
+
+	// DefaultAdminPassword is the default admin password. It is rejected in
+	// production and replaced with a random one on a first run.
+	DefaultAdminPassword = "qubicdb"
 )
 
 var builtInMCPTools = map[string]struct{}{
@@ -30,6 +39,8 @@ var builtInMCPTools = map[string]struct{}{
 	"qubicdb_recall":                  {},
 	"qubicdb_context":                 {},
 	"qubicdb_registry_find_or_create": {},
+	"qubicdb_focus_set":               {},
+	"qubicdb_focus_clear":             {},
 }
 
 // ---------------------------------------------------------------------------
@@ -52,6 +63,78 @@ var builtInMCPTools = map[string]struct{}{
 type ServerConfig struct {
 	// HTTPAddr is the TCP address the HTTP/REST API binds to.
 	HTTPAddr string `yaml:"httpAddr"`
+
+	// IndexIDSource selects where requests may name their index: "header"
+	// (X-Index-ID), "query" (indexId/index_id) or "either". With "either",
+	// a header and query parameter that disagree are rejected; otherwise the
+	// other source is ignored.
+	IndexIDSource string `yaml:"indexIdSource"`
+
+	// MinAPIVersion is the oldest behavior version (YYYY-MM) clients may
+	// select with X-QubicDB-Version; older ones are refused. Empty accepts
+	// every version.
+	MinAPIVersion string `yaml:"minApiVersion"`
+
+	// Concurrency caps the number of in-flight requests per endpoint class.
+	Concurrency ConcurrencyConfig `yaml:"concurrency"`
+
+	// Shadow mirrors a sample of /v1 traffic to a secondary server.
+	Shadow ShadowConfig `yaml:"shadow"`
+}
+
+// ShadowConfig controls asynchronous traffic mirroring to a secondary
+// QubicDB, used to validate a new build against production traffic before
+// cutover. Mirroring is fire-and-forget and never affects primary responses.
+type ShadowConfig struct {
+	// URL is the base URL of the shadow server. Empty disables mirroring.
+	URL string `yaml:"url" secret:"url"`
+
+	// SampleRate is the fraction of eligible requests mirrored (0.0-1.0).
+	SampleRate float64 `yaml:"sampleRate"`
+
+	// Endpoints restricts mirroring to these /v1 path prefixes. When empty,
+	// every /v1 endpoint except /v1/config and registry mutations is mirrored.
+	// Sensitive endpoints are only mirrored when listed here explicitly.
+	Endpoints []string `yaml:"endpoints"`
+
+	// Compare checks each shadow response against the primary's status code
+	// and result count, counting and sampling mismatches.
+	Compare bool `yaml:"compare"`
+
+	// QueueSize bounds pending mirrored requests; overflow is dropped.
+	QueueSize int `yaml:"queueSize"`
+
+	// Timeout bounds each mirrored request.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// MismatchSampleSize is how many recent mismatches are kept for
+	// GET /admin/shadow/mismatches.
+	MismatchSampleSize int `yaml:"mismatchSampleSize"`
+}
+
+// ConcurrencyConfig bounds how many requests of each endpoint class may be
+// served at once. Unlike per-client rate limiting this is a global guard that
+// keeps expensive calls from saturating the worker pool. A limit of 0 disables
+// the cap for that class.
+type ConcurrencyConfig struct {
+	// Search caps concurrent /v1/search, /v1/recall and /v1/sync requests.
+	Search int `yaml:"search"`
+
+	// Context caps concurrent /v1/context requests.
+	Context int `yaml:"context"`
+
+	// Write caps concurrent mutating requests (/v1/write, /v1/touch, /v1/forget, /v1/fire).
+	Write int `yaml:"write"`
+
+	// Admin caps concurrent /admin/* and /v1/config requests.
+	Admin int `yaml:"admin"`
+
+	// Default caps every other endpoint.
+	Default int `yaml:"default"`
+
+	// QueueTimeout is how long a request waits for a free slot before it is
+	// rejected with 503 Service Unavailable.
+	QueueTimeout time.Duration `yaml:"queueTimeout"`
 }
 
 // StorageConfig groups persistence-related settings.
@@ -77,6 +160,150 @@ type StorageConfig struct {
 
 	// StartupRepair enables startup integrity repair for corrupt/missing persisted data files.
 	StartupRepair bool `yaml:"startupRepair"`
+
+	// ManifestRetain is how many manifest/checkpoint versions are kept under
+	// manifest/ and checkpoints/, including the current one. Older versions
+	// are deleted after each flush. 0 keeps the full history.
+	ManifestRetain int `yaml:"manifestRetain"`
+
+	// StartupReportRetain is how many startup recovery reports are kept
+	// under reports/. 0 keeps all of them.
+	StartupReportRetain int `yaml:"startupReportRetain"`
+
+	// RetainVersions is how many earlier data files are kept per index
+	// under data/versions/<index>/ for as-of reads and restores. Each
+	// flush that changes an index keeps the file it replaces. 0 disables
+	// versioning.
+	RetainVersions int `yaml:"retainVersions"`
+
+	// RetainVersionsMaxAge removes retained versions older than this.
+	// 0 bounds versions by count only.
+	RetainVersionsMaxAge time.Duration `yaml:"retainVersionsMaxAge"`
+
+	// NeuronRecycleWindow is how long a forgotten neuron stays in its
+	// index's recycle bin, restorable with its synapses, before the prune
+	// daemon removes it for good. 0 removes forgotten neurons at once.
+	NeuronRecycleWindow time.Duration `yaml:"neuronRecycleWindow"`
+
+	// DiskCheckInterval is how often the free space of the data volume is
+	// checked. Flushes of large indexes also check it. 0 disables the
+	// checks, and with them read-only mode.
+	DiskCheckInterval time.Duration `yaml:"diskCheckInterval"`
+
+	// WarnFreeBytes is the free space below which /health reports the
+	// volume as low. 0 disables the warning.
+	WarnFreeBytes int64 `yaml:"warnFreeBytes"`
+
+	// MinFreeBytes is the free space below which the server turns
+	// read-only: mutations are refused with 507 until space recovers.
+	// 0 disables read-only mode.
+	MinFreeBytes int64 `yaml:"minFreeBytes"`
+
+	// FlushRetryBackoff is how long the background flush waits before
+	// retrying an index whose flush failed, doubled per further failure up
+	// to FlushRetryMaxBackoff. 0 retries on every pass.
+	FlushRetryBackoff    time.Duration `yaml:"flushRetryBackoff"`
+	FlushRetryMaxBackoff time.Duration `yaml:"flushRetryMaxBackoff"`
+
+	// FlushStaleAfter lists an index whose flushes have failed for this
+	// long under stale_indexes in the store stats, logs it as an alert and
+	// degrades /health. Past FlushStaleFailAfter /health reports the
+	// instance unavailable. 0 disables either.
+	FlushStaleAfter     time.Duration `yaml:"flushStaleAfter"`
+	FlushStaleFailAfter time.Duration `yaml:"flushStaleFailAfter"`
+
+	// AttachmentSweepInterval is how often attachment blobs under
+	// data/blobs/ that no neuron references any more are removed. 0
+	// disables the sweep.
+	AttachmentSweepInterval time.Duration `yaml:"attachmentSweepInterval"`
+
+	// AttachmentGracePeriod protects blobs uploaded or referenced within
+	// this long from the sweep, so an upload is not collected before the
+	// write that references it.
+	AttachmentGracePeriod time.Duration `yaml:"attachmentGracePeriod"`
+
+	// IndexQuotaBytes caps the on-disk bytes of each index: its data file,
+	// its WAL records since the last flush and the attachment blobs it
+	// references. A write that would take an index past the quota is
+	// handled by IndexQuotaPolicy. The registry "quota" key overrides both
+	// per index. 0 disables the quota.
+	IndexQuotaBytes int64 `yaml:"indexQuotaBytes"`
+
+	// IndexQuotaPolicy is what a write past the quota does: reject answers
+	// 507 STORAGE_QUOTA_EXCEEDED, evict_lowest_energy forgets the unpinned
+	// neurons with the least energy to make room.
+	IndexQuotaPolicy string `yaml:"indexQuotaPolicy"`
+
+	// IndexQuotaGrace is the factor of the quota writes are refused at,
+	// absorbing the error of estimating an index's size between flushes.
+	IndexQuotaGrace float64 `yaml:"indexQuotaGrace"`
+
+	// Seed lists corpus files loaded into indexes at startup. An index is
+	// only seeded while it is empty, unless SeedForce is set.
+	Seed []SeedConfig `yaml:"seed"`
+
+	// SeedForce truncates each seeded index and reloads its corpus on every
+	// startup, even when the index already holds data.
+	SeedForce bool `yaml:"seedForce"`
+
+	// WALArchive copies WAL records to an archive directory for
+	// point-in-time recovery with `qubicdb restore-pitr`.
+	WALArchive WALArchiveConfig `yaml:"walArchive"`
+
+	// Migration bounds moving the data directory with POST
+	// /admin/migrate-storage.
+	Migration MigrationConfig `yaml:"migration"`
+}
+
+// MigrationConfig controls online storage migrations. The data directory
+// is copied while the server runs, then re-copied in delta passes for the
+// indexes written meanwhile; the last changes are copied during a short
+// pause of writes, after which the server uses the new directory.
+type MigrationConfig struct {
+	// MaxPasses bounds the delta passes after the full copy. The
+	// switchover follows the last one, whatever changed during it.
+	MaxPasses int `yaml:"maxPasses"`
+
+	// SettleIndexes ends the delta passes once at most this many indexes
+	// were written during the previous one.
+	SettleIndexes int `yaml:"settleIndexes"`
+
+	// MaxPause bounds the switchover's write pause. Mutations arriving
+	// during it get 503 with Retry-After; a switchover that cannot finish
+	// in time is abandoned, leaving the server on its data directory.
+	MaxPause time.Duration `yaml:"maxPause"`
+}
+
+// WALArchiveConfig controls WAL archiving. Records appended to the WAL are
+// queued for a background archiver, which gathers them into numbered
+// segment files, each with a metadata file, in Dir. The write path never
+// waits for it: a record arriving while the queue is full is dropped and
+// counted, leaving a gap the next segment records.
+type WALArchiveConfig struct {
+	// Dir is the archive directory. Empty disables archiving. It should
+	// live outside the data directory, ideally on another volume.
+	Dir string `yaml:"dir"`
+
+	// QueueSize bounds the records waiting to be archived.
+	QueueSize int `yaml:"queueSize"`
+
+	// SegmentBytes and SegmentInterval bound a segment: it is completed
+	// once it holds SegmentBytes, or when its first record is
+	// SegmentInterval old. A restore can only replay completed segments.
+	SegmentBytes    int64         `yaml:"segmentBytes"`
+	SegmentInterval time.Duration `yaml:"segmentInterval"`
+
+	// MaxAge removes segments whose last record is older; MaxBytes removes
+	// the oldest segments while the archive holds more. 0 disables either.
+	MaxAge   time.Duration `yaml:"maxAge"`
+	MaxBytes int64         `yaml:"maxBytes"`
+}
+
+// SeedConfig names a YAML/JSON corpus file to load into an index. The file
+// is a list of {key, content, metadata, parent_ref} entries.
+type SeedConfig struct {
+	IndexID string `yaml:"indexId"`
+	File    string `yaml:"file"`
 }
 
 // MatrixConfig groups organic memory matrix bounds.
@@ -89,6 +316,12 @@ type MatrixConfig struct {
 
 	// MaxNeurons is the hard cap on the number of neurons per brain.
 	MaxNeurons int `yaml:"maxNeurons"`
+
+	// IndexedMetadataKeys are the metadata keys every brain keeps an
+	// inverted index of, so strict filters on them skip the full scan. An
+	// index may add keys through its registry metadata; empty disables the
+	// index.
+	IndexedMetadataKeys []string `yaml:"indexedMetadataKeys"`
 }
 
 // LifecycleConfig groups brain state transition thresholds.
@@ -104,6 +337,14 @@ type LifecycleConfig struct {
 	// DormantThreshold is how long a brain must be sleeping before
 	// transitioning from Sleeping → Dormant (eligible for eviction).
 	DormantThreshold time.Duration `yaml:"dormantThreshold"`
+
+	// StateWaitMax caps the timeout a client may ask of
+	// GET /v1/brain/state/wait.
+	StateWaitMax time.Duration `yaml:"stateWaitMax"`
+
+	// MaxStateWaiters is how many state waits may be open on one index at
+	// once; further waits are answered with 429.
+	MaxStateWaiters int `yaml:"maxStateWaiters"`
 }
 
 // DaemonConfig groups background daemon interval settings.
@@ -126,6 +367,155 @@ type DaemonConfig struct {
 	// ReorgInterval controls how often the matrix reorganisation daemon runs.
 	// Reorg optimises spatial locality for frequently co-accessed neurons.
 	ReorgInterval time.Duration `yaml:"reorgInterval"`
+
+	// Decay controls how the decay daemon is scheduled.
+	Decay DecayConfig `yaml:"decay"`
+
+	// Prune controls which synapses a prune pass removes.
+	Prune PruneConfig `yaml:"prune"`
+
+	// Consolidate controls what a consolidation pass does besides
+	// deepening mature neurons.
+	Consolidate ConsolidateConfig `yaml:"consolidate"`
+}
+
+// ConsolidateConfig controls the optional stages of a consolidation pass.
+type ConsolidateConfig struct {
+	// CompactContent truncates the content of old, faded surface memories.
+	CompactContent CompactContentConfig `yaml:"compactContent"`
+
+	// PromotionHistory is how many promotion records each neuron keeps:
+	// when a pass deepened it and the access count, energy, age and
+	// synapses it was deepened on. 0 records none.
+	PromotionHistory int `yaml:"promotionHistory"`
+
+	// Adaptive schedules consolidation per index by its write rate.
+	Adaptive AdaptiveScheduleConfig `yaml:"adaptive"`
+}
+
+// DecayConfig controls the decay daemon.
+type DecayConfig struct {
+	// Adaptive schedules decay per index by its write rate. A pass decays
+	// by a fixed step, so idle indexes visited less often fade more
+	// slowly.
+	Adaptive AdaptiveScheduleConfig `yaml:"adaptive"`
+}
+
+// AdaptiveScheduleConfig schedules a daemon per index instead of visiting
+// every index each interval. An index writing WriteRateTarget writes per
+// minute is visited every daemon interval; busier indexes proportionally
+// more often, down to MinInterval, quieter ones less often, up to
+// MaxInterval. An index that has not written since its last visit waits
+// MaxInterval.
+type AdaptiveScheduleConfig struct {
+	// Enabled turns adaptive scheduling on. Off by default.
+	Enabled bool `yaml:"enabled"`
+
+	// MinInterval is the shortest time between visits to one index. The
+	// daemon wakes this often to find the indexes that are due.
+	MinInterval time.Duration `yaml:"minInterval"`
+
+	// MaxInterval is the longest time an index goes without a visit.
+	MaxInterval time.Duration `yaml:"maxInterval"`
+
+	// WriteRateTarget is the write rate, in writes per minute, at which an
+	// index is visited every daemon interval.
+	WriteRateTarget float64 `yaml:"writeRateTarget"`
+}
+
+// DefaultAdaptiveSchedule returns the adaptive schedule defaults, off: a
+// visit at least every minute and at most every day, at the daemon
+// interval for 10 writes a minute.
+func DefaultAdaptiveSchedule() AdaptiveScheduleConfig {
+	return AdaptiveScheduleConfig{
+		MinInterval:     time.Minute,
+		MaxInterval:     24 * time.Hour,
+		WriteRateTarget: 10,
+	}
+}
+
+// validate checks the schedule; name is its config path.
+func (a AdaptiveScheduleConfig) validate(name string) error {
+	if a.MinInterval <= 0 {
+		return fmt.Errorf("%s.minInterval must be > 0", name)
+	}
+	if a.MaxInterval < a.MinInterval {
+		return fmt.Errorf("%s.maxInterval must be >= minInterval", name)
+	}
+	if a.WriteRateTarget <= 0 {
+		return fmt.Errorf("%s.writeRateTarget must be > 0", name)
+	}
+	return nil
+}
+
+// CompactContentConfig controls content compaction. A neuron older than
+// MinAge, below MaxEnergy and still at depth 0 has its content cut to
+// KeepChars characters and an ellipsis, and is flagged under the
+// _compacted and _orig_len metadata keys. Metadata and synapses are kept.
+// Pinned neurons, and neurons fired within RecentAccess, are exempt.
+// Searches no longer find a compacted neuron by the text that was cut.
+type CompactContentConfig struct {
+	// Enabled turns compaction on. Off by default.
+	Enabled bool `yaml:"enabled"`
+
+	// MinAge is how old a neuron must be.
+	MinAge time.Duration `yaml:"minAge"`
+
+	// MaxEnergy is the energy a neuron must be below.
+	MaxEnergy float64 `yaml:"maxEnergy"`
+
+	// KeepChars is how many characters (runes) of content are kept.
+	KeepChars int `yaml:"keepChars"`
+
+	// RecentAccess exempts neurons fired within it.
+	RecentAccess time.Duration `yaml:"recentAccess"`
+}
+
+// PruneConfig controls synapse pruning. Each synapse gets a keep score from
+// 0 to 1; a prune pass removes those below MinScore, then the lowest-scored
+// until every neuron and the index are within budget.
+type PruneConfig struct {
+	// SynapseScore weighs the factors of the keep score.
+	SynapseScore SynapseScoreConfig `yaml:"synapseScore"`
+
+	// MinScore removes synapses scoring below it regardless of budget.
+	MinScore float64 `yaml:"minScore"`
+
+	// MaxSynapsesPerNeuron is how many synapses a neuron keeps. 0 means
+	// unbounded.
+	MaxSynapsesPerNeuron int `yaml:"maxSynapsesPerNeuron"`
+
+	// MaxSynapses is how many synapses an index keeps. 0 means unbounded.
+	MaxSynapses int `yaml:"maxSynapses"`
+
+	// Adaptive schedules pruning per index by its write rate.
+	Adaptive AdaptiveScheduleConfig `yaml:"adaptive"`
+}
+
+// SynapseScoreConfig weighs the factors of a synapse's keep score, which is
+// their weighted mean. Each factor is in [0, 1].
+type SynapseScoreConfig struct {
+	// Weight weighs the synapse's Hebbian weight.
+	Weight float64 `yaml:"weight"`
+
+	// CoFire weighs how often the endpoints have fired together.
+	CoFire float64 `yaml:"coFire"`
+
+	// Recency weighs how recently the endpoints last fired together.
+	Recency float64 `yaml:"recency"`
+
+	// Depth weighs how consolidated the shallower endpoint is, so links
+	// between deep neurons outlast links to fresh ones.
+	Depth float64 `yaml:"depth"`
+
+	// RecencyHalfLife is the time since the last co-fire at which the
+	// recency factor has fallen to 0.5.
+	RecencyHalfLife time.Duration `yaml:"recencyHalfLife"`
+
+	// TypeWeights multiplies the keep score of synapses by their type, so
+	// a relation such as "follows" can outlast plain associations. Types
+	// not listed keep a factor of 1; the score stays capped at 1.
+	TypeWeights map[string]float64 `yaml:"typeWeights"`
 }
 
 // WorkerConfig groups worker pool settings.
@@ -133,6 +523,47 @@ type WorkerConfig struct {
 	// MaxIdleTime is the maximum duration a brain worker may remain idle
 	// before being evicted from the in-memory pool.
 	MaxIdleTime time.Duration `yaml:"maxIdleTime"`
+
+	// BackgroundSlice bounds how long a background (daemon) operation runs
+	// before pausing to serve queued interactive requests. Interactive
+	// operations wait at most about one slice behind maintenance work.
+	BackgroundSlice time.Duration `yaml:"backgroundSlice"`
+
+	// ReadConcurrency bounds how many read-only operations (search, read,
+	// recall, stats) a worker runs in parallel. Reads do not queue behind
+	// writes, so a read may miss writes still queued when it was submitted
+	// unless it asks for strong consistency.
+	ReadConcurrency int `yaml:"readConcurrency"`
+
+	// LoadTimeout bounds how long a request waits for a dormant index to
+	// load from disk. Concurrent requests share one load; those still
+	// waiting after LoadTimeout get 503 INDEX_LOADING while the load
+	// finishes in the background. 0 waits for as long as the load takes.
+	LoadTimeout time.Duration `yaml:"loadTimeout"`
+
+	// DeadLetters controls what is kept of operations that panic and when
+	// an index that keeps panicking is quarantined.
+	DeadLetters DeadLetterConfig `yaml:"deadLetters"`
+}
+
+// DeadLetterConfig groups dead-letter settings. A worker operation that
+// panics fails with 500 instead of taking the server down, and a record of
+// it (operation, payload summary, stack) is kept in memory, listed by
+// GET /admin/deadletters, and written under data/deadletter/.
+type DeadLetterConfig struct {
+	// Capacity is how many records the in-memory list keeps.
+	Capacity int `yaml:"capacity"`
+
+	// MaxBytes caps data/deadletter/; the oldest records are removed past
+	// it. 0 keeps records in memory only.
+	MaxBytes int64 `yaml:"maxBytes"`
+
+	// QuarantineAfter panics of one index within QuarantineWindow
+	// quarantine it: its operations are refused with 503 for
+	// QuarantineDuration. 0 never quarantines.
+	QuarantineAfter    int           `yaml:"quarantineAfter"`
+	QuarantineWindow   time.Duration `yaml:"quarantineWindow"`
+	QuarantineDuration time.Duration `yaml:"quarantineDuration"`
 }
 
 // RegistryConfig groups UUID registry settings.
@@ -171,6 +602,55 @@ type VectorConfig struct {
 	// Must be >= 512 for MiniLM. Increase if QueryRepeat×queryTokens > 512.
 	// Default: 512
 	EmbedContextSize uint32 `yaml:"embedContextSize"`
+
+	// Models lists additional embedding models that indexes may select by
+	// name through the "vector" key of their registry metadata. They are
+	// loaded on first use and share EmbedContextSize.
+	Models []VectorModelConfig `yaml:"models"`
+
+	// MaxLoadedModels bounds how many Models are loaded at once; the least
+	// recently used one is unloaded to make room and reloaded on demand.
+	// The default model (ModelPath) is not counted. Default: 2
+	MaxLoadedModels int `yaml:"maxLoadedModels"`
+
+	// ProbeTimeout bounds the startup warm-up embedding and each liveness
+	// probe; a probe that takes longer marks the vector layer failed.
+	// Default: 10s
+	ProbeTimeout time.Duration `yaml:"probeTimeout"`
+
+	// ProbeInterval runs a liveness probe against the default model this
+	// often, so a wedged llama.cpp backend degrades search to lexical
+	// matching instead of hanging it. 0 disables the probe. Default: 0
+	ProbeInterval time.Duration `yaml:"probeInterval"`
+
+	// Retries is how many more times a failed embedding call is attempted;
+	// the first retry waits RetryBackoff, each later one twice as long.
+	// Default: 2, 50ms
+	Retries      int           `yaml:"retries"`
+	RetryBackoff time.Duration `yaml:"retryBackoff"`
+
+	// BreakerThreshold opens a model's circuit breaker after that many
+	// embedding calls in a row fail. While open, searches are scored
+	// lexically and writes are stored unembedded and queued; after
+	// BreakerCooldown one call probes the model and closes the breaker if
+	// it succeeds. 0 disables the breaker. Default: 5, 30s
+	BreakerThreshold int           `yaml:"breakerThreshold"`
+	BreakerCooldown  time.Duration `yaml:"breakerCooldown"`
+
+	// PendingEmbedInterval is how often neurons queued by a failed embedding
+	// call are embedded again. Default: 30s
+	PendingEmbedInterval time.Duration `yaml:"pendingEmbedInterval"`
+}
+
+// DefaultEmbeddingModel names the model configured by vector.modelPath.
+// Embeddings without a model tag were produced by it.
+const DefaultEmbeddingModel = "default"
+
+// VectorModelConfig is a named embedding model profile.
+type VectorModelConfig struct {
+	Name      string `yaml:"name"`
+	Path      string `yaml:"path"` // GGUF file
+	GPULayers int    `yaml:"gpuLayers"`
 }
 
 // AdminConfig groups server administration settings.
@@ -184,7 +664,75 @@ type AdminConfig struct {
 
 	// Password is the admin password for /admin/login authentication.
 	// WARNING: Change the default before deploying to production.
-	Password string `yaml:"password"`
+	// User and Password always authenticate with the admin role; leave
+	// both empty to allow only the accounts listed in Users.
+	Password string `yaml:"password" secret:"true"`
+
+	// Users lists additional admin accounts with bcrypt password hashes
+	// (see `qubicdb hash-password`) and a role limiting what they may do.
+	Users []AdminUser `yaml:"users"`
+
+	// SessionSecret keys the HMAC of the session tokens /admin/login
+	// returns. Empty uses a random key per process, so tokens stop working
+	// when the server restarts; set it to keep them valid across restarts
+	// and replicas.
+	SessionSecret string `yaml:"sessionSecret" secret:"true"`
+
+	// SessionTTL is how long a session token stays valid.
+	SessionTTL time.Duration `yaml:"sessionTTL"`
+
+	// Clone controls anonymized index copies made by
+	// POST /admin/indexes/{id}/clone.
+	Clone CloneConfig `yaml:"clone"`
+
+	// Journal bounds the per-index operation journals started with
+	// POST /admin/indexes/{id}/operations.
+	Journal JournalConfig `yaml:"journal"`
+}
+
+// Admin roles for AdminUser.Role, from least to most privileged.
+const (
+	RoleViewer   = "viewer"   // read-only access to /admin and /v1/config
+	RoleOperator = "operator" // viewer plus persist, gc, daemons and wake/sleep
+	RoleAdmin    = "admin"    // everything, including destructive operations
+)
+
+// AdminUser is a role-scoped admin account.
+type AdminUser struct {
+	User         string `yaml:"user"`
+	PasswordHash string `yaml:"passwordHash" secret:"true"` // bcrypt
+	Role         string `yaml:"role"`                       // viewer | operator | admin
+}
+
+// Content anonymization modes for CloneConfig.ContentMode.
+const (
+	CloneContentHash   = "hash"
+	CloneContentRedact = "redact"
+)
+
+// CloneConfig controls how anonymized clones are scrubbed.
+type CloneConfig struct {
+	// ContentMode is hash (content replaced by its SHA-256 digest, so equal
+	// memories stay equal) or redact (a fixed placeholder).
+	ContentMode string `yaml:"contentMode"`
+
+	// StripMetadataKeys are removed from every neuron's metadata.
+	StripMetadataKeys []string `yaml:"stripMetadataKeys"`
+}
+
+// JournalConfig bounds per-index operation journals, which record every
+// operation an index's worker runs for post-mortem debugging.
+type JournalConfig struct {
+	// DefaultTTL is how long a journal records when the request does not
+	// say.
+	DefaultTTL time.Duration `yaml:"defaultTTL"`
+
+	// MaxTTL is the longest a journal may record before it turns itself
+	// off, so one cannot be left on by accident.
+	MaxTTL time.Duration `yaml:"maxTTL"`
+
+	// Capacity is how many recent operations a journal keeps in memory.
+	Capacity int `yaml:"capacity"`
 }
 
 // MCPConfig groups Model Context Protocol endpoint settings.
@@ -196,7 +744,7 @@ type MCPConfig struct {
 	Path string `yaml:"path"`
 
 	// APIKey is optional shared secret validated from X-API-Key or Bearer token.
-	APIKey string `yaml:"apiKey"`
+	APIKey string `yaml:"apiKey" secret:"true"`
 
 	// Stateless enables stateless session-id handling for streamable HTTP.
 	Stateless bool `yaml:"stateless"`
@@ -213,6 +761,11 @@ type MCPConfig struct {
 
 	// AllowedTools is an optional allowlist; empty means all built-in MCP tools.
 	AllowedTools []string `yaml:"allowedTools"`
+
+	// MaxToolDurationMS caps the time budget of every tool call, in
+	// milliseconds. It applies when a call sets no timeout_ms, and a larger
+	// timeout_ms is cut down to it.
+	MaxToolDurationMS int `yaml:"maxToolDurationMS"`
 }
 
 // SecurityConfig groups network security and request-limiting settings.
@@ -232,6 +785,10 @@ type SecurityConfig struct {
 	// Default: 65536 (64 KB).
 	MaxNeuronContentBytes int64 `yaml:"maxNeuronContentBytes"`
 
+	// MaxAttachmentBytes is the maximum size of a blob uploaded to
+	// POST /v1/attachments. Default: 8388608 (8 MB).
+	MaxAttachmentBytes int64 `yaml:"maxAttachmentBytes"`
+
 	// TLSCert is the path to a TLS certificate file for HTTPS.
 	// Leave empty to disable TLS (plain HTTP). Requires TLSKey.
 	TLSCert string `yaml:"tlsCert"`
@@ -245,6 +802,555 @@ type SecurityConfig struct {
 
 	// WriteTimeout is the maximum duration before timing out writes of the response.
 	WriteTimeout time.Duration `yaml:"writeTimeout"`
+
+	// TrustedProxies lists the reverse proxies (IPs or CIDRs) whose
+	// X-Forwarded-For header is believed when identifying the client for
+	// auth throttling. From any other peer the header is ignored.
+	TrustedProxies []string `yaml:"trustedProxies"`
+
+	// AuthThrottle locks out clients and usernames that keep failing admin
+	// authentication.
+	AuthThrottle AuthThrottleConfig `yaml:"authThrottle"`
+}
+
+// AuthThrottleConfig configures the lockout applied to /admin/login and
+// admin Basic Auth. Failures are counted per client IP and per username in
+// memory, so a restart clears them.
+type AuthThrottleConfig struct {
+	// MaxFailures is how many consecutive failures lock a client IP or
+	// username out. 0 disables throttling.
+	MaxFailures int `yaml:"maxFailures"`
+
+	// LockoutBase is the first lockout's length. Each further failure after
+	// a lockout doubles it.
+	LockoutBase time.Duration `yaml:"lockoutBase"`
+
+	// LockoutMax caps a lockout's length. Failure counts are also forgotten
+	// after this long without a failure.
+	LockoutMax time.Duration `yaml:"lockoutMax"`
+}
+
+// WriteConfig groups write-path content normalization settings.
+type WriteConfig struct {
+	// SanitizeContent repairs malformed content instead of rejecting it:
+	// invalid UTF-8 is replaced with U+FFFD and overlong lines are wrapped.
+	// Control characters other than \n and \t are always stripped.
+	SanitizeContent bool `yaml:"sanitizeContent"`
+
+	// MaxLineLength is the maximum length of a single content line in
+	// characters. Set to 0 to disable the check.
+	MaxLineLength int `yaml:"maxLineLength"`
+
+	// DetectConflicts compares each new neuron with its most similar
+	// existing neurons and flags likely contradictions with a shared
+	// _conflict_group metadata value. The write itself is never blocked
+	// or altered.
+	DetectConflicts bool `yaml:"detectConflicts"`
+
+	// Conflicts tunes contradiction detection.
+	Conflicts ConflictConfig `yaml:"conflicts"`
+}
+
+// ConflictConfig tunes write-time contradiction detection. Two neurons are
+// only compared when they share a value under one of Keys or state the same
+// kind of fact (phone, email, employer, location).
+type ConflictConfig struct {
+	// TopK is how many of the most similar candidates are checked.
+	TopK int `yaml:"topK"`
+
+	// VectorThreshold is the minimum cosine similarity between embeddings.
+	VectorThreshold float64 `yaml:"vectorThreshold"`
+
+	// LexicalThreshold is the minimum token overlap (Jaccard) used when
+	// either neuron has no embedding.
+	LexicalThreshold float64 `yaml:"lexicalThreshold"`
+
+	// MinEnergy skips faded neurons below this energy.
+	MinEnergy float64 `yaml:"minEnergy"`
+
+	// Keys are metadata keys whose equal values mark neurons as statements
+	// about the same thing, e.g. {"fact": "home_city"}.
+	Keys []string `yaml:"keys"`
+}
+
+// SearchConfig groups search-path settings.
+type SearchConfig struct {
+	// Telemetry controls aggregate retrieval quality metrics.
+	Telemetry SearchTelemetryConfig `yaml:"telemetry"`
+
+	// BM25 tunes lexical scoring.
+	BM25 BM25Config `yaml:"bm25"`
+
+	// Lexical controls how text is normalized for lexical scoring.
+	Lexical LexicalConfig `yaml:"lexical"`
+
+	// AnchorWeight is the share of an anchored search's score (anchor_id)
+	// that comes from relatedness to the anchor rather than the query,
+	// when the request does not set anchor_weight.
+	AnchorWeight float64 `yaml:"anchorWeight"`
+
+	// Reinforce bounds group co-firing, which a search or context with
+	// reinforce: true applies to the neurons it returns.
+	Reinforce ReinforceConfig `yaml:"reinforce"`
+
+	// Expansion adds the terms an index associates with a short query to
+	// the query.
+	Expansion ExpansionConfig `yaml:"expansion"`
+}
+
+// BM25Config tunes the BM25 lexical scorer.
+type BM25Config struct {
+	// K1 controls term-frequency saturation: higher values let repeated
+	// terms keep adding to the score for longer.
+	K1 float64 `yaml:"k1"`
+
+	// B is the strength of length normalization, from 0 (none) to 1 (full).
+	B float64 `yaml:"b"`
+
+	// MaxTerms caps the per-index document-frequency table. Past it, terms
+	// seen in a single neuron are dropped. 0 means unbounded.
+	MaxTerms int `yaml:"maxTerms"`
+}
+
+// LexicalConfig controls the folding lexical scoring applies to stored
+// content and queries alike: case folding always, Turkish casing for
+// Turkish text, and diacritic folding unless turned off. Embeddings see the
+// original text.
+type LexicalConfig struct {
+	// FoldDiacritics strips diacritics, so "kadikoy" matches "Kadıköy" and
+	// "muller" matches "Müller". Turn off where diacritics are contrastive.
+	FoldDiacritics bool `yaml:"foldDiacritics"`
+
+	// Language is the language of neurons without a _lang and of queries
+	// without a lang, e.g. "tr" to case-fold I to ı. Empty means none.
+	Language string `yaml:"language"`
+}
+
+// ExpansionConfig controls query expansion for search and context: a
+// cheap lexical first pass finds the terms the index's own memories
+// associate with a short query, and the retrieval adds them, to the
+// embedded text and lexically at Weight. A request sets expand_query to
+// turn it on or off; an index's registry "expansion" metadata overrides
+// these settings.
+type ExpansionConfig struct {
+	// Enabled expands the queries of requests that do not set
+	// expand_query.
+	Enabled bool `yaml:"enabled"`
+
+	// MaxTerms caps the terms added to a query.
+	MaxTerms int `yaml:"maxTerms"`
+
+	// MaxAddedTokens caps the estimated tokens (4 characters each) the
+	// added terms make up.
+	MaxAddedTokens int `yaml:"maxAddedTokens"`
+
+	// MaxCueTokens is the longest query, in tokens, that is expanded.
+	// Longer ones say enough on their own.
+	MaxCueTokens int `yaml:"maxCueTokens"`
+
+	// Seeds is how many neurons the first pass draws terms from, before
+	// following their synapses.
+	Seeds int `yaml:"seeds"`
+
+	// Weight is how much an added term counts lexically relative to a
+	// term of the query, above 0 and at most 1.
+	Weight float64 `yaml:"weight"`
+}
+
+// MaxExpansionSeeds bounds ExpansionConfig.Seeds.
+const MaxExpansionSeeds = 50
+
+// Validate checks the bounds; errors name the field, e.g. "maxTerms must
+// be >= 1".
+func (c ExpansionConfig) Validate() error {
+	switch {
+	case c.MaxTerms < 1:
+		return fmt.Errorf("maxTerms must be >= 1, got %d", c.MaxTerms)
+	case c.MaxAddedTokens < 1:
+		return fmt.Errorf("maxAddedTokens must be >= 1, got %d", c.MaxAddedTokens)
+	case c.MaxCueTokens < 1:
+		return fmt.Errorf("maxCueTokens must be >= 1, got %d", c.MaxCueTokens)
+	case c.Seeds < 1 || c.Seeds > MaxExpansionSeeds:
+		return fmt.Errorf("seeds must be between 1 and %d, got %d", MaxExpansionSeeds, c.Seeds)
+	case c.Weight <= 0 || c.Weight > 1:
+		return fmt.Errorf("weight must be above 0.0 and at most 1.0, got %f", c.Weight)
+	}
+	return nil
+}
+
+// ReinforceConfig bounds group co-firing: every synapse between neurons
+// retrieved together is strengthened, with an increment scaled down by the
+// group's size, and missing ones are created between alike members.
+type ReinforceConfig struct {
+	// SimilarityFloor is how alike (embedding cosine, else token Jaccard)
+	// two members must be for a synapse to be created between them.
+	SimilarityFloor float64 `yaml:"similarityFloor"`
+
+	// MaxNewSynapses caps the synapses one co-fire creates, most alike
+	// pairs first. 0 only strengthens existing synapses.
+	MaxNewSynapses int `yaml:"maxNewSynapses"`
+}
+
+// SearchTelemetryConfig controls opt-in search quality telemetry.
+type SearchTelemetryConfig struct {
+	// Enabled turns on in-memory rolling aggregates for search and context
+	// calls, exposed at GET /admin/search-metrics.
+	Enabled bool `yaml:"enabled"`
+
+	// SampleZeroResults keeps the raw text of zero-result queries in a ring
+	// buffer so admins can spot vocabulary gaps. Off by default: no query text
+	// is stored unless this is set.
+	SampleZeroResults bool `yaml:"sampleZeroResults"`
+
+	// ZeroResultSampleSize is the ring buffer capacity for zero-result samples.
+	ZeroResultSampleSize int `yaml:"zeroResultSampleSize"`
+}
+
+// Index attribute modes for TelemetryConfig.IndexAttribute.
+const (
+	TraceIndexRaw  = "raw"
+	TraceIndexHash = "hash"
+	TraceIndexOmit = "omit"
+)
+
+// TelemetryConfig controls OpenTelemetry tracing.
+type TelemetryConfig struct {
+	// OTLPEndpoint is the OTLP/HTTP collector URL traces are exported to,
+	// e.g. http://otel-collector:4318. Empty disables tracing entirely.
+	OTLPEndpoint string `yaml:"otlpEndpoint" secret:"url"`
+
+	// SampleRate is the fraction of new traces recorded (0.0-1.0). Requests
+	// carrying a sampled traceparent are always recorded.
+	SampleRate float64 `yaml:"sampleRate"`
+
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `yaml:"serviceName"`
+
+	// IndexAttribute controls how index IDs appear on spans: raw, hash
+	// (a short SHA-256 prefix) or omit.
+	IndexAttribute string `yaml:"indexAttribute"`
+}
+
+// StatsConfig controls what /v1/stats shows callers without admin
+// credentials. Pool-wide aggregates are only served at /admin/stats.
+type StatsConfig struct {
+	// Noise adds uniform random noise of at most NoiseBound to the counts
+	// of the caller's index, so their exact values cannot be tracked
+	// across calls. Admins always see exact values.
+	Noise      bool `yaml:"noise"`
+	NoiseBound int  `yaml:"noiseBound"`
+}
+
+// SyncConfig controls the delta sync change feed (GET /v1/sync).
+type SyncConfig struct {
+	// ChangelogSize is how many neuron removals are remembered per index.
+	// Clients whose cursor predates the oldest retained removal must
+	// resync from scratch.
+	ChangelogSize int `yaml:"changelogSize"`
+
+	// PageSize is the default and maximum number of changes per response.
+	PageSize int `yaml:"pageSize"`
+
+	// ActivityThreshold is how far a neuron's energy must move before the
+	// change is reported to clients that ask for activity changes.
+	ActivityThreshold float64 `yaml:"activityThreshold"`
+}
+
+// SubscriptionsConfig controls scheduled query subscriptions
+// (/v1/subscriptions), which periodically run a search or context query
+// and write the result back into the index as a new neuron.
+type SubscriptionsConfig struct {
+	// Enabled registers the subscription endpoints and starts the scheduler.
+	Enabled bool `yaml:"enabled"`
+
+	// MaxPerIndex caps how many subscriptions one index may hold.
+	MaxPerIndex int `yaml:"maxPerIndex"`
+
+	// TickInterval is how often the scheduler looks for due subscriptions.
+	// Schedules shorter than this run at most once per tick.
+	TickInterval time.Duration `yaml:"tickInterval"`
+
+	// HistorySize is how many runs are kept per subscription.
+	HistorySize int `yaml:"historySize"`
+}
+
+// SharesConfig controls read-only share links (/v1/shares), capability
+// tokens that let anyone holding them search or recall the neurons of one
+// index matching a stored filter.
+type SharesConfig struct {
+	// Enabled registers the share endpoints.
+	Enabled bool `yaml:"enabled"`
+
+	// MaxPerIndex caps how many unexpired shares one index may hold.
+	MaxPerIndex int `yaml:"maxPerIndex"`
+
+	// MaxResults caps how many neurons a shared request returns. Shares
+	// may ask for fewer.
+	MaxResults int `yaml:"maxResults"`
+
+	// DefaultExpiry is the lifetime of a share that does not set one.
+	DefaultExpiry time.Duration `yaml:"defaultExpiry"`
+
+	// MaxExpiry is the longest lifetime a share may ask for.
+	MaxExpiry time.Duration `yaml:"maxExpiry"`
+
+	// RateLimitRequests is how many requests one share serves per
+	// RateLimitWindow. Shared requests do not count against the server's
+	// per-client rate limit.
+	RateLimitRequests int           `yaml:"rateLimitRequests"`
+	RateLimitWindow   time.Duration `yaml:"rateLimitWindow"`
+
+	// BadgeRateLimitRequests is how many requests one badge
+	// (/v1/badge/{token}) serves per BadgeRateLimitWindow.
+	BadgeRateLimitRequests int           `yaml:"badgeRateLimitRequests"`
+	BadgeRateLimitWindow   time.Duration `yaml:"badgeRateLimitWindow"`
+
+	// BadgeMaxAge is the max-age badges are served with.
+	BadgeMaxAge time.Duration `yaml:"badgeMaxAge"`
+
+	// BadgeTimeGranularity is what a badge's last activity is truncated
+	// to, so it never reveals when exactly an index was used.
+	BadgeTimeGranularity time.Duration `yaml:"badgeTimeGranularity"`
+}
+
+// PinsConfig controls neuron pinning (/v1/pin). Pinned neurons are never
+// pruned and their energy never decays below EnergyFloor.
+type PinsConfig struct {
+	// MaxPerIndex caps how many neurons one index may pin.
+	MaxPerIndex int `yaml:"maxPerIndex"`
+
+	// EnergyFloor is the lowest energy decay leaves a pinned neuron at.
+	EnergyFloor float64 `yaml:"energyFloor"`
+}
+
+// PrefetchConfig controls prefetch hints (/v1/prefetch), which let an
+// application load dormant indexes ahead of the queries it expects.
+type PrefetchConfig struct {
+	// Enabled registers the prefetch endpoint.
+	Enabled bool `yaml:"enabled"`
+
+	// MaxIndexes caps how many distinct indexes one request may list.
+	MaxIndexes int `yaml:"maxIndexes"`
+
+	// Grace is how long a prefetched index is protected from idle
+	// eviction and dormancy. Prefetching does not otherwise count as
+	// activity.
+	Grace time.Duration `yaml:"grace"`
+
+	// MaxLoadedIndexes is the loaded-index budget prefetching respects.
+	// Once the pool holds this many workers, further prefetches are
+	// rejected rather than evicting hot ones. 0 means no budget.
+	MaxLoadedIndexes int `yaml:"maxLoadedIndexes"`
+
+	// RateLimitRequests is how many prefetch requests one client may make
+	// per RateLimitWindow, on top of the server's per-client rate limit.
+	RateLimitRequests int           `yaml:"rateLimitRequests"`
+	RateLimitWindow   time.Duration `yaml:"rateLimitWindow"`
+}
+
+// ContextConfig controls how /v1/context presents conversation turns:
+// neurons written with a role, rendered through a named template.
+type ContextConfig struct {
+	// TurnTemplates maps template names to templates such as
+	// "{{role}}: {{text}}"; see TurnTemplate. Requests select one with
+	// format, falling back to DefaultTurnTemplate.
+	TurnTemplates map[string]string `yaml:"turnTemplates"`
+
+	// TurnPattern is the regular expression the turn migration uses to
+	// split prefixed content into role, lang and text named groups.
+	TurnPattern string `yaml:"turnPattern"`
+
+	// BlockTemplate wraps each memory of a context requested with format
+	// "blocks"; see BlockTemplate. {{attrs}} receives the memory's metadata
+	// as name="value" attributes.
+	BlockTemplate string `yaml:"blockTemplate"`
+}
+
+// ReplicationConfig controls asynchronous replication to other servers.
+type ReplicationConfig struct {
+	// Standby mirrors committed mutations to a warm standby.
+	Standby StandbyConfig `yaml:"standby"`
+}
+
+// StandbyConfig controls write fan-out to a warm standby: committed writes,
+// supersedes, forgets, restores, purges and resets of matching indexes are
+// spooled under <dataPath>/replication and replayed against the standby's
+// API in order.
+type StandbyConfig struct {
+	// URL is the standby's base URL. Empty disables replication.
+	// Credentials in it authenticate replayed resets against the
+	// standby's admin endpoints.
+	URL string `yaml:"url" secret:"url"`
+
+	// APIKey, if set, is sent as X-API-Key with every replayed request.
+	APIKey string `yaml:"apiKey" secret:"true"`
+
+	// Indexes are the glob patterns (path.Match syntax) of the indexes
+	// replicated.
+	Indexes []string `yaml:"indexes"`
+
+	// QueueSize bounds unshipped mutations in the spool.
+	QueueSize int `yaml:"queueSize"`
+
+	// FlushInterval is how often spooled mutations are shipped, and the
+	// first retry delay when the standby fails.
+	FlushInterval time.Duration `yaml:"flushInterval"`
+
+	// Timeout bounds each request to the standby.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Overflow decides what a full spool does: "drop_oldest" discards the
+	// oldest unshipped mutation, "block" holds up writes until there is
+	// room.
+	Overflow string `yaml:"overflow"`
+}
+
+// Dangling synapse handling of slice imports.
+const (
+	DanglingDrop = "drop" // synapses leaving the slice are dropped
+	DanglingKeep = "keep" // recorded on the neuron inside the slice
+)
+
+// ImportConfig controls POST /v1/import/markdown, which turns an uploaded
+// zip of Markdown notes into linked neurons, and slice imports
+// (POST /admin/indexes/{id}/import).
+type ImportConfig struct {
+	// MaxUploadBytes bounds the zip or slice upload. It replaces
+	// security.maxRequestBody for these endpoints.
+	MaxUploadBytes int64 `yaml:"maxUploadBytes"`
+
+	// MaxFiles bounds the Markdown files one upload may hold.
+	MaxFiles int `yaml:"maxFiles"`
+
+	// LinkWeight is the initial weight of synapses created from links,
+	// unless a request sets link_weight.
+	LinkWeight float64 `yaml:"linkWeight"`
+
+	// DanglingSynapses decides what a slice import does with synapses whose
+	// other end is outside the slice and the index, unless a request sets
+	// dangling: "drop" discards them, "keep" records the missing neuron's
+	// ID on the neuron inside.
+	DanglingSynapses string `yaml:"danglingSynapses"`
+}
+
+// SessionsConfig controls working memory: neurons written with scope
+// "session", held in memory next to an index and never persisted.
+type SessionsConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// TTL is how long a session may go unused before it is discarded.
+	TTL time.Duration `yaml:"ttl"`
+
+	// MaxNeuronsPerIndex bounds the session neurons of one index across
+	// its sessions. Writes beyond it evict the oldest.
+	MaxNeuronsPerIndex int `yaml:"maxNeuronsPerIndex"`
+
+	// SpreadWeight scales the score a matching session neuron passes on
+	// to the persistent neurons it links to.
+	SpreadWeight float64 `yaml:"spreadWeight"`
+}
+
+// RetentionConfig controls retention policies: per-index (or default)
+// rules that delete, drain or anonymize neurons past an age.
+type RetentionConfig struct {
+	// Interval is how often the retention daemon enforces policies.
+	// 0 disables enforcement; policies can still be edited and dry-run.
+	Interval time.Duration `yaml:"interval"`
+
+	// MaxRules bounds the rules of one policy.
+	MaxRules int `yaml:"maxRules"`
+
+	// HistorySize is how many enforcement runs
+	// GET /admin/retention/history keeps.
+	HistorySize int `yaml:"historySize"`
+
+	// DefaultRules apply to every index without a policy of its own.
+	// Anonymization uses admin.clone's contentMode and stripMetadataKeys.
+	DefaultRules []RetentionRule `yaml:"defaultRules"`
+}
+
+// SentimentConfig controls the sentiment layer that labels neurons with an
+// emotion on write and boosts search results sharing the query's.
+// Registry metadata {"sentiment": {"enabled": false}} turns it off for an
+// index.
+type SentimentConfig struct {
+	// Provider names the analyzer: "vader" (English) or "none", or one
+	// registered with sentiment.Register.
+	Provider string `yaml:"provider"`
+
+	// MinASCIILetterRatio guesses the language of text without a _lang:
+	// text whose letters are at least this share ASCII is taken as
+	// English, other text as unknown, which English-only analyzers skip.
+	// 0 takes all untagged text as English.
+	MinASCIILetterRatio float64 `yaml:"minAsciiLetterRatio"`
+}
+
+// HealthConfig controls the memory health score: a 0-100 summary of an
+// index's quality, the weighted mean of the components in Weights.
+type HealthConfig struct {
+	// Interval is how often loaded indexes have their score recomputed.
+	// 0 recomputes only on request.
+	Interval time.Duration `yaml:"interval"`
+
+	// StaleAfter is the age of the last activity at which freshness
+	// reaches 0, falling linearly from 1 for an index used just now.
+	StaleAfter time.Duration `yaml:"staleAfter"`
+
+	Weights HealthWeights `yaml:"weights"`
+}
+
+// HealthWeights weigh the components of the health score. Only their
+// ratios matter; a component weighted 0 is left out.
+type HealthWeights struct {
+	Consolidation float64 `yaml:"consolidation"` // share of neurons at depth 1 or more
+	Energy        float64 `yaml:"energy"`        // average energy
+	Connectivity  float64 `yaml:"connectivity"`  // share of neurons with a synapse
+	Conflicts     float64 `yaml:"conflicts"`     // share of neurons outside conflict groups
+	Freshness     float64 `yaml:"freshness"`     // recency of the last activity
+	Embeddings    float64 `yaml:"embeddings"`    // share of neurons embedded; with the vector layer only
+}
+
+// healthFormulaRevision changes whenever the components or how they are
+// normalized change.
+const healthFormulaRevision = 1
+
+// FormulaVersion identifies the health formula c configures, so scores
+// computed with different weights are not compared unknowingly: the
+// formula revision and a hash of the weights and staleAfter, e.g.
+// "1-3f2a9c1e".
+func (c HealthConfig) FormulaVersion() string {
+	w := c.Weights
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%g/%g/%g/%g/%g/%g/%s",
+		w.Consolidation, w.Energy, w.Connectivity, w.Conflicts, w.Freshness, w.Embeddings, c.StaleAfter)))
+	return fmt.Sprintf("%d-%x", healthFormulaRevision, sum[:4])
+}
+
+// CalibrationConfig controls similarity calibration: measuring how alike
+// an index's related neurons, those strongly linked by a synapse, are
+// against random pairs of them, and deriving the thresholds conflict
+// detection and co-fires use for it.
+type CalibrationConfig struct {
+	// Interval is how often loaded indexes are recalibrated. 0 calibrates
+	// only on request.
+	Interval time.Duration `yaml:"interval"`
+
+	// Pairs is how many related pairs, and as many random pairs, one
+	// calibration samples.
+	Pairs int `yaml:"pairs"`
+
+	// MinWeight is the weight a synapse needs for the neurons it links to
+	// count as related. Consecutive writes link neurons more weakly until
+	// they fire together again; explicit links start at 0.5.
+	MinWeight float64 `yaml:"minWeight"`
+
+	// MinPairs is the fewest pairs of each kind a measure is calibrated
+	// from; with fewer it gets no threshold and the server's settings apply.
+	MinPairs int `yaml:"minPairs"`
+
+	// TargetPrecision is how reliably a threshold must tell related pairs
+	// from random ones: the share of related pairs reaching it over that
+	// share plus the share of random pairs reaching it.
+	TargetPrecision float64 `yaml:"targetPrecision"`
 }
 
 // Config is the root configuration object for a QubicDB server.
@@ -260,6 +1366,24 @@ type Config struct {
 	Admin     AdminConfig     `yaml:"admin"`
 	MCP       MCPConfig       `yaml:"mcp"`
 	Security  SecurityConfig  `yaml:"security"`
+	Write     WriteConfig     `yaml:"write"`
+	Search    SearchConfig    `yaml:"search"`
+	Telemetry TelemetryConfig `yaml:"telemetry"`
+	Stats     StatsConfig     `yaml:"stats"`
+	Sync      SyncConfig      `yaml:"sync"`
+
+	Subscriptions SubscriptionsConfig `yaml:"subscriptions"`
+	Shares        SharesConfig        `yaml:"shares"`
+	Pins          PinsConfig          `yaml:"pins"`
+	Prefetch      PrefetchConfig      `yaml:"prefetch"`
+	Context       ContextConfig       `yaml:"context"`
+	Replication   ReplicationConfig   `yaml:"replication"`
+	Import        ImportConfig        `yaml:"import"`
+	Sessions      SessionsConfig      `yaml:"sessions"`
+	Retention     RetentionConfig     `yaml:"retention"`
+	Sentiment     SentimentConfig     `yaml:"sentiment"`
+	Health        HealthConfig        `yaml:"health"`
+	Calibration   CalibrationConfig   `yaml:"calibration"`
 }
 
 // ---------------------------------------------------------------------------
@@ -270,7 +1394,23 @@ type Config struct {
 func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			HTTPAddr: ":6060",
+			HTTPAddr:      ":6060",
+			IndexIDSource: "either",
+			Concurrency: ConcurrencyConfig{
+				Search:       64,
+				Context:      32,
+				Write:        128,
+				Admin:        16,
+				Default:      256,
+				QueueTimeout: 2 * time.Second,
+			},
+			Shadow: ShadowConfig{
+				SampleRate:         0.1,
+				Compare:            true,
+				QueueSize:          1000,
+				Timeout:            5 * time.Second,
+				MismatchSampleSize: 100,
+			},
 		},
 		Storage: StorageConfig{
 			DataPath:                   "./data",
@@ -280,16 +1420,48 @@ func DefaultConfig() *Config {
 			FsyncInterval:              1 * time.Second,
 			ChecksumValidationInterval: 0,
 			StartupRepair:              true,
+			ManifestRetain:             5,
+			StartupReportRetain:        10,
+			RetainVersions:             0,
+			RetainVersionsMaxAge:       7 * 24 * time.Hour,
+			NeuronRecycleWindow:        72 * time.Hour,
+			DiskCheckInterval:          30 * time.Second,
+			WarnFreeBytes:              1 << 30,
+			MinFreeBytes:               100 << 20,
+			FlushRetryBackoff:          30 * time.Second,
+			FlushRetryMaxBackoff:       10 * time.Minute,
+			FlushStaleAfter:            15 * time.Minute,
+			FlushStaleFailAfter:        time.Hour,
+			AttachmentSweepInterval:    time.Hour,
+			AttachmentGracePeriod:      time.Hour,
+			IndexQuotaBytes:            0,
+			IndexQuotaPolicy:           QuotaReject,
+			IndexQuotaGrace:            1.05,
+			WALArchive: WALArchiveConfig{
+				QueueSize:       4096,
+				SegmentBytes:    64 << 20,
+				SegmentInterval: time.Minute,
+				MaxAge:          7 * 24 * time.Hour,
+			},
+			Migration: MigrationConfig{
+				MaxPasses:     5,
+				SettleIndexes: 4,
+				MaxPause:      5 * time.Second,
+			},
 		},
 		Matrix: MatrixConfig{
 			MinDimension: 3,
 			MaxDimension: 1000,
 			MaxNeurons:   1000000,
+
+			IndexedMetadataKeys: []string{"thread_id", "role"},
 		},
 		Lifecycle: LifecycleConfig{
 			IdleThreshold:    30 * time.Second,
 			SleepThreshold:   5 * time.Minute,
 			DormantThreshold: 30 * time.Minute,
+			StateWaitMax:     60 * time.Second,
+			MaxStateWaiters:  100,
 		},
 		Daemons: DaemonConfig{
 			DecayInterval:       1 * time.Minute,
@@ -297,42 +1469,245 @@ func DefaultConfig() *Config {
 			PruneInterval:       10 * time.Minute,
 			PersistInterval:     1 * time.Minute,
 			ReorgInterval:       15 * time.Minute,
+			Prune: PruneConfig{
+				SynapseScore: SynapseScoreConfig{
+					Weight:          0.4,
+					CoFire:          0.2,
+					Recency:         0.2,
+					Depth:           0.2,
+					RecencyHalfLife: 7 * 24 * time.Hour,
+				},
+				MinScore:             0.05,
+				MaxSynapsesPerNeuron: 50,
+				MaxSynapses:          0,
+				Adaptive:             DefaultAdaptiveSchedule(),
+			},
+			Decay: DecayConfig{
+				Adaptive: DefaultAdaptiveSchedule(),
+			},
+			Consolidate: ConsolidateConfig{
+				CompactContent: CompactContentConfig{
+					Enabled:      false,
+					MinAge:       30 * 24 * time.Hour,
+					MaxEnergy:    0.2,
+					KeepChars:    280,
+					RecentAccess: 7 * 24 * time.Hour,
+				},
+				PromotionHistory: 5,
+				Adaptive:         DefaultAdaptiveSchedule(),
+			},
 		},
 		Worker: WorkerConfig{
-			MaxIdleTime: 30 * time.Minute,
+			MaxIdleTime:     30 * time.Minute,
+			BackgroundSlice: 10 * time.Millisecond,
+			ReadConcurrency: 4,
+			LoadTimeout:     10 * time.Second,
+			DeadLetters: DeadLetterConfig{
+				Capacity:           100,
+				MaxBytes:           64 << 20,
+				QuarantineAfter:    3,
+				QuarantineWindow:   time.Minute,
+				QuarantineDuration: 5 * time.Minute,
+			},
 		},
 		Registry: RegistryConfig{
 			Enabled: false,
 		},
 		Vector: VectorConfig{
-			Enabled:          DefaultVectorEnabled,
-			ModelPath:        DefaultVectorModelPath,
-			GPULayers:        0,
-			Alpha:            0.6,
-			QueryRepeat:      2,
-			EmbedContextSize: 512,
+			Enabled:              DefaultVectorEnabled,
+			ModelPath:            DefaultVectorModelPath,
+			GPULayers:            0,
+			Alpha:                0.6,
+			QueryRepeat:          2,
+			EmbedContextSize:     512,
+			MaxLoadedModels:      2,
+			ProbeTimeout:         10 * time.Second,
+			Retries:              2,
+			RetryBackoff:         50 * time.Millisecond,
+			BreakerThreshold:     5,
+			BreakerCooldown:      30 * time.Second,
+			PendingEmbedInterval: 30 * time.Second,
 		},
 		Admin: AdminConfig{
 			Enabled:  true,
 			User:     "admin",
-			Password: "qubicdb",
+			Password: DefaultAdminPassword,
+
+			SessionTTL: time.Hour,
+
+			Clone: CloneConfig{
+				ContentMode: CloneContentHash,
+			},
+			Journal: JournalConfig{
+				DefaultTTL: 15 * time.Minute,
+				MaxTTL:     time.Hour,
+				Capacity:   1000,
+			},
 		},
 		MCP: MCPConfig{
-			Enabled:        false,
-			Path:           "/mcp",
-			APIKey:         "",
-			Stateless:      true,
-			RateLimitRPS:   30,
-			RateLimitBurst: 60,
-			EnablePrompts:  true,
-			AllowedTools:   nil,
+			Enabled:           false,
+			Path:              "/mcp",
+			APIKey:            "",
+			Stateless:         true,
+			RateLimitRPS:      30,
+			RateLimitBurst:    60,
+			EnablePrompts:     true,
+			AllowedTools:      nil,
+			MaxToolDurationMS: 30000,
 		},
 		Security: SecurityConfig{
 			AllowedOrigins:        "http://localhost:6060",
 			MaxRequestBody:        1 << 20, // 1 MB
 			MaxNeuronContentBytes: DefaultMaxNeuronContentBytes,
+			MaxAttachmentBytes:    8 << 20, // 8 MB
 			ReadTimeout:           30 * time.Second,
 			WriteTimeout:          30 * time.Second,
+			AuthThrottle: AuthThrottleConfig{
+				MaxFailures: 5,
+				LockoutBase: 30 * time.Second,
+				LockoutMax:  15 * time.Minute,
+			},
+		},
+		Write: WriteConfig{
+			SanitizeContent: false,
+			MaxLineLength:   DefaultMaxContentLineLength,
+			Conflicts: ConflictConfig{
+				TopK:             5,
+				VectorThreshold:  0.8,
+				LexicalThreshold: 0.4,
+				MinEnergy:        0.2,
+				Keys:             []string{"fact", "attribute"},
+			},
+		},
+		Search: SearchConfig{
+			Telemetry: SearchTelemetryConfig{
+				Enabled:              false,
+				SampleZeroResults:    false,
+				ZeroResultSampleSize: 100,
+			},
+			BM25: BM25Config{
+				K1:       1.2,
+				B:        0.75,
+				MaxTerms: 100000,
+			},
+			Lexical: LexicalConfig{
+				FoldDiacritics: true,
+			},
+			AnchorWeight: 0.5,
+			Reinforce: ReinforceConfig{
+				SimilarityFloor: 0.2,
+				MaxNewSynapses:  10,
+			},
+			Expansion: ExpansionConfig{
+				MaxTerms:       5,
+				MaxAddedTokens: 12,
+				MaxCueTokens:   4,
+				Seeds:          5,
+				Weight:         0.5,
+			},
+		},
+		Telemetry: TelemetryConfig{
+			SampleRate:     1.0,
+			ServiceName:    "qubicdb",
+			IndexAttribute: TraceIndexHash,
+		},
+		Stats: StatsConfig{
+			Noise:      false,
+			NoiseBound: 5,
+		},
+		Sync: SyncConfig{
+			ChangelogSize:     10000,
+			PageSize:          500,
+			ActivityThreshold: 0.1,
+		},
+		Subscriptions: SubscriptionsConfig{
+			Enabled:      false,
+			MaxPerIndex:  20,
+			TickInterval: 30 * time.Second,
+			HistorySize:  20,
+		},
+		Shares: SharesConfig{
+			Enabled:           false,
+			MaxPerIndex:       20,
+			MaxResults:        50,
+			DefaultExpiry:     7 * 24 * time.Hour,
+			MaxExpiry:         90 * 24 * time.Hour,
+			RateLimitRequests: 60,
+			RateLimitWindow:   time.Minute,
+
+			BadgeRateLimitRequests: 30,
+			BadgeRateLimitWindow:   time.Minute,
+			BadgeMaxAge:            5 * time.Minute,
+			BadgeTimeGranularity:   time.Hour,
+		},
+		Pins: PinsConfig{
+			MaxPerIndex: 100,
+			EnergyFloor: 0.5,
+		},
+		Prefetch: PrefetchConfig{
+			Enabled:           true,
+			MaxIndexes:        10,
+			Grace:             time.Minute,
+			MaxLoadedIndexes:  0,
+			RateLimitRequests: 30,
+			RateLimitWindow:   time.Minute,
+		},
+		Context: ContextConfig{
+			TurnTemplates: map[string]string{
+				DefaultTurnTemplate: "{{role}}: {{text}}",
+				"compact":           "{{text}}",
+			},
+			TurnPattern:   DefaultTurnPattern,
+			BlockTemplate: DefaultBlockTemplate,
+		},
+		Replication: ReplicationConfig{
+			Standby: StandbyConfig{
+				Indexes:       []string{"*"},
+				QueueSize:     10000,
+				FlushInterval: time.Second,
+				Timeout:       5 * time.Second,
+				Overflow:      "drop_oldest",
+			},
+		},
+		Import: ImportConfig{
+			MaxUploadBytes: 32 << 20,
+			MaxFiles:       10000,
+			LinkWeight:     0.5,
+
+			DanglingSynapses: DanglingDrop,
+		},
+		Sessions: SessionsConfig{
+			Enabled:            true,
+			TTL:                30 * time.Minute,
+			MaxNeuronsPerIndex: 1000,
+			SpreadWeight:       0.5,
+		},
+		Retention: RetentionConfig{
+			Interval:    time.Hour,
+			MaxRules:    20,
+			HistorySize: 100,
+		},
+		Sentiment: SentimentConfig{
+			Provider:            "vader",
+			MinASCIILetterRatio: 0.9,
+		},
+		Health: HealthConfig{
+			Interval:   10 * time.Minute,
+			StaleAfter: 30 * 24 * time.Hour,
+			Weights: HealthWeights{
+				Consolidation: 0.2,
+				Energy:        0.2,
+				Connectivity:  0.2,
+				Conflicts:     0.15,
+				Freshness:     0.15,
+				Embeddings:    0.1,
+			},
+		},
+		Calibration: CalibrationConfig{
+			Pairs:           500,
+			MinWeight:       0.5,
+			MinPairs:        20,
+			TargetPrecision: 0.9,
 		},
 	}
 }
@@ -360,6 +1735,21 @@ func ConfigFromFile(path string) (*Config, error) {
 // Environment variable mapping (all optional, prefix QUBICDB_):
 //
 //	QUBICDB_HTTP_ADDR           → Server.HTTPAddr
+//	QUBICDB_INDEX_ID_SOURCE     → Server.IndexIDSource (header|query|either)
+//	QUBICDB_MIN_API_VERSION     → Server.MinAPIVersion (YYYY-MM)
+//	QUBICDB_CONCURRENCY_SEARCH  → Server.Concurrency.Search  (integer, 0=unlimited)
+//	QUBICDB_CONCURRENCY_CONTEXT → Server.Concurrency.Context (integer, 0=unlimited)
+//	QUBICDB_CONCURRENCY_WRITE   → Server.Concurrency.Write   (integer, 0=unlimited)
+//	QUBICDB_CONCURRENCY_ADMIN   → Server.Concurrency.Admin   (integer, 0=unlimited)
+//	QUBICDB_CONCURRENCY_DEFAULT → Server.Concurrency.Default (integer, 0=unlimited)
+//	QUBICDB_CONCURRENCY_QUEUE_TIMEOUT → Server.Concurrency.QueueTimeout (duration string)
+//	QUBICDB_SHADOW_URL          → Server.Shadow.URL
+//	QUBICDB_SHADOW_SAMPLE_RATE  → Server.Shadow.SampleRate (float 0.0-1.0)
+//	QUBICDB_SHADOW_ENDPOINTS    → Server.Shadow.Endpoints (comma-separated path prefixes)
+//	QUBICDB_SHADOW_COMPARE      → Server.Shadow.Compare ("true"/"false")
+//	QUBICDB_SHADOW_QUEUE_SIZE   → Server.Shadow.QueueSize (integer)
+//	QUBICDB_SHADOW_TIMEOUT      → Server.Shadow.Timeout (duration string)
+//	QUBICDB_SHADOW_MISMATCH_SAMPLE_SIZE → Server.Shadow.MismatchSampleSize (integer)
 //	QUBICDB_DATA_PATH           → Storage.DataPath
 //	QUBICDB_COMPRESS            → Storage.Compress          ("true"/"false")
 //	QUBICDB_WAL_ENABLED         → Storage.WALEnabled        ("true"/"false")
@@ -367,22 +1757,85 @@ func ConfigFromFile(path string) (*Config, error) {
 //	QUBICDB_FSYNC_INTERVAL      → Storage.FsyncInterval     (duration string)
 //	QUBICDB_CHECKSUM_VALIDATION_INTERVAL → Storage.ChecksumValidationInterval (duration string, 0=off)
 //	QUBICDB_STARTUP_REPAIR      → Storage.StartupRepair     ("true"/"false")
+//	QUBICDB_MANIFEST_RETAIN     → Storage.ManifestRetain    (integer, 0=keep all)
+//	QUBICDB_STARTUP_REPORT_RETAIN → Storage.StartupReportRetain (integer, 0=keep all)
+//	QUBICDB_RETAIN_VERSIONS     → Storage.RetainVersions    (integer, 0=off)
+//	QUBICDB_RETAIN_VERSIONS_MAX_AGE → Storage.RetainVersionsMaxAge (duration, 0=no limit)
+//	QUBICDB_NEURON_RECYCLE_WINDOW → Storage.NeuronRecycleWindow (duration, 0=off)
+//	QUBICDB_DISK_CHECK_INTERVAL → Storage.DiskCheckInterval (duration, 0=off)
+//	QUBICDB_WARN_FREE_BYTES     → Storage.WarnFreeBytes     (bytes, 0=off)
+//	QUBICDB_MIN_FREE_BYTES      → Storage.MinFreeBytes      (bytes, 0=off)
+//	QUBICDB_FLUSH_RETRY_BACKOFF → Storage.FlushRetryBackoff (duration, 0=retry every pass)
+//	QUBICDB_FLUSH_RETRY_MAX_BACKOFF → Storage.FlushRetryMaxBackoff (duration)
+//	QUBICDB_FLUSH_STALE_AFTER   → Storage.FlushStaleAfter   (duration, 0=off)
+//	QUBICDB_FLUSH_STALE_FAIL_AFTER → Storage.FlushStaleFailAfter (duration, 0=off)
+//	QUBICDB_ATTACHMENT_SWEEP_INTERVAL → Storage.AttachmentSweepInterval (duration, 0=off)
+//	QUBICDB_ATTACHMENT_GRACE_PERIOD → Storage.AttachmentGracePeriod (duration)
+//	QUBICDB_INDEX_QUOTA_BYTES   → Storage.IndexQuotaBytes   (bytes, 0=off)
+//	QUBICDB_INDEX_QUOTA_POLICY  → Storage.IndexQuotaPolicy  (reject|evict_lowest_energy)
+//	QUBICDB_INDEX_QUOTA_GRACE   → Storage.IndexQuotaGrace   (float, >= 1)
+//	QUBICDB_SEED_FORCE          → Storage.SeedForce         ("true"/"false")
+//	QUBICDB_WAL_ARCHIVE_DIR     → Storage.WALArchive.Dir    (path, empty=off)
+//	QUBICDB_WAL_ARCHIVE_QUEUE_SIZE → Storage.WALArchive.QueueSize (integer)
+//	QUBICDB_WAL_ARCHIVE_SEGMENT_BYTES → Storage.WALArchive.SegmentBytes (bytes)
+//	QUBICDB_WAL_ARCHIVE_SEGMENT_INTERVAL → Storage.WALArchive.SegmentInterval (duration)
+//	QUBICDB_WAL_ARCHIVE_MAX_AGE → Storage.WALArchive.MaxAge (duration, 0=no limit)
+//	QUBICDB_WAL_ARCHIVE_MAX_BYTES → Storage.WALArchive.MaxBytes (bytes, 0=no limit)
+//	QUBICDB_MIGRATION_MAX_PASSES → Storage.Migration.MaxPasses (integer)
+//	QUBICDB_MIGRATION_SETTLE_INDEXES → Storage.Migration.SettleIndexes (integer)
+//	QUBICDB_MIGRATION_MAX_PAUSE → Storage.Migration.MaxPause (duration)
 //	QUBICDB_MIN_DIMENSION       → Matrix.MinDimension
 //	QUBICDB_MAX_DIMENSION       → Matrix.MaxDimension
 //	QUBICDB_MAX_NEURONS         → Matrix.MaxNeurons
+//	QUBICDB_INDEXED_METADATA_KEYS → Matrix.IndexedMetadataKeys (comma-separated)
 //	QUBICDB_IDLE_THRESHOLD      → Lifecycle.IdleThreshold   (duration string)
 //	QUBICDB_SLEEP_THRESHOLD     → Lifecycle.SleepThreshold  (duration string)
 //	QUBICDB_DORMANT_THRESHOLD   → Lifecycle.DormantThreshold(duration string)
+//	QUBICDB_STATE_WAIT_MAX      → Lifecycle.StateWaitMax     (duration string)
+//	QUBICDB_MAX_STATE_WAITERS   → Lifecycle.MaxStateWaiters  (int)
 //	QUBICDB_DECAY_INTERVAL      → Daemons.DecayInterval     (duration string)
 //	QUBICDB_CONSOLIDATE_INTERVAL→ Daemons.ConsolidateInterval
 //	QUBICDB_PRUNE_INTERVAL      → Daemons.PruneInterval
 //	QUBICDB_PERSIST_INTERVAL    → Daemons.PersistInterval
 //	QUBICDB_REORG_INTERVAL      → Daemons.ReorgInterval
+//	QUBICDB_PRUNE_MIN_SCORE     → Daemons.Prune.MinScore    (0.0-1.0)
+//	QUBICDB_PRUNE_MAX_SYNAPSES_PER_NEURON → Daemons.Prune.MaxSynapsesPerNeuron (integer)
+//	QUBICDB_PRUNE_MAX_SYNAPSES  → Daemons.Prune.MaxSynapses (integer)
+//	QUBICDB_PRUNE_SCORE_WEIGHT  → Daemons.Prune.SynapseScore.Weight  (float)
+//	QUBICDB_PRUNE_SCORE_COFIRE  → Daemons.Prune.SynapseScore.CoFire  (float)
+//	QUBICDB_PRUNE_SCORE_RECENCY → Daemons.Prune.SynapseScore.Recency (float)
+//	QUBICDB_PRUNE_SCORE_DEPTH   → Daemons.Prune.SynapseScore.Depth   (float)
+//	QUBICDB_PRUNE_SCORE_RECENCY_HALF_LIFE → Daemons.Prune.SynapseScore.RecencyHalfLife (duration)
+//	QUBICDB_COMPACT_CONTENT_ENABLED → Daemons.Consolidate.CompactContent.Enabled (true/false)
+//	QUBICDB_COMPACT_CONTENT_MIN_AGE → Daemons.Consolidate.CompactContent.MinAge (duration)
+//	QUBICDB_COMPACT_CONTENT_MAX_ENERGY → Daemons.Consolidate.CompactContent.MaxEnergy (0.0-1.0)
+//	QUBICDB_COMPACT_CONTENT_KEEP_CHARS → Daemons.Consolidate.CompactContent.KeepChars (integer)
+//	QUBICDB_COMPACT_CONTENT_RECENT_ACCESS → Daemons.Consolidate.CompactContent.RecentAccess (duration)
+//	QUBICDB_PROMOTION_HISTORY → Daemons.Consolidate.PromotionHistory (integer)
+//	QUBICDB_{DECAY,CONSOLIDATE,PRUNE}_ADAPTIVE → Daemons.{Decay,Consolidate,Prune}.Adaptive.Enabled (true/false)
+//	QUBICDB_{DECAY,CONSOLIDATE,PRUNE}_ADAPTIVE_MIN_INTERVAL → ….Adaptive.MinInterval (duration)
+//	QUBICDB_{DECAY,CONSOLIDATE,PRUNE}_ADAPTIVE_MAX_INTERVAL → ….Adaptive.MaxInterval (duration)
+//	QUBICDB_{DECAY,CONSOLIDATE,PRUNE}_ADAPTIVE_WRITE_RATE_TARGET → ….Adaptive.WriteRateTarget (writes per minute)
 //	QUBICDB_MAX_IDLE_TIME       → Worker.MaxIdleTime
+//	QUBICDB_WORKER_BACKGROUND_SLICE → Worker.BackgroundSlice
+//	QUBICDB_WORKER_READ_CONCURRENCY → Worker.ReadConcurrency
+//	QUBICDB_WORKER_LOAD_TIMEOUT → Worker.LoadTimeout        (duration, 0 = no timeout)
+//	QUBICDB_DEADLETTER_CAPACITY → Worker.DeadLetters.Capacity (integer)
+//	QUBICDB_DEADLETTER_MAX_BYTES → Worker.DeadLetters.MaxBytes (bytes, 0 = memory only)
+//	QUBICDB_QUARANTINE_AFTER    → Worker.DeadLetters.QuarantineAfter (integer, 0 = never)
+//	QUBICDB_QUARANTINE_WINDOW   → Worker.DeadLetters.QuarantineWindow (duration)
+//	QUBICDB_QUARANTINE_DURATION → Worker.DeadLetters.QuarantineDuration (duration)
 //	QUBICDB_REGISTRY_ENABLED    → Registry.Enabled          ("true"/"false")
 //	QUBICDB_ADMIN_ENABLED       → Admin.Enabled             ("true"/"false")
 //	QUBICDB_ADMIN_USER          → Admin.User
 //	QUBICDB_ADMIN_PASSWORD      → Admin.Password
+//	QUBICDB_ADMIN_SESSION_SECRET → Admin.SessionSecret
+//	QUBICDB_ADMIN_SESSION_TTL   → Admin.SessionTTL          (duration)
+//	QUBICDB_CLONE_CONTENT_MODE  → Admin.Clone.ContentMode   (hash|redact)
+//	QUBICDB_CLONE_STRIP_METADATA → Admin.Clone.StripMetadataKeys (comma-separated)
+//	QUBICDB_JOURNAL_DEFAULT_TTL → Admin.Journal.DefaultTTL
+//	QUBICDB_JOURNAL_MAX_TTL     → Admin.Journal.MaxTTL
+//	QUBICDB_JOURNAL_CAPACITY    → Admin.Journal.Capacity
 //	QUBICDB_MCP_ENABLED         → MCP.Enabled               ("true"/"false")
 //	QUBICDB_MCP_PATH            → MCP.Path
 //	QUBICDB_MCP_API_KEY         → MCP.APIKey
@@ -391,13 +1844,105 @@ func ConfigFromFile(path string) (*Config, error) {
 //	QUBICDB_MCP_RATE_LIMIT_BURST→ MCP.RateLimitBurst        (integer)
 //	QUBICDB_MCP_ENABLE_PROMPTS  → MCP.EnablePrompts         ("true"/"false")
 //	QUBICDB_MCP_ALLOWED_TOOLS   → MCP.AllowedTools          (comma-separated)
+//	QUBICDB_MCP_MAX_TOOL_DURATION_MS → MCP.MaxToolDurationMS (integer)
 //	QUBICDB_ALLOWED_ORIGINS     → Security.AllowedOrigins
 //	QUBICDB_MAX_REQUEST_BODY    → Security.MaxRequestBody   (bytes, integer)
 //	QUBICDB_MAX_NEURON_CONTENT_BYTES → Security.MaxNeuronContentBytes (bytes, integer)
+//	QUBICDB_MAX_ATTACHMENT_BYTES → Security.MaxAttachmentBytes (bytes, integer)
 //	QUBICDB_TLS_CERT            → Security.TLSCert
 //	QUBICDB_TLS_KEY             → Security.TLSKey
 //	QUBICDB_READ_TIMEOUT        → Security.ReadTimeout      (duration string)
 //	QUBICDB_WRITE_TIMEOUT       → Security.WriteTimeout     (duration string)
+//	QUBICDB_TRUSTED_PROXIES     → Security.TrustedProxies   (comma-separated IPs/CIDRs)
+//	QUBICDB_AUTH_THROTTLE_MAX_FAILURES → Security.AuthThrottle.MaxFailures (integer)
+//	QUBICDB_AUTH_THROTTLE_LOCKOUT_BASE → Security.AuthThrottle.LockoutBase (duration)
+//	QUBICDB_AUTH_THROTTLE_LOCKOUT_MAX  → Security.AuthThrottle.LockoutMax  (duration)
+//	QUBICDB_WRITE_SANITIZE_CONTENT → Write.SanitizeContent  ("true"/"false")
+//	QUBICDB_WRITE_MAX_LINE_LENGTH  → Write.MaxLineLength    (characters, 0=off)
+//	QUBICDB_WRITE_DETECT_CONFLICTS → Write.DetectConflicts  ("true"/"false")
+//	QUBICDB_CONFLICT_TOP_K         → Write.Conflicts.TopK   (integer)
+//	QUBICDB_CONFLICT_VECTOR_THRESHOLD  → Write.Conflicts.VectorThreshold  (0.0-1.0)
+//	QUBICDB_CONFLICT_LEXICAL_THRESHOLD → Write.Conflicts.LexicalThreshold (0.0-1.0)
+//	QUBICDB_CONFLICT_MIN_ENERGY    → Write.Conflicts.MinEnergy (0.0-1.0)
+//	QUBICDB_CONFLICT_KEYS          → Write.Conflicts.Keys   (comma-separated)
+//	QUBICDB_SEARCH_TELEMETRY_ENABLED → Search.Telemetry.Enabled ("true"/"false")
+//	QUBICDB_SEARCH_TELEMETRY_SAMPLE_ZERO_RESULTS → Search.Telemetry.SampleZeroResults ("true"/"false")
+//	QUBICDB_SEARCH_TELEMETRY_SAMPLE_SIZE → Search.Telemetry.ZeroResultSampleSize (integer)
+//	QUBICDB_SEARCH_BM25_K1              → Search.BM25.K1 (float >= 0)
+//	QUBICDB_SEARCH_BM25_B               → Search.BM25.B (float 0.0-1.0)
+//	QUBICDB_SEARCH_BM25_MAX_TERMS       → Search.BM25.MaxTerms (integer, 0=unbounded)
+//	QUBICDB_SEARCH_ANCHOR_WEIGHT        → Search.AnchorWeight (float 0.0-1.0)
+//	QUBICDB_SEARCH_REINFORCE_SIMILARITY_FLOOR → Search.Reinforce.SimilarityFloor (float 0.0-1.0)
+//	QUBICDB_SEARCH_REINFORCE_MAX_NEW_SYNAPSES → Search.Reinforce.MaxNewSynapses (integer)
+//	QUBICDB_SEARCH_EXPANSION_ENABLED    → Search.Expansion.Enabled ("true"/"false")
+//	QUBICDB_SEARCH_EXPANSION_MAX_TERMS  → Search.Expansion.MaxTerms (integer >= 1)
+//	QUBICDB_SEARCH_EXPANSION_MAX_ADDED_TOKENS → Search.Expansion.MaxAddedTokens (integer >= 1)
+//	QUBICDB_SEARCH_EXPANSION_MAX_CUE_TOKENS → Search.Expansion.MaxCueTokens (integer >= 1)
+//	QUBICDB_SEARCH_EXPANSION_SEEDS      → Search.Expansion.Seeds (integer 1-50)
+//	QUBICDB_SEARCH_EXPANSION_WEIGHT     → Search.Expansion.Weight (float, above 0.0 and at most 1.0)
+//	QUBICDB_SEARCH_FOLD_DIACRITICS      → Search.Lexical.FoldDiacritics ("true"/"false")
+//	QUBICDB_SEARCH_LANGUAGE             → Search.Lexical.Language (language tag, e.g. "tr")
+//	QUBICDB_OTLP_ENDPOINT       → Telemetry.OTLPEndpoint
+//	QUBICDB_TRACE_SAMPLE_RATE   → Telemetry.SampleRate (float 0.0-1.0)
+//	QUBICDB_TRACE_SERVICE_NAME  → Telemetry.ServiceName
+//	QUBICDB_TRACE_INDEX_ATTRIBUTE → Telemetry.IndexAttribute (raw|hash|omit)
+//	QUBICDB_STATS_NOISE         → Stats.Noise              ("true"/"false")
+//	QUBICDB_STATS_NOISE_BOUND   → Stats.NoiseBound         (integer)
+//	QUBICDB_SYNC_CHANGELOG_SIZE → Sync.ChangelogSize        (integer)
+//	QUBICDB_SYNC_PAGE_SIZE      → Sync.PageSize             (integer)
+//	QUBICDB_SYNC_ACTIVITY_THRESHOLD → Sync.ActivityThreshold (float)
+//	QUBICDB_SUBSCRIPTIONS_ENABLED → Subscriptions.Enabled ("true"/"false")
+//	QUBICDB_SUBSCRIPTIONS_MAX_PER_INDEX → Subscriptions.MaxPerIndex (integer)
+//	QUBICDB_SUBSCRIPTIONS_TICK  → Subscriptions.TickInterval (duration)
+//	QUBICDB_SUBSCRIPTIONS_HISTORY_SIZE → Subscriptions.HistorySize (integer)
+//	QUBICDB_SHARES_ENABLED      → Shares.Enabled           ("true"/"false")
+//	QUBICDB_SHARES_MAX_PER_INDEX → Shares.MaxPerIndex      (integer)
+//	QUBICDB_SHARES_MAX_RESULTS  → Shares.MaxResults        (integer)
+//	QUBICDB_SHARES_DEFAULT_EXPIRY → Shares.DefaultExpiry   (duration)
+//	QUBICDB_SHARES_MAX_EXPIRY   → Shares.MaxExpiry         (duration)
+//	QUBICDB_SHARES_RATE_LIMIT   → Shares.RateLimitRequests (integer, per share)
+//	QUBICDB_SHARES_RATE_LIMIT_WINDOW → Shares.RateLimitWindow (duration)
+//	QUBICDB_SHARES_BADGE_RATE_LIMIT    → Shares.BadgeRateLimitRequests (integer, per badge)
+//	QUBICDB_SHARES_BADGE_RATE_LIMIT_WINDOW → Shares.BadgeRateLimitWindow (duration)
+//	QUBICDB_SHARES_BADGE_MAX_AGE       → Shares.BadgeMaxAge       (duration)
+//	QUBICDB_SHARES_BADGE_TIME_GRANULARITY → Shares.BadgeTimeGranularity (duration)
+//	QUBICDB_PINS_MAX_PER_INDEX  → Pins.MaxPerIndex          (integer)
+//	QUBICDB_PINS_ENERGY_FLOOR   → Pins.EnergyFloor          (0.0-1.0)
+//	QUBICDB_PREFETCH_ENABLED    → Prefetch.Enabled          ("true"/"false")
+//	QUBICDB_PREFETCH_MAX_INDEXES → Prefetch.MaxIndexes      (integer)
+//	QUBICDB_PREFETCH_GRACE      → Prefetch.Grace            (duration)
+//	QUBICDB_PREFETCH_MAX_LOADED → Prefetch.MaxLoadedIndexes (integer, 0 = no budget)
+//	QUBICDB_PREFETCH_RATE_LIMIT → Prefetch.RateLimitRequests (integer, per client)
+//	QUBICDB_PREFETCH_RATE_LIMIT_WINDOW → Prefetch.RateLimitWindow (duration)
+//	QUBICDB_CONTEXT_TURN_PATTERN → Context.TurnPattern      (regular expression)
+//	QUBICDB_CONTEXT_BLOCK_TEMPLATE → Context.BlockTemplate
+//	QUBICDB_REPLICATION_STANDBY_URL → Replication.Standby.URL
+//	QUBICDB_REPLICATION_STANDBY_API_KEY → Replication.Standby.APIKey
+//	QUBICDB_REPLICATION_STANDBY_INDEXES → Replication.Standby.Indexes (comma-separated globs)
+//	QUBICDB_REPLICATION_STANDBY_QUEUE_SIZE → Replication.Standby.QueueSize (integer)
+//	QUBICDB_REPLICATION_STANDBY_FLUSH_INTERVAL → Replication.Standby.FlushInterval (duration)
+//	QUBICDB_REPLICATION_STANDBY_TIMEOUT → Replication.Standby.Timeout (duration)
+//	QUBICDB_REPLICATION_STANDBY_OVERFLOW → Replication.Standby.Overflow ("drop_oldest"/"block")
+//	QUBICDB_IMPORT_MAX_UPLOAD_BYTES → Import.MaxUploadBytes   (integer)
+//	QUBICDB_IMPORT_MAX_FILES    → Import.MaxFiles           (integer)
+//	QUBICDB_IMPORT_LINK_WEIGHT  → Import.LinkWeight         (float, 0.0–1.0)
+//	QUBICDB_IMPORT_DANGLING_SYNAPSES → Import.DanglingSynapses (drop|keep)
+//	QUBICDB_SESSIONS_ENABLED    → Sessions.Enabled          ("true"/"false")
+//	QUBICDB_SESSIONS_TTL        → Sessions.TTL              (duration)
+//	QUBICDB_SESSIONS_MAX_NEURONS_PER_INDEX → Sessions.MaxNeuronsPerIndex (integer)
+//	QUBICDB_SESSIONS_SPREAD_WEIGHT → Sessions.SpreadWeight  (float, 0.0–1.0)
+//	QUBICDB_RETENTION_INTERVAL  → Retention.Interval        (duration, 0=off)
+//	QUBICDB_RETENTION_MAX_RULES → Retention.MaxRules        (integer)
+//	QUBICDB_RETENTION_HISTORY_SIZE → Retention.HistorySize  (integer)
+//	QUBICDB_SENTIMENT_PROVIDER  → Sentiment.Provider        (vader|none|registered name)
+//	QUBICDB_SENTIMENT_MIN_ASCII_LETTER_RATIO → Sentiment.MinASCIILetterRatio (float, 0.0–1.0)
+//	QUBICDB_HEALTH_INTERVAL     → Health.Interval           (duration, 0=on request only)
+//	QUBICDB_HEALTH_STALE_AFTER  → Health.StaleAfter         (duration)
+//	QUBICDB_CALIBRATION_INTERVAL → Calibration.Interval     (duration, 0=on request only)
+//	QUBICDB_CALIBRATION_PAIRS   → Calibration.Pairs         (integer)
+//	QUBICDB_CALIBRATION_MIN_PAIRS → Calibration.MinPairs    (integer)
+//	QUBICDB_CALIBRATION_MIN_WEIGHT → Calibration.MinWeight  (float, 0.0–1.0)
+//	QUBICDB_CALIBRATION_TARGET_PRECISION → Calibration.TargetPrecision (float, 0.5–1.0)
 func ConfigFromEnv(cfg *Config) *Config {
 	if cfg == nil {
 		cfg = DefaultConfig()
@@ -405,6 +1950,21 @@ func ConfigFromEnv(cfg *Config) *Config {
 
 	// -- Server --
 	setEnvStr("QUBICDB_HTTP_ADDR", &cfg.Server.HTTPAddr)
+	setEnvStr("QUBICDB_INDEX_ID_SOURCE", &cfg.Server.IndexIDSource)
+	setEnvStr("QUBICDB_MIN_API_VERSION", &cfg.Server.MinAPIVersion)
+	setEnvInt("QUBICDB_CONCURRENCY_SEARCH", &cfg.Server.Concurrency.Search)
+	setEnvInt("QUBICDB_CONCURRENCY_CONTEXT", &cfg.Server.Concurrency.Context)
+	setEnvInt("QUBICDB_CONCURRENCY_WRITE", &cfg.Server.Concurrency.Write)
+	setEnvInt("QUBICDB_CONCURRENCY_ADMIN", &cfg.Server.Concurrency.Admin)
+	setEnvInt("QUBICDB_CONCURRENCY_DEFAULT", &cfg.Server.Concurrency.Default)
+	setEnvDuration("QUBICDB_CONCURRENCY_QUEUE_TIMEOUT", &cfg.Server.Concurrency.QueueTimeout)
+	setEnvStr("QUBICDB_SHADOW_URL", &cfg.Server.Shadow.URL)
+	setEnvFloat("QUBICDB_SHADOW_SAMPLE_RATE", &cfg.Server.Shadow.SampleRate)
+	setEnvCSV("QUBICDB_SHADOW_ENDPOINTS", &cfg.Server.Shadow.Endpoints)
+	setEnvBool("QUBICDB_SHADOW_COMPARE", &cfg.Server.Shadow.Compare)
+	setEnvInt("QUBICDB_SHADOW_QUEUE_SIZE", &cfg.Server.Shadow.QueueSize)
+	setEnvDuration("QUBICDB_SHADOW_TIMEOUT", &cfg.Server.Shadow.Timeout)
+	setEnvInt("QUBICDB_SHADOW_MISMATCH_SAMPLE_SIZE", &cfg.Server.Shadow.MismatchSampleSize)
 
 	// -- Storage --
 	setEnvStr("QUBICDB_DATA_PATH", &cfg.Storage.DataPath)
@@ -414,16 +1974,46 @@ func ConfigFromEnv(cfg *Config) *Config {
 	setEnvDuration("QUBICDB_FSYNC_INTERVAL", &cfg.Storage.FsyncInterval)
 	setEnvDuration("QUBICDB_CHECKSUM_VALIDATION_INTERVAL", &cfg.Storage.ChecksumValidationInterval)
 	setEnvBool("QUBICDB_STARTUP_REPAIR", &cfg.Storage.StartupRepair)
+	setEnvInt("QUBICDB_MANIFEST_RETAIN", &cfg.Storage.ManifestRetain)
+	setEnvInt("QUBICDB_STARTUP_REPORT_RETAIN", &cfg.Storage.StartupReportRetain)
+	setEnvInt("QUBICDB_RETAIN_VERSIONS", &cfg.Storage.RetainVersions)
+	setEnvDuration("QUBICDB_RETAIN_VERSIONS_MAX_AGE", &cfg.Storage.RetainVersionsMaxAge)
+	setEnvDuration("QUBICDB_NEURON_RECYCLE_WINDOW", &cfg.Storage.NeuronRecycleWindow)
+	setEnvDuration("QUBICDB_DISK_CHECK_INTERVAL", &cfg.Storage.DiskCheckInterval)
+	setEnvInt64("QUBICDB_WARN_FREE_BYTES", &cfg.Storage.WarnFreeBytes)
+	setEnvInt64("QUBICDB_MIN_FREE_BYTES", &cfg.Storage.MinFreeBytes)
+	setEnvDuration("QUBICDB_FLUSH_RETRY_BACKOFF", &cfg.Storage.FlushRetryBackoff)
+	setEnvDuration("QUBICDB_FLUSH_RETRY_MAX_BACKOFF", &cfg.Storage.FlushRetryMaxBackoff)
+	setEnvDuration("QUBICDB_FLUSH_STALE_AFTER", &cfg.Storage.FlushStaleAfter)
+	setEnvDuration("QUBICDB_FLUSH_STALE_FAIL_AFTER", &cfg.Storage.FlushStaleFailAfter)
+	setEnvDuration("QUBICDB_ATTACHMENT_SWEEP_INTERVAL", &cfg.Storage.AttachmentSweepInterval)
+	setEnvDuration("QUBICDB_ATTACHMENT_GRACE_PERIOD", &cfg.Storage.AttachmentGracePeriod)
+	setEnvInt64("QUBICDB_INDEX_QUOTA_BYTES", &cfg.Storage.IndexQuotaBytes)
+	setEnvStr("QUBICDB_INDEX_QUOTA_POLICY", &cfg.Storage.IndexQuotaPolicy)
+	setEnvFloat("QUBICDB_INDEX_QUOTA_GRACE", &cfg.Storage.IndexQuotaGrace)
+	setEnvBool("QUBICDB_SEED_FORCE", &cfg.Storage.SeedForce)
+	setEnvStr("QUBICDB_WAL_ARCHIVE_DIR", &cfg.Storage.WALArchive.Dir)
+	setEnvInt("QUBICDB_WAL_ARCHIVE_QUEUE_SIZE", &cfg.Storage.WALArchive.QueueSize)
+	setEnvInt64("QUBICDB_WAL_ARCHIVE_SEGMENT_BYTES", &cfg.Storage.WALArchive.SegmentBytes)
+	setEnvDuration("QUBICDB_WAL_ARCHIVE_SEGMENT_INTERVAL", &cfg.Storage.WALArchive.SegmentInterval)
+	setEnvDuration("QUBICDB_WAL_ARCHIVE_MAX_AGE", &cfg.Storage.WALArchive.MaxAge)
+	setEnvInt64("QUBICDB_WAL_ARCHIVE_MAX_BYTES", &cfg.Storage.WALArchive.MaxBytes)
+	setEnvInt("QUBICDB_MIGRATION_MAX_PASSES", &cfg.Storage.Migration.MaxPasses)
+	setEnvInt("QUBICDB_MIGRATION_SETTLE_INDEXES", &cfg.Storage.Migration.SettleIndexes)
+	setEnvDuration("QUBICDB_MIGRATION_MAX_PAUSE", &cfg.Storage.Migration.MaxPause)
 
 	// -- Matrix --
 	setEnvInt("QUBICDB_MIN_DIMENSION", &cfg.Matrix.MinDimension)
 	setEnvInt("QUBICDB_MAX_DIMENSION", &cfg.Matrix.MaxDimension)
 	setEnvInt("QUBICDB_MAX_NEURONS", &cfg.Matrix.MaxNeurons)
+	setEnvCSV("QUBICDB_INDEXED_METADATA_KEYS", &cfg.Matrix.IndexedMetadataKeys)
 
 	// -- Lifecycle --
 	setEnvDuration("QUBICDB_IDLE_THRESHOLD", &cfg.Lifecycle.IdleThreshold)
 	setEnvDuration("QUBICDB_SLEEP_THRESHOLD", &cfg.Lifecycle.SleepThreshold)
 	setEnvDuration("QUBICDB_DORMANT_THRESHOLD", &cfg.Lifecycle.DormantThreshold)
+	setEnvDuration("QUBICDB_STATE_WAIT_MAX", &cfg.Lifecycle.StateWaitMax)
+	setEnvInt("QUBICDB_MAX_STATE_WAITERS", &cfg.Lifecycle.MaxStateWaiters)
 
 	// -- Daemons --
 	setEnvDuration("QUBICDB_DECAY_INTERVAL", &cfg.Daemons.DecayInterval)
@@ -431,9 +2021,41 @@ func ConfigFromEnv(cfg *Config) *Config {
 	setEnvDuration("QUBICDB_PRUNE_INTERVAL", &cfg.Daemons.PruneInterval)
 	setEnvDuration("QUBICDB_PERSIST_INTERVAL", &cfg.Daemons.PersistInterval)
 	setEnvDuration("QUBICDB_REORG_INTERVAL", &cfg.Daemons.ReorgInterval)
+	setEnvFloat("QUBICDB_PRUNE_MIN_SCORE", &cfg.Daemons.Prune.MinScore)
+	setEnvInt("QUBICDB_PRUNE_MAX_SYNAPSES_PER_NEURON", &cfg.Daemons.Prune.MaxSynapsesPerNeuron)
+	setEnvInt("QUBICDB_PRUNE_MAX_SYNAPSES", &cfg.Daemons.Prune.MaxSynapses)
+	setEnvFloat("QUBICDB_PRUNE_SCORE_WEIGHT", &cfg.Daemons.Prune.SynapseScore.Weight)
+	setEnvFloat("QUBICDB_PRUNE_SCORE_COFIRE", &cfg.Daemons.Prune.SynapseScore.CoFire)
+	setEnvFloat("QUBICDB_PRUNE_SCORE_RECENCY", &cfg.Daemons.Prune.SynapseScore.Recency)
+	setEnvFloat("QUBICDB_PRUNE_SCORE_DEPTH", &cfg.Daemons.Prune.SynapseScore.Depth)
+	setEnvDuration("QUBICDB_PRUNE_SCORE_RECENCY_HALF_LIFE", &cfg.Daemons.Prune.SynapseScore.RecencyHalfLife)
+	setEnvBool("QUBICDB_COMPACT_CONTENT_ENABLED", &cfg.Daemons.Consolidate.CompactContent.Enabled)
+	setEnvDuration("QUBICDB_COMPACT_CONTENT_MIN_AGE", &cfg.Daemons.Consolidate.CompactContent.MinAge)
+	setEnvFloat("QUBICDB_COMPACT_CONTENT_MAX_ENERGY", &cfg.Daemons.Consolidate.CompactContent.MaxEnergy)
+	setEnvInt("QUBICDB_COMPACT_CONTENT_KEEP_CHARS", &cfg.Daemons.Consolidate.CompactContent.KeepChars)
+	setEnvDuration("QUBICDB_COMPACT_CONTENT_RECENT_ACCESS", &cfg.Daemons.Consolidate.CompactContent.RecentAccess)
+	setEnvInt("QUBICDB_PROMOTION_HISTORY", &cfg.Daemons.Consolidate.PromotionHistory)
+	for prefix, adaptive := range map[string]*AdaptiveScheduleConfig{
+		"QUBICDB_DECAY_ADAPTIVE":       &cfg.Daemons.Decay.Adaptive,
+		"QUBICDB_CONSOLIDATE_ADAPTIVE": &cfg.Daemons.Consolidate.Adaptive,
+		"QUBICDB_PRUNE_ADAPTIVE":       &cfg.Daemons.Prune.Adaptive,
+	} {
+		setEnvBool(prefix, &adaptive.Enabled)
+		setEnvDuration(prefix+"_MIN_INTERVAL", &adaptive.MinInterval)
+		setEnvDuration(prefix+"_MAX_INTERVAL", &adaptive.MaxInterval)
+		setEnvFloat(prefix+"_WRITE_RATE_TARGET", &adaptive.WriteRateTarget)
+	}
 
 	// -- Worker --
 	setEnvDuration("QUBICDB_MAX_IDLE_TIME", &cfg.Worker.MaxIdleTime)
+	setEnvDuration("QUBICDB_WORKER_BACKGROUND_SLICE", &cfg.Worker.BackgroundSlice)
+	setEnvInt("QUBICDB_WORKER_READ_CONCURRENCY", &cfg.Worker.ReadConcurrency)
+	setEnvDuration("QUBICDB_WORKER_LOAD_TIMEOUT", &cfg.Worker.LoadTimeout)
+	setEnvInt("QUBICDB_DEADLETTER_CAPACITY", &cfg.Worker.DeadLetters.Capacity)
+	setEnvInt64("QUBICDB_DEADLETTER_MAX_BYTES", &cfg.Worker.DeadLetters.MaxBytes)
+	setEnvInt("QUBICDB_QUARANTINE_AFTER", &cfg.Worker.DeadLetters.QuarantineAfter)
+	setEnvDuration("QUBICDB_QUARANTINE_WINDOW", &cfg.Worker.DeadLetters.QuarantineWindow)
+	setEnvDuration("QUBICDB_QUARANTINE_DURATION", &cfg.Worker.DeadLetters.QuarantineDuration)
 
 	// -- Registry --
 	setEnvBool("QUBICDB_REGISTRY_ENABLED", &cfg.Registry.Enabled)
@@ -445,11 +2067,26 @@ func ConfigFromEnv(cfg *Config) *Config {
 	setEnvFloat("QUBICDB_VECTOR_ALPHA", &cfg.Vector.Alpha)
 	setEnvInt("QUBICDB_VECTOR_QUERY_REPEAT", &cfg.Vector.QueryRepeat)
 	setEnvUint32("QUBICDB_VECTOR_EMBED_CONTEXT_SIZE", &cfg.Vector.EmbedContextSize)
+	setEnvInt("QUBICDB_VECTOR_MAX_LOADED_MODELS", &cfg.Vector.MaxLoadedModels)
+	setEnvDuration("QUBICDB_VECTOR_PROBE_TIMEOUT", &cfg.Vector.ProbeTimeout)
+	setEnvDuration("QUBICDB_VECTOR_PROBE_INTERVAL", &cfg.Vector.ProbeInterval)
+	setEnvInt("QUBICDB_VECTOR_RETRIES", &cfg.Vector.Retries)
+	setEnvDuration("QUBICDB_VECTOR_RETRY_BACKOFF", &cfg.Vector.RetryBackoff)
+	setEnvInt("QUBICDB_VECTOR_BREAKER_THRESHOLD", &cfg.Vector.BreakerThreshold)
+	setEnvDuration("QUBICDB_VECTOR_BREAKER_COOLDOWN", &cfg.Vector.BreakerCooldown)
+	setEnvDuration("QUBICDB_VECTOR_PENDING_EMBED_INTERVAL", &cfg.Vector.PendingEmbedInterval)
 
 	// -- Admin --
 	setEnvBool("QUBICDB_ADMIN_ENABLED", &cfg.Admin.Enabled)
 	setEnvStr("QUBICDB_ADMIN_USER", &cfg.Admin.User)
 	setEnvStr("QUBICDB_ADMIN_PASSWORD", &cfg.Admin.Password)
+	setEnvStr("QUBICDB_ADMIN_SESSION_SECRET", &cfg.Admin.SessionSecret)
+	setEnvDuration("QUBICDB_ADMIN_SESSION_TTL", &cfg.Admin.SessionTTL)
+	setEnvStr("QUBICDB_CLONE_CONTENT_MODE", &cfg.Admin.Clone.ContentMode)
+	setEnvCSV("QUBICDB_CLONE_STRIP_METADATA", &cfg.Admin.Clone.StripMetadataKeys)
+	setEnvDuration("QUBICDB_JOURNAL_DEFAULT_TTL", &cfg.Admin.Journal.DefaultTTL)
+	setEnvDuration("QUBICDB_JOURNAL_MAX_TTL", &cfg.Admin.Journal.MaxTTL)
+	setEnvInt("QUBICDB_JOURNAL_CAPACITY", &cfg.Admin.Journal.Capacity)
 
 	// -- MCP --
 	setEnvBool("QUBICDB_MCP_ENABLED", &cfg.MCP.Enabled)
@@ -460,15 +2097,141 @@ func ConfigFromEnv(cfg *Config) *Config {
 	setEnvInt("QUBICDB_MCP_RATE_LIMIT_BURST", &cfg.MCP.RateLimitBurst)
 	setEnvBool("QUBICDB_MCP_ENABLE_PROMPTS", &cfg.MCP.EnablePrompts)
 	setEnvCSV("QUBICDB_MCP_ALLOWED_TOOLS", &cfg.MCP.AllowedTools)
+	setEnvInt("QUBICDB_MCP_MAX_TOOL_DURATION_MS", &cfg.MCP.MaxToolDurationMS)
 
 	// -- Security --
 	setEnvStr("QUBICDB_ALLOWED_ORIGINS", &cfg.Security.AllowedOrigins)
 	setEnvInt64("QUBICDB_MAX_REQUEST_BODY", &cfg.Security.MaxRequestBody)
 	setEnvInt64("QUBICDB_MAX_NEURON_CONTENT_BYTES", &cfg.Security.MaxNeuronContentBytes)
+	setEnvInt64("QUBICDB_MAX_ATTACHMENT_BYTES", &cfg.Security.MaxAttachmentBytes)
 	setEnvStr("QUBICDB_TLS_CERT", &cfg.Security.TLSCert)
 	setEnvStr("QUBICDB_TLS_KEY", &cfg.Security.TLSKey)
 	setEnvDuration("QUBICDB_READ_TIMEOUT", &cfg.Security.ReadTimeout)
 	setEnvDuration("QUBICDB_WRITE_TIMEOUT", &cfg.Security.WriteTimeout)
+	setEnvCSV("QUBICDB_TRUSTED_PROXIES", &cfg.Security.TrustedProxies)
+	setEnvInt("QUBICDB_AUTH_THROTTLE_MAX_FAILURES", &cfg.Security.AuthThrottle.MaxFailures)
+	setEnvDuration("QUBICDB_AUTH_THROTTLE_LOCKOUT_BASE", &cfg.Security.AuthThrottle.LockoutBase)
+	setEnvDuration("QUBICDB_AUTH_THROTTLE_LOCKOUT_MAX", &cfg.Security.AuthThrottle.LockoutMax)
+
+	// -- Write --
+	setEnvBool("QUBICDB_WRITE_SANITIZE_CONTENT", &cfg.Write.SanitizeContent)
+	setEnvInt("QUBICDB_WRITE_MAX_LINE_LENGTH", &cfg.Write.MaxLineLength)
+	setEnvBool("QUBICDB_WRITE_DETECT_CONFLICTS", &cfg.Write.DetectConflicts)
+	setEnvInt("QUBICDB_CONFLICT_TOP_K", &cfg.Write.Conflicts.TopK)
+	setEnvFloat("QUBICDB_CONFLICT_VECTOR_THRESHOLD", &cfg.Write.Conflicts.VectorThreshold)
+	setEnvFloat("QUBICDB_CONFLICT_LEXICAL_THRESHOLD", &cfg.Write.Conflicts.LexicalThreshold)
+	setEnvFloat("QUBICDB_CONFLICT_MIN_ENERGY", &cfg.Write.Conflicts.MinEnergy)
+	setEnvCSV("QUBICDB_CONFLICT_KEYS", &cfg.Write.Conflicts.Keys)
+
+	// -- Search --
+	setEnvBool("QUBICDB_SEARCH_TELEMETRY_ENABLED", &cfg.Search.Telemetry.Enabled)
+	setEnvBool("QUBICDB_SEARCH_TELEMETRY_SAMPLE_ZERO_RESULTS", &cfg.Search.Telemetry.SampleZeroResults)
+	setEnvInt("QUBICDB_SEARCH_TELEMETRY_SAMPLE_SIZE", &cfg.Search.Telemetry.ZeroResultSampleSize)
+	setEnvFloat("QUBICDB_SEARCH_BM25_K1", &cfg.Search.BM25.K1)
+	setEnvFloat("QUBICDB_SEARCH_BM25_B", &cfg.Search.BM25.B)
+	setEnvInt("QUBICDB_SEARCH_BM25_MAX_TERMS", &cfg.Search.BM25.MaxTerms)
+	setEnvFloat("QUBICDB_SEARCH_ANCHOR_WEIGHT", &cfg.Search.AnchorWeight)
+	setEnvFloat("QUBICDB_SEARCH_REINFORCE_SIMILARITY_FLOOR", &cfg.Search.Reinforce.SimilarityFloor)
+	setEnvInt("QUBICDB_SEARCH_REINFORCE_MAX_NEW_SYNAPSES", &cfg.Search.Reinforce.MaxNewSynapses)
+	setEnvBool("QUBICDB_SEARCH_EXPANSION_ENABLED", &cfg.Search.Expansion.Enabled)
+	setEnvInt("QUBICDB_SEARCH_EXPANSION_MAX_TERMS", &cfg.Search.Expansion.MaxTerms)
+	setEnvInt("QUBICDB_SEARCH_EXPANSION_MAX_ADDED_TOKENS", &cfg.Search.Expansion.MaxAddedTokens)
+	setEnvInt("QUBICDB_SEARCH_EXPANSION_MAX_CUE_TOKENS", &cfg.Search.Expansion.MaxCueTokens)
+	setEnvInt("QUBICDB_SEARCH_EXPANSION_SEEDS", &cfg.Search.Expansion.Seeds)
+	setEnvFloat("QUBICDB_SEARCH_EXPANSION_WEIGHT", &cfg.Search.Expansion.Weight)
+	setEnvBool("QUBICDB_SEARCH_FOLD_DIACRITICS", &cfg.Search.Lexical.FoldDiacritics)
+	setEnvStr("QUBICDB_SEARCH_LANGUAGE", &cfg.Search.Lexical.Language)
+
+	// -- Telemetry --
+	setEnvStr("QUBICDB_OTLP_ENDPOINT", &cfg.Telemetry.OTLPEndpoint)
+	setEnvFloat("QUBICDB_TRACE_SAMPLE_RATE", &cfg.Telemetry.SampleRate)
+	setEnvStr("QUBICDB_TRACE_SERVICE_NAME", &cfg.Telemetry.ServiceName)
+	setEnvStr("QUBICDB_TRACE_INDEX_ATTRIBUTE", &cfg.Telemetry.IndexAttribute)
+
+	// -- Stats --
+	setEnvBool("QUBICDB_STATS_NOISE", &cfg.Stats.Noise)
+	setEnvInt("QUBICDB_STATS_NOISE_BOUND", &cfg.Stats.NoiseBound)
+
+	// -- Sync --
+	setEnvInt("QUBICDB_SYNC_CHANGELOG_SIZE", &cfg.Sync.ChangelogSize)
+	setEnvInt("QUBICDB_SYNC_PAGE_SIZE", &cfg.Sync.PageSize)
+	setEnvFloat("QUBICDB_SYNC_ACTIVITY_THRESHOLD", &cfg.Sync.ActivityThreshold)
+
+	// -- Subscriptions --
+	setEnvBool("QUBICDB_SUBSCRIPTIONS_ENABLED", &cfg.Subscriptions.Enabled)
+	setEnvInt("QUBICDB_SUBSCRIPTIONS_MAX_PER_INDEX", &cfg.Subscriptions.MaxPerIndex)
+	setEnvDuration("QUBICDB_SUBSCRIPTIONS_TICK", &cfg.Subscriptions.TickInterval)
+	setEnvInt("QUBICDB_SUBSCRIPTIONS_HISTORY_SIZE", &cfg.Subscriptions.HistorySize)
+
+	// -- Shares --
+	setEnvBool("QUBICDB_SHARES_ENABLED", &cfg.Shares.Enabled)
+	setEnvInt("QUBICDB_SHARES_MAX_PER_INDEX", &cfg.Shares.MaxPerIndex)
+	setEnvInt("QUBICDB_SHARES_MAX_RESULTS", &cfg.Shares.MaxResults)
+	setEnvDuration("QUBICDB_SHARES_DEFAULT_EXPIRY", &cfg.Shares.DefaultExpiry)
+	setEnvDuration("QUBICDB_SHARES_MAX_EXPIRY", &cfg.Shares.MaxExpiry)
+	setEnvInt("QUBICDB_SHARES_RATE_LIMIT", &cfg.Shares.RateLimitRequests)
+	setEnvDuration("QUBICDB_SHARES_RATE_LIMIT_WINDOW", &cfg.Shares.RateLimitWindow)
+	setEnvInt("QUBICDB_SHARES_BADGE_RATE_LIMIT", &cfg.Shares.BadgeRateLimitRequests)
+	setEnvDuration("QUBICDB_SHARES_BADGE_RATE_LIMIT_WINDOW", &cfg.Shares.BadgeRateLimitWindow)
+	setEnvDuration("QUBICDB_SHARES_BADGE_MAX_AGE", &cfg.Shares.BadgeMaxAge)
+	setEnvDuration("QUBICDB_SHARES_BADGE_TIME_GRANULARITY", &cfg.Shares.BadgeTimeGranularity)
+
+	// -- Pins --
+	setEnvInt("QUBICDB_PINS_MAX_PER_INDEX", &cfg.Pins.MaxPerIndex)
+	setEnvFloat("QUBICDB_PINS_ENERGY_FLOOR", &cfg.Pins.EnergyFloor)
+
+	// -- Prefetch --
+	setEnvBool("QUBICDB_PREFETCH_ENABLED", &cfg.Prefetch.Enabled)
+	setEnvInt("QUBICDB_PREFETCH_MAX_INDEXES", &cfg.Prefetch.MaxIndexes)
+	setEnvDuration("QUBICDB_PREFETCH_GRACE", &cfg.Prefetch.Grace)
+	setEnvInt("QUBICDB_PREFETCH_MAX_LOADED", &cfg.Prefetch.MaxLoadedIndexes)
+	setEnvInt("QUBICDB_PREFETCH_RATE_LIMIT", &cfg.Prefetch.RateLimitRequests)
+	setEnvDuration("QUBICDB_PREFETCH_RATE_LIMIT_WINDOW", &cfg.Prefetch.RateLimitWindow)
+
+	// -- Context --
+	setEnvStr("QUBICDB_CONTEXT_TURN_PATTERN", &cfg.Context.TurnPattern)
+	setEnvStr("QUBICDB_CONTEXT_BLOCK_TEMPLATE", &cfg.Context.BlockTemplate)
+
+	// -- Replication --
+	setEnvStr("QUBICDB_REPLICATION_STANDBY_URL", &cfg.Replication.Standby.URL)
+	setEnvStr("QUBICDB_REPLICATION_STANDBY_API_KEY", &cfg.Replication.Standby.APIKey)
+	setEnvCSV("QUBICDB_REPLICATION_STANDBY_INDEXES", &cfg.Replication.Standby.Indexes)
+	setEnvInt("QUBICDB_REPLICATION_STANDBY_QUEUE_SIZE", &cfg.Replication.Standby.QueueSize)
+	setEnvDuration("QUBICDB_REPLICATION_STANDBY_FLUSH_INTERVAL", &cfg.Replication.Standby.FlushInterval)
+	setEnvDuration("QUBICDB_REPLICATION_STANDBY_TIMEOUT", &cfg.Replication.Standby.Timeout)
+	setEnvStr("QUBICDB_REPLICATION_STANDBY_OVERFLOW", &cfg.Replication.Standby.Overflow)
+
+	// -- Import --
+	setEnvInt64("QUBICDB_IMPORT_MAX_UPLOAD_BYTES", &cfg.Import.MaxUploadBytes)
+	setEnvInt("QUBICDB_IMPORT_MAX_FILES", &cfg.Import.MaxFiles)
+	setEnvFloat("QUBICDB_IMPORT_LINK_WEIGHT", &cfg.Import.LinkWeight)
+	setEnvStr("QUBICDB_IMPORT_DANGLING_SYNAPSES", &cfg.Import.DanglingSynapses)
+
+	// -- Sessions --
+	setEnvBool("QUBICDB_SESSIONS_ENABLED", &cfg.Sessions.Enabled)
+	setEnvDuration("QUBICDB_SESSIONS_TTL", &cfg.Sessions.TTL)
+	setEnvInt("QUBICDB_SESSIONS_MAX_NEURONS_PER_INDEX", &cfg.Sessions.MaxNeuronsPerIndex)
+	setEnvFloat("QUBICDB_SESSIONS_SPREAD_WEIGHT", &cfg.Sessions.SpreadWeight)
+
+	// -- Retention --
+	setEnvDuration("QUBICDB_RETENTION_INTERVAL", &cfg.Retention.Interval)
+	setEnvInt("QUBICDB_RETENTION_MAX_RULES", &cfg.Retention.MaxRules)
+	setEnvInt("QUBICDB_RETENTION_HISTORY_SIZE", &cfg.Retention.HistorySize)
+
+	// -- Sentiment --
+	setEnvStr("QUBICDB_SENTIMENT_PROVIDER", &cfg.Sentiment.Provider)
+	setEnvFloat("QUBICDB_SENTIMENT_MIN_ASCII_LETTER_RATIO", &cfg.Sentiment.MinASCIILetterRatio)
+
+	// -- Health --
+	setEnvDuration("QUBICDB_HEALTH_INTERVAL", &cfg.Health.Interval)
+	setEnvDuration("QUBICDB_HEALTH_STALE_AFTER", &cfg.Health.StaleAfter)
+
+	// -- Calibration --
+	setEnvDuration("QUBICDB_CALIBRATION_INTERVAL", &cfg.Calibration.Interval)
+	setEnvInt("QUBICDB_CALIBRATION_PAIRS", &cfg.Calibration.Pairs)
+	setEnvInt("QUBICDB_CALIBRATION_MIN_PAIRS", &cfg.Calibration.MinPairs)
+	setEnvFloat("QUBICDB_CALIBRATION_MIN_WEIGHT", &cfg.Calibration.MinWeight)
+	setEnvFloat("QUBICDB_CALIBRATION_TARGET_PRECISION", &cfg.Calibration.TargetPrecision)
 
 	return cfg
 }
@@ -509,6 +2272,57 @@ func (c *Config) Validate() error {
 	if c.Server.HTTPAddr == "" {
 		return fmt.Errorf("server.httpAddr must not be empty")
 	}
+	source := strings.ToLower(strings.TrimSpace(c.Server.IndexIDSource))
+	if source != "header" && source != "query" && source != "either" {
+		return fmt.Errorf("server.indexIdSource must be one of header|query|either")
+	}
+	c.Server.IndexIDSource = source
+	if c.Server.MinAPIVersion != "" {
+		if _, err := time.Parse("2006-01", c.Server.MinAPIVersion); err != nil {
+			return fmt.Errorf("server.minApiVersion must be a YYYY-MM version, got %q", c.Server.MinAPIVersion)
+		}
+	}
+	cc := c.Server.Concurrency
+	for _, limit := range []struct {
+		name  string
+		value int
+	}{
+		{"search", cc.Search},
+		{"context", cc.Context},
+		{"write", cc.Write},
+		{"admin", cc.Admin},
+		{"default", cc.Default},
+	} {
+		if limit.value < 0 {
+			return fmt.Errorf("server.concurrency.%s must be >= 0 (0 = unlimited)", limit.name)
+		}
+	}
+	if cc.QueueTimeout < 0 {
+		return fmt.Errorf("server.concurrency.queueTimeout must be >= 0")
+	}
+	if sh := c.Server.Shadow; sh.URL != "" {
+		u, err := url.Parse(sh.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("server.shadow.url must be an absolute http(s) URL")
+		}
+		if sh.SampleRate < 0 || sh.SampleRate > 1 {
+			return fmt.Errorf("server.shadow.sampleRate must be between 0.0 and 1.0")
+		}
+		if sh.QueueSize <= 0 {
+			return fmt.Errorf("server.shadow.queueSize must be > 0")
+		}
+		if sh.Timeout <= 0 {
+			return fmt.Errorf("server.shadow.timeout must be > 0")
+		}
+		if sh.MismatchSampleSize < 0 {
+			return fmt.Errorf("server.shadow.mismatchSampleSize must be >= 0")
+		}
+		for _, ep := range sh.Endpoints {
+			if !strings.HasPrefix(ep, "/v1/") {
+				return fmt.Errorf("server.shadow.endpoints entries must start with /v1/")
+			}
+		}
+	}
 
 	// Storage
 	if c.Storage.DataPath == "" {
@@ -522,9 +2336,83 @@ func (c *Config) Validate() error {
 	if c.Storage.FsyncPolicy == "interval" && c.Storage.FsyncInterval <= 0 {
 		return fmt.Errorf("storage.fsyncInterval must be > 0 when storage.fsyncPolicy is interval")
 	}
+	for i, sc := range c.Storage.Seed {
+		if sc.IndexID == "" || sc.File == "" {
+			return fmt.Errorf("storage.seed[%d] requires indexId and file", i)
+		}
+	}
 	if c.Storage.ChecksumValidationInterval < 0 {
 		return fmt.Errorf("storage.checksumValidationInterval must be >= 0")
 	}
+	if c.Storage.ManifestRetain < 0 {
+		return fmt.Errorf("storage.manifestRetain must be >= 0")
+	}
+	if c.Storage.StartupReportRetain < 0 {
+		return fmt.Errorf("storage.startupReportRetain must be >= 0")
+	}
+	if c.Storage.RetainVersions < 0 {
+		return fmt.Errorf("storage.retainVersions must be >= 0")
+	}
+	if c.Storage.RetainVersionsMaxAge < 0 {
+		return fmt.Errorf("storage.retainVersionsMaxAge must be >= 0")
+	}
+	if c.Storage.NeuronRecycleWindow < 0 {
+		return fmt.Errorf("storage.neuronRecycleWindow must be >= 0")
+	}
+	if c.Storage.DiskCheckInterval < 0 {
+		return fmt.Errorf("storage.diskCheckInterval must be >= 0")
+	}
+	if c.Storage.AttachmentSweepInterval < 0 || c.Storage.AttachmentGracePeriod < 0 {
+		return fmt.Errorf("storage.attachmentSweepInterval and storage.attachmentGracePeriod must be >= 0")
+	}
+	if c.Storage.WarnFreeBytes < 0 || c.Storage.MinFreeBytes < 0 {
+		return fmt.Errorf("storage.warnFreeBytes and storage.minFreeBytes must be >= 0")
+	}
+	if c.Storage.WarnFreeBytes > 0 && c.Storage.WarnFreeBytes < c.Storage.MinFreeBytes {
+		return fmt.Errorf("storage.warnFreeBytes (%d) must be >= storage.minFreeBytes (%d)", c.Storage.WarnFreeBytes, c.Storage.MinFreeBytes)
+	}
+	if c.Storage.FlushRetryBackoff < 0 || c.Storage.FlushStaleAfter < 0 || c.Storage.FlushStaleFailAfter < 0 {
+		return fmt.Errorf("storage.flushRetryBackoff, storage.flushStaleAfter and storage.flushStaleFailAfter must be >= 0")
+	}
+	if c.Storage.FlushRetryMaxBackoff < c.Storage.FlushRetryBackoff {
+		return fmt.Errorf("storage.flushRetryMaxBackoff (%s) must be >= storage.flushRetryBackoff (%s)", c.Storage.FlushRetryMaxBackoff, c.Storage.FlushRetryBackoff)
+	}
+	if c.Storage.FlushStaleAfter > 0 && c.Storage.FlushStaleFailAfter > 0 && c.Storage.FlushStaleFailAfter < c.Storage.FlushStaleAfter {
+		return fmt.Errorf("storage.flushStaleFailAfter (%s) must be >= storage.flushStaleAfter (%s)", c.Storage.FlushStaleFailAfter, c.Storage.FlushStaleAfter)
+	}
+	if c.Storage.IndexQuotaBytes < 0 {
+		return fmt.Errorf("storage.indexQuotaBytes must be >= 0")
+	}
+	if !IsQuotaPolicy(c.Storage.IndexQuotaPolicy) {
+		return fmt.Errorf("storage.indexQuotaPolicy must be reject or evict_lowest_energy, got %q", c.Storage.IndexQuotaPolicy)
+	}
+	if c.Storage.IndexQuotaGrace < 1 {
+		return fmt.Errorf("storage.indexQuotaGrace must be >= 1, got %g", c.Storage.IndexQuotaGrace)
+	}
+	if archive := c.Storage.WALArchive; archive.Dir != "" {
+		switch {
+		case !c.Storage.WALEnabled:
+			return fmt.Errorf("storage.walArchive.dir requires storage.walEnabled")
+		case archive.QueueSize < 1:
+			return fmt.Errorf("storage.walArchive.queueSize must be >= 1")
+		case archive.SegmentBytes < 1:
+			return fmt.Errorf("storage.walArchive.segmentBytes must be >= 1")
+		case archive.SegmentInterval <= 0:
+			return fmt.Errorf("storage.walArchive.segmentInterval must be > 0")
+		case archive.MaxAge < 0:
+			return fmt.Errorf("storage.walArchive.maxAge must be >= 0")
+		case archive.MaxBytes < 0:
+			return fmt.Errorf("storage.walArchive.maxBytes must be >= 0")
+		}
+	}
+	switch m := c.Storage.Migration; {
+	case m.MaxPasses < 0:
+		return fmt.Errorf("storage.migration.maxPasses must be >= 0")
+	case m.SettleIndexes < 0:
+		return fmt.Errorf("storage.migration.settleIndexes must be >= 0")
+	case m.MaxPause <= 0:
+		return fmt.Errorf("storage.migration.maxPause must be > 0")
+	}
 
 	// Matrix
 	if c.Matrix.MinDimension < 1 {
@@ -537,6 +2425,9 @@ func (c *Config) Validate() error {
 	if c.Matrix.MaxNeurons < 1 {
 		return fmt.Errorf("matrix.maxNeurons must be >= 1, got %d", c.Matrix.MaxNeurons)
 	}
+	if err := ValidateIndexedMetadataKeys(c.Matrix.IndexedMetadataKeys); err != nil {
+		return fmt.Errorf("matrix.indexedMetadataKeys: %w", err)
+	}
 
 	// Lifecycle — ensure ordering makes sense
 	if c.Lifecycle.IdleThreshold <= 0 {
@@ -550,6 +2441,12 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("lifecycle.dormantThreshold (%v) must be > lifecycle.sleepThreshold (%v)",
 			c.Lifecycle.DormantThreshold, c.Lifecycle.SleepThreshold)
 	}
+	if c.Lifecycle.StateWaitMax <= 0 {
+		return fmt.Errorf("lifecycle.stateWaitMax must be > 0")
+	}
+	if c.Lifecycle.MaxStateWaiters < 1 {
+		return fmt.Errorf("lifecycle.maxStateWaiters must be >= 1, got %d", c.Lifecycle.MaxStateWaiters)
+	}
 
 	// Daemons — all intervals must be positive
 	for name, d := range map[string]time.Duration{
@@ -564,10 +2461,89 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	prune := c.Daemons.Prune
+	score := prune.SynapseScore
+	if score.Weight < 0 || score.CoFire < 0 || score.Recency < 0 || score.Depth < 0 {
+		return fmt.Errorf("daemons.prune.synapseScore weights must be >= 0")
+	}
+	if score.Weight+score.CoFire+score.Recency+score.Depth <= 0 {
+		return fmt.Errorf("daemons.prune.synapseScore weights must not all be 0")
+	}
+	if score.RecencyHalfLife <= 0 {
+		return fmt.Errorf("daemons.prune.synapseScore.recencyHalfLife must be > 0")
+	}
+	for typ, w := range score.TypeWeights {
+		if !ValidSynapseType(typ) {
+			return fmt.Errorf("daemons.prune.synapseScore.typeWeights: %q is not a synapse type", typ)
+		}
+		if w < 0 {
+			return fmt.Errorf("daemons.prune.synapseScore.typeWeights.%s must be >= 0", typ)
+		}
+	}
+	if prune.MinScore < 0 || prune.MinScore > 1 {
+		return fmt.Errorf("daemons.prune.minScore must be between 0.0 and 1.0")
+	}
+	if prune.MaxSynapsesPerNeuron < 0 {
+		return fmt.Errorf("daemons.prune.maxSynapsesPerNeuron must be >= 0")
+	}
+	if prune.MaxSynapses < 0 {
+		return fmt.Errorf("daemons.prune.maxSynapses must be >= 0")
+	}
+
+	compact := c.Daemons.Consolidate.CompactContent
+	if compact.MinAge <= 0 {
+		return fmt.Errorf("daemons.consolidate.compactContent.minAge must be > 0")
+	}
+	if compact.MaxEnergy < 0 || compact.MaxEnergy > 1 {
+		return fmt.Errorf("daemons.consolidate.compactContent.maxEnergy must be between 0.0 and 1.0")
+	}
+	if compact.KeepChars < 1 {
+		return fmt.Errorf("daemons.consolidate.compactContent.keepChars must be >= 1")
+	}
+	if compact.RecentAccess < 0 {
+		return fmt.Errorf("daemons.consolidate.compactContent.recentAccess must be >= 0")
+	}
+	if c.Daemons.Consolidate.PromotionHistory < 0 {
+		return fmt.Errorf("daemons.consolidate.promotionHistory must be >= 0")
+	}
+	if err := c.Daemons.Decay.Adaptive.validate("daemons.decay.adaptive"); err != nil {
+		return err
+	}
+	if err := c.Daemons.Consolidate.Adaptive.validate("daemons.consolidate.adaptive"); err != nil {
+		return err
+	}
+	if err := prune.Adaptive.validate("daemons.prune.adaptive"); err != nil {
+		return err
+	}
+
 	// Worker
 	if c.Worker.MaxIdleTime <= 0 {
 		return fmt.Errorf("worker.maxIdleTime must be > 0")
 	}
+	if c.Worker.BackgroundSlice <= 0 {
+		return fmt.Errorf("worker.backgroundSlice must be > 0")
+	}
+	if c.Worker.ReadConcurrency < 1 {
+		return fmt.Errorf("worker.readConcurrency must be >= 1")
+	}
+	if c.Worker.LoadTimeout < 0 {
+		return fmt.Errorf("worker.loadTimeout must be >= 0")
+	}
+	if c.Worker.LoadTimeout > 0 && c.Security.WriteTimeout > 0 && c.Worker.LoadTimeout >= c.Security.WriteTimeout {
+		return fmt.Errorf("worker.loadTimeout must be shorter than security.writeTimeout")
+	}
+	if c.Worker.DeadLetters.Capacity < 1 {
+		return fmt.Errorf("worker.deadLetters.capacity must be >= 1")
+	}
+	if c.Worker.DeadLetters.MaxBytes < 0 {
+		return fmt.Errorf("worker.deadLetters.maxBytes must be >= 0")
+	}
+	if c.Worker.DeadLetters.QuarantineAfter < 0 {
+		return fmt.Errorf("worker.deadLetters.quarantineAfter must be >= 0")
+	}
+	if c.Worker.DeadLetters.QuarantineAfter > 0 && (c.Worker.DeadLetters.QuarantineWindow <= 0 || c.Worker.DeadLetters.QuarantineDuration <= 0) {
+		return fmt.Errorf("worker.deadLetters.quarantineWindow and quarantineDuration must be > 0 when quarantineAfter is set")
+	}
 
 	// Matrix — boundary guards (unless you know what you are doing)
 	if c.Matrix.MaxNeurons > 10_000_000 {
@@ -579,16 +2555,52 @@ func (c *Config) Validate() error {
 
 	// Admin
 	if c.Admin.Enabled {
-		if c.Admin.User == "" || c.Admin.Password == "" {
+		legacyUnset := c.Admin.User == "" && c.Admin.Password == ""
+		if (c.Admin.User == "" || c.Admin.Password == "") && !(legacyUnset && len(c.Admin.Users) > 0) {
 			return fmt.Errorf("admin.user and admin.password must not be empty when admin is enabled")
 		}
-		if c.Admin.Password == "qubicdb" {
+		if c.Admin.Password == DefaultAdminPassword {
 			if isProductionMode() {
-				return fmt.Errorf("admin.password must not use default value in production")
+				return fmt.Errorf("admin.password must not use default value in production (a first run without a config file generates one)")
 			}
 			log.Printf("⚠ WARNING: admin.password is set to the default value — change it before deploying to production")
 		}
 	}
+	seenAdminUsers := make(map[string]bool, len(c.Admin.Users))
+	for i, u := range c.Admin.Users {
+		if u.User == "" {
+			return fmt.Errorf("admin.users[%d].user must not be empty", i)
+		}
+		if seenAdminUsers[u.User] || (u.User == c.Admin.User && c.Admin.Password != "") {
+			return fmt.Errorf("admin.users[%d].user %q is defined more than once", i, u.User)
+		}
+		seenAdminUsers[u.User] = true
+		if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+			return fmt.Errorf("admin.users[%d].passwordHash must be a bcrypt hash", i)
+		}
+		switch u.Role {
+		case RoleViewer, RoleOperator, RoleAdmin:
+		default:
+			return fmt.Errorf("admin.users[%d].role must be one of viewer|operator|admin", i)
+		}
+	}
+	switch c.Admin.Clone.ContentMode {
+	case CloneContentHash, CloneContentRedact:
+	default:
+		return fmt.Errorf("admin.clone.contentMode must be one of hash|redact")
+	}
+	if c.Admin.SessionTTL <= 0 {
+		return fmt.Errorf("admin.sessionTTL must be > 0")
+	}
+	if c.Admin.Journal.MaxTTL <= 0 {
+		return fmt.Errorf("admin.journal.maxTTL must be > 0")
+	}
+	if c.Admin.Journal.DefaultTTL <= 0 || c.Admin.Journal.DefaultTTL > c.Admin.Journal.MaxTTL {
+		return fmt.Errorf("admin.journal.defaultTTL must be > 0 and <= admin.journal.maxTTL")
+	}
+	if c.Admin.Journal.Capacity < 1 {
+		return fmt.Errorf("admin.journal.capacity must be >= 1")
+	}
 
 	// MCP
 	mcpPath := strings.TrimSpace(c.MCP.Path)
@@ -608,6 +2620,9 @@ func (c *Config) Validate() error {
 	if c.MCP.RateLimitBurst < 0 {
 		return fmt.Errorf("mcp.rateLimitBurst must be >= 0")
 	}
+	if c.MCP.MaxToolDurationMS <= 0 {
+		return fmt.Errorf("mcp.maxToolDurationMS must be > 0")
+	}
 	if len(c.MCP.AllowedTools) > 0 {
 		dedup := make(map[string]struct{}, len(c.MCP.AllowedTools))
 		invalid := make(map[string]struct{})
@@ -645,6 +2660,9 @@ func (c *Config) Validate() error {
 	if c.Security.MaxNeuronContentBytes <= 0 {
 		return fmt.Errorf("security.maxNeuronContentBytes must be > 0")
 	}
+	if c.Security.MaxAttachmentBytes <= 0 {
+		return fmt.Errorf("security.maxAttachmentBytes must be > 0")
+	}
 	if c.Security.ReadTimeout <= 0 {
 		return fmt.Errorf("security.readTimeout must be > 0")
 	}
@@ -663,6 +2681,300 @@ func (c *Config) Validate() error {
 	if c.Security.TLSKey != "" && c.Security.TLSCert == "" {
 		return fmt.Errorf("security.tlsCert is required when security.tlsKey is set")
 	}
+	for _, proxy := range c.Security.TrustedProxies {
+		if _, err := ParseTrustedProxy(proxy); err != nil {
+			return fmt.Errorf("security.trustedProxies: %w", err)
+		}
+	}
+	if at := c.Security.AuthThrottle; at.MaxFailures < 0 {
+		return fmt.Errorf("security.authThrottle.maxFailures must be >= 0 (0 = disabled)")
+	} else if at.MaxFailures > 0 {
+		if at.LockoutBase <= 0 {
+			return fmt.Errorf("security.authThrottle.lockoutBase must be > 0")
+		}
+		if at.LockoutMax < at.LockoutBase {
+			return fmt.Errorf("security.authThrottle.lockoutMax must be >= lockoutBase")
+		}
+	}
+
+	// Write
+	if c.Write.MaxLineLength < 0 {
+		return fmt.Errorf("write.maxLineLength must be >= 0 (0 = unlimited)")
+	}
+	if c.Write.Conflicts.TopK < 1 {
+		return fmt.Errorf("write.conflicts.topK must be >= 1")
+	}
+	if c.Write.Conflicts.VectorThreshold < 0 || c.Write.Conflicts.VectorThreshold > 1 {
+		return fmt.Errorf("write.conflicts.vectorThreshold must be between 0.0 and 1.0")
+	}
+	if c.Write.Conflicts.LexicalThreshold < 0 || c.Write.Conflicts.LexicalThreshold > 1 {
+		return fmt.Errorf("write.conflicts.lexicalThreshold must be between 0.0 and 1.0")
+	}
+	if c.Write.Conflicts.MinEnergy < 0 || c.Write.Conflicts.MinEnergy > 1 {
+		return fmt.Errorf("write.conflicts.minEnergy must be between 0.0 and 1.0")
+	}
+
+	// Search
+	if c.Search.Telemetry.ZeroResultSampleSize < 0 {
+		return fmt.Errorf("search.telemetry.zeroResultSampleSize must be >= 0")
+	}
+	if c.Search.BM25.K1 < 0 {
+		return fmt.Errorf("search.bm25.k1 must be >= 0")
+	}
+	if c.Search.BM25.B < 0 || c.Search.BM25.B > 1 {
+		return fmt.Errorf("search.bm25.b must be between 0.0 and 1.0")
+	}
+	if c.Search.BM25.MaxTerms < 0 {
+		return fmt.Errorf("search.bm25.maxTerms must be >= 0 (0 = unbounded)")
+	}
+	if c.Search.AnchorWeight < 0 || c.Search.AnchorWeight > 1 {
+		return fmt.Errorf("search.anchorWeight must be between 0.0 and 1.0")
+	}
+	if c.Search.Reinforce.SimilarityFloor < 0 || c.Search.Reinforce.SimilarityFloor > 1 {
+		return fmt.Errorf("search.reinforce.similarityFloor must be between 0.0 and 1.0, got %f", c.Search.Reinforce.SimilarityFloor)
+	}
+	if c.Search.Reinforce.MaxNewSynapses < 0 {
+		return fmt.Errorf("search.reinforce.maxNewSynapses must be >= 0, got %d", c.Search.Reinforce.MaxNewSynapses)
+	}
+	if err := c.Search.Expansion.Validate(); err != nil {
+		return fmt.Errorf("search.expansion.%w", err)
+	}
+	if lang := c.Search.Lexical.Language; lang != "" && !IsLanguageTag(lang) {
+		return fmt.Errorf("search.lexical.language must be a language tag such as tr or de-AT, got %q", lang)
+	}
+
+	// Telemetry
+	if ep := c.Telemetry.OTLPEndpoint; ep != "" {
+		u, err := url.Parse(ep)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("telemetry.otlpEndpoint must be an absolute http(s) URL")
+		}
+	}
+	if c.Telemetry.SampleRate < 0 || c.Telemetry.SampleRate > 1 {
+		return fmt.Errorf("telemetry.sampleRate must be between 0.0 and 1.0")
+	}
+	switch c.Telemetry.IndexAttribute {
+	case TraceIndexRaw, TraceIndexHash, TraceIndexOmit:
+	default:
+		return fmt.Errorf("telemetry.indexAttribute must be one of raw|hash|omit")
+	}
+
+	// Stats
+	if c.Stats.NoiseBound < 0 || (c.Stats.Noise && c.Stats.NoiseBound < 1) {
+		return fmt.Errorf("stats.noiseBound must be >= 1 when stats.noise is enabled")
+	}
+
+	// Sync
+	if c.Sync.ChangelogSize < 1 {
+		return fmt.Errorf("sync.changelogSize must be >= 1")
+	}
+	if c.Sync.PageSize < 1 {
+		return fmt.Errorf("sync.pageSize must be >= 1")
+	}
+	if c.Sync.ActivityThreshold < 0 || c.Sync.ActivityThreshold > 1 {
+		return fmt.Errorf("sync.activityThreshold must be between 0.0 and 1.0")
+	}
+
+	// Subscriptions
+	if c.Subscriptions.Enabled {
+		if c.Subscriptions.MaxPerIndex < 1 {
+			return fmt.Errorf("subscriptions.maxPerIndex must be >= 1")
+		}
+		if c.Subscriptions.TickInterval <= 0 {
+			return fmt.Errorf("subscriptions.tickInterval must be > 0")
+		}
+		if c.Subscriptions.HistorySize < 1 {
+			return fmt.Errorf("subscriptions.historySize must be >= 1")
+		}
+	}
+
+	// Shares
+	if c.Shares.Enabled {
+		if c.Shares.MaxPerIndex < 1 {
+			return fmt.Errorf("shares.maxPerIndex must be >= 1")
+		}
+		if c.Shares.MaxResults < 1 {
+			return fmt.Errorf("shares.maxResults must be >= 1")
+		}
+		if c.Shares.DefaultExpiry <= 0 || c.Shares.MaxExpiry <= 0 {
+			return fmt.Errorf("shares.defaultExpiry and shares.maxExpiry must be > 0")
+		}
+		if c.Shares.DefaultExpiry > c.Shares.MaxExpiry {
+			return fmt.Errorf("shares.defaultExpiry must not exceed shares.maxExpiry")
+		}
+		if c.Shares.RateLimitRequests < 1 || c.Shares.RateLimitWindow <= 0 {
+			return fmt.Errorf("shares.rateLimitRequests must be >= 1 and shares.rateLimitWindow > 0")
+		}
+		if c.Shares.BadgeRateLimitRequests < 1 || c.Shares.BadgeRateLimitWindow <= 0 {
+			return fmt.Errorf("shares.badgeRateLimitRequests must be >= 1 and shares.badgeRateLimitWindow > 0")
+		}
+		if c.Shares.BadgeMaxAge < 0 {
+			return fmt.Errorf("shares.badgeMaxAge must be >= 0")
+		}
+		if c.Shares.BadgeTimeGranularity < time.Second {
+			return fmt.Errorf("shares.badgeTimeGranularity must be >= 1s")
+		}
+	}
+
+	// Pins
+	if c.Pins.MaxPerIndex < 0 {
+		return fmt.Errorf("pins.maxPerIndex must be >= 0")
+	}
+	if c.Pins.EnergyFloor < 0 || c.Pins.EnergyFloor > 1 {
+		return fmt.Errorf("pins.energyFloor must be between 0.0 and 1.0")
+	}
+
+	// Prefetch
+	if c.Prefetch.Enabled {
+		if c.Prefetch.MaxIndexes < 1 {
+			return fmt.Errorf("prefetch.maxIndexes must be >= 1")
+		}
+		if c.Prefetch.Grace < 0 {
+			return fmt.Errorf("prefetch.grace must be >= 0")
+		}
+		if c.Prefetch.MaxLoadedIndexes < 0 {
+			return fmt.Errorf("prefetch.maxLoadedIndexes must be >= 0")
+		}
+		if c.Prefetch.RateLimitRequests < 1 || c.Prefetch.RateLimitWindow <= 0 {
+			return fmt.Errorf("prefetch.rateLimitRequests must be >= 1 and prefetch.rateLimitWindow > 0")
+		}
+	}
+
+	// Context
+	if _, ok := c.Context.TurnTemplates[DefaultTurnTemplate]; !ok {
+		return fmt.Errorf("context.turnTemplates must define %q", DefaultTurnTemplate)
+	}
+	for name, tmpl := range c.Context.TurnTemplates {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("context.turnTemplates names must not be empty")
+		}
+		if IsContextFormat(name) {
+			return fmt.Errorf("context.turnTemplates: %q is reserved for a context output format", name)
+		}
+		if _, err := ParseTurnTemplate(tmpl); err != nil {
+			return fmt.Errorf("context.turnTemplates.%s: %w", name, err)
+		}
+	}
+	if _, err := CompileTurnPattern(c.Context.TurnPattern); err != nil {
+		return fmt.Errorf("context.turnPattern: %w", err)
+	}
+	if _, err := ParseBlockTemplate(c.Context.BlockTemplate); err != nil {
+		return fmt.Errorf("context.blockTemplate: %w", err)
+	}
+
+	// Replication
+	if sb := c.Replication.Standby; sb.URL != "" {
+		u, err := url.Parse(sb.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("replication.standby.url must be an absolute http(s) URL")
+		}
+		if len(sb.Indexes) == 0 {
+			return fmt.Errorf("replication.standby.indexes must list at least one pattern")
+		}
+		for _, pattern := range sb.Indexes {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("replication.standby.indexes: invalid pattern %q", pattern)
+			}
+		}
+		if sb.QueueSize < 1 {
+			return fmt.Errorf("replication.standby.queueSize must be >= 1")
+		}
+		if sb.FlushInterval <= 0 || sb.Timeout <= 0 {
+			return fmt.Errorf("replication.standby.flushInterval and replication.standby.timeout must be > 0")
+		}
+		if sb.Overflow != "drop_oldest" && sb.Overflow != "block" {
+			return fmt.Errorf("replication.standby.overflow must be drop_oldest or block")
+		}
+	}
+
+	// Import
+	if c.Import.MaxUploadBytes < 1 {
+		return fmt.Errorf("import.maxUploadBytes must be >= 1")
+	}
+	if c.Import.MaxFiles < 1 {
+		return fmt.Errorf("import.maxFiles must be >= 1")
+	}
+	if c.Import.LinkWeight <= 0 || c.Import.LinkWeight > 1 {
+		return fmt.Errorf("import.linkWeight must be in (0, 1], got %f", c.Import.LinkWeight)
+	}
+	if c.Import.DanglingSynapses != DanglingDrop && c.Import.DanglingSynapses != DanglingKeep {
+		return fmt.Errorf("import.danglingSynapses must be drop or keep, got %q", c.Import.DanglingSynapses)
+	}
+
+	// Sessions
+	if c.Sessions.Enabled {
+		if c.Sessions.TTL <= 0 {
+			return fmt.Errorf("sessions.ttl must be > 0")
+		}
+		if c.Sessions.MaxNeuronsPerIndex < 1 {
+			return fmt.Errorf("sessions.maxNeuronsPerIndex must be >= 1")
+		}
+		if c.Sessions.SpreadWeight < 0 || c.Sessions.SpreadWeight > 1 {
+			return fmt.Errorf("sessions.spreadWeight must be between 0.0 and 1.0, got %f", c.Sessions.SpreadWeight)
+		}
+	}
+
+	// Retention
+	if c.Retention.Interval < 0 {
+		return fmt.Errorf("retention.interval must be >= 0")
+	}
+	if c.Retention.MaxRules < 1 {
+		return fmt.Errorf("retention.maxRules must be >= 1, got %d", c.Retention.MaxRules)
+	}
+	if c.Retention.HistorySize < 1 {
+		return fmt.Errorf("retention.historySize must be >= 1, got %d", c.Retention.HistorySize)
+	}
+	if err := ValidateRetentionRules(c.Retention.DefaultRules, c.Retention.MaxRules); err != nil {
+		return fmt.Errorf("retention.defaultRules: %w", err)
+	}
+
+	// Sentiment
+	if c.Sentiment.Provider == "" {
+		return fmt.Errorf("sentiment.provider must be set; use \"none\" to disable the sentiment layer")
+	}
+	if c.Sentiment.MinASCIILetterRatio < 0 || c.Sentiment.MinASCIILetterRatio > 1 {
+		return fmt.Errorf("sentiment.minAsciiLetterRatio must be between 0.0 and 1.0, got %f", c.Sentiment.MinASCIILetterRatio)
+	}
+
+	// Health
+	if c.Health.Interval < 0 {
+		return fmt.Errorf("health.interval must be >= 0, got %s", c.Health.Interval)
+	}
+	if c.Health.StaleAfter <= 0 {
+		return fmt.Errorf("health.staleAfter must be > 0, got %s", c.Health.StaleAfter)
+	}
+	w := c.Health.Weights
+	for _, weight := range []struct {
+		name  string
+		value float64
+	}{
+		{"consolidation", w.Consolidation}, {"energy", w.Energy}, {"connectivity", w.Connectivity},
+		{"conflicts", w.Conflicts}, {"freshness", w.Freshness}, {"embeddings", w.Embeddings},
+	} {
+		if weight.value < 0 {
+			return fmt.Errorf("health.weights.%s must be >= 0, got %f", weight.name, weight.value)
+		}
+	}
+	if w.Consolidation+w.Energy+w.Connectivity+w.Conflicts+w.Freshness+w.Embeddings == 0 {
+		return fmt.Errorf("health.weights must not all be 0")
+	}
+
+	// Calibration
+	if c.Calibration.Interval < 0 {
+		return fmt.Errorf("calibration.interval must be >= 0, got %s", c.Calibration.Interval)
+	}
+	if c.Calibration.Pairs < 1 {
+		return fmt.Errorf("calibration.pairs must be >= 1, got %d", c.Calibration.Pairs)
+	}
+	if c.Calibration.MinPairs < 1 || c.Calibration.MinPairs > c.Calibration.Pairs {
+		return fmt.Errorf("calibration.minPairs must be between 1 and calibration.pairs (%d), got %d", c.Calibration.Pairs, c.Calibration.MinPairs)
+	}
+	if c.Calibration.MinWeight <= 0 || c.Calibration.MinWeight > 1 {
+		return fmt.Errorf("calibration.minWeight must be above 0.0 and at most 1.0, got %f", c.Calibration.MinWeight)
+	}
+	if c.Calibration.TargetPrecision <= 0.5 || c.Calibration.TargetPrecision > 1 {
+		return fmt.Errorf("calibration.targetPrecision must be above 0.5 and at most 1.0, got %f", c.Calibration.TargetPrecision)
+	}
 
 	// Vector
 	if c.Vector.Enabled {
@@ -678,6 +2990,46 @@ func (c *Config) Validate() error {
 		if c.Vector.EmbedContextSize < 512 {
 			return fmt.Errorf("vector.embedContextSize must be >= 512, got %d", c.Vector.EmbedContextSize)
 		}
+		if c.Vector.MaxLoadedModels < 1 {
+			return fmt.Errorf("vector.maxLoadedModels must be >= 1, got %d", c.Vector.MaxLoadedModels)
+		}
+		if c.Vector.ProbeTimeout <= 0 {
+			return fmt.Errorf("vector.probeTimeout must be > 0, got %s", c.Vector.ProbeTimeout)
+		}
+		if c.Vector.ProbeInterval < 0 {
+			return fmt.Errorf("vector.probeInterval must be >= 0 (0 disables), got %s", c.Vector.ProbeInterval)
+		}
+		if c.Vector.Retries < 0 {
+			return fmt.Errorf("vector.retries must be >= 0, got %d", c.Vector.Retries)
+		}
+		if c.Vector.RetryBackoff < 0 {
+			return fmt.Errorf("vector.retryBackoff must be >= 0, got %s", c.Vector.RetryBackoff)
+		}
+		if c.Vector.BreakerThreshold < 0 {
+			return fmt.Errorf("vector.breakerThreshold must be >= 0 (0 disables), got %d", c.Vector.BreakerThreshold)
+		}
+		if c.Vector.BreakerThreshold > 0 && c.Vector.BreakerCooldown <= 0 {
+			return fmt.Errorf("vector.breakerCooldown must be > 0, got %s", c.Vector.BreakerCooldown)
+		}
+		if c.Vector.PendingEmbedInterval <= 0 {
+			return fmt.Errorf("vector.pendingEmbedInterval must be > 0, got %s", c.Vector.PendingEmbedInterval)
+		}
+		seenModels := make(map[string]bool, len(c.Vector.Models))
+		for i, m := range c.Vector.Models {
+			if m.Name == "" || m.Path == "" {
+				return fmt.Errorf("vector.models[%d] must set name and path", i)
+			}
+			if m.Name == DefaultEmbeddingModel {
+				return fmt.Errorf("vector.models[%d].name %q is reserved for vector.modelPath", i, m.Name)
+			}
+			if seenModels[m.Name] {
+				return fmt.Errorf("vector.models[%d].name %q is not unique", i, m.Name)
+			}
+			if m.GPULayers < 0 {
+				return fmt.Errorf("vector.models[%d].gpuLayers must be >= 0, got %d", i, m.GPULayers)
+			}
+			seenModels[m.Name] = true
+		}
 	}
 
 	// Daemon boundary guards
@@ -691,6 +3043,25 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ParseTrustedProxy parses a security.trustedProxies entry, an IP address
+// or a CIDR range, into a prefix.
+func ParseTrustedProxy(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", s)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
 func isProductionMode() bool {
 	for _, key := range []string{"QUBICDB_ENV", "GO_ENV", "APP_ENV"} {
 		v := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
@@ -822,6 +3193,7 @@ type CLIOverrides struct {
 	MaxNeuronContentBytes  *int64
 	TLSCert                *string
 	TLSKey                 *string
+	SeedForce              *bool
 }
 
 // ApplyCLIOverrides patches the Config with any explicitly-set CLI flags.
@@ -921,6 +3293,9 @@ func (c *Config) ApplyCLIOverrides(o *CLIOverrides) {
 	if o.TLSKey != nil {
 		c.Security.TLSKey = *o.TLSKey
 	}
+	if o.SeedForce != nil {
+		c.Storage.SeedForce = *o.SeedForce
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -1,10 +1,15 @@
 package core
 
 import (
+	"errors"
+	"net/netip"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // ---------------------------------------------------------------------------
@@ -666,6 +671,21 @@ func TestValidate_MaxNeuronsZero(t *testing.T) {
 	}
 }
 
+func TestValidate_IndexedMetadataKeys(t *testing.T) {
+	for _, keys := range [][]string{{""}, {"role", "role"}, {"_superseded_by"}} {
+		cfg := DefaultConfig()
+		cfg.Matrix.IndexedMetadataKeys = keys
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("indexedMetadataKeys %q should fail validation", keys)
+		}
+	}
+	cfg := DefaultConfig()
+	cfg.Matrix.IndexedMetadataKeys = nil
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("no indexed keys should pass: %v", err)
+	}
+}
+
 func TestValidate_LifecycleOrdering(t *testing.T) {
 	// IdleThreshold <= 0
 	cfg := DefaultConfig()
@@ -720,6 +740,225 @@ func TestValidate_WorkerMaxIdleTimePositive(t *testing.T) {
 	}
 }
 
+func TestWorkerBackgroundSlice_DefaultEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Worker.BackgroundSlice != 10*time.Millisecond {
+		t.Errorf("expected BackgroundSlice 10ms, got %v", cfg.Worker.BackgroundSlice)
+	}
+
+	t.Setenv("QUBICDB_WORKER_BACKGROUND_SLICE", "25ms")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Worker.BackgroundSlice != 25*time.Millisecond {
+		t.Errorf("expected BackgroundSlice 25ms from env, got %v", cfg.Worker.BackgroundSlice)
+	}
+
+	cfg.Worker.BackgroundSlice = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("BackgroundSlice 0 should fail validation")
+	}
+}
+
+func TestDaemonsPrune_DefaultEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Daemons.Prune.MaxSynapsesPerNeuron != 50 || cfg.Daemons.Prune.SynapseScore.Weight != 0.4 {
+		t.Errorf("unexpected prune defaults: %+v", cfg.Daemons.Prune)
+	}
+
+	t.Setenv("QUBICDB_PRUNE_MAX_SYNAPSES", "5000")
+	t.Setenv("QUBICDB_PRUNE_SCORE_DEPTH", "0.5")
+	t.Setenv("QUBICDB_PRUNE_SCORE_RECENCY_HALF_LIFE", "48h")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Daemons.Prune.MaxSynapses != 5000 || cfg.Daemons.Prune.SynapseScore.Depth != 0.5 ||
+		cfg.Daemons.Prune.SynapseScore.RecencyHalfLife != 48*time.Hour {
+		t.Errorf("env overrides not applied: %+v", cfg.Daemons.Prune)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid prune config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*PruneConfig){
+		"negative weight": func(p *PruneConfig) { p.SynapseScore.CoFire = -1 },
+		"all zero": func(p *PruneConfig) {
+			p.SynapseScore = SynapseScoreConfig{RecencyHalfLife: time.Hour}
+		},
+		"zero half-life":    func(p *PruneConfig) { p.SynapseScore.RecencyHalfLife = 0 },
+		"min score above 1": func(p *PruneConfig) { p.MinScore = 1.5 },
+		"negative budget":   func(p *PruneConfig) { p.MaxSynapsesPerNeuron = -1 },
+	} {
+		cfg := DefaultConfig()
+		mutate(&cfg.Daemons.Prune)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s should fail validation", name)
+		}
+	}
+}
+
+func TestCompactContentConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	compact := cfg.Daemons.Consolidate.CompactContent
+	if compact.Enabled || compact.KeepChars != 280 || compact.MinAge != 30*24*time.Hour {
+		t.Errorf("unexpected compaction defaults: %+v", compact)
+	}
+
+	t.Setenv("QUBICDB_COMPACT_CONTENT_ENABLED", "true")
+	t.Setenv("QUBICDB_COMPACT_CONTENT_MIN_AGE", "72h")
+	t.Setenv("QUBICDB_COMPACT_CONTENT_MAX_ENERGY", "0.1")
+	t.Setenv("QUBICDB_COMPACT_CONTENT_KEEP_CHARS", "64")
+	t.Setenv("QUBICDB_COMPACT_CONTENT_RECENT_ACCESS", "0s")
+	cfg = ConfigFromEnv(nil)
+	compact = cfg.Daemons.Consolidate.CompactContent
+	if !compact.Enabled || compact.MinAge != 72*time.Hour || compact.MaxEnergy != 0.1 || compact.KeepChars != 64 || compact.RecentAccess != 0 {
+		t.Errorf("env overrides not applied: %+v", compact)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid compaction config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*CompactContentConfig){
+		"zero min age":         func(c *CompactContentConfig) { c.MinAge = 0 },
+		"energy above 1":       func(c *CompactContentConfig) { c.MaxEnergy = 1.5 },
+		"keep nothing":         func(c *CompactContentConfig) { c.KeepChars = 0 },
+		"negative recent time": func(c *CompactContentConfig) { c.RecentAccess = -time.Hour },
+	} {
+		cfg := DefaultConfig()
+		mutate(&cfg.Daemons.Consolidate.CompactContent)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s should fail validation", name)
+		}
+	}
+}
+
+func TestPromotionHistoryConfig(t *testing.T) {
+	if got := DefaultConfig().Daemons.Consolidate.PromotionHistory; got != 5 {
+		t.Errorf("expected 5 promotion records by default, got %d", got)
+	}
+
+	t.Setenv("QUBICDB_PROMOTION_HISTORY", "0")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Daemons.Consolidate.PromotionHistory != 0 {
+		t.Errorf("env override not applied: %d", cfg.Daemons.Consolidate.PromotionHistory)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("recording no promotions should be valid: %v", err)
+	}
+
+	cfg.Daemons.Consolidate.PromotionHistory = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("a negative promotion history should fail validation")
+	}
+}
+
+func TestAdaptiveScheduleConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, a := range []AdaptiveScheduleConfig{cfg.Daemons.Decay.Adaptive, cfg.Daemons.Consolidate.Adaptive, cfg.Daemons.Prune.Adaptive} {
+		if a != DefaultAdaptiveSchedule() || a.Enabled {
+			t.Errorf("unexpected adaptive defaults: %+v", a)
+		}
+	}
+
+	t.Setenv("QUBICDB_PRUNE_ADAPTIVE", "true")
+	t.Setenv("QUBICDB_PRUNE_ADAPTIVE_MIN_INTERVAL", "2m")
+	t.Setenv("QUBICDB_PRUNE_ADAPTIVE_MAX_INTERVAL", "168h")
+	t.Setenv("QUBICDB_PRUNE_ADAPTIVE_WRITE_RATE_TARGET", "50")
+	t.Setenv("QUBICDB_DECAY_ADAPTIVE", "true")
+	cfg = ConfigFromEnv(nil)
+	want := AdaptiveScheduleConfig{Enabled: true, MinInterval: 2 * time.Minute, MaxInterval: 168 * time.Hour, WriteRateTarget: 50}
+	if cfg.Daemons.Prune.Adaptive != want {
+		t.Errorf("env overrides not applied: %+v", cfg.Daemons.Prune.Adaptive)
+	}
+	if !cfg.Daemons.Decay.Adaptive.Enabled || cfg.Daemons.Consolidate.Adaptive.Enabled {
+		t.Errorf("expected only decay and prune to be adaptive: %+v", cfg.Daemons)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid adaptive config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*AdaptiveScheduleConfig){
+		"zero min interval":  func(a *AdaptiveScheduleConfig) { a.MinInterval = 0 },
+		"max below min":      func(a *AdaptiveScheduleConfig) { a.MaxInterval = 30 * time.Second },
+		"zero rate target":   func(a *AdaptiveScheduleConfig) { a.WriteRateTarget = 0 },
+		"negative rate goal": func(a *AdaptiveScheduleConfig) { a.WriteRateTarget = -1 },
+	} {
+		cfg := DefaultConfig()
+		mutate(&cfg.Daemons.Consolidate.Adaptive)
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "daemons.consolidate.adaptive") {
+			t.Errorf("%s should fail validation, got %v", name, err)
+		}
+	}
+}
+
+func TestDeadLetterConfig(t *testing.T) {
+	dl := DefaultConfig().Worker.DeadLetters
+	if dl.Capacity != 100 || dl.MaxBytes != 64<<20 || dl.QuarantineAfter != 3 || dl.QuarantineWindow != time.Minute || dl.QuarantineDuration != 5*time.Minute {
+		t.Errorf("unexpected defaults %+v", dl)
+	}
+
+	t.Setenv("QUBICDB_DEADLETTER_MAX_BYTES", "0")
+	t.Setenv("QUBICDB_QUARANTINE_AFTER", "0")
+	t.Setenv("QUBICDB_QUARANTINE_WINDOW", "0s")
+	cfg := ConfigFromEnv(nil)
+	if dl := cfg.Worker.DeadLetters; dl.MaxBytes != 0 || dl.QuarantineAfter != 0 || dl.QuarantineWindow != 0 {
+		t.Errorf("env overrides not applied: %+v", dl)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("memory-only records without quarantine should be valid: %v", err)
+	}
+
+	cfg.Worker.DeadLetters.QuarantineAfter = 2
+	if err := cfg.Validate(); err == nil {
+		t.Error("quarantine without a window should fail validation")
+	}
+	cfg.Worker.DeadLetters.QuarantineWindow = time.Minute
+	cfg.Worker.DeadLetters.Capacity = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("a capacity of 0 should fail validation")
+	}
+}
+
+func TestWorkerReadConcurrency_DefaultEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Worker.ReadConcurrency != 4 {
+		t.Errorf("expected ReadConcurrency 4, got %d", cfg.Worker.ReadConcurrency)
+	}
+
+	t.Setenv("QUBICDB_WORKER_READ_CONCURRENCY", "8")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Worker.ReadConcurrency != 8 {
+		t.Errorf("expected ReadConcurrency 8 from env, got %d", cfg.Worker.ReadConcurrency)
+	}
+
+	cfg.Worker.ReadConcurrency = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("ReadConcurrency 0 should fail validation")
+	}
+}
+
+func TestWorkerLoadTimeout_DefaultEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Worker.LoadTimeout != 10*time.Second {
+		t.Errorf("expected LoadTimeout 10s, got %s", cfg.Worker.LoadTimeout)
+	}
+
+	t.Setenv("QUBICDB_WORKER_LOAD_TIMEOUT", "2s")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Worker.LoadTimeout != 2*time.Second {
+		t.Errorf("expected LoadTimeout 2s from env, got %s", cfg.Worker.LoadTimeout)
+	}
+
+	cfg.Worker.LoadTimeout = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("LoadTimeout 0 disables the timeout, got %v", err)
+	}
+	cfg.Worker.LoadTimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("a negative LoadTimeout should fail validation")
+	}
+	cfg.Worker.LoadTimeout = cfg.Security.WriteTimeout
+	if err := cfg.Validate(); err == nil {
+		t.Error("a LoadTimeout reaching the write timeout should fail validation")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Env helper function tests
 // ---------------------------------------------------------------------------
@@ -1233,6 +1472,100 @@ func TestValidate_AdminDisabledSkipsCredentialCheck(t *testing.T) {
 	}
 }
 
+func TestValidate_ConcurrencyLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Server.Concurrency.Context = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("negative server.concurrency.context should fail validation")
+	}
+
+	cfg = DefaultConfig()
+	cfg.Server.Concurrency.QueueTimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("negative server.concurrency.queueTimeout should fail validation")
+	}
+
+	cfg = DefaultConfig()
+	cfg.Server.Concurrency = ConcurrencyConfig{}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("zero concurrency limits (unlimited) should pass validation: %v", err)
+	}
+}
+
+func TestConfigFromEnv_ConcurrencyVars(t *testing.T) {
+	t.Setenv("QUBICDB_CONCURRENCY_SEARCH", "7")
+	t.Setenv("QUBICDB_CONCURRENCY_CONTEXT", "3")
+	t.Setenv("QUBICDB_CONCURRENCY_QUEUE_TIMEOUT", "250ms")
+
+	cfg := ConfigFromEnv(nil)
+	if cfg.Server.Concurrency.Search != 7 {
+		t.Errorf("expected Concurrency.Search 7, got %d", cfg.Server.Concurrency.Search)
+	}
+	if cfg.Server.Concurrency.Context != 3 {
+		t.Errorf("expected Concurrency.Context 3, got %d", cfg.Server.Concurrency.Context)
+	}
+	if cfg.Server.Concurrency.QueueTimeout != 250*time.Millisecond {
+		t.Errorf("expected Concurrency.QueueTimeout 250ms, got %v", cfg.Server.Concurrency.QueueTimeout)
+	}
+	if cfg.Server.Concurrency.Write != DefaultConfig().Server.Concurrency.Write {
+		t.Errorf("unset Concurrency.Write should keep its default, got %d", cfg.Server.Concurrency.Write)
+	}
+}
+
+func TestWriteConfig_DefaultsEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Write.SanitizeContent {
+		t.Error("expected Write.SanitizeContent to be false by default")
+	}
+	if cfg.Write.MaxLineLength != DefaultMaxContentLineLength {
+		t.Errorf("expected Write.MaxLineLength %d, got %d", DefaultMaxContentLineLength, cfg.Write.MaxLineLength)
+	}
+
+	t.Setenv("QUBICDB_WRITE_SANITIZE_CONTENT", "true")
+	t.Setenv("QUBICDB_WRITE_MAX_LINE_LENGTH", "0")
+	cfg = ConfigFromEnv(nil)
+	if !cfg.Write.SanitizeContent {
+		t.Error("expected QUBICDB_WRITE_SANITIZE_CONTENT to enable sanitization")
+	}
+	if cfg.Write.MaxLineLength != 0 {
+		t.Errorf("expected Write.MaxLineLength 0, got %d", cfg.Write.MaxLineLength)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("maxLineLength 0 should pass validation: %v", err)
+	}
+
+	cfg.Write.MaxLineLength = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("negative write.maxLineLength should fail validation")
+	}
+}
+
+func TestSearchTelemetryConfig_DefaultsEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Search.Telemetry.Enabled || cfg.Search.Telemetry.SampleZeroResults {
+		t.Error("expected search telemetry and zero-result sampling to be off by default")
+	}
+	if cfg.Search.Telemetry.ZeroResultSampleSize != 100 {
+		t.Errorf("expected default sample size 100, got %d", cfg.Search.Telemetry.ZeroResultSampleSize)
+	}
+
+	t.Setenv("QUBICDB_SEARCH_TELEMETRY_ENABLED", "true")
+	t.Setenv("QUBICDB_SEARCH_TELEMETRY_SAMPLE_ZERO_RESULTS", "true")
+	t.Setenv("QUBICDB_SEARCH_TELEMETRY_SAMPLE_SIZE", "25")
+	cfg = ConfigFromEnv(nil)
+	if !cfg.Search.Telemetry.Enabled || !cfg.Search.Telemetry.SampleZeroResults {
+		t.Error("expected env vars to enable telemetry and sampling")
+	}
+	if cfg.Search.Telemetry.ZeroResultSampleSize != 25 {
+		t.Errorf("expected sample size 25, got %d", cfg.Search.Telemetry.ZeroResultSampleSize)
+	}
+
+	cfg.Search.Telemetry.ZeroResultSampleSize = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("negative search.telemetry.zeroResultSampleSize should fail validation")
+	}
+}
+
 func TestValidate_MCPPathMustStartWithSlash(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.MCP.Enabled = true
@@ -1513,3 +1846,1233 @@ security:
 		t.Errorf("CLI should override YAML: got %q", cfg.Security.AllowedOrigins)
 	}
 }
+
+func TestShadowConfig_DefaultsEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Server.Shadow.URL != "" {
+		t.Errorf("expected shadowing disabled by default, got url %q", cfg.Server.Shadow.URL)
+	}
+	if cfg.Server.Shadow.SampleRate != 0.1 || !cfg.Server.Shadow.Compare || cfg.Server.Shadow.QueueSize != 1000 {
+		t.Errorf("unexpected shadow defaults: %+v", cfg.Server.Shadow)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config should validate: %v", err)
+	}
+
+	t.Setenv("QUBICDB_SHADOW_URL", "http://shadow:6060")
+	t.Setenv("QUBICDB_SHADOW_SAMPLE_RATE", "0.5")
+	t.Setenv("QUBICDB_SHADOW_ENDPOINTS", "/v1/search,/v1/context")
+	t.Setenv("QUBICDB_SHADOW_COMPARE", "false")
+	t.Setenv("QUBICDB_SHADOW_QUEUE_SIZE", "10")
+	cfg = ConfigFromEnv(nil)
+	sc := cfg.Server.Shadow
+	if sc.URL != "http://shadow:6060" || sc.SampleRate != 0.5 || sc.Compare || sc.QueueSize != 10 {
+		t.Errorf("env vars not applied: %+v", sc)
+	}
+	if len(sc.Endpoints) != 2 || sc.Endpoints[1] != "/v1/context" {
+		t.Errorf("expected 2 endpoints, got %v", sc.Endpoints)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("env shadow config should validate: %v", err)
+	}
+
+	bad := []func(*ShadowConfig){
+		func(s *ShadowConfig) { s.URL = "shadow:6060" },
+		func(s *ShadowConfig) { s.URL = "ftp://shadow" },
+		func(s *ShadowConfig) { s.SampleRate = 1.5 },
+		func(s *ShadowConfig) { s.QueueSize = 0 },
+		func(s *ShadowConfig) { s.Timeout = 0 },
+		func(s *ShadowConfig) { s.Endpoints = []string{"/admin"} },
+	}
+	for i, mutate := range bad {
+		c := DefaultConfig()
+		c.Server.Shadow.URL = "http://shadow:6060"
+		mutate(&c.Server.Shadow)
+		if err := c.Validate(); err == nil {
+			t.Errorf("case %d: invalid shadow config should fail validation: %+v", i, c.Server.Shadow)
+		}
+	}
+}
+
+func TestValidate_SeedEntriesRequireIndexAndFile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.Seed = []SeedConfig{{IndexID: "demo", File: "demo.yaml"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid seed config rejected: %v", err)
+	}
+	cfg.Storage.Seed = append(cfg.Storage.Seed, SeedConfig{IndexID: "demo"})
+	if err := cfg.Validate(); err == nil {
+		t.Error("seed entry without a file should fail validation")
+	}
+}
+
+func TestTelemetryConfig_DefaultsEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	tc := cfg.Telemetry
+	if tc.OTLPEndpoint != "" || tc.SampleRate != 1.0 || tc.ServiceName != "qubicdb" || tc.IndexAttribute != TraceIndexHash {
+		t.Errorf("unexpected telemetry defaults: %+v", tc)
+	}
+
+	t.Setenv("QUBICDB_OTLP_ENDPOINT", "http://otel-collector:4318")
+	t.Setenv("QUBICDB_TRACE_SAMPLE_RATE", "0.25")
+	t.Setenv("QUBICDB_TRACE_INDEX_ATTRIBUTE", "omit")
+	cfg = ConfigFromEnv(nil)
+	tc = cfg.Telemetry
+	if tc.OTLPEndpoint != "http://otel-collector:4318" || tc.SampleRate != 0.25 || tc.IndexAttribute != TraceIndexOmit {
+		t.Errorf("env vars not applied: %+v", tc)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("env telemetry config should validate: %v", err)
+	}
+
+	bad := []func(*TelemetryConfig){
+		func(c *TelemetryConfig) { c.OTLPEndpoint = "otel-collector:4318" },
+		func(c *TelemetryConfig) { c.SampleRate = 1.5 },
+		func(c *TelemetryConfig) { c.IndexAttribute = "plain" },
+	}
+	for i, mutate := range bad {
+		cfg := DefaultConfig()
+		mutate(&cfg.Telemetry)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("case %d: expected validation error for %+v", i, cfg.Telemetry)
+		}
+	}
+}
+
+func TestSyncConfig_DefaultsEnvAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Sync.ChangelogSize != 10000 || cfg.Sync.PageSize != 500 || cfg.Sync.ActivityThreshold != 0.1 {
+		t.Errorf("unexpected sync defaults: %+v", cfg.Sync)
+	}
+
+	t.Setenv("QUBICDB_SYNC_CHANGELOG_SIZE", "50")
+	t.Setenv("QUBICDB_SYNC_PAGE_SIZE", "20")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Sync.ChangelogSize != 50 || cfg.Sync.PageSize != 20 {
+		t.Errorf("env vars not applied: %+v", cfg.Sync)
+	}
+
+	cfg.Sync.ChangelogSize = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for sync.changelogSize = 0")
+	}
+	cfg.Sync.ChangelogSize = 50
+	cfg.Sync.ActivityThreshold = 2
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for sync.activityThreshold > 1")
+	}
+}
+
+func TestCloneConfig_EnvAndValidation(t *testing.T) {
+	t.Setenv("QUBICDB_CLONE_CONTENT_MODE", "redact")
+	t.Setenv("QUBICDB_CLONE_STRIP_METADATA", "email, phone")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Admin.Clone.ContentMode != CloneContentRedact || len(cfg.Admin.Clone.StripMetadataKeys) != 2 {
+		t.Errorf("env vars not applied: %+v", cfg.Admin.Clone)
+	}
+
+	cfg.Admin.Clone.ContentMode = "scramble"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown admin.clone.contentMode")
+	}
+}
+
+func TestAdminSessionConfig_EnvAndValidation(t *testing.T) {
+	if ttl := DefaultConfig().Admin.SessionTTL; ttl != time.Hour {
+		t.Errorf("expected a 1h default session TTL, got %s", ttl)
+	}
+	t.Setenv("QUBICDB_ADMIN_SESSION_SECRET", "s3cret")
+	t.Setenv("QUBICDB_ADMIN_SESSION_TTL", "15m")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Admin.SessionSecret != "s3cret" || cfg.Admin.SessionTTL != 15*time.Minute {
+		t.Errorf("env vars not applied: %q %s", cfg.Admin.SessionSecret, cfg.Admin.SessionTTL)
+	}
+
+	cfg.Admin.SessionTTL = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for admin.sessionTTL <= 0")
+	}
+}
+
+func TestJournalConfig_EnvAndValidation(t *testing.T) {
+	t.Setenv("QUBICDB_JOURNAL_DEFAULT_TTL", "5m")
+	t.Setenv("QUBICDB_JOURNAL_MAX_TTL", "30m")
+	t.Setenv("QUBICDB_JOURNAL_CAPACITY", "50")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Admin.Journal.DefaultTTL != 5*time.Minute || cfg.Admin.Journal.MaxTTL != 30*time.Minute || cfg.Admin.Journal.Capacity != 50 {
+		t.Errorf("env vars not applied: %+v", cfg.Admin.Journal)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid journal config rejected: %v", err)
+	}
+
+	cfg.Admin.Journal.DefaultTTL = time.Hour
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for admin.journal.defaultTTL above maxTTL")
+	}
+	cfg.Admin.Journal.DefaultTTL = time.Minute
+	cfg.Admin.Journal.Capacity = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for admin.journal.capacity < 1")
+	}
+}
+
+func TestAdminUsersConfig_Validation(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := func() *Config {
+		cfg := DefaultConfig()
+		cfg.Admin.Enabled = true
+		cfg.Admin.User = ""
+		cfg.Admin.Password = ""
+		cfg.Admin.Users = []AdminUser{{User: "ops", PasswordHash: string(hash), Role: RoleOperator}}
+		return cfg
+	}
+
+	if err := base().Validate(); err != nil {
+		t.Fatalf("hashed users without legacy credentials should be valid: %v", err)
+	}
+
+	cases := map[string]func(*Config){
+		"unknown role":      func(c *Config) { c.Admin.Users[0].Role = "superuser" },
+		"plaintext hash":    func(c *Config) { c.Admin.Users[0].PasswordHash = "pw" },
+		"empty user":        func(c *Config) { c.Admin.Users[0].User = "" },
+		"duplicate user":    func(c *Config) { c.Admin.Users = append(c.Admin.Users, c.Admin.Users[0]) },
+		"shadows legacy":    func(c *Config) { c.Admin.User, c.Admin.Password = "ops", "secret" },
+		"half legacy creds": func(c *Config) { c.Admin.User = "admin" },
+	}
+	for name, mutate := range cases {
+		cfg := base()
+		mutate(cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestManifestRetainConfig(t *testing.T) {
+	if got := DefaultConfig().Storage.ManifestRetain; got != 5 {
+		t.Errorf("expected default manifestRetain 5, got %d", got)
+	}
+
+	t.Setenv("QUBICDB_MANIFEST_RETAIN", "12")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Storage.ManifestRetain != 12 {
+		t.Errorf("env var not applied: %d", cfg.Storage.ManifestRetain)
+	}
+
+	cfg.Storage.ManifestRetain = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative storage.manifestRetain")
+	}
+}
+
+func TestStartupReportRetainConfig(t *testing.T) {
+	if got := DefaultConfig().Storage.StartupReportRetain; got != 10 {
+		t.Errorf("expected default startupReportRetain 10, got %d", got)
+	}
+
+	t.Setenv("QUBICDB_STARTUP_REPORT_RETAIN", "3")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Storage.StartupReportRetain != 3 {
+		t.Errorf("env var not applied: %d", cfg.Storage.StartupReportRetain)
+	}
+
+	cfg.Storage.StartupReportRetain = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative storage.startupReportRetain")
+	}
+}
+
+func TestIndexIDSourceConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Server.IndexIDSource != "either" {
+		t.Errorf("expected default index ID source either, got %q", cfg.Server.IndexIDSource)
+	}
+
+	t.Setenv("QUBICDB_INDEX_ID_SOURCE", " Header ")
+	cfg = ConfigFromEnv(nil)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid source rejected: %v", err)
+	}
+	if cfg.Server.IndexIDSource != "header" {
+		t.Errorf("source should be normalized, got %q", cfg.Server.IndexIDSource)
+	}
+
+	cfg.Server.IndexIDSource = "cookie"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown server.indexIdSource")
+	}
+}
+
+func TestBM25Config(t *testing.T) {
+	cfg := DefaultConfig()
+	if b := cfg.Search.BM25; b.K1 != 1.2 || b.B != 0.75 || b.MaxTerms != 100000 {
+		t.Errorf("unexpected BM25 defaults: %+v", b)
+	}
+
+	t.Setenv("QUBICDB_SEARCH_BM25_K1", "1.5")
+	t.Setenv("QUBICDB_SEARCH_BM25_B", "0.5")
+	t.Setenv("QUBICDB_SEARCH_BM25_MAX_TERMS", "0")
+	cfg = ConfigFromEnv(nil)
+	if b := cfg.Search.BM25; b.K1 != 1.5 || b.B != 0.5 || b.MaxTerms != 0 {
+		t.Errorf("env vars not applied: %+v", b)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid BM25 config rejected: %v", err)
+	}
+
+	cfg.Search.BM25.B = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for search.bm25.b above 1")
+	}
+	cfg.Search.BM25.B = 0.75
+	cfg.Search.BM25.K1 = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative search.bm25.k1")
+	}
+}
+
+func TestLexicalConfig(t *testing.T) {
+	if l := DefaultConfig().Search.Lexical; !l.FoldDiacritics || l.Language != "" {
+		t.Errorf("unexpected lexical defaults: %+v", l)
+	}
+
+	t.Setenv("QUBICDB_SEARCH_FOLD_DIACRITICS", "false")
+	t.Setenv("QUBICDB_SEARCH_LANGUAGE", "tr")
+	cfg := ConfigFromEnv(nil)
+	if l := cfg.Search.Lexical; l.FoldDiacritics || l.Language != "tr" {
+		t.Errorf("env vars not applied: %+v", l)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid lexical config rejected: %v", err)
+	}
+
+	for _, lang := range []string{"de-AT", "az_Latn"} {
+		cfg.Search.Lexical.Language = lang
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("language %q rejected: %v", lang, err)
+		}
+	}
+	cfg.Search.Lexical.Language = "turkish!"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a malformed search.lexical.language")
+	}
+}
+
+func TestSearchAnchorWeightConfig(t *testing.T) {
+	if w := DefaultConfig().Search.AnchorWeight; w != 0.5 {
+		t.Errorf("expected default anchor weight 0.5, got %v", w)
+	}
+	t.Setenv("QUBICDB_SEARCH_ANCHOR_WEIGHT", "0.8")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Search.AnchorWeight != 0.8 {
+		t.Errorf("env var not applied: %v", cfg.Search.AnchorWeight)
+	}
+	cfg.Search.AnchorWeight = 1.2
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for search.anchorWeight above 1")
+	}
+}
+
+func TestRetainVersionsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Storage.RetainVersions != 0 || cfg.Storage.RetainVersionsMaxAge != 7*24*time.Hour {
+		t.Errorf("unexpected version retention defaults: %d, %s", cfg.Storage.RetainVersions, cfg.Storage.RetainVersionsMaxAge)
+	}
+
+	t.Setenv("QUBICDB_RETAIN_VERSIONS", "12")
+	t.Setenv("QUBICDB_RETAIN_VERSIONS_MAX_AGE", "48h")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Storage.RetainVersions != 12 || cfg.Storage.RetainVersionsMaxAge != 48*time.Hour {
+		t.Errorf("env vars not applied: %d, %s", cfg.Storage.RetainVersions, cfg.Storage.RetainVersionsMaxAge)
+	}
+
+	cfg.Storage.RetainVersions = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative storage.retainVersions")
+	}
+}
+
+func TestDiskSpaceConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Storage.DiskCheckInterval != 30*time.Second || cfg.Storage.WarnFreeBytes != 1<<30 || cfg.Storage.MinFreeBytes != 100<<20 {
+		t.Errorf("unexpected disk space defaults: %s %d %d", cfg.Storage.DiskCheckInterval, cfg.Storage.WarnFreeBytes, cfg.Storage.MinFreeBytes)
+	}
+
+	t.Setenv("QUBICDB_DISK_CHECK_INTERVAL", "5s")
+	t.Setenv("QUBICDB_WARN_FREE_BYTES", "2048")
+	t.Setenv("QUBICDB_MIN_FREE_BYTES", "1024")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Storage.DiskCheckInterval != 5*time.Second || cfg.Storage.WarnFreeBytes != 2048 || cfg.Storage.MinFreeBytes != 1024 {
+		t.Errorf("env vars not applied: %+v", cfg.Storage)
+	}
+
+	cfg.Storage.WarnFreeBytes = 512
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for storage.warnFreeBytes below storage.minFreeBytes")
+	}
+	cfg.Storage.WarnFreeBytes = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("a disabled warning needs no ordering: %v", err)
+	}
+	cfg.Storage.MinFreeBytes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative storage.minFreeBytes")
+	}
+}
+
+func TestFlushRetryConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Storage.FlushRetryBackoff != 30*time.Second || cfg.Storage.FlushRetryMaxBackoff != 10*time.Minute ||
+		cfg.Storage.FlushStaleAfter != 15*time.Minute || cfg.Storage.FlushStaleFailAfter != time.Hour {
+		t.Errorf("unexpected flush retry defaults: %+v", cfg.Storage)
+	}
+
+	t.Setenv("QUBICDB_FLUSH_RETRY_BACKOFF", "5s")
+	t.Setenv("QUBICDB_FLUSH_RETRY_MAX_BACKOFF", "1m")
+	t.Setenv("QUBICDB_FLUSH_STALE_AFTER", "10m")
+	t.Setenv("QUBICDB_FLUSH_STALE_FAIL_AFTER", "6h")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Storage.FlushRetryBackoff != 5*time.Second || cfg.Storage.FlushRetryMaxBackoff != time.Minute ||
+		cfg.Storage.FlushStaleAfter != 10*time.Minute || cfg.Storage.FlushStaleFailAfter != 6*time.Hour {
+		t.Errorf("env vars not applied: %+v", cfg.Storage)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Storage.FlushRetryMaxBackoff = time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for storage.flushRetryMaxBackoff below storage.flushRetryBackoff")
+	}
+	cfg.Storage.FlushRetryMaxBackoff = time.Minute
+	cfg.Storage.FlushStaleFailAfter = 5 * time.Minute
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for storage.flushStaleFailAfter below storage.flushStaleAfter")
+	}
+	cfg.Storage.FlushStaleAfter = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("a disabled soft threshold needs no ordering: %v", err)
+	}
+	cfg.Storage.FlushRetryBackoff = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative storage.flushRetryBackoff")
+	}
+}
+
+func TestIndexQuotaConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Storage.IndexQuotaBytes != 0 || cfg.Storage.IndexQuotaPolicy != QuotaReject || cfg.Storage.IndexQuotaGrace != 1.05 {
+		t.Errorf("unexpected quota defaults: %+v", cfg.Storage)
+	}
+
+	t.Setenv("QUBICDB_INDEX_QUOTA_BYTES", "1048576")
+	t.Setenv("QUBICDB_INDEX_QUOTA_POLICY", "evict_lowest_energy")
+	t.Setenv("QUBICDB_INDEX_QUOTA_GRACE", "1.2")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Storage.IndexQuotaBytes != 1<<20 || cfg.Storage.IndexQuotaPolicy != QuotaEvictLowestEnergy || cfg.Storage.IndexQuotaGrace != 1.2 {
+		t.Errorf("env vars not applied: %+v", cfg.Storage)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, mutate := range map[string]func(*Config){
+		"negative bytes": func(c *Config) { c.Storage.IndexQuotaBytes = -1 },
+		"unknown policy": func(c *Config) { c.Storage.IndexQuotaPolicy = "evict_oldest" },
+		"grace below 1":  func(c *Config) { c.Storage.IndexQuotaGrace = 0.9 },
+	} {
+		c := DefaultConfig()
+		mutate(c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+
+	if err := (IndexQuota{Bytes: 10, Policy: "shrink"}).Validate(); !errors.Is(err, ErrInvalidQuota) {
+		t.Errorf("expected ErrInvalidQuota, got %v", err)
+	}
+}
+
+func TestSubscriptionsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Subscriptions.Enabled || cfg.Subscriptions.MaxPerIndex != 20 || cfg.Subscriptions.TickInterval != 30*time.Second {
+		t.Errorf("unexpected subscription defaults: %+v", cfg.Subscriptions)
+	}
+
+	t.Setenv("QUBICDB_SUBSCRIPTIONS_ENABLED", "true")
+	t.Setenv("QUBICDB_SUBSCRIPTIONS_MAX_PER_INDEX", "5")
+	t.Setenv("QUBICDB_SUBSCRIPTIONS_TICK", "10s")
+	t.Setenv("QUBICDB_SUBSCRIPTIONS_HISTORY_SIZE", "7")
+	cfg = ConfigFromEnv(nil)
+	s := cfg.Subscriptions
+	if !s.Enabled || s.MaxPerIndex != 5 || s.TickInterval != 10*time.Second || s.HistorySize != 7 {
+		t.Errorf("env vars not applied: %+v", s)
+	}
+
+	cfg.Subscriptions.MaxPerIndex = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for subscriptions.maxPerIndex 0")
+	}
+	cfg.Subscriptions.MaxPerIndex = 5
+	cfg.Subscriptions.TickInterval = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for subscriptions.tickInterval 0")
+	}
+}
+
+func TestStatsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Stats.Noise || cfg.Stats.NoiseBound != 5 {
+		t.Errorf("unexpected stats defaults: %+v", cfg.Stats)
+	}
+
+	t.Setenv("QUBICDB_STATS_NOISE", "true")
+	t.Setenv("QUBICDB_STATS_NOISE_BOUND", "3")
+	cfg = ConfigFromEnv(nil)
+	if !cfg.Stats.Noise || cfg.Stats.NoiseBound != 3 {
+		t.Errorf("env vars not applied: %+v", cfg.Stats)
+	}
+
+	cfg.Stats.NoiseBound = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for stats.noiseBound 0 with noise enabled")
+	}
+}
+
+func TestSharesConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Shares.Enabled || cfg.Shares.MaxResults != 50 || cfg.Shares.DefaultExpiry != 7*24*time.Hour {
+		t.Errorf("unexpected share defaults: %+v", cfg.Shares)
+	}
+
+	t.Setenv("QUBICDB_SHARES_ENABLED", "true")
+	t.Setenv("QUBICDB_SHARES_MAX_RESULTS", "10")
+	t.Setenv("QUBICDB_SHARES_MAX_EXPIRY", "48h")
+	t.Setenv("QUBICDB_SHARES_DEFAULT_EXPIRY", "1h")
+	t.Setenv("QUBICDB_SHARES_RATE_LIMIT", "5")
+	cfg = ConfigFromEnv(nil)
+	s := cfg.Shares
+	if !s.Enabled || s.MaxResults != 10 || s.MaxExpiry != 48*time.Hour || s.DefaultExpiry != time.Hour || s.RateLimitRequests != 5 {
+		t.Errorf("env vars not applied: %+v", s)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid share config rejected: %v", err)
+	}
+
+	cfg.Shares.DefaultExpiry = 72 * time.Hour
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for shares.defaultExpiry above shares.maxExpiry")
+	}
+	cfg.Shares.DefaultExpiry = time.Hour
+	cfg.Shares.RateLimitRequests = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for shares.rateLimitRequests 0")
+	}
+}
+
+func TestPrefetchConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if !cfg.Prefetch.Enabled || cfg.Prefetch.MaxIndexes != 10 || cfg.Prefetch.Grace != time.Minute || cfg.Prefetch.MaxLoadedIndexes != 0 {
+		t.Errorf("unexpected prefetch defaults: %+v", cfg.Prefetch)
+	}
+
+	t.Setenv("QUBICDB_PREFETCH_MAX_INDEXES", "3")
+	t.Setenv("QUBICDB_PREFETCH_GRACE", "30s")
+	t.Setenv("QUBICDB_PREFETCH_MAX_LOADED", "500")
+	t.Setenv("QUBICDB_PREFETCH_RATE_LIMIT", "5")
+	cfg = ConfigFromEnv(nil)
+	p := cfg.Prefetch
+	if p.MaxIndexes != 3 || p.Grace != 30*time.Second || p.MaxLoadedIndexes != 500 || p.RateLimitRequests != 5 {
+		t.Errorf("env vars not applied: %+v", p)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid prefetch config rejected: %v", err)
+	}
+
+	cfg.Prefetch.MaxIndexes = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for prefetch.maxIndexes 0")
+	}
+	cfg.Prefetch.MaxIndexes = 3
+	cfg.Prefetch.MaxLoadedIndexes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative prefetch.maxLoadedIndexes")
+	}
+}
+
+func TestContextConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Context.TurnTemplates[DefaultTurnTemplate] != "{{role}}: {{text}}" || cfg.Context.TurnTemplates["compact"] != "{{text}}" {
+		t.Errorf("unexpected turn template defaults: %+v", cfg.Context.TurnTemplates)
+	}
+
+	t.Setenv("QUBICDB_CONTEXT_TURN_PATTERN", `^(?P<role>\w+) says (?P<text>.+)$`)
+	cfg = ConfigFromEnv(nil)
+	if cfg.Context.TurnPattern != `^(?P<role>\w+) says (?P<text>.+)$` {
+		t.Errorf("env var not applied: %q", cfg.Context.TurnPattern)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid context config rejected: %v", err)
+	}
+
+	cfg.Context.TurnPattern = `^(\w+): (.+)$`
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a turn pattern without named groups")
+	}
+	cfg.Context.TurnPattern = DefaultTurnPattern
+	cfg.Context.TurnTemplates["loud"] = "{{ROLE}}! {{text}}"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an unknown placeholder")
+	}
+	delete(cfg.Context.TurnTemplates, "loud")
+	cfg.Context.TurnTemplates[ContextFormatBlocks] = "{{text}}"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a turn template named after an output format")
+	}
+	delete(cfg.Context.TurnTemplates, ContextFormatBlocks)
+	delete(cfg.Context.TurnTemplates, DefaultTurnTemplate)
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a missing default template")
+	}
+}
+
+func TestContextBlockTemplateConfig(t *testing.T) {
+	if cfg := DefaultConfig(); cfg.Context.BlockTemplate != DefaultBlockTemplate {
+		t.Errorf("unexpected block template default: %q", cfg.Context.BlockTemplate)
+	}
+
+	t.Setenv("QUBICDB_CONTEXT_BLOCK_TEMPLATE", "[memo{{attrs}}]{{text}}[/memo]")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Context.BlockTemplate != "[memo{{attrs}}]{{text}}[/memo]" {
+		t.Errorf("env var not applied: %q", cfg.Context.BlockTemplate)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid block template rejected: %v", err)
+	}
+
+	for _, tmpl := range []string{"<memory{{attrs}}/>", "<m>{{text}}{{text}}</m>", "<m {{id}}>{{text}}</m>"} {
+		cfg.Context.BlockTemplate = tmpl
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for block template %q", tmpl)
+		}
+	}
+}
+
+func TestReplicationConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	sb := cfg.Replication.Standby
+	if sb.URL != "" || len(sb.Indexes) != 1 || sb.Indexes[0] != "*" || sb.QueueSize != 10000 || sb.Overflow != "drop_oldest" {
+		t.Errorf("unexpected standby defaults: %+v", sb)
+	}
+
+	t.Setenv("QUBICDB_REPLICATION_STANDBY_URL", "http://standby:6060")
+	t.Setenv("QUBICDB_REPLICATION_STANDBY_INDEXES", "user-*,team-*")
+	t.Setenv("QUBICDB_REPLICATION_STANDBY_QUEUE_SIZE", "50")
+	t.Setenv("QUBICDB_REPLICATION_STANDBY_FLUSH_INTERVAL", "250ms")
+	t.Setenv("QUBICDB_REPLICATION_STANDBY_OVERFLOW", "block")
+	cfg = ConfigFromEnv(nil)
+	sb = cfg.Replication.Standby
+	if sb.URL != "http://standby:6060" || len(sb.Indexes) != 2 || sb.QueueSize != 50 || sb.FlushInterval != 250*time.Millisecond || sb.Overflow != "block" {
+		t.Errorf("env vars not applied: %+v", sb)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid replication config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*StandbyConfig){
+		"relative url":   func(sb *StandbyConfig) { sb.URL = "standby:6060" },
+		"bad glob":       func(sb *StandbyConfig) { sb.Indexes = []string{"user-["} },
+		"no indexes":     func(sb *StandbyConfig) { sb.Indexes = nil },
+		"zero queue":     func(sb *StandbyConfig) { sb.QueueSize = 0 },
+		"zero flush":     func(sb *StandbyConfig) { sb.FlushInterval = 0 },
+		"unknown policy": func(sb *StandbyConfig) { sb.Overflow = "drop_newest" },
+	} {
+		cfg := ConfigFromEnv(nil)
+		mutate(&cfg.Replication.Standby)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestAuthThrottleConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	at := cfg.Security.AuthThrottle
+	if at.MaxFailures != 5 || at.LockoutBase != 30*time.Second || at.LockoutMax != 15*time.Minute || len(cfg.Security.TrustedProxies) != 0 {
+		t.Errorf("unexpected auth throttle defaults: %+v %v", at, cfg.Security.TrustedProxies)
+	}
+
+	t.Setenv("QUBICDB_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.10")
+	t.Setenv("QUBICDB_AUTH_THROTTLE_MAX_FAILURES", "3")
+	t.Setenv("QUBICDB_AUTH_THROTTLE_LOCKOUT_BASE", "1s")
+	t.Setenv("QUBICDB_AUTH_THROTTLE_LOCKOUT_MAX", "1m")
+	cfg = ConfigFromEnv(nil)
+	at = cfg.Security.AuthThrottle
+	if at.MaxFailures != 3 || at.LockoutBase != time.Second || at.LockoutMax != time.Minute || len(cfg.Security.TrustedProxies) != 2 {
+		t.Errorf("env vars not applied: %+v %v", at, cfg.Security.TrustedProxies)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid auth throttle config rejected: %v", err)
+	}
+	if p, err := ParseTrustedProxy("192.168.1.10"); err != nil || !p.Contains(netip.MustParseAddr("192.168.1.10")) || p.Bits() != 32 {
+		t.Errorf("a bare IP should parse to a single-address prefix, got %v %v", p, err)
+	}
+
+	for name, mutate := range map[string]func(*SecurityConfig){
+		"negative failures": func(s *SecurityConfig) { s.AuthThrottle.MaxFailures = -1 },
+		"zero base":         func(s *SecurityConfig) { s.AuthThrottle.LockoutBase = 0 },
+		"max below base":    func(s *SecurityConfig) { s.AuthThrottle.LockoutMax = 500 * time.Millisecond },
+		"bad proxy":         func(s *SecurityConfig) { s.TrustedProxies = []string{"10.0.0.0/33"} },
+		"hostname proxy":    func(s *SecurityConfig) { s.TrustedProxies = []string{"proxy.local"} },
+	} {
+		cfg := ConfigFromEnv(nil)
+		mutate(&cfg.Security)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+
+	cfg = ConfigFromEnv(nil)
+	cfg.Security.AuthThrottle = AuthThrottleConfig{}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("maxFailures 0 disables throttling and needs no lockout settings: %v", err)
+	}
+}
+
+func TestPinsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Pins.MaxPerIndex != 100 || cfg.Pins.EnergyFloor != 0.5 {
+		t.Errorf("unexpected pin defaults: %+v", cfg.Pins)
+	}
+
+	t.Setenv("QUBICDB_PINS_MAX_PER_INDEX", "3")
+	t.Setenv("QUBICDB_PINS_ENERGY_FLOOR", "0.25")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Pins.MaxPerIndex != 3 || cfg.Pins.EnergyFloor != 0.25 {
+		t.Errorf("env vars not applied: %+v", cfg.Pins)
+	}
+
+	cfg.Pins.EnergyFloor = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for pins.energyFloor 1.5")
+	}
+	cfg.Pins.EnergyFloor = 0.25
+	cfg.Pins.MaxPerIndex = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for pins.maxPerIndex -1")
+	}
+}
+
+func TestConflictConfig_EnvAndValidation(t *testing.T) {
+	t.Setenv("QUBICDB_WRITE_DETECT_CONFLICTS", "true")
+	t.Setenv("QUBICDB_CONFLICT_TOP_K", "3")
+	t.Setenv("QUBICDB_CONFLICT_LEXICAL_THRESHOLD", "0.5")
+	t.Setenv("QUBICDB_CONFLICT_KEYS", "fact, slot")
+	cfg := ConfigFromEnv(nil)
+	c := cfg.Write.Conflicts
+	if !cfg.Write.DetectConflicts || c.TopK != 3 || c.LexicalThreshold != 0.5 || len(c.Keys) != 2 {
+		t.Errorf("env vars not applied: %+v", cfg.Write)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	cfg.Write.Conflicts.VectorThreshold = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for write.conflicts.vectorThreshold > 1")
+	}
+	cfg.Write.Conflicts.VectorThreshold = 0.8
+	cfg.Write.Conflicts.TopK = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for write.conflicts.topK < 1")
+	}
+}
+
+func TestVectorModelsConfig(t *testing.T) {
+	t.Setenv("QUBICDB_VECTOR_MAX_LOADED_MODELS", "3")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Vector.MaxLoadedModels != 3 {
+		t.Errorf("expected maxLoadedModels=3 from env, got %d", cfg.Vector.MaxLoadedModels)
+	}
+
+	cfg.Vector.Enabled = true
+	cfg.Vector.Models = []VectorModelConfig{
+		{Name: "code", Path: "/models/code.gguf"},
+		{Name: "chat", Path: "/models/chat.gguf", GPULayers: 8},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	for name, models := range map[string][]VectorModelConfig{
+		"missing path":   {{Name: "code"}},
+		"reserved name":  {{Name: DefaultEmbeddingModel, Path: "x.gguf"}},
+		"duplicate name": {{Name: "code", Path: "a.gguf"}, {Name: "code", Path: "b.gguf"}},
+		"gpu layers":     {{Name: "code", Path: "a.gguf", GPULayers: -1}},
+	} {
+		cfg.Vector.Models = models
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	cfg.Vector.Models = nil
+	cfg.Vector.MaxLoadedModels = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for vector.maxLoadedModels < 1")
+	}
+}
+
+func TestVectorProbeConfig(t *testing.T) {
+	t.Setenv("QUBICDB_VECTOR_PROBE_TIMEOUT", "2s")
+	t.Setenv("QUBICDB_VECTOR_PROBE_INTERVAL", "1m")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Vector.ProbeTimeout != 2*time.Second || cfg.Vector.ProbeInterval != time.Minute {
+		t.Errorf("expected probe timeout 2s and interval 1m from env, got %s and %s", cfg.Vector.ProbeTimeout, cfg.Vector.ProbeInterval)
+	}
+	if DefaultConfig().Vector.ProbeInterval != 0 {
+		t.Error("the liveness probe should be off by default")
+	}
+
+	cfg.Vector.Enabled = true
+	cfg.Vector.ProbeTimeout = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for vector.probeTimeout <= 0")
+	}
+	cfg.Vector.ProbeTimeout = time.Second
+	cfg.Vector.ProbeInterval = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative vector.probeInterval")
+	}
+}
+
+func TestVectorResilienceConfig(t *testing.T) {
+	def := DefaultConfig().Vector
+	if def.Retries != 2 || def.BreakerThreshold != 5 || def.BreakerCooldown != 30*time.Second || def.PendingEmbedInterval != 30*time.Second {
+		t.Errorf("unexpected resilience defaults: %+v", def)
+	}
+
+	t.Setenv("QUBICDB_VECTOR_RETRIES", "4")
+	t.Setenv("QUBICDB_VECTOR_RETRY_BACKOFF", "10ms")
+	t.Setenv("QUBICDB_VECTOR_BREAKER_THRESHOLD", "0")
+	t.Setenv("QUBICDB_VECTOR_BREAKER_COOLDOWN", "1m")
+	t.Setenv("QUBICDB_VECTOR_PENDING_EMBED_INTERVAL", "5s")
+	cfg := ConfigFromEnv(nil)
+	if cfg.Vector.Retries != 4 || cfg.Vector.RetryBackoff != 10*time.Millisecond || cfg.Vector.BreakerThreshold != 0 ||
+		cfg.Vector.BreakerCooldown != time.Minute || cfg.Vector.PendingEmbedInterval != 5*time.Second {
+		t.Errorf("unexpected resilience settings from env: %+v", cfg.Vector)
+	}
+
+	cfg.Vector.Enabled = true
+	cfg.Vector.BreakerCooldown = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("a disabled breaker needs no cooldown: %v", err)
+	}
+	cfg.Vector.BreakerThreshold = 3
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for vector.breakerCooldown <= 0")
+	}
+	cfg.Vector.BreakerCooldown = time.Second
+	cfg.Vector.Retries = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative vector.retries")
+	}
+	cfg.Vector.Retries = 0
+	cfg.Vector.PendingEmbedInterval = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for vector.pendingEmbedInterval <= 0")
+	}
+}
+
+func TestNeuronEmbeddingModelName(t *testing.T) {
+	n := NewNeuron("x", 3)
+	if got := n.EmbeddingModelName(); got != DefaultEmbeddingModel {
+		t.Errorf("untagged embedding should report %q, got %q", DefaultEmbeddingModel, got)
+	}
+	n.EmbeddingModel = "code"
+	if got := n.EmbeddingModelName(); got != "code" {
+		t.Errorf("expected code, got %q", got)
+	}
+}
+
+func TestImportConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if im := cfg.Import; im.MaxUploadBytes != 32<<20 || im.MaxFiles != 10000 || im.LinkWeight != 0.5 || im.DanglingSynapses != DanglingDrop {
+		t.Errorf("unexpected import defaults: %+v", im)
+	}
+
+	t.Setenv("QUBICDB_IMPORT_MAX_UPLOAD_BYTES", "1048576")
+	t.Setenv("QUBICDB_IMPORT_MAX_FILES", "50")
+	t.Setenv("QUBICDB_IMPORT_LINK_WEIGHT", "0.3")
+	t.Setenv("QUBICDB_IMPORT_DANGLING_SYNAPSES", "keep")
+	cfg = ConfigFromEnv(nil)
+	if im := cfg.Import; im.MaxUploadBytes != 1<<20 || im.MaxFiles != 50 || im.LinkWeight != 0.3 || im.DanglingSynapses != DanglingKeep {
+		t.Errorf("env vars not applied: %+v", im)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid import config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*ImportConfig){
+		"zero upload":      func(im *ImportConfig) { im.MaxUploadBytes = 0 },
+		"zero files":       func(im *ImportConfig) { im.MaxFiles = 0 },
+		"zero weight":      func(im *ImportConfig) { im.LinkWeight = 0 },
+		"weight above one": func(im *ImportConfig) { im.LinkWeight = 1.5 },
+		"unknown dangling": func(im *ImportConfig) { im.DanglingSynapses = "flag" },
+	} {
+		cfg := ConfigFromEnv(nil)
+		mutate(&cfg.Import)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestAttachmentConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Security.MaxAttachmentBytes != 8<<20 || cfg.Storage.AttachmentSweepInterval != time.Hour || cfg.Storage.AttachmentGracePeriod != time.Hour {
+		t.Errorf("unexpected attachment defaults: %d %v %v", cfg.Security.MaxAttachmentBytes, cfg.Storage.AttachmentSweepInterval, cfg.Storage.AttachmentGracePeriod)
+	}
+
+	t.Setenv("QUBICDB_MAX_ATTACHMENT_BYTES", "1024")
+	t.Setenv("QUBICDB_ATTACHMENT_SWEEP_INTERVAL", "0s")
+	t.Setenv("QUBICDB_ATTACHMENT_GRACE_PERIOD", "10m")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Security.MaxAttachmentBytes != 1024 || cfg.Storage.AttachmentSweepInterval != 0 || cfg.Storage.AttachmentGracePeriod != 10*time.Minute {
+		t.Errorf("env vars not applied: %d %v %v", cfg.Security.MaxAttachmentBytes, cfg.Storage.AttachmentSweepInterval, cfg.Storage.AttachmentGracePeriod)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid attachment config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*Config){
+		"zero size":      func(c *Config) { c.Security.MaxAttachmentBytes = 0 },
+		"negative sweep": func(c *Config) { c.Storage.AttachmentSweepInterval = -time.Second },
+		"negative grace": func(c *Config) { c.Storage.AttachmentGracePeriod = -time.Second },
+	} {
+		cfg := DefaultConfig()
+		mutate(cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestSessionsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if sc := cfg.Sessions; !sc.Enabled || sc.TTL != 30*time.Minute || sc.MaxNeuronsPerIndex != 1000 || sc.SpreadWeight != 0.5 {
+		t.Errorf("unexpected sessions defaults: %+v", sc)
+	}
+
+	t.Setenv("QUBICDB_SESSIONS_TTL", "5m")
+	t.Setenv("QUBICDB_SESSIONS_MAX_NEURONS_PER_INDEX", "200")
+	t.Setenv("QUBICDB_SESSIONS_SPREAD_WEIGHT", "0.25")
+	cfg = ConfigFromEnv(nil)
+	if sc := cfg.Sessions; sc.TTL != 5*time.Minute || sc.MaxNeuronsPerIndex != 200 || sc.SpreadWeight != 0.25 {
+		t.Errorf("env vars not applied: %+v", sc)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid sessions config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*SessionsConfig){
+		"zero ttl":         func(sc *SessionsConfig) { sc.TTL = 0 },
+		"zero neurons":     func(sc *SessionsConfig) { sc.MaxNeuronsPerIndex = 0 },
+		"spread above one": func(sc *SessionsConfig) { sc.SpreadWeight = 1.5 },
+		"negative spread":  func(sc *SessionsConfig) { sc.SpreadWeight = -0.1 },
+	} {
+		cfg := ConfigFromEnv(nil)
+		mutate(&cfg.Sessions)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+
+	cfg = ConfigFromEnv(nil)
+	cfg.Sessions = SessionsConfig{}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("disabled sessions need no settings: %v", err)
+	}
+}
+
+func TestStateWaitConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Lifecycle.StateWaitMax != 60*time.Second || cfg.Lifecycle.MaxStateWaiters != 100 {
+		t.Errorf("unexpected state wait defaults: %s %d", cfg.Lifecycle.StateWaitMax, cfg.Lifecycle.MaxStateWaiters)
+	}
+
+	t.Setenv("QUBICDB_STATE_WAIT_MAX", "2m")
+	t.Setenv("QUBICDB_MAX_STATE_WAITERS", "7")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Lifecycle.StateWaitMax != 2*time.Minute || cfg.Lifecycle.MaxStateWaiters != 7 {
+		t.Errorf("env vars not applied: %+v", cfg.Lifecycle)
+	}
+
+	cfg.Lifecycle.MaxStateWaiters = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for lifecycle.maxStateWaiters 0")
+	}
+	cfg.Lifecycle.MaxStateWaiters = 1
+	cfg.Lifecycle.StateWaitMax = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for lifecycle.stateWaitMax 0")
+	}
+}
+
+func TestRetentionConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Retention.Interval != time.Hour || cfg.Retention.MaxRules != 20 || cfg.Retention.HistorySize != 100 || len(cfg.Retention.DefaultRules) != 0 {
+		t.Errorf("unexpected retention defaults: %+v", cfg.Retention)
+	}
+
+	t.Setenv("QUBICDB_RETENTION_INTERVAL", "15m")
+	t.Setenv("QUBICDB_RETENTION_MAX_RULES", "5")
+	t.Setenv("QUBICDB_RETENTION_HISTORY_SIZE", "10")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Retention.Interval != 15*time.Minute || cfg.Retention.MaxRules != 5 || cfg.Retention.HistorySize != 10 {
+		t.Errorf("env vars not applied: %+v", cfg.Retention)
+	}
+
+	cfg.Retention.DefaultRules = []RetentionRule{{MaxAge: "30d", Action: "shred"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an invalid default rule")
+	}
+	cfg.Retention.DefaultRules = []RetentionRule{{MaxAge: "30d", Action: RetentionDelete}}
+	cfg.Retention.MaxRules = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for retention.maxRules 0")
+	}
+}
+
+func TestSentimentConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Sentiment.Provider != "vader" || cfg.Sentiment.MinASCIILetterRatio != 0.9 {
+		t.Errorf("unexpected sentiment defaults: %+v", cfg.Sentiment)
+	}
+
+	t.Setenv("QUBICDB_SENTIMENT_PROVIDER", "none")
+	t.Setenv("QUBICDB_SENTIMENT_MIN_ASCII_LETTER_RATIO", "0.75")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Sentiment.Provider != "none" || cfg.Sentiment.MinASCIILetterRatio != 0.75 {
+		t.Errorf("env vars not applied: %+v", cfg.Sentiment)
+	}
+
+	cfg.Sentiment.MinASCIILetterRatio = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for sentiment.minAsciiLetterRatio above 1")
+	}
+	cfg.Sentiment.MinASCIILetterRatio = 0
+	cfg.Sentiment.Provider = ""
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an empty sentiment.provider")
+	}
+}
+
+func TestHealthConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Health.Interval != 10*time.Minute || cfg.Health.StaleAfter != 720*time.Hour || cfg.Health.Weights.Consolidation != 0.2 {
+		t.Errorf("unexpected health defaults: %+v", cfg.Health)
+	}
+
+	t.Setenv("QUBICDB_HEALTH_INTERVAL", "0")
+	t.Setenv("QUBICDB_HEALTH_STALE_AFTER", "48h")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Health.Interval != 0 || cfg.Health.StaleAfter != 48*time.Hour {
+		t.Errorf("env vars not applied: %+v", cfg.Health)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("an on-request-only health score should be valid: %v", err)
+	}
+
+	// The formula version follows the weights, not the interval.
+	version := cfg.Health.FormulaVersion()
+	cfg.Health.Interval = time.Hour
+	if cfg.Health.FormulaVersion() != version {
+		t.Error("the interval should not change the formula version")
+	}
+	cfg.Health.Weights.Energy = 0.5
+	if cfg.Health.FormulaVersion() == version {
+		t.Error("a weight change should change the formula version")
+	}
+
+	cfg.Health.Weights.Energy = -0.1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative health weight")
+	}
+	cfg.Health.Weights = HealthWeights{}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for health weights all 0")
+	}
+	cfg.Health.Weights.Energy = 1
+	cfg.Health.StaleAfter = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for health.staleAfter 0")
+	}
+}
+
+func TestReinforceConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Search.Reinforce.SimilarityFloor != 0.2 || cfg.Search.Reinforce.MaxNewSynapses != 10 {
+		t.Errorf("unexpected reinforce defaults: %+v", cfg.Search.Reinforce)
+	}
+
+	t.Setenv("QUBICDB_SEARCH_REINFORCE_SIMILARITY_FLOOR", "0.5")
+	t.Setenv("QUBICDB_SEARCH_REINFORCE_MAX_NEW_SYNAPSES", "3")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Search.Reinforce.SimilarityFloor != 0.5 || cfg.Search.Reinforce.MaxNewSynapses != 3 {
+		t.Errorf("env vars not applied: %+v", cfg.Search.Reinforce)
+	}
+
+	cfg.Search.Reinforce.SimilarityFloor = -0.1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative search.reinforce.similarityFloor")
+	}
+	cfg.Search.Reinforce.SimilarityFloor = 0.2
+	cfg.Search.Reinforce.MaxNewSynapses = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative search.reinforce.maxNewSynapses")
+	}
+}
+
+func TestExpansionConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if x := cfg.Search.Expansion; x.Enabled || x.MaxTerms != 5 || x.MaxAddedTokens != 12 || x.MaxCueTokens != 4 || x.Seeds != 5 || x.Weight != 0.5 {
+		t.Errorf("unexpected expansion defaults: %+v", x)
+	}
+
+	t.Setenv("QUBICDB_SEARCH_EXPANSION_ENABLED", "true")
+	t.Setenv("QUBICDB_SEARCH_EXPANSION_MAX_TERMS", "3")
+	t.Setenv("QUBICDB_SEARCH_EXPANSION_WEIGHT", "0.25")
+	cfg = ConfigFromEnv(nil)
+	if x := cfg.Search.Expansion; !x.Enabled || x.MaxTerms != 3 || x.Weight != 0.25 {
+		t.Errorf("env vars not applied: %+v", x)
+	}
+
+	cfg.Search.Expansion.Seeds = MaxExpansionSeeds + 1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "search.expansion.seeds") {
+		t.Errorf("expected a search.expansion.seeds error, got %v", err)
+	}
+	cfg.Search.Expansion.Seeds = 5
+	cfg.Search.Expansion.Weight = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for search.expansion.weight 0")
+	}
+}
+
+func TestMinAPIVersionConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Server.MinAPIVersion != "" {
+		t.Errorf("expected every API version accepted by default, got %q", cfg.Server.MinAPIVersion)
+	}
+
+	t.Setenv("QUBICDB_MIN_API_VERSION", "2025-01")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Server.MinAPIVersion != "2025-01" {
+		t.Errorf("env var not applied: %q", cfg.Server.MinAPIVersion)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Server.MinAPIVersion = "latest"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a server.minApiVersion that is not YYYY-MM")
+	}
+}
+
+func TestNeuronRecycleWindowConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Storage.NeuronRecycleWindow != 72*time.Hour {
+		t.Errorf("expected a 72h default recycle window, got %s", cfg.Storage.NeuronRecycleWindow)
+	}
+
+	t.Setenv("QUBICDB_NEURON_RECYCLE_WINDOW", "1h")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Storage.NeuronRecycleWindow != time.Hour {
+		t.Errorf("env var not applied: %s", cfg.Storage.NeuronRecycleWindow)
+	}
+
+	cfg.Storage.NeuronRecycleWindow = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative storage.neuronRecycleWindow")
+	}
+}
+
+func TestSynapseTypeWeightsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if len(cfg.Daemons.Prune.SynapseScore.TypeWeights) != 0 {
+		t.Errorf("expected no type weights by default, got %v", cfg.Daemons.Prune.SynapseScore.TypeWeights)
+	}
+
+	cfg.Daemons.Prune.SynapseScore.TypeWeights = map[string]float64{SynapseFollows: 2, "resolves": 0}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Daemons.Prune.SynapseScore.TypeWeights = map[string]float64{SynapseFollows: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative type weight")
+	}
+	cfg.Daemons.Prune.SynapseScore.TypeWeights = map[string]float64{"Not A Type": 1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an invalid synapse type")
+	}
+}
+
+func TestWALArchiveConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Storage.WALArchive.Dir != "" || cfg.Storage.WALArchive.MaxAge != 168*time.Hour {
+		t.Errorf("expected archiving off with a 168h max age, got %+v", cfg.Storage.WALArchive)
+	}
+
+	t.Setenv("QUBICDB_WAL_ARCHIVE_DIR", "/var/lib/qubicdb-archive")
+	t.Setenv("QUBICDB_WAL_ARCHIVE_SEGMENT_INTERVAL", "30s")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Storage.WALArchive.Dir != "/var/lib/qubicdb-archive" || cfg.Storage.WALArchive.SegmentInterval != 30*time.Second {
+		t.Errorf("env vars not applied: %+v", cfg.Storage.WALArchive)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Storage.WALEnabled = false
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a wal archive without the WAL")
+	}
+	cfg.Storage.WALEnabled = true
+	cfg.Storage.WALArchive.QueueSize = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for storage.walArchive.queueSize 0")
+	}
+}
+
+func TestBadgeConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Shares.BadgeTimeGranularity != time.Hour || cfg.Shares.BadgeMaxAge != 5*time.Minute {
+		t.Errorf("unexpected badge defaults: %+v", cfg.Shares)
+	}
+
+	t.Setenv("QUBICDB_SHARES_ENABLED", "true")
+	t.Setenv("QUBICDB_SHARES_BADGE_TIME_GRANULARITY", "24h")
+	cfg = ConfigFromEnv(nil)
+	if cfg.Shares.BadgeTimeGranularity != 24*time.Hour {
+		t.Errorf("env var not applied: %s", cfg.Shares.BadgeTimeGranularity)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Shares.BadgeTimeGranularity = time.Millisecond
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a badge time granularity under 1s")
+	}
+	cfg.Shares.BadgeTimeGranularity = time.Hour
+	cfg.Shares.BadgeRateLimitRequests = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for shares.badgeRateLimitRequests 0")
+	}
+}
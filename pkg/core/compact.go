@@ -0,0 +1,42 @@
+package core
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Metadata keys set on a neuron whose content was compacted: CompactedKey
+// is "true" and CompactedOrigLenKey holds the content's original length in
+// bytes.
+const (
+	CompactedKey        = "_compacted"
+	CompactedOrigLenKey = "_orig_len"
+)
+
+// compactEllipsis marks where compacted content was cut.
+const compactEllipsis = "…"
+
+// IsCompacted reports whether n's content has been compacted.
+func IsCompacted(n *Neuron) bool {
+	v, ok := n.Metadata[CompactedKey]
+	return ok && v == "true"
+}
+
+// TruncateContent keeps the first keep runes of content, cut back to the
+// last word boundary when there is one, followed by an ellipsis. Content of
+// keep runes or fewer is returned as is.
+func TruncateContent(content string, keep int) string {
+	if keep <= 0 || utf8.RuneCountInString(content) <= keep {
+		return content
+	}
+	cut := 0
+	for i := 0; i < keep; i++ {
+		_, size := utf8.DecodeRuneInString(content[cut:])
+		cut += size
+	}
+	head := content[:cut]
+	if space := strings.LastIndexAny(head, " \t\n"); space > len(head)/2 {
+		head = head[:space]
+	}
+	return strings.TrimRight(head, " \t\n") + compactEllipsis
+}
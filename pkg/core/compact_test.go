@@ -0,0 +1,36 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateContent(t *testing.T) {
+	for _, tc := range []struct {
+		name, content string
+		keep          int
+		want          string
+	}{
+		{"short enough", "hello world", 11, "hello world"},
+		{"cut at a word", "the quick brown fox jumps", 12, "the quick…"},
+		{"no word boundary", "supercalifragilistic", 5, "super…"},
+		{"multibyte runes", "çok güzel bir gün geçirdik", 9, "çok güzel…"},
+		{"cjk", "記憶の整理は睡眠中に行われる", 4, "記憶の整…"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := TruncateContent(tc.content, tc.keep)
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("%q is not valid UTF-8", got)
+			}
+		})
+	}
+
+	long := strings.Repeat("é", 1000)
+	if got := TruncateContent(long, 10); utf8.RuneCountInString(got) != 11 {
+		t.Errorf("expected 10 runes and an ellipsis, got %d", utf8.RuneCountInString(got))
+	}
+}
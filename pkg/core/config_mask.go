@@ -0,0 +1,127 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretMask replaces secret values in masked configuration output.
+const SecretMask = "****"
+
+// MaskSecret replaces s with SecretMask followed by a fingerprint, the first
+// 6 hex characters of its SHA-256, so users can tell which secret is set
+// without revealing it. An empty s stays empty.
+func MaskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s))
+	return SecretMask + "(" + hex.EncodeToString(sum[:])[:6] + ")"
+}
+
+// MaskURL masks the password in raw's user info. A value that does not
+// parse as a URL is masked whole.
+func MaskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return MaskSecret(raw)
+	}
+	if u.User == nil {
+		return raw
+	}
+	pass, ok := u.User.Password()
+	if !ok {
+		return raw
+	}
+	// Spliced in after formatting so the mask is not percent-encoded.
+	u.User = url.User(u.User.Username())
+	user := u.User.String() + "@"
+	return strings.Replace(u.String(), user, strings.TrimSuffix(user, "@")+":"+MaskSecret(pass)+"@", 1)
+}
+
+// Masked returns a copy of c with every secret masked. c is not modified.
+//
+// Config fields holding secrets carry a secret struct tag, honoured
+// wherever the field sits:
+//
+//	secret:"true" — the whole string is a secret
+//	secret:"url"  — only the password in the URL's user info is
+//
+// New secret fields only need the tag to stay out of every config report.
+func (c *Config) Masked() *Config {
+	masked := *c
+	maskSecrets(reflect.ValueOf(&masked).Elem())
+	return &masked
+}
+
+// MaskedYAML renders c as YAML with every secret masked.
+func (c *Config) MaskedYAML() ([]byte, error) {
+	return yaml.Marshal(c.Masked())
+}
+
+// maskSecrets masks the secret-tagged fields reachable from v, copying
+// slices, maps and pointers first so the value v was copied from keeps its
+// secrets.
+func maskSecrets(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			f := v.Field(i)
+			switch field.Tag.Get("secret") {
+			case "true":
+				f.SetString(MaskSecret(f.String()))
+			case "url":
+				f.SetString(MaskURL(f.String()))
+			default:
+				maskSecrets(f)
+			}
+		}
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		v.Set(cp)
+		for i := 0; i < v.Len(); i++ {
+			maskSecrets(v.Index(i))
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			maskSecrets(elem)
+			cp.SetMapIndex(iter.Key(), elem)
+		}
+		v.Set(cp)
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(v.Elem())
+		maskSecrets(cp.Elem())
+		v.Set(cp)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"sync/atomic"
+	"unicode/utf8"
 )
 
 const (
@@ -13,12 +14,21 @@ const (
 
 	// MaxNeuronContentBytes is retained as a compatibility alias for tests and callers.
 	MaxNeuronContentBytes = DefaultMaxNeuronContentBytes
+
+	// DefaultMaxContentLineLength bounds a single line of neuron content, in runes.
+	// Pathological single-line blobs degrade snippet generation and tokenization.
+	DefaultMaxContentLineLength = 16 * 1024
 )
 
-var maxNeuronContentBytes atomic.Int64
+var (
+	maxNeuronContentBytes  atomic.Int64
+	maxContentLineLength   atomic.Int64
+	contentSanitizeEnabled atomic.Bool
+)
 
 func init() {
 	maxNeuronContentBytes.Store(DefaultMaxNeuronContentBytes)
+	maxContentLineLength.Store(DefaultMaxContentLineLength)
 }
 
 // SetMaxNeuronContentBytes overrides the runtime neuron content size limit.
@@ -53,3 +63,140 @@ func ValidateNeuronContent(content string) error {
 
 	return nil
 }
+
+// SetMaxContentLineLength overrides the runtime per-line rune limit. 0 disables the check.
+func SetMaxContentLineLength(limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("max content line length must be >= 0")
+	}
+	maxContentLineLength.Store(int64(limit))
+	return nil
+}
+
+// GetMaxContentLineLength returns the active per-line rune limit (0 = unlimited).
+func GetMaxContentLineLength() int {
+	return int(maxContentLineLength.Load())
+}
+
+// SetContentSanitization toggles lenient normalization on the write path.
+// When enabled, invalid UTF-8 is replaced with U+FFFD and overlong lines are
+// wrapped instead of rejected.
+func SetContentSanitization(enabled bool) {
+	contentSanitizeEnabled.Store(enabled)
+}
+
+// ContentSanitizationEnabled reports whether lenient normalization is active.
+func ContentSanitizationEnabled() bool {
+	return contentSanitizeEnabled.Load()
+}
+
+// NormalizeNeuronContent validates content for the write path and returns the
+// form that should be stored. Disallowed control characters are always
+// stripped and line endings are normalized to \n. Invalid UTF-8 and overlong
+// lines are rejected unless sanitization is enabled, in which case they are
+// repaired instead.
+func NormalizeNeuronContent(content string) (string, error) {
+	if err := ValidateNeuronContent(content); err != nil {
+		return "", err
+	}
+
+	sanitize := ContentSanitizationEnabled()
+	if !utf8.ValidString(content) {
+		if !sanitize {
+			return "", ErrInvalidEncoding
+		}
+		content = strings.ToValidUTF8(content, "\uFFFD")
+	}
+
+	content = stripControlChars(content)
+
+	if maxLine := GetMaxContentLineLength(); maxLine > 0 {
+		if sanitize {
+			content = wrapLongLines(content, maxLine)
+		} else if line, n := longestLine(content); n > maxLine {
+			return "", fmt.Errorf("%w: line %d is %d characters > %d", ErrInvalidContent, line, n, maxLine)
+		}
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return "", ErrInvalidContent
+	}
+	return content, nil
+}
+
+// CheckContentEncoding reports content that the write path would not accept
+// verbatim: invalid UTF-8 or disallowed control characters. It never mutates.
+func CheckContentEncoding(content string) error {
+	if !utf8.ValidString(content) {
+		return ErrInvalidEncoding
+	}
+	for _, r := range content {
+		if isDisallowedControl(r) {
+			return fmt.Errorf("%w: control character U+%04X", ErrInvalidEncoding, r)
+		}
+	}
+	return nil
+}
+
+// SanitizeContent unconditionally repairs content: invalid UTF-8 becomes
+// U+FFFD and disallowed control characters are stripped. Used by maintenance
+// tooling to fix neurons persisted before write-path validation existed.
+func SanitizeContent(content string) string {
+	return stripControlChars(strings.ToValidUTF8(content, "\uFFFD"))
+}
+
+// isDisallowedControl reports C0 controls and DEL, except tab and newline.
+func isDisallowedControl(r rune) bool {
+	if r == '\n' || r == '\t' {
+		return false
+	}
+	return r < 0x20 || r == 0x7f
+}
+
+func stripControlChars(content string) string {
+	if strings.IndexFunc(content, func(r rune) bool { return r == '\r' || isDisallowedControl(r) }) < 0 {
+		return content
+	}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	return strings.Map(func(r rune) rune {
+		if isDisallowedControl(r) {
+			return -1
+		}
+		return r
+	}, content)
+}
+
+// longestLine returns the 1-based index and rune length of the longest line.
+func longestLine(content string) (int, int) {
+	bestLine, bestLen := 0, 0
+	for i, line := range strings.Split(content, "\n") {
+		if n := utf8.RuneCountInString(line); n > bestLen {
+			bestLine, bestLen = i+1, n
+		}
+	}
+	return bestLine, bestLen
+}
+
+// wrapLongLines hard-wraps every line longer than maxLine runes.
+func wrapLongLines(content string, maxLine int) string {
+	if _, n := longestLine(content); n <= maxLine {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	var b strings.Builder
+	b.Grow(len(content) + len(content)/maxLine)
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		runes := []rune(line)
+		for len(runes) > maxLine {
+			b.WriteString(string(runes[:maxLine]))
+			b.WriteByte('\n')
+			runes = runes[maxLine:]
+		}
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -55,3 +56,140 @@ func TestSetMaxNeuronContentBytes_RejectsNonPositive(t *testing.T) {
 		t.Fatal("expected negative limit to fail")
 	}
 }
+
+func withContentNormalization(t *testing.T, sanitize bool, maxLine int) {
+	t.Helper()
+	prevSanitize := ContentSanitizationEnabled()
+	prevMaxLine := GetMaxContentLineLength()
+	SetContentSanitization(sanitize)
+	if err := SetMaxContentLineLength(maxLine); err != nil {
+		t.Fatalf("SetMaxContentLineLength: %v", err)
+	}
+	t.Cleanup(func() {
+		SetContentSanitization(prevSanitize)
+		_ = SetMaxContentLineLength(prevMaxLine)
+	})
+}
+
+func TestNormalizeNeuronContent_RejectsInvalidUTF8(t *testing.T) {
+	withContentNormalization(t, false, DefaultMaxContentLineLength)
+
+	cases := map[string]string{
+		"raw bytes":         "\xff\xfe\x00\x01binary",
+		"overlong slash":    "path\xc0\xafetc",
+		"overlong nul":      "a\xe0\x80\x80b",
+		"truncated rune":    "emoji \xf0\x9f\x98",
+		"mixed valid/bad":   "günaydın \x80 dünya",
+		"surrogate encoded": "x\xed\xa0\x80y",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NormalizeNeuronContent(content)
+			if !errors.Is(err, ErrInvalidEncoding) {
+				t.Fatalf("expected ErrInvalidEncoding, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNormalizeNeuronContent_SanitizeReplacesInvalidSequences(t *testing.T) {
+	withContentNormalization(t, true, DefaultMaxContentLineLength)
+
+	got, err := NormalizeNeuronContent("günaydın \x80\x81 dünya")
+	if err != nil {
+		t.Fatalf("sanitize mode should repair content, got %v", err)
+	}
+	if got != "günaydın � dünya" {
+		t.Fatalf("unexpected sanitized content %q", got)
+	}
+}
+
+func TestNormalizeNeuronContent_ValidMultibytePassesUntouched(t *testing.T) {
+	withContentNormalization(t, false, DefaultMaxContentLineLength)
+
+	for _, content := range []string{
+		"İstanbul'da çalışıyorum, şehir güzel ğ ü ö",
+		"東京で働いています。日本語のテキスト",
+		"기억을 저장합니다",
+		"launch day 🚀🎉 — family 👨‍👩‍👧",
+		"line one\n\tindented line two",
+	} {
+		got, err := NormalizeNeuronContent(content)
+		if err != nil {
+			t.Fatalf("valid content %q rejected: %v", content, err)
+		}
+		if got != content {
+			t.Fatalf("valid content modified: %q -> %q", content, got)
+		}
+	}
+}
+
+func TestNormalizeNeuronContent_StripsControlCharacters(t *testing.T) {
+	withContentNormalization(t, false, DefaultMaxContentLineLength)
+
+	got, err := NormalizeNeuronContent("bell\a null\x00 esc\x1b[0m del\x7f\r\nnext\tcol")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "bell null esc[0m del\nnext\tcol"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if _, err := NormalizeNeuronContent("\x00\x01\x02"); !errors.Is(err, ErrInvalidContent) {
+		t.Fatalf("content made only of control characters should be invalid, got %v", err)
+	}
+}
+
+func TestNormalizeNeuronContent_MaxLineLength(t *testing.T) {
+	withContentNormalization(t, false, 10)
+
+	if _, err := NormalizeNeuronContent("short\nlines\nonly"); err != nil {
+		t.Fatalf("short lines should pass: %v", err)
+	}
+	_, err := NormalizeNeuronContent("ok\n" + strings.Repeat("ş", 11))
+	if !errors.Is(err, ErrInvalidContent) {
+		t.Fatalf("expected ErrInvalidContent for an overlong line, got %v", err)
+	}
+
+	SetContentSanitization(true)
+	got, err := NormalizeNeuronContent(strings.Repeat("ş", 25))
+	if err != nil {
+		t.Fatalf("sanitize mode should wrap overlong lines: %v", err)
+	}
+	want := strings.Repeat("ş", 10) + "\n" + strings.Repeat("ş", 10) + "\n" + strings.Repeat("ş", 5)
+	if got != want {
+		t.Fatalf("unexpected wrapped content %q", got)
+	}
+
+	SetContentSanitization(false)
+	if err := SetMaxContentLineLength(0); err != nil {
+		t.Fatalf("SetMaxContentLineLength(0): %v", err)
+	}
+	if _, err := NormalizeNeuronContent(strings.Repeat("a", 1000)); err != nil {
+		t.Fatalf("line length 0 should disable the check: %v", err)
+	}
+	if err := SetMaxContentLineLength(-1); err == nil {
+		t.Fatal("negative line length should be rejected")
+	}
+}
+
+func TestCheckAndSanitizeContent(t *testing.T) {
+	if err := CheckContentEncoding("héllo\n\tworld 🌍"); err != nil {
+		t.Fatalf("valid content flagged: %v", err)
+	}
+	if err := CheckContentEncoding("bad \xff"); !errors.Is(err, ErrInvalidEncoding) {
+		t.Fatalf("expected ErrInvalidEncoding for invalid UTF-8, got %v", err)
+	}
+	if err := CheckContentEncoding("nul\x00"); !errors.Is(err, ErrInvalidEncoding) {
+		t.Fatalf("expected ErrInvalidEncoding for control character, got %v", err)
+	}
+
+	fixed := SanitizeContent("a\xffb\x00c")
+	if fixed != "a�bc" {
+		t.Fatalf("unexpected sanitized content %q", fixed)
+	}
+	if err := CheckContentEncoding(fixed); err != nil {
+		t.Fatalf("sanitized content should pass the check: %v", err)
+	}
+}
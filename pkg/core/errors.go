@@ -10,6 +10,7 @@ var (
 	ErrDimensionLimit     = errors.New("dimension limit reached")
 	ErrInvalidContent     = errors.New("invalid neuron content")
 	ErrContentTooLarge    = errors.New("neuron content exceeds maximum allowed size")
+	ErrInvalidEncoding    = errors.New("neuron content is not valid UTF-8")
 	ErrDuplicateNeuron    = errors.New("neuron with same content hash exists")
 	ErrSelfLink           = errors.New("cannot create synapse to self")
 	ErrBrainNotActive     = errors.New("brain is not active")
@@ -18,4 +19,13 @@ var (
 	ErrLoadFailed         = errors.New("failed to load matrix from persistence")
 	ErrInvalidQuery       = errors.New("invalid query")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrIndexNotEmpty      = errors.New("index already holds data")
+	ErrPinLimit           = errors.New("pinned neuron limit reached")
+	ErrSupersedeCycle     = errors.New("supersede link would create a cycle")
+	ErrSupersedeConflict  = errors.New("neuron is already part of another supersede link")
+	ErrIndexLoading       = errors.New("index is still loading")
+	ErrDraining           = errors.New("server is draining")
+	ErrIndexDiverged      = errors.New("index diverged from its data on disk")
+	ErrIndexQuarantined   = errors.New("index is quarantined after repeated operation panics")
+	ErrAppendOnly         = errors.New("index is append-only")
 )
@@ -0,0 +1,100 @@
+package core
+
+// Approximate per-object memory costs, in bytes, of the matrix footprint.
+// They cover the struct, its map entry and its adjacency bookkeeping; the
+// footprint is meant to attribute memory to indexes, not to match RSS.
+const (
+	matrixOverhead        = 1024 // Matrix struct and its empty maps
+	neuronOverhead        = 480  // Neuron struct, map entry, adjacency list
+	synapseOverhead       = 216  // Synapse struct, map entry, two adjacency entries
+	metadataEntryOverhead = 40   // key header, interface, bucket share
+	metadataValueOverhead = 16   // boxed value behind the interface
+	tagOverhead           = 16   // string header
+	attachmentOverhead    = 32   // two string headers
+	promotionOverhead     = 96   // Promotion struct and its reason string
+)
+
+// NeuronFootprint returns the approximate memory held by n: its struct,
+// strings, position, embedding, tags, lexical tokens, metadata, attachment
+// references and promotion records. It does not allocate.
+func NeuronFootprint(n *Neuron) int64 {
+	size := int64(neuronOverhead + len(n.ID) + len(n.Content) + len(n.ContentHash) + len(n.EmbeddingModel))
+	size += int64(8*len(n.Position) + 4*len(n.Embedding))
+	for _, tag := range n.Tags {
+		size += int64(tagOverhead + len(tag))
+	}
+	for _, tok := range n.LexicalTokens {
+		size += int64(tagOverhead + len(tok))
+	}
+	size += int64(len(n.LexicalKey))
+	for _, a := range n.Attachments {
+		size += int64(attachmentOverhead + len(a.Hash) + len(a.Caption))
+	}
+	size += int64(promotionOverhead * len(n.Promotions))
+	for k, v := range n.Metadata {
+		size += int64(metadataEntryOverhead + metadataValueOverhead + len(k))
+		if s, ok := v.(string); ok {
+			size += int64(len(s))
+		}
+	}
+	return size
+}
+
+// SynapseFootprint returns the approximate memory held by s. Its endpoint
+// IDs share their neurons' strings and are not counted.
+func SynapseFootprint(s *Synapse) int64 {
+	return int64(synapseOverhead + len(s.ID))
+}
+
+// Footprint returns the approximate memory held by the matrix in bytes.
+// Lexical term statistics and tombstones are not counted. It is safe to
+// call without the matrix lock.
+func (m *Matrix) Footprint() int64 {
+	return m.footprint.Load()
+}
+
+// AddFootprint adjusts the footprint by delta bytes. Callers adding or
+// removing synapses use it with SynapseFootprint.
+func (m *Matrix) AddFootprint(delta int64) {
+	m.footprint.Add(delta)
+}
+
+// Remeasure brings n's share of the footprint up to date after n was
+// added or changed. RecordChange calls it; callers that change a neuron's
+// size without recording a change call it under the matrix write lock.
+func (m *Matrix) Remeasure(n *Neuron) {
+	size := NeuronFootprint(n)
+	m.footprint.Add(size - n.footprint)
+	n.footprint = size
+}
+
+// Unmeasure removes n's share of the footprint after n was removed.
+// Callers hold the matrix write lock.
+func (m *Matrix) Unmeasure(n *Neuron) {
+	m.footprint.Add(-n.footprint)
+	n.footprint = 0
+}
+
+// RecomputeFootprint measures the whole matrix from scratch, as after it
+// was loaded, and returns the footprint.
+func (m *Matrix) RecomputeFootprint() int64 {
+	m.Lock()
+	defer m.Unlock()
+	total := int64(matrixOverhead)
+	for _, n := range m.Neurons {
+		n.footprint = NeuronFootprint(n)
+		total += n.footprint
+	}
+	for _, s := range m.Synapses {
+		total += SynapseFootprint(s)
+	}
+	for _, r := range m.Recycled {
+		r.Neuron.footprint = NeuronFootprint(r.Neuron)
+		total += r.Neuron.footprint
+		for _, s := range r.Synapses {
+			total += SynapseFootprint(s)
+		}
+	}
+	m.footprint.Store(total)
+	return total
+}
@@ -0,0 +1,48 @@
+package core
+
+import "testing"
+
+func TestNeuronFootprint(t *testing.T) {
+	n := NewNeuron("hello world", 8)
+	base := NeuronFootprint(n)
+	if base < int64(len("hello world")+8*8) {
+		t.Errorf("footprint %d does not cover content and position", base)
+	}
+
+	n.Embedding = make([]float32, 384)
+	n.Metadata["source"] = "notes/a.md"
+	n.Tags = []string{"go"}
+	want := base + 4*384 + metadataEntryOverhead + metadataValueOverhead + int64(len("source")+len("notes/a.md")) + tagOverhead + 2
+	if got := NeuronFootprint(n); got != want {
+		t.Errorf("expected %d, got %d", want, got)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { NeuronFootprint(n) }); allocs != 0 {
+		t.Errorf("measuring a neuron must not allocate, got %v allocations", allocs)
+	}
+}
+
+func TestMatrixRemeasure(t *testing.T) {
+	m := NewMatrix("mem", DefaultBounds())
+	if m.Footprint() != matrixOverhead {
+		t.Fatalf("an empty matrix should hold its overhead, got %d", m.Footprint())
+	}
+	n := NewNeuron("first", m.CurrentDim)
+	m.Neurons[n.ID] = n
+	m.RecordChange(n)
+	m.RecordChange(n)
+	if m.Footprint() != matrixOverhead+NeuronFootprint(n) {
+		t.Errorf("recording a change twice must count the neuron once, got %d", m.Footprint())
+	}
+
+	n.Content = "a much longer content than before"
+	m.Remeasure(n)
+	if got, want := m.Footprint(), m.RecomputeFootprint(); got != want {
+		t.Errorf("incremental footprint %d differs from recomputed %d", got, want)
+	}
+
+	delete(m.Neurons, n.ID)
+	m.Unmeasure(n)
+	if m.Footprint() != matrixOverhead {
+		t.Errorf("removing the neuron should release it, got %d", m.Footprint())
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Approximate sizes behind MetadataIndexStats.MemoryBytes.
+const (
+	metadataIndexMapOverhead   = 48 // map header and first bucket share
+	metadataIndexEntryOverhead = 32 // string header and bucket share of a set entry
+)
+
+// MetadataIndex is an inverted index over a few low-cardinality metadata
+// keys, such as thread_id and role: for each indexed key it maps every value
+// to the neurons holding it, so a strict filter on those keys need not scan
+// the matrix. Values compare as their fmt %v form, as strict filters do. It
+// is persisted with the matrix and maintained as neurons are written,
+// changed and forgotten; callers must hold the matrix write lock to modify
+// it.
+type MetadataIndex struct {
+	// Keys are the indexed metadata keys, sorted.
+	Keys []string `msgpack:"keys"`
+
+	// Values maps key → value → the IDs of neurons with that value.
+	Values map[string]map[string]map[NeuronID]struct{} `msgpack:"values"`
+
+	// Docs is the number of neurons counted, with or without indexed keys.
+	Docs int `msgpack:"docs"`
+}
+
+// MetadataKeyStats describes the index of one metadata key.
+type MetadataKeyStats struct {
+	Key         string `json:"key"`
+	Cardinality int    `json:"cardinality"` // distinct values
+	Neurons     int    `json:"neurons"`     // neurons holding the key
+}
+
+// MetadataIndexStats describes a matrix's metadata index.
+type MetadataIndexStats struct {
+	Keys        []MetadataKeyStats `json:"keys"`
+	MemoryBytes int64              `json:"memoryBytes"` // approximate
+}
+
+// NewMetadataIndex creates an empty index over keys.
+func NewMetadataIndex(keys []string) *MetadataIndex {
+	x := &MetadataIndex{
+		Keys:   NormalizeMetadataKeys(keys),
+		Values: make(map[string]map[string]map[NeuronID]struct{}, len(keys)),
+	}
+	for _, k := range x.Keys {
+		x.Values[k] = make(map[string]map[NeuronID]struct{})
+	}
+	return x
+}
+
+// NormalizeMetadataKeys returns keys sorted, without blanks or duplicates.
+func NormalizeMetadataKeys(keys []string) []string {
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k != "" {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return slices.Compact(out)
+}
+
+// ValidateIndexedMetadataKeys rejects blank and duplicate keys, and keys
+// starting with "_", which belong to the engine.
+func ValidateIndexedMetadataKeys(keys []string) error {
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		switch {
+		case strings.TrimSpace(k) == "":
+			return fmt.Errorf("key names must not be empty")
+		case strings.HasPrefix(k, "_"):
+			return fmt.Errorf("key %q is reserved", k)
+		case seen[k]:
+			return fmt.Errorf("key %q is listed twice", k)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// Covers reports whether the index is over exactly keys, which must be
+// normalized.
+func (x *MetadataIndex) Covers(keys []string) bool {
+	return slices.Equal(x.Keys, keys)
+}
+
+// Add counts n, indexing its values of the indexed keys.
+func (x *MetadataIndex) Add(n *Neuron) {
+	x.Docs++
+	for _, k := range x.Keys {
+		v, ok := n.Metadata[k]
+		if !ok {
+			continue
+		}
+		value := fmt.Sprintf("%v", v)
+		ids := x.Values[k][value]
+		if ids == nil {
+			ids = make(map[NeuronID]struct{})
+			x.Values[k][value] = ids
+		}
+		ids[n.ID] = struct{}{}
+	}
+}
+
+// Remove uncounts n, which must have the metadata it was added with.
+func (x *MetadataIndex) Remove(n *Neuron) {
+	if x.Docs > 0 {
+		x.Docs--
+	}
+	for _, k := range x.Keys {
+		v, ok := n.Metadata[k]
+		if !ok {
+			continue
+		}
+		value := fmt.Sprintf("%v", v)
+		if ids := x.Values[k][value]; ids != nil {
+			delete(ids, n.ID)
+			if len(ids) == 0 {
+				delete(x.Values[k], value)
+			}
+		}
+	}
+}
+
+// Lookup returns the neurons whose key has value, and whether key is
+// indexed at all. The set must not be modified.
+func (x *MetadataIndex) Lookup(key, value string) (map[NeuronID]struct{}, bool) {
+	values, ok := x.Values[key]
+	if !ok {
+		return nil, false
+	}
+	return values[value], true
+}
+
+// Stats returns the cardinality of each indexed key and an estimate of the
+// index's memory use.
+func (x *MetadataIndex) Stats() MetadataIndexStats {
+	stats := MetadataIndexStats{Keys: make([]MetadataKeyStats, 0, len(x.Keys))}
+	for _, k := range x.Keys {
+		ks := MetadataKeyStats{Key: k, Cardinality: len(x.Values[k])}
+		stats.MemoryBytes += metadataIndexMapOverhead + int64(len(k))
+		for value, ids := range x.Values[k] {
+			ks.Neurons += len(ids)
+			stats.MemoryBytes += metadataIndexMapOverhead + metadataIndexEntryOverhead + int64(len(value))
+			for id := range ids {
+				stats.MemoryBytes += metadataIndexEntryOverhead + int64(len(id))
+			}
+		}
+		stats.Keys = append(stats.Keys, ks)
+	}
+	return stats
+}
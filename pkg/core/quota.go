@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Quota policies: what a write that would take an index past its disk
+// quota does.
+const (
+	QuotaReject            = "reject"              // refuse the write
+	QuotaEvictLowestEnergy = "evict_lowest_energy" // forget the weakest unpinned neurons to make room
+)
+
+var (
+	// ErrInvalidQuota is returned for a quota that fails validation.
+	ErrInvalidQuota = errors.New("invalid quota")
+
+	// ErrQuotaExceeded is returned for a write that would take an index
+	// past its disk quota.
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// IndexQuota caps the on-disk bytes of an index.
+type IndexQuota struct {
+	// Bytes is the quota; 0 means none.
+	Bytes int64 `yaml:"bytes" json:"bytes"`
+
+	// Policy is reject or evict_lowest_energy; empty means reject.
+	Policy string `yaml:"policy" json:"policy,omitempty"`
+}
+
+// IsQuotaPolicy reports whether p names a quota policy.
+func IsQuotaPolicy(p string) bool {
+	return p == QuotaReject || p == QuotaEvictLowestEnergy
+}
+
+// Validate checks the quota's bytes and policy.
+func (q IndexQuota) Validate() error {
+	if q.Bytes < 0 {
+		return fmt.Errorf("%w: bytes must be >= 0, got %d", ErrInvalidQuota, q.Bytes)
+	}
+	if q.Policy != "" && !IsQuotaPolicy(q.Policy) {
+		return fmt.Errorf("%w: policy must be reject or evict_lowest_energy, got %q", ErrInvalidQuota, q.Policy)
+	}
+	return nil
+}
+
+// QuotaError reports a write refused by an index's disk quota. It wraps
+// ErrQuotaExceeded.
+type QuotaError struct {
+	IndexID   IndexID
+	Usage     int64 // bytes on disk before the write
+	Projected int64 // estimated bytes after it
+	Quota     int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: index %s uses %d bytes, the write would take it to about %d, quota is %d",
+		ErrQuotaExceeded, e.IndexID, e.Usage, e.Projected, e.Quota)
+}
+
+func (e *QuotaError) Unwrap() error { return ErrQuotaExceeded }
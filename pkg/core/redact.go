@@ -0,0 +1,33 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// RedactedContent replaces neuron content in redact mode.
+const RedactedContent = "[redacted]"
+
+// AnonymizeNeuron scrubs n in place: content is hashed (CloneContentHash,
+// so equal memories stay equal) or redacted (CloneContentRedact), tags and
+// attachments are dropped and stripKeys removed from metadata. Synapses, energy and the
+// rest of the neuron are kept. Metadata is replaced, not edited, under n's
+// lock.
+func AnonymizeNeuron(n *Neuron, contentMode string, stripKeys []string) {
+	n.EditMetadata(func(metadata map[string]any) {
+		for _, key := range stripKeys {
+			delete(metadata, key)
+		}
+	})
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if contentMode == CloneContentRedact {
+		n.Content = RedactedContent
+	} else {
+		sum := sha256.Sum256([]byte(n.Content))
+		n.Content = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	n.ContentHash = HashContent(n.Content)
+	n.Tags = nil
+	n.Attachments = nil
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retention actions, applied to neurons older than a rule's maxAge.
+const (
+	RetentionDelete    = "delete"    // forget the neuron
+	RetentionDrain     = "drain"     // unpin it and drop its energy, leaving it to pruning
+	RetentionAnonymize = "anonymize" // redact content, tags and stripped metadata keys
+)
+
+// ErrInvalidRetention is returned for a retention policy that fails
+// validation.
+var ErrInvalidRetention = errors.New("invalid retention policy")
+
+// RetentionRule selects neurons by metadata and says what happens to them
+// once they are older than MaxAge. Rules of a policy are evaluated in
+// order and the first whose Match fits a neuron governs it, even while the
+// neuron is younger than the rule's MaxAge.
+type RetentionRule struct {
+	// Match holds metadata values a neuron must all carry; empty matches
+	// every neuron.
+	Match map[string]string `yaml:"match" json:"match,omitempty"`
+
+	// MaxAge is a duration such as "720h", or a number of days or years:
+	// "30d", "7y" (365 days each).
+	MaxAge string `yaml:"maxAge" json:"maxAge"`
+
+	// Action is delete, drain or anonymize.
+	Action string `yaml:"action" json:"action"`
+}
+
+// Age returns the rule's parsed MaxAge, or 0 if it does not parse.
+func (r RetentionRule) Age() time.Duration {
+	d, _ := ParseRetentionAge(r.MaxAge)
+	return d
+}
+
+// ParseRetentionAge parses a retention maxAge: a Go duration, or an
+// integer followed by d (days) or y (365 days).
+func ParseRetentionAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "y"):
+		unit = 365 * 24 * time.Hour
+	default:
+		return time.ParseDuration(s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 || int64(n) > int64(1<<63-1)/int64(unit) {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// ValidateRetentionRules checks every rule of a policy and that it has at
+// most maxRules rules; maxRules <= 0 does not bound it.
+func ValidateRetentionRules(rules []RetentionRule, maxRules int) error {
+	if maxRules > 0 && len(rules) > maxRules {
+		return fmt.Errorf("%w: %d rules, at most %d allowed", ErrInvalidRetention, len(rules), maxRules)
+	}
+	for i, r := range rules {
+		switch r.Action {
+		case RetentionDelete, RetentionDrain, RetentionAnonymize:
+		default:
+			return fmt.Errorf("%w: rule %d: action must be delete, drain or anonymize, got %q", ErrInvalidRetention, i, r.Action)
+		}
+		if d, err := ParseRetentionAge(r.MaxAge); err != nil || d <= 0 {
+			return fmt.Errorf("%w: rule %d: maxAge must be a positive duration such as 720h, 30d or 7y, got %q", ErrInvalidRetention, i, r.MaxAge)
+		}
+		for k := range r.Match {
+			if k == "" {
+				return fmt.Errorf("%w: rule %d: match keys must not be empty", ErrInvalidRetention, i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRetentionAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"720h": 720 * time.Hour,
+		"30d":  30 * 24 * time.Hour,
+		"7y":   7 * 365 * 24 * time.Hour,
+		" 1d ": 24 * time.Hour,
+	} {
+		if got, err := ParseRetentionAge(in); err != nil || got != want {
+			t.Errorf("ParseRetentionAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-3d", "1.5y", "forever", "99999999999y"} {
+		if _, err := ParseRetentionAge(in); err == nil {
+			t.Errorf("ParseRetentionAge(%q) should fail", in)
+		}
+	}
+}
+
+func TestValidateRetentionRules(t *testing.T) {
+	valid := []RetentionRule{
+		{Match: map[string]string{"pii": "true"}, MaxAge: "30d", Action: RetentionAnonymize},
+		{Match: map[string]string{"class": "financial"}, MaxAge: "7y", Action: RetentionDelete},
+		{MaxAge: "90d", Action: RetentionDrain},
+	}
+	if err := ValidateRetentionRules(valid, 3); err != nil {
+		t.Fatalf("valid rules rejected: %v", err)
+	}
+	if err := ValidateRetentionRules(valid, 2); !errors.Is(err, ErrInvalidRetention) {
+		t.Errorf("expected the rule limit to be enforced, got %v", err)
+	}
+	for name, rule := range map[string]RetentionRule{
+		"unknown action": {MaxAge: "1d", Action: "archive"},
+		"zero age":       {MaxAge: "0s", Action: RetentionDelete},
+		"bad age":        {MaxAge: "soon", Action: RetentionDelete},
+		"empty key":      {Match: map[string]string{"": "x"}, MaxAge: "1d", Action: RetentionDelete},
+	} {
+		if err := ValidateRetentionRules([]RetentionRule{rule}, 0); !errors.Is(err, ErrInvalidRetention) {
+			t.Errorf("%s: expected ErrInvalidRetention, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package core_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/testutil"
+)
+
+// syntheticBrain is the shared 100K-neuron fixture, built on first use.
+// The decay benchmark only lowers its energies.
+var syntheticBrain = sync.OnceValue(func() *testutil.Brain {
+	return testutil.NewSyntheticBrain(42, testutil.SyntheticOptions{Neurons: 100000})
+})
+
+func BenchmarkSyntheticTermStatsRebuild100K(b *testing.B) {
+	m := syntheticBrain().Matrix
+	tokens := make([][]string, 0, len(m.Neurons))
+	for _, n := range m.Neurons {
+		tokens = append(tokens, strings.Fields(strings.ToLower(n.Content)))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		terms := core.NewTermStats()
+		for _, t := range tokens {
+			terms.Add(t)
+		}
+		terms.Trim(100000)
+	}
+}
+
+func BenchmarkSyntheticDecaySweep100K(b *testing.B) {
+	m := syntheticBrain().Matrix
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, n := range m.Neurons {
+			n.Decay(m.DecayRate)
+		}
+	}
+}
+
+func BenchmarkSyntheticHashContent100K(b *testing.B) {
+	m := syntheticBrain().Matrix
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, n := range m.Neurons {
+			_ = core.HashContent(n.Content)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Task is a background function a TaskRunner calls every Interval, first
+// one Interval after it starts.
+type Task struct {
+	Name     string
+	Interval time.Duration
+
+	// Run does one pass. Its context is canceled when the runner stops; a
+	// pass that returns the cancellation then is not a failure.
+	Run func(ctx context.Context) error
+
+	// Final, if set, runs once after the task's last pass when the runner
+	// stops, e.g. a final flush.
+	Final func()
+}
+
+// Task states reported by TaskStatus.State.
+const (
+	TaskRunning    = "running"
+	TaskRestarting = "restarting" // waiting to restart after a panic
+	TaskStopped    = "stopped"
+)
+
+// Restart backoff after a task panics: the first restart waits
+// taskRestartBackoff, each further consecutive panic twice as long, up to
+// taskMaxRestartBackoff.
+const (
+	taskRestartBackoff    = time.Second
+	taskMaxRestartBackoff = time.Minute
+)
+
+// TaskRunner runs background tasks with one start and one stop for all of
+// them. A task whose pass panics is logged and restarted after a backoff
+// instead of leaking or dying silently; each task's runs and failures are
+// recorded for Status.
+type TaskRunner struct {
+	mu      sync.Mutex
+	tasks   []*task
+	started bool
+	stopped bool
+
+	restartBackoff    time.Duration
+	maxRestartBackoff time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// task is a registered Task and its run history, guarded by
+// TaskRunner.mu.
+type task struct {
+	Task
+
+	state               string
+	lastStart           time.Time
+	lastDuration        time.Duration
+	lastSuccess         time.Time
+	lastError           string
+	lastErrorAt         time.Time
+	consecutiveFailures int
+	consecutivePanics   int
+	runs                uint64
+	failures            uint64
+	restarts            uint64
+}
+
+// TaskError is the last failure of a task.
+type TaskError struct {
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// TaskStatus is a task's run history, as reported under tasks by GET
+// /admin/daemons.
+type TaskStatus struct {
+	Name                string     `json:"name"`
+	Interval            string     `json:"interval"`
+	State               string     `json:"state"` // TaskRunning, TaskRestarting or TaskStopped
+	LastStart           *time.Time `json:"lastStart,omitempty"`
+	LastDuration        string     `json:"lastDuration,omitempty"` // of the last pass
+	LastSuccess         *time.Time `json:"lastSuccess,omitempty"`
+	LastError           *TaskError `json:"lastError,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Runs                uint64     `json:"runs"`
+	Failures            uint64     `json:"failures"` // passes that failed or panicked
+	Restarts            uint64     `json:"restarts"` // after a panic
+}
+
+// NewTaskRunner returns a runner with no tasks.
+func NewTaskRunner() *TaskRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TaskRunner{
+		restartBackoff:    taskRestartBackoff,
+		maxRestartBackoff: taskMaxRestartBackoff,
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+}
+
+// Register adds a task that calls fn every interval. See Add.
+func (r *TaskRunner) Register(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	r.Add(Task{Name: name, Interval: interval, Run: fn})
+}
+
+// Add registers t. A task with a non-positive interval is disabled and not
+// added. If the runner has started, t starts now; once it has stopped, t
+// never runs.
+func (r *TaskRunner) Add(t Task) {
+	if t.Interval <= 0 || t.Run == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	tk := &task{Task: t, state: TaskStopped}
+	r.tasks = append(r.tasks, tk)
+	if r.started {
+		r.startLocked(tk)
+	}
+}
+
+// Start starts every registered task. Calling it again does nothing.
+func (r *TaskRunner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started || r.stopped {
+		return
+	}
+	r.started = true
+	for _, t := range r.tasks {
+		r.startLocked(t)
+	}
+}
+
+func (r *TaskRunner) startLocked(t *task) {
+	t.state = TaskRunning
+	r.wg.Add(1)
+	go r.run(t)
+}
+
+// Stop cancels every task, waits for passes under way to return and runs
+// the Final functions. If ctx ends first, Stop returns its error; the
+// tasks still exit once their passes return.
+func (r *TaskRunner) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background tasks still running: %w", ctx.Err())
+	}
+}
+
+// run runs t until the runner stops, restarting it after each panic.
+func (r *TaskRunner) run(t *task) {
+	defer r.wg.Done()
+	for r.loop(t) {
+		r.mu.Lock()
+		backoff := r.restartBackoff << min(t.consecutivePanics-1, 16)
+		backoff = min(backoff, r.maxRestartBackoff)
+		t.state = TaskRestarting
+		r.mu.Unlock()
+
+		log.Printf("⚠ %s task panicked; restarting in %s", t.Name, backoff)
+		if !r.wait(backoff) {
+			break
+		}
+		r.mu.Lock()
+		t.state = TaskRunning
+		t.restarts++
+		r.mu.Unlock()
+	}
+	if t.Final != nil {
+		r.final(t)
+	}
+	r.mu.Lock()
+	t.state = TaskStopped
+	r.mu.Unlock()
+}
+
+// loop calls t.Run every interval until the runner stops, or until a pass
+// panics, which it reports.
+func (r *TaskRunner) loop(t *task) (panicked bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.record(t, fmt.Errorf("panic: %v", p), true)
+			panicked = true
+		}
+	}()
+	for r.wait(t.Interval) {
+		r.mu.Lock()
+		t.lastStart = time.Now()
+		r.mu.Unlock()
+		r.record(t, t.Run(r.ctx), false)
+	}
+	return false
+}
+
+// final runs t.Final, logging a panic.
+func (r *TaskRunner) final(t *task) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("⚠ %s task panicked while stopping: %v", t.Name, p)
+		}
+	}()
+	t.Final()
+}
+
+// record records the outcome of the pass of t that started at lastStart.
+func (r *TaskRunner) record(t *task, err error, panicked bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	t.lastDuration = now.Sub(t.lastStart)
+	t.runs++
+	if err != nil && errors.Is(err, context.Canceled) && r.ctx.Err() != nil {
+		err = nil
+	}
+	if err == nil {
+		t.consecutiveFailures = 0
+		t.consecutivePanics = 0
+		t.lastSuccess = now
+		return
+	}
+	t.failures++
+	t.consecutiveFailures++
+	if panicked {
+		t.consecutivePanics++
+	}
+	t.lastError = err.Error()
+	t.lastErrorAt = now
+	if !panicked {
+		log.Printf("⚠ %s task failed (%d in a row): %v", t.Name, t.consecutiveFailures, err)
+	}
+}
+
+// wait sleeps for d, reporting false if the runner stops first.
+func (r *TaskRunner) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-r.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Status returns every task's run history, in registration order.
+func (r *TaskRunner) Status() []TaskStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]TaskStatus, 0, len(r.tasks))
+	for _, t := range r.tasks {
+		st := TaskStatus{
+			Name:                t.Name,
+			Interval:            t.Interval.String(),
+			State:               t.state,
+			ConsecutiveFailures: t.consecutiveFailures,
+			Runs:                t.runs,
+			Failures:            t.failures,
+			Restarts:            t.restarts,
+		}
+		if !t.lastStart.IsZero() {
+			start := t.lastStart
+			st.LastStart = &start
+		}
+		if t.runs > 0 {
+			st.LastDuration = t.lastDuration.String()
+		}
+		if !t.lastSuccess.IsZero() {
+			success := t.lastSuccess
+			st.LastSuccess = &success
+		}
+		if t.lastError != "" {
+			st.LastError = &TaskError{Message: t.lastError, At: t.lastErrorAt}
+		}
+		out = append(out, st)
+	}
+	return out
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond for up to a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func taskStatus(r *TaskRunner, name string) TaskStatus {
+	for _, st := range r.Status() {
+		if st.Name == name {
+			return st
+		}
+	}
+	return TaskStatus{}
+}
+
+func TestTaskRunner_RecordsRuns(t *testing.T) {
+	r := NewTaskRunner()
+	var calls atomic.Int32
+	r.Register("ok", time.Millisecond, func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+	r.Register("failing", time.Millisecond, func(context.Context) error { return errors.New("disk full") })
+	r.Register("disabled", 0, func(context.Context) error { return nil })
+	if st := taskStatus(r, "ok"); st.State != TaskStopped || st.Runs != 0 {
+		t.Errorf("expected a registered task stopped before Start, got %+v", st)
+	}
+
+	r.Start()
+	waitFor(t, "runs", func() bool { return taskStatus(r, "ok").Runs >= 3 && taskStatus(r, "failing").Runs >= 3 })
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Status()) != 2 {
+		t.Errorf("expected the disabled task not registered, got %+v", r.Status())
+	}
+	ok := taskStatus(r, "ok")
+	if ok.State != TaskStopped || ok.Failures != 0 || ok.LastSuccess == nil || ok.LastError != nil || ok.Runs != uint64(calls.Load()) {
+		t.Errorf("unexpected status of the ok task: %+v", ok)
+	}
+	failing := taskStatus(r, "failing")
+	if failing.Failures != failing.Runs || failing.ConsecutiveFailures != int(failing.Runs) || failing.LastError == nil || failing.LastError.Message != "disk full" || failing.Restarts != 0 {
+		t.Errorf("unexpected status of the failing task: %+v", failing)
+	}
+
+	r.Register("late", time.Millisecond, func(context.Context) error { return nil })
+	if len(r.Status()) != 2 {
+		t.Error("a task registered after Stop should be ignored")
+	}
+}
+
+func TestTaskRunner_RestartsAfterPanic(t *testing.T) {
+	r := NewTaskRunner()
+	r.restartBackoff = time.Millisecond
+	var calls atomic.Int32
+	r.Register("flaky", time.Millisecond, func(context.Context) error {
+		if calls.Add(1) <= 2 {
+			panic("boom")
+		}
+		return nil
+	})
+	r.Start()
+	defer r.Stop(context.Background())
+
+	waitFor(t, "recovery", func() bool { return taskStatus(r, "flaky").LastSuccess != nil })
+	st := taskStatus(r, "flaky")
+	if st.Restarts != 2 || st.Failures != 2 || st.State != TaskRunning || st.ConsecutiveFailures != 0 {
+		t.Errorf("expected two restarts and a recovery, got %+v", st)
+	}
+	if st.LastError == nil || !strings.Contains(st.LastError.Message, "panic: boom") {
+		t.Errorf("expected the panic recorded, got %+v", st.LastError)
+	}
+}
+
+func TestTaskRunner_StopInterruptsRestartBackoff(t *testing.T) {
+	r := NewTaskRunner()
+	r.restartBackoff = time.Hour
+	r.Register("broken", time.Millisecond, func(context.Context) error { panic("always") })
+	r.Start()
+
+	waitFor(t, "restart wait", func() bool { return taskStatus(r, "broken").State == TaskRestarting })
+	if st := taskStatus(r, "broken"); st.Restarts != 0 || st.Failures != 1 {
+		t.Errorf("expected one panic and no restart yet, got %+v", st)
+	}
+	// Stop interrupts the backoff.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := taskStatus(r, "broken"); st.State != TaskStopped {
+		t.Errorf("expected the task stopped, got %+v", st)
+	}
+}
+
+func TestTaskRunner_StopDuringRun(t *testing.T) {
+	r := NewTaskRunner()
+	started := make(chan struct{})
+	var finished, finalRan atomic.Bool
+	r.Add(Task{
+		Name:     "slow",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-ctx.Done()
+			time.Sleep(5 * time.Millisecond)
+			finished.Store(true)
+			return ctx.Err()
+		},
+		Final: func() {
+			if !finished.Load() {
+				t.Error("Final ran before the pass returned")
+			}
+			finalRan.Store(true)
+		},
+	})
+	r.Start()
+	<-started
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !finished.Load() || !finalRan.Load() {
+		t.Error("Stop should wait for the pass and run Final")
+	}
+	if st := taskStatus(r, "slow"); st.Failures != 0 || st.Runs != 1 {
+		t.Errorf("a pass canceled by Stop is not a failure, got %+v", st)
+	}
+
+	// A pass ignoring cancellation outlives a bounded Stop.
+	r = NewTaskRunner()
+	release := make(chan struct{})
+	r.Register("stuck", time.Millisecond, func(context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+	r.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline error, got %v", err)
+	}
+	close(release)
+	waitFor(t, "stuck task to exit", func() bool { return taskStatus(r, "stuck").State == TaskStopped })
+}
+
+// TestTaskRunner_NoGoroutineLeak starts and stops runners with healthy,
+// failing and panicking tasks and checks no goroutine outlives them.
+func TestTaskRunner_NoGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for range 20 {
+		r := NewTaskRunner()
+		r.restartBackoff = time.Millisecond
+		r.Register("ok", time.Millisecond, func(context.Context) error { return nil })
+		r.Register("failing", time.Millisecond, func(context.Context) error { return errors.New("no") })
+		r.Register("panicking", time.Millisecond, func(context.Context) error { panic("no") })
+		r.Add(Task{Name: "final", Interval: time.Hour, Run: func(context.Context) error { return nil }, Final: func() {}})
+		r.Start()
+		time.Sleep(2 * time.Millisecond)
+		if err := r.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, "goroutines to exit", func() bool { return runtime.NumGoroutine() <= before })
+}
@@ -0,0 +1,94 @@
+package core
+
+import "math"
+
+// TermStats holds the corpus statistics BM25 scoring needs: how many
+// neurons contain each term and how long neurons are on average. It is
+// persisted with the matrix and maintained as neurons are written, changed
+// and forgotten; callers must hold the matrix write lock to modify it.
+type TermStats struct {
+	// DocFreq maps a term to the number of neurons that contain it.
+	DocFreq map[string]int `msgpack:"df"`
+
+	// Docs is the number of neurons counted; TotalLen sums their token
+	// counts.
+	Docs     int `msgpack:"docs"`
+	TotalLen int `msgpack:"total_len"`
+
+	// Fold names the text folding the tokens were counted with; statistics
+	// counted with another are rebuilt.
+	Fold string `msgpack:"fold,omitempty"`
+}
+
+// NewTermStats creates empty term statistics.
+func NewTermStats() *TermStats {
+	return &TermStats{DocFreq: make(map[string]int)}
+}
+
+// Add counts one neuron with the given tokens. Repeated tokens count once.
+func (t *TermStats) Add(tokens []string) {
+	t.Docs++
+	t.TotalLen += len(tokens)
+	seen := make(map[string]struct{}, len(tokens))
+	for _, tok := range tokens {
+		if _, ok := seen[tok]; ok {
+			continue
+		}
+		seen[tok] = struct{}{}
+		t.DocFreq[tok]++
+	}
+}
+
+// Remove uncounts one neuron previously passed to Add with the same tokens.
+func (t *TermStats) Remove(tokens []string) {
+	if t.Docs > 0 {
+		t.Docs--
+	}
+	t.TotalLen = max(0, t.TotalLen-len(tokens))
+	seen := make(map[string]struct{}, len(tokens))
+	for _, tok := range tokens {
+		if _, ok := seen[tok]; ok {
+			continue
+		}
+		seen[tok] = struct{}{}
+		if t.DocFreq[tok] <= 1 {
+			delete(t.DocFreq, tok)
+		} else {
+			t.DocFreq[tok]--
+		}
+	}
+}
+
+// Trim drops terms seen in a single neuron while more than maxTerms terms
+// are tracked. A dropped term scores as if it were unseen, i.e. as rare.
+// maxTerms <= 0 disables the bound.
+func (t *TermStats) Trim(maxTerms int) {
+	if maxTerms <= 0 || len(t.DocFreq) <= maxTerms {
+		return
+	}
+	for tok, df := range t.DocFreq {
+		if len(t.DocFreq) <= maxTerms {
+			return
+		}
+		if df <= 1 {
+			delete(t.DocFreq, tok)
+		}
+	}
+}
+
+// AvgLen returns the mean token count of the counted neurons.
+func (t *TermStats) AvgLen() float64 {
+	if t.Docs == 0 {
+		return 0
+	}
+	return float64(t.TotalLen) / float64(t.Docs)
+}
+
+// IDF returns the inverse document frequency of term. It uses the
+// non-negative BM25 form, so terms present in most neurons still score
+// slightly above zero.
+func (t *TermStats) IDF(term string) float64 {
+	n := float64(t.Docs)
+	df := float64(t.DocFreq[term])
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholders a turn template may use.
+const (
+	TurnRolePlaceholder = "{{role}}"
+	TurnLangPlaceholder = "{{lang}}"
+	TurnTextPlaceholder = "{{text}}"
+)
+
+// DefaultTurnTemplate is the template name used when a request names none.
+const DefaultTurnTemplate = "default"
+
+// DefaultTurnPattern matches conversation turns stored before structured
+// turns existed, e.g. "[EN] user: hello" or "assistant: hi".
+const DefaultTurnPattern = `(?is)^(?:\[(?P<lang>[a-z]{2,3}(?:[-_][a-z0-9]+)?)\]\s*)?(?P<role>user|assistant|system|tool):\s*(?P<text>.+)$`
+
+var turnPlaceholderRe = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+var languageTagRe = regexp.MustCompile(`(?i)^[a-z]{2,3}(?:[-_][a-z0-9]+)*$`)
+
+// IsLanguageTag reports whether s looks like a language tag such as "tr"
+// or "de-AT", as turns record under _lang.
+func IsLanguageTag(s string) bool {
+	return languageTagRe.MatchString(s)
+}
+
+// TurnTemplate renders a conversation turn from its role, language and
+// text, e.g. "{{role}}: {{text}}".
+type TurnTemplate string
+
+// ParseTurnTemplate checks that s only uses known placeholders and renders
+// the turn's text.
+func ParseTurnTemplate(s string) (TurnTemplate, error) {
+	for _, p := range turnPlaceholderRe.FindAllString(s, -1) {
+		switch p {
+		case TurnRolePlaceholder, TurnLangPlaceholder, TurnTextPlaceholder:
+		default:
+			return "", fmt.Errorf("unknown placeholder %s", p)
+		}
+	}
+	if !strings.Contains(s, TurnTextPlaceholder) {
+		return "", fmt.Errorf("template must contain %s", TurnTextPlaceholder)
+	}
+	return TurnTemplate(s), nil
+}
+
+// Render substitutes the turn's fields into the template.
+func (t TurnTemplate) Render(role, lang, text string) string {
+	return strings.NewReplacer(
+		TurnRolePlaceholder, role,
+		TurnLangPlaceholder, lang,
+		TurnTextPlaceholder, text,
+	).Replace(string(t))
+}
+
+// Placeholders a block template may use.
+const (
+	BlockAttrsPlaceholder = "{{attrs}}"
+	BlockTextPlaceholder  = "{{text}}"
+)
+
+// DefaultBlockTemplate wraps each memory of a "blocks" context in an
+// XML-style element carrying its metadata as attributes.
+const DefaultBlockTemplate = "<memory{{attrs}}>{{text}}</memory>"
+
+// Context output formats reserved in a context request's format; other
+// values name turn templates, which render as text.
+const (
+	ContextFormatText     = "text"
+	ContextFormatMessages = "messages"
+	ContextFormatBlocks   = "blocks"
+)
+
+// IsContextFormat reports whether name is a reserved context output format.
+func IsContextFormat(name string) bool {
+	switch name {
+	case ContextFormatText, ContextFormatMessages, ContextFormatBlocks:
+		return true
+	}
+	return false
+}
+
+// BlockTemplate wraps one memory of a "blocks" context, e.g.
+// "<memory{{attrs}}>{{text}}</memory>".
+type BlockTemplate string
+
+// ParseBlockTemplate checks that s only uses known placeholders and wraps
+// the memory's text.
+func ParseBlockTemplate(s string) (BlockTemplate, error) {
+	for _, p := range turnPlaceholderRe.FindAllString(s, -1) {
+		switch p {
+		case BlockAttrsPlaceholder, BlockTextPlaceholder:
+		default:
+			return "", fmt.Errorf("unknown placeholder %s", p)
+		}
+	}
+	if strings.Count(s, BlockTextPlaceholder) != 1 {
+		return "", fmt.Errorf("template must contain %s exactly once", BlockTextPlaceholder)
+	}
+	return BlockTemplate(s), nil
+}
+
+// Render substitutes attrs, already formatted, and text into the template.
+// Both must already be escaped.
+func (t BlockTemplate) Render(attrs, text string) string {
+	return strings.NewReplacer(
+		BlockAttrsPlaceholder, attrs,
+		BlockTextPlaceholder, text,
+	).Replace(string(t))
+}
+
+// CompileTurnPattern compiles a turn migration pattern, which must capture
+// the turn's role and text in named groups; a lang group is optional.
+func CompileTurnPattern(s string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, name := range re.SubexpNames() {
+		names[name] = true
+	}
+	if !names["role"] || !names["text"] {
+		return nil, fmt.Errorf("pattern must have named groups role and text")
+	}
+	return re, nil
+}
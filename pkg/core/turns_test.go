@@ -0,0 +1,41 @@
+package core
+
+import "testing"
+
+func TestTurnTemplate_Render(t *testing.T) {
+	tmpl, err := ParseTurnTemplate("[{{lang}}] {{role}}: {{text}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tmpl.Render("user", "tr", "Merhaba"); got != "[tr] user: Merhaba" {
+		t.Errorf("unexpected rendering %q", got)
+	}
+	if _, err := ParseTurnTemplate("{{role}} only"); err == nil {
+		t.Error("a template without {{text}} should be rejected")
+	}
+}
+
+func TestDefaultTurnPattern(t *testing.T) {
+	re, err := CompileTurnPattern(DefaultTurnPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for content, want := range map[string][3]string{
+		"[EN] user: My name is Alex":       {"EN", "user", "My name is Alex"},
+		"assistant: Got it!\nSee you soon": {"", "assistant", "Got it!\nSee you soon"},
+		"[de-AT] System: Servus":           {"de-AT", "System", "Servus"},
+	} {
+		m := re.FindStringSubmatch(content)
+		if m == nil {
+			t.Errorf("%q should match", content)
+			continue
+		}
+		got := [3]string{m[re.SubexpIndex("lang")], m[re.SubexpIndex("role")], m[re.SubexpIndex("text")]}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", content, got, want)
+		}
+	}
+	if re.MatchString("Note: deploys go out on Mondays") {
+		t.Error("only conversation roles should match")
+	}
+}
@@ -1,7 +1,10 @@
 package core
 
 import (
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,7 +29,22 @@ func NewSynapseID(from, to NeuronID) SynapseID {
 	return SynapseID(string(from) + ":" + string(to))
 }
 
-// Neuron represents a single memory unit in the brain
+// Neuron represents a single memory unit in the brain.
+//
+// Concurrency: once a neuron is in a matrix, worker operations change it
+// while handlers still encode it from the results of earlier operations,
+// outside the worker's locks. So, for a neuron in a matrix:
+//
+//   - fields Snapshot copies are written under the neuron's lock;
+//   - its maps and slices (Metadata, Position, Tags, Attachments,
+//     Promotions, Embedding) are copy-on-write: a mutator builds a new one
+//     and swaps it in under the lock, with EditMetadata, SetPosition and
+//     SetTags, and never modifies one in place;
+//   - readers outside the worker read from a Snapshot, or copy what they
+//     need under RLock; persistence, clones and exports encode the matrix
+//     through Matrix.SnapshotLocked, which does so for every neuron.
+//
+// A neuron not yet added to a matrix belongs to whoever builds it.
 type Neuron struct {
 	ID          NeuronID `msgpack:"id"`
 	Content     string   `msgpack:"content"`
@@ -58,12 +76,92 @@ type Neuron struct {
 	// Vector embedding for semantic search (set once on creation, nil if vector layer disabled)
 	Embedding []float32 `msgpack:"embedding,omitempty"`
 
+	// EmbeddingModel names the model that produced Embedding. Empty means
+	// DefaultEmbeddingModel (embeddings written before models were tagged).
+	EmbeddingModel string `msgpack:"embedding_model,omitempty"`
+
+	// EmbedPending marks a neuron written while its embedding call failed;
+	// it is embedded later, once the vector layer recovers.
+	EmbedPending bool `msgpack:"embed_pending,omitempty"`
+
 	// Metadata
 	Metadata map[string]any `msgpack:"metadata"`
 
+	// Attachments reference blobs kept outside the matrix. Their captions
+	// are searched with the content; the blobs themselves are not.
+	Attachments []Attachment `msgpack:"attachments,omitempty"`
+
+	// LexicalTokens are the case- and diacritic-folded tokens lexical
+	// search matches the neuron by; LexicalKey records what they were
+	// computed from, so stale tokens are recomputed rather than used.
+	LexicalTokens []string `msgpack:"lexical_tokens,omitempty"`
+	LexicalKey    string   `msgpack:"lexical_key,omitempty"`
+
+	// Pinned neurons are never pruned, never decay below the configured
+	// pin energy floor, and always count as important for consolidation.
+	Pinned bool `msgpack:"pinned,omitempty"`
+
+	// Promotions records the consolidation passes that deepened the
+	// neuron, oldest first, capped at daemons.consolidate.promotionHistory.
+	Promotions []Promotion `msgpack:"promotions,omitempty"`
+
+	// Delta sync markers. ChangeSeq is the matrix change sequence of the last
+	// content change, ActivitySeq of the last reported energy/depth change;
+	// SyncEnergy and SyncDepth are the values reported at that point.
+	ChangeSeq   uint64  `msgpack:"change_seq,omitempty"`
+	ActivitySeq uint64  `msgpack:"activity_seq,omitempty"`
+	SyncEnergy  float64 `msgpack:"sync_energy,omitempty"`
+	SyncDepth   int     `msgpack:"sync_depth,omitempty"`
+
+	// footprint is the size last counted into the matrix footprint.
+	footprint int64
+
 	mu sync.RWMutex `msgpack:"-"`
 }
 
+// Attachment references a blob in the content-addressed attachment store
+// by the hex SHA-256 of its bytes.
+type Attachment struct {
+	Hash    string `msgpack:"hash" json:"hash"`
+	Caption string `msgpack:"caption,omitempty" json:"caption,omitempty"`
+}
+
+// Promotion reasons: which rule of ShouldConsolidate deepened a neuron.
+const (
+	PromotionPinned = "pinned" // pinned and old enough
+	PromotionMature = "mature" // accessed often, old enough and no longer active
+)
+
+// Promotion records a consolidation pass deepening a neuron, with the
+// factors the decision rested on as they stood at the time.
+type Promotion struct {
+	At        time.Time `msgpack:"at" json:"at"`
+	FromDepth int       `msgpack:"from_depth" json:"fromDepth"`
+	ToDepth   int       `msgpack:"to_depth" json:"toDepth"`
+	Reason    string    `msgpack:"reason" json:"reason"`
+
+	AccessCount     uint64  `msgpack:"access_count" json:"accessCount"`
+	Energy          float64 `msgpack:"energy" json:"energy"`
+	AgeSeconds      float64 `msgpack:"age_seconds" json:"ageSeconds"`
+	Synapses        int     `msgpack:"synapses" json:"synapses"`
+	SynapseStrength float64 `msgpack:"synapse_strength" json:"synapseStrength"` // mean weight of its synapses
+}
+
+// LexicalText is what lexical search matches n against: its content,
+// followed by the captions of its attachments.
+func (n *Neuron) LexicalText() string {
+	if len(n.Attachments) == 0 {
+		return n.Content
+	}
+	text := n.Content
+	for _, a := range n.Attachments {
+		if a.Caption != "" {
+			text += "\n" + a.Caption
+		}
+	}
+	return text
+}
+
 // NewNeuron creates a new neuron with given content
 func NewNeuron(content string, initialDim int) *Neuron {
 	now := time.Now()
@@ -98,13 +196,19 @@ func (n *Neuron) Fire() {
 // Decay reduces energy based on time elapsed since the last decay tick,
 // not since last fire. This prevents compounding decay on long-idle neurons.
 func (n *Neuron) Decay(rate float64) {
+	n.DecayAbove(rate, 0)
+}
+
+// DecayAbove is Decay that also never leaves energy below floor. The decay
+// daemon uses it for pinned neurons.
+func (n *Neuron) DecayAbove(rate, floor float64) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
 	now := time.Now()
 	elapsed := now.Sub(n.LastDecayAt).Seconds()
 	decay := rate * elapsed / 3600 // rate per hour
-	n.Energy = max(n.BaseEnergy, n.Energy-decay)
+	n.Energy = max(n.BaseEnergy, floor, n.Energy-decay)
 	n.LastDecayAt = now
 }
 
@@ -136,14 +240,86 @@ func (n *Neuron) Reactivate(boost float64) {
 // ShouldConsolidate checks if neuron is ready to move deeper.
 // Requires sufficient access count, age, AND that energy has decayed below
 // the active threshold — a neuron still firing frequently should not consolidate.
+// Pinned neurons count as important and only need the age.
 func (n *Neuron) ShouldConsolidate(accessThreshold uint64, ageThreshold time.Duration) bool {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
 	age := time.Since(n.CreatedAt)
+	if n.Pinned {
+		return age >= ageThreshold
+	}
 	return n.AccessCount >= accessThreshold && age >= ageThreshold && n.Energy < 0.5
 }
 
+// EmbeddingModelName returns the model that produced the neuron's embedding.
+// Embeddings are only comparable when their model names are equal.
+func (n *Neuron) EmbeddingModelName() string {
+	if n.EmbeddingModel == "" {
+		return DefaultEmbeddingModel
+	}
+	return n.EmbeddingModel
+}
+
+// Snapshot returns a copy of n taken under its lock, for encoding outside
+// the worker. The copy shares n's maps and slices, which are never
+// modified in place. The delta sync markers, lexical tokens and footprint
+// belong to the matrix and are left out.
+func (n *Neuron) Snapshot() *Neuron {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return &Neuron{
+		ID:             n.ID,
+		Content:        n.Content,
+		ContentHash:    n.ContentHash,
+		Position:       n.Position,
+		Energy:         n.Energy,
+		BaseEnergy:     n.BaseEnergy,
+		Depth:          n.Depth,
+		CreatedAt:      n.CreatedAt,
+		LastFiredAt:    n.LastFiredAt,
+		LastDecayAt:    n.LastDecayAt,
+		AccessCount:    n.AccessCount,
+		Tags:           n.Tags,
+		SentimentLabel: n.SentimentLabel,
+		SentimentScore: n.SentimentScore,
+		Embedding:      n.Embedding,
+		EmbeddingModel: n.EmbeddingModel,
+		EmbedPending:   n.EmbedPending,
+		Metadata:       n.Metadata,
+		Attachments:    n.Attachments,
+		Pinned:         n.Pinned,
+		Promotions:     n.Promotions,
+	}
+}
+
+// EditMetadata applies edit to a copy of n's metadata and swaps the copy
+// in under n's lock.
+func (n *Neuron) EditMetadata(edit func(metadata map[string]any)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	metadata := make(map[string]any, len(n.Metadata)+2)
+	maps.Copy(metadata, n.Metadata)
+	edit(metadata)
+	n.Metadata = metadata
+}
+
+// SetPosition swaps in pos as n's position under n's lock. pos must not be
+// modified afterwards.
+func (n *Neuron) SetPosition(pos []float64) {
+	n.mu.Lock()
+	n.Position = pos
+	n.mu.Unlock()
+}
+
+// SetTags swaps in tags as n's tags under n's lock. tags must not be
+// modified afterwards.
+func (n *Neuron) SetTags(tags []string) {
+	n.mu.Lock()
+	n.Tags = tags
+	n.mu.Unlock()
+}
+
 // Synapse represents a connection between two neurons
 type Synapse struct {
 	ID     SynapseID `msgpack:"id"`
@@ -160,13 +336,65 @@ type Synapse struct {
 	// Bidirectional flag
 	Bidirectional bool `msgpack:"bidirectional"`
 
+	// Type names the relation the synapse stands for: one of the
+	// SynapseType constants, or a type the application chose.
+	Type string `msgpack:"type,omitempty"`
+
 	CreatedAt time.Time `msgpack:"created_at"`
 
 	mu sync.RWMutex `msgpack:"-"`
 }
 
-// NewSynapse creates a new synapse between two neurons
+// Synapse types QubicDB forms itself. Applications may link neurons with
+// types of their own; see ValidSynapseType.
+const (
+	// SynapseAssociative is formed by neurons firing close together, as
+	// consecutive writes do. Synapses stored before types existed have it.
+	SynapseAssociative = "associative"
+
+	// SynapseFollows links a write to the neuron it names as its parent,
+	// following the order of a conversation.
+	SynapseFollows = "follows"
+
+	// SynapseSimilar links neurons formed for their similar content.
+	SynapseSimilar = "similar"
+
+	// SynapseCoRetrieved links neurons retrieved by the same search.
+	SynapseCoRetrieved = "co-retrieved"
+)
+
+// MaxSynapseTypeLength bounds the length of a synapse type.
+const MaxSynapseTypeLength = 32
+
+// ValidSynapseType reports whether t can name a synapse type: 1 to
+// MaxSynapseTypeLength lowercase letters, digits, '-' and '_', starting
+// with a letter.
+func ValidSynapseType(t string) bool {
+	if t == "" || len(t) > MaxSynapseTypeLength || t[0] < 'a' || t[0] > 'z' {
+		return false
+	}
+	for i := 0; i < len(t); i++ {
+		c := t[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// SynapseTypeIn reports whether t is among types. No types match every
+// type.
+func SynapseTypeIn(t string, types []string) bool {
+	return len(types) == 0 || slices.Contains(types, t)
+}
+
+// NewSynapse creates a new associative synapse between two neurons
 func NewSynapse(from, to NeuronID, initialWeight float64) *Synapse {
+	return NewTypedSynapse(from, to, initialWeight, SynapseAssociative)
+}
+
+// NewTypedSynapse creates a new synapse of type typ between two neurons.
+func NewTypedSynapse(from, to NeuronID, initialWeight float64, typ string) *Synapse {
 	now := time.Now()
 	return &Synapse{
 		ID:            NewSynapseID(from, to),
@@ -176,6 +404,7 @@ func NewSynapse(from, to NeuronID, initialWeight float64) *Synapse {
 		CoFireCount:   1,
 		LastCoFire:    now,
 		Bidirectional: true,
+		Type:          typ,
 		CreatedAt:     now,
 	}
 }
@@ -240,6 +469,31 @@ func (s *Synapse) Reactivate(boost float64) {
 	s.LastCoFire = time.Now()
 }
 
+// Snapshot returns the synapse's weight, co-fire count and last co-fire time
+// read under its lock.
+func (s *Synapse) Snapshot() (weight float64, coFires uint64, lastCoFire time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Weight, s.CoFireCount, s.LastCoFire
+}
+
+// copy returns a copy of s taken under its lock.
+func (s *Synapse) copy() *Synapse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &Synapse{
+		ID:            s.ID,
+		FromID:        s.FromID,
+		ToID:          s.ToID,
+		Weight:        s.Weight,
+		CoFireCount:   s.CoFireCount,
+		LastCoFire:    s.LastCoFire,
+		Bidirectional: s.Bidirectional,
+		Type:          s.Type,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
 // MatrixBounds defines the organic growth limits
 type MatrixBounds struct {
 	MinDimension int `msgpack:"min_dim"`
@@ -288,13 +542,69 @@ type Matrix struct {
 	CreatedAt  time.Time `msgpack:"created_at"`
 	ModifiedAt time.Time `msgpack:"modified_at"`
 
+	// Change feed for delta sync. ChangeSeq is the last sequence number
+	// handed out; Tombstones holds recent removals, oldest first, and
+	// HistoryFloor is the newest sequence whose removal has been dropped.
+	ChangeSeq    uint64      `msgpack:"change_seq"`
+	Tombstones   []Tombstone `msgpack:"tombstones"`
+	HistoryFloor uint64      `msgpack:"history_floor"`
+
+	// Terms holds the document-frequency statistics of lexical scoring.
+	// Matrices persisted without it rebuild it on their first search.
+	Terms *TermStats `msgpack:"terms,omitempty"`
+
+	// MetadataIndex maps the values of the indexed metadata keys to the
+	// neurons holding them. Matrices persisted without it, or over other
+	// keys, rebuild it on their first search.
+	MetadataIndex *MetadataIndex `msgpack:"metadata_index,omitempty"`
+
+	// Recycled holds forgotten neurons until their recycle window ends.
+	// They are out of Neurons, so no retrieval, decay or bound sees them,
+	// but they still count toward the footprint.
+	Recycled map[NeuronID]*RecycledNeuron `msgpack:"recycled,omitempty"`
+
+	// footprint is the approximate memory held by the matrix in bytes.
+	footprint atomic.Int64
+
 	mu sync.RWMutex `msgpack:"-"`
 }
 
+// Tombstone records the removal of a neuron from a matrix.
+type Tombstone struct {
+	Seq uint64   `msgpack:"seq"`
+	ID  NeuronID `msgpack:"id"`
+}
+
+// RecycledNeuron is a forgotten neuron that can still be restored, with
+// the synapses it had when it was forgotten.
+type RecycledNeuron struct {
+	Neuron    *Neuron    `msgpack:"neuron"`
+	Synapses  []*Synapse `msgpack:"synapses"`
+	DeletedAt time.Time  `msgpack:"deleted_at"`
+	Reason    string     `msgpack:"reason"`
+}
+
+// DefaultSynapseTypes gives synapses stored without a type, by a server
+// that predates them, SynapseAssociative.
+func (m *Matrix) DefaultSynapseTypes() {
+	for _, s := range m.Synapses {
+		if s.Type == "" {
+			s.Type = SynapseAssociative
+		}
+	}
+	for _, r := range m.Recycled {
+		for _, s := range r.Synapses {
+			if s.Type == "" {
+				s.Type = SynapseAssociative
+			}
+		}
+	}
+}
+
 // NewMatrix creates a new organic memory matrix for a user
 func NewMatrix(indexID IndexID, bounds MatrixBounds) *Matrix {
 	now := time.Now()
-	return &Matrix{
+	m := &Matrix{
 		IndexID:           indexID,
 		Bounds:            bounds,
 		CurrentDim:        bounds.MinDimension,
@@ -310,6 +620,34 @@ func NewMatrix(indexID IndexID, bounds MatrixBounds) *Matrix {
 		Version:           1,
 		CreatedAt:         now,
 		ModifiedAt:        now,
+		Terms:             NewTermStats(),
+	}
+	m.footprint.Store(matrixOverhead)
+	return m
+}
+
+// NextChangeSeq advances the change sequence and returns the new value.
+// Callers must hold the matrix write lock, as for the methods below.
+func (m *Matrix) NextChangeSeq() uint64 {
+	m.ChangeSeq++
+	return m.ChangeSeq
+}
+
+// RecordChange marks n as created or modified.
+func (m *Matrix) RecordChange(n *Neuron) {
+	n.ChangeSeq = m.NextChangeSeq()
+	n.SyncEnergy, n.SyncDepth = n.Energy, n.Depth
+	m.Remeasure(n)
+}
+
+// RecordRemoval adds a tombstone for id, keeping at most limit tombstones.
+// Dropping old tombstones raises HistoryFloor. limit <= 0 keeps all.
+func (m *Matrix) RecordRemoval(id NeuronID, limit int) {
+	m.Tombstones = append(m.Tombstones, Tombstone{Seq: m.NextChangeSeq(), ID: id})
+	if limit > 0 && len(m.Tombstones) > limit {
+		drop := len(m.Tombstones) - limit
+		m.HistoryFloor = m.Tombstones[drop-1].Seq
+		m.Tombstones = append([]Tombstone(nil), m.Tombstones[drop:]...)
 	}
 }
 
@@ -348,6 +686,50 @@ func NewBrainState(indexID IndexID) *BrainState {
 	}
 }
 
+// SnapshotLocked returns a copy of m to encode. Firing and Hebbian
+// learning change neurons and synapses under their own locks while only
+// the matrix read lock is held, so each neuron is copied with Snapshot and
+// each synapse under its lock. Neurons keep their lexical tokens and delta
+// sync markers, which only change under the matrix write lock. The other
+// maps and slices are shared with m: the caller holds m's read lock until
+// it is done with the copy.
+func (m *Matrix) SnapshotLocked() *Matrix {
+	c := &Matrix{
+		IndexID:           m.IndexID,
+		Bounds:            m.Bounds,
+		CurrentDim:        m.CurrentDim,
+		Neurons:           make(map[NeuronID]*Neuron, len(m.Neurons)),
+		Synapses:          make(map[SynapseID]*Synapse, len(m.Synapses)),
+		Adjacency:         m.Adjacency,
+		DecayRate:         m.DecayRate,
+		LinkThreshold:     m.LinkThreshold,
+		ConsolFrequency:   m.ConsolFrequency,
+		TotalActivations:  m.TotalActivations,
+		LastActivity:      m.LastActivity,
+		LastConsolidation: m.LastConsolidation,
+		Version:           m.Version,
+		CreatedAt:         m.CreatedAt,
+		ModifiedAt:        m.ModifiedAt,
+		ChangeSeq:         m.ChangeSeq,
+		Tombstones:        m.Tombstones,
+		HistoryFloor:      m.HistoryFloor,
+		Terms:             m.Terms,
+		MetadataIndex:     m.MetadataIndex,
+		Recycled:          m.Recycled,
+	}
+	for id, n := range m.Neurons {
+		snap := n.Snapshot()
+		snap.LexicalTokens, snap.LexicalKey = n.LexicalTokens, n.LexicalKey
+		snap.ChangeSeq, snap.ActivitySeq = n.ChangeSeq, n.ActivitySeq
+		snap.SyncEnergy, snap.SyncDepth = n.SyncEnergy, n.SyncDepth
+		c.Neurons[id] = snap
+	}
+	for id, syn := range m.Synapses {
+		c.Synapses[id] = syn.copy()
+	}
+	return c
+}
+
 // Matrix lock methods for external packages
 func (m *Matrix) Lock()    { m.mu.Lock() }
 func (m *Matrix) Unlock()  { m.mu.Unlock() }
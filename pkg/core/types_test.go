@@ -1,6 +1,7 @@
 package core
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -98,6 +99,17 @@ func TestNeuronDecay(t *testing.T) {
 	}
 }
 
+func TestNeuronDecayAbove(t *testing.T) {
+	n := NewNeuron("Test", 3)
+	n.LastDecayAt = time.Now().Add(-100 * time.Hour)
+
+	n.DecayAbove(0.1, 0.5)
+
+	if n.Energy != 0.5 {
+		t.Errorf("Energy should stop at the floor, got %f", n.Energy)
+	}
+}
+
 func TestNeuronIsAlive(t *testing.T) {
 	n := NewNeuron("Test", 3)
 
@@ -139,6 +151,13 @@ func TestNeuronShouldConsolidate(t *testing.T) {
 	if !n.ShouldConsolidate(10, 30*time.Minute) {
 		t.Error("Should consolidate when access count, age, and low energy conditions are all met")
 	}
+
+	n.Energy = 0.9
+	n.AccessCount = 1
+	n.Pinned = true
+	if !n.ShouldConsolidate(10, 30*time.Minute) {
+		t.Error("Pinned neuron should consolidate once old enough")
+	}
 }
 
 func TestNewSynapse(t *testing.T) {
@@ -496,3 +515,138 @@ func TestMatrixBoundsValidation(t *testing.T) {
 		t.Error("Matrix should start at MinDimension")
 	}
 }
+
+func TestTermStats(t *testing.T) {
+	ts := NewTermStats()
+	ts.Add([]string{"deploy", "friday", "deploy"})
+	ts.Add([]string{"deploy", "monday"})
+
+	if ts.Docs != 2 || ts.TotalLen != 5 || ts.AvgLen() != 2.5 {
+		t.Errorf("unexpected totals: %+v", ts)
+	}
+	if ts.DocFreq["deploy"] != 2 {
+		t.Errorf("repeated tokens should count once per document, got %d", ts.DocFreq["deploy"])
+	}
+	if ts.IDF("friday") <= ts.IDF("deploy") || ts.IDF("deploy") <= 0 {
+		t.Errorf("rarer terms should have higher IDF, common ones stay positive: %f vs %f", ts.IDF("friday"), ts.IDF("deploy"))
+	}
+
+	ts.Remove([]string{"deploy", "friday", "deploy"})
+	if ts.Docs != 1 || ts.DocFreq["deploy"] != 1 {
+		t.Errorf("unexpected stats after remove: %+v", ts)
+	}
+	if _, ok := ts.DocFreq["friday"]; ok {
+		t.Error("terms no document contains should be dropped")
+	}
+
+	ts.Add([]string{"deploy", "alpha", "beta", "gamma"})
+	ts.Trim(2)
+	if len(ts.DocFreq) > 2 || ts.DocFreq["deploy"] != 2 {
+		t.Errorf("trim should drop single-document terms first, got %v", ts.DocFreq)
+	}
+	if ts.Docs != 2 || ts.TotalLen != 6 {
+		t.Error("trim should keep document totals")
+	}
+}
+
+// filledNeuron returns a neuron with every exported field set.
+func filledNeuron(content string) *Neuron {
+	n := NewNeuron(content, 3)
+	v := reflect.ValueOf(n).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.CanSet() && f.IsZero() {
+			f.Set(reflect.New(f.Type()).Elem())
+			switch f.Kind() {
+			case reflect.String:
+				f.SetString("x")
+			case reflect.Bool:
+				f.SetBool(true)
+			case reflect.Int, reflect.Uint64, reflect.Float64:
+				f.Set(reflect.ValueOf(1).Convert(f.Type()))
+			case reflect.Slice:
+				f.Set(reflect.MakeSlice(f.Type(), 1, 1))
+			}
+		}
+	}
+	return n
+}
+
+func TestNeuronSnapshot(t *testing.T) {
+	n := filledNeuron("snapshot me")
+	v := reflect.ValueOf(n).Elem()
+
+	// Matrix bookkeeping is left out of a snapshot.
+	skipped := map[string]bool{"LexicalTokens": true, "LexicalKey": true, "ChangeSeq": true, "ActivitySeq": true, "SyncEnergy": true, "SyncDepth": true}
+	snap := reflect.ValueOf(n.Snapshot()).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if !v.Field(i).CanSet() {
+			continue
+		}
+		copied := reflect.DeepEqual(v.Field(i).Interface(), snap.Field(i).Interface())
+		if copied == skipped[name] {
+			t.Errorf("field %s: copied %v, expected %v", name, copied, !skipped[name])
+		}
+	}
+}
+
+func TestNeuronEditMetadata(t *testing.T) {
+	n := NewNeuron("edit me", 3)
+	n.Metadata["keep"] = "yes"
+	before := n.Metadata
+
+	n.EditMetadata(func(metadata map[string]any) {
+		metadata["added"] = 1
+		delete(metadata, "keep")
+	})
+	if len(before) != 1 || before["keep"] != "yes" {
+		t.Errorf("expected the previous map untouched, got %v", before)
+	}
+	if len(n.Metadata) != 1 || n.Metadata["added"] != 1 {
+		t.Errorf("unexpected metadata %v", n.Metadata)
+	}
+
+	n.Metadata = nil
+	n.EditMetadata(func(metadata map[string]any) { metadata["a"] = "b" })
+	if n.Metadata["a"] != "b" {
+		t.Errorf("expected a map created for a neuron without metadata, got %v", n.Metadata)
+	}
+}
+
+func TestMatrixSnapshotLocked(t *testing.T) {
+	m := NewMatrix("snap", DefaultBounds())
+	a, b := filledNeuron("a"), filledNeuron("b")
+	m.Neurons[a.ID], m.Neurons[b.ID] = a, b
+	syn := NewSynapse(a.ID, b.ID, 0.4)
+	syn.Type = SynapseAssociative
+	m.Synapses[syn.ID] = syn
+
+	m.RLock()
+	c := m.SnapshotLocked()
+	m.RUnlock()
+
+	// Neurons keep everything persistence stores, bookkeeping included.
+	for id, n := range m.Neurons {
+		copied := c.Neurons[id]
+		if copied == n {
+			t.Fatalf("%s: expected a copy, got the live neuron", id)
+		}
+		v, cv := reflect.ValueOf(n).Elem(), reflect.ValueOf(copied).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() && !reflect.DeepEqual(v.Field(i).Interface(), cv.Field(i).Interface()) {
+				t.Errorf("%s: field %s not copied", id, v.Type().Field(i).Name)
+			}
+		}
+	}
+	copied := c.Synapses[syn.ID]
+	if copied == syn || copied.Weight != 0.4 || copied.Type != SynapseAssociative || copied.FromID != a.ID {
+		t.Errorf("expected a copy of the synapse, got %+v", copied)
+	}
+
+	// Later changes to the matrix leave the copy alone.
+	syn.Strengthen(0.5)
+	delete(m.Neurons, a.ID)
+	if copied.Weight != 0.4 || c.Neurons[a.ID] == nil {
+		t.Error("expected the snapshot unaffected by later changes")
+	}
+}
@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// schedule decides which indexes a daemon's pass visits. Disabled, every
+// pass visits every index; enabled, each index is visited on its own
+// schedule, by its write rate (see core.AdaptiveScheduleConfig), and the
+// daemon wakes every MinInterval to find the indexes that are due.
+type schedule struct {
+	mu      sync.Mutex
+	cfg     core.AdaptiveScheduleConfig
+	indexes map[core.IndexID]*indexSchedule
+}
+
+// indexSchedule is one index's visits. nextDue and writeRate are as of
+// the last pass that considered the index.
+type indexSchedule struct {
+	lastVisit  time.Time
+	lastWrites uint64
+	visits     uint64
+	nextDue    time.Time
+	writeRate  float64 // writes per minute since lastVisit
+}
+
+// ScheduleStatus is an adaptive daemon's schedule as reported by
+// GET /admin/daemons.
+type ScheduleStatus struct {
+	MinInterval     string          `json:"minInterval"`
+	MaxInterval     string          `json:"maxInterval"`
+	WriteRateTarget float64         `json:"writeRateTarget"`
+	Indexes         []IndexSchedule `json:"indexes"`
+}
+
+// IndexSchedule is one index's place in an adaptive schedule.
+type IndexSchedule struct {
+	IndexID   core.IndexID `json:"indexId"`
+	LastVisit time.Time    `json:"lastVisit"`
+	NextDue   time.Time    `json:"nextDue"`
+	WriteRate float64      `json:"writeRate"` // writes per minute since the last visit
+	Visits    uint64       `json:"visits"`
+}
+
+func newSchedule() *schedule {
+	return &schedule{cfg: core.DefaultAdaptiveSchedule(), indexes: make(map[core.IndexID]*indexSchedule)}
+}
+
+func (s *schedule) set(cfg core.AdaptiveScheduleConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	if !cfg.Enabled {
+		clear(s.indexes)
+	}
+}
+
+// wait returns how long the daemon sleeps between passes: base, or
+// MinInterval when the schedule is enabled.
+func (s *schedule) wait(base time.Duration) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.Enabled {
+		return s.cfg.MinInterval
+	}
+	return base
+}
+
+// visit reports whether a pass at now visits indexID, whose worker has
+// counted writes writes, and records the visit if so. base is the
+// daemon's interval. An index seen for the first time is visited.
+func (s *schedule) visit(indexID core.IndexID, writes uint64, now time.Time, base time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cfg.Enabled {
+		return true
+	}
+	is, ok := s.indexes[indexID]
+	if !ok {
+		s.indexes[indexID] = &indexSchedule{lastVisit: now, lastWrites: writes, visits: 1, nextDue: now.Add(s.cfg.MaxInterval)}
+		return true
+	}
+	if writes < is.lastWrites {
+		// The worker was reloaded and counts from zero.
+		is.lastWrites = 0
+	}
+	elapsed := now.Sub(is.lastVisit)
+	is.writeRate = 0
+	if elapsed > 0 {
+		is.writeRate = float64(writes-is.lastWrites) / elapsed.Minutes()
+	}
+	is.nextDue = is.lastVisit.Add(s.interval(is.writeRate, base))
+	if now.Before(is.nextDue) {
+		return false
+	}
+	is.lastVisit, is.lastWrites = now, writes
+	is.visits++
+	is.nextDue = now.Add(s.interval(is.writeRate, base))
+	return true
+}
+
+// interval is the time between visits for an index writing rate writes
+// per minute: base at WriteRateTarget, scaled inversely to the rate and
+// kept within MinInterval and MaxInterval.
+func (s *schedule) interval(rate float64, base time.Duration) time.Duration {
+	if rate <= 0 {
+		return s.cfg.MaxInterval
+	}
+	d := time.Duration(float64(base) * s.cfg.WriteRateTarget / rate)
+	return max(s.cfg.MinInterval, min(d, s.cfg.MaxInterval))
+}
+
+// retain forgets the indexes a pass did not see, e.g. evicted ones.
+func (s *schedule) retain(seen []core.IndexID) {
+	keep := make(map[core.IndexID]bool, len(seen))
+	for _, id := range seen {
+		keep[id] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.indexes {
+		if !keep[id] {
+			delete(s.indexes, id)
+		}
+	}
+}
+
+// status returns the schedule with its indexes by ID, or nil when it is
+// disabled.
+func (s *schedule) status() *ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cfg.Enabled {
+		return nil
+	}
+	st := &ScheduleStatus{
+		MinInterval:     s.cfg.MinInterval.String(),
+		MaxInterval:     s.cfg.MaxInterval.String(),
+		WriteRateTarget: s.cfg.WriteRateTarget,
+		Indexes:         make([]IndexSchedule, 0, len(s.indexes)),
+	}
+	for id, is := range s.indexes {
+		st.Indexes = append(st.Indexes, IndexSchedule{
+			IndexID:   id,
+			LastVisit: is.lastVisit,
+			NextDue:   is.nextDue,
+			WriteRate: is.writeRate,
+			Visits:    is.visits,
+		})
+	}
+	sort.Slice(st.Indexes, func(i, j int) bool { return st.Indexes[i].IndexID < st.Indexes[j].IndexID })
+	return st
+}
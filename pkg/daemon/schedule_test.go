@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// pruneVisits runs a prune pass every minute for minutes, writing
+// busyRate neurons a minute to the "busy" index and one every quietEvery
+// minutes to the "quiet" one, and returns how many passes visited each.
+func pruneVisits(t *testing.T, dm *DaemonManager, pool *concurrency.WorkerPool, clock *fakeClock, minutes, busyRate, quietEvery int) map[core.IndexID]int {
+	t.Helper()
+	visits := map[core.IndexID]int{}
+	write := func(indexID core.IndexID, n int) {
+		worker, err := pool.GetOrCreate(indexID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			if _, err := worker.Submit(&concurrency.Operation{
+				Type:    concurrency.OpWrite,
+				Payload: concurrency.AddNeuronRequest{Content: fmt.Sprintf("%s note %d", indexID, i)},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ops := func(indexID core.IndexID) uint64 {
+		worker, _ := pool.Get(indexID)
+		return worker.Stats()["ops_processed"].(uint64)
+	}
+	for minute := 0; minute < minutes; minute++ {
+		write("busy", busyRate)
+		if minute%quietEvery == 0 {
+			write("quiet", 1)
+		}
+		before := map[core.IndexID]uint64{"busy": ops("busy"), "quiet": ops("quiet")}
+		if _, err := dm.prunePass(); err != nil {
+			t.Fatal(err)
+		}
+		for id, n := range before {
+			if ops(id) > n {
+				visits[id]++
+			}
+		}
+		clock.Advance(time.Minute)
+	}
+	return visits
+}
+
+func TestAdaptiveSchedule_VisitsFollowWriteRate(t *testing.T) {
+	dm, pool, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+	defer pool.Shutdown()
+
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dm.now = clock.Now
+	adaptive := core.AdaptiveScheduleConfig{Enabled: true, MinInterval: time.Minute, MaxInterval: 30 * time.Minute, WriteRateTarget: 1}
+	dm.SetAdaptiveSchedules(core.AdaptiveScheduleConfig{}, core.AdaptiveScheduleConfig{}, adaptive)
+	if got := daemonNamed(t, dm, "prune").wait(); got != time.Minute {
+		t.Errorf("an adaptive daemon should wake every min interval, got %v", got)
+	}
+
+	// Busy: 4 writes a minute against a target of 1 at the 10m interval
+	// is a visit every 2.5 minutes. Quiet: one write an hour waits the
+	// 30m maximum.
+	visits := pruneVisits(t, dm, pool, clock, 90, 4, 60)
+	if visits["busy"] < 25 || visits["busy"] > 45 {
+		t.Errorf("expected the busy index visited every 2-3 minutes, got %d visits in 90", visits["busy"])
+	}
+	if visits["quiet"] < 3 || visits["quiet"] > 4 {
+		t.Errorf("expected the quiet index visited every 30 minutes, got %d visits in 90", visits["quiet"])
+	}
+
+	st := statusOf(t, dm, "prune").Schedule
+	if st == nil || len(st.Indexes) != 2 || st.MaxInterval != "30m0s" {
+		t.Fatalf("expected both indexes in the prune schedule, got %+v", st)
+	}
+	busy, quiet := st.Indexes[0], st.Indexes[1]
+	if busy.IndexID != "busy" || busy.Visits != uint64(visits["busy"]) || quiet.Visits != uint64(visits["quiet"]) {
+		t.Errorf("status visits do not match the passes: %+v", st.Indexes)
+	}
+	if due := quiet.NextDue.Sub(quiet.LastVisit); due != 30*time.Minute {
+		t.Errorf("expected the quiet index next due at the max interval, got %v", due)
+	}
+	if due := busy.NextDue.Sub(busy.LastVisit); due < time.Minute || due > 5*time.Minute {
+		t.Errorf("expected the busy index next due within minutes, got %v", due)
+	}
+	if statusOf(t, dm, "decay").Schedule != nil {
+		t.Error("a fixed-interval daemon should report no schedule")
+	}
+
+	// Disabled, every pass visits every index again.
+	dm.SetAdaptiveSchedules(core.AdaptiveScheduleConfig{}, core.AdaptiveScheduleConfig{}, core.DefaultAdaptiveSchedule())
+	visits = pruneVisits(t, dm, pool, clock, 10, 4, 60)
+	if visits["busy"] != 10 || visits["quiet"] != 10 {
+		t.Errorf("expected fixed-interval visits to every index, got %v", visits)
+	}
+	if got := daemonNamed(t, dm, "prune").wait(); got != dm.getPruneInterval() {
+		t.Errorf("a fixed daemon should wait its interval, got %v", got)
+	}
+	if statusOf(t, dm, "prune").Schedule != nil {
+		t.Error("a disabled schedule should not be reported")
+	}
+}
+
+func TestAdaptiveSchedule_Interval(t *testing.T) {
+	s := newSchedule()
+	s.set(core.AdaptiveScheduleConfig{Enabled: true, MinInterval: time.Minute, MaxInterval: time.Hour, WriteRateTarget: 10})
+	for rate, want := range map[float64]time.Duration{
+		0:    time.Hour,
+		10:   10 * time.Minute,
+		20:   5 * time.Minute,
+		1000: time.Minute,
+		0.1:  time.Hour,
+	} {
+		if got := s.interval(rate, 10*time.Minute); got != want {
+			t.Errorf("rate %v: got %v, want %v", rate, got, want)
+		}
+	}
+
+	// A reloaded worker counts writes from zero again.
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.visit("idx", 500, at, 10*time.Minute)
+	if !s.visit("idx", 200, at.Add(time.Minute), 10*time.Minute) {
+		t.Error("200 writes in a minute should be due")
+	}
+	s.retain(nil)
+	if len(s.status().Indexes) != 0 {
+		t.Error("retain should forget unseen indexes")
+	}
+}
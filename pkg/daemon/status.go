@@ -0,0 +1,312 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// degradedAfter is how many intervals a daemon may go without a successful
+// pass before it is reported degraded.
+const degradedAfter = 3
+
+// daemon is one background loop: every interval it runs pass, which returns
+// how many items it processed. Run results are recorded for Status.
+type daemon struct {
+	name     string
+	interval func() time.Duration
+	pass     func() (int, error)
+	onStop   func()    // run once the loop exits, if set
+	schedule *schedule // per-index schedule, if the daemon has one
+	// pauseExempt daemons keep running while the manager is paused, so
+	// writes are still flushed to disk and embedded.
+	pauseExempt bool
+
+	// Guarded by DaemonManager.statusMu.
+	running             bool
+	lastStart           time.Time
+	lastDuration        time.Duration
+	lastSuccess         time.Time
+	lastError           string
+	lastErrorAt         time.Time
+	consecutiveFailures int
+	lastItems           int
+	runs                uint64
+	failures            uint64
+}
+
+// DaemonError is the last failure of a daemon.
+type DaemonError struct {
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// Daemon states reported by DaemonStatus.State.
+const (
+	StateRunning = "running"
+	StatePaused  = "paused"
+	StateStopped = "stopped"
+)
+
+// DaemonStatus is a daemon's run history as reported by GET /admin/daemons.
+type DaemonStatus struct {
+	Name                string       `json:"name"`
+	Interval            string       `json:"interval"`
+	Running             bool         `json:"running"` // the daemon's loop is alive
+	State               string       `json:"state"`   // StateRunning, StatePaused or StateStopped
+	LastStart           *time.Time   `json:"lastStart,omitempty"`
+	LastDuration        string       `json:"lastDuration,omitempty"` // of the last pass
+	LastSuccess         *time.Time   `json:"lastSuccess,omitempty"`
+	LastError           *DaemonError `json:"lastError,omitempty"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	ItemsProcessed      int          `json:"itemsProcessed"` // in the last run
+	Runs                uint64       `json:"runs"`
+	Failures            uint64       `json:"failures"`
+	// Degraded is set once the daemon has gone 3 intervals without a
+	// successful pass since its last success, or since the manager started
+	// or was last resumed. Paused daemons are never degraded; persist and
+	// embed are never paused.
+	Degraded bool `json:"degraded"`
+	// Compaction is reported for the consolidate daemon once content
+	// compaction is enabled.
+	Compaction *CompactionStats `json:"compaction,omitempty"`
+	// Schedule is reported for a daemon scheduled per index, with when
+	// each index is next due.
+	Schedule *ScheduleStatus `json:"schedule,omitempty"`
+}
+
+// CompactionStats counts the content compaction done by consolidation
+// passes: in the last pass and since the manager was created.
+type CompactionStats struct {
+	LastRunNeurons    int    `json:"lastRunNeurons"`
+	LastRunBytesSaved int64  `json:"lastRunBytesSaved"`
+	TotalNeurons      uint64 `json:"totalNeurons"`
+	TotalBytesSaved   int64  `json:"totalBytesSaved"`
+}
+
+func (c *CompactionStats) record(r engine.CompactReport) {
+	c.LastRunNeurons = r.Compacted
+	c.LastRunBytesSaved = r.BytesSaved
+	c.TotalNeurons += uint64(r.Compacted)
+	c.TotalBytesSaved += r.BytesSaved
+}
+
+// loop runs d every interval until the manager stops, skipping passes
+// while the manager is paused unless d is pauseExempt.
+func (dm *DaemonManager) loop(d *daemon) {
+	defer dm.wg.Done()
+	defer dm.setRunning(d, false)
+
+	for dm.waitInterval(d.wait()) {
+		if d.pauseExempt || !dm.Paused() {
+			dm.runPass(d)
+		}
+	}
+	if d.onStop != nil {
+		d.onStop()
+	}
+}
+
+// wait returns how long d sleeps before its next pass.
+func (d *daemon) wait() time.Duration {
+	if d.schedule != nil {
+		return d.schedule.wait(d.interval())
+	}
+	return d.interval()
+}
+
+func (dm *DaemonManager) setRunning(d *daemon, running bool) {
+	dm.statusMu.Lock()
+	defer dm.statusMu.Unlock()
+	d.running = running
+}
+
+// runPass runs one pass of d and records its outcome. A panicking pass is
+// recorded as a failure and does not take the daemon down.
+func (dm *DaemonManager) runPass(d *daemon) {
+	dm.statusMu.Lock()
+	d.lastStart = dm.now()
+	dm.statusMu.Unlock()
+
+	items, err := func() (items int, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return d.pass()
+	}()
+
+	dm.statusMu.Lock()
+	defer dm.statusMu.Unlock()
+	now := dm.now()
+	d.lastDuration = now.Sub(d.lastStart)
+	d.runs++
+	d.lastItems = items
+	if err != nil {
+		d.failures++
+		d.consecutiveFailures++
+		d.lastError = err.Error()
+		d.lastErrorAt = now
+		log.Printf("⚠ %s daemon pass failed (%d in a row): %v", d.name, d.consecutiveFailures, err)
+		return
+	}
+	d.consecutiveFailures = 0
+	d.lastSuccess = now
+}
+
+// Status returns every daemon's run status, in run order.
+func (dm *DaemonManager) Status() []DaemonStatus {
+	dm.statusMu.Lock()
+	defer dm.statusMu.Unlock()
+	now := dm.now()
+	out := make([]DaemonStatus, 0, len(dm.daemons))
+	for _, d := range dm.daemons {
+		interval := d.interval()
+		st := DaemonStatus{
+			Name:                d.name,
+			Interval:            interval.String(),
+			Running:             d.running,
+			State:               StateRunning,
+			LastStart:           timeOrNil(d.lastStart),
+			LastSuccess:         timeOrNil(d.lastSuccess),
+			ConsecutiveFailures: d.consecutiveFailures,
+			ItemsProcessed:      d.lastItems,
+			Runs:                d.runs,
+			Failures:            d.failures,
+		}
+		switch {
+		case !d.running:
+			st.State = StateStopped
+		case dm.paused && !d.pauseExempt:
+			st.State = StatePaused
+		}
+		if d.runs > 0 {
+			st.LastDuration = d.lastDuration.String()
+		}
+		if d.lastError != "" {
+			st.LastError = &DaemonError{Message: d.lastError, At: d.lastErrorAt}
+		}
+		if d.name == "consolidate" && (dm.getCompactContent().Enabled || dm.compaction.TotalNeurons > 0) {
+			compaction := dm.compaction
+			st.Compaction = &compaction
+		}
+		if d.schedule != nil {
+			st.Schedule = d.schedule.status()
+		}
+		since := d.lastSuccess
+		if since.IsZero() {
+			since = dm.started
+		}
+		if since.Before(dm.resumed) {
+			since = dm.resumed
+		}
+		st.Degraded = st.State != StatePaused && !since.IsZero() && now.Sub(since) > degradedAfter*interval
+		out = append(out, st)
+	}
+	return out
+}
+
+// Degraded returns the names of degraded daemons, sorted.
+func (dm *DaemonManager) Degraded() []string {
+	var names []string
+	for _, st := range dm.Status() {
+		if st.Degraded {
+			names = append(names, st.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteMetrics writes the daemon status in the Prometheus text exposition
+// format. Timestamps are Unix seconds, 0 when the event has not happened.
+func (dm *DaemonManager) WriteMetrics(w io.Writer) error {
+	statuses := dm.Status()
+	metrics := []struct {
+		name, typ, help string
+		value           func(DaemonStatus) float64
+	}{
+		{"qubicdb_daemon_up", "gauge", "Whether the daemon's loop is running.", func(st DaemonStatus) float64 { return boolValue(st.Running) }},
+		{"qubicdb_daemon_paused", "gauge", "Whether the daemon's passes are paused.", func(st DaemonStatus) float64 { return boolValue(st.State == StatePaused) }},
+		{"qubicdb_daemon_degraded", "gauge", "Whether the daemon has gone 3 intervals without a successful pass.", func(st DaemonStatus) float64 { return boolValue(st.Degraded) }},
+		{"qubicdb_daemon_interval_seconds", "gauge", "Configured interval between passes.", func(st DaemonStatus) float64 {
+			d, _ := time.ParseDuration(st.Interval)
+			return d.Seconds()
+		}},
+		{"qubicdb_daemon_last_start_timestamp_seconds", "gauge", "Start time of the last pass.", func(st DaemonStatus) float64 { return unixOrZero(st.LastStart) }},
+		{"qubicdb_daemon_last_duration_seconds", "gauge", "Duration of the last pass.", func(st DaemonStatus) float64 {
+			d, _ := time.ParseDuration(st.LastDuration)
+			return d.Seconds()
+		}},
+		{"qubicdb_daemon_last_success_timestamp_seconds", "gauge", "End time of the last successful pass.", func(st DaemonStatus) float64 { return unixOrZero(st.LastSuccess) }},
+		{"qubicdb_daemon_last_error_timestamp_seconds", "gauge", "End time of the last failed pass.", func(st DaemonStatus) float64 {
+			if st.LastError == nil {
+				return 0
+			}
+			return unixOrZero(&st.LastError.At)
+		}},
+		{"qubicdb_daemon_consecutive_failures", "gauge", "Failed passes since the last successful one.", func(st DaemonStatus) float64 { return float64(st.ConsecutiveFailures) }},
+		{"qubicdb_daemon_items_processed", "gauge", "Items processed by the last pass.", func(st DaemonStatus) float64 { return float64(st.ItemsProcessed) }},
+		{"qubicdb_daemon_runs_total", "counter", "Passes run.", func(st DaemonStatus) float64 { return float64(st.Runs) }},
+		{"qubicdb_daemon_failures_total", "counter", "Passes that failed or panicked.", func(st DaemonStatus) float64 { return float64(st.Failures) }},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ); err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			value := strconv.FormatFloat(m.value(st), 'f', -1, 64)
+			if _, err := fmt.Fprintf(w, "%s{daemon=%q} %s\n", m.name, st.Name, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// passErrors collects the per-index failures of one pass. A worker that
+// stopped mid-pass, e.g. because it was evicted, is not a failure.
+type passErrors struct {
+	errs []error
+}
+
+func (e *passErrors) add(indexID core.IndexID, err error) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	e.errs = append(e.errs, fmt.Errorf("index %s: %w", indexID, err))
+}
+
+func (e *passErrors) err() error {
+	return errors.Join(e.errs...)
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func unixOrZero(t *time.Time) float64 {
+	if t == nil {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,270 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// daemonNamed returns dm's daemon called name.
+func daemonNamed(t *testing.T, dm *DaemonManager, name string) *daemon {
+	t.Helper()
+	for _, d := range dm.daemons {
+		if d.name == name {
+			return d
+		}
+	}
+	t.Fatalf("no daemon %q", name)
+	return nil
+}
+
+func statusOf(t *testing.T, dm *DaemonManager, name string) DaemonStatus {
+	t.Helper()
+	for _, st := range dm.Status() {
+		if st.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("no status for daemon %q", name)
+	return DaemonStatus{}
+}
+
+// fakeClock is a settable clock for DaemonManager.now.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestDaemonStatus_RecordsFailuresAndSurvivesPanics(t *testing.T) {
+	dm, _, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+
+	daemonNamed(t, dm, "decay").pass = func() (int, error) { panic("nil matrix") }
+	daemonNamed(t, dm, "prune").pass = func() (int, error) { return 2, errors.New("disk full") }
+	daemonNamed(t, dm, "consolidate").pass = func() (int, error) { return 7, nil }
+	dm.SetIntervals(5*time.Millisecond, 5*time.Millisecond, 5*time.Millisecond, time.Hour, time.Hour)
+	dm.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for statusOf(t, dm, "decay").Runs < 3 || statusOf(t, dm, "prune").Runs < 3 || statusOf(t, dm, "consolidate").Runs < 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("daemons did not keep running: %+v", dm.Status())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	decay := statusOf(t, dm, "decay")
+	if !decay.Running || decay.LastError == nil || !strings.Contains(decay.LastError.Message, "panic: nil matrix") {
+		t.Errorf("a panicking pass should be recorded as a failure: %+v", decay)
+	}
+	if decay.LastSuccess != nil || decay.ConsecutiveFailures < 3 || decay.Failures != decay.Runs {
+		t.Errorf("every decay pass should count as failed: %+v", decay)
+	}
+	prune := statusOf(t, dm, "prune")
+	if prune.LastError == nil || prune.LastError.Message != "disk full" || prune.ItemsProcessed != 2 {
+		t.Errorf("unexpected prune status: %+v", prune)
+	}
+	consolidate := statusOf(t, dm, "consolidate")
+	if consolidate.LastSuccess == nil || consolidate.LastError != nil || consolidate.ItemsProcessed != 7 || consolidate.ConsecutiveFailures != 0 {
+		t.Errorf("unexpected consolidate status: %+v", consolidate)
+	}
+
+	dm.Stop()
+	for _, st := range dm.Status() {
+		if st.Running {
+			t.Errorf("%s should not be running after Stop", st.Name)
+		}
+	}
+}
+
+func TestDaemonStatus_DegradedAfterThreeIntervals(t *testing.T) {
+	dm, _, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dm.now = clock.Now
+	dm.SetIntervals(time.Minute, time.Hour, time.Hour, time.Hour, time.Hour)
+	dm.SetEmbedInterval(time.Hour)
+	decay := daemonNamed(t, dm, "decay")
+	fail := errors.New("worker queue stuck")
+	decay.pass = func() (int, error) { return 0, fail }
+
+	if statusOf(t, dm, "decay").Degraded {
+		t.Fatal("a manager that never started should not report degraded daemons")
+	}
+	dm.Start()
+	defer dm.Stop()
+
+	// Never succeeded: measured from the start of the manager.
+	clock.Advance(3 * time.Minute)
+	if statusOf(t, dm, "decay").Degraded {
+		t.Error("exactly 3 intervals should not be degraded yet")
+	}
+	clock.Advance(time.Second)
+	if !statusOf(t, dm, "decay").Degraded {
+		t.Error("a daemon without a success for over 3 intervals should be degraded")
+	}
+
+	decay.pass = func() (int, error) { return 1, nil }
+	dm.runPass(decay)
+	if statusOf(t, dm, "decay").Degraded || len(dm.Degraded()) != 0 {
+		t.Error("a successful pass should clear degraded")
+	}
+
+	decay.pass = func() (int, error) { return 0, fail }
+	for i := 0; i < 4; i++ {
+		clock.Advance(time.Minute)
+		dm.runPass(decay)
+	}
+	st := statusOf(t, dm, "decay")
+	if !st.Degraded || st.ConsecutiveFailures != 4 || st.LastError.Message != fail.Error() {
+		t.Errorf("expected degraded after 4 failing minutes: %+v", st)
+	}
+	if got := dm.Degraded(); len(got) != 1 || got[0] != "decay" {
+		t.Errorf("expected only decay degraded, got %v", got)
+	}
+}
+
+func TestDaemonStatus_WriteMetrics(t *testing.T) {
+	dm, _, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+
+	clock := &fakeClock{t: time.Unix(1767225600, 0)}
+	dm.now = clock.Now
+	prune := daemonNamed(t, dm, "prune")
+	prune.pass = func() (int, error) { return 12, nil }
+	dm.runPass(prune)
+
+	var out strings.Builder
+	if err := dm.WriteMetrics(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE qubicdb_daemon_runs_total counter",
+		`qubicdb_daemon_runs_total{daemon="prune"} 1`,
+		`qubicdb_daemon_items_processed{daemon="prune"} 12`,
+		`qubicdb_daemon_last_success_timestamp_seconds{daemon="prune"} 1767225600`,
+		`qubicdb_daemon_last_success_timestamp_seconds{daemon="decay"} 0`,
+		`qubicdb_daemon_interval_seconds{daemon="decay"} 60`,
+		`qubicdb_daemon_up{daemon="embed"} 0`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestDaemonManager_AddAfterStart(t *testing.T) {
+	dm, _, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+
+	dm.Start()
+	defer dm.Stop()
+	ran := make(chan struct{}, 1)
+	dm.Add("extra", func() time.Duration { return time.Millisecond }, func() (int, error) {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return 1, nil
+	})
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("a daemon added after Start should run")
+	}
+	if st := statusOf(t, dm, "extra"); !st.Running {
+		t.Errorf("the added daemon should be reported running, got %+v", st)
+	}
+}
+
+func TestDaemonManager_PauseResume(t *testing.T) {
+	dm, _, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dm.now = clock.Now
+	dm.SetIntervals(time.Millisecond, time.Hour, time.Hour, time.Millisecond, time.Hour)
+	dm.SetEmbedInterval(time.Hour)
+	var runs, persists atomic.Int64
+	decay := daemonNamed(t, dm, "decay")
+	decay.pass = func() (int, error) {
+		runs.Add(1)
+		clock.Advance(time.Millisecond)
+		return 1, nil
+	}
+	daemonNamed(t, dm, "persist").pass = func() (int, error) {
+		persists.Add(1)
+		return 0, nil
+	}
+	waitRuns := func(n int64) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for runs.Load() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d decay passes, got %d", n, runs.Load())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	dm.Start()
+	defer dm.Stop()
+	waitRuns(1)
+
+	if !dm.Pause() || dm.Pause() {
+		t.Error("Pause should report whether the daemons were running")
+	}
+	time.Sleep(5 * time.Millisecond) // let a pass under way finish
+	before, persisted := runs.Load(), persists.Load()
+	time.Sleep(20 * time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for persists.Load() < persisted+3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if runs.Load() != before {
+		t.Errorf("a paused daemon ran %d passes", runs.Load()-before)
+	}
+	if persists.Load() < persisted+3 {
+		t.Error("persist should keep flushing while the daemons are paused")
+	}
+	if st := statusOf(t, dm, "persist"); st.State != StateRunning {
+		t.Errorf("persist should be reported running while paused, got %+v", st)
+	}
+	st := statusOf(t, dm, "decay")
+	if st.State != StatePaused || !st.Running || st.LastDuration != "1ms" {
+		t.Errorf("expected a paused daemon with its last duration, got %+v", st)
+	}
+	clock.Advance(time.Hour)
+	if st := statusOf(t, dm, "decay"); st.Degraded {
+		t.Error("a paused daemon should not be degraded")
+	}
+
+	if !dm.Resume() || dm.Resume() {
+		t.Error("Resume should report whether the daemons were paused")
+	}
+	if st := statusOf(t, dm, "decay"); st.State != StateRunning || st.Degraded {
+		t.Errorf("a resumed daemon should be running and not yet degraded, got %+v", st)
+	}
+	waitRuns(before + 3)
+}
@@ -2,12 +2,15 @@ package daemon
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/concurrency"
 	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
 	"github.com/qubicDB/qubicdb/pkg/lifecycle"
 	"github.com/qubicDB/qubicdb/pkg/persistence"
 )
@@ -24,8 +27,27 @@ type DaemonManager struct {
 	pruneInterval       time.Duration
 	persistInterval     time.Duration
 	reorgInterval       time.Duration
+	embedInterval       time.Duration
+	compactContent      core.CompactContentConfig
 	intervalMu          sync.RWMutex
 
+	// Per-index schedules of the daemons that can run adaptively.
+	decaySchedule       *schedule
+	consolidateSchedule *schedule
+	pruneSchedule       *schedule
+
+	// compaction counts what consolidation passes compacted; guarded by
+	// statusMu.
+	compaction CompactionStats
+
+	// daemons run in this order; their run status is guarded by statusMu.
+	daemons  []*daemon
+	started  time.Time
+	paused   bool      // passes are skipped while set
+	resumed  time.Time // when paused was last cleared
+	statusMu sync.Mutex
+	now      func() time.Time
+
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
@@ -39,7 +61,7 @@ func NewDaemonManager(
 ) *DaemonManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &DaemonManager{
+	dm := &DaemonManager{
 		pool:                pool,
 		lifecycle:           lm,
 		store:               store,
@@ -48,121 +70,285 @@ func NewDaemonManager(
 		pruneInterval:       10 * time.Minute,
 		persistInterval:     1 * time.Minute,
 		reorgInterval:       15 * time.Minute,
+		embedInterval:       30 * time.Second,
+		decaySchedule:       newSchedule(),
+		consolidateSchedule: newSchedule(),
+		pruneSchedule:       newSchedule(),
+		now:                 time.Now,
 		ctx:                 ctx,
 		cancel:              cancel,
 	}
+	dm.daemons = []*daemon{
+		{name: "decay", interval: dm.getDecayInterval, pass: dm.decayPass, schedule: dm.decaySchedule},
+		{name: "consolidate", interval: dm.getConsolidateInterval, pass: dm.consolidatePass, schedule: dm.consolidateSchedule},
+		{name: "prune", interval: dm.getPruneInterval, pass: dm.prunePass, schedule: dm.pruneSchedule},
+		// Final persist on shutdown
+		{name: "persist", interval: dm.getPersistInterval, pass: dm.persistPass, onStop: func() { dm.pool.PersistAll() }, pauseExempt: true},
+		{name: "reorg", interval: dm.getReorgInterval, pass: dm.reorgPass},
+		{name: "embed", interval: dm.getEmbedInterval, pass: dm.embedPass, pauseExempt: true},
+	}
+	return dm
 }
 
 // Start starts all daemon workers
 func (dm *DaemonManager) Start() {
-	dm.wg.Add(5)
+	dm.statusMu.Lock()
+	dm.started = dm.now()
+	daemons := append([]*daemon(nil), dm.daemons...)
+	for _, d := range daemons {
+		d.running = true
+	}
+	dm.statusMu.Unlock()
 
-	go dm.decayDaemon()
-	go dm.consolidateDaemon()
-	go dm.pruneDaemon()
-	go dm.persistDaemon()
-	go dm.reorgDaemon()
+	dm.wg.Add(len(daemons))
+	for _, d := range daemons {
+		go dm.loop(d)
+	}
 
 	log.Println("🧠 Daemon manager started")
 }
 
-// Stop stops all daemons gracefully
-func (dm *DaemonManager) Stop() {
-	dm.cancel()
-	dm.wg.Wait()
-	log.Println("🧠 Daemon manager stopped")
+// Add registers a daemon owned by another package. It runs pass every
+// interval and is reported by Status like the built-in daemons; if the
+// manager has already started, so does the new daemon.
+func (dm *DaemonManager) Add(name string, interval func() time.Duration, pass func() (int, error)) {
+	d := &daemon{name: name, interval: interval, pass: pass}
+
+	dm.statusMu.Lock()
+	started := !dm.started.IsZero()
+	d.running = started
+	dm.daemons = append(dm.daemons, d)
+	dm.statusMu.Unlock()
+
+	if started {
+		dm.wg.Add(1)
+		go dm.loop(d)
+	}
 }
 
-// decayDaemon applies continuous energy decay
-func (dm *DaemonManager) decayDaemon() {
-	defer dm.wg.Done()
+// Pause stops the maintenance daemons (decay, consolidate, prune, reorg
+// and those added with Add) from running passes until Resume; their loops
+// keep running and skip each pass that comes due. A pass already under way
+// finishes. Persist and embed keep running, so writes made while paused
+// still reach disk. It reports whether the daemons were running.
+func (dm *DaemonManager) Pause() bool {
+	dm.statusMu.Lock()
+	defer dm.statusMu.Unlock()
+	if dm.paused {
+		return false
+	}
+	dm.paused = true
+	log.Println("⏸ Daemons paused")
+	return true
+}
 
-	for dm.waitInterval(dm.getDecayInterval()) {
-		dm.pool.ForEach(func(indexID core.IndexID, worker *concurrency.BrainWorker) {
-			// Only decay active/idle brains, not sleeping ones
-			state := dm.lifecycle.GetState(indexID)
-			if state == core.StateActive || state == core.StateIdle {
-				worker.SubmitAsync(&concurrency.Operation{Type: concurrency.OpDecay})
-			}
-		})
+// Resume lets paused daemons run passes again, from their next interval.
+// It reports whether the daemons were paused.
+func (dm *DaemonManager) Resume() bool {
+	dm.statusMu.Lock()
+	defer dm.statusMu.Unlock()
+	if !dm.paused {
+		return false
 	}
+	dm.paused = false
+	dm.resumed = dm.now()
+	log.Println("▶ Daemons resumed")
+	return true
 }
 
-// consolidateDaemon moves mature memories to deeper layers
-func (dm *DaemonManager) consolidateDaemon() {
-	defer dm.wg.Done()
+// Paused reports whether the daemons are paused.
+func (dm *DaemonManager) Paused() bool {
+	dm.statusMu.Lock()
+	defer dm.statusMu.Unlock()
+	return dm.paused
+}
 
-	for dm.waitInterval(dm.getConsolidateInterval()) {
-		// Consolidate sleeping brains (like real sleep consolidation)
-		sleeping := dm.lifecycle.GetSleepingUsers()
-		for _, indexID := range sleeping {
-			worker, err := dm.pool.Get(indexID)
-			if err == nil && worker != nil {
-				result, _ := worker.Submit(&concurrency.Operation{
-					Type: concurrency.OpConsolidate,
-				})
-				if count, ok := result.(int); ok && count > 0 {
-					log.Printf("🌙 Index %s: consolidated %d neurons", indexID, count)
-				}
-			}
+// RunPass runs one pass of the daemon called name now, outside its
+// schedule, and records it in Status like a scheduled pass. It runs even
+// while the daemons are paused.
+func (dm *DaemonManager) RunPass(name string) error {
+	dm.statusMu.Lock()
+	var target *daemon
+	for _, d := range dm.daemons {
+		if d.name == name {
+			target = d
+			break
 		}
 	}
+	dm.statusMu.Unlock()
+	if target == nil {
+		return fmt.Errorf("unknown daemon %q", name)
+	}
+	dm.runPass(target)
+	return nil
 }
 
-// pruneDaemon removes dead neurons and synapses
-func (dm *DaemonManager) pruneDaemon() {
-	defer dm.wg.Done()
+// Stop stops all daemons gracefully
+func (dm *DaemonManager) Stop() {
+	dm.cancel()
+	dm.wg.Wait()
+	log.Println("🧠 Daemon manager stopped")
+}
 
-	for dm.waitInterval(dm.getPruneInterval()) {
-		dm.pool.ForEach(func(indexID core.IndexID, worker *concurrency.BrainWorker) {
-			// Use worker operation to safely prune
+// decayPass applies continuous energy decay
+func (dm *DaemonManager) decayPass() (int, error) {
+	submitted := 0
+	now, base := dm.now(), dm.getDecayInterval()
+	var seen []core.IndexID
+	dm.pool.ForEach(func(indexID core.IndexID, worker *concurrency.BrainWorker) {
+		seen = append(seen, indexID)
+		// Only decay active/idle brains, not sleeping ones
+		state := dm.lifecycle.GetState(indexID)
+		if (state == core.StateActive || state == core.StateIdle) && dm.decaySchedule.visit(indexID, worker.Writes(), now, base) {
+			worker.SubmitAsync(&concurrency.Operation{
+				Type:     concurrency.OpDecay,
+				Priority: concurrency.PriorityBackground,
+			})
+			submitted++
+		}
+	})
+	dm.decaySchedule.retain(seen)
+	return submitted, nil
+}
+
+// consolidatePass moves mature memories to deeper layers and, when
+// daemons.consolidate.compactContent is enabled, compacts the content of
+// old, faded ones.
+func (dm *DaemonManager) consolidatePass() (int, error) {
+	var errs passErrors
+	total := 0
+	compact := dm.getCompactContent()
+	var compacted engine.CompactReport
+	now, base := dm.now(), dm.getConsolidateInterval()
+	// Consolidate sleeping brains (like real sleep consolidation)
+	sleeping := dm.lifecycle.GetSleepingUsers()
+	for _, indexID := range sleeping {
+		worker, err := dm.pool.Get(indexID)
+		if err == nil && worker != nil && dm.consolidateSchedule.visit(indexID, worker.Writes(), now, base) {
 			result, err := worker.Submit(&concurrency.Operation{
-				Type: concurrency.OpPrune,
+				Type:     concurrency.OpConsolidate,
+				Priority: concurrency.PriorityBackground,
 			})
-			if err == nil {
-				if count, ok := result.(int); ok && count > 0 {
-					log.Printf("🧹 Index %s: pruned %d dead neurons", indexID, count)
-				}
+			errs.add(indexID, err)
+			if count, ok := result.(int); ok && count > 0 {
+				total += count
+				log.Printf("🌙 Index %s: consolidated %d neurons", indexID, count)
 			}
-		})
+			if !compact.Enabled || worker.AppendOnly() {
+				continue
+			}
+			result, err = worker.Submit(&concurrency.Operation{
+				Type:     concurrency.OpCompactContent,
+				Priority: concurrency.PriorityBackground,
+				Payload:  concurrency.CompactContentRequest{Config: compact, Now: dm.now()},
+			})
+			errs.add(indexID, err)
+			if report, ok := result.(engine.CompactReport); ok && report.Compacted > 0 {
+				compacted.Compacted += report.Compacted
+				compacted.BytesSaved += report.BytesSaved
+				log.Printf("🗜 Index %s: compacted %d neurons, %d bytes", indexID, report.Compacted, report.BytesSaved)
+			}
+		}
+	}
+	dm.consolidateSchedule.retain(sleeping)
+	if compact.Enabled {
+		dm.statusMu.Lock()
+		dm.compaction.record(compacted)
+		dm.statusMu.Unlock()
 	}
+	return total, errs.err()
 }
 
-// persistDaemon periodically saves active matrices
-func (dm *DaemonManager) persistDaemon() {
-	defer dm.wg.Done()
-
-	for dm.waitInterval(dm.getPersistInterval()) {
-		// Persist all modified matrices
-		dm.pool.ForEach(func(indexID core.IndexID, worker *concurrency.BrainWorker) {
-			if err := dm.store.SaveAsync(worker.Matrix()); err != nil {
-				log.Printf("persist daemon: async save failed for %s: %v", indexID, err)
-			}
+// prunePass removes dead neurons and synapses
+func (dm *DaemonManager) prunePass() (int, error) {
+	var errs passErrors
+	total := 0
+	now, base := dm.now(), dm.getPruneInterval()
+	var seen []core.IndexID
+	dm.pool.ForEach(func(indexID core.IndexID, worker *concurrency.BrainWorker) {
+		seen = append(seen, indexID)
+		if !dm.pruneSchedule.visit(indexID, worker.Writes(), now, base) {
+			return
+		}
+		// Use worker operation to safely prune
+		result, err := worker.Submit(&concurrency.Operation{
+			Type:     concurrency.OpPrune,
+			Priority: concurrency.PriorityBackground,
 		})
-		dm.store.FlushAll()
-	}
-
-	// Final persist on shutdown
-	dm.pool.PersistAll()
+		errs.add(indexID, err)
+		if err == nil {
+			if count, ok := result.(int); ok && count > 0 {
+				total += count
+				log.Printf("🧹 Index %s: pruned %d dead neurons", indexID, count)
+			}
+		}
+	})
+	dm.pruneSchedule.retain(seen)
+	return total, errs.err()
 }
 
-// reorgDaemon reorganizes spatial positions (sleep-like reorg)
-func (dm *DaemonManager) reorgDaemon() {
-	defer dm.wg.Done()
+// persistPass periodically saves active matrices, skipping diverged ones
+func (dm *DaemonManager) persistPass() (int, error) {
+	var errs passErrors
+	saved := 0
+	// Persist all modified matrices
+	dm.pool.ForEach(func(indexID core.IndexID, worker *concurrency.BrainWorker) {
+		err := dm.pool.PersistAsync(indexID, worker)
+		if errors.Is(err, core.ErrIndexDiverged) {
+			return
+		}
+		if err != nil {
+			log.Printf("persist daemon: async save failed for %s: %v", indexID, err)
+			errs.add(indexID, err)
+			return
+		}
+		saved++
+	})
+	dm.store.FlushAll()
+	return saved, errs.err()
+}
 
-	for dm.waitInterval(dm.getReorgInterval()) {
-		// Only reorg sleeping brains
-		sleeping := dm.lifecycle.GetSleepingUsers()
-		for _, indexID := range sleeping {
-			worker, err := dm.pool.Get(indexID)
-			if err == nil && worker != nil {
-				// Use worker operation for thread-safe reorg
-				worker.SubmitAsync(&concurrency.Operation{
-					Type: concurrency.OpReorg,
-				})
-			}
+// reorgPass reorganizes spatial positions (sleep-like reorg)
+func (dm *DaemonManager) reorgPass() (int, error) {
+	submitted := 0
+	// Only reorg sleeping brains
+	sleeping := dm.lifecycle.GetSleepingUsers()
+	for _, indexID := range sleeping {
+		worker, err := dm.pool.Get(indexID)
+		if err == nil && worker != nil {
+			// Use worker operation for thread-safe reorg
+			worker.SubmitAsync(&concurrency.Operation{
+				Type:     concurrency.OpReorg,
+				Priority: concurrency.PriorityBackground,
+			})
+			submitted++
 		}
 	}
+	return submitted, nil
+}
+
+// embedPendingBatch caps how many neurons one worker embeds per pass, so
+// a backlog does not hold up its writes for long.
+const embedPendingBatch = 64
+
+// embedPass embeds neurons that were written while the vector layer was
+// failing.
+func (dm *DaemonManager) embedPass() (int, error) {
+	var errs passErrors
+	total := 0
+	dm.pool.ForEach(func(indexID core.IndexID, worker *concurrency.BrainWorker) {
+		result, err := worker.Submit(&concurrency.Operation{
+			Type:     concurrency.OpEmbedPending,
+			Priority: concurrency.PriorityBackground,
+			Payload:  embedPendingBatch,
+		})
+		errs.add(indexID, err)
+		if count, ok := result.(int); ok && count > 0 {
+			total += count
+			log.Printf("🧬 Index %s: embedded %d pending neurons", indexID, count)
+		}
+	})
+	return total, errs.err()
 }
 
 func (dm *DaemonManager) waitInterval(interval time.Duration) bool {
@@ -206,6 +392,18 @@ func (dm *DaemonManager) getReorgInterval() time.Duration {
 	return dm.reorgInterval
 }
 
+func (dm *DaemonManager) getCompactContent() core.CompactContentConfig {
+	dm.intervalMu.RLock()
+	defer dm.intervalMu.RUnlock()
+	return dm.compactContent
+}
+
+func (dm *DaemonManager) getEmbedInterval() time.Duration {
+	dm.intervalMu.RLock()
+	defer dm.intervalMu.RUnlock()
+	return dm.embedInterval
+}
+
 func clamp(val, min, max float64) float64 {
 	if val < min {
 		return min
@@ -229,8 +427,44 @@ func (dm *DaemonManager) SetIntervals(
 	dm.reorgInterval = reorg
 }
 
+// SetAdaptiveSchedules configures the per-index scheduling of the decay,
+// consolidate and prune daemons. It takes effect from each daemon's next
+// wait; disabling a schedule forgets its indexes.
+func (dm *DaemonManager) SetAdaptiveSchedules(decay, consolidate, prune core.AdaptiveScheduleConfig) {
+	dm.decaySchedule.set(decay)
+	dm.consolidateSchedule.set(consolidate)
+	dm.pruneSchedule.set(prune)
+}
+
+// SetEmbedInterval configures how often pending embeddings are retried.
+// Values <= 0 are ignored.
+func (dm *DaemonManager) SetEmbedInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	dm.intervalMu.Lock()
+	defer dm.intervalMu.Unlock()
+	dm.embedInterval = interval
+}
+
+// SetCompactContent configures content compaction by consolidation
+// passes. It takes effect from the next pass.
+func (dm *DaemonManager) SetCompactContent(cfg core.CompactContentConfig) {
+	dm.intervalMu.Lock()
+	defer dm.intervalMu.Unlock()
+	dm.compactContent = cfg
+}
+
+// Compaction returns what consolidation passes have compacted.
+func (dm *DaemonManager) Compaction() CompactionStats {
+	dm.statusMu.Lock()
+	defer dm.statusMu.Unlock()
+	return dm.compaction
+}
+
 // Stats returns daemon statistics
 func (dm *DaemonManager) Stats() map[string]any {
+	compaction := dm.Compaction()
 	dm.intervalMu.RLock()
 	defer dm.intervalMu.RUnlock()
 	return map[string]any{
@@ -239,5 +473,8 @@ func (dm *DaemonManager) Stats() map[string]any {
 		"prune_interval":       dm.pruneInterval.String(),
 		"persist_interval":     dm.persistInterval.String(),
 		"reorg_interval":       dm.reorgInterval.String(),
+		"embed_interval":       dm.embedInterval.String(),
+		"compact_content":      dm.compactContent.Enabled,
+		"compaction":           compaction,
 	}
 }
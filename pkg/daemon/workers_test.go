@@ -1,7 +1,16 @@
 package daemon
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -192,6 +201,153 @@ func TestDaemonConsolidateIntegration(t *testing.T) {
 	}
 }
 
+func TestDaemonCompactsContent(t *testing.T) {
+	dm, pool, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+
+	dm.SetIntervals(time.Hour, 20*time.Millisecond, time.Hour, time.Hour, time.Hour)
+	dm.SetCompactContent(core.CompactContentConfig{
+		Enabled: true, MinAge: 24 * time.Hour, MaxEnergy: 0.2, KeepChars: 32, RecentAccess: time.Hour,
+	})
+
+	worker, _ := pool.GetOrCreate("compact-user")
+	var faded, pinned []*core.Neuron
+	for i := 0; i < 20; i++ {
+		// Random words, so the saving is not hidden by compression.
+		rng := rand.New(rand.NewSource(int64(i)))
+		var words []string
+		for len(words) < 120 {
+			words = append(words, strconv.FormatInt(rng.Int63(), 36))
+		}
+		content := fmt.Sprintf("memory %d opens plainly and then rambles: %s", i, strings.Join(words, " "))
+		result, err := worker.Submit(&concurrency.Operation{
+			Type:    concurrency.OpWrite,
+			Payload: concurrency.AddNeuronRequest{Content: content},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := result.(*core.Neuron)
+		n.CreatedAt = time.Now().Add(-48 * time.Hour)
+		n.LastFiredAt = time.Now().Add(-2 * time.Hour)
+		n.Energy = 0.05
+		if i%5 == 0 {
+			n.Pinned = true
+			pinned = append(pinned, n)
+		} else {
+			faded = append(faded, n)
+		}
+	}
+	store := dm.store
+	if err := store.Save(worker.Matrix()); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(tmpDir, "data", "compact-user.nrdb")
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lm.RecordActivity("compact-user")
+	lm.ForceSleep("compact-user")
+	dm.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for dm.Compaction().TotalNeurons < uint64(len(faded)) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	dm.Stop()
+
+	stats := dm.Compaction()
+	if stats.TotalNeurons != uint64(len(faded)) || stats.TotalBytesSaved <= 0 {
+		t.Fatalf("expected %d compacted neurons, got %+v", len(faded), stats)
+	}
+	for _, st := range dm.Status() {
+		if st.Name == "consolidate" && (st.Compaction == nil || st.Compaction.TotalNeurons != stats.TotalNeurons) {
+			t.Errorf("the consolidate daemon should report compaction, got %+v", st.Compaction)
+		}
+	}
+	for _, n := range faded {
+		if !core.IsCompacted(n) {
+			t.Errorf("%s should be compacted", n.ID)
+		}
+	}
+	for _, n := range pinned {
+		if core.IsCompacted(n) {
+			t.Errorf("pinned %s must be exempt", n.ID)
+		}
+	}
+
+	if err := store.Save(worker.Matrix()); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size()/2 {
+		t.Errorf("compaction should shrink the data file: %d bytes before, %d after", before.Size(), after.Size())
+	}
+}
+
+// flakyEmbedder fails while down is set.
+type flakyEmbedder struct {
+	down atomic.Bool
+}
+
+func (e *flakyEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	if e.down.Load() {
+		return nil, errors.New("llama backend wedged")
+	}
+	return []float32{1, 0, 0}, nil
+}
+func (e *flakyEmbedder) EmbedDim() int { return 3 }
+func (e *flakyEmbedder) Close() error  { return nil }
+
+func TestDaemonEmbedsPendingNeurons(t *testing.T) {
+	dm, pool, lm, tmpDir := setupTestDaemon(t)
+	defer os.RemoveAll(tmpDir)
+	defer lm.Stop()
+
+	embedder := &flakyEmbedder{}
+	embedder.down.Store(true)
+	pool.SetVectorizer(embedder, 0.6)
+	dm.SetIntervals(time.Hour, time.Hour, time.Hour, time.Hour, time.Hour)
+	dm.SetEmbedInterval(20 * time.Millisecond)
+	if dm.Stats()["embed_interval"] != "20ms" {
+		t.Errorf("expected embed_interval 20ms, got %v", dm.Stats()["embed_interval"])
+	}
+
+	worker, _ := pool.GetOrCreate("test-user")
+	result, err := worker.Submit(&concurrency.Operation{
+		Type:    concurrency.OpWrite,
+		Payload: concurrency.AddNeuronRequest{Content: "written while the model was down"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := result.(*core.Neuron)
+
+	embedder.down.Store(false)
+	dm.Start()
+	defer dm.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats, _ := worker.Submit(&concurrency.Operation{Type: concurrency.OpGetStats})
+		if stats.(map[string]any)["pending_embeddings"] == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the pending neuron was never embedded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n.EmbedPending || len(n.Embedding) != 3 {
+		t.Errorf("expected an embedding, got pending=%v dims=%d", n.EmbedPending, len(n.Embedding))
+	}
+}
+
 func TestClamp(t *testing.T) {
 	tests := []struct {
 		val, min, max, expected float64
@@ -211,3 +367,55 @@ func TestClamp(t *testing.T) {
 		}
 	}
 }
+
+// TestBackgroundTasks_StartStopCyclesNoGoroutineLeak runs the server's
+// background machinery — daemons, the store's flush and checksum tasks
+// and the lifecycle monitor — through full start/stop cycles and checks
+// no goroutine outlives them.
+func TestBackgroundTasks_StartStopCyclesNoGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for range 5 {
+		dm, pool, lm, tmpDir := setupTestDaemon(t)
+		dm.SetIntervals(time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond)
+		tasks := core.NewTaskRunner()
+		tasks.Add(lm.MonitorTask(time.Millisecond))
+		tasks.Add(dm.store.FlushTask(time.Millisecond))
+		tasks.Add(dm.store.ChecksumValidationTask(time.Millisecond))
+		dm.Start()
+		tasks.Start()
+
+		worker, err := pool.GetOrCreate("leak")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worker.Submit(&concurrency.Operation{Type: concurrency.OpWrite, Payload: concurrency.AddNeuronRequest{Content: "cycle"}}); err != nil {
+			t.Fatal(err)
+		}
+		lm.RecordActivity("leak")
+		time.Sleep(5 * time.Millisecond)
+
+		dm.Stop()
+		if err := tasks.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for _, st := range tasks.Status() {
+			if st.State != core.TaskStopped || st.Runs == 0 {
+				t.Errorf("expected %s to have run and stopped, got %+v", st.Name, st)
+			}
+		}
+		lm.Stop()
+		if err := pool.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+		os.RemoveAll(tmpDir)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines before, %d after:\n%s", before, runtime.NumGoroutine(), buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
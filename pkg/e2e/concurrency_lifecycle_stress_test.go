@@ -1,6 +1,7 @@
 package e2e
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
@@ -35,7 +36,10 @@ func TestStressConcurrentLifecycleManyUsersNoDeadlock(t *testing.T) {
 	pool := concurrency.NewWorkerPool(store, core.DefaultBounds())
 	lm := lifecycle.NewManager()
 	lm.SetThresholds(40*time.Millisecond, 120*time.Millisecond, 280*time.Millisecond)
-	lm.StartMonitor(10 * time.Millisecond)
+	tasks := core.NewTaskRunner()
+	tasks.Add(lm.MonitorTask(10 * time.Millisecond))
+	tasks.Start()
+	defer tasks.Stop(context.Background())
 
 	dm := daemon.NewDaemonManager(pool, lm, store)
 	dm.SetIntervals(
@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"bufio"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	canonicalModule = "github.com/qubicDB/qubicdb"
+	legacyModule    = "github.com/denizumutdereli/qubicdb"
+)
+
+// moduleRoot walks up from the working directory to the directory holding
+// go.mod and returns it with the declared module path.
+func moduleRoot(t *testing.T) (string, string) {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, modulePath(t, dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatal("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+// modulePath returns the module path declared by dir/go.mod.
+func modulePath(t *testing.T, dir string) string {
+	t.Helper()
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(path), `"`)
+		}
+	}
+	t.Fatalf("%s/go.mod has no module directive", dir)
+	return ""
+}
+
+// TestModulePathConsistency fails if go.mod declares anything but the
+// canonical module path, if any Go file imports the legacy path, or if an
+// import under the canonical path names a package that does not exist.
+// The legacy compatibility module under legacy/ is the one place the old
+// path may appear.
+func TestModulePathConsistency(t *testing.T) {
+	root, module := moduleRoot(t)
+	if module != canonicalModule {
+		t.Fatalf("go.mod declares module %q, want %q", module, canonicalModule)
+	}
+
+	fset := token.NewFileSet()
+	packages := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			if path == filepath.Join(root, "legacy") {
+				if module := modulePath(t, path); module != legacyModule {
+					t.Errorf("legacy/go.mod declares module %q, want %q", module, legacyModule)
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		for _, spec := range file.Imports {
+			imp, _ := strconv.Unquote(spec.Path.Value)
+			if imp == legacyModule || strings.HasPrefix(imp, legacyModule+"/") {
+				t.Errorf("%s imports legacy path %q; use %q", rel, imp, canonicalModule+strings.TrimPrefix(imp, legacyModule))
+				continue
+			}
+			if sub, ok := strings.CutPrefix(imp, canonicalModule+"/"); ok {
+				packages++
+				if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(sub))); err != nil || !info.IsDir() {
+					t.Errorf("%s imports %q, which is not a package in this module", rel, imp)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", root, err)
+	}
+	if packages == 0 {
+		t.Error("found no imports of the canonical module path")
+	}
+}
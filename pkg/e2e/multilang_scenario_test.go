@@ -12,6 +12,7 @@ import (
 	"github.com/qubicDB/qubicdb/pkg/daemon"
 	"github.com/qubicDB/qubicdb/pkg/lifecycle"
 	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/testutil"
 )
 
 // TestMultiLanguageConversation tests memory with English, Turkish, German content
@@ -37,47 +38,16 @@ func TestMultiLanguageConversation(t *testing.T) {
 	t.Log("    MULTI-LANGUAGE CONVERSATION TEST (EN/TR/DE)")
 	t.Log("============================================================\n")
 
-	// Simulate a real conversation with mixed languages
-	conversation := []struct {
-		role    string
-		content string
-		lang    string
-	}{
-		// English context
-		{"user", "My name is Alex and I work at TechCorp as a senior developer", "EN"},
-		{"assistant", "Nice to meet you Alex! I'll remember you work at TechCorp as a senior developer.", "EN"},
-		{"user", "I prefer using TypeScript and React for frontend development", "EN"},
-		{"assistant", "Got it! TypeScript and React are great choices for frontend.", "EN"},
-
-		// Turkish context
-		{"user", "Benim favori takımım Fenerbahçe ve her hafta maçlarını izlerim", "TR"},
-		{"assistant", "Fenerbahçe taraftarı olduğunuzu not ettim! Her hafta maç izlemeniz güzel.", "TR"},
-		{"user", "Istanbul'da Kadıköy'de yaşıyorum, deniz kenarında güzel bir semt", "TR"},
-		{"assistant", "Kadıköy gerçekten güzel bir semt, deniz manzarası muhteşem olmalı.", "TR"},
-
-		// German context
-		{"user", "Ich lerne gerade Deutsch und finde die Sprache sehr interessant", "DE"},
-		{"assistant", "Das ist toll! Deutsch zu lernen ist eine gute Entscheidung.", "DE"},
-		{"user", "Mein Lieblingsessen ist Schnitzel mit Kartoffelsalat", "DE"},
-		{"assistant", "Schnitzel mit Kartoffelsalat ist ein Klassiker der deutschen Küche!", "DE"},
-
-		// Mixed context - technical
-		{"user", "For the new project, I need to set up a Kubernetes cluster on AWS", "EN"},
-		{"assistant", "I can help with Kubernetes on AWS. Do you prefer EKS or self-managed?", "EN"},
-		{"user", "Projede ayrıca Redis cache kullanmamız gerekiyor performans için", "TR"},
-		{"assistant", "Redis cache iyi bir seçim, özellikle yüksek trafik senaryolarında.", "TR"},
-	}
-
 	t.Log("--- Phase 1: Recording conversation ---\n")
-	for _, msg := range conversation {
+	// Simulate a real conversation with mixed languages
+	for _, msg := range testutil.MultilangConversation {
 		// Store both user and assistant messages as the LLM would
-		content := fmt.Sprintf("[%s] %s: %s", msg.lang, msg.role, msg.content)
 		result, _ := worker.Submit(&concurrency.Operation{
 			Type:    concurrency.OpWrite,
-			Payload: concurrency.AddNeuronRequest{Content: content},
+			Payload: concurrency.AddNeuronRequest{Content: msg.Prefixed()},
 		})
 		n := result.(*core.Neuron)
-		t.Logf("  [%s] %s (energy: %.2f)", msg.lang, truncateStr(msg.content, 50), n.Energy)
+		t.Logf("  [%s] %s (energy: %.2f)", msg.Lang, truncateStr(msg.Text, 50), n.Energy)
 		lm.RecordActivity("multilang-user")
 	}
 
@@ -24,15 +24,9 @@ func TestOrganicGrowthPerUser(t *testing.T) {
 	defer pool.Shutdown()
 
 	// User A: Heavy user - adds many neurons
-	workerA, _ := pool.GetOrCreate("user-heavy")
-	for i := 0; i < 100; i++ {
-		workerA.Submit(&concurrency.Operation{
-			Type: concurrency.OpWrite,
-			Payload: concurrency.AddNeuronRequest{
-				Content: fmt.Sprintf("Heavy user content %d about programming and development", i),
-			},
-		})
-	}
+	workerA := populate(t, pool, store, "user-heavy", 100, func(i int) string {
+		return fmt.Sprintf("Heavy user content %d about programming and development", i)
+	})
 
 	// User B: Light user - adds few neurons
 	workerB, _ := pool.GetOrCreate("user-light")
@@ -85,26 +85,16 @@ func TestPerformanceOrganicLifecycle(t *testing.T) {
 
 	// Phase 2: Create users and add neurons
 	indexID := core.IndexID("lifecycle-test")
-	worker, _ := pool.GetOrCreate(indexID)
-	lm.RecordActivity(indexID)
 
 	// Add neurons
 	t.Log("\n--- PHASE 1: Neuron Insertion ---")
-	for i := 0; i < 100; i++ {
-		result, _ := worker.Submit(&concurrency.Operation{
-			Type: concurrency.OpWrite,
-			Payload: concurrency.AddNeuronRequest{
-				Content: fmt.Sprintf("Lifecycle test content %d about programming and development", i),
-			},
-		})
-		n := result.(*core.Neuron)
-		if i == 0 {
-			t.Logf("First neuron energy: %.2f, depth: %d", n.Energy, n.Depth)
-		}
-	}
+	worker := populate(t, pool, store, indexID, 100, func(i int) string {
+		return fmt.Sprintf("Lifecycle test content %d about programming and development", i)
+	})
+	lm.RecordActivity(indexID)
 
 	stats1, _ := worker.Submit(&concurrency.Operation{Type: concurrency.OpGetStats})
-	t.Logf("After 100 neurons: %v", stats1)
+	t.Logf("After insertion: %v", stats1)
 	memAfterAdd := getMemStats()
 	t.Logf("Memory: Alloc=%s (+%s)", formatBytes(memAfterAdd.Alloc), formatBytes(memAfterAdd.Alloc-memBefore.Alloc))
 
@@ -380,17 +370,9 @@ func BenchmarkNeuronOperations(b *testing.B) {
 	pool := concurrency.NewWorkerPool(store, core.DefaultBounds())
 	defer pool.Shutdown()
 
-	worker, _ := pool.GetOrCreate("bench-user")
-
-	// Populate
-	for i := 0; i < 100; i++ {
-		worker.Submit(&concurrency.Operation{
-			Type: concurrency.OpWrite,
-			Payload: concurrency.AddNeuronRequest{
-				Content: fmt.Sprintf("Benchmark content %d", i),
-			},
-		})
-	}
+	worker := populate(b, pool, store, "bench-user", 100, func(i int) string {
+		return fmt.Sprintf("Benchmark content %d", i)
+	})
 
 	b.Run("AddNeuron", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
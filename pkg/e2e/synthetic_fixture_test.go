@@ -0,0 +1,49 @@
+package e2e
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+	"github.com/qubicDB/qubicdb/pkg/testutil"
+)
+
+// syntheticNeurons is the neuron count set by QUBICDB_E2E_SYNTHETIC. When
+// set, scenario tests that call populate load a synthetic brain of that
+// size instead of writing their own content, so they can run at scale.
+func syntheticNeurons() int {
+	n, _ := strconv.Atoi(os.Getenv("QUBICDB_E2E_SYNTHETIC"))
+	return n
+}
+
+// populate returns the worker of indexID after filling it: with a
+// synthetic brain when QUBICDB_E2E_SYNTHETIC is set, otherwise by writing
+// content(i) for i below count through the worker.
+func populate(tb testing.TB, pool *concurrency.WorkerPool, store *persistence.Store, indexID core.IndexID, count int, content func(i int) string) *concurrency.BrainWorker {
+	tb.Helper()
+	if n := syntheticNeurons(); n > 0 {
+		brain := testutil.NewSyntheticBrain(1, testutil.SyntheticOptions{IndexID: indexID, Neurons: n})
+		worker, err := brain.LoadPool(pool, store)
+		if err != nil {
+			tb.Fatalf("load synthetic brain into %s: %v", indexID, err)
+		}
+		return worker
+	}
+
+	worker, err := pool.GetOrCreate(indexID)
+	if err != nil {
+		tb.Fatalf("GetOrCreate(%s): %v", indexID, err)
+	}
+	for i := 0; i < count; i++ {
+		if _, err := worker.Submit(&concurrency.Operation{
+			Type:    concurrency.OpWrite,
+			Payload: concurrency.AddNeuronRequest{Content: content(i)},
+		}); err != nil {
+			tb.Fatalf("write %d to %s: %v", i, indexID, err)
+		}
+	}
+	return worker
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// Anchored search. A candidate's relatedness to the anchor is a weighted
+// mean of its synaptic proximity, the metadata it shares with the anchor
+// and, when both are embedded by the same model, their cosine similarity;
+// the vector share is left out of the mean for neurons without one.
+const (
+	anchorMaxHops        = 3
+	anchorProximityShare = 0.5
+	anchorMetadataShare  = 0.3
+	anchorVectorShare    = 0.2
+)
+
+// anchorHopWeights discount proximity by hops from the anchor.
+var anchorHopWeights = [anchorMaxHops]float64{1, 0.5, 0.25}
+
+// SetAnchor makes Search rank candidates by their relatedness to the
+// neuron id as well as by the query, with weight the relatedness share of
+// the score (0-1). Without a query the ranking is by relatedness alone.
+// The anchor itself is left out of the results unless include is set.
+func (s *Searcher) SetAnchor(id core.NeuronID, weight float64, include bool) {
+	s.anchorID = id
+	s.anchorWeight = weight
+	s.includeAnchor = include
+}
+
+// synapseWeight returns the weight of the synapse between a and b, in
+// either direction, or 0.3 when the adjacency has no synapse behind it.
+// The caller holds the matrix read lock.
+func (s *Searcher) synapseWeight(a, b core.NeuronID) float64 {
+	if synapse := s.synapseBetween(a, b); synapse != nil {
+		return synapse.Weight
+	}
+	return 0.3
+}
+
+// synapseBetween returns the synapse between a and b, in either direction,
+// or nil. The caller holds the matrix read lock.
+func (s *Searcher) synapseBetween(a, b core.NeuronID) *core.Synapse {
+	if synapse, ok := s.matrix.Synapses[core.NewSynapseID(a, b)]; ok {
+		return synapse
+	}
+	return s.matrix.Synapses[core.NewSynapseID(b, a)]
+}
+
+// anchorProximity spreads from anchor for up to anchorMaxHops synapses.
+// A neuron's proximity is the weight of the hop it is first reached at
+// times the weight of the strongest synapse reaching it there. The caller
+// holds the matrix read lock.
+func (s *Searcher) anchorProximity(anchor *core.Neuron) map[core.NeuronID]float64 {
+	proximity := make(map[core.NeuronID]float64)
+	reached := map[core.NeuronID]int{anchor.ID: 0}
+	current := []core.NeuronID{anchor.ID}
+	for hop := 1; hop <= anchorMaxHops && len(current) > 0; hop++ {
+		var next []core.NeuronID
+		for _, from := range current {
+			for _, to := range s.matrix.Adjacency[from] {
+				if _, ok := s.matrix.Neurons[to]; !ok {
+					continue
+				}
+				if at, ok := reached[to]; !ok {
+					reached[to] = hop
+					next = append(next, to)
+				} else if at < hop {
+					continue
+				}
+				weight := min(max(s.synapseWeight(from, to), 0), 1)
+				proximity[to] = max(proximity[to], anchorHopWeights[hop-1]*weight)
+			}
+		}
+		current = next
+	}
+	return proximity
+}
+
+// anchorMetadataOverlap is the share of the anchor's metadata keys that n
+// holds with the same value. Internal keys (leading underscore) do not
+// count.
+func anchorMetadataOverlap(anchor, n *core.Neuron) float64 {
+	keys, shared := 0, 0
+	for k, v := range anchor.Metadata {
+		if strings.HasPrefix(k, "_") {
+			continue
+		}
+		keys++
+		if nv, ok := n.Metadata[k]; ok && fmt.Sprint(nv) == fmt.Sprint(v) {
+			shared++
+		}
+	}
+	if keys == 0 {
+		return 0
+	}
+	return float64(shared) / float64(keys)
+}
+
+// anchorRelatedness scores n's relatedness to anchor from 0 to 1.
+func anchorRelatedness(anchor, n *core.Neuron, proximity float64) float64 {
+	score := anchorProximityShare*proximity + anchorMetadataShare*anchorMetadataOverlap(anchor, n)
+	total := anchorProximityShare + anchorMetadataShare
+	if len(anchor.Embedding) > 0 && len(anchor.Embedding) == len(n.Embedding) &&
+		anchor.EmbeddingModelName() == n.EmbeddingModelName() {
+		score += anchorVectorShare * max(0, vector.CosineSimilarity(anchor.Embedding, n.Embedding))
+		total += anchorVectorShare
+	}
+	return score / total
+}
+
+// blendAnchor rescores results, the query's direct hits, by relatedness to
+// the anchor: each neuron's score becomes (1-w) times its query score,
+// scaled to the best hit's, plus w times its relatedness. Related neurons
+// the query missed join the results; without a query (hasQuery false)
+// relatedness is the whole score. The caller holds the matrix read lock.
+func (s *Searcher) blendAnchor(results []SearchResult, hasQuery bool) []SearchResult {
+	anchor, ok := s.matrix.Neurons[s.anchorID]
+	if !ok {
+		return results
+	}
+	proximity := s.anchorProximity(anchor)
+
+	queryScores := make(map[core.NeuronID]float64, len(results))
+	best := 0.0
+	for _, r := range results {
+		queryScores[r.Neuron.ID] = r.Score
+		best = max(best, r.Score)
+	}
+
+	weight := s.anchorWeight
+	if !hasQuery {
+		weight = 1
+	}
+	blended := make([]SearchResult, 0, len(results))
+	for id, n := range s.matrix.Neurons {
+		if id == anchor.ID && !s.includeAnchor {
+			continue
+		}
+		q := 0.0
+		if best > 0 {
+			q = queryScores[id] / best
+		}
+		a := anchorRelatedness(anchor, n, proximity[id])
+		if id == anchor.ID {
+			a = 1
+		}
+		if q == 0 && (a == 0 || !HasRole(n, s.roles)) {
+			continue // roles are checked by scoreNeuron for query hits
+		}
+		if score := (1-weight)*q + weight*a; score > 0 {
+			blended = append(blended, SearchResult{Neuron: n, Score: score, Anchor: a})
+		}
+	}
+	return blended
+}
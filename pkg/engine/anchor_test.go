@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// anchorFixture builds an incident cluster around an anchor, linked by
+// synapses and sharing the anchor's metadata, next to notes that only
+// share its words.
+func anchorFixture(t *testing.T) (*core.Matrix, map[string]core.NeuronID) {
+	t.Helper()
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	ids := make(map[string]core.NeuronID)
+	add := func(name, content string, metadata map[string]string) {
+		n, err := e.AddNeuron(content, nil, metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = n.ID
+	}
+	incident := map[string]string{"incident": "INC-42"}
+	add("anchor", "payments outage postmortem", incident)
+	add("timeline", "timeline of the payments outage", incident)
+	add("fix", "rolled back the ledger migration", incident)
+	add("followup", "add an alert on ledger write latency", nil)
+	add("other1", "outage of the office wifi", nil)
+	add("other2", "postmortem template for outage reviews", nil)
+
+	link := func(a, b string, weight float64) {
+		syn := core.NewSynapse(ids[a], ids[b], weight)
+		m.Synapses[syn.ID] = syn
+		m.Adjacency[ids[a]] = append(m.Adjacency[ids[a]], ids[b])
+		m.Adjacency[ids[b]] = append(m.Adjacency[ids[b]], ids[a])
+	}
+	link("anchor", "timeline", 0.9)
+	link("anchor", "fix", 0.8)
+	link("fix", "followup", 0.7)
+	return m, ids
+}
+
+func TestSearcherAnchorWithoutQueryRanksByRelatedness(t *testing.T) {
+	m, ids := anchorFixture(t)
+	s := NewSearcher(m)
+	s.SetAnchor(ids["anchor"], 0.5, false)
+
+	results := s.Search("", 0, 10)
+	if len(results) != 3 {
+		t.Fatalf("expected the three linked neurons, got %d", len(results))
+	}
+	for i, want := range []string{"timeline", "fix", "followup"} {
+		if results[i].ID != ids[want] {
+			t.Errorf("result %d: expected %s, got %q", i, want, results[i].Content)
+		}
+	}
+	stats := s.Stats()
+	if stats.TopComponent != ComponentAnchor {
+		t.Errorf("expected the anchor to dominate, got %q", stats.TopComponent)
+	}
+	for _, b := range stats.Breakdowns {
+		if b.Anchor <= 0 {
+			t.Errorf("anchor-only results should carry their relatedness: %+v", b)
+		}
+	}
+}
+
+func TestSearcherAnchorReranksQueryHits(t *testing.T) {
+	m, ids := anchorFixture(t)
+
+	plain := NewSearcher(m)
+	if results := plain.Search("outage", 0, 10); len(results) != 4 {
+		t.Fatalf("expected four lexical hits, got %d", len(results))
+	}
+
+	s := NewSearcher(m)
+	s.SetAnchor(ids["anchor"], 0.7, false)
+	results := s.Search("outage", 0, 10)
+	if len(results) == 0 || results[0].ID != ids["timeline"] {
+		t.Fatalf("the linked hit should rank first, got %v", results)
+	}
+	rank := map[core.NeuronID]int{}
+	for i, n := range results {
+		rank[n.ID] = i
+	}
+	if _, ok := rank[ids["anchor"]]; ok {
+		t.Error("the anchor should be left out of the results")
+	}
+	if rank[ids["fix"]] > rank[ids["other1"]] {
+		t.Error("a neighbour of the anchor should outrank an unrelated lexical hit")
+	}
+
+	s.SetAnchor(ids["anchor"], 0.7, true)
+	if results := s.Search("outage", 0, 10); results[0].ID != ids["anchor"] {
+		t.Errorf("with include the anchor should rank first, got %q", results[0].Content)
+	}
+}
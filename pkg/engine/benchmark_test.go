@@ -163,6 +163,41 @@ func BenchmarkSearcherHybridScan5K(b *testing.B) {
 	}
 }
 
+// benchmarkSearchMode compares default and exact search on a matrix of
+// 20K neurons wired into a chain, where spread activation has work to do.
+func benchmarkSearchMode(b *testing.B, opts SearchOptions) {
+	m := core.NewMatrix("bench-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	var prev *core.Neuron
+	for i := 0; i < 20000; i++ {
+		// Inserted directly: AddNeuron's duplicate check makes setup quadratic.
+		n := core.NewNeuron(fmt.Sprintf("Incident %d postmortem about database failover and replication", i), m.CurrentDim)
+		m.Neurons[n.ID] = n
+		if prev != nil {
+			syn := core.NewSynapse(prev.ID, n.ID, 0.5)
+			m.Synapses[syn.ID] = syn
+			m.Adjacency[prev.ID] = append(m.Adjacency[prev.ID], n.ID)
+			m.Adjacency[n.ID] = append(m.Adjacency[n.ID], prev.ID)
+		}
+		prev = n
+	}
+	ctx := e.traceContext()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.PeekSearch(ctx, "database failover", 2, 10, nil, false, nil, opts)
+	}
+}
+
+func BenchmarkMatrixEngineSearchDefault20K(b *testing.B) {
+	benchmarkSearchMode(b, SearchOptions{})
+}
+
+func BenchmarkMatrixEngineSearchExact20K(b *testing.B) {
+	benchmarkSearchMode(b, SearchOptions{Mode: SearchModeExact})
+}
+
 func benchmarkEmbedding(dim int, seed int64) []float32 {
 	r := rand.New(rand.NewSource(seed))
 	v := make([]float32, dim)
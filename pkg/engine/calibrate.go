@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// calibrationBuckets is how many equal-width buckets of [0, 1] a
+// SimilarityDistribution's histogram has.
+const calibrationBuckets = 10
+
+// CalibrationOptions configures CalibrateSimilarity.
+type CalibrationOptions struct {
+	Pairs           int     // related and random pairs sampled, each
+	MinPairs        int     // fewest pairs of each kind a measure is calibrated from
+	MinWeight       float64 // weight a synapse needs for the neurons it links to count as related
+	TargetPrecision float64 // precision a threshold must reach against the random pairs
+	Seed            int64
+	Now             time.Time
+}
+
+// SimilarityCalibration is how alike an index's related neurons are
+// compared with random pairs of them, and the thresholds derived from it.
+// Vector is nil when no sampled pair was embedded by the same model.
+type SimilarityCalibration struct {
+	CalibratedAt    time.Time           `json:"calibratedAt"`
+	Neurons         int                 `json:"neurons"`
+	TargetPrecision float64             `json:"targetPrecision"`
+	Seed            int64               `json:"seed"`
+	Vector          *MeasureCalibration `json:"vector,omitempty"`
+	Lexical         *MeasureCalibration `json:"lexical"`
+}
+
+// MeasureCalibration is the calibration of one similarity measure:
+// embedding cosine or token Jaccard. Threshold is nil when too few pairs
+// were measured or no similarity separates related pairs from random ones
+// at the target precision; Precision and Recall are then 0.
+type MeasureCalibration struct {
+	Threshold *float64               `json:"threshold"`
+	Precision float64                `json:"precision"`
+	Recall    float64                `json:"recall"`
+	Related   SimilarityDistribution `json:"related"`
+	Random    SimilarityDistribution `json:"random"`
+}
+
+// SimilarityDistribution summarizes the similarities of a set of pairs.
+// Histogram counts them in ten equal-width buckets from 0 to 1.
+type SimilarityDistribution struct {
+	Pairs     int     `json:"pairs"`
+	Mean      float64 `json:"mean"`
+	P10       float64 `json:"p10"`
+	P50       float64 `json:"p50"`
+	P90       float64 `json:"p90"`
+	Histogram []int   `json:"histogram"`
+}
+
+// CalibrateSimilarity measures how alike related neurons are against
+// random pairs of neurons, with the cosine of their embeddings when the
+// same model embedded both and always with the Jaccard similarity of their
+// tokens, as conflict detection and co-fires compare them. Neurons are
+// related when a synapse of at least opts.MinWeight links them: linked
+// explicitly, or fired together often enough to strengthen the synapse
+// consecutive writes form. Synapses formed for their similarity are not
+// counted, as the thresholds would only confirm themselves. Up to
+// opts.Pairs related pairs are sampled, and as many random pairs not
+// related. Superseded neurons are left out.
+//
+// A measure's threshold is the lowest similarity at which related pairs
+// are told from random ones with opts.TargetPrecision: the share of
+// related pairs at or above it over that share plus the share of random
+// pairs at or above it, weighing both kinds equally whatever their
+// counts.
+func (e *MatrixEngine) CalibrateSimilarity(opts CalibrationOptions) *SimilarityCalibration {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+	m := e.matrix
+
+	live := make([]*core.Neuron, 0, len(m.Neurons))
+	for _, n := range m.Neurons {
+		if !IsSuperseded(n) {
+			live = append(live, n)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].ID < live[j].ID })
+
+	type pair struct{ a, b *core.Neuron }
+	key := func(a, b core.NeuronID) [2]core.NeuronID {
+		if a > b {
+			a, b = b, a
+		}
+		return [2]core.NeuronID{a, b}
+	}
+	isRelated := make(map[[2]core.NeuronID]bool)
+	var related []pair
+	synapseIDs := make([]core.SynapseID, 0, len(m.Synapses))
+	for id := range m.Synapses {
+		synapseIDs = append(synapseIDs, id)
+	}
+	sort.Slice(synapseIDs, func(i, j int) bool { return synapseIDs[i] < synapseIDs[j] })
+	for _, id := range synapseIDs {
+		syn := m.Synapses[id]
+		if weight, _, _ := syn.Snapshot(); weight < opts.MinWeight || syn.Type == core.SynapseSimilar {
+			continue
+		}
+		a, b := m.Neurons[syn.FromID], m.Neurons[syn.ToID]
+		if a == nil || b == nil || a == b || IsSuperseded(a) || IsSuperseded(b) || isRelated[key(a.ID, b.ID)] {
+			continue
+		}
+		isRelated[key(a.ID, b.ID)] = true
+		related = append(related, pair{a, b})
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	rng.Shuffle(len(related), func(i, j int) { related[i], related[j] = related[j], related[i] })
+	if len(related) > opts.Pairs {
+		related = related[:opts.Pairs]
+	}
+
+	var random []pair
+	if len(live) >= 2 {
+		drawn := make(map[[2]core.NeuronID]bool)
+		for tries := 0; len(random) < opts.Pairs && tries < 4*opts.Pairs; tries++ {
+			a, b := live[rng.Intn(len(live))], live[rng.Intn(len(live))]
+			k := key(a.ID, b.ID)
+			if a == b || isRelated[k] || drawn[k] {
+				continue
+			}
+			drawn[k] = true
+			random = append(random, pair{a, b})
+		}
+	}
+
+	tokens := make(map[core.NeuronID]map[string]bool)
+	tokensOf := func(n *core.Neuron) map[string]bool {
+		set, ok := tokens[n.ID]
+		if !ok {
+			set = tokenSet(n.Content)
+			tokens[n.ID] = set
+		}
+		return set
+	}
+	measure := func(pairs []pair) (cosine, lexical []float64) {
+		for _, p := range pairs {
+			lexical = append(lexical, jaccard(tokensOf(p.a), tokensOf(p.b)))
+			if embeddedAlike(p.a, p.b) {
+				cosine = append(cosine, max(0, vector.CosineSimilarity(p.a.Embedding, p.b.Embedding)))
+			}
+		}
+		return cosine, lexical
+	}
+	relatedCosine, relatedLexical := measure(related)
+	randomCosine, randomLexical := measure(random)
+
+	c := &SimilarityCalibration{
+		CalibratedAt:    opts.Now,
+		Neurons:         len(live),
+		TargetPrecision: opts.TargetPrecision,
+		Seed:            opts.Seed,
+		Lexical:         calibrateMeasure(relatedLexical, randomLexical, opts),
+	}
+	if len(relatedCosine) > 0 || len(randomCosine) > 0 {
+		c.Vector = calibrateMeasure(relatedCosine, randomCosine, opts)
+	}
+	return c
+}
+
+// calibrateMeasure summarizes the similarities of related and random pairs
+// under one measure and picks its threshold.
+func calibrateMeasure(related, random []float64, opts CalibrationOptions) *MeasureCalibration {
+	sort.Float64s(related)
+	sort.Float64s(random)
+	mc := &MeasureCalibration{Related: distribution(related), Random: distribution(random)}
+	if len(related) < max(opts.MinPairs, 1) || len(random) < max(opts.MinPairs, 1) {
+		return mc
+	}
+
+	// atOrAbove is the share of sorted at or above t.
+	atOrAbove := func(sorted []float64, t float64) float64 {
+		return float64(len(sorted)-sort.SearchFloat64s(sorted, t)) / float64(len(sorted))
+	}
+	for _, t := range related {
+		recall, falsePositives := atOrAbove(related, t), atOrAbove(random, t)
+		if precision := recall / (recall + falsePositives); precision >= opts.TargetPrecision {
+			t = math.Floor(t*1e4) / 1e4
+			mc.Threshold, mc.Precision, mc.Recall = &t, precision, recall
+			break
+		}
+	}
+	return mc
+}
+
+// distribution summarizes sorted similarities.
+func distribution(sorted []float64) SimilarityDistribution {
+	d := SimilarityDistribution{Pairs: len(sorted), Histogram: make([]int, calibrationBuckets)}
+	if len(sorted) == 0 {
+		return d
+	}
+	sum := 0.0
+	for _, s := range sorted {
+		sum += s
+		d.Histogram[min(int(s*calibrationBuckets), calibrationBuckets-1)]++
+	}
+	quantile := func(q float64) float64 {
+		return sorted[min(int(q*float64(len(sorted))), len(sorted)-1)]
+	}
+	d.Mean, d.P10, d.P50, d.P90 = sum/float64(len(sorted)), quantile(0.1), quantile(0.5), quantile(0.9)
+	return d
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// calibrationMatrix builds ten topics of four neurons each, chained by
+// synapses within their topic, with content from profile. Neurons are
+// embedded near their topic's axis when embed is set.
+func calibrationMatrix(profile func(rng *rand.Rand, topic, i int) string, embed bool) *core.Matrix {
+	m := newTestMatrix()
+	rng := rand.New(rand.NewSource(1))
+	for topic := 0; topic < 10; topic++ {
+		var prev core.NeuronID
+		for i := 0; i < 4; i++ {
+			id := core.NeuronID(fmt.Sprintf("t%d-n%d", topic, i))
+			n := &core.Neuron{ID: id, Content: profile(rng, topic, i), Energy: 1, Metadata: map[string]any{}}
+			if embed {
+				n.Embedding = make([]float32, 10)
+				for d := range n.Embedding {
+					n.Embedding[d] = float32(rng.Float64() * 0.3)
+				}
+				n.Embedding[topic] = 1
+			}
+			m.Neurons[id] = n
+			if prev != "" {
+				syn := core.NewSynapse(prev, id, 0.5)
+				m.Synapses[syn.ID] = syn
+			}
+			prev = id
+		}
+	}
+	return m
+}
+
+// terseFact is a three-word fact sharing two words with its topic.
+func terseFact(_ *rand.Rand, topic, i int) string {
+	return fmt.Sprintf("topic%dalpha topic%dbeta fact%dx%d", topic, topic, topic, i)
+}
+
+// longDocument shares ten words with its topic among thirty drawn from a
+// vocabulary every document uses.
+func longDocument(rng *rand.Rand, topic, i int) string {
+	words := make([]string, 0, 40)
+	for k := 0; k < 10; k++ {
+		words = append(words, fmt.Sprintf("topic%dword%d", topic, k))
+	}
+	for k := 0; k < 30; k++ {
+		words = append(words, fmt.Sprintf("common%d", rng.Intn(300)))
+	}
+	return strings.Join(words, " ")
+}
+
+func TestCalibrateSimilarity_ContentLength(t *testing.T) {
+	opts := CalibrationOptions{Pairs: 200, MinPairs: 10, MinWeight: 0.5, TargetPrecision: 0.9, Seed: 7, Now: time.Now()}
+	terse := NewMatrixEngine(calibrationMatrix(terseFact, true)).CalibrateSimilarity(opts)
+	long := NewMatrixEngine(calibrationMatrix(longDocument, false)).CalibrateSimilarity(opts)
+
+	for name, c := range map[string]*SimilarityCalibration{"terse": terse, "long": long} {
+		if c.Neurons != 40 || c.Lexical.Related.Pairs != 30 || c.Lexical.Random.Pairs != 200 {
+			t.Fatalf("%s: expected 30 related and 200 random pairs of 40 neurons, got %+v", name, c)
+		}
+		if c.Lexical.Threshold == nil || c.Lexical.Precision < 0.9 {
+			t.Fatalf("%s: expected a lexical threshold at precision 0.9, got %+v", name, c.Lexical)
+		}
+	}
+	// Long documents dilute the words related ones share, so they are told
+	// apart at a lower similarity than terse facts.
+	if *long.Lexical.Threshold >= *terse.Lexical.Threshold {
+		t.Errorf("expected long documents calibrated below terse facts, got %.3f and %.3f", *long.Lexical.Threshold, *terse.Lexical.Threshold)
+	}
+
+	if terse.Vector == nil || terse.Vector.Threshold == nil || terse.Vector.Related.Mean <= terse.Vector.Random.Mean {
+		t.Errorf("expected a vector calibration of the embedded index, got %+v", terse.Vector)
+	}
+	if long.Vector != nil {
+		t.Errorf("expected no vector calibration without embeddings, got %+v", long.Vector)
+	}
+
+	// Too few related pairs leave the measure uncalibrated.
+	opts.MinPairs = 31
+	if c := NewMatrixEngine(calibrationMatrix(terseFact, false)).CalibrateSimilarity(opts); c.Lexical.Threshold != nil {
+		t.Errorf("expected no threshold from 30 related pairs, got %v", *c.Lexical.Threshold)
+	}
+}
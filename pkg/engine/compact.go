@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// CompactReport is the outcome of CompactContent.
+type CompactReport struct {
+	Scanned    int   `json:"scanned"`
+	Compacted  int   `json:"compacted"`
+	BytesSaved int64 `json:"bytesSaved"` // content bytes removed
+}
+
+// CompactContent truncates the content of old, faded surface memories as
+// cfg describes, flagging each under core.CompactedKey and
+// core.CompactedOrigLenKey. Metadata, tags and synapses are kept, and the
+// lexical index is rebuilt from what remains. The embedding was derived
+// from the full content, so it is dropped and, with a vectorizer
+// attached, queued for EmbedPending.
+func (e *MatrixEngine) CompactContent(cfg core.CompactContentConfig, now time.Time) CompactReport {
+	vectorizer, _, _, _ := e.vectorSettings()
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	var report CompactReport
+	for _, n := range e.matrix.Neurons {
+		report.Scanned++
+		if !compactable(n, cfg, now) {
+			continue
+		}
+		content := core.TruncateContent(n.Content, cfg.KeepChars)
+		if len(content) >= len(n.Content) {
+			continue
+		}
+
+		e.unindexTerms(n)
+		report.BytesSaved += int64(len(n.Content) - len(content))
+		origLen := len(n.Content)
+		n.EditMetadata(func(metadata map[string]any) {
+			metadata[core.CompactedKey] = "true"
+			metadata[core.CompactedOrigLenKey] = origLen
+		})
+		n.Lock()
+		n.Content = content
+		n.ContentHash = core.HashContent(content)
+		n.Embedding, n.EmbeddingModel = nil, ""
+		n.EmbedPending = vectorizer != nil
+		n.Unlock()
+		e.indexTerms(n)
+		e.matrix.RecordChange(n)
+		report.Compacted++
+	}
+	if report.Compacted > 0 {
+		e.matrix.ModifiedAt = now
+		e.matrix.Version++
+	}
+	return report
+}
+
+// compactable reports whether cfg lets n's content be compacted at now.
+func compactable(n *core.Neuron, cfg core.CompactContentConfig, now time.Time) bool {
+	switch {
+	case n.Pinned, n.Depth > 0, core.IsCompacted(n):
+		return false
+	case n.Energy >= cfg.MaxEnergy:
+		return false
+	case now.Sub(n.CreatedAt) <= cfg.MinAge:
+		return false
+	case cfg.RecentAccess > 0 && now.Sub(n.LastFiredAt) <= cfg.RecentAccess:
+		return false
+	}
+	return true
+}
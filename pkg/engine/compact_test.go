@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestCompactContent_Exemptions(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("compact", core.DefaultBounds()))
+	now := time.Now()
+	month := 30 * 24 * time.Hour
+	cfg := core.CompactContentConfig{Enabled: true, MinAge: month, MaxEnergy: 0.2, KeepChars: 40, RecentAccess: 7 * 24 * time.Hour}
+
+	long := func(topic string) string {
+		return topic + " was discussed at length " + strings.Repeat("with many details ", 10) + "and the closing remark mentioned zeppelins"
+	}
+	// faded is old, cold and shallow; every other neuron misses exactly
+	// one of the conditions.
+	add := func(topic string, change func(n *core.Neuron)) *core.Neuron {
+		n, err := e.AddNeuron(long(topic), nil, map[string]string{"topic": topic})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.CreatedAt = now.Add(-2 * month)
+		n.LastFiredAt = now.Add(-month)
+		n.Energy = 0.05
+		if change != nil {
+			change(n)
+		}
+		return n
+	}
+	faded := add("budget", nil)
+	exempt := map[string]*core.Neuron{
+		"pinned":    add("pinned", func(n *core.Neuron) { n.Pinned = true }),
+		"recent":    add("recent", func(n *core.Neuron) { n.LastFiredAt = now.Add(-time.Hour) }),
+		"deep":      add("deep", func(n *core.Neuron) { n.Depth = 1 }),
+		"energetic": add("energetic", func(n *core.Neuron) { n.Energy = 0.5 }),
+		"young":     add("young", func(n *core.Neuron) { n.CreatedAt = now.Add(-time.Hour) }),
+		"short":     add("short", func(n *core.Neuron) { n.Content = "short note" }),
+	}
+	syn := core.NewSynapse(faded.ID, exempt["pinned"].ID, 0.5)
+	e.matrix.Synapses[syn.ID] = syn
+
+	origLen := len(faded.Content)
+	report := e.CompactContent(cfg, now)
+	if report.Scanned != 7 || report.Compacted != 1 || report.BytesSaved != int64(origLen-len(faded.Content)) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if !core.IsCompacted(faded) || faded.Metadata[core.CompactedOrigLenKey] != origLen || faded.Metadata["topic"] != "budget" {
+		t.Errorf("compacted neuron should be flagged and keep its metadata: %v", faded.Metadata)
+	}
+	if !strings.HasPrefix(faded.Content, "budget was discussed") || !strings.HasSuffix(faded.Content, "…") {
+		t.Errorf("unexpected compacted content %q", faded.Content)
+	}
+	if _, ok := e.matrix.Synapses[syn.ID]; !ok || e.matrix.Neurons[faded.ID] == nil {
+		t.Error("compaction must keep the neuron and its synapses")
+	}
+	for name, n := range exempt {
+		if core.IsCompacted(n) {
+			t.Errorf("%s neuron should be exempt", name)
+		}
+	}
+
+	// The head still matches; a term only in the cut tail no longer does.
+	if results := e.Search("budget", 0, 10, nil, false); len(results) != 1 || results[0].ID != faded.ID {
+		t.Errorf("the remaining content should still be searchable, got %d results", len(results))
+	}
+	for _, n := range e.Search("zeppelins", 0, 10, nil, false) {
+		if n.ID == faded.ID {
+			t.Error("a compacted neuron should not match text that was cut")
+		}
+	}
+
+	if again := e.CompactContent(cfg, now); again.Compacted != 0 {
+		t.Errorf("a second run should find nothing to compact, got %+v", again)
+	}
+}
@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// ConflictGroupKey is the metadata key that links neurons flagged as
+// possibly contradicting each other.
+const ConflictGroupKey = "_conflict_group"
+
+// ConflictOptions tunes contradiction detection for a new neuron.
+type ConflictOptions struct {
+	TopK             int      // most similar candidates compared
+	VectorThreshold  float64  // min cosine similarity when both have embeddings
+	LexicalThreshold float64  // min token Jaccard similarity otherwise
+	MinEnergy        float64  // candidates below this energy are ignored
+	Keys             []string // metadata keys whose equal values make neurons comparable
+}
+
+// Conflict is an existing neuron that a new neuron may contradict.
+type Conflict struct {
+	Group      string
+	Neuron     *core.Neuron
+	Similarity float64
+	Reason     string // "metadata:<key>" or "fact:<type>"
+}
+
+// ConflictGroup is a set of neurons flagged as mutually contradicting.
+type ConflictGroup struct {
+	ID      string
+	Neurons []*core.Neuron
+}
+
+// factPatterns recognise statements about attributes that usually hold a
+// single value at a time, so two different statements of the same kind
+// are likely to contradict.
+var factPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"phone", regexp.MustCompile(`(?i)\b(phone|mobile|cell)\b|\+?\d[\d\s().-]{6,}\d`)},
+	{"email", regexp.MustCompile(`(?i)\be-?mail\b|[\w.+-]+@[\w-]+\.[\w.]+`)},
+	{"employer", regexp.MustCompile(`(?i)\b(works?|working|worked) (at|for)\b|\bemployed (at|by)\b|\bemployer\b|\bjob at\b`)},
+	{"location", regexp.MustCompile(`(?i)\b(lives?|living|resides?|based|located) in\b|\b(moved|relocated) to\b|\bhome ?town\b`)},
+}
+
+// factTypes returns the fact kinds content states.
+func factTypes(content string) []string {
+	var types []string
+	for _, p := range factPatterns {
+		if p.re.MatchString(content) {
+			types = append(types, p.name)
+		}
+	}
+	return types
+}
+
+// DetectConflicts compares neuron id with its TopK most similar existing
+// neurons. A candidate conflicts when it shares a configured metadata value
+// or fact type with the neuron, is similar enough, and each side says
+// something the other does not. Conflicting neurons are tagged with a
+// shared ConflictGroupKey; groups that meet are merged.
+func (e *MatrixEngine) DetectConflicts(id core.NeuronID, opts ConflictOptions) []Conflict {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	n, ok := e.matrix.Neurons[id]
+	if !ok {
+		return nil
+	}
+	facts := factTypes(n.Content)
+	tokens := tokenSet(n.Content)
+
+	type candidate struct {
+		neuron     *core.Neuron
+		similarity float64
+		reason     string
+	}
+	var candidates []candidate
+	for _, other := range e.matrix.Neurons {
+		if other.ID == n.ID || other.Energy < opts.MinEnergy {
+			continue
+		}
+		reason := sharedConflictReason(n, other, facts, opts.Keys)
+		if reason == "" {
+			continue
+		}
+		var sim, threshold float64
+		if len(n.Embedding) > 0 && len(n.Embedding) == len(other.Embedding) && n.EmbeddingModelName() == other.EmbeddingModelName() {
+			sim, threshold = vector.CosineSimilarity(n.Embedding, other.Embedding), opts.VectorThreshold
+		} else {
+			sim, threshold = jaccard(tokens, tokenSet(other.Content)), opts.LexicalThreshold
+		}
+		if sim < threshold {
+			continue
+		}
+		candidates = append(candidates, candidate{other, sim, reason})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].similarity != candidates[j].similarity {
+			return candidates[i].similarity > candidates[j].similarity
+		}
+		return candidates[i].neuron.ID < candidates[j].neuron.ID
+	})
+	if opts.TopK > 0 && len(candidates) > opts.TopK {
+		candidates = candidates[:opts.TopK]
+	}
+
+	var conflicts []Conflict
+	members := []*core.Neuron{n}
+	for _, c := range candidates {
+		if !differsMaterially(tokens, tokenSet(c.neuron.Content)) {
+			continue
+		}
+		members = append(members, c.neuron)
+		conflicts = append(conflicts, Conflict{Neuron: c.neuron, Similarity: c.similarity, Reason: c.reason})
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	group := e.mergeConflictGroup(members)
+	for i := range conflicts {
+		conflicts[i].Group = group
+	}
+	return conflicts
+}
+
+// mergeConflictGroup tags members with one group ID, reusing the smallest
+// existing group among them and relabelling the neurons of any other group
+// involved. Callers hold the matrix write lock.
+func (e *MatrixEngine) mergeConflictGroup(members []*core.Neuron) string {
+	oldest := members[0]
+	existing := make(map[string]bool)
+	for _, m := range members {
+		if g := conflictGroupOf(m); g != "" {
+			existing[g] = true
+		}
+		if m.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = m
+		}
+	}
+
+	group := ""
+	for g := range existing {
+		if group == "" || g < group {
+			group = g
+		}
+	}
+	if group == "" {
+		group = "cg-" + string(oldest.ID)
+	}
+
+	if len(existing) > 1 {
+		for _, other := range e.matrix.Neurons {
+			if g := conflictGroupOf(other); g != "" && g != group && existing[g] {
+				e.setConflictGroup(other, group)
+			}
+		}
+	}
+	for _, m := range members {
+		e.setConflictGroup(m, group)
+	}
+	return group
+}
+
+func (e *MatrixEngine) setConflictGroup(n *core.Neuron, group string) {
+	if conflictGroupOf(n) == group {
+		return
+	}
+	n.EditMetadata(func(metadata map[string]any) { metadata[ConflictGroupKey] = group })
+	e.matrix.RecordChange(n)
+	e.matrix.ModifiedAt = time.Now()
+	e.matrix.Version++
+}
+
+func conflictGroupOf(n *core.Neuron) string {
+	g, _ := n.Metadata[ConflictGroupKey].(string)
+	return g
+}
+
+// ConflictGroups lists flagged conflict groups, each with members oldest
+// first, ordered by group ID.
+func (e *MatrixEngine) ConflictGroups() []ConflictGroup {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	byID := make(map[string][]*core.Neuron)
+	for _, n := range e.matrix.Neurons {
+		if g := conflictGroupOf(n); g != "" {
+			byID[g] = append(byID[g], n)
+		}
+	}
+
+	groups := make([]ConflictGroup, 0, len(byID))
+	for id, neurons := range byID {
+		sort.Slice(neurons, func(i, j int) bool {
+			if !neurons[i].CreatedAt.Equal(neurons[j].CreatedAt) {
+				return neurons[i].CreatedAt.Before(neurons[j].CreatedAt)
+			}
+			return neurons[i].ID < neurons[j].ID
+		})
+		groups = append(groups, ConflictGroup{ID: id, Neurons: neurons})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups
+}
+
+// sharedConflictReason reports why a and b are comparable: an equal value
+// under one of keys, or a fact type both state. Empty means they are not.
+func sharedConflictReason(a, b *core.Neuron, aFacts []string, keys []string) string {
+	for _, k := range keys {
+		av, ok := a.Metadata[k]
+		if !ok || fmt.Sprint(av) == "" {
+			continue
+		}
+		if bv, ok := b.Metadata[k]; ok && fmt.Sprint(av) == fmt.Sprint(bv) {
+			return "metadata:" + k
+		}
+	}
+	if len(aFacts) == 0 {
+		return ""
+	}
+	for _, bf := range factTypes(b.Content) {
+		for _, af := range aFacts {
+			if af == bf {
+				return "fact:" + af
+			}
+		}
+	}
+	return ""
+}
+
+func tokenSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range tokenize(text) {
+		set[t] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for t := range a {
+		if b[t] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// differsMaterially reports whether each side has a token the other lacks.
+// A statement that only adds detail to the other ("Boston" vs "Boston MA")
+// is a refinement, not a contradiction.
+func differsMaterially(a, b map[string]bool) bool {
+	return hasTokenNotIn(a, b) && hasTokenNotIn(b, a)
+}
+
+func hasTokenNotIn(a, b map[string]bool) bool {
+	for t := range a {
+		if !b[t] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+var testConflictOptions = ConflictOptions{
+	TopK:             5,
+	VectorThreshold:  0.8,
+	LexicalThreshold: 0.4,
+	MinEnergy:        0.2,
+	Keys:             []string{"fact"},
+}
+
+func addAndDetect(t *testing.T, e *MatrixEngine, content string, metadata map[string]string) (*core.Neuron, []Conflict) {
+	t.Helper()
+	n, err := e.AddNeuron(content, nil, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, e.DetectConflicts(n.ID, testConflictOptions)
+}
+
+func TestDetectConflicts_FactFixtures(t *testing.T) {
+	fixtures := []struct {
+		name, old, new, fact string
+	}{
+		{"phone", "My phone number is 555-0101", "My phone number is 555-0199", "fact:phone"},
+		{"employer", "Alice works at Acme Corp as a designer", "Alice works at Globex as a designer", "fact:employer"},
+		{"city", "Alice lives in Boston", "Alice lives in San Francisco", "fact:location"},
+	}
+	for _, f := range fixtures {
+		t.Run(f.name, func(t *testing.T) {
+			e := NewMatrixEngine(newTestMatrix())
+			old, _ := addAndDetect(t, e, f.old, nil)
+			e.AddNeuron("Alice enjoys jazz on weekends", nil, nil)
+
+			n, conflicts := addAndDetect(t, e, f.new, nil)
+			if len(conflicts) != 1 || conflicts[0].Neuron.ID != old.ID {
+				t.Fatalf("expected a conflict with %q, got %+v", f.old, conflicts)
+			}
+			if conflicts[0].Reason != f.fact {
+				t.Errorf("expected reason %s, got %s", f.fact, conflicts[0].Reason)
+			}
+			group := conflicts[0].Group
+			if conflictGroupOf(old) != group || conflictGroupOf(n) != group {
+				t.Errorf("both neurons should carry group %s: old=%v new=%v", group, old.Metadata, n.Metadata)
+			}
+		})
+	}
+}
+
+func TestDetectConflicts_IgnoresNonConflicts(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	addAndDetect(t, e, "Alice lives in Boston", nil)
+	addAndDetect(t, e, "My phone number is 555-0101", nil)
+
+	cases := []string{
+		"Alice lives in Boston Massachusetts", // refinement, not contradiction
+		"Alice works at Initech",              // different fact type
+		"Alice likes Boston cream pie",        // no fact type
+	}
+	for _, content := range cases {
+		if _, conflicts := addAndDetect(t, e, content, nil); len(conflicts) != 0 {
+			t.Errorf("%q: expected no conflicts, got %+v", content, conflicts)
+		}
+	}
+	if groups := e.ConflictGroups(); len(groups) != 0 {
+		t.Errorf("expected no conflict groups, got %d", len(groups))
+	}
+}
+
+func TestDetectConflicts_SkipsFadedNeurons(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	old, _ := addAndDetect(t, e, "Alice lives in Boston", nil)
+	old.Energy = 0.05
+
+	if _, conflicts := addAndDetect(t, e, "Alice lives in Denver", nil); len(conflicts) != 0 {
+		t.Errorf("expected faded neuron to be skipped, got %+v", conflicts)
+	}
+}
+
+func TestDetectConflicts_MetadataKeyAndGroupMerge(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	opts := testConflictOptions
+	opts.Keys = []string{"fact", "attribute"}
+
+	add := func(content string, md map[string]string) []Conflict {
+		n, err := e.AddNeuron(content, nil, md)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.DetectConflicts(n.ID, opts)
+	}
+
+	add("Alice home base is Boston", map[string]string{"fact": "home"})
+	if c := add("Alice home base is Denver", map[string]string{"fact": "home"}); len(c) != 1 || c[0].Reason != "metadata:fact" {
+		t.Fatalf("expected metadata conflict, got %+v", c)
+	}
+	add("Alice home base is Austin", map[string]string{"attribute": "base"})
+	add("Alice home base is Miami", map[string]string{"attribute": "base"})
+	if groups := e.ConflictGroups(); len(groups) != 2 {
+		t.Fatalf("expected two separate groups, got %d", len(groups))
+	}
+
+	if c := add("Alice home base is Seattle", map[string]string{"fact": "home", "attribute": "base"}); len(c) != 4 {
+		t.Fatalf("expected conflicts with all four, got %d", len(c))
+	}
+	groups := e.ConflictGroups()
+	if len(groups) != 1 || len(groups[0].Neurons) != 5 {
+		t.Fatalf("expected groups to merge into one of 5, got %+v", groups)
+	}
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"sort"
+	"unicode"
+)
+
+// ExpansionOptions bound query expansion; see MatrixEngine.ExpandQuery.
+type ExpansionOptions struct {
+	MaxTerms       int // terms added at most
+	MaxAddedTokens int // estimated tokens (4 characters each) the terms add at most
+	MaxCueTokens   int // queries with more tokens are not expanded
+	Seeds          int // neurons the first pass draws terms from
+}
+
+// minExpansionTermLen is the shortest term expansion adds.
+const minExpansionTermLen = 3
+
+// ExpandQuery returns the terms this index associates with query, most
+// associated first, for a search to add to it (SearchOptions.Expansion).
+// A cheap first pass, lexical only and without firing, retrieves
+// opts.Seeds neurons for the query and the neurons their synapses lead
+// to; their terms are ranked by how rare they are in the index and how
+// high the neurons that hold them ranked. Terms of the query, terms held
+// by a single neuron or by most of the index, numbers and short words are
+// left out. Queries with more than opts.MaxCueTokens tokens, or none, are
+// not expanded.
+func (e *MatrixEngine) ExpandQuery(query, lang string, opts ExpansionOptions) []string {
+	queryTokens := e.fold.tokens(query, lang)
+	if len(queryTokens) == 0 || len(queryTokens) > opts.MaxCueTokens || opts.MaxTerms < 1 || opts.Seeds < 1 {
+		return nil
+	}
+	e.ensureTermStats()
+	searcher := NewSearcher(e.matrix)
+	searcher.SetBM25(e.bm25K1, e.bm25B)
+	searcher.SetFold(e.fold, lang)
+	searcher.SetPeek(true)
+	seeds := searcher.Search(query, 1, opts.Seeds)
+	if len(seeds) == 0 {
+		return nil
+	}
+
+	exclude := make(map[string]bool, len(queryTokens))
+	for _, t := range queryTokens {
+		exclude[t] = true
+	}
+	scores := make(map[string]float64)
+	e.matrix.RLock()
+	terms := e.matrix.Terms
+	for rank, n := range seeds {
+		weight := 1 / float64(rank+1)
+		seen := make(map[string]bool)
+		for _, t := range searcher.contentTokens(n) {
+			if seen[t] || exclude[t] || !expansionTerm(t) {
+				continue
+			}
+			seen[t] = true
+			df := terms.DocFreq[t]
+			if df < 2 || (terms.Docs >= 4 && df*2 > terms.Docs) {
+				continue
+			}
+			scores[t] += weight * terms.IDF(t)
+		}
+	}
+	e.matrix.RUnlock()
+
+	ranked := make([]string, 0, len(scores))
+	for t := range scores {
+		ranked = append(ranked, t)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+
+	var expansion []string
+	added := 0
+	for _, t := range ranked {
+		if len(expansion) == opts.MaxTerms {
+			break
+		}
+		cost := (len(t) + 1 + 3) / 4 // with its separating space
+		if added+cost > opts.MaxAddedTokens {
+			continue
+		}
+		added += cost
+		expansion = append(expansion, t)
+	}
+	return expansion
+}
+
+// expansionTerm reports whether token t is worth adding to a query: long
+// enough and not a number.
+func expansionTerm(t string) bool {
+	if len([]rune(t)) < minExpansionTermLen {
+		return false
+	}
+	for _, r := range t {
+		if !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandedTokens folds the expansion terms for scoring, dropping those
+// already in the query.
+func (s *Searcher) expandedTokens(queryTokens []string) []string {
+	if len(s.expansion) == 0 {
+		return nil
+	}
+	inQuery := make(map[string]bool, len(queryTokens))
+	for _, t := range queryTokens {
+		inQuery[t] = true
+	}
+	var tokens []string
+	for _, term := range s.expansion {
+		for _, t := range s.fold.tokens(term, s.queryLang) {
+			if !inQuery[t] {
+				inQuery[t] = true
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// SetExpansion adds terms to the query, from MatrixEngine.ExpandQuery:
+// the embedded query text includes them, and each counts lexically with
+// weight times the weight of a query term. A neuron matching only
+// expansion terms can be found; one matching the query scores as it
+// would unexpanded, plus what the terms add. Ignored in exact mode.
+func (s *Searcher) SetExpansion(terms []string, weight float64) {
+	s.expansion = terms
+	s.expansionWeight = weight
+}
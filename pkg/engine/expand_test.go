@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+var testExpansionOptions = ExpansionOptions{MaxTerms: 5, MaxAddedTokens: 12, MaxCueTokens: 4, Seeds: 5}
+
+func newExpansionEngine(t *testing.T, contents ...string) *MatrixEngine {
+	t.Helper()
+	e := NewMatrixEngine(newTestMatrix())
+	for _, c := range contents {
+		if _, err := e.AddNeuron(c, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return e
+}
+
+func TestExpandQuery_RecoversRelatedMemories(t *testing.T) {
+	e := newExpansionEngine(t,
+		"weekly meeting about the apollo launch",
+		"apollo standup: launch slipped again",
+		"apollo retro: launch went well",
+		"grocery list: eggs milk bread",
+		"dentist appointment tuesday",
+		"water the garden plants",
+		"renew car insurance policy",
+		"call mom on sunday",
+	)
+	search := func(opts SearchOptions) []string {
+		neurons, _ := e.PeekSearch(context.Background(), "meeting", 0, 10, nil, false, nil, opts)
+		var out []string
+		for _, n := range neurons {
+			out = append(out, n.Content)
+		}
+		return out
+	}
+
+	if plain := search(SearchOptions{}); slices.Contains(plain, "apollo retro: launch went well") {
+		t.Fatalf("expected the unexpanded cue to miss the retro, got %v", plain)
+	}
+	terms := e.ExpandQuery("meeting", "", testExpansionOptions)
+	if !slices.Contains(terms, "apollo") || !slices.Contains(terms, "launch") {
+		t.Fatalf("expected apollo and launch in the expansion, got %v", terms)
+	}
+	expanded := search(SearchOptions{Expansion: terms, ExpansionWeight: 0.5})
+	for _, want := range []string{"weekly meeting about the apollo launch", "apollo standup: launch slipped again", "apollo retro: launch went well"} {
+		if !slices.Contains(expanded, want) {
+			t.Errorf("expected %q in the expanded results %v", want, expanded)
+		}
+	}
+	if expanded[0] != "weekly meeting about the apollo launch" {
+		t.Errorf("expected the memory matching the cue first, got %v", expanded)
+	}
+}
+
+func TestExpandQuery_Bounds(t *testing.T) {
+	e := newExpansionEngine(t,
+		"weekly meeting about the apollo launch",
+		"apollo standup: launch slipped again",
+		"apollo retro: launch went well",
+	)
+	if terms := e.ExpandQuery("a long cue about the weekly meeting", "", testExpansionOptions); terms != nil {
+		t.Errorf("expected a long cue not to be expanded, got %v", terms)
+	}
+	opts := testExpansionOptions
+	opts.MaxTerms = 1
+	if terms := e.ExpandQuery("meeting", "", opts); len(terms) != 1 {
+		t.Errorf("expected one term, got %v", terms)
+	}
+	opts = testExpansionOptions
+	opts.MaxAddedTokens = 1
+	if terms := e.ExpandQuery("meeting", "", opts); terms != nil {
+		t.Errorf("expected no term within one token, got %v", terms)
+	}
+	if terms := e.ExpandQuery("unrelated", "", testExpansionOptions); terms != nil {
+		t.Errorf("expected no expansion without seeds, got %v", terms)
+	}
+}
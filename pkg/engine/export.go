@@ -0,0 +1,364 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// DanglingSynapsesKey lists, on a neuron imported with dangling synapses
+// kept, the IDs of neurons it was connected to outside the imported slice.
+const DanglingSynapsesKey = "_dangling_synapses"
+
+// SliceFilter selects the neurons of a partial export. Metadata works as
+// in search: with Strict a neuron must carry every pair, otherwise any one
+// of them. Since and Until bound the creation time. The zero filter
+// selects the whole index.
+type SliceFilter struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Strict   bool              `json:"strict,omitempty"`
+	Since    *time.Time        `json:"since,omitempty"`
+	Until    *time.Time        `json:"until,omitempty"`
+}
+
+// Empty reports whether f selects every neuron.
+func (f SliceFilter) Empty() bool {
+	return len(f.Metadata) == 0 && f.Since == nil && f.Until == nil
+}
+
+// matches reports whether n is selected by f. Callers hold n's matrix
+// lock.
+func (f SliceFilter) matches(n *core.Neuron) bool {
+	if f.Since != nil && n.CreatedAt.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && !n.CreatedAt.Before(*f.Until) {
+		return false
+	}
+	if len(f.Metadata) == 0 {
+		return true
+	}
+	hits := 0
+	for k, want := range f.Metadata {
+		if v, ok := n.Metadata[k]; ok && fmt.Sprintf("%v", v) == want {
+			hits++
+		}
+	}
+	if f.Strict {
+		return hits == len(f.Metadata)
+	}
+	return hits > 0
+}
+
+// ExportedNeuron is a neuron of an exported slice. Hops is 0 for neurons
+// the filter selected and the distance to the nearest of them for
+// neighbors. Embeddings are not exported; they are recomputed on import.
+// Attachments are references; the export stream carries their blobs.
+type ExportedNeuron struct {
+	ID             core.NeuronID     `json:"id"`
+	Content        string            `json:"content"`
+	Metadata       map[string]any    `json:"metadata,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Attachments    []core.Attachment `json:"attachments,omitempty"`
+	Energy         float64           `json:"energy"`
+	BaseEnergy     float64           `json:"baseEnergy"`
+	Depth          int               `json:"depth"`
+	AccessCount    uint64            `json:"accessCount"`
+	Pinned         bool              `json:"pinned,omitempty"`
+	SentimentLabel string            `json:"sentimentLabel,omitempty"`
+	SentimentScore float64           `json:"sentimentScore,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	LastFiredAt    time.Time         `json:"lastFiredAt"`
+	Hops           int               `json:"hops"`
+}
+
+// ExportedSynapse is a synapse of an exported slice. Dangling marks a
+// boundary synapse: one of its neurons is outside the slice.
+type ExportedSynapse struct {
+	From          core.NeuronID `json:"from"`
+	To            core.NeuronID `json:"to"`
+	Weight        float64       `json:"weight"`
+	CoFireCount   uint64        `json:"coFireCount"`
+	Bidirectional bool          `json:"bidirectional,omitempty"`
+	Type          string        `json:"synapseType,omitempty"` // associative when empty
+	CreatedAt     time.Time     `json:"createdAt"`
+	Dangling      bool          `json:"dangling,omitempty"`
+}
+
+// Slice is a set of neurons of an index and the synapses touching them.
+type Slice struct {
+	Neurons  []ExportedNeuron
+	Synapses []ExportedSynapse
+}
+
+// ExportSlice copies the neurons f selects, plus their neighborhood up to
+// neighborHops synapses away, and every synapse with at least one end in
+// the slice. Synapses leaving the slice are marked dangling. Neurons come
+// out oldest first.
+func (e *MatrixEngine) ExportSlice(f SliceFilter, neighborHops int) Slice {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	hops := make(map[core.NeuronID]int)
+	var frontier []core.NeuronID
+	for id, n := range e.matrix.Neurons {
+		if f.matches(n) {
+			hops[id] = 0
+			frontier = append(frontier, id)
+		}
+	}
+	for hop := 1; hop <= neighborHops && len(frontier) > 0; hop++ {
+		var next []core.NeuronID
+		for _, id := range frontier {
+			for _, nb := range e.matrix.Adjacency[id] {
+				if _, seen := hops[nb]; seen {
+					continue
+				}
+				if _, ok := e.matrix.Neurons[nb]; !ok {
+					continue
+				}
+				hops[nb] = hop
+				next = append(next, nb)
+			}
+		}
+		frontier = next
+	}
+
+	slice := Slice{Neurons: make([]ExportedNeuron, 0, len(hops)), Synapses: []ExportedSynapse{}}
+	for id, hop := range hops {
+		n := e.matrix.Neurons[id].Snapshot()
+		exported := ExportedNeuron{
+			ID:             n.ID,
+			Content:        n.Content,
+			Tags:           append([]string(nil), n.Tags...),
+			Attachments:    append([]core.Attachment(nil), n.Attachments...),
+			Energy:         n.Energy,
+			BaseEnergy:     n.BaseEnergy,
+			Depth:          n.Depth,
+			AccessCount:    n.AccessCount,
+			Pinned:         n.Pinned,
+			SentimentLabel: n.SentimentLabel,
+			SentimentScore: n.SentimentScore,
+			CreatedAt:      n.CreatedAt,
+			LastFiredAt:    n.LastFiredAt,
+			Hops:           hop,
+		}
+		if len(n.Metadata) > 0 {
+			exported.Metadata = make(map[string]any, len(n.Metadata))
+			for k, v := range n.Metadata {
+				exported.Metadata[k] = v
+			}
+		}
+		slice.Neurons = append(slice.Neurons, exported)
+	}
+	sort.Slice(slice.Neurons, func(i, j int) bool {
+		a, b := slice.Neurons[i], slice.Neurons[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	for _, syn := range e.matrix.Synapses {
+		_, fromIn := hops[syn.FromID]
+		_, toIn := hops[syn.ToID]
+		if !fromIn && !toIn {
+			continue
+		}
+		weight, coFires, _ := syn.Snapshot()
+		slice.Synapses = append(slice.Synapses, ExportedSynapse{
+			From:          syn.FromID,
+			To:            syn.ToID,
+			Weight:        weight,
+			CoFireCount:   coFires,
+			Bidirectional: syn.Bidirectional,
+			Type:          syn.Type,
+			CreatedAt:     syn.CreatedAt,
+			Dangling:      !fromIn || !toIn,
+		})
+	}
+	sort.Slice(slice.Synapses, func(i, j int) bool {
+		a, b := slice.Synapses[i], slice.Synapses[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return slice
+}
+
+// SliceImportResult reports ImportSlice.
+type SliceImportResult struct {
+	Created  int `json:"created"`
+	Existing int `json:"existing"` // already in the index, by ID or content
+	Synapses int `json:"synapses"` // created
+	// Dangling counts synapses with an end outside the slice: kept on the
+	// neuron inside under DanglingSynapsesKey, or dropped.
+	Dangling     int           `json:"dangling"`
+	KeptDangling bool          `json:"keptDangling"`
+	Errors       []ImportError `json:"errors"`
+}
+
+// ImportSlice adds an exported slice to the index. Neurons keep their IDs,
+// content, metadata, tags, energy and timestamps; a neuron whose ID or
+// content the index already holds is left as it is and stands in for the
+// imported one. Synapses between imported neurons are recreated unless the
+// two are connected already. A synapse with an end that is neither in the
+// slice nor in the index is dangling: with keepDangling the ID of the
+// missing end is recorded on the other under DanglingSynapsesKey, otherwise
+// the synapse is dropped. With a vectorizer attached, created neurons are
+// queued for EmbedPending.
+func (e *MatrixEngine) ImportSlice(slice Slice, keepDangling bool) SliceImportResult {
+	vectorizer, _, _, _ := e.vectorSettings()
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	res := SliceImportResult{KeptDangling: keepDangling, Errors: []ImportError{}}
+	byHash := make(map[string]core.NeuronID, len(e.matrix.Neurons))
+	for id, n := range e.matrix.Neurons {
+		byHash[n.ContentHash] = id
+	}
+	ids := make(map[core.NeuronID]core.NeuronID, len(slice.Neurons))
+	changed := false
+
+	for _, in := range slice.Neurons {
+		if in.ID == "" {
+			res.Errors = append(res.Errors, ImportError{Error: "neuron without id"})
+			continue
+		}
+		if _, ok := e.matrix.Neurons[in.ID]; ok {
+			ids[in.ID] = in.ID
+			res.Existing++
+			continue
+		}
+		content, err := core.NormalizeNeuronContent(in.Content)
+		if err != nil {
+			res.Errors = append(res.Errors, ImportError{Key: string(in.ID), Error: err.Error()})
+			continue
+		}
+		if id, ok := byHash[core.HashContent(content)]; ok {
+			ids[in.ID] = id
+			res.Existing++
+			continue
+		}
+		if len(e.matrix.Neurons) >= e.matrix.Bounds.MaxNeurons {
+			res.Errors = append(res.Errors, ImportError{Key: string(in.ID), Error: core.ErrMatrixFull.Error()})
+			continue
+		}
+
+		n := core.NewNeuron(content, e.matrix.CurrentDim)
+		n.ID = in.ID
+		n.Energy, n.BaseEnergy, n.Depth = in.Energy, in.BaseEnergy, in.Depth
+		n.AccessCount, n.Pinned = in.AccessCount, in.Pinned
+		n.SentimentLabel, n.SentimentScore = in.SentimentLabel, in.SentimentScore
+		if !in.CreatedAt.IsZero() {
+			n.CreatedAt = in.CreatedAt
+		}
+		if !in.LastFiredAt.IsZero() {
+			n.LastFiredAt = in.LastFiredAt
+		}
+		n.Tags = append([]string{}, in.Tags...)
+		if len(in.Attachments) > 0 {
+			n.Attachments = append([]core.Attachment(nil), in.Attachments...)
+		}
+		for k, v := range in.Metadata {
+			n.Metadata[k] = v
+		}
+		if nearest := e.findNearestByContent(content); nearest != nil {
+			n.Position = e.perturbPosition(nearest, 0.2)
+		} else {
+			n.Position = e.randomPosition()
+		}
+		n.EmbedPending = vectorizer != nil
+
+		e.matrix.Neurons[n.ID] = n
+		e.matrix.Adjacency[n.ID] = []core.NeuronID{}
+		e.indexTerms(n)
+		e.indexMetadata(n)
+		e.matrix.RecordChange(n)
+		byHash[n.ContentHash] = n.ID
+		ids[in.ID] = n.ID
+		res.Created++
+		changed = true
+	}
+
+	// Ends outside the slice may still be in the index, such as when a
+	// slice is imported back into its source.
+	resolve := func(id core.NeuronID) (core.NeuronID, bool) {
+		if mapped, ok := ids[id]; ok {
+			return mapped, true
+		}
+		_, ok := e.matrix.Neurons[id]
+		return id, ok
+	}
+	for _, in := range slice.Synapses {
+		from, fromOK := resolve(in.From)
+		to, toOK := resolve(in.To)
+		if !fromOK || !toOK {
+			res.Dangling++
+			if keepDangling && fromOK != toOK {
+				inside, missing := from, in.To
+				if toOK {
+					inside, missing = to, in.From
+				}
+				if e.recordDanglingLocked(inside, missing) {
+					changed = true
+				}
+			}
+			continue
+		}
+		typ := in.Type
+		if typ == "" {
+			typ = core.SynapseAssociative
+		}
+		if from == to || !e.linkTypedLocked(from, to, in.Weight, typ) {
+			continue
+		}
+		syn := e.matrix.Synapses[core.NewSynapseID(from, to)]
+		syn.CoFireCount, syn.Bidirectional = in.CoFireCount, in.Bidirectional
+		if !in.CreatedAt.IsZero() {
+			syn.CreatedAt = in.CreatedAt
+		}
+		res.Synapses++
+		changed = true
+	}
+
+	if changed {
+		e.matrix.ModifiedAt = time.Now()
+		e.matrix.Version++
+		e.checkDimensionExpansion()
+	}
+	return res
+}
+
+// recordDanglingLocked adds missing to the dangling synapses of neuron id,
+// reporting whether it was not recorded yet. Callers hold the matrix lock.
+func (e *MatrixEngine) recordDanglingLocked(id, missing core.NeuronID) bool {
+	n, ok := e.matrix.Neurons[id]
+	if !ok {
+		return false
+	}
+	var dangling []string
+	switch v := n.Metadata[DanglingSynapsesKey].(type) {
+	case []string:
+		dangling = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				dangling = append(dangling, s)
+			}
+		}
+	}
+	for _, d := range dangling {
+		if d == string(missing) {
+			return false
+		}
+	}
+	dangling = append(slices.Clip(dangling), string(missing))
+	n.EditMetadata(func(metadata map[string]any) { metadata[DanglingSynapsesKey] = dangling })
+	e.matrix.RecordChange(n)
+	return true
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// chainBrain builds a -- b -- c -- d, where only a and b belong to thread t1.
+func chainBrain(t *testing.T) (*MatrixEngine, []*core.Neuron) {
+	t.Helper()
+	e := NewMatrixEngine(core.NewMatrix("slice", core.DefaultBounds()))
+	var ns []*core.Neuron
+	for i, content := range []string{"alpha memory", "bravo memory", "charlie memory", "delta memory"} {
+		meta := map[string]string{"thread_id": "t2"}
+		if i < 2 {
+			meta["thread_id"] = "t1"
+		}
+		n, err := e.AddNeuron(content, nil, meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ns = append(ns, n)
+	}
+	e.matrix.Lock()
+	for i := 0; i+1 < len(ns); i++ {
+		e.linkLocked(ns[i].ID, ns[i+1].ID, 0.4)
+	}
+	e.matrix.Unlock()
+	return e, ns
+}
+
+func sliceIDs(s Slice) map[core.NeuronID]int {
+	ids := make(map[core.NeuronID]int, len(s.Neurons))
+	for _, n := range s.Neurons {
+		ids[n.ID] = n.Hops
+	}
+	return ids
+}
+
+func TestExportSlice_FilterAndNeighbors(t *testing.T) {
+	e, ns := chainBrain(t)
+
+	s := e.ExportSlice(SliceFilter{Metadata: map[string]string{"thread_id": "t1"}}, 0)
+	if ids := sliceIDs(s); len(ids) != 2 || ids[ns[0].ID] != 0 || ids[ns[1].ID] != 0 {
+		t.Fatalf("expected a and b, got %v", ids)
+	}
+	if len(s.Synapses) != 2 || s.Synapses[0].Dangling == s.Synapses[1].Dangling {
+		t.Errorf("expected the a-b synapse and the dangling b-c one, got %+v", s.Synapses)
+	}
+
+	s = e.ExportSlice(SliceFilter{Metadata: map[string]string{"thread_id": "t1"}}, 1)
+	if ids := sliceIDs(s); len(ids) != 3 || ids[ns[2].ID] != 1 {
+		t.Errorf("one hop should add c, got %v", ids)
+	}
+
+	s = e.ExportSlice(SliceFilter{Metadata: map[string]string{"thread_id": "t1", "role": "user"}, Strict: true}, 0)
+	if len(s.Neurons) != 0 {
+		t.Errorf("strict filters need every pair, got %d neurons", len(s.Neurons))
+	}
+
+	since := time.Now().Add(time.Hour)
+	if s := e.ExportSlice(SliceFilter{Since: &since}, 0); len(s.Neurons) != 0 {
+		t.Errorf("nothing was created after since, got %d neurons", len(s.Neurons))
+	}
+	if s := e.ExportSlice(SliceFilter{}, 0); len(s.Neurons) != 4 || len(s.Synapses) != 3 {
+		t.Errorf("the empty filter exports everything, got %d neurons and %d synapses", len(s.Neurons), len(s.Synapses))
+	}
+}
+
+func TestImportSlice_DanglingSynapses(t *testing.T) {
+	src, ns := chainBrain(t)
+	slice := src.ExportSlice(SliceFilter{Metadata: map[string]string{"thread_id": "t1"}}, 0)
+
+	dropped := NewMatrixEngine(core.NewMatrix("dropped", core.DefaultBounds()))
+	res := dropped.ImportSlice(slice, false)
+	if res.Created != 2 || res.Synapses != 1 || res.Dangling != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	m := dropped.matrix
+	if _, ok := m.Synapses[core.NewSynapseID(ns[0].ID, ns[1].ID)]; !ok || len(m.Synapses) != 1 {
+		t.Errorf("only the a-b synapse should be imported, got %d synapses", len(m.Synapses))
+	}
+	if _, ok := m.Neurons[ns[1].ID].Metadata[DanglingSynapsesKey]; ok {
+		t.Error("dropped synapses must not be recorded")
+	}
+
+	kept := NewMatrixEngine(core.NewMatrix("kept", core.DefaultBounds()))
+	kept.ImportSlice(slice, true)
+	if d, _ := kept.matrix.Neurons[ns[1].ID].Metadata[DanglingSynapsesKey].([]string); len(d) != 1 || d[0] != string(ns[2].ID) {
+		t.Errorf("b should record its synapse to c, got %v", kept.matrix.Neurons[ns[1].ID].Metadata)
+	}
+
+	// Importing again changes nothing.
+	if again := kept.ImportSlice(slice, true); again.Created != 0 || again.Existing != 2 || again.Synapses != 0 {
+		t.Errorf("a second import should find everything in place, got %+v", again)
+	}
+}
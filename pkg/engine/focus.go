@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Focus is a soft metadata boost applied to every retrieval of an index
+// while it is active, on top of what each request asks for.
+type Focus struct {
+	Metadata map[string]string
+	Boost    float64 // added to the multiplier per matching key, like a request's metadata boost
+}
+
+// factor returns the multiplier f gives n: 1 + Boost per metadata key n
+// matches, 1 for a nil focus. Caller holds the matrix read lock.
+func (f *Focus) factor(n *core.Neuron) float64 {
+	if f == nil {
+		return 1
+	}
+	matches := 0
+	for k, v := range f.Metadata {
+		if nv, ok := n.Metadata[k]; ok && fmt.Sprintf("%v", nv) == v {
+			matches++
+		}
+	}
+	return 1 + float64(matches)*f.Boost
+}
+
+// SetFocus makes Search boost neurons matching f after every other
+// factor. Exact mode ignores it, as it ignores the metadata boost. nil
+// clears it.
+func (s *Searcher) SetFocus(f *Focus) {
+	s.focus = f
+}
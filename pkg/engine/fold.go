@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// LexicalFold controls how text is normalized into the tokens lexical
+// scoring matches. Stored content and queries are folded alike; embeddings
+// see the original text.
+type LexicalFold struct {
+	// Diacritics strips the combining marks NFKD separates from their base
+	// letters, and folds the dotless ı into i, so "kadikoy" matches
+	// "Kadıköy". Off for languages where diacritics are contrastive.
+	Diacritics bool
+
+	// Language is the language of neurons without a _lang and of queries
+	// without one. Turkish and Azerbaijani fold I to ı and İ to i.
+	Language string
+
+	// CaseSensitive keeps case. Only exact-mode searches ask for it; stored
+	// tokens are always case-folded.
+	CaseSensitive bool
+}
+
+// DefaultLexicalFold is the fold of an engine not configured otherwise.
+var DefaultLexicalFold = LexicalFold{Diacritics: true}
+
+// turkishCasing reports whether lang folds case the Turkish way.
+func turkishCasing(lang string) bool {
+	lang = strings.ToLower(lang)
+	for _, tr := range []string{"tr", "az"} {
+		if lang == tr || strings.HasPrefix(lang, tr+"-") || strings.HasPrefix(lang, tr+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// lang returns lang, or the fold's default language when it is empty.
+func (f LexicalFold) lang(lang string) string {
+	if lang == "" {
+		return f.Language
+	}
+	return lang
+}
+
+// statsKey identifies the fold the term statistics of a matrix were built
+// with. The language is part of it: neurons without a _lang take it.
+func (f LexicalFold) statsKey() string {
+	key := "lower"
+	if f.Diacritics {
+		key += "+diacritics"
+	}
+	if f.Language != "" {
+		key += ":" + strings.ToLower(f.Language)
+	}
+	return key
+}
+
+// neuronKey identifies what n's stored tokens are computed from: its
+// content, its attachment captions and how it is folded.
+func (f LexicalFold) neuronKey(n *core.Neuron) string {
+	key := "lower"
+	if f.Diacritics {
+		key += "+diacritics"
+	}
+	if turkishCasing(f.lang(NeuronLang(n))) {
+		key += "+tr"
+	}
+	return key + ":" + n.ContentHash + ":" + strconv.Itoa(len(n.Attachments))
+}
+
+// text folds text written in lang; an empty lang takes the default.
+func (f LexicalFold) text(text, lang string) string {
+	if !f.CaseSensitive {
+		if turkishCasing(f.lang(lang)) {
+			text = strings.ToLowerSpecial(unicode.TurkishCase, text)
+		} else {
+			text = strings.ToLower(text)
+		}
+	}
+	if !f.Diacritics {
+		return text
+	}
+	if isASCII(text) {
+		return text
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r == 'ı':
+			b.WriteRune('i')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tokens splits text written in lang into folded tokens.
+func (f LexicalFold) tokens(text, lang string) []string {
+	folded, _ := f.terms(text, lang)
+	return folded
+}
+
+// terms is tokens, also returning the original form of each token.
+func (f LexicalFold) terms(text, lang string) (folded, original []string) {
+	words := strings.Fields(tokenSplitRegex.ReplaceAllString(text, " "))
+	folded = make([]string, 0, len(words))
+	original = make([]string, 0, len(words))
+	for _, w := range words {
+		token := f.text(w, lang)
+		// Skip very short words
+		if len(token) >= 2 {
+			folded = append(folded, token)
+			original = append(original, w)
+		}
+	}
+	return folded, original
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// SetLexicalFold sets how lexical scoring normalizes text. Stored tokens
+// and term statistics folded otherwise are rebuilt by the next search.
+func (e *MatrixEngine) SetLexicalFold(f LexicalFold) {
+	f.CaseSensitive = false
+	e.fold = f
+}
+
+// lexicalTokens returns n's folded tokens, computing and storing them on n
+// when they are missing or stale. Caller holds the matrix write lock.
+func (e *MatrixEngine) lexicalTokens(n *core.Neuron) []string {
+	key := e.fold.neuronKey(n)
+	if n.LexicalKey == key {
+		return n.LexicalTokens
+	}
+	n.LexicalTokens = e.fold.tokens(n.LexicalText(), NeuronLang(n))
+	n.LexicalKey = key
+	e.matrix.Remeasure(n)
+	return n.LexicalTokens
+}
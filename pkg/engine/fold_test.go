@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// foldedHit searches e for query and returns the breakdown of n among the
+// results, and whether it matched the whole query as a phrase.
+func foldedHit(t *testing.T, e *MatrixEngine, n *core.Neuron, query string, opts SearchOptions) (ScoreBreakdown, bool) {
+	t.Helper()
+	results, stats := e.PeekSearch(e.traceContext(), query, 0, 10, nil, false, nil, opts)
+	for i, r := range results {
+		if r.ID == n.ID {
+			return stats.Breakdowns[i], stats.Breakdowns[i].Phrase > 0
+		}
+	}
+	return ScoreBreakdown{}, false
+}
+
+func TestLexicalFold_Text(t *testing.T) {
+	fold := DefaultLexicalFold
+	for _, c := range []struct{ in, lang, want string }{
+		{"Kadıköy", "", "kadikoy"},
+		{"FENERBAHÇE", "", "fenerbahce"},
+		{"İSTANBUL", "", "istanbul"},
+		{"ILIK", "tr", "ilik"},
+		{"Müller Straße", "de", "muller straße"},
+		{"ｆｕｌｌｗｉｄｔｈ", "", "fullwidth"},
+	} {
+		if got := fold.text(c.in, c.lang); got != c.want {
+			t.Errorf("fold(%q, %q) = %q, want %q", c.in, c.lang, got, c.want)
+		}
+	}
+
+	plain := LexicalFold{}
+	for _, c := range []struct{ in, lang, want string }{
+		{"ILIK", "tr", "ılık"},
+		{"ILIK", "", "ilik"},
+		{"İzmir", "tr-TR", "izmir"},
+		{"Müller", "de", "müller"},
+	} {
+		if got := plain.text(c.in, c.lang); got != c.want {
+			t.Errorf("fold without diacritics(%q, %q) = %q, want %q", c.in, c.lang, got, c.want)
+		}
+	}
+}
+
+func TestLexicalFold_TurkishSearch(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("tr", core.DefaultBounds()))
+	kadikoy, _ := e.AddNeuron("Kadıköy iskelesinde buluştuk", nil, map[string]string{LangMetadataKey: "tr"})
+	fener, _ := e.AddNeuron("FENERBAHÇE maçı pazar günü", nil, nil)
+	ilik, _ := e.AddNeuron("ILIK SU İÇTİ", nil, map[string]string{LangMetadataKey: "tr"})
+
+	if _, ok := foldedHit(t, e, kadikoy, "kadikoy", SearchOptions{}); !ok {
+		t.Error("kadikoy should match Kadıköy")
+	}
+	if _, ok := foldedHit(t, e, fener, "Fenerbahce", SearchOptions{}); !ok {
+		t.Error("Fenerbahce should match FENERBAHÇE")
+	}
+	if _, ok := foldedHit(t, e, ilik, "ılık su içti", SearchOptions{Language: "tr"}); !ok {
+		t.Error("a Turkish query should match Turkish upper case")
+	}
+
+	// Without diacritic folding, the dotless ı survives and only Turkish
+	// casing maps I to it.
+	e.SetLexicalFold(LexicalFold{})
+	if _, ok := foldedHit(t, e, ilik, "ılık", SearchOptions{Language: "tr"}); !ok {
+		t.Error("ılık should match ILIK written in Turkish")
+	}
+	if _, ok := foldedHit(t, e, kadikoy, "KADIKÖY", SearchOptions{Language: "tr"}); !ok {
+		t.Error("KADIKÖY in Turkish should match Kadıköy")
+	}
+	if _, ok := foldedHit(t, e, kadikoy, "KADIKÖY", SearchOptions{}); ok {
+		t.Error("KADIKÖY folded the default way is kadiköy and should not match as a phrase")
+	}
+	if _, ok := foldedHit(t, e, kadikoy, "kadikoy", SearchOptions{}); ok {
+		t.Error("kadikoy should not match Kadıköy without diacritic folding")
+	}
+}
+
+func TestLexicalFold_GermanUmlauts(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("de", core.DefaultBounds()))
+	n, _ := e.AddNeuron("Frau Müller wohnt in Köln", nil, map[string]string{LangMetadataKey: "de"})
+
+	b, ok := foldedHit(t, e, n, "muller wohnt in koln", SearchOptions{})
+	if !ok {
+		t.Fatal("muller wohnt in koln should match Müller wohnt in Köln with folding")
+	}
+	if len(b.Terms) != 4 || b.Terms[0].Term != "muller" || b.Terms[0].TF != 1 || b.Terms[3].Term != "koln" || b.Terms[3].TF != 1 {
+		t.Errorf("expected the folded terms to match, got %+v", b.Terms)
+	}
+	b, _ = foldedHit(t, e, n, "MÜLLER", SearchOptions{})
+	if len(b.Terms) != 1 || b.Terms[0].Term != "muller" || b.Terms[0].Original != "MÜLLER" {
+		t.Errorf("expected explain to show the query term before and after folding, got %+v", b.Terms)
+	}
+	if !strings.Contains(strings.Join(n.LexicalTokens, " "), "muller") {
+		t.Errorf("expected folded tokens stored on the neuron, got %v", n.LexicalTokens)
+	}
+
+	// Folding off: the umlaut is contrastive, and the stored tokens and term
+	// statistics are rebuilt by the next search.
+	e.SetLexicalFold(LexicalFold{})
+	if _, ok := foldedHit(t, e, n, "muller", SearchOptions{}); ok {
+		t.Error("muller should not match Müller as a phrase without folding")
+	}
+	if b, ok := foldedHit(t, e, n, "müller", SearchOptions{}); !ok || b.Terms[0].TF != 1 {
+		t.Errorf("müller should match Müller, got %+v", b)
+	}
+	if !strings.Contains(strings.Join(n.LexicalTokens, " "), "müller") || e.matrix.Terms.Fold != "lower" {
+		t.Errorf("expected tokens and statistics rebuilt without folding, got %v under %q", n.LexicalTokens, e.matrix.Terms.Fold)
+	}
+}
+
+func TestLexicalFold_CaseSensitiveExact(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("case", core.DefaultBounds()))
+	upper, _ := e.AddNeuron("Apple announced a new phone", nil, nil)
+	lower, _ := e.AddNeuron("an apple a day keeps the doctor away", nil, nil)
+
+	exact := SearchOptions{Mode: SearchModeExact}
+	if _, ok := foldedHit(t, e, lower, "Apple", exact); !ok {
+		t.Error("exact mode folds case by default")
+	}
+
+	exact.CaseSensitive = true
+	if _, ok := foldedHit(t, e, upper, "Apple", exact); !ok {
+		t.Error("a case-sensitive search should match Apple as written")
+	}
+	results, _ := e.PeekSearch(e.traceContext(), "Apple", 0, 10, nil, false, nil, exact)
+	for _, r := range results {
+		if r.ID == lower.ID {
+			t.Error("a case-sensitive search should not match apple")
+		}
+	}
+
+	// Case sensitivity is an exact-mode option.
+	if _, ok := foldedHit(t, e, lower, "Apple", SearchOptions{CaseSensitive: true}); !ok {
+		t.Error("the default mode should ignore CaseSensitive")
+	}
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// ForgetReport lists the neurons ForgetByMetadata removed: Deleted were
+// live, Purged were already in the recycle bin.
+type ForgetReport struct {
+	Deleted []core.NeuronID `json:"deleted"`
+	Purged  []core.NeuronID `json:"purged"`
+}
+
+// Count is the number of neurons removed.
+func (r ForgetReport) Count() int {
+	return len(r.Deleted) + len(r.Purged)
+}
+
+// ForgetByMetadata removes for good, bypassing the recycle bin, every
+// neuron whose metadata holds all of the pairs in metadata, together with
+// its synapses, and purges the recycled neurons that match too. An empty
+// filter removes nothing rather than everything.
+func (e *MatrixEngine) ForgetByMetadata(metadata map[string]string) ForgetReport {
+	report := ForgetReport{Deleted: []core.NeuronID{}, Purged: []core.NeuronID{}}
+	if len(metadata) == 0 {
+		return report
+	}
+	filter := SliceFilter{Metadata: metadata, Strict: true}
+
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	var live []core.NeuronID
+	for id, n := range e.matrix.Neurons {
+		if filter.matches(n) {
+			live = append(live, id)
+		}
+	}
+	for _, id := range live {
+		if e.deleteNeuronLocked(id) == nil {
+			report.Deleted = append(report.Deleted, id)
+		}
+	}
+	for id, r := range e.matrix.Recycled {
+		if filter.matches(r.Neuron) {
+			e.purgeRecycledLocked(id)
+			report.Purged = append(report.Purged, id)
+		}
+	}
+	if len(report.Purged) > 0 {
+		e.matrix.ModifiedAt = time.Now()
+		e.matrix.Version++
+	}
+	return report
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestForgetByMetadata(t *testing.T) {
+	m := core.NewMatrix("forget", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	e.SetRecycleWindow(time.Hour)
+
+	question, _ := e.AddNeuron("what is my blood type?", nil, map[string]string{"thread_id": "conv-1", "role": "user"})
+	answer, _ := e.AddNeuron("your records say O negative", nil, map[string]string{"thread_id": "conv-1", "role": "assistant"})
+	recycled, _ := e.AddNeuron("remind me to donate", nil, map[string]string{"thread_id": "conv-1", "role": "user"})
+	other, _ := e.AddNeuron("book the dentist", nil, map[string]string{"thread_id": "conv-2", "role": "user"})
+	syn := core.NewSynapse(answer.ID, other.ID, 0.6)
+	m.Synapses[syn.ID] = syn
+	m.Adjacency[answer.ID] = append(m.Adjacency[answer.ID], other.ID)
+	m.Adjacency[other.ID] = append(m.Adjacency[other.ID], answer.ID)
+	if err := e.ForgetNeuron(recycled.ID, RecycleReasonForget); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := e.ForgetByMetadata(nil); r.Count() != 0 || len(m.Neurons) != 3 {
+		t.Fatalf("an empty filter must remove nothing, got %+v", r)
+	}
+
+	// Every pair must match.
+	r := e.ForgetByMetadata(map[string]string{"thread_id": "conv-1", "role": "user"})
+	if len(r.Deleted) != 1 || r.Deleted[0] != question.ID || len(r.Purged) != 1 || r.Purged[0] != recycled.ID {
+		t.Fatalf("expected the user turn and its recycled sibling, got %+v", r)
+	}
+
+	r = e.ForgetByMetadata(map[string]string{"thread_id": "conv-1"})
+	if len(r.Deleted) != 1 || r.Deleted[0] != answer.ID || len(r.Purged) != 0 {
+		t.Fatalf("expected the remaining turn of conv-1, got %+v", r)
+	}
+	if _, ok := m.Neurons[other.ID]; !ok || len(m.Neurons) != 1 {
+		t.Errorf("expected only conv-2 left, got %d neurons", len(m.Neurons))
+	}
+	if len(m.Synapses) != 0 || len(m.Adjacency[other.ID]) != 0 {
+		t.Errorf("expected the synapses of forgotten neurons removed, got %d", len(m.Synapses))
+	}
+	if len(m.Recycled) != 0 {
+		t.Errorf("expected the recycle bin emptied of matches, got %d", len(m.Recycled))
+	}
+	if res := e.Search("blood type", 3, 10, nil, false); len(res) != 0 {
+		t.Errorf("expected forgotten content unsearchable, got %d results", len(res))
+	}
+}
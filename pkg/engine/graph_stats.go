@@ -0,0 +1,278 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+const (
+	// DefaultClusteringSampleSize bounds how many neurons the clustering
+	// coefficient is averaged over. Smaller matrices are computed exactly.
+	DefaultClusteringSampleSize = 256
+
+	// clusteringPairCap bounds the neighbour pairs checked per neuron. Hubs
+	// with more pairs than this are estimated from a random pair sample.
+	clusteringPairCap = 4096
+)
+
+// WeightDistribution summarizes synapse weights.
+type WeightDistribution struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+	P10  float64 `json:"p10"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+}
+
+// GraphStats describes the health of a matrix's synapse graph. Synapses are
+// treated as undirected edges.
+type GraphStats struct {
+	Neurons               int                `json:"neurons"`
+	Synapses              int                `json:"synapses"`
+	AverageDegree         float64            `json:"averageDegree"`
+	MaxDegree             int                `json:"maxDegree"`
+	IsolatedNeurons       int                `json:"isolatedNeurons"`
+	Components            int                `json:"components"`
+	LargestComponent      int                `json:"largestComponent"`
+	ClusteringCoefficient float64            `json:"clusteringCoefficient"`
+	ClusteringSampled     int                `json:"clusteringSampled"` // neurons examined; equals Neurons when exact
+	Weights               WeightDistribution `json:"weights"`
+	Version               uint64             `json:"version"` // matrix generation these stats describe
+	ComputedAt            time.Time          `json:"computedAt"`
+
+	// MetadataIndex describes the inverted index over indexed metadata
+	// keys; nil when none are indexed.
+	MetadataIndex *core.MetadataIndexStats `json:"metadataIndex,omitempty"`
+}
+
+// GraphStats returns graph health statistics, recomputing them only when the
+// matrix generation has changed since the last call. Weight-only updates from
+// Hebbian strengthening do not bump the generation, so weights may lag until
+// the next structural change.
+func (e *MatrixEngine) GraphStats() GraphStats {
+	e.ensureMetadataIndex()
+	e.matrix.RLock()
+	version, index := e.matrix.Version, e.matrix.MetadataIndex
+	e.matrix.RUnlock()
+
+	e.graphStatsMu.Lock()
+	defer e.graphStatsMu.Unlock()
+
+	if e.graphStats != nil && e.graphStats.Version == version && e.graphStatsIndex == index {
+		return *e.graphStats
+	}
+
+	e.matrix.RLock()
+	stats := ComputeGraphStats(e.matrix, DefaultClusteringSampleSize)
+	index = e.matrix.MetadataIndex
+	e.matrix.RUnlock()
+
+	e.graphStats = &stats
+	e.graphStatsIndex = index
+	return stats
+}
+
+// ComputeGraphStats computes graph statistics for m. The caller must hold at
+// least a read lock on m. Sampling is seeded from the matrix version so the
+// same generation always yields the same estimate.
+func ComputeGraphStats(m *core.Matrix, sampleSize int) GraphStats {
+	stats := GraphStats{
+		Neurons:    len(m.Neurons),
+		Version:    m.Version,
+		ComputedAt: time.Now(),
+	}
+
+	ids := make([]core.NeuronID, 0, len(m.Neurons))
+	for id := range m.Neurons {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// Undirected neighbour sets, ignoring self-loops, duplicate edges and
+	// synapses whose endpoints no longer exist.
+	neighbours := make(map[core.NeuronID]map[core.NeuronID]struct{}, len(ids))
+	weights := make([]float64, 0, len(m.Synapses))
+	edges := 0
+	for _, syn := range m.Synapses {
+		weights = append(weights, syn.Weight)
+		if syn.FromID == syn.ToID {
+			continue
+		}
+		if _, ok := m.Neurons[syn.FromID]; !ok {
+			continue
+		}
+		if _, ok := m.Neurons[syn.ToID]; !ok {
+			continue
+		}
+		if addNeighbour(neighbours, syn.FromID, syn.ToID) {
+			addNeighbour(neighbours, syn.ToID, syn.FromID)
+			edges++
+		}
+	}
+	stats.Synapses = len(m.Synapses)
+	stats.Weights = weightDistribution(weights)
+	if m.MetadataIndex != nil {
+		x := m.MetadataIndex.Stats()
+		stats.MetadataIndex = &x
+	}
+
+	if len(ids) == 0 {
+		return stats
+	}
+
+	for _, id := range ids {
+		deg := len(neighbours[id])
+		if deg == 0 {
+			stats.IsolatedNeurons++
+		}
+		if deg > stats.MaxDegree {
+			stats.MaxDegree = deg
+		}
+	}
+	stats.AverageDegree = 2 * float64(edges) / float64(len(ids))
+	stats.Components, stats.LargestComponent = connectedComponents(ids, neighbours)
+
+	rng := rand.New(rand.NewSource(int64(m.Version)))
+	sample := ids
+	if sampleSize > 0 && len(ids) > sampleSize {
+		sample = make([]core.NeuronID, len(ids))
+		copy(sample, ids)
+		rng.Shuffle(len(sample), func(i, j int) { sample[i], sample[j] = sample[j], sample[i] })
+		sample = sample[:sampleSize]
+	}
+	total := 0.0
+	for _, id := range sample {
+		total += localClustering(neighbours[id], neighbours, rng)
+	}
+	stats.ClusteringSampled = len(sample)
+	stats.ClusteringCoefficient = total / float64(len(sample))
+
+	return stats
+}
+
+// addNeighbour records to as a neighbour of from, reporting whether it was new.
+func addNeighbour(neighbours map[core.NeuronID]map[core.NeuronID]struct{}, from, to core.NeuronID) bool {
+	set, ok := neighbours[from]
+	if !ok {
+		set = make(map[core.NeuronID]struct{})
+		neighbours[from] = set
+	}
+	if _, exists := set[to]; exists {
+		return false
+	}
+	set[to] = struct{}{}
+	return true
+}
+
+// connectedComponents counts components with an iterative DFS and returns
+// the count and the size of the largest one. Isolated neurons are singletons.
+func connectedComponents(ids []core.NeuronID, neighbours map[core.NeuronID]map[core.NeuronID]struct{}) (int, int) {
+	visited := make(map[core.NeuronID]bool, len(ids))
+	components, largest := 0, 0
+	var stack []core.NeuronID
+
+	for _, start := range ids {
+		if visited[start] {
+			continue
+		}
+		components++
+		size := 0
+		visited[start] = true
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			for next := range neighbours[id] {
+				if !visited[next] {
+					visited[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		if size > largest {
+			largest = size
+		}
+	}
+	return components, largest
+}
+
+// localClustering returns the fraction of neighbour pairs that are themselves
+// connected. Neurons with fewer than two neighbours score 0.
+func localClustering(own map[core.NeuronID]struct{}, neighbours map[core.NeuronID]map[core.NeuronID]struct{}, rng *rand.Rand) float64 {
+	k := len(own)
+	if k < 2 {
+		return 0
+	}
+
+	nbrs := make([]core.NeuronID, 0, k)
+	for id := range own {
+		nbrs = append(nbrs, id)
+	}
+	sort.Slice(nbrs, func(i, j int) bool { return nbrs[i] < nbrs[j] })
+
+	pairs := k * (k - 1) / 2
+	if pairs <= clusteringPairCap {
+		linked := 0
+		for i := 0; i < k; i++ {
+			for j := i + 1; j < k; j++ {
+				if _, ok := neighbours[nbrs[i]][nbrs[j]]; ok {
+					linked++
+				}
+			}
+		}
+		return float64(linked) / float64(pairs)
+	}
+
+	linked := 0
+	for n := 0; n < clusteringPairCap; n++ {
+		i := rng.Intn(k)
+		j := rng.Intn(k - 1)
+		if j >= i {
+			j++
+		}
+		if _, ok := neighbours[nbrs[i]][nbrs[j]]; ok {
+			linked++
+		}
+	}
+	return float64(linked) / float64(clusteringPairCap)
+}
+
+func weightDistribution(weights []float64) WeightDistribution {
+	if len(weights) == 0 {
+		return WeightDistribution{}
+	}
+	sort.Float64s(weights)
+
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	return WeightDistribution{
+		Min:  weights[0],
+		Max:  weights[len(weights)-1],
+		Mean: sum / float64(len(weights)),
+		P10:  percentile(weights, 0.10),
+		P50:  percentile(weights, 0.50),
+		P90:  percentile(weights, 0.90),
+		P99:  percentile(weights, 0.99),
+	}
+}
+
+// percentile uses nearest-rank on an ascending slice.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
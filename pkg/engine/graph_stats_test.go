@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// buildGraph creates neurons n0..n(count-1) and links the given pairs with
+// the given weights. Neurons are inserted directly so IDs are predictable.
+func buildGraph(count int, edges [][2]int, weights []float64) *core.Matrix {
+	m := newTestMatrix()
+	for i := 0; i < count; i++ {
+		id := core.NeuronID(fmt.Sprintf("n%d", i))
+		m.Neurons[id] = &core.Neuron{ID: id, Content: string(id)}
+	}
+	for i, e := range edges {
+		from := core.NeuronID(fmt.Sprintf("n%d", e[0]))
+		to := core.NeuronID(fmt.Sprintf("n%d", e[1]))
+		w := 0.5
+		if i < len(weights) {
+			w = weights[i]
+		}
+		syn := core.NewSynapse(from, to, w)
+		m.Synapses[syn.ID] = syn
+		m.Adjacency[from] = append(m.Adjacency[from], to)
+		m.Adjacency[to] = append(m.Adjacency[to], from)
+	}
+	return m
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestGraphStatsTriangleWithTail(t *testing.T) {
+	// Triangle n0-n1-n2, tail n2-n3, isolated n4.
+	m := buildGraph(5, [][2]int{{0, 1}, {1, 2}, {0, 2}, {2, 3}}, []float64{0.1, 0.2, 0.3, 0.4})
+	stats := ComputeGraphStats(m, 0)
+
+	if stats.Neurons != 5 || stats.Synapses != 4 {
+		t.Errorf("Expected 5 neurons and 4 synapses, got %d/%d", stats.Neurons, stats.Synapses)
+	}
+	if !approx(stats.AverageDegree, 8.0/5) {
+		t.Errorf("Expected average degree 1.6, got %f", stats.AverageDegree)
+	}
+	if stats.MaxDegree != 3 {
+		t.Errorf("Expected max degree 3, got %d", stats.MaxDegree)
+	}
+	if stats.IsolatedNeurons != 1 {
+		t.Errorf("Expected 1 isolated neuron, got %d", stats.IsolatedNeurons)
+	}
+	if stats.Components != 2 || stats.LargestComponent != 4 {
+		t.Errorf("Expected 2 components (largest 4), got %d (largest %d)", stats.Components, stats.LargestComponent)
+	}
+	// Local clustering: n0=1, n1=1, n2=1/3, n3=0, n4=0.
+	if want := (1 + 1 + 1.0/3) / 5; !approx(stats.ClusteringCoefficient, want) {
+		t.Errorf("Expected clustering %f, got %f", want, stats.ClusteringCoefficient)
+	}
+	if stats.ClusteringSampled != 5 {
+		t.Errorf("Expected exact clustering over 5 neurons, got %d", stats.ClusteringSampled)
+	}
+
+	w := stats.Weights
+	if !approx(w.Min, 0.1) || !approx(w.Max, 0.4) || !approx(w.Mean, 0.25) {
+		t.Errorf("Unexpected min/max/mean: %+v", w)
+	}
+	if !approx(w.P50, 0.2) || !approx(w.P90, 0.4) || !approx(w.P10, 0.1) {
+		t.Errorf("Unexpected percentiles: %+v", w)
+	}
+}
+
+func TestGraphStatsCompleteGraph(t *testing.T) {
+	var edges [][2]int
+	for i := 0; i < 4; i++ {
+		for j := i + 1; j < 4; j++ {
+			edges = append(edges, [2]int{i, j})
+		}
+	}
+	stats := ComputeGraphStats(buildGraph(4, edges, nil), 0)
+
+	if !approx(stats.ClusteringCoefficient, 1) {
+		t.Errorf("Expected clustering 1 for K4, got %f", stats.ClusteringCoefficient)
+	}
+	if !approx(stats.AverageDegree, 3) || stats.Components != 1 || stats.IsolatedNeurons != 0 {
+		t.Errorf("Unexpected K4 stats: %+v", stats)
+	}
+}
+
+func TestGraphStatsStarHasZeroClustering(t *testing.T) {
+	stats := ComputeGraphStats(buildGraph(5, [][2]int{{0, 1}, {0, 2}, {0, 3}, {0, 4}}, nil), 0)
+
+	if stats.ClusteringCoefficient != 0 {
+		t.Errorf("Expected clustering 0 for a star, got %f", stats.ClusteringCoefficient)
+	}
+	if stats.MaxDegree != 4 || stats.Components != 1 {
+		t.Errorf("Unexpected star stats: %+v", stats)
+	}
+}
+
+func TestGraphStatsEmptyMatrix(t *testing.T) {
+	stats := ComputeGraphStats(newTestMatrix(), 0)
+	if stats.Neurons != 0 || stats.Components != 0 || stats.ClusteringCoefficient != 0 {
+		t.Errorf("Expected zero stats for empty matrix, got %+v", stats)
+	}
+}
+
+func TestGraphStatsSamplesLargeMatrices(t *testing.T) {
+	// Disjoint triangles: every neuron has local clustering 1, so any sample
+	// yields exactly 1.
+	var edges [][2]int
+	for i := 0; i < 30; i += 3 {
+		edges = append(edges, [2]int{i, i + 1}, [2]int{i + 1, i + 2}, [2]int{i, i + 2})
+	}
+	stats := ComputeGraphStats(buildGraph(30, edges, nil), 8)
+
+	if stats.ClusteringSampled != 8 {
+		t.Errorf("Expected 8 sampled neurons, got %d", stats.ClusteringSampled)
+	}
+	if !approx(stats.ClusteringCoefficient, 1) {
+		t.Errorf("Expected clustering 1 for disjoint triangles, got %f", stats.ClusteringCoefficient)
+	}
+	if stats.Components != 10 || stats.LargestComponent != 3 {
+		t.Errorf("Expected 10 components of 3, got %d (largest %d)", stats.Components, stats.LargestComponent)
+	}
+}
+
+func TestGraphStatsCachedPerVersion(t *testing.T) {
+	m := buildGraph(3, [][2]int{{0, 1}}, nil)
+	e := NewMatrixEngine(m)
+
+	first := e.GraphStats()
+	if first.IsolatedNeurons != 1 {
+		t.Fatalf("Expected 1 isolated neuron, got %d", first.IsolatedNeurons)
+	}
+
+	// Mutate without bumping the version: the cached result is served.
+	syn := core.NewSynapse("n1", "n2", 0.5)
+	m.Synapses[syn.ID] = syn
+	if cached := e.GraphStats(); cached.IsolatedNeurons != 1 || !cached.ComputedAt.Equal(first.ComputedAt) {
+		t.Errorf("Expected cached stats for unchanged version, got %+v", cached)
+	}
+
+	m.Version++
+	if fresh := e.GraphStats(); fresh.IsolatedNeurons != 0 || fresh.Version != m.Version {
+		t.Errorf("Expected recomputed stats after version bump, got %+v", fresh)
+	}
+}
@@ -0,0 +1,370 @@
+package engine
+
+import (
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+const (
+	// DefaultSummaryCells is the grid side of a graph summary.
+	DefaultSummaryCells = 32
+	// MaxSummaryCells bounds the grid side, and so the response size.
+	MaxSummaryCells = 128
+
+	// pcaIterations bounds the power iterations per principal component.
+	pcaIterations = 100
+	// summarySampleLength is how many runes of a cell's sample are kept.
+	summarySampleLength = 80
+)
+
+// Projections a graph summary may place neurons with.
+const (
+	ProjectionPositions = "positions" // the first two position dimensions
+	ProjectionPCA       = "pca"       // the two principal components of all positions
+)
+
+// GraphCell aggregates the neurons that fall into one grid cell.
+type GraphCell struct {
+	Cell       int     `json:"cell"` // y*cells + x
+	X          int     `json:"x"`
+	Y          int     `json:"y"`
+	Neurons    int     `json:"neurons"`
+	MeanEnergy float64 `json:"meanEnergy"`
+	SampleID   string  `json:"sampleId"` // most energetic neuron, for drilling down
+	Sample     string  `json:"sample"`   // its content, truncated
+}
+
+// GraphBundle aggregates the synapses between two cells, or within one
+// when From equals To. Synapses are undirected, so From <= To.
+type GraphBundle struct {
+	From   int     `json:"from"`
+	To     int     `json:"to"`
+	Edges  int     `json:"edges"`
+	Weight float64 `json:"weight"`
+}
+
+// GraphSummary is a grid overview of a matrix whose size depends on the
+// grid, not on the number of neurons or synapses. Only non-empty cells are
+// listed. At most cells² bundles are kept, heaviest first; the rest are
+// counted in OmittedBundles and OmittedWeight, so bundle weights plus
+// OmittedWeight always add up to TotalWeight.
+type GraphSummary struct {
+	Cells          int           `json:"cells"`
+	Projection     string        `json:"projection"` // positions | pca
+	Neurons        int           `json:"neurons"`
+	Synapses       int           `json:"synapses"`    // synapses between existing neurons
+	TotalWeight    float64       `json:"totalWeight"` // of those synapses
+	Bounds         [4]float64    `json:"bounds"`      // minX, minY, maxX, maxY of the projection
+	Grid           []GraphCell   `json:"grid"`
+	Bundles        []GraphBundle `json:"bundles"`
+	OmittedBundles int           `json:"omittedBundles"`
+	OmittedWeight  float64       `json:"omittedWeight"`
+	Version        uint64        `json:"version"` // matrix generation summarized
+	ComputedAt     time.Time     `json:"computedAt"`
+}
+
+// projection maps positions onto a plane: p · axes[i] after subtracting
+// mean. It is cached per matrix generation.
+type projection struct {
+	kind    string
+	mean    []float64
+	axes    [2][]float64
+	version uint64
+}
+
+func (p *projection) apply(pos []float64) (float64, float64) {
+	var xy [2]float64
+	for i, axis := range p.axes {
+		for d, a := range axis {
+			if d < len(pos) {
+				xy[i] += (pos[d] - p.mean[d]) * a
+			}
+		}
+	}
+	return xy[0], xy[1]
+}
+
+// GraphSummary buckets the matrix into a cells × cells grid over its
+// neurons' positions and bundles the synapses between cells. Matrices of up
+// to two dimensions are gridded on their positions; higher ones on their
+// two principal components. Only synapses of the given types are bundled,
+// all without types.
+func (e *MatrixEngine) GraphSummary(cells int, types []string) GraphSummary {
+	if cells <= 0 {
+		cells = DefaultSummaryCells
+	}
+	if cells > MaxSummaryCells {
+		cells = MaxSummaryCells
+	}
+
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	proj := e.projectionLocked()
+	summary := GraphSummary{
+		Cells:      cells,
+		Projection: proj.kind,
+		Neurons:    len(e.matrix.Neurons),
+		Version:    e.matrix.Version,
+		ComputedAt: time.Now(),
+		Grid:       []GraphCell{},
+		Bundles:    []GraphBundle{},
+	}
+	if len(e.matrix.Neurons) == 0 {
+		return summary
+	}
+
+	ids := make([]core.NeuronID, 0, len(e.matrix.Neurons))
+	for id := range e.matrix.Neurons {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	xs := make([]float64, len(ids))
+	ys := make([]float64, len(ids))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, id := range ids {
+		xs[i], ys[i] = proj.apply(e.matrix.Neurons[id].Position)
+		minX, maxX = math.Min(minX, xs[i]), math.Max(maxX, xs[i])
+		minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+	}
+	summary.Bounds = [4]float64{minX, minY, maxX, maxY}
+
+	type cellAgg struct {
+		neurons int
+		energy  float64
+		sample  *core.Neuron
+	}
+	aggs := make(map[int]*cellAgg)
+	cellOf := make(map[core.NeuronID]int, len(ids))
+	for i, id := range ids {
+		n := e.matrix.Neurons[id]
+		cell := gridIndex(ys[i], minY, maxY, cells)*cells + gridIndex(xs[i], minX, maxX, cells)
+		cellOf[id] = cell
+		agg := aggs[cell]
+		if agg == nil {
+			agg = &cellAgg{}
+			aggs[cell] = agg
+		}
+		agg.neurons++
+		agg.energy += n.Energy
+		if agg.sample == nil || n.Energy > agg.sample.Energy {
+			agg.sample = n
+		}
+	}
+	for cell, agg := range aggs {
+		summary.Grid = append(summary.Grid, GraphCell{
+			Cell:       cell,
+			X:          cell % cells,
+			Y:          cell / cells,
+			Neurons:    agg.neurons,
+			MeanEnergy: agg.energy / float64(agg.neurons),
+			SampleID:   string(agg.sample.ID),
+			Sample:     truncateRunes(agg.sample.Content, summarySampleLength),
+		})
+	}
+	sort.Slice(summary.Grid, func(i, j int) bool { return summary.Grid[i].Cell < summary.Grid[j].Cell })
+
+	bundles := make(map[[2]int]*GraphBundle)
+	for _, syn := range e.matrix.Synapses {
+		if !core.SynapseTypeIn(syn.Type, types) {
+			continue
+		}
+		from, okFrom := cellOf[syn.FromID]
+		to, okTo := cellOf[syn.ToID]
+		if !okFrom || !okTo {
+			continue
+		}
+		if from > to {
+			from, to = to, from
+		}
+		b := bundles[[2]int{from, to}]
+		if b == nil {
+			b = &GraphBundle{From: from, To: to}
+			bundles[[2]int{from, to}] = b
+		}
+		b.Edges++
+		b.Weight += syn.Weight
+		summary.Synapses++
+		summary.TotalWeight += syn.Weight
+	}
+	for _, b := range bundles {
+		summary.Bundles = append(summary.Bundles, *b)
+	}
+	sort.Slice(summary.Bundles, func(i, j int) bool {
+		a, b := summary.Bundles[i], summary.Bundles[j]
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	if max := cells * cells; len(summary.Bundles) > max {
+		for _, b := range summary.Bundles[max:] {
+			summary.OmittedBundles++
+			summary.OmittedWeight += b.Weight
+		}
+		summary.Bundles = summary.Bundles[:max]
+	}
+	return summary
+}
+
+// gridIndex maps v in [min, max] onto one of cells buckets.
+func gridIndex(v, min, max float64, cells int) int {
+	if max <= min {
+		return 0
+	}
+	i := int((v - min) / (max - min) * float64(cells))
+	if i >= cells {
+		i = cells - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// projectionLocked returns the projection for the current matrix
+// generation, computing it if needed. The caller must hold at least a read
+// lock on the matrix.
+func (e *MatrixEngine) projectionLocked() *projection {
+	e.projectionMu.Lock()
+	defer e.projectionMu.Unlock()
+
+	if e.projection != nil && e.projection.version == e.matrix.Version {
+		return e.projection
+	}
+
+	dim := e.matrix.CurrentDim
+	for _, n := range e.matrix.Neurons {
+		if len(n.Position) > dim {
+			dim = len(n.Position)
+		}
+	}
+	p := &projection{kind: ProjectionPositions, mean: make([]float64, dim), version: e.matrix.Version}
+	if dim <= 2 {
+		for i := range p.axes {
+			p.axes[i] = make([]float64, dim)
+			if i < dim {
+				p.axes[i][i] = 1
+			}
+		}
+	} else {
+		p.kind = ProjectionPCA
+		p.axes = principalAxes(e.matrix.Neurons, p.mean)
+	}
+	e.projection = p
+	return p
+}
+
+// principalAxes fills mean with the mean position and returns the two
+// leading principal components of the positions, found by power iteration
+// on the covariance matrix with deflation. Iteration is bounded and starts
+// from a fixed vector, so the same positions always give the same axes.
+func principalAxes(neurons map[core.NeuronID]*core.Neuron, mean []float64) [2][]float64 {
+	dim := len(mean)
+	for _, n := range neurons {
+		for d := 0; d < dim && d < len(n.Position); d++ {
+			mean[d] += n.Position[d]
+		}
+	}
+	for d := range mean {
+		mean[d] /= float64(len(neurons))
+	}
+
+	cov := make([][]float64, dim)
+	for i := range cov {
+		cov[i] = make([]float64, dim)
+	}
+	centered := make([]float64, dim)
+	for _, n := range neurons {
+		for d := range centered {
+			centered[d] = -mean[d]
+			if d < len(n.Position) {
+				centered[d] += n.Position[d]
+			}
+		}
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				cov[i][j] += centered[i] * centered[j]
+			}
+		}
+	}
+	for i := 0; i < dim; i++ {
+		for j := 0; j < i; j++ {
+			cov[i][j] = cov[j][i]
+		}
+	}
+
+	var axes [2][]float64
+	for k := range axes {
+		v := make([]float64, dim)
+		for d := range v {
+			v[d] = 1 / math.Sqrt(float64(dim))
+		}
+		// Start off-axis from the first component so deflation leaves
+		// something to find.
+		if k == 1 {
+			v[0] = -v[0]
+		}
+		eigen := 0.0
+		for iter := 0; iter < pcaIterations; iter++ {
+			next := make([]float64, dim)
+			for i := 0; i < dim; i++ {
+				for j := 0; j < dim; j++ {
+					next[i] += cov[i][j] * v[j]
+				}
+			}
+			norm := vectorNorm(next)
+			if norm == 0 {
+				break
+			}
+			for d := range next {
+				next[d] /= norm
+			}
+			converged := math.Abs(norm-eigen) <= 1e-9*norm
+			v, eigen = next, norm
+			if converged {
+				break
+			}
+		}
+		if vectorNorm(v) == 0 || eigen == 0 {
+			// No variance left: fall back to a coordinate axis.
+			v = make([]float64, dim)
+			v[k] = 1
+		}
+		axes[k] = v
+
+		// Deflate: remove this component from the covariance.
+		for i := 0; i < dim; i++ {
+			for j := 0; j < dim; j++ {
+				cov[i][j] -= eigen * v[i] * v[j]
+			}
+		}
+	}
+	return axes
+}
+
+func vectorNorm(v []float64) float64 {
+	sum := 0.0
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
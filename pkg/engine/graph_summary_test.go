@@ -0,0 +1,155 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/testutil"
+)
+
+func totalSynapseWeight(m *core.Matrix) float64 {
+	total := 0.0
+	for _, syn := range m.Synapses {
+		total += syn.Weight
+	}
+	return total
+}
+
+func TestGraphSummary_ConservesNeuronsAndWeight(t *testing.T) {
+	brain := testutil.NewSyntheticBrain(7, testutil.SyntheticOptions{Neurons: 2000})
+	e := engine.NewMatrixEngine(brain.Matrix)
+	want := totalSynapseWeight(brain.Matrix)
+
+	for _, cells := range []int{1, 4, 16} {
+		summary := e.GraphSummary(cells, nil)
+		if summary.Projection != engine.ProjectionPCA || summary.Cells != cells {
+			t.Fatalf("cells=%d: unexpected summary header %+v", cells, summary)
+		}
+
+		neurons := 0
+		for _, c := range summary.Grid {
+			neurons += c.Neurons
+			if c.Cell != c.Y*cells+c.X || c.X >= cells || c.Y >= cells || c.Neurons == 0 {
+				t.Errorf("cells=%d: malformed cell %+v", cells, c)
+			}
+			if brain.Matrix.Neurons[core.NeuronID(c.SampleID)] == nil {
+				t.Errorf("cells=%d: sample %q is not a neuron", cells, c.SampleID)
+			}
+		}
+		if neurons != len(brain.Matrix.Neurons) || summary.Neurons != neurons {
+			t.Errorf("cells=%d: cell counts sum to %d, want %d", cells, neurons, len(brain.Matrix.Neurons))
+		}
+
+		weight, edges := summary.OmittedWeight, 0
+		for _, b := range summary.Bundles {
+			weight += b.Weight
+			edges += b.Edges
+			if b.From > b.To {
+				t.Errorf("cells=%d: bundle %d->%d is not normalized", cells, b.From, b.To)
+			}
+		}
+		if math.Abs(weight-want) > 1e-6*want || math.Abs(summary.TotalWeight-want) > 1e-6*want {
+			t.Errorf("cells=%d: bundled weight %f, total %f, want %f", cells, weight, summary.TotalWeight, want)
+		}
+		if summary.OmittedBundles == 0 && edges != len(brain.Matrix.Synapses) {
+			t.Errorf("cells=%d: bundles hold %d edges, want %d", cells, edges, len(brain.Matrix.Synapses))
+		}
+		if len(summary.Grid) > cells*cells || len(summary.Bundles) > cells*cells {
+			t.Errorf("cells=%d: %d cells and %d bundles exceed the grid", cells, len(summary.Grid), len(summary.Bundles))
+		}
+	}
+}
+
+func TestGraphSummary_SizeDependsOnGridNotMatrix(t *testing.T) {
+	const cells = 8
+	var sizes []int
+	for _, neurons := range []int{500, 5000} {
+		brain := testutil.NewSyntheticBrain(11, testutil.SyntheticOptions{Neurons: neurons})
+		body, err := json.Marshal(engine.NewMatrixEngine(brain.Matrix).GraphSummary(cells, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(body))
+	}
+
+	// A cell is well under 400 bytes with its 80-rune sample, a bundle
+	// under 100.
+	bound := cells*cells*(400+100) + 1024
+	for _, size := range sizes {
+		if size > bound {
+			t.Errorf("summary of %d bytes exceeds the %d byte bound", size, bound)
+		}
+	}
+	if sizes[1] > 2*sizes[0] {
+		t.Errorf("a 10x larger matrix should not double the summary: %v", sizes)
+	}
+}
+
+func TestGraphSummary_ProjectsOntoPrincipalAxis(t *testing.T) {
+	m := core.NewMatrix("pca", core.DefaultBounds())
+	for i := 0; i < 50; i++ {
+		n := core.NewNeuron(fmt.Sprintf("point %d", i), 3)
+		s := float64(i) / 49
+		// Points along (1, 2, 0) with a small wobble on the third axis.
+		n.Position = []float64{s, 2 * s, 0.01 * float64(i%2)}
+		m.Neurons[n.ID] = n
+	}
+	e := engine.NewMatrixEngine(m)
+	summary := e.GraphSummary(10, nil)
+
+	// Projected onto the line, the points spread evenly over the x axis
+	// while the wobble keeps y within a couple of cells.
+	if summary.Projection != engine.ProjectionPCA {
+		t.Fatalf("expected a PCA projection, got %q", summary.Projection)
+	}
+	xs := map[int]int{}
+	for _, c := range summary.Grid {
+		xs[c.X] += c.Neurons
+	}
+	if len(xs) != 10 {
+		t.Errorf("points along the principal axis should fill every column, got %v", xs)
+	}
+	if span := summary.Bounds[2] - summary.Bounds[0]; math.Abs(span-math.Sqrt(5)) > 0.01 {
+		t.Errorf("the principal axis should span the line's length sqrt(5), got %f", span)
+	}
+	if span := summary.Bounds[3] - summary.Bounds[1]; span > 0.02 {
+		t.Errorf("the second axis should only see the wobble, got %f", span)
+	}
+
+	// Turn the line to (2, -1, 0) without a new generation: the cached
+	// axes are kept, so it now projects onto the second axis.
+	for _, n := range m.Neurons {
+		n.Position[0], n.Position[1] = n.Position[1], -n.Position[0]
+	}
+	if b := e.GraphSummary(10, nil).Bounds; b[2]-b[0] > 0.1 {
+		t.Errorf("the projection should be cached per generation, got bounds %v", b)
+	}
+	m.Version++
+	if span := e.GraphSummary(10, nil).Bounds; math.Abs(span[2]-span[0]-math.Sqrt(5)) > 0.01 {
+		t.Errorf("a new generation should recompute the axes, got bounds %v", span)
+	}
+}
+
+func TestGraphSummary_TwoDimensionsUsePositions(t *testing.T) {
+	m := core.NewMatrix("flat", core.DefaultBounds())
+	m.CurrentDim = 2
+	for i, pos := range [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		n := core.NewNeuron(fmt.Sprintf("corner %d", i), 2)
+		n.Position = pos
+		m.Neurons[n.ID] = n
+	}
+	e := engine.NewMatrixEngine(m)
+	summary := e.GraphSummary(2, nil)
+	if summary.Projection != engine.ProjectionPositions || len(summary.Grid) != 4 {
+		t.Fatalf("expected one corner per cell on raw positions, got %+v", summary)
+	}
+
+	empty := engine.NewMatrixEngine(core.NewMatrix("empty", core.DefaultBounds())).GraphSummary(0, nil)
+	if empty.Cells != engine.DefaultSummaryCells || empty.Grid == nil || empty.Bundles == nil {
+		t.Errorf("an empty matrix should give an empty summary, got %+v", empty)
+	}
+}
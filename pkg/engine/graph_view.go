@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+const (
+	// activityWindow is how far back Activity looks for events.
+	activityWindow = 5 * time.Minute
+	// activityLimit bounds the events Activity returns.
+	activityLimit = 100
+	// activityDetailLength is how many bytes of content an event shows.
+	activityDetailLength = 50
+)
+
+// GraphNode is a neuron as drawn by graph visualizations.
+type GraphNode struct {
+	ID          string    `json:"id"`
+	Content     string    `json:"content"`
+	Energy      float64   `json:"energy"`
+	Depth       int       `json:"depth"`
+	AccessCount int       `json:"accessCount"`
+	Position    []float64 `json:"position"`
+	Compacted   bool      `json:"compacted,omitempty"`
+}
+
+// GraphEdge is a synapse as drawn by graph visualizations.
+type GraphEdge struct {
+	Source      string  `json:"source"`
+	Target      string  `json:"target"`
+	Weight      float64 `json:"weight"`
+	CoFireCount uint64  `json:"coFireCount"`
+	Score       float64 `json:"score"`
+	Type        string  `json:"type"`
+}
+
+// GraphDump is every neuron and synapse of a matrix, copied out so it can
+// be serialized without holding the matrix lock.
+type GraphDump struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// SynapseInfo is a synapse as listed by GET /v1/synapses.
+type SynapseInfo struct {
+	ID          string  `json:"id"`
+	FromID      string  `json:"from_id"`
+	ToID        string  `json:"to_id"`
+	Weight      float64 `json:"weight"`
+	CoFireCount uint64  `json:"co_fire_count"`
+	Score       float64 `json:"score"`
+	Type        string  `json:"type"`
+}
+
+// ActivityEvent is one recent neuron or synapse event.
+type ActivityEvent struct {
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"` // neuron | synapse
+	Action    string `json:"action"`
+	Details   string `json:"details"`
+}
+
+// GraphDump copies out every neuron and the synapses of the given types,
+// all without types; scores are the synapse retention scores from the
+// Hebbian engine.
+func (e *MatrixEngine) GraphDump(scores map[core.SynapseID]float64, types []string) GraphDump {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	dump := GraphDump{
+		Nodes: make([]GraphNode, 0, len(e.matrix.Neurons)),
+		Edges: make([]GraphEdge, 0, len(e.matrix.Synapses)),
+	}
+	for _, n := range e.matrix.Neurons {
+		// Fractal clustering moves neurons outside the matrix lock.
+		n.RLock()
+		dump.Nodes = append(dump.Nodes, GraphNode{
+			ID:          string(n.ID),
+			Content:     n.Content,
+			Energy:      n.Energy,
+			Depth:       n.Depth,
+			AccessCount: int(n.AccessCount),
+			Position:    append([]float64(nil), n.Position...),
+			Compacted:   core.IsCompacted(n),
+		})
+		n.RUnlock()
+	}
+	for _, syn := range e.matrix.Synapses {
+		if !core.SynapseTypeIn(syn.Type, types) {
+			continue
+		}
+		dump.Edges = append(dump.Edges, GraphEdge{
+			Source:      string(syn.FromID),
+			Target:      string(syn.ToID),
+			Weight:      syn.Weight,
+			CoFireCount: syn.CoFireCount,
+			Score:       scores[syn.ID],
+			Type:        syn.Type,
+		})
+	}
+	return dump
+}
+
+// Synapses copies out the synapses of the given types, all without types,
+// with their retention scores.
+func (e *MatrixEngine) Synapses(scores map[core.SynapseID]float64, types []string) []SynapseInfo {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	synapses := make([]SynapseInfo, 0, len(e.matrix.Synapses))
+	for _, syn := range e.matrix.Synapses {
+		if !core.SynapseTypeIn(syn.Type, types) {
+			continue
+		}
+		synapses = append(synapses, SynapseInfo{
+			ID:          string(syn.ID),
+			FromID:      string(syn.FromID),
+			ToID:        string(syn.ToID),
+			Weight:      syn.Weight,
+			CoFireCount: syn.CoFireCount,
+			Score:       scores[syn.ID],
+			Type:        syn.Type,
+		})
+	}
+	return synapses
+}
+
+// Activity returns the neurons fired or created and the synapses
+// strengthened or formed in the five minutes before now: the latest 100,
+// oldest first.
+func (e *MatrixEngine) Activity(now time.Time) []ActivityEvent {
+	e.matrix.RLock()
+	events := []ActivityEvent{}
+	recent := func(t time.Time) bool { return now.Sub(t) < activityWindow }
+	for _, n := range e.matrix.Neurons {
+		if recent(n.LastFiredAt) {
+			events = append(events, ActivityEvent{
+				Timestamp: n.LastFiredAt.Format(time.RFC3339),
+				Type:      "neuron",
+				Action:    "FIRED",
+				Details:   truncateBytes(n.Content, activityDetailLength),
+			})
+		}
+		if recent(n.CreatedAt) {
+			events = append(events, ActivityEvent{
+				Timestamp: n.CreatedAt.Format(time.RFC3339),
+				Type:      "neuron",
+				Action:    "CREATED",
+				Details:   truncateBytes(n.Content, activityDetailLength),
+			})
+		}
+	}
+	for _, syn := range e.matrix.Synapses {
+		if recent(syn.LastCoFire) {
+			events = append(events, ActivityEvent{
+				Timestamp: syn.LastCoFire.Format(time.RFC3339),
+				Type:      "synapse",
+				Action:    fmt.Sprintf("STRENGTHENED (%.2f)", syn.Weight),
+				Details:   fmt.Sprintf("co-fired %d times", syn.CoFireCount),
+			})
+		}
+		if recent(syn.CreatedAt) {
+			events = append(events, ActivityEvent{
+				Timestamp: syn.CreatedAt.Format(time.RFC3339),
+				Type:      "synapse",
+				Action:    "FORMED",
+				Details:   fmt.Sprintf("weight: %.2f", syn.Weight),
+			})
+		}
+	}
+	e.matrix.RUnlock()
+
+	// Latest first to keep the newest, then reversed to read like a log.
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp > events[j].Timestamp
+	})
+	if len(events) > activityLimit {
+		events = events[:activityLimit]
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events
+}
+
+// truncateBytes cuts s to n bytes, marking the cut with "...".
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Health component names, in the order a Health lists them.
+const (
+	HealthConsolidation = "consolidation"
+	HealthEnergy        = "energy"
+	HealthConnectivity  = "connectivity"
+	HealthConflicts     = "conflicts"
+	HealthFreshness     = "freshness"
+	HealthEmbeddings    = "embeddings"
+)
+
+// Health is an index's memory health score: the weighted mean of its
+// components, scaled to 0-100. FormulaVersion identifies the weights it
+// was computed with; scores of different versions are not comparable.
+type Health struct {
+	Score          float64           `json:"score"`
+	FormulaVersion string            `json:"formulaVersion"`
+	ComputedAt     time.Time         `json:"computedAt"`
+	Neurons        int               `json:"neurons"`
+	Components     []HealthComponent `json:"components"`
+}
+
+// HealthComponent is one term of a health score. Signal is the raw
+// measure, Value its normalization to 0-1 (higher is healthier), Weight
+// the component's share of the score and Points what it contributed.
+type HealthComponent struct {
+	Name   string  `json:"name"`
+	Signal float64 `json:"signal"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+	Points float64 `json:"points"`
+}
+
+// Health computes the index's health score under cfg at now. The
+// embeddings component counts only when embeddings is set, i.e. the index
+// uses the vector layer. It returns nil for an empty index, or when every
+// applicable component is weighted 0.
+func (e *MatrixEngine) Health(cfg core.HealthConfig, embeddings bool, now time.Time) *Health {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+	m := e.matrix
+
+	total := len(m.Neurons)
+	if total == 0 {
+		return nil
+	}
+
+	// Neurons with a synapse to another existing neuron.
+	linked := make(map[core.NeuronID]bool, total)
+	for _, syn := range m.Synapses {
+		if syn.FromID == syn.ToID {
+			continue
+		}
+		if _, ok := m.Neurons[syn.FromID]; !ok {
+			continue
+		}
+		if _, ok := m.Neurons[syn.ToID]; !ok {
+			continue
+		}
+		linked[syn.FromID], linked[syn.ToID] = true, true
+	}
+
+	consolidated, embedded, conflicted := 0, 0, 0
+	energy := 0.0
+	groups := make(map[string]bool)
+	for _, n := range m.Neurons {
+		if n.Depth >= 1 {
+			consolidated++
+		}
+		energy += n.Energy
+		if len(n.Embedding) > 0 && !n.EmbedPending {
+			embedded++
+		}
+		if g := conflictGroupOf(n); g != "" {
+			conflicted++
+			groups[g] = true
+		}
+	}
+	isolated := total - len(linked)
+	share := func(k int) float64 { return float64(k) / float64(total) }
+
+	age := now.Sub(m.LastActivity)
+	freshness := 0.0
+	if cfg.StaleAfter > 0 {
+		freshness = 1 - float64(max(age, 0))/float64(cfg.StaleAfter)
+	}
+
+	w := cfg.Weights
+	components := []HealthComponent{
+		{Name: HealthConsolidation, Signal: float64(consolidated), Value: share(consolidated), Weight: w.Consolidation},
+		{Name: HealthEnergy, Signal: energy / float64(total), Value: energy / float64(total), Weight: w.Energy},
+		{Name: HealthConnectivity, Signal: float64(isolated), Value: 1 - share(isolated), Weight: w.Connectivity},
+		{Name: HealthConflicts, Signal: float64(len(groups)), Value: 1 - share(conflicted), Weight: w.Conflicts},
+		{Name: HealthFreshness, Signal: max(age, 0).Hours(), Value: freshness, Weight: w.Freshness},
+	}
+	if embeddings {
+		components = append(components, HealthComponent{Name: HealthEmbeddings, Signal: float64(embedded), Value: share(embedded), Weight: w.Embeddings})
+	}
+
+	weight := 0.0
+	kept := components[:0]
+	for _, c := range components {
+		if c.Weight > 0 {
+			weight += c.Weight
+			kept = append(kept, c)
+		}
+	}
+	if weight == 0 {
+		return nil
+	}
+
+	h := &Health{FormulaVersion: cfg.FormulaVersion(), ComputedAt: now, Neurons: total, Components: kept}
+	for i := range kept {
+		c := &kept[i]
+		c.Value = clamp01(c.Value)
+		c.Weight /= weight
+		c.Points = 100 * c.Value * c.Weight
+		h.Score += c.Points
+	}
+	h.Score = math.Round(h.Score*10) / 10
+	return h
+}
+
+// clamp01 bounds v to [0, 1].
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
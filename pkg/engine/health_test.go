@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+var testHealthConfig = core.HealthConfig{
+	StaleAfter: 10 * 24 * time.Hour,
+	Weights: core.HealthWeights{
+		Consolidation: 0.2, Energy: 0.2, Connectivity: 0.2, Conflicts: 0.15, Freshness: 0.15, Embeddings: 0.1,
+	},
+}
+
+// healthFixture degrades the first k of ten otherwise healthy neurons in
+// one respect each; idle ages the index's last activity.
+type healthFixture struct {
+	shallow, lowEnergy, isolated, conflicted, unembedded int
+	idle                                                 time.Duration
+}
+
+// healthMatrix builds the fixture's matrix: neurons n0..n9, consolidated,
+// fully energized, embedded and chained together except where f degrades
+// them, last active f.idle before now.
+func healthMatrix(f healthFixture, now time.Time) *core.Matrix {
+	m := newTestMatrix()
+	for i := 0; i < 10; i++ {
+		id := core.NeuronID(fmt.Sprintf("n%d", i))
+		n := &core.Neuron{ID: id, Content: string(id), Depth: 1, Energy: 1, Embedding: []float32{1}, Metadata: map[string]any{}}
+		if i < f.shallow {
+			n.Depth = 0
+		}
+		if i < f.lowEnergy {
+			n.Energy = 0.1
+		}
+		if i < f.unembedded {
+			n.Embedding = nil
+		}
+		if i < f.conflicted {
+			n.Metadata[ConflictGroupKey] = fmt.Sprintf("g%d", i/2)
+		}
+		m.Neurons[id] = n
+		if i > f.isolated {
+			syn := core.NewSynapse(core.NeuronID(fmt.Sprintf("n%d", i-1)), id, 0.5)
+			m.Synapses[syn.ID] = syn
+		}
+	}
+	m.LastActivity = now.Add(-f.idle)
+	return m
+}
+
+func healthComponent(h *Health, name string) (HealthComponent, bool) {
+	for _, c := range h.Components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return HealthComponent{}, false
+}
+
+func TestHealth_EmptyIndex(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	if h := e.Health(testHealthConfig, true, time.Now()); h != nil {
+		t.Errorf("expected no score for an empty index, got %+v", h)
+	}
+}
+
+func TestHealth_HealthyIndex(t *testing.T) {
+	now := time.Now()
+	h := NewMatrixEngine(healthMatrix(healthFixture{}, now)).Health(testHealthConfig, true, now)
+	if h.Score != 100 || len(h.Components) != 6 || h.Neurons != 10 {
+		t.Fatalf("expected 100 from six components, got %+v", h)
+	}
+	if h.FormulaVersion != testHealthConfig.FormulaVersion() || !h.ComputedAt.Equal(now) {
+		t.Errorf("expected the formula version and time recorded, got %s at %s", h.FormulaVersion, h.ComputedAt)
+	}
+
+	// Without the vector layer embeddings do not count, and the other
+	// weights are rescaled to make up for them.
+	h = NewMatrixEngine(healthMatrix(healthFixture{unembedded: 10}, now)).Health(testHealthConfig, false, now)
+	if _, ok := healthComponent(h, HealthEmbeddings); ok || h.Score != 100 {
+		t.Errorf("expected embeddings left out without the vector layer, got %+v", h)
+	}
+	if c, _ := healthComponent(h, HealthConsolidation); !approx(c.Weight, 0.2/0.9) {
+		t.Errorf("expected the weights renormalized, got %f", c.Weight)
+	}
+
+	// A component weighted 0 is left out.
+	cfg := testHealthConfig
+	cfg.Weights.Freshness = 0
+	h = NewMatrixEngine(healthMatrix(healthFixture{idle: 100 * 24 * time.Hour}, now)).Health(cfg, true, now)
+	if _, ok := healthComponent(h, HealthFreshness); ok || h.Score != 100 {
+		t.Errorf("expected freshness left out at weight 0, got %+v", h)
+	}
+}
+
+// TestHealth_Components degrades one signal at a time and checks only its
+// component moves, and that the score falls with every step.
+func TestHealth_Components(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	cases := []struct {
+		component string
+		fixture   func(k int) healthFixture
+		signal    func(k int) float64
+	}{
+		{HealthConsolidation, func(k int) healthFixture { return healthFixture{shallow: k} }, func(k int) float64 { return float64(10 - k) }},
+		{HealthEnergy, func(k int) healthFixture { return healthFixture{lowEnergy: k} }, func(k int) float64 { return (float64(k)*0.1 + float64(10-k)) / 10 }},
+		{HealthConnectivity, func(k int) healthFixture { return healthFixture{isolated: k} }, func(k int) float64 { return float64(k) }},
+		{HealthConflicts, func(k int) healthFixture { return healthFixture{conflicted: 2 * k} }, func(k int) float64 { return float64(k) }},
+		{HealthFreshness, func(k int) healthFixture { return healthFixture{idle: time.Duration(k) * day} }, func(k int) float64 { return float64(k * 24) }},
+		{HealthEmbeddings, func(k int) healthFixture { return healthFixture{unembedded: k} }, func(k int) float64 { return float64(10 - k) }},
+	}
+	for _, c := range cases {
+		t.Run(c.component, func(t *testing.T) {
+			previous := 101.0
+			for k := 0; k <= 4; k++ {
+				h := NewMatrixEngine(healthMatrix(c.fixture(k), now)).Health(testHealthConfig, true, now)
+				if h.Score >= previous {
+					t.Errorf("step %d: expected the score to fall below %.1f, got %.1f", k, previous, h.Score)
+				}
+				previous = h.Score
+				for _, comp := range h.Components {
+					if comp.Name == c.component {
+						if !approx(comp.Signal, c.signal(k)) {
+							t.Errorf("step %d: expected signal %f, got %f", k, c.signal(k), comp.Signal)
+						}
+						if k > 0 && comp.Value >= 1 {
+							t.Errorf("step %d: expected the component to drop, got %f", k, comp.Value)
+						}
+					} else if comp.Value != 1 {
+						t.Errorf("step %d: %s moved with %s: %f", k, comp.Name, c.component, comp.Value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHealth_FreshnessBottomsOut(t *testing.T) {
+	now := time.Now()
+	h := NewMatrixEngine(healthMatrix(healthFixture{idle: 100 * 24 * time.Hour}, now)).Health(testHealthConfig, true, now)
+	if c, _ := healthComponent(h, HealthFreshness); c.Value != 0 || c.Points != 0 {
+		t.Errorf("expected freshness 0 past staleAfter, got %+v", c)
+	}
+	if h.Score != 85 {
+		t.Errorf("expected the other components to keep their 85 points, got %.1f", h.Score)
+	}
+}
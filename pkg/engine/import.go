@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Reserved metadata keys of an imported note. The key identifies the note
+// across imports and the hash tells whether it changed since the last one.
+const (
+	ImportKeyMetadataKey  = "_import_key"
+	ImportHashMetadataKey = "_import_hash"
+)
+
+// ImportedNote is one note to import, such as a Markdown file or one of
+// its heading sections.
+type ImportedNote struct {
+	Key      string // stable across imports, e.g. the file path
+	Hash     string // of everything imported for the note
+	Content  string
+	Metadata map[string]string
+	Tags     []string
+	Links    []string // keys of notes of the same import this one links to
+}
+
+// ImportError is a note that could not be imported.
+type ImportError struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// ImportResult reports an import.
+type ImportResult struct {
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Linked    int           `json:"linked"` // synapses created from links
+	Errors    []ImportError `json:"errors"`
+
+	IDs map[string]core.NeuronID `json:"-"` // neuron of each imported key
+}
+
+// ImportNotes creates a neuron for each note, or updates the neuron an
+// earlier import created for the same key when the note's hash changed,
+// and connects linked notes with synapses of weight linkWeight. Re-running
+// an import therefore changes nothing. Synapses that already exist keep
+// their weight, and neurons of notes missing from the import are kept.
+//
+// Updated neurons lose their embedding, which no longer matches their
+// content; with a vectorizer attached they are queued for EmbedPending.
+func (e *MatrixEngine) ImportNotes(notes []ImportedNote, linkWeight float64) ImportResult {
+	res := ImportResult{Errors: []ImportError{}, IDs: make(map[string]core.NeuronID, len(notes))}
+	existing := e.importedNeurons()
+	owner := make(map[core.NeuronID]string, len(notes))
+
+	for _, note := range notes {
+		if id, ok := existing[note.Key]; ok {
+			updated, err := e.updateImported(id, note)
+			if err != nil {
+				res.Errors = append(res.Errors, ImportError{Key: note.Key, Error: err.Error()})
+				continue
+			}
+			if updated {
+				res.Updated++
+			} else {
+				res.Unchanged++
+			}
+			res.IDs[note.Key] = id
+			owner[id] = note.Key
+			continue
+		}
+
+		n, err := e.AddNeuron(note.Content, nil, nil)
+		if err != nil {
+			res.Errors = append(res.Errors, ImportError{Key: note.Key, Error: err.Error()})
+			continue
+		}
+		if other, ok := owner[n.ID]; ok {
+			// AddNeuron merged identical content into an earlier note.
+			res.Errors = append(res.Errors, ImportError{Key: note.Key, Error: fmt.Sprintf("same content as %s", other)})
+			res.IDs[note.Key] = n.ID
+			continue
+		}
+		e.matrix.Lock()
+		e.applyImportedLocked(n, note)
+		e.matrix.Unlock()
+		res.Created++
+		res.IDs[note.Key] = n.ID
+		owner[n.ID] = note.Key
+	}
+
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	for _, note := range notes {
+		from, ok := res.IDs[note.Key]
+		if !ok {
+			continue
+		}
+		for _, key := range note.Links {
+			to, ok := res.IDs[key]
+			if ok && to != from && e.linkLocked(from, to, linkWeight) {
+				res.Linked++
+			}
+		}
+	}
+	if res.Linked > 0 {
+		e.matrix.ModifiedAt = time.Now()
+		e.matrix.Version++
+	}
+	return res
+}
+
+// importedNeurons maps the import keys of the matrix's neurons to their
+// IDs. When neurons share a key, the oldest wins.
+func (e *MatrixEngine) importedNeurons() map[string]core.NeuronID {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+	owners := make(map[string]*core.Neuron)
+	for _, n := range e.matrix.Neurons {
+		key, ok := n.Metadata[ImportKeyMetadataKey].(string)
+		if !ok || key == "" {
+			continue
+		}
+		if prev, ok := owners[key]; !ok || n.CreatedAt.Before(prev.CreatedAt) {
+			owners[key] = n
+		}
+	}
+	ids := make(map[string]core.NeuronID, len(owners))
+	for key, n := range owners {
+		ids[key] = n.ID
+	}
+	return ids
+}
+
+// updateImported brings neuron id up to date with note, reporting whether
+// anything changed.
+func (e *MatrixEngine) updateImported(id core.NeuronID, note ImportedNote) (bool, error) {
+	vectorizer, _, _, _ := e.vectorSettings()
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	n, ok := e.matrix.Neurons[id]
+	if !ok {
+		return false, core.ErrNeuronNotFound
+	}
+	if hash, _ := n.Metadata[ImportHashMetadataKey].(string); hash == note.Hash {
+		return false, nil
+	}
+	content, err := core.NormalizeNeuronContent(note.Content)
+	if err != nil {
+		return false, err
+	}
+	if content != n.Content {
+		e.unindexTerms(n)
+		n.Lock()
+		n.Content = content
+		n.ContentHash = core.HashContent(content)
+		if len(n.Embedding) > 0 || vectorizer != nil {
+			n.Embedding = nil
+			n.EmbeddingModel = ""
+			n.EmbedPending = vectorizer != nil
+		}
+		n.Unlock()
+		e.indexTerms(n)
+	}
+	e.applyImportedLocked(n, note)
+	e.matrix.ModifiedAt = time.Now()
+	e.matrix.Version++
+	return true, nil
+}
+
+// applyImportedLocked sets note's metadata, tags, key and hash on n.
+// Callers hold the matrix lock.
+func (e *MatrixEngine) applyImportedLocked(n *core.Neuron, note ImportedNote) {
+	e.unindexMetadata(n)
+	defer e.indexMetadata(n)
+	n.EditMetadata(func(metadata map[string]any) {
+		for k, v := range note.Metadata {
+			metadata[k] = v
+		}
+		metadata[ImportKeyMetadataKey] = note.Key
+		metadata[ImportHashMetadataKey] = note.Hash
+	})
+	tags := append([]string{}, note.Tags...)
+	sort.Strings(tags)
+	n.SetTags(tags)
+	e.matrix.RecordChange(n)
+}
+
+// linkLocked connects from and to with an associative synapse of the given
+// weight unless they are connected already. Callers hold the matrix lock.
+func (e *MatrixEngine) linkLocked(from, to core.NeuronID, weight float64) bool {
+	return e.linkTypedLocked(from, to, weight, core.SynapseAssociative)
+}
+
+// linkTypedLocked is linkLocked with a synapse of type typ.
+func (e *MatrixEngine) linkTypedLocked(from, to core.NeuronID, weight float64, typ string) bool {
+	if _, ok := e.matrix.Synapses[core.NewSynapseID(from, to)]; ok {
+		return false
+	}
+	if _, ok := e.matrix.Synapses[core.NewSynapseID(to, from)]; ok {
+		return false
+	}
+	syn := core.NewTypedSynapse(from, to, weight, typ)
+	e.matrix.Synapses[syn.ID] = syn
+	e.matrix.AddFootprint(core.SynapseFootprint(syn))
+	e.matrix.Adjacency[from] = append(e.matrix.Adjacency[from], to)
+	e.matrix.Adjacency[to] = append(e.matrix.Adjacency[to], from)
+	return true
+}
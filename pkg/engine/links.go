@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// FollowsWeight is the weight of the synapse linking a write to the parent
+// it names.
+const FollowsWeight = 0.5
+
+// RelatedWeight is the weight of the synapses Link creates for a write's
+// related neurons.
+const RelatedWeight = 0.5
+
+// Link connects from and to with a synapse of type typ and the given
+// weight, reporting whether it did: neurons already connected keep their
+// synapse and its type. It fails with core.ErrNeuronNotFound when either
+// neuron does not exist.
+func (e *MatrixEngine) Link(from, to core.NeuronID, weight float64, typ string) (bool, error) {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	if e.matrix.Neurons[from] == nil || e.matrix.Neurons[to] == nil {
+		return false, core.ErrNeuronNotFound
+	}
+	if from == to || !e.linkTypedLocked(from, to, weight, typ) {
+		return false, nil
+	}
+	e.matrix.ModifiedAt = time.Now()
+	e.matrix.Version++
+	return true, nil
+}
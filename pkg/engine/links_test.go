@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestAddNeuronLinksParentWithFollows(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("test-user", core.DefaultBounds()))
+	parent, err := e.AddNeuron("what is the deploy window?", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := e.AddNeuron("tuesdays after the standup", &parent.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syn := e.matrix.Synapses[core.NewSynapseID(parent.ID, child.ID)]
+	if syn == nil {
+		t.Fatal("expected a synapse from the parent to the child")
+	}
+	if syn.Type != core.SynapseFollows || syn.Weight != FollowsWeight {
+		t.Errorf("expected a follows synapse at %.1f, got %q at %.2f", FollowsWeight, syn.Type, syn.Weight)
+	}
+}
+
+func TestLink(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("test-user", core.DefaultBounds()))
+	a, _ := e.AddNeuron("the ledger migration failed", nil, nil)
+	b, _ := e.AddNeuron("roll back the ledger migration", nil, nil)
+
+	linked, err := e.Link(b.ID, a.ID, RelatedWeight, "resolves")
+	if err != nil || !linked {
+		t.Fatalf("expected a new link, got %v, %v", linked, err)
+	}
+	if syn := e.matrix.Synapses[core.NewSynapseID(b.ID, a.ID)]; syn.Type != "resolves" {
+		t.Errorf("expected the application type, got %q", syn.Type)
+	}
+
+	// An existing synapse, in either direction, keeps its type.
+	if linked, err := e.Link(a.ID, b.ID, RelatedWeight, core.SynapseSimilar); err != nil || linked {
+		t.Errorf("expected no new link, got %v, %v", linked, err)
+	}
+	if _, err := e.Link(a.ID, "missing", RelatedWeight, core.SynapseAssociative); !errors.Is(err, core.ErrNeuronNotFound) {
+		t.Errorf("expected ErrNeuronNotFound, got %v", err)
+	}
+}
+
+// typedFixture links a seed to one neighbour per synapse type.
+func typedFixture(t *testing.T) (*MatrixEngine, map[string]core.NeuronID) {
+	t.Helper()
+	e := NewMatrixEngine(core.NewMatrix("test-user", core.DefaultBounds()))
+	ids := make(map[string]core.NeuronID)
+	for name, content := range map[string]string{
+		"seed":    "quarterly kickoff agenda",
+		"follows": "budget numbers came in late",
+		"similar": "hiring plan for the platform team",
+	} {
+		n, err := e.AddNeuron(content, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = n.ID
+	}
+	for _, typ := range []string{core.SynapseFollows, core.SynapseSimilar} {
+		if _, err := e.Link(ids["seed"], ids[typ], 0.9, typ); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return e, ids
+}
+
+func TestSearchSpreadTypes(t *testing.T) {
+	e, ids := typedFixture(t)
+
+	neurons, stats := e.PeekSearch(e.traceContext(), "kickoff", 1, 10, nil, false, nil, SearchOptions{})
+	if len(neurons) != 3 {
+		t.Fatalf("expected the seed and both neighbours, got %d", len(neurons))
+	}
+	for i, n := range neurons {
+		if n.ID == ids[core.SynapseFollows] {
+			b := stats.Breakdowns[i]
+			if b.Via != string(ids["seed"]) || b.SynapseType != core.SynapseFollows {
+				t.Errorf("expected the breakdown to name the seed and follows, got %+v", b)
+			}
+		}
+	}
+
+	neurons, _ = e.PeekSearch(e.traceContext(), "kickoff", 1, 10, nil, false, nil, SearchOptions{SpreadTypes: []string{core.SynapseFollows}})
+	if len(neurons) != 2 || neurons[1].ID != ids[core.SynapseFollows] {
+		t.Fatalf("expected the seed and its follows neighbour only, got %d results", len(neurons))
+	}
+}
+
+func TestGraphTypeFilter(t *testing.T) {
+	e, _ := typedFixture(t)
+
+	dump := e.GraphDump(nil, []string{core.SynapseSimilar})
+	if len(dump.Nodes) != 3 {
+		t.Errorf("expected every node, got %d", len(dump.Nodes))
+	}
+	if len(dump.Edges) != 1 || dump.Edges[0].Type != core.SynapseSimilar {
+		t.Errorf("expected the similar edge only, got %+v", dump.Edges)
+	}
+	if synapses := e.Synapses(nil, nil); len(synapses) != 2 {
+		t.Errorf("expected every synapse without types, got %d", len(synapses))
+	}
+	if summary := e.GraphSummary(4, []string{core.SynapseFollows}); summary.Synapses != 1 {
+		t.Errorf("expected the summary to bundle the follows synapse only, got %d", summary.Synapses)
+	}
+}
@@ -1,11 +1,14 @@
 package engine
 
 import (
+	"context"
 	"log"
 	"math"
 	"math/rand"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
@@ -16,30 +19,138 @@ import (
 // MatrixEngine handles all matrix operations
 type MatrixEngine struct {
 	matrix            *core.Matrix
-	vectorizer        *vector.Vectorizer  // nil when vector layer is disabled
-	alpha             float64             // vector score weight for hybrid search
-	queryRepeat       int                 // query repetition count for embedding
-	sentimentAnalyzer *sentiment.Analyzer // nil when sentiment layer is disabled
+	vectorizer        vector.Embedder    // nil when vector layer is disabled
+	embeddingModel    string             // model name stored with new embeddings
+	alpha             float64            // vector score weight for hybrid search
+	queryRepeat       int                // query repetition count for embedding
+	sentimentAnalyzer sentiment.Analyzer // nil when sentiment layer is disabled
+	sentimentMinASCII float64            // see sentiment.GuessLanguage
+	traceCtx          context.Context    // trace of the operation being executed, if any
+	changelogSize     int                // tombstones kept for delta sync; 0 keeps all
+	recycleWindow     time.Duration      // how long forgotten neurons stay restorable; 0 = none
+	bm25K1            float64            // BM25 term-frequency saturation
+	bm25B             float64            // BM25 length normalization (0-1)
+	maxTerms          int                // cap on tracked terms; 0 = unbounded
+	metadataKeys      []string           // keys of the metadata index, sorted
+	fold              LexicalFold        // how lexical scoring normalizes text
+
+	// settingsMu guards vectorizer, embeddingModel, alpha, queryRepeat,
+	// sentimentAnalyzer, sentimentMinASCII and metadataKeys, which the
+	// owning worker refreshes while concurrent searches read them.
+	settingsMu sync.RWMutex
+
+	graphStatsMu    sync.Mutex
+	graphStats      *GraphStats         // cached for graphStats.Version
+	graphStatsIndex *core.MetadataIndex // metadata index graphStats describes; rebuilds keep the version
+
+	projectionMu sync.Mutex
+	projection   *projection // graph summary projection, cached for projection.version
+
+	scanOrderMu      sync.Mutex
+	scanOrder        []core.NeuronID // neuron IDs in exact-mode scan order
+	scanOrderVersion uint64          // matrix version scanOrder was built for
 }
 
 // NewMatrixEngine creates a new engine for a matrix
 func NewMatrixEngine(matrix *core.Matrix) *MatrixEngine {
-	return &MatrixEngine{matrix: matrix}
+	matrix.RecomputeFootprint()
+	return &MatrixEngine{
+		matrix:       matrix,
+		bm25K1:       defaultBM25K1,
+		bm25B:        defaultBM25B,
+		maxTerms:     defaultBM25MaxTerms,
+		fold:         DefaultLexicalFold,
+		metadataKeys: core.NormalizeMetadataKeys(DefaultIndexedMetadataKeys),
+	}
 }
 
 // SetVectorizer attaches a vectorizer to the engine for auto-embedding.
-func (e *MatrixEngine) SetVectorizer(v *vector.Vectorizer) {
+// model names it; new embeddings are tagged with it and search only
+// compares embeddings produced by the same model.
+func (e *MatrixEngine) SetVectorizer(v vector.Embedder, model string) {
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
 	e.vectorizer = v
+	e.embeddingModel = model
+}
+
+// vectorSettings returns the vectorizer, its model name, alpha and the
+// query repeat count.
+func (e *MatrixEngine) vectorSettings() (vector.Embedder, string, float64, int) {
+	e.settingsMu.RLock()
+	defer e.settingsMu.RUnlock()
+	return e.vectorizer, e.embeddingModel, e.alpha, e.queryRepeat
+}
+
+// SetTraceContext sets the trace context that embedding spans are recorded
+// under until it is cleared with nil. Called by the owning worker around
+// each traced operation.
+func (e *MatrixEngine) SetTraceContext(ctx context.Context) {
+	e.traceCtx = ctx
+}
+
+func (e *MatrixEngine) traceContext() context.Context {
+	if e.traceCtx == nil {
+		return context.Background()
+	}
+	return e.traceCtx
+}
+
+// SetChangelogSize sets how many neuron removals are kept for delta sync.
+func (e *MatrixEngine) SetChangelogSize(n int) {
+	e.changelogSize = n
 }
 
-// SetSentimentAnalyzer attaches a sentiment analyzer for auto-labeling on write.
-func (e *MatrixEngine) SetSentimentAnalyzer(a *sentiment.Analyzer) {
+// SetSentimentAnalyzer attaches a sentiment analyzer for auto-labeling on
+// write. nil disables the sentiment layer.
+func (e *MatrixEngine) SetSentimentAnalyzer(a sentiment.Analyzer) {
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
 	e.sentimentAnalyzer = a
 }
 
+// SetSentimentMinASCII sets the share of ASCII letters text without a
+// language must have to be taken as English; see sentiment.GuessLanguage.
+func (e *MatrixEngine) SetSentimentMinASCII(minASCII float64) {
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
+	e.sentimentMinASCII = minASCII
+}
+
+// sentimentSettings returns the sentiment analyzer and its minimum ASCII
+// share.
+func (e *MatrixEngine) sentimentSettings() (sentiment.Analyzer, float64) {
+	e.settingsMu.RLock()
+	defer e.settingsMu.RUnlock()
+	return e.sentimentAnalyzer, e.sentimentMinASCII
+}
+
+// labelSentiment labels n with the analyzer's emotion for its content. n
+// is left unlabeled, its sentiment null, when the analyzer does not
+// support its language: its _lang, else the fold's default language, else
+// a guess from its letters.
+func (e *MatrixEngine) labelSentiment(n *core.Neuron) {
+	lang := NeuronLang(n)
+	if lang == "" {
+		lang = e.fold.Language
+	}
+	analyzer, minASCII := e.sentimentSettings()
+	if r, ok := sentiment.Classify(analyzer, n.Content, lang, minASCII); ok {
+		n.SentimentLabel = string(r.Label)
+		n.SentimentScore = r.Compound
+	}
+}
+
 // AddNeuron creates a new neuron and positions it organically.
 // metadata is optional key-value pairs (e.g. thread_id, role, source).
 func (e *MatrixEngine) AddNeuron(content string, parentID *core.NeuronID, metadata map[string]string) (*core.Neuron, error) {
+	return e.AddNeuronWithAttachments(content, parentID, metadata, nil)
+}
+
+// AddNeuronWithAttachments is AddNeuron, referencing attachments from the
+// new neuron. When the content is already held, the existing neuron fires
+// and gains the attachments it does not reference yet.
+func (e *MatrixEngine) AddNeuronWithAttachments(content string, parentID *core.NeuronID, metadata map[string]string, attachments []core.Attachment) (*core.Neuron, error) {
 	e.matrix.Lock()
 	defer e.matrix.Unlock()
 
@@ -47,7 +158,8 @@ func (e *MatrixEngine) AddNeuron(content string, parentID *core.NeuronID, metada
 		return nil, core.ErrMatrixFull
 	}
 
-	if err := core.ValidateNeuronContent(content); err != nil {
+	content, err := core.NormalizeNeuronContent(content)
+	if err != nil {
 		return nil, err
 	}
 
@@ -57,6 +169,7 @@ func (e *MatrixEngine) AddNeuron(content string, parentID *core.NeuronID, metada
 		if n.ContentHash == contentHash {
 			// Existing neuron found - fire it instead
 			n.Fire()
+			e.addAttachmentsLocked(n, attachments)
 			return n, nil
 		}
 	}
@@ -67,7 +180,7 @@ func (e *MatrixEngine) AddNeuron(content string, parentID *core.NeuronID, metada
 	// Position organically - near parent if exists, else random
 	if parentID != nil {
 		if parent, ok := e.matrix.Neurons[*parentID]; ok {
-			neuron.Position = e.perturbPosition(parent.Position, 0.1)
+			neuron.Position = e.perturbPosition(parent, 0.1)
 		} else {
 			neuron.Position = e.randomPosition()
 		}
@@ -75,29 +188,27 @@ func (e *MatrixEngine) AddNeuron(content string, parentID *core.NeuronID, metada
 		// Find nearest existing neuron by content similarity (simple)
 		nearest := e.findNearestByContent(content)
 		if nearest != nil {
-			neuron.Position = e.perturbPosition(nearest.Position, 0.2)
+			neuron.Position = e.perturbPosition(nearest, 0.2)
 		} else {
 			neuron.Position = e.randomPosition()
 		}
 	}
 
 	// Auto-embed if vectorizer is available
-	if e.vectorizer != nil && len(neuron.Embedding) == 0 {
-		if emb, err := e.vectorizer.EmbedText(content); err == nil {
+	vectorizer, model, _, _ := e.vectorSettings()
+	if vectorizer != nil && len(neuron.Embedding) == 0 {
+		if emb, err := vectorizer.EmbedTextContext(e.traceContext(), content); err == nil {
 			vector.Normalize(emb)
 			neuron.Embedding = emb
+			neuron.EmbeddingModel = model
 		} else {
-			log.Printf("vector: embed failed for neuron %s: %v", neuron.ID, err)
+			// The write goes ahead; the embedding is retried later.
+			neuron.EmbedPending = true
+			vector.RecordFallback(vectorizer, vector.FallbackWrite)
+			log.Printf("vector: embed failed for neuron %s, queued for later: %v", neuron.ID, err)
 		}
 	}
 
-	// Auto-label sentiment if analyzer is available
-	if e.sentimentAnalyzer != nil {
-		result := e.sentimentAnalyzer.Analyze(content)
-		neuron.SentimentLabel = string(result.Label)
-		neuron.SentimentScore = result.Compound
-	}
-
 	// Apply optional metadata
 	if len(metadata) > 0 {
 		for k, v := range metadata {
@@ -105,9 +216,22 @@ func (e *MatrixEngine) AddNeuron(content string, parentID *core.NeuronID, metada
 		}
 	}
 
+	// Auto-label sentiment if analyzer is available
+	e.labelSentiment(neuron)
+
+	if len(attachments) > 0 {
+		neuron.Attachments = append([]core.Attachment(nil), attachments...)
+	}
+
 	// Add to matrix
 	e.matrix.Neurons[neuron.ID] = neuron
 	e.matrix.Adjacency[neuron.ID] = []core.NeuronID{}
+	if parentID != nil && e.matrix.Neurons[*parentID] != nil {
+		e.linkTypedLocked(*parentID, neuron.ID, FollowsWeight, core.SynapseFollows)
+	}
+	e.indexTerms(neuron)
+	e.indexMetadata(neuron)
+	e.matrix.RecordChange(neuron)
 	e.matrix.TotalActivations++
 	e.matrix.LastActivity = time.Now()
 	e.matrix.ModifiedAt = time.Now()
@@ -119,6 +243,28 @@ func (e *MatrixEngine) AddNeuron(content string, parentID *core.NeuronID, metada
 	return neuron, nil
 }
 
+// addAttachmentsLocked adds the attachments n does not reference yet,
+// reindexing it for their captions. Callers hold the matrix write lock.
+func (e *MatrixEngine) addAttachmentsLocked(n *core.Neuron, attachments []core.Attachment) {
+	var added []core.Attachment
+	for _, a := range attachments {
+		if !slices.ContainsFunc(n.Attachments, func(have core.Attachment) bool { return have.Hash == a.Hash }) {
+			added = append(added, a)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	e.unindexTerms(n)
+	n.Lock()
+	n.Attachments = append(slices.Clip(n.Attachments), added...)
+	n.Unlock()
+	e.indexTerms(n)
+	e.matrix.RecordChange(n)
+	e.matrix.ModifiedAt = time.Now()
+	e.matrix.Version++
+}
+
 // GetNeuron retrieves a neuron by ID and fires it
 func (e *MatrixEngine) GetNeuron(id core.NeuronID) (*core.Neuron, error) {
 	e.matrix.RLock()
@@ -138,24 +284,145 @@ func (e *MatrixEngine) GetNeuron(id core.NeuronID) (*core.Neuron, error) {
 	return neuron, nil
 }
 
+// PeekNeuron retrieves a neuron by ID without firing it.
+func (e *MatrixEngine) PeekNeuron(id core.NeuronID) (*core.Neuron, error) {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+	neuron, ok := e.matrix.Neurons[id]
+	if !ok {
+		return nil, core.ErrNeuronNotFound
+	}
+	return neuron, nil
+}
+
 // Search finds neurons matching a pattern with activation spread.
 // metadata is an optional filter/boost map (e.g. {"thread_id": "conv-xyz"}).
 // strict=false (default): metadata keys boost matching neurons; all neurons remain eligible.
 // strict=true: only neurons whose metadata contains ALL specified key-value pairs are returned.
 func (e *MatrixEngine) Search(query string, depth int, limit int, metadata map[string]string, strict bool) []*core.Neuron {
+	neurons, _ := e.SearchWithStats(query, depth, limit, metadata, strict, nil)
+	return neurons
+}
+
+// SearchWithStats is Search plus a SearchStats summary of the result set.
+// Non-empty roles restrict results to neurons authored by one of them.
+func (e *MatrixEngine) SearchWithStats(query string, depth int, limit int, metadata map[string]string, strict bool, roles []string) ([]*core.Neuron, SearchStats) {
+	return e.search(e.traceContext(), false, query, depth, limit, metadata, strict, roles, SearchOptions{})
+}
+
+// Search modes. The default mode scores every neuron and spreads activation
+// from the hits; exact mode only returns direct hits.
+const (
+	SearchModeDefault = ""
+	SearchModeExact   = "exact"
+)
+
+// SearchOptions are the search settings beyond query, depth, limit and
+// filters.
+type SearchOptions struct {
+	// ResolveSuperseded replaces superseded results with the current
+	// neuron of their chain.
+	ResolveSuperseded bool
+
+	// Mode is SearchModeDefault or SearchModeExact. Exact mode scans
+	// neurons in ID order and stops at the first limit direct hits scoring
+	// at least MinScore, with no spread activation and no metadata or
+	// sentiment boost. Strict metadata and role filters still apply.
+	Mode     string
+	MinScore float64
+
+	// Language is the query's language for lexical folding; "" takes the
+	// engine's default. Turkish folds I to ı and İ to i.
+	Language string
+
+	// CaseSensitive matches query terms in exact mode as written, rather
+	// than case-folded. Ignored in the default mode.
+	CaseSensitive bool
+
+	// AnchorID ranks results by their relatedness to that neuron as well
+	// as by the query, AnchorWeight being its share of the score; the query
+	// may then be empty. Ignored in exact mode. See Searcher.SetAnchor.
+	AnchorID      core.NeuronID
+	AnchorWeight  float64
+	IncludeAnchor bool
+
+	// Focus is the index's active focus, boosting the neurons it matches.
+	// Ignored in exact mode.
+	Focus *Focus
+
+	// SpreadTypes restricts spread activation to synapses of those types;
+	// empty follows every synapse.
+	SpreadTypes []string
+
+	// Expansion are terms added to the query, each counting lexically
+	// ExpansionWeight times as much as a query term; see ExpandQuery and
+	// Searcher.SetExpansion. Ignored in exact mode.
+	Expansion       []string
+	ExpansionWeight float64
+}
+
+// PeekSearch is SearchWithStats without firing the results, traced under
+// ctx. It leaves neurons and the engine unchanged, so several may run at
+// once; the caller fires the results separately.
+func (e *MatrixEngine) PeekSearch(ctx context.Context, query string, depth int, limit int, metadata map[string]string, strict bool, roles []string, opts SearchOptions) ([]*core.Neuron, SearchStats) {
+	return e.search(ctx, true, query, depth, limit, metadata, strict, roles, opts)
+}
+
+func (e *MatrixEngine) search(ctx context.Context, peek bool, query string, depth int, limit int, metadata map[string]string, strict bool, roles []string, opts SearchOptions) ([]*core.Neuron, SearchStats) {
+	e.ensureTermStats()
+	e.ensureMetadataIndex()
 	searcher := NewSearcher(e.matrix)
-	if e.vectorizer != nil {
-		searcher.SetVectorizer(e.vectorizer, e.alpha, e.queryRepeat)
+	searcher.SetBM25(e.bm25K1, e.bm25B)
+	fold := e.fold
+	fold.CaseSensitive = opts.CaseSensitive && opts.Mode == SearchModeExact
+	searcher.SetFold(fold, opts.Language)
+	if vectorizer, model, alpha, queryRepeat := e.vectorSettings(); vectorizer != nil {
+		searcher.SetVectorizer(vectorizer, model, alpha, queryRepeat)
 	}
-	if e.sentimentAnalyzer != nil {
-		searcher.SetSentimentAnalyzer(e.sentimentAnalyzer)
+	if analyzer, minASCII := e.sentimentSettings(); analyzer != nil {
+		searcher.SetSentimentAnalyzer(analyzer, minASCII)
 	}
 	searcher.SetMetadata(metadata, strict)
-	return searcher.Search(query, depth, limit)
+	searcher.SetRoles(roles)
+	searcher.SetTraceContext(ctx)
+	searcher.SetPeek(peek)
+	searcher.SetResolveSuperseded(opts.ResolveSuperseded)
+	if opts.Mode == SearchModeExact {
+		searcher.SetExact(opts.MinScore, e.exactScanOrder)
+	}
+	if opts.AnchorID != "" {
+		searcher.SetAnchor(opts.AnchorID, opts.AnchorWeight, opts.IncludeAnchor)
+	}
+	searcher.SetFocus(opts.Focus)
+	searcher.SetSpreadTypes(opts.SpreadTypes)
+	searcher.SetExpansion(opts.Expansion, opts.ExpansionWeight)
+	neurons := searcher.Search(query, depth, limit)
+	return neurons, searcher.Stats()
+}
+
+// exactScanOrder returns the neuron IDs in ascending order, rebuilt when
+// the matrix version changes. The caller holds the matrix read lock.
+func (e *MatrixEngine) exactScanOrder() []core.NeuronID {
+	e.scanOrderMu.Lock()
+	defer e.scanOrderMu.Unlock()
+
+	if e.scanOrder != nil && e.scanOrderVersion == e.matrix.Version && len(e.scanOrder) == len(e.matrix.Neurons) {
+		return e.scanOrder
+	}
+	order := make([]core.NeuronID, 0, len(e.matrix.Neurons))
+	for id := range e.matrix.Neurons {
+		order = append(order, id)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+	e.scanOrder = order
+	e.scanOrderVersion = e.matrix.Version
+	return order
 }
 
 // SetAlpha sets the vector score weight for hybrid search.
 func (e *MatrixEngine) SetAlpha(alpha float64) {
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
 	e.alpha = alpha
 }
 
@@ -164,6 +431,8 @@ func (e *MatrixEngine) SetQueryRepeat(n int) {
 	if n < 1 {
 		n = 1
 	}
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
 	e.queryRepeat = n
 }
 
@@ -285,13 +554,19 @@ func (e *MatrixEngine) UpdateNeuron(id core.NeuronID, newContent string) error {
 		return core.ErrNeuronNotFound
 	}
 
-	if err := core.ValidateNeuronContent(newContent); err != nil {
+	newContent, err := core.NormalizeNeuronContent(newContent)
+	if err != nil {
 		return err
 	}
 
+	e.unindexTerms(neuron)
+	neuron.Lock()
 	neuron.Content = newContent
 	neuron.ContentHash = core.HashContent(newContent)
+	neuron.Unlock()
+	e.indexTerms(neuron)
 	neuron.Fire()
+	e.matrix.RecordChange(neuron)
 	e.matrix.ModifiedAt = time.Now()
 	e.matrix.Version++
 
@@ -302,14 +577,44 @@ func (e *MatrixEngine) UpdateNeuron(id core.NeuronID, newContent string) error {
 func (e *MatrixEngine) DeleteNeuron(id core.NeuronID) error {
 	e.matrix.Lock()
 	defer e.matrix.Unlock()
+	return e.deleteNeuronLocked(id)
+}
 
-	if _, ok := e.matrix.Neurons[id]; !ok {
+// deleteNeuronLocked removes a neuron and its synapses. Callers hold the
+// matrix write lock.
+func (e *MatrixEngine) deleteNeuronLocked(id core.NeuronID) error {
+	neuron, ok := e.matrix.Neurons[id]
+	if !ok {
 		return core.ErrNeuronNotFound
 	}
 
-	// Remove all connected synapses
+	for _, syn := range e.detachSynapsesLocked(id) {
+		e.matrix.AddFootprint(-core.SynapseFootprint(syn))
+	}
+
+	// Remove neuron
+	delete(e.matrix.Neurons, id)
+	e.matrix.Unmeasure(neuron)
+	e.unindexTerms(neuron)
+	e.unindexMetadata(neuron)
+	e.matrix.RecordRemoval(id, e.changelogSize)
+	e.matrix.ModifiedAt = time.Now()
+	e.matrix.Version++
+
+	// Check if dimension contraction needed
+	e.checkDimensionContraction()
+
+	return nil
+}
+
+// detachSynapsesLocked removes the synapses of neuron id and its adjacency
+// entries, and returns the removed synapses. The footprint is left to the
+// caller. Callers hold the matrix write lock.
+func (e *MatrixEngine) detachSynapsesLocked(id core.NeuronID) []*core.Synapse {
+	var removed []*core.Synapse
 	for synID, syn := range e.matrix.Synapses {
 		if syn.FromID == id || syn.ToID == id {
+			removed = append(removed, syn)
 			delete(e.matrix.Synapses, synID)
 		}
 	}
@@ -325,20 +630,44 @@ func (e *MatrixEngine) DeleteNeuron(id core.NeuronID) error {
 		}
 		e.matrix.Adjacency[nID] = filtered
 	}
+	return removed
+}
 
-	// Remove neuron
-	delete(e.matrix.Neurons, id)
-	e.matrix.ModifiedAt = time.Now()
-	e.matrix.Version++
+// ListNeurons returns all neurons sorted by energy
+func (e *MatrixEngine) ListNeurons(offset, limit int, depthFilter *int) []*core.Neuron {
+	return e.ListNeuronsByRole(offset, limit, depthFilter, nil)
+}
 
-	// Check if dimension contraction needed
-	e.checkDimensionContraction()
+// ListNeuronsByRole is ListNeurons restricted to neurons authored by any of
+// roles. An empty roles list applies no restriction.
+func (e *MatrixEngine) ListNeuronsByRole(offset, limit int, depthFilter *int, roles []string) []*core.Neuron {
+	return e.ListNeuronsFocused(offset, limit, depthFilter, roles, nil)
+}
 
-	return nil
+// ListNeuronsFocused is ListNeuronsByRole ranking by energy times the
+// multiplier of focus. A nil focus ranks by energy alone.
+func (e *MatrixEngine) ListNeuronsFocused(offset, limit int, depthFilter *int, roles []string, focus *Focus) []*core.Neuron {
+	neurons, _ := e.ListNeuronsOrdered(offset, limit, depthFilter, roles, focus, ListByEnergy)
+	return neurons
 }
 
-// ListNeurons returns all neurons sorted by energy
-func (e *MatrixEngine) ListNeurons(offset, limit int, depthFilter *int) []*core.Neuron {
+// List orders for ListNeuronsOrdered.
+const (
+	ListByEnergy    = ""     // energy, times the focus multiplier
+	ListCreatedAsc  = "asc"  // oldest first
+	ListCreatedDesc = "desc" // newest first
+)
+
+// ValidListOrder reports whether order is one of the list orders.
+func ValidListOrder(order string) bool {
+	return order == ListByEnergy || order == ListCreatedAsc || order == ListCreatedDesc
+}
+
+// ListNeuronsOrdered is ListNeuronsFocused in the given order, also
+// returning how many neurons pass the filters. Creation order breaks ties
+// by ID, so it pages stably while neurons are added and fire; energy order
+// shifts as they do.
+func (e *MatrixEngine) ListNeuronsOrdered(offset, limit int, depthFilter *int, roles []string, focus *Focus, order string) ([]*core.Neuron, int) {
 	e.matrix.RLock()
 	defer e.matrix.RUnlock()
 
@@ -347,30 +676,59 @@ func (e *MatrixEngine) ListNeurons(offset, limit int, depthFilter *int) []*core.
 		if depthFilter != nil && n.Depth != *depthFilter {
 			continue
 		}
+		if !HasRole(n, roles) {
+			continue
+		}
 		neurons = append(neurons, n)
 	}
 
-	// Sort by energy descending
-	sort.Slice(neurons, func(i, j int) bool {
-		return neurons[i].Energy > neurons[j].Energy
-	})
+	total := len(neurons)
+	switch {
+	case order == ListCreatedAsc || order == ListCreatedDesc:
+		sort.Slice(neurons, func(i, j int) bool {
+			a, b := neurons[i], neurons[j]
+			if order == ListCreatedDesc {
+				a, b = b, a
+			}
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		})
+	case focus == nil:
+		// Sort by energy descending
+		sort.Slice(neurons, func(i, j int) bool {
+			return neurons[i].Energy > neurons[j].Energy
+		})
+	default:
+		rank := make(map[core.NeuronID]float64, len(neurons))
+		for _, n := range neurons {
+			rank[n.ID] = n.Energy * focus.factor(n)
+		}
+		sort.Slice(neurons, func(i, j int) bool {
+			return rank[neurons[i].ID] > rank[neurons[j].ID]
+		})
+	}
 
 	// Apply pagination
 	if offset >= len(neurons) {
-		return []*core.Neuron{}
+		return []*core.Neuron{}, total
 	}
 	neurons = neurons[offset:]
 	if limit > 0 && len(neurons) > limit {
 		neurons = neurons[:limit]
 	}
 
-	return neurons
+	return neurons, total
 }
 
-// perturbPosition creates a new position near the given one
-func (e *MatrixEngine) perturbPosition(pos []float64, magnitude float64) []float64 {
-	newPos := make([]float64, len(pos))
-	for i, p := range pos {
+// perturbPosition creates a new position near n's. n is read under its own
+// lock, since fractal clustering moves neurons outside the matrix lock.
+func (e *MatrixEngine) perturbPosition(n *core.Neuron, magnitude float64) []float64 {
+	n.RLock()
+	defer n.RUnlock()
+	newPos := make([]float64, len(n.Position))
+	for i, p := range n.Position {
 		newPos[i] = p + (rand.Float64()-0.5)*2*magnitude
 		// Clamp to [-1, 1]
 		newPos[i] = math.Max(-1, math.Min(1, newPos[i]))
@@ -446,9 +804,17 @@ func (e *MatrixEngine) checkDimensionContraction() {
 func (e *MatrixEngine) expandDimension(newDim int) {
 	for _, n := range e.matrix.Neurons {
 		// Add new dimension with small random value
-		for len(n.Position) < newDim {
-			n.Position = append(n.Position, (rand.Float64()-0.5)*0.1)
+		n.Lock()
+		if len(n.Position) < newDim {
+			pos := make([]float64, newDim)
+			copy(pos, n.Position)
+			for i := len(n.Position); i < newDim; i++ {
+				pos[i] = (rand.Float64() - 0.5) * 0.1
+			}
+			n.Position = pos
 		}
+		n.Unlock()
+		e.matrix.Remeasure(n)
 	}
 	e.matrix.CurrentDim = newDim
 }
@@ -456,8 +822,14 @@ func (e *MatrixEngine) expandDimension(newDim int) {
 // contractDimension removes the last dimension
 func (e *MatrixEngine) contractDimension(newDim int) {
 	for _, n := range e.matrix.Neurons {
-		if len(n.Position) > newDim {
-			n.Position = n.Position[:newDim]
+		n.Lock()
+		shrunk := len(n.Position) > newDim
+		if shrunk {
+			n.Position = n.Position[:newDim:newDim]
+		}
+		n.Unlock()
+		if shrunk {
+			e.matrix.Remeasure(n)
 		}
 	}
 	e.matrix.CurrentDim = newDim
@@ -470,9 +842,26 @@ func (e *MatrixEngine) GetStats() map[string]any {
 
 	depthCounts := make(map[int]int)
 	totalEnergy := 0.0
+	pinned, pendingEmbeddings, withMetadata := 0, 0, 0
+	var oldest, newest time.Time
 	for _, n := range e.matrix.Neurons {
 		depthCounts[n.Depth]++
 		totalEnergy += n.Energy
+		if n.Pinned {
+			pinned++
+		}
+		if n.EmbedPending {
+			pendingEmbeddings++
+		}
+		if len(n.Metadata) > 0 {
+			withMetadata++
+		}
+		if oldest.IsZero() || n.CreatedAt.Before(oldest) {
+			oldest = n.CreatedAt
+		}
+		if n.CreatedAt.After(newest) {
+			newest = n.CreatedAt
+		}
 	}
 
 	avgEnergy := 0.0
@@ -491,9 +880,12 @@ func (e *MatrixEngine) GetStats() map[string]any {
 		avgWeight = totalWeight / float64(len(synapseWeights))
 	}
 
-	return map[string]any{
+	stats := map[string]any{
 		"index_id":               e.matrix.IndexID,
 		"neuron_count":           len(e.matrix.Neurons),
+		"pinned_count":           pinned,
+		"pending_embeddings":     pendingEmbeddings,
+		"neurons_with_metadata":  withMetadata,
 		"synapse_count":          len(e.matrix.Synapses),
 		"current_dimension":      e.matrix.CurrentDim,
 		"depth_distribution":     depthCounts,
@@ -503,5 +895,12 @@ func (e *MatrixEngine) GetStats() map[string]any {
 		"version":                e.matrix.Version,
 		"synapse_weights":        synapseWeights,
 		"average_synapse_weight": avgWeight,
+		"memory_bytes":           e.matrix.Footprint(),
+	}
+	// An empty index has no oldest or newest neuron.
+	if len(e.matrix.Neurons) > 0 {
+		stats["oldest_neuron_at"] = oldest
+		stats["newest_neuron_at"] = newest
 	}
+	return stats
 }
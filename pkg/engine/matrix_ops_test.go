@@ -3,6 +3,7 @@ package engine
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
 )
@@ -266,16 +267,31 @@ func TestMatrixEngineGetStats(t *testing.T) {
 	m := newTestMatrix()
 	e := NewMatrixEngine(m)
 
-	e.AddNeuron("Test", nil, nil)
+	if _, ok := e.GetStats()["oldest_neuron_at"]; ok {
+		t.Error("An empty index should have no oldest neuron")
+	}
+
+	first, _ := e.AddNeuron("Test", nil, nil)
+	first.CreatedAt = first.CreatedAt.Add(-time.Hour)
+	last, _ := e.AddNeuron("Tagged", nil, map[string]string{"thread_id": "t1"})
 
 	stats := e.GetStats()
 
-	if stats["neuron_count"].(int) != 1 {
-		t.Error("Stats should show 1 neuron")
+	if stats["neuron_count"].(int) != 2 {
+		t.Error("Stats should show 2 neurons")
 	}
 	if stats["index_id"].(core.IndexID) != "test-user" {
 		t.Error("Stats should show correct user ID")
 	}
+	if stats["neurons_with_metadata"].(int) != 1 {
+		t.Errorf("Stats should count 1 neuron with metadata, got %v", stats["neurons_with_metadata"])
+	}
+	if !stats["oldest_neuron_at"].(time.Time).Equal(first.CreatedAt) || !stats["newest_neuron_at"].(time.Time).Equal(last.CreatedAt) {
+		t.Errorf("Stats should report the first and last neuron's creation, got %v and %v", stats["oldest_neuron_at"], stats["newest_neuron_at"])
+	}
+	if stats["memory_bytes"].(int64) != m.Footprint() || m.Footprint() <= 0 {
+		t.Errorf("Stats should report the footprint, got %v", stats["memory_bytes"])
+	}
 }
 
 func TestMatrixEngineDimensionExpansion(t *testing.T) {
@@ -0,0 +1,27 @@
+package engine
+
+import "github.com/qubicDB/qubicdb/pkg/core"
+
+// systemMetadataKeys are the neuron metadata keys QubicDB writes itself to
+// track supersede chains, turns, conflicts, imports, retention and
+// compaction.
+var systemMetadataKeys = map[string]bool{
+	SupersedesKey:            true,
+	SupersededByKey:          true,
+	SupersededAtKey:          true,
+	LangMetadataKey:          true,
+	FormatMetadataKey:        true,
+	ConflictGroupKey:         true,
+	ImportKeyMetadataKey:     true,
+	ImportHashMetadataKey:    true,
+	RetentionAnonymizedKey:   true,
+	DanglingSynapsesKey:      true,
+	core.CompactedKey:        true,
+	core.CompactedOrigLenKey: true,
+}
+
+// IsSystemMetadataKey reports whether key is one QubicDB sets on neurons
+// itself; client writes may not set it.
+func IsSystemMetadataKey(key string) bool {
+	return systemMetadataKeys[key]
+}
@@ -0,0 +1,68 @@
+package engine
+
+import "github.com/qubicDB/qubicdb/pkg/core"
+
+// DefaultIndexedMetadataKeys are the metadata keys indexed when the engine
+// is not told otherwise, matching matrix.indexedMetadataKeys in the default
+// configuration.
+var DefaultIndexedMetadataKeys = []string{"thread_id", "role"}
+
+// SetIndexedMetadataKeys sets the metadata keys the matrix metadata index
+// covers. The index is rebuilt over the new keys on the next search; no
+// keys drops it.
+func (e *MatrixEngine) SetIndexedMetadataKeys(keys []string) {
+	keys = core.NormalizeMetadataKeys(keys)
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
+	e.metadataKeys = keys
+}
+
+// IndexedMetadataKeys returns the metadata keys the index covers, sorted.
+func (e *MatrixEngine) IndexedMetadataKeys() []string {
+	e.settingsMu.RLock()
+	defer e.settingsMu.RUnlock()
+	return e.metadataKeys
+}
+
+// indexMetadata counts n in the matrix metadata index. Caller holds the
+// matrix write lock. A missing index is left for ensureMetadataIndex.
+func (e *MatrixEngine) indexMetadata(n *core.Neuron) {
+	if e.matrix.MetadataIndex != nil {
+		e.matrix.MetadataIndex.Add(n)
+	}
+}
+
+// unindexMetadata uncounts n from the matrix metadata index. Caller holds
+// the matrix write lock.
+func (e *MatrixEngine) unindexMetadata(n *core.Neuron) {
+	if e.matrix.MetadataIndex != nil {
+		e.matrix.MetadataIndex.Remove(n)
+	}
+}
+
+// ensureMetadataIndex rebuilds the matrix metadata index when it is
+// missing, covers other keys than the engine's, or no longer counts every
+// neuron.
+func (e *MatrixEngine) ensureMetadataIndex() {
+	keys := e.IndexedMetadataKeys()
+	e.matrix.RLock()
+	x := e.matrix.MetadataIndex
+	fresh := (x == nil && len(keys) == 0) ||
+		(x != nil && x.Covers(keys) && x.Docs == len(e.matrix.Neurons))
+	e.matrix.RUnlock()
+	if fresh {
+		return
+	}
+
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	if len(keys) == 0 {
+		e.matrix.MetadataIndex = nil
+		return
+	}
+	x = core.NewMetadataIndex(keys)
+	for _, n := range e.matrix.Neurons {
+		x.Add(n)
+	}
+	e.matrix.MetadataIndex = x
+}
@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func indexedIDs(t *testing.T, m *core.Matrix, key, value string) map[core.NeuronID]struct{} {
+	t.Helper()
+	if m.MetadataIndex == nil {
+		t.Fatal("expected a metadata index")
+	}
+	ids, ok := m.MetadataIndex.Lookup(key, value)
+	if !ok {
+		t.Fatalf("%s is not indexed", key)
+	}
+	return ids
+}
+
+func TestMetadataIndexFollowsWritesAndForgets(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	a, _ := e.AddNeuron("deploy the billing service", nil, map[string]string{"thread_id": "t1", "role": "user"})
+	b, _ := e.AddNeuron("billing deploy finished", nil, map[string]string{"thread_id": "t1", "role": "assistant"})
+	plain, _ := e.AddNeuron("billing notes without metadata", nil, nil)
+
+	// The first search builds the index; later writes maintain it.
+	e.Search("billing", 0, 10, nil, false)
+	c, _ := e.AddNeuron("billing rollback", nil, map[string]string{"thread_id": "t2", "role": "user"})
+
+	if got := len(indexedIDs(t, m, "thread_id", "t1")); got != 2 {
+		t.Errorf("expected 2 neurons in t1, got %d", got)
+	}
+	if _, ok := indexedIDs(t, m, "role", "user")[c.ID]; !ok {
+		t.Error("a neuron written after the build should be indexed")
+	}
+	if m.MetadataIndex.Docs != 4 {
+		t.Errorf("metadata-less neurons should still be counted, got %d docs", m.MetadataIndex.Docs)
+	}
+
+	if err := e.DeleteNeuron(a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := indexedIDs(t, m, "thread_id", "t1")[a.ID]; ok {
+		t.Error("a forgotten neuron should leave the index")
+	}
+	if err := e.DeleteNeuron(plain.ID); err != nil {
+		t.Fatal(err)
+	}
+	if m.MetadataIndex.Docs != 2 {
+		t.Errorf("expected 2 docs after two forgets, got %d", m.MetadataIndex.Docs)
+	}
+
+	results := e.Search("billing", 0, 10, map[string]string{"thread_id": "t1"}, true)
+	if len(results) != 1 || results[0].ID != b.ID {
+		t.Fatalf("strict search should return only the remaining t1 neuron, got %v", results)
+	}
+}
+
+func TestMetadataIndexSupersede(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	old, _ := e.AddNeuron("the deadline is friday", nil, map[string]string{"thread_id": "t1"})
+	e.Search("deadline", 0, 10, nil, false)
+	head, _ := e.AddNeuron("the deadline is monday", nil, map[string]string{"thread_id": "t1"})
+	if err := e.Supersede(old.ID, head.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := indexedIDs(t, m, "thread_id", "t1")
+	if len(ids) != 2 {
+		t.Errorf("superseded neurons stay indexed until forgotten, got %d", len(ids))
+	}
+	results := e.Search("deadline", 0, 10, map[string]string{"thread_id": "t1"}, true)
+	if len(results) != 2 {
+		t.Errorf("strict search should find both versions, got %d", len(results))
+	}
+}
+
+func TestMetadataIndexRebuilds(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	for i := 0; i < 6; i++ {
+		e.AddNeuron(fmt.Sprintf("release note %d", i), nil, map[string]string{
+			"thread_id": fmt.Sprintf("t%d", i%2),
+			"project":   fmt.Sprintf("p%d", i%3),
+		})
+	}
+
+	e.Search("release", 0, 10, nil, false)
+	if m.MetadataIndex == nil || !m.MetadataIndex.Covers([]string{"role", "thread_id"}) {
+		t.Fatal("the default keys should be indexed")
+	}
+
+	// A matrix loaded without an index gets one on the next search.
+	m.MetadataIndex = nil
+	e.Search("release", 0, 10, nil, false)
+	if got := len(indexedIDs(t, m, "thread_id", "t0")); got != 3 {
+		t.Errorf("expected 3 neurons in t0 after the rebuild, got %d", got)
+	}
+
+	e.SetIndexedMetadataKeys([]string{"project", "thread_id", "project"})
+	results := e.Search("release", 0, 10, map[string]string{"project": "p1", "thread_id": "t1"}, true)
+	if got := len(indexedIDs(t, m, "project", "p1")); got != 2 {
+		t.Errorf("expected 2 neurons in p1, got %d", got)
+	}
+	if _, ok := m.MetadataIndex.Lookup("role", "user"); ok {
+		t.Error("a key no longer listed should not be indexed")
+	}
+	if len(results) != 1 {
+		t.Errorf("strict search over two indexed keys should intersect them, got %d", len(results))
+	}
+
+	e.SetIndexedMetadataKeys(nil)
+	if got := e.Search("release", 0, 10, map[string]string{"project": "p1"}, true); len(got) != 2 {
+		t.Errorf("strict search without an index should scan, got %d", len(got))
+	}
+	if m.MetadataIndex != nil {
+		t.Error("no keys should drop the index")
+	}
+}
+
+func TestMetadataIndexStrictSearchParity(t *testing.T) {
+	build := func(keys []string) *MatrixEngine {
+		m := core.NewMatrix("test-user", core.DefaultBounds())
+		e := NewMatrixEngine(m)
+		e.SetIndexedMetadataKeys(keys)
+		for i := 0; i < 40; i++ {
+			metadata := map[string]string{"thread_id": fmt.Sprintf("t%d", i%4), "role": []string{"user", "assistant"}[i%2]}
+			if i%5 == 0 {
+				metadata = nil
+			}
+			e.AddNeuron(fmt.Sprintf("incident %d in the payments service", i), nil, metadata)
+		}
+		return e
+	}
+	indexed, scanned := build(DefaultIndexedMetadataKeys), build(nil)
+
+	for _, filter := range []map[string]string{
+		{"thread_id": "t1"},
+		{"thread_id": "t2", "role": "user"},
+		{"thread_id": "t9"},
+		{"thread_id": "t3", "topic": "payments"},
+	} {
+		want := scanned.Search("payments incident", 0, 50, filter, true)
+		got := indexed.Search("payments incident", 0, 50, filter, true)
+		if len(got) != len(want) {
+			t.Fatalf("filter %v: index returned %d results, scan %d", filter, len(got), len(want))
+		}
+		// Ties are ordered arbitrarily, so compare the result sets.
+		found := make(map[string]bool, len(got))
+		for _, n := range got {
+			found[n.Content] = true
+		}
+		for _, n := range want {
+			if !found[n.Content] {
+				t.Errorf("filter %v: the index missed %q", filter, n.Content)
+			}
+		}
+		if _, stats := indexed.SearchWithStats("payments incident", 0, 50, filter, true, nil); stats.Scanned > 10 {
+			t.Errorf("filter %v: the index should narrow the scan, scanned %d", filter, stats.Scanned)
+		}
+	}
+}
+
+func TestGraphStatsMetadataIndex(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	e.AddNeuron("one", nil, map[string]string{"thread_id": "t1", "role": "user"})
+	e.AddNeuron("two", nil, map[string]string{"thread_id": "t2", "role": "user"})
+	e.AddNeuron("three", nil, nil)
+
+	stats := e.GraphStats().MetadataIndex
+	if stats == nil {
+		t.Fatal("expected metadata index stats")
+	}
+	want := map[string]core.MetadataKeyStats{
+		"role":      {Key: "role", Cardinality: 1, Neurons: 2},
+		"thread_id": {Key: "thread_id", Cardinality: 2, Neurons: 2},
+	}
+	for _, ks := range stats.Keys {
+		if ks != want[ks.Key] {
+			t.Errorf("got %+v, want %+v", ks, want[ks.Key])
+		}
+	}
+	if len(stats.Keys) != 2 || stats.MemoryBytes <= 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// EmbedPending embeds up to limit neurons that were written while the
+// vector layer was failing, and returns how many it embedded. Embedding
+// runs outside the matrix lock; a neuron changed or deleted meanwhile is
+// left for the next pass. It stops early, with the error, once the vector
+// layer reports itself unavailable.
+func (e *MatrixEngine) EmbedPending(limit int) (int, error) {
+	vectorizer, model, _, _ := e.vectorSettings()
+	if vectorizer == nil || limit <= 0 {
+		return 0, nil
+	}
+
+	type pending struct {
+		id      core.NeuronID
+		content string
+		hash    string
+	}
+	e.matrix.RLock()
+	var queue []pending
+	for id, n := range e.matrix.Neurons {
+		if n.EmbedPending {
+			queue = append(queue, pending{id: id, content: n.Content, hash: n.ContentHash})
+			if len(queue) == limit {
+				break
+			}
+		}
+	}
+	e.matrix.RUnlock()
+
+	embedded := 0
+	for _, p := range queue {
+		emb, err := vectorizer.EmbedTextContext(e.traceContext(), p.content)
+		if errors.Is(err, vector.ErrVectorUnavailable) {
+			return embedded, err
+		}
+		if err != nil {
+			continue
+		}
+		vector.Normalize(emb)
+
+		e.matrix.Lock()
+		if n, ok := e.matrix.Neurons[p.id]; ok && n.EmbedPending && n.ContentHash == p.hash {
+			n.Lock()
+			n.Embedding = emb
+			n.EmbeddingModel = model
+			n.EmbedPending = false
+			n.Unlock()
+			e.matrix.RecordChange(n)
+			e.matrix.ModifiedAt = time.Now()
+			e.matrix.Version++
+			embedded++
+		}
+		e.matrix.Unlock()
+	}
+	return embedded, nil
+}
+
+// PendingEmbeddings returns how many neurons are waiting to be embedded.
+func (e *MatrixEngine) PendingEmbeddings() int {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	count := 0
+	for _, n := range e.matrix.Neurons {
+		if n.EmbedPending {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// downEmbedder is a unitEmbedder that fails while down is set.
+type downEmbedder struct {
+	unitEmbedder
+	down atomic.Bool
+}
+
+func (e *downEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	if e.down.Load() {
+		return nil, errors.New("llama backend wedged")
+	}
+	return e.unitEmbedder.EmbedTextContext(ctx, text)
+}
+
+func TestEmbedPending_QueuesWritesUntilRecovery(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	backend := &downEmbedder{}
+	breaker := vector.NewResilientEmbedder(backend, vector.ResiliencePolicy{Threshold: 1, Cooldown: time.Millisecond})
+	e.SetVectorizer(breaker, "code")
+
+	backend.down.Store(true)
+	queued, err := e.AddNeuron("deploys go out on Mondays", nil, nil)
+	if err != nil {
+		t.Fatalf("a failed embedding should not fail the write: %v", err)
+	}
+	changed, _ := e.AddNeuron("the release train leaves Tuesdays", nil, nil)
+	if !queued.EmbedPending || len(queued.Embedding) != 0 || e.PendingEmbeddings() != 2 {
+		t.Fatalf("expected two neurons queued for embedding, got %d", e.PendingEmbeddings())
+	}
+	if e.GetStats()["pending_embeddings"] != 2 {
+		t.Errorf("stats should report the queue, got %v", e.GetStats()["pending_embeddings"])
+	}
+
+	if n, err := e.EmbedPending(10); n != 0 || !errors.Is(err, vector.ErrCircuitOpen) {
+		t.Errorf("an open breaker should stop the pass, got %d, %v", n, err)
+	}
+
+	backend.down.Store(false)
+	time.Sleep(2 * time.Millisecond)
+	if err := e.UpdateNeuron(changed.ID, "the release train leaves Wednesdays"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := e.EmbedPending(10); n != 2 || err != nil {
+		t.Fatalf("expected both neurons embedded after recovery, got %d, %v", n, err)
+	}
+	if queued.EmbedPending || len(queued.Embedding) != 4 || queued.EmbeddingModel != "code" || e.PendingEmbeddings() != 0 {
+		t.Errorf("the neuron should carry its embedding now: pending=%v dims=%d", queued.EmbedPending, len(queued.Embedding))
+	}
+	if status := breaker.Status(); status.WriteFallbacks != 2 || status.State != vector.BreakerClosed {
+		t.Errorf("unexpected breaker status: %+v", status)
+	}
+}
+
+func TestSearcherFallsBackToLexicalWhenEmbeddingFails(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	backend := &downEmbedder{}
+	breaker := vector.NewResilientEmbedder(backend, vector.ResiliencePolicy{})
+	e.SetVectorizer(breaker, "")
+	e.AddNeuron("parseConfig reads yaml", nil, nil)
+
+	searcher := NewSearcher(m)
+	searcher.SetVectorizer(breaker, "", 0.9, 1)
+	searcher.Search("parseConfig", 0, 10)
+	if stats := searcher.Stats(); !stats.VectorQuery || stats.VectorFallback {
+		t.Errorf("expected a hybrid search, got %+v", stats)
+	}
+
+	backend.down.Store(true)
+	results := searcher.Search("parseConfig", 0, 10)
+	stats := searcher.Stats()
+	if len(results) != 1 || stats.VectorQuery || !stats.VectorFallback || stats.TopComponent != ComponentLexical {
+		t.Errorf("expected a lexical fallback, got %d results and %+v", len(results), stats)
+	}
+	if breaker.Status().SearchFallbacks != 1 {
+		t.Errorf("the fallback should be counted, got %+v", breaker.Status())
+	}
+}
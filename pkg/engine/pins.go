@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// SetPinned pins or unpins a neuron. Pinning fails with core.ErrPinLimit
+// when the matrix already holds max pinned neurons; re-pinning a pinned
+// neuron and unpinning never fail.
+func (e *MatrixEngine) SetPinned(id core.NeuronID, pinned bool, max int) (*core.Neuron, error) {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	n, ok := e.matrix.Neurons[id]
+	if !ok {
+		return nil, core.ErrNeuronNotFound
+	}
+	if n.Pinned == pinned {
+		return n, nil
+	}
+	if pinned && e.pinnedCountLocked() >= max {
+		return nil, core.ErrPinLimit
+	}
+
+	n.Lock()
+	n.Pinned = pinned
+	n.Unlock()
+	e.matrix.RecordChange(n)
+	e.matrix.ModifiedAt = time.Now()
+	e.matrix.Version++
+	return n, nil
+}
+
+// PinnedCount returns how many neurons are pinned.
+func (e *MatrixEngine) PinnedCount() int {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+	return e.pinnedCountLocked()
+}
+
+func (e *MatrixEngine) pinnedCountLocked() int {
+	count := 0
+	for _, n := range e.matrix.Neurons {
+		if n.Pinned {
+			count++
+		}
+	}
+	return count
+}
+
+// PinnedNeurons lists pinned neurons, oldest first.
+func (e *MatrixEngine) PinnedNeurons() []*core.Neuron {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	var pinned []*core.Neuron
+	for _, n := range e.matrix.Neurons {
+		if n.Pinned {
+			pinned = append(pinned, n)
+		}
+	}
+	sort.Slice(pinned, func(i, j int) bool {
+		if !pinned[i].CreatedAt.Equal(pinned[j].CreatedAt) {
+			return pinned[i].CreatedAt.Before(pinned[j].CreatedAt)
+		}
+		return pinned[i].ID < pinned[j].ID
+	})
+	return pinned
+}
+
+// EvictionCandidate is a neuron quota eviction may forget, with the
+// footprint forgetting it frees: its own and half that of each of its
+// synapses, which may also be freed through their other end.
+type EvictionCandidate struct {
+	ID        core.NeuronID
+	Footprint int64
+}
+
+// EvictionOrder lists the unpinned neurons weakest first: by energy, then
+// least recently fired, then ID.
+func (e *MatrixEngine) EvictionOrder() []EvictionCandidate {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	var order []*core.Neuron
+	for _, n := range e.matrix.Neurons {
+		if !n.Pinned {
+			order = append(order, n)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a.Energy != b.Energy {
+			return a.Energy < b.Energy
+		}
+		if !a.LastFiredAt.Equal(b.LastFiredAt) {
+			return a.LastFiredAt.Before(b.LastFiredAt)
+		}
+		return a.ID < b.ID
+	})
+
+	synapses := make(map[core.NeuronID]int64)
+	for _, syn := range e.matrix.Synapses {
+		half := core.SynapseFootprint(syn) / 2
+		synapses[syn.FromID] += half
+		synapses[syn.ToID] += half
+	}
+	candidates := make([]EvictionCandidate, len(order))
+	for i, n := range order {
+		candidates[i] = EvictionCandidate{ID: n.ID, Footprint: core.NeuronFootprint(n) + synapses[n.ID]}
+	}
+	return candidates
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// PromotedNeuron is a neuron and one of its promotion records.
+type PromotedNeuron struct {
+	Neuron    *core.Neuron
+	Promotion core.Promotion
+}
+
+// Promote moves a neuron one layer deeper at time at and records why: its
+// access count, energy, age and synapses as they stand. The neuron keeps
+// its last keep records; keep <= 0 records nothing.
+func (e *MatrixEngine) Promote(id core.NeuronID, at time.Time, keep int) (core.Promotion, error) {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	n, ok := e.matrix.Neurons[id]
+	if !ok {
+		return core.Promotion{}, core.ErrNeuronNotFound
+	}
+
+	var strength float64
+	synapses := 0
+	for _, other := range e.matrix.Adjacency[id] {
+		syn, ok := e.matrix.Synapses[core.NewSynapseID(id, other)]
+		if !ok {
+			syn, ok = e.matrix.Synapses[core.NewSynapseID(other, id)]
+		}
+		if ok {
+			strength += syn.Weight
+			synapses++
+		}
+	}
+	if synapses > 0 {
+		strength /= float64(synapses)
+	}
+
+	n.Lock()
+	p := core.Promotion{
+		At:              at,
+		FromDepth:       n.Depth,
+		ToDepth:         n.Depth + 1,
+		Reason:          core.PromotionMature,
+		AccessCount:     n.AccessCount,
+		Energy:          n.Energy,
+		AgeSeconds:      at.Sub(n.CreatedAt).Seconds(),
+		Synapses:        synapses,
+		SynapseStrength: strength,
+	}
+	if n.Pinned {
+		p.Reason = core.PromotionPinned
+	}
+	n.Depth++
+	if keep > 0 {
+		n.Promotions = append(slices.Clip(n.Promotions), p)
+		if drop := len(n.Promotions) - keep; drop > 0 {
+			n.Promotions = append([]core.Promotion(nil), n.Promotions[drop:]...)
+		}
+	}
+	n.Unlock()
+	e.matrix.Remeasure(n)
+	return p, nil
+}
+
+// Promotions lists the promotion records made at or after since, newest
+// first, at most limit of them; limit <= 0 lists all.
+func (e *MatrixEngine) Promotions(since time.Time, limit int) []PromotedNeuron {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	var promoted []PromotedNeuron
+	for _, n := range e.matrix.Neurons {
+		for _, p := range n.Promotions {
+			if !p.At.Before(since) {
+				promoted = append(promoted, PromotedNeuron{Neuron: n, Promotion: p})
+			}
+		}
+	}
+	sort.Slice(promoted, func(i, j int) bool {
+		a, b := promoted[i], promoted[j]
+		if !a.Promotion.At.Equal(b.Promotion.At) {
+			return a.Promotion.At.After(b.Promotion.At)
+		}
+		if a.Neuron.ID != b.Neuron.ID {
+			return a.Neuron.ID < b.Neuron.ID
+		}
+		return a.Promotion.ToDepth > b.Promotion.ToDepth
+	})
+	if limit > 0 && len(promoted) > limit {
+		promoted = promoted[:limit]
+	}
+	return promoted
+}
@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// RecycleReasonForget is the reason recorded for neurons forgotten on
+// request.
+const RecycleReasonForget = "forget"
+
+// RestoreResult is the outcome of RestoreNeuron.
+type RestoreResult struct {
+	Neuron *core.Neuron
+	// Reattached counts the remembered synapses restored; Dropped those
+	// whose other endpoint is gone or already linked again.
+	Reattached int
+	Dropped    int
+}
+
+// SetRecycleWindow sets how long forgotten neurons stay restorable. 0
+// removes them at once.
+func (e *MatrixEngine) SetRecycleWindow(d time.Duration) {
+	e.recycleWindow = d
+}
+
+// RecycleWindow returns how long forgotten neurons stay restorable.
+func (e *MatrixEngine) RecycleWindow() time.Duration {
+	return e.recycleWindow
+}
+
+// ForgetNeuron forgets a neuron for reason. Within a recycle window the
+// neuron moves to the matrix's recycle bin with the synapses it loses, out
+// of every retrieval and with its energy frozen, until RestoreNeuron brings
+// it back or the window ends; without one it is removed like DeleteNeuron.
+func (e *MatrixEngine) ForgetNeuron(id core.NeuronID, reason string) error {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	if e.recycleWindow <= 0 {
+		return e.deleteNeuronLocked(id)
+	}
+	return e.recycleNeuronLocked(id, reason, time.Now())
+}
+
+// recycleNeuronLocked moves neuron id to the recycle bin at now. Its share
+// of the footprint stays. Callers hold the matrix write lock.
+func (e *MatrixEngine) recycleNeuronLocked(id core.NeuronID, reason string, now time.Time) error {
+	neuron, ok := e.matrix.Neurons[id]
+	if !ok {
+		return core.ErrNeuronNotFound
+	}
+
+	synapses := e.detachSynapsesLocked(id)
+	delete(e.matrix.Neurons, id)
+	e.unindexTerms(neuron)
+	e.unindexMetadata(neuron)
+	if e.matrix.Recycled == nil {
+		e.matrix.Recycled = make(map[core.NeuronID]*core.RecycledNeuron)
+	}
+	e.matrix.Recycled[id] = &core.RecycledNeuron{Neuron: neuron, Synapses: synapses, DeletedAt: now, Reason: reason}
+	e.matrix.RecordRemoval(id, e.changelogSize)
+	e.matrix.ModifiedAt = now
+	e.matrix.Version++
+
+	e.checkDimensionContraction()
+	return nil
+}
+
+// RestoreNeuron brings a recycled neuron back with the remembered synapses
+// whose other endpoint still exists. It fails with core.ErrNeuronNotFound
+// when id is not recycled, core.ErrMatrixFull at the neuron bound and
+// core.ErrDuplicateNeuron when a live neuron holds the same content.
+func (e *MatrixEngine) RestoreNeuron(id core.NeuronID) (RestoreResult, error) {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	r, ok := e.matrix.Recycled[id]
+	if !ok {
+		return RestoreResult{}, core.ErrNeuronNotFound
+	}
+	if len(e.matrix.Neurons) >= e.matrix.Bounds.MaxNeurons {
+		return RestoreResult{}, core.ErrMatrixFull
+	}
+	n := r.Neuron
+	for _, live := range e.matrix.Neurons {
+		if live.ContentHash == n.ContentHash {
+			return RestoreResult{}, core.ErrDuplicateNeuron
+		}
+	}
+	delete(e.matrix.Recycled, id)
+
+	// The matrix may have grown or shrunk a dimension meanwhile.
+	if len(n.Position) != e.matrix.CurrentDim {
+		pos := make([]float64, e.matrix.CurrentDim)
+		copy(pos, n.Position)
+		for i := len(n.Position); i < len(pos); i++ {
+			pos[i] = (rand.Float64() - 0.5) * 0.1
+		}
+		n.SetPosition(pos)
+	}
+
+	e.matrix.Neurons[id] = n
+	e.matrix.Adjacency[id] = []core.NeuronID{}
+	result := RestoreResult{Neuron: n}
+	for _, syn := range r.Synapses {
+		other := syn.ToID
+		if other == id {
+			other = syn.FromID
+		}
+		_, linked := e.matrix.Synapses[core.NewSynapseID(syn.FromID, syn.ToID)]
+		_, reverse := e.matrix.Synapses[core.NewSynapseID(syn.ToID, syn.FromID)]
+		if e.matrix.Neurons[other] == nil || linked || reverse {
+			e.matrix.AddFootprint(-core.SynapseFootprint(syn))
+			result.Dropped++
+			continue
+		}
+		e.matrix.Synapses[syn.ID] = syn
+		e.matrix.Adjacency[id] = append(e.matrix.Adjacency[id], other)
+		e.matrix.Adjacency[other] = append(e.matrix.Adjacency[other], id)
+		result.Reattached++
+	}
+
+	e.indexTerms(n)
+	e.indexMetadata(n)
+	e.matrix.RecordChange(n)
+	e.matrix.ModifiedAt = time.Now()
+	e.matrix.Version++
+
+	e.checkDimensionExpansion()
+	return result, nil
+}
+
+// RecycledNeurons returns the recycle bin, most recently forgotten first.
+func (e *MatrixEngine) RecycledNeurons() []core.RecycledNeuron {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+	out := make([]core.RecycledNeuron, 0, len(e.matrix.Recycled))
+	for _, r := range e.matrix.Recycled {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DeletedAt.Equal(out[j].DeletedAt) {
+			return out[i].DeletedAt.After(out[j].DeletedAt)
+		}
+		return out[i].Neuron.ID < out[j].Neuron.ID
+	})
+	return out
+}
+
+// PurgeNeuron removes recycled neuron id for good, before its window ends.
+// With live set, a neuron that was never forgotten is removed too, as by
+// DeleteNeuron; otherwise it fails with core.ErrNeuronNotFound.
+func (e *MatrixEngine) PurgeNeuron(id core.NeuronID, live bool) error {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	if _, ok := e.matrix.Recycled[id]; ok {
+		e.purgeRecycledLocked(id)
+		e.matrix.ModifiedAt = time.Now()
+		e.matrix.Version++
+		return nil
+	}
+	if !live {
+		return core.ErrNeuronNotFound
+	}
+	return e.deleteNeuronLocked(id)
+}
+
+// PurgeExpiredRecycled removes the recycled neurons whose window ended at
+// now and returns their IDs.
+func (e *MatrixEngine) PurgeExpiredRecycled(now time.Time) []core.NeuronID {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	var purged []core.NeuronID
+	for id, r := range e.matrix.Recycled {
+		if now.Sub(r.DeletedAt) >= e.recycleWindow {
+			e.purgeRecycledLocked(id)
+			purged = append(purged, id)
+		}
+	}
+	if len(purged) > 0 {
+		e.matrix.ModifiedAt = now
+		e.matrix.Version++
+	}
+	return purged
+}
+
+// purgeRecycledLocked drops recycled neuron id and its share of the
+// footprint. Its tombstone was recorded when it was forgotten. Callers hold
+// the matrix write lock.
+func (e *MatrixEngine) purgeRecycledLocked(id core.NeuronID) {
+	r := e.matrix.Recycled[id]
+	delete(e.matrix.Recycled, id)
+	e.matrix.Unmeasure(r.Neuron)
+	for _, syn := range r.Synapses {
+		e.matrix.AddFootprint(-core.SynapseFootprint(syn))
+	}
+}
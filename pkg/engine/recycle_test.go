@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// hasNeuron reports whether id is among neurons.
+func hasNeuron(neurons []*core.Neuron, id core.NeuronID) bool {
+	for _, n := range neurons {
+		if n.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRecycle_ForgetRestorePurge(t *testing.T) {
+	m := core.NewMatrix("recycle", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	e.SetRecycleWindow(72 * time.Hour)
+
+	forgotten, _ := e.AddNeuron("the launch moved to thursday", nil, map[string]string{"project": "apollo"})
+	kept, _ := e.AddNeuron("thursday standup notes", nil, nil)
+	gone, _ := e.AddNeuron("launch checklist", nil, nil)
+	for _, other := range []core.NeuronID{kept.ID, gone.ID} {
+		syn := core.NewSynapse(forgotten.ID, other, 0.7)
+		m.Synapses[syn.ID] = syn
+		m.Adjacency[forgotten.ID] = append(m.Adjacency[forgotten.ID], other)
+		m.Adjacency[other] = append(m.Adjacency[other], forgotten.ID)
+	}
+	footprint := m.RecomputeFootprint()
+	energy := forgotten.Energy
+
+	if err := e.ForgetNeuron(forgotten.ID, RecycleReasonForget); err != nil {
+		t.Fatalf("ForgetNeuron: %v", err)
+	}
+	if _, err := e.GetNeuron(forgotten.ID); !errors.Is(err, core.ErrNeuronNotFound) {
+		t.Errorf("a recycled neuron should not be readable, got %v", err)
+	}
+	if hasNeuron(e.Search("launch", 0, 10, nil, false), forgotten.ID) {
+		t.Error("a recycled neuron should not be found")
+	}
+	if len(m.Synapses) != 0 || len(m.Adjacency[kept.ID]) != 0 {
+		t.Errorf("the synapses should be detached, got %d", len(m.Synapses))
+	}
+	if m.Footprint() != footprint {
+		t.Errorf("a recycled neuron should keep its footprint: %d, want %d", m.Footprint(), footprint)
+	}
+	recycled := e.RecycledNeurons()
+	if len(recycled) != 1 || recycled[0].Reason != RecycleReasonForget || len(recycled[0].Synapses) != 2 {
+		t.Fatalf("expected the neuron in the recycle bin with 2 synapses, got %+v", recycled)
+	}
+
+	// One endpoint is removed meanwhile; its synapse cannot come back.
+	if err := e.DeleteNeuron(gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := e.RestoreNeuron(forgotten.ID)
+	if err != nil {
+		t.Fatalf("RestoreNeuron: %v", err)
+	}
+	if restored.Reattached != 1 || restored.Dropped != 1 {
+		t.Errorf("expected 1 synapse reattached and 1 dropped, got %+v", restored)
+	}
+	if restored.Neuron.Energy != energy {
+		t.Errorf("the energy should be as it was forgotten: %v, want %v", restored.Neuron.Energy, energy)
+	}
+	if !hasNeuron(e.Search("launch", 0, 10, nil, false), forgotten.ID) {
+		t.Error("a restored neuron should be found again")
+	}
+	if !hasNeuron(e.Search("launch", 0, 10, map[string]string{"project": "apollo"}, true), forgotten.ID) {
+		t.Error("a restored neuron should match its metadata again")
+	}
+	if _, ok := m.Synapses[core.NewSynapseID(forgotten.ID, kept.ID)]; !ok || len(m.Adjacency[kept.ID]) != 1 {
+		t.Error("the synapse to the surviving neuron should be reattached")
+	}
+	if _, err := e.RestoreNeuron(forgotten.ID); !errors.Is(err, core.ErrNeuronNotFound) {
+		t.Errorf("a live neuron cannot be restored, got %v", err)
+	}
+
+	// Forgotten again, it stays until the window ends.
+	if err := e.ForgetNeuron(forgotten.ID, RecycleReasonForget); err != nil {
+		t.Fatal(err)
+	}
+	if purged := e.PurgeExpiredRecycled(time.Now().Add(71 * time.Hour)); len(purged) != 0 {
+		t.Errorf("nothing should be purged within the window, got %v", purged)
+	}
+	if purged := e.PurgeExpiredRecycled(time.Now().Add(73 * time.Hour)); len(purged) != 1 || purged[0] != forgotten.ID {
+		t.Errorf("the neuron should be purged once the window ends, got %v", purged)
+	}
+	if _, err := e.RestoreNeuron(forgotten.ID); !errors.Is(err, core.ErrNeuronNotFound) {
+		t.Errorf("a purged neuron cannot be restored, got %v", err)
+	}
+	if got, want := m.Footprint(), m.RecomputeFootprint(); got != want {
+		t.Errorf("the footprint drifted: %d, recomputed %d", got, want)
+	}
+}
+
+func TestRecycle_HardDelete(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("recycle", core.DefaultBounds()))
+	e.SetRecycleWindow(time.Hour)
+	n, _ := e.AddNeuron("a secret to erase", nil, nil)
+	live, _ := e.AddNeuron("a secret to erase at once", nil, nil)
+
+	if err := e.PurgeNeuron(live.ID, false); !errors.Is(err, core.ErrNeuronNotFound) {
+		t.Errorf("a live neuron is only purged when asked, got %v", err)
+	}
+	if err := e.PurgeNeuron(live.ID, true); err != nil || len(e.RecycledNeurons()) != 0 {
+		t.Errorf("a live purge should bypass the recycle bin: %v", err)
+	}
+
+	e.ForgetNeuron(n.ID, RecycleReasonForget)
+	if err := e.PurgeNeuron(n.ID, false); err != nil || len(e.RecycledNeurons()) != 0 {
+		t.Errorf("a recycled neuron should be purged before its window ends: %v", err)
+	}
+
+	// Without a window, forgetting removes at once.
+	e.SetRecycleWindow(0)
+	other, _ := e.AddNeuron("no second chances", nil, nil)
+	e.ForgetNeuron(other.ID, RecycleReasonForget)
+	if len(e.RecycledNeurons()) != 0 || e.matrix.Neurons[other.ID] != nil {
+		t.Error("a zero window should remove forgotten neurons at once")
+	}
+}
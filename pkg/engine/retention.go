@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// RetentionAnonymizedKey marks a neuron anonymized by a retention rule,
+// with the time it happened, so later runs leave it alone.
+const RetentionAnonymizedKey = "_retention_anonymized"
+
+// RetentionOptions configures ApplyRetention.
+type RetentionOptions struct {
+	Now               time.Time
+	ContentMode       string   // admin.clone.contentMode
+	StripMetadataKeys []string // removed by anonymize
+	DryRun            bool
+}
+
+// RetentionRuleResult counts what one rule of a policy did.
+type RetentionRuleResult struct {
+	Rule     int    `json:"rule"` // position in the policy
+	Action   string `json:"action"`
+	Matched  int    `json:"matched"`  // neurons the rule governs (first match)
+	Expired  int    `json:"expired"`  // of those, older than maxAge
+	Affected int    `json:"affected"` // changed, or would be on a dry run
+}
+
+// RetentionReport is the outcome of ApplyRetention. Deleted lists the
+// neurons a real run removed.
+type RetentionReport struct {
+	DryRun   bool                  `json:"dryRun"`
+	Scanned  int                   `json:"scanned"`
+	Affected int                   `json:"affected"`
+	Rules    []RetentionRuleResult `json:"rules"`
+	Deleted  []core.NeuronID       `json:"-"`
+}
+
+// ApplyRetention enforces a retention policy. Each neuron is governed by
+// the first rule whose match it fits; once it is older than that rule's
+// maxAge the rule's action is applied. Neurons a previous run already
+// drained or anonymized are not counted again. A dry run reports the same
+// counts without changing anything.
+func (e *MatrixEngine) ApplyRetention(rules []core.RetentionRule, opts RetentionOptions) RetentionReport {
+	if opts.DryRun {
+		e.matrix.RLock()
+		defer e.matrix.RUnlock()
+	} else {
+		e.matrix.Lock()
+		defer e.matrix.Unlock()
+	}
+
+	report := RetentionReport{DryRun: opts.DryRun, Rules: make([]RetentionRuleResult, len(rules))}
+	ages := make([]time.Duration, len(rules))
+	for i, r := range rules {
+		report.Rules[i] = RetentionRuleResult{Rule: i, Action: r.Action}
+		ages[i] = r.Age()
+	}
+
+	var deletes []core.NeuronID
+	for id, n := range e.matrix.Neurons {
+		report.Scanned++
+		i := firstRetentionMatch(rules, n)
+		if i < 0 {
+			continue
+		}
+		res := &report.Rules[i]
+		res.Matched++
+		if ages[i] <= 0 || opts.Now.Sub(n.CreatedAt) <= ages[i] {
+			continue
+		}
+		res.Expired++
+		if !retentionPending(rules[i].Action, n) {
+			continue
+		}
+		res.Affected++
+		report.Affected++
+		if opts.DryRun {
+			continue
+		}
+		switch rules[i].Action {
+		case core.RetentionDelete:
+			deletes = append(deletes, id)
+		case core.RetentionDrain:
+			n.Lock()
+			n.Pinned = false
+			n.Energy, n.BaseEnergy = 0, 0
+			n.Unlock()
+			e.matrix.RecordChange(n)
+		case core.RetentionAnonymize:
+			e.unindexTerms(n)
+			e.unindexMetadata(n)
+			core.AnonymizeNeuron(n, opts.ContentMode, opts.StripMetadataKeys)
+			// The embedding was derived from the erased content.
+			n.Lock()
+			n.Embedding, n.EmbeddingModel, n.EmbedPending = nil, "", false
+			n.Unlock()
+			n.EditMetadata(func(metadata map[string]any) {
+				metadata[RetentionAnonymizedKey] = opts.Now.UTC().Format(time.RFC3339)
+			})
+			e.indexTerms(n)
+			e.indexMetadata(n)
+			e.matrix.RecordChange(n)
+		}
+	}
+	for _, id := range deletes {
+		if e.deleteNeuronLocked(id) == nil {
+			report.Deleted = append(report.Deleted, id)
+		}
+	}
+	if !opts.DryRun && report.Affected > 0 {
+		e.matrix.ModifiedAt = opts.Now
+		e.matrix.Version++
+	}
+	return report
+}
+
+// firstRetentionMatch returns the index of the first rule n fits, or -1.
+func firstRetentionMatch(rules []core.RetentionRule, n *core.Neuron) int {
+	for i, r := range rules {
+		if retentionMatches(r.Match, n) {
+			return i
+		}
+	}
+	return -1
+}
+
+func retentionMatches(match map[string]string, n *core.Neuron) bool {
+	for k, want := range match {
+		v, ok := n.Metadata[k]
+		if !ok || fmt.Sprintf("%v", v) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// retentionPending reports whether applying action to n would change it.
+func retentionPending(action string, n *core.Neuron) bool {
+	switch action {
+	case core.RetentionDrain:
+		n.RLock()
+		defer n.RUnlock()
+		return n.Pinned || n.Energy > 0 || n.BaseEnergy > 0
+	case core.RetentionAnonymize:
+		_, done := n.Metadata[RetentionAnonymizedKey]
+		return !done
+	}
+	return true
+}
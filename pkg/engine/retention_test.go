@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// agedNeuron adds a neuron created age before now.
+func agedNeuron(t *testing.T, e *MatrixEngine, content string, metadata map[string]string, now time.Time, age time.Duration) *core.Neuron {
+	t.Helper()
+	n, err := e.AddNeuron(content, nil, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.CreatedAt = now.Add(-age)
+	return n
+}
+
+func TestApplyRetention_Actions(t *testing.T) {
+	m := core.NewMatrix("retention", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	now := time.Now()
+	day := 24 * time.Hour
+
+	oldPII := agedNeuron(t, e, "alice lives at 12 elm street", map[string]string{"pii": "true", "email": "a@example.com"}, now, 40*day)
+	newPII := agedNeuron(t, e, "bob moved last week", map[string]string{"pii": "true"}, now, 2*day)
+	oldTemp := agedNeuron(t, e, "scratch note about lunch", map[string]string{"class": "temp"}, now, 3*day)
+	oldNote := agedNeuron(t, e, "quarterly figures were revised", map[string]string{"class": "note"}, now, 10*day)
+	oldNote.Pinned = true
+	syn := core.NewSynapse(oldPII.ID, oldNote.ID, 0.5)
+	m.Synapses[syn.ID] = syn
+	m.Adjacency[oldPII.ID] = append(m.Adjacency[oldPII.ID], oldNote.ID)
+
+	rules := []core.RetentionRule{
+		{Match: map[string]string{"pii": "true"}, MaxAge: "30d", Action: core.RetentionAnonymize},
+		{Match: map[string]string{"class": "temp"}, MaxAge: "1d", Action: core.RetentionDelete},
+		{Match: map[string]string{"class": "note"}, MaxAge: "7d", Action: core.RetentionDrain},
+	}
+	report := e.ApplyRetention(rules, RetentionOptions{Now: now, ContentMode: core.CloneContentRedact, StripMetadataKeys: []string{"email"}})
+	if report.Scanned != 4 || report.Affected != 3 || len(report.Deleted) != 1 || report.Deleted[0] != oldTemp.ID {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if r := report.Rules[0]; r.Matched != 2 || r.Expired != 1 || r.Affected != 1 {
+		t.Errorf("unexpected anonymize counts: %+v", r)
+	}
+
+	if oldPII.Content != core.RedactedContent || oldPII.Metadata["email"] != nil || oldPII.Metadata[RetentionAnonymizedKey] == nil {
+		t.Errorf("old pii should be anonymized: %q %v", oldPII.Content, oldPII.Metadata)
+	}
+	if _, ok := m.Synapses[syn.ID]; !ok {
+		t.Error("anonymizing must keep the graph structure")
+	}
+	if results := NewSearcher(m).Search("elm street", 0, 10); len(results) != 0 {
+		t.Errorf("anonymized content should no longer be searchable, got %d results", len(results))
+	}
+	if newPII.Content != "bob moved last week" {
+		t.Error("a neuron younger than maxAge must be left alone")
+	}
+	if _, ok := m.Neurons[oldTemp.ID]; ok {
+		t.Error("expired temp neuron should be deleted")
+	}
+	if oldNote.Pinned || oldNote.Energy != 0 || oldNote.BaseEnergy != 0 {
+		t.Errorf("drained neuron should be unpinned with no energy: pinned=%v energy=%v", oldNote.Pinned, oldNote.Energy)
+	}
+
+	if again := e.ApplyRetention(rules, RetentionOptions{Now: now}); again.Affected != 0 {
+		t.Errorf("a second run should find nothing left to do, got %+v", again)
+	}
+}
+
+func TestApplyRetention_FirstMatchWins(t *testing.T) {
+	m := core.NewMatrix("retention", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	now := time.Now()
+
+	// Financial PII is kept for years by the first rule, even though the
+	// broader pii rule that follows would delete it.
+	financial := agedNeuron(t, e, "invoice 7 paid by card", map[string]string{"pii": "true", "class": "financial"}, now, 60*24*time.Hour)
+	agedNeuron(t, e, "home address on file", map[string]string{"pii": "true"}, now, 60*24*time.Hour)
+	rules := []core.RetentionRule{
+		{Match: map[string]string{"class": "financial"}, MaxAge: "7y", Action: core.RetentionDelete},
+		{Match: map[string]string{"pii": "true"}, MaxAge: "30d", Action: core.RetentionDelete},
+	}
+	report := e.ApplyRetention(rules, RetentionOptions{Now: now})
+	if report.Rules[0].Matched != 1 || report.Rules[0].Affected != 0 || report.Rules[1].Matched != 1 || report.Rules[1].Affected != 1 {
+		t.Fatalf("each neuron should be counted by its first matching rule only: %+v", report.Rules)
+	}
+	if _, ok := m.Neurons[financial.ID]; !ok {
+		t.Error("the financial neuron is governed by the first rule and must be kept")
+	}
+}
+
+func TestApplyRetention_DryRunMatchesRealRun(t *testing.T) {
+	m := core.NewMatrix("retention", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	now := time.Now()
+	for i, age := range []int{1, 5, 20, 45, 90} {
+		class := "chat"
+		if i%2 == 0 {
+			class = "log"
+		}
+		agedNeuron(t, e, strings.Repeat("memory ", i+1)+class, map[string]string{"class": class}, now, time.Duration(age)*24*time.Hour)
+	}
+	rules := []core.RetentionRule{
+		{Match: map[string]string{"class": "log"}, MaxAge: "10d", Action: core.RetentionDelete},
+		{MaxAge: "30d", Action: core.RetentionAnonymize},
+	}
+
+	version := m.Version
+	dry := e.ApplyRetention(rules, RetentionOptions{Now: now, DryRun: true})
+	if len(m.Neurons) != 5 || m.Version != version || dry.Deleted != nil {
+		t.Fatal("a dry run must not change the index")
+	}
+	real := e.ApplyRetention(rules, RetentionOptions{Now: now})
+	if dry.Affected != real.Affected || dry.Scanned != real.Scanned {
+		t.Errorf("dry run %+v does not match the real run %+v", dry, real)
+	}
+	for i := range rules {
+		d, r := dry.Rules[i], real.Rules[i]
+		if d.Matched != r.Matched || d.Expired != r.Expired || d.Affected != r.Affected {
+			t.Errorf("rule %d: dry run %+v, real run %+v", i, d, r)
+		}
+	}
+	if real.Affected != 3 || len(m.Neurons) != 3 {
+		t.Errorf("expected two logs deleted and one chat anonymized, got %+v with %d neurons left", real, len(m.Neurons))
+	}
+}
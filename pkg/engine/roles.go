@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// RoleMetadataKey is the reserved metadata key naming who authored a neuron
+// in a conversation, e.g. "user", "assistant" or "system".
+const RoleMetadataKey = "role"
+
+// NeuronRole returns the lower-cased role recorded on n, or "" if none.
+func NeuronRole(n *core.Neuron) string {
+	v, ok := n.Metadata[RoleMetadataKey]
+	if !ok || v == nil {
+		return ""
+	}
+	return strings.ToLower(fmt.Sprintf("%v", v))
+}
+
+// HasRole reports whether n was authored by any of roles. An empty roles
+// list matches every neuron; neurons without a role never match a non-empty
+// list.
+func HasRole(n *core.Neuron, roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	role := NeuronRole(n)
+	if role == "" {
+		return false
+	}
+	for _, r := range roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
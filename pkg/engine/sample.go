@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Signals a sample can be weighted by.
+const (
+	SampleWeightEnergy         = "energy"          // current energy
+	SampleWeightRecency        = "recency"         // recently fired first
+	SampleWeightInverseRecency = "inverse_recency" // long-unvisited first
+	SampleWeightUniform        = "uniform"
+)
+
+// IsSampleWeight reports whether s names a sample weighting.
+func IsSampleWeight(s string) bool {
+	switch s {
+	case SampleWeightEnergy, SampleWeightRecency, SampleWeightInverseRecency, SampleWeightUniform:
+		return true
+	}
+	return false
+}
+
+// SampleOptions configures Sample. Filter selects the candidates as in an
+// export; neurons past the maxAge of the first Expiry rule they match are
+// left out, as retention is about to act on them.
+type SampleOptions struct {
+	N        int
+	WeightBy string
+	Filter   SliceFilter
+	Expiry   []core.RetentionRule
+	Now      time.Time
+	Seed     int64
+}
+
+// SampledNeuron is a drawn neuron and its weight when the draw began.
+type SampledNeuron struct {
+	Neuron *core.Neuron
+	Weight float64
+}
+
+// SampleResult is the outcome of Sample. TotalWeight is the weight of all
+// Candidates, so Weight/TotalWeight is a neuron's chance of being drawn
+// first.
+type SampleResult struct {
+	Neurons     []SampledNeuron
+	Candidates  int
+	TotalWeight float64
+	Seed        int64
+}
+
+// Sample draws up to opts.N neurons without replacement, each with
+// probability proportional to its weight among the neurons not drawn yet.
+// Superseded and expired neurons, and neurons of weight zero, are never
+// drawn. Candidates are ordered by ID, so the same seed over an unchanged
+// index draws the same neurons.
+//
+// The weights are kept in a Fenwick tree: building it costs one pass over
+// the candidates, and each draw, and the removal of the drawn neuron,
+// O(log m).
+func (e *MatrixEngine) Sample(opts SampleOptions) SampleResult {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	candidates := make([]*core.Neuron, 0, len(e.matrix.Neurons))
+	for _, n := range e.matrix.Neurons {
+		if opts.Filter.matches(n) && !IsSuperseded(n) && !sampleExpired(opts.Expiry, n, opts.Now) {
+			candidates = append(candidates, n)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	weights := make([]float64, 0, len(candidates))
+	kept := candidates[:0]
+	var total float64
+	for _, n := range candidates {
+		if w := sampleWeight(opts.WeightBy, n, opts.Now); w > 0 {
+			kept = append(kept, n)
+			weights = append(weights, w)
+			total += w
+		}
+	}
+
+	res := SampleResult{Neurons: []SampledNeuron{}, Candidates: len(kept), TotalWeight: total, Seed: opts.Seed}
+	tree := newFenwick(weights)
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for len(res.Neurons) < opts.N && len(res.Neurons) < len(kept) {
+		i := tree.find(rng.Float64() * tree.total())
+		res.Neurons = append(res.Neurons, SampledNeuron{Neuron: kept[i], Weight: weights[i]})
+		tree.remove(i)
+	}
+	return res
+}
+
+// sampleWeight returns n's weight under weightBy. Recency is measured in
+// hours since n last fired.
+func sampleWeight(weightBy string, n *core.Neuron, now time.Time) float64 {
+	n.RLock()
+	defer n.RUnlock()
+	hours := max(now.Sub(n.LastFiredAt).Hours(), 0)
+	switch weightBy {
+	case SampleWeightEnergy:
+		return n.Energy
+	case SampleWeightRecency:
+		return 1 / (1 + hours)
+	case SampleWeightInverseRecency:
+		return 1 + hours
+	}
+	return 1
+}
+
+// sampleExpired reports whether n is older than the maxAge of the first
+// rule it matches.
+func sampleExpired(rules []core.RetentionRule, n *core.Neuron, now time.Time) bool {
+	i := firstRetentionMatch(rules, n)
+	if i < 0 {
+		return false
+	}
+	age := rules[i].Age()
+	return age > 0 && now.Sub(n.CreatedAt) > age
+}
+
+// fenwick is a binary indexed tree of weights: prefix sums, point updates
+// and weighted lookups in O(log m).
+type fenwick struct {
+	weights []float64
+	tree    []float64 // 1-based partial sums
+	top     int       // highest power of two <= len(weights)
+}
+
+func newFenwick(weights []float64) *fenwick {
+	f := &fenwick{weights: append([]float64(nil), weights...), tree: make([]float64, len(weights)+1), top: 1}
+	for i, w := range weights {
+		j := i + 1
+		f.tree[j] += w
+		if parent := j + j&-j; parent <= len(weights) {
+			f.tree[parent] += f.tree[j]
+		}
+	}
+	for f.top*2 <= len(weights) {
+		f.top *= 2
+	}
+	return f
+}
+
+// remove sets the weight at i to zero.
+func (f *fenwick) remove(i int) {
+	delta := -f.weights[i]
+	f.weights[i] = 0
+	for j := i + 1; j < len(f.tree); j += j & -j {
+		f.tree[j] += delta
+	}
+}
+
+// total returns the sum of the remaining weights.
+func (f *fenwick) total() float64 {
+	var sum float64
+	for j := len(f.tree) - 1; j > 0; j -= j & -j {
+		sum += f.tree[j]
+	}
+	return sum
+}
+
+// find returns the index whose weight interval holds target, a value in
+// [0, total): the smallest i whose prefix sum through i exceeds target.
+// Removed indexes are never returned.
+func (f *fenwick) find(target float64) int {
+	pos := 0
+	for step := f.top; step > 0; step /= 2 {
+		if next := pos + step; next < len(f.tree) && f.tree[next] <= target {
+			pos = next
+			target -= f.tree[next]
+		}
+	}
+	// Rounding in the partial sums can land on an interval of weight zero
+	// next to the right one.
+	pos = min(pos, len(f.weights)-1)
+	for i := pos; i >= 0; i-- {
+		if f.weights[i] > 0 {
+			return i
+		}
+	}
+	for i := pos + 1; i < len(f.weights); i++ {
+		if f.weights[i] > 0 {
+			return i
+		}
+	}
+	return pos
+}
@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// sampleFixture writes one neuron per weight, setting energy and last
+// firing so energy and inverse recency weigh them the same.
+func sampleFixture(t *testing.T, e *MatrixEngine, now time.Time, weights ...float64) []*core.Neuron {
+	t.Helper()
+	neurons := make([]*core.Neuron, len(weights))
+	for i, w := range weights {
+		n, err := e.AddNeuron(fmt.Sprintf("replay card %d about topic %c", i, 'a'+i), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.Energy = w
+		n.LastFiredAt = now.Add(-time.Duration((w*10 - 1) * float64(time.Hour)))
+		neurons[i] = n
+	}
+	return neurons
+}
+
+func TestSample_DistributionMatchesWeights(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	e := NewMatrixEngine(newTestMatrix())
+	weights := []float64{0.1, 0.2, 0.3, 0.4}
+	neurons := sampleFixture(t, e, now, weights...)
+
+	const draws = 8000
+	// Chi-squared critical value for 3 degrees of freedom at p = 0.001.
+	const critical = 16.27
+	for _, weightBy := range []string{SampleWeightEnergy, SampleWeightInverseRecency, SampleWeightUniform} {
+		t.Run(weightBy, func(t *testing.T) {
+			counts := map[core.NeuronID]int{}
+			for seed := int64(1); seed <= draws; seed++ {
+				res := e.Sample(SampleOptions{N: 1, WeightBy: weightBy, Now: now, Seed: seed})
+				if len(res.Neurons) != 1 {
+					t.Fatalf("expected one neuron, got %d", len(res.Neurons))
+				}
+				counts[res.Neurons[0].Neuron.ID]++
+			}
+
+			var chi2 float64
+			for i, n := range neurons {
+				p := weights[i]
+				if weightBy == SampleWeightUniform {
+					p = 0.25
+				}
+				expected := p * draws
+				d := float64(counts[n.ID]) - expected
+				chi2 += d * d / expected
+			}
+			if chi2 > critical {
+				t.Errorf("chi-squared %.2f exceeds %.2f: counts %v", chi2, critical, counts)
+			}
+		})
+	}
+}
+
+func TestSample_WithoutReplacement(t *testing.T) {
+	now := time.Now()
+	e := NewMatrixEngine(newTestMatrix())
+	weights := make([]float64, 20)
+	for i := range weights {
+		weights[i] = float64(i%4+1) / 10
+	}
+	sampleFixture(t, e, now, weights...)
+
+	for seed := int64(0); seed < 200; seed++ {
+		for _, n := range []int{5, 20, 50} {
+			res := e.Sample(SampleOptions{N: n, WeightBy: SampleWeightEnergy, Now: now, Seed: seed})
+			if want := min(n, len(weights)); len(res.Neurons) != want {
+				t.Fatalf("seed %d, n=%d: expected %d neurons, got %d", seed, n, want, len(res.Neurons))
+			}
+			seen := map[core.NeuronID]bool{}
+			for _, s := range res.Neurons {
+				if seen[s.Neuron.ID] {
+					t.Fatalf("seed %d, n=%d: %s drawn twice", seed, n, s.Neuron.ID)
+				}
+				seen[s.Neuron.ID] = true
+			}
+		}
+	}
+}
+
+func TestSample_SeedReproducesDraw(t *testing.T) {
+	now := time.Now()
+	e := NewMatrixEngine(newTestMatrix())
+	sampleFixture(t, e, now, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8)
+
+	ids := func(seed int64) string {
+		res := e.Sample(SampleOptions{N: 4, WeightBy: SampleWeightEnergy, Now: now, Seed: seed})
+		out := ""
+		for _, s := range res.Neurons {
+			out += string(s.Neuron.ID) + " "
+		}
+		return out
+	}
+	if a, b := ids(42), ids(42); a != b {
+		t.Errorf("same seed drew %q then %q", a, b)
+	}
+}
+
+func TestSample_FiltersAndExclusions(t *testing.T) {
+	now := time.Now()
+	e := NewMatrixEngine(newTestMatrix())
+	add := func(content string, metadata map[string]string) *core.Neuron {
+		n, err := e.AddNeuron(content, nil, metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	deck := add("Spanish: el perro means the dog", map[string]string{"deck": "spanish"})
+	old := add("Spanish: la casa means the car", map[string]string{"deck": "spanish"})
+	fixed := add("Spanish: la casa means the house", map[string]string{"deck": "spanish"})
+	if err := e.Supersede(old.ID, fixed.ID); err != nil {
+		t.Fatal(err)
+	}
+	expired := add("Spanish: temporary exam note", map[string]string{"deck": "spanish", "kind": "scratch"})
+	expired.CreatedAt = now.Add(-48 * time.Hour)
+	faded := add("Spanish: el gato means the cat", map[string]string{"deck": "spanish"})
+	faded.Energy = 0
+	add("French: le chien means the dog", map[string]string{"deck": "french"})
+
+	res := e.Sample(SampleOptions{
+		N:        10,
+		WeightBy: SampleWeightEnergy,
+		Filter:   SliceFilter{Metadata: map[string]string{"deck": "spanish"}},
+		Expiry:   []core.RetentionRule{{Match: map[string]string{"kind": "scratch"}, MaxAge: "24h", Action: core.RetentionDelete}},
+		Now:      now,
+		Seed:     7,
+	})
+	got := map[core.NeuronID]bool{}
+	for _, s := range res.Neurons {
+		got[s.Neuron.ID] = true
+	}
+	if len(got) != 2 || !got[deck.ID] || !got[fixed.ID] || res.Candidates != 2 {
+		t.Errorf("expected only the current, unexpired, charged spanish cards, got %v (%d candidates)", got, res.Candidates)
+	}
+
+	if res := e.Sample(SampleOptions{N: 10, WeightBy: SampleWeightUniform, Filter: SliceFilter{Metadata: map[string]string{"deck": "german"}}, Now: now}); len(res.Neurons) != 0 {
+		t.Errorf("expected an empty draw, got %d neurons", len(res.Neurons))
+	}
+}
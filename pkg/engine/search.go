@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"regexp"
@@ -18,23 +19,108 @@ import (
 type SearchResult struct {
 	Neuron *core.Neuron
 	Score  float64
+	Hop    int // 0 for direct matches, n for neurons reached by spread activation
+
+	// Anchor is the result's relatedness to the anchor of an anchored
+	// search (0-1).
+	Anchor float64
+
+	// Via is the result a spread result was reached from, through a
+	// synapse of SynapseType.
+	Via         core.NeuronID
+	SynapseType string
+}
+
+// Dominant scoring components reported in SearchStats.TopComponent.
+const (
+	ComponentLexical = "lexical"
+	ComponentVector  = "vector"
+	ComponentSpread  = "spread"
+	ComponentAnchor  = "anchor"
+)
+
+// SearchStats summarizes how a search produced its final result set.
+// It carries no query text so it is safe to aggregate.
+type SearchStats struct {
+	Results       int     // results returned after limit/filtering
+	Scanned       int     // neurons scored; fewer than the index when exact mode stopped early
+	DirectResults int     // results that matched the query directly
+	SpreadResults int     // results reached through spread activation
+	TopScore      float64 // score of the first result, 0 when empty
+	TopComponent  string  // which component dominated the first result, "" when empty
+
+	// VectorQuery is set when the query was embedded for hybrid scoring;
+	// VectorFallback when embedding it failed and the search was scored
+	// lexically only.
+	VectorQuery    bool
+	VectorFallback bool
+
+	Scores []float64 // per-result scores, parallel to the returned neurons
+
+	// Breakdowns explains each result's score, parallel to Scores.
+	Breakdowns []ScoreBreakdown
 }
 
+// ScoreBreakdown explains how a result's score was built.
+type ScoreBreakdown struct {
+	Score  float64 `json:"score"`
+	Hop    int     `json:"hop"`              // > 0 for spread results, which have no direct score
+	Phrase float64 `json:"phrase"`           // exact phrase bonus
+	BM25   float64 `json:"bm25"`             // BM25 contribution to the lexical score
+	Vector float64 `json:"vector"`           // cosine similarity, 0 when not compared
+	Anchor float64 `json:"anchor,omitempty"` // relatedness to the anchor of an anchored search
+	Focus  float64 `json:"focus,omitempty"`  // multiplier of the index focus, when it matched
+
+	// Via and SynapseType name, for spread results, the neuron they were
+	// reached from and the type of the synapse followed.
+	Via         string `json:"via,omitempty"`
+	SynapseType string `json:"synapseType,omitempty"`
+
+	// DocLength is the result's token count; LengthNorm is BM25's
+	// 1 - b + b*DocLength/AvgDocLength, above 1 for longer than average
+	// neurons.
+	DocLength    int     `json:"docLength"`
+	AvgDocLength float64 `json:"avgDocLength"`
+	LengthNorm   float64 `json:"lengthNorm"`
+
+	Terms []TermScore `json:"terms,omitempty"`
+}
+
+// TermScore is one query term's part of a BM25 score.
+type TermScore struct {
+	Term     string  `json:"term"`               // as matched, after folding
+	Original string  `json:"original,omitempty"` // as queried, when folding changed it
+	TF       float64 `json:"tf"`                 // occurrences in the result; fuzzy matches count once
+	IDF      float64 `json:"idf"`                // inverse document frequency in the index
+	Weight   float64 `json:"weight"`             // IDF times saturated, length-normalized TF
+
+	// Expansion marks a term added by query expansion; its weight is
+	// already scaled by the expansion weight.
+	Expansion bool `json:"expansion,omitempty"`
+}
+
+// contentTokens returns the tokens n is matched by: those the engine
+// stored on n when they were folded as this search folds, otherwise tokens
+// computed for this searcher.
 func (s *Searcher) contentTokens(n *core.Neuron) []string {
+	if !s.fold.CaseSensitive && n.LexicalKey != "" && n.LexicalKey == s.fold.neuronKey(n) {
+		return n.LexicalTokens
+	}
+
 	s.tokenCacheMu.RLock()
 	entry, ok := s.tokenCache[n.ID]
 	s.tokenCacheMu.RUnlock()
-	if ok && entry.hash == n.ContentHash {
+	if ok && entry.hash == n.ContentHash && entry.attachments == len(n.Attachments) {
 		return entry.tokens
 	}
 
-	tokens := tokenize(n.Content)
+	tokens := s.fold.tokens(n.LexicalText(), NeuronLang(n))
 
 	s.tokenCacheMu.Lock()
 	if len(s.tokenCache) > len(s.matrix.Neurons)*2 {
 		s.tokenCache = make(map[core.NeuronID]tokenCacheEntry, len(s.matrix.Neurons))
 	}
-	s.tokenCache[n.ID] = tokenCacheEntry{hash: n.ContentHash, tokens: tokens}
+	s.tokenCache[n.ID] = tokenCacheEntry{hash: n.ContentHash, attachments: len(n.Attachments), tokens: tokens}
 	s.tokenCacheMu.Unlock()
 
 	return tokens
@@ -43,20 +129,46 @@ func (s *Searcher) contentTokens(n *core.Neuron) []string {
 // Searcher provides advanced search capabilities
 type Searcher struct {
 	matrix            *core.Matrix
-	vectorizer        *vector.Vectorizer  // nil when vector layer is disabled
-	alpha             float64             // vector score weight (0.0-1.0)
-	queryRepeat       int                 // query repetition count for embedding (1=off, 2+=repeat)
-	sentimentAnalyzer *sentiment.Analyzer // nil when sentiment layer is disabled
-	metadata          map[string]string   // optional metadata filter/boost
-	strict            bool                // if true, only neurons matching all metadata keys are returned
+	vectorizer        vector.Embedder    // nil when vector layer is disabled
+	model             string             // embedding model of vectorizer
+	alpha             float64            // vector score weight (0.0-1.0)
+	queryRepeat       int                // query repetition count for embedding (1=off, 2+=repeat)
+	sentimentAnalyzer sentiment.Analyzer // nil when sentiment layer is disabled
+	sentimentMinASCII float64            // see sentiment.GuessLanguage
+	metadata          map[string]string  // optional metadata filter/boost
+	strict            bool               // if true, only neurons matching all metadata keys are returned
+	roles             []string           // if set, only neurons whose role metadata is listed are returned
+	traceCtx          context.Context    // parent for the query embedding span
+	bm25K1            float64            // BM25 term-frequency saturation
+	bm25B             float64            // BM25 length normalization (0-1)
+	terms             *core.TermStats    // term statistics of the current search
+	peek              bool               // if true, results are returned without firing
+	resolveSuperseded bool               // if true, superseded results are replaced by their chain head
+	exact             bool               // if true, only direct hits are returned; see SetExact
+	minScore          float64            // exact mode: hits scoring below are skipped
+	scanOrder         func() []core.NeuronID
+	fold              LexicalFold   // how text is normalized for lexical scoring
+	queryLang         string        // language of the query; "" takes the fold's default
+	queryOriginals    []string      // query tokens before folding, parallel to the folded ones
+	anchorID          core.NeuronID // anchored search; see SetAnchor
+	anchorWeight      float64
+	includeAnchor     bool
+	focus             *Focus   // index focus; see SetFocus
+	spreadTypes       []string // synapse types spread activation follows; all when empty
+	expansion         []string // terms added to the query; see SetExpansion
+	expansionWeight   float64
+	expansionTokens   []string // expansion folded for the current search
 
 	tokenCacheMu sync.RWMutex
 	tokenCache   map[core.NeuronID]tokenCacheEntry
+
+	stats SearchStats // populated by the most recent Search call
 }
 
 type tokenCacheEntry struct {
-	hash   string
-	tokens []string
+	hash        string
+	attachments int // captions are tokenized with the content
+	tokens      []string
 }
 
 var tokenSplitRegex = regexp.MustCompile(`[^\p{L}\p{N}]+`)
@@ -66,13 +178,33 @@ func NewSearcher(matrix *core.Matrix) *Searcher {
 	return &Searcher{
 		matrix:     matrix,
 		alpha:      0.6,
+		bm25K1:     defaultBM25K1,
+		bm25B:      defaultBM25B,
+		fold:       DefaultLexicalFold,
 		tokenCache: make(map[core.NeuronID]tokenCacheEntry),
 	}
 }
 
-// SetVectorizer attaches a vectorizer, alpha weight, and query repeat count to the searcher.
-func (s *Searcher) SetVectorizer(v *vector.Vectorizer, alpha float64, queryRepeat int) {
+// SetBM25 sets the BM25 term-frequency saturation (k1) and length
+// normalization (b).
+func (s *Searcher) SetBM25(k1, b float64) {
+	s.bm25K1 = k1
+	s.bm25B = b
+}
+
+// SetFold sets how the query and content are normalized for lexical
+// scoring, and the language of the query ("" takes the fold's default).
+func (s *Searcher) SetFold(f LexicalFold, queryLang string) {
+	s.fold = f
+	s.queryLang = queryLang
+}
+
+// SetVectorizer attaches a vectorizer, the name of its model, alpha weight,
+// and query repeat count to the searcher. Neurons embedded by another model
+// are scored lexically only.
+func (s *Searcher) SetVectorizer(v vector.Embedder, model string, alpha float64, queryRepeat int) {
 	s.vectorizer = v
+	s.model = model
 	s.alpha = alpha
 	if queryRepeat < 1 {
 		queryRepeat = 1
@@ -80,9 +212,12 @@ func (s *Searcher) SetVectorizer(v *vector.Vectorizer, alpha float64, queryRepea
 	s.queryRepeat = queryRepeat
 }
 
-// SetSentimentAnalyzer attaches a sentiment analyzer to the searcher.
-func (s *Searcher) SetSentimentAnalyzer(a *sentiment.Analyzer) {
+// SetSentimentAnalyzer attaches a sentiment analyzer to the searcher. The
+// language of a query without one is guessed with minASCII; see
+// sentiment.GuessLanguage.
+func (s *Searcher) SetSentimentAnalyzer(a sentiment.Analyzer, minASCII float64) {
 	s.sentimentAnalyzer = a
+	s.sentimentMinASCII = minASCII
 }
 
 // SetMetadata configures optional metadata filtering/boosting.
@@ -93,31 +228,82 @@ func (s *Searcher) SetMetadata(metadata map[string]string, strict bool) {
 	s.strict = strict
 }
 
+// SetRoles restricts results to neurons authored by any of roles (OR).
+// A nil or empty list disables the restriction.
+func (s *Searcher) SetRoles(roles []string) {
+	s.roles = roles
+}
+
+// SetTraceContext sets the trace the query embedding span is recorded under.
+func (s *Searcher) SetTraceContext(ctx context.Context) {
+	s.traceCtx = ctx
+}
+
+// SetPeek makes Search leave the neurons it returns unfired, for callers
+// that apply activation separately.
+func (s *Searcher) SetPeek(peek bool) {
+	s.peek = peek
+}
+
+// SetResolveSuperseded makes Search replace each superseded result with
+// the current neuron of its supersede chain.
+func (s *Searcher) SetResolveSuperseded(resolve bool) {
+	s.resolveSuperseded = resolve
+}
+
+// SetSpreadTypes restricts spread activation to synapses of the given
+// types. No types follow every synapse.
+func (s *Searcher) SetSpreadTypes(types []string) {
+	s.spreadTypes = types
+}
+
+// SetExact switches Search to exact mode: neurons are scored in the order
+// returned by scanOrder (called under the matrix read lock; nil sorts by
+// ID) and the scan stops once limit hits scoring at least minScore are
+// found. There is no spread activation and no metadata or sentiment boost.
+func (s *Searcher) SetExact(minScore float64, scanOrder func() []core.NeuronID) {
+	s.exact = true
+	s.minScore = minScore
+	s.scanOrder = scanOrder
+}
+
+// Stats returns the summary of the most recent Search call.
+func (s *Searcher) Stats() SearchStats {
+	return s.stats
+}
+
 // Search performs an intelligent search with multiple scoring factors
 func (s *Searcher) Search(query string, depth int, limit int) []*core.Neuron {
+	s.stats = SearchStats{}
+
 	// Clean query through the same pipeline used at write time so that
 	// embedding space alignment is consistent between stored and query vectors.
 	query = vector.CleanText(query)
-	if query == "" {
+	queryTokens, queryOriginals := s.fold.terms(query, s.queryLang)
+	s.queryOriginals = queryOriginals
+	s.expansionTokens = nil
+	if !s.exact {
+		s.expansionTokens = s.expandedTokens(queryTokens)
+	}
+	hasQuery := len(queryTokens) > 0
+	anchored := s.anchorID != "" && !s.exact
+	if !hasQuery && !anchored {
 		return []*core.Neuron{}
 	}
-
-	// Tokenize query
-	queryTokens := tokenize(query)
-	if len(queryTokens) == 0 {
-		return []*core.Neuron{}
-	}
-	queryLower := strings.ToLower(query)
+	queryFolded := s.fold.text(query, s.queryLang)
 
 	// Embed query outside matrix lock to avoid extending read-lock hold time.
 	// Short queries (≤3 tokens) are verbosely expanded before embedding so the
 	// model has enough context for meaningful bidirectional attention.
 	// The expanded form is then repeated queryRepeat times (Springer et al. 2024).
 	var queryVec []float32
-	if s.vectorizer != nil {
+	if s.vectorizer != nil && hasQuery {
 		embedInput := query
-		if len(queryTokens) <= 3 {
-			embedInput = "search for information about " + query
+		if len(s.expansionTokens) > 0 {
+			embedInput += " " + strings.Join(s.expansionTokens, " ")
+		}
+		if len(queryTokens)+len(s.expansionTokens) <= 3 {
+			embedInput = "search for information about " + embedInput
 		}
 		if s.queryRepeat > 1 {
 			parts := make([]string, s.queryRepeat)
@@ -126,16 +312,31 @@ func (s *Searcher) Search(query string, depth int, limit int) []*core.Neuron {
 			}
 			embedInput = strings.Join(parts, " ")
 		}
-		if emb, err := s.vectorizer.EmbedText(embedInput); err == nil {
+		ctx := s.traceCtx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if emb, err := s.vectorizer.EmbedTextContext(ctx, embedInput); err == nil {
 			vector.Normalize(emb)
 			queryVec = emb
+			s.stats.VectorQuery = true
+		} else if ctx.Err() == nil {
+			s.stats.VectorFallback = true
+			vector.RecordFallback(s.vectorizer, vector.FallbackSearch)
 		}
 	}
 
-	// Analyze query sentiment for downstream scoring.
+	// Analyze query sentiment for downstream scoring, unless the analyzer
+	// does not support the query's language.
 	var queryLabel sentiment.Label
-	if s.sentimentAnalyzer != nil {
-		queryLabel = s.sentimentAnalyzer.Analyze(query).Label
+	if !s.exact && hasQuery {
+		lang := s.queryLang
+		if lang == "" {
+			lang = s.fold.Language
+		}
+		if r, ok := sentiment.Classify(s.sentimentAnalyzer, query, lang, s.sentimentMinASCII); ok {
+			queryLabel = r.Label
+		}
 	}
 
 	s.matrix.RLock()
@@ -145,15 +346,53 @@ func (s *Searcher) Search(query string, depth int, limit int) []*core.Neuron {
 		return []*core.Neuron{}
 	}
 
-	// Score all neurons
-	results := make([]SearchResult, 0, len(s.matrix.Neurons))
-	for _, n := range s.matrix.Neurons {
-		score := s.scoreNeuron(n, query, queryLower, queryTokens, queryVec, queryLabel)
-		if score > 0 {
-			results = append(results, SearchResult{Neuron: n, Score: score})
+	// MatrixEngine keeps the persisted statistics current; a searcher used
+	// on its own derives them for this search.
+	s.terms = s.matrix.Terms
+	if statsFold := s.statsFold(); s.terms == nil || s.terms.Docs != len(s.matrix.Neurons) || s.terms.Fold != statsFold.statsKey() {
+		s.terms = core.NewTermStats()
+		s.terms.Fold = statsFold.statsKey()
+		for _, n := range s.matrix.Neurons {
+			s.terms.Add(statsFold.tokens(n.LexicalText(), NeuronLang(n)))
 		}
 	}
 
+	var results []SearchResult
+	if s.exact {
+		results = s.scanExact(limit, query, queryFolded, queryTokens, queryVec)
+		depth = 0
+	} else {
+		// Score every neuron a strict filter leaves, or all of them
+		results = make([]SearchResult, 0)
+		consider := func(n *core.Neuron) {
+			if score := s.scoreNeuron(n, query, queryFolded, queryTokens, queryVec, queryLabel); score > 0 {
+				results = append(results, SearchResult{Neuron: n, Score: score})
+			}
+		}
+		if candidates, ok := s.strictCandidates(); ok {
+			for _, n := range candidates {
+				if hasQuery {
+					consider(n)
+				}
+			}
+			s.stats.Scanned = len(candidates)
+		} else {
+			for _, n := range s.matrix.Neurons {
+				if hasQuery {
+					consider(n)
+				}
+			}
+			s.stats.Scanned = len(s.matrix.Neurons)
+		}
+		if anchored {
+			results = s.blendAnchor(results, hasQuery)
+		}
+	}
+
+	if s.resolveSuperseded {
+		results = s.resolveHeads(results)
+	}
+
 	// Sort by score descending
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Score > results[j].Score
@@ -162,6 +401,12 @@ func (s *Searcher) Search(query string, depth int, limit int) []*core.Neuron {
 	// Apply spread activation if depth > 0
 	if depth > 0 && len(results) > 0 {
 		results = s.spreadActivation(results, depth)
+		if s.resolveSuperseded {
+			results = s.resolveHeads(results)
+			sort.SliceStable(results, func(i, j int) bool {
+				return results[i].Score > results[j].Score
+			})
+		}
 	}
 
 	// Post-filter: strict metadata — spread activation may have added neurons
@@ -184,11 +429,39 @@ func (s *Searcher) Search(query string, depth int, limit int) []*core.Neuron {
 		results = filtered
 	}
 
+	// Post-filter: roles — same reasoning as strict metadata above.
+	if len(s.roles) > 0 {
+		filtered := results[:0]
+		for _, r := range results {
+			if HasRole(r.Neuron, s.roles) {
+				filtered = append(filtered, r)
+			}
+		}
+		results = filtered
+	}
+
 	// Limit results
 	if limit > 0 && len(results) > limit {
 		results = results[:limit]
 	}
 
+	s.stats.Results = len(results)
+	s.stats.Scores = make([]float64, len(results))
+	s.stats.Breakdowns = make([]ScoreBreakdown, len(results))
+	for i, r := range results {
+		s.stats.Scores[i] = r.Score
+		s.stats.Breakdowns[i] = s.explain(r, query, queryFolded, queryTokens, queryVec)
+		if r.Hop > 0 {
+			s.stats.SpreadResults++
+		} else {
+			s.stats.DirectResults++
+		}
+	}
+	if len(results) > 0 {
+		s.stats.TopScore = results[0].Score
+		s.stats.TopComponent = s.dominantComponent(results[0], query, queryFolded, queryTokens, queryVec)
+	}
+
 	s.matrix.RUnlock() // release before Fire() acquires neuron write-locks
 
 	// Fire neurons outside matrix lock — Fire() takes neuron.mu.Lock()
@@ -196,21 +469,126 @@ func (s *Searcher) Search(query string, depth int, limit int) []*core.Neuron {
 	// writers would cause a deadlock via Go's RWMutex writer-starvation guard).
 	neurons := make([]*core.Neuron, len(results))
 	for i, r := range results {
-		r.Neuron.Fire()
+		if !s.peek {
+			r.Neuron.Fire()
+		}
 		neurons[i] = r.Neuron
 	}
 
 	return neurons
 }
 
+// scanExact scores neurons in scan order until limit hits at or above
+// minScore are found. The caller holds the matrix read lock.
+func (s *Searcher) scanExact(limit int, query, queryFolded string, queryTokens []string, queryVec []float32) []SearchResult {
+	var order []core.NeuronID
+	if candidates, ok := s.strictCandidates(); ok {
+		order = make([]core.NeuronID, 0, len(candidates))
+		for _, n := range candidates {
+			order = append(order, n.ID)
+		}
+		sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+	} else if s.scanOrder != nil {
+		order = s.scanOrder()
+	} else {
+		order = make([]core.NeuronID, 0, len(s.matrix.Neurons))
+		for id := range s.matrix.Neurons {
+			order = append(order, id)
+		}
+		sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+	}
+
+	var results []SearchResult
+	for _, id := range order {
+		n, ok := s.matrix.Neurons[id]
+		if !ok {
+			continue
+		}
+		s.stats.Scanned++
+		// Filter roles here rather than after the scan, so a stop at limit
+		// is not followed by a filter that drops hits.
+		if len(s.roles) > 0 && !HasRole(n, s.roles) {
+			continue
+		}
+		score := s.scoreNeuron(n, query, queryFolded, queryTokens, queryVec, sentiment.LabelNeutral)
+		if score <= 0 || score < s.minScore {
+			continue
+		}
+		results = append(results, SearchResult{Neuron: n, Score: score})
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+	}
+	return results
+}
+
+// strictCandidates returns the neurons that can pass a strict metadata
+// filter according to the matrix metadata index, and whether the index
+// narrowed the search at all: it must be current and cover a filtered key.
+// Candidates are still checked against every filtered key when scored. The
+// caller holds the matrix read lock.
+func (s *Searcher) strictCandidates() ([]*core.Neuron, bool) {
+	x := s.matrix.MetadataIndex
+	if !s.strict || len(s.metadata) == 0 || x == nil || x.Docs != len(s.matrix.Neurons) {
+		return nil, false
+	}
+	var sets []map[core.NeuronID]struct{}
+	for k, v := range s.metadata {
+		if ids, ok := x.Lookup(k, v); ok {
+			sets = append(sets, ids)
+		}
+	}
+	if len(sets) == 0 {
+		return nil, false
+	}
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+
+	candidates := make([]*core.Neuron, 0, len(sets[0]))
+next:
+	for id := range sets[0] {
+		for _, ids := range sets[1:] {
+			if _, ok := ids[id]; !ok {
+				continue next
+			}
+		}
+		if n, ok := s.matrix.Neurons[id]; ok {
+			candidates = append(candidates, n)
+		}
+	}
+	return candidates, true
+}
+
+// resolveHeads replaces superseded results with the head of their chain.
+// A head reached through several chain members keeps the best score and
+// the lowest hop.
+func (s *Searcher) resolveHeads(results []SearchResult) []SearchResult {
+	index := make(map[core.NeuronID]int, len(results))
+	resolved := results[:0]
+	for _, r := range results {
+		r.Neuron = chainHead(s.matrix, r.Neuron)
+		if i, ok := index[r.Neuron.ID]; ok {
+			if r.Score > resolved[i].Score {
+				resolved[i].Score = r.Score
+			}
+			if r.Hop < resolved[i].Hop {
+				resolved[i].Hop = r.Hop
+			}
+			continue
+		}
+		index[r.Neuron.ID] = len(resolved)
+		resolved = append(resolved, r)
+	}
+	return resolved
+}
+
 // scoreNeuron calculates relevance score for a neuron using hybrid string+vector scoring.
-func (s *Searcher) scoreNeuron(n *core.Neuron, query, queryLower string, queryTokens []string, queryVec []float32, queryLabel sentiment.Label) float64 {
+func (s *Searcher) scoreNeuron(n *core.Neuron, query, queryFolded string, queryTokens []string, queryVec []float32, queryLabel sentiment.Label) float64 {
 	// --- String-based score (original mechanics) ---
-	stringScore := s.stringScore(n, query, queryLower, queryTokens)
+	stringScore := s.stringScore(n, query, queryFolded, queryTokens)
 
 	// --- Vector-based score (semantic similarity) ---
 	vectorScore := 0.0
-	if queryVec != nil && len(n.Embedding) > 0 && len(queryVec) == len(n.Embedding) {
+	if s.comparable(n, queryVec) {
 		vectorScore = vector.CosineSimilarity(queryVec, n.Embedding)
 		if vectorScore < 0 {
 			vectorScore = 0
@@ -260,6 +638,10 @@ func (s *Searcher) scoreNeuron(n *core.Neuron, query, queryLower string, queryTo
 		baseScore *= sentiment.SentimentBoost(queryLabel, sentiment.Label(n.SentimentLabel))
 	}
 
+	if !HasRole(n, s.roles) {
+		return 0
+	}
+
 	// --- Metadata boost / strict filter ---
 	// Requires neuron.Metadata to be map[string]any; values stored as string.
 	if len(s.metadata) > 0 {
@@ -274,59 +656,102 @@ func (s *Searcher) scoreNeuron(n *core.Neuron, query, queryLower string, queryTo
 		if s.strict && matchCount < len(s.metadata) {
 			return 0 // exclude neuron entirely
 		}
-		if matchCount > 0 {
+		if matchCount > 0 && !s.exact {
 			baseScore *= 1.0 + float64(matchCount)*0.3 // +30% per matching key
 		}
 	}
 
+	// --- Index focus ---
+	if !s.exact {
+		baseScore *= s.focus.factor(n)
+	}
+
 	return baseScore
 }
 
-// stringScore calculates pure lexical relevance (original scoring logic).
-func (s *Searcher) stringScore(n *core.Neuron, query, queryLower string, queryTokens []string) float64 {
-	content := strings.ToLower(n.Content)
+// comparable reports whether n's embedding can be scored against queryVec:
+// both exist, have the same size and come from the same model.
+func (s *Searcher) comparable(n *core.Neuron, queryVec []float32) bool {
+	return queryVec != nil && len(n.Embedding) > 0 && len(queryVec) == len(n.Embedding) &&
+		n.EmbeddingModelName() == s.modelName()
+}
+
+// modelName returns the model query embeddings come from.
+func (s *Searcher) modelName() string {
+	if s.model == "" {
+		return core.DefaultEmbeddingModel
+	}
+	return s.model
+}
+
+// dominantComponent reports whether the lexical or the vector half of the
+// hybrid score contributed more to r. Spread results have no direct score.
+func (s *Searcher) dominantComponent(r SearchResult, query, queryFolded string, queryTokens []string, queryVec []float32) string {
+	if r.Hop > 0 {
+		return ComponentSpread
+	}
+	if s.anchorID != "" && (len(queryTokens) == 0 || s.anchorWeight*r.Anchor >= r.Score/2) {
+		return ComponentAnchor
+	}
+	n := r.Neuron
+	if !s.comparable(n, queryVec) {
+		return ComponentLexical
+	}
+	vectorScore := vector.CosineSimilarity(queryVec, n.Embedding)
+	if vectorScore < 0 {
+		vectorScore = 0
+	}
+	lexical := (1.0 - s.alpha) * math.Tanh(s.stringScore(n, query, queryFolded, queryTokens)/10.0)
+	if s.alpha*vectorScore > lexical {
+		return ComponentVector
+	}
+	return ComponentLexical
+}
+
+// stringScore calculates pure lexical relevance: an exact phrase bonus,
+// BM25 term weighting and a bounded fuzzy match on short queries.
+func (s *Searcher) stringScore(n *core.Neuron, query, queryFolded string, queryTokens []string) float64 {
+	return s.lexicalScore(n, query, queryFolded, queryTokens, nil)
+}
+
+// lexicalScore is stringScore, filling b with the phrase and BM25 parts
+// when it is non-nil.
+func (s *Searcher) lexicalScore(n *core.Neuron, query, queryFolded string, queryTokens []string, b *ScoreBreakdown) float64 {
+	content := s.fold.text(n.LexicalText(), NeuronLang(n))
 	contentTokens := s.contentTokens(n)
 
 	var score float64
 
 	// 1. Exact phrase match (highest weight)
-	if strings.Contains(content, queryLower) {
+	if strings.Contains(content, queryFolded) {
 		score += 10.0
-	}
-
-	// 2. Word overlap scoring (Jaccard-like)
-	matchedWords := 0
-	for _, qt := range queryTokens {
-		for _, ct := range contentTokens {
-			if ct == qt {
-				matchedWords++
-				break
-			}
-			// Fuzzy match - prefix/suffix
-			if len(ct) > 3 && len(qt) > 3 {
-				if strings.HasPrefix(ct, qt[:3]) || strings.HasPrefix(qt, ct[:3]) {
-					matchedWords++
-					score += 0.3 // Partial credit for fuzzy match
-					break
-				}
-			}
+		if b != nil {
+			b.Phrase = 10.0
 		}
 	}
 
+	// 2. BM25 term weighting. Each term's weight is divided by its maximum,
+	// IDF*(k1+1), so the sum stays on the 0-5 scale of the word overlap
+	// score it replaced: a term that occurs in a short neuron scores more
+	// than the same term in a long one, and rare terms more than common ones.
 	if len(queryTokens) > 0 {
-		wordOverlapScore := float64(matchedWords) / float64(len(queryTokens))
-		score += wordOverlapScore * 5.0
+		bm25, fuzzy := s.bm25(contentTokens, queryTokens, b)
+		score += bm25*5.0 + float64(fuzzy)*0.3 // partial credit for fuzzy matches
+		if b != nil {
+			b.BM25 = bm25 * 5.0
+		}
 	}
 
-	// 3. Levenshtein distance for fuzzy matching (bounded to reduce hot-path cost)
-	if len(query) <= 20 && score < 8.0 {
+	// 3. Levenshtein distance for fuzzy matching (bounded to reduce hot-path
+	// cost). It ignores case, so case-sensitive searches skip it.
+	if len(query) <= 20 && score < 8.0 && !s.fold.CaseSensitive {
 		maxComparisons := 8
 		for i, ct := range contentTokens {
 			if i >= maxComparisons {
 				break
 			}
-			dist := levenshteinDistance(queryLower, ct)
-			maxLen := max(len(queryLower), len(ct))
+			dist := levenshteinDistance(queryFolded, ct)
+			maxLen := max(len(queryFolded), len(ct))
 			if maxLen > 0 {
 				similarity := 1.0 - float64(dist)/float64(maxLen)
 				if similarity > 0.7 {
@@ -339,6 +764,97 @@ func (s *Searcher) stringScore(n *core.Neuron, query, queryLower string, queryTo
 	return score
 }
 
+// bm25 returns the BM25 score of contentTokens for queryTokens, normalized to
+// 0-1, and the number of query tokens matched only by prefix. A prefix match
+// counts as one occurrence.
+func (s *Searcher) bm25(contentTokens, queryTokens []string, b *ScoreBreakdown) (score float64, fuzzy int) {
+	avgLen := s.terms.AvgLen()
+	lengthNorm := 1.0
+	if avgLen > 0 {
+		lengthNorm = 1 - s.bm25B + s.bm25B*float64(len(contentTokens))/avgLen
+	}
+	if b != nil {
+		b.DocLength = len(contentTokens)
+		b.AvgDocLength = avgLen
+		b.LengthNorm = lengthNorm
+	}
+
+	var weight, expanded, maxWeight float64
+	statsFold := s.statsFold()
+	for i, qt := range queryTokens {
+		tf := 0.0
+		for _, ct := range contentTokens {
+			if ct == qt {
+				tf++
+			}
+		}
+		if tf == 0 {
+			for _, ct := range contentTokens {
+				if len(ct) > 3 && len(qt) > 3 && (strings.HasPrefix(ct, qt[:3]) || strings.HasPrefix(qt, ct[:3])) {
+					tf = 1
+					fuzzy++
+					break
+				}
+			}
+		}
+
+		idf := s.terms.IDF(statsFold.text(qt, s.queryLang))
+		maxWeight += idf * (s.bm25K1 + 1)
+		var w float64
+		if tf > 0 {
+			w = idf * tf * (s.bm25K1 + 1) / (tf + s.bm25K1*lengthNorm)
+			weight += w
+		}
+		if b != nil {
+			ts := TermScore{Term: qt, TF: tf, IDF: idf, Weight: w}
+			if i < len(s.queryOriginals) && s.queryOriginals[i] != qt {
+				ts.Original = s.queryOriginals[i]
+			}
+			b.Terms = append(b.Terms, ts)
+		}
+	}
+	// Expansion terms add to the score without raising its maximum, so a
+	// neuron matching the query scores at least as it would unexpanded.
+	for _, et := range s.expansionTokens {
+		tf := 0.0
+		for _, ct := range contentTokens {
+			if ct == et {
+				tf++
+			}
+		}
+		if tf == 0 {
+			continue
+		}
+		idf := s.terms.IDF(statsFold.text(et, s.queryLang))
+		w := s.expansionWeight * idf * tf * (s.bm25K1 + 1) / (tf + s.bm25K1*lengthNorm)
+		expanded += w
+		if b != nil {
+			b.Terms = append(b.Terms, TermScore{Term: et, TF: tf, IDF: idf, Weight: w, Expansion: true})
+		}
+	}
+	if maxWeight == 0 {
+		return 0, fuzzy
+	}
+	return (weight + expanded) / maxWeight, fuzzy
+}
+
+// explain breaks down the score of result r. Caller holds the matrix read
+// lock.
+func (s *Searcher) explain(r SearchResult, query, queryFolded string, queryTokens []string, queryVec []float32) ScoreBreakdown {
+	b := ScoreBreakdown{Score: r.Score, Hop: r.Hop, Anchor: r.Anchor, Via: string(r.Via), SynapseType: r.SynapseType}
+	if r.Hop > 0 || len(queryTokens) == 0 {
+		return b
+	}
+	if f := s.focus.factor(r.Neuron); f > 1 && !s.exact {
+		b.Focus = f
+	}
+	s.lexicalScore(r.Neuron, query, queryFolded, queryTokens, &b)
+	if s.comparable(r.Neuron, queryVec) {
+		b.Vector = max(0, vector.CosineSimilarity(queryVec, r.Neuron.Embedding))
+	}
+	return b
+}
+
 // spreadActivation finds related neurons through synapse connections
 func (s *Searcher) spreadActivation(initial []SearchResult, depth int) []SearchResult {
 	seen := make(map[core.NeuronID]bool)
@@ -349,6 +865,9 @@ func (s *Searcher) spreadActivation(initial []SearchResult, depth int) []SearchR
 		seen[r.Neuron.ID] = true
 		results = append(results, r)
 	}
+	if s.anchorID != "" && !s.includeAnchor {
+		seen[s.anchorID] = true // left out by blendAnchor; keep it out
+	}
 
 	// Spread through connections
 	current := initial
@@ -368,18 +887,15 @@ func (s *Searcher) spreadActivation(initial []SearchResult, depth int) []SearchR
 					continue
 				}
 
-				// Get synapse weight
-				synapseID := core.NewSynapseID(r.Neuron.ID, connID)
-				synapse, ok := s.matrix.Synapses[synapseID]
-				if !ok {
-					// Try reverse
-					synapseID = core.NewSynapseID(connID, r.Neuron.ID)
-					synapse, ok = s.matrix.Synapses[synapseID]
-				}
-
-				weight := 0.3 // Default weight
-				if ok {
+				synapse := s.synapseBetween(r.Neuron.ID, connID)
+				weight := 0.3
+				switch {
+				case synapse != nil && !core.SynapseTypeIn(synapse.Type, s.spreadTypes):
+					continue
+				case synapse != nil:
 					weight = synapse.Weight
+				case len(s.spreadTypes) > 0:
+					continue // no synapse, so no type to match
 				}
 
 				// Spread score decays with distance and is multiplied by synapse weight
@@ -387,10 +903,16 @@ func (s *Searcher) spreadActivation(initial []SearchResult, depth int) []SearchR
 
 				if spreadScore > 0.1 { // Threshold to avoid noise
 					seen[connID] = true
-					next = append(next, SearchResult{
+					result := SearchResult{
 						Neuron: connNeuron,
 						Score:  spreadScore,
-					})
+						Hop:    d + 1,
+						Via:    r.Neuron.ID,
+					}
+					if synapse != nil {
+						result.SynapseType = synapse.Type
+					}
+					next = append(next, result)
 				}
 			}
 		}
@@ -411,6 +933,14 @@ func (s *Searcher) spreadActivation(initial []SearchResult, depth int) []SearchR
 	return results
 }
 
+// statsFold is the fold term statistics are counted with: the search's,
+// case-folded.
+func (s *Searcher) statsFold() LexicalFold {
+	f := s.fold
+	f.CaseSensitive = false
+	return f
+}
+
 // tokenize splits text into lowercase tokens
 func tokenize(text string) []string {
 	// Remove punctuation and split
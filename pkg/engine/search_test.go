@@ -1,11 +1,24 @@
 package engine
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
 )
 
+// unitEmbedder embeds every text as the same unit vector, so any two
+// embeddings it produces are identical.
+type unitEmbedder struct{}
+
+func (unitEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	return []float32{1, 0, 0, 0}, nil
+}
+func (unitEmbedder) EmbedDim() int { return 4 }
+func (unitEmbedder) Close() error  { return nil }
+
 func TestSearcherBasicSearch(t *testing.T) {
 	m := core.NewMatrix("test-user", core.DefaultBounds())
 	e := NewMatrixEngine(m)
@@ -97,6 +110,72 @@ func TestSearcherSpreadActivation(t *testing.T) {
 	}
 }
 
+func TestSearcherStatsCountsSpreadHops(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+
+	n1, _ := e.AddNeuron("TypeScript", nil, nil)
+	n2, _ := e.AddNeuron("React framework", nil, nil)
+
+	syn := core.NewSynapse(n1.ID, n2.ID, 0.8)
+	m.Synapses[syn.ID] = syn
+	m.Adjacency[n1.ID] = append(m.Adjacency[n1.ID], n2.ID)
+	m.Adjacency[n2.ID] = append(m.Adjacency[n2.ID], n1.ID)
+
+	searcher := NewSearcher(m)
+	results := searcher.Search("TypeScript", 1, 10)
+	stats := searcher.Stats()
+
+	if stats.Results != len(results) {
+		t.Errorf("Expected stats.Results=%d, got %d", len(results), stats.Results)
+	}
+	if stats.DirectResults != 1 || stats.SpreadResults != 1 {
+		t.Errorf("Expected 1 direct and 1 spread result, got %d/%d", stats.DirectResults, stats.SpreadResults)
+	}
+	if stats.TopScore <= 0 {
+		t.Errorf("Expected positive top score, got %f", stats.TopScore)
+	}
+	if stats.TopComponent != ComponentLexical {
+		t.Errorf("Expected lexical top component without embeddings, got %q", stats.TopComponent)
+	}
+
+	searcher.Search("Python", 0, 10)
+	if stats := searcher.Stats(); stats.Results != 0 || stats.TopComponent != "" {
+		t.Errorf("Expected empty stats after zero-result search, got %+v", stats)
+	}
+}
+
+func TestSearcherComparesEmbeddingsOfOneModelOnly(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	e.SetVectorizer(unitEmbedder{}, "code")
+
+	n, _ := e.AddNeuron("parseConfig reads yaml", nil, nil)
+	if n.EmbeddingModel != "code" || len(n.Embedding) != 4 {
+		t.Fatalf("expected a code embedding, got model %q with %d dims", n.EmbeddingModel, len(n.Embedding))
+	}
+
+	searcher := NewSearcher(m)
+	searcher.SetVectorizer(unitEmbedder{}, "code", 0.9, 1)
+	searcher.Search("parseConfig", 0, 10)
+	if got := searcher.Stats().TopComponent; got != ComponentVector {
+		t.Errorf("same model: expected vector component, got %q", got)
+	}
+
+	searcher.SetVectorizer(unitEmbedder{}, "chat", 0.9, 1)
+	searcher.Search("parseConfig", 0, 10)
+	if got := searcher.Stats().TopComponent; got != ComponentLexical {
+		t.Errorf("different model: expected lexical component, got %q", got)
+	}
+
+	n.EmbeddingModel = ""
+	searcher.SetVectorizer(unitEmbedder{}, "", 0.9, 1)
+	searcher.Search("parseConfig", 0, 10)
+	if got := searcher.Stats().TopComponent; got != ComponentVector {
+		t.Errorf("untagged embeddings belong to the default model, got %q", got)
+	}
+}
+
 func TestSearcherNoMatch(t *testing.T) {
 	m := core.NewMatrix("test-user", core.DefaultBounds())
 	e := NewMatrixEngine(m)
@@ -258,12 +337,16 @@ func TestSearcherTokenCacheParityAndInvalidation(t *testing.T) {
 		}
 	}
 
-	entry, ok := searcher.tokenCache[n.ID]
-	if !ok {
-		t.Fatal("expected token cache entry after search")
+	// The engine stores the folded tokens on the neuron; searches use them
+	// while they match its content.
+	if got := strings.Join(n.LexicalTokens, " "); got != "go language basics" {
+		t.Fatalf("expected stored tokens for the content, got %q", got)
+	}
+	if !strings.Contains(n.LexicalKey, n.ContentHash) {
+		t.Fatalf("expected the stored tokens keyed by content hash %s, got %q", n.ContentHash, n.LexicalKey)
 	}
-	if entry.hash != n.ContentHash {
-		t.Fatalf("expected cached hash %s, got %s", n.ContentHash, entry.hash)
+	if _, ok := searcher.tokenCache[n.ID]; ok {
+		t.Fatal("stored tokens should be used rather than recomputed")
 	}
 
 	if err := e.UpdateNeuron(n.ID, "Rust systems programming"); err != nil {
@@ -274,13 +357,19 @@ func TestSearcherTokenCacheParityAndInvalidation(t *testing.T) {
 	if len(after) == 0 || after[0].ID != n.ID {
 		t.Fatal("expected updated neuron to match rust query")
 	}
+	if got := strings.Join(n.LexicalTokens, " "); got != "rust systems programming" || !strings.Contains(n.LexicalKey, n.ContentHash) {
+		t.Fatalf("expected the update to refresh the stored tokens, got %q under %q", got, n.LexicalKey)
+	}
 
-	entry, ok = searcher.tokenCache[n.ID]
-	if !ok {
-		t.Fatal("expected token cache entry after update search")
+	// Tokens stored under another content hash are stale: the searcher
+	// computes its own.
+	n.LexicalKey = "stale"
+	if res := searcher.Search("rust", 0, 10); len(res) == 0 || res[0].ID != n.ID {
+		t.Fatal("expected a match from recomputed tokens")
 	}
-	if entry.hash != n.ContentHash {
-		t.Fatalf("expected cache invalidation to refresh hash to %s, got %s", n.ContentHash, entry.hash)
+	entry, ok := searcher.tokenCache[n.ID]
+	if !ok || entry.hash != n.ContentHash {
+		t.Fatalf("expected a cache entry for hash %s, got %+v", n.ContentHash, entry)
 	}
 }
 
@@ -354,3 +443,235 @@ func TestMetadataNoFilterReturnsAll(t *testing.T) {
 		t.Errorf("no metadata filter: expected 3 results, got %d", len(results))
 	}
 }
+
+func TestRolesFilterExcludesOtherRoles(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+
+	u, _ := e.AddNeuron("I deploy services to Kubernetes on AWS", nil, map[string]string{"role": "user"})
+	a, _ := e.AddNeuron("I can help with Kubernetes on AWS deployments", nil, map[string]string{"role": "assistant"})
+	s, _ := e.AddNeuron("Kubernetes AWS answers must be concise", nil, map[string]string{"role": "System"})
+	_, _ = e.AddNeuron("Kubernetes AWS notes without an author", nil, nil)
+
+	results, _ := e.SearchWithStats("Kubernetes AWS", 1, 10, nil, false, []string{"user", "system"})
+	got := map[core.NeuronID]bool{}
+	for _, n := range results {
+		got[n.ID] = true
+	}
+	if len(results) != 2 || !got[u.ID] || !got[s.ID] {
+		t.Errorf("expected only user and system neurons, got %d results", len(results))
+	}
+	if got[a.ID] {
+		t.Error("assistant neuron should be filtered out")
+	}
+
+	if all, _ := e.SearchWithStats("Kubernetes AWS", 1, 10, nil, false, nil); len(all) != 4 {
+		t.Errorf("no roles filter: expected 4 results, got %d", len(all))
+	}
+
+	listed := e.ListNeuronsByRole(0, 10, nil, []string{"assistant"})
+	if len(listed) != 1 || listed[0].ID != a.ID {
+		t.Errorf("expected ListNeuronsByRole to return only the assistant neuron, got %d", len(listed))
+	}
+}
+
+func TestSearcherBM25ShortFactOutranksLongDocument(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+
+	fact, _ := e.AddNeuron("The staging database password rotates every Monday", nil, nil)
+	long, _ := e.AddNeuron("Meeting notes from the quarterly infrastructure review. "+
+		"We covered the migration timeline, the on-call rotation, hiring for the platform team, "+
+		"the cost of the new observability stack, a proposal to retire the legacy queue, "+
+		"and how the database password policy interacts with the vault rollout. "+
+		"Several people asked whether the database backups are tested, whether the password "+
+		"manager licence renews in spring, and who owns the load balancer certificates. "+
+		"Action items were assigned and the next review is scheduled for the end of the quarter.", nil, nil)
+	e.AddNeuron("Deploys are frozen on Fridays", nil, nil)
+	e.AddNeuron("The cafeteria closes at three", nil, nil)
+
+	results, stats := e.SearchWithStats("database password", 0, 10, nil, false, nil)
+	if len(results) < 2 {
+		t.Fatalf("expected both neurons to match, got %d", len(results))
+	}
+	if results[0].ID != fact.ID || results[1].ID != long.ID {
+		t.Errorf("short fact should outrank the long document, got %q first", results[0].Content)
+	}
+
+	b := stats.Breakdowns[0]
+	if b.LengthNorm >= 1 || stats.Breakdowns[1].LengthNorm <= 1 {
+		t.Errorf("length norm should favor the short fact: %f vs %f", b.LengthNorm, stats.Breakdowns[1].LengthNorm)
+	}
+	if len(b.Terms) != 2 || b.Terms[0].Term != "database" || b.Terms[0].IDF <= 0 || b.Terms[0].TF != 1 {
+		t.Errorf("breakdown should list per-term IDF and TF, got %+v", b.Terms)
+	}
+	if b.BM25 <= stats.Breakdowns[1].BM25 {
+		t.Errorf("short fact should have the higher BM25 part: %f vs %f", b.BM25, stats.Breakdowns[1].BM25)
+	}
+}
+
+func TestSearcherBM25RareTermsWeighMore(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+
+	e.AddNeuron("project kickoff agenda", nil, nil)
+	e.AddNeuron("project budget review", nil, nil)
+	e.AddNeuron("project staffing plan", nil, nil)
+	rare, _ := e.AddNeuron("kubernetes upgrade plan", nil, nil)
+	common, _ := e.AddNeuron("project upgrade notes", nil, nil)
+
+	// Both match one of the two terms; "kubernetes" is rare, "project" is not.
+	results, _ := e.SearchWithStats("kubernetes project", 0, 10, nil, false, nil)
+	rank := map[core.NeuronID]int{}
+	for i, n := range results {
+		rank[n.ID] = i
+	}
+	if rank[rare.ID] > rank[common.ID] {
+		t.Errorf("the rare term match should rank above the common term match")
+	}
+}
+
+func TestMatrixEngineMaintainsTermStats(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+
+	a, _ := e.AddNeuron("Go programming language", nil, nil)
+	b, _ := e.AddNeuron("TypeScript programming", nil, nil)
+	if m.Terms.Docs != 2 || m.Terms.DocFreq["programming"] != 2 || m.Terms.TotalLen != 5 {
+		t.Fatalf("unexpected stats after writes: %+v", m.Terms)
+	}
+
+	if err := e.UpdateNeuron(b.ID, "TypeScript tooling"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Terms.DocFreq["programming"] != 1 || m.Terms.DocFreq["tooling"] != 1 {
+		t.Errorf("update should move the neuron's terms: %+v", m.Terms.DocFreq)
+	}
+
+	if err := e.DeleteNeuron(a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if m.Terms.Docs != 1 || m.Terms.TotalLen != 2 {
+		t.Errorf("unexpected stats after forget: %+v", m.Terms)
+	}
+	if _, ok := m.Terms.DocFreq["programming"]; ok {
+		t.Error("terms of forgotten neurons should be dropped")
+	}
+}
+
+func TestMatrixEngineBuildsTermStatsLazily(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	e.AddNeuron("Go programming language", nil, nil)
+	e.AddNeuron("Docker containers", nil, nil)
+
+	// A matrix persisted before term statistics existed loads without them.
+	m.Terms = nil
+	e.AddNeuron("Rust programming", nil, nil)
+	if m.Terms != nil {
+		t.Fatal("writes should not build statistics on their own")
+	}
+	results, _ := e.SearchWithStats("programming", 0, 10, nil, false, nil)
+	if len(results) != 2 {
+		t.Errorf("expected 2 results, got %d", len(results))
+	}
+	if m.Terms == nil || m.Terms.Docs != 3 || m.Terms.DocFreq["programming"] != 2 {
+		t.Errorf("first search should build statistics for every neuron, got %+v", m.Terms)
+	}
+}
+
+func TestSearchExactMatchesDefaultWithoutSpread(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	for _, content := range []string{
+		"Go channels coordinate goroutines",
+		"Rust ownership prevents data races",
+		"Go modules pin dependency versions",
+		"Python generators yield lazily",
+	} {
+		if _, err := e.AddNeuron(content, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No synapses, so spread activation adds nothing.
+	def, _ := e.PeekSearch(e.traceContext(), "Go", 2, 10, nil, false, nil, SearchOptions{})
+	exact, stats := e.PeekSearch(e.traceContext(), "Go", 2, 10, nil, false, nil, SearchOptions{Mode: SearchModeExact})
+	got, want := contents(exact), contents(def)
+	if len(got) == 0 || len(got) != len(want) {
+		t.Fatalf("expected the same results, got %v and %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("position %d: exact %q, default %q", i, got[i], want[i])
+		}
+	}
+	if stats.Scanned != 4 || stats.SpreadResults != 0 {
+		t.Errorf("expected a full scan without spread, got %+v", stats)
+	}
+}
+
+func TestSearchExactStopsAtLimitWithoutSpread(t *testing.T) {
+	m := newTestMatrix()
+	e := NewMatrixEngine(m)
+	var first *core.Neuron
+	for i := 0; i < 200; i++ {
+		n, err := e.AddNeuron(fmt.Sprintf("deploy checklist item %d", i), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = n
+		}
+	}
+	neighbour, _ := e.AddNeuron("rollback runbook", nil, nil)
+	syn := core.NewSynapse(first.ID, neighbour.ID, 0.9)
+	m.Synapses[syn.ID] = syn
+	m.Adjacency[first.ID] = append(m.Adjacency[first.ID], neighbour.ID)
+	m.Adjacency[neighbour.ID] = append(m.Adjacency[neighbour.ID], first.ID)
+
+	_, def := e.PeekSearch(e.traceContext(), "deploy checklist", 1, 5, nil, false, nil, SearchOptions{})
+	if def.Scanned != 201 {
+		t.Errorf("the default mode scores every neuron, scanned %d", def.Scanned)
+	}
+
+	results, exact := e.PeekSearch(e.traceContext(), "deploy checklist", 1, 5, nil, false, nil, SearchOptions{Mode: SearchModeExact})
+	// Only the neighbour does not match, so at most one extra neuron is scanned.
+	if len(results) != 5 || exact.Scanned > 6 {
+		t.Errorf("expected to stop after 5 hits, got %d results from %d scanned", len(results), exact.Scanned)
+	}
+	for _, n := range results {
+		if n.ID == neighbour.ID {
+			t.Error("exact mode must not spread to neighbours")
+		}
+	}
+
+	again, _ := e.PeekSearch(e.traceContext(), "deploy checklist", 1, 5, nil, false, nil, SearchOptions{Mode: SearchModeExact})
+	for i := range results {
+		if again[i].ID != results[i].ID {
+			t.Fatal("exact mode should return the same hits on every run")
+		}
+	}
+}
+
+func TestSearchExactSkipsBoostsButKeepsFilters(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	e.AddNeuron("quarterly budget review", nil, nil)
+	tagged, _ := e.AddNeuron("quarterly budget review notes", nil, map[string]string{"team": "finance"})
+	meta := map[string]string{"team": "finance"}
+
+	_, def := e.PeekSearch(e.traceContext(), "quarterly budget", 0, 10, meta, false, nil, SearchOptions{})
+	_, exact := e.PeekSearch(e.traceContext(), "quarterly budget", 0, 10, meta, false, nil, SearchOptions{Mode: SearchModeExact})
+	if exact.TopScore >= def.TopScore {
+		t.Errorf("exact mode should not apply the metadata boost, got %f vs %f", exact.TopScore, def.TopScore)
+	}
+
+	strict, _ := e.PeekSearch(e.traceContext(), "quarterly budget", 0, 10, meta, true, nil, SearchOptions{Mode: SearchModeExact})
+	if len(strict) != 1 || strict[0].ID != tagged.ID {
+		t.Errorf("strict filters should still apply, got %v", contents(strict))
+	}
+
+	high, _ := e.PeekSearch(e.traceContext(), "quarterly budget", 0, 10, nil, false, nil, SearchOptions{Mode: SearchModeExact, MinScore: 1e9})
+	if len(high) != 0 {
+		t.Errorf("hits below min score should be skipped, got %v", contents(high))
+	}
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/sentiment"
+)
+
+func TestLabelSentiment_SkipsUnsupportedLanguages(t *testing.T) {
+	e := NewMatrixEngine(core.NewMatrix("sentiment", core.DefaultBounds()))
+	e.SetSentimentAnalyzer(sentiment.New())
+	e.SetSentimentMinASCII(0.9)
+
+	english, _ := e.AddNeuron("I love this wonderful day", nil, nil)
+	if english.SentimentLabel == "" || english.SentimentScore <= 0 {
+		t.Errorf("expected English content labeled, got %q %v", english.SentimentLabel, english.SentimentScore)
+	}
+	tagged, _ := e.AddNeuron("I love this wonderful evening", nil, map[string]string{LangMetadataKey: "tr"})
+	if tagged.SentimentLabel != "" || tagged.SentimentScore != 0 {
+		t.Errorf("content tagged Turkish should be left unlabeled, got %q", tagged.SentimentLabel)
+	}
+	guessed, _ := e.AddNeuron("Bugün çok mutluyum, harika bir gün", nil, nil)
+	if guessed.SentimentLabel != "" {
+		t.Errorf("untagged content guessed not English should be left unlabeled, got %q", guessed.SentimentLabel)
+	}
+
+	// The fold's default language applies to untagged content.
+	e.SetLexicalFold(LexicalFold{Diacritics: true, Language: "tr"})
+	if n, _ := e.AddNeuron("I love this wonderful morning", nil, nil); n.SentimentLabel != "" {
+		t.Errorf("untagged content in a Turkish index should be left unlabeled, got %q", n.SentimentLabel)
+	}
+
+	e.SetSentimentAnalyzer(nil)
+	e.SetLexicalFold(DefaultLexicalFold)
+	if n, _ := e.AddNeuron("I love this wonderful night", nil, nil); n.SentimentLabel != "" {
+		t.Errorf("no analyzer should label nothing, got %q", n.SentimentLabel)
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// NeuronSimilarity returns a function rating how alike two neurons are,
+// from 0 to 1: the cosine of their embeddings when the same model embedded
+// both, else the Jaccard similarity of their tokens, as conflict detection
+// compares them. Token sets are cached for the life of the function, which
+// is not safe for concurrent use.
+func NeuronSimilarity() func(a, b *core.Neuron) float64 {
+	tokens := make(map[core.NeuronID]map[string]bool)
+	tokensOf := func(n *core.Neuron) map[string]bool {
+		set, ok := tokens[n.ID]
+		if !ok {
+			set = tokenSet(n.Content)
+			tokens[n.ID] = set
+		}
+		return set
+	}
+	return func(a, b *core.Neuron) float64 {
+		if embeddedAlike(a, b) {
+			return max(0, vector.CosineSimilarity(a.Embedding, b.Embedding))
+		}
+		return jaccard(tokensOf(a), tokensOf(b))
+	}
+}
+
+// embeddedAlike reports whether the same model embedded a and b, so their
+// embeddings can be compared.
+func embeddedAlike(a, b *core.Neuron) bool {
+	return len(a.Embedding) > 0 && len(a.Embedding) == len(b.Embedding) && a.EmbeddingModelName() == b.EmbeddingModelName()
+}
+
+// SimilarityThresholds are the similarities at which two neurons of an
+// index count as alike: Vector for the cosine of embeddings from the same
+// model, Lexical for the Jaccard similarity of their tokens otherwise. A
+// threshold of 0 is not set.
+type SimilarityThresholds struct {
+	Vector  float64
+	Lexical float64
+}
+
+// Floor returns the threshold two neurons must reach under the measure
+// NeuronSimilarity rates them with, fallback when t does not set it.
+func (t SimilarityThresholds) Floor(fallback float64) func(a, b *core.Neuron) float64 {
+	return func(a, b *core.Neuron) float64 {
+		threshold := t.Lexical
+		if embeddedAlike(a, b) {
+			threshold = t.Vector
+		}
+		if threshold > 0 {
+			return threshold
+		}
+		return fallback
+	}
+}
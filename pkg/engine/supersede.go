@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Metadata keys linking a neuron to the neuron it replaced and the one that
+// replaced it. Together they form supersede chains: the edit history of a
+// fact, whose head (the neuron without a successor) is current.
+const (
+	SupersedesKey   = "_supersedes"
+	SupersededByKey = "_superseded_by"
+	SupersededAtKey = "_superseded_at"
+)
+
+// MaxChainLength bounds how many neurons a supersede chain walk visits.
+const MaxChainLength = 100
+
+// ChainIssue is a supersede link that breaks chain integrity.
+type ChainIssue struct {
+	NeuronID core.NeuronID `json:"neuronId"`
+	Problem  string        `json:"problem"` // dangling_successor | dangling_predecessor | asymmetric | fork | cycle
+}
+
+func successorOf(n *core.Neuron) core.NeuronID {
+	id, _ := n.Metadata[SupersededByKey].(string)
+	return core.NeuronID(id)
+}
+
+func predecessorOf(n *core.Neuron) core.NeuronID {
+	id, _ := n.Metadata[SupersedesKey].(string)
+	return core.NeuronID(id)
+}
+
+// IsSuperseded reports whether a newer neuron has replaced n.
+func IsSuperseded(n *core.Neuron) bool {
+	return successorOf(n) != ""
+}
+
+// CheckSupersede reports why oldID cannot be superseded: it does not exist
+// or already has a successor. Writers call it before creating the new
+// neuron so a refused link leaves nothing behind.
+func (e *MatrixEngine) CheckSupersede(oldID core.NeuronID) error {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	old, ok := e.matrix.Neurons[oldID]
+	if !ok {
+		return core.ErrNeuronNotFound
+	}
+	if IsSuperseded(old) {
+		return core.ErrSupersedeConflict
+	}
+	return nil
+}
+
+// Supersede records that newID replaces oldID. Chains stay linear and
+// acyclic: a neuron may be superseded once and supersede one other neuron,
+// and the link is refused if oldID already follows newID. Linking a pair
+// that is already linked is a no-op.
+func (e *MatrixEngine) Supersede(oldID, newID core.NeuronID) error {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	old, ok := e.matrix.Neurons[oldID]
+	if !ok {
+		return core.ErrNeuronNotFound
+	}
+	n, ok := e.matrix.Neurons[newID]
+	if !ok {
+		return core.ErrNeuronNotFound
+	}
+	if oldID == newID {
+		return core.ErrSupersedeCycle
+	}
+	if successorOf(old) == newID && predecessorOf(n) == oldID {
+		return nil
+	}
+	if successorOf(old) != "" || predecessorOf(n) != "" {
+		return core.ErrSupersedeConflict
+	}
+	for id, steps := successorOf(n), 0; id != "" && steps < len(e.matrix.Neurons); steps++ {
+		if id == oldID {
+			return core.ErrSupersedeCycle
+		}
+		next, ok := e.matrix.Neurons[id]
+		if !ok {
+			break
+		}
+		id = successorOf(next)
+	}
+
+	now := time.Now()
+	old.EditMetadata(func(metadata map[string]any) {
+		metadata[SupersededByKey] = string(newID)
+		metadata[SupersededAtKey] = now.UTC().Format(time.RFC3339Nano)
+	})
+	e.setLink(n, SupersedesKey, string(oldID))
+	e.matrix.RecordChange(old)
+	e.matrix.RecordChange(n)
+	e.matrix.ModifiedAt = now
+	e.matrix.Version++
+	return nil
+}
+
+func (e *MatrixEngine) setLink(n *core.Neuron, key, id string) {
+	n.EditMetadata(func(metadata map[string]any) { metadata[key] = id })
+}
+
+// History returns the supersede chain through id, oldest first, and
+// whether the walk stopped at MaxChainLength before reaching either end.
+func (e *MatrixEngine) History(id core.NeuronID) ([]*core.Neuron, bool, error) {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	n, ok := e.matrix.Neurons[id]
+	if !ok {
+		return nil, false, core.ErrNeuronNotFound
+	}
+	seen := map[core.NeuronID]bool{id: true}
+	var older []*core.Neuron
+	truncated := false
+	for cur := n; predecessorOf(cur) != ""; {
+		prev, ok := e.matrix.Neurons[predecessorOf(cur)]
+		if !ok || seen[prev.ID] {
+			break
+		}
+		if len(older)+1 >= MaxChainLength {
+			truncated = true
+			break
+		}
+		seen[prev.ID] = true
+		older = append(older, prev)
+		cur = prev
+	}
+
+	chain := make([]*core.Neuron, 0, len(older)+1)
+	for i := len(older) - 1; i >= 0; i-- {
+		chain = append(chain, older[i])
+	}
+	chain = append(chain, n)
+	for cur := n; successorOf(cur) != ""; {
+		next, ok := e.matrix.Neurons[successorOf(cur)]
+		if !ok || seen[next.ID] {
+			break
+		}
+		if len(chain) >= MaxChainLength {
+			truncated = true
+			break
+		}
+		seen[next.ID] = true
+		chain = append(chain, next)
+		cur = next
+	}
+	return chain, truncated, nil
+}
+
+// chainHead follows n's successors to the current neuron of its chain.
+// Callers hold the matrix lock.
+func chainHead(m *core.Matrix, n *core.Neuron) *core.Neuron {
+	for steps := 0; steps < MaxChainLength; steps++ {
+		next, ok := m.Neurons[successorOf(n)]
+		if !ok {
+			break
+		}
+		n = next
+	}
+	return n
+}
+
+// ChainIssues lists supersede links that break chain integrity, ordered
+// by neuron ID.
+func (e *MatrixEngine) ChainIssues() []ChainIssue {
+	e.matrix.RLock()
+	defer e.matrix.RUnlock()
+
+	issues := []ChainIssue{}
+	successors := make(map[core.NeuronID]int)
+	for id, n := range e.matrix.Neurons {
+		if prev := predecessorOf(n); prev != "" {
+			successors[prev]++
+			if _, ok := e.matrix.Neurons[prev]; !ok {
+				issues = append(issues, ChainIssue{id, "dangling_predecessor"})
+			}
+		}
+		next := successorOf(n)
+		if next == "" {
+			continue
+		}
+		succ, ok := e.matrix.Neurons[next]
+		switch {
+		case !ok:
+			issues = append(issues, ChainIssue{id, "dangling_successor"})
+			continue
+		case predecessorOf(succ) != id:
+			issues = append(issues, ChainIssue{id, "asymmetric"})
+		}
+		for cur, steps := succ, 0; steps < len(e.matrix.Neurons); steps++ {
+			if cur.ID == id {
+				issues = append(issues, ChainIssue{id, "cycle"})
+				break
+			}
+			if cur, ok = e.matrix.Neurons[successorOf(cur)]; !ok {
+				break
+			}
+		}
+	}
+	for id, count := range successors {
+		if count > 1 {
+			issues = append(issues, ChainIssue{id, "fork"})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].NeuronID != issues[j].NeuronID {
+			return issues[i].NeuronID < issues[j].NeuronID
+		}
+		return issues[i].Problem < issues[j].Problem
+	})
+	return issues
+}
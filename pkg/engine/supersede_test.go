@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// supersedeChain writes contents in order, each superseding the previous.
+func supersedeChain(t *testing.T, e *MatrixEngine, contents ...string) []*core.Neuron {
+	t.Helper()
+	var chain []*core.Neuron
+	for _, content := range contents {
+		n, err := e.AddNeuron(content, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chain) > 0 {
+			if err := e.Supersede(chain[len(chain)-1].ID, n.ID); err != nil {
+				t.Fatalf("supersede %q: %v", content, err)
+			}
+		}
+		chain = append(chain, n)
+	}
+	return chain
+}
+
+func TestSupersede_FiveStepHistory(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	chain := supersedeChain(t, e,
+		"Alice lives in Boston",
+		"Alice lives in Chicago",
+		"Alice lives in Denver",
+		"Alice lives in Austin",
+		"Alice lives in Seattle")
+
+	for _, from := range []int{0, 2, 4} {
+		history, truncated, err := e.History(chain[from].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if truncated || len(history) != len(chain) {
+			t.Fatalf("from step %d: expected the full chain of %d, got %d (truncated=%v)", from, len(chain), len(history), truncated)
+		}
+		for i, n := range history {
+			if n.ID != chain[i].ID {
+				t.Errorf("from step %d: position %d is %q, want %q", from, i, n.Content, chain[i].Content)
+			}
+		}
+	}
+
+	for _, n := range chain[:4] {
+		if !IsSuperseded(n) || n.Metadata[SupersededAtKey] == nil {
+			t.Errorf("%q should be superseded with a timestamp: %v", n.Content, n.Metadata)
+		}
+	}
+	if IsSuperseded(chain[4]) {
+		t.Error("the last write should be current")
+	}
+	if issues := e.ChainIssues(); len(issues) != 0 {
+		t.Errorf("a linear chain has no issues, got %+v", issues)
+	}
+}
+
+func TestSupersede_RejectsCyclesAndForks(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	chain := supersedeChain(t, e, "v1 of the plan", "v2 of the plan", "v3 of the plan")
+	other, _ := e.AddNeuron("an unrelated note", nil, nil)
+
+	if err := e.Supersede(chain[2].ID, chain[0].ID); err != core.ErrSupersedeCycle {
+		t.Errorf("v1 superseding v3 would close a loop, expected ErrSupersedeCycle, got %v", err)
+	}
+	if err := e.Supersede(chain[0].ID, other.ID); err != core.ErrSupersedeConflict {
+		t.Errorf("a second successor would fork the chain, got %v", err)
+	}
+	if err := e.Supersede(other.ID, other.ID); err != core.ErrSupersedeCycle {
+		t.Errorf("a neuron cannot supersede itself, got %v", err)
+	}
+	if err := e.Supersede(chain[0].ID, chain[1].ID); err != nil {
+		t.Errorf("relinking an existing pair should be a no-op, got %v", err)
+	}
+	if err := e.CheckSupersede(chain[1].ID); err != core.ErrSupersedeConflict {
+		t.Errorf("CheckSupersede should refuse a superseded neuron, got %v", err)
+	}
+}
+
+func TestChainIssues_ReportsBrokenLinks(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	chain := supersedeChain(t, e, "first", "second", "third")
+	chain[1].Metadata[SupersedesKey] = "missing"
+	chain[2].Metadata[SupersededByKey] = "gone"
+
+	issues := e.ChainIssues()
+	problems := make(map[string]bool)
+	for _, issue := range issues {
+		problems[issue.Problem] = true
+	}
+	for _, want := range []string{"dangling_predecessor", "dangling_successor", "asymmetric"} {
+		if !problems[want] {
+			t.Errorf("expected a %s issue, got %+v", want, issues)
+		}
+	}
+}
+
+func TestSearch_ResolveSuperseded(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	chain := supersedeChain(t, e,
+		"the deploy window is Monday morning",
+		"the deploy window is Tuesday morning",
+		"the deploy window moved to Friday")
+
+	plain, _ := e.PeekSearch(e.traceContext(), "deploy window Monday", 0, 10, nil, false, nil, SearchOptions{})
+	if len(plain) == 0 || plain[0].ID != chain[0].ID {
+		t.Fatalf("without resolution the closest match should rank first, got %v", contents(plain))
+	}
+
+	resolved, _ := e.PeekSearch(e.traceContext(), "deploy window Monday", 0, 10, nil, false, nil, SearchOptions{ResolveSuperseded: true})
+	seen := make(map[core.NeuronID]bool)
+	for _, n := range resolved {
+		if IsSuperseded(n) {
+			t.Errorf("resolved results should not include superseded %q", n.Content)
+		}
+		if seen[n.ID] {
+			t.Errorf("%q returned twice", n.Content)
+		}
+		seen[n.ID] = true
+	}
+	if len(resolved) != 1 || resolved[0].ID != chain[2].ID {
+		t.Errorf("expected only the chain head, got %v", contents(resolved))
+	}
+}
+
+func contents(neurons []*core.Neuron) []string {
+	out := make([]string, len(neurons))
+	for i, n := range neurons {
+		out[i] = n.Content
+	}
+	return out
+}
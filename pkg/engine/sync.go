@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// ChangeSet is one page of the matrix change feed.
+type ChangeSet struct {
+	Neurons []*core.Neuron  // created or updated neurons, in sequence order
+	Removed []core.NeuronID // removed neuron IDs
+	Cursor  uint64          // pass as since to continue after this page
+	HasMore bool            // more changes follow Cursor
+	Resync  bool            // since is outside retained history; reload from since=0
+}
+
+// syncEvent is a neuron update or removal at a change sequence.
+type syncEvent struct {
+	seq     uint64
+	neuron  *core.Neuron
+	removed core.NeuronID
+}
+
+// Changes returns up to limit changes recorded after since, oldest first.
+// since=0 lists every neuron and no removals. Energy and depth changes are
+// only reported with includeActivity, and only once energy has moved by at
+// least threshold or depth has changed since the neuron was last reported.
+//
+// A cursor older than the retained tombstones, or newer than the matrix
+// has issued (e.g. after a truncate), yields Resync.
+func (e *MatrixEngine) Changes(since uint64, limit int, includeActivity bool, threshold float64) ChangeSet {
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+
+	m := e.matrix
+	e.backfillChangeSeqs()
+
+	if since > m.ChangeSeq || (since > 0 && since < m.HistoryFloor) {
+		return ChangeSet{Neurons: []*core.Neuron{}, Removed: []core.NeuronID{}, Resync: true}
+	}
+
+	var events []syncEvent
+	for _, n := range m.Neurons {
+		if includeActivity {
+			diff := n.Energy - n.SyncEnergy
+			if n.Depth != n.SyncDepth || (diff != 0 && math.Abs(diff) >= threshold) {
+				n.ActivitySeq = m.NextChangeSeq()
+				n.SyncEnergy, n.SyncDepth = n.Energy, n.Depth
+			}
+		}
+		seq := n.ChangeSeq
+		if includeActivity && n.ActivitySeq > seq {
+			seq = n.ActivitySeq
+		}
+		if since == 0 || seq > since {
+			events = append(events, syncEvent{seq: seq, neuron: n})
+		}
+	}
+	if since > 0 {
+		// Tombstones are in sequence order.
+		i := sort.Search(len(m.Tombstones), func(i int) bool { return m.Tombstones[i].Seq > since })
+		for _, t := range m.Tombstones[i:] {
+			events = append(events, syncEvent{seq: t.Seq, removed: t.ID})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].seq < events[j].seq })
+
+	cs := ChangeSet{Neurons: []*core.Neuron{}, Removed: []core.NeuronID{}, Cursor: m.ChangeSeq}
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+		cs.Cursor = events[limit-1].seq
+		cs.HasMore = true
+	}
+	for _, ev := range events {
+		if ev.neuron != nil {
+			cs.Neurons = append(cs.Neurons, ev.neuron)
+		} else {
+			cs.Removed = append(cs.Removed, ev.removed)
+		}
+	}
+	return cs
+}
+
+// backfillChangeSeqs gives neurons loaded from snapshots that predate
+// change tracking their own sequence numbers, oldest first, so every
+// change in the feed has a distinct cursor.
+func (e *MatrixEngine) backfillChangeSeqs() {
+	var legacy []*core.Neuron
+	for _, n := range e.matrix.Neurons {
+		if n.ChangeSeq == 0 {
+			legacy = append(legacy, n)
+		}
+	}
+	sort.Slice(legacy, func(i, j int) bool {
+		if !legacy[i].CreatedAt.Equal(legacy[j].CreatedAt) {
+			return legacy[i].CreatedAt.Before(legacy[j].CreatedAt)
+		}
+		return legacy[i].ID < legacy[j].ID
+	})
+	for _, n := range legacy {
+		e.matrix.RecordChange(n)
+	}
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func changeIDs(cs ChangeSet) []core.NeuronID {
+	ids := make([]core.NeuronID, len(cs.Neurons))
+	for i, n := range cs.Neurons {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+func TestChanges_IncrementalAndPaged(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	a, _ := e.AddNeuron("alpha memory", nil, nil)
+	b, _ := e.AddNeuron("beta memory", nil, nil)
+
+	full := e.Changes(0, 0, false, 0.1)
+	if len(full.Neurons) != 2 || full.HasMore || full.Cursor != 2 {
+		t.Fatalf("unexpected full sync: %+v", full)
+	}
+
+	c, _ := e.AddNeuron("gamma memory", nil, nil)
+	if err := e.UpdateNeuron(a.ID, "alpha memory, revised"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.DeleteNeuron(b.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	page := e.Changes(full.Cursor, 1, false, 0.1)
+	if ids := changeIDs(page); len(ids) != 1 || ids[0] != c.ID || !page.HasMore {
+		t.Fatalf("first page should hold only the new neuron, got %v (hasMore=%v)", ids, page.HasMore)
+	}
+	page = e.Changes(page.Cursor, 10, false, 0.1)
+	if ids := changeIDs(page); len(ids) != 1 || ids[0] != a.ID {
+		t.Fatalf("second page should hold the updated neuron, got %v", ids)
+	}
+	if len(page.Removed) != 1 || page.Removed[0] != b.ID || page.HasMore {
+		t.Fatalf("second page should report the removal and end, got %+v", page)
+	}
+
+	if idle := e.Changes(page.Cursor, 10, false, 0.1); len(idle.Neurons)+len(idle.Removed) != 0 || idle.Cursor != page.Cursor {
+		t.Fatalf("expected no changes past the latest cursor, got %+v", idle)
+	}
+}
+
+func TestChanges_ActivityThreshold(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	n, _ := e.AddNeuron("energy memory", nil, nil)
+	cursor := e.Changes(0, 0, true, 0.1).Cursor
+
+	n.Energy -= 0.05
+	if cs := e.Changes(cursor, 0, true, 0.1); len(cs.Neurons) != 0 {
+		t.Fatalf("energy drift below the threshold should not be reported, got %v", changeIDs(cs))
+	}
+
+	n.Energy -= 0.1
+	if cs := e.Changes(cursor, 0, false, 0.1); len(cs.Neurons) != 0 {
+		t.Fatalf("activity should be excluded by default, got %v", changeIDs(cs))
+	}
+	cs := e.Changes(cursor, 0, true, 0.1)
+	if len(cs.Neurons) != 1 {
+		t.Fatalf("energy change above the threshold should be reported, got %v", changeIDs(cs))
+	}
+
+	n.Depth++
+	if next := e.Changes(cs.Cursor, 0, true, 0.1); len(next.Neurons) != 1 {
+		t.Fatalf("depth change should be reported, got %v", changeIDs(next))
+	}
+}
+
+func TestChanges_ResyncWhenHistoryExpires(t *testing.T) {
+	e := NewMatrixEngine(newTestMatrix())
+	e.SetChangelogSize(2)
+	var ids []core.NeuronID
+	for _, content := range []string{"one", "two", "three", "four"} {
+		n, _ := e.AddNeuron(content+" memory", nil, nil)
+		ids = append(ids, n.ID)
+	}
+	cursor := e.Changes(0, 0, false, 0).Cursor
+
+	e.DeleteNeuron(ids[0])
+	afterFirst := e.Changes(cursor, 0, false, 0).Cursor
+	e.DeleteNeuron(ids[1])
+	e.DeleteNeuron(ids[2])
+
+	if cs := e.Changes(cursor, 0, false, 0); !cs.Resync {
+		t.Fatalf("cursor older than retained tombstones should resync, got %+v", cs)
+	}
+	cs := e.Changes(afterFirst, 0, false, 0)
+	if cs.Resync || len(cs.Removed) != 2 {
+		t.Fatalf("cursor within retained history should list 2 removals, got %+v", cs)
+	}
+	if cs := e.Changes(cs.Cursor+10, 0, false, 0); !cs.Resync {
+		t.Fatalf("cursor ahead of the matrix should resync, got %+v", cs)
+	}
+}
+
+func TestChanges_BackfillsLegacyNeurons(t *testing.T) {
+	m := newTestMatrix()
+	for i, content := range []string{"older", "newer"} {
+		n := core.NewNeuron(content, m.CurrentDim)
+		n.CreatedAt = time.Now().Add(time.Duration(i) * time.Second)
+		m.Neurons[n.ID] = n
+	}
+	e := NewMatrixEngine(m)
+
+	cs := e.Changes(0, 1, false, 0.1)
+	if len(cs.Neurons) != 1 || cs.Neurons[0].Content != "older" || !cs.HasMore {
+		t.Fatalf("legacy neurons should page oldest first, got %+v", cs)
+	}
+	cs = e.Changes(cs.Cursor, 1, false, 0.1)
+	if len(cs.Neurons) != 1 || cs.Neurons[0].Content != "newer" || cs.HasMore {
+		t.Fatalf("expected the remaining legacy neuron, got %+v", cs)
+	}
+}
@@ -0,0 +1,37 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/engine"
+	"github.com/qubicDB/qubicdb/pkg/testutil"
+)
+
+// BenchmarkSyntheticStrictThread200K compares a strict thread_id filter
+// answered from the metadata index with the full scan it replaces.
+func BenchmarkSyntheticStrictThread200K(b *testing.B) {
+	brain := testutil.NewSyntheticBrain(42, testutil.SyntheticOptions{Neurons: 200000, MeanDegree: -1})
+	filter := map[string]string{testutil.ThreadKey: "thread-0100"}
+
+	for _, bc := range []struct {
+		name string
+		keys []string
+	}{
+		{"indexed", engine.DefaultIndexedMetadataKeys},
+		{"scan", nil},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			e := engine.NewMatrixEngine(brain.Matrix)
+			e.SetIndexedMetadataKeys(bc.keys)
+			ctx := context.Background()
+			e.PeekSearch(ctx, "deployment", 0, 10, filter, true, nil, engine.SearchOptions{})
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e.PeekSearch(ctx, "deployment", 0, 10, filter, true, nil, engine.SearchOptions{})
+			}
+		})
+	}
+}
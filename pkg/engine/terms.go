@@ -0,0 +1,65 @@
+package engine
+
+import "github.com/qubicDB/qubicdb/pkg/core"
+
+// BM25 defaults, matching search.bm25 in the default configuration.
+const (
+	defaultBM25K1       = 1.2
+	defaultBM25B        = 0.75
+	defaultBM25MaxTerms = 100000
+)
+
+// SetBM25 sets the BM25 term-frequency saturation (k1), length
+// normalization (b) and the cap on tracked terms (0 = unbounded).
+func (e *MatrixEngine) SetBM25(k1, b float64, maxTerms int) {
+	e.bm25K1 = k1
+	e.bm25B = b
+	e.maxTerms = maxTerms
+}
+
+// indexTerms counts n in the matrix term statistics. Caller holds the matrix
+// write lock. Missing statistics are left for ensureTermStats to build.
+func (e *MatrixEngine) indexTerms(n *core.Neuron) {
+	if e.matrix.Terms == nil {
+		return
+	}
+	e.matrix.Terms.Add(e.lexicalTokens(n))
+	e.matrix.Terms.Trim(e.maxTerms)
+}
+
+// unindexTerms uncounts n from the matrix term statistics: the tokens stored
+// on n are those it was counted with. Caller holds the matrix write lock.
+func (e *MatrixEngine) unindexTerms(n *core.Neuron) {
+	if e.matrix.Terms == nil {
+		return
+	}
+	tokens := n.LexicalTokens
+	if n.LexicalKey == "" {
+		tokens = e.fold.tokens(n.LexicalText(), NeuronLang(n))
+	}
+	e.matrix.Terms.Remove(tokens)
+}
+
+// ensureTermStats rebuilds the matrix term statistics, and the tokens stored
+// on each neuron, when they are missing, as for matrices persisted before
+// BM25 scoring, were folded otherwise, or no longer count every neuron, as
+// after content is rewritten outside the engine.
+func (e *MatrixEngine) ensureTermStats() {
+	fold := e.fold.statsKey()
+	e.matrix.RLock()
+	fresh := e.matrix.Terms != nil && e.matrix.Terms.Docs == len(e.matrix.Neurons) && e.matrix.Terms.Fold == fold
+	e.matrix.RUnlock()
+	if fresh {
+		return
+	}
+
+	e.matrix.Lock()
+	defer e.matrix.Unlock()
+	terms := core.NewTermStats()
+	terms.Fold = fold
+	for _, n := range e.matrix.Neurons {
+		terms.Add(e.lexicalTokens(n))
+	}
+	terms.Trim(e.maxTerms)
+	e.matrix.Terms = terms
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Reserved metadata keys of a structured conversation turn. A turn stores
+// only its text as content; its role goes under RoleMetadataKey, and how
+// it is rendered back into context is decided when reading.
+const (
+	LangMetadataKey   = "_lang"
+	FormatMetadataKey = "_format" // turn template preferred by the writer
+)
+
+// maxTurnMigrationSamples bounds how many changes a migration report lists.
+const maxTurnMigrationSamples = 100
+
+// NeuronLang returns the language recorded on n, or "" if none.
+func NeuronLang(n *core.Neuron) string {
+	v, ok := n.Metadata[LangMetadataKey]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// NeuronFormat returns the turn template recorded on n, or "" if none.
+func NeuronFormat(n *core.Neuron) string {
+	v, ok := n.Metadata[FormatMetadataKey]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// TurnChange is one neuron a turn migration rewrites.
+type TurnChange struct {
+	NeuronID core.NeuronID `json:"neuronId"`
+	Role     string        `json:"role"`
+	Lang     string        `json:"lang,omitempty"`
+	Before   string        `json:"before"`
+	After    string        `json:"after"`
+}
+
+// TurnMigration reports a turn migration. Changes lists at most the first
+// maxTurnMigrationSamples rewrites, by neuron ID.
+type TurnMigration struct {
+	DryRun    bool         `json:"dryRun"`
+	Scanned   int          `json:"scanned"`
+	Matched   int          `json:"matched"`
+	Migrated  int          `json:"migrated"`  // rewritten, or would be on a dry run
+	Conflicts int          `json:"conflicts"` // matched but already carrying another role
+	Changes   []TurnChange `json:"changes"`
+}
+
+// MigrateTurns rewrites neurons whose content matches pattern — content
+// written with its role inline, such as "[EN] user: hello" — into
+// structured turns: the text becomes the content and the role and language
+// move to metadata. pattern must capture role and text groups and may
+// capture lang. Neurons that already record a different role are left
+// alone and counted as conflicts. A dry run reports without writing.
+//
+// Rewritten neurons lose their embedding, which no longer matches their
+// content; with a vectorizer attached they are queued for EmbedPending.
+func (e *MatrixEngine) MigrateTurns(pattern *regexp.Regexp, dryRun bool) TurnMigration {
+	vectorizer, _, _, _ := e.vectorSettings()
+
+	if dryRun {
+		e.matrix.RLock()
+		defer e.matrix.RUnlock()
+	} else {
+		e.matrix.Lock()
+		defer e.matrix.Unlock()
+	}
+
+	report := TurnMigration{DryRun: dryRun, Scanned: len(e.matrix.Neurons), Changes: []TurnChange{}}
+	ids := make([]core.NeuronID, 0, len(e.matrix.Neurons))
+	for id := range e.matrix.Neurons {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	roleGroup, langGroup, textGroup := pattern.SubexpIndex("role"), pattern.SubexpIndex("lang"), pattern.SubexpIndex("text")
+	for _, id := range ids {
+		n := e.matrix.Neurons[id]
+		m := pattern.FindStringSubmatch(n.Content)
+		if m == nil {
+			continue
+		}
+		text, err := core.NormalizeNeuronContent(m[textGroup])
+		if err != nil {
+			continue
+		}
+		report.Matched++
+
+		role := strings.ToLower(strings.TrimSpace(m[roleGroup]))
+		if existing := NeuronRole(n); existing != "" && existing != role {
+			report.Conflicts++
+			continue
+		}
+		lang := ""
+		if langGroup >= 0 {
+			lang = strings.ToLower(m[langGroup])
+		}
+
+		report.Migrated++
+		if len(report.Changes) < maxTurnMigrationSamples {
+			report.Changes = append(report.Changes, TurnChange{NeuronID: id, Role: role, Lang: lang, Before: n.Content, After: text})
+		}
+		if dryRun {
+			continue
+		}
+
+		e.unindexTerms(n)
+		e.unindexMetadata(n)
+		n.Lock()
+		n.Content = text
+		n.ContentHash = core.HashContent(text)
+		if len(n.Embedding) > 0 || vectorizer != nil {
+			n.Embedding = nil
+			n.EmbeddingModel = ""
+			n.EmbedPending = vectorizer != nil
+		}
+		n.Unlock()
+		e.indexTerms(n)
+		n.EditMetadata(func(metadata map[string]any) {
+			metadata[RoleMetadataKey] = role
+			if lang != "" {
+				metadata[LangMetadataKey] = lang
+			}
+		})
+		e.indexMetadata(n)
+		e.matrix.RecordChange(n)
+	}
+
+	if !dryRun && report.Migrated > 0 {
+		e.matrix.ModifiedAt = time.Now()
+		e.matrix.Version++
+	}
+	return report
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestMigrateTurns_ConflictsAndEmbeddings(t *testing.T) {
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	e := NewMatrixEngine(m)
+	e.SetVectorizer(unitEmbedder{}, "code")
+
+	plain, _ := e.AddNeuron("[EN] user: deploys go out on Mondays", nil, nil)
+	tagged, _ := e.AddNeuron("assistant: noted, Mondays it is", nil, map[string]string{RoleMetadataKey: "user"})
+	pattern := regexp.MustCompile(core.DefaultTurnPattern)
+
+	report := e.MigrateTurns(pattern, false)
+	if report.Matched != 2 || report.Migrated != 1 || report.Conflicts != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if plain.Content != "deploys go out on Mondays" || NeuronRole(plain) != "user" || NeuronLang(plain) != "en" {
+		t.Errorf("neuron not migrated: %q %v", plain.Content, plain.Metadata)
+	}
+	if tagged.Content != "assistant: noted, Mondays it is" {
+		t.Errorf("a neuron recording another role should be left alone, got %q", tagged.Content)
+	}
+
+	// The old embedding described the prefixed content.
+	if len(plain.Embedding) != 0 || !plain.EmbedPending {
+		t.Errorf("migrated neuron should be queued for embedding: pending=%v", plain.EmbedPending)
+	}
+	if n, err := e.EmbedPending(10); n != 1 || err != nil || len(plain.Embedding) != 4 {
+		t.Errorf("expected the migrated neuron re-embedded, got %d, %v", n, err)
+	}
+	if results := NewSearcher(m).Search("deploys Mondays", 0, 10); len(results) == 0 || results[0].ID != plain.ID {
+		t.Errorf("migrated text should stay searchable, got %d results", len(results))
+	}
+}
@@ -22,6 +22,13 @@ type Manager struct {
 	activityBuffer map[core.IndexID][]time.Time
 	bufferWindow   time.Duration
 
+	// holds pause state transitions until the given time, without
+	// counting as activity.
+	holds map[core.IndexID]time.Time
+
+	// watches wake WaitForChange callers on transitions.
+	watches map[core.IndexID]*stateWatch
+
 	// Thresholds
 	idleThreshold    time.Duration
 	sleepThreshold   time.Duration
@@ -41,8 +48,29 @@ type Manager struct {
 func (m *Manager) RemoveIndex(indexID core.IndexID) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if state, ok := m.states[indexID]; ok {
+		m.transitionLocked(indexID, state.State, core.StateDormant)
+	}
 	delete(m.states, indexID)
 	delete(m.activityBuffer, indexID)
+	delete(m.holds, indexID)
+	if w, ok := m.watches[indexID]; ok && w.waiters == 0 {
+		delete(m.watches, indexID)
+	}
+}
+
+// Hold keeps an index in its current state for d, so a freshly loaded
+// brain is not sent to sleep or dormancy by the next check. Unlike
+// RecordActivity it neither wakes the index nor counts as an invocation.
+// A later hold replaces an earlier one. Indexes without lifecycle state
+// have nothing to transition and are not held.
+func (m *Manager) Hold(indexID core.IndexID, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.states[indexID]; !ok {
+		return
+	}
+	m.holds[indexID] = time.Now().Add(d)
 }
 
 // SetThresholds applies lifecycle thresholds at runtime.
@@ -71,6 +99,8 @@ func NewManager() *Manager {
 	return &Manager{
 		states:           make(map[core.IndexID]*core.BrainState),
 		activityBuffer:   make(map[core.IndexID][]time.Time),
+		holds:            make(map[core.IndexID]time.Time),
+		watches:          make(map[core.IndexID]*stateWatch),
 		bufferWindow:     5 * time.Minute,
 		idleThreshold:    30 * time.Second,
 		sleepThreshold:   5 * time.Minute,
@@ -104,15 +134,17 @@ func (m *Manager) RecordActivity(indexID core.IndexID) {
 
 	now := time.Now()
 
-	// Get or create state
+	// Get or create state; an untracked index counts as dormant
+	oldState := core.StateDormant
 	state, ok := m.states[indexID]
 	if !ok {
 		state = core.NewBrainState(indexID)
 		m.states[indexID] = state
+	} else {
+		oldState = state.State
 	}
 
 	// Handle state transition on activity
-	oldState := state.State
 	if state.State == core.StateDormant || state.State == core.StateSleeping {
 		state.State = core.StateActive
 		state.SessionStart = now
@@ -132,7 +164,7 @@ func (m *Manager) RecordActivity(indexID core.IndexID) {
 	// Clean old entries from buffer
 	m.cleanBuffer(indexID)
 
-	_ = oldState // May use for metrics later
+	m.transitionLocked(indexID, oldState, state.State)
 }
 
 // cleanBuffer removes old activity entries
@@ -205,6 +237,12 @@ func (m *Manager) CheckAndTransition(indexID core.IndexID) bool {
 	}
 
 	now := time.Now()
+	if until, held := m.holds[indexID]; held {
+		if now.Before(until) {
+			return false
+		}
+		delete(m.holds, indexID)
+	}
 	elapsed := now.Sub(state.LastInvoke)
 	oldState := state.State
 
@@ -235,6 +273,7 @@ func (m *Manager) CheckAndTransition(indexID core.IndexID) bool {
 		}
 	}
 
+	m.transitionLocked(indexID, oldState, state.State)
 	return state.State != oldState
 }
 
@@ -255,21 +294,19 @@ func (m *Manager) isActivitySparseUnsafe(indexID core.IndexID) bool {
 	return count < m.sparsenessMinOps
 }
 
-// StartMonitor starts the background lifecycle monitoring
-func (m *Manager) StartMonitor(checkInterval time.Duration) {
-	go func() {
-		ticker := time.NewTicker(checkInterval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-m.ctx.Done():
-				return
-			case <-ticker.C:
+// MonitorTask returns the background task that checks every index for
+// state transitions each checkInterval, until the manager stops.
+func (m *Manager) MonitorTask(checkInterval time.Duration) core.Task {
+	return core.Task{
+		Name:     "lifecycle",
+		Interval: checkInterval,
+		Run: func(context.Context) error {
+			if m.ctx.Err() == nil {
 				m.checkAllUsers()
 			}
-		}
-	}()
+			return nil
+		},
+	}
 }
 
 // checkAllUsers checks all indexes for state transitions
@@ -319,6 +356,32 @@ func (m *Manager) GetSleepingUsers() []core.IndexID {
 	return sleeping
 }
 
+// GetDormantUsers returns the tracked indexes in Dormant state
+func (m *Manager) GetDormantUsers() []core.IndexID {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dormant := make([]core.IndexID, 0)
+	for id, state := range m.states {
+		if state.State == core.StateDormant {
+			dormant = append(dormant, id)
+		}
+	}
+	return dormant
+}
+
+// Indexes returns every index with tracked lifecycle state.
+func (m *Manager) Indexes() []core.IndexID {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]core.IndexID, 0, len(m.states))
+	for id := range m.states {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 // ForceWake forces an index to wake up
 func (m *Manager) ForceWake(indexID core.IndexID) {
 	m.mu.Lock()
@@ -328,9 +391,11 @@ func (m *Manager) ForceWake(indexID core.IndexID) {
 	if !ok {
 		state = core.NewBrainState(indexID)
 		m.states[indexID] = state
+		m.transitionLocked(indexID, core.StateDormant, core.StateActive)
 	}
 
 	if state.State != core.StateActive {
+		m.transitionLocked(indexID, state.State, core.StateActive)
 		state.State = core.StateActive
 		state.LastInvoke = time.Now()
 		if m.onWake != nil {
@@ -350,6 +415,7 @@ func (m *Manager) ForceSleep(indexID core.IndexID) {
 	}
 
 	if state.State != core.StateSleeping {
+		m.transitionLocked(indexID, state.State, core.StateSleeping)
 		state.State = core.StateSleeping
 		if m.onSleepStart != nil {
 			go m.onSleepStart(indexID)
@@ -174,6 +174,21 @@ func TestManagerGetSleepingUsers(t *testing.T) {
 	}
 }
 
+func TestManagerGetDormantUsers(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	m.RecordActivity("user-1")
+	m.RecordActivity("user-2")
+	m.RemoveIndex("user-2")
+	m.states["user-1"].State = core.StateDormant
+
+	dormant := m.GetDormantUsers()
+	if len(dormant) != 1 || dormant[0] != "user-1" {
+		t.Errorf("Expected only user-1 dormant, got %v", dormant)
+	}
+}
+
 func TestManagerCallbacks(t *testing.T) {
 	m := NewManager()
 	defer m.Stop()
@@ -226,3 +241,43 @@ func TestManagerStats(t *testing.T) {
 		t.Errorf("Expected 2 total indexes, got %v", stats["total_indexes"])
 	}
 }
+
+func TestManagerHoldPreventsDormancy(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	var dormant atomic.Int32
+	m.SetCallbacks(nil, nil, func(core.IndexID) { dormant.Add(1) }, nil)
+
+	indexID := core.IndexID("user-1")
+	m.RecordActivity(indexID)
+	m.ForceSleep(indexID)
+	m.mu.Lock()
+	lastInvoke := time.Now().Add(-time.Hour)
+	m.states[indexID].LastInvoke = lastInvoke
+	invokes := m.states[indexID].InvokeCount
+	m.mu.Unlock()
+
+	m.Hold(indexID, 50*time.Millisecond)
+	if m.CheckAndTransition(indexID) || m.GetState(indexID) != core.StateSleeping {
+		t.Fatalf("a held index should stay asleep, got %v", m.GetState(indexID))
+	}
+	state := m.GetBrainState(indexID)
+	if !state.LastInvoke.Equal(lastInvoke) || state.InvokeCount != invokes {
+		t.Errorf("a hold should not count as activity: %+v", state)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !m.CheckAndTransition(indexID) || m.GetState(indexID) != core.StateDormant {
+		t.Errorf("the index should go dormant once the hold expires, got %v", m.GetState(indexID))
+	}
+	time.Sleep(10 * time.Millisecond)
+	if dormant.Load() != 1 {
+		t.Errorf("expected one dormant callback, got %d", dormant.Load())
+	}
+
+	m.Hold("unknown", time.Minute)
+	if _, held := m.holds["unknown"]; held {
+		t.Error("an index without state should not be held")
+	}
+}
@@ -0,0 +1,141 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// ErrTooManyWaiters is returned by WaitForChange when an index already has
+// its maximum of waiters.
+var ErrTooManyWaiters = errors.New("too many state waiters for index")
+
+var stateNames = []string{"active", "idle", "sleeping", "dormant"}
+
+// StateName returns the API name of a lifecycle state.
+func StateName(s core.ActivityState) string {
+	if int(s) >= 0 && int(s) < len(stateNames) {
+		return stateNames[s]
+	}
+	return "unknown"
+}
+
+// ParseState returns the lifecycle state with the given API name.
+func ParseState(name string) (core.ActivityState, bool) {
+	for i, n := range stateNames {
+		if n == name {
+			return core.ActivityState(i), true
+		}
+	}
+	return 0, false
+}
+
+// Transition is a change of an index's lifecycle state.
+type Transition struct {
+	IndexID core.IndexID
+	From    core.ActivityState
+	To      core.ActivityState
+	At      time.Time
+}
+
+// stateWatch wakes the waiters of one index. changed is closed and
+// replaced on every transition, so each transition wakes exactly the
+// waiters of its index.
+type stateWatch struct {
+	changed chan struct{}
+	last    Transition
+	waiters int
+}
+
+// transitionLocked records that indexID moved from one state to another and
+// wakes its waiters. Callers hold mu.
+func (m *Manager) transitionLocked(indexID core.IndexID, from, to core.ActivityState) {
+	if from == to {
+		return
+	}
+	w, ok := m.watches[indexID]
+	if !ok {
+		w = &stateWatch{changed: make(chan struct{})}
+		m.watches[indexID] = w
+	}
+	w.last = Transition{IndexID: indexID, From: from, To: to, At: time.Now()}
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+// changedLocked reports whether indexID is no longer in state current, and
+// the transition that left it there when one was recorded. Callers hold
+// mu.
+func (m *Manager) changedLocked(indexID core.IndexID, current core.ActivityState) (Transition, bool) {
+	state := core.StateDormant
+	if s, ok := m.states[indexID]; ok {
+		state = s.State
+	}
+	if state == current {
+		return Transition{}, false
+	}
+	if w, ok := m.watches[indexID]; ok && w.last.To == state {
+		return w.last, true
+	}
+	return Transition{IndexID: indexID, From: state, To: state}, true
+}
+
+// WaitForChange blocks until the lifecycle state of indexID differs from
+// current or ctx is done, and reports which. The transition has a zero At
+// when the state differed before any transition was recorded. At most
+// maxWaiters calls wait on one index at once; 0 means no limit.
+func (m *Manager) WaitForChange(ctx context.Context, indexID core.IndexID, current core.ActivityState, maxWaiters int) (Transition, bool, error) {
+	m.mu.Lock()
+	if t, changed := m.changedLocked(indexID, current); changed {
+		m.mu.Unlock()
+		return t, true, nil
+	}
+	w, ok := m.watches[indexID]
+	if !ok {
+		w = &stateWatch{changed: make(chan struct{})}
+		m.watches[indexID] = w
+	}
+	if maxWaiters > 0 && w.waiters >= maxWaiters {
+		m.mu.Unlock()
+		return Transition{}, false, ErrTooManyWaiters
+	}
+	w.waiters++
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		w.waiters--
+		// The watch of a removed index outlives it only while waited on.
+		if _, tracked := m.states[indexID]; !tracked && w.waiters == 0 && m.watches[indexID] == w {
+			delete(m.watches, indexID)
+		}
+	}()
+
+	for {
+		m.mu.RLock()
+		t, changed := m.changedLocked(indexID, current)
+		ch := w.changed
+		m.mu.RUnlock()
+		if changed {
+			return t, true, nil
+		}
+		select {
+		case <-ctx.Done():
+			return Transition{}, false, nil
+		case <-ch:
+		}
+	}
+}
+
+// Waiters returns how many calls are waiting on indexID.
+func (m *Manager) Waiters(indexID core.IndexID) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if w, ok := m.watches[indexID]; ok {
+		return w.waiters
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+type waitResult struct {
+	t       Transition
+	changed bool
+	err     error
+}
+
+func startWaiter(ctx context.Context, m *Manager, indexID core.IndexID, current core.ActivityState, max int) chan waitResult {
+	done := make(chan waitResult, 1)
+	go func() {
+		t, changed, err := m.WaitForChange(ctx, indexID, current, max)
+		done <- waitResult{t, changed, err}
+	}()
+	return done
+}
+
+func awaitWaiters(t *testing.T, m *Manager, indexID core.IndexID, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Waiters(indexID) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d waiters on %s, got %d", n, indexID, m.Waiters(indexID))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWaitForChangeWakesOnlyTheIndexWaiters(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+	m.RecordActivity("a")
+	m.RecordActivity("b")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	waitA := startWaiter(ctx, m, "a", core.StateActive, 0)
+	waitB := startWaiter(ctx, m, "b", core.StateActive, 0)
+	awaitWaiters(t, m, "a", 1)
+	awaitWaiters(t, m, "b", 1)
+
+	m.ForceSleep("a")
+	select {
+	case r := <-waitA:
+		if !r.changed || r.err != nil || r.t.From != core.StateActive || r.t.To != core.StateSleeping || r.t.At.IsZero() {
+			t.Errorf("unexpected wake-up: %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the waiter was not woken by the transition")
+	}
+	select {
+	case r := <-waitB:
+		t.Fatalf("a transition of a must not wake b's waiter: %+v", r)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	m.ForceSleep("b")
+	if r := <-waitB; r.t.To != core.StateSleeping {
+		t.Errorf("unexpected wake-up of b: %+v", r)
+	}
+	if m.Waiters("a") != 0 || m.Waiters("b") != 0 {
+		t.Error("returned waiters should be released")
+	}
+}
+
+func TestWaitForChangeReturnsAtOnceWhenStateDiffers(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+	m.RecordActivity("a")
+	m.ForceSleep("a")
+
+	tr, changed, err := m.WaitForChange(context.Background(), "a", core.StateIdle, 0)
+	if err != nil || !changed || tr.To != core.StateSleeping || tr.From != core.StateActive {
+		t.Errorf("expected the recorded transition at once, got %+v %v %v", tr, changed, err)
+	}
+	if tr, changed, _ := m.WaitForChange(context.Background(), "unknown", core.StateActive, 0); !changed || tr.To != core.StateDormant {
+		t.Errorf("an untracked index is dormant, got %+v", tr)
+	}
+}
+
+func TestWaitForChangeTimeoutAndLimit(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+	m.RecordActivity("a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, changed, err := m.WaitForChange(ctx, "a", core.StateActive, 0); changed || err != nil {
+		t.Errorf("expected no change at the timeout, got %v %v", changed, err)
+	}
+
+	hold, release := context.WithCancel(context.Background())
+	first := startWaiter(hold, m, "a", core.StateActive, 1)
+	awaitWaiters(t, m, "a", 1)
+	if _, _, err := m.WaitForChange(context.Background(), "a", core.StateActive, 1); !errors.Is(err, ErrTooManyWaiters) {
+		t.Errorf("expected ErrTooManyWaiters past the limit, got %v", err)
+	}
+	release()
+	<-first
+	awaitWaiters(t, m, "a", 0)
+}
+
+func TestRemoveIndexWakesWaiters(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+	m.RecordActivity("a")
+
+	done := startWaiter(context.Background(), m, "a", core.StateActive, 0)
+	awaitWaiters(t, m, "a", 1)
+	m.RemoveIndex("a")
+	if r := <-done; !r.changed || r.t.To != core.StateDormant {
+		t.Errorf("removing an index should wake its waiters as dormant, got %+v", r)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.watches["a"]; ok {
+		t.Error("the watch of a removed index should go with its last waiter")
+	}
+}
@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	mcpproto "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// timeoutDescription documents the timeout_ms argument every tool takes.
+const timeoutDescription = "Optional time budget for this call in milliseconds. The call stops and returns a timeout result when it runs out. Capped by the server's mcp.maxToolDurationMS, which also applies when omitted."
+
+// timeoutArg is the timeout_ms argument every tool takes.
+func timeoutArg() mcpproto.ToolOption {
+	return mcpproto.WithNumber("timeout_ms", mcpproto.Description(timeoutDescription))
+}
+
+// durationCosts charges a tool call extra rate-limit tokens by how long it
+// ran, on top of the one it took to be admitted, longest first.
+var durationCosts = []struct {
+	after time.Duration
+	cost  float64
+}{
+	{15 * time.Second, 9},
+	{5 * time.Second, 4},
+	{time.Second, 1},
+}
+
+// durationCost is the extra tokens a call that ran for d costs.
+func durationCost(d time.Duration) float64 {
+	for _, c := range durationCosts {
+		if d >= c.after {
+			return c.cost
+		}
+	}
+	return 0
+}
+
+// clientKeyContextKey holds the rate-limit key of the client calling a tool.
+type clientKeyContextKey struct{}
+
+// budgetMiddleware runs each tool call under a deadline: its timeout_ms
+// argument, at most maxDuration, which applies when it is omitted, and never
+// past the request's own deadline; a maxDuration of 0 caps nothing. The
+// deadline reaches the backend through the context, so queued worker
+// operations are abandoned with the call. A call that runs out of time
+// returns a structured timeout result as soon as its deadline passes,
+// whatever the backend is still doing. Once the call has finished, rl, when
+// set, charges its client by how long it ran.
+func budgetMiddleware(maxDuration time.Duration, rl *rateLimiter) mcpserver.ToolHandlerMiddleware {
+	return func(next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+		return func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
+			budget, capped := maxDuration, false
+			if v, ok := req.GetArguments()["timeout_ms"].(float64); ok {
+				if math.IsNaN(v) || v <= 0 {
+					return errResult("timeout_ms must be a positive number of milliseconds"), nil
+				}
+				if maxDuration > 0 && v > float64(maxDuration.Milliseconds()) {
+					capped = true
+				} else {
+					budget = time.Duration(v * float64(time.Millisecond))
+				}
+			}
+			if deadline, ok := ctx.Deadline(); ok && (budget <= 0 || time.Until(deadline) < budget) {
+				budget = max(time.Until(deadline), time.Nanosecond)
+			}
+
+			start := time.Now()
+			callCtx, cancel := context.WithCancel(ctx)
+			if budget > 0 {
+				callCtx, cancel = context.WithTimeout(ctx, budget)
+			}
+			defer cancel()
+
+			type outcome struct {
+				result *mcpproto.CallToolResult
+				err    error
+			}
+			done := make(chan outcome, 1)
+			go func() {
+				var o outcome
+				defer func() {
+					if r := recover(); r != nil {
+						o.err = fmt.Errorf("panic recovered in %s tool handler: %v", req.Params.Name, r)
+					}
+					if rl != nil {
+						if key, ok := ctx.Value(clientKeyContextKey{}).(string); ok {
+							rl.charge(key, durationCost(time.Since(start)))
+						}
+					}
+					done <- o
+				}()
+				o.result, o.err = next(callCtx, req)
+			}()
+
+			select {
+			case o := <-done:
+				// A backend that gave up at the deadline reports it as an
+				// error of its own; report the timeout instead.
+				if (o.err != nil || (o.result != nil && o.result.IsError)) && callCtx.Err() != nil {
+					return budgetResult(callCtx, req.Params.Name, budget, capped, start)
+				}
+				return o.result, o.err
+			case <-callCtx.Done():
+				return budgetResult(callCtx, req.Params.Name, budget, capped, start)
+			}
+		}
+	}
+}
+
+// budgetResult is the result of a tool call stopped by ctx: a timeout,
+// with its budget and how long it ran, or a cancellation.
+func budgetResult(ctx context.Context, tool string, budget time.Duration, capped bool, start time.Time) (*mcpproto.CallToolResult, error) {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errResult("request canceled"), nil
+	}
+	blob, err := json.Marshal(map[string]any{
+		"error":     "timeout",
+		"tool":      tool,
+		"budgetMs":  budget.Milliseconds(),
+		"elapsedMs": time.Since(start).Milliseconds(),
+		"capped":    capped,
+	})
+	if err != nil {
+		return errResult(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return &mcpproto.CallToolResult{
+		Content: []mcpproto.Content{
+			mcpproto.TextContent{Type: "text", Text: fmt.Sprintf("Error: %s timed out after %dms", tool, budget.Milliseconds())},
+			mcpproto.TextContent{Type: "text", Text: string(blob)},
+		},
+		IsError: true,
+	}, nil
+}
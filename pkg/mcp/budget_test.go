@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowBackend searches for delay, or until its context ends, reporting how
+// the search ended on stopped.
+type slowBackend struct {
+	Backend
+	delay   time.Duration
+	stopped chan error
+}
+
+func (b *slowBackend) Search(ctx context.Context, _, _ string, _, _ int, _ map[string]string, _ bool, _ []string, _ string, _ Anchor) (map[string]any, error) {
+	select {
+	case <-time.After(b.delay):
+		b.stopped <- nil
+		return map[string]any{"results": []any{}}, nil
+	case <-ctx.Done():
+		b.stopped <- ctx.Err()
+		return nil, ctx.Err()
+	}
+}
+
+// callSearch calls qubicdb_search with timeoutMS, when set, and returns the
+// tool result and how long the call took.
+func callSearch(t *testing.T, h http.Handler, timeoutMS any) (map[string]any, time.Duration) {
+	t.Helper()
+	args := map[string]any{"index_id": "i", "query": "q"}
+	if timeoutMS != nil {
+		args["timeout_ms"] = timeoutMS
+	}
+	body, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "id": 1, "method": "tools/call",
+		"params": map[string]any{"name": toolSearch, "arguments": args},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	rr := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(rr, req)
+	elapsed := time.Since(start)
+
+	var resp struct {
+		Result map[string]any `json:"result"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp.Result == nil {
+		t.Fatalf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+	return resp.Result, elapsed
+}
+
+// timeoutOf decodes the structured timeout of a tool result.
+func timeoutOf(t *testing.T, result map[string]any) map[string]any {
+	t.Helper()
+	content := result["content"].([]any)
+	if result["isError"] != true || len(content) != 2 {
+		t.Fatalf("expected a two-part error result, got %v", result)
+	}
+	var timeout map[string]any
+	if err := json.Unmarshal([]byte(content[1].(map[string]any)["text"].(string)), &timeout); err != nil {
+		t.Fatalf("expected a JSON timeout, got %v", content[1])
+	}
+	return timeout
+}
+
+func TestToolBudget(t *testing.T) {
+	backend := &slowBackend{delay: 5 * time.Second, stopped: make(chan error, 4)}
+	h, err := NewHandler(Config{Stateless: true, MaxToolDuration: 300 * time.Millisecond}, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The argument's deadline reaches the backend, and the call returns at it.
+	result, elapsed := callSearch(t, h, 50)
+	if elapsed > 250*time.Millisecond {
+		t.Errorf("expected the call to stop near 50ms, took %s", elapsed)
+	}
+	if err := <-backend.stopped; err != context.DeadlineExceeded {
+		t.Errorf("expected the backend canceled at the deadline, got %v", err)
+	}
+	timeout := timeoutOf(t, result)
+	if timeout["error"] != "timeout" || timeout["tool"] != toolSearch || timeout["budgetMs"] != 50.0 || timeout["capped"] != false {
+		t.Errorf("unexpected timeout %v", timeout)
+	}
+	if ms, ok := timeout["elapsedMs"].(float64); !ok || ms < 50 {
+		t.Errorf("expected elapsedMs of at least 50, got %v", timeout["elapsedMs"])
+	}
+
+	// An hour is cut down to the server's cap, which also applies when the
+	// call sets no budget.
+	for _, requested := range []any{3600000, nil} {
+		result, elapsed := callSearch(t, h, requested)
+		if elapsed > time.Second {
+			t.Errorf("%v: expected the call to stop near 300ms, took %s", requested, elapsed)
+		}
+		<-backend.stopped
+		timeout := timeoutOf(t, result)
+		if timeout["budgetMs"] != 300.0 || timeout["capped"] != (requested != nil) {
+			t.Errorf("%v: unexpected timeout %v", requested, timeout)
+		}
+	}
+
+	// A call within its budget is unaffected.
+	backend.delay = 10 * time.Millisecond
+	if result, _ := callSearch(t, h, 200); result["isError"] == true {
+		t.Errorf("expected the search to succeed, got %v", result)
+	}
+	<-backend.stopped
+
+	for _, bad := range []any{0, -5} {
+		result, _ := callSearch(t, h, bad)
+		if text := fmt.Sprint(result["content"]); result["isError"] != true || !strings.Contains(text, "timeout_ms") {
+			t.Errorf("%v: expected timeout_ms rejected, got %v", bad, result)
+		}
+	}
+}
+
+func TestRateLimiter_ChargesLongCalls(t *testing.T) {
+	rl := newRateLimiter(0.001, 10)
+	if !rl.allow("a") || !rl.allow("b") {
+		t.Fatal("expected the first requests allowed")
+	}
+	rl.charge("a", durationCost(20*time.Second))
+	rl.charge("b", durationCost(200*time.Millisecond))
+	if rl.allow("a") {
+		t.Error("expected a client charged for a 20s call to be out of tokens")
+	}
+	if !rl.allow("b") {
+		t.Error("expected a short call to cost no more than its request")
+	}
+}
@@ -13,6 +13,8 @@ import (
 
 	mcpproto "github.com/mark3labs/mcp-go/mcp"
 	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"github.com/qubicDB/qubicdb/pkg/auth"
 )
 
 const (
@@ -22,6 +24,8 @@ const (
 	toolRecall             = "qubicdb_recall"
 	toolContext            = "qubicdb_context"
 	toolRegistryFindCreate = "qubicdb_registry_find_or_create"
+	toolFocusSet           = "qubicdb_focus_set"
+	toolFocusClear         = "qubicdb_focus_clear"
 
 	// Cross-index / Global tools
 	toolListIndexes   = "qubicdb_list_indexes"
@@ -30,6 +34,10 @@ const (
 	toolRecentIndexes = "qubicdb_recent_indexes"
 )
 
+// rolesDescription documents the roles argument shared by search, recall and
+// context.
+const rolesDescription = "Optional JSON array of authoring roles to include, matched against the reserved \"role\" metadata key (e.g. [\"user\"]). Defaults to the index's rolesFilter, if any."
+
 // Config controls MCP route behavior.
 type Config struct {
 	APIKey         string
@@ -38,16 +46,30 @@ type Config struct {
 	RateLimitBurst int
 	EnablePrompts  bool
 	AllowedTools   []string
+
+	// MaxToolDuration caps every tool call's time budget and applies when
+	// the call sets none; 0 caps nothing.
+	MaxToolDuration time.Duration
+}
+
+// Anchor asks a search to rank memories by their relatedness to an existing
+// one. The zero value is no anchor.
+type Anchor struct {
+	ID      string
+	Weight  *float64 // nil for the server's search.anchorWeight
+	Include bool     // return the anchor itself too
 }
 
 // Backend is the minimal capability contract exposed to MCP tools.
 type Backend interface {
 	Write(ctx context.Context, indexID, content string, metadata map[string]string) (map[string]any, error)
 	Read(ctx context.Context, indexID, neuronID string) (map[string]any, error)
-	Search(ctx context.Context, indexID, query string, depth, limit int, metadata map[string]string, strict bool) (map[string]any, error)
-	Recall(ctx context.Context, indexID string, limit int) (map[string]any, error)
-	Context(ctx context.Context, indexID, cue string, depth, maxTokens int) (map[string]any, error)
+	Search(ctx context.Context, indexID, query string, depth, limit int, metadata map[string]string, strict bool, roles []string, mode string, anchor Anchor) (map[string]any, error)
+	Recall(ctx context.Context, indexID string, limit int, roles []string) (map[string]any, error)
+	Context(ctx context.Context, indexID, cue string, depth, maxTokens int, roles []string, format string) (map[string]any, error)
 	RegistryFindOrCreate(ctx context.Context, uuid string, metadata map[string]any) (map[string]any, error)
+	SetFocus(ctx context.Context, indexID string, metadata map[string]string, boost *float64, ttl string) (map[string]any, error)
+	ClearFocus(ctx context.Context, indexID string) (map[string]any, error)
 
 	// Cross-index / Global operations
 	ListIndexes(ctx context.Context, activeOnly bool, limit int) (map[string]any, error)
@@ -63,12 +85,18 @@ func NewHandler(cfg Config, backend Backend) (http.Handler, error) {
 		return nil, fmt.Errorf("mcp backend is required")
 	}
 
+	var rl *rateLimiter
+	if cfg.RateLimitRPS > 0 && cfg.RateLimitBurst > 0 {
+		rl = newRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	}
+
 	s := mcpserver.NewMCPServer(
 		"qubicdb-mcp",
 		"1.0.0",
 		mcpserver.WithToolCapabilities(true),
 		mcpserver.WithPromptCapabilities(cfg.EnablePrompts),
 		mcpserver.WithRecovery(),
+		mcpserver.WithToolHandlerMiddleware(budgetMiddleware(cfg.MaxToolDuration, rl)),
 	)
 
 	registerTools(s, backend, cfg.AllowedTools)
@@ -82,8 +110,8 @@ func NewHandler(cfg Config, backend Backend) (http.Handler, error) {
 	if strings.TrimSpace(cfg.APIKey) != "" {
 		h = apiKeyMiddleware(strings.TrimSpace(cfg.APIKey), h)
 	}
-	if cfg.RateLimitRPS > 0 && cfg.RateLimitBurst > 0 {
-		h = rateLimitMiddleware(newRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst), h)
+	if rl != nil {
+		h = rateLimitMiddleware(rl, h)
 	}
 
 	return h, nil
@@ -111,6 +139,7 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithString("index_id", mcpproto.Required(), mcpproto.Description("QubicDB index id (X-Index-ID equivalent).")),
 			mcpproto.WithString("content", mcpproto.Required(), mcpproto.Description("Memory content to persist.")),
 			mcpproto.WithString("metadata", mcpproto.Description("Optional JSON object of string key-value metadata (e.g. {\"thread_id\":\"conv-1\",\"role\":\"user\"}).")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			indexID := getString(args, "index_id", "")
@@ -140,6 +169,7 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithDescription("Read one memory by neuron id from QubicDB."),
 			mcpproto.WithString("index_id", mcpproto.Required(), mcpproto.Description("QubicDB index id.")),
 			mcpproto.WithString("id", mcpproto.Required(), mcpproto.Description("Neuron id.")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			indexID := getString(args, "index_id", "")
@@ -157,19 +187,29 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 
 	if isAllowed(toolSearch) {
 		s.AddTool(mcpproto.NewTool(toolSearch,
-			mcpproto.WithDescription("Search memories in QubicDB with optional metadata boost or strict filter."),
+			mcpproto.WithDescription("Search memories in QubicDB with optional metadata boost or strict filter, or find memories related to a given one with anchor_id."),
 			mcpproto.WithString("index_id", mcpproto.Required(), mcpproto.Description("QubicDB index id.")),
-			mcpproto.WithString("query", mcpproto.Required(), mcpproto.Description("Search query.")),
+			mcpproto.WithString("query", mcpproto.Description("Search query. Required unless anchor_id is set.")),
 			mcpproto.WithNumber("depth", mcpproto.Description("Search depth (optional, default 2).")),
 			mcpproto.WithNumber("limit", mcpproto.Description("Result limit (optional, default 20).")),
 			mcpproto.WithString("metadata", mcpproto.Description("Optional JSON object of string key-value metadata to filter/boost (e.g. {\"thread_id\":\"conv-1\"}).")),
 			mcpproto.WithBoolean("strict", mcpproto.Description("If true, only return neurons matching ALL metadata keys. Default false (soft boost).")),
+			mcpproto.WithString("roles", mcpproto.Description(rolesDescription)),
+			mcpproto.WithString("mode", mcpproto.Description("\"exact\" returns only direct matches: no spread activation (unlike depth 1, which still adds direct neighbours) and no metadata boost, stopping at the first limit hits. Omit for the default associative search.")),
+			mcpproto.WithString("anchor_id", mcpproto.Description("Neuron ID to rank memories by: synaptic proximity (up to 3 hops), shared metadata and embedding similarity to it, blended with the query score when a query is given.")),
+			mcpproto.WithNumber("anchor_weight", mcpproto.Description("Share of the score from relatedness to the anchor, 0-1 (optional, server default 0.5).")),
+			mcpproto.WithBoolean("include_anchor", mcpproto.Description("Also return the anchor itself. Default false.")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			indexID := getString(args, "index_id", "")
 			query := getString(args, "query", "")
-			if indexID == "" || strings.TrimSpace(query) == "" {
-				return errResult("index_id and query are required"), nil
+			anchor := Anchor{ID: getString(args, "anchor_id", ""), Include: getBool(args, "include_anchor", false)}
+			if indexID == "" || (strings.TrimSpace(query) == "" && anchor.ID == "") {
+				return errResult("index_id and query (or anchor_id) are required"), nil
+			}
+			if w, ok := args["anchor_weight"].(float64); ok {
+				anchor.Weight = &w
 			}
 			depth := getInt(args, "depth", 2)
 			limit := getInt(args, "limit", 20)
@@ -180,7 +220,12 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 				}
 			}
 			strict := getBool(args, "strict", false)
-			result, err := backend.Search(ctx, indexID, query, depth, limit, metadata, strict)
+			roles, err := getStringList(args, "roles")
+			if err != nil {
+				return errResult("roles must be a valid JSON array of strings"), nil
+			}
+			mode := getString(args, "mode", "")
+			result, err := backend.Search(ctx, indexID, query, depth, limit, metadata, strict, roles, mode, anchor)
 			if err != nil {
 				return errResult(err.Error()), nil
 			}
@@ -193,6 +238,8 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithDescription("Recall recent memories from a QubicDB index."),
 			mcpproto.WithString("index_id", mcpproto.Required(), mcpproto.Description("QubicDB index id.")),
 			mcpproto.WithNumber("limit", mcpproto.Description("Max memories to return (optional).")),
+			mcpproto.WithString("roles", mcpproto.Description(rolesDescription)),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			indexID := getString(args, "index_id", "")
@@ -200,7 +247,11 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 				return errResult("index_id is required"), nil
 			}
 			limit := getInt(args, "limit", 100)
-			result, err := backend.Recall(ctx, indexID, limit)
+			roles, err := getStringList(args, "roles")
+			if err != nil {
+				return errResult("roles must be a valid JSON array of strings"), nil
+			}
+			result, err := backend.Recall(ctx, indexID, limit, roles)
 			if err != nil {
 				return errResult(err.Error()), nil
 			}
@@ -215,6 +266,9 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithString("cue", mcpproto.Required(), mcpproto.Description("Current user cue/query.")),
 			mcpproto.WithNumber("depth", mcpproto.Description("Search depth used during context assembly (optional).")),
 			mcpproto.WithNumber("max_tokens", mcpproto.Description("Token budget for assembled context (optional).")),
+			mcpproto.WithString("roles", mcpproto.Description(rolesDescription)),
+			mcpproto.WithString("format", mcpproto.Enum("blocks", "messages", "text"), mcpproto.Description("Output format: \"blocks\" wraps each memory in context.blockTemplate markers with its metadata as attributes, \"messages\" returns one system message per memory, \"text\" joins memories with \"---\" (default: blocks).")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			indexID := getString(args, "index_id", "")
@@ -224,7 +278,12 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			}
 			depth := getInt(args, "depth", 2)
 			maxTokens := getInt(args, "max_tokens", 2000)
-			result, err := backend.Context(ctx, indexID, cue, depth, maxTokens)
+			roles, err := getStringList(args, "roles")
+			if err != nil {
+				return errResult("roles must be a valid JSON array of strings"), nil
+			}
+			format := getString(args, "format", "blocks")
+			result, err := backend.Context(ctx, indexID, cue, depth, maxTokens, roles, format)
 			if err != nil {
 				return errResult(err.Error()), nil
 			}
@@ -236,6 +295,7 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 		s.AddTool(mcpproto.NewTool(toolRegistryFindCreate,
 			mcpproto.WithDescription("Find or create a UUID registry entry for client access."),
 			mcpproto.WithString("uuid", mcpproto.Required(), mcpproto.Description("UUID to find or create.")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			uuid := getString(args, "uuid", "")
@@ -250,6 +310,56 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 		})
 	}
 
+	if isAllowed(toolFocusSet) {
+		s.AddTool(mcpproto.NewTool(toolFocusSet,
+			mcpproto.WithDescription("Focus an index on a metadata scope for a while: every search, context and recall of the index then boosts memories matching it, without repeating the metadata. Replaces any earlier focus; it expires after ttl."),
+			mcpproto.WithString("index_id", mcpproto.Required(), mcpproto.Description("QubicDB index id.")),
+			mcpproto.WithString("metadata", mcpproto.Required(), mcpproto.Description("JSON object of string key-value metadata to focus on (e.g. {\"project\":\"apollo\"}).")),
+			mcpproto.WithNumber("boost", mcpproto.Description("Score boost per matching key (optional, default 0.3, i.e. +30%).")),
+			mcpproto.WithString("ttl", mcpproto.Required(), mcpproto.Description("How long the focus lasts, as a duration such as \"2h\" or \"30m\" (at most 24h).")),
+			timeoutArg(),
+		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
+			args := req.GetArguments()
+			indexID := getString(args, "index_id", "")
+			ttl := getString(args, "ttl", "")
+			if indexID == "" || ttl == "" {
+				return errResult("index_id, metadata and ttl are required"), nil
+			}
+			var metadata map[string]string
+			if err := json.Unmarshal([]byte(getString(args, "metadata", "")), &metadata); err != nil {
+				return errResult("metadata must be a valid JSON object of string values"), nil
+			}
+			var boost *float64
+			if b, ok := args["boost"].(float64); ok {
+				boost = &b
+			}
+			result, err := backend.SetFocus(ctx, indexID, metadata, boost, ttl)
+			if err != nil {
+				return errResult(err.Error()), nil
+			}
+			return structuredResult("focus set", result)
+		})
+	}
+
+	if isAllowed(toolFocusClear) {
+		s.AddTool(mcpproto.NewTool(toolFocusClear,
+			mcpproto.WithDescription("Clear the focus of an index, so retrievals rank as requested again."),
+			mcpproto.WithString("index_id", mcpproto.Required(), mcpproto.Description("QubicDB index id.")),
+			timeoutArg(),
+		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
+			args := req.GetArguments()
+			indexID := getString(args, "index_id", "")
+			if indexID == "" {
+				return errResult("index_id is required"), nil
+			}
+			result, err := backend.ClearFocus(ctx, indexID)
+			if err != nil {
+				return errResult(err.Error()), nil
+			}
+			return structuredResult("focus cleared", result)
+		})
+	}
+
 	// ── Cross-index / Global tools ──────────────────────────────────────────
 
 	if isAllowed(toolListIndexes) {
@@ -257,6 +367,7 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithDescription("List all registered indexes with their metadata and stats. Use active_only=true to filter to currently loaded indexes."),
 			mcpproto.WithBoolean("active_only", mcpproto.Description("If true, only return currently active/loaded indexes (default: false, returns all registered).")),
 			mcpproto.WithNumber("limit", mcpproto.Description("Maximum number of indexes to return (default: 100).")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			activeOnly := getBool(args, "active_only", false)
@@ -276,6 +387,7 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithNumber("depth", mcpproto.Description("Search depth for spreading activation (default: 2).")),
 			mcpproto.WithNumber("limit", mcpproto.Description("Max results per index (default: 10).")),
 			mcpproto.WithString("metadata", mcpproto.Description("Optional JSON metadata filter (e.g. {\"type\":\"decision\"}).")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			query := getString(args, "query", "")
@@ -306,6 +418,7 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithNumber("depth", mcpproto.Description("Search depth (default: 2).")),
 			mcpproto.WithNumber("limit", mcpproto.Description("Max results per index (default: 10).")),
 			mcpproto.WithString("metadata", mcpproto.Description("Optional JSON metadata filter.")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			indexIDsRaw := getString(args, "index_ids", "")
@@ -341,6 +454,7 @@ func registerTools(s *mcpserver.MCPServer, backend Backend, allowed []string) {
 			mcpproto.WithDescription("Get the most recently active indexes sorted by last operation time. Useful for discovering which brains have recent activity."),
 			mcpproto.WithNumber("limit", mcpproto.Description("Maximum number of indexes to return (default: 20).")),
 			mcpproto.WithNumber("min_neurons", mcpproto.Description("Only include indexes with at least this many neurons (default: 0).")),
+			timeoutArg(),
 		), func(ctx context.Context, req mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
 			args := req.GetArguments()
 			limit := getInt(args, "limit", 20)
@@ -417,6 +531,20 @@ func getString(args map[string]any, key string, def string) string {
 	return def
 }
 
+// getStringList decodes a JSON array of strings passed as a string argument.
+// A missing or empty argument yields nil.
+func getStringList(args map[string]any, key string) ([]string, error) {
+	raw := getString(args, key, "")
+	if raw == "" {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
 func getInt(args map[string]any, key string, def int) int {
 	if args == nil {
 		return def
@@ -441,22 +569,23 @@ func getBool(args map[string]any, key string, def bool) bool {
 	return def
 }
 
+// apiKeyMiddleware requires the API key as X-API-Key or as a bearer token,
+// compared in constant time.
 func apiKeyMiddleware(expected string, next http.Handler) http.Handler {
+	keys := auth.Static{auth.Plain("", expected)}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		provided := strings.TrimSpace(r.Header.Get("X-API-Key"))
-		if provided == "" {
-			auth := strings.TrimSpace(r.Header.Get("Authorization"))
-			if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
-				provided = strings.TrimSpace(auth[7:])
-			}
+		var err error
+		if provided := strings.TrimSpace(r.Header.Get("X-API-Key")); provided != "" {
+			_, err = auth.Verifier{Store: keys}.Password(r, "", provided)
+		} else {
+			_, err = auth.VerifyBearer(r, keys)
 		}
-
-		if provided == "" || provided != expected {
+		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			_, _ = w.Write([]byte("unauthorized"))
 			return
@@ -510,6 +639,20 @@ func (rl *rateLimiter) allow(key string) bool {
 	return true
 }
 
+// charge takes tokens more from key, as a long-running call costs, down to
+// a debt of one burst the client waits out before its next request.
+func (rl *rateLimiter) charge(key string, tokens float64) {
+	if tokens <= 0 {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if entry, ok := rl.clients[key]; ok {
+		entry.tokens = math.Max(-rl.burst, entry.tokens-tokens)
+		rl.clients[key] = entry
+	}
+}
+
 func rateLimitMiddleware(rl *rateLimiter, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		key := clientAddr(r)
@@ -519,7 +662,7 @@ func rateLimitMiddleware(rl *rateLimiter, next http.Handler) http.Handler {
 			_, _ = w.Write([]byte("rate limit exceeded"))
 			return
 		}
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKeyContextKey{}, key)))
 	})
 }
 
@@ -0,0 +1,295 @@
+package persistence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Attachment blob errors.
+var (
+	ErrBlobNotFound = errors.New("attachment not found")
+	ErrBlobTooLarge = errors.New("attachment too large")
+)
+
+const (
+	blobInfoSuffix   = ".json"
+	blobUploadPrefix = ".upload-"
+)
+
+// BlobInfo describes a stored attachment blob. It is kept next to the blob
+// as <hash>.json.
+type BlobInfo struct {
+	Hash        string    `json:"hash"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType,omitempty"`
+	Caption     string    `json:"caption,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BlobSweepReport is the outcome of BlobStore.Sweep.
+type BlobSweepReport struct {
+	Blobs      int   `json:"blobs"`      // blobs found
+	Referenced int   `json:"referenced"` // kept because a neuron references them
+	Recent     int   `json:"recent"`     // kept for the grace period
+	Removed    int   `json:"removed"`
+	BytesFreed int64 `json:"bytesFreed"`
+}
+
+// BlobStore keeps attachment blobs under data/blobs/, named by the hex
+// SHA-256 of their bytes, so uploading the same bytes twice stores them
+// once. Blobs are not owned by an index: a sweep removes those that no
+// neuron references any more.
+type BlobStore struct {
+	// root is the blob directory; a storage migration switches it.
+	root atomic.Pointer[string]
+	// mu orders uploads against the sweep, so a blob an upload has just
+	// deduplicated against is not removed under it.
+	mu sync.Mutex
+}
+
+// NewBlobStore returns the blob store under dir, creating dir.
+func NewBlobStore(dir string) (*BlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob path: %w", err)
+	}
+	b := &BlobStore{}
+	b.root.Store(&dir)
+	return b, nil
+}
+
+// dir returns the blob directory.
+func (b *BlobStore) dir() string {
+	return *b.root.Load()
+}
+
+// Blobs returns the store's attachment blobs.
+func (s *Store) Blobs() *BlobStore {
+	return s.blobs
+}
+
+// ValidBlobHash reports whether hash is a lowercase hex SHA-256, the only
+// names blobs are stored under.
+func ValidBlobHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BlobStore) path(hash string) string     { return filepath.Join(b.dir(), hash) }
+func (b *BlobStore) infoPath(hash string) string { return filepath.Join(b.dir(), hash+blobInfoSuffix) }
+
+// Put stores the bytes read from r, refusing more than max with
+// ErrBlobTooLarge. created is false when the blob was already stored; its
+// caption is then set only if it had none.
+func (b *BlobStore) Put(r io.Reader, max int64, contentType, caption string) (info BlobInfo, created bool, err error) {
+	tmp, err := os.CreateTemp(b.dir(), blobUploadPrefix+"*")
+	if err != nil {
+		return info, false, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name()) // a no-op once renamed
+	}()
+
+	sum := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, sum), io.LimitReader(r, max+1))
+	if err != nil {
+		return info, false, err
+	}
+	if n > max {
+		return info, false, ErrBlobTooLarge
+	}
+	if err := tmp.Sync(); err != nil {
+		return info, false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return info, false, err
+	}
+	hash := hex.EncodeToString(sum.Sum(nil))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if existing, err := b.stat(hash); err == nil {
+		if existing.Caption == "" && caption != "" {
+			existing.Caption = caption
+			if err := b.writeInfo(existing); err != nil {
+				return info, false, err
+			}
+		}
+		return existing, false, b.touchLocked(hash)
+	}
+	info = BlobInfo{Hash: hash, Size: n, ContentType: contentType, Caption: caption, CreatedAt: time.Now().UTC()}
+	if err := b.writeInfo(info); err != nil {
+		return info, false, err
+	}
+	if err := os.Rename(tmp.Name(), b.path(hash)); err != nil {
+		os.Remove(b.infoPath(hash))
+		return info, false, err
+	}
+	return info, true, nil
+}
+
+// PutVerified stores data, as read from an export, under hash. It fails if
+// the bytes do not hash to it; a blob already stored is left as it is.
+func (b *BlobStore) PutVerified(info BlobInfo, data []byte) error {
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != info.Hash {
+		return fmt.Errorf("attachment %s: content does not match its hash", info.Hash)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.stat(info.Hash); err == nil {
+		return b.touchLocked(info.Hash)
+	}
+	info.Size = int64(len(data))
+	if info.CreatedAt.IsZero() {
+		info.CreatedAt = time.Now().UTC()
+	}
+	if err := b.writeInfo(info); err != nil {
+		return err
+	}
+	tmp := b.path(info.Hash) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path(info.Hash))
+}
+
+// Stat returns the description of the blob stored under hash.
+func (b *BlobStore) Stat(hash string) (BlobInfo, error) {
+	if !ValidBlobHash(hash) {
+		return BlobInfo{}, ErrBlobNotFound
+	}
+	return b.stat(hash)
+}
+
+func (b *BlobStore) stat(hash string) (BlobInfo, error) {
+	if _, err := os.Stat(b.path(hash)); err != nil {
+		if os.IsNotExist(err) {
+			return BlobInfo{}, ErrBlobNotFound
+		}
+		return BlobInfo{}, err
+	}
+	info := BlobInfo{Hash: hash}
+	data, err := os.ReadFile(b.infoPath(hash))
+	if err == nil {
+		err = json.Unmarshal(data, &info)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return BlobInfo{}, err
+	}
+	return info, nil
+}
+
+// Open opens the blob stored under hash for reading.
+func (b *BlobStore) Open(hash string) (*os.File, BlobInfo, error) {
+	info, err := b.Stat(hash)
+	if err != nil {
+		return nil, info, err
+	}
+	f, err := os.Open(b.path(hash))
+	if os.IsNotExist(err) {
+		err = ErrBlobNotFound
+	}
+	return f, info, err
+}
+
+// Touch marks the blob under hash as in use now, which keeps it through
+// the sweep's grace period. Writes touch the blobs they reference, since
+// the sweep may have counted references before the write.
+func (b *BlobStore) Touch(hash string) error {
+	if !ValidBlobHash(hash) {
+		return ErrBlobNotFound
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.touchLocked(hash)
+}
+
+func (b *BlobStore) touchLocked(hash string) error {
+	now := time.Now()
+	err := os.Chtimes(b.path(hash), now, now)
+	if os.IsNotExist(err) {
+		return ErrBlobNotFound
+	}
+	return err
+}
+
+func (b *BlobStore) writeInfo(info BlobInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	tmp := b.infoPath(info.Hash) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.infoPath(info.Hash))
+}
+
+// Sweep removes the blobs refs does not count that were neither stored nor
+// touched within grace, with their descriptions. Abandoned uploads older
+// than grace are removed too.
+func (b *BlobStore) Sweep(refs map[string]int, grace time.Duration, now time.Time) (BlobSweepReport, error) {
+	var report BlobSweepReport
+	entries, err := os.ReadDir(b.dir())
+	if err != nil {
+		return report, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, blobUploadPrefix) || strings.HasSuffix(name, ".tmp") {
+			if fi, err := entry.Info(); err == nil && now.Sub(fi.ModTime()) > grace {
+				os.Remove(filepath.Join(b.dir(), name))
+			}
+			continue
+		}
+		if !ValidBlobHash(name) {
+			continue
+		}
+		report.Blobs++
+		if refs[name] > 0 {
+			report.Referenced++
+			continue
+		}
+		fi, err := os.Stat(b.path(name))
+		if err != nil {
+			continue // removed meanwhile
+		}
+		if now.Sub(fi.ModTime()) <= grace {
+			report.Recent++
+			continue
+		}
+		if err := os.Remove(b.path(name)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			continue
+		}
+		os.Remove(b.infoPath(name))
+		report.Removed++
+		report.BytesFreed += fi.Size()
+	}
+	return report, errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package persistence
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlobStorePutDeduplicates(t *testing.T) {
+	b, err := NewBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, created, err := b.Put(strings.NewReader("scan of the contract"), 1024, "image/png", "")
+	if err != nil || !created {
+		t.Fatalf("first put: created=%v err=%v", created, err)
+	}
+	if !ValidBlobHash(first.Hash) || first.Size != 20 {
+		t.Fatalf("unexpected blob %+v", first)
+	}
+	again, created, err := b.Put(strings.NewReader("scan of the contract"), 1024, "image/png", "signed contract")
+	if err != nil || created || again.Hash != first.Hash {
+		t.Fatalf("the same bytes should be stored once: created=%v err=%v %+v", created, err, again)
+	}
+	if again.Caption != "signed contract" {
+		t.Errorf("a later upload should fill in a missing caption, got %q", again.Caption)
+	}
+
+	f, info, err := b.Open(first.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "scan of the contract" || info.ContentType != "image/png" {
+		t.Errorf("unexpected blob read back: %q %+v", data, info)
+	}
+
+	if _, _, err := b.Put(strings.NewReader(strings.Repeat("x", 1025)), 1024, "", ""); !errors.Is(err, ErrBlobTooLarge) {
+		t.Errorf("expected ErrBlobTooLarge, got %v", err)
+	}
+	if _, err := b.Stat("../VERSION"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("a name that is not a hash should not be found, got %v", err)
+	}
+	if err := b.PutVerified(BlobInfo{Hash: first.Hash}, []byte("something else")); err == nil {
+		t.Error("bytes that do not match the hash should be refused")
+	}
+}
+
+func TestBlobStoreSweep(t *testing.T) {
+	b, err := NewBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, _, _ := b.Put(strings.NewReader("referenced"), 1024, "", "")
+	orphan, _, _ := b.Put(strings.NewReader("orphan"), 1024, "", "")
+	refs := map[string]int{kept.Hash: 1}
+
+	report, err := b.Sweep(refs, time.Hour, time.Now())
+	if err != nil || report.Removed != 0 || report.Recent != 1 || report.Referenced != 1 {
+		t.Fatalf("a fresh blob should be kept for the grace period: %+v %v", report, err)
+	}
+	report, err = b.Sweep(refs, time.Hour, time.Now().Add(2*time.Hour))
+	if err != nil || report.Removed != 1 || report.BytesFreed != orphan.Size {
+		t.Fatalf("the orphan should be removed after the grace period: %+v %v", report, err)
+	}
+	if _, err := b.Stat(orphan.Hash); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("orphan still stored: %v", err)
+	}
+	if _, err := b.Stat(kept.Hash); err != nil {
+		t.Errorf("referenced blob removed: %v", err)
+	}
+}
@@ -5,18 +5,25 @@ import (
 	"compress/gzip"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
-// Binary format constants
+// Binary format constants. Version 2 added synapse types; synapses read
+// from version 1 files are associative.
 const (
 	MagicBytes    = "NRDB" // QubicDB magic identifier
-	FormatVersion = 1
+	FormatVersion = 2
 )
 
+// ErrFormatTooNew is returned by Decode for a file written by a newer
+// server in a format this one cannot read. Such a file is not corrupt and
+// must not be repaired away.
+var ErrFormatTooNew = errors.New("data file format is newer than this server supports")
+
 // Header for binary format
 type Header struct {
 	Magic      [4]byte
@@ -46,14 +53,33 @@ func NewCodec(compress bool) *Codec {
 	}
 }
 
-// Encode serializes a matrix to binary format
+// Encode serializes a matrix to binary format. It takes the matrix read
+// lock and encodes a Matrix.SnapshotLocked, so the caller must not hold
+// the matrix lock.
 func (c *Codec) Encode(matrix *core.Matrix) ([]byte, error) {
+	data, _, err := c.encodeSnapshot(matrix)
+	return data, err
+}
+
+// encodeSnapshot is Encode, also returning the snapshot it encoded. Its
+// neurons and synapses are copies the caller may read without the matrix
+// lock; its other maps and slices are not.
+func (c *Codec) encodeSnapshot(matrix *core.Matrix) ([]byte, *core.Matrix, error) {
 	// First, encode with msgpack
-	data, err := msgpack.Marshal(matrix)
+	matrix.RLock()
+	snapshot := matrix.SnapshotLocked()
+	data, err := msgpack.Marshal(snapshot)
+	matrix.RUnlock()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	data, err = c.frame(matrix.IndexID, data)
+	return data, snapshot, err
+}
 
+// frame optionally compresses msgpack data and prefixes it with the file
+// header and indexID.
+func (c *Codec) frame(indexID core.IndexID, data []byte) ([]byte, error) {
 	// Optionally compress
 	var flags uint16 = 0
 	if c.compress {
@@ -71,7 +97,7 @@ func (c *Codec) Encode(matrix *core.Matrix) ([]byte, error) {
 	header := Header{
 		Version:    FormatVersion,
 		Flags:      flags,
-		IndexIDLen: uint32(len(matrix.IndexID)),
+		IndexIDLen: uint32(len(indexID)),
 		DataLen:    uint64(len(data)),
 		Checksum:   c.checksum(data),
 	}
@@ -86,7 +112,7 @@ func (c *Codec) Encode(matrix *core.Matrix) ([]byte, error) {
 	}
 
 	// Write indexID
-	if _, err := buf.WriteString(string(matrix.IndexID)); err != nil {
+	if _, err := buf.WriteString(string(indexID)); err != nil {
 		return nil, err
 	}
 
@@ -119,7 +145,13 @@ func (c *Codec) Decode(raw []byte) (*core.Matrix, error) {
 
 	// Check version
 	if header.Version > FormatVersion {
-		return nil, errors.New("unsupported format version")
+		return nil, fmt.Errorf("%w (file version %d, supported %d)", ErrFormatTooNew, header.Version, FormatVersion)
+	}
+
+	// The lengths must fit what follows; an export being imported is not
+	// trusted to be well formed.
+	if uint64(header.IndexIDLen)+header.DataLen > uint64(buf.Len()) {
+		return nil, errors.New("data too short")
 	}
 
 	// Read indexID
@@ -153,6 +185,9 @@ func (c *Codec) Decode(raw []byte) (*core.Matrix, error) {
 	if err := msgpack.Unmarshal(data, &matrix); err != nil {
 		return nil, err
 	}
+	if header.Version < 2 {
+		matrix.DefaultSynapseTypes()
+	}
 
 	return &matrix, nil
 }
@@ -197,6 +232,24 @@ func (c *Codec) checksum(data []byte) uint32 {
 	return sum
 }
 
+// CopyMatrix returns a deep copy of matrix, sharing no neurons, synapses
+// or maps with it. Only persisted fields are copied. Like Encode, it takes
+// the matrix read lock and copies through Matrix.SnapshotLocked; the
+// caller must not hold the matrix lock.
+func CopyMatrix(matrix *core.Matrix) (*core.Matrix, error) {
+	matrix.RLock()
+	data, err := msgpack.Marshal(matrix.SnapshotLocked())
+	matrix.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var clone core.Matrix
+	if err := msgpack.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
 // EncodeSnapshot creates a lightweight snapshot for quick persistence
 type Snapshot struct {
 	IndexID      core.IndexID `msgpack:"index_id"`
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"encoding/binary"
 	"testing"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
@@ -85,6 +86,15 @@ func TestCodecInvalidData(t *testing.T) {
 	if err == nil {
 		t.Error("Should fail on invalid magic bytes")
 	}
+
+	// Truncated: the header claims more data than follows
+	data, err := codec.Encode(core.NewMatrix("test-user", core.DefaultBounds()))
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if _, err := codec.Decode(data[:len(data)-1]); err == nil {
+		t.Error("Should fail on truncated data")
+	}
 }
 
 func TestCreateSnapshot(t *testing.T) {
@@ -164,3 +174,41 @@ func TestCodecWithLargeMatrix(t *testing.T) {
 		t.Errorf("Expected 100 neurons, got %d", len(decoded.Neurons))
 	}
 }
+
+func TestCodecSynapseTypes(t *testing.T) {
+	codec := NewCodec(false)
+
+	m := core.NewMatrix("test-user", core.DefaultBounds())
+	a := core.NewNeuron("parent", m.CurrentDim)
+	b := core.NewNeuron("child", m.CurrentDim)
+	m.Neurons[a.ID], m.Neurons[b.ID] = a, b
+	syn := core.NewTypedSynapse(a.ID, b.ID, 0.5, core.SynapseFollows)
+	m.Synapses[syn.ID] = syn
+
+	data, err := codec.Encode(m)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, err := codec.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got := decoded.Synapses[syn.ID].Type; got != core.SynapseFollows {
+		t.Errorf("expected the type to round-trip, got %q", got)
+	}
+
+	// A version 1 file predates synapse types.
+	syn.Type = ""
+	data, err = codec.Encode(m)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	binary.LittleEndian.PutUint16(data[4:6], 1)
+	decoded, err = codec.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got := decoded.Synapses[syn.ID].Type; got != core.SynapseAssociative {
+		t.Errorf("expected a version 1 synapse to be associative, got %q", got)
+	}
+}
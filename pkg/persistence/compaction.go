@@ -0,0 +1,160 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	manifestPrefix   = "MANIFEST-"
+	manifestSuffix   = ".json"
+	checkpointPrefix = "checkpoint-"
+	checkpointSuffix = ".nrdb"
+)
+
+// CompactionReport summarizes a manifest history cleanup.
+type CompactionReport struct {
+	CurrentVersion     uint64
+	ManifestsRemoved   int
+	CheckpointsRemoved int
+}
+
+// CompactManifests trims the manifest and checkpoint history under basePath
+// to the retain most recent versions, counting the one CURRENT points to.
+// It is meant for offline use on deployments that accumulated history
+// before retention existed; a running Store prunes after every flush.
+//
+// Nothing is removed unless CURRENT resolves to a readable manifest and
+// checkpoint.
+func CompactManifests(basePath string, retain int) (CompactionReport, error) {
+	if retain < 1 {
+		return CompactionReport{}, fmt.Errorf("retain must be >= 1, got %d", retain)
+	}
+
+	current, err := readCurrentManifest(basePath)
+	if err != nil {
+		return CompactionReport{}, err
+	}
+
+	report := CompactionReport{CurrentVersion: current.Version}
+	report.ManifestsRemoved, report.CheckpointsRemoved, err = pruneManifestHistory(basePath, current.Version, retain)
+	return report, err
+}
+
+// readCurrentManifest loads the manifest CURRENT points to and checks that
+// its checkpoint exists.
+func readCurrentManifest(basePath string) (manifestEntry, error) {
+	var manifest manifestEntry
+
+	name, err := os.ReadFile(filepath.Join(basePath, "manifest", "CURRENT"))
+	if err != nil {
+		return manifest, fmt.Errorf("read CURRENT: %w", err)
+	}
+	manifestName := strings.TrimSpace(string(name))
+	if _, ok := historyVersion(manifestName, manifestPrefix, manifestSuffix); !ok {
+		return manifest, fmt.Errorf("CURRENT names an unexpected manifest %q", manifestName)
+	}
+
+	data, err := os.ReadFile(filepath.Join(basePath, "manifest", manifestName))
+	if err != nil {
+		return manifest, fmt.Errorf("read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("parse manifest %s: %w", manifestName, err)
+	}
+
+	checkpointPath := manifest.Checkpoint
+	if !filepath.IsAbs(checkpointPath) {
+		checkpointPath = filepath.Join(basePath, filepath.FromSlash(checkpointPath))
+	}
+	if _, err := os.Stat(checkpointPath); err != nil {
+		return manifest, fmt.Errorf("checkpoint for %s: %w", manifestName, err)
+	}
+	return manifest, nil
+}
+
+// pruneManifestHistory removes manifests and checkpoints more than retain
+// versions behind current. current itself and anything newer (e.g. a
+// checkpoint left by a flush that crashed before its manifest) are never
+// touched. Each manifest goes before its checkpoint, so a crash part-way
+// through never leaves a manifest pointing at a missing checkpoint; leftovers
+// are picked up by the next call.
+func pruneManifestHistory(basePath string, current uint64, retain int) (manifests, checkpoints int, err error) {
+	if retain < 1 || current < uint64(retain) {
+		return 0, 0, nil
+	}
+	oldestKept := current - uint64(retain) + 1
+
+	manifestDir := filepath.Join(basePath, "manifest")
+	checkpointDir := filepath.Join(basePath, "checkpoints")
+
+	stale := make(map[uint64]bool)
+	for _, dir := range []struct{ path, prefix, suffix string }{
+		{manifestDir, manifestPrefix, manifestSuffix},
+		{checkpointDir, checkpointPrefix, checkpointSuffix},
+	} {
+		entries, err := os.ReadDir(dir.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, 0, err
+		}
+		for _, e := range entries {
+			if v, ok := historyVersion(e.Name(), dir.prefix, dir.suffix); ok && v < oldestKept {
+				stale[v] = true
+			}
+		}
+	}
+
+	versions := make([]uint64, 0, len(stale))
+	for v := range stale {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, v := range versions {
+		removed, err := removeIfExists(filepath.Join(manifestDir, fmt.Sprintf("%s%020d%s", manifestPrefix, v, manifestSuffix)))
+		if err != nil {
+			return manifests, checkpoints, err
+		}
+		if removed {
+			manifests++
+		}
+		removed, err = removeIfExists(filepath.Join(checkpointDir, fmt.Sprintf("%s%020d%s", checkpointPrefix, v, checkpointSuffix)))
+		if err != nil {
+			return manifests, checkpoints, err
+		}
+		if removed {
+			checkpoints++
+		}
+	}
+	return manifests, checkpoints, nil
+}
+
+// historyVersion parses the version out of a manifest or checkpoint name.
+func historyVersion(name, prefix, suffix string) (uint64, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+func removeIfExists(path string) (bool, error) {
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
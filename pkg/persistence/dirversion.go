@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Data directory schema versions. SchemaVersion is the layout this server
+// writes; MinReaderVersion is the oldest schema a server must support to
+// open a directory this server has written. Bump SchemaVersion with every
+// on-disk change, and MinReaderVersion only when older servers could no
+// longer read the result safely.
+const (
+	SchemaVersion    = 1
+	MinReaderVersion = 1
+)
+
+// dirVersionFile is the stamp's name under data/.
+const dirVersionFile = "VERSION"
+
+// DirVersion is the stamp kept in data/VERSION.
+type DirVersion struct {
+	SchemaVersion    int `json:"schemaVersion"`
+	MinReaderVersion int `json:"minReaderVersion"`
+}
+
+// DataDirTooNewError is returned by NewStoreWithDurability for a data
+// directory written by a newer server than this one can read. Nothing in
+// the directory has been changed.
+type DataDirTooNewError struct {
+	Path string
+	Dir  DirVersion
+}
+
+func (e *DataDirTooNewError) Error() string {
+	return fmt.Sprintf("data directory %s was written with schema version %d and needs a server supporting schema %d or newer; this server supports schema %d: upgrade the server or point it at another data directory",
+		e.Path, e.Dir.SchemaVersion, e.Dir.MinReaderVersion, SchemaVersion)
+}
+
+// schemaMigrations upgrade a data directory in place, keyed by the schema
+// version they upgrade from. Each leaves the directory at the next version.
+var schemaMigrations = map[int]func(basePath string) error{}
+
+// DataDirReport is the data directory's schema stamp as startup found or
+// left it.
+type DataDirReport struct {
+	SchemaVersion    int `json:"schemaVersion"`
+	MinReaderVersion int `json:"minReaderVersion"`
+	SupportedSchema  int `json:"supportedSchema"` // the schema this server writes
+
+	// Stamped is set when the stamp was written at this startup: a new
+	// directory, or one from before stamps existed.
+	Stamped bool `json:"stamped"`
+	// UpgradedFrom is the schema version the directory was migrated from.
+	UpgradedFrom int `json:"upgradedFrom,omitempty"`
+}
+
+// readDirVersion reads the stamp under basePath; ok is false when there is
+// none.
+func readDirVersion(basePath string) (v DirVersion, ok bool, err error) {
+	path := filepath.Join(basePath, "data", dirVersionFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, false, fmt.Errorf("%s: %w", path, err)
+	}
+	if v.SchemaVersion < 1 || v.MinReaderVersion < 1 || v.MinReaderVersion > v.SchemaVersion {
+		return v, false, fmt.Errorf("%s: invalid schema stamp %+v", path, v)
+	}
+	return v, true, nil
+}
+
+// checkDirVersion refuses a directory this server cannot read, migrates an
+// older one and stamps a new one. It runs before anything in the
+// directory is read or repaired.
+func (s *Store) checkDirVersion() (DataDirReport, error) {
+	report := DataDirReport{SupportedSchema: SchemaVersion}
+	found, ok, err := readDirVersion(s.basePath())
+	if err != nil {
+		return report, fmt.Errorf("failed to read data directory version: %w", err)
+	}
+	if ok && found.MinReaderVersion > SchemaVersion {
+		return report, &DataDirTooNewError{Path: s.basePath(), Dir: found}
+	}
+
+	current := DirVersion{SchemaVersion: SchemaVersion, MinReaderVersion: MinReaderVersion}
+	switch {
+	case !ok:
+		report.Stamped = true
+	case found.SchemaVersion < SchemaVersion:
+		for v := found.SchemaVersion; v < SchemaVersion; v++ {
+			if migrate := schemaMigrations[v]; migrate != nil {
+				if err := migrate(s.basePath()); err != nil {
+					return report, fmt.Errorf("failed to upgrade data directory from schema %d to %d: %w", v, v+1, err)
+				}
+			}
+		}
+		report.UpgradedFrom = found.SchemaVersion
+	default:
+		// Current, or newer but still readable: keep the stamp as is so
+		// the newer server's requirements are not lost.
+		s.dirVersion = found
+		report.SchemaVersion, report.MinReaderVersion = found.SchemaVersion, found.MinReaderVersion
+		return report, nil
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return report, err
+	}
+	if err := s.writeAtomically(filepath.Join(s.basePath(), "data", dirVersionFile), data, 0644); err != nil {
+		return report, fmt.Errorf("failed to write data directory version: %w", err)
+	}
+	s.dirVersion = current
+	report.SchemaVersion, report.MinReaderVersion = current.SchemaVersion, current.MinReaderVersion
+	return report, nil
+}
+
+// DirVersion returns the data directory's schema stamp.
+func (s *Store) DirVersion() DirVersion {
+	return s.dirVersion
+}
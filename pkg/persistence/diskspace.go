@@ -0,0 +1,255 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DiskState is the free-space state of the data volume.
+type DiskState string
+
+const (
+	// DiskOK means free space is above the warning threshold.
+	DiskOK DiskState = "ok"
+	// DiskLow means free space is below the warning threshold.
+	DiskLow DiskState = "low"
+	// DiskCritical means free space is below the hard floor; the server
+	// refuses mutations until it recovers.
+	DiskCritical DiskState = "critical"
+	// DiskUnknown means free space could not be read.
+	DiskUnknown DiskState = "unknown"
+)
+
+// largeFlushBytes is the encoded size above which a flush rechecks free
+// space instead of trusting the last periodic check.
+const largeFlushBytes = 1 << 20
+
+// ErrInsufficientStorage is returned when the data volume has too little
+// free space for a write.
+var ErrInsufficientStorage = errors.New("insufficient storage")
+
+// StatFunc reports the free and total bytes of the volume holding path.
+// Free counts the bytes available to this process, not to root.
+type StatFunc func(path string) (free, total uint64, err error)
+
+// DiskStatus is the last free-space reading of the data volume.
+type DiskStatus struct {
+	State         DiskState `json:"state"`
+	FreeBytes     uint64    `json:"freeBytes"`
+	TotalBytes    uint64    `json:"totalBytes"`
+	WarnFreeBytes uint64    `json:"warnFreeBytes"`
+	MinFreeBytes  uint64    `json:"minFreeBytes"`
+	ReadOnly      bool      `json:"readOnly"`
+	CheckedAt     time.Time `json:"checkedAt"`
+	Error         string    `json:"error,omitempty"`
+
+	// ReadOnlySince is when the volume last fell below the floor; zero
+	// while it is above it. ReadOnlyEntered counts the times it did.
+	ReadOnlySince   time.Time `json:"readOnlySince,omitempty"`
+	ReadOnlyEntered uint64    `json:"readOnlyEntered"`
+}
+
+// String describes the reading for log lines and errors, such as
+// "data volume /data: 12345 bytes free of 67890 (critical)".
+func (st DiskStatus) String() string {
+	if st.Error != "" {
+		return fmt.Sprintf("data volume free space unknown: %s", st.Error)
+	}
+	return fmt.Sprintf("data volume: %d bytes free of %d (%s)", st.FreeBytes, st.TotalBytes, st.State)
+}
+
+// DiskMonitor tracks the free space of the data volume. Below warnFree the
+// volume is low; below minFree it is critical and the monitor reports
+// read-only until a later check finds the space back.
+type DiskMonitor struct {
+	path    string
+	warn    uint64
+	min     uint64
+	stat    StatFunc
+	checkMu sync.Mutex
+
+	mu       sync.RWMutex
+	status   DiskStatus
+	readOnly atomic.Bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewDiskMonitor returns a monitor of the volume holding path. A nil stat
+// reads the real volume. The first check runs before it returns.
+func NewDiskMonitor(path string, warnFree, minFree uint64, stat StatFunc) *DiskMonitor {
+	if stat == nil {
+		stat = StatVolume
+	}
+	m := &DiskMonitor{path: path, warn: warnFree, min: minFree, stat: stat}
+	m.Check()
+	return m
+}
+
+// Check reads the volume's free space now and updates the state, entering
+// or leaving read-only mode as it crosses the floor.
+func (m *DiskMonitor) Check() DiskStatus {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+
+	free, total, err := m.stat(m.path)
+	m.mu.RLock()
+	st := m.status
+	m.mu.RUnlock()
+	prev := st.State
+
+	st.WarnFreeBytes, st.MinFreeBytes = m.warn, m.min
+	st.CheckedAt = time.Now()
+	st.Error = ""
+	switch {
+	case err != nil:
+		// An unreadable volume keeps its last mode: guessing either way
+		// would refuse writes or let them fail.
+		st.State, st.Error = DiskUnknown, err.Error()
+	case m.min > 0 && free < m.min:
+		st.State, st.FreeBytes, st.TotalBytes = DiskCritical, free, total
+	case m.warn > 0 && free < m.warn:
+		st.State, st.FreeBytes, st.TotalBytes = DiskLow, free, total
+	default:
+		st.State, st.FreeBytes, st.TotalBytes = DiskOK, free, total
+	}
+
+	if err == nil {
+		readOnly := st.State == DiskCritical
+		if readOnly && !st.ReadOnly {
+			st.ReadOnlySince = st.CheckedAt
+			st.ReadOnlyEntered++
+			log.Printf("⚠️ %s, below the %d byte floor: refusing writes until space recovers", st, m.min)
+		} else if !readOnly && st.ReadOnly {
+			st.ReadOnlySince = time.Time{}
+			log.Printf("✅ %s: accepting writes again", st)
+		} else if st.State == DiskLow && prev != DiskLow {
+			log.Printf("⚠️ %s, below the %d byte warning threshold", st, m.warn)
+		}
+		st.ReadOnly = readOnly
+	}
+	m.readOnly.Store(st.ReadOnly)
+
+	m.mu.Lock()
+	m.status = st
+	m.mu.Unlock()
+	return st
+}
+
+// Relocate makes the monitor watch the volume holding path, as after a
+// storage migration, and checks it at once.
+func (m *DiskMonitor) Relocate(path string) DiskStatus {
+	m.checkMu.Lock()
+	m.path = path
+	m.checkMu.Unlock()
+	return m.Check()
+}
+
+// Status returns the last reading.
+func (m *DiskMonitor) Status() DiskStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// ReadOnly reports whether the volume is below its floor.
+func (m *DiskMonitor) ReadOnly() bool {
+	return m.readOnly.Load()
+}
+
+// Start checks the volume every interval until Close.
+func (m *DiskMonitor) Start(interval time.Duration) {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.Check()
+			}
+		}
+	}()
+}
+
+// Close stops periodic checks.
+func (m *DiskMonitor) Close() {
+	m.once.Do(func() {
+		if m.stop != nil {
+			close(m.stop)
+			<-m.done
+		}
+	})
+}
+
+// isNoSpace reports whether err says the volume is full.
+func isNoSpace(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, ErrInsufficientStorage)
+}
+
+// SetDiskMonitor makes the store check m before large flushes and add its
+// reading to write errors.
+func (s *Store) SetDiskMonitor(m *DiskMonitor) {
+	s.diskMu.Lock()
+	s.disk = m
+	s.diskMu.Unlock()
+}
+
+func (s *Store) diskMonitor() *DiskMonitor {
+	s.diskMu.RLock()
+	defer s.diskMu.RUnlock()
+	return s.disk
+}
+
+// diskError adds the data volume's free space to a failed write, so the
+// log line says whether the disk is the cause. A full disk is rechecked on
+// the spot, which moves the monitor to read-only without waiting for its
+// next tick.
+func (s *Store) diskError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var st DiskStatus
+	if m := s.diskMonitor(); m != nil {
+		if isNoSpace(err) {
+			st = m.Check()
+		} else {
+			st = m.Status()
+		}
+	} else {
+		free, total, statErr := StatVolume(s.basePath())
+		st = DiskStatus{State: DiskUnknown, FreeBytes: free, TotalBytes: total}
+		if statErr != nil {
+			st.Error = statErr.Error()
+		}
+	}
+	return fmt.Errorf("%w (%s)", err, st)
+}
+
+// checkSpace refuses a write of size bytes the volume cannot hold. Large
+// writes recheck the volume first.
+func (s *Store) checkSpace(size int) error {
+	m := s.diskMonitor()
+	if m == nil {
+		return nil
+	}
+	st := m.Status()
+	if size >= largeFlushBytes {
+		st = m.Check()
+	}
+	if st.Error == "" && st.FreeBytes < uint64(size) {
+		return fmt.Errorf("%w: writing %d bytes (%s)", ErrInsufficientStorage, size, st)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// fakeVolume is a StatFunc stub whose free space tests set.
+type fakeVolume struct {
+	free  atomic.Uint64
+	fail  atomic.Bool
+	stats atomic.Int64
+}
+
+func (v *fakeVolume) stat(string) (uint64, uint64, error) {
+	v.stats.Add(1)
+	if v.fail.Load() {
+		return 0, 0, errors.New("statfs: input/output error")
+	}
+	return v.free.Load(), 1 << 30, nil
+}
+
+// fullFile fails every write the way a full disk does.
+type fullFile struct{ *os.File }
+
+func (f fullFile) Write([]byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.Name(), Err: syscall.ENOSPC}
+}
+
+func TestDiskMonitorTransitions(t *testing.T) {
+	vol := &fakeVolume{}
+	vol.free.Store(900)
+	m := NewDiskMonitor(t.TempDir(), 500, 100, vol.stat)
+	if st := m.Status(); st.State != DiskOK || st.ReadOnly {
+		t.Fatalf("expected ok, got %+v", st)
+	}
+
+	vol.free.Store(300)
+	if st := m.Check(); st.State != DiskLow || m.ReadOnly() {
+		t.Errorf("below the warning threshold the volume is low but writable, got %+v", st)
+	}
+
+	vol.free.Store(50)
+	st := m.Check()
+	if st.State != DiskCritical || !m.ReadOnly() || st.ReadOnlyEntered != 1 || st.ReadOnlySince.IsZero() {
+		t.Fatalf("below the floor the monitor should go read-only, got %+v", st)
+	}
+
+	vol.fail.Store(true)
+	if st := m.Check(); st.State != DiskUnknown || !m.ReadOnly() {
+		t.Errorf("an unreadable volume should keep read-only mode, got %+v", st)
+	}
+
+	vol.fail.Store(false)
+	vol.free.Store(800)
+	st = m.Check()
+	if st.State != DiskOK || m.ReadOnly() || !st.ReadOnlySince.IsZero() || st.ReadOnlyEntered != 1 {
+		t.Errorf("recovered space should leave read-only mode, got %+v", st)
+	}
+}
+
+func TestStoreWriteErrorsCarryFreeSpace(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+
+	vol := &fakeVolume{}
+	vol.free.Store(1 << 20)
+	m := NewDiskMonitor(tmpDir, 1<<22, 1<<21, vol.stat)
+	store.SetDiskMonitor(m)
+	store.openFile = func(name string, flag int, perm os.FileMode) (writableFile, error) {
+		f, err := os.OpenFile(name, flag, perm)
+		if err != nil {
+			return nil, err
+		}
+		return fullFile{f}, nil
+	}
+
+	vol.free.Store(4096)
+	checks := vol.stats.Load()
+	matrix := core.NewMatrix("full", core.DefaultBounds())
+	err := store.Save(matrix)
+	if err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("the cause should stay inspectable, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "wal append failed") || !strings.Contains(err.Error(), "4096 bytes free") {
+		t.Errorf("the error should name the write and the free space, got %q", err)
+	}
+	if vol.stats.Load() == checks || !m.ReadOnly() {
+		t.Error("a full disk should be rechecked at once and switch the monitor to read-only")
+	}
+}
+
+func TestStoreFlushWaitsForSpace(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+
+	vol := &fakeVolume{}
+	vol.free.Store(16)
+	m := NewDiskMonitor(tmpDir, 0, 0, vol.stat)
+	store.SetDiskMonitor(m)
+
+	matrix := core.NewMatrix("waiting", core.DefaultBounds())
+	n := core.NewNeuron("kept until the disk has room", matrix.CurrentDim)
+	matrix.Neurons[n.ID] = n
+	if err := store.Save(matrix); !errors.Is(err, ErrInsufficientStorage) {
+		t.Fatalf("expected ErrInsufficientStorage, got %v", err)
+	}
+	if store.Exists("waiting") {
+		t.Fatal("nothing should be written when the volume cannot hold it")
+	}
+
+	vol.free.Store(1 << 30)
+	m.Check()
+	if err := store.FlushAll(); err != nil {
+		t.Fatalf("flush after recovery: %v", err)
+	}
+	loaded, err := store.Load("waiting")
+	if err != nil || len(loaded.Neurons) != 1 {
+		t.Fatalf("the refused flush should have stayed pending, got %v %v", loaded, err)
+	}
+}
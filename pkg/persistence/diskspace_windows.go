@@ -0,0 +1,11 @@
+//go:build windows
+
+package persistence
+
+import "errors"
+
+// StatVolume is not supported on Windows; the monitor reports the volume
+// as unknown and never refuses writes.
+func StatVolume(path string) (free, total uint64, err error) {
+	return 0, 0, errors.New("free space is not reported on windows")
+}
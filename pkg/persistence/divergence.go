@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"log"
+	"os"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Generation returns indexID's generation after checking its data file. A
+// file removed, replaced or rewritten by anything but the store since the
+// store last saw it, say by a restore from backup or a manual cleanup,
+// starts a new generation, as does Delete. A matrix loaded under an older
+// generation has diverged from disk and must not be saved over it.
+func (s *Store) Generation(indexID core.IndexID) uint64 {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	s.checkFileLocked(indexID)
+	return s.generations[indexID]
+}
+
+// Adopt accepts indexID's data file as it is now: pending flushes of the
+// index are dropped and a new generation starts, which it returns. The
+// caller either loads the file again or saves a matrix it has chosen over
+// it under that generation.
+func (s *Store) Adopt(indexID core.IndexID) uint64 {
+	s.writeMu.Lock()
+	delete(s.pendingWrites, indexID)
+	delete(s.flushFailures, indexID)
+	s.writeMu.Unlock()
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	s.recordFileLocked(indexID)
+	s.generations[indexID]++
+	return s.generations[indexID]
+}
+
+// checkFileLocked compares indexID's data file with what the store last
+// recorded and reports whether it changed. A change starts a new
+// generation and drops pending flushes, which hold the replaced state.
+// The first check of an index only records the file. fileMu must be held.
+func (s *Store) checkFileLocked(indexID core.IndexID) bool {
+	cur, err := statDataFile(s.userFilePath(indexID))
+	if err != nil {
+		return false
+	}
+	prev, known := s.files[indexID]
+	s.files[indexID] = cur
+	if !known || sameDataFile(prev, cur) {
+		return false
+	}
+
+	s.generations[indexID]++
+	s.writeMu.Lock()
+	delete(s.pendingWrites, indexID)
+	s.writeMu.Unlock()
+	log.Printf("🚨 persistence: data file of index %s was changed outside the server (generation %d); pending flushes dropped", indexID, s.generations[indexID])
+	return true
+}
+
+// recordFileLocked records indexID's data file after the store changed it.
+// fileMu must be held.
+func (s *Store) recordFileLocked(indexID core.IndexID) {
+	if cur, err := statDataFile(s.userFilePath(indexID)); err == nil {
+		s.files[indexID] = cur
+	} else {
+		delete(s.files, indexID)
+	}
+}
+
+// statDataFile stats a data file, returning nil info when there is none.
+func statDataFile(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return info, err
+}
+
+// sameDataFile reports whether two stats are of the same, unchanged file:
+// same identity, size and modification time, or both absent.
+func sameDataFile(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
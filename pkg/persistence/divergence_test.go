@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// replaceDataFile swaps indexID's data file for matrix behind the store's
+// back, as a restore from backup would.
+func replaceDataFile(t *testing.T, store *Store, indexID core.IndexID, matrix *core.Matrix) {
+	t.Helper()
+	data, err := store.codec.Encode(matrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := store.userFilePath(indexID)
+	if err := os.WriteFile(path+".restore", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".restore", path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func matrixWith(indexID core.IndexID, content string) *core.Matrix {
+	m := core.NewMatrix(indexID, core.DefaultBounds())
+	n := core.NewNeuron(content, m.CurrentDim)
+	m.Neurons[n.ID] = n
+	return m
+}
+
+func TestGeneration_OwnWritesKeepIt(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+
+	gen := store.Generation("idx")
+	for i := 0; i < 3; i++ {
+		if err := store.Save(matrixWith("idx", "state")); err != nil {
+			t.Fatal(err)
+		}
+		if got := store.Generation("idx"); got != gen {
+			t.Fatalf("the store's own writes must not start a generation: %d -> %d", gen, got)
+		}
+	}
+
+	if err := store.Delete("idx"); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Generation("idx"); got != gen+1 {
+		t.Errorf("Delete should start a generation, got %d after %d", got, gen)
+	}
+}
+
+func TestGeneration_ExternalChangeDropsStaleFlush(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+
+	if err := store.Save(matrixWith("idx", "original")); err != nil {
+		t.Fatal(err)
+	}
+	gen := store.Generation("idx")
+
+	// A flush queued before the file is restored from backup must not
+	// overwrite the restored file.
+	store.SaveAsync(matrixWith("idx", "stale in-memory state"))
+	replaceDataFile(t, store, "idx", matrixWith("idx", "restored from backup"))
+
+	err := store.FlushAll()
+	if !errors.Is(err, core.ErrIndexDiverged) {
+		t.Fatalf("expected the stale flush to be refused, got %v", err)
+	}
+	if got := store.Generation("idx"); got != gen+1 {
+		t.Errorf("an outside change should start a generation, got %d after %d", got, gen)
+	}
+	if pending, _ := store.FlushIndex("idx"); pending {
+		t.Error("the refused flush should be dropped, not retried")
+	}
+	if len(store.FlushFailures()) != 0 {
+		t.Error("a refused stale flush is not a flush failure")
+	}
+	loaded, err := store.Load("idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range loaded.Neurons {
+		if n.Content != "restored from backup" {
+			t.Errorf("restored file was overwritten with %q", n.Content)
+		}
+	}
+
+	// Once adopted, the next save goes through under a new generation.
+	adopted := store.Adopt("idx")
+	if adopted != gen+2 {
+		t.Errorf("Adopt should start a generation, got %d", adopted)
+	}
+	if err := store.Save(matrixWith("idx", "chosen")); err != nil {
+		t.Errorf("a save after Adopt should succeed: %v", err)
+	}
+	if got := store.Generation("idx"); got != adopted {
+		t.Errorf("generation moved after an own write: %d -> %d", adopted, got)
+	}
+}
+
+func TestGeneration_ExternalRemoval(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+
+	if err := store.Save(matrixWith("idx", "x")); err != nil {
+		t.Fatal(err)
+	}
+	gen := store.Generation("idx")
+	if err := os.Remove(store.userFilePath("idx")); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Generation("idx"); got != gen+1 {
+		t.Errorf("a removed file should start a generation, got %d after %d", got, gen)
+	}
+	if got := store.Generation("idx"); got != gen+1 {
+		t.Errorf("a change is only counted once, got %d", got)
+	}
+}
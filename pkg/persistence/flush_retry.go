@@ -0,0 +1,170 @@
+package persistence
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// FlushFailure is the flush state of an index whose latest flushes failed.
+// It is cleared by the next successful flush.
+type FlushFailure struct {
+	IndexID      core.IndexID `json:"indexId"`
+	Failures     int          `json:"failures"`
+	FirstFailure time.Time    `json:"firstFailure"`
+	LastFailure  time.Time    `json:"lastFailure"`
+	NextAttempt  time.Time    `json:"nextAttempt"`
+	LastError    string       `json:"lastError"`
+
+	// Stale is set once the index has failed to persist for
+	// FlushStaleAfter, Failing once it has for FlushStaleFailAfter.
+	Stale   bool `json:"stale"`
+	Failing bool `json:"failing"`
+}
+
+// flushLevel grades how long an index has gone without persisting.
+type flushLevel int
+
+const (
+	flushRetrying flushLevel = iota
+	flushStale
+	flushFailing
+)
+
+func (s *Store) flushLevel(f *FlushFailure, now time.Time) flushLevel {
+	age := now.Sub(f.FirstFailure)
+	switch {
+	case s.durability.FlushStaleFailAfter > 0 && age >= s.durability.FlushStaleFailAfter:
+		return flushFailing
+	case s.durability.FlushStaleAfter > 0 && age >= s.durability.FlushStaleAfter:
+		return flushStale
+	}
+	return flushRetrying
+}
+
+// flushBackoff is the wait before the next flush after the given number of
+// consecutive failures: FlushRetryBackoff, doubled per further failure up
+// to FlushRetryMaxBackoff.
+func (s *Store) flushBackoff(failures int) time.Duration {
+	wait := s.durability.FlushRetryBackoff
+	if wait <= 0 {
+		return 0
+	}
+	for i := 1; i < failures && wait < s.durability.FlushRetryMaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, s.durability.FlushRetryMaxBackoff)
+}
+
+// recordFlush updates the failure state of indexID after a flush that
+// returned err. Failures are logged at each paced retry, escalating once
+// the index goes stale; refusals for lack of space are retried on every
+// pass, as the disk monitor already reports them.
+func (s *Store) recordFlush(indexID core.IndexID, err error) {
+	now := s.now()
+	s.writeMu.Lock()
+	f := s.flushFailures[indexID]
+	if err == nil {
+		delete(s.flushFailures, indexID)
+		s.writeMu.Unlock()
+		if f != nil {
+			log.Printf("✅ persistence: index %s flushed after %d failed attempts over %s", indexID, f.Failures, now.Sub(f.FirstFailure).Round(time.Second))
+		}
+		return
+	}
+	if f == nil {
+		f = &FlushFailure{IndexID: indexID, FirstFailure: now}
+		s.flushFailures[indexID] = f
+	}
+	prev := flushRetrying
+	if f.Failures > 0 {
+		prev = s.flushLevel(f, f.LastFailure)
+	}
+	f.Failures++
+	f.LastFailure = now
+	f.LastError = err.Error()
+	f.NextAttempt = now
+	paced := !errors.Is(err, ErrInsufficientStorage)
+	if paced {
+		f.NextAttempt = now.Add(s.flushBackoff(f.Failures))
+	}
+	level := s.flushLevel(f, now)
+	failures, since, next := f.Failures, now.Sub(f.FirstFailure).Round(time.Second), f.NextAttempt.Sub(now)
+	s.writeMu.Unlock()
+
+	if !paced && level <= prev {
+		return
+	}
+	switch level {
+	case flushFailing:
+		log.Printf("🚨 persistence: index %s has not persisted for %s (%d failed flushes, past storage.flushStaleFailAfter), retrying in %s: %v", indexID, since, failures, next, err)
+	case flushStale:
+		log.Printf("🚨 persistence: index %s has not persisted for %s (%d failed flushes), retrying in %s: %v", indexID, since, failures, next, err)
+	default:
+		log.Printf("⚠️ persistence: flush of index %s failed (attempt %d), retrying in %s: %v", indexID, failures, next, err)
+	}
+}
+
+// flushDue reports whether indexID's backoff allows a flush at now.
+func (s *Store) flushDue(indexID core.IndexID, now time.Time) bool {
+	f, ok := s.flushFailures[indexID]
+	return !ok || !now.Before(f.NextAttempt)
+}
+
+// FlushFailures returns the indexes whose latest flush failed, oldest
+// failure first.
+func (s *Store) FlushFailures() []FlushFailure {
+	now := s.now()
+	s.writeMu.Lock()
+	out := make([]FlushFailure, 0, len(s.flushFailures))
+	for _, f := range s.flushFailures {
+		c := *f
+		level := s.flushLevel(f, now)
+		c.Stale = level >= flushStale
+		c.Failing = level == flushFailing
+		out = append(out, c)
+	}
+	s.writeMu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].FirstFailure.Equal(out[j].FirstFailure) {
+			return out[i].FirstFailure.Before(out[j].FirstFailure)
+		}
+		return out[i].IndexID < out[j].IndexID
+	})
+	return out
+}
+
+// staleIndexes returns the stale entries of failures.
+func staleIndexes(failures []FlushFailure) []FlushFailure {
+	stale := []FlushFailure{}
+	for _, f := range failures {
+		if f.Stale {
+			stale = append(stale, f)
+		}
+	}
+	return stale
+}
+
+// Pending reports whether a state of indexID waits in the write coalescing
+// map for the next flush.
+func (s *Store) Pending(indexID core.IndexID) bool {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, ok := s.pendingWrites[indexID]
+	return ok
+}
+
+// FlushIndex flushes indexID's pending state now, regardless of any
+// backoff. It reports whether anything was pending.
+func (s *Store) FlushIndex(indexID core.IndexID) (bool, error) {
+	s.writeMu.Lock()
+	_, pending := s.pendingWrites[indexID]
+	s.writeMu.Unlock()
+	if !pending {
+		return false, nil
+	}
+	return true, s.flushUser(indexID)
+}
@@ -0,0 +1,159 @@
+package persistence
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// failIndexWrites makes writes of one index's data file fail with EIO
+// while fail is set, and counts the attempts.
+func failIndexWrites(store *Store, indexID core.IndexID, fail *atomic.Bool, attempts *atomic.Int32) {
+	target := store.userFilePath(indexID) + ".tmp"
+	store.openFile = func(name string, flag int, perm os.FileMode) (writableFile, error) {
+		if name == target {
+			attempts.Add(1)
+			if fail.Load() {
+				return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EIO}
+			}
+		}
+		return os.OpenFile(name, flag, perm)
+	}
+}
+
+func TestFlushRetryBackoffAndRecovery(t *testing.T) {
+	durability := DefaultDurabilityConfig()
+	durability.FlushRetryBackoff = 10 * time.Second
+	durability.FlushRetryMaxBackoff = 40 * time.Second
+	durability.FlushStaleAfter = time.Minute
+	durability.FlushStaleFailAfter = 5 * time.Minute
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	var fail atomic.Bool
+	var attempts atomic.Int32
+	fail.Store(true)
+	failIndexWrites(store, "broken", &fail, &attempts)
+
+	healthy := core.NewMatrix("healthy", core.DefaultBounds())
+	broken := core.NewMatrix("broken", core.DefaultBounds())
+	store.SaveAsync(healthy)
+	store.SaveAsync(broken)
+	if err := store.FlushAll(); err == nil {
+		t.Fatal("expected the broken index's flush to fail")
+	}
+	if !store.Exists("healthy") {
+		t.Error("a failing index must not hold back the others")
+	}
+
+	failures := store.FlushFailures()
+	if len(failures) != 1 || failures[0].IndexID != "broken" || failures[0].Failures != 1 || failures[0].Stale {
+		t.Fatalf("unexpected failure state %+v", failures)
+	}
+	if !failures[0].NextAttempt.Equal(now.Add(10 * time.Second)) {
+		t.Errorf("the first retry should wait the base backoff, got %s", failures[0].NextAttempt.Sub(now))
+	}
+
+	// Passes within the backoff leave the index alone; waits double up to
+	// the cap.
+	var waits []time.Duration
+	for i := 0; i < 60; i++ {
+		before := attempts.Load()
+		store.FlushAll()
+		if attempts.Load() != before {
+			f := store.FlushFailures()[0]
+			waits = append(waits, f.NextAttempt.Sub(now))
+		}
+		now = now.Add(5 * time.Second)
+	}
+	if len(waits) != 8 || waits[0] != 20*time.Second {
+		t.Fatalf("expected 8 paced retries in 5 minutes, starting at 20s, got %v", waits)
+	}
+	for _, wait := range waits[1:] {
+		if wait != 40*time.Second {
+			t.Errorf("waits should be capped at 40s, got %v", waits)
+			break
+		}
+	}
+
+	// Five minutes in, the index is past both thresholds.
+	f := store.FlushFailures()[0]
+	if !f.Stale || !f.Failing {
+		t.Errorf("expected a stale, failing index after %s, got %+v", now.Sub(f.FirstFailure), f)
+	}
+	stale, _ := store.Stats()["stale_indexes"].([]FlushFailure)
+	if len(stale) != 1 || stale[0].IndexID != "broken" || !strings.Contains(stale[0].LastError, "input/output error") {
+		t.Errorf("stats should list the stale index, got %+v", stale)
+	}
+
+	// A forced flush ignores the backoff, and success clears the state.
+	fail.Store(false)
+	before := attempts.Load()
+	pending, err := store.FlushIndex("broken")
+	if !pending || err != nil || attempts.Load() != before+1 {
+		t.Fatalf("forced flush: pending=%v err=%v", pending, err)
+	}
+	if got := store.FlushFailures(); len(got) != 0 {
+		t.Errorf("a successful flush should clear the failure state, got %+v", got)
+	}
+	if !store.Exists("broken") {
+		t.Error("the recovered index should be persisted")
+	}
+	if pending, _ := store.FlushIndex("broken"); pending {
+		t.Error("nothing should be pending after the flush")
+	}
+}
+
+func TestFlushRetryKeepsNewestState(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+	var fail atomic.Bool
+	var attempts atomic.Int32
+	fail.Store(true)
+	failIndexWrites(store, "idx", &fail, &attempts)
+
+	m := core.NewMatrix("idx", core.DefaultBounds())
+	if err := store.Save(m); err == nil {
+		t.Fatal("expected the save to fail")
+	}
+	newer := core.NewMatrix("idx", core.DefaultBounds())
+	n := core.NewNeuron("written while the flush was failing", newer.CurrentDim)
+	newer.Neurons[n.ID] = n
+	store.SaveAsync(newer)
+
+	fail.Store(false)
+	if _, err := store.FlushIndex("idx"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load("idx")
+	if err != nil || len(loaded.Neurons) != 1 {
+		t.Fatalf("the newest queued state should be flushed, got %v %v", loaded, err)
+	}
+}
+
+func TestFlushBackoffDisabled(t *testing.T) {
+	durability := DefaultDurabilityConfig()
+	durability.FlushRetryBackoff = 0
+	durability.FlushRetryMaxBackoff = 0
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+	var fail atomic.Bool
+	var attempts atomic.Int32
+	fail.Store(true)
+	failIndexWrites(store, "idx", &fail, &attempts)
+
+	store.SaveAsync(core.NewMatrix("idx", core.DefaultBounds()))
+	for i := 0; i < 3; i++ {
+		store.FlushAll()
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("without backoff every pass should retry, got %d attempts", attempts.Load())
+	}
+}
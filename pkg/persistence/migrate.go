@@ -0,0 +1,644 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+var (
+	// ErrMigrationRunning is returned when a storage migration is started
+	// while another one runs.
+	ErrMigrationRunning = errors.New("a storage migration is already running")
+
+	// ErrInvalidMigrationTarget is returned for a target directory the data
+	// directory cannot be moved to.
+	ErrInvalidMigrationTarget = errors.New("invalid migration target")
+
+	// ErrMigrationPauseExceeded is returned when the switchover could not
+	// finish within its write pause. The store keeps its data directory and
+	// a later migration to the same target reuses the copy.
+	ErrMigrationPauseExceeded = errors.New("switchover did not fit in the write pause")
+)
+
+// Storage migration phases, as reported by MigrationProgress.
+const (
+	MigrationCopy       = "copy"
+	MigrationDelta      = "delta"
+	MigrationSwitchover = "switchover"
+	MigrationDone       = "done"
+)
+
+// migrationMarker marks a target directory holding a migration's copy, so
+// a migration that failed can be started again over it.
+const migrationMarker = ".qubicdb-migration"
+
+// migrationThroughput is the copy rate a plan assumes, in bytes per
+// second.
+const migrationThroughput = 100 << 20
+
+// MigrationOptions controls Store.Migrate.
+type MigrationOptions struct {
+	// MaxPasses bounds the delta passes after the first, full copy; the
+	// switchover follows the last one whatever changed during it.
+	MaxPasses int
+
+	// SettleIndexes is a number of changed indexes small enough to switch
+	// over with: delta passes stop once at most that many indexes changed
+	// since the previous pass.
+	SettleIndexes int
+
+	// MaxPause bounds the switchover, from pausing writes to resuming
+	// them.
+	MaxPause time.Duration
+
+	// Pause stops writes from outside the store and returns the function
+	// resuming them. Nil pauses nothing.
+	Pause func(ctx context.Context) (resume func(), err error)
+
+	// Relocate moves the components keeping files in the data directory
+	// outside the store to target. It runs during the pause, after the
+	// store switched.
+	Relocate func(target string) error
+
+	// Progress, when set, is called as the migration goes: before the full
+	// copy, after each delta pass with the indexes it copied again, before
+	// the switchover with those left for it, and when done.
+	Progress func(MigrationProgress)
+}
+
+// MigrationProgress is the state of a running storage migration.
+type MigrationProgress struct {
+	Phase          string `json:"phase"`
+	Pass           int    `json:"pass"`
+	FilesCopied    int    `json:"filesCopied"`
+	BytesCopied    int64  `json:"bytesCopied"`
+	ChangedIndexes int    `json:"changedIndexes"`
+}
+
+// MigrationReport is the outcome of a storage migration. FinalIndexes
+// counts the indexes copied during the switchover, Switchover is how long
+// writes were paused and Switched whether the store moved to Target.
+type MigrationReport struct {
+	Source, Target string
+	Passes         int
+	FilesCopied    int
+	BytesCopied    int64
+	FinalIndexes   int
+	Switchover     time.Duration
+	Switched       bool
+}
+
+// MigrationPlan estimates a storage migration without copying anything.
+type MigrationPlan struct {
+	Source          string `json:"source"`
+	Target          string `json:"target"`
+	Files           int    `json:"files"`
+	Bytes           int64  `json:"bytes"`
+	Indexes         int    `json:"indexes"`
+	TargetFreeBytes uint64 `json:"targetFreeBytes"`
+	Fits            bool   `json:"fits"`
+	EstimatedPasses int    `json:"estimatedPasses"`
+}
+
+// migrationChanges is what the store wrote since a migration's last pass:
+// the indexes whose data file or versions changed, whether the manifest
+// and checkpoints did, and whether the WAL was truncated, which makes its
+// copy start over.
+type migrationChanges struct {
+	indexes      map[core.IndexID]struct{}
+	catalog      bool
+	walTruncated bool
+}
+
+// markChanged records that indexID's files changed, while a migration
+// tracks changes.
+func (s *Store) markChanged(indexID core.IndexID) {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+	if s.changes != nil {
+		s.changes.indexes[indexID] = struct{}{}
+	}
+}
+
+// markCatalogChanged records that the manifest and checkpoints changed,
+// while a migration tracks changes.
+func (s *Store) markCatalogChanged() {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+	if s.changes != nil {
+		s.changes.catalog = true
+	}
+}
+
+// markWALTruncated records that the WAL was truncated, while a migration
+// tracks changes.
+func (s *Store) markWALTruncated() {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+	if s.changes != nil {
+		s.changes.walTruncated = true
+	}
+}
+
+// trackChanges starts, or with on false stops, recording changes.
+func (s *Store) trackChanges(on bool) {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+	s.changes = nil
+	if on {
+		s.changes = &migrationChanges{indexes: make(map[core.IndexID]struct{})}
+	}
+}
+
+// takeChanges returns the changes recorded since the last call and starts
+// a new set.
+func (s *Store) takeChanges() migrationChanges {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+	taken := *s.changes
+	s.changes = &migrationChanges{indexes: make(map[core.IndexID]struct{})}
+	return taken
+}
+
+// changedIndexes counts the indexes changed since the last takeChanges.
+func (s *Store) changedIndexes() int {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+	return len(s.changes.indexes)
+}
+
+// PlanMigration estimates moving the data directory to target: what there
+// is to copy, whether the target volume holds it and how many passes it
+// should take with maxPasses delta passes at most.
+func (s *Store) PlanMigration(target string, maxPasses int) (MigrationPlan, error) {
+	source := s.basePath()
+	target, err := checkMigrationTarget(source, target)
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+	plan := MigrationPlan{Source: source, Target: target, Indexes: len(s.ListIndexes())}
+	// Files the server removes meanwhile, such as old manifests, are
+	// skipped.
+	err = filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() || skipMigrationFile(d.Name()) {
+			return err
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		plan.Files++
+		plan.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return plan, err
+	}
+	free, _, statErr := StatVolume(existingAncestor(target))
+	plan.TargetFreeBytes = free
+	plan.Fits = statErr == nil && free >= uint64(plan.Bytes)
+
+	// Each delta pass copies what changed during the previous one, which
+	// takes about half as long, until a pass takes under a second; the
+	// full copy comes before them and the switchover after.
+	delta := 0
+	for seconds := float64(plan.Bytes) / migrationThroughput; seconds >= 1 && delta < maxPasses; seconds /= 2 {
+		delta++
+	}
+	plan.EstimatedPasses = delta + 2
+	return plan, nil
+}
+
+// Migrate moves the data directory to target while the store keeps
+// serving. A full copy is followed by delta passes re-copying the indexes
+// written since the previous pass, until few enough changed or
+// opts.MaxPasses ran. Then writes are paused, the last changes and the WAL
+// are copied with the store's writers held off, and the store switches to
+// target. Cancelling ctx before the switchover abandons the migration,
+// leaving the store where it was; the source directory is never changed.
+func (s *Store) Migrate(ctx context.Context, target string, opts MigrationOptions) (MigrationReport, error) {
+	source := s.basePath()
+	report := MigrationReport{Source: source}
+	target, err := checkMigrationTarget(source, target)
+	if err != nil {
+		return report, err
+	}
+	report.Target = target
+	if !s.migrating.CompareAndSwap(false, true) {
+		return report, ErrMigrationRunning
+	}
+	defer s.migrating.Store(false)
+
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return report, s.diskError(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, migrationMarker), []byte(source+"\n"), 0644); err != nil {
+		return report, err
+	}
+
+	s.trackChanges(true)
+	defer s.trackChanges(false)
+	progress := func(phase string, changed int) {
+		if opts.Progress != nil {
+			opts.Progress(MigrationProgress{
+				Phase:          phase,
+				Pass:           report.Passes,
+				FilesCopied:    report.FilesCopied,
+				BytesCopied:    report.BytesCopied,
+				ChangedIndexes: changed,
+			})
+		}
+	}
+	m := &mirror{ctx: ctx, source: source, target: target}
+
+	// The full copy: everything but the WAL, which is copied as it grows.
+	report.Passes = 1
+	progress(MigrationCopy, 0)
+	if err := m.path("", func(rel string) bool { return rel == walFile }); err != nil {
+		return report, err
+	}
+	if err := m.walTail(); err != nil {
+		return report, err
+	}
+	report.FilesCopied, report.BytesCopied = m.files, m.bytes
+
+	for pass := 0; pass < opts.MaxPasses; pass++ {
+		changes := s.changedIndexes()
+		if changes <= opts.SettleIndexes {
+			break
+		}
+		report.Passes++
+		if err := s.copyChanges(m, s.takeChanges()); err != nil {
+			return report, err
+		}
+		report.FilesCopied, report.BytesCopied = m.files, m.bytes
+		progress(MigrationDelta, changes)
+	}
+	// Reported before the last look at ctx, so a caller that saw the
+	// switchover phase knows cancelling comes too late.
+	report.Passes++
+	progress(MigrationSwitchover, s.changedIndexes())
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	if err := s.switchover(target, m, opts, &report); err != nil {
+		return report, err
+	}
+	os.Remove(filepath.Join(target, migrationMarker))
+	progress(MigrationDone, 0)
+	log.Printf("📦 Data directory moved from %s to %s in %d passes (%d files, %d bytes; writes paused %s)",
+		source, target, report.Passes, report.FilesCopied, report.BytesCopied, report.Switchover.Round(time.Millisecond))
+	return report, nil
+}
+
+// switchover pauses writes, copies what changed since the last pass and
+// the end of the WAL while holding off the store's own writers, and
+// switches the store to target. Past opts.MaxPause it gives up before
+// switching.
+func (s *Store) switchover(target string, m *mirror, opts MigrationOptions, report *MigrationReport) (err error) {
+	started := time.Now()
+	ctx := context.Background()
+	if opts.MaxPause > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxPause)
+		defer cancel()
+	}
+	defer func() {
+		report.Switchover = time.Since(started)
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%w (storage.migration.maxPause=%s)", ErrMigrationPauseExceeded, opts.MaxPause)
+		}
+	}()
+	if opts.Pause != nil {
+		resume, err := opts.Pause(ctx)
+		if err != nil {
+			return err
+		}
+		defer resume()
+	}
+	// The final copy runs against the pause deadline, not the job's
+	// context: the migration can no longer be cancelled.
+	m.ctx = ctx
+
+	s.fileMu.Lock()
+	s.checkpointMu.Lock()
+	s.walMu.Lock()
+	s.blobs.mu.Lock()
+	locked := true
+	unlock := func() {
+		if locked {
+			s.blobs.mu.Unlock()
+			s.walMu.Unlock()
+			s.checkpointMu.Unlock()
+			s.fileMu.Unlock()
+			locked = false
+		}
+	}
+	defer unlock()
+
+	changes := s.takeChanges()
+	report.FinalIndexes = len(changes.indexes)
+	if err := s.copyChanges(m, changes); err != nil {
+		return err
+	}
+	if err := m.walTail(); err != nil {
+		return err
+	}
+	report.FilesCopied, report.BytesCopied = m.files, m.bytes
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.root.Store(&target)
+	blobs := filepath.Join(target, "data", "blobs")
+	s.blobs.root.Store(&blobs)
+	// The copies are other files: record them, or every index would look
+	// changed behind the store's back.
+	for indexID := range s.files {
+		s.recordFileLocked(indexID)
+	}
+	report.Switched = true
+	unlock()
+
+	if d := s.diskMonitor(); d != nil {
+		d.Relocate(target)
+	}
+	if opts.Relocate != nil {
+		if err := opts.Relocate(target); err != nil {
+			return fmt.Errorf("store moved to %s, but relocating other files failed: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// copyChanges copies what changed in a pass: the data files and versions
+// of the changed indexes, the manifest and checkpoints when they changed,
+// the blobs, the WAL's new records and the files kept in the data
+// directory by other components.
+func (s *Store) copyChanges(m *mirror, changes migrationChanges) error {
+	if changes.walTruncated {
+		m.walOffset = 0
+	}
+	for indexID := range changes.indexes {
+		if err := m.path(filepath.Join("data", string(indexID)+".nrdb"), nil); err != nil {
+			return err
+		}
+		if err := m.path(filepath.Join("data", "versions", string(indexID)), nil); err != nil {
+			return err
+		}
+	}
+	if changes.catalog {
+		for _, rel := range []string{"manifest", "checkpoints", "index.nrdb"} {
+			if err := m.path(rel, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if err := m.path(filepath.Join("data", "blobs"), nil); err != nil {
+		return err
+	}
+	if err := m.walTail(); err != nil {
+		return err
+	}
+	return m.path("", storeOwned)
+}
+
+// storeOwned reports whether rel, relative to the data directory, is
+// written by the store, whose changes are tracked rather than scanned for.
+func storeOwned(rel string) bool {
+	switch filepath.ToSlash(rel) {
+	case walFile, "manifest", "checkpoints", "index.nrdb", "data/versions", "data/blobs":
+		return true
+	}
+	return filepath.Dir(rel) == "data" && filepath.Ext(rel) == ".nrdb"
+}
+
+// skipMigrationFile reports whether a file is never copied: temporary
+// files of writes in progress and the migration marker.
+func skipMigrationFile(name string) bool {
+	return strings.HasSuffix(name, ".tmp") || strings.HasPrefix(name, blobUploadPrefix) || name == migrationMarker
+}
+
+// checkMigrationTarget returns target as an absolute path, refusing one
+// that is, holds or lies inside source, or that is not a directory that is
+// empty or holds an earlier migration's copy.
+func checkMigrationTarget(source, target string) (string, error) {
+	if strings.TrimSpace(target) == "" {
+		return "", fmt.Errorf("%w: targetPath is required", ErrInvalidMigrationTarget)
+	}
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMigrationTarget, err)
+	}
+	source, err = filepath.Abs(source)
+	if err != nil {
+		return "", err
+	}
+	if within(target, source) || within(source, target) {
+		return "", fmt.Errorf("%w: %s overlaps the data directory %s", ErrInvalidMigrationTarget, target, source)
+	}
+	entries, err := os.ReadDir(target)
+	if os.IsNotExist(err) {
+		return target, nil
+	} else if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMigrationTarget, err)
+	}
+	if len(entries) > 0 {
+		if _, err := os.Stat(filepath.Join(target, migrationMarker)); err != nil {
+			return "", fmt.Errorf("%w: %s is not empty", ErrInvalidMigrationTarget, target)
+		}
+	}
+	return target, nil
+}
+
+// within reports whether path is dir or lies inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// existingAncestor returns path or its nearest ancestor that exists, whose
+// volume a directory created at path would be on.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// mirror copies parts of the source directory to the target, counting
+// the files and bytes it copied.
+type mirror struct {
+	ctx            context.Context
+	source, target string
+	walOffset      int64
+	files          int
+	bytes          int64
+}
+
+// path makes rel under the target a copy of rel under the source, a file
+// or a directory tree: files missing in the target or differing in size or
+// modification time are copied, and files the source no longer has are
+// removed. Paths for which skip, when set, is true are left alone.
+func (m *mirror) path(rel string, skip func(rel string) bool) error {
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+	src, dst := filepath.Join(m.source, rel), filepath.Join(m.target, rel)
+	info, err := os.Stat(src)
+	if os.IsNotExist(err) {
+		return os.RemoveAll(dst)
+	} else if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return m.file(src, dst, info)
+	}
+
+	if err := os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if os.IsNotExist(err) {
+		return os.RemoveAll(dst)
+	} else if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		child := filepath.Join(rel, e.Name())
+		if skipMigrationFile(e.Name()) || (skip != nil && skip(child)) {
+			continue
+		}
+		present[e.Name()] = true
+		if err := m.path(child, skip); err != nil {
+			return err
+		}
+	}
+	copied, err := os.ReadDir(dst)
+	if err != nil {
+		return err
+	}
+	for _, e := range copied {
+		child := filepath.Join(rel, e.Name())
+		if present[e.Name()] || (skip != nil && skip(child)) || e.Name() == migrationMarker {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(m.target, child)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// file copies src to dst unless dst already has its size and modification
+// time. The copy is synced and given src's modification time.
+func (m *mirror) file(src, dst string, info os.FileInfo) error {
+	if cur, err := os.Stat(dst); err == nil && cur.Size() == info.Size() && cur.ModTime().Equal(info.ModTime()) {
+		return nil
+	}
+	in, err := os.Open(src)
+	if os.IsNotExist(err) {
+		// Removed since it was listed; the next pass removes the copy.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	m.files++
+	m.bytes += n
+	return nil
+}
+
+// walTail appends the WAL's records past the last copied offset to the
+// target's copy. Between checkpoints the WAL only grows, so the copy is a
+// prefix of it; after the store truncated it, the copy starts over.
+func (m *mirror) walTail() error {
+	in, err := os.Open(filepath.Join(m.source, walFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < m.walOffset {
+		m.walOffset = 0
+	}
+	out, err := os.OpenFile(filepath.Join(m.target, walFile), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if err := out.Truncate(m.walOffset); err != nil {
+		return err
+	}
+	if _, err := in.Seek(m.walOffset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := out.Seek(m.walOffset, io.SeekStart); err != nil {
+		return err
+	}
+	n, err := io.Copy(out, in)
+	if err != nil {
+		return err
+	}
+	m.walOffset += n
+	m.bytes += n
+	return out.Sync()
+}
+
+// MirrorTree makes the directory dst a copy of src the way a storage
+// migration copies the data directory: files that differ are copied and
+// files src lacks are removed. Components keeping a directory of their own
+// use it to follow a migration.
+func MirrorTree(src, dst string) error {
+	m := &mirror{ctx: context.Background(), source: src, target: dst}
+	return m.path("", nil)
+}
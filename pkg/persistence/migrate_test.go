@@ -0,0 +1,200 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// migrationWriter saves numbered states of one index until stopped, holding
+// gate's read lock across each save so a migration's pause can hold it off.
+// It records the last state it saved.
+type migrationWriter struct {
+	indexID core.IndexID
+	last    int
+}
+
+func (w *migrationWriter) state(i int) *core.Matrix {
+	m := core.NewMatrix(w.indexID, core.DefaultBounds())
+	n := core.NewNeuron(fmt.Sprintf("%s state %d", w.indexID, i), m.CurrentDim)
+	m.Neurons[n.ID] = n
+	m.Version = uint64(i)
+	return m
+}
+
+func (w *migrationWriter) run(t *testing.T, store *Store, gate *sync.RWMutex, stop <-chan struct{}) {
+	for i := 1; ; i++ {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		gate.RLock()
+		var err error
+		if i%3 == 0 {
+			err = store.Save(w.state(i))
+		} else {
+			err = store.SaveAsync(w.state(i))
+		}
+		gate.RUnlock()
+		if err != nil {
+			t.Errorf("%s: save %d: %v", w.indexID, i, err)
+			return
+		}
+		w.last = i
+	}
+}
+
+func TestStoreMigrate_UnderWriteLoad(t *testing.T) {
+	store, source := setupTestStore(t)
+	defer os.RemoveAll(source)
+	target := filepath.Join(t.TempDir(), "moved")
+
+	writers := make([]*migrationWriter, 8)
+	for i := range writers {
+		writers[i] = &migrationWriter{indexID: core.IndexID(fmt.Sprintf("idx-%d", i))}
+		if err := store.Save(writers[i].state(0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gate sync.RWMutex
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var stopOnce sync.Once
+	stopWriters := func() {
+		stopOnce.Do(func() { close(stop) })
+		wg.Wait()
+	}
+	defer stopWriters()
+	for _, w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(t, store, &gate, stop)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+				store.FlushAll()
+			}
+		}
+	}()
+
+	maxPause := 2 * time.Second
+	var phases []string
+	report, err := store.Migrate(context.Background(), target, MigrationOptions{
+		MaxPasses:     5,
+		SettleIndexes: 2,
+		MaxPause:      maxPause,
+		Pause: func(context.Context) (func(), error) {
+			gate.Lock()
+			return gate.Unlock, nil
+		},
+		Progress: func(p MigrationProgress) {
+			phases = append(phases, p.Phase)
+			if p.Phase == MigrationCopy {
+				// As if the copy took long enough for every index to change.
+				for deadline := time.Now().Add(5 * time.Second); store.changedIndexes() < len(writers) && time.Now().Before(deadline); {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	// Writes keep going to the new directory after the switch.
+	time.Sleep(20 * time.Millisecond)
+	stopWriters()
+	if err := store.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.BasePath() != target || !report.Switched {
+		t.Fatalf("expected the store switched to %s, got %s (%+v)", target, store.BasePath(), report)
+	}
+	if report.Switchover <= 0 || report.Switchover > maxPause {
+		t.Errorf("expected the switchover within %s, took %s", maxPause, report.Switchover)
+	}
+	if report.Passes < 3 || phases[0] != MigrationCopy || phases[len(phases)-1] != MigrationDone {
+		t.Errorf("expected a copy and delta passes through to done, got %d passes: %v", report.Passes, phases)
+	}
+	if _, err := os.Stat(filepath.Join(target, migrationMarker)); !os.IsNotExist(err) {
+		t.Errorf("expected the marker removed after the switch, got %v", err)
+	}
+
+	// Every saved state is in the new directory, whether it was flushed
+	// or only in the WAL.
+	reopened, err := NewStore(target, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range writers {
+		m, err := reopened.Load(w.indexID)
+		if err != nil {
+			t.Fatalf("%s: %v", w.indexID, err)
+		}
+		want := fmt.Sprintf("%s state %d", w.indexID, w.last)
+		for _, n := range m.Neurons {
+			if n.Content != want {
+				t.Errorf("%s: expected %q, got %q", w.indexID, want, n.Content)
+			}
+		}
+	}
+}
+
+func TestStoreMigrate_Targets(t *testing.T) {
+	store, source := setupTestStore(t)
+	defer os.RemoveAll(source)
+	if err := store.Save(core.NewMatrix("idx", core.DefaultBounds())); err != nil {
+		t.Fatal(err)
+	}
+
+	occupied := t.TempDir()
+	os.WriteFile(filepath.Join(occupied, "other"), []byte("x"), 0644)
+	for _, target := range []string{"", source, filepath.Join(source, "nested"), filepath.Dir(source), occupied} {
+		if _, err := store.Migrate(context.Background(), target, MigrationOptions{}); !errors.Is(err, ErrInvalidMigrationTarget) {
+			t.Errorf("%q: expected ErrInvalidMigrationTarget, got %v", target, err)
+		}
+	}
+
+	// A cancelled migration leaves the store where it was, and its copy can
+	// be taken over by the next one.
+	target := filepath.Join(t.TempDir(), "moved")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := store.Migrate(ctx, target, MigrationOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled migration to fail, got %v", err)
+	}
+	if store.BasePath() != source {
+		t.Fatalf("expected the store to stay in %s, got %s", source, store.BasePath())
+	}
+
+	plan, err := store.PlanMigration(target, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Files == 0 || plan.Bytes == 0 || plan.Indexes != 1 || !plan.Fits || plan.EstimatedPasses != 2 {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	if _, err := store.Migrate(context.Background(), target, MigrationOptions{}); err != nil {
+		t.Fatalf("expected the migration to reuse the cancelled one's target, got %v", err)
+	}
+	if !store.Exists("idx") || store.BasePath() != target {
+		t.Errorf("expected the index served from %s", target)
+	}
+}
@@ -0,0 +1,177 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+const (
+	startupReportPrefix = "startup-"
+	startupReportSuffix = ".json"
+)
+
+// StartupReport records what NewStoreWithDurability did to bring the store
+// up: WAL replay, index rebuilding and checksum repair.
+type StartupReport struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+
+	DataDir DataDirReport `json:"dataDir"`
+
+	// IndexRebuilt is set when index.nrdb could not be loaded and was
+	// rebuilt from the data files; IndexError says why.
+	IndexRebuilt bool   `json:"indexRebuilt"`
+	IndexError   string `json:"indexError,omitempty"`
+
+	WAL    WALReplayReport      `json:"wal"`
+	Repair *StartupRepairReport `json:"repair"` // nil when startup repair is disabled
+
+	Indexes int           `json:"indexes"` // persisted indexes after startup
+	Config  StartupConfig `json:"config"`
+
+	// Path is where the report was written; empty if writing failed.
+	Path string `json:"path,omitempty"`
+}
+
+// WALReplayReport summarizes the WAL records applied at startup.
+type WALReplayReport struct {
+	Records        int            `json:"records"`
+	Indexes        []core.IndexID `json:"indexes"`
+	TruncatedBytes int64          `json:"truncatedBytes"` // torn or corrupt tail discarded
+}
+
+// StartupRepairReport lists what startup checksum repair changed.
+type StartupRepairReport struct {
+	CheckedFiles   int            `json:"checkedFiles"`
+	RemovedFiles   []RemovedFile  `json:"removedFiles"`
+	DroppedEntries []core.IndexID `json:"droppedEntries"` // index entries without a data file
+	TooNewIndexes  []core.IndexID `json:"tooNewIndexes"`  // data files in a newer format, left in place
+}
+
+// RemovedFile is a corrupt data file deleted by checksum repair. The index
+// it held is gone and must be restored from backup.
+type RemovedFile struct {
+	Index core.IndexID `json:"index"`
+	Path  string       `json:"path"`
+	Error string       `json:"error"`
+}
+
+// StartupConfig is the store configuration a StartupReport was made with.
+type StartupConfig struct {
+	DataPath                   string `json:"dataPath"`
+	Compress                   bool   `json:"compress"`
+	WALEnabled                 bool   `json:"walEnabled"`
+	FsyncPolicy                string `json:"fsyncPolicy"`
+	FsyncInterval              string `json:"fsyncInterval"`
+	ChecksumValidationInterval string `json:"checksumValidationInterval"`
+	StartupRepair              bool   `json:"startupRepair"`
+	ManifestRetain             int    `json:"manifestRetain"`
+	StartupReportRetain        int    `json:"startupReportRetain"`
+}
+
+// Summary renders the report as one log line, e.g. "data directory schema
+// 1, recovered 342 WAL records across 12 indexes, removed 1 corrupt file
+// (index user-99), 2.3s".
+func (r *StartupReport) Summary() string {
+	parts := []string{fmt.Sprintf("data directory schema %d", r.DataDir.SchemaVersion)}
+	switch {
+	case r.DataDir.UpgradedFrom > 0:
+		parts[0] += fmt.Sprintf(" (upgraded from %d)", r.DataDir.UpgradedFrom)
+	case r.DataDir.Stamped:
+		parts[0] += " (stamped)"
+	}
+	parts = append(parts, fmt.Sprintf("recovered %d WAL records across %d indexes", r.WAL.Records, len(r.WAL.Indexes)))
+	if r.IndexRebuilt {
+		parts = append(parts, "rebuilt index from data files")
+	}
+	if r.Repair != nil && len(r.Repair.RemovedFiles) > 0 {
+		ids := make([]string, len(r.Repair.RemovedFiles))
+		for i, f := range r.Repair.RemovedFiles {
+			ids[i] = string(f.Index)
+		}
+		noun, label := "file", "index"
+		if len(ids) > 1 {
+			noun, label = "files", "indexes"
+		}
+		parts = append(parts, fmt.Sprintf("removed %d corrupt %s (%s %s)", len(ids), noun, label, strings.Join(ids, ", ")))
+	}
+	if r.Repair != nil && len(r.Repair.DroppedEntries) > 0 {
+		parts = append(parts, fmt.Sprintf("dropped %d index entries without data", len(r.Repair.DroppedEntries)))
+	}
+	if r.Repair != nil && len(r.Repair.TooNewIndexes) > 0 {
+		parts = append(parts, fmt.Sprintf("kept %d data files in a newer format", len(r.Repair.TooNewIndexes)))
+	}
+	parts = append(parts, fmt.Sprintf("%.1fs", float64(r.DurationMs)/1000))
+	return strings.Join(parts, ", ")
+}
+
+// StartupReport returns the report of this store's startup.
+func (s *Store) StartupReport() *StartupReport {
+	return s.startupReport
+}
+
+// writeStartupReport persists r under reports/ and removes all but the
+// retain most recent reports (0 keeps all).
+func (s *Store) writeStartupReport(r *StartupReport, retain int) error {
+	dir := filepath.Join(s.basePath(), "reports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := startupReportPrefix + r.StartedAt.UTC().Format("20060102T150405.000000000Z") + startupReportSuffix
+	path := filepath.Join(dir, name)
+	if err := s.writeAtomically(path, data, 0644); err != nil {
+		return err
+	}
+	r.Path = path
+
+	if retain < 1 {
+		return nil
+	}
+	reports, err := startupReportFiles(dir)
+	if err != nil {
+		return err
+	}
+	for len(reports) > retain {
+		if _, err := removeIfExists(filepath.Join(dir, reports[0])); err != nil {
+			return err
+		}
+		reports = reports[1:]
+	}
+	return nil
+}
+
+// startupReportFiles lists report file names in dir, oldest first.
+func startupReportFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), startupReportPrefix) && strings.HasSuffix(e.Name(), startupReportSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func sortedIndexIDs(set map[core.IndexID]struct{}) []core.IndexID {
+	ids := make([]core.IndexID, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
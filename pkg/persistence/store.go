@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -11,9 +12,11 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/tracing"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
@@ -33,6 +36,33 @@ type DurabilityConfig struct {
 	FsyncInterval              time.Duration
 	ChecksumValidationInterval time.Duration
 	StartupRepair              bool
+
+	// ManifestRetain is how many manifest/checkpoint versions to keep on
+	// disk, including the current one. 0 keeps every version.
+	ManifestRetain int
+
+	// StartupReportRetain is how many startup reports to keep under
+	// reports/. 0 keeps every report.
+	StartupReportRetain int
+
+	// RetainVersions is how many earlier data files to keep per index
+	// under data/versions/<index>/ for point-in-time reads and restores.
+	// 0 disables versioning. RetainVersionsMaxAge, when > 0, also removes
+	// versions older than it.
+	RetainVersions       int
+	RetainVersionsMaxAge time.Duration
+
+	// FlushRetryBackoff is the wait before retrying an index whose flush
+	// failed, doubled per further failure up to FlushRetryMaxBackoff. 0
+	// retries on every flush pass.
+	FlushRetryBackoff    time.Duration
+	FlushRetryMaxBackoff time.Duration
+
+	// FlushStaleAfter lists an index that has failed to persist for this
+	// long among the stale indexes; FlushStaleFailAfter fails readiness.
+	// 0 disables either.
+	FlushStaleAfter     time.Duration
+	FlushStaleFailAfter time.Duration
 }
 
 // DefaultDurabilityConfig returns the default durability profile.
@@ -43,6 +73,12 @@ func DefaultDurabilityConfig() DurabilityConfig {
 		FsyncInterval:              1 * time.Second,
 		ChecksumValidationInterval: 0,
 		StartupRepair:              true,
+		ManifestRetain:             5,
+		StartupReportRetain:        10,
+		FlushRetryBackoff:          30 * time.Second,
+		FlushRetryMaxBackoff:       10 * time.Minute,
+		FlushStaleAfter:            15 * time.Minute,
+		FlushStaleFailAfter:        time.Hour,
 	}
 }
 
@@ -61,6 +97,30 @@ func (c DurabilityConfig) normalized() DurabilityConfig {
 	if n.ChecksumValidationInterval < 0 {
 		n.ChecksumValidationInterval = 0
 	}
+	if n.ManifestRetain < 0 {
+		n.ManifestRetain = 0
+	}
+	if n.StartupReportRetain < 0 {
+		n.StartupReportRetain = 0
+	}
+	if n.RetainVersions < 0 {
+		n.RetainVersions = 0
+	}
+	if n.RetainVersionsMaxAge < 0 {
+		n.RetainVersionsMaxAge = 0
+	}
+	if n.FlushRetryBackoff < 0 {
+		n.FlushRetryBackoff = 0
+	}
+	if n.FlushRetryMaxBackoff < n.FlushRetryBackoff {
+		n.FlushRetryMaxBackoff = n.FlushRetryBackoff
+	}
+	if n.FlushStaleAfter < 0 {
+		n.FlushStaleAfter = 0
+	}
+	if n.FlushStaleFailAfter < 0 {
+		n.FlushStaleFailAfter = 0
+	}
 	return n
 }
 
@@ -68,6 +128,53 @@ type walRecord struct {
 	Op      string       `msgpack:"op"`
 	IndexID core.IndexID `msgpack:"index_id"`
 	Data    []byte       `msgpack:"data,omitempty"`
+
+	// Time is when the record was appended, in Unix nanoseconds; 0 in
+	// records written before WAL archiving.
+	Time int64 `msgpack:"time,omitempty"`
+}
+
+// encodeWALRecord frames record as the WAL stores it: its length, its
+// msgpack encoding and a CRC-32 of the encoding.
+func encodeWALRecord(record walRecord) ([]byte, error) {
+	payload, err := msgpack.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4+len(payload)+4)
+	binary.LittleEndian.PutUint32(buf[:4], uint32(len(payload)))
+	copy(buf[4:4+len(payload)], payload)
+	binary.LittleEndian.PutUint32(buf[4+len(payload):], crc32.ChecksumIEEE(payload))
+	return buf, nil
+}
+
+// scanWALRecords calls fn with each intact record framed in data, in
+// order, stopping at the first torn or corrupt one. It returns the offset
+// after the last record passed to fn.
+func scanWALRecords(data []byte, fn func(walRecord) error) (int, error) {
+	offset := 0
+	for len(data)-offset >= 8 {
+		recordLen := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+		if recordLen <= 0 || recordLen > len(data)-offset-8 {
+			break
+		}
+		end := offset + 4 + recordLen + 4
+		payload := data[offset+4 : offset+4+recordLen]
+		checksum := binary.LittleEndian.Uint32(data[offset+4+recordLen : end])
+		if crc32.ChecksumIEEE(payload) != checksum {
+			break
+		}
+
+		var record walRecord
+		if err := msgpack.Unmarshal(payload, &record); err != nil {
+			break
+		}
+		if err := fn(record); err != nil {
+			return offset, err
+		}
+		offset = end
+	}
+	return offset, nil
 }
 
 type manifestEntry struct {
@@ -81,15 +188,33 @@ type IntegrityReport struct {
 	CheckedFiles    int
 	CorruptFiles    int
 	RepairedEntries int
+
+	// CorruptIndexes names the indexes whose data file failed to decode.
+	// With repair, RemovedFiles lists the files deleted for them and
+	// DroppedEntries the index entries that had no data file.
+	CorruptIndexes []core.IndexID
+	RemovedFiles   []RemovedFile
+	DroppedEntries []core.IndexID
+
+	// TooNewIndexes names the indexes whose data file was written in a
+	// newer format than this server reads (ErrFormatTooNew). They are not
+	// counted as corrupt and repair never removes them.
+	TooNewIndexes []core.IndexID
+
+	// InvalidContentNeurons counts neurons whose content is not valid UTF-8 or
+	// carries disallowed control characters. Report-only: ValidateDataFiles
+	// never rewrites content; use RepairContent for that.
+	InvalidContentNeurons int
+	InvalidContentIndexes []core.IndexID
 }
 
 // Store handles file-based persistence of matrices
 type Store struct {
-	basePath string
-	codec    *Codec
+	// root is the data directory; a storage migration switches it.
+	root  atomic.Pointer[string]
+	codec *Codec
 
 	durability DurabilityConfig
-	walPath    string
 
 	// In-memory index of persisted users
 	index   map[core.IndexID]*Snapshot
@@ -100,6 +225,10 @@ type Store struct {
 	writeMu       sync.Mutex
 	flushInterval time.Duration
 	walMu         sync.Mutex
+
+	// flushFailures tracks indexes whose latest flush failed; guarded by
+	// writeMu.
+	flushFailures map[core.IndexID]*FlushFailure
 	checkpointMu  sync.Mutex
 
 	// Stats
@@ -109,6 +238,60 @@ type Store struct {
 	syncMu          sync.Mutex
 	lastSync        time.Time
 	manifestVersion uint64
+
+	// History pruning counters
+	manifestsPruned   atomic.Uint64
+	checkpointsPruned atomic.Uint64
+
+	startupReport *StartupReport
+	dirVersion    DirVersion
+
+	// blobs holds attachment blobs under data/blobs/.
+	blobs *BlobStore
+
+	// disk, when set, is checked before large flushes; its reading is
+	// added to write errors.
+	disk   *DiskMonitor
+	diskMu sync.RWMutex
+
+	// openFile opens files for writing; tests replace it to fail writes.
+	openFile func(name string, flag int, perm os.FileMode) (writableFile, error)
+
+	// now reads the clock for flush backoff; tests replace it.
+	now func() time.Time
+
+	// Divergence detection. files holds each index's data file as the
+	// store last wrote or checked it (nil for no file); generations count
+	// the times a data file was deleted or changed behind the store's back.
+	// fileMu guards both and is held across the store's own writes of data
+	// files, so they are never mistaken for outside changes.
+	fileMu      sync.Mutex
+	files       map[core.IndexID]os.FileInfo
+	generations map[core.IndexID]uint64
+
+	// usage holds each index's disk usage for quotas.
+	usage usageTracker
+
+	// archive, when set, copies appended WAL records to the WAL archive.
+	archive *walArchiver
+
+	// changes collects, while a storage migration runs, what the store
+	// wrote since the migration's last copy pass; trackMu guards it.
+	// migrating is set for the migration's duration.
+	trackMu   sync.Mutex
+	changes   *migrationChanges
+	migrating atomic.Bool
+}
+
+// writableFile is the part of *os.File the store writes through.
+type writableFile interface {
+	Write(p []byte) (int, error)
+	Sync() error
+	Close() error
+}
+
+func openOSFile(name string, flag int, perm os.FileMode) (writableFile, error) {
+	return os.OpenFile(name, flag, perm)
 }
 
 // NewStore creates a new persistence store
@@ -117,7 +300,10 @@ func NewStore(basePath string, compress bool) (*Store, error) {
 }
 
 // NewStoreWithDurability creates a new persistence store with durability settings.
+// It leaves a StartupReport of the recovery it performed in memory and
+// under reports/.
 func NewStoreWithDurability(basePath string, compress bool, durability DurabilityConfig) (*Store, error) {
+	started := time.Now()
 	durability = durability.normalized()
 
 	// Create directories
@@ -137,17 +323,50 @@ func NewStoreWithDurability(basePath string, compress bool, durability Durabilit
 	}
 
 	s := &Store{
-		basePath:      basePath,
 		codec:         NewCodec(compress),
 		durability:    durability,
-		walPath:       filepath.Join(basePath, "wal.log"),
 		index:         make(map[core.IndexID]*Snapshot),
 		pendingWrites: make(map[core.IndexID]*core.Matrix),
+		flushFailures: make(map[core.IndexID]*FlushFailure),
 		flushInterval: 1 * time.Second,
+		openFile:      openOSFile,
+		now:           time.Now,
+		files:         make(map[core.IndexID]os.FileInfo),
+		generations:   make(map[core.IndexID]uint64),
+		usage:         usageTracker{usage: make(map[core.IndexID]*DiskUsage), blobSizes: make(map[string]int64)},
+	}
+	s.root.Store(&basePath)
+
+	report := &StartupReport{
+		StartedAt: started,
+		Config: StartupConfig{
+			DataPath:                   basePath,
+			Compress:                   compress,
+			WALEnabled:                 durability.WALEnabled,
+			FsyncPolicy:                durability.FsyncPolicy,
+			FsyncInterval:              durability.FsyncInterval.String(),
+			ChecksumValidationInterval: durability.ChecksumValidationInterval.String(),
+			StartupRepair:              durability.StartupRepair,
+			ManifestRetain:             durability.ManifestRetain,
+			StartupReportRetain:        durability.StartupReportRetain,
+		},
+	}
+
+	// Refuse a directory written by a newer server before anything in it
+	// is read, replayed or repaired.
+	dirReport, err := s.checkDirVersion()
+	if err != nil {
+		return nil, err
+	}
+	report.DataDir = dirReport
+	if s.blobs, err = NewBlobStore(filepath.Join(dataPath, "blobs")); err != nil {
+		return nil, err
 	}
 
 	// Load index from disk
 	if err := s.loadIndex(); err != nil {
+		report.IndexRebuilt = true
+		report.IndexError = err.Error()
 		if !s.durability.StartupRepair {
 			return nil, fmt.Errorf("failed to load index: %w", err)
 		}
@@ -159,22 +378,46 @@ func NewStoreWithDurability(basePath string, compress bool, durability Durabilit
 		}
 	}
 
-	applied, err := s.replayWAL()
+	replayed, err := s.replayWAL()
 	if err != nil {
 		return nil, fmt.Errorf("failed to replay wal: %w", err)
 	}
-	if applied > 0 {
+	report.WAL = replayed
+	if replayed.Records > 0 {
 		if err := s.saveIndex(); err != nil {
 			return nil, fmt.Errorf("failed to persist replayed index: %w", err)
 		}
 	}
 
 	if s.durability.StartupRepair {
-		if _, err := s.ValidateDataFiles(true); err != nil {
+		integrity, err := s.ValidateDataFiles(true)
+		if err != nil {
 			return nil, fmt.Errorf("failed startup checksum validation/repair: %w", err)
 		}
+		report.Repair = &StartupRepairReport{
+			CheckedFiles:   integrity.CheckedFiles,
+			RemovedFiles:   integrity.RemovedFiles,
+			DroppedEntries: integrity.DroppedEntries,
+			TooNewIndexes:  integrity.TooNewIndexes,
+		}
+		if report.Repair.RemovedFiles == nil {
+			report.Repair.RemovedFiles = []RemovedFile{}
+		}
+		if report.Repair.DroppedEntries == nil {
+			report.Repair.DroppedEntries = []core.IndexID{}
+		}
+		if report.Repair.TooNewIndexes == nil {
+			report.Repair.TooNewIndexes = []core.IndexID{}
+		}
 	}
 
+	report.Indexes = len(s.ListIndexes())
+	report.DurationMs = time.Since(started).Milliseconds()
+	s.startupReport = report
+	// A report that cannot be written must not keep the store from
+	// starting; its empty Path tells the caller.
+	_ = s.writeStartupReport(report, durability.StartupReportRetain)
+
 	return s, nil
 }
 
@@ -214,8 +457,11 @@ func (s *Store) SaveAsync(matrix *core.Matrix) error {
 	return nil
 }
 
-// flushUser writes a specific user's matrix to disk
-func (s *Store) flushUser(indexID core.IndexID) error {
+// flushUser writes a specific user's matrix to disk. A failed flush stays
+// pending for the next one, unless a newer state was queued meanwhile, and
+// is recorded for backoff and staleness reporting. A flush refused because
+// the data file changed on disk is dropped: the state it held is stale.
+func (s *Store) flushUser(indexID core.IndexID) (err error) {
 	s.writeMu.Lock()
 	matrix, ok := s.pendingWrites[indexID]
 	if !ok {
@@ -225,21 +471,77 @@ func (s *Store) flushUser(indexID core.IndexID) error {
 	delete(s.pendingWrites, indexID)
 	s.writeMu.Unlock()
 
-	// Encode matrix
-	data, err := s.codec.Encode(matrix)
+	_, span := tracing.StartForIndex(context.Background(), "persistence.flush", indexID)
+	defer func() { tracing.End(span, err) }()
+
+	err = s.writeMatrix(indexID, matrix)
+	if errors.Is(err, core.ErrIndexDiverged) {
+		s.writeMu.Lock()
+		delete(s.flushFailures, indexID)
+		s.writeMu.Unlock()
+		return err
+	}
+	if err != nil {
+		s.writeMu.Lock()
+		if _, queued := s.pendingWrites[indexID]; !queued {
+			s.pendingWrites[indexID] = matrix
+		}
+		s.writeMu.Unlock()
+	}
+	s.recordFlush(indexID, err)
+	return err
+}
+
+// writeMatrix writes matrix as indexID's data file and records it in the
+// manifest.
+func (s *Store) writeMatrix(indexID core.IndexID, matrix *core.Matrix) error {
+	// The worker may go on changing matrix: the version, usage and index
+	// entry are read from the snapshot the data was encoded from.
+	data, snapshot, err := s.codec.encodeSnapshot(matrix)
 	if err != nil {
 		return fmt.Errorf("encode failed: %w", err)
 	}
 
+	// The volume must be able to hold the flush.
+	if err := s.checkSpace(len(data)); err != nil {
+		return err
+	}
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if s.checkFileLocked(indexID) {
+		return fmt.Errorf("%w: data file of %s changed since it was loaded", core.ErrIndexDiverged, indexID)
+	}
+
+	// Keep the previous state when the matrix has changed since it was
+	// written.
+	s.indexMu.RLock()
+	prev, ok := s.index[indexID]
+	s.indexMu.RUnlock()
+	if !ok || prev.Version != snapshot.Version {
+		if err := s.rotateVersion(indexID); err != nil {
+			return fmt.Errorf("version rotation failed: %w", err)
+		}
+	}
+
 	filename := s.userFilePath(indexID)
 	if err := s.writeAtomically(filename, data, 0644); err != nil {
 		return fmt.Errorf("write failed: %w", err)
 	}
+	if s.durability.RetainVersions > 0 {
+		// Version IDs come from the mtime; set it from the wall clock since
+		// filesystem timestamps can lag it by a tick.
+		now := time.Now()
+		os.Chtimes(filename, now, now)
+	}
+	s.recordFileLocked(indexID)
+	s.recordUsage(indexID, int64(len(data)), matrix, snapshot)
+	s.markChanged(indexID)
 
 	// Update index
-	snapshot := CreateSnapshot(matrix)
+	entry := CreateSnapshot(snapshot)
 	s.indexMu.Lock()
-	s.index[indexID] = &snapshot
+	s.index[indexID] = &entry
 	s.totalWrites++
 	s.indexMu.Unlock()
 
@@ -247,12 +549,28 @@ func (s *Store) flushUser(indexID core.IndexID) error {
 	return s.saveIndex()
 }
 
-// FlushAll writes all pending matrices
+// PendingFootprint returns how many matrices wait to be flushed and their
+// approximate memory footprint.
+func (s *Store) PendingFootprint() (int, int64) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	var bytes int64
+	for _, m := range s.pendingWrites {
+		bytes += m.Footprint()
+	}
+	return len(s.pendingWrites), bytes
+}
+
+// FlushAll writes all pending matrices, skipping indexes still backing off
+// from a failed flush.
 func (s *Store) FlushAll() error {
+	now := s.now()
 	s.writeMu.Lock()
 	users := make([]core.IndexID, 0, len(s.pendingWrites))
 	for id := range s.pendingWrites {
-		users = append(users, id)
+		if s.flushDue(id, now) {
+			users = append(users, id)
+		}
 	}
 	s.writeMu.Unlock()
 
@@ -281,6 +599,8 @@ func (s *Store) Load(indexID core.IndexID) (*core.Matrix, error) {
 	if err != nil {
 		return nil, fmt.Errorf("decode failed: %w", err)
 	}
+	// Nothing else holds the decoded matrix yet.
+	s.recordUsage(indexID, int64(len(data)), matrix, matrix)
 
 	s.indexMu.Lock()
 	s.totalReads++
@@ -305,23 +625,38 @@ func (s *Store) Exists(indexID core.IndexID) bool {
 	return err == nil
 }
 
-// Delete removes a user's matrix from disk
+// Delete removes a user's matrix from disk. With versioning enabled the
+// last data file is kept as a version and can be restored. It starts a new
+// generation of the index, so a worker still holding the deleted state is
+// never persisted over it.
 func (s *Store) Delete(indexID core.IndexID) error {
 	if err := s.appendWAL(walRecord{Op: walOpDelete, IndexID: indexID}); err != nil {
 		return err
 	}
 
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	s.generations[indexID]++
+
 	filename := s.userFilePath(indexID)
+	if err := s.rotateVersion(indexID); err != nil {
+		return fmt.Errorf("version rotation failed: %w", err)
+	}
 
 	s.writeMu.Lock()
 	delete(s.pendingWrites, indexID)
+	delete(s.flushFailures, indexID)
 	s.writeMu.Unlock()
 
 	s.indexMu.Lock()
 	delete(s.index, indexID)
 	s.indexMu.Unlock()
+	s.forgetUsage(indexID)
 
-	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+	err := os.Remove(filename)
+	s.recordFileLocked(indexID)
+	s.markChanged(indexID)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
@@ -349,9 +684,28 @@ func (s *Store) ListIndexes() []core.IndexID {
 	return users
 }
 
+// basePath returns the data directory.
+func (s *Store) basePath() string {
+	return *s.root.Load()
+}
+
+// BasePath returns the data directory the store reads and writes, which a
+// storage migration may have moved from the configured one.
+func (s *Store) BasePath() string {
+	return s.basePath()
+}
+
+// walPath returns the path of the write-ahead log.
+func (s *Store) walPath() string {
+	return filepath.Join(s.basePath(), walFile)
+}
+
+// walFile is the WAL's file name in the data directory.
+const walFile = "wal.log"
+
 // userFilePath returns the file path for a user's matrix
 func (s *Store) userFilePath(indexID core.IndexID) string {
-	return filepath.Join(s.basePath, "data", string(indexID)+".nrdb")
+	return filepath.Join(s.basePath(), "data", string(indexID)+".nrdb")
 }
 
 // loadIndex loads the index from disk
@@ -387,7 +741,7 @@ func (s *Store) saveIndex() error {
 	syncVersion := s.manifestVersion + 1
 	checkpointName := fmt.Sprintf("checkpoint-%020d.nrdb", syncVersion)
 	checkpointRelPath := filepath.ToSlash(filepath.Join("checkpoints", checkpointName))
-	checkpointPath := filepath.Join(s.basePath, "checkpoints", checkpointName)
+	checkpointPath := filepath.Join(s.basePath(), "checkpoints", checkpointName)
 
 	if err := s.writeAtomically(checkpointPath, data, 0644); err != nil {
 		return err
@@ -404,30 +758,38 @@ func (s *Store) saveIndex() error {
 	}
 
 	manifestName := fmt.Sprintf("MANIFEST-%020d.json", syncVersion)
-	manifestPath := filepath.Join(s.basePath, "manifest", manifestName)
+	manifestPath := filepath.Join(s.basePath(), "manifest", manifestName)
 	if err := s.writeAtomically(manifestPath, manifestData, 0644); err != nil {
 		return err
 	}
 
-	currentPath := filepath.Join(s.basePath, "manifest", "CURRENT")
+	currentPath := filepath.Join(s.basePath(), "manifest", "CURRENT")
 	if err := s.writeAtomically(currentPath, []byte(manifestName), 0644); err != nil {
 		return err
 	}
 
-	legacyIndexPath := filepath.Join(s.basePath, "index.nrdb")
+	legacyIndexPath := filepath.Join(s.basePath(), "index.nrdb")
 	if err := s.writeAtomically(legacyIndexPath, data, 0644); err != nil {
 		return err
 	}
 
 	s.manifestVersion = syncVersion
+	s.markCatalogChanged()
+
+	// CURRENT already points at syncVersion, so pruning is best-effort: a
+	// failure or crash here only leaves extra history for the next flush.
+	manifests, checkpoints, _ := pruneManifestHistory(s.basePath(), syncVersion, s.durability.ManifestRetain)
+	s.manifestsPruned.Add(uint64(manifests))
+	s.checkpointsPruned.Add(uint64(checkpoints))
 	return nil
 }
 
 // ValidateDataFiles verifies checksums/decoding of persisted .nrdb files.
 // When repair=true, corrupt files are removed and index entries are repaired.
+// Files in a newer format are reported apart and always left in place.
 func (s *Store) ValidateDataFiles(repair bool) (IntegrityReport, error) {
 	report := IntegrityReport{}
-	dataPath := filepath.Join(s.basePath, "data")
+	dataPath := filepath.Join(s.basePath(), "data")
 
 	entries, err := os.ReadDir(dataPath)
 	if err != nil {
@@ -447,15 +809,25 @@ func (s *Store) ValidateDataFiles(repair bool) (IntegrityReport, error) {
 		path := filepath.Join(dataPath, entry.Name())
 
 		raw, readErr := os.ReadFile(path)
+		var matrix *core.Matrix
 		if readErr == nil {
-			_, readErr = s.codec.Decode(raw)
+			matrix, readErr = s.codec.Decode(raw)
 		}
 
 		if readErr == nil {
+			if bad := countInvalidContent(matrix); bad > 0 {
+				report.InvalidContentNeurons += bad
+				report.InvalidContentIndexes = append(report.InvalidContentIndexes, indexID)
+			}
+			continue
+		}
+		if errors.Is(readErr, ErrFormatTooNew) {
+			report.TooNewIndexes = append(report.TooNewIndexes, indexID)
 			continue
 		}
 
 		report.CorruptFiles++
+		report.CorruptIndexes = append(report.CorruptIndexes, indexID)
 		if !repair {
 			continue
 		}
@@ -463,6 +835,8 @@ func (s *Store) ValidateDataFiles(repair bool) (IntegrityReport, error) {
 		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 			return report, err
 		}
+		report.RemovedFiles = append(report.RemovedFiles, RemovedFile{Index: indexID, Path: path, Error: readErr.Error()})
+		s.markChanged(indexID)
 
 		s.indexMu.Lock()
 		delete(s.index, indexID)
@@ -479,6 +853,7 @@ func (s *Store) ValidateDataFiles(repair bool) (IntegrityReport, error) {
 			}
 			delete(s.index, indexID)
 			report.RepairedEntries++
+			report.DroppedEntries = append(report.DroppedEntries, indexID)
 		}
 		s.indexMu.Unlock()
 
@@ -492,9 +867,58 @@ func (s *Store) ValidateDataFiles(repair bool) (IntegrityReport, error) {
 	return report, nil
 }
 
+// countInvalidContent returns the number of neurons whose content fails
+// core.CheckContentEncoding.
+func countInvalidContent(matrix *core.Matrix) int {
+	bad := 0
+	for _, n := range matrix.Neurons {
+		if core.CheckContentEncoding(n.Content) != nil {
+			bad++
+		}
+	}
+	return bad
+}
+
+// RepairContent rewrites persisted neurons whose content fails
+// core.CheckContentEncoding using core.SanitizeContent, and saves every
+// affected index. It is a maintenance operation meant to run while no
+// workers hold the indexes in memory. Returns the number of repaired neurons
+// per index.
+func (s *Store) RepairContent() (map[core.IndexID]int, error) {
+	repaired := make(map[core.IndexID]int)
+	for _, indexID := range s.ListIndexes() {
+		matrix, err := s.Load(indexID)
+		if err != nil {
+			return repaired, fmt.Errorf("load %s: %w", indexID, err)
+		}
+
+		fixed := 0
+		for _, n := range matrix.Neurons {
+			if core.CheckContentEncoding(n.Content) == nil {
+				continue
+			}
+			n.Content = core.SanitizeContent(n.Content)
+			n.ContentHash = core.HashContent(n.Content)
+			fixed++
+		}
+		if fixed == 0 {
+			continue
+		}
+
+		matrix.Terms = nil // rebuilt from the sanitized content on first search
+		matrix.ModifiedAt = time.Now()
+		matrix.Version++
+		if err := s.Save(matrix); err != nil {
+			return repaired, fmt.Errorf("save %s: %w", indexID, err)
+		}
+		repaired[indexID] = fixed
+	}
+	return repaired, nil
+}
+
 // rebuildIndex rebuilds index from data files
 func (s *Store) rebuildIndex() error {
-	dataPath := filepath.Join(s.basePath, "data")
+	dataPath := filepath.Join(s.basePath(), "data")
 
 	entries, err := os.ReadDir(dataPath)
 	if err != nil {
@@ -530,71 +954,51 @@ func (s *Store) rebuildIndex() error {
 	return nil
 }
 
-func (s *Store) replayWAL() (int, error) {
+// replayWAL applies every intact WAL record and truncates the WAL after
+// the last one.
+func (s *Store) replayWAL() (report WALReplayReport, err error) {
+	report.Indexes = []core.IndexID{}
 	if !s.durability.WALEnabled {
-		return 0, nil
+		return report, nil
 	}
 
 	s.walMu.Lock()
 	defer s.walMu.Unlock()
 
-	data, err := os.ReadFile(s.walPath)
+	data, err := os.ReadFile(s.walPath())
 	if err != nil {
 		if os.IsNotExist(err) {
-			return 0, nil
+			return report, nil
 		}
-		return 0, err
+		return report, err
 	}
 
-	offset := 0
-	applied := 0
-	for {
-		if len(data)-offset < 8 {
-			break
-		}
-
-		recordLen := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
-		if recordLen <= 0 {
-			break
-		}
-		if recordLen > len(data)-offset-8 {
-			break
-		}
-
-		end := offset + 4 + recordLen + 4
-		if end > len(data) {
-			break
-		}
-
-		payload := data[offset+4 : offset+4+recordLen]
-		checksum := binary.LittleEndian.Uint32(data[offset+4+recordLen : end])
-		if crc32.ChecksumIEEE(payload) != checksum {
-			break
-		}
-
-		var record walRecord
-		if err := msgpack.Unmarshal(payload, &record); err != nil {
-			break
-		}
-
+	indexes := make(map[core.IndexID]struct{})
+	defer func() { report.Indexes = sortedIndexIDs(indexes) }()
+	offset, err := scanWALRecords(data, func(record walRecord) error {
 		if err := s.applyWALRecord(record); err != nil {
-			return applied, err
+			return err
 		}
-
-		offset = end
-		applied++
+		report.Records++
+		indexes[record.IndexID] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return report, err
 	}
 
 	if offset < len(data) {
+		report.TruncatedBytes = int64(len(data) - offset)
 		if err := s.truncateWALLocked(int64(offset)); err != nil {
-			return applied, err
+			return report, err
 		}
 	}
 
-	return applied, nil
+	return report, nil
 }
 
 func (s *Store) applyWALRecord(record walRecord) error {
+	defer s.markChanged(record.IndexID)
 	switch record.Op {
 	case walOpPut:
 		if len(record.Data) == 0 {
@@ -628,25 +1032,29 @@ func (s *Store) applyWALRecord(record walRecord) error {
 	return nil
 }
 
-func (s *Store) appendWAL(record walRecord) error {
+func (s *Store) appendWAL(record walRecord) (err error) {
 	if !s.durability.WALEnabled {
 		return nil
 	}
 
+	_, span := tracing.StartForIndex(context.Background(), "persistence.wal_append", record.IndexID)
+	defer func() { tracing.End(span, err) }()
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("wal append failed: %w", s.diskError(err))
+		}
+	}()
+
 	s.walMu.Lock()
 	defer s.walMu.Unlock()
 
-	payload, err := msgpack.Marshal(record)
+	record.Time = time.Now().UnixNano()
+	buf, err := encodeWALRecord(record)
 	if err != nil {
 		return err
 	}
 
-	buf := make([]byte, 4+len(payload)+4)
-	binary.LittleEndian.PutUint32(buf[:4], uint32(len(payload)))
-	copy(buf[4:4+len(payload)], payload)
-	binary.LittleEndian.PutUint32(buf[4+len(payload):], crc32.ChecksumIEEE(payload))
-
-	f, err := os.OpenFile(s.walPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	f, err := s.openFile(s.walPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return err
 	}
@@ -655,12 +1063,18 @@ func (s *Store) appendWAL(record walRecord) error {
 	if _, err := f.Write(buf); err != nil {
 		return err
 	}
+	if record.Op == walOpPut {
+		s.addWALUsage(record.IndexID, len(buf))
+	}
+	if s.archive != nil {
+		s.archive.enqueue(record, buf)
+	}
 
 	if s.shouldSync() {
 		if err := f.Sync(); err != nil {
 			return err
 		}
-		if err := s.syncDir(filepath.Dir(s.walPath)); err != nil {
+		if err := s.syncDir(filepath.Dir(s.walPath())); err != nil {
 			return err
 		}
 	}
@@ -681,7 +1095,7 @@ func (s *Store) truncateWAL(size int64) error {
 
 func (s *Store) truncateWALLocked(size int64) error {
 
-	f, err := os.OpenFile(s.walPath, os.O_CREATE|os.O_WRONLY, 0644)
+	f, err := os.OpenFile(s.walPath(), os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
@@ -690,12 +1104,13 @@ func (s *Store) truncateWALLocked(size int64) error {
 	if err := f.Truncate(size); err != nil {
 		return err
 	}
+	s.markWALTruncated()
 
 	if s.shouldSync() {
 		if err := f.Sync(); err != nil {
 			return err
 		}
-		if err := s.syncDir(filepath.Dir(s.walPath)); err != nil {
+		if err := s.syncDir(filepath.Dir(s.walPath())); err != nil {
 			return err
 		}
 	}
@@ -704,7 +1119,7 @@ func (s *Store) truncateWALLocked(size int64) error {
 }
 
 func (s *Store) loadIndexFromManifest() error {
-	currentPath := filepath.Join(s.basePath, "manifest", "CURRENT")
+	currentPath := filepath.Join(s.basePath(), "manifest", "CURRENT")
 	manifestName, err := os.ReadFile(currentPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -718,7 +1133,7 @@ func (s *Store) loadIndexFromManifest() error {
 		return os.ErrNotExist
 	}
 
-	manifestPath := filepath.Join(s.basePath, "manifest", name)
+	manifestPath := filepath.Join(s.basePath(), "manifest", name)
 	manifestData, err := os.ReadFile(manifestPath)
 	if err != nil {
 		return err
@@ -731,7 +1146,7 @@ func (s *Store) loadIndexFromManifest() error {
 
 	checkpointPath := manifest.Checkpoint
 	if !filepath.IsAbs(checkpointPath) {
-		checkpointPath = filepath.Join(s.basePath, filepath.FromSlash(checkpointPath))
+		checkpointPath = filepath.Join(s.basePath(), filepath.FromSlash(checkpointPath))
 	}
 
 	checkpointData, err := os.ReadFile(checkpointPath)
@@ -756,9 +1171,13 @@ func (s *Store) loadIndexFromManifest() error {
 	return nil
 }
 
-func (s *Store) writeAtomically(path string, data []byte, perm os.FileMode) error {
+// writeAtomically replaces path with data through a temporary file. Errors
+// carry the data volume's free space.
+func (s *Store) writeAtomically(path string, data []byte, perm os.FileMode) (err error) {
+	defer func() { err = s.diskError(err) }()
+
 	tmpPath := path + ".tmp"
-	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	f, err := s.openFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
 	if err != nil {
 		return err
 	}
@@ -838,59 +1257,54 @@ func (s *Store) Stats() map[string]any {
 	pendingCount := len(s.pendingWrites)
 	s.writeMu.Unlock()
 
+	versions, versionBytes := s.versionStats()
+	failures := s.FlushFailures()
+
 	return map[string]any{
 		"persisted_users": len(s.index),
 		"pending_writes":  pendingCount,
 		"total_writes":    s.totalWrites,
 		"total_reads":     s.totalReads,
-		"base_path":       s.basePath,
+		"base_path":       s.basePath(),
 		"wal_enabled":     s.durability.WALEnabled,
 		"fsync_policy":    s.durability.FsyncPolicy,
-	}
-}
 
-// StartFlushWorker starts background flush worker
-func (s *Store) StartFlushWorker(interval time.Duration) chan struct{} {
-	stop := make(chan struct{})
+		"manifest_retain":    s.durability.ManifestRetain,
+		"manifests_pruned":   s.manifestsPruned.Load(),
+		"checkpoints_pruned": s.checkpointsPruned.Load(),
 
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
+		"retain_versions": s.durability.RetainVersions,
+		"versions":        versions,
+		"versions_bytes":  versionBytes,
 
-		for {
-			select {
-			case <-stop:
-				s.FlushAll()
-				return
-			case <-ticker.C:
-				s.FlushAll()
-			}
-		}
-	}()
+		"flush_failures": len(failures),
+		"stale_indexes":  staleIndexes(failures),
 
-	return stop
+		"wal_archive": s.WALArchiveStats(),
+	}
 }
 
-// StartChecksumValidationWorker starts periodic checksum validation over persisted data files.
-func (s *Store) StartChecksumValidationWorker(interval time.Duration) chan struct{} {
-	if interval <= 0 {
-		return nil
+// FlushTask returns the background task that flushes pending saves every
+// interval, and once more when it stops.
+func (s *Store) FlushTask(interval time.Duration) core.Task {
+	return core.Task{
+		Name:     "flush",
+		Interval: interval,
+		Run:      func(context.Context) error { return s.FlushAll() },
+		Final:    func() { s.FlushAll() },
 	}
+}
 
-	stop := make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-stop:
-				return
-			case <-ticker.C:
-				_, _ = s.ValidateDataFiles(false)
-			}
-		}
-	}()
-
-	return stop
+// ChecksumValidationTask returns the background task that validates the
+// checksums of persisted data files every interval; it is disabled when
+// interval is not positive.
+func (s *Store) ChecksumValidationTask(interval time.Duration) core.Task {
+	return core.Task{
+		Name:     "checksum-validation",
+		Interval: interval,
+		Run: func(context.Context) error {
+			_, err := s.ValidateDataFiles(false)
+			return err
+		},
+	}
 }
@@ -2,6 +2,8 @@ package persistence
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -482,3 +484,540 @@ func TestStoreStartupRepairRemovesCorruptFiles(t *testing.T) {
 		t.Fatalf("expected corrupt file to be removed during startup repair, stat err=%v", err)
 	}
 }
+
+func TestStoreValidateDataFilesKeepsNewerFormat(t *testing.T) {
+	durability := DurabilityConfig{
+		WALEnabled:    false,
+		FsyncPolicy:   FsyncPolicyOff,
+		FsyncInterval: time.Second,
+	}
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+
+	for _, id := range []core.IndexID{"corrupt-user", "newer-user"} {
+		if err := store.Save(core.NewMatrix(id, core.DefaultBounds())); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+	corruptPath := filepath.Join(tmpDir, "data", "corrupt-user.nrdb")
+	if err := os.WriteFile(corruptPath, []byte("broken-file"), 0644); err != nil {
+		t.Fatalf("failed to corrupt user file: %v", err)
+	}
+	// Header.Version follows the 4 magic bytes, little endian.
+	newerPath := filepath.Join(tmpDir, "data", "newer-user.nrdb")
+	raw, err := os.ReadFile(newerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[4] = FormatVersion + 1
+	if err := os.WriteFile(newerPath, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := store.ValidateDataFiles(true)
+	if err != nil {
+		t.Fatalf("validate data files failed: %v", err)
+	}
+	if report.CheckedFiles != 2 || report.CorruptFiles != 1 || len(report.CorruptIndexes) != 1 || report.CorruptIndexes[0] != "corrupt-user" {
+		t.Fatalf("expected only corrupt-user to be corrupt, got %+v", report)
+	}
+	if len(report.TooNewIndexes) != 1 || report.TooNewIndexes[0] != "newer-user" {
+		t.Fatalf("expected newer-user to be reported as too new, got %v", report.TooNewIndexes)
+	}
+	if _, err := os.Stat(corruptPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the corrupt file to be removed, stat err=%v", err)
+	}
+	if _, err := os.Stat(newerPath); err != nil {
+		t.Fatalf("a file in a newer format must never be removed: %v", err)
+	}
+	if !store.Exists("newer-user") {
+		t.Fatal("a file in a newer format must keep its index entry")
+	}
+}
+
+func TestStoreRefusesNewerDataDirectory(t *testing.T) {
+	durability := DurabilityConfig{
+		WALEnabled:    true,
+		FsyncPolicy:   FsyncPolicyOff,
+		FsyncInterval: time.Second,
+		StartupRepair: true,
+	}
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+
+	if got := store.DirVersion(); got != (DirVersion{SchemaVersion, MinReaderVersion}) {
+		t.Fatalf("a new directory should be stamped with the current schema, got %+v", got)
+	}
+	if report := store.StartupReport(); !report.DataDir.Stamped || report.DataDir.SchemaVersion != SchemaVersion {
+		t.Fatalf("unexpected data directory report %+v", report.DataDir)
+	}
+	if err := store.Save(core.NewMatrix("kept-user", core.DefaultBounds())); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	// A file the newer server wrote in a format this one cannot decode
+	// would be removed by startup repair if it were mistaken for corrupt.
+	if err := os.WriteFile(filepath.Join(tmpDir, "data", "future-user.nrdb"), []byte("future-format"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stamp := fmt.Sprintf(`{"schemaVersion": %d, "minReaderVersion": %d}`, SchemaVersion+2, SchemaVersion+1)
+	if err := os.WriteFile(filepath.Join(tmpDir, "data", dirVersionFile), []byte(stamp), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before := snapshotDir(t, tmpDir)
+
+	_, err := NewStoreWithDurability(tmpDir, true, durability)
+	var tooNew *DataDirTooNewError
+	if !errors.As(err, &tooNew) {
+		t.Fatalf("expected a DataDirTooNewError, got %v", err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("schema version %d", SchemaVersion+2),
+		fmt.Sprintf("supporting schema %d", SchemaVersion+1),
+		fmt.Sprintf("this server supports schema %d", SchemaVersion),
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+
+	after := snapshotDir(t, tmpDir)
+	if len(after) != len(before) {
+		t.Fatalf("refusing to start must not add or remove files: before %v, after %v", before, after)
+	}
+	for path, data := range before {
+		if after[path] != data {
+			t.Errorf("refusing to start must not change %s", path)
+		}
+	}
+}
+
+// snapshotDir returns the content of every file under dir by relative path.
+func snapshotDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestStoreValidateDataFilesReportsInvalidContent(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+
+	m := core.NewMatrix("bad-content-user", core.DefaultBounds())
+	good := core.NewNeuron("perfectly fine — çok güzel 🌱", m.CurrentDim)
+	bad := core.NewNeuron("binary \xff\xfe junk\x00", m.CurrentDim)
+	m.Neurons[good.ID] = good
+	m.Neurons[bad.ID] = bad
+	if err := store.Save(m); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	report, err := store.ValidateDataFiles(false)
+	if err != nil {
+		t.Fatalf("validate data files failed: %v", err)
+	}
+	if report.InvalidContentNeurons != 1 {
+		t.Fatalf("expected InvalidContentNeurons=1, got %d", report.InvalidContentNeurons)
+	}
+	if len(report.InvalidContentIndexes) != 1 || report.InvalidContentIndexes[0] != "bad-content-user" {
+		t.Fatalf("unexpected InvalidContentIndexes %v", report.InvalidContentIndexes)
+	}
+	if report.CorruptFiles != 0 {
+		t.Fatalf("invalid content must not count as file corruption, got %d", report.CorruptFiles)
+	}
+
+	loaded, err := store.Load("bad-content-user")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if loaded.Neurons[bad.ID].Content != bad.Content {
+		t.Fatal("ValidateDataFiles must not rewrite content")
+	}
+}
+
+func TestStoreRepairContent(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+
+	m := core.NewMatrix("repair-content-user", core.DefaultBounds())
+	bad := core.NewNeuron("text \xc0\xaf with\x07 bell", m.CurrentDim)
+	m.Neurons[bad.ID] = bad
+	clean := core.NewMatrix("clean-user", core.DefaultBounds())
+	ok := core.NewNeuron("nothing to fix", clean.CurrentDim)
+	clean.Neurons[ok.ID] = ok
+	for _, matrix := range []*core.Matrix{m, clean} {
+		if err := store.Save(matrix); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	repaired, err := store.RepairContent()
+	if err != nil {
+		t.Fatalf("RepairContent failed: %v", err)
+	}
+	if repaired["repair-content-user"] != 1 {
+		t.Fatalf("expected 1 repaired neuron, got %v", repaired)
+	}
+	if _, touched := repaired["clean-user"]; touched {
+		t.Fatal("clean index should not be rewritten")
+	}
+
+	loaded, err := store.Load("repair-content-user")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	n := loaded.Neurons[bad.ID]
+	if n.Content != "text � with bell" {
+		t.Fatalf("unexpected repaired content %q", n.Content)
+	}
+	if n.ContentHash != core.HashContent(n.Content) {
+		t.Fatal("content hash should be recomputed after repair")
+	}
+
+	report, err := store.ValidateDataFiles(false)
+	if err != nil {
+		t.Fatalf("validate data files failed: %v", err)
+	}
+	if report.InvalidContentNeurons != 0 {
+		t.Fatalf("expected no invalid content after repair, got %d", report.InvalidContentNeurons)
+	}
+}
+
+// historyFiles returns the sorted manifest and checkpoint file names.
+func historyFiles(t *testing.T, basePath string) (manifests, checkpoints []string) {
+	t.Helper()
+	for _, dir := range []struct {
+		name, prefix string
+		out          *[]string
+	}{
+		{"manifest", "MANIFEST-", &manifests},
+		{"checkpoints", "checkpoint-", &checkpoints},
+	} {
+		entries, err := os.ReadDir(filepath.Join(basePath, dir.name))
+		if err != nil {
+			t.Fatalf("read %s: %v", dir.name, err)
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), dir.prefix) && !strings.HasSuffix(e.Name(), ".tmp") {
+				*dir.out = append(*dir.out, e.Name())
+			}
+		}
+	}
+	return manifests, checkpoints
+}
+
+func TestStorePrunesManifestHistory(t *testing.T) {
+	durability := DefaultDurabilityConfig()
+	durability.ManifestRetain = 3
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+
+	m := core.NewMatrix("retain-user", core.DefaultBounds())
+	for i := 0; i < 25; i++ {
+		if err := store.Save(m); err != nil {
+			t.Fatalf("save %d failed: %v", i, err)
+		}
+		manifests, checkpoints := historyFiles(t, tmpDir)
+		if len(manifests) > 3 || len(checkpoints) > 3 {
+			t.Fatalf("cycle %d: history exceeds retention: %v %v", i, manifests, checkpoints)
+		}
+	}
+
+	stats := store.Stats()
+	if stats["manifests_pruned"].(uint64) == 0 || stats["checkpoints_pruned"].(uint64) == 0 {
+		t.Errorf("expected pruning counters to advance, got %v", stats)
+	}
+
+	reopened, err := NewStoreWithDurability(tmpDir, true, durability)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if !reopened.Exists("retain-user") {
+		t.Error("index entry lost after pruning")
+	}
+}
+
+func TestStoreManifestRetainZeroKeepsHistory(t *testing.T) {
+	durability := DefaultDurabilityConfig()
+	durability.ManifestRetain = 0
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+
+	m := core.NewMatrix("keep-all", core.DefaultBounds())
+	for i := 0; i < 8; i++ {
+		if err := store.Save(m); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+	if manifests, _ := historyFiles(t, tmpDir); len(manifests) < 8 {
+		t.Errorf("expected full history with retain=0, got %d manifests", len(manifests))
+	}
+}
+
+// TestStoreManifestCrashRecovery rebuilds the on-disk state a crash would
+// leave at each step of a flush and checks the store reopens with its data
+// and returns to the retention bound on the next flush.
+func TestStoreManifestCrashRecovery(t *testing.T) {
+	const retain = 3
+	crashes := map[string]func(t *testing.T, basePath string, next uint64){
+		"after checkpoint write": func(t *testing.T, basePath string, next uint64) {
+			os.WriteFile(filepath.Join(basePath, "checkpoints", fmt.Sprintf("checkpoint-%020d.nrdb", next)), []byte("partial"), 0644)
+		},
+		"during manifest write": func(t *testing.T, basePath string, next uint64) {
+			os.WriteFile(filepath.Join(basePath, "checkpoints", fmt.Sprintf("checkpoint-%020d.nrdb", next)), []byte("partial"), 0644)
+			os.WriteFile(filepath.Join(basePath, "manifest", fmt.Sprintf("MANIFEST-%020d.json.tmp", next)), []byte("{"), 0644)
+		},
+		"before CURRENT update": func(t *testing.T, basePath string, next uint64) {
+			os.WriteFile(filepath.Join(basePath, "checkpoints", fmt.Sprintf("checkpoint-%020d.nrdb", next)), []byte("partial"), 0644)
+			data, _ := json.Marshal(manifestEntry{Version: next, Checkpoint: fmt.Sprintf("checkpoints/checkpoint-%020d.nrdb", next)})
+			os.WriteFile(filepath.Join(basePath, "manifest", fmt.Sprintf("MANIFEST-%020d.json", next)), data, 0644)
+		},
+		"before pruning": func(t *testing.T, basePath string, next uint64) {
+			// Stale history a server without retention would have left.
+			for v := uint64(1); v < next-retain; v++ {
+				os.WriteFile(filepath.Join(basePath, "checkpoints", fmt.Sprintf("checkpoint-%020d.nrdb", v)), []byte("old"), 0644)
+				os.WriteFile(filepath.Join(basePath, "manifest", fmt.Sprintf("MANIFEST-%020d.json", v)), []byte("{}"), 0644)
+			}
+		},
+		"mid pruning": func(t *testing.T, basePath string, next uint64) {
+			// Manifest removed, its checkpoint not yet.
+			os.WriteFile(filepath.Join(basePath, "checkpoints", fmt.Sprintf("checkpoint-%020d.nrdb", 1)), []byte("old"), 0644)
+		},
+	}
+
+	for name, crash := range crashes {
+		t.Run(name, func(t *testing.T) {
+			durability := DefaultDurabilityConfig()
+			durability.ManifestRetain = retain
+			store, tmpDir := setupTestStoreWithDurability(t, durability)
+			defer os.RemoveAll(tmpDir)
+
+			m := core.NewMatrix("crash-user", core.DefaultBounds())
+			n := core.NewNeuron("survives the crash", m.CurrentDim)
+			m.Neurons[n.ID] = n
+			for i := 0; i < 10; i++ {
+				if err := store.Save(m); err != nil {
+					t.Fatalf("save failed: %v", err)
+				}
+			}
+			crash(t, tmpDir, store.manifestVersion+1)
+
+			reopened, err := NewStoreWithDurability(tmpDir, true, durability)
+			if err != nil {
+				t.Fatalf("reopen after crash failed: %v", err)
+			}
+			loaded, err := reopened.Load("crash-user")
+			if err != nil || len(loaded.Neurons) != 1 {
+				t.Fatalf("data lost after crash: %v", err)
+			}
+
+			if err := reopened.Save(loaded); err != nil {
+				t.Fatalf("save after recovery failed: %v", err)
+			}
+			manifests, checkpoints := historyFiles(t, tmpDir)
+			if len(manifests) > retain || len(checkpoints) > retain {
+				t.Errorf("history not back within retention: %v %v", manifests, checkpoints)
+			}
+		})
+	}
+}
+
+func TestCompactManifests(t *testing.T) {
+	durability := DefaultDurabilityConfig()
+	durability.ManifestRetain = 0
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+
+	m := core.NewMatrix("offline-user", core.DefaultBounds())
+	for i := 0; i < 12; i++ {
+		if err := store.Save(m); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	report, err := CompactManifests(tmpDir, 4)
+	if err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if report.CurrentVersion != store.manifestVersion {
+		t.Errorf("expected current version %d, got %d", store.manifestVersion, report.CurrentVersion)
+	}
+	manifests, checkpoints := historyFiles(t, tmpDir)
+	if len(manifests) != 4 || len(checkpoints) != 4 {
+		t.Errorf("expected 4 versions kept, got %v %v", manifests, checkpoints)
+	}
+	if report.ManifestsRemoved != 8 || report.CheckpointsRemoved != 8 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if _, err := NewStoreWithDurability(tmpDir, true, durability); err != nil {
+		t.Fatalf("reopen after compaction failed: %v", err)
+	}
+
+	// A broken CURRENT must leave history untouched.
+	os.WriteFile(filepath.Join(tmpDir, "manifest", "CURRENT"), []byte("MANIFEST-99999999999999999999.json"), 0644)
+	if _, err := CompactManifests(tmpDir, 1); err == nil {
+		t.Error("expected error when CURRENT does not resolve")
+	}
+	if manifests, _ := historyFiles(t, tmpDir); len(manifests) < 4 {
+		t.Errorf("history removed despite unreadable CURRENT: %v", manifests)
+	}
+}
+
+func TestStoreStartupReport(t *testing.T) {
+	durability := DurabilityConfig{
+		WALEnabled:          true,
+		FsyncPolicy:         FsyncPolicyOff,
+		FsyncInterval:       time.Second,
+		StartupRepair:       true,
+		StartupReportRetain: 2,
+	}
+
+	// corrupt-user is written without the WAL so replay cannot restore it.
+	noWAL := durability
+	noWAL.WALEnabled = false
+	store, tmpDir := setupTestStoreWithDurability(t, noWAL)
+	defer os.RemoveAll(tmpDir)
+
+	corrupt := core.NewMatrix("corrupt-user", core.DefaultBounds())
+	if err := store.Save(corrupt); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	store, err := NewStoreWithDurability(tmpDir, true, durability)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	for _, id := range []core.IndexID{"wal-a", "wal-b"} {
+		m := core.NewMatrix(id, core.DefaultBounds())
+		n := core.NewNeuron("replayed from "+string(id), m.CurrentDim)
+		m.Neurons[n.ID] = n
+		if err := store.SaveAsync(m); err != nil {
+			t.Fatalf("SaveAsync failed: %v", err)
+		}
+	}
+	corruptPath := filepath.Join(tmpDir, "data", "corrupt-user.nrdb")
+	if err := os.WriteFile(corruptPath, []byte("broken-file"), 0644); err != nil {
+		t.Fatalf("failed to corrupt user file: %v", err)
+	}
+
+	restarted, err := NewStoreWithDurability(tmpDir, true, durability)
+	if err != nil {
+		t.Fatalf("failed to restart store: %v", err)
+	}
+
+	report := restarted.StartupReport()
+	if report == nil {
+		t.Fatal("expected a startup report")
+	}
+	walIndexes := map[core.IndexID]bool{}
+	for _, id := range report.WAL.Indexes {
+		walIndexes[id] = true
+	}
+	if report.WAL.Records < 2 || !walIndexes["wal-a"] || !walIndexes["wal-b"] {
+		t.Fatalf("expected WAL records for wal-a and wal-b, got %+v", report.WAL)
+	}
+	if report.Repair == nil || len(report.Repair.RemovedFiles) != 1 {
+		t.Fatalf("expected one removed file, got %+v", report.Repair)
+	}
+	if removed := report.Repair.RemovedFiles[0]; removed.Index != "corrupt-user" || removed.Error == "" {
+		t.Fatalf("unexpected removed file %+v", removed)
+	}
+	if !report.Config.WALEnabled || report.Config.StartupReportRetain != 2 {
+		t.Fatalf("unexpected report config %+v", report.Config)
+	}
+	if summary := report.Summary(); !strings.Contains(summary, "removed 1 corrupt file (index corrupt-user)") {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+
+	data, err := os.ReadFile(report.Path)
+	if err != nil {
+		t.Fatalf("expected report file to be written: %v", err)
+	}
+	var persisted StartupReport
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		t.Fatalf("invalid report file: %v", err)
+	}
+	if persisted.WAL.Records != report.WAL.Records || len(persisted.Repair.RemovedFiles) != 1 {
+		t.Fatalf("persisted report differs: %+v", persisted)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := NewStoreWithDurability(tmpDir, true, durability); err != nil {
+			t.Fatalf("failed to restart store: %v", err)
+		}
+	}
+	reports, err := startupReportFiles(filepath.Join(tmpDir, "reports"))
+	if err != nil {
+		t.Fatalf("list reports: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 retained reports, got %v", reports)
+	}
+}
+
+func TestStoreRetainsVersions(t *testing.T) {
+	durability := DefaultDurabilityConfig()
+	durability.RetainVersions = 2
+	store, tmpDir := setupTestStoreWithDurability(t, durability)
+	defer os.RemoveAll(tmpDir)
+
+	m := core.NewMatrix("versioned", core.DefaultBounds())
+	save := func(content string) time.Time {
+		n := core.NewNeuron(content, m.CurrentDim)
+		m.Neurons[n.ID] = n
+		m.Version++
+		if err := store.Save(m); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		return time.Now()
+	}
+	first := save("first state")
+	save("second state")
+	save("third state")
+	save("fourth state")
+
+	versions, err := store.ListVersions("versioned")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	if len(versions) != 3 || versions[0].ID != CurrentVersion {
+		t.Fatalf("expected current plus 2 retained versions, got %+v", versions)
+	}
+
+	if _, err := store.VersionAt("versioned", first); err != ErrVersionNotFound {
+		t.Errorf("the first state should be pruned, got %v", err)
+	}
+	v, err := store.VersionAt("versioned", versions[2].WrittenAt.Add(time.Millisecond))
+	if err != nil {
+		t.Fatalf("VersionAt failed: %v", err)
+	}
+	old, err := store.LoadVersion("versioned", v.ID)
+	if err != nil {
+		t.Fatalf("LoadVersion failed: %v", err)
+	}
+	if len(old.Neurons) != 2 {
+		t.Errorf("expected the second state with 2 neurons, got %d", len(old.Neurons))
+	}
+	if _, err := store.LoadVersion("versioned", "../escape"); err != ErrVersionNotFound {
+		t.Errorf("invalid version IDs should be rejected, got %v", err)
+	}
+
+	stats := store.Stats()
+	if stats["versions"] != 2 {
+		t.Errorf("stats should count retained versions, got %v", stats["versions"])
+	}
+}
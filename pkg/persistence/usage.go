@@ -0,0 +1,149 @@
+package persistence
+
+import (
+	"sync"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// DiskUsage is the on-disk footprint of an index, measured when its data
+// file was last written or read.
+type DiskUsage struct {
+	DataBytes       int64 `json:"dataBytes"`       // the data file
+	WALBytes        int64 `json:"walBytes"`        // WAL records of the index appended since it was last flushed
+	AttachmentBytes int64 `json:"attachmentBytes"` // the blobs its neurons reference
+	Neurons         int   `json:"neurons"`         // neurons in the data file
+
+	// Footprint is the memory footprint of the matrix the data file
+	// holds, relating the file's size to the live matrix; 0 when the
+	// file was not measured with its matrix.
+	Footprint int64 `json:"-"`
+}
+
+// Bytes is the index's total footprint.
+func (u DiskUsage) Bytes() int64 {
+	return u.DataBytes + u.WALBytes + u.AttachmentBytes
+}
+
+// usageTracker holds the disk usage of each index. Blob sizes are cached
+// by hash: blobs are content-addressed and never change.
+type usageTracker struct {
+	mu        sync.Mutex
+	usage     map[core.IndexID]*DiskUsage
+	blobSizes map[string]int64
+}
+
+// Usage returns indexID's disk usage. An index not read or written since
+// the store opened is measured by its data file alone.
+func (s *Store) Usage(indexID core.IndexID) DiskUsage {
+	var usage DiskUsage
+	s.usage.mu.Lock()
+	u, ok := s.usage.usage[indexID]
+	if ok {
+		usage = *u
+	}
+	s.usage.mu.Unlock()
+	if ok {
+		return usage
+	}
+
+	if info, err := statDataFile(s.userFilePath(indexID)); err == nil && info != nil {
+		usage.DataBytes = info.Size()
+	}
+	if snap, ok := s.GetSnapshot(indexID); ok {
+		usage.Neurons = snap.NeuronCount
+	}
+	return usage
+}
+
+// recordUsage records the data file of indexID as size bytes holding
+// snapshot, encoded from matrix. WAL records of the index are covered by
+// the file unless a newer state is already queued for the next flush.
+func (s *Store) recordUsage(indexID core.IndexID, size int64, matrix, snapshot *core.Matrix) {
+	attachments := s.attachmentBytes(snapshot)
+	footprint := matrix.Footprint()
+	if footprint == 0 {
+		// Freshly decoded; the engine measures it again when it loads.
+		footprint = matrix.RecomputeFootprint()
+	}
+
+	s.writeMu.Lock()
+	_, queued := s.pendingWrites[indexID]
+	s.writeMu.Unlock()
+
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	u, ok := s.usage.usage[indexID]
+	if !ok {
+		u = &DiskUsage{}
+		s.usage.usage[indexID] = u
+	}
+	u.DataBytes = size
+	u.AttachmentBytes = attachments
+	u.Neurons = len(snapshot.Neurons)
+	u.Footprint = footprint
+	if !queued {
+		u.WALBytes = 0
+	}
+}
+
+// addWALUsage counts n bytes of WAL appended for indexID.
+func (s *Store) addWALUsage(indexID core.IndexID, n int) {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	u, ok := s.usage.usage[indexID]
+	if !ok {
+		u = &DiskUsage{}
+		if info, err := statDataFile(s.userFilePath(indexID)); err == nil && info != nil {
+			u.DataBytes = info.Size()
+		}
+		s.usage.usage[indexID] = u
+	}
+	u.WALBytes += int64(n)
+}
+
+// forgetUsage drops the recorded usage of a deleted index.
+func (s *Store) forgetUsage(indexID core.IndexID) {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	delete(s.usage.usage, indexID)
+}
+
+// attachmentBytes sums the sizes of the distinct blobs matrix references.
+// Missing blobs count nothing.
+func (s *Store) attachmentBytes(matrix *core.Matrix) int64 {
+	if s.blobs == nil {
+		return 0
+	}
+	seen := make(map[string]bool)
+	var total int64
+	for _, n := range matrix.Neurons {
+		for _, a := range n.Attachments {
+			if seen[a.Hash] {
+				continue
+			}
+			seen[a.Hash] = true
+			total += s.blobSize(a.Hash)
+		}
+	}
+	return total
+}
+
+// blobSize returns the size of the blob stored under hash, or 0 when it is
+// missing.
+func (s *Store) blobSize(hash string) int64 {
+	s.usage.mu.Lock()
+	size, ok := s.usage.blobSizes[hash]
+	s.usage.mu.Unlock()
+	if ok {
+		return size
+	}
+	info, err := s.blobs.Stat(hash)
+	if err != nil {
+		return 0
+	}
+	s.usage.mu.Lock()
+	s.usage.blobSizes[hash] = info.Size
+	s.usage.mu.Unlock()
+	return info.Size
+}
@@ -0,0 +1,98 @@
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestUsageTracksFilesAcrossFlushes(t *testing.T) {
+	store, err := NewStore(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, _, err := store.Blobs().Put(strings.NewReader(strings.Repeat("scanned page ", 100)), 1<<20, "image/png", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := core.NewMatrix("tenant", core.DefaultBounds())
+	addNeurons := func(count int) {
+		for i := 0; i < count; i++ {
+			n := core.NewNeuron(fmt.Sprintf("note %d: %s", len(m.Neurons), strings.Repeat("lorem ipsum ", 20)), m.CurrentDim)
+			if i == 0 {
+				n.Attachments = []core.Attachment{{Hash: blob.Hash}}
+			}
+			m.Neurons[n.ID] = n
+		}
+		m.Version++
+	}
+	checkFile := func(stage string) DiskUsage {
+		t.Helper()
+		info, err := os.Stat(store.userFilePath("tenant"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		usage := store.Usage("tenant")
+		if diff := usage.DataBytes - info.Size(); diff > info.Size()/100 || -diff > info.Size()/100 {
+			t.Errorf("%s: usage reports %d data bytes, the file has %d", stage, usage.DataBytes, info.Size())
+		}
+		if usage.Neurons != len(m.Neurons) {
+			t.Errorf("%s: expected %d neurons, got %d", stage, len(m.Neurons), usage.Neurons)
+		}
+		return usage
+	}
+
+	addNeurons(10)
+	if err := store.Save(m); err != nil {
+		t.Fatal(err)
+	}
+	first := checkFile("first flush")
+	if first.AttachmentBytes != blob.Size || first.WALBytes != 0 {
+		t.Errorf("expected %d attachment bytes and no pending WAL, got %+v", blob.Size, first)
+	}
+
+	addNeurons(40)
+	if err := store.SaveAsync(m); err != nil {
+		t.Fatal(err)
+	}
+	if pending := store.Usage("tenant"); pending.WALBytes == 0 || pending.Bytes() <= first.Bytes() {
+		t.Errorf("expected the queued state to count as WAL, got %+v", pending)
+	}
+	if err := store.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	second := checkFile("second flush")
+	if second.WALBytes != 0 || second.DataBytes <= first.DataBytes || second.AttachmentBytes != blob.Size {
+		t.Errorf("expected a larger file, the same attachment and no pending WAL, got %+v", second)
+	}
+
+	// A reopened store measures the data file alone until it loads it.
+	reopened, err := NewStore(store.basePath(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(reopened.userFilePath("tenant"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Usage("tenant"); got.DataBytes != info.Size() || got.AttachmentBytes != 0 {
+		t.Errorf("before loading, expected the %d byte data file alone, got %+v", info.Size(), got)
+	}
+	if _, err := reopened.Load("tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Usage("tenant"); got.Bytes() != info.Size()+blob.Size || got.Footprint == 0 {
+		t.Errorf("after loading, expected %d bytes, got %+v", info.Size()+blob.Size, got)
+	}
+
+	if err := store.Delete("tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Usage("tenant"); got.Bytes() != 0 {
+		t.Errorf("expected no usage after delete, got %+v", got)
+	}
+}
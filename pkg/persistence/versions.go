@@ -0,0 +1,202 @@
+package persistence
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// CurrentVersion is the ID of an index's live data file in version lists.
+const CurrentVersion = "current"
+
+const (
+	versionStampLayout = "20060102T150405.000000000Z"
+	versionSuffix      = ".nrdb"
+)
+
+// ErrVersionNotFound is returned when no retained version matches.
+var ErrVersionNotFound = errors.New("version not found")
+
+// Version is a persisted state of an index: a retained copy of an earlier
+// data file, or the live data file itself (ID CurrentVersion).
+type Version struct {
+	ID        string    `json:"id"`
+	WrittenAt time.Time `json:"writtenAt"`
+	Bytes     int64     `json:"bytes"`
+}
+
+// versionsDir is where retained versions of indexID are kept.
+func (s *Store) versionsDir(indexID core.IndexID) string {
+	return filepath.Join(s.basePath(), "data", "versions", string(indexID))
+}
+
+// rotateVersion keeps a copy of indexID's data file under data/versions
+// before it is overwritten or deleted, then applies the retention bounds.
+// It does nothing when versioning is off or there is no data file.
+func (s *Store) rotateVersion(indexID core.IndexID) error {
+	if s.durability.RetainVersions < 1 {
+		return nil
+	}
+	src := s.userFilePath(indexID)
+	info, err := os.Stat(src)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	dir := s.versionsDir(indexID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, info.ModTime().UTC().Format(versionStampLayout)+versionSuffix)
+	// The data file is replaced by rename, so a hard link keeps the old
+	// contents without copying them.
+	if err := os.Link(src, dst); err != nil && !os.IsExist(err) {
+		if err := copyFile(src, dst); err != nil {
+			return s.diskError(err)
+		}
+	}
+	return s.pruneVersions(indexID)
+}
+
+// pruneVersions removes retained versions beyond storage.retainVersions
+// and, when set, older than storage.retainVersionsMaxAge.
+func (s *Store) pruneVersions(indexID core.IndexID) error {
+	versions, err := s.retainedVersions(indexID)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Time{}
+	if s.durability.RetainVersionsMaxAge > 0 {
+		cutoff = time.Now().Add(-s.durability.RetainVersionsMaxAge)
+	}
+	for i, v := range versions {
+		if i < s.durability.RetainVersions && !v.WrittenAt.Before(cutoff) {
+			continue
+		}
+		if _, err := removeIfExists(filepath.Join(s.versionsDir(indexID), v.ID+versionSuffix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retainedVersions lists the retained copies of indexID, newest first.
+func (s *Store) retainedVersions(indexID core.IndexID) ([]Version, error) {
+	entries, err := os.ReadDir(s.versionsDir(indexID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), versionSuffix)
+		if e.IsDir() || !ok {
+			continue
+		}
+		writtenAt, err := time.Parse(versionStampLayout, id)
+		if err != nil {
+			continue
+		}
+		v := Version{ID: id, WrittenAt: writtenAt}
+		if info, err := e.Info(); err == nil {
+			v.Bytes = info.Size()
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].WrittenAt.After(versions[j].WrittenAt) })
+	return versions, nil
+}
+
+// ListVersions lists the persisted states of indexID, newest first: the
+// live data file, if any, followed by the retained versions.
+func (s *Store) ListVersions(indexID core.IndexID) ([]Version, error) {
+	var versions []Version
+	if info, err := os.Stat(s.userFilePath(indexID)); err == nil {
+		versions = append(versions, Version{ID: CurrentVersion, WrittenAt: info.ModTime().UTC(), Bytes: info.Size()})
+	}
+	retained, err := s.retainedVersions(indexID)
+	if err != nil {
+		return nil, err
+	}
+	return append(versions, retained...), nil
+}
+
+// VersionAt returns the newest persisted state of indexID written at or
+// before t.
+func (s *Store) VersionAt(indexID core.IndexID, t time.Time) (Version, error) {
+	versions, err := s.ListVersions(indexID)
+	if err != nil {
+		return Version{}, err
+	}
+	for _, v := range versions {
+		if !v.WrittenAt.After(t) {
+			return v, nil
+		}
+	}
+	return Version{}, ErrVersionNotFound
+}
+
+// LoadVersion decodes one persisted state of indexID into a new matrix that
+// is not shared with the live index.
+func (s *Store) LoadVersion(indexID core.IndexID, id string) (*core.Matrix, error) {
+	path := s.userFilePath(indexID)
+	if id != CurrentVersion {
+		if _, err := time.Parse(versionStampLayout, id); err != nil {
+			return nil, ErrVersionNotFound
+		}
+		path = filepath.Join(s.versionsDir(indexID), id+versionSuffix)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrVersionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.codec.Decode(data)
+}
+
+// versionStats counts the retained versions of every index and their size.
+func (s *Store) versionStats() (count int, bytes int64) {
+	root := filepath.Join(s.basePath(), "data", "versions")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0, 0
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		versions, _ := s.retainedVersions(core.IndexID(e.Name()))
+		for _, v := range versions {
+			count++
+			bytes += v.Bytes
+		}
+	}
+	return count, bytes
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,418 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// WAL archive file names: a segment holds framed WAL records as wal.log
+// does, and its metadata file is written once the segment is complete.
+const (
+	walSegmentPrefix = "wal-"
+	walSegmentExt    = ".seg"
+	walSegmentMeta   = ".json"
+)
+
+// WALArchiveConfig controls WAL archiving; see core.WALArchiveConfig.
+type WALArchiveConfig struct {
+	Dir             string
+	QueueSize       int
+	SegmentBytes    int64
+	SegmentInterval time.Duration
+	MaxAge          time.Duration
+	MaxBytes        int64
+}
+
+// WALSegment describes a completed segment of the WAL archive.
+type WALSegment struct {
+	Sequence uint64 `json:"sequence"`
+	File     string `json:"file"`
+	Bytes    int64  `json:"bytes"`
+	Records  int    `json:"records"`
+
+	// Start and End are the append times of its first and last record.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Indexes counts the segment's records per index.
+	Indexes map[core.IndexID]int `json:"indexes"`
+
+	// DroppedBefore counts the records dropped since the previous segment
+	// because the archive queue was full. Replaying past such a gap misses
+	// those writes.
+	DroppedBefore uint64 `json:"droppedBefore,omitempty"`
+}
+
+// WALArchiveStats reports the archiver's progress.
+type WALArchiveStats struct {
+	Enabled  bool   `json:"enabled"`
+	Dir      string `json:"dir,omitempty"`
+	Queued   int    `json:"queued"`
+	Archived uint64 `json:"archived"` // records in completed segments
+	Segments uint64 `json:"segments"` // segments completed since startup
+	Dropped  uint64 `json:"dropped"`  // records dropped on a full queue or after failed writes
+	Failures uint64 `json:"failures"` // failed segment writes
+	Removed  uint64 `json:"removed"`  // segments removed by retention
+}
+
+// archivedRecord is a WAL record queued for the archive with its framing.
+type archivedRecord struct {
+	indexID core.IndexID
+	time    int64
+	framed  []byte
+}
+
+// walArchiver gathers WAL records into segments in the background.
+type walArchiver struct {
+	cfg   WALArchiveConfig
+	queue chan archivedRecord
+	stop  chan struct{}
+	done  chan struct{}
+
+	// Owned by the archiver goroutine. lostBefore is lost as of the last
+	// completed segment.
+	seq        uint64
+	buf        bytes.Buffer
+	pending    WALSegment
+	lostBefore uint64
+
+	archived atomic.Uint64
+	segments atomic.Uint64
+	lost     atomic.Uint64
+	failures atomic.Uint64
+	removed  atomic.Uint64
+}
+
+// StartWALArchive starts copying appended WAL records to the archive
+// directory cfg.Dir, continuing its sequence numbers. It does nothing when
+// the WAL is disabled.
+func (s *Store) StartWALArchive(cfg WALArchiveConfig) error {
+	if !s.durability.WALEnabled || cfg.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("creating wal archive: %w", err)
+	}
+	segments, err := ListWALSegments(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	a := &walArchiver{
+		cfg:   cfg,
+		queue: make(chan archivedRecord, max(cfg.QueueSize, 1)),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if n := len(segments); n > 0 {
+		a.seq = segments[n-1].Sequence
+	}
+
+	s.walMu.Lock()
+	defer s.walMu.Unlock()
+	if s.archive != nil {
+		return errors.New("wal archive already started")
+	}
+	s.archive = a
+	go a.run()
+	return nil
+}
+
+// StopWALArchive archives the queued records as a last segment and stops
+// the archiver.
+func (s *Store) StopWALArchive() {
+	s.walMu.Lock()
+	a := s.archive
+	s.archive = nil
+	s.walMu.Unlock()
+	if a != nil {
+		close(a.stop)
+		<-a.done
+	}
+}
+
+// WALArchiveStats reports the WAL archiver's progress.
+func (s *Store) WALArchiveStats() WALArchiveStats {
+	s.walMu.Lock()
+	a := s.archive
+	s.walMu.Unlock()
+	if a == nil {
+		return WALArchiveStats{}
+	}
+	return WALArchiveStats{
+		Enabled:  true,
+		Dir:      a.cfg.Dir,
+		Queued:   len(a.queue),
+		Archived: a.archived.Load(),
+		Segments: a.segments.Load(),
+		Dropped:  a.lost.Load(),
+		Failures: a.failures.Load(),
+		Removed:  a.removed.Load(),
+	}
+}
+
+// enqueue queues a record without waiting; a full queue drops it.
+func (a *walArchiver) enqueue(record walRecord, framed []byte) {
+	select {
+	case a.queue <- archivedRecord{indexID: record.IndexID, time: record.Time, framed: framed}:
+	default:
+		a.lost.Add(1)
+	}
+}
+
+func (a *walArchiver) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(min(a.cfg.SegmentInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case r := <-a.queue:
+			a.add(r)
+			if int64(a.buf.Len()) >= a.cfg.SegmentBytes {
+				a.complete()
+			}
+		case <-ticker.C:
+			if a.buf.Len() > 0 && time.Since(a.pending.Start) >= a.cfg.SegmentInterval {
+				a.complete()
+			}
+		case <-a.stop:
+			// Nothing is queued after stop: the store dropped the archiver
+			// first.
+			for len(a.queue) > 0 {
+				a.add(<-a.queue)
+			}
+			if a.buf.Len() > 0 {
+				a.complete()
+			}
+			return
+		}
+	}
+}
+
+func (a *walArchiver) add(r archivedRecord) {
+	t := time.Unix(0, r.time).UTC()
+	if a.pending.Records == 0 {
+		a.pending = WALSegment{Start: t, Indexes: make(map[core.IndexID]int)}
+	}
+	a.buf.Write(r.framed)
+	a.pending.Records++
+	a.pending.End = t
+	a.pending.Indexes[r.indexID]++
+}
+
+// complete writes the pending records as the next segment, then applies
+// retention. A segment that cannot be written is retried with the next
+// one, unless it has grown past twice the segment size: then its records
+// are dropped.
+func (a *walArchiver) complete() {
+	seg := a.pending
+	seg.Sequence = a.seq + 1
+	seg.File = walSegmentName(seg.Sequence) + walSegmentExt
+	seg.Bytes = int64(a.buf.Len())
+	lost := a.lost.Load()
+	seg.DroppedBefore = lost - a.lostBefore
+	if err := writeWALSegment(a.cfg.Dir, seg, a.buf.Bytes()); err != nil {
+		a.failures.Add(1)
+		if seg.Bytes > 2*a.cfg.SegmentBytes {
+			a.lost.Add(uint64(seg.Records))
+			a.reset()
+		}
+		return
+	}
+	a.seq = seg.Sequence
+	a.lostBefore = lost
+	a.archived.Add(uint64(seg.Records))
+	a.segments.Add(1)
+	a.reset()
+	a.applyRetention(time.Now())
+}
+
+func (a *walArchiver) reset() {
+	a.buf.Reset()
+	a.pending = WALSegment{}
+}
+
+// applyRetention removes segments whose last record is older than MaxAge,
+// then the oldest while the archive holds more than MaxBytes.
+func (a *walArchiver) applyRetention(now time.Time) {
+	if a.cfg.MaxAge <= 0 && a.cfg.MaxBytes <= 0 {
+		return
+	}
+	segments, err := ListWALSegments(a.cfg.Dir)
+	if err != nil {
+		return
+	}
+	var total int64
+	for _, seg := range segments {
+		total += seg.Bytes
+	}
+	for _, seg := range segments[:max(len(segments)-1, 0)] { // never the newest
+		expired := a.cfg.MaxAge > 0 && now.Sub(seg.End) > a.cfg.MaxAge
+		if !expired && (a.cfg.MaxBytes <= 0 || total <= a.cfg.MaxBytes) {
+			break
+		}
+		if removeWALSegment(a.cfg.Dir, seg) != nil {
+			return
+		}
+		total -= seg.Bytes
+		a.removed.Add(1)
+	}
+}
+
+func walSegmentName(seq uint64) string {
+	return fmt.Sprintf("%s%016d", walSegmentPrefix, seq)
+}
+
+// writeWALSegment writes a segment, then its metadata: a segment without
+// metadata is incomplete and ignored.
+func writeWALSegment(dir string, seg WALSegment, data []byte) error {
+	meta, err := json.MarshalIndent(seg, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(dir, walSegmentName(seg.Sequence))
+	if err := writeFileSynced(name+walSegmentExt, data); err != nil {
+		return err
+	}
+	return writeFileSynced(name+walSegmentMeta, meta)
+}
+
+// writeFileSynced replaces path with data through a synced temporary file.
+func writeFileSynced(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeWALSegment removes the metadata first, so a half-removed segment
+// reads as incomplete.
+func removeWALSegment(dir string, seg WALSegment) error {
+	name := filepath.Join(dir, walSegmentName(seg.Sequence))
+	if err := os.Remove(name + walSegmentMeta); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(name + walSegmentExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ListWALSegments returns the completed segments of the WAL archive in
+// dir, oldest first.
+func ListWALSegments(dir string) ([]WALSegment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading wal archive: %w", err)
+	}
+	var segments []WALSegment
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, walSegmentPrefix) || !strings.HasSuffix(name, walSegmentMeta) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("reading wal archive: %w", err)
+		}
+		var seg WALSegment
+		if err := json.Unmarshal(data, &seg); err != nil {
+			return nil, fmt.Errorf("wal archive segment %s: %w", name, err)
+		}
+		segments = append(segments, seg)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Sequence < segments[j].Sequence })
+	return segments, nil
+}
+
+// WALArchiveReplayReport describes a ReplayWALArchive run.
+type WALArchiveReplayReport struct {
+	Segments int                  `json:"segments"` // segments read
+	Applied  int                  `json:"applied"`  // records applied
+	Skipped  int                  `json:"skipped"`  // archived records after the cutoff
+	Indexes  map[core.IndexID]int `json:"indexes"`  // applied records per index
+	Last     time.Time            `json:"last"`     // append time of the last applied record
+
+	// Gaps counts records the archive is missing before the cutoff: those
+	// dropped on a full queue, and sequence numbers with no segment.
+	Gaps uint64 `json:"gaps"`
+}
+
+// ReplayWALArchive applies the records of the WAL archive in dir appended
+// at or before until, in order, on top of the store's data: each index
+// ends up as its last archived record up to the cutoff left it. Every
+// record is a full index state, so replaying records the store already
+// holds is harmless. The store's own WAL, whose records the data files now
+// cover, is emptied so a later startup does not replay it over the result.
+// Run it on a stopped store.
+func (s *Store) ReplayWALArchive(dir string, until time.Time) (WALArchiveReplayReport, error) {
+	report := WALArchiveReplayReport{Indexes: make(map[core.IndexID]int)}
+	segments, err := ListWALSegments(dir)
+	if err != nil {
+		return report, err
+	}
+	cutoff := until.UnixNano()
+	for i, seg := range segments {
+		if seg.Start.After(until) {
+			report.Skipped += seg.Records
+			continue
+		}
+		if i > 0 && seg.Sequence != segments[i-1].Sequence+1 {
+			report.Gaps += seg.Sequence - segments[i-1].Sequence - 1
+		}
+		report.Gaps += seg.DroppedBefore
+		data, err := os.ReadFile(filepath.Join(dir, seg.File))
+		if err != nil {
+			return report, fmt.Errorf("reading wal archive: %w", err)
+		}
+		report.Segments++
+		_, err = scanWALRecords(data, func(record walRecord) error {
+			if record.Time > cutoff {
+				report.Skipped++
+				return nil
+			}
+			if err := s.applyWALRecord(record); err != nil {
+				return err
+			}
+			report.Applied++
+			report.Indexes[record.IndexID]++
+			report.Last = time.Unix(0, record.Time).UTC()
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("replaying %s: %w", seg.File, err)
+		}
+	}
+	if report.Applied == 0 {
+		return report, nil
+	}
+	if err := s.saveIndex(); err != nil {
+		return report, err
+	}
+	return report, s.truncateWAL(0)
+}
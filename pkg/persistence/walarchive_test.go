@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func saveNeurons(t *testing.T, store *Store, indexID core.IndexID, contents ...string) {
+	t.Helper()
+	m := core.NewMatrix(indexID, core.DefaultBounds())
+	for _, c := range contents {
+		n := core.NewNeuron(c, m.CurrentDim)
+		m.Neurons[n.ID] = n
+	}
+	if err := store.Save(m); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+}
+
+func TestWALArchiveSegments(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+	dir := filepath.Join(tmpDir, "archive")
+	cfg := WALArchiveConfig{Dir: dir, QueueSize: 16, SegmentBytes: 1 << 20, SegmentInterval: time.Hour}
+
+	if err := store.StartWALArchive(cfg); err != nil {
+		t.Fatal(err)
+	}
+	saveNeurons(t, store, "user-a", "first")
+	saveNeurons(t, store, "user-b", "second")
+	saveNeurons(t, store, "user-a", "first", "third")
+	store.StopWALArchive()
+
+	segments, err := ListWALSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 1 {
+		t.Fatalf("expected stopping to complete one segment, got %d", len(segments))
+	}
+	seg := segments[0]
+	if seg.Sequence != 1 || seg.Records != 3 || seg.Indexes["user-a"] != 2 || seg.Indexes["user-b"] != 1 {
+		t.Errorf("unexpected segment metadata: %+v", seg)
+	}
+	if seg.Start.IsZero() || seg.End.Before(seg.Start) {
+		t.Errorf("expected a time range, got %s to %s", seg.Start, seg.End)
+	}
+	if info, err := os.Stat(filepath.Join(dir, seg.File)); err != nil || info.Size() != seg.Bytes {
+		t.Errorf("expected a %d byte segment file, got %v", seg.Bytes, err)
+	}
+
+	// A restart continues the sequence; a full segment completes at once.
+	cfg.SegmentBytes = 1
+	if err := store.StartWALArchive(cfg); err != nil {
+		t.Fatal(err)
+	}
+	saveNeurons(t, store, "user-b", "second", "fourth")
+	deadline := time.Now().Add(5 * time.Second)
+	for store.WALArchiveStats().Segments == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stats := store.WALArchiveStats()
+	if !stats.Enabled || stats.Segments != 1 || stats.Archived != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	store.StopWALArchive()
+	if segments, _ := ListWALSegments(dir); len(segments) != 2 || segments[1].Sequence != 2 {
+		t.Errorf("expected the sequence to continue, got %+v", segments)
+	}
+	if stats := store.WALArchiveStats(); stats.Enabled {
+		t.Error("expected the archive to be stopped")
+	}
+}
+
+func TestWALArchiveRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for seq := uint64(1); seq <= 4; seq++ {
+		end := now.Add(-time.Duration(5-seq) * time.Hour)
+		seg := WALSegment{Sequence: seq, File: walSegmentName(seq) + walSegmentExt, Bytes: 10, Records: 1, Start: end, End: end}
+		if err := writeWALSegment(dir, seg, make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Segments 1 and 2 ended more than 2h30m ago.
+	a := &walArchiver{cfg: WALArchiveConfig{Dir: dir, MaxAge: 150 * time.Minute}}
+	a.applyRetention(now)
+	segments, _ := ListWALSegments(dir)
+	if len(segments) != 2 || segments[0].Sequence != 3 || a.removed.Load() != 2 {
+		t.Fatalf("expected age retention to keep segments 3 and 4, got %+v", segments)
+	}
+	if _, err := os.Stat(filepath.Join(dir, walSegmentName(1)+walSegmentExt)); !os.IsNotExist(err) {
+		t.Errorf("expected the segment file to be removed, got %v", err)
+	}
+
+	// Size retention never removes the newest segment.
+	a.cfg = WALArchiveConfig{Dir: dir, MaxBytes: 1}
+	a.applyRetention(now)
+	if segments, _ := ListWALSegments(dir); len(segments) != 1 || segments[0].Sequence != 4 {
+		t.Errorf("expected size retention to keep the newest segment, got %+v", segments)
+	}
+}
+
+func TestWALArchiveFullQueueDrops(t *testing.T) {
+	a := &walArchiver{queue: make(chan archivedRecord, 1)}
+	a.enqueue(walRecord{IndexID: "user-a"}, nil)
+	a.enqueue(walRecord{IndexID: "user-a"}, nil)
+	if a.lost.Load() != 1 || len(a.queue) != 1 {
+		t.Errorf("expected the second record to be dropped, got %d lost", a.lost.Load())
+	}
+}
+
+func TestReplayWALArchiveUntil(t *testing.T) {
+	store, tmpDir := setupTestStore(t)
+	defer os.RemoveAll(tmpDir)
+	dir := filepath.Join(tmpDir, "archive")
+	if err := store.StartWALArchive(WALArchiveConfig{Dir: dir, QueueSize: 16, SegmentBytes: 1, SegmentInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	saveNeurons(t, store, "user-a", "first")
+	time.Sleep(2 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	saveNeurons(t, store, "user-a", "first", "second")
+	saveNeurons(t, store, "user-b", "third")
+	store.StopWALArchive()
+
+	target, err := NewStoreWithDurability(t.TempDir(), true, DefaultDurabilityConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := target.ReplayWALArchive(dir, cutoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Applied != 1 || report.Indexes["user-a"] != 1 || report.Gaps != 0 || report.Last.After(cutoff) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	loaded, err := target.Load("user-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Neurons) != 1 || target.Exists("user-b") {
+		t.Errorf("expected user-a as of the cutoff only, got %d neurons, user-b %v", len(loaded.Neurons), target.Exists("user-b"))
+	}
+}
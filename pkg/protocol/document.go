@@ -0,0 +1,160 @@
+package protocol
+
+import (
+	_ "embed"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// DocumentFields is a bit mask of optional neuron document fields.
+//
+// Every document carries the core fields _id, id (an alias of _id, unless
+// DocumentOptions.OmitIDAlias), content, energy, depth and createdAt. Optional fields are present,
+// never omitted or null-vs-empty ambiguous, whenever their bit is set:
+// tags, position and metadata are empty collections rather than null, and
+// sentiment is null when the neuron has not been labelled.
+type DocumentFields uint32
+
+const (
+	FieldPosition    DocumentFields = 1 << iota // position: []float64
+	FieldTags                                   // tags: []string
+	FieldAccessCount                            // accessCount: integer
+	FieldLastFiredAt                            // lastFiredAt: RFC 3339 time
+	FieldMetadata                               // metadata: object
+	FieldSentiment                              // sentiment: {label, score} or null
+	FieldPinned                                 // pinned: boolean
+
+	// DefaultDocumentFields is what every HTTP and MCP endpoint returns.
+	DefaultDocumentFields = FieldPosition | FieldTags | FieldAccessCount | FieldLastFiredAt | FieldMetadata | FieldSentiment | FieldPinned
+)
+
+// DocumentOptions controls how a neuron is rendered by Document.
+type DocumentOptions struct {
+	// Fields selects the optional fields to include.
+	Fields DocumentFields
+
+	// Projection is an optional MongoDB-style projection ({"content": 1} or
+	// {"metadata": 0}) applied after Fields. It may also drop core fields;
+	// id always follows _id.
+	Projection map[string]int
+
+	// OmitIDAlias leaves out id, for clients of API versions that predate
+	// the alias.
+	OmitIDAlias bool
+}
+
+// DocumentSchema is the JSON Schema of a document rendered with
+// DefaultDocumentFields.
+//
+//go:embed document.schema.json
+var DocumentSchema []byte
+
+// AttachmentURL is where the blob of an attachment is served.
+func AttachmentURL(hash string) string {
+	return "/v1/attachments/" + hash
+}
+
+// Document renders a neuron in the canonical document shape shared by every
+// endpoint, from a snapshot of n.
+func Document(n *core.Neuron, opts DocumentOptions) map[string]any {
+	n = n.Snapshot()
+	doc := map[string]any{
+		"_id":       string(n.ID),
+		"id":        string(n.ID),
+		"content":   n.Content,
+		"energy":    n.Energy,
+		"depth":     n.Depth,
+		"createdAt": n.CreatedAt,
+	}
+
+	if opts.Fields&FieldPosition != 0 {
+		position := n.Position
+		if position == nil {
+			position = []float64{}
+		}
+		doc["position"] = position
+	}
+	if opts.Fields&FieldTags != 0 {
+		tags := n.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		doc["tags"] = tags
+	}
+	if opts.Fields&FieldAccessCount != 0 {
+		doc["accessCount"] = n.AccessCount
+	}
+	if opts.Fields&FieldLastFiredAt != 0 {
+		doc["lastFiredAt"] = n.LastFiredAt
+	}
+	if opts.Fields&FieldMetadata != 0 {
+		metadata := n.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		doc["metadata"] = metadata
+	}
+	if opts.Fields&FieldSentiment != 0 {
+		if n.SentimentLabel != "" {
+			doc["sentiment"] = map[string]any{"label": n.SentimentLabel, "score": n.SentimentScore}
+		} else {
+			doc["sentiment"] = nil
+		}
+	}
+	if opts.Fields&FieldPinned != 0 {
+		doc["pinned"] = n.Pinned
+	}
+	if core.IsCompacted(n) {
+		doc["compacted"] = true
+	}
+	if len(n.Attachments) > 0 {
+		attachments := make([]map[string]any, len(n.Attachments))
+		for i, a := range n.Attachments {
+			attachments[i] = map[string]any{"hash": a.Hash, "url": AttachmentURL(a.Hash)}
+			if a.Caption != "" {
+				attachments[i]["caption"] = a.Caption
+			}
+		}
+		doc["attachments"] = attachments
+	}
+	if len(n.Promotions) > 0 {
+		doc["promotions"] = append([]core.Promotion(nil), n.Promotions...)
+	}
+
+	if len(opts.Projection) > 0 {
+		applyProjection(doc, opts.Projection)
+	}
+	if opts.OmitIDAlias {
+		delete(doc, "id")
+	}
+	return doc
+}
+
+// applyProjection trims doc to a MongoDB-style projection. Any 0 value
+// switches to exclusion mode; otherwise only fields set to 1 are kept.
+func applyProjection(doc map[string]any, projection map[string]int) {
+	exclusionMode := false
+	for _, v := range projection {
+		if v == 0 {
+			exclusionMode = true
+			break
+		}
+	}
+
+	for name := range doc {
+		if name == "id" {
+			continue
+		}
+		keep := projection[name] == 1
+		if exclusionMode {
+			v, listed := projection[name]
+			keep = !listed || v != 0
+		}
+		if !keep {
+			delete(doc, name)
+		}
+	}
+	if _, ok := doc["_id"]; !ok {
+		delete(doc, "id")
+	}
+}
@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func TestDocument_CoreFieldsAlwaysPresent(t *testing.T) {
+	n := core.NewNeuron("core only", 3)
+	doc := Document(n, DocumentOptions{})
+
+	for _, field := range []string{"_id", "id", "content", "energy", "depth", "createdAt"} {
+		if _, ok := doc[field]; !ok {
+			t.Errorf("missing core field %q", field)
+		}
+	}
+	if len(doc) != 6 {
+		t.Errorf("expected only core fields without a mask, got %v", doc)
+	}
+	if doc["id"] != doc["_id"] {
+		t.Errorf("id must alias _id: %v vs %v", doc["id"], doc["_id"])
+	}
+}
+
+func TestDocument_OptionalFieldsNeverNull(t *testing.T) {
+	n := core.NewNeuron("empty collections", 3)
+	n.Tags, n.Metadata, n.Position = nil, nil, nil
+
+	raw, _ := json.Marshal(Document(n, DocumentOptions{Fields: DefaultDocumentFields}))
+	var m map[string]any
+	json.Unmarshal(raw, &m)
+	for _, field := range []string{"tags", "position"} {
+		if _, ok := m[field].([]any); !ok {
+			t.Errorf("%s should be an empty array, got %v", field, m[field])
+		}
+	}
+	if _, ok := m["metadata"].(map[string]any); !ok {
+		t.Errorf("metadata should be an empty object, got %v", m["metadata"])
+	}
+	if v, ok := m["sentiment"]; !ok || v != nil {
+		t.Errorf("unlabelled sentiment should be present and null, got %v", v)
+	}
+
+	n.SentimentLabel, n.SentimentScore = "happiness", 0.7
+	s := Document(n, DocumentOptions{Fields: FieldSentiment})["sentiment"].(map[string]any)
+	if s["label"] != "happiness" || s["score"] != 0.7 {
+		t.Errorf("unexpected sentiment: %v", s)
+	}
+}
+
+func TestDocument_Projection(t *testing.T) {
+	n := core.NewNeuron("projected", 3)
+
+	doc := Document(n, DocumentOptions{Fields: DefaultDocumentFields, Projection: map[string]int{"metadata": 0, "position": 0}})
+	if _, ok := doc["metadata"]; ok {
+		t.Error("metadata should be excluded")
+	}
+	if _, ok := doc["tags"]; !ok {
+		t.Error("unlisted fields should stay in exclusion mode")
+	}
+
+	doc = Document(n, DocumentOptions{Fields: DefaultDocumentFields, Projection: map[string]int{"content": 1}})
+	if len(doc) != 1 || doc["content"] != "projected" {
+		t.Errorf("inclusion mode should keep only listed fields, got %v", doc)
+	}
+
+	doc = Document(n, DocumentOptions{Fields: DefaultDocumentFields, Projection: map[string]int{"_id": 1}})
+	if doc["id"] != doc["_id"] || len(doc) != 2 {
+		t.Errorf("id should follow _id, got %v", doc)
+	}
+
+	doc = Document(n, DocumentOptions{Fields: DefaultDocumentFields, OmitIDAlias: true})
+	if _, ok := doc["id"]; ok || doc["_id"] != string(n.ID) {
+		t.Errorf("OmitIDAlias should keep _id only, got %v", doc)
+	}
+}
+
+func TestDocumentSchema_MatchesDefaultFields(t *testing.T) {
+	var schema struct {
+		Required   []string       `json:"required"`
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(DocumentSchema, &schema); err != nil {
+		t.Fatalf("invalid schema JSON: %v", err)
+	}
+
+	doc := Document(core.NewNeuron("schema", 3), DocumentOptions{Fields: DefaultDocumentFields})
+	var fields []string
+	for k := range doc {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	required := append([]string(nil), schema.Required...)
+	sort.Strings(required)
+
+	if len(fields) != len(required) {
+		t.Fatalf("schema requires %v, document has %v", required, fields)
+	}
+	for i := range fields {
+		if fields[i] != required[i] {
+			t.Fatalf("schema requires %v, document has %v", required, fields)
+		}
+		if _, ok := schema.Properties[fields[i]]; !ok {
+			t.Errorf("schema has no property for %q", fields[i])
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"slices"
 	"sort"
 
 	"github.com/qubicDB/qubicdb/pkg/concurrency"
@@ -65,8 +66,22 @@ func (e *Executor) ListCommands() []CommandType {
 	return cmds
 }
 
+// commandOps maps the commands that change existing memories to the
+// worker operation they submit, so they share its classification.
+var commandOps = map[CommandType]concurrency.OpType{
+	CmdUpdate:    concurrency.OpTouch,
+	CmdUpdateOne: concurrency.OpTouch,
+	CmdDelete:    concurrency.OpForget,
+	CmdDeleteOne: concurrency.OpForget,
+}
+
 // Execute dispatches a command to the appropriate registered handler.
+// Destructive commands on an append-only index fail with
+// core.ErrAppendOnly's message.
 func (e *Executor) Execute(worker *concurrency.BrainWorker, cmd *Command) *Result {
+	if op, ok := commandOps[cmd.Type]; ok && op.IsDestructive() && worker.AppendOnly() {
+		return &Result{Success: false, Error: core.ErrAppendOnly.Error() + ": " + string(cmd.Type) + " refused"}
+	}
 	if cmd.Type == CmdUpdate || cmd.Type == CmdUpdateOne || cmd.Type == CmdDelete || cmd.Type == CmdDeleteOne || cmd.Type == CmdActivate {
 		return &Result{Success: false, Error: "direct neuron mutation is disabled; use high-level index operations"}
 	}
@@ -224,39 +239,44 @@ func (e *Executor) applyUpdate(worker *concurrency.BrainWorker, n *core.Neuron,
 					}
 				}
 				if tags, ok := fieldMap["tags"].([]any); ok {
-					n.Tags = make([]string, 0)
+					set := make([]string, 0, len(tags))
 					for _, t := range tags {
 						if s, ok := t.(string); ok {
-							n.Tags = append(n.Tags, s)
+							set = append(set, s)
 						}
 					}
+					n.SetTags(set)
 				}
 				if meta, ok := fieldMap["metadata"].(map[string]any); ok {
-					for k, v := range meta {
-						n.Metadata[k] = v
-					}
+					n.EditMetadata(func(metadata map[string]any) {
+						for k, v := range meta {
+							metadata[k] = v
+						}
+					})
 				}
 			}
 		case "$inc":
 			if fieldMap, ok := fields.(map[string]any); ok {
 				if energyInc, ok := fieldMap["energy"].(float64); ok {
-					n.Energy += energyInc
-					if n.Energy > 1.0 {
-						n.Energy = 1.0
-					}
-					if n.Energy < 0 {
-						n.Energy = 0
-					}
+					n.Lock()
+					n.Energy = max(0, min(1.0, n.Energy+energyInc))
+					n.Unlock()
 				}
 			}
 		case "$push":
 			if fieldMap, ok := fields.(map[string]any); ok {
 				if tag, ok := fieldMap["tags"].(string); ok {
-					n.Tags = append(n.Tags, tag)
+					n.Lock()
+					n.Tags = append(slices.Clip(n.Tags), tag)
+					n.Unlock()
 				}
 			}
 		}
 	}
+	matrix := worker.Matrix()
+	matrix.Lock()
+	matrix.Remeasure(n)
+	matrix.Unlock()
 	return nil
 }
 
@@ -216,6 +216,8 @@ func (fm *FilterMatcher) getFieldValue(n *core.Neuron, field string) any {
 		return n.CreatedAt
 	case "lastFiredAt":
 		return n.LastFiredAt
+	case "pinned":
+		return n.Pinned
 	default:
 		// Check metadata
 		if n.Metadata != nil {
@@ -318,50 +320,10 @@ func MarshalResult(r *Result) ([]byte, error) {
 	return json.Marshal(r)
 }
 
-// NeuronToDocument converts a neuron to a document map
+// NeuronToDocument renders a neuron with DefaultDocumentFields and an
+// optional projection. See Document.
 func NeuronToDocument(n *core.Neuron, projection map[string]int) map[string]any {
-	doc := make(map[string]any)
-	
-	// If no projection, return all fields
-	includeAll := len(projection) == 0
-	
-	// Check for exclusion mode (any value is 0)
-	exclusionMode := false
-	for _, v := range projection {
-		if v == 0 {
-			exclusionMode = true
-			break
-		}
-	}
-
-	addField := func(name string, value any) {
-		if includeAll {
-			doc[name] = value
-			return
-		}
-		if exclusionMode {
-			if projection[name] != 0 {
-				doc[name] = value
-			}
-		} else {
-			if projection[name] == 1 {
-				doc[name] = value
-			}
-		}
-	}
-
-	addField("_id", string(n.ID))
-	addField("content", n.Content)
-	addField("energy", n.Energy)
-	addField("depth", n.Depth)
-	addField("position", n.Position)
-	addField("tags", n.Tags)
-	addField("accessCount", n.AccessCount)
-	addField("createdAt", n.CreatedAt)
-	addField("lastFiredAt", n.LastFiredAt)
-	addField("metadata", n.Metadata)
-
-	return doc
+	return Document(n, DocumentOptions{Fields: DefaultDocumentFields, Projection: projection})
 }
 
 // DocumentToNeuron creates a neuron from a document (for inserts)
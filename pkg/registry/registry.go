@@ -1,14 +1,132 @@
 package registry
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// FallbackIndexesKey is the metadata key holding the ordered list of indexes
+// consulted when a search on this index returns too few results.
+const FallbackIndexesKey = "fallbackIndexes"
+
+// RolesFilterKey is the metadata key holding the authoring roles that
+// search, recall and context are restricted to when a request names none.
+const RolesFilterKey = "rolesFilter"
+
+// VectorKey is the metadata key holding per-index vector overrides:
+// {"alpha": 0.9, "queryRepeat": 1, "model": "code"}. Every field is
+// optional; unset ones use the server's vector settings.
+const VectorKey = "vector"
+
+// IndexedMetadataKeysKey is the metadata key holding metadata keys the
+// index keeps an inverted index of in addition to the server's
+// matrix.indexedMetadataKeys.
+const IndexedMetadataKeysKey = "indexedMetadataKeys"
+
+// RetentionKey is the metadata key holding the index's retention policy:
+// an ordered list of {match, maxAge, action} rules.
+const RetentionKey = "retention"
+
+// QuotaKey is the metadata key holding the index's disk quota, overriding
+// the server's storage.indexQuotaBytes and storage.indexQuotaPolicy:
+// {"bytes": 1073741824, "policy": "evict_lowest_energy"}.
+const QuotaKey = "quota"
+
+// SentimentKey is the metadata key holding the index's sentiment switch:
+// {"enabled": false} stops labeling its writes and queries.
+const SentimentKey = "sentiment"
+
+// ExpansionKey is the metadata key holding the index's query expansion
+// settings, overriding the server's search.expansion: {"enabled": true,
+// "maxTerms": 3, "maxAddedTokens": 8, "maxCueTokens": 3, "seeds": 5,
+// "weight": 0.4}. Every field is optional.
+const ExpansionKey = "expansion"
+
+// AppendOnlyKey is the metadata key marking an index append-only: true
+// keeps anything from removing or rewriting its memories. It can only be
+// set when the entry is created, and an append-only entry cannot be
+// renamed or deleted.
+const AppendOnlyKey = "appendOnly"
+
+// SimilarityKey is the metadata key holding the index's similarity
+// thresholds, overriding calibrated ones and the server's:
+// {"vectorThreshold": 0.8, "lexicalThreshold": 0.3}. Either is optional.
+const SimilarityKey = "similarity"
+
+// CalibrationKey is the metadata key holding the index's last similarity
+// calibration (POST /admin/indexes/{id}/calibrate): the distributions it
+// measured and, under vector.threshold and lexical.threshold, the
+// thresholds it derived from them.
+const CalibrationKey = "calibration"
+
+var (
+	// ErrInvalidFallback is returned when fallbackIndexes is not a list of
+	// non-empty strings.
+	ErrInvalidFallback = errors.New("fallbackIndexes must be a list of index IDs")
+
+	// ErrFallbackCycle is returned when an entry's fallback chain leads back
+	// to itself.
+	ErrFallbackCycle = errors.New("fallback chain contains a cycle")
+
+	// ErrInvalidRolesFilter is returned when rolesFilter is not a list of
+	// non-empty strings.
+	ErrInvalidRolesFilter = errors.New("rolesFilter must be a list of role names")
+
+	// ErrInvalidVectorOverride is returned when the vector key is not an
+	// object of valid alpha, queryRepeat and model values.
+	ErrInvalidVectorOverride = errors.New("vector must be an object with alpha (0.0-1.0), queryRepeat (1-3) and model (name)")
+
+	// ErrInvalidIndexedMetadataKeys is returned when indexedMetadataKeys is
+	// not a list of distinct metadata keys outside the reserved "_" range.
+	ErrInvalidIndexedMetadataKeys = errors.New("indexedMetadataKeys must be a list of distinct metadata keys not starting with _")
+
+	// ErrInvalidSentiment is returned when the sentiment key is not an
+	// object with a boolean enabled.
+	ErrInvalidSentiment = errors.New("sentiment must be an object with enabled (true or false)")
+
+	// ErrInvalidExpansion is returned when the expansion key is not an
+	// object of valid search.expansion settings.
+	ErrInvalidExpansion = errors.New("expansion must be an object with enabled, maxTerms, maxAddedTokens, maxCueTokens, seeds and weight")
+
+	// ErrInvalidAppendOnly is returned when appendOnly is not a boolean.
+	ErrInvalidAppendOnly = errors.New("appendOnly must be true or false")
+
+	// ErrInvalidSimilarity is returned when the similarity key is not an
+	// object of thresholds between 0 and 1.
+	ErrInvalidSimilarity = errors.New("similarity must be an object with vectorThreshold and lexicalThreshold (0.0-1.0)")
+
+	// ErrInvalidCalibration is returned when the calibration key is not a
+	// calibration with thresholds between 0 and 1.
+	ErrInvalidCalibration = errors.New("calibration must be an object with vector and lexical measures whose thresholds are 0.0-1.0")
+
+	// ErrAppendOnlyImmutable is returned when an update would change
+	// appendOnly, which is fixed when the entry is created.
+	ErrAppendOnlyImmutable = errors.New("appendOnly can only be set when an index is registered")
 )
 
+// VectorOverride is an index's override of the server vector settings.
+// Nil and empty fields are not overridden.
+type VectorOverride struct {
+	Alpha       *float64
+	QueryRepeat *int
+	Model       string
+}
+
+// SimilarityThresholds are an index's similarity thresholds for embedding
+// cosine (Vector) and token Jaccard (Lexical). Nil fields are not set.
+type SimilarityThresholds struct {
+	Vector  *float64
+	Lexical *float64
+}
+
 // Entry represents a registered UUID with its metadata
 type Entry struct {
 	UUID      string         `json:"uuid"`
@@ -50,6 +168,9 @@ func (s *Store) Create(uuid string, metadata map[string]any) (*Entry, error) {
 	if _, exists := s.entries[uuid]; exists {
 		return nil, fmt.Errorf("uuid already exists: %s", uuid)
 	}
+	if err := s.checkMetadata(uuid, "", metadata); err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
 	entry := &Entry{
@@ -115,6 +236,15 @@ func (s *Store) Update(oldUUID string, newUUID string, metadata map[string]any)
 			return nil, fmt.Errorf("new uuid already exists: %s", newUUID)
 		}
 	}
+	if err := s.checkMetadata(newUUID, oldUUID, metadata); err != nil {
+		return nil, err
+	}
+	if err := checkAppendOnlyUnchanged(entry.Metadata, metadata); err != nil {
+		return nil, err
+	}
+	if newUUID != oldUUID && appendOnly(entry.Metadata) {
+		return nil, fmt.Errorf("%w: %s cannot be renamed", core.ErrAppendOnly, oldUUID)
+	}
 
 	// Update entry
 	entry.UUID = newUUID
@@ -150,6 +280,9 @@ func (s *Store) Delete(uuid string) error {
 	}
 
 	deleted := s.entries[uuid]
+	if appendOnly(deleted.Metadata) {
+		return fmt.Errorf("%w: %s cannot be unregistered", core.ErrAppendOnly, uuid)
+	}
 	delete(s.entries, uuid)
 
 	if err := s.save(); err != nil {
@@ -160,6 +293,37 @@ func (s *Store) Delete(uuid string) error {
 	return nil
 }
 
+// DeleteWith removes uuid's entry together with the data it names, even
+// when it is append-only; callers decide whether that is allowed. The
+// entry's removal is persisted first and then remove is called with the
+// registry locked; if remove fails the entry is put back so the registry
+// never forgets an index whose data survived. When uuid is not registered
+// remove still runs and deleted is false.
+func (s *Store) DeleteWith(uuid string, remove func() error) (deleted bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[uuid]
+	if !exists {
+		return false, remove()
+	}
+
+	delete(s.entries, uuid)
+	if err := s.save(); err != nil {
+		s.entries[uuid] = entry
+		return false, fmt.Errorf("failed to persist: %w", err)
+	}
+
+	if err := remove(); err != nil {
+		s.entries[uuid] = entry
+		if saveErr := s.save(); saveErr != nil {
+			return false, fmt.Errorf("%w (registry rollback failed: %v)", err, saveErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 // FindOrCreate returns existing entry or creates a new one
 func (s *Store) FindOrCreate(uuid string, metadata map[string]any) (*Entry, bool, error) {
 	s.mu.Lock()
@@ -168,6 +332,9 @@ func (s *Store) FindOrCreate(uuid string, metadata map[string]any) (*Entry, bool
 	if entry, exists := s.entries[uuid]; exists {
 		return entry, false, nil // found, not created
 	}
+	if err := s.checkMetadata(uuid, "", metadata); err != nil {
+		return nil, false, err
+	}
 
 	now := time.Now()
 	entry := &Entry{
@@ -194,8 +361,593 @@ func (s *Store) Count() int {
 	return len(s.entries)
 }
 
+// Fallbacks returns the fallback indexes configured for uuid, or nil.
+// Malformed metadata written before validation existed is ignored.
+func (s *Store) Fallbacks(uuid string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return nil
+	}
+	fallbacks, _ := FallbackIndexes(entry.Metadata)
+	return fallbacks
+}
+
+// FallbackIndexes extracts the fallback list from entry metadata.
+func FallbackIndexes(metadata map[string]any) ([]string, error) {
+	ids, ok := stringList(metadata[FallbackIndexesKey])
+	if !ok {
+		return nil, ErrInvalidFallback
+	}
+	return ids, nil
+}
+
+// DefaultRoles returns the rolesFilter configured for uuid, or nil.
+func (s *Store) DefaultRoles(uuid string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return nil
+	}
+	roles, _ := RolesFilter(entry.Metadata)
+	return roles
+}
+
+// RolesFilter extracts the default roles list from entry metadata.
+func RolesFilter(metadata map[string]any) ([]string, error) {
+	roles, ok := stringList(metadata[RolesFilterKey])
+	if !ok {
+		return nil, ErrInvalidRolesFilter
+	}
+	return roles, nil
+}
+
+// MetadataIndexKeys returns the indexedMetadataKeys configured for uuid, or
+// nil.
+func (s *Store) MetadataIndexKeys(uuid string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return nil
+	}
+	keys, _ := IndexedMetadataKeys(entry.Metadata)
+	return keys
+}
+
+// IndexedMetadataKeys extracts the extra indexed metadata keys from entry
+// metadata.
+func IndexedMetadataKeys(metadata map[string]any) ([]string, error) {
+	keys, ok := stringList(metadata[IndexedMetadataKeysKey])
+	if !ok || core.ValidateIndexedMetadataKeys(keys) != nil {
+		return nil, ErrInvalidIndexedMetadataKeys
+	}
+	return keys, nil
+}
+
+// VectorOverrides returns the vector overrides configured for uuid, or nil.
+func (s *Store) VectorOverrides(uuid string) *VectorOverride {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return nil
+	}
+	override, _ := VectorConfig(entry.Metadata)
+	return override
+}
+
+// VectorConfig extracts the vector overrides from entry metadata; nil
+// when none are set.
+func VectorConfig(metadata map[string]any) (*VectorOverride, error) {
+	raw, ok := metadata[VectorKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	fields, ok := raw.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidVectorOverride
+	}
+
+	override := &VectorOverride{}
+	for k, v := range fields {
+		switch k {
+		case "alpha":
+			alpha, ok := v.(float64)
+			if !ok || alpha < 0 || alpha > 1 {
+				return nil, ErrInvalidVectorOverride
+			}
+			override.Alpha = &alpha
+		case "queryRepeat":
+			f, ok := v.(float64)
+			if !ok || f != float64(int(f)) || f < 1 || f > 3 {
+				return nil, ErrInvalidVectorOverride
+			}
+			repeat := int(f)
+			override.QueryRepeat = &repeat
+		case "model":
+			model, ok := v.(string)
+			if !ok || model == "" {
+				return nil, ErrInvalidVectorOverride
+			}
+			override.Model = model
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidVectorOverride, k)
+		}
+	}
+	return override, nil
+}
+
+// SentimentEnabled reports whether uuid uses the sentiment layer: true
+// unless its entry turns it off.
+func (s *Store) SentimentEnabled(uuid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return true
+	}
+	enabled, err := SentimentConfig(entry.Metadata)
+	return err != nil || enabled
+}
+
+// SentimentConfig extracts the sentiment switch from entry metadata; true
+// when none is set.
+func SentimentConfig(metadata map[string]any) (bool, error) {
+	raw, ok := metadata[SentimentKey]
+	if !ok || raw == nil {
+		return true, nil
+	}
+	fields, ok := raw.(map[string]any)
+	if !ok {
+		return false, ErrInvalidSentiment
+	}
+	enabled := true
+	for k, v := range fields {
+		if k != "enabled" {
+			return false, fmt.Errorf("%w: unknown field %q", ErrInvalidSentiment, k)
+		}
+		if enabled, ok = v.(bool); !ok {
+			return false, ErrInvalidSentiment
+		}
+	}
+	return enabled, nil
+}
+
+// Expansion returns the query expansion settings of uuid: defaults with
+// its entry's overrides applied.
+func (s *Store) Expansion(uuid string, defaults core.ExpansionConfig) core.ExpansionConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return defaults
+	}
+	cfg, err := ExpansionConfig(entry.Metadata, defaults)
+	if err != nil {
+		return defaults
+	}
+	return cfg
+}
+
+// ExpansionConfig applies the expansion overrides of entry metadata to
+// defaults. The result must pass core.ExpansionConfig.Validate.
+func ExpansionConfig(metadata map[string]any, defaults core.ExpansionConfig) (core.ExpansionConfig, error) {
+	raw, ok := metadata[ExpansionKey]
+	if !ok || raw == nil {
+		return defaults, nil
+	}
+	fields, ok := raw.(map[string]any)
+	if !ok {
+		return defaults, ErrInvalidExpansion
+	}
+
+	cfg := defaults
+	for k, v := range fields {
+		var target *int
+		switch k {
+		case "enabled":
+			enabled, ok := v.(bool)
+			if !ok {
+				return defaults, fmt.Errorf("%w: enabled must be true or false", ErrInvalidExpansion)
+			}
+			cfg.Enabled = enabled
+			continue
+		case "weight":
+			weight, ok := v.(float64)
+			if !ok {
+				return defaults, fmt.Errorf("%w: weight must be a number", ErrInvalidExpansion)
+			}
+			cfg.Weight = weight
+			continue
+		case "maxTerms":
+			target = &cfg.MaxTerms
+		case "maxAddedTokens":
+			target = &cfg.MaxAddedTokens
+		case "maxCueTokens":
+			target = &cfg.MaxCueTokens
+		case "seeds":
+			target = &cfg.Seeds
+		default:
+			return defaults, fmt.Errorf("%w: unknown field %q", ErrInvalidExpansion, k)
+		}
+		f, ok := v.(float64)
+		if !ok || f != float64(int(f)) {
+			return defaults, fmt.Errorf("%w: %s must be an integer", ErrInvalidExpansion, k)
+		}
+		*target = int(f)
+	}
+	if err := cfg.Validate(); err != nil {
+		return defaults, fmt.Errorf("%w: %v", ErrInvalidExpansion, err)
+	}
+	return cfg, nil
+}
+
+// AppendOnly reports whether uuid is registered as append-only.
+func (s *Store) AppendOnly(uuid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	return ok && appendOnly(entry.Metadata)
+}
+
+// AppendOnlyConfig extracts the appendOnly flag from entry metadata; false
+// when none is set.
+func AppendOnlyConfig(metadata map[string]any) (bool, error) {
+	raw, ok := metadata[AppendOnlyKey]
+	if !ok || raw == nil {
+		return false, nil
+	}
+	enabled, ok := raw.(bool)
+	if !ok {
+		return false, ErrInvalidAppendOnly
+	}
+	return enabled, nil
+}
+
+// appendOnly reports whether metadata marks its index append-only.
+func appendOnly(metadata map[string]any) bool {
+	enabled, _ := AppendOnlyConfig(metadata)
+	return enabled
+}
+
+// checkAppendOnlyUnchanged refuses metadata replacing previous when it
+// would change the appendOnly flag.
+func checkAppendOnlyUnchanged(previous, metadata map[string]any) error {
+	if appendOnly(previous) != appendOnly(metadata) {
+		return ErrAppendOnlyImmutable
+	}
+	return nil
+}
+
+// SimilarityThresholds returns the similarity thresholds set for uuid and
+// those its last calibration derived.
+func (s *Store) SimilarityThresholds(uuid string) (override, calibrated SimilarityThresholds) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return override, calibrated
+	}
+	override, _ = SimilarityConfig(entry.Metadata)
+	calibrated, _ = Calibration(entry.Metadata)
+	return override, calibrated
+}
+
+// SimilarityConfig extracts the similarity thresholds set in entry
+// metadata.
+func SimilarityConfig(metadata map[string]any) (SimilarityThresholds, error) {
+	var t SimilarityThresholds
+	raw, ok := metadata[SimilarityKey]
+	if !ok || raw == nil {
+		return t, nil
+	}
+	fields, ok := raw.(map[string]any)
+	if !ok {
+		return t, ErrInvalidSimilarity
+	}
+	for k, v := range fields {
+		threshold, ok := v.(float64)
+		if !ok || threshold < 0 || threshold > 1 {
+			return SimilarityThresholds{}, ErrInvalidSimilarity
+		}
+		switch k {
+		case "vectorThreshold":
+			t.Vector = &threshold
+		case "lexicalThreshold":
+			t.Lexical = &threshold
+		default:
+			return SimilarityThresholds{}, fmt.Errorf("%w: unknown field %q", ErrInvalidSimilarity, k)
+		}
+	}
+	return t, nil
+}
+
+// Calibration extracts the thresholds of the calibration in entry
+// metadata; a measure without one is not set.
+func Calibration(metadata map[string]any) (SimilarityThresholds, error) {
+	var t SimilarityThresholds
+	raw, ok := metadata[CalibrationKey]
+	if !ok || raw == nil {
+		return t, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return t, fmt.Errorf("%w: %v", ErrInvalidCalibration, err)
+	}
+	type measure struct {
+		Threshold *float64 `json:"threshold"`
+	}
+	var c struct {
+		Vector  *measure `json:"vector"`
+		Lexical *measure `json:"lexical"`
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return t, ErrInvalidCalibration
+	}
+	for _, m := range []struct {
+		measure *measure
+		target  **float64
+	}{{c.Vector, &t.Vector}, {c.Lexical, &t.Lexical}} {
+		if m.measure == nil || m.measure.Threshold == nil {
+			continue
+		}
+		if threshold := *m.measure.Threshold; threshold < 0 || threshold > 1 {
+			return SimilarityThresholds{}, ErrInvalidCalibration
+		}
+		*m.target = m.measure.Threshold
+	}
+	return t, nil
+}
+
+// RetentionRules returns the retention policy configured for uuid, and
+// whether the entry has one.
+func (s *Store) RetentionRules(uuid string) ([]core.RetentionRule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return nil, false
+	}
+	rules, err := Retention(entry.Metadata)
+	if err != nil || rules == nil {
+		return nil, false
+	}
+	return rules, true
+}
+
+// Retention extracts the retention policy from entry metadata; nil when
+// none is set.
+func Retention(metadata map[string]any) ([]core.RetentionRule, error) {
+	raw, ok := metadata[RetentionKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrInvalidRetention, err)
+	}
+	rules := []core.RetentionRule{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: must be a list of {match, maxAge, action} rules", core.ErrInvalidRetention)
+	}
+	if err := core.ValidateRetentionRules(rules, 0); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// IndexQuota returns the disk quota configured for uuid, and whether the
+// entry has one.
+func (s *Store) IndexQuota(uuid string) (core.IndexQuota, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[uuid]
+	if !ok {
+		return core.IndexQuota{}, false
+	}
+	quota, err := Quota(entry.Metadata)
+	if err != nil || quota == nil {
+		return core.IndexQuota{}, false
+	}
+	return *quota, true
+}
+
+// Quota extracts the disk quota from entry metadata; nil when none is set.
+func Quota(metadata map[string]any) (*core.IndexQuota, error) {
+	raw, ok := metadata[QuotaKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrInvalidQuota, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	quota := &core.IndexQuota{}
+	if err := dec.Decode(quota); err != nil {
+		return nil, fmt.Errorf("%w: must be an object of bytes and policy", core.ErrInvalidQuota)
+	}
+	if err := quota.Validate(); err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// SetMetadataKey sets one metadata key of uuid's entry, leaving the others
+// alone; a nil value removes the key.
+func (s *Store) SetMetadataKey(uuid, key string, value any) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[uuid]
+	if !exists {
+		return nil, fmt.Errorf("uuid not found: %s", uuid)
+	}
+	metadata := make(map[string]any, len(entry.Metadata)+1)
+	for k, v := range entry.Metadata {
+		metadata[k] = v
+	}
+	if value == nil {
+		delete(metadata, key)
+	} else {
+		metadata[key] = value
+	}
+	if err := s.checkMetadata(uuid, uuid, metadata); err != nil {
+		return nil, err
+	}
+	if err := checkAppendOnlyUnchanged(entry.Metadata, metadata); err != nil {
+		return nil, err
+	}
+
+	previous := entry.Metadata
+	entry.Metadata = metadata
+	entry.UpdatedAt = time.Now()
+	if err := s.save(); err != nil {
+		entry.Metadata = previous
+		return nil, fmt.Errorf("failed to persist: %w", err)
+	}
+	return entry, nil
+}
+
+// stringList converts a JSON-decoded list of non-empty strings. A missing
+// (nil) value is an empty list.
+func stringList(raw any) ([]string, bool) {
+	var items []string
+	switch v := raw.(type) {
+	case nil:
+		return nil, true
+	case []string:
+		items = v
+	case []any:
+		items = make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, str)
+		}
+	default:
+		return nil, false
+	}
+
+	for _, item := range items {
+		if item == "" {
+			return nil, false
+		}
+	}
+	return items, true
+}
+
+// checkMetadata validates the reserved metadata keys of an entry being
+// written. Caller must hold s.mu.
+func (s *Store) checkMetadata(uuid, replacing string, metadata map[string]any) error {
+	if _, err := RolesFilter(metadata); err != nil {
+		return err
+	}
+	if _, err := VectorConfig(metadata); err != nil {
+		return err
+	}
+	if _, err := IndexedMetadataKeys(metadata); err != nil {
+		return err
+	}
+	if _, err := Retention(metadata); err != nil {
+		return err
+	}
+	if _, err := Quota(metadata); err != nil {
+		return err
+	}
+	if _, err := SentimentConfig(metadata); err != nil {
+		return err
+	}
+	if _, err := AppendOnlyConfig(metadata); err != nil {
+		return err
+	}
+	if _, err := SimilarityConfig(metadata); err != nil {
+		return err
+	}
+	if _, err := Calibration(metadata); err != nil {
+		return err
+	}
+	// The bounds checked do not depend on the server's defaults.
+	if _, err := ExpansionConfig(metadata, core.DefaultConfig().Search.Expansion); err != nil {
+		return err
+	}
+	return s.checkFallbacks(uuid, replacing, metadata)
+}
+
+// checkFallbacks validates metadata for uuid and rejects it if following
+// the fallback chain from uuid would revisit uuid. replacing is the UUID
+// being renamed away from, if any. Caller must hold s.mu.
+func (s *Store) checkFallbacks(uuid, replacing string, metadata map[string]any) error {
+	start, err := FallbackIndexes(metadata)
+	if err != nil {
+		return err
+	}
+	if len(start) == 0 {
+		return nil
+	}
+
+	next := func(id string) []string {
+		if id == replacing {
+			return nil
+		}
+		entry, ok := s.entries[id]
+		if !ok {
+			return nil
+		}
+		ids, _ := FallbackIndexes(entry.Metadata)
+		return ids
+	}
+
+	visited := make(map[string]bool)
+	stack := append([]string(nil), start...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == uuid {
+			return fmt.Errorf("%w: %s", ErrFallbackCycle, uuid)
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, next(id)...)
+	}
+	return nil
+}
+
 // ── Persistence ──────────────────────────────────────────────
 
+// Relocate moves the store's file to registry.json under dataPath, as a storage
+// migration moves the data directory, and writes the current registry
+// there. On failure the store keeps its file.
+func (s *Store) Relocate(dataPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.filePath
+	s.filePath = filepath.Join(dataPath, "registry.json")
+	if err := s.save(); err != nil {
+		s.filePath = prev
+		return err
+	}
+	return nil
+}
+
 func (s *Store) load() error {
 	data, err := os.ReadFile(s.filePath)
 	if err != nil {
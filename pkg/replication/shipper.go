@@ -0,0 +1,292 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxBackoff caps the wait between attempts at an entry the standby keeps
+// failing.
+const maxBackoff = time.Minute
+
+// Config configures a Shipper.
+type Config struct {
+	// URL is the standby's base URL. Credentials in it are sent as basic
+	// auth, which the standby's admin endpoints need to replay resets.
+	URL string
+
+	// APIKey, if set, is sent as X-API-Key with every request.
+	APIKey string
+
+	// FlushInterval is how often the shipper wakes up to ship what the
+	// spool holds, and the first retry delay after a failure.
+	FlushInterval time.Duration
+
+	// Timeout bounds each request to the standby.
+	Timeout time.Duration
+}
+
+// Shipper replays spooled entries against the standby in spool order,
+// which keeps every index's mutations in commit order. An entry that fails
+// with a transient error (network, 408, 429, 5xx) is retried with backoff
+// before anything after it; one the standby rejects outright (other 4xx)
+// is counted and skipped.
+type Shipper struct {
+	spool   *Spool
+	baseURL string
+	apiKey  string
+	client  *http.Client
+	flush   time.Duration
+
+	shipped  atomic.Uint64
+	rejected atomic.Uint64
+	retries  atomic.Uint64
+
+	mu            sync.Mutex
+	lastShippedAt time.Time
+	lastError     string
+	lastErrorAt   time.Time
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewShipper starts shipping spool's entries to the standby.
+func NewShipper(spool *Spool, cfg Config) *Shipper {
+	sh := &Shipper{
+		spool:   spool,
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		apiKey:  cfg.APIKey,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		flush:   cfg.FlushInterval,
+		stop:    make(chan struct{}),
+	}
+	sh.wg.Add(1)
+	go sh.run()
+	return sh
+}
+
+func (sh *Shipper) run() {
+	defer sh.wg.Done()
+	wait := sh.flush
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-sh.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if sh.drain() {
+			wait = sh.flush
+		} else {
+			sh.retries.Add(1)
+			wait = min(wait*2, maxBackoff)
+		}
+	}
+}
+
+// drain ships entries until the spool is empty, returning false if an
+// entry failed and must be retried.
+func (sh *Shipper) drain() bool {
+	for {
+		select {
+		case <-sh.stop:
+			return true
+		default:
+		}
+		e, ok := sh.spool.Peek()
+		if !ok {
+			return true
+		}
+		retry, err := sh.ship(e)
+		if err != nil {
+			sh.recordError(e, err)
+			if retry {
+				return false
+			}
+			sh.rejected.Add(1)
+		} else {
+			sh.shipped.Add(1)
+			sh.mu.Lock()
+			sh.lastShippedAt = time.Now()
+			sh.mu.Unlock()
+		}
+		if err := sh.spool.Ack(e.Seq); err != nil {
+			sh.recordError(e, err)
+			return false
+		}
+	}
+}
+
+func (sh *Shipper) recordError(e Entry, err error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.lastError = fmt.Sprintf("seq %d (%s %s): %v", e.Seq, e.Op, e.Index, err)
+	sh.lastErrorAt = time.Now()
+}
+
+// ship replays e against the standby. retry reports whether a failure is
+// worth retrying.
+func (sh *Shipper) ship(e Entry) (retry bool, err error) {
+	switch e.Op {
+	case OpWrite:
+		body := map[string]any{"content": e.Content}
+		if len(e.Metadata) > 0 {
+			body["metadata"] = e.Metadata
+		}
+		if e.Pinned {
+			body["pinned"] = true
+		}
+		// References to neurons the standby never got, e.g. written before
+		// replication was enabled, are dropped rather than failing the write.
+		if id, ok := sh.spool.StandbyID(e.Index, e.ParentID); ok && e.ParentID != "" {
+			body["parent_id"] = id
+		}
+		if id, ok := sh.spool.StandbyID(e.Index, e.Supersedes); ok && e.Supersedes != "" {
+			body["supersedes"] = id
+		}
+		var related []string
+		for _, primary := range e.Related {
+			if id, ok := sh.spool.StandbyID(e.Index, primary); ok {
+				related = append(related, id)
+			}
+		}
+		if len(related) > 0 {
+			body["related_ids"] = related
+			if e.RelatedType != "" {
+				body["related_type"] = e.RelatedType
+			}
+		}
+		var doc struct {
+			ID string `json:"id"`
+		}
+		if retry, err := sh.do("POST", "/v1/write", e.Index, body, &doc); err != nil {
+			return retry, err
+		}
+		if doc.ID == "" {
+			return false, fmt.Errorf("standby write returned no id")
+		}
+		return true, sh.spool.MapID(e.Index, e.NeuronID, doc.ID)
+
+	case OpForget:
+		id, ok := sh.spool.StandbyID(e.Index, e.NeuronID)
+		if !ok {
+			return false, fmt.Errorf("neuron %s was never shipped", e.NeuronID)
+		}
+		return sh.do("DELETE", "/v1/forget/"+url.PathEscape(id), e.Index, nil, nil)
+
+	case OpRestore, OpPurge:
+		id, ok := sh.spool.StandbyID(e.Index, e.NeuronID)
+		if !ok {
+			return false, fmt.Errorf("neuron %s was never shipped", e.NeuronID)
+		}
+		if e.Op == OpRestore {
+			return sh.do("POST", "/v1/recycle/"+url.PathEscape(id)+"/restore", e.Index, nil, nil)
+		}
+		return sh.do("DELETE", "/v1/recycle/"+url.PathEscape(id), e.Index, nil, nil)
+
+	case OpReset:
+		if retry, err := sh.do("POST", "/admin/indexes/"+url.PathEscape(e.Index)+"/reset", e.Index, nil, nil); err != nil {
+			return retry, err
+		}
+		return true, sh.spool.ResetIDs(e.Index)
+	}
+	return false, fmt.Errorf("unknown op %q", e.Op)
+}
+
+// do sends one request to the standby and decodes a 2xx response into
+// out, if set.
+func (sh *Shipper) do(method, path, index string, body, out any) (retry bool, err error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return false, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, sh.baseURL+path, reader)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-Index-ID", index)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if sh.apiKey != "" {
+		req.Header.Set("X-API-Key", sh.apiKey)
+	}
+
+	resp, err := sh.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		if out != nil {
+			if err := json.Unmarshal(data, out); err != nil {
+				return true, fmt.Errorf("decode standby response: %w", err)
+			}
+		}
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("standby answered %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	default:
+		return false, fmt.Errorf("standby rejected with %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+}
+
+// URL returns the standby's base URL with any password redacted.
+func (sh *Shipper) URL() string {
+	return redactURL(sh.baseURL)
+}
+
+// Stats returns shipping counters, the spool's counters and the lag.
+func (sh *Shipper) Stats() map[string]any {
+	stats := sh.spool.Stats()
+	entries, seconds := sh.spool.Lag(time.Now())
+	stats["lag"] = map[string]any{"entries": entries, "seconds": seconds}
+	stats["shipped"] = sh.shipped.Load()
+	stats["rejected"] = sh.rejected.Load()
+	stats["retries"] = sh.retries.Load()
+	stats["url"] = sh.URL()
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if !sh.lastShippedAt.IsZero() {
+		stats["lastShippedAt"] = sh.lastShippedAt
+	}
+	if sh.lastError != "" {
+		stats["lastError"] = map[string]any{"message": sh.lastError, "at": sh.lastErrorAt}
+	}
+	return stats
+}
+
+// Close stops shipping. Unshipped entries stay in the spool.
+func (sh *Shipper) Close() {
+	sh.once.Do(func() {
+		close(sh.stop)
+		sh.wg.Wait()
+	})
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,130 @@
+package replication
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStandby records the requests it receives and fails every write once
+// failAfter writes have succeeded, until failAfter is raised.
+type fakeStandby struct {
+	mu        sync.Mutex
+	requests  []string
+	writes    []map[string]any
+	failAfter atomic.Int32
+}
+
+func (f *fakeStandby) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Index-ID"))
+	switch {
+	case r.URL.Path == "/v1/write":
+		if int32(len(f.writes)) >= f.failAfter.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["content"] == "rejected" {
+			http.Error(w, "bad write", http.StatusBadRequest)
+			return
+		}
+		f.writes = append(f.writes, body)
+		fmt.Fprintf(w, `{"id":"s%d"}`, len(f.writes))
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (f *fakeStandby) written() []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]any(nil), f.writes...)
+}
+
+func waitForDrain(t *testing.T, s *Spool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if n, _ := s.Lag(time.Now()); n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the shipper did not drain the spool")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShipper_ResumesAfterRestartMidShipment(t *testing.T) {
+	standby := &fakeStandby{}
+	standby.failAfter.Store(2)
+	srv := httptest.NewServer(standby)
+	defer srv.Close()
+	dir := t.TempDir()
+	cfg := Config{URL: srv.URL, FlushInterval: 5 * time.Millisecond, Timeout: time.Second}
+
+	s := openTestSpool(t, dir, 100, OverflowBlock)
+	appendWrites(t, s, "team-a", "one", "two", "three")
+	s.Append(Entry{Index: "team-a", Op: OpWrite, NeuronID: "four-id", Content: "four", Supersedes: "one-id"})
+	s.Append(Entry{Index: "team-a", Op: OpWrite, NeuronID: "bad-id", Content: "rejected"})
+	s.Append(Entry{Index: "team-a", Op: OpReset})
+
+	sh := NewShipper(s, cfg)
+	deadline := time.Now().Add(5 * time.Second)
+	for sh.Stats()["retries"].(uint64) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the shipper never retried the failing write")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sh.Close()
+	s.Close()
+
+	// Restart with the standby healthy again.
+	standby.failAfter.Store(100)
+	s = openTestSpool(t, dir, 100, OverflowBlock)
+	if n, _ := s.Lag(time.Now()); n != 4 {
+		t.Fatalf("expected the 4 unshipped entries to survive the restart, got %d", n)
+	}
+	sh = NewShipper(s, cfg)
+	defer sh.Close()
+	waitForDrain(t, s)
+
+	writes := standby.written()
+	if len(writes) != 4 {
+		t.Fatalf("expected each write shipped once, got %v", writes)
+	}
+	for i, want := range []string{"one", "two", "three", "four"} {
+		if writes[i]["content"] != want {
+			t.Errorf("write %d: expected %q in commit order, got %v", i, want, writes[i]["content"])
+		}
+	}
+	if writes[3]["supersedes"] != "s1" {
+		t.Errorf("supersedes should be mapped to the standby's ID from before the restart, got %v", writes[3]["supersedes"])
+	}
+	standby.mu.Lock()
+	last := standby.requests[len(standby.requests)-1]
+	standby.mu.Unlock()
+	if last != "POST /admin/indexes/team-a/reset team-a" {
+		t.Errorf("expected the reset to be shipped last, got %q", last)
+	}
+	if _, ok := s.StandbyID("team-a", "one-id"); ok {
+		t.Error("the shipped reset should clear the index's ID mappings")
+	}
+
+	stats := sh.Stats()
+	if stats["shipped"] != uint64(3) || stats["rejected"] != uint64(1) {
+		t.Errorf("unexpected shipper stats: %v", stats)
+	}
+	if lag := stats["lag"].(map[string]any); lag["entries"] != 0 {
+		t.Errorf("expected no lag, got %v", lag)
+	}
+}
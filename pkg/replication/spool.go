@@ -0,0 +1,458 @@
+// Package replication mirrors committed mutations to a warm standby server.
+// Mutations are appended to a bounded on-disk spool and a shipper replays
+// them, in order, against the standby's HTTP API. Delivery is at least
+// once: an entry shipped just before a crash is shipped again on restart,
+// which the standby's content deduplication absorbs for writes.
+package replication
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// Op is the kind of mutation an entry replays.
+type Op string
+
+const (
+	OpWrite   Op = "write"
+	OpForget  Op = "forget"
+	OpRestore Op = "restore" // a forgotten neuron out of the recycle bin
+	OpPurge   Op = "purge"   // a forgotten neuron removed for good
+	OpReset   Op = "reset"
+)
+
+// Overflow policies for a full spool.
+const (
+	// OverflowBlock makes Append wait for the shipper to make room, so
+	// writes on the primary stall while the standby is unreachable.
+	OverflowBlock = "block"
+
+	// OverflowDropOldest discards the oldest unshipped entry to make room.
+	// The standby then misses that mutation until it is re-seeded.
+	OverflowDropOldest = "drop_oldest"
+)
+
+// ErrClosed is returned by Append once the spool is closed.
+var ErrClosed = errors.New("replication spool is closed")
+
+// File names under the spool directory.
+const (
+	spoolFile  = "spool.jsonl" // entries, oldest first
+	cursorFile = "cursor"      // highest seq shipped or dropped
+	idsFile    = "ids.jsonl"   // primary to standby neuron ID mappings
+)
+
+// Entry is one committed mutation awaiting shipment.
+type Entry struct {
+	Seq      uint64    `json:"seq"`
+	Time     time.Time `json:"time"` // when the primary committed it
+	Index    string    `json:"index"`
+	Op       Op        `json:"op"`
+	NeuronID string    `json:"neuronId,omitempty"` // primary ID; all but reset
+
+	// Write fields, with neuron references still primary IDs.
+	Content    string            `json:"content,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	ParentID   string            `json:"parentId,omitempty"`
+	Supersedes string            `json:"supersedes,omitempty"`
+	Pinned     bool              `json:"pinned,omitempty"`
+
+	Related     []string `json:"related,omitempty"`
+	RelatedType string   `json:"relatedType,omitempty"`
+}
+
+// idRecord is one line of ids.jsonl. A record with Reset set forgets every
+// mapping of Index.
+type idRecord struct {
+	Index   string `json:"index"`
+	Primary string `json:"primary,omitempty"`
+	Standby string `json:"standby,omitempty"`
+	Reset   bool   `json:"reset,omitempty"`
+}
+
+// Spool is the durable outbound queue. Entries are appended to a file as
+// they are committed and stay there until the shipper acknowledges them,
+// so a restart resumes where shipping stopped. It also keeps the mapping
+// from primary to standby neuron IDs, since the standby assigns its own.
+type Spool struct {
+	dir      string
+	capacity int
+	overflow string
+
+	mu      sync.Mutex
+	space   *sync.Cond // signalled when entries leave the queue or on Close
+	file    *os.File
+	idFile  *os.File
+	pending []Entry
+	nextSeq uint64
+	done    uint64 // highest seq shipped or dropped
+	written int    // entries in the spool file, shipped ones included
+	ids     map[string]map[string]string
+	closed  bool
+
+	appended uint64
+	dropped  uint64
+	blocked  uint64 // appends that waited for room
+}
+
+// OpenSpool opens or creates the spool in dir, recovering entries that
+// were not shipped before the last shutdown. capacity bounds unshipped
+// entries; overflow is OverflowBlock or OverflowDropOldest.
+func OpenSpool(dir string, capacity int, overflow string) (*Spool, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("replication spool capacity must be >= 1")
+	}
+	if overflow != OverflowBlock && overflow != OverflowDropOldest {
+		return nil, fmt.Errorf("unknown replication overflow policy %q", overflow)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &Spool{
+		dir:      dir,
+		capacity: capacity,
+		overflow: overflow,
+		ids:      make(map[string]map[string]string),
+	}
+	s.space = sync.NewCond(&s.mu)
+
+	if err := s.loadCursor(); err != nil {
+		return nil, err
+	}
+	if err := s.loadEntries(); err != nil {
+		return nil, err
+	}
+	if err := s.loadIDs(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if s.file, err = os.OpenFile(s.path(spoolFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		return nil, err
+	}
+	if s.idFile, err = os.OpenFile(s.path(idsFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		s.file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Spool) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func (s *Spool) loadCursor() error {
+	data, err := os.ReadFile(s.path(cursorFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	done, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("replication cursor: %w", err)
+	}
+	s.done = done
+	s.nextSeq = done + 1
+	return nil
+}
+
+// loadEntries reads the spool file. A torn last line, from a crash
+// mid-append, is skipped.
+func (s *Spool) loadEntries() error {
+	f, err := os.Open(s.path(spoolFile))
+	if errors.Is(err, os.ErrNotExist) {
+		s.nextSeq = max(s.nextSeq, 1)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Seq == 0 {
+			continue
+		}
+		s.written++
+		if e.Seq >= s.nextSeq {
+			s.nextSeq = e.Seq + 1
+		}
+		if e.Seq > s.done {
+			s.pending = append(s.pending, e)
+		}
+	}
+	s.nextSeq = max(s.nextSeq, 1)
+	return scanner.Err()
+}
+
+func (s *Spool) loadIDs() error {
+	f, err := os.Open(s.path(idsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec idRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		s.applyID(rec)
+	}
+	return scanner.Err()
+}
+
+func (s *Spool) applyID(rec idRecord) {
+	if rec.Reset {
+		delete(s.ids, rec.Index)
+		return
+	}
+	m := s.ids[rec.Index]
+	if m == nil {
+		m = make(map[string]string)
+		s.ids[rec.Index] = m
+	}
+	m[rec.Primary] = rec.Standby
+}
+
+// Append queues e, assigning its sequence number and, if unset, its time.
+// On a full spool it waits for room or drops the oldest entry, depending
+// on the overflow policy.
+func (s *Spool) Append(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waited := false
+	for !s.closed && len(s.pending) >= s.capacity {
+		if s.overflow == OverflowDropOldest {
+			s.done = s.pending[0].Seq
+			s.pending = s.pending[1:]
+			s.dropped++
+			if err := s.saveCursor(); err != nil {
+				return err
+			}
+			break
+		}
+		if !waited {
+			waited = true
+			s.blocked++
+		}
+		s.space.Wait()
+	}
+	if s.closed {
+		return ErrClosed
+	}
+
+	e.Seq = s.nextSeq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.nextSeq++
+	s.written++
+	s.appended++
+	s.pending = append(s.pending, e)
+	return nil
+}
+
+// Peek returns the oldest unshipped entry.
+func (s *Spool) Peek() (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return Entry{}, false
+	}
+	return s.pending[0], true
+}
+
+// Ack marks every entry up to seq as shipped. The spool file is truncated
+// once it holds no unshipped entries, and rewritten once shipped ones
+// outnumber the capacity.
+func (s *Spool) Ack(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seq <= s.done {
+		// Dropped while it was being shipped.
+		return nil
+	}
+	s.done = seq
+	i := 0
+	for i < len(s.pending) && s.pending[i].Seq <= seq {
+		i++
+	}
+	s.pending = s.pending[i:]
+	s.space.Broadcast()
+	if err := s.saveCursor(); err != nil {
+		return err
+	}
+
+	switch {
+	case len(s.pending) == 0:
+		if err := s.file.Truncate(0); err != nil {
+			return err
+		}
+		s.written = 0
+	case s.written-len(s.pending) >= s.capacity:
+		return s.compact()
+	}
+	return nil
+}
+
+// compact rewrites the spool file with the unshipped entries only.
+// Callers hold the lock.
+func (s *Spool) compact() error {
+	var buf []byte
+	for _, e := range s.pending {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	tmpPath := s.path(spoolFile) + ".tmp"
+	if err := os.WriteFile(tmpPath, buf, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path(spoolFile)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path(spoolFile), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = f
+	s.written = len(s.pending)
+	return nil
+}
+
+// saveCursor persists done atomically. Callers hold the lock.
+func (s *Spool) saveCursor() error {
+	tmpPath := s.path(cursorFile) + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strconv.FormatUint(s.done, 10)+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path(cursorFile))
+}
+
+// MapID records that the standby stored the primary neuron as standby.
+func (s *Spool) MapID(index, primary, standby string) error {
+	return s.writeID(idRecord{Index: index, Primary: primary, Standby: standby})
+}
+
+// ResetIDs forgets the ID mappings of index, whose neurons are gone.
+func (s *Spool) ResetIDs(index string) error {
+	return s.writeID(idRecord{Index: index, Reset: true})
+}
+
+func (s *Spool) writeID(rec idRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.idFile.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.applyID(rec)
+	return nil
+}
+
+// StandbyID returns the standby's ID for a primary neuron of index.
+func (s *Spool) StandbyID(index, primary string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.ids[index][primary]
+	return id, ok
+}
+
+// Lag returns how many entries await shipment and how long the oldest of
+// them has waited at now.
+func (s *Spool) Lag(now time.Time) (entries int, seconds float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return 0, 0
+	}
+	return len(s.pending), max(now.Sub(s.pending[0].Time).Seconds(), 0)
+}
+
+// Stats returns the spool's counters.
+func (s *Spool) Stats() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]any{
+		"queueLength":   len(s.pending),
+		"queueCapacity": s.capacity,
+		"overflow":      s.overflow,
+		"appended":      s.appended,
+		"dropped":       s.dropped,
+		"blocked":       s.blocked,
+	}
+}
+
+// Relocate moves the spool's files to dir, as a storage migration moves
+// the data directory, and appends there from then on. On failure the
+// spool keeps appending where it was.
+func (s *Spool) Relocate(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if err := persistence.MirrorTree(s.dir, dir); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, spoolFile), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	idFile, err := os.OpenFile(filepath.Join(dir, idsFile), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file.Close()
+	s.idFile.Close()
+	s.dir, s.file, s.idFile = dir, file, idFile
+	return nil
+}
+
+// Close releases waiting appenders and closes the spool's files.
+// Unshipped entries stay on disk for the next OpenSpool.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.space.Broadcast()
+	return errors.Join(s.file.Close(), s.idFile.Close())
+}
@@ -0,0 +1,145 @@
+package replication
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestSpool(t *testing.T, dir string, capacity int, overflow string) *Spool {
+	t.Helper()
+	s, err := OpenSpool(dir, capacity, overflow)
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func appendWrites(t *testing.T, s *Spool, index string, contents ...string) {
+	t.Helper()
+	for i, content := range contents {
+		if err := s.Append(Entry{Index: index, Op: OpWrite, NeuronID: content + "-id", Content: content}); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+}
+
+func TestSpool_RecoversUnshippedEntriesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 10, OverflowDropOldest)
+	appendWrites(t, s, "team-a", "one", "two", "three")
+	if err := s.Ack(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MapID("team-a", "one-id", "standby-1"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// A crash mid-append leaves a torn last line.
+	f, err := os.OpenFile(filepath.Join(dir, spoolFile), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":4,"index":"team-a","op":"wri`)
+	f.Close()
+
+	s = openTestSpool(t, dir, 10, OverflowDropOldest)
+	if e, ok := s.Peek(); !ok || e.Seq != 2 || e.Content != "two" {
+		t.Fatalf("expected the first unshipped entry, got %+v %v", e, ok)
+	}
+	if n, _ := s.Lag(time.Now()); n != 2 {
+		t.Errorf("expected 2 entries of lag, got %d", n)
+	}
+	if id, ok := s.StandbyID("team-a", "one-id"); !ok || id != "standby-1" {
+		t.Errorf("ID mapping lost across restart: %q %v", id, ok)
+	}
+	appendWrites(t, s, "team-a", "four")
+	if err := s.Ack(3); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := s.Peek(); e.Seq != 4 || e.Content != "four" {
+		t.Errorf("sequence should continue after the recovered entries, got %+v", e)
+	}
+
+	if err := s.ResetIDs("team-a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.StandbyID("team-a", "one-id"); ok {
+		t.Error("a reset should forget the index's mappings")
+	}
+}
+
+func TestSpool_AckTruncatesAndCompacts(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 2, OverflowBlock)
+	appendWrites(t, s, "i", "a", "b")
+	if err := s.Ack(2); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, spoolFile)); info.Size() != 0 {
+		t.Errorf("a drained spool file should be truncated, size %d", info.Size())
+	}
+
+	appendWrites(t, s, "i", "c", "d")
+	s.Ack(3)
+	appendWrites(t, s, "i", "e")
+	s.Ack(4)
+	s.Close()
+	s = openTestSpool(t, dir, 2, OverflowBlock)
+	if s.written != 1 {
+		t.Errorf("shipped entries should have been compacted away, file holds %d", s.written)
+	}
+	if e, _ := s.Peek(); e.Content != "e" {
+		t.Errorf("expected e after compaction, got %+v", e)
+	}
+}
+
+func TestSpool_OverflowDropOldest(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), 2, OverflowDropOldest)
+	appendWrites(t, s, "i", "a", "b", "c")
+	if e, _ := s.Peek(); e.Content != "b" {
+		t.Errorf("the oldest entry should have been dropped, head is %+v", e)
+	}
+	if stats := s.Stats(); stats["dropped"] != uint64(1) || stats["queueLength"] != 2 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+	// The shipper may still ack the dropped entry.
+	if err := s.Ack(1); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := s.Peek(); e.Content != "b" {
+		t.Errorf("acking a dropped entry should be a no-op, head is %+v", e)
+	}
+}
+
+func TestSpool_OverflowBlockWaitsForRoom(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), 1, OverflowBlock)
+	appendWrites(t, s, "i", "a")
+
+	done := make(chan error, 1)
+	go func() { done <- s.Append(Entry{Index: "i", Op: OpWrite, Content: "b"}) }()
+	select {
+	case err := <-done:
+		t.Fatalf("append to a full blocking spool returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := s.Ack(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if stats := s.Stats(); stats["blocked"] != uint64(1) || stats["dropped"] != uint64(0) {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+
+	go func() { done <- s.Append(Entry{Index: "i", Op: OpWrite, Content: "c"}) }()
+	time.Sleep(20 * time.Millisecond)
+	s.Close()
+	if err := <-done; err != ErrClosed {
+		t.Errorf("Close should release a blocked append with ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidDocument is returned when a seed document cannot be parsed or
+// its parent references do not resolve.
+var ErrInvalidDocument = errors.New("invalid seed document")
+
+// Entry is one neuron in a seed document. ParentRef names an earlier entry,
+// either by its zero-based position in the document or by its Key, so
+// chains of related memories can be expressed.
+type Entry struct {
+	Key       string            `yaml:"key,omitempty" json:"key,omitempty"`
+	Content   string            `yaml:"content" json:"content"`
+	Metadata  map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	ParentRef any               `yaml:"parent_ref,omitempty" json:"parent_ref,omitempty"`
+}
+
+// Parse decodes a YAML or JSON list of entries and checks that every
+// parent_ref points at an earlier entry.
+func Parse(data []byte) ([]Entry, error) {
+	var entries []Entry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if _, err := resolveParents(entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Load reads and parses the seed document at path.
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// resolveParents returns, for each entry, the position of its parent or -1.
+func resolveParents(entries []Entry) ([]int, error) {
+	keys := make(map[string]int)
+	parents := make([]int, len(entries))
+	for i, e := range entries {
+		parents[i] = -1
+		switch ref := e.ParentRef.(type) {
+		case nil:
+		case int:
+			if ref < 0 || ref >= i {
+				return nil, fmt.Errorf("%w: entry %d: parent_ref %d must name an earlier entry", ErrInvalidDocument, i, ref)
+			}
+			parents[i] = ref
+		case string:
+			p, ok := keys[ref]
+			if !ok {
+				return nil, fmt.Errorf("%w: entry %d: parent_ref %q does not match an earlier key", ErrInvalidDocument, i, ref)
+			}
+			parents[i] = p
+		default:
+			return nil, fmt.Errorf("%w: entry %d: parent_ref must be an entry position or key", ErrInvalidDocument, i)
+		}
+
+		if e.Key != "" {
+			if _, dup := keys[e.Key]; dup {
+				return nil, fmt.Errorf("%w: entry %d: duplicate key %q", ErrInvalidDocument, i, e.Key)
+			}
+			keys[e.Key] = i
+		}
+	}
+	return parents, nil
+}
+
+// WriteFunc stores one entry under parentID ("" for none) and returns the
+// new neuron's ID.
+type WriteFunc func(e Entry, parentID string) (string, error)
+
+// Failure records an entry that could not be written.
+type Failure struct {
+	Entry int    `json:"entry"`
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error"`
+}
+
+// Result summarises a seeding run.
+type Result struct {
+	IndexID  string    `json:"indexId"`
+	Source   string    `json:"source,omitempty"`
+	Total    int       `json:"total"`
+	Written  int       `json:"written"`
+	Skipped  bool      `json:"skipped"`
+	Failures []Failure `json:"failures"`
+}
+
+// Apply writes entries in order, linking each to its parent's new neuron.
+// A failed entry is recorded and seeding continues; entries whose parent
+// failed are recorded as failures too. progress, if non-nil, is called
+// after each entry.
+func Apply(entries []Entry, write WriteFunc, progress func(done, total int)) (Result, error) {
+	parents, err := resolveParents(entries)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{Total: len(entries), Failures: []Failure{}}
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		if err := writeEntry(i, e, parents[i], ids, write); err != nil {
+			res.Failures = append(res.Failures, Failure{Entry: i, Key: e.Key, Error: err.Error()})
+		} else {
+			res.Written++
+		}
+		if progress != nil {
+			progress(i+1, len(entries))
+		}
+	}
+	return res, nil
+}
+
+// writeEntry writes entry i, recording its new ID in ids.
+func writeEntry(i int, e Entry, parent int, ids []string, write WriteFunc) error {
+	parentID := ""
+	if parent >= 0 {
+		if ids[parent] == "" {
+			return fmt.Errorf("parent entry %d was not written", parent)
+		}
+		parentID = ids[parent]
+	}
+	id, err := write(e, parentID)
+	if err != nil {
+		return err
+	}
+	ids[i] = id
+	return nil
+}
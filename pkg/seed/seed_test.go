@@ -0,0 +1,114 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// recordingWriter hands out sequential IDs and remembers each entry's parent.
+type recordingWriter struct {
+	parents map[string]string // content -> parent content
+	content map[string]string // id -> content
+	fail    map[string]bool   // content that fails to write
+}
+
+func newRecordingWriter() *recordingWriter {
+	return &recordingWriter{parents: map[string]string{}, content: map[string]string{}, fail: map[string]bool{}}
+}
+
+func (r *recordingWriter) write(e Entry, parentID string) (string, error) {
+	if r.fail[e.Content] {
+		return "", errors.New("content too large")
+	}
+	id := fmt.Sprintf("n%d", len(r.content))
+	r.content[id] = e.Content
+	r.parents[e.Content] = r.content[parentID]
+	return id, nil
+}
+
+func TestParse_ResolvesParentRefsByKeyAndPosition(t *testing.T) {
+	doc := `
+- key: project
+  content: Project kickoff
+  metadata: {topic: planning}
+- content: Chose Go for the backend
+  parent_ref: project
+- content: Added a Postgres dependency
+  parent_ref: 1
+`
+	entries, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if entries[0].Metadata["topic"] != "planning" {
+		t.Errorf("metadata not decoded: %v", entries[0].Metadata)
+	}
+
+	w := newRecordingWriter()
+	res, err := Apply(entries, w.write, nil)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if res.Written != 3 || len(res.Failures) != 0 {
+		t.Fatalf("expected 3 written, got %+v", res)
+	}
+	if got := w.parents["Chose Go for the backend"]; got != "Project kickoff" {
+		t.Errorf("key parent_ref: expected Project kickoff, got %q", got)
+	}
+	if got := w.parents["Added a Postgres dependency"]; got != "Chose Go for the backend" {
+		t.Errorf("positional parent_ref: expected Chose Go for the backend, got %q", got)
+	}
+}
+
+func TestParse_AcceptsJSON(t *testing.T) {
+	entries, err := Parse([]byte(`[{"key":"a","content":"first"},{"content":"second","parent_ref":"a"}]`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(entries) != 2 || entries[1].ParentRef != "a" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestParse_RejectsUnresolvableRefs(t *testing.T) {
+	docs := map[string]string{
+		"forward position": `[{"content":"a","parent_ref":1},{"content":"b"}]`,
+		"self reference":   `[{"content":"a","parent_ref":0}]`,
+		"unknown key":      `[{"content":"a","parent_ref":"missing"}]`,
+		"later key":        `[{"content":"a","parent_ref":"b"},{"key":"b","content":"b"}]`,
+		"duplicate key":    `[{"key":"a","content":"a"},{"key":"a","content":"b"}]`,
+		"non-integer ref":  `[{"content":"a"},{"content":"b","parent_ref":0.5}]`,
+		"not a list":       `{"content":"a"}`,
+	}
+	for name, doc := range docs {
+		if _, err := Parse([]byte(doc)); !errors.Is(err, ErrInvalidDocument) {
+			t.Errorf("%s: expected ErrInvalidDocument, got %v", name, err)
+		}
+	}
+}
+
+func TestApply_FailedParentFailsChildren(t *testing.T) {
+	entries := []Entry{
+		{Key: "root", Content: "too big"},
+		{Content: "child", ParentRef: "root"},
+		{Content: "unrelated"},
+	}
+	w := newRecordingWriter()
+	w.fail["too big"] = true
+
+	var calls int
+	res, err := Apply(entries, w.write, func(done, total int) { calls++ })
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if res.Written != 1 || len(res.Failures) != 2 {
+		t.Fatalf("expected 1 written and 2 failures, got %+v", res)
+	}
+	if res.Failures[0].Key != "root" || res.Failures[1].Entry != 1 {
+		t.Errorf("unexpected failures: %+v", res.Failures)
+	}
+	if calls != 3 {
+		t.Errorf("expected progress after every entry, got %d calls", calls)
+	}
+}
@@ -2,6 +2,7 @@ package sentiment
 
 import (
 	"math"
+	"strings"
 	"sync"
 
 	"github.com/jonreiter/govader"
@@ -30,33 +31,56 @@ type Result struct {
 	Neutral  float64 // VADER neutral ratio [0, 1]
 }
 
-// Analyzer wraps govader's SentimentIntensityAnalyzer and maps its output
-// to the six basic emotions. It is safe for concurrent use.
-type Analyzer struct {
+// Analyzer labels text with a basic emotion. Implementations must be safe
+// for concurrent use.
+type Analyzer interface {
+	// Name is the provider name the analyzer is selected by.
+	Name() string
+
+	// Supports reports whether the analyzer gives meaningful results for
+	// text in lang, a language tag such as "en" or "tr-TR". "" is text of
+	// unknown language.
+	Supports(lang string) bool
+
+	Analyze(text string) Result
+}
+
+// Vader wraps govader's SentimentIntensityAnalyzer and maps its output
+// to the six basic emotions. Its lexicon is English.
+type Vader struct {
 	sia *govader.SentimentIntensityAnalyzer
 	mu  sync.Mutex
 }
 
 var (
-	defaultAnalyzer *Analyzer
+	defaultAnalyzer *Vader
 	once            sync.Once
 )
 
-// Default returns the package-level singleton Analyzer (lazy-initialized).
-func Default() *Analyzer {
+// Default returns the package-level singleton Vader (lazy-initialized).
+func Default() *Vader {
 	once.Do(func() {
 		defaultAnalyzer = New()
 	})
 	return defaultAnalyzer
 }
 
-// New creates a new Analyzer. Prefer Default() for shared use.
-func New() *Analyzer {
-	return &Analyzer{
+// New creates a new Vader. Prefer Default() for shared use.
+func New() *Vader {
+	return &Vader{
 		sia: govader.NewSentimentIntensityAnalyzer(),
 	}
 }
 
+// Name returns "vader".
+func (a *Vader) Name() string { return ProviderVader }
+
+// Supports reports whether lang is English.
+func (a *Vader) Supports(lang string) bool {
+	lang = strings.ToLower(lang)
+	return lang == "en" || strings.HasPrefix(lang, "en-")
+}
+
 // Analyze returns the sentiment Result for the given text.
 // The Label is derived from VADER polarity scores using the mapping below:
 //
@@ -68,7 +92,7 @@ func New() *Analyzer {
 //
 // Within the strong-negative band, the highest sub-score among neg/pos/neu
 // is used to pick anger vs disgust vs fear heuristically.
-func (a *Analyzer) Analyze(text string) Result {
+func (a *Vader) Analyze(text string) Result {
 	a.mu.Lock()
 	scores := a.sia.PolarityScores(text)
 	a.mu.Unlock()
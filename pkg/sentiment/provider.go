@@ -0,0 +1,108 @@
+package sentiment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"unicode"
+)
+
+// Built-in providers.
+const (
+	ProviderVader = "vader"
+	ProviderNone  = "none"
+)
+
+// ErrUnknownProvider is returned by NewAnalyzer for a name no provider is
+// registered under.
+var ErrUnknownProvider = errors.New("unknown sentiment provider")
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]func() (Analyzer, error){
+		ProviderVader: func() (Analyzer, error) { return New(), nil },
+		ProviderNone:  func() (Analyzer, error) { return None{}, nil },
+	}
+)
+
+// Register makes an analyzer selectable as sentiment.provider name,
+// replacing any provider of that name. factory is called once per
+// NewAnalyzer.
+func Register(name string, factory func() (Analyzer, error)) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+// NewAnalyzer returns a new analyzer of the provider registered as name.
+func NewAnalyzer(name string) (Analyzer, error) {
+	providersMu.RLock()
+	factory, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (registered: %v)", ErrUnknownProvider, name, Providers())
+	}
+	return factory()
+}
+
+// Providers returns the registered provider names, sorted.
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// None is the analyzer that supports no language, so no text is labeled.
+type None struct{}
+
+// Name returns "none".
+func (None) Name() string { return ProviderNone }
+
+// Supports reports false.
+func (None) Supports(string) bool { return false }
+
+// Analyze returns a neutral result.
+func (None) Analyze(string) Result { return Result{Label: LabelNeutral, Neutral: 1} }
+
+// GuessLanguage guesses the language of untagged text: "en" when at least
+// minASCII of its letters are ASCII, "" (unknown) otherwise. Text without
+// letters is taken as English. It tells English from text written with
+// accented or non-Latin letters, nothing finer.
+func GuessLanguage(text string, minASCII float64) string {
+	letters, ascii := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if r < unicode.MaxASCII {
+			ascii++
+		}
+	}
+	if letters == 0 || float64(ascii) >= minASCII*float64(letters) {
+		return "en"
+	}
+	return ""
+}
+
+// Classify analyzes text with a if a supports its language: lang when
+// set, else GuessLanguage(text, minASCII). ok is false when a is nil or
+// the language is not supported, and text should be left unlabeled.
+func Classify(a Analyzer, text, lang string, minASCII float64) (r Result, ok bool) {
+	if a == nil {
+		return Result{}, false
+	}
+	if lang == "" {
+		lang = GuessLanguage(text, minASCII)
+	}
+	if !a.Supports(lang) {
+		return Result{}, false
+	}
+	return a.Analyze(text), true
+}
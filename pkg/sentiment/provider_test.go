@@ -0,0 +1,62 @@
+package sentiment
+
+import (
+	"errors"
+	"testing"
+)
+
+type constant struct{ label Label }
+
+func (c constant) Name() string              { return "constant" }
+func (c constant) Supports(lang string) bool { return lang == "tr" }
+func (c constant) Analyze(string) Result     { return Result{Label: c.label} }
+
+func TestNewAnalyzer_Providers(t *testing.T) {
+	a, err := NewAnalyzer(ProviderVader)
+	if err != nil || a.Name() != ProviderVader {
+		t.Fatalf("expected vader, got %v, %v", a, err)
+	}
+	if a, err := NewAnalyzer(ProviderNone); err != nil || a.Name() != ProviderNone {
+		t.Fatalf("expected none, got %v, %v", a, err)
+	}
+	if _, err := NewAnalyzer("lexicon"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("expected ErrUnknownProvider, got %v", err)
+	}
+
+	Register("lexicon", func() (Analyzer, error) { return constant{LabelSadness}, nil })
+	a, err = NewAnalyzer("lexicon")
+	if err != nil || a.Name() != "constant" {
+		t.Fatalf("expected the registered provider, got %v, %v", a, err)
+	}
+	if r, ok := Classify(a, "bugün çok üzgünüm", "tr", 0.9); !ok || r.Label != LabelSadness {
+		t.Errorf("expected the registered analyzer to label Turkish, got %+v, %v", r, ok)
+	}
+}
+
+func TestClassify_SkipsUnsupportedLanguages(t *testing.T) {
+	vader := New()
+	for _, c := range []struct {
+		text, lang string
+		ok         bool
+	}{
+		{"I love this, it is wonderful", "", true},
+		{"I love this, it is wonderful", "en-GB", true},
+		{"I love this, it is wonderful", "de", false},
+		{"Bu film çok güzeldi, bayıldım", "", false},
+		{"Café au lait was great", "", true},
+		{"12345", "", true},
+	} {
+		if _, ok := Classify(vader, c.text, c.lang, 0.9); ok != c.ok {
+			t.Errorf("Classify(%q, %q): ok = %v, want %v", c.text, c.lang, ok, c.ok)
+		}
+	}
+	if _, ok := Classify(vader, "Bu film çok güzeldi, bayıldım", "", 0); !ok {
+		t.Error("a ratio of 0 should take untagged text as English")
+	}
+	if _, ok := Classify(None{}, "I love this", "en", 0.9); ok {
+		t.Error("none should label nothing")
+	}
+	if _, ok := Classify(nil, "I love this", "en", 0.9); ok {
+		t.Error("a nil analyzer should label nothing")
+	}
+}
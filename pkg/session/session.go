@@ -0,0 +1,415 @@
+// Package session holds working memory: neurons an agent writes for one
+// session, searched alongside an index's persistent neurons but kept only
+// in memory, and discarded when the session is deleted or idles past its
+// TTL. A session neuron may link to persistent neurons, passing part of its
+// search score on to them.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+var (
+	// ErrNotFound is returned for an unknown or expired session.
+	ErrNotFound = errors.New("session not found")
+
+	// ErrInvalid is returned for a malformed session ID.
+	ErrInvalid = errors.New("invalid session")
+)
+
+// maxIDLength bounds session IDs, which clients choose.
+const maxIDLength = 128
+
+// Entry is a session neuron and the persistent neurons it links to.
+type Entry struct {
+	Neuron *core.Neuron
+	Links  []core.NeuronID
+}
+
+// Hit is a session neuron matching a search.
+type Hit struct {
+	Entry
+	Score float64
+}
+
+// Info summarises a session.
+type Info struct {
+	ID         string    `json:"id"`
+	Neurons    int       `json:"neurons"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// session is one session's overlay: its own matrix, searched with the same
+// engine as persistent neurons.
+type session struct {
+	id       string
+	matrix   *core.Matrix
+	engine   *engine.MatrixEngine
+	links    map[core.NeuronID][]core.NeuronID
+	order    []core.NeuronID // oldest first
+	created  time.Time
+	lastUsed time.Time
+}
+
+// Store holds the sessions of every index. Nothing in it is persisted.
+type Store struct {
+	ttl        time.Duration
+	maxNeurons int
+
+	mu      sync.Mutex
+	indexes map[core.IndexID]map[string]*session
+
+	started uint64
+	expired uint64
+	evicted uint64 // neurons dropped to stay within maxNeurons
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewStore returns a store discarding sessions unused for ttl and holding
+// at most maxNeurons session neurons per index.
+func NewStore(ttl time.Duration, maxNeurons int) *Store {
+	st := &Store{
+		ttl:        ttl,
+		maxNeurons: maxNeurons,
+		indexes:    make(map[core.IndexID]map[string]*session),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go st.run(min(max(ttl/4, time.Second), time.Minute))
+	return st
+}
+
+func (st *Store) run(interval time.Duration) {
+	defer close(st.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-st.stop:
+			return
+		case now := <-ticker.C:
+			st.Expire(now)
+		}
+	}
+}
+
+// Close stops expiring sessions.
+func (st *Store) Close() {
+	st.once.Do(func() {
+		close(st.stop)
+		<-st.done
+	})
+}
+
+// ValidateID checks a client-chosen session ID.
+func ValidateID(id string) error {
+	if id == "" || len(id) > maxIDLength {
+		return fmt.Errorf("%w: session_id must be 1 to %d characters", ErrInvalid, maxIDLength)
+	}
+	for _, r := range id {
+		if r <= ' ' || r == '/' || r == 0x7f {
+			return fmt.Errorf("%w: session_id must not contain spaces, slashes or control characters", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+// Write adds a neuron to the session, starting it if needed. links are
+// persistent neurons the neuron connects to; writing content the session
+// already holds returns that neuron with the links added. When the index
+// is at its bound, its oldest session neurons are evicted first.
+func (st *Store) Write(index core.IndexID, id, content string, metadata map[string]string, links []core.NeuronID) (Entry, error) {
+	if err := ValidateID(id); err != nil {
+		return Entry{}, err
+	}
+	content, err := core.NormalizeNeuronContent(content)
+	if err != nil {
+		return Entry{}, err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	sessions := st.indexes[index]
+	if sessions == nil {
+		sessions = make(map[string]*session)
+		st.indexes[index] = sessions
+	}
+	s := sessions[id]
+	if s == nil {
+		bounds := core.DefaultBounds()
+		bounds.MaxNeurons = st.maxNeurons
+		matrix := core.NewMatrix(index, bounds)
+		s = &session{
+			id:      id,
+			matrix:  matrix,
+			engine:  engine.NewMatrixEngine(matrix),
+			links:   make(map[core.NeuronID][]core.NeuronID),
+			created: now,
+		}
+		sessions[id] = s
+		st.started++
+	}
+	s.lastUsed = now
+
+	if !s.holds(content) {
+		st.makeRoom(index, s)
+	}
+	before := len(s.matrix.Neurons)
+	n, err := s.engine.AddNeuron(content, nil, metadata)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(s.matrix.Neurons) > before {
+		s.order = append(s.order, n.ID)
+	}
+	for _, link := range links {
+		if !containsID(s.links[n.ID], link) {
+			s.links[n.ID] = append(s.links[n.ID], link)
+		}
+	}
+	return Entry{Neuron: n, Links: append([]core.NeuronID(nil), s.links[n.ID]...)}, nil
+}
+
+// holds reports whether the session has a neuron with content, which a
+// write would return rather than add. Callers hold the store lock.
+func (s *session) holds(content string) bool {
+	hash := core.HashContent(content)
+	s.matrix.RLock()
+	defer s.matrix.RUnlock()
+	for _, n := range s.matrix.Neurons {
+		if n.ContentHash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// makeRoom evicts the index's oldest session neurons until one more fits.
+// Sessions left empty are dropped, except keep, which is being written to.
+// Callers hold mu.
+func (st *Store) makeRoom(index core.IndexID, keep *session) {
+	for st.countLocked(index) >= st.maxNeurons {
+		var oldest *session
+		var oldestAt time.Time
+		for _, s := range st.indexes[index] {
+			if len(s.order) == 0 {
+				continue
+			}
+			n := s.matrix.Neurons[s.order[0]]
+			if oldest == nil || n.CreatedAt.Before(oldestAt) {
+				oldest, oldestAt = s, n.CreatedAt
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		id := oldest.order[0]
+		oldest.order = oldest.order[1:]
+		delete(oldest.links, id)
+		oldest.engine.DeleteNeuron(id)
+		st.evicted++
+		if len(oldest.order) == 0 && oldest != keep {
+			delete(st.indexes[index], oldest.id)
+		}
+	}
+}
+
+func (st *Store) countLocked(index core.IndexID) int {
+	total := 0
+	for _, s := range st.indexes[index] {
+		total += len(s.order)
+	}
+	return total
+}
+
+// get returns a live session and marks it used.
+func (st *Store) get(index core.IndexID, id string) (*session, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.indexes[index][id]
+	if ok {
+		s.lastUsed = time.Now()
+	}
+	return s, ok
+}
+
+// Search scores the session's neurons against query the way the index's
+// own search does, without firing them. An unknown session has no hits.
+func (st *Store) Search(ctx context.Context, index core.IndexID, id, query string, depth, limit int, metadata map[string]string, strict bool, roles []string) []Hit {
+	s, ok := st.get(index, id)
+	if !ok {
+		return nil
+	}
+	neurons, stats := s.engine.PeekSearch(ctx, query, depth, limit, metadata, strict, roles, engine.SearchOptions{})
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	hits := make([]Hit, len(neurons))
+	for i, n := range neurons {
+		hits[i] = Hit{Entry: Entry{Neuron: n, Links: append([]core.NeuronID(nil), s.links[n.ID]...)}}
+		if i < len(stats.Scores) {
+			hits[i].Score = stats.Scores[i]
+		}
+	}
+	return hits
+}
+
+// Neurons returns the session's neurons, oldest first.
+func (st *Store) Neurons(index core.IndexID, id string) ([]Entry, error) {
+	s, ok := st.get(index, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	entries := make([]Entry, 0, len(s.order))
+	for _, nid := range s.order {
+		if n, ok := s.matrix.Neurons[nid]; ok {
+			entries = append(entries, Entry{Neuron: n, Links: append([]core.NeuronID(nil), s.links[nid]...)})
+		}
+	}
+	return entries, nil
+}
+
+// Remove drops one neuron from the session, such as one just committed.
+func (st *Store) Remove(index core.IndexID, id string, neuronID core.NeuronID) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.indexes[index][id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := s.engine.DeleteNeuron(neuronID); err != nil {
+		return err
+	}
+	delete(s.links, neuronID)
+	for i, nid := range s.order {
+		if nid == neuronID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Delete discards a session, returning how many neurons it held.
+func (st *Store) Delete(index core.IndexID, id string) (int, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.indexes[index][id]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	delete(st.indexes[index], id)
+	if len(st.indexes[index]) == 0 {
+		delete(st.indexes, index)
+	}
+	return len(s.order), nil
+}
+
+// DeleteIndex discards every session of index, returning how many there
+// were.
+func (st *Store) DeleteIndex(index core.IndexID) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	n := len(st.indexes[index])
+	delete(st.indexes, index)
+	return n
+}
+
+// List returns the index's sessions, most recently used first.
+func (st *Store) List(index core.IndexID) []Info {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	infos := make([]Info, 0, len(st.indexes[index]))
+	for _, s := range st.indexes[index] {
+		infos = append(infos, st.infoLocked(s))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].LastUsedAt.After(infos[j].LastUsedAt) })
+	return infos
+}
+
+// Info returns a summary of one session.
+func (st *Store) Info(index core.IndexID, id string) (Info, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.indexes[index][id]
+	if !ok {
+		return Info{}, ErrNotFound
+	}
+	return st.infoLocked(s), nil
+}
+
+func (st *Store) infoLocked(s *session) Info {
+	return Info{
+		ID:         s.id,
+		Neurons:    len(s.order),
+		CreatedAt:  s.created,
+		LastUsedAt: s.lastUsed,
+		ExpiresAt:  s.lastUsed.Add(st.ttl),
+	}
+}
+
+// Expire discards the sessions unused for the TTL at now, returning how
+// many there were.
+func (st *Store) Expire(now time.Time) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	n := 0
+	for index, sessions := range st.indexes {
+		for id, s := range sessions {
+			if now.Sub(s.lastUsed) >= st.ttl {
+				delete(sessions, id)
+				n++
+			}
+		}
+		if len(sessions) == 0 {
+			delete(st.indexes, index)
+		}
+	}
+	st.expired += uint64(n)
+	return n
+}
+
+// Stats returns the live sessions and neurons and the lifetime counters.
+func (st *Store) Stats() map[string]any {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	sessions, neurons := 0, 0
+	for index, s := range st.indexes {
+		sessions += len(s)
+		neurons += st.countLocked(index)
+	}
+	return map[string]any{
+		"sessions":           sessions,
+		"neurons":            neurons,
+		"started":            st.started,
+		"expired":            st.expired,
+		"evicted":            st.evicted,
+		"ttlSeconds":         st.ttl.Seconds(),
+		"maxNeuronsPerIndex": st.maxNeurons,
+	}
+}
+
+func containsID(ids []core.NeuronID, id core.NeuronID) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
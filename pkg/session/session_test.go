@@ -0,0 +1,117 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func newTestStore(t *testing.T, ttl time.Duration, maxNeurons int) *Store {
+	t.Helper()
+	st := NewStore(ttl, maxNeurons)
+	t.Cleanup(st.Close)
+	return st
+}
+
+func mustWrite(t *testing.T, st *Store, index core.IndexID, id, content string, links ...core.NeuronID) *core.Neuron {
+	t.Helper()
+	e, err := st.Write(index, id, content, nil, links)
+	if err != nil {
+		t.Fatalf("write %q to %s/%s: %v", content, index, id, err)
+	}
+	return e.Neuron
+}
+
+func TestStore_SessionsAreIsolated(t *testing.T) {
+	st := newTestStore(t, time.Hour, 100)
+	mustWrite(t, st, "idx", "a", "the deploy key rotates on friday")
+	mustWrite(t, st, "idx", "b", "the staging deploy is frozen")
+	mustWrite(t, st, "other", "a", "deploy notes for another index")
+
+	hits := st.Search(context.Background(), "idx", "a", "deploy", 2, 10, nil, false, nil)
+	if len(hits) != 1 || hits[0].Neuron.Content != "the deploy key rotates on friday" || hits[0].Score <= 0 {
+		t.Fatalf("expected only session a's neuron of idx, got %+v", hits)
+	}
+	if hits := st.Search(context.Background(), "idx", "missing", "deploy", 2, 10, nil, false, nil); len(hits) != 0 {
+		t.Errorf("an unknown session has no hits, got %d", len(hits))
+	}
+
+	if n, err := st.Delete("idx", "a"); err != nil || n != 1 {
+		t.Fatalf("delete: %d %v", n, err)
+	}
+	if _, err := st.Neurons("idx", "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("a deleted session should be gone, got %v", err)
+	}
+	if entries, _ := st.Neurons("idx", "b"); len(entries) != 1 {
+		t.Errorf("deleting one session must leave others, got %d neurons in b", len(entries))
+	}
+}
+
+func TestStore_WriteKeepsLinksAndDeduplicates(t *testing.T) {
+	st := newTestStore(t, time.Hour, 100)
+	first := mustWrite(t, st, "idx", "s", "check the runbook", "p1")
+	again := mustWrite(t, st, "idx", "s", "check the runbook", "p1", "p2")
+	if again.ID != first.ID {
+		t.Fatal("rewriting content should return the same session neuron")
+	}
+	entries, _ := st.Neurons("idx", "s")
+	if len(entries) != 1 || len(entries[0].Links) != 2 {
+		t.Fatalf("expected one neuron linked to p1 and p2, got %+v", entries)
+	}
+
+	if _, err := st.Write("idx", "bad/id", "x", nil, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a slash in the ID, got %v", err)
+	}
+	if _, err := st.Write("idx", "s", "", nil, nil); err == nil {
+		t.Error("empty content should be rejected")
+	}
+}
+
+func TestStore_EvictsOldestNeuronsOfTheIndex(t *testing.T) {
+	st := newTestStore(t, time.Hour, 3)
+	mustWrite(t, st, "idx", "old", "first note")
+	time.Sleep(2 * time.Millisecond)
+	mustWrite(t, st, "idx", "new", "second note")
+	time.Sleep(2 * time.Millisecond)
+	mustWrite(t, st, "idx", "new", "third note")
+	time.Sleep(2 * time.Millisecond)
+	mustWrite(t, st, "idx", "new", "fourth note")
+	mustWrite(t, st, "elsewhere", "old", "not counted against idx")
+
+	if _, err := st.Neurons("idx", "old"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("the session whose only neuron was evicted should be dropped, got %v", err)
+	}
+	if entries, _ := st.Neurons("idx", "new"); len(entries) != 3 || entries[0].Neuron.Content != "second note" {
+		t.Errorf("expected the three newest neurons, got %d", len(entries))
+	}
+	mustWrite(t, st, "idx", "new", "fifth note")
+	if entries, _ := st.Neurons("idx", "new"); len(entries) != 3 || entries[0].Neuron.Content != "third note" {
+		t.Errorf("a session at the bound should evict its own oldest neuron")
+	}
+	if stats := st.Stats(); stats["evicted"] != uint64(2) || stats["neurons"] != 4 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+}
+
+func TestStore_ExpiresIdleSessions(t *testing.T) {
+	st := newTestStore(t, time.Minute, 100)
+	mustWrite(t, st, "idx", "idle", "stale scratch")
+	mustWrite(t, st, "idx", "busy", "fresh scratch")
+
+	st.mu.Lock()
+	st.indexes["idx"]["idle"].lastUsed = time.Now().Add(-2 * time.Minute)
+	st.mu.Unlock()
+
+	if n := st.Expire(time.Now()); n != 1 {
+		t.Fatalf("expected one session expired, got %d", n)
+	}
+	if infos := st.List("idx"); len(infos) != 1 || infos[0].ID != "busy" {
+		t.Errorf("expected only the busy session left, got %+v", infos)
+	}
+	if n := st.Expire(time.Now().Add(2 * time.Minute)); n != 1 || len(st.List("idx")) != 0 {
+		t.Errorf("every session expires once idle past the TTL")
+	}
+}
@@ -0,0 +1,611 @@
+// Package share stores read-only share links: capability tokens that let
+// anyone holding them search or recall the neurons of one index that match
+// a fixed filter, without naming the index or authenticating. Badges are
+// shares that expose a few counts of their index and never its content.
+package share
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// TokenPrefix starts every share token so leaked tokens are recognisable.
+const TokenPrefix = "qsh_"
+
+// tokenBytes is the number of random bytes in a token.
+const tokenBytes = 32
+
+// visiblePrefix is how much of a token may be shown in listings and logs.
+const visiblePrefix = len(TokenPrefix) + 4
+
+// ExpiredRetention is how long an expired share is kept so its token can
+// answer as expired rather than unknown.
+const ExpiredRetention = 7 * 24 * time.Hour
+
+var (
+	// ErrNotFound is returned for an unknown or revoked share.
+	ErrNotFound = errors.New("share not found")
+
+	// ErrExpired is returned for a share past its expiry.
+	ErrExpired = errors.New("share expired")
+
+	// ErrLimitReached is returned when an index already holds the maximum
+	// number of active shares.
+	ErrLimitReached = errors.New("share limit reached for index")
+
+	// ErrInvalid is returned when a share spec is malformed.
+	ErrInvalid = errors.New("invalid share")
+)
+
+// Filter selects the neurons a share exposes. A neuron matches when it has
+// every metadata pair, every tag and, case-insensitively, every word of
+// Query in its content.
+type Filter struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Query    string            `json:"query,omitempty"`
+}
+
+// Empty reports whether the filter would match every neuron.
+func (f Filter) Empty() bool {
+	return len(f.Metadata) == 0 && len(f.Tags) == 0 && strings.TrimSpace(f.Query) == ""
+}
+
+// Matches reports whether n passes the filter.
+func (f Filter) Matches(n *core.Neuron) bool {
+	if f.Empty() {
+		return false
+	}
+	n.RLock()
+	defer n.RUnlock()
+
+	for k, v := range f.Metadata {
+		if nv, ok := n.Metadata[k]; !ok || fmt.Sprintf("%v", nv) != v {
+			return false
+		}
+	}
+	for _, tag := range f.Tags {
+		found := false
+		for _, t := range n.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if terms := strings.Fields(strings.ToLower(f.Query)); len(terms) > 0 {
+		content := strings.ToLower(n.Content)
+		for _, term := range terms {
+			if !strings.Contains(content, term) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (f Filter) clone() Filter {
+	c := Filter{Query: f.Query}
+	if f.Metadata != nil {
+		c.Metadata = make(map[string]string, len(f.Metadata))
+		for k, v := range f.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	c.Tags = append([]string(nil), f.Tags...)
+	return c
+}
+
+// KindBadge marks a badge; search shares have no kind.
+const KindBadge = "badge"
+
+// Badge fields: the values a badge may expose.
+const (
+	BadgeNeurons      = "neurons"
+	BadgeSynapses     = "synapses"
+	BadgeState        = "state"
+	BadgeLastActivity = "lastActivity"
+	BadgeHealth       = "health"
+)
+
+// BadgeFields lists the badge fields in display order.
+var BadgeFields = []string{BadgeNeurons, BadgeSynapses, BadgeState, BadgeLastActivity, BadgeHealth}
+
+// BadgeSpec is what an owner asks for when creating a badge.
+type BadgeSpec struct {
+	Fields []string `json:"fields,omitempty"` // empty selects every field
+	Expiry string   `json:"expiry,omitempty"` // Go duration; empty never expires
+}
+
+// Spec is what an owner asks for when creating a share.
+type Spec struct {
+	Filter     Filter `json:"filter"`
+	Expiry     string `json:"expiry,omitempty"`     // Go duration; empty uses the default
+	MaxResults int    `json:"maxResults,omitempty"` // 0 uses the server cap
+}
+
+// Share is a read-only view of one index. The token itself is never
+// stored; TokenHint shows its first characters so owners can tell shares
+// apart.
+type Share struct {
+	ID         string    `json:"id"`
+	IndexID    string    `json:"indexId"`
+	Kind       string    `json:"kind,omitempty"`
+	Filter     Filter    `json:"filter"`
+	MaxResults int       `json:"maxResults"`
+	Fields     []string  `json:"fields,omitempty"` // badge fields, in display order
+	TokenHint  string    `json:"tokenHint"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"` // zero for a badge that never expires
+
+	tokenHash string
+}
+
+// Expired reports whether the share has expired at now.
+func (s *Share) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// IsBadge reports whether the share is a badge.
+func (s *Share) IsBadge() bool {
+	return s.Kind == KindBadge
+}
+
+// HasField reports whether a badge exposes field.
+func (s *Share) HasField(field string) bool {
+	for _, f := range s.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// gone reports whether the share expired longer than ExpiredRetention
+// before now.
+func (s *Share) gone(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.Sub(s.ExpiresAt) > ExpiredRetention
+}
+
+func (s *Share) clone() *Share {
+	c := *s
+	c.Filter = s.Filter.clone()
+	c.Fields = append([]string(nil), s.Fields...)
+	return &c
+}
+
+// record is the persisted form of a share, which carries the token hash.
+type record struct {
+	*Share
+	TokenHash string `json:"tokenHash"`
+}
+
+// Redact shortens the share token in path, if any, to its visible prefix.
+func Redact(path string) string {
+	i := strings.Index(path, TokenPrefix)
+	if i < 0 {
+		return path
+	}
+	end := strings.IndexByte(path[i:], '/')
+	if end < 0 {
+		end = len(path) - i
+	}
+	if end <= visiblePrefix {
+		return path
+	}
+	return path[:i+visiblePrefix] + "…" + path[i+end:]
+}
+
+// Stats counts shares.
+type Stats struct {
+	Total   int `json:"total"`
+	Active  int `json:"active"`
+	Expired int `json:"expired"`
+}
+
+// Store holds shares with file-based persistence. Returned shares are
+// copies.
+type Store struct {
+	shares   map[string]*Share // by ID
+	byHash   map[string]*Share // by token hash
+	mu       sync.RWMutex
+	filePath string
+
+	maxPerIndex   int
+	maxResults    int
+	defaultExpiry time.Duration
+	maxExpiry     time.Duration
+	now           func() time.Time
+}
+
+// NewStore loads shares from shares.json under dataPath. Each index may
+// hold at most maxPerIndex active shares, each returning at most
+// maxResults neurons per request and living at most maxExpiry.
+func NewStore(dataPath string, maxPerIndex, maxResults int, defaultExpiry, maxExpiry time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dataPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create shares path: %w", err)
+	}
+
+	s := &Store{
+		shares:        make(map[string]*Share),
+		byHash:        make(map[string]*Share),
+		filePath:      filepath.Join(dataPath, "shares.json"),
+		maxPerIndex:   maxPerIndex,
+		maxResults:    maxResults,
+		defaultExpiry: defaultExpiry,
+		maxExpiry:     maxExpiry,
+		now:           time.Now,
+	}
+
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load shares: %w", err)
+	}
+
+	return s, nil
+}
+
+// validate checks spec and returns its lifetime and result cap.
+func (s *Store) validate(spec Spec) (time.Duration, int, error) {
+	if spec.Filter.Empty() {
+		return 0, 0, fmt.Errorf("%w: filter needs metadata, tags or query", ErrInvalid)
+	}
+	for k := range spec.Filter.Metadata {
+		if k == "" {
+			return 0, 0, fmt.Errorf("%w: filter metadata keys must not be empty", ErrInvalid)
+		}
+	}
+	for _, tag := range spec.Filter.Tags {
+		if tag == "" {
+			return 0, 0, fmt.Errorf("%w: filter tags must not be empty", ErrInvalid)
+		}
+	}
+
+	expiry := s.defaultExpiry
+	if spec.Expiry != "" {
+		d, err := time.ParseDuration(spec.Expiry)
+		if err != nil || d <= 0 {
+			return 0, 0, fmt.Errorf("%w: expiry must be a positive duration such as 24h", ErrInvalid)
+		}
+		expiry = d
+	}
+	if expiry > s.maxExpiry {
+		return 0, 0, fmt.Errorf("%w: expiry must not exceed %s", ErrInvalid, s.maxExpiry)
+	}
+
+	maxResults := spec.MaxResults
+	if maxResults < 0 || maxResults > s.maxResults {
+		return 0, 0, fmt.Errorf("%w: maxResults must be between 0 and %d", ErrInvalid, s.maxResults)
+	}
+	if maxResults == 0 {
+		maxResults = s.maxResults
+	}
+	return expiry, maxResults, nil
+}
+
+// validateBadge checks spec and returns its fields in display order and
+// its lifetime, 0 for none.
+func (s *Store) validateBadge(spec BadgeSpec) ([]string, time.Duration, error) {
+	selected := make(map[string]bool, len(spec.Fields))
+	for _, f := range spec.Fields {
+		known := false
+		for _, name := range BadgeFields {
+			known = known || f == name
+		}
+		if !known {
+			return nil, 0, fmt.Errorf("%w: unknown badge field %q (use %s)", ErrInvalid, f, strings.Join(BadgeFields, ", "))
+		}
+		selected[f] = true
+	}
+	var fields []string
+	for _, name := range BadgeFields {
+		if len(selected) == 0 || selected[name] {
+			fields = append(fields, name)
+		}
+	}
+
+	var expiry time.Duration
+	if spec.Expiry != "" {
+		d, err := time.ParseDuration(spec.Expiry)
+		if err != nil || d <= 0 {
+			return nil, 0, fmt.Errorf("%w: expiry must be a positive duration such as 24h", ErrInvalid)
+		}
+		if d > s.maxExpiry {
+			return nil, 0, fmt.Errorf("%w: expiry must not exceed %s", ErrInvalid, s.maxExpiry)
+		}
+		expiry = d
+	}
+	return fields, expiry, nil
+}
+
+// Create adds a share of indexID and returns it with its token. The token
+// is not kept and cannot be retrieved again.
+func (s *Store) Create(indexID string, spec Spec) (*Share, string, error) {
+	expiry, maxResults, err := s.validate(spec)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.add(&Share{
+		IndexID:    indexID,
+		Filter:     spec.Filter.clone(),
+		MaxResults: maxResults,
+	}, expiry)
+}
+
+// CreateBadge adds a badge of indexID and returns it with its token. It
+// counts against the index's share limit and is listed, resolved and
+// revoked like a share.
+func (s *Store) CreateBadge(indexID string, spec BadgeSpec) (*Share, string, error) {
+	fields, expiry, err := s.validateBadge(spec)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.add(&Share{IndexID: indexID, Kind: KindBadge, Fields: fields}, expiry)
+}
+
+// add stores sh with a new ID and token, expiring after expiry unless it
+// is 0.
+func (s *Store) add(sh *Share, expiry time.Duration) (*Share, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.purgeLocked(now)
+	if s.countActiveLocked(sh.IndexID, now) >= s.maxPerIndex {
+		return nil, "", fmt.Errorf("%w (%d)", ErrLimitReached, s.maxPerIndex)
+	}
+
+	sh.ID = uuid.NewString()
+	sh.TokenHint = token[:visiblePrefix]
+	sh.CreatedAt = now
+	if expiry > 0 {
+		sh.ExpiresAt = now.Add(expiry)
+	}
+	sh.tokenHash = hashToken(token)
+	s.shares[sh.ID] = sh
+	s.byHash[sh.tokenHash] = sh
+
+	if err := s.save(); err != nil {
+		delete(s.shares, sh.ID)
+		delete(s.byHash, sh.tokenHash)
+		return nil, "", fmt.Errorf("failed to persist: %w", err)
+	}
+	return sh.clone(), token, nil
+}
+
+// Resolve returns the share a token grants. It returns ErrNotFound for an
+// unknown or revoked token and ErrExpired, with the share, for an expired
+// one.
+func (s *Store) Resolve(token string) (*Share, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return nil, ErrNotFound
+	}
+	hash := hashToken(token)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sh, ok := s.byHash[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	now := s.now()
+	if sh.Expired(now) {
+		if sh.gone(now) {
+			return nil, ErrNotFound
+		}
+		return sh.clone(), ErrExpired
+	}
+	return sh.clone(), nil
+}
+
+// List returns the shares of indexID, oldest first, including expired ones
+// still within ExpiredRetention.
+func (s *Store) List(indexID string) []*Share {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	result := []*Share{}
+	for _, sh := range s.shares {
+		if sh.IndexID == indexID && !sh.gone(now) {
+			result = append(result, sh.clone())
+		}
+	}
+	sortByCreation(result)
+	return result
+}
+
+// Delete revokes share id of indexID. Its token stops working immediately.
+func (s *Store) Delete(indexID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sh, ok := s.shares[id]
+	if !ok || sh.IndexID != indexID {
+		return ErrNotFound
+	}
+	delete(s.shares, id)
+	delete(s.byHash, sh.tokenHash)
+
+	if err := s.save(); err != nil {
+		s.shares[id] = sh
+		s.byHash[sh.tokenHash] = sh
+		return fmt.Errorf("failed to persist: %w", err)
+	}
+	return nil
+}
+
+// DeleteIndex revokes every share of indexID and returns how many there
+// were.
+func (s *Store) DeleteIndex(indexID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := make(map[string]*Share)
+	for id, sh := range s.shares {
+		if sh.IndexID == indexID {
+			removed[id] = sh
+			delete(s.shares, id)
+			delete(s.byHash, sh.tokenHash)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	if err := s.save(); err != nil {
+		for id, sh := range removed {
+			s.shares[id] = sh
+			s.byHash[sh.tokenHash] = sh
+		}
+		return 0, fmt.Errorf("failed to persist: %w", err)
+	}
+	return len(removed), nil
+}
+
+// Stats counts active and expired shares across all indexes.
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	st := Stats{Total: len(s.shares)}
+	for _, sh := range s.shares {
+		if sh.Expired(now) {
+			st.Expired++
+		} else {
+			st.Active++
+		}
+	}
+	return st
+}
+
+func (s *Store) countActiveLocked(indexID string, now time.Time) int {
+	n := 0
+	for _, sh := range s.shares {
+		if sh.IndexID == indexID && !sh.Expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// purgeLocked drops shares expired for longer than ExpiredRetention. They
+// are persisted with the next save.
+func (s *Store) purgeLocked(now time.Time) {
+	for id, sh := range s.shares {
+		if sh.gone(now) {
+			delete(s.shares, id)
+			delete(s.byHash, sh.tokenHash)
+		}
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return TokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func sortByCreation(shares []*Share) {
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+		}
+		return shares[i].ID < shares[j].ID
+	})
+}
+
+// Relocate moves the store's file to shares.json under dataPath, as a
+// storage migration moves the data directory, and writes the current
+// shares there. On failure the store keeps its file.
+func (s *Store) Relocate(dataPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.filePath
+	s.filePath = filepath.Join(dataPath, "shares.json")
+	if err := s.save(); err != nil {
+		s.filePath = prev
+		return err
+	}
+	return nil
+}
+
+// load reads shares from disk
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var records []record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if rec.Share == nil || rec.TokenHash == "" {
+			continue
+		}
+		rec.Share.tokenHash = rec.TokenHash
+		s.shares[rec.ID] = rec.Share
+		s.byHash[rec.TokenHash] = rec.Share
+	}
+	return nil
+}
+
+// save writes shares to disk atomically. Callers hold the lock.
+func (s *Store) save() error {
+	shares := make([]*Share, 0, len(s.shares))
+	for _, sh := range s.shares {
+		shares = append(shares, sh)
+	}
+	sortByCreation(shares)
+
+	records := make([]record, len(shares))
+	for i, sh := range shares {
+		records[i] = record{Share: sh, TokenHash: sh.tokenHash}
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.filePath)
+}
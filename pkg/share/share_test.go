@@ -0,0 +1,196 @@
+package share
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func newTestStore(t *testing.T, dir string) *Store {
+	t.Helper()
+	store, err := NewStore(dir, 2, 10, 24*time.Hour, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestFilterMatches(t *testing.T) {
+	n := core.NewNeuron("Quarterly Roadmap for the billing team", 8)
+	n.Metadata = map[string]any{"project": "billing", "year": 2026}
+	n.Tags = []string{"public", "roadmap"}
+
+	cases := []struct {
+		filter Filter
+		want   bool
+	}{
+		{Filter{Metadata: map[string]string{"project": "billing"}}, true},
+		{Filter{Metadata: map[string]string{"project": "billing", "year": "2026"}}, true},
+		{Filter{Metadata: map[string]string{"project": "search"}}, false},
+		{Filter{Tags: []string{"public"}}, true},
+		{Filter{Tags: []string{"public", "internal"}}, false},
+		{Filter{Query: "ROADMAP billing"}, true},
+		{Filter{Query: "roadmap payroll"}, false},
+		{Filter{Tags: []string{"public"}, Query: "payroll"}, false},
+		{Filter{}, false},
+	}
+	for i, c := range cases {
+		if got := c.filter.Matches(n); got != c.want {
+			t.Errorf("case %d %+v: got %v, want %v", i, c.filter, got, c.want)
+		}
+	}
+}
+
+func TestStoreCreateResolveAndPersist(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore(t, dir)
+
+	sh, token, err := store.Create("idx", Spec{Filter: Filter{Tags: []string{"public"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, TokenPrefix) || len(token) < 40 {
+		t.Fatalf("token looks weak: %q", token)
+	}
+	if sh.MaxResults != 10 || !sh.ExpiresAt.Equal(sh.CreatedAt.Add(24*time.Hour)) {
+		t.Errorf("defaults not applied: %+v", sh)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "shares.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Fatal("token must not be persisted in clear")
+	}
+
+	reloaded := newTestStore(t, dir)
+	got, err := reloaded.Resolve(token)
+	if err != nil || got.ID != sh.ID || got.IndexID != "idx" {
+		t.Fatalf("resolve after reload: %+v, %v", got, err)
+	}
+	if _, err := reloaded.Resolve(token + "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a wrong token, got %v", err)
+	}
+	if st := reloaded.Stats(); st.Total != 1 || st.Active != 1 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
+
+func TestStoreValidatesSpec(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	filter := Filter{Query: "roadmap"}
+	for _, spec := range []Spec{
+		{},
+		{Filter: Filter{Tags: []string{""}}},
+		{Filter: filter, Expiry: "soon"},
+		{Filter: filter, Expiry: "-1h"},
+		{Filter: filter, Expiry: "100h"},
+		{Filter: filter, MaxResults: 11},
+	} {
+		if _, _, err := store.Create("idx", spec); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%+v: expected ErrInvalid, got %v", spec, err)
+		}
+	}
+}
+
+func TestStoreExpiryLimitAndRevocation(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	spec := Spec{Filter: Filter{Query: "roadmap"}, Expiry: "1h"}
+	first, firstToken, err := store.Create("idx", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, secondToken, err := store.Create("idx", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Create("idx", spec); !errors.Is(err, ErrLimitReached) {
+		t.Fatalf("expected ErrLimitReached, got %v", err)
+	}
+
+	if err := store.Delete("other", first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("another index must not revoke the share, got %v", err)
+	}
+	if err := store.Delete("idx", first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Resolve(firstToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoked token should be unknown, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := store.Resolve(secondToken); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+	if st := store.Stats(); st.Expired != 1 || st.Active != 0 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+	// Expired shares do not count against the limit.
+	if _, _, err := store.Create("idx", spec); err != nil {
+		t.Errorf("expired share should free its slot: %v", err)
+	}
+
+	now = now.Add(ExpiredRetention + time.Hour)
+	if _, err := store.Resolve(secondToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("long-expired token should be unknown, got %v", err)
+	}
+}
+
+func TestStoreBadges(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore(t, dir)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	badge, token, err := store.CreateBadge("idx", BadgeSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !badge.IsBadge() || len(badge.Fields) != len(BadgeFields) || !badge.ExpiresAt.IsZero() {
+		t.Fatalf("expected a badge with every field and no expiry, got %+v", badge)
+	}
+	if _, _, err := store.CreateBadge("idx", BadgeSpec{Fields: []string{"content"}}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an unknown field, got %v", err)
+	}
+	if _, _, err := store.CreateBadge("idx", BadgeSpec{Expiry: "1000h"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid past maxExpiry, got %v", err)
+	}
+
+	// Badges count against the share limit and never expire.
+	if _, _, err := store.Create("idx", Spec{Filter: Filter{Tags: []string{"public"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.CreateBadge("idx", BadgeSpec{Fields: []string{BadgeState}}); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("expected ErrLimitReached, got %v", err)
+	}
+	now = now.Add(ExpiredRetention + 30*24*time.Hour)
+	reloaded := newTestStore(t, dir)
+	reloaded.now = store.now
+	got, err := reloaded.Resolve(token)
+	if err != nil || !got.IsBadge() || !got.HasField(BadgeLastActivity) {
+		t.Fatalf("expected the badge to outlive expiry retention, got %+v, %v", got, err)
+	}
+	if list := reloaded.List("idx"); len(list) != 1 || list[0].ID != badge.ID {
+		t.Errorf("expected only the badge to be listed, got %d", len(list))
+	}
+}
+
+func TestRedact(t *testing.T) {
+	token := TokenPrefix + "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+	got := Redact("/v1/shared/" + token + "/search")
+	if strings.Contains(got, token) || got != "/v1/shared/"+TokenPrefix+"abcd…/search" {
+		t.Errorf("unexpected redaction %q", got)
+	}
+	if got := Redact("/v1/search"); got != "/v1/search" {
+		t.Errorf("paths without tokens must be unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,176 @@
+package subscription
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a subscription runs next.
+type Schedule interface {
+	// Next returns the first run time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule accepts an interval ("15m", "@every 1h"), a shorthand
+// (@hourly, @daily, @weekly) or a five-field cron expression
+// ("minute hour day-of-month month day-of-week", evaluated in UTC).
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "":
+		return nil, fmt.Errorf("%w: schedule is required", ErrInvalid)
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		return parseInterval(strings.TrimSpace(every))
+	}
+	if !strings.Contains(spec, " ") {
+		return parseInterval(spec)
+	}
+	return parseCron(spec)
+}
+
+// interval runs every d after the previous run.
+type interval time.Duration
+
+func parseInterval(s string) (Schedule, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: schedule %q is neither an interval nor a cron expression", ErrInvalid, s)
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("%w: schedule interval must be positive", ErrInvalid)
+	}
+	return interval(d), nil
+}
+
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// cron matches minutes against five field sets. As in standard cron, when
+// both day-of-month and day-of-week are restricted a day matching either
+// one qualifies.
+type cron struct {
+	minute, hour, dom, month, dow uint64 // bit i set when value i matches
+	domAny, dowAny                bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day-of-month", 1, 31},
+	{"month", 1, 12},
+	{"day-of-week", 0, 7},
+}
+
+func parseCron(spec string) (Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w: cron expression %q must have 5 fields", ErrInvalid, spec)
+	}
+	var sets [5]uint64
+	for i, part := range parts {
+		f := cronFields[i]
+		set, err := parseCronField(part, f.min, f.max)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cron %s field %q: %v", ErrInvalid, f.name, part, err)
+		}
+		sets[i] = set
+	}
+	// Sunday may be written as 0 or 7.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	c := &cron{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAny: parts[2] == "*", dowAny: parts[4] == "*",
+	}
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: cron expression %q never matches", ErrInvalid, spec)
+	}
+	return c, nil
+}
+
+// parseCronField parses a comma-separated list of *, n, a-b, */s and a-b/s.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, 1
+		if r, s, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", s)
+			}
+			rng, step = r, n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("invalid value %q", a)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("invalid value %q", b)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("out of range %d-%d", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// cronSearchLimit bounds the minute-by-minute search for a match; a valid
+// expression always matches within four years (29 February).
+const cronSearchLimit = 4 * 366 * 24 * 60
+
+func (c *cron) Next(t time.Time) time.Time {
+	next := t.UTC().Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < cronSearchLimit; i++ {
+		if c.matches(next) {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+func (c *cron) matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowMatch
+	case c.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
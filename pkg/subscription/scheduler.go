@@ -0,0 +1,104 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Output is what a successful run produced.
+type Output struct {
+	Results  int    // neurons matched
+	NeuronID string // neuron written, empty when nothing matched
+}
+
+// Runner executes one subscription against its index.
+type Runner func(ctx context.Context, sub *Subscription) (Output, error)
+
+// Scheduler runs due subscriptions on a fixed tick. Runs are sequential, so
+// one slow subscription delays the others until the next tick at most.
+type Scheduler struct {
+	store *Store
+	run   Runner
+	tick  time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler that checks store for due subscriptions
+// every tick and executes them with run.
+func NewScheduler(store *Store, run Runner, tick time.Duration) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		store:  store,
+		run:    run,
+		tick:   tick,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start launches the scheduler loop.
+func (sc *Scheduler) Start() {
+	sc.wg.Add(1)
+	go func() {
+		defer sc.wg.Done()
+
+		ticker := time.NewTicker(sc.tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sc.ctx.Done():
+				return
+			case now := <-ticker.C:
+				sc.RunDue(now)
+			}
+		}
+	}()
+}
+
+// Stop cancels a running subscription and waits for the loop to exit.
+func (sc *Scheduler) Stop() {
+	sc.cancel()
+	sc.wg.Wait()
+}
+
+// RunDue executes every subscription due at now and records the outcome in
+// its history.
+func (sc *Scheduler) RunDue(now time.Time) {
+	for _, sub := range sc.store.Due(now) {
+		if sc.ctx.Err() != nil {
+			return
+		}
+		run := sc.execute(sub)
+		if err := sc.store.RecordRun(sub.ID, run); err != nil && err != ErrNotFound {
+			log.Printf("subscription %s: failed to record run: %v", sub.ID, err)
+		}
+	}
+}
+
+// execute runs sub, turning errors and panics into a failed Run.
+func (sc *Scheduler) execute(sub *Subscription) (run Run) {
+	run = Run{StartedAt: time.Now(), Status: StatusOK}
+	defer func() {
+		if r := recover(); r != nil {
+			run.Status, run.Error = StatusError, fmt.Sprintf("panic: %v", r)
+		}
+		run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+		if run.Status == StatusError {
+			log.Printf("subscription %s (index %s): run failed: %s", sub.ID, sub.IndexID, run.Error)
+		}
+	}()
+
+	out, err := sc.run(sc.ctx, sub)
+	if err != nil {
+		run.Status, run.Error = StatusError, err.Error()
+		return run
+	}
+	run.Results, run.NeuronID = out.Results, out.NeuronID
+	return run
+}
@@ -0,0 +1,424 @@
+// Package subscription stores scheduled queries that periodically run a
+// search or context assembly against an index and write the result back
+// into it as a new neuron, leaving a record of what was relevant when.
+package subscription
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actions a subscription can run.
+const (
+	// ActionContextDigest assembles context for the cue and stores the text.
+	ActionContextDigest = "context_digest"
+
+	// ActionSearchSnapshot searches for the query and stores the IDs of the
+	// top results.
+	ActionSearchSnapshot = "search_snapshot"
+)
+
+// Run statuses.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+var (
+	// ErrNotFound is returned for an unknown subscription ID.
+	ErrNotFound = errors.New("subscription not found")
+
+	// ErrLimitReached is returned when an index already holds the maximum
+	// number of subscriptions.
+	ErrLimitReached = errors.New("subscription limit reached for index")
+
+	// ErrInvalid is returned when a subscription spec is malformed.
+	ErrInvalid = errors.New("invalid subscription")
+)
+
+// Spec is the user-editable part of a subscription.
+type Spec struct {
+	Query    string            `json:"query,omitempty"`
+	Cue      string            `json:"cue,omitempty"`
+	Schedule string            `json:"schedule"`
+	Action   string            `json:"action"`
+	Metadata map[string]string `json:"metadata,omitempty"` // added to every result neuron
+
+	Limit     int `json:"limit,omitempty"`     // search_snapshot: results kept
+	MaxTokens int `json:"maxTokens,omitempty"` // context_digest: token budget
+	Depth     int `json:"depth,omitempty"`     // spread depth
+}
+
+// Text returns the cue or query the action runs with; either may be given.
+func (s Spec) Text() string {
+	if s.Action == ActionContextDigest && s.Cue != "" {
+		return s.Cue
+	}
+	if s.Query != "" {
+		return s.Query
+	}
+	return s.Cue
+}
+
+// Validate checks the action, text and schedule.
+func (s Spec) Validate() error {
+	switch s.Action {
+	case ActionContextDigest, ActionSearchSnapshot:
+	default:
+		return fmt.Errorf("%w: action must be %s or %s", ErrInvalid, ActionContextDigest, ActionSearchSnapshot)
+	}
+	if s.Text() == "" {
+		return fmt.Errorf("%w: query or cue is required", ErrInvalid)
+	}
+	if s.Limit < 0 || s.MaxTokens < 0 || s.Depth < 0 {
+		return fmt.Errorf("%w: limit, maxTokens and depth must not be negative", ErrInvalid)
+	}
+	_, err := ParseSchedule(s.Schedule)
+	return err
+}
+
+// Run is one execution of a subscription.
+type Run struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Results    int       `json:"results"`            // neurons matched
+	NeuronID   string    `json:"neuronId,omitempty"` // empty when nothing matched or the run failed
+}
+
+// Subscription is a scheduled query owned by one index.
+type Subscription struct {
+	ID      string `json:"id"`
+	IndexID string `json:"indexId"`
+	Spec
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	NextRunAt time.Time `json:"nextRunAt"`
+
+	// History lists the most recent runs, newest first.
+	History []Run `json:"history"`
+}
+
+// LastRun returns the most recent run, or nil before the first one.
+func (s *Subscription) LastRun() *Run {
+	if len(s.History) == 0 {
+		return nil
+	}
+	return &s.History[0]
+}
+
+func (s *Subscription) clone() *Subscription {
+	c := *s
+	if s.Metadata != nil {
+		c.Metadata = make(map[string]string, len(s.Metadata))
+		for k, v := range s.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	c.History = append([]Run{}, s.History...)
+	return &c
+}
+
+// Store holds subscriptions with file-based persistence. Returned
+// subscriptions are copies.
+type Store struct {
+	subs     map[string]*Subscription
+	mu       sync.RWMutex
+	filePath string
+
+	maxPerIndex int
+	historySize int
+	now         func() time.Time
+}
+
+// NewStore loads subscriptions from subscriptions.json under dataPath.
+// Each index may hold at most maxPerIndex subscriptions and each keeps its
+// last historySize runs.
+func NewStore(dataPath string, maxPerIndex, historySize int) (*Store, error) {
+	if err := os.MkdirAll(dataPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create subscriptions path: %w", err)
+	}
+
+	s := &Store{
+		subs:        make(map[string]*Subscription),
+		filePath:    filepath.Join(dataPath, "subscriptions.json"),
+		maxPerIndex: maxPerIndex,
+		historySize: historySize,
+		now:         time.Now,
+	}
+
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load subscriptions: %w", err)
+	}
+
+	return s, nil
+}
+
+// Create adds a subscription to indexID. Its first run is scheduled from now.
+func (s *Store) Create(indexID string, spec Spec) (*Subscription, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	sched, _ := ParseSchedule(spec.Schedule)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.countLocked(indexID) >= s.maxPerIndex {
+		return nil, fmt.Errorf("%w (%d)", ErrLimitReached, s.maxPerIndex)
+	}
+
+	now := s.now()
+	sub := &Subscription{
+		ID:        uuid.NewString(),
+		IndexID:   indexID,
+		Spec:      spec,
+		CreatedAt: now,
+		UpdatedAt: now,
+		NextRunAt: sched.Next(now),
+		History:   []Run{},
+	}
+	s.subs[sub.ID] = sub
+
+	if err := s.save(); err != nil {
+		delete(s.subs, sub.ID)
+		return nil, fmt.Errorf("failed to persist: %w", err)
+	}
+	return sub.clone(), nil
+}
+
+// Get returns subscription id of indexID.
+func (s *Store) Get(indexID, id string) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subs[id]
+	if !ok || sub.IndexID != indexID {
+		return nil, ErrNotFound
+	}
+	return sub.clone(), nil
+}
+
+// List returns the subscriptions of indexID, oldest first.
+func (s *Store) List(indexID string) []*Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []*Subscription{}
+	for _, sub := range s.subs {
+		if sub.IndexID == indexID {
+			result = append(result, sub.clone())
+		}
+	}
+	sortByCreation(result)
+	return result
+}
+
+// Update replaces the spec of subscription id and reschedules it from now.
+// Its history is kept.
+func (s *Store) Update(indexID, id string, spec Spec) (*Subscription, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	sched, _ := ParseSchedule(spec.Schedule)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[id]
+	if !ok || sub.IndexID != indexID {
+		return nil, ErrNotFound
+	}
+
+	previous := sub.clone()
+	now := s.now()
+	sub.Spec = spec
+	sub.UpdatedAt = now
+	sub.NextRunAt = sched.Next(now)
+
+	if err := s.save(); err != nil {
+		s.subs[id] = previous
+		return nil, fmt.Errorf("failed to persist: %w", err)
+	}
+	return sub.clone(), nil
+}
+
+// Delete removes subscription id of indexID.
+func (s *Store) Delete(indexID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[id]
+	if !ok || sub.IndexID != indexID {
+		return ErrNotFound
+	}
+	delete(s.subs, id)
+
+	if err := s.save(); err != nil {
+		s.subs[id] = sub
+		return fmt.Errorf("failed to persist: %w", err)
+	}
+	return nil
+}
+
+// DeleteIndex removes every subscription of indexID and returns how many
+// there were.
+func (s *Store) DeleteIndex(indexID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := make(map[string]*Subscription)
+	for id, sub := range s.subs {
+		if sub.IndexID == indexID {
+			removed[id] = sub
+			delete(s.subs, id)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	if err := s.save(); err != nil {
+		for id, sub := range removed {
+			s.subs[id] = sub
+		}
+		return 0, fmt.Errorf("failed to persist: %w", err)
+	}
+	return len(removed), nil
+}
+
+// Due returns the subscriptions whose next run is at or before now, oldest
+// first.
+func (s *Store) Due(now time.Time) []*Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var due []*Subscription
+	for _, sub := range s.subs {
+		if !sub.NextRunAt.IsZero() && !sub.NextRunAt.After(now) {
+			due = append(due, sub.clone())
+		}
+	}
+	sortByCreation(due)
+	return due
+}
+
+// RecordRun prepends run to the history of subscription id and schedules
+// its next run after the run started. It returns ErrNotFound if the
+// subscription was deleted while it ran.
+func (s *Store) RecordRun(id string, run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	history := append([]Run{run}, sub.History...)
+	if len(history) > s.historySize {
+		history = history[:s.historySize]
+	}
+	sub.History = history
+	if sched, err := ParseSchedule(sub.Schedule); err == nil {
+		sub.NextRunAt = sched.Next(run.StartedAt)
+	} else {
+		sub.NextRunAt = time.Time{}
+	}
+
+	return s.save()
+}
+
+// Count returns the total number of subscriptions.
+func (s *Store) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subs)
+}
+
+func (s *Store) countLocked(indexID string) int {
+	n := 0
+	for _, sub := range s.subs {
+		if sub.IndexID == indexID {
+			n++
+		}
+	}
+	return n
+}
+
+func sortByCreation(subs []*Subscription) {
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].CreatedAt.Equal(subs[j].CreatedAt) {
+			return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+		}
+		return subs[i].ID < subs[j].ID
+	})
+}
+
+// Relocate moves the store's file to subscriptions.json under dataPath,
+// as a storage migration moves the data directory, and writes the current
+// subscriptions there. On failure the store keeps its file.
+func (s *Store) Relocate(dataPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.filePath
+	s.filePath = filepath.Join(dataPath, "subscriptions.json")
+	if err := s.save(); err != nil {
+		s.filePath = prev
+		return err
+	}
+	return nil
+}
+
+// load reads subscriptions from disk
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var subs []*Subscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if sub.History == nil {
+			sub.History = []Run{}
+		}
+		s.subs[sub.ID] = sub
+	}
+	return nil
+}
+
+// save writes subscriptions to disk atomically. Callers hold the lock.
+func (s *Store) save() error {
+	subs := make([]*Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	sortByCreation(subs)
+
+	data, err := json.MarshalIndent(subs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.filePath)
+}
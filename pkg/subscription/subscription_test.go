@@ -0,0 +1,164 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestParseSchedule(t *testing.T) {
+	from := mustTime(t, "2026-03-04T10:17:30Z") // a Wednesday
+	cases := []struct {
+		spec string
+		want string
+	}{
+		{"15m", "2026-03-04T10:32:30Z"},
+		{"@every 2h", "2026-03-04T12:17:30Z"},
+		{"@hourly", "2026-03-04T11:00:00Z"},
+		{"@daily", "2026-03-05T00:00:00Z"},
+		{"*/20 * * * *", "2026-03-04T10:20:00Z"},
+		{"30 9 * * 1-5", "2026-03-05T09:30:00Z"},
+		{"0 3 * * 7", "2026-03-08T03:00:00Z"},
+		{"0 0 1 * *", "2026-04-01T00:00:00Z"},
+		{"0 12 15 * 0", "2026-03-08T12:00:00Z"}, // day-of-month or Sunday
+	}
+	for _, c := range cases {
+		sched, err := ParseSchedule(c.spec)
+		if err != nil {
+			t.Errorf("%q: %v", c.spec, err)
+			continue
+		}
+		if got := sched.Next(from); !got.Equal(mustTime(t, c.want)) {
+			t.Errorf("%q: next run %s, want %s", c.spec, got.Format(time.RFC3339), c.want)
+		}
+	}
+
+	for _, spec := range []string{"", "soon", "-5m", "* * * *", "60 * * * *", "*/0 * * * *", "0 0 30 2 *"} {
+		if _, err := ParseSchedule(spec); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: expected ErrInvalid, got %v", spec, err)
+		}
+	}
+}
+
+func TestStoreLimitsAndPersists(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := Spec{Cue: "project decisions", Schedule: "1h", Action: ActionContextDigest}
+
+	if _, err := store.Create("idx", Spec{Schedule: "1h", Action: ActionContextDigest}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid without a cue, got %v", err)
+	}
+	if _, err := store.Create("idx", Spec{Query: "q", Schedule: "1h", Action: "summarize"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an unknown action, got %v", err)
+	}
+
+	first, err := store.Create("idx", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create("idx", spec); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create("idx", spec); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("expected ErrLimitReached, got %v", err)
+	}
+	if _, err := store.Create("other", spec); err != nil {
+		t.Errorf("the cap is per index: %v", err)
+	}
+	if _, err := store.Get("other", first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("subscriptions must not be visible from another index, got %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		run := Run{StartedAt: first.CreatedAt.Add(time.Duration(i) * time.Hour), Status: StatusOK, Results: i}
+		if err := store.RecordRun(first.ID, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded, err := NewStore(dir, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reloaded.List("idx")); got != 2 {
+		t.Fatalf("expected 2 subscriptions after reload, got %d", got)
+	}
+	sub, err := reloaded.Get("idx", first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sub.History) != 3 || sub.LastRun().Results != 4 {
+		t.Errorf("expected the 3 newest runs, newest first, got %+v", sub.History)
+	}
+	if want := first.CreatedAt.Add(5 * time.Hour); !sub.NextRunAt.Equal(want) {
+		t.Errorf("next run %s, want %s", sub.NextRunAt, want)
+	}
+}
+
+func TestSchedulerRecordsFailuresAndSkipsDeleted(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, _ := store.Create("idx", Spec{Query: "fine", Schedule: "1m", Action: ActionSearchSnapshot})
+	fails, _ := store.Create("idx", Spec{Query: "fails", Schedule: "1m", Action: ActionSearchSnapshot})
+	panics, _ := store.Create("idx", Spec{Query: "panics", Schedule: "1m", Action: ActionSearchSnapshot})
+	deleted, _ := store.Create("idx", Spec{Query: "deleted", Schedule: "1m", Action: ActionSearchSnapshot})
+	if err := store.Delete("idx", deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+	sc := NewScheduler(store, func(_ context.Context, sub *Subscription) (Output, error) {
+		ran = append(ran, sub.Query)
+		switch sub.Query {
+		case "fails":
+			return Output{}, errors.New("index unavailable")
+		case "panics":
+			panic("boom")
+		}
+		return Output{Results: 2, NeuronID: "n-1"}, nil
+	}, time.Minute)
+
+	sc.RunDue(time.Now().Add(time.Minute))
+	if len(ran) != 3 {
+		t.Fatalf("expected 3 runs, got %v", ran)
+	}
+
+	check := func(id, status, errText, neuronID string) {
+		t.Helper()
+		sub, err := store.Get("idx", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := sub.LastRun()
+		if last == nil || last.Status != status || last.Error != errText || last.NeuronID != neuronID {
+			t.Errorf("unexpected last run %+v", last)
+		}
+		if !sub.NextRunAt.After(last.StartedAt) {
+			t.Errorf("next run %s not after %s", sub.NextRunAt, last.StartedAt)
+		}
+	}
+	check(ok.ID, StatusOK, "", "n-1")
+	check(fails.ID, StatusError, "index unavailable", "")
+	check(panics.ID, StatusError, "panic: boom", "")
+
+	ran = nil
+	sc.RunDue(time.Now())
+	if len(ran) != 0 {
+		t.Errorf("nothing should be due right after running, ran %v", ran)
+	}
+}
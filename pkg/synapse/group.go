@@ -0,0 +1,140 @@
+package synapse
+
+import (
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// GroupCoFireOptions bounds a group co-fire.
+type GroupCoFireOptions struct {
+	// SimilarityFloor is the similarity two unlinked members need for a
+	// synapse to be created between them.
+	SimilarityFloor float64
+
+	// Floor, when set, replaces SimilarityFloor with a floor for each
+	// pair, e.g. by the measure Similarity rates it with.
+	Floor func(a, b *core.Neuron) float64
+
+	// MaxNewSynapses caps the synapses one co-fire creates, most similar
+	// pairs first. 0 creates none.
+	MaxNewSynapses int
+
+	// Similarity rates two neurons from 0 to 1. nil creates no synapses.
+	Similarity func(a, b *core.Neuron) float64
+}
+
+// GroupCoFire is what a group co-fire did.
+type GroupCoFire struct {
+	Strengthened int `json:"strengthened"`
+	Created      int `json:"created"`
+}
+
+// CoFireGroup reinforces ids as a group retrieved together: every synapse
+// between two members is strengthened once, and missing ones are created
+// between members at least opts.SimilarityFloor alike, up to
+// opts.MaxNewSynapses, as similar synapses at the weight co-activation
+// forms them with. Each
+// increment is the pairwise one scaled by 2/n, so a member gains about as
+// much in total however large the group and large result sets do not
+// saturate. It runs in one pass under the matrix write lock, then records
+// the members as fired for later co-activation. Members no longer in the
+// matrix are skipped.
+func (h *HebbianEngine) CoFireGroup(ids []core.NeuronID, opts GroupCoFireOptions) GroupCoFire {
+	var result GroupCoFire
+
+	h.mu.Lock()
+	rate := h.learningRate
+	formWeight := h.minWeightToForm
+	maxPerNeuron := h.maxSynapsesPerNeuron
+	h.mu.Unlock()
+
+	h.matrix.Lock()
+	members := make([]*core.Neuron, 0, len(ids))
+	seen := make(map[core.NeuronID]bool, len(ids))
+	for _, id := range ids {
+		if n := h.matrix.Neurons[id]; n != nil && !seen[id] {
+			seen[id] = true
+			members = append(members, n)
+		}
+	}
+	if len(members) < 2 {
+		h.matrix.Unlock()
+		return result
+	}
+	scale := 2 / float64(len(members))
+
+	type candidate struct {
+		from, to   core.NeuronID
+		similarity float64
+	}
+	var missing []candidate
+	for i, a := range members {
+		for _, b := range members[i+1:] {
+			syn := h.matrix.Synapses[core.NewSynapseID(a.ID, b.ID)]
+			if syn == nil {
+				syn = h.matrix.Synapses[core.NewSynapseID(b.ID, a.ID)]
+			}
+			if syn != nil {
+				weight, _, _ := syn.Snapshot()
+				syn.Strengthen(rate * (1 - weight) * scale)
+				result.Strengthened++
+				continue
+			}
+			if opts.Similarity == nil || opts.MaxNewSynapses <= 0 {
+				continue
+			}
+			floor := opts.SimilarityFloor
+			if opts.Floor != nil {
+				floor = opts.Floor(a, b)
+			}
+			if sim := opts.Similarity(a, b); sim >= floor {
+				missing = append(missing, candidate{a.ID, b.ID, sim})
+			}
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].similarity != missing[j].similarity {
+			return missing[i].similarity > missing[j].similarity
+		}
+		if missing[i].from != missing[j].from {
+			return missing[i].from < missing[j].from
+		}
+		return missing[i].to < missing[j].to
+	})
+	for _, c := range missing {
+		if result.Created >= opts.MaxNewSynapses {
+			break
+		}
+		if len(h.matrix.Adjacency[c.from]) >= maxPerNeuron || len(h.matrix.Adjacency[c.to]) >= maxPerNeuron {
+			continue
+		}
+		if h.createSynapseLocked(c.from, c.to, formWeight, core.SynapseSimilar) {
+			result.Created++
+		}
+	}
+	if result.Strengthened > 0 {
+		h.matrix.ModifiedAt = time.Now()
+		h.matrix.Version++
+	}
+	h.matrix.Unlock()
+
+	h.mu.Lock()
+	now := time.Now()
+	for _, n := range members {
+		h.recentFires[n.ID] = now
+	}
+	h.mu.Unlock()
+
+	h.groupCoFires.Add(1)
+	h.groupSynapses.Add(uint64(result.Created))
+	return result
+}
+
+// GroupStats returns how many group co-fires ran and the synapses they
+// created since the engine was created.
+func (h *HebbianEngine) GroupStats() (coFires, synapsesCreated uint64) {
+	return h.groupCoFires.Load(), h.groupSynapses.Load()
+}
@@ -0,0 +1,112 @@
+package synapse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// addNeurons adds n neurons to m and returns their IDs.
+func addNeurons(m *core.Matrix, prefix string, n int) []core.NeuronID {
+	ids := make([]core.NeuronID, n)
+	for i := range ids {
+		neuron := core.NewNeuron(fmt.Sprintf("%s %d", prefix, i), m.CurrentDim)
+		m.Neurons[neuron.ID] = neuron
+		m.Adjacency[neuron.ID] = []core.NeuronID{}
+		ids[i] = neuron.ID
+	}
+	return ids
+}
+
+// weightBetween returns the weight of the synapse linking a and b, or 0.
+func weightBetween(m *core.Matrix, a, b core.NeuronID) float64 {
+	syn := m.Synapses[core.NewSynapseID(a, b)]
+	if syn == nil {
+		syn = m.Synapses[core.NewSynapseID(b, a)]
+	}
+	if syn == nil {
+		return 0
+	}
+	weight, _, _ := syn.Snapshot()
+	return weight
+}
+
+func alike(_, _ *core.Neuron) float64 { return 1 }
+
+func TestCoFireGroup_IntraGroupConverges(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+	apollo := addNeurons(m, "apollo", 4)
+	gemini := addNeurons(m, "gemini", 4)
+	opts := GroupCoFireOptions{MaxNewSynapses: 100, Similarity: alike}
+
+	// One mixed retrieval links everything at the forming weight.
+	first := h.CoFireGroup(append(append([]core.NeuronID{}, apollo...), gemini...), opts)
+	if first.Created != 28 || first.Strengthened != 0 {
+		t.Fatalf("expected 28 synapses created, got %+v", first)
+	}
+	baseline := weightBetween(m, apollo[0], gemini[0])
+
+	for i := 0; i < 30; i++ {
+		if got := h.CoFireGroup(apollo, opts); got.Strengthened != 6 || got.Created != 0 {
+			t.Fatalf("round %d: expected the 6 apollo synapses strengthened, got %+v", i, got)
+		}
+		h.CoFireGroup(gemini, opts)
+	}
+
+	for _, group := range [][]core.NeuronID{apollo, gemini} {
+		for i, a := range group {
+			for _, b := range group[i+1:] {
+				if w := weightBetween(m, a, b); w < 0.8 {
+					t.Errorf("intra-group weight %.3f should have converged toward 1", w)
+				}
+			}
+		}
+	}
+	for _, a := range apollo {
+		for _, b := range gemini {
+			if w := weightBetween(m, a, b); w != baseline {
+				t.Errorf("cross-group weight %.3f should stay at the baseline %.3f", w, baseline)
+			}
+		}
+	}
+
+	coFires, created := h.GroupStats()
+	if coFires != 61 || created != 28 {
+		t.Errorf("expected 61 co-fires and 28 synapses created, got %d and %d", coFires, created)
+	}
+}
+
+func TestCoFireGroup_Caps(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+	ids := addNeurons(m, "note", 6)
+
+	got := h.CoFireGroup(ids, GroupCoFireOptions{MaxNewSynapses: 4, Similarity: alike})
+	if got.Created != 4 || len(m.Synapses) != 4 {
+		t.Fatalf("expected the cap of 4 new synapses, got %+v with %d synapses", got, len(m.Synapses))
+	}
+	got = h.CoFireGroup(ids, GroupCoFireOptions{MaxNewSynapses: 4, Similarity: alike})
+	if got.Created != 4 || got.Strengthened != 4 || len(m.Synapses) != 8 {
+		t.Errorf("expected 4 strengthened and 4 more created, got %+v with %d synapses", got, len(m.Synapses))
+	}
+
+	// Pairs below the similarity floor stay unlinked.
+	unlike := func(_, _ *core.Neuron) float64 { return 0.1 }
+	got = h.CoFireGroup(ids, GroupCoFireOptions{SimilarityFloor: 0.2, MaxNewSynapses: 10, Similarity: unlike})
+	if got.Created != 0 || got.Strengthened != 8 {
+		t.Errorf("expected only the existing 8 strengthened, got %+v", got)
+	}
+	// A per-pair floor replaces it.
+	lenient := func(_, _ *core.Neuron) float64 { return 0.05 }
+	got = h.CoFireGroup(ids, GroupCoFireOptions{SimilarityFloor: 0.2, Floor: lenient, MaxNewSynapses: 2, Similarity: unlike})
+	if got.Created != 2 || got.Strengthened != 8 {
+		t.Errorf("expected 2 created under the per-pair floor, got %+v", got)
+	}
+
+	// A single member is not a group.
+	if got := h.CoFireGroup(ids[:1], GroupCoFireOptions{MaxNewSynapses: 10, Similarity: alike}); got != (GroupCoFire{}) {
+		t.Errorf("expected nothing for one member, got %+v", got)
+	}
+}
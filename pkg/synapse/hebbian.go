@@ -2,7 +2,9 @@ package synapse
 
 import (
 	"math"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/qubicDB/qubicdb/pkg/core"
@@ -23,6 +25,13 @@ type HebbianEngine struct {
 	minWeightToForm      float64
 	maxSynapsesPerNeuron int
 
+	// prune scores synapses and bounds how many survive a prune pass
+	prune core.PruneConfig
+
+	// Group co-fire counters since the engine was created; see CoFireGroup.
+	groupCoFires  atomic.Uint64
+	groupSynapses atomic.Uint64
+
 	mu sync.Mutex
 }
 
@@ -36,12 +45,26 @@ func NewHebbianEngine(matrix *core.Matrix) *HebbianEngine {
 		forgettingRate:       0.01,
 		minWeightToForm:      0.2,
 		maxSynapsesPerNeuron: 50,
+		prune:                core.DefaultConfig().Daemons.Prune,
 	}
 }
 
 // OnNeuronFired is called whenever a neuron fires
-// It checks for co-activation with recently fired neurons
+// It checks for co-activation with recently fired neurons, forming
+// associative synapses
 func (h *HebbianEngine) OnNeuronFired(neuronID core.NeuronID) {
+	h.onFired(neuronID, core.SynapseAssociative)
+}
+
+// OnNeuronRetrieved is OnNeuronFired for a neuron a search returned: the
+// synapses it forms are co-retrieved.
+func (h *HebbianEngine) OnNeuronRetrieved(neuronID core.NeuronID) {
+	h.onFired(neuronID, core.SynapseCoRetrieved)
+}
+
+// onFired records a fire of neuronID, linking it to the neurons that fired
+// within the co-activation window with synapses of type typ.
+func (h *HebbianEngine) onFired(neuronID core.NeuronID, typ string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -70,12 +93,13 @@ func (h *HebbianEngine) OnNeuronFired(neuronID core.NeuronID) {
 
 	// Strengthen/create synapses with co-activated neurons
 	for _, coID := range coActivated {
-		h.strengthenOrCreate(neuronID, coID)
+		h.strengthenOrCreate(neuronID, coID, typ)
 	}
 }
 
-// strengthenOrCreate either strengthens existing synapse or creates new one
-func (h *HebbianEngine) strengthenOrCreate(from, to core.NeuronID) {
+// strengthenOrCreate either strengthens existing synapse, whatever its
+// type, or creates a new one of type typ
+func (h *HebbianEngine) strengthenOrCreate(from, to core.NeuronID, typ string) {
 	h.matrix.RLock()
 
 	// Check if synapse exists (either direction for bidirectional)
@@ -104,23 +128,33 @@ func (h *HebbianEngine) strengthenOrCreate(from, to core.NeuronID) {
 		h.matrix.RUnlock()
 
 		if fromCount < h.maxSynapsesPerNeuron && toCount < h.maxSynapsesPerNeuron {
-			h.createSynapse(from, to)
+			h.createSynapse(from, to, typ)
 		}
 	}
 }
 
-// createSynapse creates a new synapse between two neurons
-func (h *HebbianEngine) createSynapse(from, to core.NeuronID) {
+// createSynapse creates a new synapse of type typ between two neurons. A
+// neuron forgotten since it fired gets none.
+func (h *HebbianEngine) createSynapse(from, to core.NeuronID, typ string) {
 	h.matrix.Lock()
 	defer h.matrix.Unlock()
+	h.createSynapseLocked(from, to, h.minWeightToForm, typ)
+}
 
+// createSynapseLocked creates a synapse of the given weight and type between
+// two neurons, reporting whether it did. Callers hold the matrix write lock.
+func (h *HebbianEngine) createSynapseLocked(from, to core.NeuronID, weight float64, typ string) bool {
 	synID := core.NewSynapseID(from, to)
 	if _, exists := h.matrix.Synapses[synID]; exists {
-		return
+		return false
+	}
+	if h.matrix.Neurons[from] == nil || h.matrix.Neurons[to] == nil {
+		return false
 	}
 
-	syn := core.NewSynapse(from, to, h.minWeightToForm)
+	syn := core.NewTypedSynapse(from, to, weight, typ)
 	h.matrix.Synapses[synID] = syn
+	h.matrix.AddFootprint(core.SynapseFootprint(syn))
 
 	// Update adjacency lists (bidirectional)
 	h.matrix.Adjacency[from] = append(h.matrix.Adjacency[from], to)
@@ -128,6 +162,7 @@ func (h *HebbianEngine) createSynapse(from, to core.NeuronID) {
 
 	h.matrix.ModifiedAt = time.Now()
 	h.matrix.Version++
+	return true
 }
 
 // updateFractalCluster implements fractal spatial clustering for co-activated neurons.
@@ -162,14 +197,17 @@ func (h *HebbianEngine) updateFractalCluster(id1, id2 core.NeuronID, strength fl
 	if id1 > id2 {
 		first, second = n2, n1
 	}
+	// Positions are copy-on-write: handlers may be encoding the old ones.
 	first.Lock()
 	second.Lock()
-	dim := min(len(n1.Position), len(n2.Position))
+	pos1, pos2 := slices.Clone(n1.Position), slices.Clone(n2.Position)
+	dim := min(len(pos1), len(pos2))
 	for i := 0; i < dim; i++ {
-		mid := (n1.Position[i] + n2.Position[i]) / 2
-		n1.Position[i] += (mid - n1.Position[i]) * strength
-		n2.Position[i] += (mid - n2.Position[i]) * strength
+		mid := (pos1[i] + pos2[i]) / 2
+		pos1[i] += (mid - pos1[i]) * strength
+		pos2[i] += (mid - pos2[i]) * strength
 	}
+	n1.Position, n2.Position = pos1, pos2
 	second.Unlock()
 	first.Unlock()
 
@@ -228,14 +266,16 @@ func (h *HebbianEngine) pullToCentroid(id core.NeuronID, neighbours []core.Neuro
 	// Phase 2: apply update — no matrix lock held.
 	n.Lock()
 	defer n.Unlock()
-	for i := 0; i < dim; i++ {
-		n.Position[i] += (centroid[i] - n.Position[i]) * strength
-		if n.Position[i] > 1 {
-			n.Position[i] = 1
-		} else if n.Position[i] < -1 {
-			n.Position[i] = -1
+	pos := slices.Clone(n.Position)
+	for i := 0; i < dim && i < len(pos); i++ {
+		pos[i] += (centroid[i] - pos[i]) * strength
+		if pos[i] > 1 {
+			pos[i] = 1
+		} else if pos[i] < -1 {
+			pos[i] = -1
 		}
 	}
+	n.Position = pos
 }
 
 // repelUnconnected pushes a small random sample of unconnected neurons away from `id`
@@ -279,15 +319,17 @@ func (h *HebbianEngine) repelUnconnected(id core.NeuronID, neighbours []core.Neu
 	// --- Phase 2: update candidate positions, no matrix lock held ---
 	for _, c := range candidates {
 		c.neuron.Lock()
-		for i := 0; i < dim && i < len(c.neuron.Position); i++ {
-			diff := c.neuron.Position[i] - nPos[i]
-			c.neuron.Position[i] += diff * strength
-			if c.neuron.Position[i] > 1 {
-				c.neuron.Position[i] = 1
-			} else if c.neuron.Position[i] < -1 {
-				c.neuron.Position[i] = -1
+		pos := slices.Clone(c.neuron.Position)
+		for i := 0; i < dim && i < len(pos); i++ {
+			diff := pos[i] - nPos[i]
+			pos[i] += diff * strength
+			if pos[i] > 1 {
+				pos[i] = 1
+			} else if pos[i] < -1 {
+				pos[i] = -1
 			}
 		}
+		c.neuron.Position = pos
 		c.neuron.Unlock()
 	}
 }
@@ -329,31 +371,6 @@ func (h *HebbianEngine) DecayAll() {
 	}
 }
 
-// PruneDeadSynapses removes synapses that have decayed below threshold
-func (h *HebbianEngine) PruneDeadSynapses() int {
-	h.matrix.Lock()
-	defer h.matrix.Unlock()
-
-	pruned := 0
-	for synID, syn := range h.matrix.Synapses {
-		if !syn.IsAlive() {
-			// Remove from adjacency
-			h.removeFromAdjacency(syn.FromID, syn.ToID)
-			h.removeFromAdjacency(syn.ToID, syn.FromID)
-
-			delete(h.matrix.Synapses, synID)
-			pruned++
-		}
-	}
-
-	if pruned > 0 {
-		h.matrix.ModifiedAt = time.Now()
-		h.matrix.Version++
-	}
-
-	return pruned
-}
-
 // removeFromAdjacency removes 'remove' from the adjacency list of 'from'
 func (h *HebbianEngine) removeFromAdjacency(from, remove core.NeuronID) {
 	adj := h.matrix.Adjacency[from]
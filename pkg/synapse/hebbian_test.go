@@ -94,7 +94,7 @@ func TestHebbianEngineDecayAll(t *testing.T) {
 	}
 }
 
-func TestHebbianEnginePruneDeadSynapses(t *testing.T) {
+func TestHebbianEnginePruneSynapses(t *testing.T) {
 	m := newTestMatrix()
 	h := NewHebbianEngine(m)
 
@@ -104,13 +104,14 @@ func TestHebbianEnginePruneDeadSynapses(t *testing.T) {
 	m.Neurons[n1.ID] = n1
 	m.Neurons[n2.ID] = n2
 
-	// Create a weak synapse
-	s := core.NewSynapse(n1.ID, n2.ID, 0.01) // Below alive threshold
+	// Create a weak synapse that has not co-fired in months
+	s := core.NewSynapse(n1.ID, n2.ID, 0.01)
+	s.LastCoFire = time.Now().Add(-90 * 24 * time.Hour)
 	m.Synapses[s.ID] = s
 	m.Adjacency[n1.ID] = []core.NeuronID{n2.ID}
 	m.Adjacency[n2.ID] = []core.NeuronID{n1.ID}
 
-	pruned := h.PruneDeadSynapses()
+	pruned := h.PruneSynapses()
 
 	if pruned != 1 {
 		t.Errorf("Expected 1 pruned synapse, got %d", pruned)
@@ -196,3 +197,30 @@ func TestHebbianEngineStats(t *testing.T) {
 		t.Error("Stats should include forgetting_rate")
 	}
 }
+
+func TestHebbianEngineSynapseTypes(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+	ids := addNeurons(m, "note", 3)
+
+	h.OnNeuronFired(ids[0])
+	h.OnNeuronFired(ids[1])
+	syn := m.Synapses[core.NewSynapseID(ids[1], ids[0])]
+	if syn == nil || syn.Type != core.SynapseAssociative {
+		t.Fatalf("expected an associative synapse from co-activation, got %+v", syn)
+	}
+
+	h.OnNeuronRetrieved(ids[2])
+	for _, id := range ids[:2] {
+		syn := m.Synapses[core.NewSynapseID(ids[2], id)]
+		if syn == nil || syn.Type != core.SynapseCoRetrieved {
+			t.Errorf("expected a co-retrieved synapse from retrieval, got %+v", syn)
+		}
+	}
+
+	// Strengthening keeps the type a synapse was formed with.
+	h.OnNeuronRetrieved(ids[0])
+	if syn := m.Synapses[core.NewSynapseID(ids[1], ids[0])]; syn.Type != core.SynapseAssociative {
+		t.Errorf("expected strengthening to keep the type, got %q", syn.Type)
+	}
+}
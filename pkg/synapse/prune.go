@@ -0,0 +1,215 @@
+package synapse
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// Reasons a prune pass removes a synapse.
+const (
+	EvictLowScore      = "low_score"      // scored below MinScore
+	EvictNeuronBudget  = "neuron_budget"  // an endpoint had more than MaxSynapsesPerNeuron
+	EvictIndexBudget   = "index_budget"   // the index had more than MaxSynapses
+	EvictMissingNeuron = "missing_neuron" // an endpoint no longer exists
+)
+
+// coFireHalfSaturation is the co-fire count at which the co-fire factor of
+// a synapse's score reaches 0.5.
+const coFireHalfSaturation = 5
+
+// Score rates how worth keeping a synapse is, from 0 to 1: the mean of its
+// weight, co-fire count, recency of its last co-fire and the depth of its
+// shallower endpoint, weighted by cfg.
+func Score(cfg core.SynapseScoreConfig, weight float64, coFires uint64, lastCoFire time.Time, fromDepth, toDepth int, now time.Time) float64 {
+	total := cfg.Weight + cfg.CoFire + cfg.Recency + cfg.Depth
+	if total <= 0 {
+		return weight
+	}
+
+	coFire := float64(coFires) / float64(coFires+coFireHalfSaturation)
+
+	recency := 0.0
+	if cfg.RecencyHalfLife > 0 {
+		age := max(0, now.Sub(lastCoFire))
+		recency = math.Exp2(-float64(age) / float64(cfg.RecencyHalfLife))
+	}
+
+	depth := float64(max(0, min(fromDepth, toDepth)))
+	depth /= depth + 1
+
+	score := cfg.Weight*weight + cfg.CoFire*coFire + cfg.Recency*recency + cfg.Depth*depth
+	return score / total
+}
+
+// Eviction is a synapse a prune pass removes.
+type Eviction struct {
+	ID          core.SynapseID `json:"id"`
+	FromID      core.NeuronID  `json:"from_id"`
+	ToID        core.NeuronID  `json:"to_id"`
+	Weight      float64        `json:"weight"`
+	CoFireCount uint64         `json:"co_fire_count"`
+	LastCoFire  time.Time      `json:"last_co_fire"`
+	Type        string         `json:"type"`
+	Score       float64        `json:"score"`
+	Reason      string         `json:"reason"`
+}
+
+// PrunePlan lists what a prune pass would remove, lowest score first.
+type PrunePlan struct {
+	Evictions []Eviction `json:"evictions"`
+	Synapses  int        `json:"synapses"` // synapses before the pass
+}
+
+// SetPrunePolicy sets how synapses are scored and how many survive a prune.
+// Call before the engine is used.
+func (h *HebbianEngine) SetPrunePolicy(cfg core.PruneConfig) {
+	h.prune = cfg
+	h.maxSynapsesPerNeuron = cfg.MaxSynapsesPerNeuron
+	if h.maxSynapsesPerNeuron <= 0 {
+		h.maxSynapsesPerNeuron = math.MaxInt
+	}
+}
+
+// scoreLocked describes syn with its keep score under the engine's prune
+// policy. ok is false if an endpoint no longer exists. The caller must hold
+// the matrix lock.
+func (h *HebbianEngine) scoreLocked(syn *core.Synapse, now time.Time) (e Eviction, ok bool) {
+	weight, coFires, lastCoFire := syn.Snapshot()
+	e = Eviction{
+		ID:          syn.ID,
+		FromID:      syn.FromID,
+		ToID:        syn.ToID,
+		Weight:      weight,
+		CoFireCount: coFires,
+		LastCoFire:  lastCoFire,
+		Type:        syn.Type,
+	}
+	from, ok1 := h.matrix.Neurons[syn.FromID]
+	to, ok2 := h.matrix.Neurons[syn.ToID]
+	if !ok1 || !ok2 {
+		return e, false
+	}
+	e.Score = Score(h.prune.SynapseScore, weight, coFires, lastCoFire, neuronDepth(from), neuronDepth(to), now)
+	if w, ok := h.prune.SynapseScore.TypeWeights[syn.Type]; ok {
+		e.Score = min(1, e.Score*w)
+	}
+	return e, true
+}
+
+func neuronDepth(n *core.Neuron) int {
+	n.RLock()
+	defer n.RUnlock()
+	return n.Depth
+}
+
+// Scores returns the keep score of every synapse.
+func (h *HebbianEngine) Scores() map[core.SynapseID]float64 {
+	h.matrix.RLock()
+	defer h.matrix.RUnlock()
+
+	now := time.Now()
+	scores := make(map[core.SynapseID]float64, len(h.matrix.Synapses))
+	for id, syn := range h.matrix.Synapses {
+		e, _ := h.scoreLocked(syn, now)
+		scores[id] = e.Score
+	}
+	return scores
+}
+
+// PlanPrune reports what PruneSynapses would remove without removing it.
+func (h *HebbianEngine) PlanPrune() PrunePlan {
+	h.matrix.RLock()
+	defer h.matrix.RUnlock()
+	return h.planLocked(time.Now())
+}
+
+// planLocked picks the synapses to remove: those with a missing endpoint or
+// scoring below MinScore, then, lowest score first, any synapse with an
+// endpoint over the per-neuron budget, then the lowest-scored until the
+// index is within its budget. The caller must hold the matrix lock.
+func (h *HebbianEngine) planLocked(now time.Time) PrunePlan {
+	plan := PrunePlan{Synapses: len(h.matrix.Synapses)}
+
+	kept := make([]Eviction, 0, len(h.matrix.Synapses))
+	for _, syn := range h.matrix.Synapses {
+		e, ok := h.scoreLocked(syn, now)
+		switch {
+		case !ok:
+			e.Reason = EvictMissingNeuron
+			plan.Evictions = append(plan.Evictions, e)
+		case e.Score < h.prune.MinScore:
+			e.Reason = EvictLowScore
+			plan.Evictions = append(plan.Evictions, e)
+		default:
+			kept = append(kept, e)
+		}
+	}
+	sortByScore(kept)
+
+	if limit := h.prune.MaxSynapsesPerNeuron; limit > 0 {
+		degree := make(map[core.NeuronID]int)
+		for _, e := range kept {
+			degree[e.FromID]++
+			degree[e.ToID]++
+		}
+		survivors := kept[:0]
+		for _, e := range kept {
+			if degree[e.FromID] > limit || degree[e.ToID] > limit {
+				degree[e.FromID]--
+				degree[e.ToID]--
+				e.Reason = EvictNeuronBudget
+				plan.Evictions = append(plan.Evictions, e)
+				continue
+			}
+			survivors = append(survivors, e)
+		}
+		kept = survivors
+	}
+
+	if limit := h.prune.MaxSynapses; limit > 0 && len(kept) > limit {
+		for _, e := range kept[:len(kept)-limit] {
+			e.Reason = EvictIndexBudget
+			plan.Evictions = append(plan.Evictions, e)
+		}
+	}
+
+	sortByScore(plan.Evictions)
+	return plan
+}
+
+// sortByScore orders es by ascending score, then ID.
+func sortByScore(es []Eviction) {
+	sort.Slice(es, func(i, j int) bool {
+		if es[i].Score != es[j].Score {
+			return es[i].Score < es[j].Score
+		}
+		return es[i].ID < es[j].ID
+	})
+}
+
+// PruneSynapses removes the synapses PlanPrune selects and returns how many
+// were removed.
+func (h *HebbianEngine) PruneSynapses() int {
+	h.matrix.Lock()
+	defer h.matrix.Unlock()
+
+	plan := h.planLocked(time.Now())
+	for _, e := range plan.Evictions {
+		h.removeFromAdjacency(e.FromID, e.ToID)
+		h.removeFromAdjacency(e.ToID, e.FromID)
+		if syn, ok := h.matrix.Synapses[e.ID]; ok {
+			h.matrix.AddFootprint(-core.SynapseFootprint(syn))
+		}
+		delete(h.matrix.Synapses, e.ID)
+	}
+
+	if len(plan.Evictions) > 0 {
+		h.matrix.ModifiedAt = time.Now()
+		h.matrix.Version++
+	}
+
+	return len(plan.Evictions)
+}
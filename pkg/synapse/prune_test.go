@@ -0,0 +1,190 @@
+package synapse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+func addTestNeuron(m *core.Matrix, content string, depth int) *core.Neuron {
+	n := core.NewNeuron(content, m.CurrentDim)
+	n.Depth = depth
+	m.Neurons[n.ID] = n
+	return n
+}
+
+func addTestSynapse(m *core.Matrix, from, to *core.Neuron, weight float64, coFires uint64, age time.Duration) *core.Synapse {
+	s := core.NewSynapse(from.ID, to.ID, weight)
+	s.CoFireCount = coFires
+	s.LastCoFire = time.Now().Add(-age)
+	m.Synapses[s.ID] = s
+	m.Adjacency[from.ID] = append(m.Adjacency[from.ID], to.ID)
+	m.Adjacency[to.ID] = append(m.Adjacency[to.ID], from.ID)
+	return s
+}
+
+func TestPruneKeepsWeakButMeaningfulSynapse(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+
+	a := addTestNeuron(m, "early topic A", 3)
+	b := addTestNeuron(m, "early topic B", 3)
+	c := addTestNeuron(m, "passing remark C", 0)
+	d := addTestNeuron(m, "passing remark D", 0)
+
+	// Both are below the old weight threshold.
+	meaningful := addTestSynapse(m, a, b, 0.03, 40, 60*24*time.Hour)
+	trivial := addTestSynapse(m, c, d, 0.03, 1, 60*24*time.Hour)
+	if meaningful.IsAlive() || trivial.IsAlive() {
+		t.Fatal("test synapses should be below the weight threshold")
+	}
+
+	if pruned := h.PruneSynapses(); pruned != 1 {
+		t.Fatalf("expected 1 pruned synapse, got %d", pruned)
+	}
+	if _, ok := m.Synapses[meaningful.ID]; !ok {
+		t.Error("frequently co-fired synapse between deep neurons should survive")
+	}
+	if _, ok := m.Synapses[trivial.ID]; ok {
+		t.Error("rarely co-fired synapse between fresh neurons should be pruned")
+	}
+	if len(m.Adjacency[c.ID]) != 0 || len(m.Adjacency[a.ID]) != 1 {
+		t.Errorf("adjacency not updated: %v", m.Adjacency)
+	}
+}
+
+func TestPruneNeuronBudgetEvictsLowestScoreNotLowestWeight(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+	policy := core.DefaultConfig().Daemons.Prune
+	policy.MaxSynapsesPerNeuron = 2
+	h.SetPrunePolicy(policy)
+
+	hub := addTestNeuron(m, "hub", 3)
+	deep := addTestNeuron(m, "consolidated", 3)
+	inflated := addTestNeuron(m, "burst", 0)
+	fresh := addTestNeuron(m, "fresh", 0)
+
+	lightest := addTestSynapse(m, hub, deep, 0.3, 40, 30*24*time.Hour)
+	heaviest := addTestSynapse(m, hub, inflated, 0.6, 3, 10*24*time.Hour)
+	addTestSynapse(m, hub, fresh, 0.5, 10, 0)
+
+	plan := h.PlanPrune()
+	if len(plan.Evictions) != 1 || plan.Synapses != 3 {
+		t.Fatalf("expected 1 of 3 synapses evicted, got %+v", plan)
+	}
+	if e := plan.Evictions[0]; e.ID != heaviest.ID || e.Reason != EvictNeuronBudget {
+		t.Errorf("expected the inflated synapse evicted for neuron_budget, got %s (%s)", e.ID, e.Reason)
+	}
+	if len(m.Synapses) != 3 {
+		t.Fatal("PlanPrune must not remove synapses")
+	}
+
+	h.PruneSynapses()
+	if _, ok := m.Synapses[lightest.ID]; !ok {
+		t.Error("lowest-weight synapse should survive on its co-fire history and depth")
+	}
+	if _, ok := m.Synapses[heaviest.ID]; ok {
+		t.Error("highest-weight synapse should be evicted")
+	}
+}
+
+func TestPruneIndexBudget(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+	policy := core.DefaultConfig().Daemons.Prune
+	policy.MaxSynapses = 1
+	h.SetPrunePolicy(policy)
+
+	var best *core.Synapse
+	for i, weight := range []float64{0.2, 0.9, 0.5} {
+		s := addTestSynapse(m, addTestNeuron(m, "from", 0), addTestNeuron(m, "to", 0), weight, uint64(i+1), time.Hour)
+		if weight == 0.9 {
+			best = s
+		}
+	}
+
+	plan := h.PlanPrune()
+	if len(plan.Evictions) != 2 {
+		t.Fatalf("expected 2 evictions, got %d", len(plan.Evictions))
+	}
+	for _, e := range plan.Evictions {
+		if e.Reason != EvictIndexBudget {
+			t.Errorf("expected index_budget, got %s", e.Reason)
+		}
+	}
+	if plan.Evictions[0].Score > plan.Evictions[1].Score {
+		t.Error("evictions should be ordered by ascending score")
+	}
+
+	h.PruneSynapses()
+	if _, ok := m.Synapses[best.ID]; !ok || len(m.Synapses) != 1 {
+		t.Error("only the highest-scored synapse should survive")
+	}
+}
+
+func TestPruneRemovesSynapsesToMissingNeurons(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+
+	a := addTestNeuron(m, "kept", 2)
+	b := addTestNeuron(m, "deleted", 2)
+	addTestSynapse(m, a, b, 0.9, 20, 0)
+	delete(m.Neurons, b.ID)
+
+	plan := h.PlanPrune()
+	if len(plan.Evictions) != 1 || plan.Evictions[0].Reason != EvictMissingNeuron {
+		t.Fatalf("expected a missing_neuron eviction, got %+v", plan.Evictions)
+	}
+}
+
+func TestScoreFactors(t *testing.T) {
+	cfg := core.DefaultConfig().Daemons.Prune.SynapseScore
+	now := time.Now()
+
+	base := Score(cfg, 0.5, 5, now.Add(-cfg.RecencyHalfLife), 0, 0, now)
+	if want := (0.4*0.5 + 0.2*0.5 + 0.2*0.5) / 1.0; base < want-1e-9 || base > want+1e-9 {
+		t.Errorf("expected score %.4f, got %.4f", want, base)
+	}
+	if deeper := Score(cfg, 0.5, 5, now.Add(-cfg.RecencyHalfLife), 1, 4, now); deeper <= base {
+		t.Error("deeper endpoints should raise the score")
+	}
+	if older := Score(cfg, 0.5, 5, now.Add(-3*cfg.RecencyHalfLife), 0, 0, now); older >= base {
+		t.Error("an older last co-fire should lower the score")
+	}
+
+	weightOnly := core.SynapseScoreConfig{Weight: 1, RecencyHalfLife: time.Hour}
+	if got := Score(weightOnly, 0.7, 100, now, 5, 5, now); got != 0.7 {
+		t.Errorf("weight-only policy should score the weight, got %f", got)
+	}
+}
+
+func TestPruneTypeWeights(t *testing.T) {
+	m := newTestMatrix()
+	h := NewHebbianEngine(m)
+	policy := core.DefaultConfig().Daemons.Prune
+	policy.MinScore = 0.2
+	policy.SynapseScore.TypeWeights = map[string]float64{core.SynapseFollows: 3}
+	h.SetPrunePolicy(policy)
+
+	a := addTestNeuron(m, "question", 0)
+	b := addTestNeuron(m, "answer", 0)
+	c := addTestNeuron(m, "aside", 0)
+	follows := addTestSynapse(m, a, b, 0.1, 1, 30*24*time.Hour)
+	follows.Type = core.SynapseFollows
+	associative := addTestSynapse(m, a, c, 0.1, 1, 30*24*time.Hour)
+
+	scores := h.Scores()
+	if got, want := scores[follows.ID], 3*scores[associative.ID]; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("expected the follows score tripled to %.4f, got %.4f", want, got)
+	}
+
+	plan := h.PlanPrune()
+	if len(plan.Evictions) != 1 || plan.Evictions[0].ID != associative.ID {
+		t.Fatalf("expected only the associative synapse evicted, got %+v", plan.Evictions)
+	}
+	if typ := plan.Evictions[0].Type; typ != core.SynapseAssociative {
+		t.Errorf("expected the eviction to carry its type, got %q", typ)
+	}
+}
@@ -0,0 +1,222 @@
+package telemetry
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+// resultBuckets are the upper bounds of the result-count histogram.
+// The last bucket is open-ended.
+var resultBuckets = [...]int{0, 1, 5, 10, 20, 50}
+
+// resultBucketLabels name each histogram bucket for JSON output.
+var resultBucketLabels = [...]string{"0", "1", "2-5", "6-10", "11-20", "21-50", "51+"}
+
+// scoreScale converts float scores to fixed-point for atomic accumulation.
+const scoreScale = 1e6
+
+// ZeroResultSample is a sampled search that returned nothing.
+type ZeroResultSample struct {
+	IndexID   string    `json:"indexId"`
+	Query     string    `json:"query"`
+	Kind      string    `json:"kind"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// searchCounters is a set of rolling aggregates for one index.
+// Every field is updated with atomic ops only.
+type searchCounters struct {
+	queries        atomic.Uint64
+	zeroResults    atomic.Uint64
+	results        atomic.Uint64
+	directResults  atomic.Uint64
+	spreadResults  atomic.Uint64
+	topScoreSum    atomic.Uint64 // fixed-point, scoreScale
+	lexicalTop     atomic.Uint64
+	vectorTop      atomic.Uint64
+	spreadTop      atomic.Uint64
+	anchorTop      atomic.Uint64
+	resultBuckets  [len(resultBucketLabels)]atomic.Uint64
+	lastRecordedAt atomic.Int64
+}
+
+func (c *searchCounters) record(stats engine.SearchStats) {
+	c.queries.Add(1)
+	c.results.Add(uint64(stats.Results))
+	c.directResults.Add(uint64(stats.DirectResults))
+	c.spreadResults.Add(uint64(stats.SpreadResults))
+	c.resultBuckets[bucketFor(stats.Results)].Add(1)
+	c.lastRecordedAt.Store(time.Now().UnixNano())
+
+	if stats.Results == 0 {
+		c.zeroResults.Add(1)
+		return
+	}
+	if stats.TopScore > 0 {
+		c.topScoreSum.Add(uint64(math.Round(stats.TopScore * scoreScale)))
+	}
+	switch stats.TopComponent {
+	case engine.ComponentVector:
+		c.vectorTop.Add(1)
+	case engine.ComponentSpread:
+		c.spreadTop.Add(1)
+	case engine.ComponentAnchor:
+		c.anchorTop.Add(1)
+	default:
+		c.lexicalTop.Add(1)
+	}
+}
+
+func (c *searchCounters) snapshot() map[string]any {
+	queries := c.queries.Load()
+	zero := c.zeroResults.Load()
+	results := c.results.Load()
+	direct := c.directResults.Load()
+	spread := c.spreadResults.Load()
+	lexical := c.lexicalTop.Load()
+	vec := c.vectorTop.Load()
+	spreadTop := c.spreadTop.Load()
+	anchorTop := c.anchorTop.Load()
+	tops := lexical + vec + spreadTop + anchorTop
+
+	buckets := make(map[string]uint64, len(resultBucketLabels))
+	for i, label := range resultBucketLabels {
+		buckets[label] = c.resultBuckets[i].Load()
+	}
+
+	out := map[string]any{
+		"queries":                 queries,
+		"zeroResultQueries":       zero,
+		"zeroResultRate":          ratio(zero, queries),
+		"meanResultCount":         ratio(results, queries),
+		"meanTopScore":            ratio(c.topScoreSum.Load(), queries-zero) / scoreScale,
+		"spreadResultFraction":    ratio(spread, direct+spread),
+		"directResultFraction":    ratio(direct, direct+spread),
+		"resultCountDistribution": buckets,
+		"topResultDominance": map[string]any{
+			"lexical": ratio(lexical, tops),
+			"vector":  ratio(vec, tops),
+			"spread":  ratio(spreadTop, tops),
+			"anchor":  ratio(anchorTop, tops),
+		},
+	}
+	if ts := c.lastRecordedAt.Load(); ts > 0 {
+		out["lastQueryAt"] = time.Unix(0, ts)
+	}
+	return out
+}
+
+// SearchMetrics aggregates retrieval quality signals from live search and
+// context traffic. Raw query text is only retained for zero-result samples,
+// and only when sampling is enabled.
+type SearchMetrics struct {
+	global  searchCounters
+	indexes sync.Map // index ID -> *searchCounters
+
+	sampleZero bool
+	sampleMu   sync.Mutex
+	samples    []ZeroResultSample
+	sampleNext int
+	sampleCap  int
+	sampled    atomic.Uint64
+}
+
+// NewSearchMetrics creates an aggregator. When sampleZeroResults is true, up
+// to sampleSize zero-result queries are kept in a ring buffer.
+func NewSearchMetrics(sampleZeroResults bool, sampleSize int) *SearchMetrics {
+	if sampleSize < 0 {
+		sampleSize = 0
+	}
+	return &SearchMetrics{
+		sampleZero: sampleZeroResults && sampleSize > 0,
+		sampleCap:  sampleSize,
+	}
+}
+
+// Record adds one search or context call. kind is a free-form label such as
+// "search" or "context" used only in zero-result samples.
+func (m *SearchMetrics) Record(indexID, kind, query string, stats engine.SearchStats) {
+	m.global.record(stats)
+	m.countersFor(indexID).record(stats)
+
+	if stats.Results == 0 && m.sampleZero {
+		m.sampleZeroResult(ZeroResultSample{
+			IndexID:   indexID,
+			Query:     query,
+			Kind:      kind,
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+func (m *SearchMetrics) countersFor(indexID string) *searchCounters {
+	if c, ok := m.indexes.Load(indexID); ok {
+		return c.(*searchCounters)
+	}
+	c, _ := m.indexes.LoadOrStore(indexID, &searchCounters{})
+	return c.(*searchCounters)
+}
+
+func (m *SearchMetrics) sampleZeroResult(sample ZeroResultSample) {
+	m.sampleMu.Lock()
+	defer m.sampleMu.Unlock()
+
+	m.sampled.Add(1)
+	if len(m.samples) < m.sampleCap {
+		m.samples = append(m.samples, sample)
+		return
+	}
+	m.samples[m.sampleNext] = sample
+	m.sampleNext = (m.sampleNext + 1) % m.sampleCap
+}
+
+// ZeroResultSamples returns the sampled zero-result queries, oldest first.
+func (m *SearchMetrics) ZeroResultSamples() []ZeroResultSample {
+	m.sampleMu.Lock()
+	defer m.sampleMu.Unlock()
+
+	out := make([]ZeroResultSample, 0, len(m.samples))
+	out = append(out, m.samples[m.sampleNext:]...)
+	out = append(out, m.samples[:m.sampleNext]...)
+	return out
+}
+
+// Snapshot returns global and per-index aggregates.
+func (m *SearchMetrics) Snapshot() map[string]any {
+	indexes := make(map[string]any)
+	m.indexes.Range(func(key, value any) bool {
+		indexes[key.(string)] = value.(*searchCounters).snapshot()
+		return true
+	})
+
+	return map[string]any{
+		"global":  m.global.snapshot(),
+		"indexes": indexes,
+		"zeroResultSampling": map[string]any{
+			"enabled":  m.sampleZero,
+			"capacity": m.sampleCap,
+			"sampled":  m.sampled.Load(),
+		},
+		"indexCount": len(indexes),
+	}
+}
+
+func bucketFor(results int) int {
+	for i, upper := range resultBuckets {
+		if results <= upper {
+			return i
+		}
+	}
+	return len(resultBucketLabels) - 1
+}
+
+func ratio(num, den uint64) float64 {
+	if den == 0 {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
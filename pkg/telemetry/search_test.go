@@ -0,0 +1,157 @@
+package telemetry
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/engine"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestSearchMetrics_MixedTrafficAggregates(t *testing.T) {
+	m := NewSearchMetrics(false, 0)
+
+	// idx-a: two direct lexical hits, one vector hit with a spread neighbour.
+	m.Record("idx-a", "search", "go", engine.SearchStats{Results: 3, DirectResults: 3, TopScore: 0.9, TopComponent: engine.ComponentLexical})
+	m.Record("idx-a", "search", "rust", engine.SearchStats{Results: 1, DirectResults: 1, TopScore: 0.5, TopComponent: engine.ComponentLexical})
+	m.Record("idx-a", "context", "neural", engine.SearchStats{Results: 2, DirectResults: 1, SpreadResults: 1, TopScore: 0.7, TopComponent: engine.ComponentVector})
+	// idx-b: one zero-result query and one spread-dominated result.
+	m.Record("idx-b", "search", "missing", engine.SearchStats{})
+	m.Record("idx-b", "search", "linked", engine.SearchStats{Results: 12, SpreadResults: 12, TopScore: 0.3, TopComponent: engine.ComponentSpread})
+
+	snap := m.Snapshot()
+	global := snap["global"].(map[string]any)
+
+	if global["queries"].(uint64) != 5 {
+		t.Errorf("queries = %v, want 5", global["queries"])
+	}
+	if global["zeroResultQueries"].(uint64) != 1 {
+		t.Errorf("zeroResultQueries = %v, want 1", global["zeroResultQueries"])
+	}
+	if got := global["zeroResultRate"].(float64); !approxEqual(got, 0.2) {
+		t.Errorf("zeroResultRate = %v, want 0.2", got)
+	}
+	if got := global["meanResultCount"].(float64); !approxEqual(got, 18.0/5) {
+		t.Errorf("meanResultCount = %v, want 3.6", got)
+	}
+	// Mean top score only counts queries that returned something.
+	if got := global["meanTopScore"].(float64); !approxEqual(got, (0.9+0.5+0.7+0.3)/4) {
+		t.Errorf("meanTopScore = %v, want 0.6", got)
+	}
+	if got := global["spreadResultFraction"].(float64); !approxEqual(got, 13.0/18) {
+		t.Errorf("spreadResultFraction = %v, want 13/18", got)
+	}
+	if got := global["directResultFraction"].(float64); !approxEqual(got, 5.0/18) {
+		t.Errorf("directResultFraction = %v, want 5/18", got)
+	}
+
+	dominance := global["topResultDominance"].(map[string]any)
+	if got := dominance["lexical"].(float64); !approxEqual(got, 0.5) {
+		t.Errorf("lexical dominance = %v, want 0.5", got)
+	}
+	if got := dominance["vector"].(float64); !approxEqual(got, 0.25) {
+		t.Errorf("vector dominance = %v, want 0.25", got)
+	}
+	if got := dominance["spread"].(float64); !approxEqual(got, 0.25) {
+		t.Errorf("spread dominance = %v, want 0.25", got)
+	}
+
+	buckets := global["resultCountDistribution"].(map[string]uint64)
+	want := map[string]uint64{"0": 1, "1": 1, "2-5": 2, "6-10": 0, "11-20": 1, "21-50": 0, "51+": 0}
+	for label, n := range want {
+		if buckets[label] != n {
+			t.Errorf("bucket %s = %d, want %d", label, buckets[label], n)
+		}
+	}
+
+	if snap["indexCount"].(int) != 2 {
+		t.Fatalf("indexCount = %v, want 2", snap["indexCount"])
+	}
+	indexes := snap["indexes"].(map[string]any)
+	a := indexes["idx-a"].(map[string]any)
+	if a["queries"].(uint64) != 3 || a["zeroResultQueries"].(uint64) != 0 {
+		t.Errorf("idx-a queries/zero = %v/%v, want 3/0", a["queries"], a["zeroResultQueries"])
+	}
+	b := indexes["idx-b"].(map[string]any)
+	if got := b["zeroResultRate"].(float64); !approxEqual(got, 0.5) {
+		t.Errorf("idx-b zeroResultRate = %v, want 0.5", got)
+	}
+}
+
+func TestSearchMetrics_ZeroResultSamplingCap(t *testing.T) {
+	m := NewSearchMetrics(true, 3)
+
+	for i := 0; i < 5; i++ {
+		m.Record("idx", "search", fmt.Sprintf("q%d", i), engine.SearchStats{})
+	}
+	m.Record("idx", "search", "hit", engine.SearchStats{Results: 1, DirectResults: 1, TopScore: 1})
+
+	samples := m.ZeroResultSamples()
+	if len(samples) != 3 {
+		t.Fatalf("expected ring buffer capped at 3, got %d", len(samples))
+	}
+	for i, want := range []string{"q2", "q3", "q4"} {
+		if samples[i].Query != want {
+			t.Errorf("sample[%d] = %q, want %q (oldest first)", i, samples[i].Query, want)
+		}
+		if samples[i].IndexID != "idx" || samples[i].Kind != "search" || samples[i].Timestamp.IsZero() {
+			t.Errorf("sample[%d] missing fields: %+v", i, samples[i])
+		}
+	}
+
+	sampling := m.Snapshot()["zeroResultSampling"].(map[string]any)
+	if sampling["sampled"].(uint64) != 5 {
+		t.Errorf("sampled = %v, want 5", sampling["sampled"])
+	}
+	if sampling["capacity"].(int) != 3 {
+		t.Errorf("capacity = %v, want 3", sampling["capacity"])
+	}
+}
+
+func TestSearchMetrics_NoQueryStorageWhenSamplingDisabled(t *testing.T) {
+	m := NewSearchMetrics(false, 100)
+	m.Record("idx", "search", "secret query", engine.SearchStats{})
+
+	if samples := m.ZeroResultSamples(); len(samples) != 0 {
+		t.Errorf("expected no samples with sampling disabled, got %v", samples)
+	}
+	if m.Snapshot()["zeroResultSampling"].(map[string]any)["enabled"].(bool) {
+		t.Error("sampling should report disabled")
+	}
+}
+
+func TestSearchMetrics_ConcurrentRecord(t *testing.T) {
+	m := NewSearchMetrics(true, 8)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				stats := engine.SearchStats{}
+				if i%2 == 0 {
+					stats = engine.SearchStats{Results: 1, DirectResults: 1, TopScore: 0.5, TopComponent: engine.ComponentLexical}
+				}
+				m.Record(fmt.Sprintf("idx-%d", g%2), "search", "q", stats)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	global := m.Snapshot()["global"].(map[string]any)
+	if global["queries"].(uint64) != 800 {
+		t.Errorf("queries = %v, want 800", global["queries"])
+	}
+	if global["zeroResultQueries"].(uint64) != 400 {
+		t.Errorf("zeroResultQueries = %v, want 400", global["zeroResultQueries"])
+	}
+	if len(m.ZeroResultSamples()) != 8 {
+		t.Errorf("expected 8 samples, got %d", len(m.ZeroResultSamples()))
+	}
+}
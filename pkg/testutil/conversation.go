@@ -0,0 +1,47 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Turn is one message of a scripted conversation.
+type Turn struct {
+	Role string
+	Lang string // one of the Lang constants
+	Text string
+}
+
+// Prefixed renders the turn with its language and role inline, the way
+// conversations were stored before structured turns: "[EN] user: text".
+func (t Turn) Prefixed() string {
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(t.Lang), t.Role, t.Text)
+}
+
+// MultilangConversation is a user and assistant talking in English,
+// Turkish and German, as recorded by the multi-language e2e scenario.
+var MultilangConversation = []Turn{
+	// English context
+	{"user", LangEnglish, "My name is Alex and I work at TechCorp as a senior developer"},
+	{"assistant", LangEnglish, "Nice to meet you Alex! I'll remember you work at TechCorp as a senior developer."},
+	{"user", LangEnglish, "I prefer using TypeScript and React for frontend development"},
+	{"assistant", LangEnglish, "Got it! TypeScript and React are great choices for frontend."},
+
+	// Turkish context
+	{"user", LangTurkish, "Benim favori takımım Fenerbahçe ve her hafta maçlarını izlerim"},
+	{"assistant", LangTurkish, "Fenerbahçe taraftarı olduğunuzu not ettim! Her hafta maç izlemeniz güzel."},
+	{"user", LangTurkish, "Istanbul'da Kadıköy'de yaşıyorum, deniz kenarında güzel bir semt"},
+	{"assistant", LangTurkish, "Kadıköy gerçekten güzel bir semt, deniz manzarası muhteşem olmalı."},
+
+	// German context
+	{"user", LangGerman, "Ich lerne gerade Deutsch und finde die Sprache sehr interessant"},
+	{"assistant", LangGerman, "Das ist toll! Deutsch zu lernen ist eine gute Entscheidung."},
+	{"user", LangGerman, "Mein Lieblingsessen ist Schnitzel mit Kartoffelsalat"},
+	{"assistant", LangGerman, "Schnitzel mit Kartoffelsalat ist ein Klassiker der deutschen Küche!"},
+
+	// Mixed context - technical
+	{"user", LangEnglish, "For the new project, I need to set up a Kubernetes cluster on AWS"},
+	{"assistant", LangEnglish, "I can help with Kubernetes on AWS. Do you prefer EKS or self-managed?"},
+	{"user", LangTurkish, "Projede ayrıca Redis cache kullanmamız gerekiyor performans için"},
+	{"assistant", LangTurkish, "Redis cache iyi bir seçim, özellikle yüksek trafik senaryolarında."},
+}
@@ -0,0 +1,133 @@
+package testutil
+
+import (
+	"fmt"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+// Stats summarizes a matrix for invariant checks and benchmark reports.
+type Stats struct {
+	Neurons    int
+	Synapses   int
+	MeanDegree float64
+	MaxDegree  int
+	Isolated   int // neurons without synapses
+	Threads    int // distinct thread_id values
+	Roles      map[string]int
+	Embedded   int // neurons with an embedding
+}
+
+// Measure returns the Stats of m.
+func Measure(m *core.Matrix) Stats {
+	m.RLock()
+	defer m.RUnlock()
+
+	s := Stats{Neurons: len(m.Neurons), Synapses: len(m.Synapses), Roles: make(map[string]int)}
+	threads := make(map[any]bool)
+	edges := 0
+	for id, n := range m.Neurons {
+		degree := len(m.Adjacency[id])
+		edges += degree
+		s.MaxDegree = max(s.MaxDegree, degree)
+		if degree == 0 {
+			s.Isolated++
+		}
+		if thread, ok := n.Metadata[ThreadKey]; ok {
+			threads[thread] = true
+		}
+		if role, ok := n.Metadata[RoleKey].(string); ok {
+			s.Roles[role]++
+		}
+		if len(n.Embedding) > 0 {
+			s.Embedded++
+		}
+	}
+	s.Threads = len(threads)
+	if s.Neurons > 0 {
+		s.MeanDegree = float64(edges) / float64(s.Neurons)
+	}
+	return s
+}
+
+// Check verifies the structural invariants the engine relies on: content
+// hashes match, every synapse joins two distinct existing neurons and
+// appears in both adjacency lists, and every adjacency entry has a
+// synapse.
+func Check(m *core.Matrix) error {
+	m.RLock()
+	defer m.RUnlock()
+
+	for id, n := range m.Neurons {
+		if n.ID != id {
+			return fmt.Errorf("neuron %s is stored under %s", n.ID, id)
+		}
+		if n.ContentHash != core.HashContent(n.Content) {
+			return fmt.Errorf("neuron %s: content hash does not match its content", id)
+		}
+	}
+
+	linked := func(a, b core.NeuronID) bool {
+		for _, id := range m.Adjacency[a] {
+			if id == b {
+				return true
+			}
+		}
+		return false
+	}
+	for id, syn := range m.Synapses {
+		if id != core.NewSynapseID(syn.FromID, syn.ToID) {
+			return fmt.Errorf("synapse %s is stored under %s", core.NewSynapseID(syn.FromID, syn.ToID), id)
+		}
+		if syn.FromID == syn.ToID {
+			return fmt.Errorf("synapse %s links a neuron to itself", id)
+		}
+		if m.Neurons[syn.FromID] == nil || m.Neurons[syn.ToID] == nil {
+			return fmt.Errorf("synapse %s joins a missing neuron", id)
+		}
+		if !linked(syn.FromID, syn.ToID) || !linked(syn.ToID, syn.FromID) {
+			return fmt.Errorf("synapse %s is missing from the adjacency lists", id)
+		}
+	}
+
+	for id, adjacent := range m.Adjacency {
+		seen := make(map[core.NeuronID]bool, len(adjacent))
+		for _, other := range adjacent {
+			if seen[other] {
+				return fmt.Errorf("neuron %s lists %s twice", id, other)
+			}
+			seen[other] = true
+			if m.Synapses[core.NewSynapseID(id, other)] == nil && m.Synapses[core.NewSynapseID(other, id)] == nil {
+				return fmt.Errorf("neuron %s lists %s without a synapse", id, other)
+			}
+		}
+	}
+	return nil
+}
+
+// Save persists the brain to store. A WorkerPool built on the same store
+// loads it on first use of its index.
+func (b *Brain) Save(store *persistence.Store) error {
+	return store.Save(b.Matrix)
+}
+
+// LoadPool saves the brain to store and loads it into pool, which must be
+// built on store and must not have the index loaded yet. The worker owns a
+// decoded copy of the matrix, not b.Matrix.
+func (b *Brain) LoadPool(pool *concurrency.WorkerPool, store *persistence.Store) (*concurrency.BrainWorker, error) {
+	if _, err := pool.Get(b.Matrix.IndexID); err == nil {
+		return nil, fmt.Errorf("index %s is already loaded", b.Matrix.IndexID)
+	}
+	if err := b.Save(store); err != nil {
+		return nil, err
+	}
+	return pool.GetOrCreate(b.Matrix.IndexID)
+}
+
+// NewWorker starts a worker that owns b.Matrix, without persistence. The
+// caller stops it.
+func (b *Brain) NewWorker() *concurrency.BrainWorker {
+	return concurrency.NewBrainWorker(b.Matrix.IndexID, b.Matrix)
+}
@@ -0,0 +1,276 @@
+// Package testutil builds deterministic synthetic brains for tests and
+// benchmarks, so they share one realistic fixture instead of hand-rolled
+// write loops. Everything a brain contains, IDs and timestamps included,
+// follows from its seed and options.
+package testutil
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/vector"
+)
+
+// Metadata keys written on every synthetic neuron.
+const (
+	ThreadKey = "thread_id"
+	RoleKey   = "role"
+)
+
+// SyntheticOptions shape a synthetic brain. Zero values take the defaults
+// noted on each field.
+type SyntheticOptions struct {
+	IndexID core.IndexID // default "synthetic"
+	Neurons int          // default 1000
+
+	// Languages the content is drawn from; default English, Turkish and
+	// German.
+	Languages []string
+
+	// Threads is the number of distinct thread_id values, default one per
+	// 20 neurons. Thread sizes follow a Zipf distribution with exponent
+	// ThreadSkew (> 1, default 1.2), so a few threads hold most memories.
+	Threads    int
+	ThreadSkew float64
+
+	// Roles are the role values; default user and assistant. Roles
+	// alternate along a parent chain.
+	Roles []string
+
+	// MaxChain is the longest parent chain, default 4. Each chain is one
+	// thread and topic, and every neuron after the first is linked to its
+	// parent. 1 writes no chains.
+	MaxChain int
+
+	// MeanDegree is the target mean synapse degree, default 4; parent
+	// links count toward it. DegreeSkew 0 picks the endpoints of the other
+	// synapses uniformly; a value > 1 is a Zipf exponent that concentrates
+	// them on a few hubs.
+	MeanDegree float64
+	DegreeSkew float64
+
+	// EmbeddingDim is the size of the fake embeddings, which cluster by
+	// topic. 0 writes none.
+	EmbeddingDim int
+
+	Bounds core.MatrixBounds // default core.DefaultBounds()
+	Start  time.Time         // creation time of the first neuron; default 2025-01-01 UTC
+}
+
+func (o SyntheticOptions) withDefaults() SyntheticOptions {
+	if o.IndexID == "" {
+		o.IndexID = "synthetic"
+	}
+	if o.Neurons <= 0 {
+		o.Neurons = 1000
+	}
+	if len(o.Languages) == 0 {
+		o.Languages = []string{LangEnglish, LangTurkish, LangGerman}
+	}
+	if o.Threads <= 0 {
+		o.Threads = max(1, o.Neurons/20)
+	}
+	if o.ThreadSkew <= 1 {
+		o.ThreadSkew = 1.2
+	}
+	if len(o.Roles) == 0 {
+		o.Roles = []string{"user", "assistant"}
+	}
+	if o.MaxChain <= 0 {
+		o.MaxChain = 4
+	}
+	if o.MeanDegree < 0 {
+		o.MeanDegree = 0
+	} else if o.MeanDegree == 0 {
+		o.MeanDegree = 4
+	}
+	if o.Bounds.MaxNeurons == 0 {
+		o.Bounds = core.DefaultBounds()
+	}
+	if o.Start.IsZero() {
+		o.Start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return o
+}
+
+// Brain is a synthetic matrix and what it was built from.
+type Brain struct {
+	Matrix  *core.Matrix
+	IDs     []core.NeuronID                 // in creation order
+	Parents map[core.NeuronID]core.NeuronID // child to parent
+	Options SyntheticOptions                // with defaults applied
+}
+
+// NewSyntheticBrain builds a matrix of conversation-like memories. The same
+// seed and options always produce the same matrix.
+func NewSyntheticBrain(seed int64, opts SyntheticOptions) *Brain {
+	opts = opts.withDefaults()
+	r := rand.New(rand.NewSource(seed))
+
+	m := core.NewMatrix(opts.IndexID, opts.Bounds)
+	b := &Brain{Matrix: m, Parents: make(map[core.NeuronID]core.NeuronID), Options: opts}
+
+	var centroids [][]float32
+	if opts.EmbeddingDim > 0 {
+		for range topics {
+			centroids = append(centroids, randomUnit(r, opts.EmbeddingDim))
+		}
+	}
+
+	threads := rand.NewZipf(r, opts.ThreadSkew, 1, uint64(opts.Threads-1))
+	used := make(map[string]int)
+	at := opts.Start
+	for len(b.IDs) < opts.Neurons {
+		length := 1 + r.Intn(opts.MaxChain)
+		thread := fmt.Sprintf("thread-%04d", threads.Uint64())
+		lang := opts.Languages[r.Intn(len(opts.Languages))]
+		t := r.Intn(len(topics))
+		role := r.Intn(len(opts.Roles))
+
+		var parent *core.Neuron
+		for j := 0; j < length && len(b.IDs) < opts.Neurons; j++ {
+			at = at.Add(time.Duration(1+r.Intn(30)) * time.Minute)
+			n := core.NewNeuron(pickContent(r, topics[t], lang, used), m.CurrentDim)
+			n.ID = newID(r)
+			n.CreatedAt, n.LastFiredAt, n.LastDecayAt = at, at, at
+			n.Energy = n.BaseEnergy + (1-n.BaseEnergy)*r.Float64()
+			n.Depth = r.Intn(3)
+			n.AccessCount = uint64(1 + r.Intn(20))
+			n.Metadata[ThreadKey] = thread
+			n.Metadata[RoleKey] = opts.Roles[(role+j)%len(opts.Roles)]
+			if parent != nil {
+				n.Position = perturb(r, parent.Position, 0.1)
+			} else {
+				n.Position = perturb(r, make([]float64, m.CurrentDim), 1)
+			}
+			if centroids != nil {
+				n.Embedding = embedNear(r, centroids[t])
+				n.EmbeddingModel = core.DefaultEmbeddingModel
+			}
+
+			m.Neurons[n.ID] = n
+			m.Adjacency[n.ID] = []core.NeuronID{}
+			m.RecordChange(n)
+			b.IDs = append(b.IDs, n.ID)
+			if parent != nil {
+				b.Parents[n.ID] = parent.ID
+				b.link(r, parent, n)
+			}
+			parent = n
+		}
+	}
+	b.wire(r)
+
+	m.Version = uint64(len(b.IDs))
+	m.TotalActivations = uint64(len(b.IDs))
+	m.CreatedAt = opts.Start
+	m.LastActivity, m.ModifiedAt = at, at
+	return b
+}
+
+// wire adds synapses between random neurons until the mean degree reaches
+// its target. It gives up after a bounded number of attempts, so a target
+// denser than the matrix allows leaves it as dense as it got.
+func (b *Brain) wire(r *rand.Rand) {
+	n := len(b.IDs)
+	target := int(math.Round(float64(n) * b.Options.MeanDegree / 2))
+	if n < 2 || len(b.Matrix.Synapses) >= target {
+		return
+	}
+
+	pick := func() int { return r.Intn(n) }
+	if b.Options.DegreeSkew > 1 {
+		// Hubs are spread over the matrix rather than being the oldest
+		// neurons.
+		rank := r.Perm(n)
+		zipf := rand.NewZipf(r, b.Options.DegreeSkew, 1, uint64(n-1))
+		pick = func() int { return rank[zipf.Uint64()] }
+	}
+
+	for attempts := 0; len(b.Matrix.Synapses) < target && attempts < target*20; attempts++ {
+		from, to := b.Matrix.Neurons[b.IDs[pick()]], b.Matrix.Neurons[b.IDs[pick()]]
+		b.link(r, from, to)
+	}
+}
+
+// link connects two neurons unless they are the same or already linked.
+func (b *Brain) link(r *rand.Rand, from, to *core.Neuron) {
+	m := b.Matrix
+	if from.ID == to.ID {
+		return
+	}
+	if _, ok := m.Synapses[core.NewSynapseID(from.ID, to.ID)]; ok {
+		return
+	}
+	if _, ok := m.Synapses[core.NewSynapseID(to.ID, from.ID)]; ok {
+		return
+	}
+
+	syn := core.NewSynapse(from.ID, to.ID, 0.1+0.8*r.Float64())
+	syn.CoFireCount = uint64(1 + r.Intn(10))
+	syn.CreatedAt = from.CreatedAt
+	if to.CreatedAt.After(syn.CreatedAt) {
+		syn.CreatedAt = to.CreatedAt
+	}
+	syn.LastCoFire = syn.CreatedAt
+	m.Synapses[syn.ID] = syn
+	m.Adjacency[from.ID] = append(m.Adjacency[from.ID], to.ID)
+	m.Adjacency[to.ID] = append(m.Adjacency[to.ID], from.ID)
+}
+
+// pickContent fills a template of the topic. Repeats get a counter so every
+// neuron has distinct content, as the write path's deduplication would
+// ensure.
+func pickContent(r *rand.Rand, t topic, lang string, used map[string]int) string {
+	templates := t.templates[lang]
+	content := fmt.Sprintf(templates[r.Intn(len(templates))], t.keywords[r.Intn(len(t.keywords))])
+	if d := details[lang][r.Intn(len(details[lang]))]; d != "" {
+		content += ", " + d
+	}
+	used[content]++
+	if n := used[content]; n > 1 {
+		content = fmt.Sprintf("%s (%d)", content, n)
+	}
+	return content
+}
+
+func newID(r *rand.Rand) core.NeuronID {
+	id, err := uuid.NewRandomFromReader(r)
+	if err != nil {
+		panic(err) // math/rand never fails to read
+	}
+	return core.NeuronID(id.String())
+}
+
+// perturb returns a copy of pos moved by up to scale in each dimension.
+func perturb(r *rand.Rand, pos []float64, scale float64) []float64 {
+	out := make([]float64, len(pos))
+	for i, v := range pos {
+		out[i] = v + (r.Float64()*2-1)*scale
+	}
+	return out
+}
+
+func randomUnit(r *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = float32(r.NormFloat64())
+	}
+	vector.Normalize(v)
+	return v
+}
+
+// embedNear returns a unit vector close to centroid.
+func embedNear(r *rand.Rand, centroid []float32) []float32 {
+	v := make([]float32, len(centroid))
+	for i, c := range centroid {
+		v[i] = c + 0.3*float32(r.NormFloat64())/float32(math.Sqrt(float64(len(centroid))))
+	}
+	vector.Normalize(v)
+	return v
+}
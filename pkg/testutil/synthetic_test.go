@@ -0,0 +1,115 @@
+package testutil
+
+import (
+	"math"
+	"testing"
+
+	"github.com/qubicDB/qubicdb/pkg/concurrency"
+	"github.com/qubicDB/qubicdb/pkg/core"
+	"github.com/qubicDB/qubicdb/pkg/persistence"
+)
+
+func TestNewSyntheticBrain_Deterministic(t *testing.T) {
+	opts := SyntheticOptions{Neurons: 500, EmbeddingDim: 8, DegreeSkew: 1.5}
+	a := NewSyntheticBrain(7, opts)
+	b := NewSyntheticBrain(7, opts)
+
+	if len(a.IDs) != len(b.IDs) {
+		t.Fatalf("expected equal sizes, got %d and %d", len(a.IDs), len(b.IDs))
+	}
+	for i, id := range a.IDs {
+		na, nb := a.Matrix.Neurons[id], b.Matrix.Neurons[b.IDs[i]]
+		if id != b.IDs[i] || na.Content != nb.Content || !na.CreatedAt.Equal(nb.CreatedAt) ||
+			na.Metadata[ThreadKey] != nb.Metadata[ThreadKey] || na.Embedding[0] != nb.Embedding[0] {
+			t.Fatalf("neuron %d differs between builds with one seed", i)
+		}
+	}
+	if len(a.Matrix.Synapses) != len(b.Matrix.Synapses) {
+		t.Fatalf("expected equal synapse counts, got %d and %d", len(a.Matrix.Synapses), len(b.Matrix.Synapses))
+	}
+	for id, syn := range a.Matrix.Synapses {
+		if other := b.Matrix.Synapses[id]; other == nil || other.Weight != syn.Weight {
+			t.Fatalf("synapse %s differs between builds with one seed", id)
+		}
+	}
+
+	if c := NewSyntheticBrain(8, opts); c.IDs[0] == a.IDs[0] {
+		t.Error("a different seed should produce a different brain")
+	}
+}
+
+func TestNewSyntheticBrain_Shape(t *testing.T) {
+	b := NewSyntheticBrain(1, SyntheticOptions{Neurons: 2000, Threads: 50, MeanDegree: 6, EmbeddingDim: 16})
+	if err := Check(b.Matrix); err != nil {
+		t.Fatal(err)
+	}
+
+	s := Measure(b.Matrix)
+	if s.Neurons != 2000 || s.Embedded != 2000 {
+		t.Errorf("expected 2000 embedded neurons, got %+v", s)
+	}
+	if math.Abs(s.MeanDegree-6) > 0.1 {
+		t.Errorf("expected a mean degree near 6, got %.2f", s.MeanDegree)
+	}
+	if s.Threads > 50 || s.Roles["user"] == 0 || s.Roles["assistant"] == 0 {
+		t.Errorf("unexpected metadata distribution: %+v", s)
+	}
+
+	sizes := make(map[any]int)
+	for _, n := range b.Matrix.Neurons {
+		sizes[n.Metadata[ThreadKey]]++
+	}
+	if sizes["thread-0000"] < 2*2000/50 {
+		t.Errorf("thread sizes should be skewed, the largest holds %d", sizes["thread-0000"])
+	}
+
+	for child, parent := range b.Parents {
+		if b.Matrix.Synapses[core.NewSynapseID(parent, child)] == nil {
+			t.Fatalf("%s should be linked to its parent %s", child, parent)
+		}
+	}
+	if len(b.Parents) == 0 {
+		t.Error("expected parent chains")
+	}
+}
+
+func TestNewSyntheticBrain_DegreeSkewMakesHubs(t *testing.T) {
+	uniform := Measure(NewSyntheticBrain(3, SyntheticOptions{Neurons: 3000, MaxChain: 1}).Matrix)
+	skewed := Measure(NewSyntheticBrain(3, SyntheticOptions{Neurons: 3000, MaxChain: 1, DegreeSkew: 1.3}).Matrix)
+	if skewed.MaxDegree < 5*uniform.MaxDegree {
+		t.Errorf("a skewed degree distribution should have hubs, max degree %d vs %d", skewed.MaxDegree, uniform.MaxDegree)
+	}
+}
+
+func TestBrain_LoadPool(t *testing.T) {
+	store, err := persistence.NewStore(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := concurrency.NewWorkerPool(store, core.DefaultBounds())
+	defer pool.Shutdown()
+
+	b := NewSyntheticBrain(5, SyntheticOptions{IndexID: "loaded", Neurons: 300})
+	worker, err := b.LoadPool(pool, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := Measure(worker.Matrix()); s.Neurons != 300 || s.Synapses != len(b.Matrix.Synapses) {
+		t.Errorf("the pool should hold the whole brain, got %+v", s)
+	}
+	if err := Check(worker.Matrix()); err != nil {
+		t.Error(err)
+	}
+
+	result, err := worker.Submit(&concurrency.Operation{
+		Type:    concurrency.OpSearch,
+		Payload: concurrency.SearchRequest{Query: "Kubernetes", Depth: 1, Limit: 5},
+	})
+	if err != nil || len(result.([]*core.Neuron)) == 0 {
+		t.Errorf("expected search hits in the loaded brain, got %v (%v)", result, err)
+	}
+
+	if _, err := b.LoadPool(pool, store); err == nil {
+		t.Error("loading over a loaded index should fail")
+	}
+}
@@ -0,0 +1,119 @@
+package testutil
+
+// Languages the synthetic vocabulary covers.
+const (
+	LangEnglish = "en"
+	LangTurkish = "tr"
+	LangGerman  = "de"
+)
+
+// topic is one subject synthetic memories are about. Templates take one
+// keyword each; the phrasing follows the e2e conversation scenarios.
+type topic struct {
+	name      string
+	keywords  []string
+	templates map[string][]string // by language
+}
+
+var topics = []topic{
+	{
+		name:     "frontend",
+		keywords: []string{"TypeScript", "React", "Vue", "Tailwind", "Vite"},
+		templates: map[string][]string{
+			LangEnglish: {
+				"I prefer using %s for frontend development",
+				"The dashboard rewrite moved to %s last sprint",
+				"Remember that the design team wants %s components",
+			},
+			LangTurkish: {
+				"Frontend için %s kullanmayı tercih ediyorum",
+				"Yeni arayüz projesinde %s kullanacağız",
+			},
+			LangGerman: {
+				"Für das Frontend verwenden wir jetzt %s",
+				"Das Team möchte %s im neuen Dashboard",
+			},
+		},
+	},
+	{
+		name:     "infrastructure",
+		keywords: []string{"Kubernetes", "AWS", "Terraform", "Redis", "PostgreSQL"},
+		templates: map[string][]string{
+			LangEnglish: {
+				"For the new project I need to set up %s",
+				"The staging cluster depends on %s for deployments",
+				"Database failover was tested together with %s",
+			},
+			LangTurkish: {
+				"Projede ayrıca %s kullanmamız gerekiyor performans için",
+				"Canlı ortamda %s ayarlarını güncelledik",
+			},
+			LangGerman: {
+				"Für das neue Projekt richten wir %s ein",
+				"Die Replikation läuft jetzt über %s",
+			},
+		},
+	},
+	{
+		name:     "personal",
+		keywords: []string{"Istanbul", "Kadıköy", "Berlin", "TechCorp", "Alex"},
+		templates: map[string][]string{
+			LangEnglish: {
+				"My name is %s and I work as a senior developer",
+				"I moved closer to %s last year",
+				"The offsite this spring takes place near %s",
+			},
+			LangTurkish: {
+				"%s tarafında yaşıyorum, deniz kenarında güzel bir semt",
+				"Geçen hafta %s civarında bir toplantı yaptık",
+			},
+			LangGerman: {
+				"Ich wohne seit zwei Jahren in der Nähe von %s",
+				"Unser Büro in %s ist sehr gut erreichbar",
+			},
+		},
+	},
+	{
+		name:     "hobbies",
+		keywords: []string{"Fenerbahçe", "football", "chess", "cycling", "Schnitzel"},
+		templates: map[string][]string{
+			LangEnglish: {
+				"Every weekend I spend some time on %s",
+				"My favourite topic outside work is %s",
+			},
+			LangTurkish: {
+				"Benim favori hobim %s ve her hafta zaman ayırırım",
+				"Her hafta sonu %s ile ilgileniyorum",
+			},
+			LangGerman: {
+				"Mein Lieblingsthema in der Freizeit ist %s",
+				"Am Wochenende beschäftige ich mich gern mit %s",
+			},
+		},
+	},
+	{
+		name:     "learning",
+		keywords: []string{"Deutsch", "Go", "Rust", "statistics", "machine learning"},
+		templates: map[string][]string{
+			LangEnglish: {
+				"I am currently learning %s and find it very interesting",
+				"The next workshop will cover %s basics",
+			},
+			LangTurkish: {
+				"Şu anda %s öğreniyorum ve çok ilginç buluyorum",
+				"Gelecek ay %s eğitimine katılacağım",
+			},
+			LangGerman: {
+				"Ich lerne gerade %s und finde es sehr interessant",
+				"Der nächste Kurs behandelt die Grundlagen von %s",
+			},
+		},
+	},
+}
+
+// details vary otherwise identical sentences, by language.
+var details = map[string][]string{
+	LangEnglish: {"", "since last week", "for the next release", "according to the retro", "as discussed on Monday", "for now"},
+	LangTurkish: {"", "geçen haftadan beri", "bir sonraki sürüm için", "toplantıda konuştuğumuz gibi", "şimdilik"},
+	LangGerman:  {"", "seit letzter Woche", "für das nächste Release", "wie am Montag besprochen", "vorerst"},
+}
@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/qubicDB/qubicdb/pkg/core"
+)
+
+// instrumentationName identifies QubicDB's spans to the tracer provider.
+const instrumentationName = "github.com/qubicDB/qubicdb"
+
+// Span attribute keys.
+const (
+	AttrOperation = attribute.Key("qubicdb.operation")
+	AttrIndexID   = attribute.Key("qubicdb.index.id")
+	AttrIndexHash = attribute.Key("qubicdb.index.hash")
+)
+
+var (
+	// enabled is false until a tracer provider is installed, so every
+	// instrumentation point reduces to one atomic load.
+	enabled    atomic.Bool
+	indexMode  atomic.Value // string
+	propagator = propagation.TraceContext{}
+	noopSpan   = trace.SpanFromContext(context.Background())
+)
+
+func init() {
+	indexMode.Store(core.TraceIndexHash)
+}
+
+// Setup installs an OTLP/HTTP exporter for cfg. With no endpoint configured
+// tracing stays disabled. The returned function flushes and stops the
+// exporter.
+func Setup(ctx context.Context, cfg core.TelemetryConfig) (func(context.Context) error, error) {
+	SetIndexAttribute(cfg.IndexAttribute)
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+	SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// SetTracerProvider installs tp as the source of QubicDB spans and enables
+// instrumentation. A nil tp disables it again.
+func SetTracerProvider(tp trace.TracerProvider) {
+	if tp == nil {
+		enabled.Store(false)
+		return
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+	enabled.Store(true)
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// SetIndexAttribute selects how index IDs are attached to spans: raw, hash
+// or omit. Unknown values fall back to hash.
+func SetIndexAttribute(mode string) {
+	switch mode {
+	case core.TraceIndexRaw, core.TraceIndexOmit:
+	default:
+		mode = core.TraceIndexHash
+	}
+	indexMode.Store(mode)
+}
+
+// IndexAttributes returns the span attributes identifying indexID under the
+// configured mode.
+func IndexAttributes(indexID core.IndexID) []attribute.KeyValue {
+	switch indexMode.Load().(string) {
+	case core.TraceIndexRaw:
+		return []attribute.KeyValue{AttrIndexID.String(string(indexID))}
+	case core.TraceIndexOmit:
+		return nil
+	default:
+		sum := sha256.Sum256([]byte(indexID))
+		return []attribute.KeyValue{AttrIndexHash.String(hex.EncodeToString(sum[:6]))}
+	}
+}
+
+// Start begins a span named name as a child of any span in ctx. When tracing
+// is disabled it returns ctx unchanged and a no-op span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return ctx, noopSpan
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartForIndex is Start with the attributes identifying indexID added.
+func StartForIndex(ctx context.Context, name string, indexID core.IndexID, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return ctx, noopSpan
+	}
+	return Start(ctx, name, append(IndexAttributes(indexID), attrs...)...)
+}
+
+// StartRequest begins the server span for r, continuing the trace named by
+// an incoming traceparent header if there is one. route is the low
+// cardinality path template used in the span name.
+func StartRequest(r *http.Request, route string) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return r.Context(), noopSpan
+	}
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return otel.Tracer(instrumentationName).Start(ctx, r.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+		),
+	)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,454 @@
+// Package vault reads a folder of Markdown notes, such as an Obsidian
+// vault, into notes and the links between them. [[wikilinks]] and relative
+// Markdown links to other notes of the vault are resolved to those notes;
+// links to files outside it are reported as unresolved.
+package vault
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options controls how a vault is read.
+type Options struct {
+	// SplitHeadings makes every heading section of a file its own note.
+	// Text before the first heading, if any, stays a note of its own.
+	SplitHeadings bool
+
+	// MaxFiles bounds the Markdown files read; 0 means no bound.
+	MaxFiles int
+
+	// MaxFileBytes skips larger files; 0 means no bound.
+	MaxFileBytes int64
+}
+
+// ErrTooManyFiles is returned when a vault holds more than MaxFiles notes.
+var ErrTooManyFiles = errors.New("vault holds too many markdown files")
+
+// Note is one imported file, or one heading section of it.
+type Note struct {
+	Path    string   // slash-separated, relative to the vault root
+	Section string   // heading of the section; "" for a whole file or the text before its first heading
+	Title   string   // frontmatter title, else the first # heading, else the file name
+	Tags    []string // frontmatter tags
+	Content string
+	Links   []string // keys of the notes this one links to, deduplicated
+}
+
+// Key identifies the note across imports: its path, plus "#" and the
+// section heading for a section.
+func (n Note) Key() string {
+	if n.Section == "" {
+		return n.Path
+	}
+	return n.Path + "#" + n.Section
+}
+
+// Hash digests everything imported for the note, so a re-import can tell
+// an unchanged note from an edited one.
+func (n Note) Hash() string {
+	h := sha256.New()
+	for _, part := range []string{n.Title, n.Section, strings.Join(n.Tags, "\x1f"), n.Content} {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// UnresolvedLink is a link to a note the vault does not hold.
+type UnresolvedLink struct {
+	From   string `json:"from"`   // path of the linking file
+	Target string `json:"target"` // the link as written
+}
+
+// SkippedFile is a Markdown file that was not read.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Vault is the result of Read.
+type Vault struct {
+	Notes      []Note
+	Unresolved []UnresolvedLink
+	Skipped    []SkippedFile
+}
+
+var (
+	wikiLinkRe     = regexp.MustCompile(`!?\[\[([^\[\]]+)\]\]`)
+	markdownLinkRe = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	headingRe      = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	schemeRe       = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// file is a parsed Markdown file before its links are resolved.
+type file struct {
+	path     string
+	title    string
+	tags     []string
+	sections []section
+}
+
+// section is a run of a file's lines and the links written in it.
+type section struct {
+	heading string
+	content string
+	links   []string
+}
+
+// Read walks fsys for .md and .markdown files, skipping hidden files and
+// directories such as .obsidian, and returns their notes in path order.
+func Read(fsys fs.FS, opts Options) (*Vault, error) {
+	v := &Vault{Unresolved: []UnresolvedLink{}, Skipped: []SkippedFile{}}
+	var files []*file
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !isMarkdown(p) {
+			return nil
+		}
+		if opts.MaxFiles > 0 && len(files) >= opts.MaxFiles {
+			return fmt.Errorf("%w (more than %d)", ErrTooManyFiles, opts.MaxFiles)
+		}
+		data, reason, err := readFile(fsys, p, opts.MaxFileBytes)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			v.Skipped = append(v.Skipped, SkippedFile{Path: p, Reason: reason})
+			return nil
+		}
+		f, err := parseFile(p, data, opts.SplitHeadings)
+		if err != nil {
+			v.Skipped = append(v.Skipped, SkippedFile{Path: p, Reason: err.Error()})
+			return nil
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+
+	r := newResolver(files)
+	for _, f := range files {
+		for _, s := range f.sections {
+			note := Note{Path: f.path, Section: s.heading, Title: f.title, Tags: f.tags, Content: s.content}
+			seen := make(map[string]bool)
+			for _, link := range s.links {
+				target, ok := r.resolve(f.path, link)
+				if !ok {
+					v.Unresolved = append(v.Unresolved, UnresolvedLink{From: f.path, Target: link})
+					continue
+				}
+				if target == note.Key() || seen[target] {
+					continue
+				}
+				seen[target] = true
+				note.Links = append(note.Links, target)
+			}
+			v.Notes = append(v.Notes, note)
+		}
+	}
+	return v, nil
+}
+
+func isMarkdown(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// isAttachment reports whether a wikilink names a file other than a note,
+// such as "diagram.png". A dot in a note name, as in "v1.2 plan", does not
+// make an extension.
+func isAttachment(name string) bool {
+	ext := path.Ext(name)
+	return ext != "" && !isMarkdown(name) && len(ext) <= 6 && !strings.ContainsAny(ext, " ")
+}
+
+// readFile reads p, or returns why it was skipped.
+func readFile(fsys fs.FS, p string, maxBytes int64) ([]byte, string, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if maxBytes > 0 {
+		r = io.LimitReader(f, maxBytes+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, fmt.Sprintf("larger than %d bytes", maxBytes), nil
+	}
+	return data, "", nil
+}
+
+// parseFile splits a file into its frontmatter and sections.
+func parseFile(p string, data []byte, split bool) (*file, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	f := &file{path: p}
+
+	body, front, err := splitFrontmatter(text)
+	if err != nil {
+		return nil, err
+	}
+	f.title = frontString(front["title"])
+	f.tags = frontTags(front["tags"])
+
+	var cur *section
+	var lines []string
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		cur.content = strings.TrimSpace(strings.Join(lines, "\n"))
+		f.sections = append(f.sections, *cur)
+	}
+	cur = &section{}
+	headings := make(map[string]int)
+	fenced := false
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+		}
+		if !fenced {
+			if m := headingRe.FindStringSubmatch(line); m != nil {
+				if f.title == "" && len(m[1]) == 1 {
+					f.title = m[2]
+				}
+				if split {
+					flush()
+					heading := m[2]
+					if headings[heading]++; headings[heading] > 1 {
+						heading = fmt.Sprintf("%s (%d)", heading, headings[heading])
+					}
+					cur, lines = &section{heading: heading}, nil
+				}
+			}
+			cur.links = append(cur.links, extractLinks(line)...)
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	if f.title == "" {
+		f.title = strings.TrimSuffix(path.Base(p), path.Ext(p))
+	}
+	// Drop empty sections, but keep one note per file so links to it
+	// resolve.
+	kept := f.sections[:0]
+	for _, s := range f.sections {
+		if s.content != "" {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		kept = append(kept, section{content: f.title})
+	}
+	f.sections = kept
+	return f, nil
+}
+
+// splitFrontmatter separates a leading YAML frontmatter block, closed by
+// a "---" or "..." line. An unterminated block is left in the body.
+func splitFrontmatter(text string) (string, map[string]any, error) {
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		return text, nil, nil
+	}
+	var block []string
+	for {
+		line, after, found := strings.Cut(rest, "\n")
+		if line == "---" || line == "..." {
+			front := map[string]any{}
+			if err := yaml.Unmarshal([]byte(strings.Join(block, "\n")), &front); err != nil {
+				return "", nil, fmt.Errorf("invalid frontmatter: %v", err)
+			}
+			return after, front, nil
+		}
+		if !found {
+			return text, nil, nil
+		}
+		block = append(block, line)
+		rest = after
+	}
+}
+
+func frontString(v any) string {
+	if s, ok := v.(string); ok {
+		return strings.TrimSpace(s)
+	}
+	return ""
+}
+
+// frontTags accepts tags as a list or a comma- or space-separated string,
+// with or without leading #.
+func frontTags(v any) []string {
+	var raw []string
+	switch t := v.(type) {
+	case string:
+		raw = strings.FieldsFunc(t, func(r rune) bool { return r == ',' || r == ' ' })
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range raw {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// extractLinks returns the note links written on line: wikilinks as
+// "[[target]]" and relative Markdown links as their target. Links to URLs
+// and to files other than notes, such as images, are ignored.
+func extractLinks(line string) []string {
+	var links []string
+	for _, m := range wikiLinkRe.FindAllStringSubmatch(line, -1) {
+		target, _, _ := strings.Cut(m[1], "|")
+		target = strings.TrimSpace(target)
+		name, _, _ := strings.Cut(target, "#")
+		if name == "" || isAttachment(name) {
+			continue
+		}
+		links = append(links, "[["+target+"]]")
+	}
+	for _, m := range markdownLinkRe.FindAllStringSubmatch(line, -1) {
+		target := m[1]
+		if schemeRe.MatchString(target) || strings.HasPrefix(target, "#") {
+			continue
+		}
+		name, _, _ := strings.Cut(target, "#")
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !isMarkdown(name) {
+			continue
+		}
+		links = append(links, target)
+	}
+	return links
+}
+
+// resolver maps links to note keys.
+type resolver struct {
+	byPath map[string]*file   // lower-cased path
+	byName map[string][]*file // lower-cased base name without extension
+}
+
+func newResolver(files []*file) *resolver {
+	r := &resolver{byPath: make(map[string]*file), byName: make(map[string][]*file)}
+	for _, f := range files {
+		r.byPath[strings.ToLower(f.path)] = f
+		name := strings.ToLower(strings.TrimSuffix(path.Base(f.path), path.Ext(f.path)))
+		r.byName[name] = append(r.byName[name], f)
+	}
+	return r
+}
+
+// resolve returns the key of the note link points to from the file at
+// from.
+func (r *resolver) resolve(from, link string) (string, bool) {
+	var target *file
+	var anchor string
+	if strings.HasPrefix(link, "[[") {
+		name, heading, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(link, "[["), "]]"), "#")
+		anchor = heading
+		target = r.wikiTarget(from, name)
+	} else {
+		name, heading, _ := strings.Cut(link, "#")
+		anchor = heading
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		if strings.HasPrefix(name, "/") {
+			name = strings.TrimPrefix(name, "/")
+		} else {
+			name = path.Join(path.Dir(from), name)
+		}
+		target = r.byPath[strings.ToLower(path.Clean(name))]
+	}
+	if target == nil {
+		return "", false
+	}
+	return noteKey(target, anchor), true
+}
+
+// wikiTarget resolves an Obsidian-style link name: a path from the vault
+// root, or a file name matched anywhere, preferring the linking file's
+// folder and then the shortest path.
+func (r *resolver) wikiTarget(from, name string) *file {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if isMarkdown(name) {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	for _, ext := range []string{".md", ".markdown"} {
+		if f := r.byPath[name+ext]; f != nil {
+			return f
+		}
+	}
+	if strings.Contains(name, "/") {
+		return nil
+	}
+	candidates := r.byName[name]
+	var best *file
+	for _, f := range candidates {
+		switch {
+		case best == nil:
+			best = f
+		case path.Dir(f.path) == path.Dir(from) && path.Dir(best.path) != path.Dir(from):
+			best = f
+		case (path.Dir(f.path) == path.Dir(from)) == (path.Dir(best.path) == path.Dir(from)) && len(f.path) < len(best.path):
+			best = f
+		}
+	}
+	return best
+}
+
+// noteKey returns the key of the section of f headed anchor, or of f's
+// first note.
+func noteKey(f *file, anchor string) string {
+	if anchor != "" {
+		for _, s := range f.sections {
+			if strings.EqualFold(s.heading, strings.TrimSpace(anchor)) {
+				return Note{Path: f.path, Section: s.heading}.Key()
+			}
+		}
+	}
+	return Note{Path: f.path, Section: f.sections[0].heading}.Key()
+}
@@ -0,0 +1,98 @@
+package vault
+
+import (
+	"maps"
+	"os"
+	"slices"
+	"testing"
+)
+
+func readFixture(t *testing.T, opts Options) *Vault {
+	t.Helper()
+	v, err := Read(os.DirFS("testdata/vault"), opts)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return v
+}
+
+func notesByKey(v *Vault) map[string]Note {
+	notes := make(map[string]Note, len(v.Notes))
+	for _, n := range v.Notes {
+		notes[n.Key()] = n
+	}
+	return notes
+}
+
+func TestRead_FrontmatterNestedFoldersAndLinks(t *testing.T) {
+	v := readFixture(t, Options{})
+	notes := notesByKey(v)
+	if len(notes) != 5 {
+		t.Fatalf("expected 5 notes, hidden folders skipped, got %v", slices.Sorted(maps.Keys(notes)))
+	}
+
+	for key, want := range map[string]struct {
+		title string
+		tags  []string
+	}{
+		"Home.md":           {"Home", []string{"index", "pkm"}},
+		"Projects/Alpha.md": {"Alpha project", nil},
+		"Projects/Beta.md":  {"Beta", []string{"work", "planning"}},
+		"Reading List.md":   {"Reading List", nil},
+		"garden/Plants.md":  {"Plants", nil},
+	} {
+		n := notes[key]
+		if n.Title != want.title || !slices.Equal(n.Tags, want.tags) {
+			t.Errorf("%s: expected title %q tags %v, got %q %v", key, want.title, want.tags, n.Title, n.Tags)
+		}
+	}
+	if home := notes["Home.md"]; home.Content[:7] != "Welcome" {
+		t.Errorf("frontmatter should be stripped from content, got %q", home.Content)
+	}
+
+	if got := notes["Home.md"].Links; !slices.Equal(got, []string{"Projects/Alpha.md", "Reading List.md", "garden/Plants.md"}) {
+		t.Errorf("unexpected links from Home: %v", got)
+	}
+	if got := notes["Projects/Beta.md"].Links; !slices.Equal(got, []string{"Projects/Alpha.md"}) {
+		t.Errorf("a bare name should resolve case-insensitively and code blocks be ignored, got %v", got)
+	}
+	want := []UnresolvedLink{{From: "Home.md", Target: "[[Missing Note]]"}, {From: "Reading List.md", Target: "../outside.md"}}
+	if !slices.Equal(v.Unresolved, want) {
+		t.Errorf("expected unresolved %v, got %v", want, v.Unresolved)
+	}
+}
+
+func TestRead_SplitHeadings(t *testing.T) {
+	notes := notesByKey(readFixture(t, Options{SplitHeadings: true}))
+
+	for _, key := range []string{"Projects/Alpha.md#Alpha project", "Projects/Alpha.md#Risks", "Projects/Alpha.md#Timeline", "Projects/Beta.md#Timeline", "Home.md"} {
+		if _, ok := notes[key]; !ok {
+			t.Errorf("expected a note %q", key)
+		}
+	}
+	if n := notes["Projects/Beta.md#Timeline"]; n.Title != "Beta" || n.Content[:11] != "## Timeline" {
+		t.Errorf("a section keeps its file's title and its heading, got %q %q", n.Title, n.Content)
+	}
+	if _, ok := notes["Projects/Beta.md#not a heading"]; ok {
+		t.Error("a heading inside a code block must not split")
+	}
+	if got := notes["Projects/Alpha.md#Risks"].Links; !slices.Equal(got, []string{"Projects/Beta.md#Timeline"}) {
+		t.Errorf("a heading anchor should link to that section, got %v", got)
+	}
+	if got := notes["Projects/Beta.md#Timeline"].Links; !slices.Equal(got, []string{"Projects/Alpha.md#Alpha project"}) {
+		t.Errorf("a file link should target the file's first section, got %v", got)
+	}
+}
+
+func TestRead_Limits(t *testing.T) {
+	if _, err := Read(os.DirFS("testdata/vault"), Options{MaxFiles: 2}); err == nil {
+		t.Error("expected an error past MaxFiles")
+	}
+	v := readFixture(t, Options{MaxFileBytes: 100})
+	if len(v.Skipped) == 0 || len(v.Notes)+len(v.Skipped) != 5 {
+		t.Errorf("files over MaxFileBytes should be skipped and reported, got %d notes %v", len(v.Notes), v.Skipped)
+	}
+	if (Note{Path: "a.md", Content: "x"}).Hash() == (Note{Path: "a.md", Content: "y"}).Hash() {
+		t.Error("the hash should change with the content")
+	}
+}
@@ -0,0 +1,270 @@
+package vector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ProbeText is the fixed text embedded by warm-up and liveness probes.
+const ProbeText = "QubicDB vector layer warm-up probe."
+
+// ErrVectorUnavailable is returned by a HealthMonitor's embedder while the
+// vector layer is failed. Callers fall back to lexical matching.
+var ErrVectorUnavailable = errors.New("vector layer unavailable")
+
+// Vector layer health states.
+const (
+	HealthReady  = "ready"
+	HealthFailed = "failed"
+)
+
+// ProbeResult is the outcome of one probe embedding.
+type ProbeResult struct {
+	OK        bool      `json:"ok"`
+	At        time.Time `json:"at"`
+	LatencyMs float64   `json:"latencyMs"`
+	Dim       int       `json:"dim,omitempty"`
+	// Fingerprint identifies the model by its embedding of ProbeText: the
+	// first 12 hex characters of a SHA-256 over the rounded vector. It
+	// changes when the model file does.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Probe embeds ProbeText with e and reports how it went. A call still
+// running after timeout is reported as failed and left to finish in the
+// background, since llama.cpp calls cannot be interrupted.
+func Probe(ctx context.Context, e Embedder, timeout time.Duration) ProbeResult {
+	type embedResult struct {
+		vec []float32
+		err error
+	}
+	start := time.Now()
+	done := make(chan embedResult, 1)
+	go func() {
+		vec, err := e.EmbedTextContext(ctx, ProbeText)
+		done <- embedResult{vec, err}
+	}()
+
+	res := ProbeResult{At: start}
+	select {
+	case r := <-done:
+		res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		switch {
+		case r.err != nil:
+			res.Error = r.err.Error()
+		case len(r.vec) == 0 || len(r.vec) != e.EmbedDim():
+			res.Error = fmt.Sprintf("probe returned %d dimensions, model reports %d", len(r.vec), e.EmbedDim())
+		default:
+			res.OK = true
+			res.Dim = len(r.vec)
+			res.Fingerprint = fingerprint(r.vec)
+		}
+	case <-time.After(timeout):
+		res.LatencyMs = float64(timeout.Microseconds()) / 1000
+		res.Error = fmt.Sprintf("probe timed out after %s", timeout)
+	}
+	return res
+}
+
+func fingerprint(vec []float32) string {
+	Normalize(vec)
+	h := sha256.New()
+	var buf [4]byte
+	for _, v := range vec {
+		// Rounded so nondeterministic low bits do not change the print.
+		binary.LittleEndian.PutUint32(buf[:], uint32(int32(math.Round(float64(v)*1e4))))
+		h.Write(buf[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// HealthStatus is a snapshot of a HealthMonitor.
+type HealthStatus struct {
+	State string `json:"state"` // ready | failed
+	// Warmup is the probe run at startup; readiness depends on it.
+	Warmup *ProbeResult `json:"warmup,omitempty"`
+	// Last is the most recent liveness probe, nil until one has run.
+	Last          *ProbeResult `json:"last,omitempty"`
+	ProbeInterval string       `json:"probeInterval,omitempty"`
+	Failures      uint64       `json:"failures"` // failed probes so far
+	// Breaker is the circuit breaker around the same embedder, when the
+	// caller wraps it in a ResilientEmbedder.
+	Breaker *BreakerStatus `json:"breaker,omitempty"`
+}
+
+// HealthMonitor warms up an embedder, optionally probes it periodically,
+// and guards it: while the last probe failed, its Embedder fails fast with
+// ErrVectorUnavailable instead of calling a wedged backend.
+type HealthMonitor struct {
+	embedder Embedder
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	warmup   *ProbeResult
+	last     *ProbeResult
+	failures uint64
+	probing  bool // a probe is still running
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewHealthMonitor returns a monitor for e whose probes time out after
+// timeout. It reports ready until a probe fails.
+func NewHealthMonitor(e Embedder, timeout time.Duration) *HealthMonitor {
+	return &HealthMonitor{embedder: e, timeout: timeout, state: HealthReady}
+}
+
+// Warmup embeds ProbeText once so the first real request does not pay for
+// context initialization, and records the result as the readiness probe.
+func (m *HealthMonitor) Warmup(ctx context.Context) ProbeResult {
+	res := m.probe(ctx)
+	m.mu.Lock()
+	m.warmup = &res
+	m.mu.Unlock()
+	return res
+}
+
+// probe runs one probe and updates the state. It does not start a probe
+// while the previous one is still running; that counts as a failure.
+func (m *HealthMonitor) probe(ctx context.Context) ProbeResult {
+	m.mu.Lock()
+	if m.probing {
+		res := ProbeResult{At: time.Now(), Error: "previous probe has not returned"}
+		m.recordLocked(res)
+		m.mu.Unlock()
+		return res
+	}
+	m.probing = true
+	m.mu.Unlock()
+
+	guard := &probeGuard{Embedder: m.embedder, done: func() {
+		m.mu.Lock()
+		m.probing = false
+		m.mu.Unlock()
+	}}
+	res := Probe(ctx, guard, m.timeout)
+
+	m.mu.Lock()
+	m.recordLocked(res)
+	m.mu.Unlock()
+	return res
+}
+
+func (m *HealthMonitor) recordLocked(res ProbeResult) {
+	m.last = &res
+	if res.OK {
+		m.state = HealthReady
+		return
+	}
+	m.state = HealthFailed
+	m.failures++
+}
+
+// probeGuard calls done when the wrapped embedding returns, even after
+// Probe has given up on it.
+type probeGuard struct {
+	Embedder
+	done func()
+}
+
+func (g *probeGuard) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	defer g.done()
+	return g.Embedder.EmbedTextContext(ctx, text)
+}
+
+// Start probes the embedder every interval until Stop. A failed probe
+// marks the layer failed; the next successful one makes it ready again.
+func (m *HealthMonitor) Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	m.mu.Lock()
+	if m.stop != nil {
+		m.mu.Unlock()
+		return
+	}
+	m.interval = interval
+	m.stop = make(chan struct{})
+	stop := m.stop
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.probe(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop ends periodic probing.
+func (m *HealthMonitor) Stop() {
+	m.mu.Lock()
+	stop := m.stop
+	m.stop = nil
+	m.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		m.wg.Wait()
+	}
+}
+
+// Ready reports whether the warm-up probe succeeded. A monitor that has
+// not warmed up is not ready.
+func (m *HealthMonitor) Ready() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.warmup != nil && m.warmup.OK
+}
+
+// Status returns a snapshot of the monitor.
+func (m *HealthMonitor) Status() HealthStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := HealthStatus{State: m.state, Warmup: m.warmup, Failures: m.failures}
+	if m.last != m.warmup {
+		status.Last = m.last
+	}
+	if m.interval > 0 {
+		status.ProbeInterval = m.interval.String()
+	}
+	return status
+}
+
+// Embedder returns the monitored embedder, failing fast with
+// ErrVectorUnavailable while the vector layer is failed.
+func (m *HealthMonitor) Embedder() Embedder {
+	return &guardedEmbedder{Embedder: m.embedder, monitor: m}
+}
+
+type guardedEmbedder struct {
+	Embedder
+	monitor *HealthMonitor
+}
+
+func (g *guardedEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	g.monitor.mu.Lock()
+	failed := g.monitor.state == HealthFailed
+	g.monitor.mu.Unlock()
+	if failed {
+		return nil, ErrVectorUnavailable
+	}
+	return g.Embedder.EmbedTextContext(ctx, text)
+}
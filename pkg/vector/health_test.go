@@ -0,0 +1,105 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// probeEmbedder is a fake embedder with injectable latency and errors.
+type probeEmbedder struct {
+	dim   int
+	delay atomic.Int64 // nanoseconds each embedding takes
+	fail  atomic.Bool
+}
+
+func (p *probeEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	time.Sleep(time.Duration(p.delay.Load()))
+	if p.fail.Load() {
+		return nil, errors.New("backend error")
+	}
+	out := make([]float32, p.dim)
+	for i := range out {
+		out[i] = float32(len(text)+i) / 100
+	}
+	return out, nil
+}
+
+func (p *probeEmbedder) EmbedDim() int { return p.dim }
+func (p *probeEmbedder) Close() error  { return nil }
+
+func TestHealthMonitor_WarmupGatesReadiness(t *testing.T) {
+	e := &probeEmbedder{dim: 8}
+	m := NewHealthMonitor(e, time.Second)
+	if m.Ready() {
+		t.Fatal("a monitor that has not warmed up should not be ready")
+	}
+
+	res := m.Warmup(context.Background())
+	if !res.OK || res.Dim != 8 || len(res.Fingerprint) != 12 {
+		t.Fatalf("unexpected warm-up result: %+v", res)
+	}
+	if !m.Ready() || m.Status().State != HealthReady {
+		t.Errorf("a successful warm-up should be ready, got %+v", m.Status())
+	}
+	if again := Probe(context.Background(), e, time.Second); again.Fingerprint != res.Fingerprint {
+		t.Errorf("the fingerprint should be stable, got %s and %s", res.Fingerprint, again.Fingerprint)
+	}
+
+	failing := &probeEmbedder{dim: 8}
+	failing.fail.Store(true)
+	m = NewHealthMonitor(failing, time.Second)
+	if res := m.Warmup(context.Background()); res.OK || res.Error == "" {
+		t.Errorf("expected a failed warm-up, got %+v", res)
+	}
+	if m.Ready() {
+		t.Error("a failed warm-up must fail readiness")
+	}
+}
+
+func TestHealthMonitor_WedgedBackendDegrades(t *testing.T) {
+	e := &probeEmbedder{dim: 4}
+	m := NewHealthMonitor(e, 20*time.Millisecond)
+	m.Warmup(context.Background())
+	guarded := m.Embedder()
+	if _, err := guarded.EmbedTextContext(context.Background(), "hello"); err != nil {
+		t.Fatalf("a ready layer should embed: %v", err)
+	}
+
+	e.delay.Store(int64(200 * time.Millisecond))
+	m.Start(10 * time.Millisecond)
+	defer m.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Status().State != HealthFailed {
+		if time.Now().After(deadline) {
+			t.Fatal("a probe exceeding its timeout should mark the layer failed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := guarded.EmbedTextContext(context.Background(), "hello"); !errors.Is(err, ErrVectorUnavailable) {
+		t.Errorf("a failed layer should fail fast with ErrVectorUnavailable, got %v", err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("a failed layer must not wait on the backend")
+	}
+	if !m.Ready() {
+		t.Error("readiness reflects the warm-up; liveness failures only degrade")
+	}
+
+	e.delay.Store(0)
+	deadline = time.Now().Add(2 * time.Second)
+	for m.Status().State != HealthReady {
+		if time.Now().After(deadline) {
+			t.Fatal("a successful probe should make the layer ready again")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := m.Status(); status.Failures == 0 || status.Last == nil || status.ProbeInterval != "10ms" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
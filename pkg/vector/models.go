@@ -0,0 +1,332 @@
+package vector
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownModel is returned for a model name that is not configured.
+var ErrUnknownModel = errors.New("unknown embedding model")
+
+// ModelSpec describes a named embedding model that can be loaded on demand.
+type ModelSpec struct {
+	Name      string
+	Path      string
+	GPULayers int
+}
+
+// ModelLoader loads the model described by spec.
+type ModelLoader func(spec ModelSpec) (Embedder, error)
+
+// VectorizerLoader returns a ModelLoader that loads GGUF models with
+// NewVectorizer.
+func VectorizerLoader(embedContextSize uint32) ModelLoader {
+	return func(spec ModelSpec) (Embedder, error) {
+		return NewVectorizer(spec.Path, spec.GPULayers, embedContextSize)
+	}
+}
+
+// MemoryEstimator is implemented by embedders that can report their
+// approximate resident size. Models that do not are estimated by the size
+// of their model file.
+type MemoryEstimator interface {
+	MemoryEstimate() int64
+}
+
+// ModelStatus reports the state of one configured model.
+type ModelStatus struct {
+	Name        string
+	Path        string
+	GPULayers   int
+	Loaded      bool
+	InUse       int // embeddings currently running
+	Dim         int
+	MemoryBytes int64
+	LoadedAt    time.Time
+	LastUsedAt  time.Time
+}
+
+// ModelRegistry lazily loads named embedding models and keeps at most
+// maxLoaded of them resident, unloading the least recently used one when
+// another has to be loaded. A model is only closed once no embedding is
+// running on it; it is loaded again the next time it is used.
+type ModelRegistry struct {
+	mu        sync.Mutex
+	specs     map[string]ModelSpec
+	maxLoaded int
+	loader    ModelLoader
+
+	loaded  map[string]*loadedModel
+	lru     *list.List // of *loadedModel, most recently used first
+	loading map[string]chan struct{}
+
+	loads     uint64
+	evictions uint64
+
+	// resilience wraps handles in a ResilientEmbedder per model when set.
+	resilience *ResiliencePolicy
+	breakers   map[string]*ResilientEmbedder
+}
+
+type loadedModel struct {
+	spec     ModelSpec
+	embedder Embedder
+	elem     *list.Element
+	refs     int
+	evicted  bool
+	dim      int
+	memory   int64
+	loadedAt time.Time
+	lastUsed time.Time
+}
+
+// NewModelRegistry creates a registry for specs. maxLoaded < 1 means
+// models are never unloaded.
+func NewModelRegistry(specs []ModelSpec, maxLoaded int, loader ModelLoader) *ModelRegistry {
+	r := &ModelRegistry{
+		specs:     make(map[string]ModelSpec, len(specs)),
+		maxLoaded: maxLoaded,
+		loader:    loader,
+		loaded:    make(map[string]*loadedModel),
+		lru:       list.New(),
+		loading:   make(map[string]chan struct{}),
+		breakers:  make(map[string]*ResilientEmbedder),
+	}
+	for _, spec := range specs {
+		r.specs[spec.Name] = spec
+	}
+	return r
+}
+
+// Has reports whether name is a configured model.
+func (r *ModelRegistry) Has(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.specs[name]
+	return ok
+}
+
+// Handle returns an Embedder for the named model that loads it on demand.
+// The handle stays valid across evictions; closing it is a no-op.
+func (r *ModelRegistry) Handle(name string) Embedder {
+	handle := &modelHandle{registry: r, name: name}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.resilience == nil {
+		return handle
+	}
+	breaker, ok := r.breakers[name]
+	if !ok {
+		breaker = NewResilientEmbedder(handle, *r.resilience)
+		r.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// SetResilience makes handles retry failed calls and share one circuit
+// breaker per model. Call it before handing out handles.
+func (r *ModelRegistry) SetResilience(policy ResiliencePolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resilience = &policy
+}
+
+// Breaker returns the breaker status of the named model, or false when it
+// has none yet.
+func (r *ModelRegistry) Breaker(name string) (BreakerStatus, bool) {
+	r.mu.Lock()
+	breaker, ok := r.breakers[name]
+	r.mu.Unlock()
+	if !ok {
+		return BreakerStatus{}, false
+	}
+	return breaker.Status(), true
+}
+
+// acquire returns the loaded model name, loading it (and unloading the
+// least recently used model) if needed. Callers must release it.
+func (r *ModelRegistry) acquire(name string) (*loadedModel, error) {
+	r.mu.Lock()
+	for {
+		if m, ok := r.loaded[name]; ok {
+			m.refs++
+			m.lastUsed = time.Now()
+			r.lru.MoveToFront(m.elem)
+			r.mu.Unlock()
+			return m, nil
+		}
+		wait, busy := r.loading[name]
+		if !busy {
+			break
+		}
+		r.mu.Unlock()
+		<-wait
+		r.mu.Lock()
+	}
+
+	spec, ok := r.specs[name]
+	if !ok {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	done := make(chan struct{})
+	r.loading[name] = done
+	r.mu.Unlock()
+
+	embedder, err := r.loader(spec)
+
+	r.mu.Lock()
+	delete(r.loading, name)
+	close(done)
+	if err != nil {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("load model %s: %w", name, err)
+	}
+
+	now := time.Now()
+	m := &loadedModel{
+		spec:     spec,
+		embedder: embedder,
+		refs:     1,
+		dim:      embedder.EmbedDim(),
+		memory:   EstimateMemory(embedder, spec.Path),
+		loadedAt: now,
+		lastUsed: now,
+	}
+	m.elem = r.lru.PushFront(m)
+	r.loaded[name] = m
+	r.loads++
+	unused := r.evictLocked()
+	r.mu.Unlock()
+
+	for _, e := range unused {
+		e.embedder.Close()
+	}
+	return m, nil
+}
+
+// evictLocked unloads least recently used models until at most maxLoaded
+// remain and returns the ones nobody is using, for the caller to close
+// after unlocking. Models still in use are closed by their last release.
+func (r *ModelRegistry) evictLocked() []*loadedModel {
+	var unused []*loadedModel
+	for r.maxLoaded > 0 && r.lru.Len() > r.maxLoaded {
+		m := r.lru.Remove(r.lru.Back()).(*loadedModel)
+		delete(r.loaded, m.spec.Name)
+		m.evicted = true
+		r.evictions++
+		if m.refs == 0 {
+			unused = append(unused, m)
+		}
+	}
+	return unused
+}
+
+func (r *ModelRegistry) release(m *loadedModel) {
+	r.mu.Lock()
+	m.refs--
+	closeNow := m.evicted && m.refs == 0
+	r.mu.Unlock()
+	if closeNow {
+		m.embedder.Close()
+	}
+}
+
+// Models lists every configured model, ordered by name.
+func (r *ModelRegistry) Models() []ModelStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]ModelStatus, 0, len(r.specs))
+	for _, spec := range r.specs {
+		status := ModelStatus{Name: spec.Name, Path: spec.Path, GPULayers: spec.GPULayers}
+		if m, ok := r.loaded[spec.Name]; ok {
+			status.Loaded = true
+			status.InUse = m.refs
+			status.Dim = m.dim
+			status.MemoryBytes = m.memory
+			status.LoadedAt = m.loadedAt
+			status.LastUsedAt = m.lastUsed
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Stats returns how many models have been loaded and unloaded so far.
+func (r *ModelRegistry) Stats() (loads, evictions uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loads, r.evictions
+}
+
+// MaxLoaded returns the resident model bound.
+func (r *ModelRegistry) MaxLoaded() int {
+	return r.maxLoaded
+}
+
+// Close unloads every model. Embeddings still running keep their model
+// until they finish.
+func (r *ModelRegistry) Close() error {
+	r.mu.Lock()
+	var unused []*loadedModel
+	for r.lru.Len() > 0 {
+		m := r.lru.Remove(r.lru.Back()).(*loadedModel)
+		delete(r.loaded, m.spec.Name)
+		m.evicted = true
+		if m.refs == 0 {
+			unused = append(unused, m)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, m := range unused {
+		m.embedder.Close()
+	}
+	return nil
+}
+
+// EstimateMemory returns e's approximate resident size: its own estimate
+// if it reports one, otherwise the size of the model file at path.
+func EstimateMemory(e Embedder, path string) int64 {
+	if est, ok := e.(MemoryEstimator); ok {
+		return est.MemoryEstimate()
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// modelHandle is the Embedder returned by ModelRegistry.Handle.
+type modelHandle struct {
+	registry *ModelRegistry
+	name     string
+}
+
+func (h *modelHandle) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	m, err := h.registry.acquire(h.name)
+	if err != nil {
+		return nil, err
+	}
+	defer h.registry.release(m)
+	return m.embedder.EmbedTextContext(ctx, text)
+}
+
+func (h *modelHandle) EmbedDim() int {
+	m, err := h.registry.acquire(h.name)
+	if err != nil {
+		return 0
+	}
+	defer h.registry.release(m)
+	return m.dim
+}
+
+func (h *modelHandle) Close() error { return nil }
@@ -0,0 +1,192 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeEmbedder returns a fixed vector and records whether it was closed.
+type fakeEmbedder struct {
+	dim    int
+	closed atomic.Bool
+	block  chan struct{} // when set, embedding waits for it to close
+}
+
+func (f *fakeEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	if f.closed.Load() {
+		return nil, errors.New("embed on closed model")
+	}
+	out := make([]float32, f.dim)
+	out[0] = 1
+	return out, nil
+}
+
+func (f *fakeEmbedder) EmbedDim() int         { return f.dim }
+func (f *fakeEmbedder) Close() error          { f.closed.Store(true); return nil }
+func (f *fakeEmbedder) MemoryEstimate() int64 { return int64(f.dim) * 1000 }
+
+// fakeLoader loads a fresh fakeEmbedder per call and remembers them all.
+type fakeLoader struct {
+	mu     sync.Mutex
+	loaded map[string][]*fakeEmbedder
+}
+
+func (l *fakeLoader) load(spec ModelSpec) (Embedder, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.loaded == nil {
+		l.loaded = make(map[string][]*fakeEmbedder)
+	}
+	dim := 4
+	if spec.Name == "code" {
+		dim = 8
+	}
+	e := &fakeEmbedder{dim: dim}
+	l.loaded[spec.Name] = append(l.loaded[spec.Name], e)
+	return e, nil
+}
+
+func (l *fakeLoader) loads(name string) []*fakeEmbedder {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loaded[name]
+}
+
+var testSpecs = []ModelSpec{{Name: "code", Path: "code.gguf"}, {Name: "chat", Path: "chat.gguf"}}
+
+func TestModelRegistry_LoadsLazily(t *testing.T) {
+	loader := &fakeLoader{}
+	r := NewModelRegistry(testSpecs, 2, loader.load)
+
+	for _, m := range r.Models() {
+		if m.Loaded {
+			t.Fatalf("%s loaded before first use", m.Name)
+		}
+	}
+
+	emb, err := r.Handle("code").EmbedTextContext(context.Background(), "x")
+	if err != nil || len(emb) != 8 {
+		t.Fatalf("embed: %v, len %d", err, len(emb))
+	}
+	r.Handle("code").EmbedTextContext(context.Background(), "y")
+	if n := len(loader.loads("code")); n != 1 {
+		t.Fatalf("expected one load, got %d", n)
+	}
+	if n := len(loader.loads("chat")); n != 0 {
+		t.Fatalf("chat should not be loaded, got %d loads", n)
+	}
+
+	models := r.Models()
+	if models[0].Name != "chat" || models[1].Name != "code" {
+		t.Fatalf("expected models ordered by name, got %+v", models)
+	}
+	if !models[1].Loaded || models[1].Dim != 8 || models[1].MemoryBytes != 8000 {
+		t.Errorf("unexpected status for code: %+v", models[1])
+	}
+}
+
+func TestModelRegistry_EvictsLeastRecentlyUsed(t *testing.T) {
+	loader := &fakeLoader{}
+	r := NewModelRegistry(testSpecs, 1, loader.load)
+	ctx := context.Background()
+
+	if _, err := r.Handle("code").EmbedTextContext(ctx, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Handle("chat").EmbedTextContext(ctx, "x"); err != nil {
+		t.Fatal(err)
+	}
+	first := loader.loads("code")[0]
+	if !first.closed.Load() {
+		t.Error("evicted model should be closed")
+	}
+
+	emb, err := r.Handle("code").EmbedTextContext(ctx, "x")
+	if err != nil || len(emb) != 8 {
+		t.Fatalf("reload after eviction: %v", err)
+	}
+	if n := len(loader.loads("code")); n != 2 {
+		t.Fatalf("expected code to be loaded again, got %d loads", n)
+	}
+	if loads, evictions := r.Stats(); loads != 3 || evictions != 2 {
+		t.Errorf("expected 3 loads and 2 evictions, got %d and %d", loads, evictions)
+	}
+}
+
+func TestModelRegistry_EvictionWaitsForRunningEmbeds(t *testing.T) {
+	block := make(chan struct{})
+	blocking := &fakeEmbedder{dim: 8, block: block}
+	loader := &fakeLoader{}
+	r := NewModelRegistry(testSpecs, 1, func(spec ModelSpec) (Embedder, error) {
+		if spec.Name == "code" {
+			return blocking, nil
+		}
+		return loader.load(spec)
+	})
+	ctx := context.Background()
+
+	done := make(chan error)
+	go func() {
+		_, err := r.Handle("code").EmbedTextContext(ctx, "slow")
+		done <- err
+	}()
+	for r.Models()[1].InUse == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := r.Handle("chat").EmbedTextContext(ctx, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if blocking.closed.Load() {
+		t.Fatal("model closed while an embedding was running on it")
+	}
+
+	close(block)
+	if err := <-done; err != nil {
+		t.Fatalf("running embedding failed after eviction: %v", err)
+	}
+	if !blocking.closed.Load() {
+		t.Error("evicted model should be closed once its last embedding finishes")
+	}
+}
+
+func TestModelRegistry_UnknownModel(t *testing.T) {
+	r := NewModelRegistry(testSpecs, 1, (&fakeLoader{}).load)
+	if r.Has("nope") {
+		t.Error("Has reported an unconfigured model")
+	}
+	_, err := r.Handle("nope").EmbedTextContext(context.Background(), "x")
+	if !errors.Is(err, ErrUnknownModel) {
+		t.Fatalf("expected ErrUnknownModel, got %v", err)
+	}
+}
+
+func TestModelRegistry_BreakerPerModel(t *testing.T) {
+	r := NewModelRegistry(testSpecs, 2, (&fakeLoader{}).load)
+	r.SetResilience(ResiliencePolicy{Threshold: 1, Cooldown: time.Minute})
+
+	if _, ok := r.Breaker("code"); ok {
+		t.Error("no breaker should exist before a handle is taken")
+	}
+	r.Handle("nope").EmbedTextContext(context.Background(), "x")
+	if status, ok := r.Breaker("nope"); !ok || status.State != BreakerOpen {
+		t.Fatalf("a failing model should open its breaker: %+v", status)
+	}
+	if _, err := r.Handle("nope").EmbedTextContext(context.Background(), "x"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("handles of one model should share its breaker, got %v", err)
+	}
+
+	if _, err := r.Handle("code").EmbedTextContext(context.Background(), "x"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := r.Breaker("code"); status.State != BreakerClosed {
+		t.Errorf("another model's breaker should stay closed: %+v", status)
+	}
+}
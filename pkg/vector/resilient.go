@@ -0,0 +1,217 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a ResilientEmbedder while its breaker
+// rejects calls. It wraps ErrVectorUnavailable, so callers fall back to
+// lexical matching the same way.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit breaker open", ErrVectorUnavailable)
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// Requests that carried on without an embedding, by kind.
+const (
+	FallbackSearch = "search" // scored lexically
+	FallbackWrite  = "write"  // stored and queued for a later embedding
+)
+
+// FallbackRecorder is implemented by embedders that count the requests
+// that carried on without an embedding after a failed call.
+type FallbackRecorder interface {
+	RecordFallback(kind string)
+}
+
+// RecordFallback counts a fallback of kind on e if it keeps count.
+func RecordFallback(e Embedder, kind string) {
+	if r, ok := e.(FallbackRecorder); ok {
+		r.RecordFallback(kind)
+	}
+}
+
+// ResiliencePolicy configures a ResilientEmbedder.
+type ResiliencePolicy struct {
+	// Retries is how many more times a failed call is attempted. The first
+	// retry waits Backoff, each later one twice as long as the one before.
+	Retries int
+	Backoff time.Duration
+
+	// Threshold is how many calls in a row must fail, retries exhausted,
+	// to open the breaker; 0 never opens it. An open breaker fails calls
+	// at once for Cooldown, then lets one call through as a probe: its
+	// success closes the breaker, its failure opens it again.
+	Threshold int
+	Cooldown  time.Duration
+}
+
+// BreakerStatus is a snapshot of a ResilientEmbedder.
+type BreakerStatus struct {
+	State               string     `json:"state"` // closed | open | half_open
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Calls               uint64     `json:"calls"`
+	Failures            uint64     `json:"failures"` // calls that failed after their retries
+	Retries             uint64     `json:"retries"`
+	Opens               uint64     `json:"opens"`    // times the breaker opened
+	Rejected            uint64     `json:"rejected"` // calls failed fast while open
+	SearchFallbacks     uint64     `json:"searchFallbacks"`
+	WriteFallbacks      uint64     `json:"writeFallbacks"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"` // last time the breaker opened
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+// ResilientEmbedder retries failed embedding calls and stops calling an
+// embedder that keeps failing. It works on any Embedder, so local models
+// and remote providers get the same protection.
+type ResilientEmbedder struct {
+	Embedder
+	policy ResiliencePolicy
+
+	mu       sync.Mutex
+	status   BreakerStatus // OpenedAt is filled in by Status
+	openedAt time.Time
+	probing  bool // a half-open probe is in flight
+}
+
+// NewResilientEmbedder wraps e with policy.
+func NewResilientEmbedder(e Embedder, policy ResiliencePolicy) *ResilientEmbedder {
+	return &ResilientEmbedder{Embedder: e, policy: policy, status: BreakerStatus{State: BreakerClosed}}
+}
+
+// EmbedTextContext embeds text, retrying failures and failing fast with
+// ErrCircuitOpen while the breaker is open. Calls cancelled by ctx do not
+// count against the embedder.
+func (r *ResilientEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	probe, err := r.admit()
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := 1 + max(0, r.policy.Retries)
+	if probe {
+		attempts = 1
+	}
+	var vec []float32
+	backoff := r.policy.Backoff
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if !sleepContext(ctx, backoff) {
+				break
+			}
+			backoff *= 2
+			r.mu.Lock()
+			r.status.Retries++
+			r.mu.Unlock()
+		}
+		vec, err = r.Embedder.EmbedTextContext(ctx, text)
+		if err == nil || ctx.Err() != nil || errors.Is(err, ErrVectorUnavailable) {
+			break
+		}
+	}
+
+	r.record(probe, err, ctx.Err() != nil)
+	return vec, err
+}
+
+// admit decides whether a call may reach the embedder; probe is true for
+// the single call let through by a half-open breaker.
+func (r *ResilientEmbedder) admit() (probe bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Calls++
+
+	switch r.status.State {
+	case BreakerOpen:
+		if time.Since(r.openedAt) >= r.policy.Cooldown && !r.probing {
+			r.status.State = BreakerHalfOpen
+			r.probing = true
+			return true, nil
+		}
+	case BreakerHalfOpen:
+		if !r.probing {
+			r.probing = true
+			return true, nil
+		}
+	default:
+		return false, nil
+	}
+	r.status.Rejected++
+	return false, ErrCircuitOpen
+}
+
+func (r *ResilientEmbedder) record(probe bool, err error, cancelled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if probe {
+		r.probing = false
+	}
+
+	switch {
+	case err == nil:
+		r.status.ConsecutiveFailures = 0
+		r.status.State = BreakerClosed
+	case cancelled:
+		// The caller gave up; that says nothing about the embedder. A
+		// cancelled probe leaves the breaker half-open for the next call.
+	default:
+		r.status.Failures++
+		r.status.ConsecutiveFailures++
+		r.status.LastError = err.Error()
+		threshold := r.policy.Threshold
+		if probe || (threshold > 0 && r.status.ConsecutiveFailures >= threshold && r.status.State == BreakerClosed) {
+			r.status.State = BreakerOpen
+			r.openedAt = time.Now()
+			r.status.Opens++
+		}
+	}
+}
+
+// RecordFallback counts a request that carried on without an embedding.
+func (r *ResilientEmbedder) RecordFallback(kind string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch kind {
+	case FallbackSearch:
+		r.status.SearchFallbacks++
+	case FallbackWrite:
+		r.status.WriteFallbacks++
+	}
+}
+
+// Status returns a snapshot of the breaker and its counters. An open
+// breaker whose cooldown has passed still reports open until the next
+// call probes the embedder.
+func (r *ResilientEmbedder) Status() BreakerStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status
+	if !r.openedAt.IsZero() {
+		openedAt := r.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// sleepContext waits d and reports whether ctx is still live.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,144 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("CUDA error: out of memory")
+
+// scriptedEmbedder fails or succeeds call by call following script; calls
+// past the end of the script succeed.
+type scriptedEmbedder struct {
+	mu     sync.Mutex
+	script []bool // true fails the call
+	calls  int
+}
+
+func (s *scriptedEmbedder) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	call := s.calls
+	s.calls++
+	if call < len(s.script) && s.script[call] {
+		return nil, errTransient
+	}
+	return []float32{1, 0, 0}, nil
+}
+
+func (s *scriptedEmbedder) EmbedDim() int { return 3 }
+func (s *scriptedEmbedder) Close() error  { return nil }
+
+func (s *scriptedEmbedder) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// failing returns a script of n failures.
+func failing(n int) []bool {
+	script := make([]bool, n)
+	for i := range script {
+		script[i] = true
+	}
+	return script
+}
+
+func TestResilientEmbedder_RetriesTransientFailures(t *testing.T) {
+	fake := &scriptedEmbedder{script: failing(2)}
+	r := NewResilientEmbedder(fake, ResiliencePolicy{Retries: 2, Backoff: time.Millisecond, Threshold: 3, Cooldown: time.Minute})
+
+	if _, err := r.EmbedTextContext(context.Background(), "hello"); err != nil {
+		t.Fatalf("two failures should be retried away, got %v", err)
+	}
+	status := r.Status()
+	if fake.callCount() != 3 || status.Retries != 2 || status.Failures != 0 || status.State != BreakerClosed {
+		t.Errorf("unexpected status after retries: calls=%d %+v", fake.callCount(), status)
+	}
+}
+
+func TestResilientEmbedder_OpensAfterConsecutiveFailures(t *testing.T) {
+	// Each call is tried twice, so six failures fail three calls.
+	fake := &scriptedEmbedder{script: failing(6)}
+	r := NewResilientEmbedder(fake, ResiliencePolicy{Retries: 1, Threshold: 3, Cooldown: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.EmbedTextContext(context.Background(), "hello"); !errors.Is(err, errTransient) {
+			t.Fatalf("call %d: expected the backend error, got %v", i, err)
+		}
+	}
+	if status := r.Status(); status.State != BreakerOpen || status.Opens != 1 || status.Failures != 3 || status.OpenedAt == nil {
+		t.Fatalf("expected an open breaker after three failed calls: %+v", status)
+	}
+
+	_, err := r.EmbedTextContext(context.Background(), "hello")
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrVectorUnavailable) {
+		t.Errorf("an open breaker should fail fast, got %v", err)
+	}
+	if fake.callCount() != 6 || r.Status().Rejected != 1 {
+		t.Errorf("an open breaker should not call the embedder: calls=%d %+v", fake.callCount(), r.Status())
+	}
+}
+
+func TestResilientEmbedder_HalfOpenProbeRecovers(t *testing.T) {
+	// Two failed calls open the breaker; the first probe fails too.
+	fake := &scriptedEmbedder{script: failing(3)}
+	r := NewResilientEmbedder(fake, ResiliencePolicy{Threshold: 2, Cooldown: 20 * time.Millisecond})
+	for i := 0; i < 2; i++ {
+		r.EmbedTextContext(context.Background(), "hello")
+	}
+	if r.Status().State != BreakerOpen {
+		t.Fatalf("expected an open breaker: %+v", r.Status())
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if _, err := r.EmbedTextContext(context.Background(), "probe"); !errors.Is(err, errTransient) {
+		t.Fatalf("the probe should reach the embedder and fail, got %v", err)
+	}
+	if status := r.Status(); status.State != BreakerOpen || status.Opens != 2 {
+		t.Fatalf("a failed probe should reopen the breaker: %+v", status)
+	}
+	if _, err := r.EmbedTextContext(context.Background(), "hello"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("the reopened breaker should wait out a new cooldown, got %v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if _, err := r.EmbedTextContext(context.Background(), "probe"); err != nil {
+		t.Fatalf("the second probe should succeed, got %v", err)
+	}
+	if status := r.Status(); status.State != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("a successful probe should close the breaker: %+v", status)
+	}
+}
+
+func TestResilientEmbedder_CancelledCallsDoNotCount(t *testing.T) {
+	fake := &scriptedEmbedder{script: failing(10)}
+	r := NewResilientEmbedder(fake, ResiliencePolicy{Retries: 3, Backoff: time.Hour, Threshold: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := r.EmbedTextContext(ctx, "hello"); err == nil {
+		t.Fatal("expected the call to fail")
+	}
+	if status := r.Status(); status.State != BreakerClosed || status.Failures != 0 {
+		t.Errorf("a call cancelled during backoff should not open the breaker: %+v", status)
+	}
+}
+
+func TestRecordFallback(t *testing.T) {
+	r := NewResilientEmbedder(&scriptedEmbedder{}, ResiliencePolicy{})
+	RecordFallback(r, FallbackSearch)
+	RecordFallback(r, FallbackWrite)
+	RecordFallback(r, FallbackWrite)
+	RecordFallback(&scriptedEmbedder{}, FallbackSearch) // no counters, no panic
+
+	if status := r.Status(); status.SearchFallbacks != 1 || status.WriteFallbacks != 2 {
+		t.Errorf("unexpected fallback counts: %+v", status)
+	}
+}
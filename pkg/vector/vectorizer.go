@@ -4,15 +4,25 @@
 package vector
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"strings"
 	"sync"
 
+	"github.com/qubicDB/qubicdb/pkg/tracing"
 	"github.com/qubicDB/qubicdb/pkg/vector/simd"
 	"github.com/sentencizer/sentencizer"
 )
 
+// Embedder converts text into embedding vectors. *Vectorizer is the
+// llama.cpp implementation.
+type Embedder interface {
+	EmbedTextContext(ctx context.Context, text string) ([]float32, error)
+	EmbedDim() int
+	Close() error
+}
+
 // Vectorizer represents a loaded GGUF embedding model.
 type Vectorizer struct {
 	handle  uintptr
@@ -51,6 +61,15 @@ func NewVectorizer(modelPath string, gpuLayers int, embedContextSize uint32) (*V
 	return v, nil
 }
 
+// EmbedTextContext is EmbedText recorded as a "vector.embed" span under the
+// span in ctx.
+func (v *Vectorizer) EmbedTextContext(ctx context.Context, text string) ([]float32, error) {
+	_, span := tracing.Start(ctx, "vector.embed")
+	emb, err := v.EmbedText(text)
+	tracing.End(span, err)
+	return emb, err
+}
+
 // EmbedText cleans the input text and converts it into a float32 embedding
 // vector. If the text exceeds the model's token budget it is split into
 // sentence-boundary-aware chunks and the embeddings are averaged.